@@ -0,0 +1,130 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Requests given to an app container that sets none: enough for the
+// scheduler to spread pods across a Kind cluster's nodes.
+var (
+	defaultCPURequest    = resource.MustParse("100m")
+	defaultMemoryRequest = resource.MustParse("128Mi")
+)
+
+// localRegistryHosts are the registries kindling pushes to: the host
+// side of the local registry and the in-cluster one CI builds push to.
+var localRegistryHosts = []string{"localhost", "127.0.0.1", "registry", "kind-registry"}
+
+// ApplyDefaults fills in what a short manifest leaves out, so the object
+// in the cluster says what the operator will do. The defaulting webhook
+// runs it on every apply; signature verification and kindling validate's
+// policies run it too, to see the object the cluster would hold.
+func (cr *DevStagingEnvironment) ApplyDefaults() {
+	if cr.Name != "" {
+		if cr.Labels == nil {
+			cr.Labels = map[string]string{}
+		}
+		setDefault(cr.Labels, "app.kubernetes.io/name", cr.Name)
+		setDefault(cr.Labels, "app.kubernetes.io/managed-by", "kindling")
+	}
+
+	d := &cr.Spec.Deployment
+	if d.Replicas == nil {
+		one := int32(1)
+		d.Replicas = &one
+	}
+	if d.ImagePullPolicy == "" && d.Image != "" {
+		d.ImagePullPolicy = corev1.PullIfNotPresent
+		if inLocalRegistry(d.Image) {
+			d.ImagePullPolicy = corev1.PullAlways
+		}
+	}
+
+	// Spell the probe out as the operator builds it from the health
+	// check path.
+	if hc := d.HealthCheck; hc != nil {
+		if hc.Type == "" {
+			hc.Type = "http"
+		}
+		if hc.Path == "" && hc.Type == "http" {
+			hc.Path = "/healthz"
+		}
+		if hc.Port == nil {
+			port := d.Port
+			hc.Port = &port
+		}
+		if hc.InitialDelaySeconds == nil {
+			delay := int32(5)
+			hc.InitialDelaySeconds = &delay
+		}
+		if hc.PeriodSeconds == nil {
+			period := int32(10)
+			hc.PeriodSeconds = &period
+		}
+	}
+
+	if c := cr.Spec.Canary; c != nil && c.Replicas == nil {
+		one := int32(1)
+		c.Replicas = &one
+	}
+
+	if d.Resources == nil {
+		d.Resources = &ResourceRequirements{}
+	}
+	d.Resources.CPURequest = defaultRequest(d.Resources.CPURequest, d.Resources.CPULimit, defaultCPURequest)
+	d.Resources.MemoryRequest = defaultRequest(d.Resources.MemoryRequest, d.Resources.MemoryLimit, defaultMemoryRequest)
+}
+
+func setDefault(m map[string]string, key, value string) {
+	if _, ok := m[key]; !ok {
+		m[key] = value
+	}
+}
+
+// defaultRequest returns request, or the default when it is unset — but
+// never more than limit, which the API server would reject.
+func defaultRequest(request, limit *resource.Quantity, def resource.Quantity) *resource.Quantity {
+	if request != nil {
+		return request
+	}
+	q := def.DeepCopy()
+	if limit != nil && limit.Cmp(q) < 0 {
+		q = limit.DeepCopy()
+	}
+	return &q
+}
+
+// inLocalRegistry reports whether image is pushed to a kindling registry,
+// where a tag is rebuilt in place and must be pulled again.
+func inLocalRegistry(image string) bool {
+	host, _, found := strings.Cut(image, "/")
+	if !found || !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return false
+	}
+	host, _, _ = strings.Cut(host, ":")
+	for _, h := range localRegistryHosts {
+		if host == h {
+			return true
+		}
+	}
+	return false
+}
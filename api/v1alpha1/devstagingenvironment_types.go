@@ -36,6 +36,14 @@ type DeploymentSpec struct {
 	//+kubebuilder:validation:MinLength=1
 	Image string `json:"image"`
 
+	// ImagePullPolicy applies to the app container and to the init
+	// containers and jobs that run its image. The defaulting webhook picks
+	// Always for an image in a local registry, whose tags (e.g. ":dev") are
+	// rebuilt in place, and IfNotPresent for any other.
+	//+kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	//+optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
 	// Port is the container port the application listens on.
 	//+kubebuilder:validation:Minimum=1
 	//+kubebuilder:validation:Maximum=65535
@@ -53,6 +61,12 @@ type DeploymentSpec struct {
 	//+optional
 	Env []corev1.EnvVar `json:"env,omitempty"`
 
+	// EnvFrom populates the container's environment from whole Secrets or
+	// ConfigMaps, e.g. the Secret that "kindling secrets sync" creates from
+	// a .env file. Variables in Env take precedence.
+	//+optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
 	// Resources defines CPU and memory requests/limits for the container.
 	//+optional
 	Resources *ResourceRequirements `json:"resources,omitempty"`
@@ -60,6 +74,127 @@ type DeploymentSpec struct {
 	// HealthCheck configures liveness and readiness probes.
 	//+optional
 	HealthCheck *HealthCheckSpec `json:"healthCheck,omitempty"`
+
+	// NodeSelector pins the app's pods to nodes carrying all of these labels
+	// (e.g. {"kindling.dev/worker": "1"} on a multi-node cluster).
+	//+optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Affinity sets node and pod (anti-)affinity rules for the app's pods.
+	//+optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Schedule runs the app on a cron schedule (e.g. "*/5 * * * *") as a
+	// CronJob instead of a Deployment: a periodic worker rather than a
+	// service. A scheduled app gets no Service or Ingress, runs one pod at
+	// a time, and ignores replicas and healthCheck.
+	//+optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// InitContainers run to completion, in order, before the app container
+	// starts — after the operator's own waits for each dependency.
+	//+optional
+	InitContainers []InitContainerSpec `json:"initContainers,omitempty"`
+
+	// Sidecars run next to the app container for the life of every app
+	// pod, e.g. a log shipper or a metrics agent. They start after the
+	// init containers and before the app container, and stop after it.
+	//+optional
+	//+listType=map
+	//+listMapKey=name
+	Sidecars []SidecarSpec `json:"sidecars,omitempty"`
+
+	// Volumes are mounted into the app container so files written there
+	// survive pod restarts and redeploys.
+	//+optional
+	//+listType=map
+	//+listMapKey=name
+	Volumes []VolumeSpec `json:"volumes,omitempty"`
+}
+
+//+kubebuilder:validation:XValidation:rule="!(has(self.hostPath) && has(self.size))",message="size applies to claims only, not to a hostPath volume"
+
+// VolumeSpec is a directory in the app container that outlives its pods.
+// By default it is backed by a PersistentVolumeClaim named
+// <environment>-<name> on the cluster's default StorageClass (Kind's
+// local-path provisioner), which is deleted along with the environment.
+// With HostPath it is a directory on the Kind node instead; a directory
+// mounted into the nodes with "kindling init --mount" makes it a
+// directory on the host, which outlives the cluster too.
+type VolumeSpec struct {
+	// Name of the volume, unique within the app.
+	//+kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	//+kubebuilder:validation:MaxLength=40
+	Name string `json:"name"`
+
+	// MountPath is where the volume appears in the app container.
+	//+kubebuilder:validation:Pattern=`^/`
+	MountPath string `json:"mountPath"`
+
+	// Size of the claim (default "1Gi"). A changed size applies when the
+	// claim is next created, not to the existing one.
+	//+optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// HostPath is an absolute path on the Kind node to mount instead of a
+	// claim, e.g. "/kindling/uploads" with kindling init --mount
+	// ./data:/kindling. It is created if it doesn't exist.
+	//+kubebuilder:validation:Pattern=`^/`
+	//+optional
+	HostPath string `json:"hostPath,omitempty"`
+}
+
+// InitContainerSpec is a container that runs before the app container in
+// every app pod, e.g. to render config or wait for an external service.
+// It receives the app's environment, including the dependency connection
+// env vars.
+type InitContainerSpec struct {
+	// Name of the init container, unique within the pod.
+	//+kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	//+kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Image defaults to the app's image.
+	//+optional
+	Image string `json:"image,omitempty"`
+
+	// Command overrides the image's entrypoint.
+	//+optional
+	Command []string `json:"command,omitempty"`
+
+	// Args are arguments passed to the entrypoint.
+	//+optional
+	Args []string `json:"args,omitempty"`
+
+	// Env is added to the app's environment for this container only.
+	//+optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// SidecarSpec is a container that runs next to the app container in
+// every app pod. Unlike an init container it doesn't get the app's
+// environment, only its own.
+type SidecarSpec struct {
+	// Name of the sidecar, unique within the pod.
+	//+kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	//+kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Image to run.
+	//+kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// Command overrides the image's entrypoint.
+	//+optional
+	Command []string `json:"command,omitempty"`
+
+	// Args are arguments passed to the entrypoint.
+	//+optional
+	Args []string `json:"args,omitempty"`
+
+	// Env sets environment variables in the sidecar.
+	//+optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
 }
 
 // ResourceRequirements defines compute resource requests and limits.
@@ -83,7 +218,15 @@ type ResourceRequirements struct {
 
 // HealthCheckSpec configures liveness and readiness probes.
 type HealthCheckSpec struct {
+	// Type selects the probe: "http" sends GET Path, "tcp" only checks that
+	// the port accepts connections (for apps without a health endpoint).
+	//+kubebuilder:validation:Enum=http;tcp
+	//+kubebuilder:default="http"
+	//+optional
+	Type string `json:"type,omitempty"`
+
 	// Path is the HTTP path for the health check endpoint (e.g. "/healthz").
+	// Ignored for tcp probes.
 	//+kubebuilder:default="/healthz"
 	Path string `json:"path,omitempty"`
 
@@ -115,6 +258,13 @@ type ServiceSpec struct {
 	//+kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
 	//+kubebuilder:default="ClusterIP"
 	Type string `json:"type,omitempty"`
+	// NodePort fixes the node port of a NodePort or LoadBalancer Service,
+	// e.g. one a Kind extraPortMapping forwards to. Left empty, Kubernetes
+	// picks one. Two Services can't share a node port.
+	//+kubebuilder:validation:Minimum=30000
+	//+kubebuilder:validation:Maximum=32767
+	//+optional
+	NodePort *int32 `json:"nodePort,omitempty"`
 }
 
 // IngressSpec defines the desired state of the Ingress.
@@ -136,6 +286,14 @@ type IngressSpec struct {
 	//+kubebuilder:default="Prefix"
 	PathType string `json:"pathType,omitempty"`
 
+	// Protocol is what the application speaks behind the Ingress. grpc and
+	// websocket add the annotations the ingress controller needs to route
+	// it: an HTTP/2 upstream for gRPC, long-lived upgraded connections for
+	// WebSockets. Annotations set explicitly take precedence.
+	//+kubebuilder:validation:Enum=http;grpc;websocket
+	//+optional
+	Protocol string `json:"protocol,omitempty"`
+
 	// IngressClassName is the name of the IngressClass to use (e.g. "nginx").
 	//+optional
 	IngressClassName *string `json:"ingressClassName,omitempty"`
@@ -144,6 +302,13 @@ type IngressSpec struct {
 	//+optional
 	TLS *IngressTLSSpec `json:"tls,omitempty"`
 
+	// Tunnel routes the Ingress through the running kindling expose
+	// tunnel: while one is up, its hostname replaces Host and TLS is left
+	// to the tunnel. The operator follows the tunnel as it restarts and
+	// falls back to Host when it stops.
+	//+optional
+	Tunnel bool `json:"tunnel,omitempty"`
+
 	// Annotations are additional annotations to set on the Ingress resource.
 	//+optional
 	Annotations map[string]string `json:"annotations,omitempty"`
@@ -159,6 +324,46 @@ type IngressTLSSpec struct {
 	Hosts []string `json:"hosts,omitempty"`
 }
 
+//+kubebuilder:validation:XValidation:rule="!has(self.headerValue) || has(self.header)",message="headerValue needs a header to match"
+
+// CanarySpec is a second build of the app, run as the Deployment and
+// Service <name>-canary with the app's configuration and its own image.
+// Routing its share of requests takes ingress-nginx; behind another
+// ingress controller the canary runs but only its Service reaches it.
+type CanarySpec struct {
+	// Image is the build under test (e.g. "localhost:5001/orders:abc123").
+	//+kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// Replicas is the number of canary pods.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default=1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Weight is the percentage of requests the Ingress sends to the canary
+	// rather than the app. 0 sends it only the requests Header picks.
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:validation:Maximum=100
+	//+optional
+	Weight int32 `json:"weight,omitempty"`
+
+	// Header names a request header that picks the build whatever the
+	// weight: requests whose value is HeaderValue go to the canary. Without
+	// HeaderValue, "always" goes to the canary and "never" to the app.
+	//+optional
+	Header string `json:"header,omitempty"`
+
+	// HeaderValue is the value of Header that selects the canary.
+	//+optional
+	HeaderValue string `json:"headerValue,omitempty"`
+
+	// Env is added to the canary's environment only, e.g. a feature flag
+	// the A/B test turns on. It overrides the app's variables of the same
+	// name.
+	//+optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
 // DependencyType represents a well-known service dependency.
 // +kubebuilder:validation:Enum=postgres;redis;mysql;mongodb;rabbitmq;minio;elasticsearch;kafka;nats;memcached;cassandra;consul;vault;influxdb;jaeger
 type DependencyType string
@@ -206,6 +411,12 @@ type DependencySpec struct {
 	//+optional
 	Env []corev1.EnvVar `json:"env,omitempty"`
 
+	// EnvFrom populates the dependency container's environment from whole
+	// Secrets or ConfigMaps. Variables in Env and the operator's defaults
+	// take precedence.
+	//+optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
 	// EnvVarName overrides the name of the connection-string env var
 	// injected into the app container (e.g. "MY_DB_URL" instead of "DATABASE_URL").
 	//+optional
@@ -218,6 +429,93 @@ type DependencySpec struct {
 	// Resources defines CPU/memory requests and limits for the dependency container.
 	//+optional
 	Resources *ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector pins the dependency's pod to nodes carrying all of these
+	// labels, e.g. to give a database or Kafka a dedicated worker.
+	//+optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Affinity sets node and pod (anti-)affinity rules for the dependency's pod.
+	//+optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Seed loads initial data into the dependency once it is available.
+	//+optional
+	Seed *SeedSpec `json:"seed,omitempty"`
+}
+
+//+kubebuilder:validation:XValidation:rule="has(self.configMap) || has(self.command)",message="seed needs a configMap or a command"
+
+// SeedSpec describes a one-off Job that loads data into a dependency after
+// it becomes available. The Job runs once; it runs again when the seed spec
+// changes or when it is deleted (kindling reseed).
+type SeedSpec struct {
+	// ConfigMap names a ConfigMap of seed files, mounted at /seed. Without a
+	// Command they are applied in key order with the dependency's own
+	// client: *.sql for postgres and mysql, *.js and *.json (one collection
+	// per file) for mongodb, and *.redis or *.txt files of commands for redis.
+	//+optional
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// Image runs the seed container from a different image. Defaults to the
+	// dependency's image, which ships its client tools.
+	//+optional
+	Image string `json:"image,omitempty"`
+
+	// Command replaces the built-in loader. It runs with the connection env
+	// vars the app receives (e.g. DATABASE_URL) and the dependency's
+	// credentials in its environment.
+	//+optional
+	Command []string `json:"command,omitempty"`
+
+	// Args are passed to Command.
+	//+optional
+	Args []string `json:"args,omitempty"`
+
+	// BackoffLimit is how many times a failed seed is retried (default 3).
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+}
+
+// JobSpec describes a component that runs to completion instead of
+// serving traffic, such as a database migration or a one-off seeder. It
+// runs as a Job once every dependency is available, with the app's
+// environment. A finished Job is kept as the record that it ran; it runs
+// again when its spec changes — including the app image it defaults to —
+// or when it is deleted.
+type JobSpec struct {
+	// Name of the job; the Job is named <environment>-<name>.
+	//+kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	//+kubebuilder:validation:MaxLength=40
+	Name string `json:"name"`
+
+	// Image defaults to the app's image, so a migration runs from the same
+	// build as the code it migrates for.
+	//+optional
+	Image string `json:"image,omitempty"`
+
+	// Command overrides the image's entrypoint, e.g. ["npm", "run", "migrate"].
+	//+optional
+	Command []string `json:"command,omitempty"`
+
+	// Args are arguments passed to the entrypoint.
+	//+optional
+	Args []string `json:"args,omitempty"`
+
+	// Env is added to the app's environment for this job only.
+	//+optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// EnvFrom populates the job's environment from whole Secrets or
+	// ConfigMaps. Defaults to the app's envFrom.
+	//+optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
+	// BackoffLimit is how many times a failed job is retried (default 3).
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
 }
 
 // DevStagingEnvironmentSpec defines the desired state of DevStagingEnvironment
@@ -232,11 +530,121 @@ type DevStagingEnvironmentSpec struct {
 	//+optional
 	Ingress *IngressSpec `json:"ingress,omitempty"`
 
+	// Canary runs a second build of the app beside the first and sends it
+	// a share of the Ingress's requests, for A/B testing a change against
+	// the current build.
+	//+optional
+	Canary *CanarySpec `json:"canary,omitempty"`
+
 	// Dependencies declares supporting services (databases, caches, queues)
 	// that the operator will provision alongside the application.
 	// Connection env vars are automatically injected into the app container.
 	//+optional
 	Dependencies []DependencySpec `json:"dependencies,omitempty"`
+
+	// Jobs declares run-to-completion components, such as migrations, that
+	// the operator runs once the dependencies are available. The
+	// environment is Ready only after every job has succeeded.
+	//+optional
+	//+listType=map
+	//+listMapKey=name
+	Jobs []JobSpec `json:"jobs,omitempty"`
+
+	// DependsOn names the DevStagingEnvironments in the same namespace that
+	// this one calls. The operator creates the app's Deployment (or
+	// CronJob) only once each of them has all its replicas available, so
+	// components start in order; later updates aren't held back.
+	//+optional
+	//+listType=set
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// NetworkPolicies set to strict has the operator write NetworkPolicies
+	// that admit only the declared edges: the app accepts connections from
+	// the DevStagingEnvironments that list it in dependsOn, from the
+	// namespace of the controller serving its Ingress class (ingress-nginx,
+	// traefik, or projectcontour), and, with autoSleep, from the activator;
+	// no other namespace gets in. Each dependency accepts connections only
+	// from this environment's app, jobs, and seeds. They are enforced only
+	// by a CNI with NetworkPolicy support, such as Calico. Defaults to open.
+	//+kubebuilder:validation:Enum=open;strict
+	//+optional
+	NetworkPolicies string `json:"networkPolicies,omitempty"`
+
+	// Observability configures tracing for the app.
+	//+optional
+	Observability *ObservabilitySpec `json:"observability,omitempty"`
+
+	// Paused scales the app, its canary, and its dependencies to zero pods
+	// and suspends a scheduled app, keeping everything else — claims,
+	// Secrets, Services, Ingress — so that unpausing brings the environment
+	// back with its data.
+	//+optional
+	Paused bool `json:"paused,omitempty"`
+
+	// AutoSleep scales the environment to zero, as Paused does, once its
+	// Ingress has had no requests for a while, and routes the Ingress
+	// through the operator's activator, which wakes it on the next request.
+	// Requires an HTTP or WebSocket Ingress and no canary.
+	//+optional
+	AutoSleep *AutoSleepSpec `json:"autoSleep,omitempty"`
+}
+
+// AutoSleepSpec configures when an idle environment goes to sleep.
+type AutoSleepSpec struct {
+	// IdleMinutes is how long the Ingress must go without a request before
+	// the environment sleeps.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default=30
+	IdleMinutes int32 `json:"idleMinutes,omitempty"`
+}
+
+// Values of spec.networkPolicies.
+const (
+	NetworkPoliciesOpen   = "open"
+	NetworkPoliciesStrict = "strict"
+)
+
+// ObservabilitySpec configures the telemetry the app sends.
+type ObservabilitySpec struct {
+	// Tracing runs an OpenTelemetry collector, kindling-otel-collector, in
+	// the namespace (one for every DevStagingEnvironment that enables
+	// tracing) and points the app's OTEL_* exporter variables at it. The
+	// collector keeps recent traces in memory and serves the Jaeger UI.
+	//+optional
+	Tracing bool `json:"tracing,omitempty"`
+
+	// Instrumentation asks the OpenTelemetry Operator, when the cluster
+	// runs one, to inject its auto-instrumentation agent for this language
+	// into the app's pods. Requires tracing.
+	//+kubebuilder:validation:Enum=java;nodejs;python;dotnet
+	//+optional
+	Instrumentation string `json:"instrumentation,omitempty"`
+}
+
+// Job phases reported in JobStatus.
+const (
+	JobPending   = "Pending"
+	JobRunning   = "Running"
+	JobSucceeded = "Succeeded"
+	JobFailed    = "Failed"
+)
+
+// JobStatus is the outcome of one of spec.jobs.
+type JobStatus struct {
+	// Name is the job's name in spec.jobs.
+	Name string `json:"name"`
+
+	// Phase is Pending (waiting for dependencies), Running, Succeeded, or
+	// Failed (out of retries).
+	Phase string `json:"phase"`
+
+	// CompletionTime is when the job succeeded.
+	//+optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message says why the job failed.
+	//+optional
+	Message string `json:"message,omitempty"`
 }
 
 // DevStagingEnvironmentStatus defines the observed state of DevStagingEnvironment
@@ -260,6 +668,15 @@ type DevStagingEnvironmentStatus struct {
 	//+optional
 	URL string `json:"url,omitempty"`
 
+	// Jobs reports the outcome of each of spec.jobs.
+	//+optional
+	Jobs []JobStatus `json:"jobs,omitempty"`
+
+	// AsleepSince is when spec.autoSleep last scaled the environment to
+	// zero; it is cleared when a request wakes it.
+	//+optional
+	AsleepSince *metav1.Time `json:"asleepSince,omitempty"`
+
 	// Conditions represent the latest available observations of the resource's state.
 	//+optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
@@ -267,6 +684,7 @@ type DevStagingEnvironmentStatus struct {
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:storageversion
 //+kubebuilder:resource:shortName=dse
 //+kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.deployment.image`
 //+kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=`.spec.deployment.replicas`
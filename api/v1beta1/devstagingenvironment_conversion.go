@@ -0,0 +1,320 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+// extraResourcesAnnotation carries the resources v1alpha1 has no field for
+// (anything but cpu and memory, e.g. ephemeral-storage) while an object is
+// stored as v1alpha1, so that reading it back as v1beta1 returns them.
+const extraResourcesAnnotation = "apps.example.com/v1beta1-extra-resources"
+
+// extraResources are the resources of one object that v1alpha1 cannot
+// hold, keyed "deployment" or by dependency type.
+type extraResources map[string]ResourceRequirements
+
+// ConvertTo converts this DevStagingEnvironment to the hub version (v1alpha1).
+func (src *DevStagingEnvironment) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.DevStagingEnvironment)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Status = statusToHub(src.Status.DeepCopy())
+
+	extras := extraResources{}
+	spec := src.Spec.DeepCopy()
+	dst.Spec = v1alpha1.DevStagingEnvironmentSpec{
+		Deployment: v1alpha1.DeploymentSpec{
+			Replicas:        spec.Deployment.Replicas,
+			Image:           spec.Deployment.Image,
+			ImagePullPolicy: spec.Deployment.ImagePullPolicy,
+			Port:            spec.Deployment.Port,
+			Command:         spec.Deployment.Command,
+			Args:            spec.Deployment.Args,
+			Env:             spec.Deployment.Env,
+			EnvFrom:         spec.Deployment.EnvFrom,
+			Resources:       resourcesToHub(spec.Deployment.Resources, "deployment", extras),
+			HealthCheck:     (*v1alpha1.HealthCheckSpec)(spec.Deployment.HealthCheck),
+			NodeSelector:    spec.Deployment.NodeSelector,
+			Affinity:        spec.Deployment.Affinity,
+			Schedule:        spec.Deployment.Schedule,
+		},
+		Service:         v1alpha1.ServiceSpec(spec.Service),
+		Ingress:         ingressToHub(spec.Ingress),
+		Canary:          (*v1alpha1.CanarySpec)(spec.Canary),
+		DependsOn:       spec.DependsOn,
+		NetworkPolicies: spec.NetworkPolicies,
+		Observability:   (*v1alpha1.ObservabilitySpec)(spec.Observability),
+		Paused:          spec.Paused,
+		AutoSleep:       (*v1alpha1.AutoSleepSpec)(spec.AutoSleep),
+	}
+	for _, ic := range spec.Deployment.InitContainers {
+		dst.Spec.Deployment.InitContainers = append(dst.Spec.Deployment.InitContainers, v1alpha1.InitContainerSpec(ic))
+	}
+	for _, sc := range spec.Deployment.Sidecars {
+		dst.Spec.Deployment.Sidecars = append(dst.Spec.Deployment.Sidecars, v1alpha1.SidecarSpec(sc))
+	}
+	for _, v := range spec.Deployment.Volumes {
+		dst.Spec.Deployment.Volumes = append(dst.Spec.Deployment.Volumes, v1alpha1.VolumeSpec(v))
+	}
+	for _, job := range spec.Jobs {
+		dst.Spec.Jobs = append(dst.Spec.Jobs, v1alpha1.JobSpec(job))
+	}
+	for _, dep := range spec.Dependencies {
+		dst.Spec.Dependencies = append(dst.Spec.Dependencies, v1alpha1.DependencySpec{
+			Type:         v1alpha1.DependencyType(dep.Type),
+			Version:      dep.Version,
+			Image:        dep.Image,
+			Port:         dep.Port,
+			Env:          dep.Env,
+			EnvFrom:      dep.EnvFrom,
+			EnvVarName:   dep.EnvVarName,
+			StorageSize:  dep.StorageSize,
+			Resources:    resourcesToHub(dep.Resources, string(dep.Type), extras),
+			NodeSelector: dep.NodeSelector,
+			Affinity:     dep.Affinity,
+			Seed:         (*v1alpha1.SeedSpec)(dep.Seed),
+		})
+	}
+
+	delete(dst.Annotations, extraResourcesAnnotation)
+	if len(extras) > 0 {
+		data, err := json.Marshal(extras)
+		if err != nil {
+			return err
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[extraResourcesAnnotation] = string(data)
+	}
+	return nil
+}
+
+// ConvertFrom converts from the hub version (v1alpha1) to this version.
+func (dst *DevStagingEnvironment) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.DevStagingEnvironment)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Status = statusFromHub(src.Status.DeepCopy())
+
+	extras := extraResources{}
+	if data, ok := dst.Annotations[extraResourcesAnnotation]; ok {
+		// A malformed annotation only loses the extra resources.
+		_ = json.Unmarshal([]byte(data), &extras)
+		delete(dst.Annotations, extraResourcesAnnotation)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+	}
+
+	spec := src.Spec.DeepCopy()
+	dst.Spec = DevStagingEnvironmentSpec{
+		Deployment: DeploymentSpec{
+			Replicas:        spec.Deployment.Replicas,
+			Image:           spec.Deployment.Image,
+			ImagePullPolicy: spec.Deployment.ImagePullPolicy,
+			Port:            spec.Deployment.Port,
+			Command:         spec.Deployment.Command,
+			Args:            spec.Deployment.Args,
+			Env:             spec.Deployment.Env,
+			EnvFrom:         spec.Deployment.EnvFrom,
+			Resources:       resourcesFromHub(spec.Deployment.Resources, extras["deployment"]),
+			HealthCheck:     (*HealthCheckSpec)(spec.Deployment.HealthCheck),
+			NodeSelector:    spec.Deployment.NodeSelector,
+			Affinity:        spec.Deployment.Affinity,
+			Schedule:        spec.Deployment.Schedule,
+		},
+		Service:         ServiceSpec(spec.Service),
+		Ingress:         ingressFromHub(spec.Ingress),
+		Canary:          (*CanarySpec)(spec.Canary),
+		DependsOn:       spec.DependsOn,
+		NetworkPolicies: spec.NetworkPolicies,
+		Observability:   (*ObservabilitySpec)(spec.Observability),
+		Paused:          spec.Paused,
+		AutoSleep:       (*AutoSleepSpec)(spec.AutoSleep),
+	}
+	for _, ic := range spec.Deployment.InitContainers {
+		dst.Spec.Deployment.InitContainers = append(dst.Spec.Deployment.InitContainers, InitContainerSpec(ic))
+	}
+	for _, sc := range spec.Deployment.Sidecars {
+		dst.Spec.Deployment.Sidecars = append(dst.Spec.Deployment.Sidecars, SidecarSpec(sc))
+	}
+	for _, v := range spec.Deployment.Volumes {
+		dst.Spec.Deployment.Volumes = append(dst.Spec.Deployment.Volumes, VolumeSpec(v))
+	}
+	for _, job := range spec.Jobs {
+		dst.Spec.Jobs = append(dst.Spec.Jobs, JobSpec(job))
+	}
+	for _, dep := range spec.Dependencies {
+		dst.Spec.Dependencies = append(dst.Spec.Dependencies, DependencySpec{
+			Type:         DependencyType(dep.Type),
+			Version:      dep.Version,
+			Image:        dep.Image,
+			Port:         dep.Port,
+			Env:          dep.Env,
+			EnvFrom:      dep.EnvFrom,
+			EnvVarName:   dep.EnvVarName,
+			StorageSize:  dep.StorageSize,
+			Resources:    resourcesFromHub(dep.Resources, extras[string(dep.Type)]),
+			NodeSelector: dep.NodeSelector,
+			Affinity:     dep.Affinity,
+			Seed:         (*SeedSpec)(dep.Seed),
+		})
+	}
+	return nil
+}
+
+func statusToHub(in *DevStagingEnvironmentStatus) v1alpha1.DevStagingEnvironmentStatus {
+	out := v1alpha1.DevStagingEnvironmentStatus{
+		AvailableReplicas: in.AvailableReplicas,
+		DeploymentReady:   in.DeploymentReady,
+		ServiceReady:      in.ServiceReady,
+		IngressReady:      in.IngressReady,
+		DependenciesReady: in.DependenciesReady,
+		URL:               in.URL,
+		AsleepSince:       in.AsleepSince,
+		Conditions:        in.Conditions,
+	}
+	for _, job := range in.Jobs {
+		out.Jobs = append(out.Jobs, v1alpha1.JobStatus(job))
+	}
+	return out
+}
+
+func statusFromHub(in *v1alpha1.DevStagingEnvironmentStatus) DevStagingEnvironmentStatus {
+	out := DevStagingEnvironmentStatus{
+		AvailableReplicas: in.AvailableReplicas,
+		DeploymentReady:   in.DeploymentReady,
+		ServiceReady:      in.ServiceReady,
+		IngressReady:      in.IngressReady,
+		DependenciesReady: in.DependenciesReady,
+		URL:               in.URL,
+		AsleepSince:       in.AsleepSince,
+		Conditions:        in.Conditions,
+	}
+	for _, job := range in.Jobs {
+		out.Jobs = append(out.Jobs, JobStatus(job))
+	}
+	return out
+}
+
+func ingressToHub(in *IngressSpec) *v1alpha1.IngressSpec {
+	if in == nil {
+		return nil
+	}
+	return &v1alpha1.IngressSpec{
+		Enabled:          in.Enabled,
+		Host:             in.Host,
+		Path:             in.Path,
+		PathType:         in.PathType,
+		Protocol:         in.Protocol,
+		IngressClassName: in.IngressClassName,
+		TLS:              (*v1alpha1.IngressTLSSpec)(in.TLS),
+		Tunnel:           in.Tunnel,
+		Annotations:      in.Annotations,
+	}
+}
+
+func ingressFromHub(in *v1alpha1.IngressSpec) *IngressSpec {
+	if in == nil {
+		return nil
+	}
+	return &IngressSpec{
+		Enabled:          in.Enabled,
+		Host:             in.Host,
+		Path:             in.Path,
+		PathType:         in.PathType,
+		Protocol:         in.Protocol,
+		IngressClassName: in.IngressClassName,
+		TLS:              (*IngressTLSSpec)(in.TLS),
+		Tunnel:           in.Tunnel,
+		Annotations:      in.Annotations,
+	}
+}
+
+// resourcesToHub maps requests and limits onto v1alpha1's cpu and memory
+// fields, recording any other resource in extras under key.
+func resourcesToHub(in *ResourceRequirements, key string, extras extraResources) *v1alpha1.ResourceRequirements {
+	if in == nil {
+		return nil
+	}
+	out := &v1alpha1.ResourceRequirements{
+		CPURequest:    quantity(in.Requests, corev1.ResourceCPU),
+		CPULimit:      quantity(in.Limits, corev1.ResourceCPU),
+		MemoryRequest: quantity(in.Requests, corev1.ResourceMemory),
+		MemoryLimit:   quantity(in.Limits, corev1.ResourceMemory),
+	}
+	extra := ResourceRequirements{Requests: otherResources(in.Requests), Limits: otherResources(in.Limits)}
+	if extra.Requests != nil || extra.Limits != nil {
+		extras[key] = extra
+	}
+	return out
+}
+
+// resourcesFromHub builds requests and limits from v1alpha1's cpu and
+// memory fields plus the extra resources recorded for the object.
+func resourcesFromHub(in *v1alpha1.ResourceRequirements, extra ResourceRequirements) *ResourceRequirements {
+	if in == nil {
+		return nil
+	}
+	out := &ResourceRequirements{Requests: extra.Requests, Limits: extra.Limits}
+	out.Requests = setQuantity(out.Requests, corev1.ResourceCPU, in.CPURequest)
+	out.Requests = setQuantity(out.Requests, corev1.ResourceMemory, in.MemoryRequest)
+	out.Limits = setQuantity(out.Limits, corev1.ResourceCPU, in.CPULimit)
+	out.Limits = setQuantity(out.Limits, corev1.ResourceMemory, in.MemoryLimit)
+	return out
+}
+
+func quantity(list corev1.ResourceList, name corev1.ResourceName) *resource.Quantity {
+	if q, ok := list[name]; ok {
+		return &q
+	}
+	return nil
+}
+
+func setQuantity(list corev1.ResourceList, name corev1.ResourceName, q *resource.Quantity) corev1.ResourceList {
+	if q == nil {
+		return list
+	}
+	if list == nil {
+		list = corev1.ResourceList{}
+	}
+	list[name] = *q
+	return list
+}
+
+// otherResources returns the entries of list other than cpu and memory,
+// or nil when there are none.
+func otherResources(list corev1.ResourceList) corev1.ResourceList {
+	var out corev1.ResourceList
+	for name, q := range list {
+		if name == corev1.ResourceCPU || name == corev1.ResourceMemory {
+			continue
+		}
+		if out == nil {
+			out = corev1.ResourceList{}
+		}
+		out[name] = q
+	}
+	return out
+}
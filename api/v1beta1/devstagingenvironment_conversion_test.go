@@ -0,0 +1,193 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"sigs.k8s.io/randfill"
+
+	"github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+var _ = Describe("DevStagingEnvironment conversion", func() {
+	// newBeta returns a v1beta1 object whose app and dependency ask for
+	// ephemeral-storage, which v1alpha1 has no field for.
+	newBeta := func() *DevStagingEnvironment {
+		return &DevStagingEnvironment{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default", Annotations: map[string]string{"team": "payments"}},
+			Spec: DevStagingEnvironmentSpec{
+				Deployment: DeploymentSpec{
+					Image: "localhost:5001/orders:dev",
+					Port:  8080,
+					Resources: &ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:              resource.MustParse("100m"),
+							corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory:           resource.MustParse("256Mi"),
+							corev1.ResourceEphemeralStorage: resource.MustParse("2Gi"),
+						},
+					},
+				},
+				Service: ServiceSpec{Port: 80},
+				Dependencies: []DependencySpec{{
+					Type: "postgres",
+					Resources: &ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("4Gi")},
+					},
+				}},
+			},
+		}
+	}
+
+	It("round-trips v1beta1 through the hub, keeping ephemeral-storage", func() {
+		in := newBeta()
+		hub := &v1alpha1.DevStagingEnvironment{}
+		Expect(in.ConvertTo(hub)).To(Succeed())
+
+		resources := hub.Spec.Deployment.Resources
+		Expect(resources.CPURequest.String()).To(Equal("100m"))
+		Expect(resources.MemoryLimit.String()).To(Equal("256Mi"))
+		Expect(resources.CPULimit).To(BeNil())
+		Expect(resources.MemoryRequest).To(BeNil())
+		Expect(hub.Annotations).To(HaveKeyWithValue("team", "payments"))
+		Expect(hub.Annotations).To(HaveKey(extraResourcesAnnotation))
+
+		out := &DevStagingEnvironment{}
+		Expect(out.ConvertFrom(hub)).To(Succeed())
+		Expect(equality.Semantic.DeepEqual(in, out)).To(BeTrue(), "round trip changed the object:\n%s", diff.Diff(in, out))
+		Expect(out.Annotations).NotTo(HaveKey(extraResourcesAnnotation))
+	})
+
+	It("round-trips the hub through v1beta1", func() {
+		cpu, memory := resource.MustParse("250m"), resource.MustParse("128Mi")
+		in := &v1alpha1.DevStagingEnvironment{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default"},
+			Spec: v1alpha1.DevStagingEnvironmentSpec{
+				Deployment: v1alpha1.DeploymentSpec{
+					Image:     "localhost:5001/orders:dev",
+					Port:      8080,
+					Resources: &v1alpha1.ResourceRequirements{CPURequest: &cpu, MemoryLimit: &memory},
+				},
+				Service:      v1alpha1.ServiceSpec{Port: 80},
+				Dependencies: []v1alpha1.DependencySpec{{Type: v1alpha1.DependencyRedis}},
+			},
+			Status: v1alpha1.DevStagingEnvironmentStatus{DeploymentReady: true, URL: "http://orders.localhost"},
+		}
+
+		spoke := &DevStagingEnvironment{}
+		Expect(spoke.ConvertFrom(in)).To(Succeed())
+		Expect(spoke.Spec.Deployment.Resources.Requests).To(HaveKey(corev1.ResourceCPU))
+		Expect(spoke.Spec.Deployment.Resources.Limits).To(HaveKey(corev1.ResourceMemory))
+
+		out := &v1alpha1.DevStagingEnvironment{}
+		Expect(spoke.ConvertTo(out)).To(Succeed())
+		Expect(equality.Semantic.DeepEqual(in, out)).To(BeTrue(), "round trip changed the object:\n%s", diff.Diff(in, out))
+		Expect(out.Annotations).To(BeNil())
+	})
+
+	It("drops a stale extra-resources annotation", func() {
+		// The object once asked for ephemeral-storage and no longer does.
+		in := newBeta()
+		in.Annotations[extraResourcesAnnotation] = `{"deployment":{"limits":{"ephemeral-storage":"2Gi"}}}`
+		in.Spec.Deployment.Resources = &ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+		}
+		in.Spec.Dependencies = nil
+
+		hub := &v1alpha1.DevStagingEnvironment{}
+		Expect(in.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Annotations).NotTo(HaveKey(extraResourcesAnnotation))
+		Expect(hub.Annotations).To(HaveKeyWithValue("team", "payments"))
+
+		out := &DevStagingEnvironment{}
+		Expect(out.ConvertFrom(hub)).To(Succeed())
+		Expect(out.Spec.Deployment.Resources.Limits).To(Equal(corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")}))
+	})
+
+	It("reads the extra resources back only from the hub's annotation", func() {
+		hub := &v1alpha1.DevStagingEnvironment{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders", Annotations: map[string]string{
+				extraResourcesAnnotation: `{"deployment":{"limits":{"ephemeral-storage":"2Gi"}}}`,
+			}},
+			Spec: v1alpha1.DevStagingEnvironmentSpec{
+				Deployment: v1alpha1.DeploymentSpec{Resources: &v1alpha1.ResourceRequirements{}},
+			},
+		}
+		out := &DevStagingEnvironment{}
+		Expect(out.ConvertFrom(hub)).To(Succeed())
+		Expect(out.Annotations).To(BeNil(), "the annotation is the hub's bookkeeping, not the object's")
+		Expect(out.Spec.Deployment.Resources.Limits).To(HaveKeyWithValue(corev1.ResourceEphemeralStorage, resource.MustParse("2Gi")))
+		Expect(hub.Annotations).To(HaveKey(extraResourcesAnnotation), "ConvertFrom mustn't change the hub")
+	})
+
+	Context("with random objects", func() {
+		const rounds = 500
+
+		// Quantities are filled with canonical values: randfill would
+		// otherwise fill their internals, which no API object has.
+		filler := func(seed int64) *randfill.Filler {
+			return randfill.NewWithSeed(seed).NilChance(0.3).NumElements(0, 3).Funcs(
+				func(q *resource.Quantity, c randfill.Continue) {
+					*q = *resource.NewQuantity(c.Int63n(1<<20), resource.DecimalSI)
+				},
+				// The API server sets the kind and version; conversion
+				// doesn't.
+				func(t *metav1.TypeMeta, c randfill.Continue) {},
+			)
+		}
+
+		It("round-trips v1beta1 through the hub", func() {
+			for seed := int64(0); seed < rounds; seed++ {
+				in := &DevStagingEnvironment{}
+				filler(seed).Fill(in)
+				// Extra resources are kept per dependency type, so types
+				// must be unique, as the webhook requires.
+				for i := range in.Spec.Dependencies {
+					in.Spec.Dependencies[i].Type = DependencyType(string(rune('a' + i)))
+				}
+
+				hub := &v1alpha1.DevStagingEnvironment{}
+				Expect(in.ConvertTo(hub)).To(Succeed())
+				out := &DevStagingEnvironment{}
+				Expect(out.ConvertFrom(hub)).To(Succeed())
+				Expect(equality.Semantic.DeepEqual(in, out)).To(BeTrue(), "seed %d: round trip changed the object:\n%s", seed, diff.Diff(in, out))
+			}
+		})
+
+		It("round-trips the hub through v1beta1", func() {
+			for seed := int64(0); seed < rounds; seed++ {
+				in := &v1alpha1.DevStagingEnvironment{}
+				filler(seed).Fill(in)
+
+				spoke := &DevStagingEnvironment{}
+				Expect(spoke.ConvertFrom(in)).To(Succeed())
+				out := &v1alpha1.DevStagingEnvironment{}
+				Expect(spoke.ConvertTo(out)).To(Succeed())
+				Expect(equality.Semantic.DeepEqual(in, out)).To(BeTrue(), "seed %d: round trip changed the object:\n%s", seed, diff.Diff(in, out))
+			}
+		})
+	})
+})
@@ -0,0 +1,511 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+	"github.com/jeffvincent/kindling/cli/pkg/kind"
+	"github.com/spf13/cobra"
+)
+
+// ── Air-gapped installs ─────────────────────────────────────────
+//
+// kindling airgap prepare, run where registries can be reached, packs
+// everything kindling init downloads into one tarball: the images, the
+// upstream manifests, and kustomize. kindling init --airgap installs from
+// it without network access. Loading the images into the nodes isn't
+// enough, since an untagged or :latest image is pulled again on every
+// start, so init pushes them into the local registry container instead
+// and points containerd at it as the mirror of every registry they came
+// from.
+
+var airgapCmd = &cobra.Command{
+	Use:   "airgap",
+	Short: "Install kindling on networks without registry access",
+}
+
+var airgapPrepareCmd = &cobra.Command{
+	Use:   "prepare",
+	Short: "Pack every image and manifest kindling init needs into a tarball",
+	Long: `Pulls every image a kindling cluster runs and packs them, with the
+upstream manifests kindling init applies and the kustomize binary, into a
+tarball for kindling init --airgap. Run it from the kindling project root
+on a machine that can reach the registries; it builds the operator image
+like kindling init does.
+
+The tarball holds:
+  • the Kind node image
+  • the ingress controller, cert-manager, and — when the profile enables
+    them — Calico and metrics-server, with their manifests
+  • the operator, its kube-rbac-proxy, and the in-cluster registry
+  • the default image of each dependency type (--dependencies to pick)
+    and the helpers the operator runs beside them
+  • the base images of every Dockerfile in the current directory
+  • any --image
+
+The profile decides the ingress controller and CNI the bundle serves,
+so prepare with the profile the air-gapped cluster will use. Bundles
+support the kind backend, without observability or logging.
+
+Examples:
+  kindling airgap prepare
+  kindling airgap prepare --profile full -f kindling-full.tar.gz
+  kindling airgap prepare --dependencies postgres,redis --image node:20-alpine`,
+	RunE: runAirgapPrepare,
+}
+
+var (
+	airgapFile         string
+	airgapProfile      string
+	airgapIngress      string
+	airgapNodeImage    string
+	airgapDependencies []string
+	airgapImages       []string
+)
+
+func init() {
+	airgapPrepareCmd.Flags().StringVarP(&airgapFile, "file", "f", "kindling-airgap.tar.gz", "Tarball to write")
+	airgapPrepareCmd.Flags().StringVar(&airgapProfile, "profile", "", "Cluster profile to prepare for (default: .kindling/cluster.yaml, else standard)")
+	airgapPrepareCmd.Flags().StringVar(&airgapIngress, "ingress", "", "Ingress controller: nginx, contour, traefik, or none (overrides the profile)")
+	airgapPrepareCmd.Flags().StringVar(&airgapNodeImage, "node-image", "", "Kind node image (default: Kind's own)")
+	airgapPrepareCmd.Flags().StringSliceVar(&airgapDependencies, "dependencies", nil, "Dependency types whose default images to include (default: all)")
+	airgapPrepareCmd.Flags().StringArrayVar(&airgapImages, "image", nil, "Extra image to include (repeatable)")
+	airgapCmd.AddCommand(airgapPrepareCmd)
+	rootCmd.AddCommand(airgapCmd)
+}
+
+const (
+	airgapIndexFile    = "airgap.json"
+	airgapImagesFile   = "images.tar"
+	airgapKustomizeBin = "bin/kustomize"
+)
+
+// operatorHelperImages are the images the operator runs besides the
+// dependencies: seed Jobs and the tracing collector.
+var operatorHelperImages = []string{"busybox:1.36", "jaegertracing/jaeger:2.5.0"}
+
+// airgapIndex is airgap.json, the table of contents of a bundle.
+type airgapIndex struct {
+	Created   time.Time         `json:"created"`
+	Ingress   string            `json:"ingress"`
+	CNI       string            `json:"cni"`
+	NodeImage string            `json:"nodeImage"`
+	Images    []string          `json:"images"`
+	Manifests map[string]string `json:"manifests"` // upstream URL → file in the bundle
+	File      string            `json:"file,omitempty"`
+}
+
+// airgapSupports reports why profile can't be installed air-gapped, if
+// it can't.
+func airgapSupports(p clusterProfile) error {
+	if p.backend() != "kind" {
+		return fmt.Errorf("air-gapped installs support the kind backend, not %s", p.backend())
+	}
+	if p.Observability || p.Logging {
+		return fmt.Errorf("air-gapped installs don't support observability or logging, which install helm charts")
+	}
+	return nil
+}
+
+// covers reports why the bundle can't install profile, if it can't.
+func (index airgapIndex) covers(p clusterProfile) error {
+	if err := airgapSupports(p); err != nil {
+		return err
+	}
+	if p.Ingress != index.Ingress {
+		return fmt.Errorf("the bundle was prepared for ingress %s, but the profile uses %s — prepare it with --ingress %s", index.Ingress, p.Ingress, p.Ingress)
+	}
+	for _, url := range airgapManifestURLs(p) {
+		if _, ok := index.Manifests[url]; !ok {
+			return fmt.Errorf("the bundle has no copy of %s — prepare it with the same profile", url)
+		}
+	}
+	return nil
+}
+
+// airgapManifestURLs are the upstream manifests kindling init applies
+// for profile.
+func airgapManifestURLs(p clusterProfile) []string {
+	urls := []string{certManagerManifestURL}
+	if m := ingressControllers[p.Ingress].manifest; m != "" {
+		urls = append(urls, m)
+	}
+	if p.CNI == "calico" {
+		urls = append(urls, calicoManifestURL)
+	}
+	if p.MetricsServer {
+		urls = append(urls, metricsServerManifestURL)
+	}
+	return urls
+}
+
+// airgapManifests maps upstream manifest URLs to their copies in the
+// bundle kindling init --airgap installs from.
+var airgapManifests map[string]string
+
+// manifestSource returns the local copy of an upstream manifest during an
+// air-gapped install, and url otherwise.
+func manifestSource(url string) string {
+	if path, ok := airgapManifests[url]; ok {
+		return path
+	}
+	return url
+}
+
+var manifestImagePattern = regexp.MustCompile(`(?m)^\s*(?:-\s+)?image:\s*["']?([^"'\s]+)`)
+
+// manifestImageRefs returns the images a Kubernetes manifest runs.
+func manifestImageRefs(manifest string) []string {
+	var images []string
+	for _, m := range manifestImagePattern.FindAllStringSubmatch(manifest, -1) {
+		images = append(images, m[1])
+	}
+	return images
+}
+
+// stripDigest drops the digest of an image pinned as name:tag@sha256:…
+// — pushed to the mirror, the image gets a digest of its own.
+func stripDigest(image string) string {
+	name, _, found := strings.Cut(image, "@")
+	if !found || !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+		return image
+	}
+	return name
+}
+
+// dependencyDefaultImage is the image the operator runs for a dependency
+// without image or version, as its dependencyImage picks it.
+func dependencyDefaultImage(typ string) string {
+	image := devstaging.DependencyConventions[typ].Image
+	switch typ {
+	case "rabbitmq":
+		return image + ":3-management"
+	case "elasticsearch":
+		return image + ":8.12.0"
+	}
+	return image + ":latest"
+}
+
+// splitImage splits an image reference into its registry host and the
+// repository and tag under it, as containerd resolves them.
+func splitImage(image string) (host, repo, tag string) {
+	image, _, _ = strings.Cut(image, "@")
+	host, repo = "docker.io", image
+	if inRegistry(image) {
+		host, repo, _ = strings.Cut(image, "/")
+	} else if !strings.Contains(image, "/") {
+		repo = "library/" + image
+	}
+	tag = "latest"
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo, tag = repo[:i], repo[i+1:]
+	}
+	return host, repo, tag
+}
+
+// dockerfileImages returns the base images of every Dockerfile under dir.
+func dockerfileImages(dir string) []string {
+	var images []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", ".kindling", "node_modules", "vendor", "bin":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name := d.Name(); name == "Dockerfile" || strings.HasPrefix(name, "Dockerfile.") || strings.HasSuffix(name, ".Dockerfile") {
+			for _, image := range devstaging.DockerfileBaseImages(path) {
+				if image != "scratch" && !strings.Contains(image, "$") {
+					images = append(images, image)
+				}
+			}
+		}
+		return nil
+	})
+	return images
+}
+
+// ── prepare ─────────────────────────────────────────────────────
+
+func runAirgapPrepare(cmd *cobra.Command, args []string) error {
+	dir, err := resolveProjectDir()
+	if err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	profile, _, err := resolveClusterProfile(cwd, airgapProfile)
+	if err != nil {
+		return err
+	}
+	if airgapIngress != "" {
+		profile.Ingress = airgapIngress
+		if err := profile.validate(); err != nil {
+			return err
+		}
+	}
+	dependencies := airgapDependencies
+	if len(dependencies) == 0 {
+		for typ := range devstaging.DependencyConventions {
+			dependencies = append(dependencies, typ)
+		}
+		sort.Strings(dependencies)
+	}
+	for _, typ := range dependencies {
+		if _, ok := devstaging.DependencyConventions[typ]; !ok {
+			return fmt.Errorf("--dependencies: unknown dependency type %q", typ)
+		}
+	}
+	index := airgapIndex{
+		Created:   time.Now().UTC(),
+		Ingress:   profile.Ingress,
+		CNI:       profile.CNI,
+		NodeImage: stripDigest(kind.DefaultNodeImage),
+		Manifests: map[string]string{},
+	}
+	if err := airgapSupports(profile); err != nil {
+		return err
+	}
+	if !commandExists("docker") {
+		return fmt.Errorf("docker not found on PATH")
+	}
+
+	work, err := os.MkdirTemp("", "kindling-airgap-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(work)
+
+	header("Preparing air-gapped bundle")
+
+	// ── Manifests ───────────────────────────────────────────────
+	if err := os.MkdirAll(filepath.Join(work, "manifests"), 0700); err != nil {
+		return err
+	}
+	images := []string{}
+	for i, url := range airgapManifestURLs(profile) {
+		step("📥", url)
+		data, err := download(url)
+		if err != nil {
+			return fmt.Errorf("cannot download %s: %w", url, err)
+		}
+		manifest := string(data)
+		images = append(images, manifestImageRefs(manifest)...)
+		// The mirror serves images by tag only.
+		manifest = manifestImagePattern.ReplaceAllStringFunc(manifest, func(line string) string {
+			image := manifestImagePattern.FindStringSubmatch(line)[1]
+			return strings.Replace(line, image, stripDigest(image), 1)
+		})
+		file := fmt.Sprintf("manifests/%d-%s", i, filepath.Base(url))
+		if err := os.WriteFile(filepath.Join(work, filepath.FromSlash(file)), []byte(manifest), 0600); err != nil {
+			return err
+		}
+		index.Manifests[url] = file
+	}
+
+	// ── Images ──────────────────────────────────────────────────
+	local := []string{filepath.Join(dir, "config", "default"), filepath.Join(dir, "config", "registry")}
+	if profile.Ingress == "traefik" {
+		local = append(local, filepath.Join(dir, "config", "ingress", "traefik.yaml"))
+	}
+	for _, path := range local {
+		files := []string{path}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			files, _ = filepath.Glob(filepath.Join(path, "*.yaml"))
+		}
+		for _, file := range files {
+			if data, err := os.ReadFile(file); err == nil {
+				images = append(images, manifestImageRefs(string(data))...)
+			}
+		}
+	}
+	for _, typ := range dependencies {
+		images = append(images, dependencyDefaultImage(typ))
+	}
+	images = append(images, operatorHelperImages...)
+	images = append(images, dockerfileImages(cwd)...)
+	images = append(images, airgapImages...)
+
+	nodeImage := kind.DefaultNodeImage
+	if airgapNodeImage != "" {
+		nodeImage = airgapNodeImage
+		index.NodeImage = stripDigest(airgapNodeImage)
+	}
+	images = append([]string{nodeImage}, images...)
+
+	seen := map[string]bool{"controller:latest": true}
+	for _, image := range images {
+		if seen[stripDigest(image)] {
+			continue
+		}
+		seen[stripDigest(image)] = true
+		spin := startSpinner(fmt.Sprintf("docker pull %s", image))
+		out, err := runSilent("docker", "pull", image)
+		spin.stop()
+		if err != nil {
+			return fmt.Errorf("docker pull %s failed: %s", image, lastLines(out, 3))
+		}
+		if name := stripDigest(image); name != image {
+			if out, err := runSilent("docker", "tag", image, name); err != nil {
+				return fmt.Errorf("docker tag %s failed: %s", name, out)
+			}
+		}
+		step("✓", stripDigest(image))
+		index.Images = append(index.Images, stripDigest(image))
+	}
+
+	step("🏗️ ", "docker build -t controller:latest")
+	if out, err := runSilent("docker", "build", "-t", "controller:latest", dir); err != nil {
+		return fmt.Errorf("operator image build failed: %s", lastLines(out, 10))
+	}
+	index.Images = append(index.Images, "controller:latest")
+
+	spin := startSpinner(fmt.Sprintf("docker save (%d images)", len(index.Images)))
+	out, err := runSilent("docker", append([]string{"save", "-o", filepath.Join(work, airgapImagesFile)}, index.Images...)...)
+	spin.stop()
+	if err != nil {
+		return fmt.Errorf("docker save failed: %s", lastLines(out, 5))
+	}
+
+	// ── kustomize ───────────────────────────────────────────────
+	kustomizeBin, err := ensureKustomize(dir)
+	if err != nil {
+		return fmt.Errorf("failed to set up kustomize: %w", err)
+	}
+	data, err := os.ReadFile(kustomizeBin)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(work, "bin"), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(work, filepath.FromSlash(airgapKustomizeBin)), data, 0700); err != nil {
+		return err
+	}
+
+	indexData, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(work, airgapIndexFile), indexData, 0600); err != nil {
+		return err
+	}
+	spin = startSpinner(fmt.Sprintf("Writing %s", airgapFile))
+	err = writeTarball(airgapFile, work)
+	spin.stop()
+	if err != nil {
+		return err
+	}
+	index.File = airgapFile
+
+	return render(index, func() {
+		size := ""
+		if fi, err := os.Stat(airgapFile); err == nil {
+			size = " (" + devstaging.FormatBytes(fi.Size()) + ")"
+		}
+		success(fmt.Sprintf("Wrote %s%s: %d images, %d manifests", airgapFile, size, len(index.Images), len(index.Manifests)))
+		fmt.Println()
+		fmt.Printf("  Copy it and the kindling project to the air-gapped machine, then run:\n")
+		fmt.Printf("    %skindling init --airgap %s%s\n", colorCyan, filepath.Base(airgapFile), colorReset)
+		fmt.Println()
+	})
+}
+
+// ── init --airgap ───────────────────────────────────────────────
+
+// loadAirgapBundle unpacks bundle into work, loads its images into
+// Docker, and pushes all but the node image into the local registry. It
+// returns the bundle's index and the containerd mirrors that serve the
+// pushed images.
+func loadAirgapBundle(bundle, work string, p clusterProfile) (airgapIndex, map[string]string, error) {
+	var index airgapIndex
+	step("📦", fmt.Sprintf("Unpacking %s", bundle))
+	if err := extractTarball(bundle, work); err != nil {
+		return index, nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(work, airgapIndexFile))
+	if err != nil {
+		return index, nil, fmt.Errorf("%s has no %s — not a kindling airgap bundle", bundle, airgapIndexFile)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return index, nil, fmt.Errorf("cannot parse %s: %w", airgapIndexFile, err)
+	}
+	if err := index.covers(p); err != nil {
+		return index, nil, err
+	}
+
+	spin := startSpinner(fmt.Sprintf("docker load (%d images)", len(index.Images)))
+	out, err := runSilent("docker", "load", "-i", filepath.Join(work, airgapImagesFile))
+	spin.stop()
+	if err != nil {
+		return index, nil, fmt.Errorf("docker load failed: %s", lastLines(out, 5))
+	}
+	success(fmt.Sprintf("Loaded %d images", len(index.Images)))
+
+	addr, err := startLocalRegistry(defaultLocalRegistryPort)
+	if err != nil {
+		return index, nil, err
+	}
+	// Images are stored under their registry host, so that the mirror of
+	// each host serves only its own.
+	mirrors := map[string]string{}
+	spin = startSpinner(fmt.Sprintf("Pushing images to %s", addr))
+	for _, image := range index.Images {
+		if image == index.NodeImage {
+			continue
+		}
+		host, repo, tag := splitImage(image)
+		if strings.HasPrefix(host, "localhost") {
+			continue
+		}
+		target := fmt.Sprintf("%s/%s/%s:%s", addr, host, repo, tag)
+		if out, err := runSilent("docker", "tag", image, target); err != nil {
+			spin.stop()
+			return index, nil, fmt.Errorf("docker tag %s failed: %s", image, out)
+		}
+		if out, err := runSilent("docker", "push", target); err != nil {
+			spin.stop()
+			return index, nil, fmt.Errorf("docker push %s failed: %s", target, lastLines(out, 5))
+		}
+		mirrors[host] = fmt.Sprintf("http://%s:5000/v2/%s", localRegistryName, host)
+	}
+	spin.stop()
+	success(fmt.Sprintf("Images pushed to %s", addr))
+
+	airgapManifests = map[string]string{}
+	for url, file := range index.Manifests {
+		airgapManifests[url] = filepath.Join(work, filepath.FromSlash(file))
+	}
+	return index, mirrors, nil
+}
+
+// installAirgapKustomize puts the bundle's kustomize where ensureKustomize
+// looks, unless one is already there.
+func installAirgapKustomize(work, dir string) error {
+	target := filepath.Join(dir, "bin", "kustomize")
+	if _, err := os.Stat(target); err == nil {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(work, filepath.FromSlash(airgapKustomizeBin)))
+	if err != nil {
+		return fmt.Errorf("the bundle has no kustomize: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0755)
+}
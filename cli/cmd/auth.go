@@ -0,0 +1,444 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/tunnel"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Set up OAuth providers for the public tunnel",
+	Long: `Helpers for the OAuth/OIDC providers an app signs in with while it is
+exposed through kindling expose.
+
+Examples:
+  kindling auth configure --provider auth0 --domain my-tenant.us.auth0.com
+  kindling auth configure --provider google --client-id ... --client-secret ...`,
+}
+
+var authConfigureCmd = &cobra.Command{
+	Use:   "configure",
+	Short: "Print or apply the callback URLs of the current tunnel for an OAuth provider",
+	Long: `Works out the callback URL, allowed origin, and logout URL an OAuth
+provider needs for the running kindling expose tunnel, and prints them in
+the provider's terms, ready to paste into its dashboard.
+
+With an API token (--token, or $AUTH0_MANAGEMENT_TOKEN / $OKTA_API_TOKEN)
+and --client-id, the URLs are added to the application at the provider
+instead; URLs already registered are kept. Google has no API for this, so
+its URLs are always printed.
+
+Once the client ID is known, it is written with the client secret and the
+provider's domain into the Secret kindling-auth-<provider>, in the default
+namespace and the current environment's, for components to load:
+
+  envFrom:
+    - secretRef:
+        name: kindling-auth-auth0
+
+A quick tunnel gets a new URL when it restarts: run configure again, and
+reference the URL in env values as ${KINDLING_TUNNEL_URL} so the operator
+keeps them current.
+
+Examples:
+  kindling auth configure --provider auth0 --domain my-tenant.us.auth0.com
+  kindling auth configure --provider auth0 --domain my-tenant.us.auth0.com \
+    --client-id abc123 --token $AUTH0_MANAGEMENT_TOKEN
+  kindling auth configure --provider okta --domain dev-123456.okta.com \
+    --client-id 0oa1b2c3 --callback-path /authorization-code/callback
+  kindling auth configure --provider google --service web \
+    --client-id 123.apps.googleusercontent.com --client-secret GOCSPX-...`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runAuthConfigure,
+}
+
+var (
+	authProvider     string
+	authService      string
+	authDomain       string
+	authClientID     string
+	authClientSecret string
+	authToken        string
+	authCallbackPath string
+	authLogoutPath   string
+)
+
+func init() {
+	authConfigureCmd.Flags().StringVar(&authProvider, "provider", "", "OAuth provider: auth0, okta, or google (required)")
+	authConfigureCmd.Flags().StringVar(&authService, "service", "", "Use the tunnel started with kindling expose --service (default: the default tunnel)")
+	authConfigureCmd.Flags().StringVar(&authDomain, "domain", "", "Auth0 tenant or Okta org domain (default: $AUTH0_DOMAIN / $OKTA_DOMAIN)")
+	authConfigureCmd.Flags().StringVar(&authClientID, "client-id", "", "Client ID of the application at the provider")
+	authConfigureCmd.Flags().StringVar(&authClientSecret, "client-secret", "", "Client secret to store (default: read from the provider with --token)")
+	authConfigureCmd.Flags().StringVar(&authToken, "token", "", "Provider API token to apply the URLs with (default: $AUTH0_MANAGEMENT_TOKEN / $OKTA_API_TOKEN)")
+	authConfigureCmd.Flags().StringVar(&authCallbackPath, "callback-path", "/callback", "Path of the app's OAuth callback")
+	authConfigureCmd.Flags().StringVar(&authLogoutPath, "logout-path", "/", "Path the provider returns to after logout")
+	_ = authConfigureCmd.MarkFlagRequired("provider")
+	authCmd.AddCommand(authConfigureCmd)
+	rootCmd.AddCommand(authCmd)
+}
+
+// oauthProvider is what configure knows about one provider.
+type oauthProvider struct {
+	prefix    string // of the keys in the Secret, e.g. AUTH0
+	domainEnv string // environment variable with the domain, "" if none
+	tokenEnv  string // environment variable with the API token, "" if no API
+	console   string // where the URLs are entered by hand
+}
+
+var oauthProviders = map[string]oauthProvider{
+	"auth0": {
+		prefix:    "AUTH0",
+		domainEnv: "AUTH0_DOMAIN",
+		tokenEnv:  "AUTH0_MANAGEMENT_TOKEN",
+		console:   "Auth0 Dashboard → Applications → <app> → Settings",
+	},
+	"okta": {
+		prefix:    "OKTA",
+		domainEnv: "OKTA_DOMAIN",
+		tokenEnv:  "OKTA_API_TOKEN",
+		console:   "Okta Admin Console → Applications → <app> → General, and Security → API → Trusted Origins",
+	},
+	"google": {
+		prefix:  "GOOGLE",
+		console: "https://console.cloud.google.com/apis/credentials → <OAuth client>",
+	},
+}
+
+// authConfigureResult is the JSON form of auth configure's output.
+type authConfigureResult struct {
+	Provider   string   `json:"provider"`
+	TunnelURL  string   `json:"tunnelUrl"`
+	Callbacks  []string `json:"callbacks"`
+	Origins    []string `json:"origins"`
+	LogoutURLs []string `json:"logoutUrls"`
+	Applied    bool     `json:"applied"`
+	Secret     string   `json:"secret,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+func runAuthConfigure(cmd *cobra.Command, args []string) error {
+	provider, ok := oauthProviders[strings.ToLower(authProvider)]
+	if !ok {
+		return fmt.Errorf("--provider must be auth0, okta, or google, got %q", authProvider)
+	}
+	name := strings.ToLower(authProvider)
+
+	tunnelURL, err := authTunnelURL(authService)
+	if err != nil {
+		return err
+	}
+	result := authConfigureResult{
+		Provider:   name,
+		TunnelURL:  tunnelURL,
+		Callbacks:  []string{tunnelURL + authPath(authCallbackPath)},
+		Origins:    []string{tunnelURL},
+		LogoutURLs: []string{tunnelURL + authPath(authLogoutPath)},
+	}
+
+	domain := authDomain
+	if domain == "" && provider.domainEnv != "" {
+		domain = os.Getenv(provider.domainEnv)
+	}
+	domain = strings.TrimSuffix(strings.TrimPrefix(domain, "https://"), "/")
+	if domain == "" && name != "google" {
+		return fmt.Errorf("--domain is required for %s (or set $%s)", name, provider.domainEnv)
+	}
+	token := authToken
+	if token == "" && provider.tokenEnv != "" {
+		token = os.Getenv(provider.tokenEnv)
+	}
+
+	// ── Apply at the provider ───────────────────────────────────
+	clientSecret := authClientSecret
+	if token != "" && authClientID != "" && name != "google" {
+		sp := startSpinner(fmt.Sprintf("Adding the URLs to %s application %s", name, authClientID))
+		var secret string
+		if name == "auth0" {
+			secret, err = applyAuth0(domain, token, authClientID, result)
+		} else {
+			secret, err = applyOkta(domain, token, authClientID, result)
+		}
+		sp.stop()
+		if err != nil {
+			return err
+		}
+		result.Applied = true
+		if clientSecret == "" {
+			clientSecret = secret
+		}
+	}
+
+	// ── Store the credentials ───────────────────────────────────
+	if authClientID != "" {
+		data := map[string]string{provider.prefix + "_CLIENT_ID": authClientID}
+		if clientSecret != "" {
+			data[provider.prefix+"_CLIENT_SECRET"] = clientSecret
+		}
+		if domain != "" {
+			data[provider.prefix+"_DOMAIN"] = domain
+		}
+		if name == "okta" {
+			data["OKTA_ISSUER"] = "https://" + domain + "/oauth2/default"
+		}
+		result.Secret = "kindling-auth-" + name
+		result.Namespaces = []string{secretsNamespace}
+		if env := currentEnvironment(); env != defaultEnvName {
+			result.Namespaces = append(result.Namespaces, environmentNamespace(env))
+		}
+		for _, ns := range result.Namespaces {
+			if err := applyAuthSecret(result.Secret, ns, data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return render(result, func() { printAuthConfigure(result, provider) })
+}
+
+// authTunnelURL returns the public URL of the running tunnel for service,
+// "" meaning the default one.
+func authTunnelURL(service string) (string, error) {
+	tunnels, err := loadTunnels()
+	if err != nil {
+		return "", err
+	}
+	i := tunnel.Find(tunnels, service)
+	if i < 0 && service == "" && len(tunnels) > 0 {
+		i = 0
+	}
+	if i < 0 || !processAlive(tunnels[i].PID) {
+		if service != "" {
+			return "", fmt.Errorf("no tunnel is running for %s — start one with: kindling expose --service %s", service, service)
+		}
+		return "", fmt.Errorf("no tunnel is running — start one with: kindling expose")
+	}
+	return strings.TrimSuffix(tunnels[i].URL, "/"), nil
+}
+
+// authPath makes p an absolute URL path.
+func authPath(p string) string {
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
+
+// applyAuth0 adds the URLs to an Auth0 application through the Management
+// API and returns its client secret, when the token may read it.
+func applyAuth0(domain, token, clientID string, result authConfigureResult) (string, error) {
+	endpoint := "https://" + domain + "/api/v2/clients/" + clientID
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	var client struct {
+		ClientSecret      string   `json:"client_secret"`
+		Callbacks         []string `json:"callbacks"`
+		AllowedOrigins    []string `json:"allowed_origins"`
+		WebOrigins        []string `json:"web_origins"`
+		AllowedLogoutURLs []string `json:"allowed_logout_urls"`
+	}
+	if err := authRequest("Auth0", http.MethodGet, endpoint, headers, nil, &client); err != nil {
+		return "", err
+	}
+	// The Management API replaces these lists, so the URLs already there
+	// are sent back with the new ones.
+	patch := map[string][]string{
+		"callbacks":           appendMissing(client.Callbacks, result.Callbacks...),
+		"allowed_origins":     appendMissing(client.AllowedOrigins, result.Origins...),
+		"web_origins":         appendMissing(client.WebOrigins, result.Origins...),
+		"allowed_logout_urls": appendMissing(client.AllowedLogoutURLs, result.LogoutURLs...),
+	}
+	if err := authRequest("Auth0", http.MethodPatch, endpoint, headers, patch, nil); err != nil {
+		return "", err
+	}
+	return client.ClientSecret, nil
+}
+
+// applyOkta adds the callback and logout URLs to an Okta OIDC application
+// and the origin to the org's trusted origins, and returns the
+// application's client secret.
+func applyOkta(domain, token, clientID string, result authConfigureResult) (string, error) {
+	base := "https://" + domain + "/api/v1"
+	headers := map[string]string{"Authorization": "SSWS " + token}
+
+	// The application is sent back whole, so it is kept as generic JSON.
+	var app map[string]interface{}
+	if err := authRequest("Okta", http.MethodGet, base+"/apps/"+clientID, headers, nil, &app); err != nil {
+		return "", err
+	}
+	settings, _ := app["settings"].(map[string]interface{})
+	oauth, _ := settings["oauthClient"].(map[string]interface{})
+	if oauth == nil {
+		return "", fmt.Errorf("Okta application %s is not an OIDC application", clientID)
+	}
+	oauth["redirect_uris"] = appendMissing(jsonStrings(oauth["redirect_uris"]), result.Callbacks...)
+	oauth["post_logout_redirect_uris"] = appendMissing(jsonStrings(oauth["post_logout_redirect_uris"]), result.LogoutURLs...)
+	if err := authRequest("Okta", http.MethodPut, base+"/apps/"+clientID, headers, app, nil); err != nil {
+		return "", err
+	}
+
+	var origins []struct {
+		Origin string `json:"origin"`
+	}
+	if err := authRequest("Okta", http.MethodGet, base+"/trustedOrigins", headers, nil, &origins); err != nil {
+		return "", err
+	}
+	for _, origin := range result.Origins {
+		known := false
+		for _, o := range origins {
+			known = known || o.Origin == origin
+		}
+		if known {
+			continue
+		}
+		body := map[string]interface{}{
+			"name":   "kindling " + tunnelHostname(origin),
+			"origin": origin,
+			"scopes": []map[string]string{{"type": "CORS"}, {"type": "REDIRECT"}},
+		}
+		if err := authRequest("Okta", http.MethodPost, base+"/trustedOrigins", headers, body, nil); err != nil {
+			return "", err
+		}
+	}
+
+	credentials, _ := app["credentials"].(map[string]interface{})
+	client, _ := credentials["oauthClient"].(map[string]interface{})
+	secret, _ := client["client_secret"].(string)
+	return secret, nil
+}
+
+// authRequest sends a JSON request to a provider API and decodes a 2xx
+// response into out, if it isn't nil.
+func authRequest(label, method, endpoint string, headers map[string]string, reqBody, out interface{}) error {
+	var body io.Reader
+	if reqBody != nil {
+		data, err := json.Marshal(reqBody)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := outboundClient(30 * time.Second).Do(req)
+	if err != nil {
+		return outboundError(endpoint, fmt.Errorf("%s API request failed: %w", label, err))
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s API returned HTTP %d: %s", label, resp.StatusCode, lastLines(string(respBody), 5))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
+}
+
+// appendMissing appends the values not in list yet.
+func appendMissing(list []string, values ...string) []string {
+	for _, v := range values {
+		if !containsString(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// jsonStrings converts a decoded JSON array of strings.
+func jsonStrings(v interface{}) []string {
+	items, _ := v.([]interface{})
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// applyAuthSecret creates or updates the Secret holding a provider's
+// credentials. It is labelled like kindling secrets, so new environments
+// get a copy.
+func applyAuthSecret(name, namespace string, data map[string]string) error {
+	secret := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    map[string]string{secretsLabelKey: secretsLabelValue},
+		},
+		"type":       "Opaque",
+		"stringData": data,
+	}
+	manifest, err := json.Marshal(secret)
+	if err != nil {
+		return err
+	}
+	if out, err := runSilentStdin(string(manifest), "kubectl", "--context", kubeContextName(), "apply", "-f", "-"); err != nil {
+		return fmt.Errorf("cannot write Secret %s in %s: %s", name, namespace, out)
+	}
+	return nil
+}
+
+func printAuthConfigure(result authConfigureResult, provider oauthProvider) {
+	header(fmt.Sprintf("OAuth URLs for %s", result.Provider))
+	fmt.Printf("  %-24s %s\n", "Tunnel:", result.TunnelURL)
+	fmt.Println()
+
+	labels := map[string][3]string{
+		"auth0":  {"Allowed Callback URLs:", "Allowed Web Origins:", "Allowed Logout URLs:"},
+		"okta":   {"Sign-in redirect URIs:", "Trusted Origins:", "Sign-out redirect URIs:"},
+		"google": {"Authorized redirect URIs:", "Authorized JavaScript origins:", ""},
+	}[result.Provider]
+	rows := [][]string{result.Callbacks, result.Origins, result.LogoutURLs}
+	for i, label := range labels {
+		if label == "" {
+			continue
+		}
+		fmt.Printf("  %-32s %s%s%s\n", label, colorCyan, strings.Join(rows[i], ", "), colorReset)
+	}
+	fmt.Println()
+
+	if result.Applied {
+		success(fmt.Sprintf("Added to the %s application — URLs already registered were kept", result.Provider))
+	} else {
+		fmt.Printf("  %sEnter them in: %s%s\n", colorDim, provider.console, colorReset)
+		if provider.tokenEnv != "" {
+			fmt.Printf("  %sOr apply them with --client-id and --token ($%s)%s\n", colorDim, provider.tokenEnv, colorReset)
+		}
+	}
+	if result.Secret != "" {
+		success(fmt.Sprintf("Credentials written to Secret %s in %s", result.Secret, strings.Join(result.Namespaces, ", ")))
+		fmt.Println()
+		fmt.Printf("  %sLoad them in a component with envFrom: [{secretRef: {name: %s}}],%s\n", colorDim, result.Secret, colorReset)
+		fmt.Printf("  %sand pass the callback as \"${KINDLING_TUNNEL_URL}%s\" so it follows the tunnel%s\n", colorDim, authPath(authCallbackPath), colorReset)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/build"
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build every service image in parallel and ship it to the cluster",
+	Long: `Builds the image of every DevStagingEnvironment in a manifest at once,
+with at most --jobs builds running concurrently, then gets each image into
+the Kind cluster — pushed to the local registry when kindling registry is
+running, loaded with kind load otherwise.
+
+Images are tagged with the repo's short git SHA (with a -dirty suffix when
+the work tree has uncommitted changes), so the same commit always produces
+the same tag. Build contexts are resolved the same way as kindling dev.
+
+--builder hands the builds to a docker buildx builder on another machine
+— a BuildKit daemon or a Docker host reached over SSH — building for the
+platform of the Kind nodes and pulling each image back to ship it. It
+defaults to $KINDLING_BUILDER, then build.builder in .kindling/config.yaml.
+
+When every build has finished, a table reports how long each build and
+ship step took and what fraction of the Dockerfile steps came from the
+build cache.
+
+--scan runs Trivy (from PATH, or its container image) on each built
+image and reports its vulnerabilities by severity. --fail-on high (or
+critical, medium, low) implies --scan: an image with a vulnerability at
+or above that severity is not shipped, and the command exits non-zero.
+
+Examples:
+  kindling build -f dev-environment.yaml
+  kindling build -f dev-environment.yaml -j 2
+  kindling build -f dev-environment.yaml --tag v1.2.0 -o json
+  kindling build -f dev-environment.yaml --builder buildfarm
+  kindling build -f dev-environment.yaml --scan --fail-on critical`,
+	SilenceUsage: true,
+	RunE:         runBuild,
+}
+
+var (
+	buildFile     string
+	buildRepoPath string
+	buildJobs     int
+	buildTag      string
+	buildBuilder  string
+
+	buildScan          bool
+	buildFailOn        string
+	buildIgnoreUnfixed bool
+)
+
+func init() {
+	buildCmd.Flags().StringVarP(&buildFile, "file", "f", "", "DevStagingEnvironment YAML to build (required)")
+	buildCmd.Flags().StringVarP(&buildRepoPath, "repo-path", "r", "", "Repository root the images are built from (default: the file's directory)")
+	buildCmd.Flags().IntVarP(&buildJobs, "jobs", "j", runtime.NumCPU(), "Maximum number of concurrent builds")
+	buildCmd.Flags().StringVar(&buildTag, "tag", "", "Image tag (default: the short git SHA)")
+	buildCmd.Flags().BoolVar(&buildScan, "scan", false, "Scan each built image for vulnerabilities with Trivy")
+	buildCmd.Flags().StringVar(&buildFailOn, "fail-on", "", "Don't ship images with a vulnerability at or above this severity: critical, high, medium, or low (implies --scan)")
+	buildCmd.Flags().BoolVar(&buildIgnoreUnfixed, "ignore-unfixed", false, "Leave out vulnerabilities that have no fixed version yet")
+	buildCmd.Flags().StringVar(&buildBuilder, "builder", "", "docker buildx builder to build on (default: $KINDLING_BUILDER, then build.builder in .kindling/config.yaml)")
+	_ = buildCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(buildCmd)
+}
+
+// buildResult is one row of the build report.
+type buildResult struct {
+	Service      string       `json:"service"`
+	Image        string       `json:"image"`
+	BuildSeconds float64      `json:"buildSeconds"`
+	ShipSeconds  float64      `json:"shipSeconds"`
+	Shipped      string       `json:"shipped,omitempty"`
+	CachedSteps  int          `json:"cachedSteps"`
+	TotalSteps   int          `json:"totalSteps"`
+	Scan         *scanSummary `json:"scan,omitempty"`
+	Error        string       `json:"error,omitempty"`
+}
+
+// cacheHitRate is the fraction of Dockerfile steps served from cache, or
+// -1 when the build output had no recognisable steps.
+func (r buildResult) cacheHitRate() float64 {
+	if r.TotalSteps == 0 {
+		return -1
+	}
+	return float64(r.CachedSteps) / float64(r.TotalSteps)
+}
+
+func runBuild(cmd *cobra.Command, args []string) error {
+	for _, bin := range []string{"docker"} {
+		if !commandExists(bin) {
+			return fmt.Errorf("%s is not installed — run: kindling doctor", bin)
+		}
+	}
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	if err := requireImageRegistry(); err != nil {
+		return err
+	}
+	if buildJobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1")
+	}
+	if buildFailOn != "" {
+		threshold, err := validScanThreshold(buildFailOn)
+		if err != nil {
+			return err
+		}
+		buildFailOn, buildScan = threshold, true
+	}
+
+	data, err := os.ReadFile(buildFile)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", buildFile, err)
+	}
+	repoPath := buildRepoPath
+	if repoPath == "" {
+		repoPath = validateRepoRoot(buildFile)
+	}
+	repoPath, _ = filepath.Abs(repoPath)
+
+	services, err := devServices(data, repoPath, buildFile)
+	if err != nil {
+		return err
+	}
+	tag := buildTag
+	if tag == "" {
+		tag = gitImageTag(repoPath)
+	}
+
+	builder, err := resolveBuilderName(buildBuilder, repoPath)
+	if err != nil {
+		return err
+	}
+	if err := selectBuilder(builder); err != nil {
+		return err
+	}
+	header(fmt.Sprintf("Building %d image(s), %d at a time", len(services), min(buildJobs, len(services))))
+	results := make([]buildResult, len(services))
+	sem := make(chan struct{}, buildJobs)
+	var wg sync.WaitGroup
+	for i, svc := range services {
+		wg.Add(1)
+		go func(i int, svc *devService) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = buildService(svc, clusterImage(svc.repo, tag))
+		}(i, svc)
+	}
+	wg.Wait()
+
+	if err := render(results, func() { printBuildReport(results) }); err != nil {
+		return err
+	}
+	failed, blocked := 0, 0
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			failed++
+		case r.Scan != nil && r.Scan.Blocked:
+			blocked++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d build(s) failed", failed, len(results))
+	}
+	if blocked > 0 {
+		return fmt.Errorf("%d of %d image(s) have %s or more severe vulnerabilities and were not shipped", blocked, len(results), strings.ToLower(buildFailOn))
+	}
+	return nil
+}
+
+// buildService builds and ships one image, timing each half.
+func buildService(svc *devService, image string) buildResult {
+	res := buildResult{Service: svc.name, Image: image}
+	step("🔨", fmt.Sprintf("%s: building %s", svc.name, image))
+
+	start := time.Now()
+	out, err := runSilent("docker", imageBuildArgs(svc, image, "--progress=plain")...)
+	res.BuildSeconds = time.Since(start).Seconds()
+	res.CachedSteps, res.TotalSteps = build.CacheStats(out)
+	saveBuildLog(svc.name, out)
+	if err != nil {
+		res.Error = "docker build failed:\n" + lastLines(out, 15)
+		fail(fmt.Sprintf("%s: build failed after %s", svc.name, formatSeconds(res.BuildSeconds)))
+		return res
+	}
+
+	if buildScan {
+		step("🔍", fmt.Sprintf("%s: scanning %s", svc.name, image))
+		res.Scan = scanImage(image, buildIgnoreUnfixed)
+		switch {
+		case res.Scan.Error != "" && buildFailOn != "":
+			res.Error = res.Scan.Error
+			fail(fmt.Sprintf("%s: %s — not shipping an unscanned image", svc.name, res.Scan.Error))
+			return res
+		case res.Scan.Error != "":
+			warn(fmt.Sprintf("%s: %s", svc.name, res.Scan.Error))
+		case buildFailOn != "" && res.Scan.exceeds(buildFailOn):
+			res.Scan.Blocked = true
+			fail(fmt.Sprintf("%s: %s — at or above %s, not shipped", svc.name, res.Scan.short(), strings.ToLower(buildFailOn)))
+			return res
+		}
+	}
+
+	start = time.Now()
+	res.Shipped, err = shipImage(image)
+	res.ShipSeconds = time.Since(start).Seconds()
+	if err != nil {
+		res.Error = err.Error()
+		fail(fmt.Sprintf("%s: %v", svc.name, err))
+		return res
+	}
+	success(fmt.Sprintf("%s: built in %s, %s", svc.name, formatSeconds(res.BuildSeconds), res.Shipped))
+	return res
+}
+
+// gitImageTag returns the tag build.GitTag gives repoPath, or a timestamp
+// tag outside git.
+func gitImageTag(repoPath string) string {
+	tag, err := build.GitTag(repoPath)
+	if err != nil {
+		tag = fmt.Sprintf("dev-%d", time.Now().Unix())
+		warn(fmt.Sprintf("%v — tagging images %s", err, tag))
+	}
+	return tag
+}
+
+func printBuildReport(results []buildResult) {
+	scanned := false
+	for _, r := range results {
+		scanned = scanned || r.Scan != nil
+	}
+	header("Build report")
+	if scanned {
+		fmt.Printf("  %s%-24s %-9s %-9s %-8s %-16s %s%s\n", colorBold, "SERVICE", "BUILD", "SHIP", "CACHE", "VULNERABILITIES", "IMAGE", colorReset)
+	} else {
+		fmt.Printf("  %s%-24s %-9s %-9s %-8s %s%s\n", colorBold, "SERVICE", "BUILD", "SHIP", "CACHE", "IMAGE", colorReset)
+	}
+	var total float64
+	for _, r := range results {
+		cache := "—"
+		if rate := r.cacheHitRate(); rate >= 0 {
+			cache = fmt.Sprintf("%d%%", int(rate*100+0.5))
+		}
+		ship := formatSeconds(r.ShipSeconds)
+		if r.Shipped == "" {
+			ship = "—"
+		}
+		line := fmt.Sprintf("  %-24s %-9s %-9s %-8s %s", r.Service, formatSeconds(r.BuildSeconds), ship, cache, r.Image)
+		if scanned {
+			line = fmt.Sprintf("  %-24s %-9s %-9s %-8s %-16s %s", r.Service, formatSeconds(r.BuildSeconds), ship, cache, r.Scan.short(), r.Image)
+		}
+		if r.Error != "" {
+			fmt.Printf("%s%s%s\n", colorRed, line, colorReset)
+			for _, l := range strings.Split(r.Error, "\n") {
+				fmt.Printf("      %s\n", dimText(l))
+			}
+			continue
+		}
+		if r.Scan != nil && r.Scan.Blocked {
+			fmt.Printf("%s%s%s\n", colorRed, line, colorReset)
+		} else {
+			fmt.Println(line)
+		}
+		if r.Scan != nil {
+			for _, f := range r.Scan.Top {
+				fixed := "no fix yet"
+				if f.Fixed != "" {
+					fixed = "fixed in " + f.Fixed
+				}
+				fmt.Printf("      %s\n", dimText(fmt.Sprintf("%-8s %-16s %s %s, %s", strings.ToLower(f.Severity), f.ID, f.Package, f.Installed, fixed)))
+			}
+		}
+		total += r.BuildSeconds + r.ShipSeconds
+	}
+	fmt.Printf("\n  %s%s of build and ship time across %d service(s)%s\n\n", colorDim, formatSeconds(total), len(results), colorReset)
+}
+
+func formatSeconds(s float64) string {
+	return time.Duration(s * float64(time.Second)).Round(100 * time.Millisecond).String()
+}
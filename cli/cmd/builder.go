@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ── Remote builders ─────────────────────────────────────────────
+//
+// kindling build and kindling dev can hand builds to a buildx builder on
+// another machine — a BuildKit daemon reached over TCP, or a Docker host
+// over SSH — chosen with --builder, $KINDLING_BUILDER, or build.builder
+// in .kindling/config.yaml. The image comes back into the local image
+// store (--load) and is shipped to the cluster like a local build,
+// through the local registry when it's running. Builds target the
+// platform of the Kind nodes, so an amd64 build server can build for an
+// arm64 laptop and the other way round.
+
+var (
+	remoteBuilder  string // buildx builder to build on, "" to build locally
+	remotePlatform string // --platform of the cluster's nodes, e.g. linux/arm64
+)
+
+// resolveBuilderName picks the builder: the --builder flag, then
+// $KINDLING_BUILDER, then build.builder in the project's or the user's
+// config.yaml.
+func resolveBuilderName(flag, dir string) (string, error) {
+	if flag != "" {
+		return flag, nil
+	}
+	if env := os.Getenv("KINDLING_BUILDER"); env != "" {
+		return env, nil
+	}
+	cfg, err := loadKindlingConfig(dir)
+	if err != nil {
+		return "", err
+	}
+	return cfg.Build.Builder, nil
+}
+
+// selectBuilder prepares the builds of this command: the named remote
+// builder, or the local kindling builder when name is empty.
+func selectBuilder(name string) error {
+	if name == "" || name == buildCacheBuilder {
+		ensureBuildCache()
+		return nil
+	}
+	out, err := runSilent("docker", "buildx", "inspect", name)
+	if err != nil {
+		return fmt.Errorf("no buildx builder named %q — create it with, e.g., docker buildx create --name %s --driver remote tcp://<host>:1234 (a BuildKit daemon) or docker buildx create --name %s ssh://<user>@<host> (a Docker host)",
+			name, name, name)
+	}
+
+	platform, err := clusterPlatform()
+	if err != nil {
+		return err
+	}
+	native, supported := builderPlatforms(out)
+	switch {
+	case containsString(native, platform):
+	case containsString(supported, platform):
+		warn(fmt.Sprintf("builder %s runs %s natively — %s builds are emulated and much slower; append a native node with: docker buildx create --append --name %s <endpoint>",
+			name, strings.Join(native, ", "), platform, name))
+	case len(supported) > 0:
+		return fmt.Errorf("builder %s can't build %s for the cluster's nodes (it supports %s) — append a node that can with: docker buildx create --append --name %s <endpoint>",
+			name, platform, strings.Join(supported, ", "), name)
+	}
+
+	remoteBuilder, remotePlatform = name, platform
+	step("🛰️ ", fmt.Sprintf("Building on %s for %s", name, platform))
+	if _, ok := localRegistryAddress(); !ok {
+		warn("images from a remote builder are shipped with kind load — kindling registry start pushes only the layers that changed")
+	}
+	return nil
+}
+
+// clusterPlatform returns the platform of the Kind cluster's nodes.
+func clusterPlatform() (string, error) {
+	out, err := kubectlJSON("get", "nodes", "-o", "jsonpath={.items[*].status.nodeInfo.architecture}")
+	if err != nil {
+		return "", fmt.Errorf("cannot read the architecture of cluster %q: %w", clusterName, err)
+	}
+	archs := strings.Fields(out)
+	if len(archs) == 0 {
+		return "", fmt.Errorf("cluster %q has no nodes", clusterName)
+	}
+	return "linux/" + archs[0], nil
+}
+
+// builderPlatforms reads the Platforms lines of docker buildx inspect:
+// the first platform of each node is the one it runs natively, the rest
+// it builds through emulation.
+func builderPlatforms(inspect string) (native, supported []string) {
+	for _, line := range strings.Split(inspect, "\n") {
+		list, ok := strings.CutPrefix(strings.TrimSpace(line), "Platforms:")
+		if !ok {
+			continue
+		}
+		for i, p := range strings.Split(list, ",") {
+			p = strings.TrimSuffix(strings.TrimSpace(p), "*")
+			if p == "" {
+				continue
+			}
+			if i == 0 {
+				native = append(native, p)
+			}
+			supported = append(supported, p)
+		}
+	}
+	return native, supported
+}
@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeffvincent/kindling/cli/internal/logging"
+	"github.com/jeffvincent/kindling/cli/pkg/kube"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// ── Diagnostics bundle ──────────────────────────────────────────
+//
+// kindling bundle gathers what a maintainer needs to look into a bug
+// report into one tarball, sanitized on the way in: env var values named
+// like credentials are dropped from the DevStagingEnvironments, and every
+// text file goes through logging.RedactText. Secrets themselves are never
+// read. Nothing is uploaded.
+
+const bundleLong = `Packs what a maintainer needs to look into a problem into one gzipped
+tarball to attach to a GitHub issue:
+
+  version.json          CLI version, OS, architecture
+  config.json           the resolved settings and where each came from
+  doctor.json           the doctor checks
+  tunnels.json          the tunnels kindling expose is running
+  logs/                 the debug logs, and the last build log of each service
+  daemons/              the background processes and their logs
+  cluster/              when the cluster is up: version, nodes, pods, recent
+                        events, DevStagingEnvironment specs and statuses, and
+                        the controller's logs
+
+The bundle is sanitized: Secrets aren't read, env var values whose names
+look like credentials (…PASSWORD, …TOKEN, …API_KEY) are replaced with ***,
+and tokens, passwords in URLs, and key=value credentials are masked in
+every file. Nothing is uploaded — look through the bundle, then attach it
+yourself.
+
+Examples:
+  kindling bundle
+  kindling bundle --out /tmp/kindling-bundle.tar.gz
+  kindling bundle -o json | jq -r .file`
+
+var bundleCmd = &cobra.Command{
+	Use:          "bundle",
+	Short:        "Collect logs, cluster state, and specs into a sanitized tarball for a bug report",
+	Long:         bundleLong,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runBundle,
+}
+
+// debugBundleCmd is kindling bundle under kindling debug.
+var debugBundleCmd = &cobra.Command{
+	Use:          "bundle",
+	Short:        "Same as kindling bundle",
+	Long:         bundleLong,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runBundle,
+}
+
+var bundleOut string
+
+func init() {
+	for _, c := range []*cobra.Command{bundleCmd, debugBundleCmd} {
+		c.Flags().StringVar(&bundleOut, "out", "", "Tarball to write (default: kindling-bundle-<timestamp>.tar.gz)")
+	}
+	debugCmd.AddCommand(debugBundleCmd)
+	rootCmd.AddCommand(bundleCmd)
+}
+
+const (
+	// bundleControllerLines is how much of the controller's log is kept.
+	bundleControllerLines = 2000
+	// bundleEventsSince is how far back the events go.
+	bundleEventsSince = time.Hour
+)
+
+// bundleKubectl is the cluster state a bundle holds besides the
+// DevStagingEnvironments: the output of each kubectl command, in its file.
+var bundleKubectl = []struct {
+	file string
+	args []string
+}{
+	{"cluster/version.txt", []string{"version"}},
+	{"cluster/nodes.txt", []string{"get", "nodes", "-o", "wide"}},
+	{"cluster/pods.txt", []string{"get", "pods", "-A", "-o", "wide"}},
+	{"cluster/kindling-system.txt", []string{"get", "all", "-n", "kindling-system"}},
+}
+
+// bundleResult is the JSON output of bundle.
+type bundleResult struct {
+	File  string   `json:"file"`
+	Files []string `json:"files"`
+}
+
+// bundle is a tarball being assembled in a temporary directory.
+type bundle struct {
+	dir   string
+	files []string
+}
+
+// add writes a file into the bundle, redacting it.
+func (b *bundle) add(name string, data []byte) {
+	path := filepath.Join(b.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	if os.WriteFile(path, []byte(logging.RedactText(string(data))), 0o600) == nil {
+		b.files = append(b.files, name)
+	}
+}
+
+// addJSON writes v into the bundle as indented JSON.
+func (b *bundle) addJSON(name string, v interface{}) {
+	if data, err := json.MarshalIndent(v, "", "  "); err == nil {
+		b.add(name, append(data, '\n'))
+	}
+}
+
+// addFile copies the file at path into the bundle, if it exists.
+func (b *bundle) addFile(name, path string) {
+	if data, err := os.ReadFile(path); err == nil {
+		b.add(name, data)
+	}
+}
+
+func runBundle(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	out := bundleOut
+	if out == "" {
+		out = "kindling-bundle-" + time.Now().Format("20060102-150405") + ".tar.gz"
+	}
+	dir, err := os.MkdirTemp("", "kindling-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	b := &bundle{dir: dir}
+
+	header("Collecting diagnostics")
+	step("📋", "CLI version, settings, and doctor checks")
+	b.addJSON("version.json", struct {
+		Version string `json:"version"`
+		OS      string `json:"os"`
+		Arch    string `json:"arch"`
+		Go      string `json:"go"`
+	}{Version, runtime.GOOS, runtime.GOARCH, runtime.Version()})
+	if project, user, err := loadConfigLayers(); err == nil {
+		var settings []configSource
+		for _, s := range configSettings {
+			settings = append(settings, resolveConfigSetting(cmd, s, project, user))
+		}
+		b.addJSON("config.json", settings)
+	}
+	b.addJSON("doctor.json", collectDoctorReport())
+
+	step("📜", "Logs, build logs, tunnels, and background processes")
+	logsDir := logging.Dir(cwd)
+	for _, path := range logging.Files(logsDir) {
+		b.addFile("logs/"+filepath.Base(path), path)
+	}
+	buildLogs, _ := filepath.Glob(filepath.Join(logsDir, buildLogsDir, "*.log"))
+	for _, path := range buildLogs {
+		b.addFile("logs/builds/"+filepath.Base(path), path)
+	}
+	if reports, err := collectTunnelReports(false); err == nil {
+		b.addJSON("tunnels.json", reports)
+	}
+	daemonFiles, _ := filepath.Glob(filepath.Join(cwd, ".kindling", "daemons", "*"))
+	for _, path := range daemonFiles {
+		b.addFile("daemons/"+filepath.Base(path), path)
+	}
+
+	if clusterExists(clusterName) {
+		step("☸️ ", fmt.Sprintf("State of %s", clusterLabel()))
+		for _, c := range bundleKubectl {
+			text, err := runSilent("kubectl", c.args...)
+			if err != nil {
+				text = fmt.Sprintf("kubectl %s failed: %v\n%s", strings.Join(c.args, " "), err, text)
+			}
+			b.add(c.file, []byte(text+"\n"))
+		}
+		b.add("cluster/events.txt", []byte(recentEvents(bundleEventsSince)))
+		b.add("cluster/devstagingenvironments.yaml", sanitizedDSEs())
+
+		var logs bytes.Buffer
+		opts := kube.LogOptions{Container: "manager", Tail: bundleControllerLines}
+		if err := controllerLogs(opts, &logs); err != nil {
+			fmt.Fprintf(&logs, "\nreading the controller logs failed: %v\n", err)
+		}
+		b.add("cluster/controller.log", logs.Bytes())
+	} else {
+		warn(fmt.Sprintf("%s is not running — collecting local state only", clusterLabel()))
+	}
+
+	if err := writeTarball(out, dir); err != nil {
+		return err
+	}
+	return render(bundleResult{File: out, Files: b.files}, func() {
+		success(fmt.Sprintf("Wrote %s (%d files)", out, len(b.files)))
+		fmt.Printf("  %sNothing was uploaded. Look through it, then attach it to the issue.%s\n\n", colorDim, colorReset)
+	})
+}
+
+// recentEvents returns the cluster's events of the last since, oldest
+// first, one per line.
+func recentEvents(since time.Duration) string {
+	out, err := kubectlJSON("get", "events", "-A", "-o", "json")
+	if err != nil {
+		return fmt.Sprintf("kubectl get events failed: %v\n", err)
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+			Type           string    `json:"type"`
+			Reason         string    `json:"reason"`
+			Message        string    `json:"message"`
+			LastTimestamp  time.Time `json:"lastTimestamp"`
+			EventTime      time.Time `json:"eventTime"`
+			InvolvedObject struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"involvedObject"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return fmt.Sprintf("cannot parse the events: %v\n", err)
+	}
+	cutoff := time.Now().Add(-since)
+	var lines []string
+	for _, e := range list.Items {
+		at := e.LastTimestamp
+		if at.IsZero() {
+			at = e.EventTime
+		}
+		if at.Before(cutoff) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s  %-7s %s  %s/%s  %s: %s",
+			at.UTC().Format(time.RFC3339), e.Type, e.Metadata.Namespace,
+			e.InvolvedObject.Kind, e.InvolvedObject.Name, e.Reason, e.Message))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// sanitizedDSEs returns every DevStagingEnvironment — spec and status —
+// as YAML, without server-side bookkeeping and with the values of env vars
+// named like credentials replaced.
+func sanitizedDSEs() []byte {
+	out, err := kubectlJSON("get", "devstagingenvironments", "-A", "-o", "json")
+	if err != nil {
+		return []byte(fmt.Sprintf("kubectl get devstagingenvironments failed: %v\n", err))
+	}
+	var list struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return []byte(fmt.Sprintf("cannot parse the DevStagingEnvironments: %v\n", err))
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, dse := range list.Items {
+		if meta, ok := dse["metadata"].(map[string]interface{}); ok {
+			delete(meta, "managedFields")
+			if annotations, ok := meta["annotations"].(map[string]interface{}); ok {
+				// It repeats the spec, unsanitized.
+				delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+			}
+		}
+		logging.RedactEnvValues(dse["spec"])
+		_ = enc.Encode(dse)
+	}
+	_ = enc.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/build"
+	"github.com/jeffvincent/kindling/cli/pkg/generate"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and prune the BuildKit cache kindling builds with, and clear the generate cache",
+	Long: `kindling build and kindling dev build images with a dedicated BuildKit
+builder, "kindling": a buildkitd container on the host's Docker, created
+on first use, whose layer cache lives in a Docker volume. Neither belongs
+to the Kind cluster, so after kindling destroy and kindling init the
+first builds reuse every unchanged layer instead of starting from scratch.
+
+The cache grows with every build. stats shows how big it is and how much
+of it prune can reclaim; prune deletes what no build needs right now.
+
+Without docker buildx, builds fall back to the plain docker build cache.
+
+kindling generate caches the workflows the AI writes, by the content of
+the repo and the prompt; clear generate deletes them.
+
+Examples:
+  kindling cache stats
+  kindling cache prune
+  kindling cache prune --older-than 72h
+  kindling cache prune --keep 5GB
+  kindling cache prune --all
+  kindling cache clear generate`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:          "stats",
+	Short:        "Show the size of the build cache",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runCacheStats,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:          "prune",
+	Short:        "Delete build cache that no build is using",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runCachePrune,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:          "clear generate",
+	Short:        "Delete the workflows kindling generate cached",
+	Args:         cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs:    []string{"generate"},
+	SilenceUsage: true,
+	RunE:         runCacheClear,
+}
+
+var (
+	cachePruneAll       bool
+	cachePruneOlderThan time.Duration
+	cachePruneKeep      string
+)
+
+func init() {
+	cachePruneCmd.Flags().BoolVar(&cachePruneAll, "all", false, "Also delete cached base images and layers that builds may reuse")
+	cachePruneCmd.Flags().DurationVar(&cachePruneOlderThan, "older-than", 0, "Only delete cache entries not used for this long (e.g. 72h)")
+	cachePruneCmd.Flags().StringVar(&cachePruneKeep, "keep", "", "Keep up to this much cache (e.g. 5GB)")
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// buildCacheBuilder is the buildx builder kindling builds with. The
+// docker-container driver runs it as buildx_buildkit_kindling0, keeping
+// its state in the buildx_buildkit_kindling0_state volume.
+const buildCacheBuilder = "kindling"
+
+// buildCacheVolume is the Docker volume holding the builder's cache.
+const buildCacheVolume = "buildx_buildkit_" + buildCacheBuilder + "0_state"
+
+var (
+	buildCacheOnce  sync.Once
+	buildCacheReady bool
+)
+
+// ensureBuildCache creates the kindling builder when it doesn't exist and
+// reports whether builds can use it. It runs once per command; builds
+// running in parallel share the answer.
+func ensureBuildCache() bool {
+	buildCacheOnce.Do(func() {
+		if _, err := runSilent("docker", "buildx", "version"); err != nil {
+			warn("docker buildx is not installed — building with the plain docker build cache")
+			return
+		}
+		if _, err := runSilent("docker", "buildx", "inspect", buildCacheBuilder); err != nil {
+			step("🗄️ ", fmt.Sprintf("Creating the %q BuildKit builder — its cache outlives the cluster", buildCacheBuilder))
+			args := []string{"buildx", "create", "--name", buildCacheBuilder, "--driver", "docker-container", "--bootstrap"}
+			// The builder container pulls base images itself.
+			for _, env := range proxyEnv() {
+				args = append(args, "--driver-opt", "env."+env)
+			}
+			if out, err := runSilent("docker", args...); err != nil {
+				warn(fmt.Sprintf("cannot create the BuildKit builder, building with docker build: %s", lastLines(out, 3)))
+				return
+			}
+		}
+		buildCacheReady = true
+	})
+	return buildCacheReady
+}
+
+// imageBuildArgs returns the docker arguments that build svc as image:
+// through the remote builder when one is selected, otherwise through the
+// kindling builder, loading the result into the local image store for
+// shipImage, or with plain docker build when neither is available. extra
+// flags go before the tag.
+func imageBuildArgs(svc *devService, image string, extra ...string) []string {
+	opts := build.Options{Context: svc.context, Dockerfile: svc.dockerfile, Image: image, Flags: extra}
+	switch {
+	case remoteBuilder != "":
+		opts.Builder, opts.Platform = remoteBuilder, remotePlatform
+	case ensureBuildCache():
+		opts.Builder = buildCacheBuilder
+	}
+	// RUN steps that download (npm install, go mod download) see the proxy
+	// through Docker's predefined build args, which stay out of the image.
+	for _, env := range proxyEnv() {
+		name, _, _ := strings.Cut(env, "=")
+		opts.BuildArgs = append(opts.BuildArgs, name)
+	}
+	return build.Args(opts)
+}
+
+// cacheStats is the report printed by kindling cache stats.
+type cacheStats struct {
+	Builder     string `json:"builder"`
+	Exists      bool   `json:"exists"`
+	Status      string `json:"status,omitempty"`
+	Volume      string `json:"volume,omitempty"`
+	Entries     int    `json:"entries"`
+	Total       string `json:"total,omitempty"`
+	Reclaimable string `json:"reclaimable,omitempty"`
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	st := cacheStats{Builder: buildCacheBuilder}
+	if out, err := runSilent("docker", "buildx", "inspect", buildCacheBuilder); err == nil {
+		st.Exists = true
+		st.Volume = buildCacheVolume
+		for _, line := range strings.Split(out, "\n") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(line), "Status:"); ok {
+				st.Status = strings.TrimSpace(v)
+			}
+		}
+		if out, err := runSilent("docker", "buildx", "du", "--builder", buildCacheBuilder); err == nil {
+			st.Entries, st.Reclaimable, st.Total = parseBuildxDu(out)
+		}
+		if st.Total == "" {
+			st.Total, st.Reclaimable = "0B", "0B"
+		}
+	}
+
+	return render(st, func() {
+		header("Build cache")
+		if !st.Exists {
+			fmt.Printf("  %sNo %q builder yet — kindling build or kindling dev creates it%s\n\n", colorDim, buildCacheBuilder, colorReset)
+			return
+		}
+		fmt.Printf("  %-14s %s (%s)\n", "Builder:", st.Builder, st.Status)
+		fmt.Printf("  %-14s %s\n", "Volume:", st.Volume)
+		fmt.Printf("  %-14s %d\n", "Entries:", st.Entries)
+		fmt.Printf("  %-14s %s\n", "Size:", st.Total)
+		fmt.Printf("  %-14s %s\n", "Reclaimable:", st.Reclaimable)
+		fmt.Printf("\n  %sFree space with: kindling cache prune%s\n\n", colorDim, colorReset)
+	})
+}
+
+// parseBuildxDu reads docker buildx du output: a table of cache records
+// followed by "Reclaimable:" and "Total:" summary lines.
+func parseBuildxDu(out string) (entries int, reclaimable, total string) {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0 || fields[0] == "ID":
+		case fields[0] == "Reclaimable:" && len(fields) > 1:
+			reclaimable = fields[1]
+		case fields[0] == "Total:" && len(fields) > 1:
+			total = fields[1]
+		case strings.HasSuffix(fields[0], ":"):
+			// Shared:, Private:, and other summary lines
+		default:
+			entries++
+		}
+	}
+	return entries, reclaimable, total
+}
+
+// cachePruneResult is the JSON form of cache prune's output.
+type cachePruneResult struct {
+	Builder   string `json:"builder"`
+	Reclaimed string `json:"reclaimed"`
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	if _, err := runSilent("docker", "buildx", "inspect", buildCacheBuilder); err != nil {
+		return fmt.Errorf("there is no %q builder to prune — kindling build creates it", buildCacheBuilder)
+	}
+	pruneArgs := []string{"buildx", "prune", "--builder", buildCacheBuilder, "--force"}
+	if cachePruneAll {
+		pruneArgs = append(pruneArgs, "--all")
+	}
+	if cachePruneOlderThan > 0 {
+		pruneArgs = append(pruneArgs, "--filter", "until="+cachePruneOlderThan.String())
+	}
+	if cachePruneKeep != "" {
+		pruneArgs = append(pruneArgs, "--keep-storage", cachePruneKeep)
+	}
+
+	sp := startSpinner("Pruning the build cache")
+	out, err := runSilent("docker", pruneArgs...)
+	sp.stop()
+	if err != nil {
+		return fmt.Errorf("docker buildx prune failed: %s", lastLines(out, 5))
+	}
+	result := cachePruneResult{Builder: buildCacheBuilder, Reclaimed: "0B"}
+	for _, line := range strings.Split(out, "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "Total:"); ok {
+			result.Reclaimed = strings.TrimSpace(v)
+		}
+	}
+	return render(result, func() {
+		success(fmt.Sprintf("Reclaimed %s from the build cache", result.Reclaimed))
+	})
+}
+
+// cacheClearResult is the report printed by kindling cache clear.
+type cacheClearResult struct {
+	Cache   string `json:"cache"`
+	Removed int    `json:"removed"`
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	n, err := generate.ClearCache()
+	if err != nil {
+		return fmt.Errorf("cannot clear the generate cache: %w", err)
+	}
+	result := cacheClearResult{Cache: args[0], Removed: n}
+	return render(result, func() {
+		success(fmt.Sprintf("Removed %d cached workflow(s)", n))
+	})
+}
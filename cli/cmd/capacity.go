@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+// ── Cluster capacity ────────────────────────────────────────────
+//
+// validate and deploy compare the CPU and memory a manifest requests with
+// what the Kind cluster can still schedule; devstaging.CheckCapacity does
+// the sums, this reads the cluster.
+
+// readClusterCapacity reads the nodes and pods of the Kind cluster. It
+// returns false when the cluster doesn't exist or can't be read, so the
+// check is skipped rather than failed.
+func readClusterCapacity() (*devstaging.Capacity, bool) {
+	if !clusterExists(clusterName) {
+		return nil, false
+	}
+	out, err := kubectlJSON("get", "nodes", "-o", "json")
+	if err != nil {
+		return nil, false
+	}
+	var nodes struct {
+		Items []struct {
+			Metadata struct {
+				Labels map[string]string `json:"labels"`
+			} `json:"metadata"`
+			Spec struct {
+				Unschedulable bool `json:"unschedulable"`
+				Taints        []struct {
+					Effect string `json:"effect"`
+				} `json:"taints"`
+			} `json:"spec"`
+			Status struct {
+				Allocatable map[string]string `json:"allocatable"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if json.Unmarshal([]byte(out), &nodes) != nil {
+		return nil, false
+	}
+	capacity := &devstaging.Capacity{Cluster: clusterName}
+	for _, n := range nodes.Items {
+		schedulable := !n.Spec.Unschedulable
+		for _, t := range n.Spec.Taints {
+			if t.Effect == "NoSchedule" {
+				schedulable = false
+			}
+		}
+		if !schedulable {
+			continue
+		}
+		cpu, _ := devstaging.ParseQuantity(n.Status.Allocatable["cpu"])
+		mem, _ := devstaging.ParseQuantity(n.Status.Allocatable["memory"])
+		capacity.Nodes++
+		capacity.CPUMilli += int64(cpu * 1000)
+		capacity.MemBytes += int64(mem)
+		capacity.LargestCPU = max(capacity.LargestCPU, int64(cpu*1000))
+		capacity.LargestMem = max(capacity.LargestMem, int64(mem))
+		if _, ok := n.Metadata.Labels["node-role.kubernetes.io/control-plane"]; ok {
+			capacity.ControlPlaneSchedulable = true
+		}
+	}
+	if capacity.Nodes == 0 {
+		return nil, false
+	}
+
+	if out, err := kubectlJSON("get", "pods", "-A", "-o", "json"); err == nil {
+		var pods struct {
+			Items []struct {
+				Metadata struct {
+					Labels map[string]string `json:"labels"`
+				} `json:"metadata"`
+				Spec struct {
+					Containers []struct {
+						Resources struct {
+							Requests map[string]string `json:"requests"`
+							Limits   map[string]string `json:"limits"`
+						} `json:"resources"`
+					} `json:"containers"`
+				} `json:"spec"`
+				Status struct {
+					Phase string `json:"phase"`
+				} `json:"status"`
+			} `json:"items"`
+		}
+		if json.Unmarshal([]byte(out), &pods) == nil {
+			for _, p := range pods.Items {
+				// Environments are counted from the manifest instead, so
+				// redeploying one isn't counted twice.
+				if p.Metadata.Labels["app.kubernetes.io/managed-by"] == "devstagingenvironment-operator" ||
+					p.Status.Phase == "Succeeded" || p.Status.Phase == "Failed" {
+					continue
+				}
+				for _, c := range p.Spec.Containers {
+					r := devstaging.ContainerRequest(c.Resources.Requests["cpu"], c.Resources.Limits["cpu"],
+						c.Resources.Requests["memory"], c.Resources.Limits["memory"])
+					capacity.ReservedCPU += r.CPUMilli
+					capacity.ReservedMem += r.MemBytes
+				}
+			}
+		}
+	}
+	return capacity, true
+}
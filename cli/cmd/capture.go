@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+var captureCmd = &cobra.Command{
+	Use:   "capture <environment>",
+	Short: "Record the HTTP traffic to an environment's ingress",
+	Long: `Runs a recording proxy in front of a DevStagingEnvironment's ingress:
+point a browser, test suite, or another service at the local address it
+prints, and every request and response passing through is appended to
+.kindling/captures/<environment>-<time>.jsonl until Ctrl+C.
+
+kindling replay sends a capture again — typically at the same
+environment after a rebuild — and reports the responses that changed.
+
+Captures hold whatever the traffic holds, credentials and cookies
+included; .kindling/ is added to .gitignore. Bodies beyond --max-body are
+cut short, and such requests are skipped by replay.
+
+Examples:
+  kindling capture orders-dev
+  kindling capture orders-dev --port 9000
+  kindling capture orders-dev --url https://orders.localtest.me`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeDSEs),
+	RunE:              runTrafficCapture,
+}
+
+var (
+	capturePort    int
+	captureURL     string
+	captureMaxBody int64
+)
+
+func init() {
+	captureCmd.Flags().IntVar(&capturePort, "port", 8888, "Local port to listen on (the next free one if taken)")
+	captureCmd.Flags().StringVar(&captureURL, "url", "", "Forward to this URL instead of the environment's ingress")
+	captureCmd.Flags().Int64Var(&captureMaxBody, "max-body", 1<<20, "Most bytes of each request and response body to record")
+	rootCmd.AddCommand(captureCmd)
+}
+
+const captureDir = "captures"
+
+// capturedExchange is one request and its response, a line of a capture.
+// Bodies that aren't UTF-8 are stored base64-encoded.
+type capturedExchange struct {
+	Time              time.Time   `json:"time"`
+	Environment       string      `json:"environment"`
+	Method            string      `json:"method"`
+	Path              string      `json:"path"` // with the query string, below the upstream URL
+	RequestHeader     http.Header `json:"requestHeader,omitempty"`
+	RequestBody       string      `json:"requestBody,omitempty"`
+	RequestBodyBase64 bool        `json:"requestBodyBase64,omitempty"`
+	Status            int         `json:"status"`
+	ResponseHeader    http.Header `json:"responseHeader,omitempty"`
+	Body              string      `json:"body,omitempty"`
+	BodyBase64        bool        `json:"bodyBase64,omitempty"`
+	Truncated         bool        `json:"truncated,omitempty"`
+	Millis            int64       `json:"millis"`
+}
+
+// capturesPath returns .kindling/captures under the working directory.
+func capturesPath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine working directory: %w", err)
+	}
+	return filepath.Join(cwd, ".kindling", captureDir), nil
+}
+
+// environmentURL returns the ingress URL the operator reports for a DSE.
+func environmentURL(name string) (string, error) {
+	for _, env := range collectEnvironments() {
+		if env.Name != name {
+			continue
+		}
+		if env.URL == "" {
+			return "", fmt.Errorf("%s has no ingress — pass --url", name)
+		}
+		return env.URL, nil
+	}
+	return "", fmt.Errorf("no DevStagingEnvironment named %s — see: kindling status", name)
+}
+
+// encodeBody returns body as a string, base64-encoded unless it is UTF-8.
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+// decodeBody reverses encodeBody.
+func decodeBody(s string, b64 bool) ([]byte, error) {
+	if b64 {
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return []byte(s), nil
+}
+
+// capBody returns at most limit bytes of body, and whether it cut any.
+func capBody(body []byte, limit int64) ([]byte, bool) {
+	if int64(len(body)) > limit {
+		return body[:limit], true
+	}
+	return body, false
+}
+
+func runTrafficCapture(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	target := captureURL
+	if target == "" {
+		if !clusterExists(clusterName) {
+			return errNoCluster()
+		}
+		var err error
+		if target, err = environmentURL(name); err != nil {
+			return err
+		}
+	}
+	upstream, err := url.Parse(target)
+	if err != nil || upstream.Host == "" {
+		return fmt.Errorf("invalid upstream URL %q", target)
+	}
+
+	dir, err := capturesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	ensureTunnelGitignored(filepath.Dir(filepath.Dir(dir)))
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.jsonl", name, time.Now().Format("20060102-150405")))
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rec := &captureRecorder{environment: name, w: bufio.NewWriter(file)}
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(upstream)
+			r.Out.Host = upstream.Host
+			// Left to the transport, compression is undone before the
+			// response is recorded, so captures hold readable bodies.
+			r.Out.Header.Del("Accept-Encoding")
+		},
+		ModifyResponse: rec.record,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			warn(fmt.Sprintf("%s %s: %v", r.Method, r.URL.RequestURI(), err))
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Keep the request body for the record; the upstream gets it whole.
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		ctx := context.WithValue(r.Context(), captureRequestKey{}, captureRequest{start: time.Now(), path: r.URL.RequestURI(), body: data})
+		proxy.ServeHTTP(w, r.WithContext(ctx))
+	})
+
+	port := freeLocalPort(capturePort, nil)
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	header(fmt.Sprintf("Capturing %s", name))
+	success(fmt.Sprintf("Proxy at http://localhost:%d → %s", port, upstream))
+	fmt.Printf("  Recording to %s — Ctrl+C to stop\n\n", dimText(path))
+
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(listener) }()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	select {
+	case <-sig:
+		_ = server.Close()
+	case err := <-done:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	}
+
+	n, err := rec.close()
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	success(fmt.Sprintf("Captured %d request(s) in %s", n, path))
+	if n > 0 {
+		fmt.Printf("  Replay them: %skindling replay %s%s\n\n", colorCyan, strings.TrimSuffix(filepath.Base(path), ".jsonl"), colorReset)
+	}
+	return nil
+}
+
+// captureRequest carries what the proxy saw of a request through to its
+// response: when it arrived, its path before the upstream's was prefixed,
+// and its body.
+type captureRequest struct {
+	start time.Time
+	path  string
+	body  []byte
+}
+
+type captureRequestKey struct{}
+
+// captureRecorder appends exchanges to a capture as responses arrive.
+type captureRecorder struct {
+	environment string
+	mu          sync.Mutex
+	w           *bufio.Writer
+	n           int
+}
+
+func (c *captureRecorder) record(resp *http.Response) error {
+	req := resp.Request
+	sent, _ := req.Context().Value(captureRequestKey{}).(captureRequest)
+	start := sent.start
+
+	// Read one byte past --max-body to learn whether the body is longer,
+	// then hand the client everything: what was read and what wasn't.
+	read, err := io.ReadAll(io.LimitReader(resp.Body, captureMaxBody+1))
+	if err != nil {
+		return err
+	}
+	rest := resp.Body
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(read), rest), rest}
+	body, bodyCut := capBody(read, captureMaxBody)
+	reqBody, reqCut := capBody(sent.body, captureMaxBody)
+
+	ex := capturedExchange{
+		Time:           start,
+		Environment:    c.environment,
+		Method:         req.Method,
+		Path:           sent.path,
+		RequestHeader:  req.Header.Clone(),
+		Status:         resp.StatusCode,
+		ResponseHeader: resp.Header.Clone(),
+		Truncated:      bodyCut || reqCut,
+		Millis:         time.Since(start).Milliseconds(),
+	}
+	ex.RequestBody, ex.RequestBodyBase64 = encodeBody(reqBody)
+	ex.Body, ex.BodyBase64 = encodeBody(body)
+
+	line, err := json.Marshal(ex)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n++
+	fmt.Printf("  %s %-6s %s %s\n", statusColor(resp.StatusCode), req.Method, ex.Path, dimText(fmt.Sprintf("%dms", ex.Millis)))
+	if _, err := c.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+func (c *captureRecorder) close() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n, c.w.Flush()
+}
+
+// statusColor renders an HTTP status in green, yellow, or red.
+func statusColor(status int) string {
+	switch {
+	case status >= 500:
+		return fmt.Sprintf("%s%d%s", colorRed, status, colorReset)
+	case status >= 400:
+		return fmt.Sprintf("%s%d%s", colorYellow, status, colorReset)
+	}
+	return fmt.Sprintf("%s%d%s", colorGreen, status, colorReset)
+}
+
+// captureFile resolves a capture name or path to a .jsonl path.
+func captureFile(arg string) (string, error) {
+	if strings.HasSuffix(arg, ".jsonl") || strings.ContainsRune(arg, os.PathSeparator) {
+		return arg, nil
+	}
+	dir, err := capturesPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, arg+".jsonl"), nil
+}
+
+// listCaptures returns the capture names in .kindling/captures, newest
+// first.
+func listCaptures() []string {
+	dir, err := capturesPath()
+	if err != nil {
+		return nil
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	var names []string
+	for _, f := range files {
+		names = append(names, strings.TrimSuffix(filepath.Base(f), ".jsonl"))
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names
+}
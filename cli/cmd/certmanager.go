@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ── cert-manager ────────────────────────────────────────────────
+//
+// kindling init installs cert-manager into every cluster: it issues the
+// serving certificate of the operator's conversion webhook, and the
+// certificates of kindling init --tls.
+
+const certManagerManifestURL = "https://github.com/cert-manager/cert-manager/releases/download/v1.16.2/cert-manager.yaml"
+
+// installCertManager applies the cert-manager release and waits for its
+// deployments. Re-applying an installed release is a no-op.
+func installCertManager() error {
+	step("📦", "Installing cert-manager")
+	if err := run("kubectl", "apply", "-f", manifestSource(certManagerManifestURL)); err != nil {
+		return fmt.Errorf("cert-manager install failed: %w", err)
+	}
+	if err := run("kubectl", "wait", "--for=condition=Available", "deployment", "--all",
+		"-n", "cert-manager", "--timeout=180s"); err != nil {
+		return fmt.Errorf("cert-manager did not become ready: %w", err)
+	}
+	success("cert-manager ready")
+	return nil
+}
+
+// applyWhenWebhookReady applies a cert-manager resource, retrying while
+// cert-manager's webhook finishes starting — its Deployment reports
+// Available before the webhook serves.
+func applyWhenWebhookReady(manifest string) error {
+	var err error
+	for i := 0; i < 20; i++ {
+		var out string
+		if out, err = runSilentStdin(manifest, "kubectl", "apply", "-f", "-"); err == nil {
+			return nil
+		} else if !strings.Contains(out, "webhook") {
+			return fmt.Errorf("%s", out)
+		}
+		time.Sleep(3 * time.Second)
+	}
+	return err
+}
@@ -0,0 +1,471 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var chaosCmd = &cobra.Command{
+	Use:   "chaos",
+	Short: "Inject failures into running components",
+	Long: `Breaks running components on purpose, to see how the rest of the
+environment copes: kill pods, slow down or drop a component's traffic,
+or cut two components off from each other. kindling chaos heal undoes
+latency and partitions; killed pods are replaced by their workload.
+
+Components are named the same way as in kindling logs: an environment
+name (its app), a dependency type such as postgres, or a full name such as
+orders-dev-postgres.`,
+}
+
+var chaosKillCmd = &cobra.Command{
+	Use:   "kill <component>",
+	Short: "Delete one of a component's pods, or all of them",
+	Long: `Deletes a random running pod of the component — every one with --all —
+as a crash or node loss would. Its Deployment or StatefulSet starts a
+replacement; watch it with kindling status.
+
+Examples:
+  kindling chaos kill orders-dev
+  kindling chaos kill postgres --env orders-dev --all`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeComponents),
+	RunE:              runChaosKill,
+}
+
+var chaosLatencyCmd = &cobra.Command{
+	Use:   "latency <component>",
+	Short: "Delay or drop the traffic a component's pods send",
+	Long: `Adds a netem queue to the network interface of each running pod of the
+component: every packet it sends — responses to callers as well as its
+own calls — is held back --ms milliseconds (give or take --jitter), and
+--loss drops a share of them.
+
+The queue is set up by a short-lived ephemeral container with the
+NET_ADMIN capability (kubectl debug --profile=netadmin), so the app's
+image needs no tools. It removes itself after --duration; --duration 0
+keeps it until kindling chaos heal. Pods started later, such as
+replacements for crashed ones, aren't affected.
+
+Examples:
+  kindling chaos latency orders-dev --ms 300
+  kindling chaos latency postgres --env orders-dev --ms 100 --jitter 50
+  kindling chaos latency orders-dev --loss 10 --duration 10m`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeComponents),
+	RunE:              runChaosLatency,
+}
+
+var chaosPartitionCmd = &cobra.Command{
+	Use:   "partition <a> <b>",
+	Short: "Cut two components of a namespace off from each other",
+	Long: `Writes a NetworkPolicy for each of the two components that admits
+traffic from everything but the other, so neither can open a connection
+to the other while both stay reachable from the rest. The partition lasts
+until kindling chaos heal.
+
+NetworkPolicies only add up: one that already admits the other
+component, such as that of networkPolicies: strict for a declared
+dependsOn edge, keeps doing so. They take effect only with a CNI that
+enforces them, such as Calico (kindling init --profile full).
+
+Examples:
+  kindling chaos partition orders-dev inventory-dev
+  kindling chaos partition orders-dev postgres --env orders-dev`,
+	Args:              cobra.ExactArgs(2),
+	SilenceUsage:      true,
+	ValidArgsFunction: completeComponents,
+	RunE:              runChaosPartition,
+}
+
+var chaosHealCmd = &cobra.Command{
+	Use:   "heal [component]",
+	Short: "Remove the latency and partitions kindling chaos added",
+	Long: `Removes the netem queues of kindling chaos latency and the
+NetworkPolicies of kindling chaos partition — those of one component, or
+all of them in the cluster.
+
+Examples:
+  kindling chaos heal
+  kindling chaos heal orders-dev`,
+	Args:              cobra.MaximumNArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeComponents),
+	RunE:              runChaosHeal,
+}
+
+var (
+	chaosEnv      string
+	chaosKillAll  bool
+	chaosMs       int
+	chaosJitter   int
+	chaosLoss     float64
+	chaosDuration time.Duration
+)
+
+func init() {
+	chaosCmd.PersistentFlags().StringVar(&chaosEnv, "env", "", "DevStagingEnvironment to resolve the components in")
+	_ = chaosCmd.RegisterFlagCompletionFunc("env", completeEnvFlag)
+	chaosKillCmd.Flags().BoolVar(&chaosKillAll, "all", false, "Delete every pod of the component")
+	chaosLatencyCmd.Flags().IntVar(&chaosMs, "ms", 0, "Delay every packet by this many milliseconds")
+	chaosLatencyCmd.Flags().IntVar(&chaosJitter, "jitter", 0, "Vary the delay by up to this many milliseconds")
+	chaosLatencyCmd.Flags().Float64Var(&chaosLoss, "loss", 0, "Drop this percentage of packets")
+	chaosLatencyCmd.Flags().DurationVar(&chaosDuration, "duration", 5*time.Minute, "Remove the latency after this long (0 keeps it until kindling chaos heal)")
+	chaosCmd.AddCommand(chaosKillCmd, chaosLatencyCmd, chaosPartitionCmd, chaosHealCmd)
+	rootCmd.AddCommand(chaosCmd)
+}
+
+const (
+	// chaosNetImage provides tc for the netem ephemeral containers.
+	chaosNetImage = "nicolaka/netshoot:v0.13"
+	// chaosLatencyAnnotation marks the pods kindling chaos latency slowed
+	// down, so heal can find them.
+	chaosLatencyAnnotation = "kindling.dev/chaos-latency"
+	// chaosLabel marks the partition NetworkPolicies; chaosComponentLabel
+	// and chaosPeerLabel name the component a policy guards and the one
+	// it shuts out.
+	chaosLabel          = "kindling.dev/chaos"
+	chaosComponentLabel = "kindling.dev/chaos-component"
+	chaosPeerLabel      = "kindling.dev/chaos-peer"
+)
+
+// chaosComponent resolves arg to exactly one component.
+func chaosComponent(arg string) (componentRef, error) {
+	comps, err := resolveComponents(collectEnvironments(), arg, chaosEnv)
+	if err != nil {
+		return componentRef{}, err
+	}
+	if len(comps) > 1 {
+		var names []string
+		for _, c := range comps {
+			names = append(names, c.name)
+		}
+		return componentRef{}, fmt.Errorf("%q matches %s — pass the full name", arg, strings.Join(names, ", "))
+	}
+	return comps[0], nil
+}
+
+// runningPods returns every Running pod of a component, sorted.
+func runningPods(c componentRef) ([]string, error) {
+	out, err := kubectlJSON("get", "pods", "-n", c.namespace,
+		"-l", "app.kubernetes.io/name="+c.name,
+		"--field-selector=status.phase=Running",
+		"-o", "jsonpath={range .items[*]}{.metadata.name}{\"\\n\"}{end}")
+	if err != nil {
+		return nil, fmt.Errorf("cannot list pods of %s: %w", c.name, err)
+	}
+	pods := strings.Fields(out)
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("%s has no running pod — see: kindling status", c.name)
+	}
+	sort.Strings(pods)
+	return pods, nil
+}
+
+// chaosKillResult is the JSON form of chaos kill's output.
+type chaosKillResult struct {
+	Component   string   `json:"component"`
+	Namespace   string   `json:"namespace"`
+	PodsDeleted []string `json:"podsDeleted"`
+}
+
+func runChaosKill(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	target, err := chaosComponent(args[0])
+	if err != nil {
+		return err
+	}
+	pods, err := runningPods(target)
+	if err != nil {
+		return err
+	}
+	if !chaosKillAll {
+		pods = []string{pods[rand.IntN(len(pods))]}
+	}
+
+	header(fmt.Sprintf("Killing %s", target.name))
+	result := chaosKillResult{Component: target.name, Namespace: target.namespace, PodsDeleted: []string{}}
+	for _, pod := range pods {
+		if out, err := captureKubectl("delete", "pod", pod, "-n", target.namespace, "--wait=false"); err != nil {
+			return fmt.Errorf("cannot delete pod %s: %s", pod, out)
+		}
+		success(fmt.Sprintf("Deleted pod %s", pod))
+		result.PodsDeleted = append(result.PodsDeleted, pod)
+	}
+	return render(result, func() {
+		step("💡", fmt.Sprintf("Watch it recover: %skindling status%s", colorCyan, colorReset))
+	})
+}
+
+// netemArgs renders the netem options of the latency flags.
+func netemArgs() string {
+	var opts []string
+	if chaosMs > 0 {
+		delay := fmt.Sprintf("delay %dms", chaosMs)
+		if chaosJitter > 0 {
+			delay += fmt.Sprintf(" %dms", chaosJitter)
+		}
+		opts = append(opts, delay)
+	}
+	if chaosLoss > 0 {
+		opts = append(opts, fmt.Sprintf("loss %g%%", chaosLoss))
+	}
+	return strings.Join(opts, " ")
+}
+
+// chaosLatencyResult is the JSON form of chaos latency's output. Until is
+// unset when the latency lasts until kindling chaos heal.
+type chaosLatencyResult struct {
+	Component string     `json:"component"`
+	Namespace string     `json:"namespace"`
+	Netem     string     `json:"netem"`
+	Until     *time.Time `json:"until,omitempty"`
+	Pods      []string   `json:"pods"`
+}
+
+func runChaosLatency(cmd *cobra.Command, args []string) error {
+	switch {
+	case chaosMs <= 0 && chaosLoss <= 0:
+		return fmt.Errorf("pass --ms, --loss, or both")
+	case chaosJitter < 0 || chaosJitter > 0 && chaosMs <= 0:
+		return fmt.Errorf("--jitter needs a positive --ms")
+	case chaosLoss < 0 || chaosLoss > 100:
+		return fmt.Errorf("--loss must be a percentage, got %g", chaosLoss)
+	}
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	target, err := chaosComponent(args[0])
+	if err != nil {
+		return err
+	}
+	pods, err := runningPods(target)
+	if err != nil {
+		return err
+	}
+
+	netem := netemArgs()
+	result := chaosLatencyResult{Component: target.name, Namespace: target.namespace, Netem: netem, Pods: []string{}}
+	script := "tc qdisc replace dev eth0 root netem " + netem
+	note := netem
+	if chaosDuration > 0 {
+		until := time.Now().Add(chaosDuration)
+		result.Until = &until
+		script += fmt.Sprintf(" && sleep %d; tc qdisc del dev eth0 root", int(chaosDuration.Seconds()))
+		note += " until " + until.Format("15:04:05")
+	}
+	header(fmt.Sprintf("Adding %s to %s", netem, target.name))
+	for _, pod := range pods {
+		if err := chaosNetem(target.namespace, pod, script); err != nil {
+			return err
+		}
+		_, _ = captureKubectl("annotate", "pod", pod, "-n", target.namespace, "--overwrite", chaosLatencyAnnotation+"="+note)
+		success(fmt.Sprintf("%s: %s", pod, note))
+		result.Pods = append(result.Pods, pod)
+	}
+	return render(result, func() {
+		step("💡", fmt.Sprintf("Remove it early: %skindling chaos heal %s%s", colorCyan, target.name, colorReset))
+	})
+}
+
+// chaosNetem runs script in an ephemeral container with NET_ADMIN in pod,
+// where it shares the pod's network namespace.
+func chaosNetem(namespace, pod, script string) error {
+	container := fmt.Sprintf("kindling-chaos-%d", time.Now().UnixNano()%1_000_000)
+	if out, err := captureKubectl("debug", "pod/"+pod, "-n", namespace, "--quiet",
+		"--image", chaosNetImage, "--profile=netadmin", "--container", container,
+		"--", "sh", "-c", script); err != nil {
+		return fmt.Errorf("cannot add an ephemeral container to %s: %s", pod, out)
+	}
+	return nil
+}
+
+// chaosPartitionName names the NetworkPolicy that shuts peer out of
+// component.
+func chaosPartitionName(component, peer string) string {
+	return "kindling-partition-" + component + "-" + peer
+}
+
+// chaosPartitionPolicy returns the NetworkPolicy that shuts peer out of
+// component: it admits every pod of the namespace but peer's, and every
+// other namespace.
+func chaosPartitionPolicy(namespace, component, peer string) string {
+	return fmt.Sprintf(`apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: %[7]s
+  namespace: %[1]s
+  labels:
+    %[4]s: partition
+    %[5]s: %[2]s
+    %[6]s: %[3]s
+spec:
+  podSelector:
+    matchLabels:
+      app.kubernetes.io/name: %[2]s
+  policyTypes: [Ingress]
+  ingress:
+    - from:
+        - podSelector:
+            matchExpressions:
+              - {key: app.kubernetes.io/name, operator: NotIn, values: [%[3]s]}
+        - namespaceSelector:
+            matchExpressions:
+              - {key: kubernetes.io/metadata.name, operator: NotIn, values: [%[1]s]}
+`, namespace, component, peer, chaosLabel, chaosComponentLabel, chaosPeerLabel, chaosPartitionName(component, peer))
+}
+
+// chaosPartitionResult is the JSON form of chaos partition's output.
+type chaosPartitionResult struct {
+	Namespace       string   `json:"namespace"`
+	Components      []string `json:"components"`
+	PoliciesWritten []string `json:"policiesWritten"`
+	Warnings        []string `json:"warnings,omitempty"`
+}
+
+func runChaosPartition(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	a, err := chaosComponent(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := chaosComponent(args[1])
+	if err != nil {
+		return err
+	}
+	switch {
+	case a.namespace != b.namespace:
+		return fmt.Errorf("%s is in %s and %s in %s — partition components of one namespace", a.name, a.namespace, b.name, b.namespace)
+	case a.name == b.name:
+		return fmt.Errorf("name two different components")
+	}
+
+	header(fmt.Sprintf("Partitioning %s from %s", a.name, b.name))
+	result := chaosPartitionResult{Namespace: a.namespace, Components: []string{a.name, b.name}, PoliciesWritten: []string{}}
+	for _, side := range [][2]string{{a.name, b.name}, {b.name, a.name}} {
+		if out, err := runSilentStdin(chaosPartitionPolicy(a.namespace, side[0], side[1]), "kubectl", "--context", kubeContextName(), "apply", "-f", "-"); err != nil {
+			return fmt.Errorf("cannot write the partition NetworkPolicy: %s", out)
+		}
+		success(fmt.Sprintf("%s no longer admits %s", side[0], side[1]))
+		result.PoliciesWritten = append(result.PoliciesWritten, chaosPartitionName(side[0], side[1]))
+	}
+	if out, err := kubectlJSON("get", "networkpolicies", "-n", a.namespace, "-l", "app.kubernetes.io/managed-by=devstagingenvironment-operator", "-o", "name"); err == nil && out != "" {
+		msg := "This namespace has networkPolicies: strict policies — traffic they admit between the two still gets through"
+		result.Warnings = append(result.Warnings, msg)
+		warn(msg)
+	}
+	return render(result, func() {
+		step("💡", fmt.Sprintf("Heal it: %skindling chaos heal %s%s", colorCyan, a.name, colorReset))
+	})
+}
+
+// chaosHealResult is the JSON form of chaos heal's output: the partition
+// NetworkPolicies removed, and the pods whose latency was removed.
+type chaosHealResult struct {
+	PoliciesRemoved []string `json:"policiesRemoved"`
+	PodsHealed      []string `json:"podsHealed"`
+}
+
+func runChaosHeal(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	var target *componentRef
+	if len(args) > 0 {
+		c, err := chaosComponent(args[0])
+		if err != nil {
+			return err
+		}
+		target = &c
+	}
+
+	header("Healing")
+	result := chaosHealResult{PoliciesRemoved: []string{}, PodsHealed: []string{}}
+
+	selectors := []string{chaosLabel + "=partition"}
+	if target != nil {
+		selectors = []string{
+			fmt.Sprintf("%s=partition,%s=%s", chaosLabel, chaosComponentLabel, target.name),
+			fmt.Sprintf("%s=partition,%s=%s", chaosLabel, chaosPeerLabel, target.name),
+		}
+	}
+	for _, selector := range selectors {
+		for _, row := range statusRows("networkpolicies", allNamespaces, selector, func(o map[string]interface{}) map[string]string {
+			return map[string]string{"name": statusField(o, "metadata", "name"), "namespace": statusField(o, "metadata", "namespace")}
+		}) {
+			if out, err := captureKubectl("delete", "networkpolicy", row["name"], "-n", row["namespace"], "--ignore-not-found"); err != nil {
+				return fmt.Errorf("cannot delete NetworkPolicy %s: %s", row["name"], out)
+			}
+			success(fmt.Sprintf("Removed partition %s", row["name"]))
+			result.PoliciesRemoved = append(result.PoliciesRemoved, row["namespace"]+"/"+row["name"])
+		}
+	}
+
+	pods, err := chaosLatencyPods(target)
+	if err != nil {
+		return err
+	}
+	for _, p := range pods {
+		if err := chaosNetem(p.namespace, p.name, "tc qdisc del dev eth0 root 2>/dev/null; true"); err != nil {
+			return err
+		}
+		_, _ = captureKubectl("annotate", "pod", p.name, "-n", p.namespace, chaosLatencyAnnotation+"-")
+		success(fmt.Sprintf("Removed latency from %s", p.name))
+		result.PodsHealed = append(result.PodsHealed, p.namespace+"/"+p.name)
+	}
+
+	return render(result, func() {
+		if len(result.PoliciesRemoved)+len(result.PodsHealed) == 0 {
+			step("📭", "No chaos to heal")
+		}
+	})
+}
+
+// chaosPod is a pod kindling chaos latency annotated.
+type chaosPod struct{ namespace, name string }
+
+// chaosLatencyPods lists the annotated pods of target, or of the cluster.
+func chaosLatencyPods(target *componentRef) ([]chaosPod, error) {
+	args := []string{"get", "pods", "-A", "-o", "json"}
+	if target != nil {
+		args = []string{"get", "pods", "-n", target.namespace, "-l", "app.kubernetes.io/name=" + target.name, "-o", "json"}
+	}
+	out, err := kubectlJSON(args...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list pods: %w", err)
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name        string            `json:"name"`
+				Namespace   string            `json:"namespace"`
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+			Status struct {
+				Phase string `json:"phase"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return nil, fmt.Errorf("cannot parse pods: %w", err)
+	}
+	var pods []chaosPod
+	for _, p := range list.Items {
+		if _, ok := p.Metadata.Annotations[chaosLatencyAnnotation]; ok && p.Status.Phase == "Running" {
+			pods = append(pods, chaosPod{namespace: p.Metadata.Namespace, name: p.Metadata.Name})
+		}
+	}
+	return pods, nil
+}
@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Run a DevStagingEnvironment end to end in a throwaway cluster",
+	Long: `Commands for running kindling in CI, with the same steps as the
+kindling GitHub Actions deploy action but from any CI system or a laptop.`,
+}
+
+var ciRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Provision, build, deploy, test, and tear down in one step",
+	Long: `Runs a manifest end to end in an ephemeral Kind cluster:
+
+  1. Creates a cluster named kindling-ci-<timestamp> and installs the
+     operator, exactly as kindling init does
+  2. Builds every service image (tagged with the short git SHA, like
+     kindling build) and ships it to the cluster
+  3. Applies the manifest and points each environment at its new image
+  4. Waits for every DevStagingEnvironment to report Ready
+  5. Runs each --test command in order, stopping at the first failure
+  6. On any failure, prints the environment tree, Warning events, and
+     the logs of every workload that isn't ready
+  7. Deletes the cluster, even when a step failed or the run was
+     interrupted
+
+The command exits non-zero when any step fails, so it can be the whole
+CI job. --timeout bounds everything but the teardown; it is checked
+between steps and cuts short the readiness wait and the tests.
+
+Test commands run with sh -c from the current directory, with
+KINDLING_CLUSTER set to the cluster name and KINDLING_<NAME>_URL set to
+the ingress URL of each environment (orders-dev → KINDLING_ORDERS_DEV_URL).
+
+Pass -c to use a cluster of your own; an existing one is reused and is
+never deleted. Build contexts are resolved the same way as kindling dev.
+
+Examples:
+  kindling ci run -f dev-environment.yaml
+  kindling ci run -f dev-environment.yaml --timeout 10m --test "npm test"
+  kindling ci run -f dev-environment.yaml --profile minimal --test ./e2e.sh --test "make smoke"
+  kindling ci run -f dev-environment.yaml -c ci --keep-cluster`,
+	SilenceUsage: true,
+	RunE:         runCI,
+}
+
+var (
+	ciFile        string
+	ciRepoPath    string
+	ciTimeout     time.Duration
+	ciTests       []string
+	ciProfile     string
+	ciKeepCluster bool
+)
+
+func init() {
+	ciRunCmd.Flags().StringVarP(&ciFile, "file", "f", "", "DevStagingEnvironment YAML to run (required)")
+	ciRunCmd.Flags().StringVarP(&ciRepoPath, "repo-path", "r", "", "Repository root the images are built from (default: the file's directory)")
+	ciRunCmd.Flags().DurationVar(&ciTimeout, "timeout", 10*time.Minute, "Time limit for the whole run, teardown excluded")
+	ciRunCmd.Flags().StringArrayVar(&ciTests, "test", nil, "Command to run once the environments are ready (repeatable)")
+	ciRunCmd.Flags().StringVar(&ciProfile, "profile", "", "Cluster profile: minimal, standard, or full (default: .kindling/cluster.yaml, else standard)")
+	ciRunCmd.Flags().BoolVar(&ciKeepCluster, "keep-cluster", false, "Don't delete the cluster at the end, e.g. to debug a failure")
+	_ = ciRunCmd.MarkFlagRequired("file")
+	ciCmd.AddCommand(ciRunCmd)
+	rootCmd.AddCommand(ciCmd)
+}
+
+// ciTarget is one DevStagingEnvironment of the manifest under test.
+type ciTarget struct {
+	name      string
+	namespace string
+}
+
+func runCI(cmd *cobra.Command, args []string) (err error) {
+	if isJSONOutput() {
+		return fmt.Errorf("kindling ci streams output and does not support --output json")
+	}
+	if err := requireLocalCluster("kindling ci"); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(ciFile)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", ciFile, err)
+	}
+	names, err := manifestDSENames(ciFile)
+	if err != nil {
+		return err
+	}
+	targets := make([]ciTarget, 0, len(names))
+	for _, n := range names {
+		ns := n[1]
+		if ns == "" {
+			ns = "default"
+		}
+		targets = append(targets, ciTarget{name: n[0], namespace: ns})
+	}
+	repoPath := ciRepoPath
+	if repoPath == "" {
+		repoPath = validateRepoRoot(ciFile)
+	}
+	repoPath, _ = filepath.Abs(repoPath)
+	services, err := devServices(data, repoPath, ciFile)
+	if err != nil {
+		return err
+	}
+
+	// Cancellation still runs the teardown below, so a cancelled CI job
+	// doesn't leave a cluster behind.
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(sigCtx, ciTimeout)
+	defer cancel()
+	start := time.Now()
+
+	if !cmd.Flags().Changed("cluster") {
+		clusterName = fmt.Sprintf("kindling-ci-%d", start.Unix())
+	}
+	owned := !clusterExists(clusterName)
+	defer func() {
+		if err != nil {
+			ciDiagnostics(targets)
+		}
+		ciTeardown(owned)
+		fmt.Println()
+		if err != nil {
+			fail(fmt.Sprintf("CI run failed after %s", time.Since(start).Round(time.Second)))
+			return
+		}
+		success(fmt.Sprintf("CI run passed in %s", time.Since(start).Round(time.Second)))
+	}()
+
+	// ── Provision ───────────────────────────────────────────────
+	if owned {
+		header(fmt.Sprintf("Provisioning cluster %q", clusterName))
+	} else {
+		header(fmt.Sprintf("Reusing cluster %q", clusterName))
+	}
+	initProfile = ciProfile
+	if err := runInit(cmd, nil); err != nil {
+		return fmt.Errorf("provisioning failed: %w", err)
+	}
+	if err := ciCheck(ctx, "provisioning"); err != nil {
+		return err
+	}
+
+	// ── Build ───────────────────────────────────────────────────
+	header("Building images")
+	tag := gitImageTag(repoPath)
+	images := map[string]string{}
+	for _, svc := range services {
+		res := buildService(svc, clusterImage(svc.repo, tag))
+		if res.Error != "" {
+			return fmt.Errorf("%s: %s", svc.name, res.Error)
+		}
+		images[svc.name] = res.Image
+		if err := ciCheck(ctx, "building "+svc.name); err != nil {
+			return err
+		}
+	}
+
+	// ── Deploy ──────────────────────────────────────────────────
+	header("Deploying")
+	step("📄", fmt.Sprintf("Applying %s", ciFile))
+	if out, err := captureKubectl("apply", "-f", ciFile); err != nil {
+		return fmt.Errorf("kubectl apply failed: %s", out)
+	}
+	for _, t := range targets {
+		image, ok := images[t.name]
+		if !ok {
+			continue
+		}
+		patch := fmt.Sprintf(`{"spec":{"deployment":{"image":%q}}}`, image)
+		if out, err := captureKubectl("patch", "devstagingenvironment", t.name, "-n", t.namespace,
+			"--type", "merge", "-p", patch); err != nil {
+			return fmt.Errorf("patching %s failed: %s", t.name, out)
+		}
+		step("🏷️ ", fmt.Sprintf("%s → %s", t.name, image))
+	}
+
+	// ── Wait for readiness ──────────────────────────────────────
+	header("Waiting for environments")
+	for _, t := range targets {
+		remaining := time.Until(deadlineOf(ctx))
+		if err := ciCheck(ctx, "waiting for "+t.name); err != nil {
+			return err
+		}
+		step("⏳", fmt.Sprintf("%s (up to %s)", t.name, remaining.Round(time.Second)))
+		if out, err := captureKubectl("wait", "--for=condition=Ready", "devstagingenvironment/"+t.name,
+			"-n", t.namespace, fmt.Sprintf("--timeout=%ds", int(remaining.Seconds())+1)); err != nil {
+			return fmt.Errorf("%s did not become ready: %s", t.name, strings.TrimSpace(out))
+		}
+		success(fmt.Sprintf("%s is ready", t.name))
+	}
+
+	// ── Tests ───────────────────────────────────────────────────
+	if len(ciTests) == 0 {
+		return nil
+	}
+	header("Running tests")
+	env := ciTestEnv(targets)
+	for _, test := range ciTests {
+		step("🧪", test)
+		c := exec.CommandContext(ctx, "sh", "-c", test)
+		c.Env = append(os.Environ(), env...)
+		c.Stdout, c.Stderr = os.Stdout, os.Stderr
+		if err := runCommand(c); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("test %q: %w", test, ciCheck(ctx, "running tests"))
+			}
+			return fmt.Errorf("test %q failed: %w", test, err)
+		}
+		success("passed")
+	}
+	return nil
+}
+
+// ciCheck reports why the run has to stop, if it does: the time limit ran
+// out or the job was cancelled.
+func ciCheck(ctx context.Context, during string) error {
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return fmt.Errorf("timed out after %s while %s", ciTimeout, during)
+	default:
+		return fmt.Errorf("cancelled while %s", during)
+	}
+}
+
+func deadlineOf(ctx context.Context) time.Time {
+	deadline, _ := ctx.Deadline()
+	return deadline
+}
+
+// ciTestEnv is the environment the test commands get on top of the
+// runner's own: the cluster name and each environment's URL.
+func ciTestEnv(targets []ciTarget) []string {
+	env := []string{"KINDLING_CLUSTER=" + clusterName}
+	for _, t := range targets {
+		url, err := kubectlJSON("get", "devstagingenvironment", t.name, "-n", t.namespace,
+			"-o", "jsonpath={.status.url}")
+		if err != nil || url == "" {
+			continue
+		}
+		key := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(t.name))
+		env = append(env, fmt.Sprintf("KINDLING_%s_URL=%s", key, url))
+	}
+	return env
+}
+
+// ciDiagnostics prints what's needed to debug a failed run from the CI log
+// alone: the environment tree, Warning events, the logs of every workload
+// that isn't ready, and the tail of the controller log.
+func ciDiagnostics(targets []ciTarget) {
+	if !clusterExists(clusterName) {
+		return
+	}
+	header("Diagnostics")
+	wanted := map[string]bool{}
+	for _, t := range targets {
+		wanted[t.namespace+"/"+t.name] = true
+	}
+	var envs []envStatus
+	for _, env := range collectEnvironments() {
+		if wanted[env.Namespace+"/"+env.Name] {
+			envs = append(envs, env)
+		}
+	}
+	if len(envs) == 0 {
+		step("📭", "No environment from the manifest exists in the cluster")
+	} else {
+		printEnvironmentTree(envs)
+	}
+
+	namespaces := map[string]bool{}
+	for _, t := range targets {
+		if namespaces[t.namespace] {
+			continue
+		}
+		namespaces[t.namespace] = true
+		if out, _ := captureKubectl("get", "events", "-n", t.namespace,
+			"--field-selector=type=Warning", "--sort-by=.lastTimestamp"); strings.TrimSpace(out) != "" {
+			fmt.Println()
+			step("⚡", fmt.Sprintf("Warning events in %s", t.namespace))
+			fmt.Println(out)
+		}
+	}
+
+	for _, env := range envs {
+		for _, c := range env.Components {
+			if c.Ready >= c.Desired && c.Problem == "" {
+				continue
+			}
+			selector := "app.kubernetes.io/name=" + c.Name
+			fmt.Println()
+			step("📜", fmt.Sprintf("%s %s (%d/%d ready)", c.Kind, c.Name, c.Ready, c.Desired))
+			if out, _ := captureKubectl("describe", "pods", "-n", env.Namespace, "-l", selector); out != "" {
+				fmt.Println(lastLines(out, 25))
+			}
+			if out, _ := captureKubectl("logs", "-n", env.Namespace, "-l", selector,
+				"--all-containers", "--prefix", "--tail=50"); out != "" {
+				fmt.Println(out)
+			}
+		}
+	}
+
+	fmt.Println()
+	step("📜", "Controller log (last 50 lines)")
+	if out, _ := captureKubectl("logs", "-n", "kindling-system",
+		"deployment/kindling-controller-manager", "--tail=50"); out != "" {
+		fmt.Println(out)
+	}
+}
+
+// ciTeardown deletes the cluster if this run created it.
+func ciTeardown(owned bool) {
+	switch {
+	case !owned:
+		header("Teardown")
+		step("♻️ ", fmt.Sprintf("Leaving cluster %q — it existed before the run", clusterName))
+		return
+	case ciKeepCluster:
+		header("Teardown")
+		step("♻️ ", fmt.Sprintf("Keeping cluster %q (--keep-cluster) — delete it with: kindling destroy -c %s -y", clusterName, clusterName))
+		return
+	case !clusterExists(clusterName):
+		return
+	}
+	header("Teardown")
+	step("💥", fmt.Sprintf("Deleting %s cluster %s", clusterProvider().Name(), clusterName))
+	if err := deleteCluster(clusterName); err != nil {
+		warn(fmt.Sprintf("Could not delete cluster %q: %v", clusterName, err))
+		return
+	}
+	success("Cluster deleted")
+}
@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+// ── Clones ──────────────────────────────────────────────────────
+//
+// A clone is a second DevStagingEnvironment made from the live spec of
+// another: under a new name, in another environment, or both. Whatever
+// would collide with the original — the ingress host and a fixed node
+// port — is replaced, and the ConfigMaps and Secrets the spec references
+// are copied when the clone lands in another namespace. With --data the
+// dependencies' volumes are copied too, through the snapshot helpers.
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <env-name> [new-name]",
+	Short: "Copy a DevStagingEnvironment, optionally with its data",
+	Long: `Creates a copy of a running DevStagingEnvironment — to reproduce a
+teammate's bug, or try a risky migration — without touching the
+original.
+
+The copy gets a new name, another environment (--to), or both. It runs
+the original's spec as it is on the cluster, images included, with:
+
+  - an ingress host of its own: <new-name>.<environment>.localtest.me,
+    or --host
+  - no fixed node port, and not paused
+  - the ConfigMaps and Secrets the spec references, copied into its
+    namespace when they aren't there yet
+
+Its dependencies start empty and seeded, like a fresh deploy. --data
+copies the contents of the original's dependency volumes into them
+instead; each dependency of the original is stopped while its volume is
+copied, unless --live.
+
+Examples:
+  kindling clone orders-dev orders-repro
+  kindling clone orders-dev --env alice --to repro-1234 --data
+  kindling clone orders-dev orders-copy --data --live`,
+	Args:              cobra.RangeArgs(1, 2),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeDSEs),
+	RunE:              runClone,
+}
+
+var (
+	cloneEnv  string
+	cloneTo   string
+	cloneHost string
+	cloneData bool
+	cloneLive bool
+)
+
+func init() {
+	cloneCmd.Flags().StringVar(&cloneEnv, "env", "", "Environment to find the original in (default: the current one)")
+	_ = cloneCmd.RegisterFlagCompletionFunc("env", completeEnvironments)
+	cloneCmd.Flags().StringVar(&cloneTo, "to", "", "Environment to create the copy in, created if needed (default: the original's)")
+	_ = cloneCmd.RegisterFlagCompletionFunc("to", completeEnvironments)
+	cloneCmd.Flags().StringVar(&cloneHost, "host", "", "Ingress host of the copy (default: <new-name>.<environment>.localtest.me)")
+	cloneCmd.Flags().BoolVar(&cloneData, "data", false, "Copy the contents of the dependencies' volumes")
+	cloneCmd.Flags().BoolVar(&cloneLive, "live", false, "With --data, copy without stopping the original's dependencies")
+	cloneCmd.Flags().DurationVar(&snapshotTimeout, "timeout", 3*time.Minute, "How long to wait for each workload to stop, start, or become ready")
+	rootCmd.AddCommand(cloneCmd)
+}
+
+// cloneAnnotation names the DevStagingEnvironment a clone was made from,
+// as <namespace>/<name>.
+const cloneAnnotation = "kindling.dev/cloned-from"
+
+// cloneResult is the JSON form of clone's output.
+type cloneResult struct {
+	Source      string           `json:"source"` // <namespace>/<name> of the original
+	Name        string           `json:"name"`
+	Namespace   string           `json:"namespace"`
+	Environment string           `json:"environment"`
+	Host        string           `json:"host,omitempty"`
+	ConfigMaps  []string         `json:"configMaps"` // copied into the namespace
+	Secrets     []string         `json:"secrets"`    // copied into the namespace
+	Volumes     []snapshotVolume `json:"volumes"`    // copied with --data, by the copy's claim
+}
+
+// cloneSource finds the DevStagingEnvironment to copy: the one named arg
+// in env, or anywhere when env is empty and no environment is current.
+func cloneSource(envs []envStatus, arg, env string) (envStatus, error) {
+	if env == "" {
+		env = currentEnvironment()
+	}
+	var found []envStatus
+	for _, e := range envs {
+		if e.Name == arg && (env == "" || e.Environment == env) {
+			found = append(found, e)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return envStatus{}, fmt.Errorf("DevStagingEnvironment %q not found — see: kindling status", arg)
+	case 1:
+		return found[0], nil
+	}
+	var where []string
+	for _, e := range found {
+		where = append(where, e.Environment)
+	}
+	return envStatus{}, fmt.Errorf("%q exists in environments %s — pass --env", arg, strings.Join(where, ", "))
+}
+
+// cloneHostFor returns the default ingress host of a copy.
+func cloneHostFor(name, namespace string) string {
+	if namespace == defaultEnvName {
+		return name + ".localtest.me"
+	}
+	return name + "." + environmentOf(namespace) + ".localtest.me"
+}
+
+// cloneObject turns the original's object into the copy's: renamed,
+// stripped of server fields, and without what would collide with the
+// original.
+func cloneObject(dse map[string]interface{}, source, name, namespace, host string) map[string]interface{} {
+	obj := cleanObject(dse)
+	meta := obj["metadata"].(map[string]interface{})
+	meta["name"], meta["namespace"] = name, namespace
+	annotations, _ := meta["annotations"].(map[string]interface{})
+	if annotations == nil {
+		annotations = map[string]interface{}{}
+	}
+	annotations[cloneAnnotation] = source
+	meta["annotations"] = annotations
+
+	spec, ok := obj["spec"].(map[string]interface{})
+	if !ok {
+		return obj
+	}
+	delete(spec, "paused")
+	if svc, ok := spec["service"].(map[string]interface{}); ok {
+		delete(svc, "nodePort")
+	}
+	if ing, ok := spec["ingress"].(map[string]interface{}); ok && ing["enabled"] == true {
+		ing["host"] = host
+	}
+	return obj
+}
+
+// copyReferencedObjects copies the named ConfigMaps or Secrets from one
+// namespace into another, leaving any already there alone, and returns
+// the names copied.
+func copyReferencedObjects(kind string, names []string, from, to string) ([]string, error) {
+	copied := []string{}
+	var items []map[string]interface{}
+	for _, n := range names {
+		if _, err := kubectlJSON("get", kind, n, "-n", to, "-o", "name"); err == nil {
+			continue
+		}
+		out, err := kubectlJSON("get", kind, n, "-n", from, "-o", "json")
+		if err != nil {
+			warn(fmt.Sprintf("%s %s is referenced but missing — skipped", kind, n))
+			continue
+		}
+		var obj map[string]interface{}
+		if json.Unmarshal([]byte(out), &obj) != nil {
+			continue
+		}
+		obj = cleanObject(obj)
+		obj["metadata"].(map[string]interface{})["namespace"] = to
+		items = append(items, obj)
+		copied = append(copied, n)
+	}
+	if len(items) == 0 {
+		return copied, nil
+	}
+	data, err := json.Marshal(map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": items})
+	if err != nil {
+		return nil, err
+	}
+	if out, err := runSilentStdin(string(data), "kubectl", "--context", kubeContextName(), "apply", "-f", "-"); err != nil {
+		return nil, fmt.Errorf("cannot copy the %ss into %s: %s", kind, to, out)
+	}
+	return copied, nil
+}
+
+// cloneVolume copies the contents of the original's claim v into the
+// copy's claim of the same dependency, through a local file.
+func cloneVolume(v snapshotVolume, from, to string, target snapshotVolume) (int64, error) {
+	f, err := os.CreateTemp("", "kindling-clone-*.tar.gz")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	err = withStoppedStatefulSet(from, v.StatefulSet, cloneLive, func() error {
+		return withVolumeHelper(from, v.Claim, func(pod string) error {
+			return kubectlCtx(nil, f, "exec", "-n", from, pod, "--", "tar", "czf", "-", "-C", snapshotMount, ".")
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("cannot copy volume %s: %w", v.Claim, err)
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	err = withStoppedStatefulSet(to, target.StatefulSet, false, func() error {
+		return withVolumeHelper(to, target.Claim, func(pod string) error {
+			script := fmt.Sprintf("find %[1]s -mindepth 1 -delete && tar xzf - -C %[1]s", snapshotMount)
+			return kubectlCtx(f, io.Discard, "exec", "-i", "-n", to, pod, "--", "sh", "-c", script)
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("cannot fill volume %s: %w", target.Claim, err)
+	}
+	return size, nil
+}
+
+func runClone(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	src, err := cloneSource(collectEnvironments(), args[0], cloneEnv)
+	if err != nil {
+		return err
+	}
+	name, ns := src.Name, src.Namespace
+	if len(args) == 2 {
+		name = args[1]
+	}
+	if cloneTo != "" {
+		ns = environmentNamespace(cloneTo)
+	}
+	if name == src.Name && ns == src.Namespace {
+		return fmt.Errorf("the copy needs a new name or another environment — e.g. kindling clone %s %s-copy, or --to <environment>", src.Name, src.Name)
+	}
+	if sanitizeEnvName(name) != name {
+		return fmt.Errorf("%q is not a valid name — use lowercase letters, digits, and dashes", name)
+	}
+	if _, err := kubectlJSON("get", "devstagingenvironment", name, "-n", ns, "-o", "name"); err == nil {
+		return fmt.Errorf("DevStagingEnvironment %s already exists in %s — pick another name, or remove it with: kindling delete %s", name, ns, name)
+	}
+
+	out, err := kubectlJSON("get", "devstagingenvironment", src.Name, "-n", src.Namespace, "-o", "json")
+	if err != nil {
+		return fmt.Errorf("cannot read DevStagingEnvironment %s", src.Name)
+	}
+	var dse map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &dse); err != nil {
+		return fmt.Errorf("cannot parse DevStagingEnvironment %s: %w", src.Name, err)
+	}
+
+	host := cloneHost
+	if host == "" {
+		host = cloneHostFor(name, ns)
+	}
+	result := cloneResult{
+		Source:      src.Namespace + "/" + src.Name,
+		Name:        name,
+		Namespace:   ns,
+		Environment: environmentOf(ns),
+		ConfigMaps:  []string{},
+		Secrets:     []string{},
+		Volumes:     []snapshotVolume{},
+	}
+	if spec, ok := dse["spec"].(map[string]interface{}); ok {
+		if ing, ok := spec["ingress"].(map[string]interface{}); ok && ing["enabled"] == true {
+			result.Host = host
+		}
+	}
+
+	header(fmt.Sprintf("Cloning %s to %s", result.Source, ns+"/"+name))
+	if cloneTo != "" {
+		if _, err := ensureEnvironment(cloneTo); err != nil {
+			return err
+		}
+		step("🌿", fmt.Sprintf("Namespace %s", ns))
+	}
+
+	// ── The objects the spec references ─────────────────────────
+	if ns != src.Namespace {
+		configMaps, secrets := referencedObjects(dse["spec"])
+		if result.ConfigMaps, err = copyReferencedObjects("configmap", configMaps, src.Namespace, ns); err != nil {
+			return err
+		}
+		if result.Secrets, err = copyReferencedObjects("secret", secrets, src.Namespace, ns); err != nil {
+			return err
+		}
+		for _, copied := range []struct {
+			kind  string
+			names []string
+		}{{"configmap", result.ConfigMaps}, {"secret", result.Secrets}} {
+			if len(copied.names) > 0 {
+				step("🔑", fmt.Sprintf("%d %s(s): %s", len(copied.names), copied.kind, strings.Join(copied.names, ", ")))
+			}
+		}
+	}
+
+	// ── The DSE ─────────────────────────────────────────────────
+	manifest, err := json.Marshal(cloneObject(dse, result.Source, name, ns, host))
+	if err != nil {
+		return err
+	}
+	if out, err := runSilentStdin(string(manifest), "kubectl", "--context", kubeContextName(), "apply", "-f", "-"); err != nil {
+		return fmt.Errorf("cannot create DevStagingEnvironment %s: %s", name, out)
+	}
+	step("📄", fmt.Sprintf("DevStagingEnvironment %s", name))
+	if len(src.DependsOn) > 0 && ns != src.Namespace {
+		warn(fmt.Sprintf("%s waits for %s, which the copy looks for in %s — clone them there too", src.Name, strings.Join(src.DependsOn, ", "), ns))
+	}
+
+	// ── Volumes ─────────────────────────────────────────────────
+	if cloneData {
+		claims, err := environmentClaims(src.Namespace, src.Name)
+		if err != nil {
+			return err
+		}
+		if len(claims) > 0 {
+			// As in snapshot restore, the copy's dependencies must be up
+			// before their volumes are replaced.
+			spin := startSpinner(fmt.Sprintf("Waiting for the dependencies of %s", name))
+			out, err := captureKubectl("wait", "--for=condition=DependenciesReady", "devstagingenvironment/"+name,
+				"-n", ns, fmt.Sprintf("--timeout=%s", snapshotTimeout))
+			spin.stop()
+			if err != nil {
+				return fmt.Errorf("dependencies of %s did not become ready: %s", name, out)
+			}
+		}
+		for _, v := range claims {
+			// Claims are data-<statefulset>-0, and a dependency's
+			// StatefulSet is <env-name>-<type>.
+			sts := name + strings.TrimPrefix(v.StatefulSet, src.Name)
+			target := snapshotVolume{Claim: strings.Replace(v.Claim, v.StatefulSet, sts, 1), StatefulSet: sts}
+			if target.Bytes, err = cloneVolume(v, src.Namespace, ns, target); err != nil {
+				return err
+			}
+			step("💾", fmt.Sprintf("volume %s → %s (%s)", v.Claim, target.Claim, devstaging.FormatBytes(target.Bytes)))
+			result.Volumes = append(result.Volumes, target)
+		}
+	}
+
+	return render(result, func() {
+		success(fmt.Sprintf("Cloned %s to %s", src.Name, name))
+		if !cloneData {
+			fmt.Printf("\n  %sIts dependencies start empty — pass --data to copy the original's.%s\n", colorDim, colorReset)
+		}
+		fmt.Println()
+		if result.Host != "" {
+			fmt.Printf("  🔗 Served on %s once ready\n", result.Host)
+		}
+		if cloneTo != "" {
+			fmt.Printf("  Work against it with: %skindling env switch %s%s\n", colorCyan, cloneTo, colorReset)
+		}
+		fmt.Printf("  Track progress with: %skindling status%s\n", colorCyan, colorReset)
+		fmt.Println()
+	})
+}
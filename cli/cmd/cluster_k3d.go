@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ── k3d backend ─────────────────────────────────────────────────
+//
+// k3d runs k3s in Docker containers. Its load balancer publishes the host
+// ports, and k3s' bundled Traefik and ServiceLB are disabled so the
+// profile's ingress controller binds 80/443 as it does on Kind.
+
+// k3dProvider runs the cluster with the k3d binary.
+type k3dProvider struct{}
+
+func (k3dProvider) Name() string                  { return "k3d" }
+func (k3dProvider) Tools() []string               { return []string{"k3d"} }
+func (k3dProvider) Context(cluster string) string { return "k3d-" + cluster }
+func (k3dProvider) Network(cluster string) string { return "k3d-" + cluster }
+func (k3dProvider) CertsDir() string              { return "/var/lib/rancher/k3s/agent/etc/containerd/certs.d" }
+
+func (k3dProvider) Exists(cluster string) bool {
+	out, err := runCapture("k3d", "cluster", "list", "-o", "json")
+	if err != nil {
+		return false
+	}
+	var clusters []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(out), &clusters); err != nil {
+		return false
+	}
+	for _, c := range clusters {
+		if c.Name == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+func (p k3dProvider) Create(cluster string, spec clusterSpec) error {
+	profile := spec.Profile
+	args := []string{
+		"cluster", "create", cluster,
+		"--servers", "1",
+		"--agents", strconv.Itoa(profile.Workers),
+		"--port", "80:80@loadbalancer",
+		"--port", "443:443@loadbalancer",
+		"--k3s-arg", "--disable=traefik@server:*",
+		"--k3s-arg", "--disable=servicelb@server:*",
+		"--k3s-arg", "--node-label=ingress-ready=true@server:0",
+		"--kubeconfig-update-default",
+		"--kubeconfig-switch-context=false",
+	}
+	for i := 1; i <= profile.Workers; i++ {
+		args = append(args, "--k3s-arg", fmt.Sprintf("--node-label=%s=%d@agent:%d", workerNodeLabel, i, i-1))
+	}
+	for _, port := range profile.Ports {
+		hostPort, nodePort, _ := splitPort(port)
+		args = append(args, "--port", fmt.Sprintf("%d:%d@loadbalancer", hostPort, nodePort))
+	}
+	mounts, err := profile.hostMounts(spec.ProjectDir)
+	if err != nil {
+		return err
+	}
+	for _, m := range mounts {
+		args = append(args, "--volume", m.HostPath+":"+m.ContainerPath+"@all")
+	}
+	if gates := featureGateFlag(profile.FeatureGates); gates != "" {
+		for _, component := range []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler"} {
+			args = append(args, "--k3s-arg", fmt.Sprintf("--%s-arg=feature-gates=%s@server:*", component, gates))
+		}
+		args = append(args, "--k3s-arg", "--kubelet-arg=feature-gates="+gates+"@all")
+	}
+	if profile.CNI == "calico" {
+		// installCNI applies Calico, whose default pool is 192.168.0.0/16.
+		args = append(args,
+			"--k3s-arg", "--flannel-backend=none@server:*",
+			"--k3s-arg", "--disable-network-policy@server:*",
+			"--k3s-arg", "--cluster-cidr=192.168.0.0/16@server:*",
+		)
+	}
+	// Unlike Kind, k3d doesn't hand the proxy to the nodes; their
+	// containerd needs it to pull images, and the pod and Service
+	// networks must bypass it.
+	for _, env := range proxyEnv() {
+		if name, value, _ := strings.Cut(env, "="); strings.EqualFold(name, "NO_PROXY") {
+			env = name + "=" + mergeNoProxy(value, []string{"10.42.0.0/16", "10.43.0.0/16", "192.168.0.0/16"})
+		}
+		args = append(args, "--env", strings.ReplaceAll(env, "@", `\@`)+"@all")
+	}
+	if spec.NodeImage != "" {
+		args = append(args, "--image", spec.NodeImage)
+	}
+	if spec.Wait > 0 {
+		args = append(args, "--wait", "--timeout", spec.Wait.String())
+	}
+
+	step("🔧", fmt.Sprintf("k3d %s", strings.Join(args, " ")))
+	if err := run("k3d", args...); err != nil {
+		return err
+	}
+	return writeNodeMirrors(p, cluster, profile.Mirrors)
+}
+
+func (k3dProvider) Delete(cluster string) error {
+	if out, err := runSilent("k3d", "cluster", "delete", cluster); err != nil {
+		return fmt.Errorf("%s", lastLines(out, 5))
+	}
+	return nil
+}
+
+// Nodes returns the server and agent containers, leaving out k3d's load
+// balancer and tools containers.
+func (k3dProvider) Nodes(cluster string) ([]string, error) {
+	out, err := runCapture("docker", "ps", "-a",
+		"--filter", "label=k3d.cluster="+cluster,
+		"--format", `{{.Names}} {{.Label "k3d.role"}}`)
+	if err != nil {
+		return nil, fmt.Errorf("%s", out)
+	}
+	var nodes []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && (fields[1] == "server" || fields[1] == "agent") {
+			nodes = append(nodes, fields[0])
+		}
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+func (k3dProvider) LoadImage(cluster, image string) error {
+	if out, err := runSilent("k3d", "image", "import", image, "--cluster", cluster); err != nil {
+		return fmt.Errorf("%s", lastLines(out, 5))
+	}
+	return nil
+}
+
+// featureGateFlag renders feature gates as the value of --feature-gates.
+func featureGateFlag(gates map[string]bool) string {
+	parts := make([]string, 0, len(gates))
+	for gate, enabled := range gates {
+		parts = append(parts, fmt.Sprintf("%s=%t", gate, enabled))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ── minikube backend ────────────────────────────────────────────
+//
+// minikube runs each node as a container with the docker driver; the
+// cluster name is the minikube profile. containerd is used as the runtime
+// so images, mirrors, and crictl behave as they do on Kind.
+
+// minikubeProvider runs the cluster with the minikube binary.
+type minikubeProvider struct{}
+
+func (minikubeProvider) Name() string                  { return "minikube" }
+func (minikubeProvider) Tools() []string               { return []string{"minikube"} }
+func (minikubeProvider) Context(cluster string) string { return cluster }
+func (minikubeProvider) Network(cluster string) string { return cluster }
+func (minikubeProvider) CertsDir() string              { return "/etc/containerd/certs.d" }
+
+func (minikubeProvider) Exists(cluster string) bool {
+	out, err := runCapture("minikube", "profile", "list", "-o", "json")
+	if err != nil {
+		return false
+	}
+	var profiles struct {
+		Valid []struct {
+			Name string `json:"Name"`
+		} `json:"valid"`
+	}
+	if err := json.Unmarshal([]byte(out), &profiles); err != nil {
+		return false
+	}
+	for _, p := range profiles.Valid {
+		if p.Name == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+func (p minikubeProvider) Create(cluster string, spec clusterSpec) error {
+	profile := spec.Profile
+	if len(profile.Mounts) > 1 {
+		return fmt.Errorf("minikube supports one host mount, the profile has %d", len(profile.Mounts))
+	}
+	ports := []string{"80:80", "443:443"}
+	for _, port := range profile.Ports {
+		hostPort, nodePort, _ := splitPort(port)
+		ports = append(ports, fmt.Sprintf("%d:%d", hostPort, nodePort))
+	}
+	args := []string{
+		"start",
+		"--profile", cluster,
+		"--driver", "docker",
+		"--container-runtime", "containerd",
+		"--nodes", strconv.Itoa(profile.Workers + 1),
+		"--ports", strings.Join(ports, ","),
+		"--keep-context",
+	}
+	mounts, err := profile.hostMounts(spec.ProjectDir)
+	if err != nil {
+		return err
+	}
+	for _, m := range mounts {
+		args = append(args, "--mount", "--mount-string", m.HostPath+":"+m.ContainerPath)
+	}
+	if gates := featureGateFlag(profile.FeatureGates); gates != "" {
+		args = append(args, "--feature-gates", gates)
+	}
+	if profile.CNI == "calico" {
+		args = append(args, "--cni", "calico")
+	}
+	if spec.NodeImage != "" {
+		args = append(args, "--base-image", spec.NodeImage)
+	}
+	if spec.Wait > 0 {
+		args = append(args, "--wait", "all", "--wait-timeout", spec.Wait.String())
+	}
+
+	step("🔧", fmt.Sprintf("minikube %s", strings.Join(args, " ")))
+	if err := run("minikube", args...); err != nil {
+		return err
+	}
+
+	// minikube names the nodes <profile>, <profile>-m02, … and can't label
+	// them at start.
+	labels := map[string]map[string]string{cluster: {"ingress-ready": "true"}}
+	for i := 1; i <= profile.Workers; i++ {
+		labels[fmt.Sprintf("%s-m%02d", cluster, i+1)] = map[string]string{workerNodeLabel: strconv.Itoa(i)}
+	}
+	if err := labelNodes(p.Context(cluster), labels); err != nil {
+		return err
+	}
+	return writeNodeMirrors(p, cluster, profile.Mirrors)
+}
+
+func (minikubeProvider) Delete(cluster string) error {
+	if out, err := runSilent("minikube", "delete", "--profile", cluster); err != nil {
+		return fmt.Errorf("%s", lastLines(out, 5))
+	}
+	return nil
+}
+
+func (minikubeProvider) Nodes(cluster string) ([]string, error) {
+	out, err := runCapture("minikube", "node", "list", "--profile", cluster)
+	if err != nil {
+		return nil, fmt.Errorf("%s", out)
+	}
+	var nodes []string
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			nodes = append(nodes, fields[0])
+		}
+	}
+	return nodes, nil
+}
+
+func (minikubeProvider) LoadImage(cluster, image string) error {
+	if out, err := runSilent("minikube", "image", "load", image, "--profile", cluster); err != nil {
+		return fmt.Errorf("%s", lastLines(out, 5))
+	}
+	return nil
+}
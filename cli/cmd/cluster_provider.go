@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ── Cluster backends ────────────────────────────────────────────
+//
+// The local cluster is Kind by default. Where Kind can't run — no nested
+// virtualization, rootless Docker — k3d or minikube can provision it
+// instead. A backend only creates, inspects, and deletes the cluster and
+// gets images onto its nodes; ingress, registry, and operator are installed
+// the same way on all of them. The backend is part of the cluster profile
+// in .kindling/cluster.yaml, so every later command uses it too.
+
+// ClusterProvider provisions the local cluster on one backend.
+type ClusterProvider interface {
+	// Name is the backend's name, as accepted by init --backend.
+	Name() string
+	// Tools are the binaries the backend needs on PATH.
+	Tools() []string
+	// Context is the kubeconfig context of the named cluster.
+	Context(cluster string) string
+	// Network is the Docker network the cluster's nodes are attached to.
+	Network(cluster string) string
+	// CertsDir is where containerd on the nodes looks for registry
+	// hosts.toml files.
+	CertsDir() string
+
+	Exists(cluster string) bool
+	Create(cluster string, spec clusterSpec) error
+	Delete(cluster string) error
+	// Nodes returns the node containers, which docker exec can reach.
+	Nodes(cluster string) ([]string, error)
+	// LoadImage copies an image from the local Docker daemon into every
+	// node.
+	LoadImage(cluster, image string) error
+}
+
+// clusterSpec is what Create builds the cluster from.
+type clusterSpec struct {
+	Profile    clusterProfile
+	ConfigPath string        // kind-config.yaml, which only the kind backend reads
+	ProjectDir string        // where .kindling/ lives
+	NodeImage  string        // node image override; "" is the backend's default
+	Wait       time.Duration // how long to wait for the control plane
+	Retain     bool          // keep the nodes when creation fails (kind only)
+}
+
+// clusterProviders are the backends accepted by init --backend.
+var clusterProviders = map[string]ClusterProvider{
+	"kind":     kindProvider{},
+	"k3d":      k3dProvider{},
+	"minikube": minikubeProvider{},
+}
+
+const defaultClusterBackend = "kind"
+
+func clusterBackendNames() []string {
+	names := make([]string, 0, len(clusterProviders))
+	for name := range clusterProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	providerOnce   sync.Once
+	activeProvider ClusterProvider
+)
+
+// clusterProvider returns the backend of the project in the working
+// directory: the one its saved cluster profile names, else Kind.
+func clusterProvider() ClusterProvider {
+	providerOnce.Do(func() {
+		activeProvider = clusterProviders[defaultClusterBackend]
+		cwd, err := os.Getwd()
+		if err != nil {
+			return
+		}
+		if p, saved, err := resolveClusterProfile(cwd, ""); err == nil && saved {
+			if provider, ok := clusterProviders[p.backend()]; ok {
+				activeProvider = provider
+			}
+		}
+	})
+	return activeProvider
+}
+
+// useClusterProvider makes every later command in this process use the
+// named backend, as init does once it has resolved the profile.
+func useClusterProvider(name string) ClusterProvider {
+	providerOnce.Do(func() {})
+	activeProvider = clusterProviders[name]
+	return activeProvider
+}
+
+// clusterExists checks whether a cluster with the given name exists. On a
+// remote cluster it checks that the API server answers instead.
+func clusterExists(name string) bool {
+	if remoteCluster() {
+		return remoteClusterReachable()
+	}
+	return clusterProvider().Exists(name)
+}
+
+// clusterLabel names the target cluster in messages: the local cluster
+// with its backend, or the --context of a remote one.
+func clusterLabel() string {
+	if remoteCluster() {
+		return fmt.Sprintf("remote context %q", kubeContext)
+	}
+	return fmt.Sprintf("%s cluster %q", clusterProvider().Name(), clusterName)
+}
+
+// errNoCluster is what commands return when clusterExists is false.
+func errNoCluster() error {
+	if remoteCluster() {
+		return fmt.Errorf("cannot reach %s — check --context and --kubeconfig", clusterLabel())
+	}
+	return fmt.Errorf("%s not found — run 'kindling init' first", clusterLabel())
+}
+
+// clusterNodes returns the node container names of the cluster.
+func clusterNodes(name string) ([]string, error) {
+	nodes, err := clusterProvider().Nodes(name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list nodes of %q: %w", name, err)
+	}
+	return nodes, nil
+}
+
+// loadImage copies a local image into every node of the cluster.
+func loadImage(name, image string) error {
+	return clusterProvider().LoadImage(name, image)
+}
+
+// deleteCluster deletes the cluster and its kubeconfig context.
+func deleteCluster(name string) error {
+	return clusterProvider().Delete(name)
+}
+
+// writeNodeMirrors points containerd on every node at the profile's
+// registry mirrors, for backends that can't mount them at creation.
+func writeNodeMirrors(provider ClusterProvider, cluster string, mirrors map[string]string) error {
+	if len(mirrors) == 0 {
+		return nil
+	}
+	nodes, err := provider.Nodes(cluster)
+	if err != nil {
+		return err
+	}
+	for registry, endpoint := range mirrors {
+		dir := provider.CertsDir() + "/" + registry
+		hostsToml := mirrorHostsToml(endpoint)
+		for _, node := range nodes {
+			if out, err := runSilent("docker", "exec", node, "mkdir", "-p", dir); err != nil {
+				return fmt.Errorf("configuring %s failed: %s", node, out)
+			}
+			if out, err := runSilentStdin(hostsToml, "docker", "exec", "-i", node, "sh", "-c", "cat > "+dir+"/hosts.toml"); err != nil {
+				return fmt.Errorf("configuring %s failed: %s", node, out)
+			}
+		}
+	}
+	return nil
+}
+
+// labelNodes sets labels on cluster nodes through kubectl, for backends
+// that can't label them at creation.
+func labelNodes(context string, labels map[string]map[string]string) error {
+	nodes := make([]string, 0, len(labels))
+	for node := range labels {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		args := []string{"--context", context, "label", "node", node, "--overwrite"}
+		for k, v := range labels[node] {
+			args = append(args, k+"="+v)
+		}
+		if out, err := runSilent("kubectl", args...); err != nil {
+			return fmt.Errorf("labelling %s failed: %s", node, strings.TrimSpace(out))
+		}
+	}
+	return nil
+}
+
+// ── Remote clusters ─────────────────────────────────────────────
+//
+// --context can name any cluster, such as a shared dev cluster that
+// already runs the operator. kindling deploys to it like to the local one,
+// but never creates or deletes it, and since its nodes can't be reached
+// with docker, images are pushed to --image-registry instead of loaded.
+
+// remoteCluster reports whether --context names a cluster other than the
+// backend's local one.
+func remoteCluster() bool {
+	return kubeContext != "" && kubeContext != clusterProvider().Context(clusterName)
+}
+
+// remoteClusterReachable reports whether the --context API server answers.
+func remoteClusterReachable() bool {
+	if useKubectl {
+		_, err := runSilent("kubectl", "get", "--raw", "/version", "--request-timeout=10s")
+		return err == nil
+	}
+	c, err := kubeClient()
+	if err != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return c.Clientset.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error() == nil
+}
+
+// requireLocalCluster refuses commands that create or delete the cluster
+// when --context names a remote one.
+func requireLocalCluster(action string) error {
+	if remoteCluster() {
+		return fmt.Errorf("%s only works on the local %s cluster, but --context is %q", action, clusterProvider().Name(), kubeContext)
+	}
+	return nil
+}
+
+// requireImageRegistry fails early when images would have nowhere to go:
+// a remote cluster can only pull them from --image-registry.
+func requireImageRegistry() error {
+	if remoteCluster() && imageRegistry == "" {
+		return fmt.Errorf("context %q is a remote cluster — pass --image-registry (or set KINDLING_IMAGE_REGISTRY) to push images to a registry it can pull from", kubeContext)
+	}
+	return nil
+}
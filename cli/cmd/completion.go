@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jeffvincent/kindling/cli/pkg/kube"
+	"github.com/spf13/cobra"
+)
+
+// ── Shell completion ────────────────────────────────────────────
+//
+// cobra's completion command writes the bash, zsh, fish, and PowerShell
+// scripts. The functions here complete arguments and flag values from the
+// cluster and the project, so kindling logs <TAB> lists the components
+// that are actually running. A lookup that fails — no cluster, no
+// controller — completes nothing rather than printing an error into the
+// shell.
+
+// completionFunc is the signature of cobra's ValidArgsFunction and flag
+// completions.
+type completionFunc = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// firstArg limits a completion to the command's first argument.
+func firstArg(fn completionFunc) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return fn(cmd, args, toComplete)
+	}
+}
+
+// completeComponents completes a component — the argument of logs, exec,
+// and the like, or --component — in the --env environment or the current
+// one.
+func completeComponents(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	env, _ := cmd.Flags().GetString("env")
+	if env == "" {
+		env = currentEnvironment()
+	}
+	dses := map[string]bool{}
+	for _, dse := range kubeList("devstagingenvironments", "") {
+		if env == "" || dse.Metadata.Name == env || environmentOf(dse.Metadata.Namespace) == env {
+			dses[dse.Metadata.Namespace+"/"+dse.Metadata.Name] = true
+		}
+	}
+	workloads := append(kubeList("deployments", operatorManagedBy), kubeList("statefulsets", operatorManagedBy)...)
+	var names []string
+	for _, w := range workloads {
+		l := w.Metadata.Labels
+		owner := l["app.kubernetes.io/instance"]
+		if owner == "" {
+			owner = l["app.kubernetes.io/part-of"]
+		}
+		if !dses[w.Metadata.Namespace+"/"+owner] {
+			continue
+		}
+		desc := "app"
+		if role := l["app.kubernetes.io/component"]; role != "" {
+			desc = role
+		}
+		names = append(names, w.Metadata.Name+"\t"+desc+" of "+owner)
+	}
+	return completions(names, args), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeDSEs completes DevStagingEnvironment names, each at most once
+// for commands that take several.
+func completeDSEs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	for _, dse := range kubeList("devstagingenvironments", "") {
+		names = append(names, dse.Metadata.Name+"\tin "+dse.Metadata.Namespace)
+	}
+	return completions(names, args), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeEnvironments completes environment names, as env list shows
+// them.
+func completeEnvironments(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completions(environmentNames(), args), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeEnvFlag completes --env, which takes an environment or a
+// DevStagingEnvironment.
+func completeEnvFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names := environmentNames()
+	for _, dse := range kubeList("devstagingenvironments", "") {
+		names = append(names, dse.Metadata.Name+"\tDevStagingEnvironment")
+	}
+	return completions(names, nil), cobra.ShellCompDirectiveNoFileComp
+}
+
+// environmentNames returns the default environment and every namespace
+// that is an environment or runs DevStagingEnvironments.
+func environmentNames() []string {
+	seen := map[string]bool{defaultEnvName: true}
+	for _, ns := range kubeList("namespaces", envLabel) {
+		seen[environmentOf(ns.Metadata.Name)] = true
+	}
+	for _, dse := range kubeList("devstagingenvironments", "") {
+		seen[environmentOf(dse.Metadata.Namespace)] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name+"\tenvironment")
+	}
+	return names
+}
+
+// completeDaemons completes the background processes of ps logs and
+// ps stop.
+func completeDaemons(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	list, _ := daemons().List()
+	var names []string
+	for _, d := range list {
+		names = append(names, d.Name+"\t"+string(d.Kind))
+	}
+	return completions(names, args), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSnapshots completes the snapshots in .kindling/snapshots, and
+// falls back to file names for a tarball elsewhere.
+func completeSnapshots(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	dir, err := snapshotsPath()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.tar.gz"))
+	var names []string
+	for _, f := range files {
+		names = append(names, strings.TrimSuffix(filepath.Base(f), ".tar.gz")+"\tsnapshot")
+	}
+	if len(names) == 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return completions(names, args), cobra.ShellCompDirectiveDefault
+}
+
+// completeClusterProfiles completes init --profile with the built-in
+// profiles and any saved in .kindling/cluster.yaml.
+func completeClusterProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names := []string{}
+	for _, name := range clusterProfileNames() {
+		names = append(names, name+"\tbuilt-in profile")
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if p, saved, err := resolveClusterProfile(cwd, ""); err == nil && saved {
+			names = append(names, p.Profile+"\tsaved in .kindling/cluster.yaml")
+		}
+	}
+	return completions(names, nil), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completions sorts candidates ("name" or "name\tdescription"), dropping
+// duplicates and names already given as arguments.
+func completions(candidates, args []string) []string {
+	given := map[string]bool{}
+	for _, a := range args {
+		given[a] = true
+	}
+	seen := map[string]bool{}
+	out := []string{}
+	for _, c := range candidates {
+		name, _, _ := strings.Cut(c, "\t")
+		if given[name] || seen[name] {
+			continue
+		}
+		seen[name] = true
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// fixedCompletions completes a flag with a fixed set of values.
+func fixedCompletions(values ...string) completionFunc {
+	return cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp)
+}
+
+// completeKubeContexts completes --context with the kubeconfig's contexts.
+func completeKubeContexts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	contexts, err := kube.Contexts(kubeconfigPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completions(contexts, nil), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,567 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffvincent/kindling/cli/pkg/generate"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Get and set kindling settings",
+	Long: `Reads and writes kindling's settings: the cluster name, default
+namespace, output format, image registry, tunnel provider, LLM provider
+and correction rounds, and builder.
+
+Each setting is taken from the first of these that has it:
+
+  1. its flag, e.g. --cluster
+  2. its environment variable, e.g. KINDLING_CLUSTER
+  3. the project's .kindling/config.yaml
+  4. your ~/.config/kindling/config.yaml ($XDG_CONFIG_HOME/kindling)
+
+config set writes the project's file, or yours with --user.
+
+Examples:
+  kindling config list
+  kindling config get cluster
+  kindling config set namespace team-a
+  kindling config set --user llm.provider anthropic
+  kindling config unset registry`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:               "get <key>",
+	Short:             "Print the value of a setting",
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeConfigKeys),
+	RunE:              runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:               "set <key> <value>",
+	Short:             "Save a setting in the project's or your config file",
+	Args:              cobra.ExactArgs(2),
+	SilenceUsage:      true,
+	ValidArgsFunction: completeConfigSet,
+	RunE:              runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:               "unset <key>",
+	Short:             "Remove a setting from the project's or your config file",
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeConfigKeys),
+	RunE:              runConfigUnset,
+}
+
+var configListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List every setting, its value, and where it comes from",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runConfigList,
+}
+
+var configUser bool
+
+func init() {
+	configSetCmd.Flags().BoolVar(&configUser, "user", false, "Write ~/.config/kindling/config.yaml instead of the project's .kindling/config.yaml")
+	configUnsetCmd.Flags().BoolVar(&configUser, "user", false, "Remove from ~/.config/kindling/config.yaml instead of the project's .kindling/config.yaml")
+	configCmd.AddCommand(configGetCmd, configSetCmd, configUnsetCmd, configListCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// configFileName is the kindling settings file, both per project inside
+// .kindling/ and per user in ~/.config/kindling/.
+const configFileName = "config.yaml"
+
+// kindlingConfig is the layout of config.yaml. Every section is optional.
+// Settings are layered: flags, then environment variables, then the
+// project's .kindling/config.yaml, then the user's
+// ~/.config/kindling/config.yaml.
+//
+//	cluster: dev
+//	namespace: team-a
+//	output: text
+//	registry: ghcr.io/acme
+//	tunnel:
+//	  provider: cloudflared
+type kindlingConfig struct {
+	Cluster   string          `yaml:"cluster,omitempty"`   // --cluster
+	Namespace string          `yaml:"namespace,omitempty"` // default namespace of kubectl and the client
+	Output    string          `yaml:"output,omitempty"`    // --output
+	Registry  string          `yaml:"registry,omitempty"`  // --image-registry
+	Tunnel    tunnelConfig    `yaml:"tunnel,omitempty"`
+	LLM       llmConfig       `yaml:"llm,omitempty"`
+	Build     buildConfig     `yaml:"build,omitempty"`
+	Templates templatesConfig `yaml:"templates,omitempty"`
+}
+
+// tunnelConfig configures kindling expose.
+type tunnelConfig struct {
+	Provider string `yaml:"provider,omitempty"` // cloudflared, ngrok, or tailscale
+}
+
+// buildConfig configures how kindling build and kindling dev build images.
+//
+//	build:
+//	  builder: buildfarm
+type buildConfig struct {
+	Builder string `yaml:"builder,omitempty"` // docker buildx builder to build on
+}
+
+// templatesConfig registers the template repositories of kindling new,
+// see kindling template repo.
+//
+//	templates:
+//	  repos:
+//	    - name: acme
+//	      url: https://github.com/acme/kindling-templates.git
+//	      ref: v3
+type templatesConfig struct {
+	Repos []templateRepo `yaml:"repos,omitempty"`
+}
+
+// llmConfig selects and configures the LLM backends used by generate.
+//
+//	llm:
+//	  provider: azure
+//	  providers:
+//	    azure:
+//	      endpoint: https://my-resource.openai.azure.com
+//	      deployment: gpt-4o
+//	    ollama:
+//	      model: llama3.1
+type llmConfig struct {
+	Provider      string                             `yaml:"provider,omitempty"`
+	Corrections   string                             `yaml:"corrections,omitempty"`   // correction rounds, see --max-corrections
+	ContextTokens string                             `yaml:"contextTokens,omitempty"` // prompt budget, see --context-tokens
+	Retries       string                             `yaml:"retries,omitempty"`       // retries of a failed call, see --max-retries
+	Providers     map[string]generate.ProviderConfig `yaml:"providers,omitempty"`
+}
+
+// configPath returns the path of .kindling/config.yaml under dir.
+func configPath(dir string) string {
+	return filepath.Join(dir, ".kindling", configFileName)
+}
+
+// userConfigPath returns the path of the user's config.yaml:
+// $XDG_CONFIG_HOME/kindling/config.yaml, or ~/.config/kindling/config.yaml.
+func userConfigPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "kindling", configFileName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "kindling", configFileName), nil
+}
+
+// loadKindlingConfig reads the user's config.yaml with
+// <dir>/.kindling/config.yaml layered over it. Missing files yield an
+// empty config.
+func loadKindlingConfig(dir string) (*kindlingConfig, error) {
+	cfg := &kindlingConfig{}
+	if path, err := userConfigPath(); err == nil {
+		if err := readConfigFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+	if err := readConfigFile(configPath(dir), cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// readConfigFile unmarshals path into cfg; settings the file doesn't have
+// keep their value. A missing file is not an error.
+func readConfigFile(path string, cfg *kindlingConfig) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeConfigFile writes cfg to path, creating its directory.
+func writeConfigFile(path string, cfg *kindlingConfig) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if string(data) == "{}\n" {
+		data = nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// configSetting is one key that config get/set/list know: where it is in
+// config.yaml and what overrides it.
+type configSetting struct {
+	Key    string
+	Env    string
+	Flag   string   // root flag it is the default of, "" if none
+	Values []string // allowed values, nil for any
+	Usage  string
+	count  bool // a whole number, 0 or more
+	field  func(*kindlingConfig) *string
+	target *string // variable a root flag setting is applied to
+	def    string  // shown when nothing sets it
+}
+
+var configSettings = []configSetting{
+	{
+		Key: "cluster", Env: "KINDLING_CLUSTER", Flag: "cluster",
+		Usage:  "Cluster name",
+		field:  func(c *kindlingConfig) *string { return &c.Cluster },
+		target: &clusterName,
+	},
+	{
+		Key: "namespace", Env: "KINDLING_NAMESPACE",
+		Usage:  "Default namespace of kubectl and the built-in client",
+		field:  func(c *kindlingConfig) *string { return &c.Namespace },
+		target: &kubeNamespace,
+		def:    "the context's",
+	},
+	{
+		Key: "output", Env: "KINDLING_OUTPUT", Flag: "output", Values: []string{outputText, outputJSON},
+		Usage:  "Output format",
+		field:  func(c *kindlingConfig) *string { return &c.Output },
+		target: &outputFormat,
+	},
+	{
+		Key: "registry", Env: "KINDLING_IMAGE_REGISTRY", Flag: "image-registry",
+		Usage:  "Registry images are pushed to for a remote --context cluster",
+		field:  func(c *kindlingConfig) *string { return &c.Registry },
+		target: &imageRegistry,
+	},
+	{
+		Key: "tunnel.provider", Env: "KINDLING_TUNNEL_PROVIDER", Values: []string{"cloudflared", "ngrok", "tailscale"},
+		Usage: "Tunnel provider of kindling expose",
+		field: func(c *kindlingConfig) *string { return &c.Tunnel.Provider },
+		def:   "auto-detected",
+	},
+	{
+		Key: "llm.provider", Env: "KINDLING_LLM_PROVIDER", Values: generate.Providers,
+		Usage: "LLM provider of kindling generate",
+		field: func(c *kindlingConfig) *string { return &c.LLM.Provider },
+		def:   "openai",
+	},
+	{
+		Key: "llm.corrections", Env: "KINDLING_LLM_CORRECTIONS",
+		Usage: "Rounds in which kindling generate sends the checks' findings back to the AI",
+		count: true,
+		field: func(c *kindlingConfig) *string { return &c.LLM.Corrections },
+		def:   strconv.Itoa(generate.DefaultCorrections),
+	},
+	{
+		Key: "llm.contextTokens", Env: "KINDLING_LLM_CONTEXT_TOKENS",
+		Usage: "Token budget of the prompt kindling generate builds from the repo",
+		count: true,
+		field: func(c *kindlingConfig) *string { return &c.LLM.ContextTokens },
+		def:   strconv.Itoa(generate.DefaultContextTokens),
+	},
+	{
+		Key: "llm.retries", Env: "KINDLING_LLM_RETRIES",
+		Usage: "Times kindling generate retries an AI call that was rate-limited, failed on the server, or timed out",
+		count: true,
+		field: func(c *kindlingConfig) *string { return &c.LLM.Retries },
+		def:   strconv.Itoa(generate.DefaultRetries),
+	},
+	{
+		Key: "build.builder", Env: "KINDLING_BUILDER",
+		Usage: "docker buildx builder of kindling build and dev",
+		field: func(c *kindlingConfig) *string { return &c.Build.Builder },
+		def:   "local",
+	},
+}
+
+// lookupConfigSetting returns the setting named key.
+func lookupConfigSetting(key string) (configSetting, error) {
+	var keys []string
+	for _, s := range configSettings {
+		if s.Key == key {
+			return s, nil
+		}
+		keys = append(keys, s.Key)
+	}
+	return configSetting{}, fmt.Errorf("unknown setting %q (use %s)", key, strings.Join(keys, ", "))
+}
+
+// configSource is where a setting's value came from.
+type configSource struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // flag, env, project, user, or default
+	Origin string `json:"origin,omitempty"`
+}
+
+// resolveConfigSetting finds s in the layers: its flag when cmd was given
+// it, its environment variable, the project's file, then the user's.
+func resolveConfigSetting(cmd *cobra.Command, s configSetting, project, user *kindlingConfig) configSource {
+	def := s.def
+	if s.Flag != "" && cmd != nil {
+		// A command's own flag of the same name, like generate --output,
+		// isn't this setting.
+		flag := cmd.Root().PersistentFlags().Lookup(s.Flag)
+		if f := cmd.Flags().Lookup(s.Flag); f == flag && f.Changed {
+			return configSource{Key: s.Key, Value: f.Value.String(), Source: "flag", Origin: "--" + s.Flag}
+		}
+		def = flag.DefValue
+	}
+	if v := os.Getenv(s.Env); v != "" {
+		return configSource{Key: s.Key, Value: v, Source: "env", Origin: s.Env}
+	}
+	if v := *s.field(project); v != "" {
+		return configSource{Key: s.Key, Value: v, Source: "project", Origin: filepath.Join(".kindling", configFileName)}
+	}
+	if v := *s.field(user); v != "" {
+		path, _ := userConfigPath()
+		return configSource{Key: s.Key, Value: v, Source: "user", Origin: path}
+	}
+	return configSource{Key: s.Key, Value: def, Source: "default"}
+}
+
+// loadConfigLayers reads the project's config.yaml, in the working
+// directory, and the user's separately.
+func loadConfigLayers() (project, user *kindlingConfig, err error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, nil, err
+	}
+	project, user = &kindlingConfig{}, &kindlingConfig{}
+	if err := readConfigFile(configPath(cwd), project); err != nil {
+		return nil, nil, err
+	}
+	if path, err := userConfigPath(); err == nil {
+		if err := readConfigFile(path, user); err != nil {
+			return nil, nil, err
+		}
+	}
+	return project, user, nil
+}
+
+// applyConfigDefaults sets the global options that cmd wasn't given a
+// flag for from the environment and the config files.
+func applyConfigDefaults(cmd *cobra.Command) error {
+	project, user, err := loadConfigLayers()
+	if err != nil {
+		return err
+	}
+	for _, s := range configSettings {
+		if s.target == nil {
+			continue
+		}
+		if r := resolveConfigSetting(cmd, s, project, user); r.Source != "flag" && r.Source != "default" {
+			*s.target = r.Value
+		}
+	}
+	return nil
+}
+
+// configSettingInt resolves a count setting that is the default of the
+// command's own flag: the flag when given, then the setting, then def.
+func configSettingInt(cmd *cobra.Command, flag, key string, def int) (int, error) {
+	if cmd.Flags().Changed(flag) {
+		n, err := cmd.Flags().GetInt(flag)
+		if err != nil {
+			return 0, err
+		}
+		if n < 0 {
+			return 0, fmt.Errorf("--%s must be 0 or more", flag)
+		}
+		return n, nil
+	}
+	v := configSettingValue(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a whole number, 0 or more, got %q", key, v)
+	}
+	return n, nil
+}
+
+// configSettingValue resolves a setting that has no global flag — the
+// command's own flag is checked by the caller — or "" when none is set.
+func configSettingValue(key string) string {
+	s, err := lookupConfigSetting(key)
+	if err != nil {
+		return ""
+	}
+	project, user, err := loadConfigLayers()
+	if err != nil {
+		project, user = &kindlingConfig{}, &kindlingConfig{}
+	}
+	if r := resolveConfigSetting(nil, s, project, user); r.Source != "default" {
+		return r.Value
+	}
+	return ""
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	s, err := lookupConfigSetting(args[0])
+	if err != nil {
+		return err
+	}
+	project, user, err := loadConfigLayers()
+	if err != nil {
+		return err
+	}
+	r := resolveConfigSetting(cmd, s, project, user)
+	return render(r, func() { fmt.Println(r.Value) })
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	project, user, err := loadConfigLayers()
+	if err != nil {
+		return err
+	}
+	var rows []configSource
+	for _, s := range configSettings {
+		rows = append(rows, resolveConfigSetting(cmd, s, project, user))
+	}
+	return render(rows, func() {
+		header("Settings")
+		fmt.Printf("    %-18s %-28s %s\n", "KEY", "VALUE", "SOURCE")
+		for _, r := range rows {
+			value := r.Value
+			if r.Source == "default" {
+				value = dimText(fmt.Sprintf("%-28s", value))
+			} else {
+				value = fmt.Sprintf("%-28s", value)
+			}
+			source := r.Source
+			if r.Origin != "" {
+				source += " (" + r.Origin + ")"
+			}
+			fmt.Printf("    %-18s %s %s\n", r.Key, value, source)
+		}
+		fmt.Println()
+	})
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+	s, err := lookupConfigSetting(key)
+	if err != nil {
+		return err
+	}
+	if s.Key == "llm.provider" {
+		value = strings.ToLower(value)
+	}
+	if len(s.Values) > 0 && !containsString(s.Values, value) {
+		return fmt.Errorf("invalid %s %q (use %s)", key, value, strings.Join(s.Values, ", "))
+	}
+	if s.count {
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return fmt.Errorf("invalid %s %q (use a whole number, 0 or more)", key, value)
+		}
+	}
+	path, err := editConfigFile(func(cfg *kindlingConfig) { *s.field(cfg) = value })
+	if err != nil {
+		return err
+	}
+	return render(configSource{Key: key, Value: value, Source: configLayer(), Origin: path}, func() {
+		success(fmt.Sprintf("Set %s = %s in %s", key, value, path))
+		if v := os.Getenv(s.Env); v != "" {
+			warn(fmt.Sprintf("%s=%s is set and takes precedence", s.Env, v))
+		}
+	})
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	s, err := lookupConfigSetting(args[0])
+	if err != nil {
+		return err
+	}
+	path, err := editConfigFile(func(cfg *kindlingConfig) { *s.field(cfg) = "" })
+	if err != nil {
+		return err
+	}
+	return render(configSource{Key: s.Key, Source: configLayer(), Origin: path}, func() {
+		success(fmt.Sprintf("Removed %s from %s", s.Key, path))
+	})
+}
+
+// configLayer is the file config set and unset write: "project", or
+// "user" with --user.
+func configLayer() string {
+	if configUser {
+		return "user"
+	}
+	return "project"
+}
+
+// editConfigFile applies edit to the config file config set and unset
+// write, and returns its path.
+func editConfigFile(edit func(*kindlingConfig)) (string, error) {
+	var path string
+	if configUser {
+		p, err := userConfigPath()
+		if err != nil {
+			return "", err
+		}
+		path = p
+	} else {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		path = configPath(cwd)
+	}
+	cfg := &kindlingConfig{}
+	if err := readConfigFile(path, cfg); err != nil {
+		return "", err
+	}
+	edit(cfg)
+	return path, writeConfigFile(path, cfg)
+}
+
+// completeConfigKeys completes the keys of config get, set, and unset.
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var keys []string
+	for _, s := range configSettings {
+		keys = append(keys, s.Key+"\t"+s.Usage)
+	}
+	return completions(keys, nil), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeConfigSet completes config set's key, then the setting's
+// allowed values.
+func completeConfigSet(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeConfigKeys(cmd, args, toComplete)
+	case 1:
+		if s, err := lookupConfigSetting(args[0]); err == nil && len(s.Values) > 0 {
+			return completions(s.Values, nil), cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
@@ -142,6 +142,6 @@ func jsonError(w http.ResponseWriter, msg string, code int) {
 
 // kubectl runs a kubectl command and returns stdout.
 func kubectlJSON(args ...string) (string, error) {
-	fullArgs := append([]string{"--context", "kind-" + clusterName}, args...)
+	fullArgs := append([]string{"--context", kubeContextName()}, args...)
 	return runCapture("kubectl", fullArgs...)
 }
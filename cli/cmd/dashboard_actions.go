@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,9 +8,11 @@ import (
 	"os"
 	"os/exec"
 	"strings"
-	"sync"
-	"syscall"
 	"time"
+
+	"github.com/jeffvincent/kindling/cli/internal/daemon"
+	"github.com/jeffvincent/kindling/cli/internal/procutil"
+	"github.com/jeffvincent/kindling/cli/pkg/tunnel"
 )
 
 // actionResult is the standard JSON envelope for mutation endpoints.
@@ -42,7 +43,7 @@ func requireMethod(w http.ResponseWriter, r *http.Request, method string) bool {
 
 // captureKubectl runs a kubectl command against the active cluster and returns output.
 func captureKubectl(args ...string) (string, error) {
-	full := append([]string{"--context", "kind-" + clusterName}, args...)
+	full := append([]string{"--context", kubeContextName()}, args...)
 	return runSilent("kubectl", full...)
 }
 
@@ -61,9 +62,9 @@ func handleDeployAction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cmd := exec.Command("kubectl", "--context", "kind-"+clusterName, "apply", "-f", "-")
+	cmd := exec.Command("kubectl", "--context", kubeContextName(), "apply", "-f", "-")
 	cmd.Stdin = strings.NewReader(body.YAML)
-	out, err := cmd.CombinedOutput()
+	out, err := combinedOutput(cmd)
 	if err != nil {
 		actionErr(w, string(out), http.StatusUnprocessableEntity)
 		return
@@ -205,9 +206,9 @@ spec:
   labels:
     - kindling`, body.Username, body.Username, body.Repo)
 
-	cmd := exec.Command("kubectl", "--context", "kind-"+clusterName, "apply", "-f", "-")
+	cmd := exec.Command("kubectl", "--context", kubeContextName(), "apply", "-f", "-")
 	cmd.Stdin = strings.NewReader(yaml)
-	applyOut, err := cmd.CombinedOutput()
+	applyOut, err := combinedOutput(cmd)
 	if err != nil {
 		actionErr(w, "failed to apply runner pool: "+string(applyOut), http.StatusInternalServerError)
 		return
@@ -360,6 +361,10 @@ func handleEnvList(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, envVars)
 }
 
+// dashboardTunnelDaemon is the daemons registry name of the dashboard's
+// tunnel.
+const dashboardTunnelDaemon = "tunnel-dashboard"
+
 // ── POST /api/expose ────────────────────────────────────────────
 // Starts a cloudflared tunnel. Body: { "service": "my-ingress" } (optional)
 
@@ -378,22 +383,18 @@ func handleExposeAction(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if already running — verify the PID is actually alive.
-	if pidBytes, err := os.ReadFile("/tmp/kindling-tunnel.pid"); err == nil {
-		var pid int
-		fmt.Sscanf(string(pidBytes), "%d", &pid)
-		if proc, err := os.FindProcess(pid); err == nil {
-			if proc.Signal(syscall.Signal(0)) == nil {
-				actionErr(w, "tunnel already running — stop it first", http.StatusConflict)
-				return
-			}
+	registry := daemons()
+	if d, err := registry.Get(dashboardTunnelDaemon); err == nil {
+		if d.Alive() {
+			actionErr(w, "tunnel already running — stop it first", http.StatusConflict)
+			return
 		}
-		// Stale PID file — clean up before starting fresh.
-		os.Remove("/tmp/kindling-tunnel.pid")
-		os.Remove("/tmp/kindling-tunnel.log")
+		// Stale entry — clean up before starting fresh.
+		_ = registry.Remove(dashboardTunnelDaemon)
 	}
 
 	// Kill any orphaned cloudflared tunnel processes to avoid conflicts.
-	exec.Command("pkill", "-f", "cloudflared tunnel --url").Run()
+	procutil.KillMatching("cloudflared tunnel --url")
 
 	// Parse optional service from body
 	var body struct {
@@ -403,118 +404,39 @@ func handleExposeAction(w http.ResponseWriter, r *http.Request) {
 		json.NewDecoder(r.Body).Decode(&body)
 	}
 
-	// Set up a log file for output capture.
-	logFile, err := os.Create("/tmp/kindling-tunnel.log")
+	// Run in the background so cloudflared survives if the dashboard restarts.
+	d, err := startDaemon(registry, daemon.Daemon{
+		Name:   dashboardTunnelDaemon,
+		Kind:   daemon.KindTunnel,
+		Labels: map[string]string{"provider": "cloudflared", "service": (&tunnel.State{Service: body.Service}).Label()},
+	}, exec.Command("cloudflared", "tunnel", "--url", "http://localhost:80"))
 	if err != nil {
-		actionErr(w, "failed to create log file: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// Use a pipe so we can read stderr in real-time without racing on the file.
-	pr, pw := io.Pipe()
-
-	cmd := exec.Command("cloudflared", "tunnel", "--url", "http://localhost:80")
-	cmd.Stdout = nil
-	cmd.Stderr = io.MultiWriter(logFile, pw)
-	// Detach from parent process group so cloudflared survives if the dashboard restarts.
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-
-	if err := cmd.Start(); err != nil {
-		logFile.Close()
-		pw.Close()
-		actionErr(w, "failed to start cloudflared: "+err.Error(), http.StatusInternalServerError)
+		actionErr(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Save PID immediately.
-	os.WriteFile("/tmp/kindling-tunnel.pid", []byte(fmt.Sprintf("%d", cmd.Process.Pid)), 0644)
-
-	// Read stderr into a buffer in the background.
-	var stderrBuf bytes.Buffer
-	var mu sync.Mutex
-	go func() {
-		buf := make([]byte, 4096)
-		for {
-			n, err := pr.Read(buf)
-			if n > 0 {
-				mu.Lock()
-				stderrBuf.Write(buf[:n])
-				mu.Unlock()
-			}
-			if err != nil {
-				return
-			}
-		}
-	}()
-
-	// Let the process run independently.
-	go func() {
-		cmd.Wait()
-		pw.Close()
-		logFile.Close()
-	}()
-
 	// Poll for the tunnel URL (cloudflared can take several seconds).
-	tunnelURL := ""
-	for i := 0; i < 20; i++ {
-		time.Sleep(1 * time.Second)
-		mu.Lock()
-		data := stderrBuf.String()
-		mu.Unlock()
-		for _, line := range strings.Split(data, "\n") {
-			if strings.Contains(line, ".trycloudflare.com") {
-				for _, word := range strings.Fields(line) {
-					if strings.HasPrefix(word, "https://") {
-						tunnelURL = strings.TrimRight(word, "|, ")
-						break
-					}
-				}
-			}
-		}
-		if tunnelURL != "" {
-			break
-		}
-	}
-
-	// Patch ingress hosts to route through the tunnel.
-	if tunnelURL != "" {
-		exposeService = body.Service
-		patchIngressesForTunnel(tunnelURL)
-		actionOK(w, "Tunnel started: "+tunnelURL)
-	} else {
+	tunnelURL, err := d.WaitFor(20*time.Second, quickTunnelURL)
+	if err != nil {
 		// Couldn't detect URL — kill the orphan process.
-		if cmd.Process != nil {
-			cmd.Process.Kill()
-		}
-		os.Remove("/tmp/kindling-tunnel.pid")
-		os.Remove("/tmp/kindling-tunnel.log")
+		_ = registry.Stop(dashboardTunnelDaemon, 0)
 		actionErr(w, "Tunnel started but could not detect public URL — try again or use the CLI", http.StatusInternalServerError)
+		return
 	}
+	d.Health = tunnelURL
+	_ = registry.Register(*d)
+
+	// Patch ingress hosts to route through the tunnel.
+	patchIngressesForTunnel(tunnelURL, body.Service)
+	actionOK(w, "Tunnel started: "+tunnelURL)
 }
 
 // ── DELETE /api/expose ──────────────────────────────────────────
 
 func handleUnexpose(w http.ResponseWriter, r *http.Request) {
-	pidBytes, err := os.ReadFile("/tmp/kindling-tunnel.pid")
-	if err != nil {
-		// No PID file — try to clean up orphans anyway.
-		exec.Command("pkill", "-f", "cloudflared tunnel --url").Run()
-		restoreIngresses()
-		actionOK(w, "Tunnel stopped")
-		return
-	}
-	var pid int
-	fmt.Sscanf(string(pidBytes), "%d", &pid)
-
-	proc, err := os.FindProcess(pid)
-	if err == nil {
-		proc.Kill()
-	}
+	_ = daemons().Stop(dashboardTunnelDaemon, 0)
 	// Also kill any orphaned cloudflared processes.
-	exec.Command("pkill", "-f", "cloudflared tunnel --url").Run()
-
-	os.Remove("/tmp/kindling-tunnel.pid")
-	os.Remove("/tmp/kindling-tunnel.log")
+	procutil.KillMatching("cloudflared tunnel --url")
 
 	// Restore original ingress hosts
 	restoreIngresses()
@@ -531,37 +453,16 @@ func handleExposeStatus(w http.ResponseWriter, r *http.Request) {
 	}
 	status := tunnelStatus{}
 
-	pidBytes, err := os.ReadFile("/tmp/kindling-tunnel.pid")
-	if err == nil {
-		var pid int
-		fmt.Sscanf(string(pidBytes), "%d", &pid)
-		proc, err := os.FindProcess(pid)
-		if err == nil {
-			// Signal 0 checks if process exists without sending a real signal
-			if err := proc.Signal(syscall.Signal(0)); err == nil {
-				status.Running = true
-			}
-		}
-
+	registry := daemons()
+	if d, err := registry.Get(dashboardTunnelDaemon); err == nil {
+		status.Running = d.Alive()
 		if !status.Running {
-			// Stale PID file — clean up
-			os.Remove("/tmp/kindling-tunnel.pid")
-			os.Remove("/tmp/kindling-tunnel.log")
+			// Stale entry — clean up
+			_ = registry.Remove(dashboardTunnelDaemon)
 			jsonResponse(w, status)
 			return
 		}
-
-		logContent, _ := os.ReadFile("/tmp/kindling-tunnel.log")
-		for _, line := range strings.Split(string(logContent), "\n") {
-			if strings.Contains(line, ".trycloudflare.com") {
-				for _, word := range strings.Fields(line) {
-					if strings.HasPrefix(word, "https://") {
-						status.URL = strings.TrimRight(word, "|, ")
-						break
-					}
-				}
-			}
-		}
+		status.URL = d.Health
 	}
 
 	jsonResponse(w, status)
@@ -574,17 +475,20 @@ func handleDestroyCluster(w http.ResponseWriter, r *http.Request) {
 	if !requireMethod(w, r, http.MethodDelete) {
 		return
 	}
+	if err := requireLocalCluster("destroying the cluster"); err != nil {
+		actionErr(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if !clusterExists(clusterName) {
 		actionErr(w, "cluster '"+clusterName+"' does not exist", http.StatusNotFound)
 		return
 	}
 
-	out, err := runSilent("kind", "delete", "cluster", "--name", clusterName)
-	if err != nil {
-		actionErr(w, out, http.StatusInternalServerError)
+	if err := deleteCluster(clusterName); err != nil {
+		actionErr(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	actionOK(w, out)
+	actionOK(w, "Deleted cluster \""+clusterName+"\"")
 }
 
 // ── POST /api/init ──────────────────────────────────────────────
@@ -594,6 +498,10 @@ func handleInitCluster(w http.ResponseWriter, r *http.Request) {
 	if !requireMethod(w, r, http.MethodPost) {
 		return
 	}
+	if err := requireLocalCluster("kindling init"); err != nil {
+		actionErr(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	flusher, canFlush := w.(http.Flusher)
 	w.Header().Set("Content-Type", "application/x-ndjson")
@@ -607,7 +515,8 @@ func handleInitCluster(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Preflight
-	for _, bin := range []string{"kind", "kubectl", "docker"} {
+	provider := clusterProvider()
+	for _, bin := range append([]string{"kubectl", "docker"}, provider.Tools()...) {
 		if !commandExists(bin) {
 			json.NewEncoder(w).Encode(actionResult{OK: false, Error: bin + " is not installed"})
 			return
@@ -617,16 +526,23 @@ func handleInitCluster(w http.ResponseWriter, r *http.Request) {
 	if clusterExists(clusterName) {
 		send("Cluster '" + clusterName + "' already exists — skipping creation")
 	} else {
-		send("Creating Kind cluster '" + clusterName + "'...")
+		send("Creating " + provider.Name() + " cluster '" + clusterName + "'...")
 		projDir, err := resolveProjectDir()
 		if err != nil {
 			json.NewEncoder(w).Encode(actionResult{OK: false, Error: err.Error()})
 			return
 		}
-		configPath := projDir + "/kind-config.yaml"
-		out, err := runSilent("kind", "create", "cluster", "--name", clusterName, "--config", configPath)
+		cwd, _ := os.Getwd()
+		profile, _, err := resolveClusterProfile(cwd, "")
+		if err == nil {
+			err = provider.Create(clusterName, clusterSpec{
+				Profile:    profile,
+				ConfigPath: projDir + "/kind-config.yaml",
+				ProjectDir: cwd,
+			})
+		}
 		if err != nil {
-			json.NewEncoder(w).Encode(actionResult{OK: false, Error: "kind create failed: " + out})
+			json.NewEncoder(w).Encode(actionResult{OK: false, Error: provider.Name() + " create failed: " + err.Error()})
 			return
 		}
 		send("Cluster created")
@@ -637,9 +553,15 @@ func handleInitCluster(w http.ResponseWriter, r *http.Request) {
 	projDir, _ := resolveProjectDir()
 	ingressScript := projDir + "/setup-ingress.sh"
 	if _, err := os.Stat(ingressScript); err == nil {
-		out, err := runSilent("bash", ingressScript)
+		nodes, _ := clusterNodes(clusterName)
+		script := exec.Command("bash", ingressScript)
+		script.Env = append(os.Environ(),
+			"KIND_CLUSTER_NAME="+clusterName,
+			"KINDLING_NODES="+strings.Join(nodes, " "),
+			"KINDLING_CERTS_DIR="+provider.CertsDir())
+		out, err := combinedOutput(script)
 		if err != nil {
-			send("Warning: ingress setup issue: " + out)
+			send("Warning: ingress setup issue: " + string(out))
 		} else {
 			send("Ingress-nginx configured")
 		}
@@ -656,9 +578,8 @@ func handleInitCluster(w http.ResponseWriter, r *http.Request) {
 
 	// Load into Kind
 	send("Loading image into Kind cluster...")
-	out, err = runSilent("kind", "load", "docker-image", "kindling-operator:latest", "--name", clusterName)
-	if err != nil {
-		json.NewEncoder(w).Encode(actionResult{OK: false, Error: "kind load failed: " + out})
+	if err := loadImage(clusterName, "kindling-operator:latest"); err != nil {
+		json.NewEncoder(w).Encode(actionResult{OK: false, Error: "kind load failed: " + err.Error()})
 		return
 	}
 	send("Image loaded")
@@ -673,6 +594,19 @@ func handleInitCluster(w http.ResponseWriter, r *http.Request) {
 		send("CRDs applied")
 	}
 
+	// cert-manager issues the operator's webhook serving certificate
+	send("Installing cert-manager...")
+	out, err = captureKubectl("apply", "-f", certManagerManifestURL)
+	if err == nil {
+		out, err = captureKubectl("wait", "--for=condition=Available", "deployment", "--all",
+			"-n", "cert-manager", "--timeout=180s")
+	}
+	if err != nil {
+		json.NewEncoder(w).Encode(actionResult{OK: false, Error: "cert-manager install failed: " + out})
+		return
+	}
+	send("cert-manager ready")
+
 	// Deploy operator via kustomize
 	send("Deploying operator...")
 	kustomize, err := ensureKustomize(projDir)
@@ -685,9 +619,17 @@ func handleInitCluster(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(actionResult{OK: false, Error: "kustomize build failed"})
 		return
 	}
-	cmd := exec.Command("kubectl", "--context", "kind-"+clusterName, "apply", "-f", "-")
-	cmd.Stdin = strings.NewReader(kOut)
-	applyOut, err := cmd.CombinedOutput()
+	// Retry while cert-manager's webhook, which validates the operator's
+	// Certificate, finishes starting.
+	var applyOut []byte
+	for i := 0; i < 20; i++ {
+		cmd := exec.Command("kubectl", "--context", kubeContextName(), "apply", "-f", "-")
+		cmd.Stdin = strings.NewReader(kOut)
+		if applyOut, err = combinedOutput(cmd); err == nil || !strings.Contains(string(applyOut), "webhook") {
+			break
+		}
+		time.Sleep(3 * time.Second)
+	}
 	if err != nil {
 		json.NewEncoder(w).Encode(actionResult{OK: false, Error: "operator deploy failed: " + string(applyOut)})
 		return
@@ -810,9 +752,9 @@ func handleApplyYAML(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cmd := exec.Command("kubectl", "--context", "kind-"+clusterName, "apply", "-f", "-")
+	cmd := exec.Command("kubectl", "--context", kubeContextName(), "apply", "-f", "-")
 	cmd.Stdin = strings.NewReader(payload.YAML)
-	out, err := cmd.CombinedOutput()
+	out, err := combinedOutput(cmd)
 	if err != nil {
 		actionErr(w, string(out), http.StatusUnprocessableEntity)
 		return
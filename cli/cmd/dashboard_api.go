@@ -20,19 +20,11 @@ func handleCluster(w http.ResponseWriter, r *http.Request) {
 
 	info := clusterInfo{
 		Name:    clusterName,
-		Context: "kind-" + clusterName,
+		Context: kubeContextName(),
 	}
 
 	// Check cluster exists
-	out, err := runCapture("kind", "get", "clusters")
-	if err == nil {
-		for _, line := range strings.Split(out, "\n") {
-			if strings.TrimSpace(line) == clusterName {
-				info.Exists = true
-				break
-			}
-		}
-	}
+	info.Exists = clusterExists(clusterName)
 
 	if !info.Exists {
 		jsonResponse(w, info)
@@ -101,8 +93,13 @@ func handleRegistry(w http.ResponseWriter, r *http.Request) {
 // ── /api/ingress-controller ─────────────────────────────────────
 
 func handleIngressController(w http.ResponseWriter, r *http.Request) {
-	out, err := kubectlJSON("get", "pods", "-n", "ingress-nginx",
-		"-l", "app.kubernetes.io/component=controller", "-o", "json")
+	_, ing, found := installedIngressController()
+	if !found {
+		jsonError(w, "ingress controller not found", 404)
+		return
+	}
+	out, err := kubectlJSON("get", "pods", "-n", ing.namespace,
+		"-l", ing.selector, "-o", "json")
 	if err != nil {
 		jsonError(w, "ingress controller not found", 404)
 		return
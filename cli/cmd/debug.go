@@ -0,0 +1,710 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug <component>",
+	Short: "Explain why a component isn't running",
+	Long: `Gathers everything needed to troubleshoot a component in one place —
+its pods' states and probes, recent events, image pull status, the last
+logs (including the previous container's after a crash), and the
+difference between the env vars in the DevStagingEnvironment spec and
+those the container actually got — then prints a ranked list of likely
+causes with a suggested fix for each.
+
+Causes it recognises include a failing image pull, a missing Secret or
+ConfigMap, a bad CMD or entrypoint, an env var the app reports missing,
+the app listening on a different port than the spec says, a wrong
+health-check path, OOMKilled containers, and pods that can't be
+scheduled.
+
+Components are named the same way as in kindling logs: an environment
+name (its app), a dependency type such as postgres, or a full name such as
+orders-dev-postgres.
+
+kindling debug bundle collects the debug logs and the cluster's state
+into a tarball to attach to a bug report.
+
+Examples:
+  kindling debug orders-dev
+  kindling debug postgres --env orders-dev
+  kindling debug orders-dev -o json | jq '.causes[0]'
+  kindling debug bundle`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeComponents),
+	RunE:              runDebug,
+}
+
+var debugEnv string
+
+func init() {
+	debugCmd.Flags().StringVar(&debugEnv, "env", "", "DevStagingEnvironment to resolve the component in")
+	_ = debugCmd.RegisterFlagCompletionFunc("env", completeEnvFlag)
+	rootCmd.AddCommand(debugCmd)
+}
+
+// debugLogLines is how many log lines are kept per container.
+const debugLogLines = 40
+
+// debugReport is everything debug gathered about a component, plus the
+// causes it inferred. It is also the JSON output.
+type debugReport struct {
+	Component string        `json:"component"`
+	Namespace string        `json:"namespace"`
+	Pods      []debugPod    `json:"pods"`
+	Events    []envEvent    `json:"events"`
+	EnvDiff   *debugEnvDiff `json:"envDiff,omitempty"`
+	Causes    []debugCause  `json:"causes"`
+}
+
+// debugPod is one pod of the component and the state of its containers.
+type debugPod struct {
+	Name       string           `json:"name"`
+	Phase      string           `json:"phase"`
+	Containers []debugContainer `json:"containers"`
+}
+
+type debugContainer struct {
+	Name         string   `json:"name"`
+	Image        string   `json:"image"`
+	Ready        bool     `json:"ready"`
+	Restarts     int      `json:"restarts"`
+	State        string   `json:"state"`               // e.g. "waiting: CrashLoopBackOff"
+	LastState    string   `json:"lastState,omitempty"` // e.g. "terminated: Error (exit 1)"
+	Ports        []int    `json:"ports,omitempty"`
+	Probe        string   `json:"probe,omitempty"` // e.g. "GET /healthz on 8080"
+	MemoryLimit  string   `json:"memoryLimit,omitempty"`
+	Logs         []string `json:"logs,omitempty"`
+	PreviousLogs []string `json:"previousLogs,omitempty"`
+
+	env        map[string]bool
+	waiting    containerReason
+	terminated containerReason // current or last termination
+	probePort  string
+}
+
+// containerReason is a waiting or terminated state's reason.
+type containerReason struct {
+	reason   string
+	message  string
+	exitCode int
+}
+
+// debugEnvDiff compares the env vars in the DSE spec with the app
+// container's. Missing ones mean the pod predates the spec; injected ones
+// are the operator's dependency connection strings and are expected.
+type debugEnvDiff struct {
+	Missing  []string `json:"missing"`
+	Injected []string `json:"injected"`
+}
+
+// debugCause is one likely cause, most likely first.
+type debugCause struct {
+	Title    string `json:"title"`
+	Evidence string `json:"evidence"`
+	Fix      string `json:"fix"`
+	Score    int    `json:"score"`
+
+	change *specChange // the spec change that fixes it, for kindling explain
+}
+
+// podJSON holds the fields debug reads from a Pod.
+type podJSON struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Containers []struct {
+			Name  string `json:"name"`
+			Image string `json:"image"`
+			Env   []struct {
+				Name string `json:"name"`
+			} `json:"env"`
+			EnvFrom []struct {
+				SecretRef *struct {
+					Name string `json:"name"`
+				} `json:"secretRef"`
+				ConfigMapRef *struct {
+					Name string `json:"name"`
+				} `json:"configMapRef"`
+			} `json:"envFrom"`
+			Ports []struct {
+				ContainerPort int `json:"containerPort"`
+			} `json:"ports"`
+			ReadinessProbe *probeJSON `json:"readinessProbe"`
+			Resources      struct {
+				Limits map[string]string `json:"limits"`
+			} `json:"resources"`
+		} `json:"containers"`
+	} `json:"spec"`
+	Status struct {
+		Phase             string `json:"phase"`
+		ContainerStatuses []struct {
+			Name         string         `json:"name"`
+			Ready        bool           `json:"ready"`
+			RestartCount int            `json:"restartCount"`
+			State        containerState `json:"state"`
+			LastState    containerState `json:"lastState"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+type probeJSON struct {
+	HTTPGet *struct {
+		Path string `json:"path"`
+		Port any    `json:"port"`
+	} `json:"httpGet"`
+	TCPSocket *struct {
+		Port any `json:"port"`
+	} `json:"tcpSocket"`
+}
+
+type containerState struct {
+	Waiting *struct {
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	} `json:"waiting"`
+	Terminated *struct {
+		Reason   string `json:"reason"`
+		Message  string `json:"message"`
+		ExitCode int    `json:"exitCode"`
+	} `json:"terminated"`
+}
+
+// debugSpec holds the fields debug compares against from the DSE.
+type debugSpec struct {
+	Spec struct {
+		Deployment struct {
+			Port int `json:"port"`
+			Env  []struct {
+				Name string `json:"name"`
+			} `json:"env"`
+		} `json:"deployment"`
+	} `json:"spec"`
+}
+
+func runDebug(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	comps, err := resolveComponents(collectEnvironments(), args[0], debugEnv)
+	if err != nil {
+		return err
+	}
+	if len(comps) > 1 {
+		var names []string
+		for _, c := range comps {
+			names = append(names, c.name)
+		}
+		return fmt.Errorf("%q matches %s — pass the full name", args[0], strings.Join(names, ", "))
+	}
+	target := comps[0]
+
+	sp := startSpinner(fmt.Sprintf("Gathering diagnostics for %s", target.name))
+	report, err := gatherDebugReport(target)
+	sp.stop()
+	if err != nil {
+		return err
+	}
+	return render(report, func() { printDebugReport(report) })
+}
+
+// gatherDebugReport collects pods, logs, events, and the DSE spec of a
+// component and ranks the likely causes.
+func gatherDebugReport(target componentRef) (debugReport, error) {
+	report := debugReport{Component: target.name, Namespace: target.namespace, Pods: []debugPod{}, Events: []envEvent{}}
+
+	out, err := kubectlJSON("get", "pods", "-n", target.namespace, "-l", "app.kubernetes.io/name="+target.name, "-o", "json")
+	if err != nil {
+		return report, fmt.Errorf("cannot list pods of %s: %w", target.name, err)
+	}
+	var pods struct {
+		Items []podJSON `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &pods); err != nil {
+		return report, fmt.Errorf("cannot parse pods of %s: %w", target.name, err)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Metadata.Name < pods.Items[j].Metadata.Name })
+
+	involved := []string{target.name}
+	for _, p := range pods.Items {
+		pod := debugPodFrom(p, target.namespace)
+		for i := range pod.Containers {
+			c := &pod.Containers[i]
+			c.Logs = debugLogs(target.namespace, pod.Name, c.Name, false)
+			if c.Restarts > 0 {
+				c.PreviousLogs = debugLogs(target.namespace, pod.Name, c.Name, true)
+			}
+		}
+		report.Pods = append(report.Pods, pod)
+		involved = append(involved, pod.Name)
+	}
+	report.Events = debugEvents(target.namespace, involved)
+
+	// Only an app has a DSE of its own name; dependencies are configured
+	// by the operator.
+	var spec *debugSpec
+	if out, err := kubectlJSON("get", "devstagingenvironment", target.name, "-n", target.namespace, "-o", "json"); err == nil {
+		var s debugSpec
+		if json.Unmarshal([]byte(out), &s) == nil {
+			spec = &s
+		}
+	}
+	if spec != nil && len(report.Pods) > 0 {
+		report.EnvDiff = diffDebugEnv(spec, appContainer(report.Pods[0], target.name))
+	}
+	report.Causes = rankDebugCauses(report, spec, target.name)
+	return report, nil
+}
+
+// debugPodFrom flattens a Pod into its debug view. The env of each
+// container includes the keys of the Secrets and ConfigMaps it imports.
+func debugPodFrom(p podJSON, namespace string) debugPod {
+	pod := debugPod{Name: p.Metadata.Name, Phase: p.Status.Phase}
+	for _, spec := range p.Spec.Containers {
+		c := debugContainer{Name: spec.Name, Image: spec.Image, env: map[string]bool{}, MemoryLimit: spec.Resources.Limits["memory"]}
+		for _, e := range spec.Env {
+			c.env[e.Name] = true
+		}
+		for _, from := range spec.EnvFrom {
+			switch {
+			case from.SecretRef != nil:
+				for _, k := range objectKeys(namespace, "secret", from.SecretRef.Name) {
+					c.env[k] = true
+				}
+			case from.ConfigMapRef != nil:
+				for _, k := range objectKeys(namespace, "configmap", from.ConfigMapRef.Name) {
+					c.env[k] = true
+				}
+			}
+		}
+		for _, port := range spec.Ports {
+			c.Ports = append(c.Ports, port.ContainerPort)
+		}
+		if pr := spec.ReadinessProbe; pr != nil {
+			switch {
+			case pr.HTTPGet != nil:
+				c.probePort = fmt.Sprint(pr.HTTPGet.Port)
+				c.Probe = fmt.Sprintf("GET %s on %s", pr.HTTPGet.Path, c.probePort)
+			case pr.TCPSocket != nil:
+				c.probePort = fmt.Sprint(pr.TCPSocket.Port)
+				c.Probe = "TCP on " + c.probePort
+			}
+		}
+		for _, st := range p.Status.ContainerStatuses {
+			if st.Name != spec.Name {
+				continue
+			}
+			c.Ready, c.Restarts = st.Ready, st.RestartCount
+			c.State = describeContainerState(st.State)
+			c.LastState = describeContainerState(st.LastState)
+			if w := st.State.Waiting; w != nil {
+				c.waiting = containerReason{reason: w.Reason, message: w.Message}
+			}
+			if t := st.State.Terminated; t != nil {
+				c.terminated = containerReason{reason: t.Reason, message: t.Message, exitCode: t.ExitCode}
+			} else if t := st.LastState.Terminated; t != nil {
+				c.terminated = containerReason{reason: t.Reason, message: t.Message, exitCode: t.ExitCode}
+			}
+		}
+		if c.State == "" {
+			c.State = "not started"
+		}
+		pod.Containers = append(pod.Containers, c)
+	}
+	return pod
+}
+
+func describeContainerState(s containerState) string {
+	switch {
+	case s.Waiting != nil:
+		return "waiting: " + s.Waiting.Reason
+	case s.Terminated != nil:
+		return fmt.Sprintf("terminated: %s (exit %d)", s.Terminated.Reason, s.Terminated.ExitCode)
+	}
+	return ""
+}
+
+// objectKeys lists the data keys of a Secret or ConfigMap, nil when it
+// doesn't exist.
+func objectKeys(namespace, kind, name string) []string {
+	out, err := kubectlJSON("get", kind, name, "-n", namespace, "-o", "jsonpath={.data}")
+	if err != nil || out == "" {
+		return nil
+	}
+	var data map[string]string
+	if json.Unmarshal([]byte(out), &data) != nil {
+		return nil
+	}
+	return sortedKeys(data)
+}
+
+func debugLogs(namespace, pod, container string, previous bool) []string {
+	args := []string{"logs", pod, "-n", namespace, "-c", container, fmt.Sprintf("--tail=%d", debugLogLines)}
+	if previous {
+		args = append(args, "--previous")
+	}
+	out, err := kubectlJSON(args...)
+	if err != nil || strings.TrimSpace(out) == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(out, "\n"), "\n")
+}
+
+// debugEvents returns the events of the component's workload and pods,
+// oldest first.
+func debugEvents(namespace string, names []string) []envEvent {
+	out, err := kubectlJSON("get", "events", "-n", namespace, "--sort-by=.lastTimestamp", "-o", "json")
+	if err != nil {
+		return []envEvent{}
+	}
+	var list struct {
+		Items []struct {
+			InvolvedObject struct {
+				Name string `json:"name"`
+			} `json:"involvedObject"`
+			Type          string `json:"type"`
+			Reason        string `json:"reason"`
+			Message       string `json:"message"`
+			Count         int    `json:"count"`
+			LastTimestamp string `json:"lastTimestamp"`
+		} `json:"items"`
+	}
+	if json.Unmarshal([]byte(out), &list) != nil {
+		return []envEvent{}
+	}
+	events := []envEvent{}
+	for _, e := range list.Items {
+		if containsString(names, e.InvolvedObject.Name) {
+			events = append(events, envEvent{Type: e.Type, Reason: e.Reason, Message: e.Message, Count: e.Count, LastSeen: e.LastTimestamp})
+		}
+	}
+	return events
+}
+
+// appContainer is the container named after the component, else the
+// pod's first.
+func appContainer(pod debugPod, name string) *debugContainer {
+	for i := range pod.Containers {
+		if pod.Containers[i].Name == name {
+			return &pod.Containers[i]
+		}
+	}
+	if len(pod.Containers) == 0 {
+		return nil
+	}
+	return &pod.Containers[0]
+}
+
+func diffDebugEnv(spec *debugSpec, c *debugContainer) *debugEnvDiff {
+	if c == nil {
+		return nil
+	}
+	diff := &debugEnvDiff{Missing: []string{}, Injected: []string{}}
+	declared := map[string]bool{}
+	for _, e := range spec.Spec.Deployment.Env {
+		declared[e.Name] = true
+		if !c.env[e.Name] {
+			diff.Missing = append(diff.Missing, e.Name)
+		}
+	}
+	for _, name := range sortedKeys(c.env) {
+		if !declared[name] {
+			diff.Injected = append(diff.Injected, name)
+		}
+	}
+	sort.Strings(diff.Missing)
+	return diff
+}
+
+var (
+	// Patterns apps use to report a required env var that isn't set.
+	missingEnvPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)(?:environment variable|env var(?:iable)?)\s+["'` + "`" + `]?([A-Z][A-Z0-9_]+)["'` + "`" + `]?\s+(?:is\s+)?(?:not set|not defined|undefined|required|missing|must be set|is empty)`),
+		regexp.MustCompile(`(?i)(?:missing|required|undefined)\s+(?:environment variables?|env vars?)\s*:?\s*["'` + "`" + `]?([A-Z][A-Z0-9_]+)`),
+		regexp.MustCompile(`KeyError: ['"]([A-Z][A-Z0-9_]+)['"]`),
+		regexp.MustCompile(`\b([A-Z][A-Z0-9]*_[A-Z0-9_]+) (?:is not set|must be set|is required|not set)`),
+	}
+	// listenPattern picks the port out of "listening on :3000" style lines.
+	listenPattern = regexp.MustCompile(`(?i)\b(?:listening|serving|running|started|bound)\b[^\n]*?(?:port\s*|:)(\d{2,5})\b`)
+	// probeStatusPattern reads the status code of a failed HTTP probe.
+	probeStatusPattern = regexp.MustCompile(`statuscode: (\d{3})`)
+)
+
+// rankDebugCauses turns the gathered facts into likely causes, most
+// likely first. Each cause is reported once, from the first pod showing it.
+func rankDebugCauses(report debugReport, spec *debugSpec, name string) []debugCause {
+	causes := []debugCause{}
+	seen := map[string]bool{}
+	add := func(score int, title, evidence, fix string) bool {
+		if seen[title] {
+			return false
+		}
+		seen[title] = true
+		causes = append(causes, debugCause{Title: title, Evidence: evidence, Fix: fix, Score: score})
+		return true
+	}
+	// suggest attaches a change to the cause just added.
+	suggest := func(added bool, change specChange) {
+		if added {
+			causes[len(causes)-1].change = &change
+		}
+	}
+
+	if len(report.Pods) == 0 {
+		add(80, "No pods exist", fmt.Sprintf("nothing matches app.kubernetes.io/name=%s in %s", name, report.Namespace),
+			"check the operator's events and log: kindling status, kindling logs")
+	}
+	specPort := 0
+	if spec != nil {
+		specPort = spec.Spec.Deployment.Port
+	}
+
+	for _, pod := range report.Pods {
+		for i := range pod.Containers {
+			c := &pod.Containers[i]
+			switch c.waiting.reason {
+			case "ErrImagePull", "ImagePullBackOff", "InvalidImageName":
+				add(95, "Image can't be pulled", fmt.Sprintf("%s: %s %s", c.Image, c.waiting.reason, firstLine(c.waiting.message)),
+					"build and load it into the cluster (kindling build -f <file>), or fix the image name and tag")
+			case "CreateContainerConfigError":
+				add(90, "A referenced Secret or ConfigMap is missing", firstLine(c.waiting.message),
+					"create it (kindling secrets set <KEY> <value>) or remove the reference from the spec")
+			case "RunContainerError", "StartError", "CreateContainerError":
+				add(90, "Bad CMD or entrypoint", firstLine(c.waiting.message),
+					"check the Dockerfile's CMD/ENTRYPOINT, or spec.deployment.command and args")
+			}
+
+			t := c.terminated
+			switch {
+			case t.reason == "OOMKilled":
+				limit := "no limit set"
+				if c.MemoryLimit != "" {
+					limit = "limit " + c.MemoryLimit
+				}
+				added := add(95, "Out of memory (OOMKilled)", fmt.Sprintf("%s was killed for using too much memory (%s)", c.Name, limit),
+					"raise spec.deployment.resources.memoryLimit")
+				if c.Name == name && spec != nil {
+					suggest(added, specChange{Path: "spec.deployment.resources.memoryLimit", Value: doubledMemory(c.MemoryLimit),
+						Reason: "give the app twice the memory it was killed at"})
+				}
+			case t.exitCode == 126 || t.exitCode == 127 || t.reason == "StartError" || isBadCommandMessage(t.message):
+				add(90, "Bad CMD or entrypoint", fmt.Sprintf("%s exited with code %d %s", c.Name, t.exitCode, firstLine(t.message)),
+					"check the Dockerfile's CMD/ENTRYPOINT, or spec.deployment.command and args")
+			}
+
+			logs := append(append([]string{}, c.PreviousLogs...), c.Logs...)
+			for _, line := range logs {
+				for _, re := range missingEnvPatterns {
+					m := re.FindStringSubmatch(line)
+					if m == nil || c.env[m[1]] {
+						continue
+					}
+					add(85, "Missing env var "+m[1], strings.TrimSpace(line),
+						fmt.Sprintf("add %s to spec.deployment.env, or set it now: kindling env set %s %s=...", m[1], name, m[1]))
+				}
+				if isBadCommandMessage(line) && (t.exitCode != 0 || c.Restarts > 0) {
+					add(80, "Bad CMD or entrypoint", strings.TrimSpace(line),
+						"check the Dockerfile's CMD/ENTRYPOINT, or spec.deployment.command and args")
+				}
+			}
+
+			if specPort != 0 && c.Name == name {
+				for _, line := range logs {
+					m := listenPattern.FindStringSubmatch(line)
+					if m == nil {
+						continue
+					}
+					if port, _ := strconv.Atoi(m[1]); port != specPort && port != 0 {
+						suggest(add(85, "Port mismatch", fmt.Sprintf("the app logs %q but spec.deployment.port is %d", strings.TrimSpace(line), specPort),
+							fmt.Sprintf("set spec.deployment.port to %d, or make the app listen on $PORT", port)),
+							specChange{Path: "spec.deployment.port", Value: port, Reason: "match the port the app listens on"})
+					}
+					break
+				}
+				if c.probePort != "" && c.probePort != strconv.Itoa(specPort) {
+					if _, err := strconv.Atoi(c.probePort); err == nil {
+						add(70, "Probe port differs from the app port",
+							fmt.Sprintf("the readiness probe checks %s but spec.deployment.port is %d", c.probePort, specPort),
+							"redeploy so the operator regenerates the probe: kindling deploy -f <file>")
+					}
+				}
+			}
+
+			if t.exitCode != 0 && len(causes) == 0 {
+				add(40, fmt.Sprintf("The app exits with code %d", t.exitCode), c.LastState+c.State,
+					fmt.Sprintf("read the crash output: kindling logs %s --previous", name))
+			}
+		}
+	}
+
+	for _, e := range report.Events {
+		switch {
+		case e.Reason == "FailedScheduling":
+			add(85, "Pod can't be scheduled", e.Message,
+				"lower spec.deployment.resources.requests or add nodes (kindling init --workers N)")
+		case e.Reason == "Unhealthy" && strings.Contains(e.Message, "connection refused"):
+			add(65, "Nothing listens on the probed port", e.Message,
+				"make the app listen on 0.0.0.0 and on spec.deployment.port, not 127.0.0.1")
+		case e.Reason == "Unhealthy" && probeStatusPattern.MatchString(e.Message):
+			code := probeStatusPattern.FindStringSubmatch(e.Message)[1]
+			if code == "404" {
+				added := add(70, "Wrong health-check path", e.Message,
+					"set spec.deployment.healthCheck.path to a route the app serves, or type: tcp")
+				if spec != nil {
+					suggest(added, specChange{Path: "spec.deployment.healthCheck.type", Value: "tcp",
+						Reason: "check that the port accepts connections until the app serves a health route"})
+				}
+			} else {
+				add(60, "Health check returns "+code, e.Message, "check the app's health endpoint in its logs")
+			}
+		case e.Reason == "Unhealthy":
+			add(50, "Health check failing", e.Message, "check the app's health endpoint in its logs")
+		case e.Reason == "FailedMount":
+			add(80, "A volume can't be mounted", e.Message, "check the referenced ConfigMap, Secret, or PersistentVolumeClaim exists")
+		}
+	}
+
+	if report.EnvDiff != nil && len(report.EnvDiff.Missing) > 0 {
+		add(50, "Pod is running an older spec", "env vars in the spec but not in the container: "+strings.Join(report.EnvDiff.Missing, ", "),
+			fmt.Sprintf("wait for the rollout or restart it: kubectl rollout restart deployment/%s -n %s", name, report.Namespace))
+	}
+
+	sort.SliceStable(causes, func(i, j int) bool { return causes[i].Score > causes[j].Score })
+	return causes
+}
+
+// doubledMemory is twice a memory limit, or 512Mi when there is none.
+func doubledMemory(limit string) string {
+	q, err := resource.ParseQuantity(limit)
+	if err != nil || q.IsZero() {
+		return "512Mi"
+	}
+	q.Add(q)
+	return q.String()
+}
+
+func isBadCommandMessage(s string) bool {
+	for _, marker := range []string{"executable file not found", "exec format error", "no such file or directory", "permission denied", "command not found"} {
+		if strings.Contains(s, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+func printDebugReport(r debugReport) {
+	header(fmt.Sprintf("Debugging %s (%s)", r.Component, r.Namespace))
+
+	if len(r.Pods) == 0 {
+		fmt.Printf("  %sNo pods%s\n", colorDim, colorReset)
+	}
+	for _, pod := range r.Pods {
+		fmt.Printf("\n  %s%s%s  %s\n", colorBold, pod.Name, colorReset, dimText(pod.Phase))
+		for _, c := range pod.Containers {
+			icon := colorGreen + "✓" + colorReset
+			if !c.Ready {
+				icon = colorRed + "✗" + colorReset
+			}
+			fmt.Printf("    %s %s  %s  restarts %d  %s\n", icon, c.Name, c.State, c.Restarts, dimText(c.Image))
+			if c.LastState != "" {
+				fmt.Printf("      %s\n", dimText("last: "+c.LastState))
+			}
+			if c.Probe != "" {
+				fmt.Printf("      %s\n", dimText("probe: "+c.Probe))
+			}
+		}
+	}
+
+	if len(r.Events) > 0 {
+		header("Events")
+		for _, e := range r.Events {
+			icon := "•"
+			if e.Type == "Warning" {
+				icon = colorYellow + "⚡" + colorReset
+			}
+			count := ""
+			if e.Count > 1 {
+				count = dimText(fmt.Sprintf(" ×%d", e.Count))
+			}
+			fmt.Printf("  %s %s%s  %s\n", icon, e.Reason, count, dimText(firstLine(e.Message)))
+		}
+	}
+
+	if d := r.EnvDiff; d != nil {
+		header("Env vars")
+		if len(d.Missing) == 0 {
+			fmt.Printf("  %s✓ every env var in the spec is set in the container%s\n", colorGreen, colorReset)
+		} else {
+			fmt.Printf("  %s✗ in the spec, not in the container: %s%s\n", colorRed, strings.Join(d.Missing, ", "), colorReset)
+		}
+		if len(d.Injected) > 0 {
+			fmt.Printf("  %s\n", dimText("also set by the operator or envFrom: "+strings.Join(d.Injected, ", ")))
+		}
+	}
+
+	for _, pod := range r.Pods {
+		for _, c := range pod.Containers {
+			if len(c.PreviousLogs) > 0 {
+				header(fmt.Sprintf("Logs before the last crash: %s/%s", pod.Name, c.Name))
+				printDebugLogs(c.PreviousLogs)
+			}
+			if len(c.Logs) > 0 {
+				header(fmt.Sprintf("Logs: %s/%s", pod.Name, c.Name))
+				printDebugLogs(c.Logs)
+			}
+		}
+	}
+
+	header("Likely causes")
+	if len(r.Causes) == 0 {
+		if debugAllReady(r) {
+			success("Nothing looks wrong — every container is ready")
+		} else {
+			warn("No known cause recognised — the logs and events above are the place to look")
+		}
+		fmt.Println()
+		return
+	}
+	for i, c := range r.Causes {
+		fmt.Printf("\n  %s%d. %s%s\n", colorBold, i+1, c.Title, colorReset)
+		fmt.Printf("     %s\n", dimText(c.Evidence))
+		fmt.Printf("     %s→ %s%s\n", colorCyan, c.Fix, colorReset)
+	}
+	fmt.Println()
+}
+
+// debugAllReady reports whether the component has pods and every
+// container in them is ready.
+func debugAllReady(r debugReport) bool {
+	for _, pod := range r.Pods {
+		for _, c := range pod.Containers {
+			if !c.Ready {
+				return false
+			}
+		}
+	}
+	return len(r.Pods) > 0
+}
+
+func printDebugLogs(lines []string) {
+	for _, l := range lines {
+		fmt.Printf("  %s│%s %s\n", colorDim, colorReset, l)
+	}
+}
@@ -0,0 +1,439 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete [env-name...]",
+	Short: "Tear down DevStagingEnvironments and everything they created",
+	Long: `Deletes DevStagingEnvironments — the counterpart of kindling deploy.
+
+The operator's children (Deployments, StatefulSets and their volumes,
+Services, Ingress, Secrets, seed Jobs) are removed with the environment;
+delete waits until they are gone before reporting what was freed.
+
+Environments are named as arguments, read from the manifests of a file
+(--file), or selected with --all. --prune-images also removes the app
+images of the deleted environments from the Kind nodes, unless another
+environment still runs them.
+
+Examples:
+  kindling delete myuser-app
+  kindling delete -f dev-environment.yaml
+  kindling delete --all -y
+  kindling delete myuser-app --prune-images`,
+	SilenceUsage:      true,
+	ValidArgsFunction: completeDSEs,
+	RunE:              runDelete,
+}
+
+var (
+	deleteAll         bool
+	deleteFile        string
+	deleteForce       bool
+	deletePruneImages bool
+	deleteTimeout     time.Duration
+)
+
+func init() {
+	deleteCmd.Flags().BoolVar(&deleteAll, "all", false, "Delete every DevStagingEnvironment in the cluster")
+	deleteCmd.Flags().StringVarP(&deleteFile, "file", "f", "", "Delete the DevStagingEnvironments declared in this YAML file")
+	deleteCmd.Flags().BoolVarP(&deleteForce, "force", "y", false, "With --all, skip the confirmation prompt")
+	deleteCmd.Flags().BoolVar(&deletePruneImages, "prune-images", false, "Remove the environments' app images from the Kind nodes")
+	deleteCmd.Flags().DurationVar(&deleteTimeout, "timeout", 2*time.Minute, "How long to wait for the environments to be removed")
+	rootCmd.AddCommand(deleteCmd)
+}
+
+// deleteTarget is one DevStagingEnvironment to delete.
+type deleteTarget struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Image     string `json:"image"`
+	// Removed counts the children deleted with the environment, by kind.
+	Removed map[string]int `json:"removed"`
+}
+
+// deleteResult is the JSON form of delete's output.
+type deleteResult struct {
+	Environments []deleteTarget `json:"environments"`
+	PrunedImages []string       `json:"prunedImages,omitempty"`
+	FreedBytes   int64          `json:"freedBytes,omitempty"`
+	TimedOut     []string       `json:"timedOut,omitempty"`
+}
+
+func runDelete(cmd *cobra.Command, args []string) error {
+	selectors := 0
+	for _, set := range []bool{len(args) > 0, deleteAll, deleteFile != ""} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		return fmt.Errorf("name the environments to delete, or use exactly one of --all and --file")
+	}
+
+	dses, err := listDSEs()
+	if err != nil {
+		return err
+	}
+	targets, err := resolveDeleteTargets(dses, args)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		warn("No DevStagingEnvironments found — nothing to delete")
+		return render(deleteResult{Environments: []deleteTarget{}}, nil)
+	}
+
+	header("Deleting DevStagingEnvironments")
+	for _, t := range targets {
+		step("📦", fmt.Sprintf("%s %s", t.Name, dimText(t.Namespace)))
+	}
+
+	if deleteAll && !deleteForce {
+		if isJSONOutput() {
+			return fmt.Errorf("--all needs -y with -o json")
+		}
+		fmt.Printf("\n  %s⚠️  This deletes %d environment(s) and their data.%s\n", colorYellow, len(targets), colorReset)
+		fmt.Printf("  Continue? [y/N] ")
+		var confirm string
+		fmt.Scanln(&confirm)
+		if confirm != "y" && confirm != "Y" {
+			fmt.Println("  Aborted.")
+			return nil
+		}
+	}
+
+	for i := range targets {
+		targets[i].Removed = countEnvironmentChildren(targets[i].Namespace, targets[i].Name)
+	}
+
+	// Foreground deletion keeps each DSE until the garbage collector has
+	// removed its children, so waiting for the DSE waits for everything.
+	for _, t := range targets {
+		if out, err := captureKubectl("delete", "devstagingenvironment", t.Name, "-n", t.Namespace,
+			"--cascade=foreground", "--wait=false"); err != nil {
+			return fmt.Errorf("cannot delete %s: %s", t.Name, out)
+		}
+	}
+	result := deleteResult{Environments: targets}
+	result.TimedOut = waitForDeletion(targets, deleteTimeout)
+	if len(result.TimedOut) == 0 {
+		success(fmt.Sprintf("Deleted %d environment(s)", len(targets)))
+	} else {
+		warn(fmt.Sprintf("Still terminating after %s: %s — check with: kindling status", deleteTimeout, strings.Join(result.TimedOut, ", ")))
+	}
+
+	if deletePruneImages {
+		result.PrunedImages, result.FreedBytes = pruneEnvironmentImages(targets, dses)
+	}
+
+	return render(result, func() { printDeleteSummary(result) })
+}
+
+// dseSummary holds the fields delete reads from a live DSE.
+type dseSummary struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Deployment struct {
+			Image string `json:"image"`
+		} `json:"deployment"`
+	} `json:"spec"`
+}
+
+func listDSEs() ([]dseSummary, error) {
+	out, err := kubectlJSON("get", "devstagingenvironments", "-A", "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("cannot list DevStagingEnvironments — is kindling initialised? (kindling init)")
+	}
+	var list struct {
+		Items []dseSummary `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return nil, fmt.Errorf("cannot parse DevStagingEnvironments: %w", err)
+	}
+	return list.Items, nil
+}
+
+// resolveDeleteTargets picks the live DSEs named by args, --all, or
+// --file. A name matches in any namespace, but must be unambiguous.
+func resolveDeleteTargets(dses []dseSummary, args []string) ([]deleteTarget, error) {
+	type ref struct{ name, namespace string }
+	var refs []ref
+	switch {
+	case deleteAll:
+		for _, d := range dses {
+			refs = append(refs, ref{d.Metadata.Name, d.Metadata.Namespace})
+		}
+	case deleteFile != "":
+		names, err := manifestDSENames(deleteFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range names {
+			refs = append(refs, ref{n[0], n[1]})
+		}
+	default:
+		for _, a := range args {
+			refs = append(refs, ref{name: a})
+		}
+	}
+
+	var targets []deleteTarget
+	seen := map[string]bool{}
+	for _, r := range refs {
+		var matches []dseSummary
+		for _, d := range dses {
+			if d.Metadata.Name == r.name && (r.namespace == "" || d.Metadata.Namespace == r.namespace) {
+				matches = append(matches, d)
+			}
+		}
+		switch {
+		case len(matches) == 0 && deleteFile != "":
+			step("⏭️ ", fmt.Sprintf("%s is not deployed", r.name))
+			continue
+		case len(matches) == 0:
+			return nil, fmt.Errorf("DevStagingEnvironment %q not found — see: kindling status", r.name)
+		case len(matches) > 1:
+			return nil, fmt.Errorf("%q exists in several namespaces — list it in a file with its namespace and use --file", r.name)
+		}
+		d := matches[0]
+		key := d.Metadata.Namespace + "/" + d.Metadata.Name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		targets = append(targets, deleteTarget{Name: d.Metadata.Name, Namespace: d.Metadata.Namespace, Image: d.Spec.Deployment.Image})
+	}
+	return targets, nil
+}
+
+// manifestDSENames returns the [name, namespace] of every DSE in a YAML
+// file. The namespace is "" when the manifest leaves it out.
+func manifestDSENames(file string) ([][2]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", file, err)
+	}
+	var names [][2]string
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name      string `yaml:"name"`
+				Namespace string `yaml:"namespace"`
+			} `yaml:"metadata"`
+		}
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML in %s: %w", file, err)
+		}
+		if doc.Kind == "DevStagingEnvironment" && doc.Metadata.Name != "" {
+			names = append(names, [2]string{doc.Metadata.Name, doc.Metadata.Namespace})
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no DevStagingEnvironment found in %s", file)
+	}
+	return names, nil
+}
+
+// countEnvironmentChildren counts the operator-created objects of an
+// environment by kind, matched the way status matches them.
+func countEnvironmentChildren(ns, name string) map[string]int {
+	out, err := kubectlJSON("get", "deployments,statefulsets,services,ingresses,jobs,secrets,pods",
+		"-n", ns, "-l", operatorManagedBy, "-o", "json")
+	counts := map[string]int{}
+	if err != nil {
+		return counts
+	}
+	var list struct {
+		Items []kubeObject `json:"items"`
+	}
+	if json.Unmarshal([]byte(out), &list) != nil {
+		return counts
+	}
+	for _, o := range list.Items {
+		l := o.Metadata.Labels
+		if l["app.kubernetes.io/instance"] == name || l["app.kubernetes.io/part-of"] == name {
+			counts[o.Kind]++
+		}
+	}
+	return counts
+}
+
+// waitForDeletion polls until every target is gone or timeout passes, and
+// returns the names still present.
+func waitForDeletion(targets []deleteTarget, timeout time.Duration) []string {
+	spin := startSpinner("Waiting for the environments and their children to be removed")
+	defer spin.stop()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		var remaining []string
+		for _, t := range targets {
+			out, err := kubectlJSON("get", "devstagingenvironment", t.Name, "-n", t.Namespace,
+				"--ignore-not-found", "-o", "name")
+			if err != nil || out != "" {
+				remaining = append(remaining, t.Name)
+			}
+		}
+		if len(remaining) == 0 || time.Now().After(deadline) {
+			return remaining
+		}
+		spin.update(fmt.Sprintf("Waiting for %s (%d of %d left)", strings.Join(remaining, ", "), len(remaining), len(targets)))
+		time.Sleep(time.Second)
+	}
+}
+
+// ── Image pruning ───────────────────────────────────────────────
+
+// pruneEnvironmentImages removes the app images of the deleted
+// environments from every Kind node, skipping images another remaining
+// environment still uses, and returns the removed images and bytes freed.
+// A remote cluster's nodes are out of reach, so nothing is pruned there.
+func pruneEnvironmentImages(targets []deleteTarget, dses []dseSummary) ([]string, int64) {
+	if remoteCluster() {
+		return nil, 0
+	}
+	deleted := map[string]bool{}
+	for _, t := range targets {
+		deleted[t.Namespace+"/"+t.Name] = true
+	}
+	inUse := map[string]bool{}
+	for _, d := range dses {
+		if !deleted[d.Metadata.Namespace+"/"+d.Metadata.Name] {
+			inUse[normalizeImageRef(d.Spec.Deployment.Image)] = true
+		}
+	}
+	images := map[string]bool{}
+	for _, t := range targets {
+		if ref := normalizeImageRef(t.Image); t.Image != "" && !inUse[ref] {
+			images[ref] = true
+		}
+	}
+	if len(images) == 0 {
+		return nil, 0
+	}
+
+	nodes, err := clusterNodes(clusterName)
+	if err != nil {
+		warn(fmt.Sprintf("Cannot list the nodes of cluster %q — images not pruned", clusterName))
+		return nil, 0
+	}
+
+	header("Pruning images from Kind nodes")
+	pruned := map[string]bool{}
+	var freed int64
+	for _, node := range nodes {
+		out, err := runCapture("docker", "exec", node, "crictl", "images", "-o", "json")
+		if err != nil {
+			warn(fmt.Sprintf("%s: cannot list images", node))
+			continue
+		}
+		var list struct {
+			Images []struct {
+				ID       string   `json:"id"`
+				RepoTags []string `json:"repoTags"`
+				Size     string   `json:"size"`
+			} `json:"images"`
+		}
+		if json.Unmarshal([]byte(out), &list) != nil {
+			continue
+		}
+		for _, img := range list.Images {
+			var tag string
+			for _, t := range img.RepoTags {
+				if images[normalizeImageRef(t)] {
+					tag = normalizeImageRef(t)
+					break
+				}
+			}
+			if tag == "" {
+				continue
+			}
+			if rmOut, err := runSilent("docker", "exec", node, "crictl", "rmi", img.ID); err != nil {
+				warn(fmt.Sprintf("%s: cannot remove %s: %s", node, tag, rmOut))
+				continue
+			}
+			size, _ := strconv.ParseInt(img.Size, 10, 64)
+			freed += size
+			pruned[tag] = true
+			step("🧹", fmt.Sprintf("%s: removed %s (%s)", node, tag, devstaging.FormatBytes(size)))
+		}
+	}
+
+	names := make([]string, 0, len(pruned))
+	for name := range pruned {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, freed
+}
+
+// normalizeImageRef expands an image reference the way containerd names
+// it, so "myapp:dev" and "docker.io/library/myapp:dev" compare equal.
+func normalizeImageRef(ref string) string {
+	if ref == "" || strings.Contains(ref, "@") {
+		return ref
+	}
+	name, tag := ref, "latest"
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		name, tag = ref[:i], ref[i+1:]
+	}
+	first := strings.SplitN(name, "/", 2)[0]
+	isHost := strings.ContainsAny(first, ".:") || first == "localhost"
+	switch {
+	case !strings.Contains(name, "/"):
+		name = "docker.io/library/" + name
+	case !isHost:
+		name = "docker.io/" + name
+	}
+	return name + ":" + tag
+}
+
+func printDeleteSummary(r deleteResult) {
+	header("Freed")
+	for _, t := range r.Environments {
+		kinds := make([]string, 0, len(t.Removed))
+		for kind := range t.Removed {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		parts := make([]string, 0, len(kinds))
+		for _, kind := range kinds {
+			parts = append(parts, fmt.Sprintf("%d %s", t.Removed[kind], kind))
+		}
+		detail := "no child objects"
+		if len(parts) > 0 {
+			detail = strings.Join(parts, ", ")
+		}
+		fmt.Printf("    📦 %s  %s\n", t.Name, dimText(detail))
+	}
+	if len(r.PrunedImages) > 0 {
+		fmt.Printf("    🧹 %d image(s), %s on the Kind nodes\n", len(r.PrunedImages), devstaging.FormatBytes(r.FreedBytes))
+	}
+	fmt.Println()
+}
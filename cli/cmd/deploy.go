@@ -3,8 +3,11 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/deploy"
 )
 
 var deployCmd = &cobra.Command{
@@ -13,16 +16,47 @@ var deployCmd = &cobra.Command{
 	Long: `Applies one or more DevStagingEnvironment custom resources from a YAML
 file into the current cluster.
 
+With --diff, the file is first dry-run on the server and compared with the
+live DevStagingEnvironments; the changed spec fields (images, env vars,
+dependencies, ...) are shown and you are asked to confirm before anything
+is applied. Pass -y to apply without the prompt.
+
+Before applying, the CPU and memory the file requests are compared with
+what the Kind cluster has free, with a warning when they don't fit —
+the same insufficient_capacity check as kindling validate.
+
+With --env, the manifests go into the namespace of that environment,
+env-<name>, which is created on first use with the kindling secrets
+copied into it; --env-from-branch names the environment after the git
+branch of the file's directory. Without either, they go into the current
+environment (see kindling env switch), or the default namespace. A
+manifest that sets metadata.namespace itself can't be put elsewhere.
+
 Examples:
   kindling deploy -f examples/sample-app/dev-environment.yaml
-  kindling deploy -f examples/platform-api/dev-environment.yaml`,
+  kindling deploy -f examples/platform-api/dev-environment.yaml
+  kindling deploy -f dev-environment.yaml --diff
+  kindling deploy -f dev-environment.yaml --diff -o json   # diff only, no apply
+  kindling deploy -f dev-environment.yaml --env feature-login
+  kindling deploy -f dev-environment.yaml --env-from-branch`,
 	RunE: runDeploy,
 }
 
-var deployFile string
+var (
+	deployFile          string
+	deployShowDiff      bool
+	deployForce         bool
+	deployEnv           string
+	deployEnvFromBranch bool
+)
 
 func init() {
 	deployCmd.Flags().StringVarP(&deployFile, "file", "f", "", "Path to DevStagingEnvironment YAML file (required)")
+	deployCmd.Flags().BoolVar(&deployShowDiff, "diff", false, "Show what would change against the live objects and confirm before applying")
+	deployCmd.Flags().BoolVarP(&deployForce, "force", "y", false, "With --diff, apply without the confirmation prompt")
+	deployCmd.Flags().StringVar(&deployEnv, "env", "", "Environment to deploy into (default: the current environment)")
+	_ = deployCmd.RegisterFlagCompletionFunc("env", completeEnvironments)
+	deployCmd.Flags().BoolVar(&deployEnvFromBranch, "env-from-branch", false, "Deploy into an environment named after the current git branch")
 	_ = deployCmd.MarkFlagRequired("file")
 	rootCmd.AddCommand(deployCmd)
 }
@@ -32,11 +66,76 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("file not found: %s", deployFile)
 	}
 
+	env, err := resolveEnvName(deployEnv, deployEnvFromBranch, filepath.Dir(deployFile))
+	if err != nil {
+		return err
+	}
+	if env == "" {
+		env = currentEnvironment()
+	}
+	namespace := ""
+	if env != "" {
+		namespace = environmentNamespace(env)
+	}
+
+	warnings := deployCapacityWarnings(deployFile)
+
+	var diffs []dseDiff
+	if deployShowDiff {
+		if diffs, err = diffDeployFile(deployFile, namespace); err != nil {
+			return err
+		}
+		// JSON output can't prompt, so without -y it only reports the diff.
+		if isJSONOutput() && !deployForce {
+			return printJSON(deployResult{File: deployFile, Environment: env, Resources: []string{}, Diff: diffs, Warnings: warnings})
+		}
+	}
+
+	if isJSONOutput() {
+		if env != "" {
+			if _, err := ensureEnvironment(env); err != nil {
+				return err
+			}
+		}
+		return runDeployJSON(env, namespace, diffs, warnings)
+	}
+	for _, w := range warnings {
+		warn(w)
+	}
+
+	if deployShowDiff {
+		header("Changes")
+		printDeployDiff(diffs)
+		if !hasDeployChanges(diffs) {
+			success("No changes — nothing to apply")
+			return nil
+		}
+		if !deployForce {
+			fmt.Printf("  Apply these changes? [y/N] ")
+			var confirm string
+			fmt.Scanln(&confirm)
+			if confirm != "y" && confirm != "Y" {
+				fmt.Println("  Aborted.")
+				return nil
+			}
+		}
+	}
+
 	header("Deploying DevStagingEnvironment")
 
+	if env != "" {
+		step("🌿", fmt.Sprintf("Environment %s %s", env, dimText(namespace)))
+		if _, err := ensureEnvironment(env); err != nil {
+			return err
+		}
+	}
 	step("📄", fmt.Sprintf("Applying %s", deployFile))
-	if err := run("kubectl", "apply", "-f", deployFile); err != nil {
-		return fmt.Errorf("kubectl apply failed: %w", err)
+	applied, err := applyManifestFile(deployFile, namespace, false)
+	for _, obj := range applied {
+		fmt.Printf("    %s applied\n", objectName(obj))
+	}
+	if err != nil {
+		return fmt.Errorf("apply failed: %w", err)
 	}
 	success("Resources applied")
 
@@ -44,8 +143,16 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 	step("📋", "Current DevStagingEnvironments:")
 	fmt.Println()
-	if err := run("kubectl", "get", "devstagingenvironments", "-o", "wide"); err != nil {
+	dses := statusRows("devstagingenvironments", namespace, "", func(o map[string]interface{}) map[string]string {
+		return map[string]string{"name": statusField(o, "metadata", "name"), "image": statusField(o, "spec", "deployment", "image"),
+			"replicas": statusField(o, "spec", "deployment", "replicas"), "available": statusField(o, "status", "availableReplicas"),
+			"ready": statusField(o, "status", "deploymentReady")}
+	})
+	if len(dses) == 0 {
 		warn("Could not list DevStagingEnvironments (CRD may not be installed)")
+	} else {
+		printStatusRows(append([]map[string]string{{"name": "NAME", "image": "IMAGE", "replicas": "REPLICAS", "available": "AVAILABLE", "ready": "READY"}}, dses...),
+			[]string{"name", "image", "replicas", "available", "ready"}, "")
 	}
 
 	fmt.Println()
@@ -55,3 +162,54 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// deployResult is the JSON form of deploy's output. Diff is only set with
+// --diff; Applied is false when the diff was reported without applying.
+type deployResult struct {
+	File        string    `json:"file"`
+	Environment string    `json:"environment,omitempty"`
+	Resources   []string  `json:"resources"`
+	Diff        []dseDiff `json:"diff,omitempty"`
+	Warnings    []string  `json:"warnings,omitempty"`
+	Applied     bool      `json:"applied"`
+}
+
+// runDeployJSON applies the file and reports the applied resources as JSON
+// instead of printing progress.
+func runDeployJSON(env, namespace string, diffs []dseDiff, warnings []string) error {
+	applied, err := applyManifestFile(deployFile, namespace, false)
+	if err != nil {
+		return fmt.Errorf("apply failed: %w", err)
+	}
+	result := deployResult{File: deployFile, Environment: env, Resources: []string{}, Diff: diffs, Warnings: warnings, Applied: true}
+	for _, obj := range applied {
+		result.Resources = append(result.Resources, objectName(obj))
+	}
+	return printJSON(result)
+}
+
+// deployCapacityWarnings checks the file's resource requests against the
+// cluster's free capacity.
+func deployCapacityWarnings(file string) []string {
+	capacity, ok := readClusterCapacity()
+	if !ok {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	return deploy.CapacityWarnings(data, capacity)
+}
+
+// withNamespace adds -n namespace to kubectl arguments; "" is the
+// namespace setting, if any.
+func withNamespace(namespace string, args ...string) []string {
+	if namespace == "" {
+		namespace = kubeNamespace
+	}
+	if namespace == "" {
+		return args
+	}
+	return append(args, "-n", namespace)
+}
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// deploy --diff
+// ────────────────────────────────────────────────────────────────────────────
+//
+// The desired objects come from a server-side dry-run apply, so CRD
+// defaults and admission are applied before comparing against the live
+// objects and only real changes show up.
+
+// dseDiff is the pending change to one DevStagingEnvironment.
+type dseDiff struct {
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace"`
+	Action    string      `json:"action"` // "create", "update", or "unchanged"
+	Changes   []dseChange `json:"changes"`
+}
+
+// dseChange is one changed field under spec. Path uses dots for fields
+// and [key] for list items matched by name or type, e.g.
+// deployment.env[DATABASE_URL].value.
+type dseChange struct {
+	Op   string      `json:"op"` // "add", "remove", or "change"
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// diffDeployFile dry-runs the file against the API server and diffs each
+// DevStagingEnvironment in it with the live object — in namespace, when
+// it's not "", where deploy --env will put it. The dry run itself happens
+// in the default namespace, as the environment's may not exist yet.
+func diffDeployFile(file, namespace string) ([]dseDiff, error) {
+	objects, err := applyManifestFile(file, "", true)
+	if err != nil {
+		return nil, fmt.Errorf("server-side dry-run failed: %w", err)
+	}
+
+	var diffs []dseDiff
+	for _, desired := range objects {
+		if desired["kind"] != "DevStagingEnvironment" {
+			continue
+		}
+		meta, _ := desired["metadata"].(map[string]interface{})
+		name, _ := meta["name"].(string)
+		ns, _ := meta["namespace"].(string)
+		if namespace != "" {
+			ns = namespace
+		} else if ns == "" {
+			ns = "default"
+		}
+		d := dseDiff{Name: name, Namespace: ns, Changes: []dseChange{}}
+
+		live, err := getObject("devstagingenvironments", ns, name)
+		if errors.Is(err, errNotFound) {
+			d.Action = "create"
+			diffValues("", nil, desired["spec"], &d.Changes)
+			diffs = append(diffs, d)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read live %s: %w", name, err)
+		}
+		diffValues("", live["spec"], desired["spec"], &d.Changes)
+		d.Action = "update"
+		if len(d.Changes) == 0 {
+			d.Action = "unchanged"
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs, nil
+}
+
+// diffValues appends the changes that turn old into new. Maps are
+// compared key by key and lists of objects by their name or type, so a
+// reordered env list isn't reported as a change.
+func diffValues(path string, old, new interface{}, changes *[]dseChange) {
+	switch {
+	case old == nil && new == nil:
+		return
+	case old == nil:
+		// Spell out a new object field by field.
+		if m, ok := new.(map[string]interface{}); ok {
+			for _, k := range sortedKeys(m) {
+				diffValues(joinPath(path, k), nil, m[k], changes)
+			}
+			return
+		}
+		*changes = append(*changes, dseChange{Op: "add", Path: path, New: new})
+		return
+	case new == nil:
+		*changes = append(*changes, dseChange{Op: "remove", Path: path, Old: old})
+		return
+	}
+
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := map[string]bool{}
+		for k := range oldMap {
+			keys[k] = true
+		}
+		for k := range newMap {
+			keys[k] = true
+		}
+		for _, k := range sortedKeys(keys) {
+			diffValues(joinPath(path, k), oldMap[k], newMap[k], changes)
+		}
+		return
+	}
+
+	oldList, oldIsList := old.([]interface{})
+	newList, newIsList := new.([]interface{})
+	if oldIsList && newIsList {
+		oldKeyed, ok1 := keyedItems(oldList)
+		newKeyed, ok2 := keyedItems(newList)
+		if ok1 && ok2 {
+			keys := map[string]bool{}
+			for k := range oldKeyed {
+				keys[k] = true
+			}
+			for k := range newKeyed {
+				keys[k] = true
+			}
+			for _, k := range sortedKeys(keys) {
+				itemPath := fmt.Sprintf("%s[%s]", path, k)
+				o, n := oldKeyed[k], newKeyed[k]
+				switch {
+				case o == nil:
+					*changes = append(*changes, dseChange{Op: "add", Path: itemPath, New: n})
+				case n == nil:
+					*changes = append(*changes, dseChange{Op: "remove", Path: itemPath, Old: o})
+				default:
+					diffValues(itemPath, o, n, changes)
+				}
+			}
+			return
+		}
+	}
+
+	if !jsonEqual(old, new) {
+		*changes = append(*changes, dseChange{Op: "change", Path: path, Old: old, New: new})
+	}
+}
+
+// keyedItems indexes a list of objects by their "name" (env vars) or
+// "type" (dependencies) field. It reports false for any other list.
+func keyedItems(list []interface{}) (map[string]interface{}, bool) {
+	if len(list) == 0 {
+		return map[string]interface{}{}, true
+	}
+	for _, field := range []string{"name", "type"} {
+		keyed := map[string]interface{}{}
+		for _, item := range list {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			key, ok := m[field].(string)
+			if !ok || keyed[key] != nil {
+				keyed = nil
+				break
+			}
+			keyed[key] = m
+		}
+		if keyed != nil {
+			return keyed, true
+		}
+	}
+	return nil, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonEqual(a, b interface{}) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+// hasDeployChanges reports whether applying would change anything.
+func hasDeployChanges(diffs []dseDiff) bool {
+	for _, d := range diffs {
+		if d.Action != "unchanged" {
+			return true
+		}
+	}
+	return false
+}
+
+func printDeployDiff(diffs []dseDiff) {
+	if len(diffs) == 0 {
+		warn("No DevStagingEnvironments in the file — nothing to diff")
+		return
+	}
+	for _, d := range diffs {
+		fmt.Fprintln(os.Stderr)
+		switch d.Action {
+		case "create":
+			step("🆕", fmt.Sprintf("%s%s%s (new in %s)", colorBold, d.Name, colorReset, d.Namespace))
+		case "unchanged":
+			step("✔️ ", fmt.Sprintf("%s%s%s %s", colorBold, d.Name, colorReset, dimText("(no changes)")))
+			continue
+		default:
+			step("📝", fmt.Sprintf("%s%s%s (%d change(s))", colorBold, d.Name, colorReset, len(d.Changes)))
+		}
+		for _, c := range d.Changes {
+			switch c.Op {
+			case "add":
+				fmt.Fprintf(os.Stderr, "       %s+ %s: %s%s\n", colorGreen, c.Path, formatDiffValue(c.New), colorReset)
+			case "remove":
+				fmt.Fprintf(os.Stderr, "       %s- %s: %s%s\n", colorRed, c.Path, formatDiffValue(c.Old), colorReset)
+			default:
+				fmt.Fprintf(os.Stderr, "       %s~ %s: %s → %s%s\n", colorYellow, c.Path, formatDiffValue(c.Old), formatDiffValue(c.New), colorReset)
+			}
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+// formatDiffValue renders scalars bare and objects as compact JSON.
+func formatDiffValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
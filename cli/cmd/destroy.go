@@ -23,6 +23,9 @@ func init() {
 }
 
 func runDestroy(cmd *cobra.Command, args []string) error {
+	if err := requireLocalCluster("kindling destroy"); err != nil {
+		return err
+	}
 	header("Destroying Kind cluster")
 
 	if !clusterExists(clusterName) {
@@ -42,20 +45,23 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Stop any running tunnel before tearing down the cluster.
-	if info, _ := readTunnelInfo(); info != nil && info.PID > 0 && processAlive(info.PID) {
-		step("🛑", "Stopping tunnel...")
-		_ = stopTunnel()
+	// Stop any running tunnels before tearing down the cluster.
+	if tunnels, _ := loadTunnels(); len(tunnels) > 0 {
+		step("🛑", "Stopping tunnels...")
+		_ = stopTunnels("")
 	}
 
-	step("💥", fmt.Sprintf("kind delete cluster --name %s", clusterName))
-	if err := run("kind", "delete", "cluster", "--name", clusterName); err != nil {
+	step("💥", fmt.Sprintf("Deleting %s cluster %s", clusterProvider().Name(), clusterName))
+	if err := deleteCluster(clusterName); err != nil {
 		return fmt.Errorf("failed to delete cluster: %w", err)
 	}
 
 	success("Cluster deleted")
 	fmt.Println()
 	fmt.Printf("  Recreate with: %skindling init%s\n", colorCyan, colorReset)
+	if _, err := runSilent("docker", "buildx", "inspect", buildCacheBuilder); err == nil {
+		fmt.Printf("  %sThe build cache is kept, so rebuilds reuse its layers — see: kindling cache stats%s\n", colorDim, colorReset)
+	}
 	fmt.Println()
 
 	return nil
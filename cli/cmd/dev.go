@@ -0,0 +1,456 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jeffvincent/kindling/cli/internal/daemon"
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+	"github.com/spf13/cobra"
+)
+
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Watch the source tree and redeploy changed services live",
+	Long: `Runs the inner dev loop against the local Kind cluster:
+
+  1. Applies the DevStagingEnvironment manifest
+  2. Builds every service image and loads it into Kind
+  3. Watches each service's build context for changes
+  4. On change, rebuilds just that image, loads it, and patches the
+     DevStagingEnvironment so the operator rolls its pods
+  5. Streams the pods' logs, prefixed by service, until Ctrl+C
+
+Each build is tagged <image>:dev-<timestamp> so every rebuild is a new
+image the Deployment has to roll to. Build contexts are resolved the same
+way as kindling validate: the repo directory (or .kindling/dockerfiles
+overlay) named after each image.
+
+--builder builds on a remote docker buildx builder, as in kindling build.
+
+--sync skips the rebuild for Python, Node, Ruby, and PHP services: changed
+files are copied into the running pods, under the Dockerfile's WORKDIR,
+in about a second. The app has to reload them itself (uvicorn --reload,
+nodemon, flask --debug, ...). Changes to the Dockerfile or a dependency
+manifest (package.json, requirements.txt, ...) still rebuild, and synced
+files are lost when a pod restarts until the next rebuild.
+
+Examples:
+  kindling dev -f dev-environment.yaml
+  kindling dev -f dev-environment.yaml -r ~/src/shop
+  kindling dev -f dev-environment.yaml --no-logs --interval 2s
+  kindling dev -f dev-environment.yaml --sync`,
+	SilenceUsage: true,
+	RunE:         runDev,
+}
+
+var (
+	devFile     string
+	devRepoPath string
+	devInterval time.Duration
+	devNoLogs   bool
+	devSyncMode bool
+	devBuilder  string
+)
+
+func init() {
+	devCmd.Flags().StringVarP(&devFile, "file", "f", "", "DevStagingEnvironment YAML to run (required)")
+	devCmd.Flags().StringVarP(&devRepoPath, "repo-path", "r", "", "Repository root the images are built from (default: the file's directory)")
+	devCmd.Flags().DurationVar(&devInterval, "interval", time.Second, "How often to poll the source tree for changes")
+	devCmd.Flags().BoolVar(&devNoLogs, "no-logs", false, "Don't stream pod logs")
+	devCmd.Flags().StringVar(&devBuilder, "builder", "", "docker buildx builder to build on (default: $KINDLING_BUILDER, then build.builder in .kindling/config.yaml)")
+	devCmd.Flags().BoolVar(&devSyncMode, "sync", false, "Copy changed files of interpreted services into their pods instead of rebuilding")
+	_ = devCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(devCmd)
+}
+
+// devDebounce is how long the tree must be quiet before a rebuild starts,
+// so a multi-file save triggers one build.
+const devDebounce = 300 * time.Millisecond
+
+// devService is one DevStagingEnvironment whose image kindling dev builds.
+type devService struct {
+	name       string // DSE name, also the Deployment name
+	repo       string // image without its tag
+	context    string // absolute build context
+	dockerfile string // absolute Dockerfile, "" for <context>/Dockerfile
+	skip       []string
+	snapshot   map[string]time.Time
+	color      string
+	syncDir    string // container directory changes are synced to, "" to rebuild
+	synced     bool   // the pods run files the image doesn't have
+}
+
+func runDev(cmd *cobra.Command, args []string) error {
+	if isJSONOutput() {
+		return fmt.Errorf("kindling dev streams output and does not support --output json")
+	}
+	for _, bin := range []string{"docker", "kubectl"} {
+		if !commandExists(bin) {
+			return fmt.Errorf("%s is not installed — run: kindling doctor", bin)
+		}
+	}
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	if err := requireImageRegistry(); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(devFile)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", devFile, err)
+	}
+	repoPath := devRepoPath
+	if repoPath == "" {
+		repoPath = validateRepoRoot(devFile)
+	}
+	repoPath, _ = filepath.Abs(repoPath)
+
+	services, err := devServices(data, repoPath, devFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	header("kindling dev")
+	builder, err := resolveBuilderName(devBuilder, repoPath)
+	if err != nil {
+		return err
+	}
+	if err := selectBuilder(builder); err != nil {
+		return err
+	}
+	step("📄", fmt.Sprintf("Applying %s", devFile))
+	if out, err := devKubectl("apply", "-f", devFile); err != nil {
+		return fmt.Errorf("kubectl apply failed: %s", out)
+	}
+	for _, svc := range services {
+		svc.snapshot = svc.scan()
+		if err := devRebuild(svc); err != nil {
+			fail(fmt.Sprintf("%s: %v", svc.name, err))
+		}
+	}
+	if devSyncMode {
+		for _, svc := range services {
+			dir, reason := devSyncDir(svc)
+			if dir == "" {
+				step("🔨", fmt.Sprintf("%s: rebuilds on change — %s", svc.name, reason))
+				continue
+			}
+			svc.syncDir = dir
+			step("🔁", fmt.Sprintf("%s: syncs changes to %s", svc.name, dir))
+		}
+	}
+
+	// The session is listed by kindling ps, and kindling ps stop ends it
+	// like Ctrl+C would.
+	session := daemon.Daemon{
+		Name:    "dev-" + currentEnvironment(),
+		Kind:    daemon.KindDev,
+		PID:     os.Getpid(),
+		Command: os.Args,
+		Labels:  map[string]string{"file": devFile, "services": fmt.Sprint(len(services))},
+	}
+	if err := daemons().Register(session); err != nil {
+		warn(fmt.Sprintf("cannot register the dev session: %v", err))
+	}
+	defer func() { _ = daemons().Remove(session.Name) }()
+
+	logs := newDevLogs(ctx)
+	if !devNoLogs {
+		for _, svc := range services {
+			logs.restart(svc)
+		}
+	}
+
+	fmt.Fprintln(os.Stderr)
+	step("👀", fmt.Sprintf("Watching %d service(s) — Ctrl+C to stop", len(services)))
+	ticker := time.NewTicker(devInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logs.wait()
+			fmt.Fprintln(os.Stderr)
+			success("Stopped watching — the environment is still running")
+			for _, svc := range services {
+				if svc.synced {
+					fmt.Fprintf(os.Stderr, "  %s\n", dimText(fmt.Sprintf("%s runs synced files its image doesn't have — they are lost when its pods restart", svc.name)))
+				}
+			}
+			return nil
+		case <-ticker.C:
+		}
+
+		for _, svc := range services {
+			changed := svc.changes()
+			if len(changed) == 0 {
+				continue
+			}
+			// Let the editor finish writing before building.
+			time.Sleep(devDebounce)
+			changed = append(changed, svc.changes()...)
+
+			fmt.Fprintln(os.Stderr)
+			step("✏️ ", fmt.Sprintf("%s: %s", svc.name, describeChanges(changed)))
+			if svc.syncDir != "" && !svc.needsRebuild(changed) {
+				err := devSync(svc, changed)
+				if err == nil {
+					continue
+				}
+				warn(fmt.Sprintf("%s: sync failed, rebuilding instead: %v", svc.name, err))
+			}
+			if err := devRebuild(svc); err != nil {
+				fail(fmt.Sprintf("%s: %v", svc.name, err))
+				continue
+			}
+			if !devNoLogs {
+				logs.restart(svc)
+			}
+		}
+	}
+}
+
+// devServices resolves the build context of every DevStagingEnvironment in
+// file. Services whose image isn't built from the repo are left out with a
+// warning.
+func devServices(data []byte, repoPath, file string) ([]*devService, error) {
+	targets, isWorkflow, findings := devstaging.ParseTargets(data)
+	var schemaErrs []string
+	for _, f := range findings {
+		if f.Severity == devstaging.SeverityError {
+			schemaErrs = append(schemaErrs, strings.TrimSpace(f.Resource+" "+f.Detail))
+		}
+	}
+	if isWorkflow {
+		return nil, fmt.Errorf("%s is a workflow — a DevStagingEnvironment manifest is needed", file)
+	}
+	if len(schemaErrs) > 0 {
+		return nil, fmt.Errorf("invalid manifest (run kindling validate -f %s):\n  %s", file, strings.Join(schemaErrs, "\n  "))
+	}
+
+	var services []*devService
+	for _, t := range targets {
+		image := t.Manifest.Spec.Deployment.Image
+		dir, dockerfile, _ := devstaging.BuildContext(image, repoPath)
+		if dir == "" && dockerfile == "" {
+			warn(fmt.Sprintf("%s: no build context found for %s — skipping it", t.Name, image))
+			continue
+		}
+		repo := image
+		if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+			repo = repo[:i]
+		}
+		svc := &devService{
+			name:    t.Name,
+			repo:    repo,
+			context: filepath.Join(repoPath, dir),
+			color:   logPrefixColors[len(services)%len(logPrefixColors)],
+		}
+		if dockerfile != "" {
+			svc.dockerfile = filepath.Join(repoPath, dockerfile)
+		}
+		services = append(services, svc)
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no service in %s is built from %s", file, repoPath)
+	}
+
+	// A context nested inside another one belongs only to the inner
+	// service, so editing it doesn't rebuild both.
+	for _, a := range services {
+		for _, b := range services {
+			if a != b && a.context != b.context && strings.HasPrefix(b.context, a.context+string(filepath.Separator)) {
+				a.skip = append(a.skip, b.context)
+			}
+		}
+	}
+	return services, nil
+}
+
+// scan records the modification time of every file in the build context.
+func (s *devService) scan() map[string]time.Time {
+	files := map[string]time.Time{}
+	_ = filepath.WalkDir(s.context, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != s.context && (source.SkipDir(d.Name()) || containsString(s.skip, path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files[path] = info.ModTime()
+		}
+		return nil
+	})
+	if s.dockerfile != "" {
+		if info, err := os.Stat(s.dockerfile); err == nil {
+			files[s.dockerfile] = info.ModTime()
+		}
+	}
+	return files
+}
+
+// changes rescans the context and returns the files added, modified, or
+// removed since the last scan.
+func (s *devService) changes() []string {
+	current := s.scan()
+	var changed []string
+	for path, mod := range current {
+		if prev, ok := s.snapshot[path]; !ok || !prev.Equal(mod) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range s.snapshot {
+		if _, ok := current[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	s.snapshot = current
+	return changed
+}
+
+func describeChanges(paths []string) string {
+	seen := map[string]bool{}
+	var names []string
+	for _, p := range paths {
+		if !seen[p] {
+			seen[p] = true
+			names = append(names, filepath.Base(p))
+		}
+	}
+	sort.Strings(names)
+	if len(names) > 3 {
+		return fmt.Sprintf("%s and %d more changed", strings.Join(names[:3], ", "), len(names)-3)
+	}
+	return strings.Join(names, ", ") + " changed"
+}
+
+// devRebuild builds a freshly tagged image, pushes it to the local registry
+// (or loads it into Kind when none is running), and points the
+// DevStagingEnvironment at it so the operator rolls the Deployment.
+func devRebuild(svc *devService) error {
+	image := clusterImage(svc.repo, fmt.Sprintf("dev-%d", time.Now().Unix()))
+	start := time.Now()
+
+	step("🔨", fmt.Sprintf("Building %s", image))
+	out, err := runSilent("docker", imageBuildArgs(svc, image)...)
+	saveBuildLog(svc.name, out)
+	if err != nil {
+		return fmt.Errorf("docker build failed:\n%s", lastLines(out, 15))
+	}
+
+	step("📦", "Shipping to "+clusterLabel())
+	if _, err := shipImage(image); err != nil {
+		return err
+	}
+
+	patch := fmt.Sprintf(`{"spec":{"deployment":{"image":%q}}}`, image)
+	if out, err := devKubectl("patch", "devstagingenvironment", svc.name, "--type", "merge", "-p", patch); err != nil {
+		return fmt.Errorf("patching %s failed: %s", svc.name, out)
+	}
+
+	step("🔄", fmt.Sprintf("Rolling deployment/%s", svc.name))
+	if out, err := devKubectl("rollout", "status", "deployment/"+svc.name, "--timeout=120s"); err != nil {
+		return fmt.Errorf("rollout did not finish: %s", out)
+	}
+	svc.synced = false
+	success(fmt.Sprintf("%s redeployed in %s", svc.name, time.Since(start).Round(100*time.Millisecond)))
+	return nil
+}
+
+func devKubectl(args ...string) (string, error) {
+	return runSilent("kubectl", append([]string{"--context", kubeContextName()}, args...)...)
+}
+
+// lastLines keeps the tail of a long build log.
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ── Log streaming ───────────────────────────────────────────────
+
+// devLogs runs one kubectl logs stream per service and interleaves their
+// lines on stdout. A stream is restarted after each rollout, since
+// kubectl logs -f doesn't follow replacement pods.
+type devLogs struct {
+	ctx     context.Context
+	mu      sync.Mutex // guards stdout and cancels
+	cancels map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func newDevLogs(ctx context.Context) *devLogs {
+	return &devLogs{ctx: ctx, cancels: map[string]context.CancelFunc{}}
+}
+
+func (l *devLogs) restart(svc *devService) {
+	l.mu.Lock()
+	if cancel, ok := l.cancels[svc.name]; ok {
+		cancel()
+	}
+	ctx, cancel := context.WithCancel(l.ctx)
+	l.cancels[svc.name] = cancel
+	l.mu.Unlock()
+
+	c := exec.CommandContext(ctx, "kubectl", "--context", kubeContextName(),
+		"logs", "-f", "--all-containers", "--max-log-requests=20", "--since=10s",
+		"-l", "app.kubernetes.io/instance="+svc.name)
+	out, err := c.StdoutPipe()
+	if err != nil {
+		cancel()
+		return
+	}
+	c.Stderr = c.Stdout
+	if err := startCommand(c); err != nil {
+		cancel()
+		warn(fmt.Sprintf("%s: could not stream logs: %v", svc.name, err))
+		return
+	}
+
+	prefix := fmt.Sprintf("%s%-*s │%s ", svc.color, 12, svc.name, colorReset)
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		l.copy(prefix, out)
+		_ = c.Wait()
+	}()
+}
+
+func (l *devLogs) copy(prefix string, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		l.mu.Lock()
+		fmt.Println(prefix + scanner.Text())
+		l.mu.Unlock()
+	}
+}
+
+// wait blocks until every stream has exited after the context is done.
+func (l *devLogs) wait() {
+	l.wg.Wait()
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ── Live sync ───────────────────────────────────────────────────
+//
+// With --sync, a change to an interpreted service (Python, Node, Ruby,
+// PHP) is copied straight into its running pods instead of rebuilding the
+// image: the changed files are tarred and unpacked over the image's
+// WORKDIR through kubectl exec. The app picks them up the way it would
+// locally — uvicorn --reload, nodemon, flask --debug, and so on. A change
+// that a running container can't absorb, like a new dependency, still
+// rebuilds.
+
+// syncLanguageFiles mark a build context as an interpreted service.
+var syncLanguageFiles = []string{
+	"package.json",
+	"requirements.txt", "pyproject.toml", "Pipfile",
+	"Gemfile",
+	"composer.json",
+}
+
+// syncCompiledFiles mark a build context whose image holds a build
+// output, which copying sources into wouldn't change.
+var syncCompiledFiles = []string{"go.mod", "Cargo.toml", "pom.xml", "build.gradle", "build.gradle.kts", "tsconfig.json"}
+
+// syncRebuildFiles are files whose change needs a rebuild even in sync
+// mode: the Dockerfile and the dependency manifests it installs from.
+var syncRebuildFiles = map[string]bool{
+	"Dockerfile": true, ".dockerignore": true,
+	"package.json": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"requirements.txt": true, "pyproject.toml": true, "poetry.lock": true, "Pipfile": true, "Pipfile.lock": true,
+	"Gemfile": true, "Gemfile.lock": true,
+	"composer.json": true, "composer.lock": true,
+}
+
+// devSyncDir returns the directory in the container that svc's build
+// context is copied to — the final stage's WORKDIR — or a reason the
+// service can't be synced.
+func devSyncDir(svc *devService) (string, string) {
+	for _, f := range syncCompiledFiles {
+		if fileExists(filepath.Join(svc.context, f)) {
+			return "", f + " means the image runs a build output"
+		}
+	}
+	interpreted := false
+	for _, f := range syncLanguageFiles {
+		if fileExists(filepath.Join(svc.context, f)) {
+			interpreted = true
+			break
+		}
+	}
+	if !interpreted {
+		return "", "not a Python, Node, Ruby, or PHP service"
+	}
+
+	dockerfile := svc.dockerfile
+	if dockerfile == "" {
+		dockerfile = filepath.Join(svc.context, "Dockerfile")
+	}
+	workdir := dockerfileWorkdir(dockerfile)
+	if workdir == "" {
+		return "", "its Dockerfile sets no WORKDIR to copy the sources to"
+	}
+	return workdir, ""
+}
+
+// dockerfileWorkdir returns the WORKDIR in effect at the end of the
+// Dockerfile's final stage, or "" when it sets none.
+func dockerfileWorkdir(dockerfile string) string {
+	f, err := os.Open(dockerfile)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	workdir := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "FROM":
+			workdir = "" // a new stage starts from its base image's
+		case "WORKDIR":
+			dir := strings.Trim(fields[1], `"`)
+			if !path.IsAbs(dir) {
+				dir = path.Join("/", workdir, dir)
+			}
+			workdir = dir
+		}
+	}
+	return workdir
+}
+
+// needsRebuild reports whether any of the changed files is one that
+// syncing can't apply.
+func (s *devService) needsRebuild(changed []string) bool {
+	for _, p := range changed {
+		if p == s.dockerfile || syncRebuildFiles[filepath.Base(p)] {
+			return true
+		}
+	}
+	return false
+}
+
+// devSync copies the changed files into every running pod of svc and
+// deletes the ones removed from the build context.
+func devSync(svc *devService, changed []string) error {
+	start := time.Now()
+	out, err := devKubectl("get", "pods", "-l", "app.kubernetes.io/instance="+svc.name,
+		"--field-selector=status.phase=Running", "-o", "jsonpath={.items[*].metadata.name}")
+	if err != nil {
+		return fmt.Errorf("cannot list pods: %s", out)
+	}
+	pods := strings.Fields(out)
+	if len(pods) == 0 {
+		return fmt.Errorf("no running pod")
+	}
+
+	archive, removed, err := syncArchive(svc.context, changed)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		if archive != "" {
+			if out, err := runSilentStdin(archive, "kubectl", "--context", kubeContextName(),
+				"exec", "-i", pod, "-c", svc.name, "--", "tar", "xf", "-", "-C", svc.syncDir); err != nil {
+				return fmt.Errorf("copying into %s failed (does the image have tar?): %s", pod, out)
+			}
+		}
+		if len(removed) > 0 {
+			args := []string{"exec", pod, "-c", svc.name, "--", "rm", "-f"}
+			for _, rel := range removed {
+				args = append(args, path.Join(svc.syncDir, rel))
+			}
+			if out, err := devKubectl(args...); err != nil {
+				return fmt.Errorf("removing files from %s failed: %s", pod, out)
+			}
+		}
+	}
+	svc.synced = true
+	success(fmt.Sprintf("%s synced to %d pod(s) in %s", svc.name, len(pods), time.Since(start).Round(100*time.Millisecond)))
+	return nil
+}
+
+// syncArchive tars the changed files that still exist, with paths
+// relative to the build context, and returns the relative paths of the
+// ones that were removed.
+func syncArchive(context string, changed []string) (string, []string, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	var removed []string
+	added := 0
+	seen := map[string]bool{}
+	for _, p := range changed {
+		rel, err := filepath.Rel(context, p)
+		if err != nil || strings.HasPrefix(rel, "..") || seen[rel] {
+			continue
+		}
+		seen[rel] = true
+		data, err := os.ReadFile(p)
+		if os.IsNotExist(err) {
+			removed = append(removed, filepath.ToSlash(rel))
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return "", nil, err
+		}
+		hdr := &tar.Header{
+			Name:    filepath.ToSlash(rel),
+			Mode:    int64(info.Mode().Perm()),
+			Size:    int64(len(data)),
+			ModTime: info.ModTime(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return "", nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return "", nil, err
+		}
+		added++
+	}
+	if err := tw.Close(); err != nil {
+		return "", nil, err
+	}
+	if added == 0 {
+		return "", removed, nil
+	}
+	return buf.String(), removed, nil
+}
@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that this machine is ready to run kindling",
+	Long: `Runs preflight checks for kindling and prints a fix for every problem:
+
+  • docker and kubectl on PATH (kind, cloudflared, ngrok, tailscale optional)
+  • Docker daemon reachable
+  • Free disk space and memory available to Docker
+  • Host ports 80/443 free for the ingress controller
+  • kindling CRDs and controller installed in the cluster
+
+--network also follows every outbound path kindling uses, to diagnose
+proxy problems: the HTTP(S)_PROXY and NO_PROXY variables, the proxy
+itself, GitHub, the registries, the AI and tunnel providers through it,
+the Docker daemon's proxy, and the cluster nodes'. It makes requests to
+the internet, so it is opt-in.
+
+Exits non-zero when a required check fails.
+
+Examples:
+  kindling doctor
+  kindling doctor --network
+  kindling doctor -o json`,
+	SilenceUsage: true,
+	RunE:         runDoctor,
+}
+
+var doctorNetwork bool
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorNetwork, "network", false, "Also check proxy settings and the endpoints kindling connects to")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// Doctor check outcomes.
+const (
+	doctorOK   = "ok"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+)
+
+// doctorCheck is the result of one preflight check.
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	Fix    string `json:"fix,omitempty"`
+}
+
+// doctorReport is the full preflight result.
+type doctorReport struct {
+	Checks   []doctorCheck `json:"checks"`
+	Failures int           `json:"failures"`
+	Warnings int           `json:"warnings"`
+}
+
+const (
+	minDiskGiB   = 10
+	minMemoryGiB = 4
+)
+
+// doctorTools are the binaries kindling shells out to. Optional tools only
+// warn when missing.
+var doctorTools = []struct {
+	name     string
+	required bool
+	install  string
+}{
+	{"docker", true, "install Docker Desktop or Docker Engine: https://docs.docker.com/get-docker/"},
+	{"kubectl", true, "brew install kubectl  (or: https://kubernetes.io/docs/tasks/tools/)"},
+	{"kind", false, "brew install kind — only to run kind yourself; kindling has Kind built in"},
+	{"k3d", false, "brew install k3d — needed for kindling init --backend k3d"},
+	{"minikube", false, "brew install minikube — needed for kindling init --backend minikube"},
+	{"cloudflared", false, "brew install cloudflared — needed for kindling expose"},
+	{"ngrok", false, "brew install ngrok/ngrok/ngrok — alternative kindling expose provider"},
+	{"tailscale", false, "https://tailscale.com/download — alternative kindling expose provider"},
+	{"mkcert", false, "brew install mkcert — needed for kindling init --tls"},
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	report := collectDoctorReport()
+	if err := render(report, func() { printDoctorReport(report) }); err != nil {
+		return err
+	}
+	if report.Failures > 0 {
+		return fmt.Errorf("%d preflight check(s) failed", report.Failures)
+	}
+	return nil
+}
+
+// collectDoctorReport runs every check. Checks that depend on an earlier
+// one (the daemon, the cluster) are skipped when it failed.
+func collectDoctorReport() doctorReport {
+	var checks []doctorCheck
+	add := func(c doctorCheck) { checks = append(checks, c) }
+
+	found := map[string]bool{}
+	for _, t := range doctorTools {
+		found[t.name] = commandExists(t.name)
+		switch {
+		case found[t.name]:
+			add(doctorCheck{Name: t.name, Status: doctorOK, Detail: "found on PATH"})
+		case t.required:
+			add(doctorCheck{Name: t.name, Status: doctorFail, Detail: "not found on PATH", Fix: t.install})
+		default:
+			add(doctorCheck{Name: t.name, Status: doctorWarn, Detail: "not found on PATH (optional)", Fix: t.install})
+		}
+	}
+
+	dockerUp := false
+	if found["docker"] {
+		c := checkDockerDaemon()
+		dockerUp = c.Status == doctorOK
+		add(c)
+	}
+	if dockerUp {
+		add(checkDockerMemory())
+	}
+	add(checkDiskSpace())
+	if dockerUp {
+		for _, port := range []int{80, 443} {
+			add(checkHostPort(port))
+		}
+	}
+	if found["kubectl"] && dockerUp {
+		checks = append(checks, checkKindlingInstall()...)
+	}
+	if doctorNetwork {
+		checks = append(checks, checkNetwork(dockerUp)...)
+	}
+
+	report := doctorReport{Checks: checks}
+	for _, c := range checks {
+		switch c.Status {
+		case doctorFail:
+			report.Failures++
+		case doctorWarn:
+			report.Warnings++
+		}
+	}
+	return report
+}
+
+func checkDockerDaemon() doctorCheck {
+	version, err := runCapture("docker", "info", "--format", "{{.ServerVersion}}")
+	if err != nil || version == "" {
+		fix := "start Docker Desktop"
+		if runtime.GOOS == "linux" {
+			fix = "sudo systemctl start docker  (and add yourself to the docker group)"
+		}
+		return doctorCheck{Name: "docker daemon", Status: doctorFail, Detail: "not reachable", Fix: fix}
+	}
+	return doctorCheck{Name: "docker daemon", Status: doctorOK, Detail: "running, server " + version}
+}
+
+// checkDockerMemory reads the memory available to Docker, which on macOS
+// and Windows is the Docker Desktop VM rather than the host.
+func checkDockerMemory() doctorCheck {
+	out, err := runCapture("docker", "info", "--format", "{{.MemTotal}}")
+	bytes, perr := strconv.ParseInt(out, 10, 64)
+	if err != nil || perr != nil {
+		return doctorCheck{Name: "memory", Status: doctorWarn, Detail: "could not read Docker's memory limit"}
+	}
+	gib := float64(bytes) / (1 << 30)
+	detail := fmt.Sprintf("%.1f GiB available to Docker", gib)
+	if gib < minMemoryGiB {
+		return doctorCheck{
+			Name:   "memory",
+			Status: doctorWarn,
+			Detail: detail,
+			Fix:    fmt.Sprintf("give Docker at least %d GiB (Docker Desktop → Settings → Resources)", minMemoryGiB),
+		}
+	}
+	return doctorCheck{Name: "memory", Status: doctorOK, Detail: detail}
+}
+
+// checkDiskSpace checks the filesystem holding Docker's data (or the home
+// directory when that isn't local).
+func checkDiskSpace() doctorCheck {
+	path, _ := runCapture("docker", "info", "--format", "{{.DockerRootDir}}")
+	if _, err := os.Stat(path); path == "" || err != nil {
+		path, _ = os.UserHomeDir()
+	}
+	gib, err := freeDiskGiB(path)
+	if err != nil {
+		return doctorCheck{Name: "disk space", Status: doctorWarn, Detail: fmt.Sprintf("could not check free space on %s", path)}
+	}
+	detail := fmt.Sprintf("%.1f GiB free on %s", gib, path)
+	if gib < minDiskGiB {
+		return doctorCheck{
+			Name:   "disk space",
+			Status: doctorWarn,
+			Detail: detail,
+			Fix:    fmt.Sprintf("free at least %d GiB — docker system prune -a removes unused images", minDiskGiB),
+		}
+	}
+	return doctorCheck{Name: "disk space", Status: doctorOK, Detail: detail}
+}
+
+// checkHostPort makes sure port is free for the Kind ingress mapping, or
+// already held by this cluster.
+func checkHostPort(port int) doctorCheck {
+	name := fmt.Sprintf("port %d", port)
+	owner, _ := runCapture("docker", "ps", "--filter", fmt.Sprintf("publish=%d", port), "--format", "{{.Names}}")
+	owner = strings.TrimSpace(strings.SplitN(owner, "\n", 2)[0])
+	switch {
+	case owner == clusterName+"-control-plane":
+		return doctorCheck{Name: name, Status: doctorOK, Detail: fmt.Sprintf("in use by Kind cluster %q", clusterName)}
+	case owner != "":
+		return doctorCheck{
+			Name:   name,
+			Status: doctorFail,
+			Detail: fmt.Sprintf("published by container %s", owner),
+			Fix:    fmt.Sprintf("stop it: docker stop %s", owner),
+		}
+	}
+
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 500*time.Millisecond)
+	if err == nil {
+		conn.Close()
+		return doctorCheck{
+			Name:   name,
+			Status: doctorFail,
+			Detail: "another process is listening",
+			Fix:    fmt.Sprintf("find and stop it: sudo lsof -iTCP:%d -sTCP:LISTEN", port),
+		}
+	}
+	return doctorCheck{Name: name, Status: doctorOK, Detail: "free"}
+}
+
+// checkKindlingInstall checks the cluster, the CRDs, and the controller.
+func checkKindlingInstall() []doctorCheck {
+	if !clusterExists(clusterName) {
+		if remoteCluster() {
+			return []doctorCheck{{
+				Name:   "cluster",
+				Status: doctorFail,
+				Detail: fmt.Sprintf("%s is unreachable", clusterLabel()),
+				Fix:    "check --context and --kubeconfig",
+			}}
+		}
+		return []doctorCheck{{
+			Name:   "cluster",
+			Status: doctorWarn,
+			Detail: fmt.Sprintf("%s does not exist", clusterLabel()),
+			Fix:    "run: kindling init",
+		}}
+	}
+	checks := []doctorCheck{{Name: "cluster", Status: doctorOK, Detail: fmt.Sprintf("%s is up", clusterLabel())}}
+
+	kctx := kubeContextName()
+	for _, crd := range []string{"devstagingenvironments.apps.example.com", "githubactionrunnerpools.apps.example.com"} {
+		if _, err := runCapture("kubectl", "--context", kctx, "get", "crd", crd); err != nil {
+			checks = append(checks, doctorCheck{Name: "crd " + crd, Status: doctorFail, Detail: "not installed", Fix: "run: kindling init --skip-cluster"})
+		} else {
+			checks = append(checks, doctorCheck{Name: "crd " + crd, Status: doctorOK, Detail: "installed"})
+		}
+	}
+
+	ready, err := runCapture("kubectl", "--context", kctx, "get", "deployment",
+		"-n", "kindling-system", "kindling-controller-manager",
+		"-o", "jsonpath={.status.readyReplicas}/{.spec.replicas}")
+	switch {
+	case err != nil:
+		checks = append(checks, doctorCheck{Name: "controller", Status: doctorFail, Detail: "not deployed in kindling-system", Fix: "run: kindling init --skip-cluster"})
+	case strings.HasPrefix(ready, "/") || strings.HasPrefix(ready, "0/"):
+		checks = append(checks, doctorCheck{Name: "controller", Status: doctorFail, Detail: "deployed but not ready", Fix: "inspect it: kindling logs"})
+	default:
+		checks = append(checks, doctorCheck{Name: "controller", Status: doctorOK, Detail: "ready " + ready})
+	}
+
+	if controller, ok := controllerVersion(); ok {
+		problems, newer := versionSkewProblems(controller)
+		detail := fmt.Sprintf("CLI %s, controller %s", Version, controller)
+		switch {
+		case len(problems) > 0:
+			checks = append(checks, doctorCheck{Name: "version skew", Status: doctorFail, Detail: strings.Join(problems, "; "), Fix: "run: kindling upgrade --controller"})
+		case newer != "":
+			checks = append(checks, doctorCheck{Name: "version skew", Status: doctorWarn, Detail: newer, Fix: "run: kindling upgrade --cli"})
+		default:
+			checks = append(checks, doctorCheck{Name: "version skew", Status: doctorOK, Detail: detail})
+		}
+	}
+	return checks
+}
+
+func printDoctorReport(r doctorReport) {
+	header("kindling doctor")
+	for _, c := range r.Checks {
+		icon := colorGreen + "✓" + colorReset
+		switch c.Status {
+		case doctorWarn:
+			icon = colorYellow + "⚠" + colorReset
+		case doctorFail:
+			icon = colorRed + "✗" + colorReset
+		}
+		fmt.Fprintf(os.Stderr, "  %s  %-20s %s\n", icon, c.Name, c.Detail)
+		if c.Fix != "" && c.Status != doctorOK {
+			fmt.Fprintf(os.Stderr, "     %s\n", dimText("→ "+c.Fix))
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+
+	summary := fmt.Sprintf("%d check(s), %d failure(s), %d warning(s)", len(r.Checks), r.Failures, r.Warnings)
+	if r.Failures > 0 {
+		fail(summary)
+	} else {
+		success(summary)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package cmd
+
+import "syscall"
+
+// freeDiskGiB returns the space available to unprivileged users on the
+// filesystem holding path.
+func freeDiskGiB(path string) (float64, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return 0, err
+	}
+	return float64(fs.Bavail) * float64(fs.Bsize) / (1 << 30), nil
+}
@@ -9,15 +9,22 @@ import (
 
 var envCmd = &cobra.Command{
 	Use:   "env",
-	Short: "Manage environment variables on running deployments",
+	Short: "Manage environments and the environment variables of deployments",
 	Long: `Set, list, or remove environment variables on a running deployment
 without redeploying. Changes take effect immediately via a rolling restart.
 
+List, switch between, and delete environments: the namespaces that
+kindling deploy --env puts DevStagingEnvironments in, so several of them
+run side by side.
+
 Examples:
   kindling env set jeff-vincent-compute DATABASE_PORT=5432
   kindling env set jeff-vincent-compute DB_HOST=my-db DB_PORT=5432
   kindling env list jeff-vincent-compute
-  kindling env unset jeff-vincent-compute DATABASE_PORT`,
+  kindling env unset jeff-vincent-compute DATABASE_PORT
+  kindling env list
+  kindling env switch feature-login
+  kindling env delete feature-login`,
 }
 
 var envSetCmd = &cobra.Command{
@@ -29,22 +36,25 @@ will do a rolling restart to pick up the new values.
 Examples:
   kindling env set jeff-vincent-compute DATABASE_PORT=5432
   kindling env set jeff-vincent-compute DB_HOST=my-db DB_PORT=5432 DEBUG=true`,
-	Args: cobra.MinimumNArgs(2),
-	RunE: runEnvSet,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: firstArg(completeDSEs),
+	RunE:              runEnvSet,
 }
 
 var envListCmd = &cobra.Command{
-	Use:   "list <deployment>",
-	Short: "List environment variables on a deployment",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runEnvList,
+	Use:               "list [deployment]",
+	Short:             "List environments, or the environment variables of a deployment",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: firstArg(completeDSEs),
+	RunE:              runEnvList,
 }
 
 var envUnsetCmd = &cobra.Command{
-	Use:   "unset <deployment> KEY [KEY ...]",
-	Short: "Remove environment variables from a deployment",
-	Args:  cobra.MinimumNArgs(2),
-	RunE:  runEnvUnset,
+	Use:               "unset <deployment> KEY [KEY ...]",
+	Short:             "Remove environment variables from a deployment",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: firstArg(completeDSEs),
+	RunE:              runEnvUnset,
 }
 
 func init() {
@@ -88,6 +98,9 @@ func runEnvSet(cmd *cobra.Command, args []string) error {
 }
 
 func runEnvList(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return runEnvironmentList()
+	}
 	deploy := args[0]
 
 	if _, err := runSilent("kubectl", "get", "deployment/"+deploy); err != nil {
@@ -0,0 +1,442 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/deploy"
+)
+
+// ── Environments ────────────────────────────────────────────────
+//
+// An environment is a namespace of its own for a set of
+// DevStagingEnvironments, so several copies of an app — one per branch,
+// say — run side by side without their names colliding. kindling deploy
+// --env feature-x applies the manifest into the env-feature-x namespace,
+// creating it and copying the kindling secrets into it; env list, switch,
+// and delete manage them. The current environment, set by env switch, is
+// what status, logs, port-forward, and deploy use when no --env is given.
+// DevStagingEnvironments deployed without one live in the default
+// namespace, the "default" environment.
+
+// envNamespacePrefix starts the name of every environment namespace.
+const envNamespacePrefix = deploy.NamespacePrefix
+
+// envLabel marks a namespace as a kindling environment and names it.
+const envLabel = deploy.EnvironmentLabel
+
+// defaultEnvName is the environment of the default namespace.
+const defaultEnvName = deploy.DefaultEnvironment
+
+// currentEnvFile holds the current environment inside .kindling/.
+const currentEnvFile = "environment"
+
+var envSwitchCmd = &cobra.Command{
+	Use:   "switch <environment>",
+	Short: "Make an environment the default for status, logs, port-forward, and deploy",
+	Long: `Sets the current environment of the project in the working directory.
+status shows only its DevStagingEnvironments, logs, port-forward, exec,
+and the other commands that take a component resolve it there, and
+deploy applies manifests into it — each unless given --env.
+
+The current environment is kept in .kindling/environment; $KINDLING_ENV
+overrides it. Switch to "default" to go back to the default namespace.
+
+Examples:
+  kindling env switch feature-login
+  kindling env switch default`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeEnvironments),
+	RunE:              runEnvSwitch,
+}
+
+var envDeleteCmd = &cobra.Command{
+	Use:   "delete <environment>",
+	Short: "Delete an environment's namespace and everything in it",
+	Long: `Deletes the namespace of an environment: its DevStagingEnvironments,
+their dependencies and data, and the secrets copied into it. When it was
+the current environment, the default one becomes current again.
+
+Examples:
+  kindling env delete feature-login
+  kindling env delete feature-login -y`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeEnvironments),
+	RunE:              runEnvDelete,
+}
+
+var (
+	envDeleteForce   bool
+	envDeleteTimeout time.Duration
+)
+
+func init() {
+	envDeleteCmd.Flags().BoolVarP(&envDeleteForce, "force", "y", false, "Skip the confirmation prompt")
+	envDeleteCmd.Flags().DurationVar(&envDeleteTimeout, "timeout", 3*time.Minute, "How long to wait for the namespace to be removed")
+	envCmd.AddCommand(envSwitchCmd)
+	envCmd.AddCommand(envDeleteCmd)
+}
+
+var envNameInvalid = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// sanitizeEnvName turns a branch or free-form name into an environment
+// name that fits a namespace: lowercase letters, digits, and dashes.
+func sanitizeEnvName(s string) string {
+	name := envNameInvalid.ReplaceAllString(strings.ToLower(s), "-")
+	name = strings.Trim(name, "-")
+	if max := 63 - len(envNamespacePrefix); len(name) > max {
+		name = strings.TrimRight(name[:max], "-")
+	}
+	return name
+}
+
+// environmentNamespace returns the namespace of an environment.
+func environmentNamespace(name string) string {
+	return deploy.Namespace(name)
+}
+
+// environmentOf returns the environment a namespace belongs to. Namespaces
+// kindling didn't create are environments named after themselves.
+func environmentOf(namespace string) string {
+	if name, ok := strings.CutPrefix(namespace, envNamespacePrefix); ok {
+		return name
+	}
+	return namespace
+}
+
+// branchEnvName names an environment after the git branch checked out in
+// dir.
+func branchEnvName(dir string) (string, error) {
+	out, err := runCapture("git", "-C", dir, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("cannot read the git branch of %s — is it a git checkout on a branch?", dir)
+	}
+	name := sanitizeEnvName(out)
+	if name == "" {
+		return "", fmt.Errorf("git branch %q has no characters an environment name can use", out)
+	}
+	return name, nil
+}
+
+// resolveEnvName picks the environment a manifest goes into: --env, the
+// current git branch with --env-from-branch, or "" for the current
+// environment.
+func resolveEnvName(flag string, fromBranch bool, dir string) (string, error) {
+	switch {
+	case flag != "" && fromBranch:
+		return "", fmt.Errorf("use either --env or --env-from-branch, not both")
+	case fromBranch:
+		return branchEnvName(dir)
+	case flag == defaultEnvName:
+		return flag, nil
+	case flag != "":
+		name := sanitizeEnvName(flag)
+		if name != flag {
+			return "", fmt.Errorf("environment names are lowercase letters, digits, and dashes — try %q", name)
+		}
+		return name, nil
+	}
+	return "", nil
+}
+
+func currentEnvPath() string {
+	cwd, _ := os.Getwd()
+	return filepath.Join(cwd, ".kindling", currentEnvFile)
+}
+
+// currentEnvironment returns the current environment: $KINDLING_ENV, then
+// .kindling/environment, then "" for the default namespace.
+func currentEnvironment() string {
+	cwd, _ := os.Getwd()
+	return projectEnvironment(cwd)
+}
+
+// projectEnvironment is the current environment of the project in dir.
+func projectEnvironment(dir string) string {
+	if env := os.Getenv("KINDLING_ENV"); env != "" {
+		return env
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ".kindling", currentEnvFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// setCurrentEnvironment records the current environment; the default one
+// removes the file.
+func setCurrentEnvironment(name string) error {
+	if name == "" || name == defaultEnvName {
+		if err := os.Remove(currentEnvPath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(currentEnvPath()), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(currentEnvPath(), []byte(name+"\n"), 0644)
+}
+
+// inEnvironment keeps the DevStagingEnvironments of one environment.
+func inEnvironment(envs []envStatus, name string) []envStatus {
+	var kept []envStatus
+	for _, e := range envs {
+		if e.Environment == name {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// ensureEnvironment creates the namespace of an environment if needed and
+// copies the kindling secrets into it, so secretKeyRefs resolve there as
+// they do in the default namespace. It returns the namespace.
+func ensureEnvironment(name string) (string, error) {
+	ns := environmentNamespace(name)
+	if ns == defaultEnvName {
+		return ns, nil
+	}
+	if !useKubectl {
+		c, err := kubeClient()
+		if err != nil {
+			return "", err
+		}
+		return deploy.EnsureEnvironment(context.Background(), c, name)
+	}
+	manifest := fmt.Sprintf(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: %s
+  labels:
+    %s: %s
+    app.kubernetes.io/managed-by: kindling
+`, ns, envLabel, name)
+	if out, err := runSilentStdin(manifest, "kubectl", "--context", kubeContextName(), "apply", "-f", "-"); err != nil {
+		return "", fmt.Errorf("cannot create namespace %s: %s", ns, out)
+	}
+	if err := copyKindlingSecrets(ns); err != nil {
+		return "", err
+	}
+	return ns, nil
+}
+
+// copyKindlingSecrets copies the secrets kindling secrets set stored in
+// the default namespace into ns, replacing older copies.
+func copyKindlingSecrets(ns string) error {
+	out, err := kubectlJSON("get", "secrets", "-n", secretsNamespace,
+		"-l", secretsLabelKey+"="+secretsLabelValue, "-o", "json")
+	if err != nil {
+		return nil // no secrets yet
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name   string            `json:"name"`
+				Labels map[string]string `json:"labels"`
+			} `json:"metadata"`
+			Type string            `json:"type"`
+			Data map[string]string `json:"data"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return fmt.Errorf("cannot parse the kindling secrets: %w", err)
+	}
+	if len(list.Items) == 0 {
+		return nil
+	}
+	items := make([]map[string]interface{}, 0, len(list.Items))
+	for _, s := range list.Items {
+		items = append(items, map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": s.Metadata.Name, "namespace": ns, "labels": s.Metadata.Labels},
+			"type":       s.Type,
+			"data":       s.Data,
+		})
+	}
+	data, err := json.Marshal(map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": items})
+	if err != nil {
+		return err
+	}
+	if out, err := runSilentStdin(string(data), "kubectl", "--context", kubeContextName(), "apply", "-f", "-"); err != nil {
+		return fmt.Errorf("cannot copy the kindling secrets into %s: %s", ns, out)
+	}
+	return nil
+}
+
+// envSummary is one environment in env list.
+type envSummary struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Current   bool   `json:"current"`
+	DSEs      int    `json:"devStagingEnvironments"`
+	Ready     int    `json:"ready"`
+	Created   string `json:"created,omitempty"`
+}
+
+// collectEnvSummaries lists the environment namespaces and every other
+// namespace running DevStagingEnvironments.
+func collectEnvSummaries() ([]envSummary, error) {
+	out, err := kubectlJSON("get", "namespaces", "-l", envLabel, "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("cannot list namespaces — is the cluster running? (kindling init)")
+	}
+	var nsList struct {
+		Items []struct {
+			Metadata struct {
+				Name              string `json:"name"`
+				CreationTimestamp string `json:"creationTimestamp"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &nsList); err != nil {
+		return nil, fmt.Errorf("cannot parse namespaces: %w", err)
+	}
+
+	byNamespace := map[string]*envSummary{}
+	for _, n := range nsList.Items {
+		ns := n.Metadata.Name
+		byNamespace[ns] = &envSummary{Name: environmentOf(ns), Namespace: ns, Created: n.Metadata.CreationTimestamp}
+	}
+	for _, e := range collectEnvironments() {
+		s := byNamespace[e.Namespace]
+		if s == nil {
+			s = &envSummary{Name: e.Environment, Namespace: e.Namespace}
+			byNamespace[e.Namespace] = s
+		}
+		s.DSEs++
+		if e.Ready {
+			s.Ready++
+		}
+	}
+
+	current := currentEnvironment()
+	if current == "" {
+		current = defaultEnvName
+	}
+	summaries := make([]envSummary, 0, len(byNamespace))
+	for _, s := range byNamespace {
+		s.Current = s.Name == current
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries, nil
+}
+
+// runEnvironmentList is env list without a deployment.
+func runEnvironmentList() error {
+	summaries, err := collectEnvSummaries()
+	if err != nil {
+		return err
+	}
+	return render(summaries, func() {
+		header("Environments")
+		if len(summaries) == 0 {
+			fmt.Printf("    %sNone — run:%s kindling deploy -f <file.yaml> --env <name>\n\n", colorDim, colorReset)
+			return
+		}
+		fmt.Printf("    %s  %-28s %-32s %s\n", " ", "NAME", "NAMESPACE", "READY")
+		for _, s := range summaries {
+			marker := " "
+			if s.Current {
+				marker = colorGreen + "*" + colorReset
+			}
+			fmt.Printf("    %s  %-28s %-32s %d/%d\n", marker, s.Name, s.Namespace, s.Ready, s.DSEs)
+		}
+		fmt.Printf("\n  %sSwitch with: kindling env switch <name>%s\n\n", colorDim, colorReset)
+	})
+}
+
+// envSwitchResult is the JSON form of env switch's output.
+type envSwitchResult struct {
+	Environment string `json:"environment"`
+	Namespace   string `json:"namespace"`
+}
+
+func runEnvSwitch(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	ns := environmentNamespace(name)
+	if name != defaultEnvName {
+		if _, err := kubectlJSON("get", "namespace", ns); err != nil {
+			return fmt.Errorf("environment %q not found — see: kindling env list", name)
+		}
+	}
+	if os.Getenv("KINDLING_ENV") != "" {
+		warn("$KINDLING_ENV is set and overrides the current environment while it is")
+	}
+	if err := setCurrentEnvironment(name); err != nil {
+		return fmt.Errorf("cannot save the current environment: %w", err)
+	}
+	return render(envSwitchResult{Environment: name, Namespace: ns}, func() {
+		success(fmt.Sprintf("Current environment is %s %s", name, dimText(ns)))
+	})
+}
+
+// envDeleteResult is the JSON form of env delete's output.
+type envDeleteResult struct {
+	Environment string   `json:"environment"`
+	Namespace   string   `json:"namespace"`
+	Deleted     []string `json:"deleted"`
+}
+
+func runEnvDelete(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if name == defaultEnvName {
+		return fmt.Errorf("the default environment can't be deleted — remove its DevStagingEnvironments with kindling delete")
+	}
+	ns := environmentNamespace(name)
+	if _, err := kubectlJSON("get", "namespace", ns); err != nil {
+		return fmt.Errorf("environment %q not found — see: kindling env list", name)
+	}
+	result := envDeleteResult{Environment: name, Namespace: ns, Deleted: []string{}}
+	for _, e := range collectEnvironments() {
+		if e.Namespace == ns {
+			result.Deleted = append(result.Deleted, e.Name)
+		}
+	}
+
+	header(fmt.Sprintf("Deleting environment %s", name))
+	for _, dse := range result.Deleted {
+		step("📦", dse)
+	}
+	if !envDeleteForce {
+		if isJSONOutput() {
+			return fmt.Errorf("env delete needs -y with -o json")
+		}
+		fmt.Printf("\n  %s⚠️  This deletes namespace %s and all of its data.%s\n", colorYellow, ns, colorReset)
+		fmt.Printf("  Continue? [y/N] ")
+		var confirm string
+		fmt.Scanln(&confirm)
+		if confirm != "y" && confirm != "Y" {
+			fmt.Println("  Aborted.")
+			return nil
+		}
+	}
+
+	sp := startSpinner(fmt.Sprintf("Deleting namespace %s", ns))
+	out, err := captureKubectl("delete", "namespace", ns, "--timeout", envDeleteTimeout.String())
+	sp.stop()
+	if err != nil {
+		return fmt.Errorf("deleting namespace %s failed: %s", ns, lastLines(out, 3))
+	}
+	if currentEnvironment() == name && os.Getenv("KINDLING_ENV") == "" {
+		if err := setCurrentEnvironment(defaultEnvName); err != nil {
+			return err
+		}
+	}
+	return render(result, func() {
+		success(fmt.Sprintf("Environment %s deleted", name))
+	})
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <component> [-- command...]",
+	Short: "Run a command or shell in a component's container",
+	Long: `Finds a running pod of a DevStagingEnvironment component and runs a
+command in it with kubectl exec. Without a command, opens an interactive
+shell (bash when the image has it, otherwise sh).
+
+Components are named the same way as in kindling logs: an environment
+name (its app), a dependency type such as postgres, or a full name such as
+orders-dev-postgres. When a component has several replicas, the first
+running pod is used; pass --pod to pick one.
+
+The command's exit code is passed through.
+
+Examples:
+  kindling exec orders-dev
+  kindling exec orders-dev -- env
+  kindling exec postgres --env orders-dev -- psql -U devuser
+  kindling exec orders-dev --container sidecar -- sh`,
+	Args:              cobra.MinimumNArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeComponents),
+	RunE:              runExec,
+}
+
+var (
+	execEnv       string
+	execContainer string
+	execPod       string
+)
+
+func init() {
+	execCmd.Flags().StringVar(&execEnv, "env", "", "DevStagingEnvironment to resolve the component in")
+	_ = execCmd.RegisterFlagCompletionFunc("env", completeEnvFlag)
+	execCmd.Flags().StringVar(&execContainer, "container", "", "Container to exec into (default: the pod's first container)")
+	execCmd.Flags().StringVar(&execPod, "pod", "", "Pod to exec into when the component has several replicas")
+	rootCmd.AddCommand(execCmd)
+}
+
+// execDefaultShell prefers bash and falls back to sh, so slim images work.
+var execDefaultShell = []string{"sh", "-c", "command -v bash >/dev/null 2>&1 && exec bash || exec sh"}
+
+func runExec(cmd *cobra.Command, args []string) error {
+	switch dash := cmd.ArgsLenAtDash(); {
+	case dash == 0:
+		return fmt.Errorf("name a component before --, e.g. kindling exec orders-dev -- %s", strings.Join(args, " "))
+	case dash > 1:
+		return fmt.Errorf("expected a single component before --, got %s", strings.Join(args[:dash], " "))
+	case dash < 0 && len(args) > 1:
+		return fmt.Errorf("separate the command from the component with --, e.g. kindling exec %s -- %s", args[0], strings.Join(args[1:], " "))
+	}
+	component, command := args[0], args[1:]
+	if len(command) == 0 {
+		command = execDefaultShell
+	}
+
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	comps, err := resolveComponents(collectEnvironments(), component, execEnv)
+	if err != nil {
+		return err
+	}
+	if len(comps) > 1 {
+		var names []string
+		for _, c := range comps {
+			names = append(names, c.name)
+		}
+		return fmt.Errorf("%q matches %s — pass the full name", component, strings.Join(names, ", "))
+	}
+	target := comps[0]
+
+	pod := execPod
+	if pod == "" {
+		if pod, err = runningPod(target); err != nil {
+			return err
+		}
+	}
+
+	kubectlArgs := []string{"--context", kubeContextName(), "exec", "-i"}
+	if stdinIsTerminal() {
+		kubectlArgs = append(kubectlArgs, "-t")
+	}
+	kubectlArgs = append(kubectlArgs, "-n", target.namespace, pod)
+	if execContainer != "" {
+		kubectlArgs = append(kubectlArgs, "-c", execContainer)
+	}
+	kubectlArgs = append(kubectlArgs, "--")
+	kubectlArgs = append(kubectlArgs, command...)
+
+	if stdinIsTerminal() {
+		step("🐚", fmt.Sprintf("%s/%s", target.namespace, pod))
+	}
+	err = run("kubectl", kubectlArgs...)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// Pass the remote command's exit code through, like kubectl does.
+		os.Exit(exitErr.ExitCode())
+	}
+	return err
+}
+
+// runningPod returns the first Running pod of a component.
+func runningPod(c componentRef) (string, error) {
+	out, err := kubectlJSON("get", "pods", "-n", c.namespace,
+		"-l", "app.kubernetes.io/name="+c.name,
+		"--field-selector=status.phase=Running",
+		"-o", "jsonpath={range .items[*]}{.metadata.name}{\"\\n\"}{end}")
+	if err != nil {
+		return "", fmt.Errorf("cannot list pods of %s: %w", c.name, err)
+	}
+	pods := strings.Fields(out)
+	if len(pods) == 0 {
+		return "", fmt.Errorf("%s has no running pod — see: kindling status", c.name)
+	}
+	sort.Strings(pods)
+	return pods[0], nil
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal, which
+// decides whether kubectl exec allocates a TTY.
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,487 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/internal/logging"
+	"github.com/jeffvincent/kindling/cli/pkg/generate"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <environment>",
+	Short: "Explain in plain English why a DevStagingEnvironment is failing, and how to fix its spec",
+	Long: `Gathers what kindling debug gathers for every component of a
+DevStagingEnvironment that isn't ready — pod states, recent events, the
+last logs — together with the DSE's status conditions and spec, and has
+the configured LLM explain what is wrong and suggest changes to the spec.
+
+The LLM is chosen as for kindling generate: --llm-provider, then
+KINDLING_LLM_PROVIDER, then llm.provider in the config. Without an API key,
+with --no-ai, or when the provider is unavailable, the rules of kindling
+debug explain instead. Logs and env values are sent with credentials
+masked, and the call is recorded in .kindling/ai-audit/.
+
+Suggested changes are fields of the spec, such as spec.deployment.port.
+--apply shows each one next to its current value and, once confirmed,
+patches the live DevStagingEnvironment after a server-side dry run. Copy
+the changes into your manifest too, or the next kindling deploy reverts
+them.
+
+Examples:
+  kindling explain orders-dev
+  kindling explain orders-dev --no-ai
+  kindling explain orders-dev --apply
+  kindling explain orders-dev -o json | jq '.changes'`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeDSEs),
+	RunE:              runExplain,
+}
+
+var (
+	explainEnv    string
+	explainNoAI   bool
+	explainLLM    string
+	explainModel  string
+	explainAPIKey string
+	explainApply  bool
+	explainForce  bool
+)
+
+func init() {
+	explainCmd.Flags().StringVar(&explainEnv, "env", "", "Environment the DevStagingEnvironment is in, when the name is in several")
+	_ = explainCmd.RegisterFlagCompletionFunc("env", completeEnvFlag)
+	explainCmd.Flags().BoolVar(&explainNoAI, "no-ai", false, "Explain with kindling debug's rules instead of the LLM")
+	explainCmd.Flags().StringVar(&explainLLM, "llm-provider", "", "LLM provider: openai, azure, anthropic, or ollama (default: as for kindling generate)")
+	explainCmd.Flags().StringVar(&explainModel, "model", "", "Model name (default: as for kindling generate)")
+	explainCmd.Flags().StringVarP(&explainAPIKey, "api-key", "k", "", "GenAI API key (default: from .kindling/config.yaml or the provider's env var)")
+	explainCmd.Flags().BoolVar(&explainApply, "apply", false, "Patch the DevStagingEnvironment with the suggested changes, after confirmation")
+	explainCmd.Flags().BoolVarP(&explainForce, "force", "y", false, "With --apply, skip the confirmation prompt")
+	rootCmd.AddCommand(explainCmd)
+}
+
+// explainMaxTokens caps the explanation.
+const explainMaxTokens = 2048
+
+// explanation is what explain prints. It is also the JSON output.
+type explanation struct {
+	Environment string         `json:"environment"`
+	Namespace   string         `json:"namespace"`
+	Ready       bool           `json:"ready"`
+	Source      string         `json:"source"` // "rules", or the provider and model
+	Summary     string         `json:"summary"`
+	Causes      []explainCause `json:"causes"`
+	Changes     []specChange   `json:"changes"`
+	Applied     bool           `json:"applied,omitempty"`
+}
+
+// explainCause is one problem, in plain English.
+type explainCause struct {
+	Title       string `json:"title"`
+	Explanation string `json:"explanation"`
+	Evidence    string `json:"evidence,omitempty"`
+}
+
+// specChange sets one field of a DevStagingEnvironment.
+type specChange struct {
+	Path    string      `json:"path"` // dot-separated, e.g. spec.deployment.port
+	Value   interface{} `json:"value"`
+	Current interface{} `json:"current,omitempty"`
+	Reason  string      `json:"reason"`
+}
+
+// specChangePath is the form of a change's path: fields of the spec, not
+// list items — a list is replaced whole.
+var specChangePath = regexp.MustCompile(`^spec(\.[A-Za-z][A-Za-z0-9]*)+$`)
+
+// explainFacts is what the explanation is drawn from, and what the LLM
+// is sent.
+type explainFacts struct {
+	Environment envStatus              `json:"environment"`
+	Spec        map[string]interface{} `json:"spec"`
+	Components  []debugReport          `json:"failingComponents"`
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	env, err := explainTarget(collectEnvironments(), args[0], explainEnv)
+	if err != nil {
+		return err
+	}
+	obj, err := getObject("devstagingenvironments", env.Namespace, env.Name)
+	if err != nil {
+		return fmt.Errorf("cannot read DevStagingEnvironment %s: %w", env.Name, err)
+	}
+
+	sp := startSpinner(fmt.Sprintf("Gathering diagnostics for %s", env.Name))
+	facts := gatherExplainFacts(env, obj)
+	sp.stop()
+
+	result := explanation{Environment: env.Name, Namespace: env.Namespace, Ready: env.Ready, Causes: []explainCause{}, Changes: []specChange{}}
+	if env.Ready && len(facts.Components) == 0 {
+		result.Source = "rules"
+		result.Summary = fmt.Sprintf("%s is ready — every component is running and passing its health checks.", env.Name)
+		return render(result, func() { printExplanation(result) })
+	}
+
+	explained := false
+	if !explainNoAI {
+		explained, err = explainWithAI(&result, facts)
+		if err != nil {
+			warn(fmt.Sprintf("%v — explaining with kindling debug's rules instead", err))
+		}
+	}
+	if !explained {
+		explainWithRules(&result, facts)
+	}
+	for i := range result.Changes {
+		result.Changes[i].Current = objectPath(obj, result.Changes[i].Path)
+	}
+
+	if explainApply && len(result.Changes) > 0 {
+		if !isJSONOutput() {
+			printExplanation(result)
+		}
+		applied, err := applySpecChanges(env, result.Changes)
+		if err != nil {
+			return err
+		}
+		result.Applied = applied
+		if isJSONOutput() {
+			return render(result, nil)
+		}
+		return nil
+	}
+	return render(result, func() { printExplanation(result) })
+}
+
+// explainTarget finds the DevStagingEnvironment named name, in the
+// environment env when given.
+func explainTarget(envs []envStatus, name, env string) (envStatus, error) {
+	var matches []envStatus
+	for _, e := range envs {
+		if e.Name == name && (env == "" || e.Environment == env || e.Namespace == env) {
+			matches = append(matches, e)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return envStatus{}, fmt.Errorf("DevStagingEnvironment %q not found — see: kindling status", name)
+	case 1:
+		return matches[0], nil
+	}
+	var where []string
+	for _, m := range matches {
+		where = append(where, m.Environment)
+	}
+	return envStatus{}, fmt.Errorf("%q is in several environments (%s) — pass --env", name, strings.Join(where, ", "))
+}
+
+// gatherExplainFacts collects a debug report of every component of env
+// that isn't ready, and the spec with credentials masked.
+func gatherExplainFacts(env envStatus, obj map[string]interface{}) explainFacts {
+	facts := explainFacts{Environment: env, Components: []debugReport{}}
+	if spec, ok := obj["spec"].(map[string]interface{}); ok {
+		data, _ := json.Marshal(spec)
+		_ = json.Unmarshal(data, &facts.Spec) // a copy to mask
+		logging.RedactEnvValues(facts.Spec)
+	}
+	for _, c := range env.Components {
+		if c.Role == "job" || (c.Ready >= c.Desired && c.Problem == "") {
+			continue
+		}
+		report, err := gatherDebugReport(componentRef{namespace: env.Namespace, name: c.Name})
+		if err != nil {
+			logger.Warn("cannot gather diagnostics", "component", c.Name, "error", err)
+			continue
+		}
+		facts.Components = append(facts.Components, report)
+	}
+	return facts
+}
+
+// explainWithRules explains with the causes kindling debug recognises.
+func explainWithRules(result *explanation, facts explainFacts) {
+	result.Source = "rules"
+	for _, report := range facts.Components {
+		for _, c := range report.Causes {
+			title := c.Title
+			if report.Component != result.Environment {
+				title = report.Component + ": " + title
+			}
+			result.Causes = append(result.Causes, explainCause{
+				Title:       title,
+				Explanation: sentenceCase(c.Fix) + ".",
+				Evidence:    c.Evidence,
+			})
+			if c.change != nil {
+				result.Changes = append(result.Changes, *c.change)
+			}
+		}
+	}
+	for _, cond := range facts.Environment.Conditions {
+		if cond.Status == "False" && cond.Message != "" {
+			result.Causes = append(result.Causes, explainCause{
+				Title:       fmt.Sprintf("%s is false (%s)", cond.Type, cond.Reason),
+				Explanation: cond.Message,
+			})
+		}
+	}
+	switch len(result.Causes) {
+	case 0:
+		result.Summary = fmt.Sprintf("%s isn't ready, and no known cause was recognised — kindling debug <component> shows the logs and events.", result.Environment)
+	case 1:
+		result.Summary = fmt.Sprintf("%s isn't ready: %s.", result.Environment, lowerFirst(result.Causes[0].Title))
+	default:
+		result.Summary = fmt.Sprintf("%s isn't ready; %d problems were found, the most likely first.", result.Environment, len(result.Causes))
+	}
+}
+
+// explainSystemPrompt tells the model what it is looking at and what to
+// answer.
+const explainSystemPrompt = `You diagnose failing kindling DevStagingEnvironments (DSEs) on a local Kubernetes cluster. The kindling operator turns a DSE into a Deployment for the app (spec.deployment), a Service, an optional Ingress (spec.ingress), and a StatefulSet or Deployment per backing service (spec.dependencies), injecting each dependency's connection URL into the app's env.
+
+You are given the DSE's status, its spec (credential values masked as ***), and for every component that isn't ready: its pods and container states, recent events, and the last log lines.
+
+Explain to a developer, in plain English and without jargon they'd have to look up, what is wrong and why, most likely cause first. Quote the evidence — a log line, an event — that shows it.
+
+Suggest spec changes only when a change to the DSE fixes the problem; problems in the app's code or Dockerfile get an explanation and no change. Each change sets one field: path is dot-separated from spec, e.g. spec.deployment.port, spec.deployment.resources.memoryLimit, spec.deployment.healthCheck.path; value is the new value as JSON, e.g. 8080 or "512Mi". Paths can't index into lists: to change spec.deployment.env or spec.dependencies, give the whole new list. Never invent credential values.`
+
+// explainSchema is the JSON Schema of the model's answer.
+func explainSchema() generate.ReplySchema {
+	str := func(desc string) map[string]interface{} {
+		return map[string]interface{}{"type": "string", "description": desc}
+	}
+	list := func(desc string, items map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"type": "array", "description": desc, "items": items}
+	}
+	return generate.ReplySchema{
+		Name:        "explanation",
+		Description: "Why a DevStagingEnvironment is failing and how to fix its spec.",
+		Schema: generate.SchemaObject("A diagnosis.", map[string]interface{}{
+			"summary": str("One or two sentences: what is wrong, in plain English."),
+			"causes": list("The problems, most likely first.", generate.SchemaObject("One problem.", map[string]interface{}{
+				"title":       str("Short name of the problem."),
+				"explanation": str("What is wrong, why, and how to fix it, in plain English."),
+				"evidence":    str(`The log line, event, or status that shows it, or "".`),
+			})),
+			"changes": list("Spec changes that fix the problems; empty when none would.", generate.SchemaObject("A change to one spec field.", map[string]interface{}{
+				"path":   str("Dot-separated field path from spec, e.g. spec.deployment.port."),
+				"value":  str(`The new value as JSON, e.g. 8080, "512Mi", or a whole list.`),
+				"reason": str("Why this change fixes the problem."),
+			})),
+		}),
+	}
+}
+
+// explainReply is the model's answer.
+type explainReply struct {
+	Summary string         `json:"summary"`
+	Causes  []explainCause `json:"causes"`
+	Changes []struct {
+		Path   string `json:"path"`
+		Value  string `json:"value"`
+		Reason string `json:"reason"`
+	} `json:"changes"`
+}
+
+// explainWithAI has the configured LLM explain facts. It reports false,
+// with no error, when no provider is usable without asking for one.
+func explainWithAI(result *explanation, facts explainFacts) (bool, error) {
+	cfg, err := loadKindlingConfig(".")
+	if err != nil {
+		return false, err
+	}
+	provider := resolveLLMProvider(cfg, explainLLM)
+	if !generate.IsProvider(provider) {
+		return false, fmt.Errorf("unsupported LLM provider %q (use %s)", provider, strings.Join(generate.Providers, ", "))
+	}
+	providerCfg := cfg.LLM.Providers[provider]
+	apiKey := generate.ResolveAPIKey(provider, explainAPIKey, providerCfg)
+	if apiKey == "" && generate.NeedsAPIKey(provider) {
+		return false, nil
+	}
+	generator, err := generate.NewGenerator(provider, apiKey, explainModel, providerCfg, llmOptions(".", generate.DefaultRetries, generate.DefaultRequestTimeout))
+	if err != nil {
+		return false, err
+	}
+	sg, ok := generator.(generate.StructuredGenerator)
+	if !ok {
+		return false, fmt.Errorf("%s can't hold replies to a schema", generator.Name())
+	}
+	budget, err := generate.NewBudget(generator, providerCfg, 0, 0)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := json.MarshalIndent(facts, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	userPrompt := "Explain why this DevStagingEnvironment is failing:\n\n" + logging.RedactText(string(data))
+	maxTokens, err := budget.ReplyLimit(explainSystemPrompt + userPrompt)
+	if err != nil {
+		return false, err
+	}
+
+	sp := startSpinner(fmt.Sprintf("Asking %s (%s)", generator.Name(), generator.Model()))
+	reply, usage, err := sg.GenerateJSON(explainSystemPrompt, userPrompt, min(maxTokens, explainMaxTokens), explainSchema())
+	sp.stop()
+	budget.Spend(usage)
+	reportUsage(budget, ".")
+	if err != nil {
+		if errors.Is(err, generate.ErrUnavailable) {
+			return false, err
+		}
+		return false, fmt.Errorf("%s couldn't explain it: %w", generator.Name(), err)
+	}
+
+	var answer explainReply
+	if err := json.Unmarshal([]byte(strings.TrimSpace(generate.CleanYAML(reply))), &answer); err != nil {
+		return false, fmt.Errorf("%s's answer isn't the expected JSON: %w", generator.Name(), err)
+	}
+	result.Source = generator.Name() + "/" + generator.Model()
+	result.Summary = answer.Summary
+	if answer.Causes != nil {
+		result.Causes = answer.Causes
+	}
+	for _, c := range answer.Changes {
+		if !specChangePath.MatchString(c.Path) {
+			logger.Warn("ignoring a suggested change", "path", c.Path)
+			continue
+		}
+		var value interface{}
+		if json.Unmarshal([]byte(c.Value), &value) != nil {
+			value = c.Value // a bare string
+		}
+		result.Changes = append(result.Changes, specChange{Path: c.Path, Value: value, Reason: c.Reason})
+	}
+	return true, nil
+}
+
+// objectPath returns the value at a dot-separated path of obj, or nil.
+func objectPath(obj map[string]interface{}, path string) interface{} {
+	var v interface{} = obj
+	for _, field := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[field]
+	}
+	return v
+}
+
+// specChangesPatch is the JSON merge patch that makes changes.
+func specChangesPatch(changes []specChange) ([]byte, error) {
+	patch := map[string]interface{}{}
+	for _, c := range changes {
+		fields := strings.Split(c.Path, ".")
+		m := patch
+		for _, field := range fields[:len(fields)-1] {
+			next, ok := m[field].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				m[field] = next
+			}
+			m = next
+		}
+		m[fields[len(fields)-1]] = c.Value
+	}
+	return json.Marshal(patch)
+}
+
+// applySpecChanges confirms changes and patches env with them, after a
+// server-side dry run. It reports whether the DSE was patched.
+func applySpecChanges(env envStatus, changes []specChange) (bool, error) {
+	if !explainForce {
+		fmt.Fprintln(os.Stderr)
+		if !promptYesNo(bufio.NewReader(os.Stdin), fmt.Sprintf("Patch DevStagingEnvironment %s with these %d change(s)", env.Name, len(changes)), false) {
+			step("↩️ ", "Nothing changed")
+			return false, nil
+		}
+	}
+	patch, err := specChangesPatch(changes)
+	if err != nil {
+		return false, err
+	}
+	args := []string{"patch", "devstagingenvironment", env.Name, "-n", env.Namespace, "--type", "merge", "-p", string(patch)}
+	if out, err := captureKubectl(append(args, "--dry-run=server")...); err != nil {
+		return false, fmt.Errorf("the cluster rejects the changes, so none were made: %s", strings.TrimSpace(out))
+	}
+	if out, err := captureKubectl(args...); err != nil {
+		return false, fmt.Errorf("kubectl patch failed: %s", strings.TrimSpace(out))
+	}
+	success(fmt.Sprintf("Patched %s — the operator is rolling it out (kindling status)", env.Name))
+	step("💡", "Make the same changes in your manifest, or the next kindling deploy reverts them")
+	return true, nil
+}
+
+func printExplanation(r explanation) {
+	header(fmt.Sprintf("Explaining %s (%s)", r.Environment, r.Namespace))
+	source := "kindling debug's rules"
+	if r.Source != "rules" {
+		source = r.Source
+	}
+	fmt.Printf("  %s\n", dimText("by "+source))
+	fmt.Printf("\n  %s\n", r.Summary)
+
+	for i, c := range r.Causes {
+		fmt.Printf("\n  %s%d. %s%s\n", colorBold, i+1, c.Title, colorReset)
+		fmt.Printf("     %s\n", c.Explanation)
+		if c.Evidence != "" {
+			fmt.Printf("     %s\n", dimText(c.Evidence))
+		}
+	}
+
+	if len(r.Changes) > 0 {
+		header("Suggested spec changes")
+		for _, c := range r.Changes {
+			fmt.Printf("  %s%s%s: %s → %s%s%s\n", colorBold, c.Path, colorReset,
+				dimText(jsonText(c.Current)), colorCyan, jsonText(c.Value), colorReset)
+			fmt.Printf("    %s\n", dimText(c.Reason))
+		}
+		if !explainApply {
+			fmt.Printf("\n  Apply them with: %skindling explain %s --apply%s\n", colorCyan, r.Environment, colorReset)
+		}
+	}
+	fmt.Println()
+}
+
+// sentenceCase capitalises the first letter of s.
+func sentenceCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// lowerFirst lowers the first letter of s.
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// jsonText shows a value as JSON, or "unset" for none.
+func jsonText(v interface{}) string {
+	if v == nil {
+		return "unset"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
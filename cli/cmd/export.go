@@ -0,0 +1,595 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffvincent/kindling/cli/pkg/build"
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a DevStagingEnvironment as plain manifests, a kustomize base, or a Helm chart",
+	Long: `Writes out the Kubernetes objects the operator created for the
+DevStagingEnvironments in a manifest — Deployments, StatefulSets,
+Services, Ingresses, and Secrets — so a working dev environment can be
+carried into a real deployment pipeline.
+
+The objects are read from the cluster, so deploy the manifest first
+(kindling deploy -f). Server-set fields, owner references, and namespaces
+are stripped, and Secret values are replaced with placeholders to fill in.
+The ConfigMaps and Secrets the spec references are included too.
+
+Formats:
+  manifests   one multi-document YAML stream (stdout unless --out)
+  kustomize   a base directory: one file per object, Secrets as a
+              secretGenerator with .env placeholders, app images under
+              images: for kustomize edit set image
+  helm        a chart whose values.yaml holds each environment's image,
+              replicas, and ingress host, and every Secret value
+
+Examples:
+  kindling export -f dev-environment.yaml
+  kindling export -f dev-environment.yaml --format kustomize --out deploy/base
+  kindling export -f dev-environment.yaml --format helm --out charts/orders
+  kindling export -f dev-environment.yaml --skip-dependencies`,
+	SilenceUsage: true,
+	RunE:         runExport,
+}
+
+var (
+	exportFile     string
+	exportFormat   string
+	exportOut      string
+	exportSkipDeps bool
+)
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFile, "file", "f", "", "DevStagingEnvironment manifest to export (required)")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "manifests", "Output format: manifests, kustomize, or helm")
+	exportCmd.Flags().StringVar(&exportOut, "out", "", "Output file (manifests) or directory (kustomize, helm) (default: stdout, or <file>-<format>/)")
+	exportCmd.Flags().BoolVar(&exportSkipDeps, "skip-dependencies", false, "Leave out the dependencies' workloads, Services, and credentials (e.g. for managed databases)")
+	_ = exportCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(exportCmd)
+}
+
+const (
+	operatorManagedByValue = "devstagingenvironment-operator"
+	exportManagedByValue   = "kindling-export"
+)
+
+// exportKindOrder is the order objects are written in, so that what
+// others reference comes first.
+var exportKindOrder = map[string]int{
+	"Secret": 0, "ConfigMap": 1, "Service": 2, "StatefulSet": 3, "Deployment": 4, "Ingress": 5,
+}
+
+// exportObject is one object of an export, with the environment it
+// belongs to.
+type exportObject struct {
+	env  string
+	obj  map[string]interface{}
+	kind string
+	name string
+}
+
+// exportResult is the JSON report of kindling export.
+type exportResult struct {
+	Format       string         `json:"format"`
+	Out          string         `json:"out,omitempty"`
+	Environments []string       `json:"environments"`
+	Objects      []exportedItem `json:"objects"`
+	Manifest     string         `json:"manifest,omitempty"`
+}
+
+type exportedItem struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	File string `json:"file,omitempty"`
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	switch exportFormat {
+	case "manifests", "kustomize", "helm":
+	default:
+		return fmt.Errorf("invalid --format %q (use manifests, kustomize, or helm)", exportFormat)
+	}
+	names, err := manifestDSENames(exportFile)
+	if err != nil {
+		return err
+	}
+	live, err := listDSEs()
+	if err != nil {
+		return err
+	}
+
+	var objects []exportObject
+	var envs []string
+	for _, n := range names {
+		name, ns := n[0], n[1]
+		var dse *dseSummary
+		for i, d := range live {
+			if d.Metadata.Name == name && (ns == "" || d.Metadata.Namespace == ns) {
+				dse = &live[i]
+				break
+			}
+		}
+		if dse == nil {
+			return fmt.Errorf("%s is not deployed — export reads what the operator created, so run: kindling deploy -f %s", name, exportFile)
+		}
+		objs, err := collectExportObjects(name, dse.Metadata.Namespace)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, objs...)
+		envs = append(envs, name)
+	}
+	sort.SliceStable(objects, func(i, k int) bool {
+		if exportKindOrder[objects[i].kind] != exportKindOrder[objects[k].kind] {
+			return exportKindOrder[objects[i].kind] < exportKindOrder[objects[k].kind]
+		}
+		return objects[i].name < objects[k].name
+	})
+
+	result := exportResult{Format: exportFormat, Environments: envs, Objects: []exportedItem{}}
+	base := strings.TrimSuffix(filepath.Base(exportFile), filepath.Ext(exportFile))
+	out := exportOut
+	if out == "" && exportFormat != "manifests" {
+		out = base + "-" + exportFormat
+	}
+	result.Out = out
+
+	switch exportFormat {
+	case "manifests":
+		err = exportManifests(objects, out, &result)
+	case "kustomize":
+		err = exportKustomize(objects, out, &result)
+	case "helm":
+		err = exportHelm(objects, base, out, &result)
+	}
+	if err != nil {
+		return err
+	}
+
+	return render(result, func() {
+		if out == "" {
+			fmt.Print(result.Manifest)
+			return
+		}
+		success(fmt.Sprintf("Exported %d object(s) from %s to %s", len(result.Objects), strings.Join(envs, ", "), out))
+		if len(result.Objects) > 0 {
+			fmt.Fprintln(os.Stderr)
+			step("💡", "Fill in the Secret placeholders and point the images at your registry before deploying")
+		}
+	})
+}
+
+// collectExportObjects reads the operator's objects for an environment,
+// plus the ConfigMaps and Secrets its spec references, ready to export.
+func collectExportObjects(env, ns string) ([]exportObject, error) {
+	selectors := []string{"app.kubernetes.io/instance=" + env}
+	if !exportSkipDeps {
+		selectors = append(selectors, "app.kubernetes.io/part-of="+env)
+	}
+	var items []map[string]interface{}
+	for _, sel := range selectors {
+		out, err := kubectlJSON("get", "deployments,statefulsets,services,ingresses,secrets", "-n", ns,
+			"-l", sel+",app.kubernetes.io/managed-by="+operatorManagedByValue, "-o", "json")
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the objects of %s", env)
+		}
+		var list struct {
+			Items []map[string]interface{} `json:"items"`
+		}
+		if err := json.Unmarshal([]byte(out), &list); err != nil {
+			return nil, err
+		}
+		items = append(items, list.Items...)
+	}
+
+	dseOut, err := kubectlJSON("get", "devstagingenvironment", env, "-n", ns, "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read DevStagingEnvironment %s", env)
+	}
+	var dse map[string]interface{}
+	if err := json.Unmarshal([]byte(dseOut), &dse); err != nil {
+		return nil, err
+	}
+	configMaps, secrets := referencedObjects(dse["spec"])
+	for _, ref := range []struct {
+		kind  string
+		names []string
+	}{{"configmap", configMaps}, {"secret", secrets}} {
+		for _, name := range ref.names {
+			out, err := kubectlJSON("get", ref.kind, name, "-n", ns, "-o", "json")
+			if err != nil {
+				warn(fmt.Sprintf("%s %s is referenced by %s but missing — skipped", ref.kind, name, env))
+				continue
+			}
+			var obj map[string]interface{}
+			if json.Unmarshal([]byte(out), &obj) == nil {
+				items = append(items, obj)
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	var objects []exportObject
+	for _, obj := range items {
+		o := exportObject{env: env, obj: cleanExportObject(obj)}
+		o.kind, _ = o.obj["kind"].(string)
+		meta, _ := o.obj["metadata"].(map[string]interface{})
+		o.name, _ = meta["name"].(string)
+		if seen[o.kind+"/"+o.name] {
+			continue
+		}
+		seen[o.kind+"/"+o.name] = true
+		objects = append(objects, o)
+	}
+	return objects, nil
+}
+
+// cleanExportObject strips what the API server and the operator added to
+// an object, leaving what a pipeline would apply.
+func cleanExportObject(obj map[string]interface{}) map[string]interface{} {
+	obj = cleanObject(obj)
+	meta := obj["metadata"].(map[string]interface{})
+	delete(meta, "namespace")
+	if annotations, ok := meta["annotations"].(map[string]interface{}); ok {
+		delete(annotations, "deployment.kubernetes.io/revision")
+		if len(annotations) == 0 {
+			delete(meta, "annotations")
+		}
+	}
+
+	spec, _ := obj["spec"].(map[string]interface{})
+	switch obj["kind"] {
+	case "Deployment", "StatefulSet":
+		if tmpl, ok := spec["template"].(map[string]interface{}); ok {
+			if tm, ok := tmpl["metadata"].(map[string]interface{}); ok {
+				delete(tm, "creationTimestamp")
+				if a, ok := tm["annotations"].(map[string]interface{}); ok {
+					delete(a, "kubectl.kubernetes.io/restartedAt")
+					if len(a) == 0 {
+						delete(tm, "annotations")
+					}
+				}
+			}
+		}
+		if claims, ok := spec["volumeClaimTemplates"].([]interface{}); ok {
+			for _, c := range claims {
+				if claim, ok := c.(map[string]interface{}); ok {
+					delete(claim, "status")
+					if cm, ok := claim["metadata"].(map[string]interface{}); ok {
+						delete(cm, "creationTimestamp")
+					}
+				}
+			}
+		}
+	case "Service":
+		for _, f := range []string{"clusterIP", "clusterIPs", "ipFamilies", "ipFamilyPolicy", "internalTrafficPolicy"} {
+			delete(spec, f)
+		}
+	case "Secret":
+		data, _ := obj["data"].(map[string]interface{})
+		stringData := map[string]interface{}{}
+		for key := range data {
+			stringData[key] = ""
+		}
+		delete(obj, "data")
+		obj["stringData"] = stringData
+	}
+
+	// The objects are no longer the operator's; relabel them so a running
+	// operator doesn't prune them, keeping selectors and labels in step.
+	relabelManagedBy(obj)
+	return obj
+}
+
+// relabelManagedBy rewrites the operator's managed-by label everywhere in
+// v: metadata, selectors, and pod templates alike.
+func relabelManagedBy(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if k == "app.kubernetes.io/managed-by" && child == operatorManagedByValue {
+				v[k] = exportManagedByValue
+				continue
+			}
+			relabelManagedBy(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			relabelManagedBy(child)
+		}
+	}
+}
+
+// exportYAML encodes an object with apiVersion, kind, and metadata first,
+// as kubectl and hand-written manifests order them.
+func exportYAML(obj map[string]interface{}) ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(obj); err != nil {
+		return nil, err
+	}
+	rank := map[string]int{"apiVersion": 0, "kind": 1, "metadata": 2}
+	pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, k int) bool {
+		ri, ok := rank[pairs[i][0].Value]
+		if !ok {
+			ri = len(rank)
+		}
+		rk, ok := rank[pairs[k][0].Value]
+		if !ok {
+			rk = len(rank)
+		}
+		return ri < rk
+	})
+	node.Content = node.Content[:0]
+	for _, p := range pairs {
+		node.Content = append(node.Content, p[0], p[1])
+	}
+	return marshalYAML(&node)
+}
+
+// marshalYAML encodes v with the two-space indent Kubernetes manifests use.
+func marshalYAML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func exportFileName(o exportObject) string {
+	return fmt.Sprintf("%s-%s.yaml", o.name, strings.ToLower(o.kind))
+}
+
+// ── manifests ───────────────────────────────────────────────────
+
+func exportManifests(objects []exportObject, out string, result *exportResult) error {
+	var sb strings.Builder
+	for i, o := range objects {
+		data, err := exportYAML(o.obj)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			sb.WriteString("---\n")
+		}
+		sb.Write(data)
+		result.Objects = append(result.Objects, exportedItem{Kind: o.kind, Name: o.name})
+	}
+	if out == "" {
+		result.Manifest = sb.String()
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(out, []byte(sb.String()), 0644)
+}
+
+// ── kustomize ───────────────────────────────────────────────────
+
+func exportKustomize(objects []exportObject, dir string, result *exportResult) error {
+	if err := os.MkdirAll(filepath.Join(dir, "secrets"), 0755); err != nil {
+		return fmt.Errorf("cannot create %s: %w", dir, err)
+	}
+
+	kustomization := map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+	}
+	var resources []string
+	var generators []interface{}
+	images := map[string]map[string]interface{}{}
+	for _, o := range objects {
+		if o.kind == "Secret" {
+			// Secrets are generated from .env files so their values stay
+			// out of the resources and can come from the pipeline.
+			stringData, _ := o.obj["stringData"].(map[string]interface{})
+			var env strings.Builder
+			fmt.Fprintf(&env, "# Values for Secret %s — fill in before applying\n", o.name)
+			for _, key := range sortedKeys(stringData) {
+				fmt.Fprintf(&env, "%s=\n", key)
+			}
+			file := filepath.Join("secrets", o.name+".env")
+			if err := os.WriteFile(filepath.Join(dir, file), []byte(env.String()), 0600); err != nil {
+				return err
+			}
+			gen := map[string]interface{}{"name": o.name, "envs": []string{filepath.ToSlash(file)}}
+			if t, ok := o.obj["type"].(string); ok && t != "" && t != "Opaque" {
+				gen["type"] = t
+			}
+			generators = append(generators, gen)
+			result.Objects = append(result.Objects, exportedItem{Kind: o.kind, Name: o.name, File: file})
+			continue
+		}
+
+		file := exportFileName(o)
+		data, err := exportYAML(o.obj)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), data, 0644); err != nil {
+			return err
+		}
+		resources = append(resources, file)
+		result.Objects = append(result.Objects, exportedItem{Kind: o.kind, Name: o.name, File: file})
+
+		if image := exportAppImage(o); image != "" {
+			name, tag := build.SplitTag(image)
+			images[name] = map[string]interface{}{"name": name, "newTag": tag}
+		}
+	}
+
+	kustomization["resources"] = resources
+	if len(generators) > 0 {
+		kustomization["secretGenerator"] = generators
+		// Deployments reference the Secrets by their plain names.
+		kustomization["generatorOptions"] = map[string]interface{}{"disableNameSuffixHash": true}
+	}
+	if len(images) > 0 {
+		var list []interface{}
+		for _, name := range sortedKeys(images) {
+			list = append(list, images[name])
+		}
+		kustomization["images"] = list
+	}
+	data, err := exportYAML(kustomization)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "kustomization.yaml"), data, 0644)
+}
+
+// exportAppImage returns the image of an environment's app container —
+// the one the operator names after the DSE — or "" for other objects.
+func exportAppImage(o exportObject) string {
+	container := exportAppContainer(o)
+	if container == nil {
+		return ""
+	}
+	image, _ := container["image"].(string)
+	return image
+}
+
+func exportAppContainer(o exportObject) map[string]interface{} {
+	if o.kind != "Deployment" || o.name != o.env {
+		return nil
+	}
+	spec, _ := o.obj["spec"].(map[string]interface{})
+	tmpl, _ := spec["template"].(map[string]interface{})
+	podSpec, _ := tmpl["spec"].(map[string]interface{})
+	containers, _ := podSpec["containers"].([]interface{})
+	for _, c := range containers {
+		if container, ok := c.(map[string]interface{}); ok && container["name"] == o.env {
+			return container
+		}
+	}
+	return nil
+}
+
+// ── helm ────────────────────────────────────────────────────────
+
+// helmMarker wraps a template expression in a string that survives YAML
+// encoding; helmMarkerPattern turns it back into {{ expression }}.
+func helmMarker(expr string) string { return "@@helm:" + expr + "@@" }
+
+var helmMarkerPattern = regexp.MustCompile(`'?@@helm:([^@]*)@@'?`)
+
+func exportHelm(objects []exportObject, name, dir string, result *exportResult) error {
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), 0755); err != nil {
+		return fmt.Errorf("cannot create %s: %w", dir, err)
+	}
+
+	environments := map[string]interface{}{}
+	secrets := map[string]interface{}{}
+	for _, o := range objects {
+		envValues, _ := environments[o.env].(map[string]interface{})
+		if envValues == nil {
+			envValues = map[string]interface{}{}
+			environments[o.env] = envValues
+		}
+		ref := func(key string) string {
+			return fmt.Sprintf("index .Values.environments %q %q", o.env, key)
+		}
+
+		switch {
+		case o.kind == "Secret":
+			stringData, _ := o.obj["stringData"].(map[string]interface{})
+			values := map[string]interface{}{}
+			for key := range stringData {
+				values[key] = ""
+				stringData[key] = helmMarker(fmt.Sprintf("index .Values.secrets %q %q | quote", o.name, key))
+			}
+			secrets[o.name] = values
+		case o.kind == "Deployment" && o.name == o.env:
+			spec := o.obj["spec"].(map[string]interface{})
+			if replicas, ok := spec["replicas"]; ok {
+				envValues["replicas"] = replicas
+				spec["replicas"] = helmMarker(ref("replicas"))
+			}
+			if container := exportAppContainer(o); container != nil {
+				envValues["image"] = container["image"]
+				container["image"] = helmMarker(ref("image") + " | quote")
+			}
+		case o.kind == "Ingress" && o.name == o.env:
+			spec, _ := o.obj["spec"].(map[string]interface{})
+			rules, _ := spec["rules"].([]interface{})
+			var host string
+			for _, r := range rules {
+				if rule, ok := r.(map[string]interface{}); ok && rule["host"] != nil {
+					host, _ = rule["host"].(string)
+					rule["host"] = helmMarker(ref("host") + " | quote")
+				}
+			}
+			if host == "" {
+				break
+			}
+			envValues["host"] = host
+			tls, _ := spec["tls"].([]interface{})
+			for _, t := range tls {
+				entry, _ := t.(map[string]interface{})
+				hosts, _ := entry["hosts"].([]interface{})
+				for i, h := range hosts {
+					if h == host {
+						hosts[i] = helmMarker(ref("host") + " | quote")
+					}
+				}
+			}
+		}
+
+		data, err := exportYAML(o.obj)
+		if err != nil {
+			return err
+		}
+		data = helmMarkerPattern.ReplaceAll(data, []byte("{{ $1 }}"))
+		file := filepath.Join("templates", exportFileName(o))
+		if err := os.WriteFile(filepath.Join(dir, file), data, 0644); err != nil {
+			return err
+		}
+		result.Objects = append(result.Objects, exportedItem{Kind: o.kind, Name: o.name, File: file})
+	}
+
+	chart := fmt.Sprintf(`apiVersion: v2
+name: %s
+description: Exported by kindling export from %s
+type: application
+version: 0.1.0
+appVersion: "dev"
+`, source.DNSLabel(name), filepath.Base(exportFile))
+	if err := os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(chart), 0644); err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{"environments": environments}
+	if len(secrets) > 0 {
+		values["secrets"] = secrets
+	}
+	data, err := marshalYAML(values)
+	if err != nil {
+		return err
+	}
+	header := "# Per-environment image, replicas, and ingress host, and the value of\n# every Secret key. Fill in the secrets before installing.\n"
+	return os.WriteFile(filepath.Join(dir, "values.yaml"), append([]byte(header), data...), 0644)
+}
@@ -1,21 +1,20 @@
 package cmd
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
 	"strings"
-	"sync"
-	"syscall"
 	"time"
 
+	"github.com/jeffvincent/kindling/cli/internal/daemon"
+	"github.com/jeffvincent/kindling/cli/internal/procutil"
 	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/tunnel"
 )
 
 var exposeCmd = &cobra.Command{
@@ -26,18 +25,28 @@ ingress controller, enabling external OAuth/OIDC providers (Auth0, Okta,
 Firebase Auth, etc.) to call back into local services.
 
 The tunnel runs in the background — you get your terminal back immediately.
+On a remote --context cluster it reaches the ingress controller through a
+kubectl port-forward, which 'kindling port-forward --stop' ends.
 
 Supported providers:
-  cloudflared  — Cloudflare Tunnel (free, no account required for quick tunnels)
+  cloudflared  — Cloudflare Tunnel (free, no account required for quick tunnels;
+                 named tunnels with --tunnel-name/--hostname need a Cloudflare zone)
   ngrok        — ngrok tunnel (requires free account + auth token)
+  tailscale    — Tailscale Funnel (requires a tailnet with Funnel enabled)
 
 Examples:
   kindling expose                          # auto-detect provider, expose port 80
   kindling expose --provider cloudflared   # use cloudflared explicitly
   kindling expose --port 443               # expose a different port
-  kindling expose --stop                   # stop a running tunnel
+  kindling expose --service api --port 8080  # a second tunnel just for the api ingress
+  kindling expose --list                   # list running tunnels
+  kindling expose --stop --service api     # stop only the api tunnel
+  kindling expose --stop                   # stop all tunnels
+
+  # Named Cloudflare tunnel on your own domain (run 'cloudflared tunnel login' first)
+  kindling expose --tunnel-name kindling-dev --hostname dev.mycompany.com
 
-The public URL is saved to .kindling/tunnel.yaml so that other commands
+Each tunnel is tracked in .kindling/tunnels.yaml so that other commands
 (kindling generate) can reference it.`,
 	RunE: runExpose,
 }
@@ -46,69 +55,146 @@ var (
 	exposeProvider string
 	exposePort     int
 	exposeStop     bool
+	exposeList     bool
 	exposeService  string
+	exposeTunnel   string
+	exposeHostname string
 )
 
 func init() {
-	exposeCmd.Flags().StringVar(&exposeProvider, "provider", "", "Tunnel provider: cloudflared or ngrok (auto-detected if omitted)")
-	exposeCmd.Flags().IntVar(&exposePort, "port", 80, "Local port to expose (default: 80, the ingress controller)")
-	exposeCmd.Flags().BoolVar(&exposeStop, "stop", false, "Stop a running tunnel")
-	exposeCmd.Flags().StringVar(&exposeService, "service", "", "Ingress name to route tunnel traffic to (default: first ingress found)")
+	exposeCmd.Flags().StringVar(&exposeProvider, "provider", "", "Tunnel provider: cloudflared, ngrok, or tailscale (default: $KINDLING_TUNNEL_PROVIDER, then tunnel.provider in .kindling/config.yaml, then auto-detected)")
+	exposeCmd.Flags().IntVar(&exposePort, "port", 80, "Local port to expose (default: 80, the ingress controller; a port-forward to it on remote clusters)")
+	exposeCmd.Flags().BoolVar(&exposeStop, "stop", false, "Stop running tunnels (only the --service tunnel if given)")
+	exposeCmd.Flags().BoolVar(&exposeList, "list", false, "List tracked tunnels")
+	exposeCmd.Flags().StringVar(&exposeService, "service", "", "Ingress to route this tunnel to; each service gets its own tunnel (default: all unclaimed ingresses)")
+	exposeCmd.Flags().StringVar(&exposeTunnel, "tunnel-name", "", "Run a named Cloudflare tunnel instead of a quick tunnel (requires --hostname)")
+	exposeCmd.Flags().StringVar(&exposeHostname, "hostname", "", "Custom domain to route to the named tunnel (e.g. dev.mycompany.com)")
 	rootCmd.AddCommand(exposeCmd)
 }
 
 func runExpose(cmd *cobra.Command, args []string) error {
-	// ── Stop mode ───────────────────────────────────────────────
+	// ── List / stop modes ───────────────────────────────────────
+	if exposeList {
+		return listTunnels()
+	}
 	if exposeStop {
-		return stopTunnel()
+		return stopTunnels(exposeService)
 	}
 
 	header("Public HTTPS tunnel")
 
-	// ── Check for already-running tunnel ────────────────────────
-	if info, _ := readTunnelInfo(); info != nil && info.PID > 0 {
-		if processAlive(info.PID) {
-			success(fmt.Sprintf("Tunnel already running → %s%s%s (pid %d)", colorBold, info.URL, colorReset, info.PID))
+	// ── Check for an already-running tunnel for this service ────
+	pruneTunnels()
+	tunnels, _ := loadTunnels()
+	if i := tunnel.Find(tunnels, exposeService); i >= 0 {
+		info := tunnels[i]
+		result := exposeResult{Status: "running", Service: info.Label(), Provider: info.Provider, URL: info.URL, PID: info.PID}
+		return render(result, func() {
+			success(fmt.Sprintf("Tunnel for %s already running → %s%s%s (pid %d)", info.Label(), colorBold, info.URL, colorReset, info.PID))
 			fmt.Println()
-			fmt.Printf("  Stop with: %skindling expose --stop%s\n", colorCyan, colorReset)
+			fmt.Printf("  Stop with: %s%s%s\n", colorCyan, stopTunnelCommand(info.Service), colorReset)
 			fmt.Println()
-			return nil
-		}
-		// Stale PID — clean up and start fresh
-		cleanupTunnel()
+		})
 	}
 
 	// ── Resolve provider ────────────────────────────────────────
 	provider := exposeProvider
+	if exposeTunnel != "" || exposeHostname != "" {
+		if exposeTunnel == "" || exposeHostname == "" {
+			return fmt.Errorf("--tunnel-name and --hostname must be used together")
+		}
+		if provider != "" && provider != "cloudflared" {
+			return fmt.Errorf("named tunnels require --provider cloudflared")
+		}
+		provider = "cloudflared"
+	}
+	if provider == "" {
+		provider = configSettingValue("tunnel.provider")
+	}
 	if provider == "" {
 		provider = detectTunnelProvider()
 	}
 	if provider == "" {
 		fail("No tunnel provider found")
+		if isJSONOutput() {
+			return fmt.Errorf("install cloudflared, ngrok, or tailscale and try again")
+		}
 		fmt.Println()
 		fmt.Println("  Install one of:")
 		fmt.Printf("    brew install cloudflare/cloudflare/cloudflared\n")
 		fmt.Printf("    brew install ngrok/ngrok/ngrok\n")
+		fmt.Printf("    brew install tailscale\n")
 		fmt.Println()
-		return fmt.Errorf("install cloudflared or ngrok and try again")
+		return fmt.Errorf("install cloudflared, ngrok, or tailscale and try again")
 	}
 
 	// ── Verify cluster is running ───────────────────────────────
 	if !clusterExists(clusterName) {
-		return fmt.Errorf("Kind cluster %q not found — run 'kindling init' first", clusterName)
+		return errNoCluster()
+	}
+	if remoteCluster() && !cmd.Flags().Changed("port") {
+		port, err := forwardRemoteIngress()
+		if err != nil {
+			return err
+		}
+		exposePort = port
 	}
 
 	// ── Start tunnel ────────────────────────────────────────────
 	switch provider {
 	case "cloudflared":
+		if exposeTunnel != "" {
+			return runCloudflaredNamedTunnel()
+		}
 		return runCloudflaredTunnel()
 	case "ngrok":
 		return runNgrokTunnel()
+	case "tailscale":
+		return runTailscaleFunnel()
 	default:
 		return fmt.Errorf("unsupported provider: %s", provider)
 	}
 }
 
+// forwardRemoteIngress port-forwards a local port to the ingress
+// controller of a remote cluster, whose port 80 isn't published on this
+// host the way the local cluster's is, and returns that port. The forward
+// is tracked like any other, so 'kindling port-forward --stop' ends it.
+func forwardRemoteIngress() (int, error) {
+	name, c, ok := installedIngressController()
+	if !ok {
+		return 0, fmt.Errorf("no ingress controller found on %s — pass --port to expose a local port instead", clusterLabel())
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return 0, err
+	}
+	forwards, err := livePortForwards(cwd)
+	if err != nil {
+		return 0, err
+	}
+	if i := findPortForward(forwards, c.namespace, c.service); i >= 0 {
+		return forwards[i].LocalPort, nil
+	}
+	claimed := map[int]bool{}
+	for _, f := range forwards {
+		claimed[f.LocalPort] = true
+	}
+
+	state := PortForwardState{Component: c.service, Namespace: c.namespace, LocalPort: freeLocalPort(80, claimed), RemotePort: 80}
+	step("🔌", fmt.Sprintf("Forwarding localhost:%d to the %s ingress controller on %s", state.LocalPort, name, clusterLabel()))
+	pid, err := startPortForward(cwd, state)
+	if err != nil {
+		return 0, err
+	}
+	state.PID, state.Created = pid, time.Now().UTC().Truncate(time.Second)
+	if err := writePortForwards(cwd, append(forwards, state)); err != nil {
+		return 0, err
+	}
+	ensureTunnelGitignored(cwd)
+	return state.LocalPort, nil
+}
+
 // detectTunnelProvider checks for available tunnel binaries.
 func detectTunnelProvider() string {
 	if commandExists("cloudflared") {
@@ -117,6 +203,9 @@ func detectTunnelProvider() string {
 	if commandExists("ngrok") {
 		return "ngrok"
 	}
+	if commandExists("tailscale") {
+		return "tailscale"
+	}
 	return ""
 }
 
@@ -125,84 +214,84 @@ func detectTunnelProvider() string {
 func runCloudflaredTunnel() error {
 	step("⏳", "Starting cloudflared tunnel...")
 
-	tunnelCmd := exec.Command("cloudflared", "tunnel",
-		"--url", fmt.Sprintf("http://localhost:%d", exposePort),
+	d, publicURL, err := startCloudflared(
+		[]string{"tunnel", "--url", fmt.Sprintf("http://localhost:%d", exposePort)},
+		quickTunnelURL,
 	)
+	if err != nil {
+		return err
+	}
 
-	// Capture stderr silently for URL parsing — no noise on the terminal.
-	var stderrBuf bytes.Buffer
-	var mu sync.Mutex
-	pr, pw := io.Pipe()
-	tunnelCmd.Stdout = nil
-	tunnelCmd.Stderr = pw
-
-	// Detach from parent process group so it survives CLI exit.
-	tunnelCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-
-	// Read stderr into buffer in background.
-	go func() {
-		buf := make([]byte, 4096)
-		for {
-			n, err := pr.Read(buf)
-			if n > 0 {
-				mu.Lock()
-				stderrBuf.Write(buf[:n])
-				mu.Unlock()
-			}
-			if err != nil {
-				return
-			}
+	// Success — save PID so we can stop it later, then let it run.
+	saveTunnelInfo(publicURL, "cloudflared", d.PID, nil)
+	patchIngressesForTunnel(publicURL, exposeService)
+	return printTunnelRunning(publicURL, "cloudflared", d.PID)
+}
+
+// quickTunnelURL extracts the https://*.trycloudflare.com URL from
+// cloudflared's log output, or returns "" if it has not appeared yet.
+func quickTunnelURL(logs string) string {
+	for _, line := range strings.Split(logs, "\n") {
+		if !strings.Contains(line, ".trycloudflare.com") {
+			continue
 		}
-	}()
-
-	if err := tunnelCmd.Start(); err != nil {
-		pw.Close()
-		return fmt.Errorf("failed to start cloudflared: %w", err)
-	}
-
-	// Poll the captured stderr for the tunnel URL.
-	var publicURL string
-	for i := 0; i < 30; i++ {
-		time.Sleep(1 * time.Second)
-		mu.Lock()
-		data := stderrBuf.String()
-		mu.Unlock()
-		for _, line := range strings.Split(data, "\n") {
-			if strings.Contains(line, ".trycloudflare.com") {
-				for _, word := range strings.Fields(line) {
-					if strings.HasPrefix(word, "https://") && strings.Contains(word, ".trycloudflare.com") {
-						publicURL = strings.TrimRight(word, "|, ")
-						break
-					}
-				}
+		for _, word := range strings.Fields(line) {
+			if strings.HasPrefix(word, "https://") && strings.Contains(word, ".trycloudflare.com") {
+				return strings.TrimRight(word, "|, ")
 			}
 		}
-		if publicURL != "" {
-			break
-		}
 	}
+	return ""
+}
 
-	if publicURL == "" {
-		// Kill the process if we couldn't get a URL — no point leaving it around.
-		if tunnelCmd.Process != nil {
-			_ = tunnelCmd.Process.Kill()
-		}
-		pw.Close()
-		return fmt.Errorf("could not detect public URL — try running cloudflared manually")
+// startCloudflared launches cloudflared in the background with args and
+// polls its log with detect until it yields the public URL. The process
+// is stopped if no URL appears within 30 seconds.
+//
+// Behind a proxy it connects to the edge over HTTP/2 rather than QUIC:
+// cloudflared reaches the Cloudflare API through the proxy, but dials the
+// edge directly on port 7844, and proxied networks tend to block UDP.
+func startCloudflared(args []string, detect func(logs string) string) (*daemon.Daemon, string, error) {
+	if proxyFor("https://api.trycloudflare.com") != nil && len(args) > 0 && args[0] == "tunnel" {
+		args = append([]string{"tunnel", "--protocol", "http2"}, args[1:]...)
+	}
+	d, publicURL, err := startTunnelDaemon("cloudflared", exec.Command("cloudflared", args...), 30*time.Second, detect)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not detect public URL — try running cloudflared manually")
 	}
+	return d, publicURL, nil
+}
 
-	// Success — save PID so we can stop it later, then let it run.
-	saveTunnelInfo(publicURL, "cloudflared", tunnelCmd.Process.Pid)
-	patchIngressesForTunnel(publicURL)
-	printTunnelRunning(publicURL, tunnelCmd.Process.Pid)
-
-	// Release the child — we don't wait on it; it runs in the background.
-	go func() {
-		_ = tunnelCmd.Wait()
-		pw.Close()
-	}()
+// startTunnelDaemon starts a tunnel process as the daemon of the
+// --service tunnel and waits until detect, given its log, returns the
+// public URL. The process is stopped when that doesn't happen within
+// timeout.
+func startTunnelDaemon(provider string, cmd *exec.Cmd, timeout time.Duration, detect func(logs string) string) (*daemon.Daemon, string, error) {
+	registry := daemons()
+	d, err := startDaemon(registry, daemon.Daemon{
+		Name:   tunnelDaemonName(exposeService),
+		Kind:   daemon.KindTunnel,
+		Labels: map[string]string{"provider": provider, "service": (&tunnel.State{Service: exposeService}).Label()},
+	}, cmd)
+	if err != nil {
+		return nil, "", err
+	}
+	publicURL, err := d.WaitFor(timeout, detect)
+	if err != nil {
+		_ = registry.Stop(d.Name, time.Second)
+		return nil, "", err
+	}
+	d.Health = publicURL
+	_ = registry.Register(*d)
+	return d, publicURL, nil
+}
 
-	return nil
+// tunnelDaemonName names the daemon of the tunnel for service.
+func tunnelDaemonName(service string) string {
+	if service == "" {
+		return "tunnel-default"
+	}
+	return "tunnel-" + service
 }
 
 // ── Ngrok ───────────────────────────────────────────────────────
@@ -215,208 +304,274 @@ func runNgrokTunnel() error {
 		"--log", "stdout",
 		"--log-format", "json",
 	)
-	tunnelCmd.Stdout = nil
-	tunnelCmd.Stderr = nil
-
-	// Detach from parent process group so it survives CLI exit.
-	tunnelCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-
-	if err := tunnelCmd.Start(); err != nil {
-		return fmt.Errorf("failed to start ngrok: %w", err)
+	// ngrok reads only http_proxy, which it uses for its TLS connection
+	// to the ngrok service.
+	if proxy := proxyFor("https://connect.ngrok-agent.com"); proxy != nil {
+		tunnelCmd.Env = append(os.Environ(), "http_proxy="+proxy.String())
 	}
 
 	// Poll the ngrok local API for the public URL
-	var publicURL string
-	for i := 0; i < 15; i++ {
-		time.Sleep(1 * time.Second)
-		url, err := getNgrokPublicURL()
-		if err == nil && url != "" {
-			publicURL = url
-			break
-		}
-	}
-
-	if publicURL == "" {
-		if tunnelCmd.Process != nil {
-			_ = tunnelCmd.Process.Kill()
-		}
-		return fmt.Errorf("could not detect public URL — check ngrok dashboard at http://localhost:4040")
+	d, publicURL, err := startTunnelDaemon("ngrok", tunnelCmd, 15*time.Second, func(string) string {
+		url, _ := getNgrokPublicURL(exposePort)
+		return url
+	})
+	if err != nil {
+		return fmt.Errorf("could not detect public URL — check the ngrok dashboard at http://localhost:4040")
 	}
 
-	saveTunnelInfo(publicURL, "ngrok", tunnelCmd.Process.Pid)
-	patchIngressesForTunnel(publicURL)
-	printTunnelRunning(publicURL, tunnelCmd.Process.Pid)
-
-	// Release the child — runs in background.
-	go func() { _ = tunnelCmd.Wait() }()
-
-	return nil
+	saveTunnelInfo(publicURL, "ngrok", d.PID, nil)
+	patchIngressesForTunnel(publicURL, exposeService)
+	return printTunnelRunning(publicURL, "ngrok", d.PID)
 }
 
-// getNgrokPublicURL queries the ngrok local API for the tunnel URL.
-func getNgrokPublicURL() (string, error) {
-	out, err := runSilent("curl", "-s", "http://localhost:4040/api/tunnels")
-	if err != nil {
-		return "", err
-	}
-	// Parse the JSON response
-	var resp struct {
-		Tunnels []struct {
-			PublicURL string `json:"public_url"`
-			Proto     string `json:"proto"`
-		} `json:"tunnels"`
-	}
-	if err := json.Unmarshal([]byte(out), &resp); err != nil {
-		return "", err
-	}
-	// Prefer HTTPS
-	for _, t := range resp.Tunnels {
-		if t.Proto == "https" {
-			return t.PublicURL, nil
+// getNgrokPublicURL queries the ngrok local API for the URL of the tunnel
+// forwarding to port. Each ngrok agent binds the first free inspection port
+// from 4040 upwards, so concurrent tunnels are found by scanning that range.
+func getNgrokPublicURL(port int) (string, error) {
+	suffix := fmt.Sprintf(":%d", port)
+	for apiPort := 4040; apiPort < 4050; apiPort++ {
+		out, err := runSilent("curl", "-s", fmt.Sprintf("http://localhost:%d/api/tunnels", apiPort))
+		if err != nil {
+			continue
+		}
+		// Parse the JSON response
+		var resp struct {
+			Tunnels []struct {
+				PublicURL string `json:"public_url"`
+				Proto     string `json:"proto"`
+				Config    struct {
+					Addr string `json:"addr"`
+				} `json:"config"`
+			} `json:"tunnels"`
+		}
+		if err := json.Unmarshal([]byte(out), &resp); err != nil {
+			continue
+		}
+		for _, t := range resp.Tunnels {
+			if strings.HasSuffix(t.Config.Addr, suffix) || t.Config.Addr == fmt.Sprintf("%d", port) {
+				return t.PublicURL, nil
+			}
 		}
-	}
-	if len(resp.Tunnels) > 0 {
-		return resp.Tunnels[0].PublicURL, nil
 	}
 	return "", fmt.Errorf("no tunnels found")
 }
 
 // ── Shared helpers ──────────────────────────────────────────────
 
-// tunnelInfo represents the persisted state of a running tunnel.
-type tunnelInfo struct {
-	Provider string
-	URL      string
-	PID      int
+// exposeResult is the machine-readable outcome of kindling expose.
+type exposeResult struct {
+	Status   string `json:"status"`
+	Service  string `json:"service,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	URL      string `json:"url,omitempty"`
+	PID      int    `json:"pid,omitempty"`
 }
 
 // printTunnelRunning shows the success output after backgrounding.
-func printTunnelRunning(publicURL string, pid int) {
-	fmt.Println()
-	success(fmt.Sprintf("%s%s%s", colorBold, publicURL, colorReset))
-	fmt.Println()
-	fmt.Printf("  Tunnel running in background %s(pid %d)%s\n", colorDim, pid, colorReset)
-	fmt.Printf("  Stop with: %skindling expose --stop%s\n", colorCyan, colorReset)
-	fmt.Println()
+func printTunnelRunning(publicURL, provider string, pid int) error {
+	service := (&tunnel.State{Service: exposeService}).Label()
+	result := exposeResult{Status: "started", Service: service, Provider: provider, URL: publicURL, PID: pid}
+	return render(result, func() {
+		fmt.Println()
+		success(fmt.Sprintf("%s%s%s", colorBold, publicURL, colorReset))
+		fmt.Println()
+		fmt.Printf("  Tunnel for %s running in background %s(pid %d)%s\n", service, colorDim, pid, colorReset)
+		fmt.Printf("  Stop with: %s%s%s\n", colorCyan, stopTunnelCommand(exposeService), colorReset)
+		fmt.Println()
+	})
 }
 
-// saveTunnelInfo persists the tunnel URL and PID to .kindling/tunnel.yaml
-// and creates a ConfigMap in the cluster so the deploy action can discover it.
-func saveTunnelInfo(publicURL, provider string, pid int) {
+// saveTunnelInfo records the new tunnel (plus the named-tunnel mapping, if
+// any) in .kindling/tunnels.yaml and updates the ConfigMap in the cluster so
+// the deploy action can discover it.
+func saveTunnelInfo(publicURL, provider string, pid int, named *tunnel.Named) {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return
 	}
-	kindlingDir := filepath.Join(cwd, ".kindling")
-	_ = os.MkdirAll(kindlingDir, 0755)
 
-	tunnelFile := filepath.Join(kindlingDir, "tunnel.yaml")
-	content := fmt.Sprintf("# Generated by kindling expose — do not edit\nprovider: %s\nurl: %s\npid: %d\ncreated: %s\n",
-		provider, publicURL, pid, time.Now().Format(time.RFC3339))
-
-	_ = os.WriteFile(tunnelFile, []byte(content), 0644)
+	tunnels, _ := tunnel.Read(cwd)
+	state := tunnel.State{
+		Provider: provider,
+		URL:      publicURL,
+		PID:      pid,
+		Port:     exposePort,
+		Service:  exposeService,
+		Created:  time.Now().UTC().Truncate(time.Second),
+		Named:    named,
+	}
+	if i := tunnel.Find(tunnels, exposeService); i >= 0 {
+		tunnels[i] = state
+	} else {
+		tunnels = append(tunnels, state)
+	}
+	_ = tunnel.Write(cwd, tunnels)
 
 	// Ensure .kindling/ is gitignored
 	ensureTunnelGitignored(cwd)
 
 	// Create/update ConfigMap in the cluster so the deploy action can auto-detect the tunnel.
-	saveTunnelConfigMap(publicURL)
+	saveTunnelConfigMap(tunnels)
 }
 
-// saveTunnelConfigMap creates a ConfigMap with the tunnel URL + hostname.
-func saveTunnelConfigMap(publicURL string) {
-	hostname := publicURL
-	if u, err := url.Parse(publicURL); err == nil && u.Host != "" {
-		hostname = u.Host
-	}
-	// Pipe through apply so it's idempotent (create or update).
-	yaml, err := runSilent("kubectl", "create", "configmap", "kindling-tunnel",
-		"--from-literal=url="+publicURL,
-		"--from-literal=hostname="+hostname,
-		"--dry-run=client", "-o", "yaml",
-	)
-	if err != nil {
+// saveTunnelConfigMap writes the tunnel URLs + hostnames to the
+// kindling-tunnel ConfigMap. The url/hostname keys hold the default tunnel
+// (or the first one, if every tunnel is per-service); per-service tunnels
+// are also published as <service>.url and <service>.hostname. The
+// ConfigMap is deleted once no tunnels remain.
+func saveTunnelConfigMap(tunnels []tunnel.State) {
+	if len(tunnels) == 0 {
+		_ = deleteConfigMap("default", "kindling-tunnel")
 		return
 	}
-	applyCmd := exec.Command("kubectl", "apply", "-f", "-")
-	applyCmd.Stdin = strings.NewReader(yaml)
-	_ = applyCmd.Run()
+
+	primary := tunnels[0]
+	if i := tunnel.Find(tunnels, ""); i >= 0 {
+		primary = tunnels[i]
+	}
+	data := map[string]string{
+		"url":      primary.URL,
+		"hostname": tunnelHostname(primary.URL),
+	}
+	for _, t := range tunnels {
+		if t.Service == "" {
+			continue
+		}
+		data[t.Service+".url"] = t.URL
+		data[t.Service+".hostname"] = tunnelHostname(t.URL)
+	}
+	_ = writeConfigMap("default", "kindling-tunnel", nil, data, nil)
 }
 
-// readTunnelInfo loads tunnel state from .kindling/tunnel.yaml.
-func readTunnelInfo() (*tunnelInfo, error) {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return nil, err
+// tunnelHostname returns the host part of a public tunnel URL.
+func tunnelHostname(publicURL string) string {
+	if u, err := url.Parse(publicURL); err == nil && u.Host != "" {
+		return u.Host
 	}
-	data, err := os.ReadFile(filepath.Join(cwd, ".kindling", "tunnel.yaml"))
+	return publicURL
+}
+
+// loadTunnels loads every tracked tunnel from .kindling/tunnels.yaml.
+func loadTunnels() ([]tunnel.State, error) {
+	cwd, err := os.Getwd()
 	if err != nil {
 		return nil, err
 	}
-
-	info := &tunnelInfo{}
-	for _, line := range strings.Split(string(data), "\n") {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "provider:") {
-			info.Provider = strings.TrimSpace(strings.TrimPrefix(line, "provider:"))
-		} else if strings.HasPrefix(line, "url:") {
-			info.URL = strings.TrimSpace(strings.TrimPrefix(line, "url:"))
-		} else if strings.HasPrefix(line, "pid:") {
-			info.PID, _ = strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "pid:")))
-		}
-	}
-	return info, nil
+	return tunnel.Read(cwd)
 }
 
 // processAlive checks if a process with the given PID is still running.
 func processAlive(pid int) bool {
-	proc, err := os.FindProcess(pid)
-	if err != nil {
-		return false
-	}
-	// On Unix, signal 0 checks if the process exists.
-	return proc.Signal(syscall.Signal(0)) == nil
+	return procutil.Alive(pid)
 }
 
-// stopTunnel kills a running tunnel and cleans up.
-func stopTunnel() error {
-	info, err := readTunnelInfo()
-	if err != nil || info == nil || info.PID == 0 {
-		fmt.Println("  No tunnel is currently running.")
-		return nil
+// stopTunnelCommand returns the command that stops the tunnel for service.
+func stopTunnelCommand(service string) string {
+	if service == "" {
+		return "kindling expose --stop"
 	}
+	return "kindling expose --stop --service " + service
+}
 
-	if !processAlive(info.PID) {
-		cleanupTunnel()
-		fmt.Println("  Tunnel process already exited — cleaned up.")
-		return nil
+// listTunnels prints every tracked tunnel as a table.
+func listTunnels() error {
+	reports, err := collectTunnelReports(false)
+	if err != nil {
+		return err
 	}
+	return render(reports, func() {
+		header("Tunnels")
+		if len(reports) == 0 {
+			fmt.Printf("    %sNo tunnels — run:%s kindling expose\n\n", colorDim, colorReset)
+			return
+		}
+		fmt.Printf("    %s%-20s %-12s %-6s %-8s %-8s %s%s\n", colorBold, "SERVICE", "PROVIDER", "PORT", "PID", "STATUS", "URL", colorReset)
+		for _, r := range reports {
+			statusColor := colorGreen
+			if !r.Running {
+				statusColor = colorRed
+			}
+			fmt.Printf("    %-20s %-12s %-6d %-8d %s%-8s%s %s\n",
+				r.Service, r.Provider, r.Port, r.PID, statusColor, r.Process, colorReset, r.URL)
+		}
+		fmt.Println()
+	})
+}
 
-	step("🛑", fmt.Sprintf("Stopping %s tunnel (pid %d)...", info.Provider, info.PID))
+// pruneTunnels forgets tunnels whose process has exited and restores the
+// ingresses they had patched, so a crashed tunnel doesn't block a restart.
+func pruneTunnels() {
+	tunnels, _ := loadTunnels()
+	for _, t := range tunnels {
+		if !processAlive(t.PID) {
+			cleanupTunnel(t)
+		}
+	}
+}
 
-	proc, err := os.FindProcess(info.PID)
+// stopTunnels kills the tunnel for service, or every tunnel when service is
+// empty, and cleans up after each one.
+func stopTunnels(service string) error {
+	tunnels, err := loadTunnels()
 	if err != nil {
-		return fmt.Errorf("could not find process %d: %w", info.PID, err)
-	}
+		return err
+	}
+	if service != "" {
+		i := tunnel.Find(tunnels, service)
+		if i < 0 {
+			return render([]exposeResult{}, func() {
+				fmt.Printf("  No tunnel is running for %s.\n", service)
+			})
+		}
+		tunnels = tunnels[i : i+1]
+	}
+	if len(tunnels) == 0 {
+		return render([]exposeResult{}, func() {
+			fmt.Println("  No tunnel is currently running.")
+		})
+	}
+
+	results := []exposeResult{}
+	for _, info := range tunnels {
+		result := exposeResult{Status: "stopped", Service: info.Label(), Provider: info.Provider, URL: info.URL, PID: info.PID}
+		if !processAlive(info.PID) {
+			cleanupTunnel(info)
+			result.Status = "exited"
+			if !isJSONOutput() {
+				fmt.Printf("  Tunnel for %s already exited — cleaned up.\n", info.Label())
+			}
+			results = append(results, result)
+			continue
+		}
 
-	_ = proc.Signal(syscall.SIGTERM)
-	// Give it a moment, then force-kill.
-	time.Sleep(2 * time.Second)
-	if processAlive(info.PID) {
-		_ = proc.Kill()
-	}
+		step("🛑", fmt.Sprintf("Stopping %s tunnel for %s (pid %d)...", info.Provider, info.Label(), info.PID))
+		// Give it a moment to exit, then force-kill.
+		procutil.Stop(info.PID, 2*time.Second)
+		_ = daemons().Remove(tunnelDaemonName(info.Service))
 
-	cleanupTunnel()
-	success("Tunnel stopped")
-	return nil
+		cleanupTunnel(info)
+		success(fmt.Sprintf("Tunnel for %s stopped", info.Label()))
+		results = append(results, result)
+	}
+	return render(results, nil)
 }
 
-// cleanupTunnel restores ingress hosts, removes tunnel.yaml, and deletes the ConfigMap.
-func cleanupTunnel() {
-	restoreIngresses()
+// cleanupTunnel restores the ingresses routed through the tunnel, drops it
+// from tunnels.yaml, and updates (or deletes) the ConfigMap.
+func cleanupTunnel(info tunnel.State) {
+	host := tunnelHostname(info.URL)
+	restoreIngressesWhere(func(_, current string) bool { return current == host })
+
 	cwd, _ := os.Getwd()
-	_ = os.Remove(filepath.Join(cwd, ".kindling", "tunnel.yaml"))
-	_, _ = runSilent("kubectl", "delete", "configmap", "kindling-tunnel", "--ignore-not-found")
+	tunnels, _ := tunnel.Read(cwd)
+	remaining := tunnels[:0]
+	for _, t := range tunnels {
+		if t.PID != info.PID || t.Service != info.Service {
+			remaining = append(remaining, t)
+		}
+	}
+	_ = tunnel.Write(cwd, remaining)
+	saveTunnelConfigMap(remaining)
 }
 
 // ── Ingress patching ──────────────────────────────────────────
@@ -424,18 +579,30 @@ func cleanupTunnel() {
 const originalHostAnnotation = "kindling.dev/original-host"
 const originalTLSAnnotation = "kindling.dev/original-tls"
 
+// tunnelHostAnnotation marks an Ingress the operator routes through the
+// tunnel itself, because its DevStagingEnvironment sets ingress.tunnel.
+const tunnelHostAnnotation = "kindling.dev/tunnel-host"
+
 // patchIngressesForTunnel replaces the host on every Ingress in the default
 // namespace with the tunnel hostname, saving the original host as an annotation
 // so it can be restored later.
-func patchIngressesForTunnel(publicURL string) {
-	// Always restore any orphaned ingresses first — self-heals if a previous
-	// tunnel died without cleanup (e.g. machine sleep, force-kill).
-	restoreIngresses()
-
-	hostname := publicURL
-	if u, err := url.Parse(publicURL); err == nil && u.Host != "" {
-		hostname = u.Host
+func patchIngressesForTunnel(publicURL, service string) {
+	hostname := tunnelHostname(publicURL)
+
+	// Always restore orphaned ingresses first — self-heals if a previous
+	// tunnel died without cleanup (e.g. machine sleep, force-kill). Ingresses
+	// routed through another live tunnel are left alone.
+	liveHosts := map[string]bool{hostname: true}
+	if tunnels, err := loadTunnels(); err == nil {
+		for _, t := range tunnels {
+			if processAlive(t.PID) {
+				liveHosts[tunnelHostname(t.URL)] = true
+			}
+		}
 	}
+	restoreIngressesWhere(func(name, currentHost string) bool {
+		return !liveHosts[currentHost] || (service != "" && name == service)
+	})
 
 	names, err := getIngressNames()
 	if err != nil || len(names) == 0 {
@@ -443,16 +610,16 @@ func patchIngressesForTunnel(publicURL string) {
 	}
 
 	// If --service was specified, only patch that one.
-	if exposeService != "" {
+	if service != "" {
 		found := false
 		for _, n := range names {
-			if n == exposeService {
+			if n == service {
 				found = true
 				break
 			}
 		}
 		if found {
-			names = []string{exposeService}
+			names = []string{service}
 		} else {
 			return
 		}
@@ -460,6 +627,13 @@ func patchIngressesForTunnel(publicURL string) {
 
 	patched := 0
 	for _, name := range names {
+		// An ingress with tunnel: true is routed by the operator from the
+		// kindling-tunnel ConfigMap, and already owns the tunnel's host.
+		if ingressAnnotation(name, tunnelHostAnnotation) != "" {
+			step("🔀", fmt.Sprintf("Routing tunnel → ingress/%s (by the operator)", name))
+			return
+		}
+
 		// Read current host
 		currentHost, err := runSilent("kubectl", "get", "ingress", name,
 			"-o", "jsonpath={.spec.rules[0].host}")
@@ -468,8 +642,8 @@ func patchIngressesForTunnel(publicURL string) {
 		}
 		currentHost = strings.TrimSpace(currentHost)
 
-		// Skip if already set to tunnel host
-		if currentHost == hostname {
+		// Skip if already set to tunnel host, or routed through another tunnel
+		if currentHost == hostname || ingressOriginalHost(name) != "" {
 			continue
 		}
 
@@ -508,6 +682,33 @@ func patchIngressesForTunnel(publicURL string) {
 // restoreIngresses reverts any ingresses that were patched by patchIngressesForTunnel,
 // restoring the original host from the saved annotation.
 func restoreIngresses() {
+	restoreIngressesWhere(func(string, string) bool { return true })
+}
+
+// ingressOriginalHost returns the host saved by patchIngressesForTunnel, or
+// "" if the ingress is not routed through a tunnel.
+func ingressOriginalHost(name string) string {
+	return ingressAnnotation(name, originalHostAnnotation)
+}
+
+// ingressAnnotation returns an annotation of the named Ingress, or "".
+func ingressAnnotation(name, key string) string {
+	value, err := runSilent("kubectl", "get", "ingress", name,
+		"-o", fmt.Sprintf(`go-template={{index .metadata.annotations %q}}`, key),
+	)
+	if err != nil {
+		return ""
+	}
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "no value") {
+		return ""
+	}
+	return value
+}
+
+// restoreIngressesWhere reverts the patched ingresses for which match,
+// given the ingress name and its current (tunnel) host, returns true.
+func restoreIngressesWhere(match func(name, currentHost string) bool) {
 	names, err := getIngressNames()
 	if err != nil || len(names) == 0 {
 		return
@@ -515,14 +716,13 @@ func restoreIngresses() {
 
 	restored := 0
 	for _, name := range names {
-		originalHost, err := runSilent("kubectl", "get", "ingress", name,
-			"-o", `go-template={{index .metadata.annotations "kindling.dev/original-host"}}`,
-		)
-		if err != nil {
+		originalHost := ingressOriginalHost(name)
+		if originalHost == "" {
 			continue
 		}
-		originalHost = strings.TrimSpace(originalHost)
-		if originalHost == "" || strings.Contains(originalHost, "no value") {
+		currentHost, _ := runSilent("kubectl", "get", "ingress", name,
+			"-o", "jsonpath={.spec.rules[0].host}")
+		if !match(name, strings.TrimSpace(currentHost)) {
 			continue
 		}
 
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeffvincent/kindling/cli/pkg/tunnel"
+)
+
+// ── Cloudflare named tunnels ────────────────────────────────────
+//
+// Quick tunnels get a random *.trycloudflare.com URL that changes on every
+// run. Named tunnels are created once in a Cloudflare account and routed to
+// a stable hostname on a zone the user controls, so OAuth callback URLs
+// don't need to be re-registered each session.
+
+// cloudflaredConfigDirs lists the directories cloudflared itself searches
+// for cert.pem and tunnel credential files, in order.
+func cloudflaredConfigDirs() []string {
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs,
+			filepath.Join(home, ".cloudflared"),
+			filepath.Join(home, ".cloudflare-warp"),
+			filepath.Join(home, "cloudflare-warp"),
+		)
+	}
+	return append(dirs, "/etc/cloudflared", "/usr/local/etc/cloudflared")
+}
+
+// findCloudflaredFile returns the first existing file called name in the
+// cloudflared config directories, preferring the path in envVar if set.
+func findCloudflaredFile(envVar, name string) string {
+	if p := os.Getenv(envVar); p != "" {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	for _, dir := range cloudflaredConfigDirs() {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// lookupNamedTunnel returns the ID of the named tunnel, or "" if it does
+// not exist in the account.
+func lookupNamedTunnel(name string) (string, error) {
+	out, err := runCapture("cloudflared", "tunnel", "list", "--name", name, "--output", "json")
+	if err != nil {
+		return "", fmt.Errorf("cloudflared tunnel list failed: %w", err)
+	}
+	var tunnels []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if out != "" {
+		if err := json.Unmarshal([]byte(out), &tunnels); err != nil {
+			return "", fmt.Errorf("cannot parse cloudflared tunnel list output: %w", err)
+		}
+	}
+	for _, t := range tunnels {
+		if t.Name == name {
+			return t.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// ensureNamedTunnel resolves the named tunnel to its ID and credentials
+// file, creating the tunnel if it does not exist yet.
+func ensureNamedTunnel(name string) (*tunnel.Named, error) {
+	if findCloudflaredFile("TUNNEL_ORIGIN_CERT", "cert.pem") == "" {
+		return nil, fmt.Errorf("no Cloudflare origin certificate found — run: cloudflared tunnel login")
+	}
+
+	id, err := lookupNamedTunnel(name)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		step("🆕", fmt.Sprintf("Creating Cloudflare tunnel %q", name))
+		if out, err := runSilent("cloudflared", "tunnel", "create", name); err != nil {
+			return nil, fmt.Errorf("cloudflared tunnel create failed: %s", out)
+		}
+		if id, err = lookupNamedTunnel(name); err != nil {
+			return nil, err
+		}
+		if id == "" {
+			return nil, fmt.Errorf("tunnel %q was not found after creating it", name)
+		}
+	}
+
+	creds := findCloudflaredFile("TUNNEL_CRED_FILE", id+".json")
+	if creds == "" {
+		return nil, fmt.Errorf("credentials file %s.json for tunnel %q not found in ~/.cloudflared — "+
+			"recreate it with: cloudflared tunnel token --cred-file ~/.cloudflared/%s.json %s", id, name, id, name)
+	}
+	success(fmt.Sprintf("Using tunnel %s (%s) %s", name, id, dimText(creds)))
+
+	return &tunnel.Named{Name: name, ID: id, CredentialsFile: creds}, nil
+}
+
+// routeNamedTunnelDNS points hostname at the tunnel with a proxied CNAME.
+// An existing record for the hostname is left untouched.
+func routeNamedTunnelDNS(named *tunnel.Named) error {
+	step("🌍", fmt.Sprintf("Routing DNS %s → tunnel %s", named.Hostname, named.Name))
+	out, err := runSilent("cloudflared", "tunnel", "route", "dns", named.Name, named.Hostname)
+	if err != nil {
+		if strings.Contains(out, "already exists") {
+			warn(fmt.Sprintf("DNS record for %s already exists — leaving it in place", named.Hostname))
+			return nil
+		}
+		return fmt.Errorf("cloudflared tunnel route dns failed: %s", out)
+	}
+	return nil
+}
+
+// runCloudflaredNamedTunnel runs an authenticated named tunnel routed to
+// --hostname, creating the tunnel and its DNS record on first use.
+func runCloudflaredNamedTunnel() error {
+	named, err := ensureNamedTunnel(exposeTunnel)
+	if err != nil {
+		return err
+	}
+	named.Hostname = exposeHostname
+	if err := routeNamedTunnelDNS(named); err != nil {
+		return err
+	}
+
+	step("⏳", fmt.Sprintf("Starting named tunnel %s...", named.Name))
+	publicURL := "https://" + named.Hostname
+	d, _, err := startCloudflared(
+		[]string{"tunnel", "--no-autoupdate",
+			"--url", fmt.Sprintf("http://localhost:%d", exposePort),
+			"run", "--credentials-file", named.CredentialsFile, named.Name},
+		func(logs string) string {
+			if strings.Contains(logs, "Registered tunnel connection") {
+				return publicURL
+			}
+			return ""
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	saveTunnelInfo(publicURL, "cloudflared", d.PID, named)
+	patchIngressesForTunnel(publicURL, exposeService)
+	return printTunnelRunning(publicURL, "cloudflared", d.PID)
+}
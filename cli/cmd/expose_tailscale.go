@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jeffvincent/kindling/cli/internal/daemon"
+)
+
+// ── Tailscale Funnel ────────────────────────────────────────────
+//
+// Funnel publishes a local port on the machine's tailnet name
+// (https://<host>.<tailnet>.ts.net). kindling runs `tailscale funnel` in
+// the foreground mode, detached like the other providers, so killing the
+// process tears the funnel down again.
+
+// tailscaleStatus is the subset of `tailscale status --json` kindling reads.
+type tailscaleStatus struct {
+	BackendState string `json:"BackendState"`
+	Self         struct {
+		DNSName string `json:"DNSName"`
+	} `json:"Self"`
+}
+
+// tailscaleHostname returns this node's MagicDNS name without the trailing
+// dot, or an error if tailscale is not logged in.
+func tailscaleHostname() (string, error) {
+	out, err := runCapture("tailscale", "status", "--json")
+	if err != nil {
+		return "", fmt.Errorf("tailscale status failed — is tailscaled running? (%w)", err)
+	}
+	var status tailscaleStatus
+	if err := json.Unmarshal([]byte(out), &status); err != nil {
+		return "", fmt.Errorf("cannot parse tailscale status output: %w", err)
+	}
+	if status.BackendState != "Running" {
+		return "", fmt.Errorf("tailscale is %s — run: tailscale up", strings.ToLower(status.BackendState))
+	}
+	host := strings.TrimSuffix(status.Self.DNSName, ".")
+	if host == "" {
+		return "", fmt.Errorf("tailscale did not report a MagicDNS name — enable MagicDNS for your tailnet")
+	}
+	return host, nil
+}
+
+// ensureSingleFunnel refuses to start a second Funnel: every funnel on a
+// node shares its MagicDNS name, so ingress host routing can't tell them
+// apart.
+func ensureSingleFunnel() error {
+	tunnels, err := loadTunnels()
+	if err != nil {
+		return nil
+	}
+	for _, t := range tunnels {
+		if t.Provider == "tailscale" && processAlive(t.PID) {
+			return fmt.Errorf("a Tailscale Funnel is already running for %s — use cloudflared or ngrok for additional tunnels", t.Label())
+		}
+	}
+	return nil
+}
+
+func runTailscaleFunnel() error {
+	if err := ensureSingleFunnel(); err != nil {
+		return err
+	}
+	host, err := tailscaleHostname()
+	if err != nil {
+		return err
+	}
+
+	step("⏳", "Starting Tailscale Funnel...")
+
+	// Funnel prints the public URL once the serve config is in place; if
+	// the process exits first, its output (Funnel not enabled in the
+	// tailnet policy, etc.) is the error.
+	publicURL := "https://" + host
+	d, _, err := startTunnelDaemon("tailscale", exec.Command("tailscale", "funnel", fmt.Sprintf("%d", exposePort)), 15*time.Second,
+		func(logs string) string {
+			if strings.Contains(logs, host) {
+				return publicURL
+			}
+			return ""
+		})
+	if err != nil {
+		if errors.Is(err, daemon.ErrTimeout) {
+			return fmt.Errorf("could not confirm the funnel is up — check: tailscale funnel status")
+		}
+		return fmt.Errorf("tailscale funnel failed: %w", err)
+	}
+
+	saveTunnelInfo(publicURL, "tailscale", d.PID, nil)
+	patchIngressesForTunnel(publicURL, exposeService)
+	return printTunnelRunning(publicURL, "tailscale", d.PID)
+}
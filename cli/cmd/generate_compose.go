@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/jeffvincent/kindling/cli/pkg/generate"
+)
+
+// runComposeGenerate converts a docker-compose file into DevStagingEnvironment
+// manifests and writes them like offline generation does.
+func runComposeGenerate(repoPath, composePath string) error {
+	header("Converting docker-compose services")
+	step("📄", composePath)
+
+	components, notes, err := generate.ConvertCompose(repoPath, composePath)
+	if err != nil {
+		return err
+	}
+	for _, note := range notes {
+		warn(note)
+	}
+	if genInteractive {
+		if components, err = runGenerateWizard(components, &generate.Repo{}); err != nil {
+			return err
+		}
+	}
+	rel, err := filepath.Rel(repoPath, composePath)
+	if err != nil {
+		rel = composePath
+	}
+	return writeOfflineManifest(repoPath, components, "kindling generate --from-compose "+filepath.ToSlash(rel))
+}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+	"github.com/jeffvincent/kindling/cli/pkg/generate"
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Interactive generation (--interactive)
+// ────────────────────────────────────────────────────────────────────────────
+//
+// The wizard shows what the scan detected for each component and lets the
+// user confirm or correct it before anything is written. Offline, the
+// answers go straight into the manifest; with the AI they are added to the
+// prompt as facts that override its own reading of the repo.
+
+// runGenerateWizard walks through the detected components and returns the
+// ones the user kept, as they left them. It returns an error when the user
+// drops every component or declines to continue.
+func runGenerateWizard(components []*generate.Component, ctx *generate.Repo) ([]*generate.Component, error) {
+	if !stdinIsTerminal() {
+		return nil, fmt.Errorf("--interactive needs a terminal — run without it to accept the detected settings")
+	}
+	reader := bufio.NewReader(os.Stdin)
+
+	header("Reviewing detected components")
+	fmt.Printf("  %sPress Enter to keep the value in [brackets].%s\n", colorDim, colorReset)
+	if len(ctx.ExternalSecrets) > 0 {
+		fmt.Printf("  %sCredentials seen in the source: %s%s\n", colorDim, strings.Join(ctx.ExternalSecrets, ", "), colorReset)
+	}
+
+	var kept []*generate.Component
+	names := map[string]bool{}
+	for _, c := range components {
+		fmt.Println()
+		fmt.Printf("  📦 %s%s%s (%s)\n", colorBold, c.Name, colorReset, c.Dir)
+		if !promptYesNo(reader, "Include this component", true) {
+			continue
+		}
+		for {
+			c.Name = source.DNSLabel(promptDefault(reader, "Name", c.Name))
+			if !names[c.Name] {
+				break
+			}
+			fmt.Printf("  %s%s is already taken%s\n", colorRed, c.Name, colorReset)
+		}
+		names[c.Name] = true
+		c.Port = promptPort(reader, "Container port", c.Port)
+		c.Replicas = promptReplicas(reader, max(c.Replicas, 1))
+		c.HealthPath = promptHealthPath(reader, c.HealthPath)
+		c.Protocol = promptProtocol(reader, c.Protocol)
+		c.Env = promptEnv(reader, c.Env)
+		c.Dependencies = promptDependencies(reader, c.Dependencies)
+		kept = append(kept, c)
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("no components selected — nothing to generate")
+	}
+
+	fmt.Println()
+	for _, c := range kept {
+		step("📦", describeComponent(c))
+	}
+	if !promptYesNo(reader, "Generate with these settings", true) {
+		return nil, fmt.Errorf("generation cancelled")
+	}
+	return kept, nil
+}
+
+// promptDefault asks for a value, returning def when the answer is empty.
+func promptDefault(reader *bufio.Reader, label, def string) string {
+	if def != "" {
+		label = fmt.Sprintf("%s [%s]", label, def)
+	}
+	if answer := prompt(reader, label); answer != "" {
+		return answer
+	}
+	return def
+}
+
+// promptYesNo asks a yes/no question, returning def on an empty answer.
+func promptYesNo(reader *bufio.Reader, label string, def bool) bool {
+	hint := "Y/n"
+	if !def {
+		hint = "y/N"
+	}
+	for {
+		switch strings.ToLower(prompt(reader, fmt.Sprintf("%s? [%s]", label, hint))) {
+		case "":
+			return def
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+	}
+}
+
+func promptPort(reader *bufio.Reader, label string, def int) int {
+	for {
+		answer := promptDefault(reader, label, strconv.Itoa(def))
+		if port, err := strconv.Atoi(answer); err == nil && port > 0 && port < 65536 {
+			return port
+		}
+		fmt.Printf("  %s%q is not a port (1-65535)%s\n", colorRed, answer, colorReset)
+	}
+}
+
+func promptReplicas(reader *bufio.Reader, def int) int {
+	for {
+		answer := promptDefault(reader, "Replicas", strconv.Itoa(def))
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 {
+			return n
+		}
+		fmt.Printf("  %s%q is not a replica count (1 or more)%s\n", colorRed, answer, colorReset)
+	}
+}
+
+// promptHealthPath asks for the HTTP health route; "tcp" probes the port.
+func promptHealthPath(reader *bufio.Reader, def string) string {
+	if def == "" {
+		def = "tcp"
+	}
+	for {
+		answer := promptDefault(reader, "Health check path (or tcp)", def)
+		if answer == "tcp" {
+			return ""
+		}
+		if strings.HasPrefix(answer, "/") {
+			return answer
+		}
+		fmt.Printf("  %sUse a path starting with / or tcp%s\n", colorRed, colorReset)
+	}
+}
+
+// promptProtocol asks for the ingress protocol; http is stored as "".
+func promptProtocol(reader *bufio.Reader, def string) string {
+	if def == "" {
+		def = "http"
+	}
+	for {
+		switch answer := promptDefault(reader, "Protocol (http, grpc, websocket)", def); answer {
+		case "http":
+			return ""
+		case "grpc", "websocket":
+			return answer
+		default:
+			fmt.Printf("  %s%q is not http, grpc, or websocket%s\n", colorRed, answer, colorReset)
+		}
+	}
+}
+
+// promptEnv edits the component's literal env vars: the current ones can
+// be kept, then NAME=value lines add or replace entries until a blank line.
+// NAME= removes one.
+func promptEnv(reader *bufio.Reader, env []generate.EnvVar) []generate.EnvVar {
+	if len(env) > 0 {
+		for _, e := range env {
+			fmt.Printf("      %s\n", e)
+		}
+		if !promptYesNo(reader, "Keep these env vars", true) {
+			env = nil
+		}
+	}
+	fmt.Printf("  %sAdd env vars as NAME=value, one per line (NAME= removes one, blank line when done).\n", colorDim)
+	fmt.Printf("  Dependency connection strings (DATABASE_URL, REDIS_URL, ...) are injected for you.%s\n", colorReset)
+	for {
+		answer := prompt(reader, "Env var")
+		if answer == "" {
+			return env
+		}
+		name, value, ok := strings.Cut(answer, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || !envKeyRe.MatchString(name) {
+			fmt.Printf("  %sUse NAME=value%s\n", colorRed, colorReset)
+			continue
+		}
+		var next []generate.EnvVar
+		for _, e := range env {
+			if e.Name != name {
+				next = append(next, e)
+			}
+		}
+		if value != "" {
+			next = append(next, generate.EnvVar{Name: name, Value: value})
+		}
+		env = next
+	}
+}
+
+// promptDependencies asks for the comma-separated dependency types; "none"
+// clears them.
+func promptDependencies(reader *bufio.Reader, def map[string]bool) map[string]bool {
+	current := "none"
+	if len(def) > 0 {
+		current = strings.Join(sortedKeys(def), ", ")
+	}
+	for {
+		answer := promptDefault(reader, "Dependencies (comma-separated, or none)", current)
+		deps := map[string]bool{}
+		var unknown []string
+		if answer != "none" {
+			for _, d := range strings.Split(answer, ",") {
+				d = strings.ToLower(strings.TrimSpace(d))
+				if d == "" {
+					continue
+				}
+				if _, ok := devstaging.DependencyConventions[d]; !ok {
+					unknown = append(unknown, d)
+				}
+				deps[d] = true
+			}
+		}
+		if len(unknown) == 0 {
+			return deps
+		}
+		fmt.Printf("  %sUnknown dependency type(s): %s — use %s%s\n", colorRed,
+			strings.Join(unknown, ", "), strings.Join(sortedKeys(devstaging.DependencyConventions), ", "), colorReset)
+	}
+}
+
+// describeComponent is the one-line summary of a component.
+func describeComponent(c *generate.Component) string {
+	health := "tcp probe"
+	if c.HealthPath != "" {
+		health = c.HealthPath
+	}
+	protocol := "http"
+	if c.Protocol != "" {
+		protocol = c.Protocol
+	}
+	deps := "no dependencies"
+	if len(c.Dependencies) > 0 {
+		deps = strings.Join(sortedKeys(c.Dependencies), ", ")
+	}
+	summary := fmt.Sprintf("%s (%s) → port %d, %s, %s, %s", c.Name, c.Dir, c.Port, protocol, health, deps)
+	if c.Replicas > 1 {
+		summary += fmt.Sprintf(", %d replicas", c.Replicas)
+	}
+	if len(c.Env) > 0 {
+		summary += fmt.Sprintf(", %d env var(s)", len(c.Env))
+	}
+	return summary
+}
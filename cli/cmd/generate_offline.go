@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jeffvincent/kindling/cli/pkg/generate"
+)
+
+// runOfflineGenerate writes (or prints, with --dry-run) the heuristic
+// DevStagingEnvironment manifests for the scanned repo. With --interactive
+// the components were already confirmed by the wizard and are passed in.
+func runOfflineGenerate(repoPath string, repoCtx *generate.Repo, components []*generate.Component) error {
+	header("Generating DevStagingEnvironment (offline)")
+
+	if components == nil {
+		components = generate.DetectComponents(repoPath, repoCtx)
+	}
+	if repoCtx.DockerfileCount == 0 {
+		warn("No Dockerfile found — add one before building the image, or rerun with --synthesize-dockerfiles")
+	}
+	return writeOfflineManifest(repoPath, components, "kindling generate --no-ai")
+}
+
+// writeOfflineManifest renders the components and writes the manifest to
+// --output, or prints it with --dry-run. generatedBy names the command in
+// the file's header comment.
+func writeOfflineManifest(repoPath string, components []*generate.Component, generatedBy string) error {
+	// Reference the local registry when it's running, so the images can be
+	// pushed there instead of loaded into every node. Likewise serve the
+	// ingresses over HTTPS when init --tls set it up, through the
+	// controller the cluster was created with.
+	cluster := generate.Cluster{IngressClass: clusterIngressClass(), Namespace: genNamespace}
+	cluster.Registry, _ = localRegistryAddress()
+	if tls, ok := localTLSConfig(); ok {
+		cluster.TLSIssuer, cluster.TLSDomain = tls.Issuer, tls.Domain
+	}
+	manifest := generate.RenderManifest(components, cluster)
+	for _, c := range components {
+		deps := "no dependencies"
+		if len(c.Dependencies) > 0 {
+			names := make([]string, 0, len(c.Dependencies))
+			for d := range c.Dependencies {
+				names = append(names, d)
+			}
+			sort.Strings(names)
+			deps = strings.Join(names, ", ")
+		}
+		step("📦", fmt.Sprintf("%s (%s) → port %d, %s", c.Name, c.Dir, c.Port, deps))
+	}
+
+	relPath, _ := filepath.Rel(repoPath, genOutput)
+	if relPath == "" {
+		relPath = genOutput
+	}
+	if genDryRun {
+		if !genNoReview {
+			manifest = reviewGenerated(manifest, repoPath, "")
+		}
+		header("Generated manifest (dry-run)")
+		fmt.Fprintln(os.Stderr)
+		fmt.Print(manifest)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(genOutput), 0755); err != nil {
+		return fmt.Errorf("cannot create output directory: %w", err)
+	}
+	content := fmt.Sprintf("# Generated by %s — review before deploying\n", generatedBy) + manifest
+	if !genNoReview {
+		content = reviewGenerated(content, repoPath, relPath)
+	}
+	if err := os.WriteFile(genOutput, []byte(content), 0644); err != nil {
+		return fmt.Errorf("cannot write manifest: %w", err)
+	}
+
+	success(fmt.Sprintf("Manifest written to %s", relPath))
+
+	fmt.Println()
+	fmt.Printf("  %sNext steps:%s\n", colorBold, colorReset)
+	fmt.Printf("    1. Review ports, health checks, and dependencies in %s%s%s\n", colorCyan, relPath, colorReset)
+	fmt.Printf("    2. Build and load or push each image (see the comments in the manifest)\n")
+	fmt.Printf("    3. Apply it with %skindling deploy -f %s%s\n", colorCyan, relPath, colorReset)
+	fmt.Println()
+
+	return nil
+}
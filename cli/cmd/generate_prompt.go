@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jeffvincent/kindling/cli/pkg/generate"
+)
+
+// showGeneratePrompt prints the prompts of the first AI call, exactly as
+// they would be sent, and sends nothing.
+func showGeneratePrompt(systemPrompt, userPrompt string, stats generate.ContextStats, structured bool) {
+	fmt.Printf("──── System prompt ────\n\n%s\n\n──── User prompt ────\n\n%s\n", systemPrompt, userPrompt)
+	fmt.Fprintln(os.Stderr)
+	for _, path := range stats.Omitted {
+		step("✂️ ", "Left out: "+path)
+	}
+	if structured {
+		step("📐", "The deploy plan schema goes with it (kindling generate --print-schema)")
+	}
+	step("🔁", "Correction rounds resend the user prompt with the previous answer and the checks' findings")
+	success("Nothing was sent — drop --show-prompt to call the AI")
+}
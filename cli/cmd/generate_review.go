@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+// ── Review of generated YAML ────────────────────────────────────
+//
+// Whatever generate writes — a workflow or a manifest — is run through the
+// same checks as kindling validate before it is written. Each finding
+// becomes a "# kindling: …" comment above the line it is about, so the
+// problems are fixed where they are instead of after the first deploy.
+
+// reviewCommentPrefix starts every comment the review adds.
+const reviewCommentPrefix = "# kindling: "
+
+// reviewGenerated validates the generated YAML, prints a summary, and
+// returns data with the findings as comments. file is where the YAML is
+// written, "" when it is printed.
+func reviewGenerated(data, repoPath, file string) string {
+	data = stripReviewComments(data)
+	report := devstaging.Validate([]byte(data), devstaging.Options{RepoPath: repoPath, HostMounts: hostMounts()})
+
+	header("Reviewing generated YAML")
+	if len(report.Findings) == 0 {
+		success("No problems found")
+		return data
+	}
+	for _, f := range report.Findings {
+		target := ""
+		if f.Resource != "" {
+			target = f.Resource + ": "
+		}
+		fmt.Fprintf(os.Stderr, "  %s  %s%s %s\n", severityIcon(f.Severity), target, f.Detail, dimText("["+f.Check+"]"))
+	}
+	fmt.Fprintln(os.Stderr)
+	where := "the output"
+	if file != "" {
+		where = file
+	}
+	warn(fmt.Sprintf("%d error(s), %d warning(s), %d finding(s) total — marked with %q comments in %s",
+		report.Errors, report.Warnings, len(report.Findings), strings.TrimSpace(reviewCommentPrefix), where))
+	if file != "" {
+		fmt.Fprintf(os.Stderr, "  %sFix them, delete the comments, and recheck with: kindling validate -f %s%s\n", colorDim, file, colorReset)
+	}
+	return annotateFindings(data, report.Findings)
+}
+
+// stripReviewComments drops the comments of an earlier review.
+func stripReviewComments(data string) string {
+	lines := strings.SplitAfter(data, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), reviewCommentPrefix) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+// annotateFindings inserts each finding as a comment, indented like the
+// line it precedes: the field the finding names, or else the start of its
+// resource. Data that doesn't parse is returned as is.
+func annotateFindings(data string, findings []devstaging.Finding) string {
+	idx, ok := indexReviewLines([]byte(data))
+	if !ok {
+		return data
+	}
+	comments := map[int][]string{}
+	for _, f := range findings {
+		line := idx.locate(f)
+		comments[line] = append(comments[line], fmt.Sprintf("%s%s: %s [%s]", reviewCommentPrefix, f.Severity, f.Detail, f.Check))
+	}
+
+	lines := strings.SplitAfter(data, "\n")
+	var out strings.Builder
+	for i, line := range lines {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+		for _, c := range comments[i+1] {
+			out.WriteString(indent + c + "\n")
+		}
+		out.WriteString(line)
+	}
+	return out.String()
+}
+
+// reviewIndex maps each resource to the lines of its fields, keyed by the
+// path a finding names (e.g. spec.deployment.env[2].name), by "env NAME"
+// for env entries, and by "" for the resource's first line.
+type reviewIndex struct {
+	resources map[string]map[string]int
+	first     int
+}
+
+// reviewCheckFields are the fields each check is about, most precise
+// first, for findings that don't name one.
+var reviewCheckFields = map[string][]string{
+	"missing_health_check":  {"spec.deployment.healthCheck.path", "spec.deployment.healthCheck", "spec.deployment"},
+	"expose_mismatch":       {"spec.deployment.port"},
+	"port_mismatch":         {"spec.service.targetPort"},
+	"missing_dockerfile":    {"spec.deployment.image"},
+	"duplicate_hostname":    {"spec.ingress.host", "spec.ingress"},
+	"duplicate_node_port":   {"spec.service.nodePort", "spec.service"},
+	"duplicate_name":        {"metadata.name"},
+	"insufficient_capacity": {"spec.deployment.resources", "spec.deployment"},
+}
+
+var (
+	reviewFieldPath = regexp.MustCompile(`\b(?:spec|metadata|healthCheck|ingress|dependencies)(?:\.\w+|\[\d+\])*`)
+	reviewEnvName   = regexp.MustCompile(`^env (\S+) `)
+)
+
+// locate returns the line a finding's comment goes above.
+func (idx reviewIndex) locate(f devstaging.Finding) int {
+	fields := idx.resources[f.Resource]
+	if fields == nil {
+		return idx.first
+	}
+	if m := reviewEnvName.FindStringSubmatch(f.Detail); m != nil {
+		if line, ok := fields["env "+m[1]]; ok {
+			return line
+		}
+		if line, ok := fields["spec.deployment.env"]; ok {
+			return line
+		}
+	}
+	for _, p := range reviewCheckFields[f.Check] {
+		if line, ok := fields[p]; ok {
+			return line
+		}
+	}
+	if p := reviewFieldPath.FindString(f.Detail); p != "" {
+		switch {
+		case strings.HasPrefix(p, "healthCheck"):
+			p = "spec.deployment." + p
+		case strings.HasPrefix(p, "ingress"), strings.HasPrefix(p, "dependencies"):
+			p = "spec." + p
+		}
+		for ; p != ""; p = parentPath(p) {
+			if line, ok := fields[p]; ok {
+				return line
+			}
+		}
+	}
+	if line, ok := fields["metadata.name"]; ok {
+		return line
+	}
+	return fields[""]
+}
+
+// parentPath drops the last segment of a field path.
+func parentPath(p string) string {
+	if i := strings.LastIndexAny(p, ".["); i > 0 {
+		return p[:i]
+	}
+	return ""
+}
+
+// indexReviewLines indexes the resources of a manifest or workflow.
+func indexReviewLines(data []byte) (reviewIndex, bool) {
+	idx := reviewIndex{resources: map[string]map[string]int{}}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	var docs []*yaml.Node
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return idx, false
+		}
+		if len(doc.Content) > 0 && doc.Content[0].Tag != "!!null" {
+			docs = append(docs, &doc)
+		}
+	}
+	if len(docs) == 0 {
+		return idx, false
+	}
+	idx.first = docs[0].Content[0].Line
+
+	for _, doc := range docs {
+		if jobs := devstaging.MappingValue(doc.Content[0], "jobs"); jobs != nil {
+			indexWorkflowSteps(jobs, idx.resources)
+			return idx, true
+		}
+	}
+	for i, doc := range docs {
+		root := doc.Content[0]
+		fields := map[string]int{"": root.Line}
+		indexFieldLines(root, "", fields)
+		idx.resources[fmt.Sprintf("document %d", i+1)] = fields
+		if meta := devstaging.MappingValue(root, "metadata"); meta != nil {
+			if name := devstaging.MappingValue(meta, "name"); name != nil && name.Value != "" {
+				idx.resources[name.Value] = fields
+			}
+		}
+	}
+	return idx, true
+}
+
+// indexFieldLines records the line of every field and list item under node.
+func indexFieldLines(node *yaml.Node, path string, fields map[string]int) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			p := key
+			if path != "" {
+				p = path + "." + key
+			}
+			fields[p] = node.Content[i].Line
+			indexFieldLines(node.Content[i+1], p, fields)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			fields[p] = item.Line
+			if path == "spec.deployment.env" {
+				if name := devstaging.MappingValue(item, "name"); name != nil {
+					fields["env "+name.Value] = item.Line
+				}
+			}
+			indexFieldLines(item, p, fields)
+		}
+	}
+}
+
+// workflowInputFields maps kindling-deploy's inputs to the manifest fields
+// they become, so findings about a field land on its input.
+var workflowInputFields = []struct {
+	input  string
+	fields []string
+}{
+	{"name", []string{"metadata.name", "spec.deployment"}},
+	{"image", []string{"spec.deployment.image"}},
+	{"port", []string{"spec.deployment.port", "spec.service.port"}},
+	{"service-type", []string{"spec.service.type"}},
+	{"health-check-path", []string{"spec.deployment.healthCheck.path", "spec.deployment.healthCheck"}},
+	{"health-check-type", []string{"spec.deployment.healthCheck.type", "spec.deployment.healthCheck"}},
+	{"env", []string{"spec.deployment.env"}},
+	{"dependencies", []string{"spec.dependencies"}},
+	{"ingress-host", []string{"spec.ingress.host", "spec.ingress"}},
+	{"ingress-protocol", []string{"spec.ingress.protocol", "spec.ingress"}},
+	{"cpu-request", []string{"spec.deployment.resources"}},
+	{"cpu-limit", []string{"spec.deployment.resources"}},
+	{"memory-request", []string{"spec.deployment.resources"}},
+	{"memory-limit", []string{"spec.deployment.resources"}},
+}
+
+// indexWorkflowSteps indexes each kindling-deploy step under the name the
+// validator gives it, with the lines of its inputs as manifest fields.
+func indexWorkflowSteps(jobs *yaml.Node, resources map[string]map[string]int) {
+	for i := 1; i < len(jobs.Content); i += 2 {
+		steps := devstaging.MappingValue(jobs.Content[i], "steps")
+		if steps == nil {
+			continue
+		}
+		for _, step := range steps.Content {
+			uses := devstaging.MappingValue(step, "uses")
+			with := devstaging.MappingValue(step, "with")
+			if uses == nil || with == nil || !strings.Contains(uses.Value, "kindling-deploy") {
+				continue
+			}
+			fields := map[string]int{"": step.Line}
+			for _, in := range workflowInputFields {
+				for j := 0; j+1 < len(with.Content); j += 2 {
+					if with.Content[j].Value != in.input {
+						continue
+					}
+					for _, f := range in.fields {
+						if _, ok := fields[f]; !ok {
+							fields[f] = with.Content[j].Line
+						}
+					}
+				}
+			}
+			name := ""
+			if n := devstaging.MappingValue(with, "name"); n != nil {
+				name = actorPrefix.ReplaceAllString(n.Value, "")
+			}
+			resources[name] = fields
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jeffvincent/kindling/cli/pkg/generate"
+)
+
+// reportUsage prints the run's usage, records it in the ledger, and
+// prints the month's total.
+func reportUsage(b *generate.Budget, repoPath string) {
+	if b.Calls == 0 {
+		return
+	}
+	estimated := ""
+	if b.Used.Estimated {
+		estimated = " (estimated)"
+	}
+	step("🧾", fmt.Sprintf("%d call(s): %d prompt + %d completion tokens%s — %s",
+		b.Calls, b.Used.Prompt, b.Used.Completion, estimated, b.CostText()))
+
+	entry := usageEntry{
+		Time: time.Now().UTC(), Repo: repoPath, Provider: b.Provider, Model: b.Model,
+		Calls: b.Calls, PromptTokens: b.Used.Prompt, CompletionTokens: b.Used.Completion, Estimated: b.Used.Estimated,
+	}
+	if cost, ok := b.Cost(); ok {
+		entry.CostUSD = &cost
+	}
+	path, err := usageLedgerPath()
+	if err == nil {
+		err = appendUsage(path, entry)
+	}
+	if err != nil {
+		logger.Warn("cannot record usage", "error", err)
+		return
+	}
+	runs, cost, unpriced := monthUsage(path, entry.Time)
+	total := fmt.Sprintf("~$%.2f over %d run(s)", cost, runs)
+	if unpriced > 0 {
+		total += fmt.Sprintf(", %d of them unpriced", unpriced)
+	}
+	step("📒", fmt.Sprintf("This month: %s %s", total, dimText("("+path+")")))
+}
+
+// ── Usage ledger ────────────────────────────────────────────────
+
+// usageLedgerFile is the ledger, one JSON line per run, next to the user's
+// config.yaml.
+const usageLedgerFile = "usage.jsonl"
+
+// usageEntry is one run of generate in the ledger.
+type usageEntry struct {
+	Time             time.Time `json:"time"`
+	Repo             string    `json:"repo"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	Calls            int       `json:"calls"`
+	PromptTokens     int       `json:"promptTokens"`
+	CompletionTokens int       `json:"completionTokens"`
+	Estimated        bool      `json:"estimated,omitempty"`
+	CostUSD          *float64  `json:"costUSD,omitempty"` // nil when the price isn't known
+}
+
+// usageLedgerPath returns the ledger's path.
+func usageLedgerPath() (string, error) {
+	cfg, err := userConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(cfg), usageLedgerFile), nil
+}
+
+// appendUsage adds entry to the ledger at path.
+func appendUsage(path string, entry usageEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// monthUsage totals the ledger's runs in the month of now: how many, what
+// the priced ones cost, and how many had no price.
+func monthUsage(path string, now time.Time) (runs int, cost float64, unpriced int) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, 0
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e struct {
+			Time    time.Time `json:"time"`
+			CostUSD *float64  `json:"costUSD"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue
+		}
+		if e.Time.Year() != now.Year() || e.Time.Month() != now.Month() {
+			continue
+		}
+		runs++
+		if e.CostUSD == nil {
+			unpriced++
+		} else {
+			cost += *e.CostUSD
+		}
+	}
+	return runs, cost, unpriced
+}
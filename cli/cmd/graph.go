@@ -0,0 +1,390 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+// Patterns for the values a kindling-deploy step and its env hold.
+var (
+	envURLPattern    = regexp.MustCompile(`(?:https?|redis|rediss|mongodb|amqp|grpc|postgres|postgresql|mysql|nats|kafka)://(?:[^@/\s]+@)?([^:/\s]+):(\d+)`)
+	actorPrefix      = regexp.MustCompile(`\$\{\{\s*github\.actor\s*\}\}-?`)
+	actionExpression = regexp.MustCompile(`\$\{\{[^}]*\}\}`)
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Draw the components, dependencies, and ingress routes of a manifest or workflow",
+	Long: `Renders what a DevStagingEnvironment manifest — or a generated
+dev-deploy.yml workflow — would deploy as a diagram, so it can be reviewed
+before anything is applied: the ingress routes, each component with its
+image and port, the backing services the operator provisions for it and
+the connection variable it injects, its jobs, and the calls between
+components (spec.dependsOn, and env URLs that address another component).
+
+Nothing is read from the cluster.
+
+Formats:
+  mermaid   a flowchart for Markdown, GitHub, and mermaid.live (default)
+  dot       Graphviz; render it with: dot -Tpng
+  svg       a standalone image, laid out by kindling
+
+Without --format, the format follows the --out extension (.mmd, .dot or
+.gv, .svg). With -o json, the nodes and edges are printed instead.
+
+Examples:
+  kindling graph -f dev-environment.yaml
+  kindling graph -f .github/workflows/dev-deploy.yml --out environment.svg
+  kindling graph -f dev-environment.yaml --format dot | dot -Tpng > environment.png`,
+	SilenceUsage: true,
+	RunE:         runGraph,
+}
+
+var (
+	graphFile   string
+	graphFormat string
+	graphOut    string
+)
+
+func init() {
+	graphCmd.Flags().StringVarP(&graphFile, "file", "f", "", "DevStagingEnvironment YAML or dev-deploy workflow to draw (required)")
+	graphCmd.Flags().StringVar(&graphFormat, "format", "", "Output format: mermaid, dot, or svg (default: from the --out extension, then mermaid)")
+	graphCmd.Flags().StringVar(&graphOut, "out", "", "File to write (default: stdout)")
+	_ = graphCmd.MarkFlagRequired("file")
+	_ = graphCmd.RegisterFlagCompletionFunc("format", fixedCompletions("mermaid", "dot", "svg"))
+	rootCmd.AddCommand(graphCmd)
+}
+
+// Node kinds of an environment graph.
+const (
+	graphIngress    = "ingress"
+	graphComponent  = "component"
+	graphDependency = "dependency"
+	graphJob        = "job"
+	graphExternal   = "external" // a dependsOn name the file doesn't deploy
+)
+
+// envGraph is what an environment deploys and how the parts connect.
+type envGraph struct {
+	File  string      `json:"file"`
+	Nodes []graphNode `json:"nodes"`
+	Edges []graphEdge `json:"edges"`
+}
+
+// graphNode is one box of the diagram.
+type graphNode struct {
+	ID     string `json:"id"`
+	Kind   string `json:"kind"`
+	Label  string `json:"label"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// graphEdge points from the caller to what it reaches. Dashed edges are
+// calls found in env rather than declared in dependsOn.
+type graphEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Label  string `json:"label,omitempty"`
+	Dashed bool   `json:"dashed,omitempty"`
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	format := graphFormat
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(graphOut)) {
+		case ".dot", ".gv":
+			format = "dot"
+		case ".svg":
+			format = "svg"
+		default:
+			format = "mermaid"
+		}
+	}
+	var renderer func(envGraph) string
+	switch format {
+	case "mermaid":
+		renderer = renderGraphMermaid
+	case "dot":
+		renderer = renderGraphDOT
+	case "svg":
+		renderer = renderGraphSVG
+	default:
+		return fmt.Errorf("invalid --format %q (use mermaid, dot, or svg)", format)
+	}
+
+	data, err := os.ReadFile(graphFile)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", graphFile, err)
+	}
+	targets, _, findings := devstaging.ParseTargets(data)
+	var problems []string
+	for _, f := range findings {
+		if f.Severity == devstaging.SeverityError {
+			problems = append(problems, f.Detail)
+		}
+	}
+	if len(targets) == 0 {
+		if len(problems) > 0 {
+			return fmt.Errorf("cannot draw %s: %s", graphFile, problems[0])
+		}
+		return fmt.Errorf("no DevStagingEnvironment found in %s", graphFile)
+	}
+	g := buildEnvGraph(targets)
+	g.File = graphFile
+
+	if isJSONOutput() {
+		return printJSON(g)
+	}
+	out := renderer(g)
+	if graphOut == "" {
+		fmt.Print(out)
+		return nil
+	}
+	if err := os.WriteFile(graphOut, []byte(out), 0o644); err != nil {
+		return fmt.Errorf("cannot write %s: %w", graphOut, err)
+	}
+	success(fmt.Sprintf("Drew %d component(s) of %s to %s", len(targets), graphFile, graphOut))
+	if len(problems) > 0 {
+		warn(fmt.Sprintf("%s has %d schema error(s) — see: kindling validate -f %s", graphFile, len(problems), graphFile))
+	}
+	return nil
+}
+
+// buildEnvGraph turns the DSEs of a file into a graph. Dependencies and
+// jobs belong to one component each, as the operator runs them.
+func buildEnvGraph(targets []devstaging.Target) envGraph {
+	g := envGraph{Nodes: []graphNode{}, Edges: []graphEdge{}}
+	seen := map[string]bool{}
+	addNode := func(n graphNode) {
+		if !seen[n.ID] {
+			seen[n.ID] = true
+			g.Nodes = append(g.Nodes, n)
+		}
+	}
+	components := map[string]bool{}
+	for _, t := range targets {
+		components[t.Name] = true
+	}
+
+	for _, t := range targets {
+		spec := t.Manifest.Spec
+		id := "component:" + t.Name
+		var detail []string
+		// A workflow's image is mostly expressions: keep the part that
+		// names it.
+		image := actionExpression.ReplaceAllString(spec.Deployment.Image, "")
+		if image = strings.Trim(image, "/:"); image != "" {
+			detail = append(detail, image)
+		}
+		if spec.Deployment.Schedule != "" {
+			detail = append(detail, "runs "+strconv.Quote(spec.Deployment.Schedule))
+		} else if spec.Deployment.Port > 0 {
+			port := fmt.Sprintf(":%d", spec.Deployment.Port)
+			if r := spec.Deployment.Replicas; r != nil && *r != 1 {
+				port += fmt.Sprintf(" ×%d", *r)
+			}
+			detail = append(detail, port)
+		}
+		addNode(graphNode{ID: id, Kind: graphComponent, Label: t.Name, Detail: strings.Join(detail, " ")})
+
+		if ing := spec.Ingress; ing != nil && ing.Enabled && ing.Host != "" {
+			host := actorPrefix.ReplaceAllString(ing.Host, "")
+			scheme := "http://"
+			if ing.TLS != nil {
+				scheme = "https://"
+			}
+			path := ing.Path
+			if path == "" {
+				path = "/"
+			}
+			addNode(graphNode{ID: "ingress:" + host + path, Kind: graphIngress, Label: scheme + host + path})
+			g.Edges = append(g.Edges, graphEdge{From: "ingress:" + host + path, To: id, Label: ing.Protocol})
+		}
+
+		for _, d := range spec.Dependencies {
+			conv := devstaging.DependencyConventions[d.Type]
+			depID := fmt.Sprintf("dependency:%s-%s", t.Name, d.Type)
+			var depDetail []string
+			switch {
+			case d.Image != "":
+				depDetail = append(depDetail, d.Image)
+			case d.Version != "":
+				depDetail = append(depDetail, conv.Image+":"+d.Version)
+			case conv.Image != "":
+				depDetail = append(depDetail, conv.Image)
+			}
+			port := conv.Port
+			if d.Port != nil {
+				port = *d.Port
+			}
+			if port > 0 {
+				depDetail = append(depDetail, fmt.Sprintf(":%d", port))
+			}
+			addNode(graphNode{ID: depID, Kind: graphDependency, Label: d.Type, Detail: strings.Join(depDetail, " ")})
+			envVar := conv.EnvVar
+			if d.EnvVarName != "" {
+				envVar = d.EnvVarName
+			}
+			g.Edges = append(g.Edges, graphEdge{From: id, To: depID, Label: envVar})
+		}
+
+		for _, j := range spec.Jobs {
+			jobID := fmt.Sprintf("job:%s-%s", t.Name, j.Name)
+			addNode(graphNode{ID: jobID, Kind: graphJob, Label: j.Name, Detail: strings.Join(j.Command, " ")})
+			g.Edges = append(g.Edges, graphEdge{From: id, To: jobID, Label: "job", Dashed: true})
+		}
+
+		declared := map[string]bool{}
+		for _, up := range spec.DependsOn {
+			declared[up] = true
+			upID := "component:" + up
+			if !components[up] {
+				upID = "external:" + up
+				addNode(graphNode{ID: upID, Kind: graphExternal, Label: up, Detail: "not in this file"})
+			}
+			g.Edges = append(g.Edges, graphEdge{From: id, To: upID, Label: "dependsOn"})
+		}
+
+		// Calls found only in env are drawn dashed, named after the var.
+		for _, e := range spec.Deployment.Env {
+			m := envURLPattern.FindStringSubmatch(actorPrefix.ReplaceAllString(e.Value, ""))
+			if m == nil {
+				continue
+			}
+			host, _, _ := strings.Cut(m[1], ".")
+			if host == t.Name || declared[host] || !components[host] {
+				continue
+			}
+			declared[host] = true
+			g.Edges = append(g.Edges, graphEdge{From: id, To: "component:" + host, Label: e.Name, Dashed: true})
+		}
+	}
+	return g
+}
+
+// graphNodeIDs maps node IDs to the short identifiers the DOT and Mermaid
+// sources use, in node order.
+func graphNodeIDs(g envGraph) map[string]string {
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+	}
+	return ids
+}
+
+// ── Mermaid ─────────────────────────────────────────────────────
+
+// graphMermaidShapes are the node shapes by kind: a stadium for ingress
+// routes, a cylinder for backing services.
+var graphMermaidShapes = map[string][2]string{
+	graphIngress:    {"([", "])"},
+	graphComponent:  {"[", "]"},
+	graphDependency: {"[(", ")]"},
+	graphJob:        {"[/", "/]"},
+	graphExternal:   {"{{", "}}"},
+}
+
+// renderGraphMermaid renders g as a Mermaid flowchart.
+func renderGraphMermaid(g envGraph) string {
+	ids := graphNodeIDs(g)
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, n := range g.Nodes {
+		label := mermaidText(n.Label)
+		if n.Detail != "" {
+			label += "<br/><small>" + mermaidText(n.Detail) + "</small>"
+		}
+		shape := graphMermaidShapes[n.Kind]
+		fmt.Fprintf(&b, "  %s%s\"%s\"%s:::%s\n", ids[n.ID], shape[0], label, shape[1], n.Kind)
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Dashed {
+			arrow = "-.->"
+		}
+		if e.Label != "" {
+			arrow += "|\"" + mermaidText(e.Label) + "\"|"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", ids[e.From], arrow, ids[e.To])
+	}
+	for _, kind := range sortedKeys(graphColors) {
+		c := graphColors[kind]
+		fmt.Fprintf(&b, "  classDef %s fill:%s,stroke:%s,color:#1f2328\n", kind, c[0], c[1])
+	}
+	return b.String()
+}
+
+// mermaidText escapes s for a quoted Mermaid label.
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
+
+// ── Graphviz ────────────────────────────────────────────────────
+
+// graphDOTShapes are the Graphviz node shapes by kind.
+var graphDOTShapes = map[string]string{
+	graphIngress:    "oval",
+	graphComponent:  "box",
+	graphDependency: "cylinder",
+	graphJob:        "parallelogram",
+	graphExternal:   "hexagon",
+}
+
+// renderGraphDOT renders g as a Graphviz digraph.
+func renderGraphDOT(g envGraph) string {
+	ids := graphNodeIDs(g)
+	var b strings.Builder
+	b.WriteString("digraph environment {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [fontname=\"Helvetica\", fontsize=11, style=\"filled\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=9, color=\"#57606a\"];\n")
+	for _, n := range g.Nodes {
+		label := n.Label
+		if n.Detail != "" {
+			label += "\n" + n.Detail
+		}
+		c := graphColors[n.Kind]
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s, fillcolor=%q, color=%q];\n",
+			ids[n.ID], strconv.Quote(label), graphDOTShapes[n.Kind], c[0], c[1])
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.Label != "" {
+			attrs = append(attrs, "label="+strconv.Quote(e.Label))
+		}
+		if e.Dashed {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&b, "  %s -> %s", ids[e.From], ids[e.To])
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// graphColors are the fill and stroke of each node kind, shared by every
+// format.
+var graphColors = map[string][2]string{
+	graphIngress:    {"#ddf4ff", "#54aeff"},
+	graphComponent:  {"#dafbe1", "#4ac26b"},
+	graphDependency: {"#fff8c5", "#d4a72c"},
+	graphJob:        {"#fbefff", "#c297ff"},
+	graphExternal:   {"#ffebe9", "#ff8182"},
+}
+
+// graphKindOrder sorts nodes of the same column: ingress routes, then
+// components, then what they use.
+func graphKindOrder(kind string) int {
+	return map[string]int{graphIngress: 0, graphComponent: 1, graphExternal: 2, graphDependency: 3, graphJob: 4}[kind]
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// ── SVG ─────────────────────────────────────────────────────────
+//
+// The SVG is laid out in columns, left to right: a caller one column left
+// of the components it calls, each component's ingress routes just before
+// it and its backing services and jobs just after. Within a column, nodes
+// sit near the average row of their neighbours, which keeps most edges
+// short and uncrossed for the handful of components an environment has.
+
+const (
+	svgMargin    = 24
+	svgColumnGap = 96
+	svgRowGap    = 20
+	svgNodeH     = 48
+	svgMinNodeW  = 150
+)
+
+// svgNode is a node with its place in the drawing.
+type svgNode struct {
+	graphNode
+	col, row   int
+	x, y, w, h int
+}
+
+// renderGraphSVG lays out g and draws it as a standalone SVG image.
+func renderGraphSVG(g envGraph) string {
+	nodes := layoutGraph(g)
+	byID := make(map[string]*svgNode, len(nodes))
+	width, height := 0, 0
+	for _, n := range nodes {
+		byID[n.ID] = n
+		width = max(width, n.x+n.w+svgMargin)
+		height = max(height, n.y+n.h+svgMargin)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">`+"\n", width, height, width, height)
+	b.WriteString(`  <defs><marker id="arrow" viewBox="0 0 10 10" refX="9" refY="5" markerWidth="7" markerHeight="7" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="#57606a"/></marker></defs>` + "\n")
+	fmt.Fprintf(&b, `  <rect width="%d" height="%d" fill="#ffffff"/>`+"\n", width, height)
+
+	// Edges first, so the nodes cover their ends.
+	var labels strings.Builder
+	for _, e := range g.Edges {
+		from, to := byID[e.From], byID[e.To]
+		if from == nil || to == nil {
+			continue
+		}
+		var path string
+		var lx, ly int
+		if to.col > from.col {
+			x1, y1 := from.x+from.w, from.y+from.h/2
+			x2, y2 := to.x, to.y+to.h/2
+			mid := (x1 + x2) / 2
+			path = fmt.Sprintf("M%d,%d C%d,%d %d,%d %d,%d", x1, y1, mid, y1, mid, y2, x2, y2)
+			lx, ly = x1+svgColumnGap/2, y1+(y2-y1)/2-4
+		} else {
+			// A call back to an earlier column: a dependsOn cycle.
+			x1, y1 := from.x+from.w/2, from.y+from.h
+			x2, y2 := to.x+to.w/2, to.y+to.h
+			low := max(y1, y2) + svgRowGap + 16
+			path = fmt.Sprintf("M%d,%d C%d,%d %d,%d %d,%d", x1, y1, x1, low, x2, low, x2, y2)
+			lx, ly = (x1+x2)/2, low-4
+		}
+		dash := ""
+		if e.Dashed {
+			dash = ` stroke-dasharray="5,4"`
+		}
+		fmt.Fprintf(&b, `  <path d="%s" fill="none" stroke="#57606a" stroke-width="1.3"%s marker-end="url(#arrow)"/>`+"\n", path, dash)
+		if e.Label != "" {
+			fmt.Fprintf(&labels, `  <text x="%d" y="%d" font-size="10" fill="#57606a" text-anchor="middle" stroke="#ffffff" stroke-width="3" paint-order="stroke">%s</text>`+"\n",
+				lx, ly, html.EscapeString(e.Label))
+		}
+	}
+
+	for _, n := range nodes {
+		c := graphColors[n.Kind]
+		rx := 6
+		switch n.Kind {
+		case graphIngress:
+			rx = n.h / 2
+		case graphDependency:
+			rx = 14
+		}
+		dash := ""
+		if n.Kind == graphExternal || n.Kind == graphJob {
+			dash = ` stroke-dasharray="4,3"`
+		}
+		fmt.Fprintf(&b, `  <rect x="%d" y="%d" width="%d" height="%d" rx="%d" fill="%s" stroke="%s" stroke-width="1.5"%s/>`+"\n",
+			n.x, n.y, n.w, n.h, rx, c[0], c[1], dash)
+		cx := n.x + n.w/2
+		if n.Detail == "" {
+			fmt.Fprintf(&b, `  <text x="%d" y="%d" font-size="13" font-weight="bold" fill="#1f2328" text-anchor="middle">%s</text>`+"\n",
+				cx, n.y+n.h/2+5, html.EscapeString(n.Label))
+			continue
+		}
+		fmt.Fprintf(&b, `  <text x="%d" y="%d" font-size="13" font-weight="bold" fill="#1f2328" text-anchor="middle">%s</text>`+"\n",
+			cx, n.y+20, html.EscapeString(n.Label))
+		fmt.Fprintf(&b, `  <text x="%d" y="%d" font-size="10" fill="#57606a" text-anchor="middle">%s</text>`+"\n",
+			cx, n.y+36, html.EscapeString(n.Detail))
+	}
+	b.WriteString(labels.String())
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// layoutGraph assigns every node its column, row, and box.
+func layoutGraph(g envGraph) []*svgNode {
+	nodes := make([]*svgNode, len(g.Nodes))
+	byID := make(map[string]*svgNode, len(g.Nodes))
+	for i, n := range g.Nodes {
+		nodes[i] = &svgNode{graphNode: n}
+		byID[n.ID] = nodes[i]
+	}
+	calls := func(n *svgNode) bool { return n.Kind == graphComponent || n.Kind == graphExternal }
+
+	// Components: the longest chain of calls leading to each, bounded by
+	// the node count so cycles end.
+	for range nodes {
+		changed := false
+		for _, e := range g.Edges {
+			from, to := byID[e.From], byID[e.To]
+			if from != nil && to != nil && calls(from) && calls(to) && to.col < from.col+1 && to.col < len(nodes) {
+				to.col, changed = from.col+1, true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	for _, n := range nodes {
+		if calls(n) {
+			n.col++ // after the ingress routes
+		}
+	}
+	// An ingress route sits just left of its component, a backing service
+	// or job just right of its owner, so every edge spans one gap.
+	for _, e := range g.Edges {
+		from, to := byID[e.From], byID[e.To]
+		switch {
+		case from == nil || to == nil:
+		case from.Kind == graphIngress:
+			from.col = to.col - 1
+		case to.Kind == graphDependency || to.Kind == graphJob:
+			to.col = from.col + 1
+		}
+	}
+
+	// Rows, column by column: near the average row of the nodes pointing
+	// at them, or of the ones they point at when nothing does. The second
+	// sweep sees the rows the first one gave the later columns.
+	columns := map[int][]*svgNode{}
+	maxCol := 0
+	for _, n := range nodes {
+		columns[n.col] = append(columns[n.col], n)
+		maxCol = max(maxCol, n.col)
+	}
+	for sweep := 0; sweep < 2; sweep++ {
+		for col := 0; col <= maxCol; col++ {
+			column := columns[col]
+			weight := map[*svgNode]float64{}
+			for _, n := range column {
+				weight[n] = svgRowWeight(g, byID, n, true)
+				if weight[n] < 0 && sweep > 0 {
+					weight[n] = svgRowWeight(g, byID, n, false)
+				}
+			}
+			sort.SliceStable(column, func(i, k int) bool {
+				if weight[column[i]] != weight[column[k]] {
+					return weight[column[i]] < weight[column[k]]
+				}
+				if graphKindOrder(column[i].Kind) != graphKindOrder(column[k].Kind) {
+					return graphKindOrder(column[i].Kind) < graphKindOrder(column[k].Kind)
+				}
+				return column[i].Label < column[k].Label
+			})
+			for row, n := range column {
+				n.row = row
+			}
+		}
+	}
+
+	// Boxes: one width per column, wide enough for its longest text.
+	x := svgMargin
+	for col := 0; col <= maxCol; col++ {
+		w := svgMinNodeW
+		for _, n := range columns[col] {
+			w = max(w, 8*len([]rune(n.Label))+24, 6*len([]rune(n.Detail))+24)
+		}
+		for _, n := range columns[col] {
+			n.w, n.h = w, svgNodeH
+			if n.Detail == "" {
+				n.h = svgNodeH - 14
+			}
+			n.x = x
+			n.y = svgMargin + n.row*(svgNodeH+svgRowGap) + (svgNodeH-n.h)/2
+		}
+		if len(columns[col]) > 0 {
+			x += w + svgColumnGap
+		}
+	}
+	return nodes
+}
+
+// svgRowWeight is the average row of the nodes in earlier columns with an
+// edge to n (incoming), or of those in later columns n has an edge to;
+// -1 when there are none.
+func svgRowWeight(g envGraph, byID map[string]*svgNode, n *svgNode, incoming bool) float64 {
+	sum, count := 0.0, 0
+	for _, e := range g.Edges {
+		other := byID[e.From]
+		if !incoming {
+			other = byID[e.To]
+		}
+		if other == nil || (incoming && e.To != n.ID) || (!incoming && e.From != n.ID) {
+			continue
+		}
+		if incoming && other.col < n.col || !incoming && other.col > n.col {
+			sum += float64(other.row)
+			count++
+		}
+	}
+	if count == 0 {
+		return -1
+	}
+	return sum / float64(count)
+}
@@ -2,12 +2,15 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 )
 
 // ── ANSI colours ────────────────────────────────────────────────
@@ -22,24 +25,42 @@ const (
 )
 
 // ── Pretty-print helpers ────────────────────────────────────────
+//
+// The pretty-print helpers are silenced in --output json mode so stdout
+// carries nothing but the JSON document.
 
 func header(msg string) {
+	if isJSONOutput() {
+		return
+	}
 	fmt.Fprintf(os.Stderr, "\n%s%s▸ %s%s\n", colorBold, colorCyan, msg, colorReset)
 }
 
 func step(emoji, msg string) {
+	if isJSONOutput() {
+		return
+	}
 	fmt.Fprintf(os.Stderr, "  %s  %s\n", emoji, msg)
 }
 
 func success(msg string) {
+	if isJSONOutput() {
+		return
+	}
 	fmt.Fprintf(os.Stderr, "  %s✅ %s%s\n", colorGreen, msg, colorReset)
 }
 
 func warn(msg string) {
+	if isJSONOutput() {
+		return
+	}
 	fmt.Fprintf(os.Stderr, "  %s⚠️  %s%s\n", colorYellow, msg, colorReset)
 }
 
 func fail(msg string) {
+	if isJSONOutput() {
+		return
+	}
 	fmt.Printf("  %s❌ %s%s\n", colorRed, msg, colorReset)
 }
 
@@ -47,15 +68,108 @@ func dimText(msg string) string {
 	return fmt.Sprintf("%s%s%s", colorDim, msg, colorReset)
 }
 
+// spinner animates a progress message on stderr while a long wait runs.
+// When stderr is not a terminal, or in JSON mode, it prints nothing.
+type spinner struct {
+	mu   sync.Mutex
+	msg  string
+	done chan struct{}
+	wg   sync.WaitGroup
+	tty  bool
+}
+
+func startSpinner(msg string) *spinner {
+	s := &spinner{msg: msg, done: make(chan struct{})}
+	fi, err := os.Stderr.Stat()
+	s.tty = err == nil && fi.Mode()&os.ModeCharDevice != 0 && !isJSONOutput()
+	if !s.tty {
+		return s
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		frames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			s.mu.Lock()
+			fmt.Fprintf(os.Stderr, "\r\033[K  %s%s%s  %s", colorCyan, frames[i%len(frames)], colorReset, s.msg)
+			s.mu.Unlock()
+			select {
+			case <-s.done:
+				fmt.Fprint(os.Stderr, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+// update replaces the spinner's message.
+func (s *spinner) update(msg string) {
+	s.mu.Lock()
+	s.msg = msg
+	s.mu.Unlock()
+}
+
+// stop clears the spinner line.
+func (s *spinner) stop() {
+	close(s.done)
+	s.wg.Wait()
+}
+
+// ── Output rendering ────────────────────────────────────────────
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// validateOutputFormat rejects unknown values of the global --output flag.
+func validateOutputFormat() error {
+	switch outputFormat {
+	case outputText, outputJSON:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %q (use %q or %q)", outputFormat, outputText, outputJSON)
+	}
+}
+
+// isJSONOutput reports whether the user asked for machine-readable output.
+func isJSONOutput() bool {
+	return outputFormat == outputJSON
+}
+
+// render is the single place commands hand their result to. In JSON mode
+// v is written to stdout as an indented JSON document; otherwise the
+// human-readable printer is called.
+func render(v interface{}, human func()) error {
+	if isJSONOutput() {
+		return printJSON(v)
+	}
+	if human != nil {
+		human()
+	}
+	return nil
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
 // ── Command execution helpers ───────────────────────────────────
 
 // run executes a command, streaming stdout/stderr to the terminal.
 func run(name string, args ...string) error {
-	cmd := exec.Command(name, args...)
+	cmd := exec.Command(name, withKubeContext(name, args)...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
-	return cmd.Run()
+	return runCommand(cmd)
 }
 
 // runDir executes a command in a specific directory.
@@ -65,35 +179,86 @@ func runDir(dir, name string, args ...string) error {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
-	return cmd.Run()
+	return runCommand(cmd)
+}
+
+// runDirEnv executes a command in a specific directory with extra
+// environment variables ("KEY=value") on top of the current environment.
+func runDirEnv(dir string, env []string, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	return runCommand(cmd)
 }
 
 // runSilent executes a command and returns combined output.
 func runSilent(name string, args ...string) (string, error) {
-	cmd := exec.Command(name, args...)
+	cmd := exec.Command(name, withKubeContext(name, args)...)
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	cmd.Stderr = &out
-	err := cmd.Run()
+	err := runCommand(cmd)
 	return strings.TrimSpace(out.String()), err
 }
 
 // runCapture executes a command and returns stdout only.
 func runCapture(name string, args ...string) (string, error) {
-	cmd := exec.Command(name, args...)
+	cmd := exec.Command(name, withKubeContext(name, args)...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
-	err := cmd.Run()
+	err := runCommand(cmd)
 	return strings.TrimSpace(stdout.String()), err
 }
 
+// withKubeContext pins a kubectl invocation to the --context cluster,
+// unless it names a context itself, so commands never act on whatever
+// kubectl's current context happens to be. The namespace setting, when
+// there is one, is added the same way.
+func withKubeContext(name string, args []string) []string {
+	if name != "kubectl" {
+		return args
+	}
+	context, namespace := true, kubeNamespace != ""
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		switch {
+		case arg == "--context" || strings.HasPrefix(arg, "--context="):
+			context = false
+		case arg == "-n" || arg == "--namespace" || strings.HasPrefix(arg, "--namespace=") ||
+			arg == "-A" || arg == "--all-namespaces":
+			namespace = false
+		case arg == "-f" || arg == "--filename" || arg == "-k" || arg == "--kustomize":
+			// Manifests name their namespaces; kubectl rejects any other.
+			namespace = false
+		}
+	}
+	if namespace {
+		args = append([]string{"--namespace", kubeNamespace}, args...)
+	}
+	if context {
+		args = append([]string{"--context", kubeContextName()}, args...)
+	}
+	return args
+}
+
 // commandExists checks if a binary is on PATH.
 func commandExists(name string) bool {
 	_, err := exec.LookPath(name)
 	return err == nil
 }
 
+// fileExists reports whether path exists and is a regular file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
 // resolveProjectDir returns the project directory.
 //
 // Resolution order:
@@ -143,27 +308,25 @@ func resolveProjectDir() (string, error) {
 	return cachedDir, nil
 }
 
-// clusterExists checks whether a Kind cluster with the given name exists.
-func clusterExists(name string) bool {
-	out, err := runCapture("kind", "get", "clusters")
-	if err != nil {
-		return false
-	}
-	for _, line := range strings.Split(out, "\n") {
-		if strings.TrimSpace(line) == name {
-			return true
-		}
-	}
-	return false
-}
-
 // runStdin executes a command with the given string piped to stdin.
 func runStdin(input, name string, args ...string) error {
-	cmd := exec.Command(name, args...)
+	cmd := exec.Command(name, withKubeContext(name, args)...)
 	cmd.Stdin = strings.NewReader(input)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return runCommand(cmd)
+}
+
+// runSilentStdin executes a command with the given string piped to stdin
+// and returns combined output.
+func runSilentStdin(input, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, withKubeContext(name, args)...)
+	cmd.Stdin = strings.NewReader(input)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := runCommand(cmd)
+	return strings.TrimSpace(out.String()), err
 }
 
 // ensureKustomize downloads kustomize to <dir>/bin/ if not already present
@@ -195,7 +358,7 @@ func ensureKustomize(dir string) (string, error) {
 		fmt.Sprintf("curl -sL '%s' | tar xz -C '%s'", url, binDir))
 	tarCmd.Stdout = os.Stdout
 	tarCmd.Stderr = os.Stderr
-	if err := tarCmd.Run(); err != nil {
+	if err := runCommand(tarCmd); err != nil {
 		return "", fmt.Errorf("failed to download kustomize: %w", err)
 	}
 
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// ── Ingress controllers ─────────────────────────────────────────
+//
+// kindling init installs one of these, per the profile's ingress field.
+// setup-ingress.sh makes its IngressClass the cluster default, which is
+// how generate and the deploy action pick the class for new ingresses.
+
+// ingressController describes where an installed controller runs.
+type ingressController struct {
+	namespace string
+	selector  string // label selector of the pods that serve traffic
+	service   string // Service in front of those pods, listening on 80
+	manifest  string // upstream manifest setup-ingress.sh applies; "" when it ships with kindling
+}
+
+var ingressControllers = map[string]ingressController{
+	"nginx": {namespace: "ingress-nginx", selector: "app.kubernetes.io/component=controller", service: "ingress-nginx-controller",
+		manifest: "https://raw.githubusercontent.com/kubernetes/ingress-nginx/main/deploy/static/provider/kind/deploy.yaml"},
+	"contour": {namespace: "projectcontour", selector: "app=envoy", service: "envoy",
+		manifest: "https://projectcontour.io/quickstart/contour.yaml"},
+	"traefik": {namespace: "traefik", selector: "app.kubernetes.io/name=traefik", service: "traefik"},
+}
+
+// defaultIngressClass is assumed when the cluster has no default
+// IngressClass, as on clusters created before the default was set.
+const defaultIngressClass = "nginx"
+
+func ingressControllerNames() []string {
+	names := make([]string, 0, len(ingressControllers))
+	for name := range ingressControllers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// clusterIngressClass returns the cluster's default IngressClass, or the
+// only one when none is marked default.
+func clusterIngressClass() string {
+	out, err := kubectlJSON("get", "ingressclasses", "-o", "json")
+	if err != nil {
+		return defaultIngressClass
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name        string            `json:"name"`
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if json.Unmarshal([]byte(out), &list) != nil {
+		return defaultIngressClass
+	}
+	for _, ic := range list.Items {
+		if ic.Metadata.Annotations["ingressclass.kubernetes.io/is-default-class"] == "true" {
+			return ic.Metadata.Name
+		}
+	}
+	if len(list.Items) == 1 {
+		return list.Items[0].Metadata.Name
+	}
+	return defaultIngressClass
+}
+
+// installedIngressController returns the controller whose pods are running
+// in the cluster, checking the default class first.
+func installedIngressController() (string, ingressController, bool) {
+	names := append([]string{clusterIngressClass()}, ingressControllerNames()...)
+	for _, name := range names {
+		c, ok := ingressControllers[name]
+		if !ok {
+			continue
+		}
+		out, err := kubectlJSON("get", "pods", "-n", c.namespace, "-l", c.selector, "-o", "name")
+		if err == nil && out != "" {
+			return name, c, true
+		}
+	}
+	return "", ingressController{}, false
+}
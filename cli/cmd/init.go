@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -16,41 +17,126 @@ var initCmd = &cobra.Command{
 ingress-nginx controller, builds the kindling operator image, and deploys
 it into the cluster.
 
-This is the equivalent of running:
+Kind is built in, so the kind binary isn't needed. This is the
+equivalent of running:
   kind create cluster --name dev --config kind-config.yaml
   ./setup-ingress.sh
   make docker-build IMG=controller:latest
   kind load docker-image controller:latest --name dev
   make install deploy IMG=controller:latest
 
-Optional flags are passed through to "kind create cluster":
+--profile picks what the cluster contains:
+  minimal    1 node, ingress-nginx, no registry (images via kind load)
+  standard   1 node, ingress-nginx, in-cluster registry (default)
+  full       3 nodes, ingress-nginx, registry, Calico, metrics-server
+
+The resolved profile is saved to .kindling/cluster.yaml, so a later
+"kindling init" without --profile (e.g. after "kindling destroy")
+recreates the same cluster. Edit that file to fine-tune a profile: its
+fields are workers, ingress (nginx|contour|traefik|none), cni
+(kindnet|calico), registry, metricsServer, tls, tlsDomain, observability,
+logging, mounts, ports
+(extra <hostPort>[:<nodePort>] mappings), mirrors (registry host →
+mirror URL for containerd), and featureGates. The Kind config built from
+kind-config.yaml and the profile is saved to .kindling/kind-config.yaml
+for reference. --workers
+and --ingress override the profile's node count and ingress controller;
+workers are labelled kindling.dev/worker=1, 2, … so a DevStagingEnvironment
+can pin components to them with nodeSelector. The ingress controller's
+IngressClass becomes the cluster default, which generated manifests use.
+
+--mount makes a host directory visible inside the cluster: it is mounted
+into every Kind node at the given node path (default /kindling/<dir>),
+where a DevStagingEnvironment volume with that hostPath picks it up. Files
+an app writes there — uploads, database data — survive pod restarts and
+kindling destroy, and can be inspected from the host. Repeat it for more
+directories; like --workers, it replaces the profile's mounts and only
+takes effect when the cluster is created.
+
+--tls serves ingresses over HTTPS with certificates your browser trusts,
+without the public tunnel. mkcert installs a local CA on this machine,
+cert-manager issues certificates from it in the cluster, and ingress-nginx
+serves a wildcard certificate for *.localtest.me (or --tls-domain), which
+resolves to 127.0.0.1. Requires mkcert; the setting is saved to the profile.
+
+--observability installs kube-prometheus-stack into the monitoring
+namespace with helm, has Prometheus scrape the operator's metrics, and
+adds a kindling dashboard to Grafana (grafana.localhost, user admin,
+password kindling). Requires helm; the setting is saved to the profile.
+
+--logging installs Loki and promtail into the monitoring namespace with
+helm, collecting the logs of every pod, so "kindling logs --env <name>
+--all-components" can search an environment's history — including pods
+that are gone. Requires helm; the setting is saved to the profile.
+
+--backend picks what provisions the cluster: kind (default; built in),
+k3d, or minikube (docker driver), for machines where Kind can't run. The
+k3d and minikube binaries must be on PATH. Everything after creation —
+ingress, registry, operator — is the same on every backend, and the
+backend is saved to the profile so later commands use it too.
+
+--airgap installs from a bundle written by "kindling airgap prepare", for
+networks without registry access. Its images are loaded into Docker and
+pushed into the kindling-registry container (see "kindling registry"),
+which every node's containerd then uses as the mirror of docker.io,
+registry.k8s.io, and the other registries they came from; the upstream
+manifests and kustomize come from the bundle, and the operator image
+isn't rebuilt. The bundle must have been prepared for the profile's
+ingress controller and CNI; only the kind backend is supported, without
+observability or logging.
+
+Optional cluster creation flags, as for "kind create cluster":
   --image        Node image to use (e.g. kindest/node:v1.29.0)
-  --kubeconfig   Path to write kubeconfig (default: $KUBECONFIG or ~/.kube/config)
+  --kubeconfig   Kubeconfig to add the context to (global flag)
   --wait         Wait for control plane to be ready (e.g. 60s, 5m)
-  --retain       Retain nodes for debugging if cluster creation fails`,
+  --retain       Retain nodes for debugging if cluster creation fails (kind only)`,
 	RunE: runInit,
 }
 
 var (
-	skipCluster    bool
-	kindNodeImage  string
-	kindKubeconfig string
-	kindWait       string
-	kindRetain     bool
-	initExpose     bool
+	skipCluster   bool
+	kindNodeImage string
+	kindWait      string
+	kindRetain    bool
+	initExpose    bool
+	initProfile   string
+	initBackend   string
+	initWorkers   int
+	initMounts    []string
+	initTLS       bool
+	initIngress   string
+	initTLSDomain string
+	initObserve   bool
+	initLogging   bool
+	initAirgap    string
 )
 
 func init() {
 	initCmd.Flags().BoolVar(&skipCluster, "skip-cluster", false, "Skip Kind cluster creation (use existing cluster)")
 	initCmd.Flags().StringVar(&kindNodeImage, "image", "", "Node Docker image for Kind (e.g. kindest/node:v1.29.0)")
-	initCmd.Flags().StringVar(&kindKubeconfig, "kubeconfig", "", "Path to write kubeconfig instead of default location")
 	initCmd.Flags().StringVar(&kindWait, "wait", "", "Wait for control plane to be ready (e.g. 60s, 5m)")
 	initCmd.Flags().BoolVar(&kindRetain, "retain", false, "Retain cluster nodes for debugging on creation failure")
 	initCmd.Flags().BoolVar(&initExpose, "expose", false, "Start a public HTTPS tunnel after bootstrap (runs kindling expose)")
+	initCmd.Flags().IntVar(&initWorkers, "workers", 0, "Number of worker nodes besides the control plane (overrides the profile)")
+	initCmd.Flags().StringArrayVar(&initMounts, "mount", nil, "Mount a host directory into every node, as <hostDir>[:<nodePath>] (repeatable; overrides the profile)")
+	initCmd.Flags().StringVar(&initIngress, "ingress", "", "Ingress controller: nginx, contour, traefik, or none (overrides the profile)")
+	_ = initCmd.RegisterFlagCompletionFunc("ingress", fixedCompletions(append(ingressControllerNames(), "none")...))
+	initCmd.Flags().BoolVar(&initTLS, "tls", false, "Serve ingresses over HTTPS with locally trusted certificates (mkcert + cert-manager)")
+	initCmd.Flags().StringVar(&initTLSDomain, "tls-domain", "", "Domain for the wildcard certificate (default localtest.me; implies --tls)")
+	initCmd.Flags().BoolVar(&initObserve, "observability", false, "Install kube-prometheus-stack with the kindling Grafana dashboard")
+	initCmd.Flags().BoolVar(&initLogging, "logging", false, "Install Loki and promtail to aggregate every component's logs (kindling logs --all-components)")
+	initCmd.Flags().StringVar(&initBackend, "backend", "", "Cluster backend: kind, k3d, or minikube (overrides the profile)")
+	_ = initCmd.RegisterFlagCompletionFunc("backend", fixedCompletions(clusterBackendNames()...))
+	initCmd.Flags().StringVar(&initAirgap, "airgap", "", "Install from a bundle written by kindling airgap prepare, without network access")
+	initCmd.Flags().StringVar(&initProfile, "profile", "", "Cluster profile: minimal, standard, or full (default: .kindling/cluster.yaml, else standard)")
+	_ = initCmd.RegisterFlagCompletionFunc("profile", completeClusterProfiles)
 	rootCmd.AddCommand(initCmd)
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
+	if err := requireLocalCluster("kindling init"); err != nil {
+		return fmt.Errorf("%w — install the operator on a remote cluster with: make deploy", err)
+	}
 	dir, err := resolveProjectDir()
 	if err != nil {
 		return err
@@ -59,8 +145,12 @@ func runInit(cmd *cobra.Command, args []string) error {
 	// ── Preflight checks ────────────────────────────────────────
 	header("Preflight checks")
 
+	tools := []string{"kubectl", "docker"}
+	if initObserve || initLogging {
+		tools = append(tools, "helm")
+	}
 	missing := []string{}
-	for _, tool := range []string{"kind", "kubectl", "docker"} {
+	for _, tool := range tools {
 		if commandExists(tool) {
 			step("✓", fmt.Sprintf("%s found", tool))
 		} else {
@@ -72,78 +162,226 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("missing required tools: %v", missing)
 	}
 
+	// ── Cluster profile ─────────────────────────────────────────
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	profile, saved, err := resolveClusterProfile(cwd, initProfile)
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("workers") {
+		if initWorkers < 0 {
+			return fmt.Errorf("--workers must not be negative")
+		}
+		profile.Workers, saved = initWorkers, false
+	}
+	if cmd.Flags().Changed("mount") {
+		profile.Mounts, saved = initMounts, false
+		if err := profile.validate(); err != nil {
+			return err
+		}
+	}
+	if initBackend != "" {
+		profile.Backend, saved = initBackend, false
+		if err := profile.validate(); err != nil {
+			return err
+		}
+	}
+	if initIngress != "" {
+		profile.Ingress, saved = initIngress, false
+		if err := profile.validate(); err != nil {
+			return err
+		}
+	}
+	if initTLS || initTLSDomain != "" {
+		profile.TLS, saved = true, false
+		if initTLSDomain != "" {
+			profile.TLSDomain = initTLSDomain
+		}
+		if err := profile.validate(); err != nil {
+			return err
+		}
+	}
+	if initObserve {
+		profile.Observability, saved = true, false
+	}
+	if initLogging {
+		profile.Logging, saved = true, false
+	}
+	if (profile.Observability || profile.Logging) && !commandExists("helm") {
+		return fmt.Errorf("the profile enables observability or logging, which need helm — brew install helm (or see https://helm.sh/docs/intro/install/)")
+	}
+	provider := useClusterProvider(profile.backend())
+	for _, tool := range provider.Tools() {
+		if !commandExists(tool) {
+			return fmt.Errorf("the %s backend needs %s on PATH — run: kindling doctor", provider.Name(), tool)
+		}
+	}
 	configPath := filepath.Join(dir, "kind-config.yaml")
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+	if _, err := os.Stat(configPath); os.IsNotExist(err) && provider.Name() == "kind" {
 		return fmt.Errorf("kind-config.yaml not found in %s — are you in the kindling project root?", dir)
 	}
+	if initAirgap != "" {
+		if err := airgapSupports(profile); err != nil {
+			return err
+		}
+	}
+	if profile.TLS && !commandExists("mkcert") {
+		return fmt.Errorf("the profile enables tls, which needs mkcert — brew install mkcert (or see https://github.com/FiloSottile/mkcert#installation)")
+	}
+	if saved {
+		step("📋", fmt.Sprintf("Profile %s from %s: %s", profile.Profile, clusterProfilePath(cwd), profile.summary()))
+	} else {
+		step("📋", fmt.Sprintf("Profile %s: %s", profile.Profile, profile.summary()))
+		if err := writeClusterProfile(cwd, profile); err != nil {
+			return fmt.Errorf("cannot save cluster profile: %w", err)
+		}
+	}
 
-	// ── Create Kind cluster ─────────────────────────────────────
+	// ── Air-gapped bundle ───────────────────────────────────────
+	var airgapMirrors map[string]string
+	if initAirgap != "" {
+		header("Loading air-gapped bundle")
+		work, err := os.MkdirTemp("", "kindling-airgap-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(work)
+		index, mirrors, err := loadAirgapBundle(initAirgap, work, profile)
+		if err != nil {
+			return err
+		}
+		if err := installAirgapKustomize(work, dir); err != nil {
+			return err
+		}
+		if kindNodeImage == "" {
+			kindNodeImage = index.NodeImage
+		}
+		airgapMirrors = mirrors
+	}
+
+	// ── Create cluster ──────────────────────────────────────────
 	if skipCluster {
 		header("Skipping cluster creation (--skip-cluster)")
 	} else {
-		header("Creating Kind cluster")
+		header(fmt.Sprintf("Creating %s cluster", provider.Name()))
 
 		if clusterExists(clusterName) {
-			warn(fmt.Sprintf("Cluster %q already exists — skipping creation", clusterName))
+			warn(fmt.Sprintf("Cluster %q already exists — skipping creation (node count, CNI, and mounts are unchanged)", clusterName))
 		} else {
-			kindArgs := []string{
-				"create", "cluster",
-				"--name", clusterName,
-				"--config", configPath,
-			}
-			if kindNodeImage != "" {
-				kindArgs = append(kindArgs, "--image", kindNodeImage)
-			}
-			if kindKubeconfig != "" {
-				kindArgs = append(kindArgs, "--kubeconfig", kindKubeconfig)
-			}
+			var wait time.Duration
 			if kindWait != "" {
-				kindArgs = append(kindArgs, "--wait", kindWait)
+				if wait, err = time.ParseDuration(kindWait); err != nil {
+					return fmt.Errorf("--wait: %w", err)
+				}
 			}
-			if kindRetain {
-				kindArgs = append(kindArgs, "--retain")
-			}
-
-			step("🔧", fmt.Sprintf("kind %s", strings.Join(kindArgs, " ")))
-			if err := runDir(dir, "kind", kindArgs...); err != nil {
-				return fmt.Errorf("failed to create Kind cluster: %w", err)
+			err := provider.Create(clusterName, clusterSpec{
+				Profile:    profile,
+				ConfigPath: configPath,
+				ProjectDir: cwd,
+				NodeImage:  kindNodeImage,
+				Wait:       wait,
+				Retain:     kindRetain,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create %s cluster: %w", provider.Name(), err)
 			}
-			success("Kind cluster created")
+			success(fmt.Sprintf("%s cluster created", provider.Name()))
 		}
 	}
 
 	// ── Set kubectl context ─────────────────────────────────────
-	ctx := fmt.Sprintf("kind-%s", clusterName)
+	ctx := kubeContextName()
 	step("🔗", fmt.Sprintf("Switching kubectl context to %s", ctx))
 	if err := run("kubectl", "cluster-info", "--context", ctx); err != nil {
 		return fmt.Errorf("cannot reach cluster %q: %w", ctx, err)
 	}
+	if addr, ok := localRegistryAddress(); ok {
+		// A registry started by 'kindling registry start' outlives clusters.
+		if err := connectLocalRegistry(addr); err != nil {
+			warn(fmt.Sprintf("Could not wire up the local registry: %v", err))
+		}
+	}
+	if len(airgapMirrors) > 0 {
+		step("🧩", "Pulling every registry through the local registry")
+		if err := writeNodeMirrors(provider, clusterName, airgapMirrors); err != nil {
+			return err
+		}
+	}
+	if err := installCNI(profile); err != nil {
+		return err
+	}
 
 	// ── Setup ingress + registry ────────────────────────────────
-	header("Installing ingress-nginx + in-cluster registry")
+	header("Installing ingress + in-cluster registry")
 
 	ingressScript := filepath.Join(dir, "setup-ingress.sh")
 	if _, err := os.Stat(ingressScript); os.IsNotExist(err) {
 		return fmt.Errorf("setup-ingress.sh not found in %s", dir)
 	}
 
-	if err := runDir(dir, "bash", ingressScript); err != nil {
+	nodes, _ := clusterNodes(clusterName)
+	scriptEnv := []string{
+		"KIND_CLUSTER_NAME=" + clusterName,
+		"KINDLING_NODES=" + strings.Join(nodes, " "),
+		"KINDLING_CERTS_DIR=" + provider.CertsDir(),
+		"KINDLING_INGRESS=" + profile.Ingress,
+		fmt.Sprintf("KINDLING_REGISTRY=%t", profile.Registry),
+	}
+	if m := ingressControllers[profile.Ingress].manifest; m != "" && manifestSource(m) != m {
+		scriptEnv = append(scriptEnv, "KINDLING_INGRESS_MANIFEST="+manifestSource(m))
+	}
+	if err := runDirEnv(dir, scriptEnv, "bash", ingressScript); err != nil {
 		return fmt.Errorf("setup-ingress.sh failed: %w", err)
 	}
 	success("Ingress and registry ready")
 
+	if profile.MetricsServer {
+		if err := installMetricsServer(); err != nil {
+			return err
+		}
+	}
+
+	// cert-manager issues the operator's webhook serving certificate.
+	if err := installCertManager(); err != nil {
+		return err
+	}
+
+	if profile.TLS {
+		if err := setupLocalTLS(profile.tlsDomain(), profile.Ingress); err != nil {
+			return err
+		}
+	}
+
+	if profile.Observability {
+		if err := installPrometheusStack(profile); err != nil {
+			return err
+		}
+	}
+	if profile.Logging {
+		if err := installLogging(profile); err != nil {
+			return err
+		}
+	}
+
 	// ── Build the operator image ────────────────────────────────
-	header("Building kindling operator image")
+	if initAirgap != "" {
+		header("Using the kindling operator image from the bundle")
+	} else {
+		header("Building kindling operator image")
 
-	step("🏗️ ", "docker build -t controller:latest")
-	if err := runDir(dir, "docker", "build", "-t", "controller:latest", "."); err != nil {
-		return fmt.Errorf("operator image build failed: %w", err)
+		step("🏗️ ", "docker build -t controller:latest")
+		if err := runDir(dir, "docker", "build", "-t", "controller:latest", "."); err != nil {
+			return fmt.Errorf("operator image build failed: %w", err)
+		}
+		success("Operator image built")
 	}
-	success("Operator image built")
 
 	// ── Load image into Kind ────────────────────────────────────
 	step("📦", "Loading image into Kind cluster")
-	if err := run("kind", "load", "docker-image", "controller:latest", "--name", clusterName); err != nil {
+	if err := loadImage(clusterName, "controller:latest"); err != nil {
 		return fmt.Errorf("failed to load image into Kind: %w", err)
 	}
 	success("Image loaded")
@@ -178,7 +416,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("kustomize build failed: %w", err)
 	}
-	if err := runStdin(kustomizeOut, "kubectl", "apply", "-f", "-"); err != nil {
+	if err := applyWhenWebhookReady(kustomizeOut); err != nil {
 		return fmt.Errorf("operator deployment failed: %w", err)
 	}
 	success("Operator deployed")
@@ -195,6 +433,12 @@ func runInit(cmd *cobra.Command, args []string) error {
 		success("Controller is running")
 	}
 
+	if profile.Observability {
+		if err := applyObservability(kustomizeBin, dir); err != nil {
+			return err
+		}
+	}
+
 	// ── Done ────────────────────────────────────────────────────
 	fmt.Println()
 	fmt.Printf("  %s🎉 kindling is ready!%s\n", colorGreen+colorBold, colorReset)
@@ -204,6 +448,9 @@ func runInit(cmd *cobra.Command, args []string) error {
 	fmt.Printf("    %skindling deploy -f examples/sample-app/dev-environment.yaml%s\n", colorCyan, colorReset)
 	fmt.Printf("    %skindling status%s\n", colorCyan, colorReset)
 	fmt.Println()
+	if profile.Observability {
+		printGrafanaAccess(profile)
+	}
 
 	// ── Optional: start tunnel ──────────────────────────────────
 	if initExpose {
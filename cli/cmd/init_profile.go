@@ -0,0 +1,439 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jeffvincent/kindling/cli/pkg/kind"
+	"gopkg.in/yaml.v3"
+)
+
+// ── Cluster profiles ────────────────────────────────────────────
+//
+// A cluster profile decides what kindling init builds: how many nodes,
+// which ingress controller and CNI, and which add-ons. The resolved
+// profile is written to .kindling/cluster.yaml so that a later
+// kindling init — e.g. after kindling destroy — recreates the same
+// cluster without repeating --profile.
+
+// clusterProfileFile is the file name of the persisted profile inside .kindling/.
+const clusterProfileFile = "cluster.yaml"
+
+const (
+	calicoManifestURL        = "https://raw.githubusercontent.com/projectcalico/calico/v3.28.2/manifests/calico.yaml"
+	metricsServerManifestURL = "https://github.com/kubernetes-sigs/metrics-server/releases/latest/download/components.yaml"
+)
+
+// clusterProfile is the layout of .kindling/cluster.yaml. Every field can
+// be edited by hand; Profile only records which preset it started from.
+type clusterProfile struct {
+	Profile       string   `yaml:"profile"`
+	Backend       string   `yaml:"backend,omitempty"`   // kind (default), k3d, or minikube
+	Workers       int      `yaml:"workers"`             // worker nodes besides the control plane
+	Ingress       string   `yaml:"ingress"`             // nginx, contour, traefik, or none
+	CNI           string   `yaml:"cni"`                 // kindnet or calico
+	Registry      bool     `yaml:"registry"`            // in-cluster registry:5000 for Kaniko builds
+	MetricsServer bool     `yaml:"metricsServer"`       // enables kubectl top and HPAs
+	TLS           bool     `yaml:"tls"`                 // locally trusted HTTPS via mkcert + cert-manager
+	TLSDomain     string   `yaml:"tlsDomain,omitempty"` // wildcard certificate domain (default localtest.me)
+	Observability bool     `yaml:"observability"`       // kube-prometheus-stack and the kindling Grafana dashboard
+	Logging       bool     `yaml:"logging"`             // Loki and promtail, for kindling logs --all-components
+	Mounts        []string `yaml:"mounts,omitempty"`    // host directories mounted into every node, as <hostDir>[:<nodePath>]
+
+	Ports        []string          `yaml:"ports,omitempty"`        // extra host ports forwarded to the control plane, as <hostPort>[:<nodePort>]
+	Mirrors      map[string]string `yaml:"mirrors,omitempty"`      // registry host → mirror endpoint, e.g. docker.io: http://mirror:5000
+	FeatureGates map[string]bool   `yaml:"featureGates,omitempty"` // Kubernetes feature gates for every component
+}
+
+// clusterProfiles are the presets accepted by init --profile.
+var clusterProfiles = map[string]clusterProfile{
+	// Fastest start-up: no registry, so images come from kind load
+	// (kindling dev) rather than CI builds.
+	"minimal": {Profile: "minimal", Ingress: "nginx", CNI: "kindnet"},
+	// What kindling init has always created.
+	"standard": {Profile: "standard", Ingress: "nginx", CNI: "kindnet", Registry: true},
+	// Closer to a real cluster: multiple nodes, NetworkPolicy support, and
+	// resource metrics.
+	"full": {Profile: "full", Workers: 2, Ingress: "nginx", CNI: "calico", Registry: true, MetricsServer: true},
+}
+
+const defaultClusterProfile = "standard"
+
+// defaultMountRoot is where a --mount without a node path appears on the
+// nodes: /kindling/<base name of the host directory>.
+const defaultMountRoot = "/kindling"
+
+// workerNodeLabel numbers the worker nodes (1, 2, …) for nodeSelectors.
+const workerNodeLabel = "kindling.dev/worker"
+
+func clusterProfileNames() []string {
+	names := make([]string, 0, len(clusterProfiles))
+	for name := range clusterProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func clusterProfilePath(dir string) string {
+	return filepath.Join(dir, ".kindling", clusterProfileFile)
+}
+
+// resolveClusterProfile returns the named preset, or the profile saved in
+// dir when name is empty, or the default preset when nothing is saved.
+// saved reports whether the profile came from .kindling/cluster.yaml.
+func resolveClusterProfile(dir, name string) (p clusterProfile, saved bool, err error) {
+	if name != "" {
+		p, ok := clusterProfiles[name]
+		if !ok {
+			return p, false, fmt.Errorf("unknown profile %q (want %s)", name, strings.Join(clusterProfileNames(), ", "))
+		}
+		return p, false, nil
+	}
+
+	data, err := os.ReadFile(clusterProfilePath(dir))
+	if os.IsNotExist(err) {
+		return clusterProfiles[defaultClusterProfile], false, nil
+	}
+	if err != nil {
+		return p, false, err
+	}
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return p, false, fmt.Errorf("cannot parse %s: %w", clusterProfilePath(dir), err)
+	}
+	if err := p.validate(); err != nil {
+		return p, false, fmt.Errorf("%s: %w", clusterProfilePath(dir), err)
+	}
+	return p, true, nil
+}
+
+func (p clusterProfile) validate() error {
+	if _, ok := clusterProviders[p.backend()]; !ok {
+		return fmt.Errorf("backend must be %s, got %q", strings.Join(clusterBackendNames(), ", "), p.Backend)
+	}
+	if _, ok := ingressControllers[p.Ingress]; !ok && p.Ingress != "none" {
+		return fmt.Errorf("ingress must be %s, or none, got %q", strings.Join(ingressControllerNames(), ", "), p.Ingress)
+	}
+	switch p.CNI {
+	case "kindnet", "calico":
+	default:
+		return fmt.Errorf("cni must be kindnet or calico, got %q", p.CNI)
+	}
+	if p.Workers < 0 {
+		return fmt.Errorf("workers must not be negative, got %d", p.Workers)
+	}
+	if p.TLS && p.Ingress == "none" {
+		return fmt.Errorf("tls needs an ingress controller, but ingress is none")
+	}
+	nodePaths := map[string]bool{}
+	for _, m := range p.Mounts {
+		_, nodePath := splitMount(m)
+		if !strings.HasPrefix(nodePath, "/") {
+			return fmt.Errorf("mount %q: the node path must be absolute", m)
+		}
+		if nodePaths[nodePath] {
+			return fmt.Errorf("mount %q: %s is already mounted", m, nodePath)
+		}
+		nodePaths[nodePath] = true
+	}
+	hostPorts := map[int32]bool{80: true, 443: true}
+	for _, port := range p.Ports {
+		hostPort, _, err := splitPort(port)
+		if err != nil {
+			return err
+		}
+		if hostPorts[hostPort] {
+			return fmt.Errorf("port %q: host port %d is already mapped", port, hostPort)
+		}
+		hostPorts[hostPort] = true
+	}
+	for registry, endpoint := range p.Mirrors {
+		if registry == "" || strings.ContainsAny(registry, "/ ") {
+			return fmt.Errorf("mirror %q: the key must be a registry host such as docker.io", registry)
+		}
+		if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+			return fmt.Errorf("mirror %s: the endpoint must be an http:// or https:// URL, got %q", registry, endpoint)
+		}
+	}
+	return nil
+}
+
+// splitMount splits a mount into its host directory and node path. The
+// node path is what follows the last colon when it is absolute, so that a
+// Windows drive letter stays part of the host directory.
+func splitMount(m string) (hostDir, nodePath string) {
+	if i := strings.LastIndex(m, ":"); i > 0 && strings.HasPrefix(m[i+1:], "/") {
+		return m[:i], m[i+1:]
+	}
+	return m, defaultMountRoot + "/" + filepath.Base(filepath.Clean(m))
+}
+
+// hostMounts resolves the profile's mounts to Kind extraMounts, with host
+// directories relative to dir made absolute, and creates the host
+// directories — otherwise Docker would create them owned by root.
+func (p clusterProfile) hostMounts(dir string) ([]kind.Mount, error) {
+	var mounts []kind.Mount
+	for _, m := range p.Mounts {
+		hostDir, nodePath := splitMount(m)
+		if !filepath.IsAbs(hostDir) {
+			hostDir = filepath.Join(dir, hostDir)
+		}
+		if err := os.MkdirAll(hostDir, 0755); err != nil {
+			return nil, fmt.Errorf("mount %q: %w", m, err)
+		}
+		mounts = append(mounts, kind.Mount{HostPath: hostDir, ContainerPath: nodePath})
+	}
+	return mounts, nil
+}
+
+// mirrorMounts writes a containerd hosts.toml for each of the profile's
+// registry mirrors to <dir>/.kindling/containerd/certs.d/<registry>/ and
+// returns the mounts that put them where containerd's config_path looks.
+func (p clusterProfile) mirrorMounts(dir string) ([]kind.Mount, error) {
+	registries := make([]string, 0, len(p.Mirrors))
+	for registry := range p.Mirrors {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+
+	var mounts []kind.Mount
+	for _, registry := range registries {
+		hostDir := filepath.Join(dir, ".kindling", "containerd", "certs.d", registry)
+		if err := os.MkdirAll(hostDir, 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(hostDir, "hosts.toml"), []byte(mirrorHostsToml(p.Mirrors[registry])), 0644); err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, kind.Mount{HostPath: hostDir, ContainerPath: "/etc/containerd/certs.d/" + registry, Readonly: true})
+	}
+	return mounts, nil
+}
+
+// mirrorHostsToml is the containerd hosts.toml that pulls through
+// endpoint. An endpoint with a path, such as
+// http://kindling-registry:5000/v2/docker.io, is used as the API root
+// instead of <endpoint>/v2.
+func mirrorHostsToml(endpoint string) string {
+	toml := fmt.Sprintf("[host.%q]\n  capabilities = [\"pull\", \"resolve\"]\n", endpoint)
+	if u, err := url.Parse(endpoint); err == nil && strings.Trim(u.Path, "/") != "" {
+		toml += "  override_path = true\n"
+	}
+	return toml
+}
+
+// portMappings turns the profile's ports into control-plane port mappings.
+func (p clusterProfile) portMappings() []kind.PortMapping {
+	var mappings []kind.PortMapping
+	for _, port := range p.Ports {
+		hostPort, nodePort, _ := splitPort(port)
+		mappings = append(mappings, kind.PortMapping{HostPort: hostPort, ContainerPort: nodePort, Protocol: "TCP"})
+	}
+	return mappings
+}
+
+// splitPort parses a port as <hostPort>[:<nodePort>]; the node port
+// defaults to the host port.
+func splitPort(port string) (hostPort, nodePort int32, err error) {
+	host, node, found := strings.Cut(port, ":")
+	if !found {
+		node = host
+	}
+	h, err := strconv.ParseUint(host, 10, 16)
+	if err != nil || h == 0 {
+		return 0, 0, fmt.Errorf("port %q: want <hostPort>[:<nodePort>]", port)
+	}
+	n, err := strconv.ParseUint(node, 10, 16)
+	if err != nil || n == 0 {
+		return 0, 0, fmt.Errorf("port %q: want <hostPort>[:<nodePort>]", port)
+	}
+	return int32(h), int32(n), nil
+}
+
+// writeClusterProfile saves p as <dir>/.kindling/cluster.yaml.
+func writeClusterProfile(dir string, p clusterProfile) error {
+	if err := os.MkdirAll(filepath.Join(dir, ".kindling"), 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(clusterProfilePath(dir), data, 0644)
+}
+
+// summary is the one-line description printed by init.
+func (p clusterProfile) summary() string {
+	nodes := "1 node"
+	if p.Workers > 0 {
+		nodes = fmt.Sprintf("%d nodes", p.Workers+1)
+	}
+	parts := []string{nodes, "ingress " + p.Ingress, p.CNI}
+	if p.backend() != defaultClusterBackend {
+		parts = append([]string{p.backend()}, parts...)
+	}
+	if p.Registry {
+		parts = append(parts, "registry")
+	}
+	if p.MetricsServer {
+		parts = append(parts, "metrics-server")
+	}
+	if p.TLS {
+		parts = append(parts, "tls *."+p.tlsDomain())
+	}
+	if p.Observability {
+		parts = append(parts, "prometheus + grafana")
+	}
+	if p.Logging {
+		parts = append(parts, "loki")
+	}
+	for _, m := range p.Mounts {
+		hostDir, nodePath := splitMount(m)
+		parts = append(parts, fmt.Sprintf("mount %s → %s", hostDir, nodePath))
+	}
+	for _, port := range p.Ports {
+		parts = append(parts, "port "+port)
+	}
+	if len(p.Mirrors) > 0 {
+		parts = append(parts, fmt.Sprintf("%d registry mirror(s)", len(p.Mirrors)))
+	}
+	if len(p.FeatureGates) > 0 {
+		parts = append(parts, fmt.Sprintf("%d feature gate(s)", len(p.FeatureGates)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// backend is the name of the profile's ClusterProvider.
+func (p clusterProfile) backend() string {
+	if p.Backend != "" {
+		return p.Backend
+	}
+	return defaultClusterBackend
+}
+
+// tlsDomain is the domain the wildcard certificate is issued for.
+func (p clusterProfile) tlsDomain() string {
+	if p.TLSDomain != "" {
+		return p.TLSDomain
+	}
+	return defaultTLSDomain
+}
+
+// kindConfigForProfile returns the Kind config to create the cluster with:
+// base with the profile's worker nodes, host mounts, registry mirrors, port
+// mappings, feature gates, and CNI settings added. A copy is written to
+// <dir>/.kindling/kind-config.yaml for reference.
+func kindConfigForProfile(base, dir string, p clusterProfile) (*kind.Config, error) {
+	cfg, err := kind.ReadConfig(base)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Nodes) == 0 {
+		cfg.Nodes = []kind.Node{{Role: kind.ControlPlaneRole}}
+	}
+
+	// Workers are labelled kindling.dev/worker=<n> so components can be
+	// pinned to one with a nodeSelector.
+	for i := 1; i <= p.Workers; i++ {
+		cfg.Nodes = append(cfg.Nodes, kind.Node{
+			Role:   kind.WorkerRole,
+			Labels: map[string]string{workerNodeLabel: strconv.Itoa(i)},
+		})
+	}
+
+	// Every node gets the mounts, so a hostPath volume finds the same
+	// directory wherever its pod is scheduled, and every node pulls
+	// through the same mirrors.
+	mounts, err := p.hostMounts(dir)
+	if err != nil {
+		return nil, err
+	}
+	mirrors, err := p.mirrorMounts(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cfg.Nodes {
+		cfg.Nodes[i].ExtraMounts = append(cfg.Nodes[i].ExtraMounts, mounts...)
+		cfg.Nodes[i].ExtraMounts = append(cfg.Nodes[i].ExtraMounts, mirrors...)
+	}
+
+	// Extra ports go to the first control-plane node, next to 80 and 443.
+	for i := range cfg.Nodes {
+		if cfg.Nodes[i].Role == kind.ControlPlaneRole || cfg.Nodes[i].Role == "" {
+			cfg.Nodes[i].ExtraPortMappings = append(cfg.Nodes[i].ExtraPortMappings, p.portMappings()...)
+			break
+		}
+	}
+
+	if len(p.FeatureGates) > 0 {
+		if cfg.FeatureGates == nil {
+			cfg.FeatureGates = map[string]bool{}
+		}
+		for gate, enabled := range p.FeatureGates {
+			cfg.FeatureGates[gate] = enabled
+		}
+	}
+
+	if p.CNI == "calico" {
+		cfg.Networking.DisableDefaultCNI = true
+		cfg.Networking.PodSubnet = "192.168.0.0/16" // Calico's default pool
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ".kindling"), 0755); err != nil {
+		return nil, err
+	}
+	header := fmt.Sprintf("# Generated by kindling init from %s for profile %q — for reference only.\n", base, p.Profile)
+	if err := kind.WriteConfig(filepath.Join(dir, ".kindling", "kind-config.yaml"), header, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// installCNI installs the profile's CNI when it isn't Kind's built-in
+// kindnet, and waits for the nodes to become Ready.
+func installCNI(p clusterProfile) error {
+	// minikube installs Calico itself when it starts the cluster.
+	if p.CNI != "calico" || p.backend() == "minikube" {
+		return nil
+	}
+	step("🕸️ ", "Installing Calico")
+	if err := run("kubectl", "apply", "-f", manifestSource(calicoManifestURL)); err != nil {
+		return fmt.Errorf("calico install failed: %w", err)
+	}
+	step("⏳", "Waiting for nodes to become Ready")
+	if err := run("kubectl", "wait", "--for=condition=Ready", "nodes", "--all", "--timeout=180s"); err != nil {
+		return fmt.Errorf("nodes did not become Ready: %w", err)
+	}
+	success("Calico ready")
+	return nil
+}
+
+// installMetricsServer installs metrics-server with kubelet TLS
+// verification off, since Kind's kubelets use self-signed certificates.
+func installMetricsServer() error {
+	step("📈", "Installing metrics-server")
+	if err := run("kubectl", "apply", "-f", manifestSource(metricsServerManifestURL)); err != nil {
+		return fmt.Errorf("metrics-server install failed: %w", err)
+	}
+	// Re-running init re-applies the manifest, which keeps the arg; only
+	// add it once.
+	args, _ := runCapture("kubectl", "get", "deployment", "metrics-server", "-n", "kube-system",
+		"-o", "jsonpath={.spec.template.spec.containers[0].args}")
+	if !strings.Contains(args, "--kubelet-insecure-tls") {
+		patch := `[{"op":"add","path":"/spec/template/spec/containers/0/args/-","value":"--kubelet-insecure-tls"}]`
+		if err := run("kubectl", "patch", "deployment", "metrics-server", "-n", "kube-system", "--type", "json", "-p", patch); err != nil {
+			return fmt.Errorf("metrics-server patch failed: %w", err)
+		}
+	}
+	if err := run("kubectl", "rollout", "status", "deployment/metrics-server", "-n", "kube-system", "--timeout=120s"); err != nil {
+		warn("metrics-server rollout timed out — kubectl top may not work yet")
+		return nil
+	}
+	success("metrics-server ready")
+	return nil
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ── Local TLS ───────────────────────────────────────────────────
+//
+// kindling init --tls gives ingresses HTTPS certificates the host's
+// browsers already trust. mkcert creates a local CA and adds it to the
+// system and browser trust stores; cert-manager in the cluster signs
+// certificates with that same CA through the kindling-ca ClusterIssuer.
+// A wildcard certificate for the TLS domain becomes ingress-nginx's
+// default certificate, and the kindling-tls ConfigMap tells generators
+// and the deploy action to add a tls section to the ingresses they write.
+
+const (
+	// localCAIssuer is the ClusterIssuer that signs with the mkcert CA.
+	localCAIssuer = "kindling-ca"
+	// localTLSConfigMap records the issuer and domain in the default
+	// namespace, next to kindling-tunnel, for the deploy action to read.
+	localTLSConfigMap = "kindling-tls"
+	// defaultTLSDomain resolves to 127.0.0.1 for every subdomain, and,
+	// unlike *.localhost, can carry a wildcard certificate browsers accept.
+	defaultTLSDomain = "localtest.me"
+	// ingressDefaultCert is the wildcard certificate ingress-nginx serves
+	// for hosts whose Ingress has no tls section.
+	ingressDefaultCert = "kindling-default-tls"
+)
+
+// setupLocalTLS loads the mkcert CA into the cluster's cert-manager and
+// issues the wildcard certificate for domain. Every step is idempotent,
+// so re-running init is safe.
+func setupLocalTLS(domain, ingress string) error {
+	header("Local TLS")
+
+	if !commandExists("mkcert") {
+		return fmt.Errorf("mkcert is not installed — brew install mkcert (or see https://github.com/FiloSottile/mkcert#installation)")
+	}
+	step("🔐", "mkcert -install (adds the local CA to your trust stores)")
+	if err := run("mkcert", "-install"); err != nil {
+		return fmt.Errorf("mkcert -install failed: %w", err)
+	}
+	caRoot, err := runCapture("mkcert", "-CAROOT")
+	if err != nil || caRoot == "" {
+		return fmt.Errorf("cannot find the mkcert CA: %v", err)
+	}
+	caCert, caKey := filepath.Join(caRoot, "rootCA.pem"), filepath.Join(caRoot, "rootCA-key.pem")
+	for _, f := range []string{caCert, caKey} {
+		if _, err := os.Stat(f); err != nil {
+			return fmt.Errorf("mkcert CA file missing: %w", err)
+		}
+	}
+
+	step("🔑", fmt.Sprintf("Loading the mkcert CA as ClusterIssuer %s", localCAIssuer))
+	secret, err := runCapture("kubectl", "create", "secret", "tls", localCAIssuer, "-n", "cert-manager",
+		"--cert="+caCert, "--key="+caKey, "--dry-run=client", "-o", "yaml")
+	if err != nil {
+		return fmt.Errorf("cannot build the CA secret: %w", err)
+	}
+	if err := runStdin(secret, "kubectl", "apply", "-f", "-"); err != nil {
+		return fmt.Errorf("cannot store the CA secret: %w", err)
+	}
+	issuer := fmt.Sprintf(`apiVersion: cert-manager.io/v1
+kind: ClusterIssuer
+metadata:
+  name: %[1]s
+spec:
+  ca:
+    secretName: %[1]s
+`, localCAIssuer)
+	if err := applyWhenWebhookReady(issuer); err != nil {
+		return fmt.Errorf("cannot create ClusterIssuer %s: %w", localCAIssuer, err)
+	}
+
+	if ingress == "nginx" {
+		if err := setIngressDefaultCert(domain); err != nil {
+			return err
+		}
+	} else {
+		warn(fmt.Sprintf("ingress %s has no default certificate — only ingresses with a tls section get HTTPS", ingress))
+	}
+
+	step("📝", fmt.Sprintf("Recording the TLS settings in ConfigMap %s", localTLSConfigMap))
+	if err := writeConfigMap("default", localTLSConfigMap, nil,
+		map[string]string{"issuer": localCAIssuer, "domain": domain}, nil); err != nil {
+		return fmt.Errorf("cannot create ConfigMap %s: %w", localTLSConfigMap, err)
+	}
+
+	success(fmt.Sprintf("HTTPS ready for *.%s", domain))
+	return nil
+}
+
+// setIngressDefaultCert issues a wildcard certificate for domain and makes
+// it ingress-nginx's default, so every host under domain gets HTTPS even
+// when its Ingress has no tls section.
+func setIngressDefaultCert(domain string) error {
+	step("📜", fmt.Sprintf("Issuing *.%s as the ingress-nginx default certificate", domain))
+	cert := fmt.Sprintf(`apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: %[1]s
+  namespace: ingress-nginx
+spec:
+  secretName: %[1]s
+  dnsNames:
+    - "*.%[2]s"
+    - "%[2]s"
+  issuerRef:
+    kind: ClusterIssuer
+    name: %[3]s
+`, ingressDefaultCert, domain, localCAIssuer)
+	if err := applyWhenWebhookReady(cert); err != nil {
+		return fmt.Errorf("cannot create Certificate %s: %w", ingressDefaultCert, err)
+	}
+	if err := run("kubectl", "wait", "--for=condition=Ready", "certificate/"+ingressDefaultCert,
+		"-n", "ingress-nginx", "--timeout=60s"); err != nil {
+		return fmt.Errorf("certificate %s was not issued: %w", ingressDefaultCert, err)
+	}
+
+	// Re-running init must not add the arg twice.
+	arg := "--default-ssl-certificate=ingress-nginx/" + ingressDefaultCert
+	args, _ := runCapture("kubectl", "get", "deployment", "ingress-nginx-controller", "-n", "ingress-nginx",
+		"-o", "jsonpath={.spec.template.spec.containers[0].args}")
+	if strings.Contains(args, arg) {
+		return nil
+	}
+	patch := fmt.Sprintf(`[{"op":"add","path":"/spec/template/spec/containers/0/args/-","value":%q}]`, arg)
+	if err := run("kubectl", "patch", "deployment", "ingress-nginx-controller", "-n", "ingress-nginx",
+		"--type", "json", "-p", patch); err != nil {
+		return fmt.Errorf("cannot set the ingress-nginx default certificate: %w", err)
+	}
+	if err := run("kubectl", "rollout", "status", "deployment/ingress-nginx-controller",
+		"-n", "ingress-nginx", "--timeout=120s"); err != nil {
+		warn("ingress-nginx rollout timed out — HTTPS may take a moment to come up")
+	}
+	return nil
+}
+
+// localTLS is the cluster's local TLS setup, read from the kindling-tls
+// ConfigMap.
+type localTLS struct {
+	Issuer string `json:"issuer"`
+	Domain string `json:"domain"`
+}
+
+// localTLSConfig returns the cluster's local TLS setup, if init --tls ran.
+func localTLSConfig() (localTLS, bool) {
+	data, err := readConfigMap("default", localTLSConfigMap)
+	if err != nil || data["issuer"] == "" || data["domain"] == "" {
+		return localTLS{}, false
+	}
+	return localTLS{Issuer: data["issuer"], Domain: data["domain"]}, true
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jeffvincent/kindling/cli/pkg/kind"
+)
+
+// ── Kind backend ────────────────────────────────────────────────
+//
+// Clusters are created, listed, and deleted, and images loaded into their
+// nodes, through the Kind library rather than the kind binary, which
+// kindling doesn't need. The container runtime is picked the way kind
+// picks it, honouring KIND_EXPERIMENTAL_PROVIDER.
+
+// kindProvider is the default ClusterProvider.
+type kindProvider struct{}
+
+func (kindProvider) Name() string                  { return "kind" }
+func (kindProvider) Tools() []string               { return nil }
+func (kindProvider) Context(cluster string) string { return "kind-" + cluster }
+func (kindProvider) Network(cluster string) string { return "kind" }
+func (kindProvider) CertsDir() string              { return "/etc/containerd/certs.d" }
+
+func (kindProvider) Exists(cluster string) bool {
+	return kind.New(nil).Exists(cluster)
+}
+
+// Create builds the Kind config from kind-config.yaml and the profile,
+// printing each phase of creation as a step.
+func (kindProvider) Create(cluster string, spec clusterSpec) error {
+	cfg, err := kindConfigForProfile(spec.ConfigPath, spec.ProjectDir, spec.Profile)
+	if err != nil {
+		return fmt.Errorf("cannot build Kind config for profile %s: %w", spec.Profile.Profile, err)
+	}
+	step("🔧", fmt.Sprintf("Creating Kind cluster %s with %d node(s) (config: .kindling/kind-config.yaml)", cluster, len(cfg.Nodes)))
+	return kind.New(printKindProgress).Create(cluster, cfg, kind.CreateOptions{
+		NodeImage:  spec.NodeImage,
+		Kubeconfig: kubeconfigPath,
+		Wait:       spec.Wait,
+		Retain:     spec.Retain,
+	})
+}
+
+func (kindProvider) Delete(cluster string) error {
+	return kind.New(nil).Delete(cluster, kubeconfigPath)
+}
+
+func (kindProvider) Nodes(cluster string) ([]string, error) {
+	return kind.New(nil).Nodes(cluster)
+}
+
+func (kindProvider) LoadImage(cluster, image string) error {
+	return kind.New(nil).LoadImage(cluster, image)
+}
+
+func printKindProgress(p kind.Progress) {
+	switch p.State {
+	case kind.Started:
+		step("•", p.Message+" …")
+	case kind.Done:
+		step("✓", p.Message)
+	case kind.Failed:
+		fail(p.Message)
+	case kind.Warning:
+		warn(p.Message)
+	}
+}
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/jeffvincent/kindling/cli/pkg/kube"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ── Cluster connection ──────────────────────────────────────────
+//
+// Deploy, status, logs, and ConfigMap operations go through the built-in
+// client, so they work without kubectl and always reach the --context
+// cluster. --kubectl (or KINDLING_KUBECTL=1) sends them through the kubectl
+// binary instead; every other command still runs kubectl, with the same
+// --kubeconfig and --context.
+
+// kubeContextName returns the kubeconfig context commands target: the
+// cluster backend's context unless --context names another.
+func kubeContextName() string {
+	if kubeContext != "" {
+		return kubeContext
+	}
+	return clusterProvider().Context(clusterName)
+}
+
+var (
+	kubeOnce    sync.Once
+	kubeConn    *kube.Client
+	kubeConnErr error
+)
+
+// kubeClient returns the client of the --context cluster, connecting on
+// first use.
+func kubeClient() (*kube.Client, error) {
+	kubeOnce.Do(func() {
+		kubeConn, kubeConnErr = kube.New(kubeconfigPath, kubeContextName(), kubeNamespace, logger)
+		if kubeConnErr != nil {
+			kubeConnErr = fmt.Errorf("%w — pass --context, or --kubectl to use the kubectl binary", kubeConnErr)
+		}
+	})
+	return kubeConn, kubeConnErr
+}
+
+// ── Listing ─────────────────────────────────────────────────────
+
+// allNamespaces makes listObjects list across every namespace.
+const allNamespaces = "*"
+
+// listObjects returns resource as kubectl get -o json prints it: a List
+// of the objects in namespace ("" is the context's namespace,
+// allNamespaces every one) matching the label selector.
+func listObjects(resource, namespace, selector string) ([]byte, error) {
+	if useKubectl {
+		args := []string{"get", resource, "-o", "json"}
+		switch namespace {
+		case allNamespaces:
+			args = append(args, "-A")
+		case "":
+		default:
+			args = append(args, "-n", namespace)
+		}
+		if selector != "" {
+			args = append(args, "-l", selector)
+		}
+		out, err := kubectlJSON(args...)
+		if err != nil {
+			return nil, fmt.Errorf("kubectl get %s failed", resource)
+		}
+		return []byte(out), nil
+	}
+	c, err := kubeClient()
+	if err != nil {
+		return nil, err
+	}
+	switch namespace {
+	case allNamespaces:
+		namespace = ""
+	case "":
+		namespace = c.Namespace()
+	}
+	list, err := c.List(context.Background(), resource, namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	return list.MarshalJSON()
+}
+
+// errNotFound is returned by getObject when the object doesn't exist.
+var errNotFound = errors.New("not found")
+
+// getObject returns one object of resource, decoded from its JSON. The
+// namespace is ignored for cluster-scoped resources.
+func getObject(resource, namespace, name string) (map[string]interface{}, error) {
+	if useKubectl {
+		out, err := captureKubectl(withNamespace(namespace, "get", resource, name, "-o", "json")...)
+		if err != nil {
+			if strings.Contains(out, "NotFound") {
+				return nil, errNotFound
+			}
+			return nil, fmt.Errorf("%s", strings.TrimSpace(out))
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &obj); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}
+	c, err := kubeClient()
+	if err != nil {
+		return nil, err
+	}
+	obj, err := c.Get(context.Background(), resource, namespace, name)
+	if kube.IsNotFound(err) {
+		return nil, errNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return obj.Object, nil
+}
+
+// ── Apply ───────────────────────────────────────────────────────
+
+// applyManifestFile applies every object of file — into namespace, ""
+// being the context's — and returns the objects as the server stored
+// them. dryRun only has the server validate and default them.
+func applyManifestFile(file, namespace string, dryRun bool) ([]map[string]interface{}, error) {
+	if useKubectl {
+		args := withNamespace(namespace, "apply", "-f", file, "-o", "json")
+		if dryRun {
+			args = append(args, "--dry-run=server")
+		}
+		out, err := captureKubectl(args...)
+		if err != nil {
+			return nil, fmt.Errorf("kubectl apply failed: %s", out)
+		}
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			return nil, fmt.Errorf("cannot parse kubectl apply output: %w", err)
+		}
+		if items, ok := result["items"].([]interface{}); ok {
+			objects := []map[string]interface{}{}
+			for _, item := range items {
+				if obj, ok := item.(map[string]interface{}); ok {
+					objects = append(objects, obj)
+				}
+			}
+			return objects, nil
+		}
+		return []map[string]interface{}{result}, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	c, err := kubeClient()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := c.Apply(context.Background(), data, kube.ApplyOptions{Namespace: namespace, DryRun: dryRun})
+	objects := make([]map[string]interface{}, 0, len(applied))
+	for _, obj := range applied {
+		objects = append(objects, obj.Object)
+	}
+	return objects, err
+}
+
+// objectName returns the kind.group/name of a decoded object, the way
+// kubectl apply -o name prints it.
+func objectName(obj map[string]interface{}) string {
+	return kube.ObjectName(&unstructured.Unstructured{Object: obj})
+}
+
+// ── ConfigMaps ──────────────────────────────────────────────────
+
+// readConfigMap returns the data of a ConfigMap.
+func readConfigMap(namespace, name string) (map[string]string, error) {
+	if useKubectl {
+		out, err := kubectlJSON("get", "configmap", name, "-n", namespace, "-o", "jsonpath={.data}")
+		if err != nil {
+			return nil, fmt.Errorf("configmap %s/%s not found", namespace, name)
+		}
+		data := map[string]string{}
+		if out != "" {
+			if err := json.Unmarshal([]byte(out), &data); err != nil {
+				return nil, err
+			}
+		}
+		return data, nil
+	}
+	c, err := kubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return c.ConfigMap(context.Background(), namespace, name)
+}
+
+// writeConfigMap creates or replaces a ConfigMap so it holds exactly data
+// and binaryData.
+func writeConfigMap(namespace, name string, labels, data map[string]string, binaryData map[string][]byte) error {
+	if useKubectl {
+		cm := map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": name, "namespace": namespace, "labels": labels},
+			"data":       data,
+		}
+		if len(binaryData) > 0 {
+			encoded := map[string]string{}
+			for k, v := range binaryData {
+				encoded[k] = base64.StdEncoding.EncodeToString(v)
+			}
+			cm["binaryData"] = encoded
+		}
+		manifest, err := yaml.Marshal(cm)
+		if err != nil {
+			return err
+		}
+		if out, err := runSilentStdin(string(manifest), "kubectl", "--context", kubeContextName(), "apply", "-f", "-"); err != nil {
+			return fmt.Errorf("%s", out)
+		}
+		return nil
+	}
+	c, err := kubeClient()
+	if err != nil {
+		return err
+	}
+	return c.ApplyConfigMap(context.Background(), namespace, name, labels, data, binaryData)
+}
+
+// deleteConfigMap deletes a ConfigMap. A missing one is not an error.
+func deleteConfigMap(namespace, name string) error {
+	if useKubectl {
+		if out, err := captureKubectl("delete", "configmap", name, "-n", namespace, "--ignore-not-found"); err != nil {
+			return fmt.Errorf("%s", out)
+		}
+		return nil
+	}
+	c, err := kubeClient()
+	if err != nil {
+		return err
+	}
+	return c.DeleteConfigMap(context.Background(), namespace, name)
+}
+
+// ── Logs ────────────────────────────────────────────────────────
+
+// streamPodLogs calls emit with each log line of a pod in namespace (""
+// is the context's), until the log ends or, with opts.Follow, ctx is done.
+func streamPodLogs(ctx context.Context, namespace, pod string, opts kube.LogOptions, emit func(line string)) error {
+	if useKubectl {
+		args := withNamespace(namespace, append([]string{"logs", pod}, kubectlLogFlags(opts)...)...)
+		c := exec.CommandContext(ctx, "kubectl", withKubeContext("kubectl", args)...)
+		out, err := c.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		c.Stderr = c.Stdout
+		if err := startCommand(c); err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(out)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			emit(scanner.Text())
+		}
+		if err := c.Wait(); err != nil && ctx.Err() == nil {
+			return err
+		}
+		return nil
+	}
+	c, err := kubeClient()
+	if err != nil {
+		return err
+	}
+	return c.PodLogs(ctx, namespace, pod, opts, emit)
+}
+
+// kubectlLogFlags turns opts into kubectl logs flags.
+func kubectlLogFlags(opts kube.LogOptions) []string {
+	var args []string
+	switch {
+	case opts.AllContainers:
+		args = append(args, "--all-containers=true")
+	case opts.Container != "":
+		args = append(args, "-c", opts.Container)
+	}
+	if opts.Since > 0 {
+		args = append(args, "--since="+opts.Since.String())
+	}
+	if opts.Tail > 0 {
+		args = append(args, fmt.Sprintf("--tail=%d", opts.Tail))
+	}
+	if opts.Previous {
+		args = append(args, "--previous")
+	}
+	if opts.Follow {
+		args = append(args, "-f")
+	}
+	return args
+}
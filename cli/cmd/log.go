@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jeffvincent/kindling/cli/internal/daemon"
+	"github.com/jeffvincent/kindling/cli/internal/logging"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ── Debug log ───────────────────────────────────────────────────
+//
+// Behind the emoji output, every run records what it did — the commands
+// it ran, the API requests it made, and how each ended — in
+// .kindling/logs/kindling.log (see internal/logging). --verbose prints
+// the same records to stderr as they happen. kindling debug bundle
+// collects the logs for a bug report; nothing is ever sent anywhere.
+
+var (
+	// verbose prints the debug log to stderr as well.
+	verbose bool
+
+	// logger is the debug log. It discards everything until setupLogging.
+	logger = slog.New(logging.Fanout())
+
+	logFile  *os.File
+	runStart = time.Now()
+)
+
+// setupLogging opens the project's log and, with --verbose, adds stderr.
+// A log that can't be opened is not an error: the command runs without it.
+func setupLogging(cmd *cobra.Command) {
+	if name := cmd.Name(); name == cobra.ShellCompRequestCmd || name == cobra.ShellCompNoDescRequestCmd {
+		return
+	}
+	var handlers []slog.Handler
+	if verbose {
+		handlers = append(handlers, slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+	}
+	cwd, err := os.Getwd()
+	if err == nil {
+		logFile, err = logging.Open(logging.Dir(cwd))
+	}
+	if err == nil {
+		handlers = append(handlers, slog.NewTextHandler(logFile, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	logger = slog.New(logging.Fanout(handlers...))
+	if err != nil {
+		logger.Warn("no log file", "error", err)
+	}
+	// Positional arguments can be secret values (secrets set NAME VALUE),
+	// so only the command and its flags are logged.
+	var flags []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		flags = append(flags, "--"+f.Name+"="+f.Value.String())
+	})
+	logger.Info("run",
+		"command", cmd.CommandPath(),
+		"flags", strings.Join(logging.Redact(flags), " "),
+		"version", Version,
+		"platform", runtime.GOOS+"/"+runtime.GOARCH,
+		"cluster", clusterName,
+		"context", kubeContext,
+		"pid", os.Getpid())
+}
+
+// closeLogging records how the run ended and closes the log.
+func closeLogging(err error) {
+	elapsed := time.Since(runStart).Round(time.Millisecond)
+	if err != nil {
+		logger.Error("run failed", "error", err, "duration", elapsed)
+	} else {
+		logger.Info("run done", "duration", elapsed)
+	}
+	if logFile != nil {
+		_ = logFile.Close()
+	}
+}
+
+// buildLogsDir holds the output of each service's last image build, under
+// the logs directory.
+const buildLogsDir = "builds"
+
+// saveBuildLog keeps the output of service's image build for kindling
+// bundle, replacing the previous one.
+func saveBuildLog(service, output string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	dir := filepath.Join(logging.Dir(cwd), buildLogsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(dir, service+".log"), []byte(output+"\n"), 0o600)
+}
+
+// logCommand logs c as it is about to run and returns the function that
+// logs how it ended.
+func logCommand(c *exec.Cmd) func(error) {
+	start := time.Now()
+	attrs := []any{"cmd", strings.Join(logging.Redact(c.Args), " ")}
+	if c.Dir != "" {
+		attrs = append(attrs, "dir", c.Dir)
+	}
+	logger.Debug("exec", attrs...)
+	return func(err error) {
+		attrs = append(attrs, "duration", time.Since(start).Round(time.Millisecond))
+		var exit *exec.ExitError
+		switch {
+		case errors.As(err, &exit):
+			logger.Debug("exec failed", append(attrs, "exit", exit.ExitCode())...)
+		case err != nil:
+			logger.Debug("exec failed", append(attrs, "error", err)...)
+		default:
+			logger.Debug("exec done", attrs...)
+		}
+	}
+}
+
+// runCommand runs c, logging it.
+func runCommand(c *exec.Cmd) error {
+	done := logCommand(c)
+	err := c.Run()
+	done(err)
+	return err
+}
+
+// commandOutput runs c and returns its stdout, logging it.
+func commandOutput(c *exec.Cmd) ([]byte, error) {
+	done := logCommand(c)
+	out, err := c.Output()
+	done(err)
+	return out, err
+}
+
+// combinedOutput runs c and returns its stdout and stderr, logging it.
+func combinedOutput(c *exec.Cmd) ([]byte, error) {
+	done := logCommand(c)
+	out, err := c.CombinedOutput()
+	done(err)
+	return out, err
+}
+
+// startCommand starts c in the background, logging it.
+func startCommand(c *exec.Cmd) error {
+	logger.Debug("start", "cmd", strings.Join(logging.Redact(c.Args), " "))
+	err := c.Start()
+	if err != nil {
+		logger.Debug("start failed", "cmd", c.Path, "error", err)
+	}
+	return err
+}
+
+// startDaemon starts c as the background process d, logging it.
+func startDaemon(registry *daemon.Registry, d daemon.Daemon, c *exec.Cmd) (*daemon.Daemon, error) {
+	logger.Debug("start", "daemon", d.Name, "cmd", strings.Join(logging.Redact(c.Args), " "))
+	started, err := registry.Start(d, c)
+	if err != nil {
+		logger.Debug("start failed", "daemon", d.Name, "error", err)
+		return nil, err
+	}
+	logger.Debug("started", "daemon", d.Name, "pid", started.PID)
+	return started, nil
+}
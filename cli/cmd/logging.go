@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+)
+
+// ── Log aggregation ─────────────────────────────────────────────
+//
+// kindling init --logging installs Loki, in single-binary mode on the
+// node's disk, and promtail, which ships every pod's logs to it labelled
+// with namespace, pod, container, and app (app.kubernetes.io/name — the
+// component). kindling logs --all-components queries it.
+
+const (
+	lokiRelease      = "loki"
+	promtailRelease  = "promtail"
+	grafanaChartRepo = "https://grafana.github.io/helm-charts"
+	lokiPort         = 3100
+)
+
+// lokiValues run Loki as one replica with filesystem storage, no caches or
+// gateway, keeping three days of logs.
+const lokiValues = `deploymentMode: SingleBinary
+loki:
+  auth_enabled: false
+  commonConfig:
+    replication_factor: 1
+  storage:
+    type: filesystem
+  schemaConfig:
+    configs:
+      - from: "2024-04-01"
+        store: tsdb
+        object_store: filesystem
+        schema: v13
+        index:
+          prefix: index_
+          period: 24h
+  limits_config:
+    retention_period: 72h
+  compactor:
+    retention_enabled: true
+    delete_request_store: filesystem
+singleBinary:
+  replicas: 1
+  persistence:
+    size: 5Gi
+backend:
+  replicas: 0
+read:
+  replicas: 0
+write:
+  replicas: 0
+gateway:
+  enabled: false
+chunksCache:
+  enabled: false
+resultsCache:
+  enabled: false
+lokiCanary:
+  enabled: false
+test:
+  enabled: false
+`
+
+// lokiDatasource adds Loki to the Grafana of kindling init --observability,
+// whose sidecar loads datasources from labelled ConfigMaps.
+var lokiDatasource = fmt.Sprintf(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kindling-loki-datasource
+  namespace: %[1]s
+  labels:
+    grafana_datasource: "1"
+data:
+  loki.yaml: |
+    apiVersion: 1
+    datasources:
+      - name: Loki
+        type: loki
+        uid: loki
+        access: proxy
+        url: http://%[2]s.%[1]s:%[3]d
+`, observabilityNamespace, lokiRelease, lokiPort)
+
+// installLogging installs or upgrades Loki and promtail into the
+// monitoring namespace.
+func installLogging(profile clusterProfile) error {
+	step("🪵", "Installing Loki and promtail")
+	values, err := os.CreateTemp("", "kindling-loki-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(values.Name())
+	if _, err := values.WriteString(lokiValues); err != nil {
+		values.Close()
+		return err
+	}
+	values.Close()
+
+	if err := run("helm", "upgrade", "--install", lokiRelease, "loki",
+		"--repo", grafanaChartRepo,
+		"-n", observabilityNamespace, "--create-namespace",
+		"--kube-context", kubeContextName(),
+		"--wait", "--timeout", "10m",
+		"-f", values.Name(),
+	); err != nil {
+		return fmt.Errorf("Loki install failed: %w", err)
+	}
+	pushURL := fmt.Sprintf("http://%s.%s:%d/loki/api/v1/push", lokiRelease, observabilityNamespace, lokiPort)
+	if err := run("helm", "upgrade", "--install", promtailRelease, "promtail",
+		"--repo", grafanaChartRepo,
+		"-n", observabilityNamespace,
+		"--kube-context", kubeContextName(),
+		"--wait", "--timeout", "5m",
+		"--set", "config.clients[0].url="+pushURL,
+	); err != nil {
+		return fmt.Errorf("promtail install failed: %w", err)
+	}
+	if profile.Observability {
+		if out, err := runSilentStdin(lokiDatasource, "kubectl", "apply", "-f", "-"); err != nil {
+			return fmt.Errorf("adding Loki to Grafana failed: %s", out)
+		}
+	}
+	success("Logs of every pod are collected in Loki")
+	return nil
+}
+
+// lokiInstalled reports whether kindling init --logging set up Loki.
+func lokiInstalled() bool {
+	_, err := kubectlJSON("get", "service", lokiRelease, "-n", observabilityNamespace, "-o", "name")
+	return err == nil
+}
@@ -1,53 +1,166 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
 
+	"github.com/jeffvincent/kindling/cli/pkg/kube"
 	"github.com/spf13/cobra"
 )
 
 var logsCmd = &cobra.Command{
-	Use:   "logs",
-	Short: "Tail the kindling controller logs",
-	Long: `Streams logs from the kindling controller-manager pod. Press Ctrl+C to stop.
+	Use:   "logs [component]",
+	Short: "Tail the kindling controller or an environment component's logs",
+	Long: `Without arguments, streams logs from the kindling controller-manager pod.
 
-Use --all to see logs from all containers in the pod (including kube-rbac-proxy).`,
-	RunE: runLogs,
+With a component — a DevStagingEnvironment name (its app), a dependency
+type such as postgres, or a full name such as orders-dev-postgres — streams
+the logs of every replica of that component, each line prefixed with its
+pod in its own colour. --env narrows the lookup to one environment; --env
+alone streams every component of that environment.
+
+--all-components reads an environment's logs from the Loki that
+kindling init --logging installs instead: every component's lines between
+--since and --until, oldest first, including those of pods that are gone.
+--grep keeps only the lines matching a regular expression.
+
+Use --all to see logs from all containers in the pod (including kube-rbac-proxy).
+Use --no-follow to print the current logs and exit; this is required with
+--output json, which emits the log lines as a JSON array.
+
+Examples:
+  kindling logs                          # controller
+  kindling logs orders-dev               # the orders-dev app, all replicas
+  kindling logs postgres --env orders-dev
+  kindling logs --env orders-dev         # every component of orders-dev
+  kindling logs orders-dev --previous    # the crashed container's last run
+  kindling logs --env orders-dev --all-components --since 1h --grep 'timeout|5\d\d'`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: firstArg(completeComponents),
+	RunE:              runLogs,
 }
 
 var (
-	logsAll    bool
-	logsSince  string
-	logsFollow bool
+	logsAll       bool
+	logsSince     string
+	logsFollow    bool
+	logsNoFollow  bool
+	logsEnv       string
+	logsPrevious  bool
+	logsContainer string
+	logsAllComps  bool
+	logsGrep      string
+	logsUntil     string
 )
 
 func init() {
 	logsCmd.Flags().BoolVar(&logsAll, "all", false, "Show logs from all containers")
 	logsCmd.Flags().StringVar(&logsSince, "since", "5m", "Show logs since duration (e.g. 5m, 1h)")
 	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", true, "Follow log output (stream)")
+	logsCmd.Flags().BoolVar(&logsNoFollow, "no-follow", false, "Print current logs and exit instead of streaming")
+	logsCmd.Flags().StringVar(&logsEnv, "env", "", "DevStagingEnvironment to resolve the component in")
+	_ = logsCmd.RegisterFlagCompletionFunc("env", completeEnvFlag)
+	logsCmd.Flags().BoolVar(&logsPrevious, "previous", false, "Show logs from the previous (crashed) container instance")
+	logsCmd.Flags().StringVar(&logsContainer, "container", "", "Container name to show logs for")
+	logsCmd.Flags().BoolVar(&logsAllComps, "all-components", false, "Query every component's logs of --env from Loki (kindling init --logging)")
+	logsCmd.Flags().StringVar(&logsGrep, "grep", "", "With --all-components, only lines matching this regular expression")
+	logsCmd.Flags().StringVar(&logsUntil, "until", "", "With --all-components, only logs up to this long ago (e.g. 10m)")
 	rootCmd.AddCommand(logsCmd)
 }
 
 func runLogs(cmd *cobra.Command, args []string) error {
+	if logsAllComps {
+		if len(args) > 0 {
+			return fmt.Errorf("--all-components covers every component — drop %q, or use --grep", args[0])
+		}
+		return runAggregatedLogs()
+	}
+	if logsGrep != "" || logsUntil != "" {
+		return fmt.Errorf("--grep and --until need --all-components")
+	}
+	if logsNoFollow || logsPrevious {
+		logsFollow = false
+	}
+	if isJSONOutput() && logsFollow {
+		return fmt.Errorf("--output json requires --no-follow")
+	}
+	if len(args) > 0 || logsEnv != "" {
+		component := ""
+		if len(args) > 0 {
+			component = args[0]
+		}
+		return runComponentLogs(component)
+	}
+
 	header("Controller logs")
 
-	kubectlArgs := []string{
-		"logs",
-		"-n", "kindling-system",
-		"-l", "control-plane=controller-manager",
-		"--since=" + logsSince,
+	opts, err := logOptions()
+	if err != nil {
+		return err
 	}
-
-	if logsAll {
-		kubectlArgs = append(kubectlArgs, "--all-containers=true")
-	} else {
-		kubectlArgs = append(kubectlArgs, "-c", "manager")
+	if !logsAll && logsContainer == "" {
+		opts.Container = "manager"
 	}
-
 	if logsFollow {
-		kubectlArgs = append(kubectlArgs, "-f")
 		fmt.Printf("  %sStreaming (Ctrl+C to stop)...%s\n\n", colorDim, colorReset)
 	}
 
-	return run("kubectl", kubectlArgs...)
+	if isJSONOutput() {
+		var out bytes.Buffer
+		if err := controllerLogs(opts, &out); err != nil {
+			return fmt.Errorf("reading the controller logs failed: %w", err)
+		}
+		lines := []string{}
+		for _, line := range strings.Split(out.String(), "\n") {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return printJSON(struct {
+			Lines []string `json:"lines"`
+		}{lines})
+	}
+
+	return controllerLogs(opts, os.Stdout)
+}
+
+// logOptions turns the logs flags into log options.
+func logOptions() (kube.LogOptions, error) {
+	opts := kube.LogOptions{
+		Container:     logsContainer,
+		AllContainers: logsAll,
+		Follow:        logsFollow,
+		Previous:      logsPrevious,
+	}
+	// The previous instance may have died long before --since.
+	if !logsPrevious && logsSince != "" {
+		since, err := time.ParseDuration(logsSince)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --since %q: %w", logsSince, err)
+		}
+		opts.Since = since
+	}
+	return opts, nil
+}
+
+// controllerLogs writes the logs of the controller-manager pods to w.
+func controllerLogs(opts kube.LogOptions, w io.Writer) error {
+	const namespace, selector = "kindling-system", "control-plane=controller-manager"
+	if useKubectl {
+		c := exec.Command("kubectl", append([]string{"--context", kubeContextName(), "logs", "-n", namespace, "-l", selector},
+			kubectlLogFlags(opts)...)...)
+		c.Stdout, c.Stderr = w, os.Stderr
+		return runCommand(c)
+	}
+	c, err := kubeClient()
+	if err != nil {
+		return err
+	}
+	return c.Logs(context.Background(), namespace, selector, opts, w)
 }
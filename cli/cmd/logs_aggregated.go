@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ── Aggregated logs ─────────────────────────────────────────────
+//
+// kindling logs --all-components reads an environment's logs from the Loki
+// that kindling init --logging installs, rather than from the pods: the
+// lines of every component come back in one time-ordered list, can be
+// searched with --grep, and outlive the pods that wrote them.
+
+// lokiQueryLimit is the most lines one query returns; the newest are kept.
+const lokiQueryLimit = 5000
+
+// lokiStreams is the part of Loki's query_range reply kindling reads.
+type lokiStreams struct {
+	Data struct {
+		Result []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"` // [ns timestamp, line]
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// runAggregatedLogs prints the logs of every component of --env (or the
+// current environment) between --since and --until, optionally only the
+// lines matching --grep.
+func runAggregatedLogs() error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	env := logsEnv
+	if env == "" {
+		env = currentEnvironment()
+	}
+	if env == "" {
+		return fmt.Errorf("--all-components needs --env <name>")
+	}
+	if !lokiInstalled() {
+		return fmt.Errorf("no log aggregation in this cluster — run: kindling init --logging")
+	}
+	if logsGrep != "" {
+		if _, err := regexp.Compile(logsGrep); err != nil {
+			return fmt.Errorf("invalid --grep %q: %w", logsGrep, err)
+		}
+	}
+	end := time.Now()
+	if logsUntil != "" {
+		until, err := time.ParseDuration(logsUntil)
+		if err != nil {
+			return fmt.Errorf("invalid --until %q: %w", logsUntil, err)
+		}
+		end = end.Add(-until)
+	}
+	since, err := time.ParseDuration(logsSince)
+	if err != nil {
+		return fmt.Errorf("invalid --since %q: %w", logsSince, err)
+	}
+	start := time.Now().Add(-since)
+	if !start.Before(end) {
+		return fmt.Errorf("--since %s is not before --until %s", logsSince, logsUntil)
+	}
+
+	comps, err := resolveComponents(collectEnvironments(), "", env)
+	if err != nil {
+		return err
+	}
+	byNamespace := map[string][]string{}
+	for _, c := range comps {
+		byNamespace[c.namespace] = append(byNamespace[c.namespace], regexp.QuoteMeta(c.name))
+	}
+
+	entries := []logEntry{}
+	for ns, names := range byNamespace {
+		query := lokiQuery(ns, names, logsGrep)
+		found, err := queryLoki(query, start, end)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, found...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	truncated := len(entries) >= lokiQueryLimit
+	if len(entries) > lokiQueryLimit {
+		entries = entries[len(entries)-lokiQueryLimit:]
+	}
+
+	if isJSONOutput() {
+		return printJSON(struct {
+			Lines []logEntry `json:"lines"`
+		}{entries})
+	}
+
+	target := env
+	if logsGrep != "" {
+		target += fmt.Sprintf(" matching %q", logsGrep)
+	}
+	header(fmt.Sprintf("Logs: %s, all components (%d line(s))", target, len(entries)))
+	if len(entries) == 0 {
+		fmt.Printf("    %sNothing logged between %s and %s%s\n\n",
+			colorDim, start.Format("15:04:05"), end.Format("15:04:05"), colorReset)
+		return nil
+	}
+	if truncated {
+		warn(fmt.Sprintf("Only the newest %d lines are shown — narrow --since, --until, or --grep", lokiQueryLimit))
+	}
+	printAggregatedLogs(entries)
+	return nil
+}
+
+// lokiQuery selects the lines of the named components (regexp-quoted) in
+// namespace, promtail's app label being their app.kubernetes.io/name.
+func lokiQuery(namespace string, names []string, grep string) string {
+	q := fmt.Sprintf(`{namespace=%s, app=~%s}`, strconv.Quote(namespace), strconv.Quote(strings.Join(names, "|")))
+	if grep != "" {
+		q += " |~ " + strconv.Quote(grep)
+	}
+	return q
+}
+
+// queryLoki runs query over [start, end] through the API server's service
+// proxy, newest lines first so the limit drops the oldest.
+func queryLoki(query string, start, end time.Time) ([]logEntry, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	params.Set("end", strconv.FormatInt(end.UnixNano(), 10))
+	params.Set("limit", strconv.Itoa(lokiQueryLimit))
+	params.Set("direction", "backward")
+	path := fmt.Sprintf("/api/v1/namespaces/%s/services/%s:%d/proxy/loki/api/v1/query_range?%s",
+		observabilityNamespace, lokiRelease, lokiPort, params.Encode())
+	out, err := kubectlJSON("get", "--raw", path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot query Loki: %w", err)
+	}
+	var reply lokiStreams
+	if err := json.Unmarshal([]byte(out), &reply); err != nil {
+		return nil, fmt.Errorf("unexpected reply from Loki: %w", err)
+	}
+	var entries []logEntry
+	for _, stream := range reply.Data.Result {
+		for _, v := range stream.Values {
+			ns, err := strconv.ParseInt(v[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			entries = append(entries, logEntry{
+				Time:      time.Unix(0, ns).UTC(),
+				Component: stream.Stream["app"],
+				Pod:       stream.Stream["pod"],
+				Line:      strings.TrimRight(v[1], "\n"),
+			})
+		}
+	}
+	return entries, nil
+}
+
+// printAggregatedLogs prints each line behind its time and a pod prefix
+// coloured the same way as the live component logs.
+func printAggregatedLogs(entries []logEntry) {
+	var pods []string
+	seen := map[string]bool{}
+	width := 0
+	for _, e := range entries {
+		if !seen[e.Pod] {
+			seen[e.Pod] = true
+			pods = append(pods, e.Pod)
+			width = max(width, len(e.Pod))
+		}
+	}
+	sort.Strings(pods)
+	colors := map[string]string{}
+	for i, pod := range pods {
+		colors[pod] = logPrefixColors[i%len(logPrefixColors)]
+	}
+	for _, e := range entries {
+		fmt.Printf("%s %s%-*s │%s %s\n", dimText(e.Time.Local().Format("15:04:05.000")), colors[e.Pod], width, e.Pod, colorReset, e.Line)
+	}
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ── Component logs ──────────────────────────────────────────────
+//
+// kindling logs <component> resolves the component through the same
+// environment tree as kindling status, then streams the logs of each pod
+// and interleaves their lines behind a coloured pod prefix.
+
+// logPrefixColors are cycled through for per-source log prefixes.
+var logPrefixColors = []string{colorCyan, colorGreen, colorYellow, "\033[35m", "\033[34m", colorRed}
+
+// logSource is one pod whose logs are streamed.
+type logSource struct {
+	component string
+	namespace string
+	pod       string
+	prefix    string
+}
+
+// logEntry is one line of --output json component logs. Time is only set
+// for --all-components, which reads it from Loki.
+type logEntry struct {
+	Time      time.Time `json:"time,omitzero"`
+	Component string    `json:"component"`
+	Pod       string    `json:"pod"`
+	Line      string    `json:"line"`
+}
+
+func runComponentLogs(component string) error {
+	comps, err := resolveComponents(collectEnvironments(), component, logsEnv)
+	if err != nil {
+		return err
+	}
+	sources := logSources(comps)
+	if len(sources) == 0 {
+		return fmt.Errorf("no pods found for %s", describeLogTarget(component, logsEnv))
+	}
+
+	opts, err := logOptions()
+	if err != nil {
+		return err
+	}
+
+	if isJSONOutput() {
+		entries := []logEntry{}
+		for _, src := range sources {
+			err := streamPodLogs(context.Background(), src.namespace, src.pod, opts, func(line string) {
+				if line != "" {
+					entries = append(entries, logEntry{Component: src.component, Pod: src.pod, Line: line})
+				}
+			})
+			if err != nil {
+				return fmt.Errorf("reading the logs of %s failed: %w", src.pod, err)
+			}
+		}
+		return printJSON(struct {
+			Lines []logEntry `json:"lines"`
+		}{entries})
+	}
+
+	header(fmt.Sprintf("Logs: %s (%d pod(s))", describeLogTarget(component, logsEnv), len(sources)))
+	if logsFollow {
+		fmt.Printf("  %sStreaming (Ctrl+C to stop)...%s\n\n", colorDim, colorReset)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, src := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := streamPodLogs(ctx, src.namespace, src.pod, opts, func(line string) {
+				mu.Lock()
+				defer mu.Unlock()
+				fmt.Println(src.prefix + line)
+			})
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				fmt.Println(src.prefix + dimText(err.Error()))
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// logSources lists the pods of each component and assigns each a prefix.
+func logSources(comps []componentRef) []logSource {
+	var sources []logSource
+	width := 0
+	for _, c := range comps {
+		var pods []string
+		for _, row := range statusRows("pods", c.namespace, "app.kubernetes.io/name="+c.name, func(o map[string]interface{}) map[string]string {
+			return map[string]string{"name": statusField(o, "metadata", "name")}
+		}) {
+			pods = append(pods, row["name"])
+		}
+		sort.Strings(pods)
+		for _, pod := range pods {
+			sources = append(sources, logSource{component: c.name, namespace: c.namespace, pod: pod})
+			if len(pod) > width {
+				width = len(pod)
+			}
+		}
+	}
+	for i := range sources {
+		color := logPrefixColors[i%len(logPrefixColors)]
+		sources[i].prefix = fmt.Sprintf("%s%-*s │%s ", color, width, sources[i].pod, colorReset)
+	}
+	return sources
+}
+
+func describeLogTarget(component, env string) string {
+	switch {
+	case component == "":
+		return env
+	case env == "":
+		return component
+	default:
+		return component + " in " + env
+	}
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade v1alpha1 DevStagingEnvironment manifests to v1beta1",
+	Long: `Rewrites DevStagingEnvironment manifests from apps.example.com/v1alpha1
+to apps.example.com/v1beta1.
+
+v1beta1 replaces the flat cpuRequest/cpuLimit/memoryRequest/memoryLimit
+resource fields of the deployment and each dependency with Kubernetes-style
+requests and limits:
+
+  resources:                    resources:
+    cpuRequest: 100m              requests:
+    memoryLimit: 256Mi    →         cpu: 100m
+                                  limits:
+                                    memory: 256Mi
+
+Comments, key order, and other documents in the file are kept. The
+operator converts between the versions, so existing environments keep
+running whichever version their manifest uses.
+
+The migrated YAML is written to stdout unless --write is given.
+
+Examples:
+  kindling migrate -f dev-environment.yaml
+  kindling migrate -f dev-environment.yaml --write
+  kindling migrate -f api.yaml -f worker.yaml -w
+  cat dev-environment.yaml | kindling migrate -f -`,
+	SilenceUsage: true,
+	RunE:         runMigrate,
+}
+
+var (
+	migrateFiles []string
+	migrateWrite bool
+)
+
+func init() {
+	migrateCmd.Flags().StringArrayVarP(&migrateFiles, "file", "f", nil, "Manifest to migrate, or - for stdin (repeatable, required)")
+	migrateCmd.Flags().BoolVarP(&migrateWrite, "write", "w", false, "Rewrite the files in place instead of printing to stdout")
+	_ = migrateCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(migrateCmd)
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	printed := false
+	for _, file := range migrateFiles {
+		var data []byte
+		var err error
+		if file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", file, err)
+		}
+
+		out, migrated, err := migrateManifests(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		if migrateWrite && file != "-" {
+			if migrated == 0 {
+				step("⏭️ ", fmt.Sprintf("%s: nothing to migrate", file))
+				continue
+			}
+			if err := os.WriteFile(file, out, 0644); err != nil {
+				return fmt.Errorf("cannot write %s: %w", file, err)
+			}
+			success(fmt.Sprintf("%s: migrated %d DevStagingEnvironment(s) to v1beta1", file, migrated))
+			continue
+		}
+		if printed {
+			fmt.Println("---")
+		}
+		fmt.Print(string(out))
+		printed = true
+	}
+	return nil
+}
+
+// migrateManifests rewrites every v1alpha1 DevStagingEnvironment in data
+// to v1beta1 and returns the re-encoded documents and how many changed.
+// Working on yaml.Nodes rather than structs keeps comments and key order.
+func migrateManifests(data []byte) ([]byte, int, error) {
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid YAML: %w", err)
+		}
+		docs = append(docs, &doc)
+	}
+
+	migrated := 0
+	for _, doc := range docs {
+		if len(doc.Content) == 0 {
+			continue
+		}
+		root := doc.Content[0]
+		kind, apiVersion := devstaging.MappingValue(root, "kind"), devstaging.MappingValue(root, "apiVersion")
+		if kind == nil || kind.Value != "DevStagingEnvironment" ||
+			apiVersion == nil || apiVersion.Value != devstaging.APIVersionV1alpha1 {
+			continue
+		}
+		if err := migrateDSE(root); err != nil {
+			name := ""
+			if meta := devstaging.MappingValue(root, "metadata"); meta != nil {
+				if n := devstaging.MappingValue(meta, "name"); n != nil {
+					name = n.Value
+				}
+			}
+			return nil, 0, fmt.Errorf("DevStagingEnvironment %q: %w", name, err)
+		}
+		apiVersion.Value = devstaging.APIVersionV1beta1
+		migrated++
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), migrated, nil
+}
+
+// migrateDSE converts the resources of the deployment and of every
+// dependency in a v1alpha1 DevStagingEnvironment.
+func migrateDSE(root *yaml.Node) error {
+	spec := devstaging.MappingValue(root, "spec")
+	if spec == nil {
+		return nil
+	}
+	if deployment := devstaging.MappingValue(spec, "deployment"); deployment != nil {
+		if err := migrateResources(deployment, "spec.deployment"); err != nil {
+			return err
+		}
+	}
+	if deps := devstaging.MappingValue(spec, "dependencies"); deps != nil && deps.Kind == yaml.SequenceNode {
+		for i, dep := range deps.Content {
+			if err := migrateResources(dep, fmt.Sprintf("spec.dependencies[%d]", i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// migrateResources replaces the resources mapping of parent, if any, with
+// its requests/limits form. Quantity nodes are moved as they are, so
+// their comments follow them.
+func migrateResources(parent *yaml.Node, path string) error {
+	res := devstaging.MappingValue(parent, "resources")
+	if res == nil || res.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	lists := map[string]*yaml.Node{}
+	var order []string
+	for i := 0; i+1 < len(res.Content); i += 2 {
+		key, value := res.Content[i], res.Content[i+1]
+		field, ok := devstaging.V1alpha1ResourceFields[key.Value]
+		if !ok {
+			return fmt.Errorf("%s.resources.%s is not a v1alpha1 resource field", path, key.Value)
+		}
+		list, ok := lists[field[0]]
+		if !ok {
+			list = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			lists[field[0]] = list
+			order = append(order, field[0])
+		}
+		name := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: field[1],
+			HeadComment: key.HeadComment, LineComment: key.LineComment}
+		list.Content = append(list.Content, name, value)
+	}
+
+	res.Content = nil
+	for _, name := range order {
+		res.Content = append(res.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, lists[name])
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+// ── Project templates ───────────────────────────────────────────
+//
+// kindling new writes a runnable project from one of the templates under
+// templates/: source, Dockerfiles, and a dev-environment.yaml that
+// kindling dev deploys as is. Images are named after the project, or
+// <project>-<dir> for a service in a subdirectory, so the build contexts
+// resolve the way they do for any other repo.
+//
+// Template files are copied verbatim but for two placeholders,
+// {{name}} and {{ingressClass}}. Go files and go.mod are stored with a
+// .tmpl suffix, which is dropped on the way out, so the CLI's own build
+// neither compiles them nor treats a template as a module of its own.
+//
+// Templates of the repositories registered with kindling template repo
+// (template.go) are named <repo>/<template> and render the same way.
+
+//go:embed all:templates
+var templatesFS embed.FS
+
+// projectTemplate is one entry of the template catalog.
+type projectTemplate struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Dependencies []string `json:"dependencies"`       // provisioned by the operator
+	Repo         string   `json:"repo,omitempty"`     // "" for the built-in catalog
+	Versions     []string `json:"versions,omitempty"` // newest first
+}
+
+// projectTemplates is the catalog, in the order new --list shows it.
+var projectTemplates = []projectTemplate{
+	{
+		Name:         "go-postgres",
+		Description:  "Go JSON API over Postgres",
+		Dependencies: []string{"postgres"},
+	},
+	{
+		Name:         "fastapi-redis",
+		Description:  "Python FastAPI service backed by Redis",
+		Dependencies: []string{"redis"},
+	},
+	{
+		Name:         "react-node-bff",
+		Description:  "React frontend with a Node backend-for-frontend, on one host",
+		Dependencies: []string{},
+	},
+	{
+		Name:         "grpc-microservices",
+		Description:  "Go gRPC service and the HTTP gateway that calls it",
+		Dependencies: []string{},
+	},
+}
+
+var newCmd = &cobra.Command{
+	Use:   "new <name>",
+	Short: "Scaffold a runnable project from a template",
+	Long: `Creates the directory <name> with a small working app from the template
+catalog — its source, Dockerfiles, and a dev-environment.yaml — so a
+first environment runs without kindling generate:
+
+  go-postgres          Go JSON API over Postgres
+  fastapi-redis        Python FastAPI service backed by Redis
+  react-node-bff       React frontend with a Node backend-for-frontend
+  grpc-microservices   Go gRPC service and the HTTP gateway that calls it
+
+Templates of your organization's repositories, added with kindling
+template repo add, are <repo>/<template>, optionally @<version> (default:
+the newest). Their DevStagingEnvironments get the repository's labels,
+resource tier, and required sidecars.
+
+The name becomes the images', the DevStagingEnvironments', and the
+ingress host's (<name>.localhost), so it must be lowercase letters,
+digits, and dashes. The ingress class is the cluster's when one is
+running, nginx otherwise.
+
+Examples:
+  kindling new --template go-postgres myapp
+  kindling new --template react-node-bff storefront && cd storefront
+  kindling new --template acme/go-service@1.4 payments
+  kindling new --list`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if newList {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	SilenceUsage: true,
+	RunE:         runNew,
+}
+
+var (
+	newTemplate string
+	newList     bool
+	newForce    bool
+)
+
+func init() {
+	names := make([]string, 0, len(projectTemplates))
+	for _, t := range projectTemplates {
+		names = append(names, t.Name)
+	}
+	newCmd.Flags().StringVarP(&newTemplate, "template", "t", "", "Template to scaffold: "+strings.Join(names, ", ")+", or <repo>/<template>[@version] (required)")
+	_ = newCmd.RegisterFlagCompletionFunc("template", completeNewTemplates)
+	newCmd.Flags().BoolVar(&newList, "list", false, "List the templates")
+	newCmd.Flags().BoolVar(&newForce, "force", false, "Write into an existing directory, overwriting files of the same name")
+	rootCmd.AddCommand(newCmd)
+}
+
+// newResult is the JSON form of new's output.
+type newResult struct {
+	Template string   `json:"template"`
+	Version  string   `json:"version,omitempty"`
+	Dir      string   `json:"dir"`
+	Files    []string `json:"files"` // relative to dir
+}
+
+// findTemplate returns the catalog entry named name.
+func findTemplate(name string) (projectTemplate, error) {
+	for _, t := range projectTemplates {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	names := make([]string, 0, len(projectTemplates))
+	for _, t := range projectTemplates {
+		names = append(names, t.Name)
+	}
+	return projectTemplate{}, fmt.Errorf("unknown template %q — pick one of %s", name, strings.Join(names, ", "))
+}
+
+// renderTemplate writes the template at root in src into dir for a
+// project named name, and returns the paths written, relative to dir.
+func renderTemplate(src fs.FS, root, dir, name, ingressClass string) ([]string, error) {
+	replacer := strings.NewReplacer("{{name}}", name, "{{ingressClass}}", ingressClass)
+	var written []string
+	err := fs.WalkDir(src, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(src, p)
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimSuffix(strings.TrimPrefix(p, root+"/"), ".tmpl")
+		dest := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("cannot create %s: %w", filepath.Dir(dest), err)
+		}
+		if err := os.WriteFile(dest, []byte(replacer.Replace(string(data))), 0644); err != nil {
+			return fmt.Errorf("cannot write %s: %w", dest, err)
+		}
+		written = append(written, rel)
+		return nil
+	})
+	return written, err
+}
+
+func runNew(cmd *cobra.Command, args []string) error {
+	if newList {
+		templates := append(append([]projectTemplate{}, projectTemplates...), repoTemplates()...)
+		return render(templates, func() {
+			header("Templates")
+			for _, t := range templates {
+				deps := ""
+				if len(t.Dependencies) > 0 {
+					deps = dimText(" + " + strings.Join(t.Dependencies, ", "))
+				}
+				if len(t.Versions) > 0 {
+					deps += dimText(" (" + strings.Join(t.Versions, ", ") + ")")
+				}
+				fmt.Printf("    📦 %-20s %s%s\n", t.Name, t.Description, deps)
+			}
+			fmt.Printf("\n  Scaffold one with: %skindling new --template <template> <name>%s\n\n", colorCyan, colorReset)
+		})
+	}
+	if newTemplate == "" {
+		return fmt.Errorf("--template is required — see: kindling new --list")
+	}
+	name := args[0]
+	if source.DNSLabel(name) != name {
+		return fmt.Errorf("%q can't name the images and DevStagingEnvironments — use lowercase letters, digits, and dashes, e.g. %s", name, source.DNSLabel(name))
+	}
+	dir, err := filepath.Abs(name)
+	if err != nil {
+		return err
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 && !newForce {
+		return fmt.Errorf("%s already exists and isn't empty — pick another name, or pass --force to write into it", dir)
+	}
+
+	// A repository's template is fetched, if it hasn't been, before
+	// anything is written.
+	var src fs.FS = templatesFS
+	var rt *repoTemplate
+	result := newResult{Template: newTemplate, Dir: dir}
+	root := ""
+	if strings.Contains(newTemplate, "/") {
+		if rt, err = findRepoTemplate(newTemplate); err != nil {
+			return err
+		}
+		src, root = os.DirFS(rt.Dir), "."
+		result.Template = rt.Repo.Name + "/" + rt.Template.Name
+		result.Version = rt.Version
+	} else {
+		t, err := findTemplate(newTemplate)
+		if err != nil {
+			return err
+		}
+		root = path.Join("templates", t.Name)
+	}
+
+	ingressClass := defaultIngressClass
+	if clusterExists(clusterName) {
+		ingressClass = clusterIngressClass()
+	}
+
+	from := result.Template
+	if result.Version != "" {
+		from += "@" + result.Version
+	}
+	header(fmt.Sprintf("Scaffolding %s from %s", name, from))
+	if result.Files, err = renderTemplate(src, root, dir, name, ingressClass); err != nil {
+		return err
+	}
+	for _, f := range result.Files {
+		step("📄", f)
+	}
+	if rt != nil {
+		changed, err := applyConventions(dir, result.Files, rt)
+		if err != nil {
+			return err
+		}
+		for _, f := range changed {
+			step("🏷️ ", fmt.Sprintf("%s follows %s's conventions", f, rt.Repo.Name))
+		}
+	}
+
+	return render(result, func() {
+		success(fmt.Sprintf("Created %s", dir))
+		fmt.Println()
+		fmt.Println("  Next:")
+		fmt.Printf("    %scd %s%s\n", colorCyan, name, colorReset)
+		fmt.Printf("    %skindling dev -f dev-environment.yaml%s\n", colorCyan, colorReset)
+		fmt.Printf("\n  %sThen open http://%s.localhost — the README has the rest.%s\n\n", colorDim, name, colorReset)
+	})
+}
+
+// completeNewTemplates completes --template with the built-in templates
+// and those of the repositories already fetched.
+func completeNewTemplates(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	for _, t := range projectTemplates {
+		names = append(names, t.Name+"\t"+t.Description)
+	}
+	repos, _ := templateRepos()
+	for _, repo := range repos {
+		dir, err := templateRepoDir(repo)
+		if err != nil {
+			continue
+		}
+		catalog, err := loadTemplateCatalog(dir)
+		if err != nil {
+			continue
+		}
+		for _, t := range catalog.Templates {
+			names = append(names, repo.Name+"/"+t.Name+"\t"+t.Description)
+		}
+	}
+	return completions(names, nil), cobra.ShellCompDirectiveNoFileComp
+}
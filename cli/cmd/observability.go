@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ── Observability ───────────────────────────────────────────────
+//
+// kindling init --observability installs kube-prometheus-stack (Prometheus,
+// Grafana, kube-state-metrics, node-exporter) and points it at the
+// operator: config/observability adds the ServiceMonitor for the
+// controller's metrics and the bundled kindling dashboard.
+
+const (
+	observabilityNamespace  = "monitoring"
+	promStackRelease        = "kube-prometheus-stack"
+	promStackChartRepo      = "https://prometheus-community.github.io/helm-charts"
+	grafanaAdminPassword    = "kindling"
+	grafanaHostWithoutTLS   = "grafana.localhost"
+	grafanaPortForwardLocal = "3000"
+)
+
+// grafanaHost is the host Grafana's ingress answers on: under the
+// wildcard certificate's domain with --tls, else *.localhost.
+func grafanaHost(profile clusterProfile) string {
+	if profile.TLS {
+		return "grafana." + profile.tlsDomain()
+	}
+	return grafanaHostWithoutTLS
+}
+
+// installPrometheusStack installs or upgrades kube-prometheus-stack with
+// the pieces a Kind cluster can't scrape (etcd, scheduler, controller
+// manager, kube-proxy) and Alertmanager turned off. Prometheus picks up
+// every ServiceMonitor, and Grafana's sidecar every dashboard ConfigMap,
+// in any namespace.
+func installPrometheusStack(profile clusterProfile) error {
+	step("📊", "Installing kube-prometheus-stack (this takes a few minutes)")
+	args := []string{"upgrade", "--install", promStackRelease, "kube-prometheus-stack",
+		"--repo", promStackChartRepo,
+		"-n", observabilityNamespace, "--create-namespace",
+		"--kube-context", kubeContextName(),
+		"--wait", "--timeout", "10m",
+		"--set", "prometheus.prometheusSpec.serviceMonitorSelectorNilUsesHelmValues=false",
+		"--set", "prometheus.prometheusSpec.podMonitorSelectorNilUsesHelmValues=false",
+		"--set", "grafana.sidecar.dashboards.searchNamespace=ALL",
+		"--set", "grafana.adminPassword=" + grafanaAdminPassword,
+		"--set", "alertmanager.enabled=false",
+		"--set", "kubeEtcd.enabled=false",
+		"--set", "kubeControllerManager.enabled=false",
+		"--set", "kubeScheduler.enabled=false",
+		"--set", "kubeProxy.enabled=false",
+	}
+	if profile.Ingress != "none" {
+		args = append(args,
+			"--set", "grafana.ingress.enabled=true",
+			"--set", "grafana.ingress.ingressClassName="+profile.Ingress,
+			"--set", "grafana.ingress.hosts[0]="+grafanaHost(profile),
+		)
+	}
+	if err := run("helm", args...); err != nil {
+		return fmt.Errorf("kube-prometheus-stack install failed: %w", err)
+	}
+	success("Prometheus and Grafana ready")
+	return nil
+}
+
+// applyObservability applies config/observability: the operator's
+// ServiceMonitor and the kindling Grafana dashboard. It needs the
+// ServiceMonitor CRD, so it runs after installPrometheusStack.
+func applyObservability(kustomizeBin, dir string) error {
+	step("📈", "Adding the kindling ServiceMonitor and Grafana dashboard")
+	out, err := runCapture(kustomizeBin, "build", filepath.Join(dir, "config", "observability"))
+	if err != nil {
+		return fmt.Errorf("kustomize build config/observability failed: %w", err)
+	}
+	if out, err := runSilentStdin(out, "kubectl", "apply", "-f", "-"); err != nil {
+		return fmt.Errorf("applying config/observability failed: %s", out)
+	}
+	success("kindling metrics are scraped")
+	return nil
+}
+
+// printGrafanaAccess tells the user where Grafana is and how to log in.
+func printGrafanaAccess(profile clusterProfile) {
+	scheme := "http"
+	if profile.TLS {
+		scheme = "https"
+	}
+	fmt.Println("  Grafana (dashboard \"kindling\"):")
+	if profile.Ingress != "none" {
+		fmt.Printf("    %s%s://%s%s\n", colorCyan, scheme, grafanaHost(profile), colorReset)
+	} else {
+		fmt.Printf("    %skubectl port-forward -n %s svc/%s-grafana %s:80%s\n",
+			colorCyan, observabilityNamespace, promStackRelease, grafanaPortForwardLocal, colorReset)
+		fmt.Printf("    then http://localhost:%s\n", grafanaPortForwardLocal)
+	}
+	fmt.Printf("    user admin, password %s\n", grafanaAdminPassword)
+	fmt.Println()
+}
@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause <environment>",
+	Short: "Stop an environment's pods, keeping its data and definition",
+	Long: `Sets spec.paused on a DevStagingEnvironment — or on every one of an
+environment — and waits until the operator has scaled the app, its
+canary, and its dependencies to zero and suspended scheduled apps.
+
+Everything else stays: volume and database claims, Secrets, ConfigMaps,
+Services, and Ingresses. kindling resume scales the pods back up onto
+the same data, so an environment you aren't using costs disk, not CPU
+or memory. No Job or seed starts while paused.
+
+spec.paused survives kindling deploy: a paused environment stays paused
+until kindling resume, or paused: false in its manifest.
+
+Examples:
+  kindling pause orders-dev
+  kindling pause feature-checkout      # every DSE of an environment
+  kindling pause orders-dev --no-wait`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeEnvFlag),
+	RunE:              runPause,
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume <environment>",
+	Short: "Start a paused environment's pods again",
+	Long: `Clears spec.paused on a DevStagingEnvironment — or on every one of an
+environment — and waits until it is Ready again, with the data it had
+when it was paused.
+
+It also wakes one that spec.autoSleep put to sleep, as its next request
+would.
+
+Examples:
+  kindling resume orders-dev
+  kindling resume feature-checkout --timeout 10m`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeEnvFlag),
+	RunE:              runResume,
+}
+
+var (
+	pauseNoWait  bool
+	pauseTimeout time.Duration
+)
+
+func init() {
+	for _, c := range []*cobra.Command{pauseCmd, resumeCmd} {
+		c.Flags().BoolVar(&pauseNoWait, "no-wait", false, "Return once the DevStagingEnvironments are patched")
+		c.Flags().DurationVar(&pauseTimeout, "timeout", 5*time.Minute, "How long to wait for the pods to stop or be ready")
+		rootCmd.AddCommand(c)
+	}
+}
+
+// pauseResult is the JSON form of pause's and resume's output.
+type pauseResult struct {
+	Environments []string `json:"environments"` // <namespace>/<name> of each DSE patched
+	Unchanged    []string `json:"unchanged,omitempty"`
+	Pods         int      `json:"pods"`                  // stopped by pause
+	CPU          int64    `json:"cpuMillis,omitempty"`   // requested by the stopped pods
+	Memory       int64    `json:"memoryBytes,omitempty"` // requested by the stopped pods
+	Done         bool     `json:"done"`                  // stopped, or Ready again
+	Pending      []string `json:"pending,omitempty"`     // still running, or not Ready yet
+}
+
+// pauseTargets returns the DSEs arg names: one DSE, or every DSE of an
+// environment.
+func pauseTargets(envs []envStatus, arg string) ([]envStatus, error) {
+	var targets []envStatus
+	for _, e := range envs {
+		if e.Name == arg || e.Environment == arg {
+			targets = append(targets, e)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("DevStagingEnvironment or environment %q not found — see: kindling status, kindling env list", arg)
+	}
+	return targets, nil
+}
+
+// environmentPods counts the running pods of targets and what they
+// request.
+func environmentPods(targets []envStatus) (int, devstaging.Request) {
+	out, err := listObjects("pods", allNamespaces, operatorManagedBy)
+	if err != nil {
+		return 0, devstaging.Request{}
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Namespace string            `json:"namespace"`
+				Labels    map[string]string `json:"labels"`
+			} `json:"metadata"`
+			Spec struct {
+				Containers []struct {
+					Resources struct {
+						Requests map[string]string `json:"requests"`
+						Limits   map[string]string `json:"limits"`
+					} `json:"resources"`
+				} `json:"containers"`
+			} `json:"spec"`
+			Status struct {
+				Phase string `json:"phase"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if json.Unmarshal(out, &list) != nil {
+		return 0, devstaging.Request{}
+	}
+	n, total := 0, devstaging.Request{}
+	for _, p := range list.Items {
+		if p.Status.Phase == "Succeeded" || p.Status.Phase == "Failed" {
+			continue
+		}
+		l := p.Metadata.Labels
+		for _, t := range targets {
+			if p.Metadata.Namespace != t.Namespace ||
+				(l["app.kubernetes.io/instance"] != t.Name && l["app.kubernetes.io/part-of"] != t.Name &&
+					l["app.kubernetes.io/instance"] != t.Name+"-canary") {
+				continue
+			}
+			n++
+			for _, c := range p.Spec.Containers {
+				total.Add(devstaging.ContainerRequest(c.Resources.Requests["cpu"], c.Resources.Limits["cpu"],
+					c.Resources.Requests["memory"], c.Resources.Limits["memory"]))
+			}
+			break
+		}
+	}
+	return n, total
+}
+
+// setPaused patches spec.paused on each target whose value differs, and
+// returns the names of those patched and those left as they were.
+func setPaused(targets []envStatus, paused bool) (patched, unchanged []string, err error) {
+	value := "null" // paused: false is the default, so the field is removed
+	if paused {
+		value = "true"
+	}
+	for _, t := range targets {
+		key := t.Namespace + "/" + t.Name
+		if t.Paused == paused {
+			unchanged = append(unchanged, key)
+			continue
+		}
+		if out, err := captureKubectl("patch", "devstagingenvironment", t.Name, "-n", t.Namespace,
+			"--type", "merge", "-p", fmt.Sprintf(`{"spec":{"paused":%s}}`, value)); err != nil {
+			return patched, unchanged, fmt.Errorf("patching %s failed: %s", t.Name, strings.TrimSpace(out))
+		}
+		patched = append(patched, key)
+	}
+	return patched, unchanged, nil
+}
+
+// wakeAsleep clears status.asleepSince on each target that spec.autoSleep
+// put to sleep, and returns the names of those woken.
+func wakeAsleep(targets []envStatus) (woken []string, err error) {
+	for _, t := range targets {
+		if !t.Asleep {
+			continue
+		}
+		if out, err := captureKubectl("patch", "devstagingenvironment", t.Name, "-n", t.Namespace,
+			"--subresource=status", "--type", "merge", "-p", `{"status":{"asleepSince":null}}`); err != nil {
+			return woken, fmt.Errorf("waking %s failed: %s", t.Name, strings.TrimSpace(out))
+		}
+		woken = append(woken, t.Namespace+"/"+t.Name)
+	}
+	return woken, nil
+}
+
+func runPause(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	targets, err := pauseTargets(collectEnvironments(), args[0])
+	if err != nil {
+		return err
+	}
+	pods, requested := environmentPods(targets)
+	result := pauseResult{Pods: pods, CPU: requested.CPUMilli, Memory: requested.MemBytes}
+
+	header(fmt.Sprintf("Pausing %s", args[0]))
+	if result.Environments, result.Unchanged, err = setPaused(targets, true); err != nil {
+		return err
+	}
+	for _, name := range result.Environments {
+		step("⏸️ ", name)
+	}
+	for _, name := range result.Unchanged {
+		step("⏸️ ", name+" "+dimText("(already paused)"))
+	}
+
+	if !pauseNoWait {
+		sp := startSpinner(fmt.Sprintf("Waiting for %d pod(s) to stop", pods))
+		deadline := time.Now().Add(pauseTimeout)
+		for {
+			left, _ := environmentPods(targets)
+			if left == 0 {
+				result.Done = true
+				break
+			}
+			if time.Now().After(deadline) {
+				result.Pending = []string{fmt.Sprintf("%d pod(s)", left)}
+				break
+			}
+			sp.update(fmt.Sprintf("Waiting for %d pod(s) to stop", left))
+			time.Sleep(2 * time.Second)
+		}
+		sp.stop()
+	}
+
+	if err := render(result, func() {
+		switch {
+		case result.Done && requested.MemBytes > 0:
+			success(fmt.Sprintf("Stopped %d pod(s), freeing %s CPU and %s memory they requested",
+				pods, devstaging.FormatMilliCPU(requested.CPUMilli), devstaging.FormatBytes(requested.MemBytes)))
+		case result.Done:
+			success(fmt.Sprintf("Stopped %d pod(s)", pods))
+		case pauseNoWait:
+			success("Paused — the operator is stopping the pods")
+		}
+		fmt.Printf("\n  %sClaims, Secrets, and config are kept. Start it again with: kindling resume %s%s\n\n", colorDim, args[0], colorReset)
+	}); err != nil {
+		return err
+	}
+	if len(result.Pending) > 0 {
+		return fmt.Errorf("%s still running after %s — see: kindling status", result.Pending[0], pauseTimeout)
+	}
+	return nil
+}
+
+func runResume(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	targets, err := pauseTargets(collectEnvironments(), args[0])
+	if err != nil {
+		return err
+	}
+	result := pauseResult{}
+
+	header(fmt.Sprintf("Resuming %s", args[0]))
+	if result.Environments, result.Unchanged, err = setPaused(targets, false); err != nil {
+		return err
+	}
+	woken, err := wakeAsleep(targets)
+	if err != nil {
+		return err
+	}
+	unchanged := result.Unchanged[:0]
+	for _, name := range result.Unchanged {
+		if containsString(woken, name) {
+			result.Environments = append(result.Environments, name)
+		} else {
+			unchanged = append(unchanged, name)
+		}
+	}
+	result.Unchanged = unchanged
+	for _, name := range result.Environments {
+		step("▶️ ", name)
+	}
+	for _, name := range result.Unchanged {
+		step("▶️ ", name+" "+dimText("(not paused)"))
+	}
+
+	if !pauseNoWait && len(result.Environments) > 0 {
+		sp := startSpinner("Waiting for the pods to be ready")
+		deadline := time.Now().Add(pauseTimeout)
+		for {
+			current, _ := pauseTargets(collectEnvironments(), args[0])
+			result.Pending = nil
+			for _, e := range current {
+				if !e.Ready {
+					result.Pending = append(result.Pending, e.Name)
+				}
+			}
+			if len(result.Pending) == 0 {
+				result.Done = true
+				break
+			}
+			if time.Now().After(deadline) {
+				break
+			}
+			sp.update("Waiting for " + strings.Join(result.Pending, ", "))
+			time.Sleep(2 * time.Second)
+		}
+		sp.stop()
+	}
+
+	if err := render(result, func() {
+		switch {
+		case result.Done:
+			success(fmt.Sprintf("%s is running again", args[0]))
+		case len(result.Environments) > 0 && pauseNoWait:
+			success("Resumed — the operator is starting the pods")
+		}
+		fmt.Println()
+	}); err != nil {
+		return err
+	}
+	if len(result.Pending) > 0 {
+		return fmt.Errorf("%s not ready after %s — see: kindling status", strings.Join(result.Pending, ", "), pauseTimeout)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+	"github.com/jeffvincent/kindling/pkg/policy"
+)
+
+// ── Policies ────────────────────────────────────────────────────
+//
+// Platform admins keep rules every environment must follow in
+// .kindling/policies/*.yaml: named lists of CEL validations over the
+// DevStagingEnvironment, shaped like a ValidatingAdmissionPolicy's, or
+// Rego modules.
+// kindling validate evaluates them against manifests and workflows;
+// kindling policy sync copies them into the kindling-policies ConfigMap,
+// where the operator's admission webhook evaluates them on every apply.
+// Both run the operator module's evaluator (pkg/policy), so the two agree.
+
+// policyConfigMap is the ConfigMap, in the default namespace, the
+// webhook reads the policies from.
+const policyConfigMap = "kindling-policies"
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Check and publish the platform's policies",
+	Long: `Manages the policies in .kindling/policies/: rules every
+DevStagingEnvironment must follow, such as no :latest tags, mandatory
+resource limits, or approved base images. kindling validate enforces
+them on manifests and workflows; after kindling policy sync the
+operator's admission webhook enforces them on every apply too.
+
+Each .yaml file holds one or more policies, each a list of CEL
+expressions over the environment (object, in its v1alpha1 form) that
+must all be true:
+
+  name: no-latest-tags
+  description: Pin every image to a version
+  action: deny                  # or warn
+  validations:
+    - expression: "!object.spec.deployment.image.endsWith(':latest')"
+      messageExpression: "'image ' + object.spec.deployment.image + ' is not pinned'"
+      fieldPath: spec.deployment.image
+
+Expressions that read baseImages — the images the app's Dockerfile
+builds FROM — only run in kindling validate, which reads the Dockerfile.
+
+A policy can be OPA Rego instead, with language: rego and a module
+whose violation rule is the set of its violations; it reads
+input.object and input.baseImages.
+
+Examples:
+  kindling policy list
+  kindling policy sync`,
+}
+
+var policyListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the project's policies and whether the cluster has them",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runPolicyList,
+}
+
+var policySyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Publish the project's policies to the admission webhook",
+	Long: `Compiles every policy in .kindling/policies/ and writes the files to
+the kindling-policies ConfigMap in the default namespace, which the
+operator's admission webhook reads. From then on a DevStagingEnvironment
+that breaks a deny policy is rejected at kubectl apply, and one that
+breaks a warn policy is applied with a warning. An update is only
+rejected for a violation it introduces.
+
+The policies apply to every environment in the cluster. With no
+policies left, sync deletes the ConfigMap.
+
+Examples:
+  kindling policy sync
+  kindling policy sync --dir ../platform/policies`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runPolicySync,
+}
+
+var policyDir string
+
+func init() {
+	policyListCmd.Flags().StringVar(&policyDir, "dir", "", "Policies directory (default: .kindling/policies of the project)")
+	policySyncCmd.Flags().StringVar(&policyDir, "dir", "", "Policies directory (default: .kindling/policies of the project)")
+	policyCmd.AddCommand(policyListCmd, policySyncCmd)
+	rootCmd.AddCommand(policyCmd)
+}
+
+// policiesDir resolves the directory of policy list and sync.
+func policiesDir() (string, error) {
+	if policyDir != "" {
+		return policyDir, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	if dir := devstaging.FindPoliciesDir(cwd); dir != "" {
+		return dir, nil
+	}
+	return "", fmt.Errorf("no .kindling/%s directory in this project — create one with a policy file, or pass --dir", devstaging.PoliciesDirName)
+}
+
+// policyList is the JSON form of policy list.
+type policyList struct {
+	Dir      string          `json:"dir"`
+	Policies []policy.Policy `json:"policies"`
+	Cluster  string          `json:"cluster,omitempty"` // synced, outdated, or not synced; "" without a cluster
+}
+
+func runPolicyList(cmd *cobra.Command, args []string) error {
+	dir, err := policiesDir()
+	if err != nil {
+		return err
+	}
+	files, err := devstaging.PolicyFiles(dir)
+	if err != nil {
+		return err
+	}
+	compiled, err := policy.Load(files)
+	if err != nil {
+		return err
+	}
+	result := policyList{Dir: dir, Policies: []policy.Policy{}}
+	for _, p := range compiled {
+		result.Policies = append(result.Policies, p.Policy)
+	}
+	if clusterExists(clusterName) {
+		result.Cluster = "not synced"
+		if data, err := readConfigMap("default", policyConfigMap); err == nil {
+			result.Cluster = "outdated"
+			if equalStringMaps(data, files) {
+				result.Cluster = "synced"
+			}
+		}
+	}
+
+	return render(result, func() {
+		header(fmt.Sprintf("Policies in %s", dir))
+		if len(result.Policies) == 0 {
+			fmt.Printf("    %s\n\n", dimText("None"))
+			return
+		}
+		for _, p := range result.Policies {
+			icon := "🛑"
+			if p.Action == policy.ActionWarn {
+				icon = "⚠️ "
+			}
+			rules := fmt.Sprintf("%d validation(s)", len(p.Validations))
+			if p.Language == policy.LanguageRego {
+				rules = "rego"
+			}
+			fmt.Printf("    %s %-24s %s %s\n", icon, p.Name, p.Description,
+				dimText(fmt.Sprintf("(%s, %s, %s)", p.Action, rules, p.File)))
+		}
+		fmt.Println()
+		switch result.Cluster {
+		case "synced":
+			success("The admission webhook enforces these policies")
+		case "outdated":
+			warn("The cluster has other policies — run: kindling policy sync")
+		case "not synced":
+			warn("The admission webhook doesn't enforce these yet — run: kindling policy sync")
+		}
+		fmt.Println()
+	})
+}
+
+// equalStringMaps reports whether a and b hold the same entries.
+func equalStringMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+// policySyncResult is the JSON form of policy sync.
+type policySyncResult struct {
+	Dir      string   `json:"dir"`
+	Files    []string `json:"files"`
+	Policies []string `json:"policies"`
+}
+
+func runPolicySync(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	dir, err := policiesDir()
+	if err != nil {
+		return err
+	}
+	files, err := devstaging.PolicyFiles(dir)
+	if err != nil {
+		return err
+	}
+	// Only policies that compile reach the webhook, which would otherwise
+	// skip them with a warning on every apply.
+	compiled, err := policy.Load(files)
+	if err != nil {
+		return err
+	}
+	result := policySyncResult{Dir: dir, Files: []string{}, Policies: []string{}}
+	for name := range files {
+		result.Files = append(result.Files, name)
+	}
+	sort.Strings(result.Files)
+	for _, p := range compiled {
+		result.Policies = append(result.Policies, p.Name)
+	}
+
+	header("Syncing policies")
+	if len(files) == 0 {
+		if err := deleteConfigMap("default", policyConfigMap); err != nil {
+			return fmt.Errorf("cannot delete configmap default/%s: %w", policyConfigMap, err)
+		}
+		return render(result, func() {
+			success(fmt.Sprintf("No policies in %s — removed them from the cluster", dir))
+		})
+	}
+	labels := map[string]string{"app.kubernetes.io/managed-by": "kindling"}
+	if err := writeConfigMap("default", policyConfigMap, labels, files, nil); err != nil {
+		return fmt.Errorf("cannot write configmap default/%s: %w", policyConfigMap, err)
+	}
+	return render(result, func() {
+		for _, p := range compiled {
+			step("📜", fmt.Sprintf("%s %s", p.Name, dimText("("+p.Action+")")))
+		}
+		success(fmt.Sprintf("The admission webhook now enforces %d polic(ies) from %s", len(compiled), dir))
+		fmt.Println()
+	})
+}
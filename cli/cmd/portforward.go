@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeffvincent/kindling/cli/internal/daemon"
+	"github.com/jeffvincent/kindling/cli/internal/procutil"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var portForwardCmd = &cobra.Command{
+	Use:   "port-forward [component]",
+	Short: "Forward localhost ports to environment components in the background",
+	Long: `Starts a background kubectl port-forward to the Service of one component,
+every component of an environment (--env), or every component in the
+cluster (no arguments), and prints a summary table.
+
+Local ports are allocated automatically: the Service's own port when it's
+free, otherwise the next free port above it. Forwards keep running after
+the command exits and are tracked in .kindling/port-forwards.yaml, with
+their logs in .kindling/daemons/ — kindling ps lists them alongside the
+tunnels started by kindling expose.
+
+Components are named the same way as in kindling logs: an environment
+name (its app), a dependency type such as postgres, or a full name such as
+orders-dev-postgres.
+
+Examples:
+  kindling port-forward                        # everything
+  kindling port-forward --env orders-dev       # one environment
+  kindling port-forward postgres --env orders-dev
+  kindling port-forward orders-dev --port 3000 # pick the local port
+  kindling port-forward --list
+  kindling port-forward --stop                 # stop all forwards
+  kindling port-forward --stop orders-dev-postgres`,
+	Args:              cobra.MaximumNArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeComponents),
+	RunE:              runPortForward,
+}
+
+var (
+	portForwardEnv  string
+	portForwardPort int
+	portForwardStop bool
+	portForwardList bool
+)
+
+func init() {
+	portForwardCmd.Flags().StringVar(&portForwardEnv, "env", "", "DevStagingEnvironment to forward (all of its components without an argument)")
+	_ = portForwardCmd.RegisterFlagCompletionFunc("env", completeEnvFlag)
+	portForwardCmd.Flags().IntVar(&portForwardPort, "port", 0, "Local port to use (single component only; default: auto)")
+	portForwardCmd.Flags().BoolVar(&portForwardStop, "stop", false, "Stop running forwards (only the named component if given)")
+	portForwardCmd.Flags().BoolVar(&portForwardList, "list", false, "List tracked forwards")
+	rootCmd.AddCommand(portForwardCmd)
+}
+
+// ── Persisted state ─────────────────────────────────────────────
+
+// portForwardStateFile is the file name of the forward list inside .kindling/.
+const portForwardStateFile = "port-forwards.yaml"
+
+// PortForwardState is one background kubectl port-forward, stored as an
+// entry in .kindling/port-forwards.yaml.
+type PortForwardState struct {
+	Component  string    `yaml:"component" json:"component"`
+	Namespace  string    `yaml:"namespace" json:"namespace"`
+	LocalPort  int       `yaml:"localPort" json:"localPort"`
+	RemotePort int       `yaml:"remotePort" json:"remotePort"`
+	PID        int       `yaml:"pid" json:"pid"`
+	Created    time.Time `yaml:"created" json:"created"`
+}
+
+// portForwardFile is the on-disk layout of .kindling/port-forwards.yaml.
+type portForwardFile struct {
+	Forwards []PortForwardState `yaml:"forwards"`
+}
+
+func portForwardStatePath(dir string) string {
+	return filepath.Join(dir, ".kindling", portForwardStateFile)
+}
+
+// readPortForwards loads the tracked forwards. A missing file is not an
+// error.
+func readPortForwards(dir string) ([]PortForwardState, error) {
+	data, err := os.ReadFile(portForwardStatePath(dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list portForwardFile
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", portForwardStatePath(dir), err)
+	}
+	return list.Forwards, nil
+}
+
+// writePortForwards persists the list, removing the file once it's empty.
+func writePortForwards(dir string, forwards []PortForwardState) error {
+	if len(forwards) == 0 {
+		if err := os.Remove(portForwardStatePath(dir)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".kindling"), 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(portForwardFile{Forwards: forwards})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(portForwardStatePath(dir), data, 0644)
+}
+
+// livePortForwards returns the tracked forwards whose process is still
+// running, dropping the rest from the state file.
+func livePortForwards(dir string) ([]PortForwardState, error) {
+	forwards, err := readPortForwards(dir)
+	if err != nil {
+		return nil, err
+	}
+	live := forwards[:0]
+	for _, f := range forwards {
+		if processAlive(f.PID) {
+			live = append(live, f)
+		}
+	}
+	if len(live) != len(forwards) {
+		_ = writePortForwards(dir, live)
+	}
+	return live, nil
+}
+
+// ── Command ─────────────────────────────────────────────────────
+
+// portForwardResult is one row of the summary table.
+type portForwardResult struct {
+	PortForwardState
+	Status string `json:"status"` // "started", "running", "stopped", or "failed"
+	Error  string `json:"error,omitempty"`
+}
+
+func runPortForward(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	component := ""
+	if len(args) > 0 {
+		component = args[0]
+	}
+
+	if portForwardList {
+		forwards, err := livePortForwards(cwd)
+		if err != nil {
+			return err
+		}
+		results := []portForwardResult{}
+		for _, f := range forwards {
+			results = append(results, portForwardResult{PortForwardState: f, Status: "running"})
+		}
+		return render(results, func() { printPortForwards(results) })
+	}
+	if portForwardStop {
+		return stopPortForwards(cwd, component)
+	}
+
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	comps, err := resolveComponents(collectEnvironments(), component, portForwardEnv)
+	if err != nil {
+		return err
+	}
+	if portForwardPort != 0 && len(comps) > 1 {
+		return fmt.Errorf("--port needs a single component, but %d matched", len(comps))
+	}
+
+	existing, err := livePortForwards(cwd)
+	if err != nil {
+		return err
+	}
+	claimed := map[int]bool{}
+	for _, f := range existing {
+		claimed[f.LocalPort] = true
+	}
+
+	header("Port forwards")
+	results := []portForwardResult{}
+	for _, c := range comps {
+		if i := findPortForward(existing, c.namespace, c.name); i >= 0 {
+			results = append(results, portForwardResult{PortForwardState: existing[i], Status: "running"})
+			continue
+		}
+		_, portStr, ok := strings.Cut(c.service, ":")
+		remote, _ := strconv.Atoi(portStr)
+		if !ok || remote == 0 {
+			warn(fmt.Sprintf("%s has no Service — skipping", c.name))
+			continue
+		}
+
+		local := portForwardPort
+		if local == 0 {
+			local = freeLocalPort(remote, claimed)
+		}
+		state := PortForwardState{Component: c.name, Namespace: c.namespace, LocalPort: local, RemotePort: remote}
+		pid, err := startPortForward(cwd, state)
+		if err != nil {
+			results = append(results, portForwardResult{PortForwardState: state, Status: "failed", Error: err.Error()})
+			continue
+		}
+		state.PID, state.Created = pid, time.Now().UTC().Truncate(time.Second)
+		claimed[local] = true
+		existing = append(existing, state)
+		results = append(results, portForwardResult{PortForwardState: state, Status: "started"})
+	}
+	if err := writePortForwards(cwd, existing); err != nil {
+		return err
+	}
+	ensureTunnelGitignored(cwd)
+
+	if err := render(results, func() {
+		printPortForwards(results)
+		fmt.Printf("  Stop with: %skindling port-forward --stop%s\n\n", colorCyan, colorReset)
+	}); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r.Status == "failed" {
+			return fmt.Errorf("port-forward to %s failed", r.Component)
+		}
+	}
+	return nil
+}
+
+func findPortForward(forwards []PortForwardState, namespace, component string) int {
+	for i, f := range forwards {
+		if f.Namespace == namespace && f.Component == component {
+			return i
+		}
+	}
+	return -1
+}
+
+// freeLocalPort returns want if nothing listens on it, else the next free
+// port above it. Ports under 1024 start at 8000+port so no root is needed.
+func freeLocalPort(want int, claimed map[int]bool) int {
+	if want < 1024 {
+		want += 8000
+	}
+	for port := want; port < 65535; port++ {
+		if claimed[port] {
+			continue
+		}
+		l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if err == nil {
+			l.Close()
+			return port
+		}
+	}
+	return want
+}
+
+// portForwardDaemonName is the daemons registry name of a forward.
+func portForwardDaemonName(namespace, component string) string {
+	return "port-forward-" + namespace + "-" + component
+}
+
+// startPortForward runs kubectl port-forward as a registered daemon, with
+// its output in .kindling/daemons/, and waits until the local port accepts
+// connections.
+func startPortForward(dir string, f PortForwardState) (int, error) {
+	c := exec.Command("kubectl", "--context", kubeContextName(),
+		"port-forward", "-n", f.Namespace, "svc/"+f.Component,
+		fmt.Sprintf("%d:%d", f.LocalPort, f.RemotePort))
+	registry := daemon.Open(dir)
+	d, err := startDaemon(registry, daemon.Daemon{
+		Name:   portForwardDaemonName(f.Namespace, f.Component),
+		Kind:   daemon.KindPortForward,
+		Health: fmt.Sprintf("tcp://127.0.0.1:%d", f.LocalPort),
+		Labels: map[string]string{
+			"component": f.Component,
+			"namespace": f.Namespace,
+			"ports":     fmt.Sprintf("%d:%d", f.LocalPort, f.RemotePort),
+		},
+	}, c)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < 20; i++ {
+		time.Sleep(250 * time.Millisecond)
+		if !d.Alive() {
+			break
+		}
+		if d.Check(250*time.Millisecond) == nil {
+			return d.PID, nil
+		}
+	}
+	tail, _ := d.Logs(3)
+	_ = registry.Stop(d.Name, 0)
+	return 0, fmt.Errorf("port-forward did not come up: %s", strings.TrimSpace(tail))
+}
+
+// stopPortForwards kills the forward for component, or every forward when
+// component is empty.
+func stopPortForwards(dir, component string) error {
+	forwards, err := readPortForwards(dir)
+	if err != nil {
+		return err
+	}
+	results := []portForwardResult{}
+	remaining := []PortForwardState{}
+	for _, f := range forwards {
+		if component != "" && f.Component != component {
+			remaining = append(remaining, f)
+			continue
+		}
+		if processAlive(f.PID) {
+			_ = procutil.Terminate(f.PID)
+		}
+		_ = daemon.Open(dir).Remove(portForwardDaemonName(f.Namespace, f.Component))
+		results = append(results, portForwardResult{PortForwardState: f, Status: "stopped"})
+	}
+	if err := writePortForwards(dir, remaining); err != nil {
+		return err
+	}
+	return render(results, func() {
+		if len(results) == 0 {
+			fmt.Println("  No port-forward is currently running.")
+			return
+		}
+		for _, r := range results {
+			success(fmt.Sprintf("Stopped localhost:%d → %s", r.LocalPort, r.Component))
+		}
+	})
+}
+
+func printPortForwards(results []portForwardResult) {
+	if len(results) == 0 {
+		fmt.Printf("    %sNo port-forwards — run:%s kindling port-forward\n\n", colorDim, colorReset)
+		return
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Component < results[j].Component })
+	fmt.Printf("    %s%-28s %-22s %-8s %-8s %s%s\n", colorBold, "COMPONENT", "LOCAL", "REMOTE", "PID", "STATUS", colorReset)
+	for _, r := range results {
+		statusColor := colorGreen
+		if r.Status == "failed" {
+			statusColor = colorRed
+		}
+		fmt.Printf("    %-28s %-22s %-8d %-8d %s%-8s%s\n",
+			r.Component, fmt.Sprintf("localhost:%d", r.LocalPort), r.RemotePort, r.PID, statusColor, r.Status, colorReset)
+		if r.Error != "" {
+			fmt.Printf("      %s\n", dimText(r.Error))
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+var previewCmd = &cobra.Command{
+	Use:   "preview",
+	Short: "Deploy the current git branch as a preview environment of its own",
+	Long: `Builds and deploys the checked-out branch next to everything else in
+the cluster, the way a hosted preview environment would:
+
+  1. The environment is named after the git branch (feature/login →
+     feature-login) and gets its own namespace, env-feature-login,
+     with the kindling secrets copied into it
+  2. Every image built from the repo is tagged <branch>-<short SHA>
+     (-dirty with uncommitted changes) and shipped to the cluster
+  3. The manifest is applied into the namespace with those images
+  4. Each ingress gets a host of the branch's own:
+     feature-login.localtest.me, or <name>.feature-login.localtest.me
+     when the manifest has several — *.localtest.me resolves to
+     127.0.0.1, so no /etc/hosts entries are needed
+
+With --subpath the branch is served under /<branch> on the host of the
+running kindling expose tunnel instead, so a preview can be shared over
+the one public hostname a quick tunnel has. This needs ingress-nginx,
+which strips the prefix before the request reaches the app.
+
+Running preview again on the same branch updates the environment in
+place. Remove it with kindling env delete <branch>.
+
+Examples:
+  kindling preview -f dev-environment.yaml
+  kindling preview -f dev-environment.yaml --env demo-for-design
+  kindling preview -f dev-environment.yaml --subpath`,
+	SilenceUsage: true,
+	RunE:         runPreview,
+}
+
+var (
+	previewFile     string
+	previewRepoPath string
+	previewEnv      string
+	previewDomain   string
+	previewSubpath  bool
+	previewBuilder  string
+	previewTimeout  time.Duration
+)
+
+func init() {
+	previewCmd.Flags().StringVarP(&previewFile, "file", "f", "", "DevStagingEnvironment YAML to preview (required)")
+	previewCmd.Flags().StringVarP(&previewRepoPath, "repo-path", "r", "", "Repository root the images are built from (default: the file's directory)")
+	previewCmd.Flags().StringVar(&previewEnv, "env", "", "Environment name (default: the current git branch)")
+	previewCmd.Flags().StringVar(&previewDomain, "domain", "localtest.me", "Domain the preview hosts are made under")
+	previewCmd.Flags().BoolVar(&previewSubpath, "subpath", false, "Serve the preview under /<branch> on the expose tunnel's host")
+	previewCmd.Flags().StringVar(&previewBuilder, "builder", "", "docker buildx builder to build on (default: $KINDLING_BUILDER, then build.builder in .kindling/config.yaml)")
+	previewCmd.Flags().DurationVar(&previewTimeout, "timeout", 5*time.Minute, "How long to wait for the preview to be ready")
+	_ = previewCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(previewCmd)
+}
+
+// previewResult is the JSON form of preview's output.
+type previewResult struct {
+	Environment string          `json:"environment"`
+	Namespace   string          `json:"namespace"`
+	Tag         string          `json:"tag"`
+	Builds      []buildResult   `json:"builds"`
+	Components  []previewTarget `json:"components"`
+	Ready       bool            `json:"ready"`
+}
+
+// previewTarget is one DevStagingEnvironment of the preview.
+type previewTarget struct {
+	Name  string `json:"name"`
+	Image string `json:"image,omitempty"`
+	URL   string `json:"url,omitempty"`
+	Ready bool   `json:"ready"`
+}
+
+func runPreview(cmd *cobra.Command, args []string) error {
+	for _, bin := range []string{"docker", "kubectl", "git"} {
+		if !commandExists(bin) {
+			return fmt.Errorf("%s is not installed — run: kindling doctor", bin)
+		}
+	}
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	if err := requireImageRegistry(); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(previewFile)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", previewFile, err)
+	}
+	repoPath := previewRepoPath
+	if repoPath == "" {
+		repoPath = validateRepoRoot(previewFile)
+	}
+	repoPath, _ = filepath.Abs(repoPath)
+
+	env, err := resolveEnvName(previewEnv, previewEnv == "", repoPath)
+	if err != nil {
+		return err
+	}
+	if env == defaultEnvName {
+		return fmt.Errorf("a preview needs an environment of its own — pass another --env")
+	}
+
+	// The tunnel host is resolved before anything is built, so a missing
+	// tunnel fails fast.
+	tunnelHost := ""
+	if previewSubpath {
+		if class := clusterIngressClass(); class != "nginx" {
+			return fmt.Errorf("--subpath needs ingress-nginx to strip the path prefix, but the cluster runs %s", class)
+		}
+		if tunnelHost = liveTunnelHost(); tunnelHost == "" {
+			return fmt.Errorf("--subpath serves the preview on the tunnel's host, but no tunnel is running — start one with: kindling expose")
+		}
+	}
+
+	services, err := devServices(data, repoPath, previewFile)
+	if err != nil {
+		return err
+	}
+	targets, _, _ := devstaging.ParseTargets(data)
+
+	result := previewResult{Environment: env, Namespace: environmentNamespace(env), Tag: env + "-" + gitImageTag(repoPath)}
+
+	header(fmt.Sprintf("Preview %s", env))
+	step("🌿", fmt.Sprintf("Namespace %s", result.Namespace))
+	if _, err := ensureEnvironment(env); err != nil {
+		return err
+	}
+
+	// ── Build ───────────────────────────────────────────────────
+	builder, err := resolveBuilderName(previewBuilder, repoPath)
+	if err != nil {
+		return err
+	}
+	if err := selectBuilder(builder); err != nil {
+		return err
+	}
+	images := map[string]string{}
+	for _, svc := range services {
+		res := buildService(svc, clusterImage(svc.repo, result.Tag))
+		result.Builds = append(result.Builds, res)
+		if res.Error != "" {
+			return fmt.Errorf("%s: %s", svc.name, res.Error)
+		}
+		images[svc.name] = res.Image
+	}
+
+	// ── Deploy ──────────────────────────────────────────────────
+	step("📄", fmt.Sprintf("Applying %s", previewFile))
+	if out, err := captureKubectl("apply", "-f", previewFile, "-n", result.Namespace); err != nil {
+		return fmt.Errorf("kubectl apply failed: %s", out)
+	}
+	withIngress := 0
+	for _, t := range targets {
+		if t.Manifest.Spec.Ingress != nil && t.Manifest.Spec.Ingress.Enabled {
+			withIngress++
+		}
+	}
+	// The operator reports a regex path as is, so the URLs of a subpath
+	// preview are made here.
+	subpathURLs := map[string]string{}
+	for _, t := range targets {
+		spec := map[string]interface{}{}
+		if image, ok := images[t.Name]; ok {
+			spec["deployment"] = map[string]interface{}{"image": image}
+		}
+		if t.Manifest.Spec.Ingress != nil && t.Manifest.Spec.Ingress.Enabled {
+			ingress, prefix := previewIngress(t.Name, env, tunnelHost, withIngress > 1)
+			spec["ingress"] = ingress
+			if prefix != "" {
+				subpathURLs[t.Name] = "https://" + tunnelHost + prefix + "/"
+			}
+		}
+		result.Components = append(result.Components, previewTarget{Name: t.Name, Image: images[t.Name]})
+		if len(spec) == 0 {
+			continue
+		}
+		patch, _ := json.Marshal(map[string]interface{}{"spec": spec})
+		if out, err := captureKubectl("patch", "devstagingenvironment", t.Name, "-n", result.Namespace,
+			"--type", "merge", "-p", string(patch)); err != nil {
+			return fmt.Errorf("patching %s failed: %s", t.Name, out)
+		}
+	}
+
+	// ── Wait for readiness ──────────────────────────────────────
+	sp := startSpinner(fmt.Sprintf("Waiting for %d DevStagingEnvironment(s) to be ready", len(result.Components)))
+	deadline := time.Now().Add(previewTimeout)
+	result.Ready = true
+	for i := range result.Components {
+		c := &result.Components[i]
+		remaining := max(time.Until(deadline), time.Second)
+		_, err := captureKubectl("wait", "--for=condition=Ready", "devstagingenvironment/"+c.Name,
+			"-n", result.Namespace, fmt.Sprintf("--timeout=%ds", int(remaining.Seconds())))
+		c.Ready = err == nil
+		result.Ready = result.Ready && c.Ready
+		if url, ok := subpathURLs[c.Name]; ok {
+			c.URL = url
+			continue
+		}
+		c.URL, _ = kubectlJSON("get", "devstagingenvironment", c.Name, "-n", result.Namespace, "-o", "jsonpath={.status.url}")
+		c.URL = strings.TrimSpace(c.URL)
+	}
+	sp.stop()
+
+	if err := render(result, func() { printPreview(result) }); err != nil {
+		return err
+	}
+	if !result.Ready {
+		return fmt.Errorf("the preview is not ready after %s — run kindling env switch %s, then kindling status", previewTimeout, env)
+	}
+	return nil
+}
+
+// previewIngress returns the spec.ingress fields that give a preview its
+// own route: a host under --domain, or with a tunnel host a path prefix
+// on it, which it also returns. several adds the component's name, so
+// each ingress of the manifest stays distinct.
+func previewIngress(name, env, tunnelHost string, several bool) (map[string]interface{}, string) {
+	if tunnelHost == "" {
+		host := env + "." + previewDomain
+		if several {
+			host = name + "." + host
+		}
+		return map[string]interface{}{"host": host}, ""
+	}
+	prefix := "/" + env
+	if several {
+		prefix += "/" + name
+	}
+	return map[string]interface{}{
+		"host":     tunnelHost,
+		"path":     prefix + "(/|$)(.*)",
+		"pathType": "ImplementationSpecific",
+		"annotations": map[string]string{
+			"nginx.ingress.kubernetes.io/use-regex":      "true",
+			"nginx.ingress.kubernetes.io/rewrite-target": "/$2",
+		},
+	}, prefix
+}
+
+// liveTunnelHost returns the hostname of a running kindling expose
+// tunnel, or "".
+func liveTunnelHost() string {
+	tunnels, err := loadTunnels()
+	if err != nil {
+		return ""
+	}
+	for _, t := range tunnels {
+		if processAlive(t.PID) {
+			return tunnelHostname(t.URL)
+		}
+	}
+	return ""
+}
+
+func printPreview(result previewResult) {
+	header("Preview")
+	for _, c := range result.Components {
+		icon := colorGreen + "✓" + colorReset
+		if !c.Ready {
+			icon = colorYellow + "⚠" + colorReset
+		}
+		line := fmt.Sprintf("    %s %-28s", icon, c.Name)
+		if c.URL != "" {
+			line += " " + c.URL
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+	fmt.Printf("  %sWork against it with: kindling env switch %s%s\n", colorDim, result.Environment, colorReset)
+	fmt.Printf("  %sRemove it with: kindling env delete %s%s\n\n", colorDim, result.Environment, colorReset)
+}
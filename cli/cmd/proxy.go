@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ── Proxies ─────────────────────────────────────────────────────
+//
+// Behind a corporate proxy, kindling reads HTTP_PROXY, HTTPS_PROXY, and
+// NO_PROXY (or their lowercase spellings) and carries them to everything
+// that connects out: its own API clients (AI providers, tunnel providers,
+// GitHub releases), the tools it runs (kind, k3d, helm, curl, cloudflared,
+// ngrok), the cluster nodes that pull images, and the runner pods that
+// build them. Destinations on this machine and in the cluster always
+// bypass the proxy.
+
+// proxyEnvNames are the proxy variables, in the spelling most tools read
+// first and the one curl and ngrok require.
+var proxyEnvNames = [][2]string{
+	{"HTTP_PROXY", "http_proxy"},
+	{"HTTPS_PROXY", "https_proxy"},
+	{"NO_PROXY", "no_proxy"},
+}
+
+// localNoProxy are the destinations that never go through a proxy: this
+// machine, the local registry container, the in-cluster registry, and
+// the names ingresses are served under.
+var localNoProxy = []string{"localhost", "127.0.0.1", "::1", ".localhost", ".localtest.me", localRegistryName, "registry", ".svc", ".cluster.local"}
+
+// proxyFromEnv returns the proxy configuration of the environment, as Go
+// and most tools read it.
+func proxyFromEnv() *httpproxy.Config {
+	return httpproxy.FromEnvironment()
+}
+
+// proxyConfigured reports whether an HTTP or HTTPS proxy is set.
+func proxyConfigured() bool {
+	cfg := proxyFromEnv()
+	return cfg.HTTPProxy != "" || cfg.HTTPSProxy != ""
+}
+
+// hostProxyEnv is the proxy environment kindling was started with, before
+// applyProxyEnv, for kindling doctor --network.
+var hostProxyEnv = map[string]string{}
+
+// applyProxyEnv makes the proxy settings consistent for this process and
+// everything it starts: each variable is set in both spellings, and
+// NO_PROXY gains localNoProxy, so that tools which read only one spelling
+// (curl, ngrok) use the same proxy, and kind hands the bypass list to the
+// nodes' containerd. Nothing changes when no proxy is set.
+func applyProxyEnv() {
+	for _, names := range proxyEnvNames {
+		for _, name := range names {
+			if value, ok := os.LookupEnv(name); ok {
+				hostProxyEnv[name] = value
+			}
+		}
+	}
+	if !proxyConfigured() {
+		return
+	}
+	for _, names := range proxyEnvNames {
+		value := os.Getenv(names[0])
+		if value == "" {
+			value = os.Getenv(names[1])
+		}
+		if names[0] == "NO_PROXY" {
+			value = mergeNoProxy(value, localNoProxy)
+		}
+		if value == "" {
+			continue
+		}
+		os.Setenv(names[0], value)
+		os.Setenv(names[1], value)
+	}
+}
+
+// mergeNoProxy appends the entries of extra that list lacks.
+func mergeNoProxy(list string, extra []string) string {
+	seen := map[string]bool{}
+	var entries []string
+	for _, e := range strings.Split(list, ",") {
+		if e = strings.TrimSpace(e); e != "" && !seen[e] {
+			seen[e] = true
+			entries = append(entries, e)
+		}
+	}
+	for _, e := range extra {
+		if !seen[e] {
+			seen[e] = true
+			entries = append(entries, e)
+		}
+	}
+	return strings.Join(entries, ",")
+}
+
+// proxyEnv returns the proxy variables to hand to a process or pod that
+// doesn't inherit this environment, as NAME=value.
+func proxyEnv() []string {
+	if !proxyConfigured() {
+		return nil
+	}
+	var env []string
+	for _, names := range proxyEnvNames {
+		for _, name := range names {
+			if value := os.Getenv(name); value != "" {
+				env = append(env, name+"="+value)
+			}
+		}
+	}
+	return env
+}
+
+// runnerProxyEnvYAML is the env block of a GithubActionRunnerPool that
+// hands the proxy to the runner, which the operator passes on to the
+// build-agent and its Kaniko pods; "" without a proxy.
+func runnerProxyEnvYAML() string {
+	env := proxyEnv()
+	if len(env) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("  env:\n")
+	for _, e := range env {
+		name, value, _ := strings.Cut(e, "=")
+		fmt.Fprintf(&b, "    - name: %s\n      value: %q\n", name, value)
+	}
+	return b.String()
+}
+
+// proxyFor returns the proxy a request to target goes through, or nil
+// when it connects directly.
+func proxyFor(target string) *url.URL {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil
+	}
+	proxy, err := proxyFromEnv().ProxyFunc()(u)
+	if err != nil {
+		return nil
+	}
+	return proxy
+}
+
+// outboundClient is the HTTP client for calls to the internet. It reads
+// the proxy settings on each request rather than once per process.
+func outboundClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFromEnv().ProxyFunc()(req.URL)
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// outboundError adds the proxy a failed request went through, and where
+// to look next, to err.
+func outboundError(target string, err error) error {
+	proxy := proxyFor(target)
+	if proxy == nil {
+		return err
+	}
+	return fmt.Errorf("%w (via proxy %s — diagnose with: kindling doctor --network)", err, redactProxy(proxy))
+}
+
+// outboundTransport adds outboundError's hint to the errors of requests
+// that failed on the way.
+type outboundTransport struct {
+	base http.RoundTripper
+}
+
+func (t outboundTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, outboundError(req.URL.String(), err)
+	}
+	return resp, nil
+}
+
+// redactProxy returns the proxy URL without its password.
+func redactProxy(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jeffvincent/kindling/cli/internal/daemon"
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/tunnel"
+)
+
+var psCmd = &cobra.Command{
+	Use:   "ps",
+	Short: "List the background processes kindling is running",
+	Long: `Lists every background process kindling started for this project —
+tunnels from kindling expose and the dashboard, kubectl port-forwards,
+and running kindling dev sessions — with its health.
+
+Each process is registered in .kindling/daemons/: <name>.yaml records how
+it was started and <name>.log holds its output. A process is healthy when
+it is running and answers on its target: the public URL of a tunnel, the
+local port of a port-forward.
+
+Examples:
+  kindling ps
+  kindling ps --prune
+  kindling ps logs tunnel-default
+  kindling ps logs port-forward-default-orders-dev -n 50
+  kindling ps stop tunnel-default`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runPs,
+}
+
+var psLogsCmd = &cobra.Command{
+	Use:               "logs <name>",
+	Short:             "Print the log of a background process",
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeDaemons),
+	RunE:              runPsLogs,
+}
+
+var psStopCmd = &cobra.Command{
+	Use:   "stop <name>",
+	Short: "Stop a background process",
+	Long: `Stops a background process and removes it from the registry. Tunnels
+and port-forwards are stopped the way kindling expose --stop and kindling
+port-forward --stop would, so ingress hosts are restored too.`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeDaemons),
+	RunE:              runPsStop,
+}
+
+var (
+	psPrune    bool
+	psLogLines int
+)
+
+func init() {
+	psCmd.Flags().BoolVar(&psPrune, "prune", false, "Remove processes that have exited from the registry")
+	psLogsCmd.Flags().IntVarP(&psLogLines, "lines", "n", 0, "Print only the last n lines")
+	psCmd.AddCommand(psLogsCmd, psStopCmd)
+	rootCmd.AddCommand(psCmd)
+}
+
+// daemons returns the registry of the project in the working directory.
+func daemons() *daemon.Registry {
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = "."
+	}
+	return daemon.Open(cwd)
+}
+
+// psEntry is one row of kindling ps.
+type psEntry struct {
+	daemon.Daemon
+	Uptime string `json:"uptime"`
+	Status string `json:"status"` // "healthy", "unhealthy", or "exited"
+	Error  string `json:"error,omitempty"`
+}
+
+func runPs(cmd *cobra.Command, args []string) error {
+	registry := daemons()
+	if psPrune {
+		if _, err := registry.Prune(); err != nil {
+			return err
+		}
+	}
+	list, err := registry.List()
+	if err != nil {
+		return err
+	}
+	entries := []psEntry{}
+	for _, d := range list {
+		entry := psEntry{Daemon: d, Uptime: d.Uptime().String(), Status: "healthy"}
+		if !d.Alive() {
+			entry.Status = "exited"
+		} else if err := d.Check(3 * time.Second); err != nil {
+			entry.Status, entry.Error = "unhealthy", err.Error()
+		}
+		entries = append(entries, entry)
+	}
+	return render(entries, func() { printPs(entries) })
+}
+
+func printPs(entries []psEntry) {
+	header("Background processes")
+	if len(entries) == 0 {
+		fmt.Printf("    %sNothing running — start one with:%s kindling expose, kindling port-forward, or kindling dev\n\n", colorDim, colorReset)
+		return
+	}
+	fmt.Printf("    %s%-14s %-36s %-8s %-10s %-10s %s%s\n", colorBold, "KIND", "NAME", "PID", "UPTIME", "STATUS", "TARGET", colorReset)
+	exited := false
+	for _, e := range entries {
+		statusColor := colorGreen
+		switch e.Status {
+		case "unhealthy":
+			statusColor = colorYellow
+		case "exited":
+			statusColor = colorRed
+			exited = true
+		}
+		fmt.Printf("    %-14s %-36s %-8d %-10s %s%-10s%s %s\n",
+			e.Kind, e.Name, e.PID, e.Uptime, statusColor, e.Status, colorReset, e.Health)
+		if e.Error != "" {
+			fmt.Printf("      %s\n", dimText(e.Error))
+		}
+	}
+	fmt.Println()
+	if exited {
+		fmt.Printf("  Clean up with: %skindling ps --prune%s\n\n", colorCyan, colorReset)
+	}
+}
+
+func runPsLogs(cmd *cobra.Command, args []string) error {
+	d, err := daemons().Get(args[0])
+	if err != nil {
+		return err
+	}
+	out, err := d.Logs(psLogLines)
+	if err != nil {
+		return err
+	}
+	fmt.Println(out)
+	return nil
+}
+
+func runPsStop(cmd *cobra.Command, args []string) error {
+	registry := daemons()
+	d, err := registry.Get(args[0])
+	if err != nil {
+		return err
+	}
+	switch {
+	case d.Name == dashboardTunnelDaemon:
+		if err := registry.Stop(d.Name, 0); err != nil {
+			return err
+		}
+		restoreIngresses()
+	case d.Kind == daemon.KindTunnel:
+		service := d.Labels["service"]
+		if service == (&tunnel.State{}).Label() {
+			service = ""
+		}
+		return stopTunnels(service)
+	case d.Kind == daemon.KindPortForward:
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		return stopPortForwards(cwd, d.Labels["component"])
+	default:
+		// A dev session and kindlingd shut down on SIGTERM the way they do
+		// on Ctrl+C.
+		if err := registry.Stop(d.Name, 5*time.Second); err != nil {
+			return err
+		}
+	}
+	return render(d, func() { success(fmt.Sprintf("Stopped %s", d.Name)) })
+}
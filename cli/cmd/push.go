@@ -116,5 +116,5 @@ func runGit(args ...string) error {
 	c.Stdout = os.Stdout
 	c.Stderr = os.Stderr
 	c.Stdin = os.Stdin
-	return c.Run()
+	return runCommand(c)
 }
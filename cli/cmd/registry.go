@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Run a local image registry container wired into the Kind cluster",
+	Long: `Manages a registry:2 container on the host that the Kind cluster pulls
+from, following Kind's local-registry pattern.
+
+Pushing to it only sends the layers that changed, where kind load copies
+the whole image into every node on each rebuild — a big difference for
+large images. While it's running, kindling dev pushes to it instead of
+using kind load, and kindling generate --no-ai writes image references
+that point at it.
+
+The registry is reachable as localhost:<port> both from the host (docker
+push) and from the cluster (image references), and is announced in the
+kube-public/local-registry-hosting ConfigMap. Images survive kindling
+destroy; a new cluster is wired to the running registry by kindling init.
+
+This is separate from the in-cluster registry:5000 that CI builds push to.
+
+Examples:
+  kindling registry start
+  kindling registry status
+  kindling registry stop`,
+}
+
+var registryStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the registry container and connect it to the cluster",
+	RunE:  runRegistryStart,
+}
+
+var registryStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the registry container (images are kept; --delete removes them)",
+	RunE:  runRegistryStop,
+}
+
+var registryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the registry address, cluster wiring, and stored repositories",
+	RunE:  runRegistryStatus,
+}
+
+var (
+	registryPort   int
+	registryDelete bool
+)
+
+func init() {
+	registryStartCmd.Flags().IntVar(&registryPort, "port", defaultLocalRegistryPort, "Host port to publish the registry on")
+	registryStopCmd.Flags().BoolVar(&registryDelete, "delete", false, "Remove the container and every image in it")
+	registryCmd.AddCommand(registryStartCmd)
+	registryCmd.AddCommand(registryStopCmd)
+	registryCmd.AddCommand(registryStatusCmd)
+	rootCmd.AddCommand(registryCmd)
+}
+
+const (
+	// localRegistryName is the registry container, and its hostname on the
+	// kind Docker network.
+	localRegistryName = "kindling-registry"
+	// defaultLocalRegistryPort avoids 5000, which macOS AirPlay occupies.
+	defaultLocalRegistryPort = 5001
+)
+
+// localRegistryAddress returns "localhost:<port>" when the registry
+// container is running.
+func localRegistryAddress() (string, bool) {
+	out, err := runCapture("docker", "inspect", "-f",
+		`{{.State.Running}} {{range $p, $b := .NetworkSettings.Ports}}{{range $b}}{{.HostPort}}{{end}}{{end}}`,
+		localRegistryName)
+	if err != nil {
+		return "", false
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 || fields[0] != "true" {
+		return "", false
+	}
+	return "localhost:" + fields[1], true
+}
+
+// clusterImage returns the reference to build repo:tag as so that
+// shipImage can get it into the cluster: under --image-registry on a
+// remote cluster, under the local registry when it is running, otherwise
+// unchanged for kind load. Any registry host already in repo is replaced.
+func clusterImage(repo, tag string) string {
+	registry, ok := imageRegistry, remoteCluster()
+	if !ok {
+		registry, ok = localRegistryAddress()
+	}
+	if !ok {
+		return repo + ":" + tag
+	}
+	if host, rest, found := strings.Cut(repo, "/"); found &&
+		(strings.ContainsAny(host, ".:") || host == "localhost") {
+		repo = rest
+	}
+	return registry + "/" + repo + ":" + tag
+}
+
+// shipImage makes a locally built image available to the cluster: a push
+// when it is tagged for --image-registry or the local registry, otherwise
+// kind load. A remote cluster can only pull, so it never loads. It returns
+// a short description of what it did.
+func shipImage(image string) (string, error) {
+	if remoteCluster() {
+		if err := requireImageRegistry(); err != nil {
+			return "", err
+		}
+		if out, err := runSilent("docker", "push", image); err != nil {
+			return "", fmt.Errorf("docker push failed: %s", lastLines(out, 5))
+		}
+		return "pushed to " + imageRegistry, nil
+	}
+	if registry, ok := localRegistryAddress(); ok && strings.HasPrefix(image, registry+"/") {
+		if out, err := runSilent("docker", "push", image); err != nil {
+			return "", fmt.Errorf("docker push failed: %s", lastLines(out, 5))
+		}
+		return "pushed to " + registry, nil
+	}
+	if err := loadImage(clusterName, image); err != nil {
+		return "", fmt.Errorf("kind load failed: %w", err)
+	}
+	return "loaded into " + clusterName, nil
+}
+
+func runRegistryStart(cmd *cobra.Command, args []string) error {
+	if err := requireLocalCluster("kindling registry start"); err != nil {
+		return fmt.Errorf("%w — use --image-registry for remote clusters", err)
+	}
+	header("Local registry")
+
+	addr, err := startLocalRegistry(registryPort)
+	if err != nil {
+		return err
+	}
+
+	if !clusterExists(clusterName) {
+		warn(fmt.Sprintf("%s not found — it will be wired up by 'kindling init'", clusterLabel()))
+	} else if err := connectLocalRegistry(addr); err != nil {
+		return err
+	}
+
+	success(fmt.Sprintf("Registry ready at %s", addr))
+	fmt.Println()
+	fmt.Printf("  Push:  %sdocker tag app:dev %s/app:dev && docker push %s/app:dev%s\n", colorCyan, addr, addr, colorReset)
+	fmt.Printf("  Use:   %simage: %s/app:dev%s\n", colorCyan, addr, colorReset)
+	fmt.Println()
+	return nil
+}
+
+// startLocalRegistry starts the registry container, creating it on port
+// when it doesn't exist, and returns its address.
+func startLocalRegistry(port int) (string, error) {
+	if addr, ok := localRegistryAddress(); ok {
+		step("✓", fmt.Sprintf("%s already running at %s", localRegistryName, addr))
+		return addr, nil
+	}
+	if _, err := runCapture("docker", "inspect", localRegistryName); err == nil {
+		step("▶️ ", fmt.Sprintf("Starting existing %s container", localRegistryName))
+		if out, err := runSilent("docker", "start", localRegistryName); err != nil {
+			return "", fmt.Errorf("docker start failed: %s", out)
+		}
+	} else {
+		step("📦", fmt.Sprintf("docker run %s on 127.0.0.1:%d", localRegistryName, port))
+		if out, err := runSilent("docker", "run", "-d", "--restart=always",
+			"-p", fmt.Sprintf("127.0.0.1:%d:5000", port),
+			"--name", localRegistryName, "registry:2"); err != nil {
+			return "", fmt.Errorf("docker run failed: %s", out)
+		}
+	}
+
+	addr, ok := localRegistryAddress()
+	if !ok {
+		return "", fmt.Errorf("%s did not start — check: docker logs %s", localRegistryName, localRegistryName)
+	}
+	return addr, nil
+}
+
+// connectLocalRegistry attaches the registry container to Kind's network,
+// points every node's containerd at it for addr, and publishes the
+// local-registry-hosting ConfigMap. Every step is idempotent.
+func connectLocalRegistry(addr string) error {
+	network := clusterProvider().Network(clusterName)
+	step("🔗", fmt.Sprintf("Connecting %s to the %s network", localRegistryName, network))
+	if out, err := runSilent("docker", "network", "connect", network, localRegistryName); err != nil &&
+		!strings.Contains(out, "already exists") {
+		return fmt.Errorf("docker network connect failed: %s", out)
+	}
+
+	nodes, err := clusterNodes(clusterName)
+	if err != nil {
+		return err
+	}
+	// On Kind this requires the config_path containerd patch in kind-config.yaml.
+	certsDir := clusterProvider().CertsDir() + "/" + addr
+	hostsToml := fmt.Sprintf("[host.%q]\n", "http://"+localRegistryName+":5000")
+	for _, node := range nodes {
+		step("🧩", fmt.Sprintf("Configuring containerd on %s", node))
+		if out, err := runSilent("docker", "exec", node, "mkdir", "-p", certsDir); err != nil {
+			return fmt.Errorf("configuring %s failed: %s", node, out)
+		}
+		if err := runStdin(hostsToml, "docker", "exec", "-i", node, "sh", "-c", "cat > "+certsDir+"/hosts.toml"); err != nil {
+			return fmt.Errorf("configuring %s failed: %w", node, err)
+		}
+	}
+
+	// https://github.com/kubernetes/enhancements/tree/master/keps/sig-cluster-lifecycle/generic/1755-communicating-a-local-registry
+	hosting := fmt.Sprintf("host: %q\nhelp: \"https://kind.sigs.k8s.io/docs/user/local-registry/\"\n", addr)
+	if err := writeConfigMap("kube-public", "local-registry-hosting", nil,
+		map[string]string{"localRegistryHosting.v1": hosting}, nil); err != nil {
+		return fmt.Errorf("cannot publish local-registry-hosting: %w", err)
+	}
+	return nil
+}
+
+func runRegistryStop(cmd *cobra.Command, args []string) error {
+	header("Local registry")
+	if _, err := runCapture("docker", "inspect", localRegistryName); err != nil {
+		warn(fmt.Sprintf("%s does not exist — nothing to do", localRegistryName))
+		return nil
+	}
+	if registryDelete {
+		step("🗑️ ", fmt.Sprintf("docker rm -f %s", localRegistryName))
+		if out, err := runSilent("docker", "rm", "-f", localRegistryName); err != nil {
+			return fmt.Errorf("docker rm failed: %s", out)
+		}
+		success("Registry and its images removed")
+		return nil
+	}
+	step("🛑", fmt.Sprintf("docker stop %s", localRegistryName))
+	if out, err := runSilent("docker", "stop", localRegistryName); err != nil {
+		return fmt.Errorf("docker stop failed: %s", out)
+	}
+	success("Registry stopped — images are kept; start it again with: kindling registry start")
+	return nil
+}
+
+// registryStatus is the report printed by kindling registry status.
+type registryStatus struct {
+	Name         string   `json:"name"`
+	Running      bool     `json:"running"`
+	Address      string   `json:"address,omitempty"`
+	Connected    bool     `json:"connected"`
+	Repositories []string `json:"repositories"`
+}
+
+func runRegistryStatus(cmd *cobra.Command, args []string) error {
+	st := registryStatus{Name: localRegistryName, Repositories: []string{}}
+	st.Address, st.Running = localRegistryAddress()
+	if st.Running {
+		networks, _ := runCapture("docker", "inspect", "-f",
+			`{{range $n, $_ := .NetworkSettings.Networks}}{{$n}} {{end}}`, localRegistryName)
+		for _, n := range strings.Fields(networks) {
+			if n == clusterProvider().Network(clusterName) {
+				st.Connected = true
+			}
+		}
+		if repos, err := registryCatalog(st.Address); err == nil {
+			st.Repositories = repos
+		}
+	}
+
+	return render(st, func() {
+		header("Local registry")
+		if !st.Running {
+			fmt.Printf("  %s%s is not running — start it with: kindling registry start%s\n\n", colorDim, localRegistryName, colorReset)
+			return
+		}
+		fmt.Printf("  %-14s %s\n", "Address:", st.Address)
+		connected := colorGreen + "yes" + colorReset
+		if !st.Connected {
+			connected = colorYellow + "no — run: kindling registry start" + colorReset
+		}
+		fmt.Printf("  %-14s %s\n", "Kind network:", connected)
+		fmt.Printf("  %-14s %s\n", "Repositories:", strconv.Itoa(len(st.Repositories)))
+		for _, r := range st.Repositories {
+			fmt.Printf("    • %s/%s\n", st.Address, r)
+		}
+		fmt.Println()
+	})
+}
+
+// registryCatalog lists the repositories stored in the registry.
+func registryCatalog(addr string) ([]string, error) {
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get("http://" + addr + "/v2/_catalog")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var catalog struct {
+		Repositories []string `json:"repositories"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		return nil, err
+	}
+	return catalog.Repositories, nil
+}
@@ -0,0 +1,329 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <capture>",
+	Short: "Send captured traffic again and report what changed",
+	Long: `Sends the requests of a kindling capture, one at a time and in order, to
+an environment and compares each response with the recorded one:
+
+  same       status and body match
+  changed    the status matches, the body doesn't
+  regressed  the status differs
+
+JSON bodies are compared as values, so key order and whitespace don't
+count, and --ignore-field drops fields that differ on every run (ids,
+timestamps) wherever they appear. The command exits non-zero when any
+request regressed, or — with --strict — changed.
+
+The capture is a name from .kindling/captures or a path to a .jsonl file.
+Requests go to the environment the capture was recorded from unless
+--env or --url says otherwise. Replaying writes again whatever the
+capture wrote; --read-only sends only GET, HEAD, and OPTIONS.
+
+Examples:
+  kindling replay orders-dev-20260301-101500
+  kindling replay orders-dev-20260301-101500 --read-only --ignore-field id,createdAt
+  kindling replay ./captures/checkout.jsonl --env checkout-dev --strict -o json`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	ValidArgsFunction: firstArg(func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return listCaptures(), cobra.ShellCompDirectiveNoFileComp
+	}),
+	RunE: runReplay,
+}
+
+var (
+	replayEnv      string
+	replayURL      string
+	replayReadOnly bool
+	replayStrict   bool
+	replayIgnore   []string
+	replayTimeout  time.Duration
+)
+
+func init() {
+	replayCmd.Flags().StringVar(&replayEnv, "env", "", "DevStagingEnvironment to send the requests to (default: the one captured)")
+	replayCmd.Flags().StringVar(&replayURL, "url", "", "Send the requests to this URL instead of an environment's ingress")
+	replayCmd.Flags().BoolVar(&replayReadOnly, "read-only", false, "Send only GET, HEAD, and OPTIONS requests")
+	replayCmd.Flags().BoolVar(&replayStrict, "strict", false, "Fail on changed bodies, not only on changed statuses")
+	replayCmd.Flags().StringSliceVar(&replayIgnore, "ignore-field", nil, "JSON field to leave out of body comparisons, at any depth (repeatable)")
+	replayCmd.Flags().DurationVar(&replayTimeout, "timeout", 30*time.Second, "How long each request may take")
+	_ = replayCmd.RegisterFlagCompletionFunc("env", completeDSEs)
+	rootCmd.AddCommand(replayCmd)
+}
+
+// replayResult is one row of the replay report.
+type replayResult struct {
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Recorded int    `json:"recorded"`
+	Got      int    `json:"got,omitempty"`
+	Result   string `json:"result"` // same, changed, regressed, skipped
+	Reason   string `json:"reason,omitempty"`
+	Millis   int64  `json:"millis,omitempty"`
+}
+
+// readOnlyMethods are the methods --read-only replays.
+var readOnlyMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+
+// replayDroppedHeaders are recorded request headers the client sets for
+// itself on the way out.
+var replayDroppedHeaders = []string{"Host", "Content-Length", "Connection", "Accept-Encoding"}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	path, err := captureFile(args[0])
+	if err != nil {
+		return err
+	}
+	exchanges, err := readCapture(path)
+	if err != nil {
+		return err
+	}
+	if len(exchanges) == 0 {
+		return fmt.Errorf("%s holds no requests", path)
+	}
+
+	target := replayURL
+	if target == "" {
+		if !clusterExists(clusterName) {
+			return errNoCluster()
+		}
+		env := replayEnv
+		if env == "" {
+			env = exchanges[0].Environment
+		}
+		if target, err = environmentURL(env); err != nil {
+			return err
+		}
+	}
+	base, err := url.Parse(target)
+	if err != nil || base.Host == "" {
+		return fmt.Errorf("invalid target URL %q", target)
+	}
+
+	header(fmt.Sprintf("Replaying %d request(s) against %s", len(exchanges), base))
+	client := &http.Client{
+		Timeout: replayTimeout,
+		// A redirect is a response to compare, not one to follow.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	results := make([]replayResult, 0, len(exchanges))
+	for _, ex := range exchanges {
+		results = append(results, replayExchange(client, base, ex))
+	}
+
+	if err := render(results, func() { printReplayReport(results) }); err != nil {
+		return err
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Result == "regressed" || (replayStrict && r.Result == "changed") {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d request(s) didn't match the capture", failed, len(results))
+	}
+	return nil
+}
+
+// readCapture decodes a capture file, one exchange per line.
+func readCapture(path string) ([]capturedExchange, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no capture at %s — see: kindling capture --help", path)
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var exchanges []capturedExchange
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var ex capturedExchange
+		if err := json.Unmarshal(scanner.Bytes(), &ex); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		exchanges = append(exchanges, ex)
+	}
+	return exchanges, scanner.Err()
+}
+
+// replayExchange sends one recorded request to base and compares the
+// response with the recorded one.
+func replayExchange(client *http.Client, base *url.URL, ex capturedExchange) replayResult {
+	result := replayResult{Method: ex.Method, Path: ex.Path, Recorded: ex.Status, Result: "skipped"}
+	if replayReadOnly && !containsString(readOnlyMethods, ex.Method) {
+		result.Reason = "not read-only"
+		return result
+	}
+	if ex.Truncated {
+		result.Reason = "body beyond --max-body"
+		return result
+	}
+
+	reqBody, err := decodeBody(ex.RequestBody, ex.RequestBodyBase64)
+	if err != nil {
+		result.Reason = err.Error()
+		return result
+	}
+	ref, err := url.Parse(ex.Path)
+	if err != nil {
+		result.Reason = err.Error()
+		return result
+	}
+	u := *base
+	u.Path, u.RawPath, u.RawQuery = strings.TrimSuffix(base.Path, "/")+ref.Path, "", ref.RawQuery
+	req, err := http.NewRequest(ex.Method, u.String(), bytes.NewReader(reqBody))
+	if err != nil {
+		result.Reason = err.Error()
+		return result
+	}
+	req.Header = ex.RequestHeader.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	for _, h := range replayDroppedHeaders {
+		req.Header.Del(h)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Result, result.Reason = "regressed", err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	got, err := io.ReadAll(resp.Body)
+	result.Millis = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Result, result.Reason = "regressed", err.Error()
+		return result
+	}
+	result.Got = resp.StatusCode
+
+	want, err := decodeBody(ex.Body, ex.BodyBase64)
+	if err != nil {
+		result.Reason = err.Error()
+		return result
+	}
+	switch {
+	case resp.StatusCode != ex.Status:
+		result.Result = "regressed"
+	case ex.Method == http.MethodHead || sameBody(want, got, replayIgnore):
+		result.Result = "same"
+	default:
+		result.Result, result.Reason = "changed", bodyDifference(want, got)
+	}
+	return result
+}
+
+// sameBody compares two bodies, as JSON values when both parse as JSON.
+func sameBody(want, got []byte, ignore []string) bool {
+	var w, g any
+	if json.Unmarshal(want, &w) != nil || json.Unmarshal(got, &g) != nil {
+		return bytes.Equal(want, got)
+	}
+	return reflect.DeepEqual(dropFields(w, ignore), dropFields(g, ignore))
+}
+
+// dropFields removes the named keys from every object in a decoded JSON
+// value.
+func dropFields(v any, names []string) any {
+	if len(names) == 0 {
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if containsString(names, k) {
+				delete(v, k)
+			} else {
+				v[k] = dropFields(child, names)
+			}
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = dropFields(child, names)
+		}
+	}
+	return v
+}
+
+// bodyDifference describes how a body changed, briefly.
+func bodyDifference(want, got []byte) string {
+	var w, g map[string]any
+	if json.Unmarshal(want, &w) != nil || json.Unmarshal(got, &g) != nil {
+		return fmt.Sprintf("%d → %d bytes", len(want), len(got))
+	}
+	w, g = dropFields(w, replayIgnore).(map[string]any), dropFields(g, replayIgnore).(map[string]any)
+	var fields []string
+	for _, k := range sortedKeys(w) {
+		if gv, ok := g[k]; !ok || !reflect.DeepEqual(w[k], gv) {
+			fields = append(fields, k)
+		}
+	}
+	for _, k := range sortedKeys(g) {
+		if _, ok := w[k]; !ok {
+			fields = append(fields, k)
+		}
+	}
+	if len(fields) == 0 {
+		return "body differs"
+	}
+	return "fields: " + strings.Join(fields, ", ")
+}
+
+func printReplayReport(results []replayResult) {
+	fmt.Printf("  %s%-8s %-40s %-10s %-10s %s%s\n", colorBold, "METHOD", "PATH", "STATUS", "RESULT", "DETAIL", colorReset)
+	counts := map[string]int{}
+	for _, r := range results {
+		counts[r.Result]++
+		status := fmt.Sprintf("%d", r.Recorded)
+		if r.Got != 0 && r.Got != r.Recorded {
+			status = fmt.Sprintf("%d → %d", r.Recorded, r.Got)
+		}
+		result := r.Result
+		switch r.Result {
+		case "same":
+			result = colorGreen + result + colorReset
+		case "changed":
+			result = colorYellow + result + colorReset
+		case "regressed":
+			result = colorRed + result + colorReset
+		default:
+			result = colorDim + result + colorReset
+		}
+		path := r.Path
+		if len(path) > 40 {
+			path = path[:37] + "..."
+		}
+		// The colour codes take room in the padding, so RESULT is padded
+		// by hand.
+		fmt.Printf("  %-8s %-40s %-10s %s%s %s\n", r.Method, path, status, result,
+			strings.Repeat(" ", max(0, 10-len(r.Result))), dimText(r.Reason))
+	}
+	fmt.Printf("\n  %s%d same, %d changed, %d regressed, %d skipped%s\n\n", colorDim,
+		counts["same"], counts["changed"], counts["regressed"], counts["skipped"], colorReset)
+}
@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+var reseedCmd = &cobra.Command{
+	Use:   "reseed [dependency]",
+	Short: "Re-run the seed Jobs of an environment's dependencies",
+	Long: `Deletes the seed Job of each matching dependency so the operator runs it
+again, then waits for the new run to finish and reports the outcome.
+
+Dependencies are named by type (postgres) or full name (orders-dev-postgres);
+without one, every seeded dependency is reseeded. --env narrows the lookup
+to one environment.
+
+--from-dir first replaces the seed's ConfigMap with the files in a local
+directory, so edited fixtures can be loaded without touching the manifest.
+
+Seeds run against the existing data, so write them to be re-runnable
+(CREATE TABLE IF NOT EXISTS, upserts) or have them clear what they load.
+
+Examples:
+  kindling reseed --env orders-dev
+  kindling reseed postgres --env orders-dev --from-dir db/seeds
+  kindling reseed orders-dev-mongodb --no-wait`,
+	Args:              cobra.MaximumNArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeComponents),
+	RunE:              runReseed,
+}
+
+var (
+	reseedEnv     string
+	reseedFromDir string
+	reseedNoWait  bool
+	reseedTimeout time.Duration
+)
+
+func init() {
+	reseedCmd.Flags().StringVar(&reseedEnv, "env", "", "DevStagingEnvironment to reseed")
+	_ = reseedCmd.RegisterFlagCompletionFunc("env", completeEnvFlag)
+	reseedCmd.Flags().StringVar(&reseedFromDir, "from-dir", "", "Replace the seed ConfigMap with the files in this directory first")
+	reseedCmd.Flags().BoolVar(&reseedNoWait, "no-wait", false, "Return once the seed Jobs are restarted")
+	reseedCmd.Flags().DurationVar(&reseedTimeout, "timeout", 5*time.Minute, "How long to wait for the seeds to finish")
+	rootCmd.AddCommand(reseedCmd)
+}
+
+// seedTarget is a dependency that declares a seed.
+type seedTarget struct {
+	Env        string `json:"env"`
+	Namespace  string `json:"namespace"`
+	Dependency string `json:"dependency"`
+	Job        string `json:"job"`
+	ConfigMap  string `json:"configMap,omitempty"`
+	Status     string `json:"status"` // Restarted, Succeeded, Failed, or TimedOut
+	Message    string `json:"message,omitempty"`
+}
+
+func runReseed(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	arg := ""
+	if len(args) > 0 {
+		arg = args[0]
+	}
+	targets, err := resolveSeedTargets(arg, reseedEnv)
+	if err != nil {
+		return err
+	}
+	if reseedFromDir != "" {
+		if len(targets) > 1 {
+			return fmt.Errorf("--from-dir needs a single dependency, but %d match — name one", len(targets))
+		}
+		if targets[0].ConfigMap == "" {
+			return fmt.Errorf("the seed of %s runs a command and has no configMap to replace", targets[0].Dependency)
+		}
+		if info, err := os.Stat(reseedFromDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", reseedFromDir)
+		}
+	}
+
+	header("Reseeding")
+	if reseedFromDir != "" {
+		t := targets[0]
+		step("📄", fmt.Sprintf("Replacing ConfigMap %s with %s", t.ConfigMap, reseedFromDir))
+		if err := replaceSeedConfigMap(t.Namespace, t.ConfigMap, reseedFromDir); err != nil {
+			return err
+		}
+	}
+
+	for i := range targets {
+		t := &targets[i]
+		step("🔄", fmt.Sprintf("Restarting %s", t.Job))
+		if out, err := runSilent("kubectl", "--context", kubeContextName(), "delete", "job", t.Job,
+			"-n", t.Namespace, "--ignore-not-found", "--wait"); err != nil {
+			return fmt.Errorf("cannot delete job %s: %s", t.Job, out)
+		}
+		t.Status = "Restarted"
+	}
+
+	if !reseedNoWait {
+		deadline := time.Now().Add(reseedTimeout)
+		for i := range targets {
+			waitForSeed(&targets[i], deadline)
+		}
+	}
+
+	failed := 0
+	for _, t := range targets {
+		if t.Status == "Failed" || t.Status == "TimedOut" {
+			failed++
+		}
+	}
+	if err := render(targets, func() { printReseedReport(targets) }); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d seed(s) did not complete", failed, len(targets))
+	}
+	return nil
+}
+
+// resolveSeedTargets finds the seeded dependencies named by arg, a
+// dependency type or full name, or every seeded dependency when arg is "".
+func resolveSeedTargets(arg, env string) ([]seedTarget, error) {
+	out, err := kubectlJSON("get", "devstagingenvironments", "-A", "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("cannot list DevStagingEnvironments: %w", err)
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+			Spec struct {
+				Dependencies []struct {
+					Type string `json:"type"`
+					Seed *struct {
+						ConfigMap string `json:"configMap"`
+					} `json:"seed"`
+				} `json:"dependencies"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return nil, fmt.Errorf("cannot parse DevStagingEnvironments: %w", err)
+	}
+
+	var targets []seedTarget
+	found := env == ""
+	for _, dse := range list.Items {
+		name := dse.Metadata.Name
+		if env != "" && name != env {
+			continue
+		}
+		found = true
+		for _, dep := range dse.Spec.Dependencies {
+			full := name + "-" + dep.Type
+			if arg != "" && arg != dep.Type && arg != full {
+				continue
+			}
+			if dep.Seed == nil {
+				if arg != "" {
+					return nil, fmt.Errorf("%s has no seed — add one under its seed: key", full)
+				}
+				continue
+			}
+			targets = append(targets, seedTarget{Env: name, Namespace: dse.Metadata.Namespace,
+				Dependency: full, Job: full + "-seed", ConfigMap: dep.Seed.ConfigMap})
+		}
+	}
+	switch {
+	case !found:
+		return nil, fmt.Errorf("DevStagingEnvironment %q not found — see: kindling status", env)
+	case len(targets) == 0 && arg == "":
+		return nil, fmt.Errorf("no dependency of %s declares a seed", describeSeedScope(env))
+	case len(targets) == 0:
+		return nil, fmt.Errorf("no dependency matches %s — see: kindling status", describeLogTarget(arg, env))
+	}
+	return targets, nil
+}
+
+func describeSeedScope(env string) string {
+	if env == "" {
+		return "any environment"
+	}
+	return env
+}
+
+// replaceSeedConfigMap recreates a seed ConfigMap from the files in dir,
+// one key per file as kubectl create configmap --from-file does.
+func replaceSeedConfigMap(namespace, name, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	data, binaryData := map[string]string{}, map[string][]byte{}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		if utf8.Valid(content) {
+			data[e.Name()] = string(content)
+		} else {
+			binaryData[e.Name()] = content
+		}
+	}
+	if err := deleteConfigMap(namespace, name); err != nil {
+		return fmt.Errorf("cannot replace configmap %s: %w", name, err)
+	}
+	if err := writeConfigMap(namespace, name, nil, data, binaryData); err != nil {
+		return fmt.Errorf("cannot create configmap %s: %w", name, err)
+	}
+	return nil
+}
+
+// waitForSeed polls the recreated seed Job until it succeeds, fails, or
+// the deadline passes, recording the outcome on t.
+func waitForSeed(t *seedTarget, deadline time.Time) {
+	step("⏳", fmt.Sprintf("Waiting for %s", t.Job))
+	for time.Now().Before(deadline) {
+		out, err := kubectlJSON("get", "job", t.Job, "-n", t.Namespace, "-o", "json")
+		if err == nil {
+			var job struct {
+				Status struct {
+					Succeeded  int32 `json:"succeeded"`
+					Conditions []struct {
+						Type    string `json:"type"`
+						Status  string `json:"status"`
+						Message string `json:"message"`
+					} `json:"conditions"`
+				} `json:"status"`
+			}
+			if json.Unmarshal([]byte(out), &job) == nil {
+				if job.Status.Succeeded > 0 {
+					t.Status = "Succeeded"
+					return
+				}
+				for _, c := range job.Status.Conditions {
+					if c.Type == "Failed" && c.Status == "True" {
+						t.Status = "Failed"
+						t.Message = c.Message
+						if logs, err := runSilent("kubectl", "--context", kubeContextName(), "logs",
+							"job/"+t.Job, "-n", t.Namespace, "--tail=20"); err == nil && logs != "" {
+							t.Message += "\n" + logs
+						}
+						return
+					}
+				}
+			}
+		}
+		time.Sleep(2 * time.Second)
+	}
+	t.Status = "TimedOut"
+	t.Message = fmt.Sprintf("still running after %s — see: kubectl logs job/%s -n %s", reseedTimeout, t.Job, t.Namespace)
+}
+
+func printReseedReport(targets []seedTarget) {
+	fmt.Println()
+	for _, t := range targets {
+		switch t.Status {
+		case "Succeeded":
+			success(fmt.Sprintf("%s seeded", t.Dependency))
+		case "Restarted":
+			success(fmt.Sprintf("%s is being reseeded — follow it with: kubectl logs -f job/%s -n %s", t.Dependency, t.Job, t.Namespace))
+		default:
+			fail(fmt.Sprintf("%s: seed %s", t.Dependency, strings.ToLower(t.Status)))
+			for _, l := range strings.Split(t.Message, "\n") {
+				fmt.Printf("      %s\n", dimText(l))
+			}
+		}
+	}
+	fmt.Println()
+}
@@ -32,7 +32,7 @@ func runReset(cmd *cobra.Command, args []string) error {
 	header("Resetting runner pool")
 
 	if !clusterExists(clusterName) {
-		fail(fmt.Sprintf("Kind cluster %q not found", clusterName))
+		fail(errNoCluster().Error())
 		return nil
 	}
 
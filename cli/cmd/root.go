@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 )
@@ -12,6 +13,30 @@ var (
 
 	// projectDir is the root of the kindling project (defaults to cwd).
 	projectDir string
+
+	// outputFormat selects human-readable ("text") or machine-readable ("json") output.
+	outputFormat string
+
+	// kubeconfigPath and kubeContext select the cluster connection; the
+	// defaults are the usual kubeconfig and the cluster backend's context.
+	kubeconfigPath string
+	kubeContext    string
+
+	// kubeNamespace is the default namespace of kubectl and the built-in
+	// client — the namespace setting — or "" for the context's.
+	kubeNamespace string
+
+	// useKubectl runs deploy, status, logs, and ConfigMap operations
+	// through the kubectl binary instead of the built-in client.
+	useKubectl bool
+
+	// imageRegistry is where build, dev, and preview push images when
+	// --context is a remote cluster, which can't load them from Docker.
+	imageRegistry string
+
+	// forceVersionSkew runs commands even when the cluster's CRDs or
+	// controller are older than the CLI expects.
+	forceVersionSkew bool
 )
 
 var rootCmd = &cobra.Command{
@@ -23,27 +48,61 @@ self-hosted GitHub Actions runners.
 
 Common workflow:
 
+  kindling doctor                         # check tools, Docker, and ports
   kindling init                           # create cluster + deploy operator
   kindling runners -u <user> -r <repo> -t <pat>      # register a runner
   kindling generate -k <api-key> -r .     # AI-generate a dev-deploy.yml
   kindling secrets set STRIPE_KEY sk_...  # store an external secret
+  kindling validate -f dev-environment.yaml # static checks before deploying
   kindling deploy -f dev-environment.yaml # spin up a staging environment
+  kindling registry start                 # local registry: push instead of kind load
+  kindling dev -f dev-environment.yaml    # rebuild + redeploy on every save
+  kindling build -f dev-environment.yaml  # parallel image builds, git SHA tags
   kindling push -s orders                 # git push, rebuild orders only
   kindling expose                         # public HTTPS tunnel for OAuth
   kindling status                         # view everything at a glance
+  kindling ui                             # interactive TUI: tree, logs, port-forward
   kindling logs                           # tail the controller
+  kindling exec orders-dev                # shell into a component
+  kindling port-forward                   # localhost ports for every component
   kindling reset                          # remove runner pool, keep cluster
   kindling destroy                        # tear it all down`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if kubeconfigPath != "" {
+			// kind and kubectl read it from the environment.
+			os.Setenv("KUBECONFIG", kubeconfigPath)
+		}
+		applyProxyEnv()
+		if err := applyConfigDefaults(cmd); err != nil {
+			return err
+		}
+		setupLogging(cmd)
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		return checkVersionSkew(cmd)
+	},
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVarP(&clusterName, "cluster", "c", "dev", "Kind cluster name")
+	rootCmd.PersistentFlags().StringVarP(&clusterName, "cluster", "c", "dev", "Kind cluster name (or set KINDLING_CLUSTER, or cluster in .kindling/config.yaml)")
 	rootCmd.PersistentFlags().StringVarP(&projectDir, "project-dir", "p", "", "Path to kindling project root (default: current directory)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "Output format: text or json (or set KINDLING_OUTPUT, or output in .kindling/config.yaml)")
+	_ = rootCmd.RegisterFlagCompletionFunc("output", fixedCompletions(outputText, outputJSON))
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig", "", "Path to the kubeconfig file (default: $KUBECONFIG, then ~/.kube/config)")
+	rootCmd.PersistentFlags().StringVar(&kubeContext, "context", os.Getenv("KINDLING_CONTEXT"), "Kubeconfig context to use; any other than the cluster backend's (e.g. kind-<cluster>) is a remote cluster (or set KINDLING_CONTEXT)")
+	_ = rootCmd.RegisterFlagCompletionFunc("context", completeKubeContexts)
+	rootCmd.PersistentFlags().StringVar(&imageRegistry, "image-registry", "", "Registry to push images to on a remote --context cluster, e.g. ghcr.io/acme (or set KINDLING_IMAGE_REGISTRY, or registry in .kindling/config.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&forceVersionSkew, "force-version-skew", false, "Run even when the cluster's CRDs or controller are older than this CLI expects")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Print every command and API request to stderr (always logged to .kindling/logs/)")
+	rootCmd.PersistentFlags().BoolVar(&useKubectl, "kubectl", os.Getenv("KINDLING_KUBECTL") != "", "Use the kubectl binary instead of the built-in Kubernetes client (or set KINDLING_KUBECTL=1)")
 }
 
 // Execute runs the root command.
 func Execute() error {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	closeLogging(err)
+	if err != nil {
 		return fmt.Errorf("cli error: %w", err)
 	}
 	return nil
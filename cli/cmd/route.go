@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var routeCmd = &cobra.Command{
+	Use:   "route",
+	Short: "Split an app's ingress traffic with its canary",
+	Long: `Shows and changes how an app's Ingress splits requests between the app
+and the canary build that spec.canary runs beside it (<name>-canary):
+a percentage of all requests, and the requests a header picks.
+
+Splitting takes ingress-nginx, kindling's default ingress controller.`,
+}
+
+var routeSetCmd = &cobra.Command{
+	Use:   "set <component>",
+	Short: "Change the share of requests an app's canary gets",
+	Long: `Sets spec.canary.weight, header, and headerValue on a DevStagingEnvironment;
+the operator updates the canary Ingress and ingress-nginx routes the next
+request accordingly.
+
+--canary takes a percentage: 0% sends the canary only the requests the
+header picks, 100% every request. A request whose --header is
+--header-value goes to the canary whatever the weight; without
+--header-value, the values "always" and "never" pick the canary or the app.
+
+The app must already have a canary: add spec.canary, with the image to
+test, to its manifest. Like kindling scale, the change lives on the
+cluster's DevStagingEnvironment; the next kindling deploy of the manifest
+sets the file's routing back.
+
+Examples:
+  kindling route set orders-dev --canary 20%
+  kindling route set orders-dev --header X-Canary
+  kindling route set orders-dev --canary 0% --header X-User --header-value qa
+  kindling route set orders-dev --canary 100%`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeComponents),
+	RunE:              runRouteSet,
+}
+
+var routeShowCmd = &cobra.Command{
+	Use:   "show [component]",
+	Short: "List the apps with a canary and how their traffic is split",
+	Long: `Lists every app with a canary: the canary's image and ready pods, and
+the weight and header its Ingress routes by.
+
+Examples:
+  kindling route show
+  kindling route show orders-dev -o json`,
+	Args:              cobra.MaximumNArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeComponents),
+	RunE:              runRouteShow,
+}
+
+var (
+	routeEnv         string
+	routeWeight      string
+	routeHeader      string
+	routeHeaderValue string
+)
+
+func init() {
+	routeSetCmd.Flags().StringVar(&routeWeight, "canary", "", "Percentage of requests to send to the canary, e.g. 20%")
+	routeSetCmd.Flags().StringVar(&routeHeader, "header", "", "Request header that picks the build whatever the weight (\"\" to stop)")
+	routeSetCmd.Flags().StringVar(&routeHeaderValue, "header-value", "", "Value of --header that picks the canary (default: \"always\")")
+	for _, c := range []*cobra.Command{routeSetCmd, routeShowCmd} {
+		c.Flags().StringVar(&routeEnv, "env", "", "DevStagingEnvironment to resolve the component in")
+		_ = c.RegisterFlagCompletionFunc("env", completeEnvFlag)
+	}
+	routeCmd.AddCommand(routeSetCmd)
+	routeCmd.AddCommand(routeShowCmd)
+	rootCmd.AddCommand(routeCmd)
+}
+
+// routeStatus is one app's canary and its routing, as route show and
+// the JSON form of route set report them.
+type routeStatus struct {
+	Component   string `json:"component"`
+	Namespace   string `json:"namespace"`
+	Canary      string `json:"canary"`
+	Image       string `json:"image"`
+	Ready       int    `json:"ready"`
+	Desired     int    `json:"desired"`
+	Weight      int    `json:"weight"`
+	Header      string `json:"header,omitempty"`
+	HeaderValue string `json:"headerValue,omitempty"`
+	Routed      bool   `json:"routed"` // false when no Ingress can split traffic
+}
+
+// canaryDSE holds the fields route reads from a DSE.
+type canaryDSE struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Ingress *struct {
+			Enabled          bool   `json:"enabled"`
+			IngressClassName string `json:"ingressClassName"`
+		} `json:"ingress"`
+		Canary *struct {
+			Image       string `json:"image"`
+			Replicas    *int   `json:"replicas"`
+			Weight      int    `json:"weight"`
+			Header      string `json:"header"`
+			HeaderValue string `json:"headerValue"`
+		} `json:"canary"`
+	} `json:"spec"`
+}
+
+// parseWeight reads a --canary percentage, with or without the % sign.
+func parseWeight(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%")))
+	if err != nil || n < 0 || n > 100 {
+		return 0, fmt.Errorf("--canary takes a percentage from 0%% to 100%%, got %q", s)
+	}
+	return n, nil
+}
+
+// resolveApp resolves arg to one app, the way scale does.
+func resolveApp(envs []envStatus, arg string) (componentRef, error) {
+	comps, err := resolveComponents(envs, arg, routeEnv)
+	if err != nil {
+		return componentRef{}, err
+	}
+	if len(comps) > 1 {
+		var names []string
+		for _, c := range comps {
+			names = append(names, c.name)
+		}
+		return componentRef{}, fmt.Errorf("%q matches %s — pass the full name", arg, strings.Join(names, ", "))
+	}
+	target := comps[0]
+	switch c := lookupComponent(envs, target); {
+	case c != nil && c.Role == "canary":
+		return componentRef{}, fmt.Errorf("%s is a canary — route the app it belongs to, %s", target.name, strings.TrimSuffix(target.name, "-canary"))
+	case c == nil || c.Role != "app":
+		return componentRef{}, fmt.Errorf("%s is a dependency — only apps have a canary", target.name)
+	}
+	return target, nil
+}
+
+func getCanaryDSE(target componentRef) (*canaryDSE, error) {
+	out, err := kubectlJSON("get", "devstagingenvironment", target.name, "-n", target.namespace, "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read DevStagingEnvironment %s: %w", target.name, err)
+	}
+	var dse canaryDSE
+	if err := json.Unmarshal([]byte(out), &dse); err != nil {
+		return nil, fmt.Errorf("cannot parse DevStagingEnvironment %s: %w", target.name, err)
+	}
+	return &dse, nil
+}
+
+func runRouteSet(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	if !flags.Changed("canary") && !flags.Changed("header") && !flags.Changed("header-value") {
+		return fmt.Errorf("nothing to set — pass --canary, --header, or --header-value")
+	}
+	canary := map[string]interface{}{}
+	if flags.Changed("canary") {
+		weight, err := parseWeight(routeWeight)
+		if err != nil {
+			return err
+		}
+		canary["weight"] = weight
+	}
+	if flags.Changed("header") {
+		canary["header"] = routeHeader
+		if routeHeader == "" {
+			canary["headerValue"] = nil // a value without a header is invalid
+		}
+	}
+	if flags.Changed("header-value") {
+		if routeHeaderValue == "" {
+			canary["headerValue"] = nil
+		} else {
+			canary["headerValue"] = routeHeaderValue
+		}
+	}
+
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	envs := collectEnvironments()
+	target, err := resolveApp(envs, args[0])
+	if err != nil {
+		return err
+	}
+	dse, err := getCanaryDSE(target)
+	if err != nil {
+		return err
+	}
+	if dse.Spec.Canary == nil {
+		return fmt.Errorf("%s has no canary — add spec.canary, with the image to test, to its manifest and deploy it", target.name)
+	}
+	if v, ok := canary["headerValue"].(string); ok && v != "" && dse.Spec.Canary.Header == "" && routeHeader == "" {
+		return fmt.Errorf("--header-value needs a header to match — pass --header too")
+	}
+
+	header(fmt.Sprintf("Routing %s", target.name))
+	patch, _ := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"canary": canary}})
+	if out, err := captureKubectl("patch", "devstagingenvironment", target.name, "-n", target.namespace,
+		"--type", "merge", "-p", string(patch)); err != nil {
+		return fmt.Errorf("patching %s failed: %s", target.name, strings.TrimSpace(out))
+	}
+	if dse, err = getCanaryDSE(target); err != nil {
+		return err
+	}
+	status := canaryRoute(dse, envs)
+	step("🔀", describeRoute(status))
+	if !status.Routed {
+		warn("The Ingress can't split traffic — canary routing takes ingress-nginx and spec.ingress.enabled")
+	}
+	return render(status, func() { fmt.Println() })
+}
+
+func runRouteShow(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	envs := collectEnvironments()
+	var dses []canaryDSE
+	if len(args) == 1 {
+		target, err := resolveApp(envs, args[0])
+		if err != nil {
+			return err
+		}
+		dse, err := getCanaryDSE(target)
+		if err != nil {
+			return err
+		}
+		dses = append(dses, *dse)
+	} else {
+		out, err := kubectlJSON("get", "devstagingenvironments", "-A", "-o", "json")
+		if err != nil {
+			return fmt.Errorf("cannot list DevStagingEnvironments: %w", err)
+		}
+		var list struct {
+			Items []canaryDSE `json:"items"`
+		}
+		if err := json.Unmarshal([]byte(out), &list); err != nil {
+			return fmt.Errorf("cannot parse DevStagingEnvironments: %w", err)
+		}
+		dses = list.Items
+	}
+
+	routes := []routeStatus{}
+	for i := range dses {
+		if dses[i].Spec.Canary != nil {
+			routes = append(routes, canaryRoute(&dses[i], envs))
+		}
+	}
+	if len(args) == 1 && len(routes) == 0 {
+		return fmt.Errorf("%s has no canary — add spec.canary, with the image to test, to its manifest and deploy it", args[0])
+	}
+	return render(routes, func() { printRoutes(routes) })
+}
+
+// canaryRoute reports dse's canary, with its readiness from envs.
+func canaryRoute(dse *canaryDSE, envs []envStatus) routeStatus {
+	c := dse.Spec.Canary
+	s := routeStatus{
+		Component: dse.Metadata.Name, Namespace: dse.Metadata.Namespace, Canary: dse.Metadata.Name + "-canary",
+		Image: c.Image, Desired: 1, Weight: c.Weight, Header: c.Header, HeaderValue: c.HeaderValue,
+	}
+	if c.Replicas != nil {
+		s.Desired = *c.Replicas
+	}
+	if ing := dse.Spec.Ingress; ing != nil && ing.Enabled {
+		s.Routed = ing.IngressClassName != "traefik" && ing.IngressClassName != "contour"
+	}
+	if comp := lookupComponent(envs, componentRef{namespace: s.Namespace, name: s.Canary}); comp != nil {
+		s.Ready = comp.Ready
+	}
+	return s
+}
+
+// describeRoute says in words which requests reach the canary.
+func describeRoute(s routeStatus) string {
+	msg := fmt.Sprintf("%d%% of requests to %s", s.Weight, s.Canary)
+	switch {
+	case s.Header != "" && s.HeaderValue != "":
+		msg += fmt.Sprintf(", and every one with %s: %s", s.Header, s.HeaderValue)
+	case s.Header != "":
+		msg += fmt.Sprintf(", and every one with %s: always", s.Header)
+	}
+	return msg
+}
+
+func printRoutes(routes []routeStatus) {
+	header("Canary routes")
+	if len(routes) == 0 {
+		fmt.Printf("  %sNo app has a canary — add spec.canary to a manifest to run one.%s\n\n", colorDim, colorReset)
+		return
+	}
+	fmt.Printf("  %s%-24s %-36s %-7s %-8s %s%s\n", colorBold, "APP", "CANARY IMAGE", "READY", "WEIGHT", "HEADER", colorReset)
+	for _, r := range routes {
+		weight := fmt.Sprintf("%d%%", r.Weight)
+		if !r.Routed {
+			weight = "—"
+		}
+		hdr := r.Header
+		if hdr != "" && r.HeaderValue != "" {
+			hdr += ": " + r.HeaderValue
+		}
+		fmt.Printf("  %-24s %-36s %-7s %-8s %s\n", r.Component, r.Image, fmt.Sprintf("%d/%d", r.Ready, r.Desired), weight, hdr)
+	}
+	for _, r := range routes {
+		if !r.Routed {
+			fmt.Printf("\n  %s— the Ingress can't split traffic: canary routing takes ingress-nginx and spec.ingress.enabled%s\n", colorDim, colorReset)
+			break
+		}
+	}
+	fmt.Println()
+}
@@ -66,11 +66,11 @@ func runRunners(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to generate secret YAML: %w", err)
 	}
 
-	applyCmd := exec.Command("kubectl", "apply", "-f", "-")
+	applyCmd := exec.Command("kubectl", "--context", kubeContextName(), "apply", "-f", "-")
 	applyCmd.Stdin = strings.NewReader(secretYAML)
 	applyCmd.Stdout = os.Stdout
 	applyCmd.Stderr = os.Stderr
-	if err := applyCmd.Run(); err != nil {
+	if err := runCommand(applyCmd); err != nil {
 		return fmt.Errorf("failed to apply secret: %w", err)
 	}
 	success("Secret github-runner-token ready")
@@ -91,13 +91,13 @@ spec:
   replicas: 1
   labels:
     - linux
-`, ghUsername, ghUsername, ghRepo)
+%s`, ghUsername, ghUsername, ghRepo, runnerProxyEnvYAML())
 
-	applyCmd2 := exec.Command("kubectl", "apply", "-f", "-")
+	applyCmd2 := exec.Command("kubectl", "--context", kubeContextName(), "apply", "-f", "-")
 	applyCmd2.Stdin = strings.NewReader(crYAML)
 	applyCmd2.Stdout = os.Stdout
 	applyCmd2.Stderr = os.Stderr
-	if err := applyCmd2.Run(); err != nil {
+	if err := runCommand(applyCmd2); err != nil {
 		return fmt.Errorf("failed to apply GithubActionRunnerPool: %w", err)
 	}
 	success("GithubActionRunnerPool applied")
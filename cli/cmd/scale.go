@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var scaleCmd = &cobra.Command{
+	Use:   "scale <component>",
+	Short: "Set how many replicas of an app run",
+	Long: `Sets spec.deployment.replicas on a DevStagingEnvironment and waits for
+the operator to roll the Deployment to that many ready pods.
+
+Running several replicas locally reproduces bugs that only show up
+behind a load balancer — sessions kept in memory, per-pod caches that
+drift apart, background jobs that run once per pod.
+
+Components are named the same way as in kindling logs. Only apps with a
+Deployment can be scaled: dependencies and scheduled apps run a single
+instance.
+
+The change lives on the cluster's DevStagingEnvironment; the next
+kindling deploy of the manifest sets the replica count back to the
+file's.
+
+Examples:
+  kindling scale orders-dev --replicas 3
+  kindling scale orders-dev --replicas 1
+  kindling scale orders-dev --replicas 5 --no-wait`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeComponents),
+	RunE:              runScale,
+}
+
+var (
+	scaleReplicas int
+	scaleEnv      string
+	scaleNoWait   bool
+	scaleTimeout  time.Duration
+)
+
+func init() {
+	scaleCmd.Flags().IntVar(&scaleReplicas, "replicas", 0, "Number of replicas to run (required)")
+	scaleCmd.Flags().StringVar(&scaleEnv, "env", "", "DevStagingEnvironment to resolve the component in")
+	_ = scaleCmd.RegisterFlagCompletionFunc("env", completeEnvFlag)
+	scaleCmd.Flags().BoolVar(&scaleNoWait, "no-wait", false, "Return once the DevStagingEnvironment is patched")
+	scaleCmd.Flags().DurationVar(&scaleTimeout, "timeout", 2*time.Minute, "How long to wait for the replicas to be ready")
+	_ = scaleCmd.MarkFlagRequired("replicas")
+	rootCmd.AddCommand(scaleCmd)
+}
+
+// scaleResult is the JSON form of scale's output.
+type scaleResult struct {
+	Component string `json:"component"`
+	Namespace string `json:"namespace"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	Ready     bool   `json:"ready"`
+}
+
+func runScale(cmd *cobra.Command, args []string) error {
+	if scaleReplicas < 1 {
+		return fmt.Errorf("--replicas must be at least 1 — to remove an environment, run: kindling delete %s", args[0])
+	}
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	envs := collectEnvironments()
+	comps, err := resolveComponents(envs, args[0], scaleEnv)
+	if err != nil {
+		return err
+	}
+	if len(comps) > 1 {
+		var names []string
+		for _, c := range comps {
+			names = append(names, c.name)
+		}
+		return fmt.Errorf("%q matches %s — pass the full name", args[0], strings.Join(names, ", "))
+	}
+	target := comps[0]
+	switch c := lookupComponent(envs, target); {
+	case c == nil || c.Role != "app":
+		return fmt.Errorf("%s is a dependency, which runs a single instance — only apps can be scaled", target.name)
+	case c.Kind == "CronJob":
+		return fmt.Errorf("%s runs on a schedule, one pod at a time — only apps with a Deployment can be scaled", target.name)
+	}
+
+	out, err := kubectlJSON("get", "devstagingenvironment", target.name, "-n", target.namespace,
+		"-o", "jsonpath={.spec.deployment.replicas}")
+	if err != nil {
+		return fmt.Errorf("cannot read DevStagingEnvironment %s: %w", target.name, err)
+	}
+	from, _ := strconv.Atoi(strings.TrimSpace(out))
+	if from == 0 {
+		from = 1
+	}
+	result := scaleResult{Component: target.name, Namespace: target.namespace, From: from, To: scaleReplicas}
+
+	header(fmt.Sprintf("Scaling %s", target.name))
+	patch := fmt.Sprintf(`{"spec":{"deployment":{"replicas":%d}}}`, scaleReplicas)
+	if out, err := captureKubectl("patch", "devstagingenvironment", target.name, "-n", target.namespace,
+		"--type", "merge", "-p", patch); err != nil {
+		return fmt.Errorf("patching %s failed: %s", target.name, strings.TrimSpace(out))
+	}
+	step("📐", fmt.Sprintf("replicas %d → %d", from, scaleReplicas))
+
+	if !scaleNoWait {
+		sp := startSpinner(fmt.Sprintf("Waiting for %d ready replica(s)", scaleReplicas))
+		err := waitForReplicas(target, scaleReplicas, scaleTimeout)
+		sp.stop()
+		if err != nil {
+			return err
+		}
+		result.Ready = true
+		success(fmt.Sprintf("%s is running %d replica(s)", target.name, scaleReplicas))
+	}
+
+	return render(result, func() {
+		if scaleReplicas > 1 {
+			fmt.Printf("\n  %sRequests to %s are now spread across the pods; follow them all with: kindling logs %s%s\n\n",
+				colorDim, target.name, target.name, colorReset)
+		}
+	})
+}
+
+// lookupComponent returns the status of a resolved component, or nil.
+func lookupComponent(envs []envStatus, ref componentRef) *componentStatus {
+	for _, e := range envs {
+		if e.Namespace != ref.namespace {
+			continue
+		}
+		for i, c := range e.Components {
+			if c.Name == ref.name {
+				return &e.Components[i]
+			}
+		}
+	}
+	return nil
+}
+
+// waitForReplicas waits until the operator has rolled the Deployment to
+// want replicas and all of them are ready. The operator updates the
+// Deployment asynchronously, so its spec is polled before kubectl rollout
+// status can tell the new rollout apart from the old one.
+func waitForReplicas(target componentRef, want int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		out, _ := kubectlJSON("get", "deployment", target.name, "-n", target.namespace,
+			"-o", "jsonpath={.spec.replicas} {.status.readyReplicas} {.status.updatedReplicas}")
+		var spec, ready, updated int
+		fmt.Sscan(out, &spec, &ready, &updated)
+		if spec == want && ready == want && updated == want {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s has %d of %d replica(s) ready after %s — see: kindling debug %s", target.name, ready, want, timeout, target.name)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
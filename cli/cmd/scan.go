@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ── Vulnerability scanning ──────────────────────────────────────
+//
+// kindling build --scan runs Trivy against each image it built and
+// counts the vulnerabilities by severity. With --fail-on, an image with a
+// vulnerability at or above that severity isn't shipped to the cluster
+// and the build exits non-zero, so a script can stop before deploying.
+// Trivy runs from PATH, or from the aquasec/trivy image when it isn't
+// installed, with its vulnerability database cached in a Docker volume.
+
+// scanSeverities are Trivy's severities, most severe first.
+var scanSeverities = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"}
+
+// trivyImage runs Trivy when it isn't installed.
+const trivyImage = "aquasec/trivy:latest"
+
+// trivyCacheVolume keeps Trivy's vulnerability database between runs.
+const trivyCacheVolume = "kindling-trivy-cache"
+
+// scanTopFindings is how many critical and high findings a summary keeps.
+const scanTopFindings = 5
+
+// scanSummary is what the scan of one image found.
+type scanSummary struct {
+	Critical int           `json:"critical"`
+	High     int           `json:"high"`
+	Medium   int           `json:"medium"`
+	Low      int           `json:"low"`
+	Unknown  int           `json:"unknown"`
+	Top      []scanFinding `json:"top,omitempty"` // the most severe, fixable ones first
+	Blocked  bool          `json:"blocked"`       // at or above --fail-on
+	Error    string        `json:"error,omitempty"`
+}
+
+// scanFinding is one vulnerable package.
+type scanFinding struct {
+	ID        string `json:"id"`
+	Severity  string `json:"severity"`
+	Package   string `json:"package"`
+	Installed string `json:"installed"`
+	Fixed     string `json:"fixed,omitempty"`
+	Title     string `json:"title,omitempty"`
+}
+
+// scanMu runs one scan at a time: parallel Trivy runs would contend for
+// the lock on its database.
+var scanMu sync.Mutex
+
+// scanImage scans a locally available image with Trivy.
+func scanImage(image string, ignoreUnfixed bool) *scanSummary {
+	scanMu.Lock()
+	defer scanMu.Unlock()
+
+	name := "trivy"
+	var args []string
+	if !commandExists("trivy") {
+		name = "docker"
+		args = []string{"run", "--rm",
+			"-v", "/var/run/docker.sock:/var/run/docker.sock",
+			"-v", trivyCacheVolume + ":/root/.cache/trivy",
+			trivyImage}
+	}
+	args = append(args, "image", "--quiet", "--format", "json", "--scanners", "vuln")
+	if ignoreUnfixed {
+		args = append(args, "--ignore-unfixed")
+	}
+	args = append(args, image)
+
+	out, err := runCapture(name, args...)
+	if err != nil {
+		return &scanSummary{Error: fmt.Sprintf("trivy failed: %v", err)}
+	}
+	summary, err := parseTrivyReport(out)
+	if err != nil {
+		return &scanSummary{Error: err.Error()}
+	}
+	return summary
+}
+
+// parseTrivyReport counts the vulnerabilities in Trivy's JSON report.
+func parseTrivyReport(out string) (*scanSummary, error) {
+	var report struct {
+		Results []struct {
+			Vulnerabilities []struct {
+				VulnerabilityID  string
+				PkgName          string
+				InstalledVersion string
+				FixedVersion     string
+				Severity         string
+				Title            string
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		return nil, fmt.Errorf("cannot parse the trivy report: %w", err)
+	}
+
+	s := &scanSummary{}
+	seen := map[string]bool{}
+	for _, r := range report.Results {
+		for _, v := range r.Vulnerabilities {
+			// The same CVE can show up in several packages; count it once
+			// per package.
+			key := v.VulnerabilityID + " " + v.PkgName
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			switch v.Severity {
+			case "CRITICAL":
+				s.Critical++
+			case "HIGH":
+				s.High++
+			case "MEDIUM":
+				s.Medium++
+			case "LOW":
+				s.Low++
+			default:
+				s.Unknown++
+			}
+			if v.Severity == "CRITICAL" || v.Severity == "HIGH" {
+				s.Top = append(s.Top, scanFinding{ID: v.VulnerabilityID, Severity: v.Severity, Package: v.PkgName,
+					Installed: v.InstalledVersion, Fixed: v.FixedVersion, Title: v.Title})
+			}
+		}
+	}
+	sort.SliceStable(s.Top, func(i, j int) bool {
+		if s.Top[i].Severity != s.Top[j].Severity {
+			return s.Top[i].Severity == "CRITICAL"
+		}
+		return s.Top[i].Fixed != "" && s.Top[j].Fixed == ""
+	})
+	if len(s.Top) > scanTopFindings {
+		s.Top = s.Top[:scanTopFindings]
+	}
+	return s, nil
+}
+
+// exceeds reports whether the image has a vulnerability at or above
+// threshold, one of scanSeverities.
+func (s *scanSummary) exceeds(threshold string) bool {
+	counts := []int{s.Critical, s.High, s.Medium, s.Low}
+	for i, severity := range scanSeverities {
+		if counts[i] > 0 {
+			return true
+		}
+		if severity == threshold {
+			break
+		}
+	}
+	return false
+}
+
+// short is the one-cell form of the summary for the build report.
+func (s *scanSummary) short() string {
+	switch {
+	case s == nil:
+		return "—"
+	case s.Error != "":
+		return "error"
+	case s.Critical+s.High+s.Medium+s.Low+s.Unknown == 0:
+		return "clean"
+	}
+	return fmt.Sprintf("C%d H%d M%d L%d", s.Critical, s.High, s.Medium, s.Low)
+}
+
+// validScanThreshold normalises a --fail-on value.
+func validScanThreshold(v string) (string, error) {
+	t := strings.ToUpper(v)
+	if !containsString(scanSeverities, t) {
+		return "", fmt.Errorf("--fail-on must be critical, high, medium, or low, got %q", v)
+	}
+	return t, nil
+}
@@ -0,0 +1,588 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// ── Registry pull secrets ───────────────────────────────────────
+//
+// Component pods run as their namespace's service accounts, so a
+// dockerconfigjson Secret listed in those accounts' imagePullSecrets lets
+// every component pull from a private registry without touching the
+// DevStagingEnvironment. Credentials are backed up to
+// .kindling/registries.yaml and re-applied by kindling secrets restore.
+
+const (
+	// pullSecretPrefix starts the name of every kindling pull secret.
+	pullSecretPrefix = "kindling-pull-"
+	// registriesFileName is the local credentials backup (gitignored).
+	registriesFileName = "registries.yaml"
+	// dockerHubServer is the auth key Docker Hub credentials are stored under.
+	dockerHubServer = "https://index.docker.io/v1/"
+)
+
+var secretsRegistryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage pull secrets for private image registries",
+	Long: `Creates image pull secrets for private registries (ghcr.io, Docker
+Hub, ECR, ...) and attaches them to the service accounts that
+DevStagingEnvironment pods run as, so images from those registries pull
+without ImagePullBackOff.
+
+Examples:
+  kindling secrets registry add --server ghcr.io --username octocat --token ghp_...
+  echo "$TOKEN" | kindling secrets registry add --server ghcr.io --username octocat --token-stdin
+  kindling secrets registry list
+  kindling secrets registry remove --server ghcr.io`,
+}
+
+var secretsRegistryAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Create a registry pull secret and attach it to component service accounts",
+	Long: `Creates a kubernetes.io/dockerconfigjson Secret named
+kindling-pull-<server> in every namespace that holds a
+DevStagingEnvironment (or just the one given with --env or --namespace),
+adds it to the imagePullSecrets of the service accounts the components run
+as, and restarts pods that are stuck pulling an image.
+
+The credentials are also saved to .kindling/registries.yaml (gitignored)
+so kindling secrets restore can recreate them after a cluster rebuild.
+
+Examples:
+  kindling secrets registry add --server ghcr.io --username octocat --token ghp_abc123
+  kindling secrets registry add --server docker.io --username me --token-stdin < token.txt
+  kindling secrets registry add --server ghcr.io --username octocat --token ghp_abc123 --env orders-dev`,
+	SilenceUsage: true,
+	RunE:         runSecretsRegistryAdd,
+}
+
+var secretsRegistryListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List registry pull secrets and the service accounts using them",
+	SilenceUsage: true,
+	RunE:         runSecretsRegistryList,
+}
+
+var secretsRegistryRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Delete a registry pull secret and detach it from service accounts",
+	Long: `Detaches kindling-pull-<server> from every service account, deletes the
+Secret from every namespace, and removes it from .kindling/registries.yaml.
+
+Examples:
+  kindling secrets registry remove --server ghcr.io`,
+	SilenceUsage: true,
+	RunE:         runSecretsRegistryRemove,
+}
+
+var (
+	registrySecretServer     string
+	registrySecretUsername   string
+	registrySecretToken      string
+	registrySecretTokenStdin bool
+	registrySecretEnv        string
+	registrySecretNamespace  string
+)
+
+func init() {
+	secretsRegistryAddCmd.Flags().StringVar(&registrySecretServer, "server", "", "Registry host, e.g. ghcr.io or docker.io (required)")
+	secretsRegistryAddCmd.Flags().StringVar(&registrySecretUsername, "username", "", "Registry username (required)")
+	secretsRegistryAddCmd.Flags().StringVar(&registrySecretToken, "token", "", "Registry password or access token")
+	secretsRegistryAddCmd.Flags().BoolVar(&registrySecretTokenStdin, "token-stdin", false, "Read the token from stdin")
+	secretsRegistryAddCmd.Flags().StringVar(&registrySecretEnv, "env", "", "Only the namespace of this DevStagingEnvironment")
+	_ = secretsRegistryAddCmd.RegisterFlagCompletionFunc("env", completeEnvFlag)
+	secretsRegistryAddCmd.Flags().StringVar(&registrySecretNamespace, "namespace", "", "Only this namespace")
+	_ = secretsRegistryAddCmd.MarkFlagRequired("server")
+	_ = secretsRegistryAddCmd.MarkFlagRequired("username")
+	secretsRegistryAddCmd.MarkFlagsMutuallyExclusive("token", "token-stdin")
+	secretsRegistryAddCmd.MarkFlagsMutuallyExclusive("env", "namespace")
+
+	secretsRegistryRemoveCmd.Flags().StringVar(&registrySecretServer, "server", "", "Registry host the secret was added for (required)")
+	_ = secretsRegistryRemoveCmd.MarkFlagRequired("server")
+
+	secretsRegistryCmd.AddCommand(secretsRegistryAddCmd)
+	secretsRegistryCmd.AddCommand(secretsRegistryListCmd)
+	secretsRegistryCmd.AddCommand(secretsRegistryRemoveCmd)
+	secretsCmd.AddCommand(secretsRegistryCmd)
+}
+
+// registryCredential is one entry of .kindling/registries.yaml. Namespace
+// is empty when the secret goes to every DevStagingEnvironment namespace.
+type registryCredential struct {
+	Server    string `yaml:"server"`
+	Username  string `yaml:"username"`
+	Token     string `yaml:"token"` // base64-encoded, like secrets.yaml
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// ── Add ─────────────────────────────────────────────────────────
+
+func runSecretsRegistryAdd(cmd *cobra.Command, args []string) error {
+	token := registrySecretToken
+	if registrySecretTokenStdin {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("cannot read token from stdin: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token == "" {
+		return fmt.Errorf("pass the token with --token or --token-stdin")
+	}
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+
+	namespace := registrySecretNamespace
+	if registrySecretEnv != "" {
+		ns, err := envNamespace(registrySecretEnv)
+		if err != nil {
+			return err
+		}
+		namespace = ns
+	}
+
+	header("Adding registry pull secret")
+	cred := registryCredential{
+		Server:    registrySecretServer,
+		Username:  registrySecretUsername,
+		Token:     base64.StdEncoding.EncodeToString([]byte(token)),
+		Namespace: namespace,
+	}
+	if err := applyRegistryCredential(cred); err != nil {
+		return err
+	}
+
+	if err := saveRegistryCredential(cred); err != nil {
+		warn(fmt.Sprintf("Could not save to local backup: %v", err))
+		warn("The pull secret exists in the cluster but won't survive a cluster rebuild")
+	} else {
+		success(fmt.Sprintf("Backed up to %s/%s", secretsDirName, registriesFileName))
+	}
+	fmt.Println()
+	return nil
+}
+
+// applyRegistryCredential creates the pull secret in each target namespace,
+// attaches it to the component service accounts there, and restarts pods
+// that failed to pull.
+func applyRegistryCredential(cred registryCredential) error {
+	token, err := base64.StdEncoding.DecodeString(cred.Token)
+	if err != nil {
+		return fmt.Errorf("invalid token for %s in %s: %w", cred.Server, registriesFileName, err)
+	}
+	name := pullSecretName(cred.Server)
+
+	namespaces := []string{cred.Namespace}
+	if cred.Namespace == "" {
+		namespaces = dseNamespaces()
+	}
+	for _, ns := range namespaces {
+		step("🔑", fmt.Sprintf("Secret %s in namespace %s", name, ns))
+		manifest, err := kubectlJSON("create", "secret", "docker-registry", name,
+			"-n", ns,
+			"--docker-server="+dockerConfigServer(cred.Server),
+			"--docker-username="+cred.Username,
+			"--docker-password="+string(token),
+			"--dry-run=client", "-o", "yaml")
+		if err != nil {
+			return fmt.Errorf("cannot build secret %s: %w", name, err)
+		}
+		if err := runStdin(manifest, "kubectl", "--context", kubeContextName(), "apply", "-f", "-"); err != nil {
+			return fmt.Errorf("cannot create secret %s in %s: %w", name, ns, err)
+		}
+		_ = runSilent2("kubectl", "--context", kubeContextName(), "label", "secret", name,
+			"-n", ns, secretsLabelKey+"="+secretsLabelValue, "--overwrite")
+
+		for _, sa := range componentServiceAccounts(ns) {
+			changed, err := setImagePullSecret(ns, sa, name, true)
+			if err != nil {
+				return err
+			}
+			if changed {
+				step("👤", fmt.Sprintf("Attached to service account %s/%s", ns, sa))
+			}
+		}
+		restartImagePullFailures(ns)
+	}
+	success(fmt.Sprintf("Pull secret for %s ready in %s", cred.Server, strings.Join(namespaces, ", ")))
+	return nil
+}
+
+// ── List ────────────────────────────────────────────────────────
+
+// pullSecretStatus is one row of kindling secrets registry list.
+type pullSecretStatus struct {
+	Namespace       string   `json:"namespace"`
+	Name            string   `json:"name"`
+	Server          string   `json:"server"`
+	ServiceAccounts []string `json:"serviceAccounts"`
+}
+
+func runSecretsRegistryList(cmd *cobra.Command, args []string) error {
+	out, err := kubectlJSON("get", "secrets", "-A",
+		"--field-selector=type=kubernetes.io/dockerconfigjson",
+		"-l", secretsLabelKey+"="+secretsLabelValue, "-o", "json")
+	if err != nil {
+		return fmt.Errorf("failed to list secrets: %w", err)
+	}
+	var secrets struct {
+		Items []struct {
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+			Data map[string]string `json:"data"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &secrets); err != nil {
+		return fmt.Errorf("cannot parse secrets: %w", err)
+	}
+
+	rows := []pullSecretStatus{}
+	for _, s := range secrets.Items {
+		if !strings.HasPrefix(s.Metadata.Name, pullSecretPrefix) {
+			continue
+		}
+		row := pullSecretStatus{
+			Namespace:       s.Metadata.Namespace,
+			Name:            s.Metadata.Name,
+			Server:          dockerConfigServers(s.Data[".dockerconfigjson"]),
+			ServiceAccounts: []string{},
+		}
+		for sa, pullSecrets := range serviceAccountPullSecrets(row.Namespace) {
+			for _, ps := range pullSecrets {
+				if ps == row.Name {
+					row.ServiceAccounts = append(row.ServiceAccounts, sa)
+				}
+			}
+		}
+		sort.Strings(row.ServiceAccounts)
+		rows = append(rows, row)
+	}
+
+	return render(rows, func() {
+		header("Registry pull secrets")
+		if len(rows) == 0 {
+			step("📭", "No registry pull secrets found")
+			fmt.Println()
+			fmt.Printf("  Run %skindling secrets registry add --server <host> --username <user> --token <token>%s to add one.\n", colorCyan, colorReset)
+			fmt.Println()
+			return
+		}
+		fmt.Printf("  %s%-16s %-32s %-24s %s%s\n", colorBold, "NAMESPACE", "SECRET", "SERVER", "SERVICE ACCOUNTS", colorReset)
+		for _, r := range rows {
+			accounts := strings.Join(r.ServiceAccounts, ", ")
+			if accounts == "" {
+				accounts = colorYellow + "none — re-run kindling secrets registry add" + colorReset
+			}
+			fmt.Printf("  %-16s %-32s %-24s %s\n", r.Namespace, r.Name, r.Server, accounts)
+		}
+		fmt.Println()
+	})
+}
+
+// ── Remove ──────────────────────────────────────────────────────
+
+func runSecretsRegistryRemove(cmd *cobra.Command, args []string) error {
+	name := pullSecretName(registrySecretServer)
+	header("Removing registry pull secret")
+
+	out, err := kubectlJSON("get", "secrets", "-A", "--field-selector=metadata.name="+name,
+		"-o", "jsonpath={range .items[*]}{.metadata.namespace}{\"\\n\"}{end}")
+	if err != nil {
+		return fmt.Errorf("failed to list secrets: %w", err)
+	}
+	namespaces := strings.Fields(out)
+	if len(namespaces) == 0 {
+		step("📭", fmt.Sprintf("No secret %s in the cluster", name))
+	}
+	for _, ns := range namespaces {
+		for sa := range serviceAccountPullSecrets(ns) {
+			changed, err := setImagePullSecret(ns, sa, name, false)
+			if err != nil {
+				return err
+			}
+			if changed {
+				step("👤", fmt.Sprintf("Detached from service account %s/%s", ns, sa))
+			}
+		}
+		step("☸️", fmt.Sprintf("Deleting %s in namespace %s", name, ns))
+		if out, err := runSilent("kubectl", "--context", kubeContextName(), "delete", "secret", name,
+			"-n", ns, "--ignore-not-found"); err != nil {
+			return fmt.Errorf("cannot delete %s in %s: %s", name, ns, out)
+		}
+	}
+
+	if err := removeRegistryCredential(registrySecretServer); err != nil {
+		warn(fmt.Sprintf("Could not remove from local backup: %v", err))
+	} else {
+		success(fmt.Sprintf("Removed from %s/%s", secretsDirName, registriesFileName))
+	}
+	return nil
+}
+
+// ── Cluster helpers ─────────────────────────────────────────────
+
+// pullSecretName returns the Secret name for a registry host, e.g.
+// kindling-pull-ghcr-io for ghcr.io.
+func pullSecretName(server string) string {
+	host := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://"), "/")
+	clean := strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(host), "-"), "-")
+	return pullSecretPrefix + clean
+}
+
+// dockerConfigServer returns the auth key the container runtime looks up
+// for server; Docker Hub images resolve to the legacy index URL.
+func dockerConfigServer(server string) string {
+	switch strings.TrimSuffix(server, "/") {
+	case "docker.io", "index.docker.io", "registry-1.docker.io", "https://index.docker.io/v1":
+		return dockerHubServer
+	}
+	return server
+}
+
+// dockerConfigServers lists the registries in a base64 .dockerconfigjson.
+func dockerConfigServers(encoded string) string {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "?"
+	}
+	var cfg struct {
+		Auths map[string]json.RawMessage `json:"auths"`
+	}
+	if json.Unmarshal(data, &cfg) != nil {
+		return "?"
+	}
+	var servers []string
+	for s := range cfg.Auths {
+		if s == dockerHubServer {
+			s = "docker.io"
+		}
+		servers = append(servers, s)
+	}
+	sort.Strings(servers)
+	return strings.Join(servers, ", ")
+}
+
+// envNamespace returns the namespace of a DevStagingEnvironment.
+func envNamespace(env string) (string, error) {
+	for _, dse := range kubeList("devstagingenvironments", "") {
+		if dse.Metadata.Name == env {
+			return dse.Metadata.Namespace, nil
+		}
+	}
+	return "", fmt.Errorf("DevStagingEnvironment %q not found — see: kindling status", env)
+}
+
+// dseNamespaces returns every namespace that holds a DevStagingEnvironment,
+// or just "default" — where kindling deploy puts them — when there are none
+// yet.
+func dseNamespaces() []string {
+	seen := map[string]bool{}
+	var namespaces []string
+	for _, dse := range kubeList("devstagingenvironments", "") {
+		if ns := dse.Metadata.Namespace; !seen[ns] {
+			seen[ns] = true
+			namespaces = append(namespaces, ns)
+		}
+	}
+	if len(namespaces) == 0 {
+		return []string{"default"}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// componentServiceAccounts returns the service accounts that
+// operator-managed pods in ns run as. Pods without serviceAccountName use
+// "default", which is always included so environments deployed later are
+// covered too.
+func componentServiceAccounts(ns string) []string {
+	out, _ := kubectlJSON("get", "deployments,statefulsets", "-n", ns, "-l", operatorManagedBy,
+		"-o", "jsonpath={range .items[*]}{.spec.template.spec.serviceAccountName}{\"\\n\"}{end}")
+	accounts := []string{"default"}
+	for _, sa := range strings.Fields(out) {
+		if sa != "default" && !containsString(accounts, sa) {
+			accounts = append(accounts, sa)
+		}
+	}
+	return accounts
+}
+
+// serviceAccountPullSecrets maps each service account in ns to the names in
+// its imagePullSecrets.
+func serviceAccountPullSecrets(ns string) map[string][]string {
+	out, err := kubectlJSON("get", "serviceaccounts", "-n", ns, "-o", "json")
+	if err != nil {
+		return nil
+	}
+	var list struct {
+		Items []serviceAccountPullSecretList `json:"items"`
+	}
+	if json.Unmarshal([]byte(out), &list) != nil {
+		return nil
+	}
+	accounts := map[string][]string{}
+	for _, sa := range list.Items {
+		accounts[sa.Metadata.Name] = sa.names()
+	}
+	return accounts
+}
+
+type serviceAccountPullSecretList struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	ImagePullSecrets []struct {
+		Name string `json:"name"`
+	} `json:"imagePullSecrets"`
+}
+
+func (sa serviceAccountPullSecretList) names() []string {
+	var names []string
+	for _, s := range sa.ImagePullSecrets {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// setImagePullSecret adds secret to, or removes it from, the
+// imagePullSecrets of a service account. It reports whether the account
+// changed.
+func setImagePullSecret(ns, sa, secret string, attach bool) (bool, error) {
+	out, err := kubectlJSON("get", "serviceaccount", sa, "-n", ns, "-o", "json")
+	if err != nil {
+		return false, fmt.Errorf("cannot read service account %s/%s: %w", ns, sa, err)
+	}
+	var account serviceAccountPullSecretList
+	if err := json.Unmarshal([]byte(out), &account); err != nil {
+		return false, fmt.Errorf("cannot parse service account %s/%s: %w", ns, sa, err)
+	}
+	current := account.names()
+	if containsString(current, secret) == attach {
+		return false, nil
+	}
+
+	var names []string
+	for _, n := range current {
+		if n != secret {
+			names = append(names, n)
+		}
+	}
+	if attach {
+		names = append(names, secret)
+	}
+	// A JSON merge patch replaces the whole list, keeping unrelated secrets.
+	refs := []map[string]string{}
+	for _, n := range names {
+		refs = append(refs, map[string]string{"name": n})
+	}
+	patch, _ := json.Marshal(map[string]interface{}{"imagePullSecrets": refs})
+	if out, err := runSilent("kubectl", "--context", kubeContextName(), "patch", "serviceaccount", sa,
+		"-n", ns, "--type", "merge", "-p", string(patch)); err != nil {
+		return false, fmt.Errorf("cannot patch service account %s/%s: %s", ns, sa, out)
+	}
+	return true, nil
+}
+
+// restartImagePullFailures deletes operator-managed pods in ns that are
+// stuck pulling an image. Pull secrets are copied from the service account
+// only when a pod is created, so their replacements pick up the new secret.
+func restartImagePullFailures(ns string) {
+	for _, pod := range kubeList("pods", operatorManagedBy) {
+		if pod.Metadata.Namespace != ns {
+			continue
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if w := cs.State.Waiting; w != nil && (w.Reason == "ImagePullBackOff" || w.Reason == "ErrImagePull") {
+				step("🔄", fmt.Sprintf("Restarting %s (%s)", pod.Metadata.Name, w.Reason))
+				_ = runSilent2("kubectl", "--context", kubeContextName(), "delete", "pod", pod.Metadata.Name,
+					"-n", ns, "--wait=false")
+				break
+			}
+		}
+	}
+}
+
+// ── Local registries file (gitignored) ──────────────────────────
+
+func registriesFilePath() string {
+	return filepath.Join(filepath.Dir(secretsFilePath()), registriesFileName)
+}
+
+func loadRegistryCredentials() ([]registryCredential, error) {
+	data, err := os.ReadFile(registriesFilePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var creds []registryCredential
+	if err := yaml.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", registriesFilePath(), err)
+	}
+	return creds, nil
+}
+
+// saveRegistryCredential adds cred to .kindling/registries.yaml, replacing
+// any entry for the same server.
+func saveRegistryCredential(cred registryCredential) error {
+	creds, err := loadRegistryCredentials()
+	if err != nil {
+		return err
+	}
+	kept := []registryCredential{cred}
+	for _, c := range creds {
+		if pullSecretName(c.Server) != pullSecretName(cred.Server) {
+			kept = append(kept, c)
+		}
+	}
+	return writeRegistryCredentials(kept)
+}
+
+func removeRegistryCredential(server string) error {
+	creds, err := loadRegistryCredentials()
+	if err != nil {
+		return err
+	}
+	var kept []registryCredential
+	for _, c := range creds {
+		if pullSecretName(c.Server) != pullSecretName(server) {
+			kept = append(kept, c)
+		}
+	}
+	return writeRegistryCredentials(kept)
+}
+
+func writeRegistryCredentials(creds []registryCredential) error {
+	path := registriesFilePath()
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("cannot create %s: %w", dir, err)
+	}
+	ensureTunnelGitignored(filepath.Dir(dir))
+
+	sort.Slice(creds, func(i, j int) bool { return creds[i].Server < creds[j].Server })
+	data, err := yaml.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	content := "# kindling registry credentials backup\n" +
+		"# This file is gitignored. Do not commit.\n" +
+		"# Tokens are base64-encoded.\n" +
+		"# Restore with: kindling secrets restore\n\n" + string(data)
+	return os.WriteFile(path, []byte(content), 0600)
+}
@@ -0,0 +1,406 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ── Sync ────────────────────────────────────────────────────────
+//
+// kindling secrets sync copies a whole set of variables — a .env file or
+// an item in an external store — into one Secret per component, and adds
+// that Secret to the component's envFrom in its DevStagingEnvironment, so
+// manifests never have to carry the values themselves.
+
+// envSecretPrefix starts the name of every Secret created by secrets sync.
+const envSecretPrefix = "kindling-env-"
+
+var secretsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Load a component's environment from a .env file or secret store",
+	Long: `Creates or updates a Secret named kindling-env-<component> holding every
+variable from one source, and wires it into the component's envFrom in its
+DevStagingEnvironment. Re-run it whenever the source changes; the
+component's pods are restarted to pick up the new values.
+
+Sources:
+  --from-env-file <file>     a dotenv file (KEY=value lines)
+  --from-sops <file>         a SOPS-encrypted file, decrypted with sops -d
+  --from-1password <item>    a 1Password item's fields, read with op
+  --from-vault <path>        a Vault KV secret, read with vault kv get
+
+Components are named the same way as in kindling logs: an environment
+name (its app), a dependency type such as postgres, or a full name such as
+orders-dev-postgres. Variables set in the manifest's env take precedence.
+
+Examples:
+  kindling secrets sync --from-env-file .env --component orders-dev
+  kindling secrets sync --from-sops secrets.enc.env --component orders-dev
+  kindling secrets sync --from-1password "Orders dev" --op-vault Engineering --component orders-dev
+  kindling secrets sync --from-vault secret/orders/dev --component orders-dev`,
+	SilenceUsage: true,
+	RunE:         runSecretsSync,
+}
+
+var (
+	syncComponent   string
+	syncEnv         string
+	syncEnvFile     string
+	syncSops        string
+	syncOnePassword string
+	syncOpVault     string
+	syncVault       string
+)
+
+func init() {
+	f := secretsSyncCmd.Flags()
+	f.StringVar(&syncComponent, "component", "", "Component to load the variables into (required)")
+	f.StringVar(&syncEnv, "env", "", "DevStagingEnvironment to resolve the component in")
+	f.StringVar(&syncEnvFile, "from-env-file", "", "Read variables from a dotenv file")
+	f.StringVar(&syncSops, "from-sops", "", "Read variables from a SOPS-encrypted file")
+	f.StringVar(&syncOnePassword, "from-1password", "", "Read variables from the fields of a 1Password item")
+	f.StringVar(&syncOpVault, "op-vault", "", "1Password vault that holds the item")
+	f.StringVar(&syncVault, "from-vault", "", "Read variables from a Vault KV path")
+	_ = secretsSyncCmd.MarkFlagRequired("component")
+	_ = secretsSyncCmd.RegisterFlagCompletionFunc("component", completeComponents)
+	_ = secretsSyncCmd.RegisterFlagCompletionFunc("env", completeEnvFlag)
+	secretsSyncCmd.MarkFlagsOneRequired("from-env-file", "from-sops", "from-1password", "from-vault")
+	secretsSyncCmd.MarkFlagsMutuallyExclusive("from-env-file", "from-sops", "from-1password", "from-vault")
+	secretsCmd.AddCommand(secretsSyncCmd)
+}
+
+// syncTarget is the place in a DevStagingEnvironment a component's
+// container is configured.
+type syncTarget struct {
+	env       string // DevStagingEnvironment name
+	namespace string
+	component string // Deployment name
+	path      string // JSON pointer to the container spec, e.g. /spec/deployment
+	envFrom   []map[string]interface{}
+}
+
+func runSecretsSync(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	target, err := resolveSyncTarget(syncComponent, syncEnv)
+	if err != nil {
+		return err
+	}
+
+	header("Syncing secrets")
+	source, values, err := readSyncSource()
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("%s has no variables", source)
+	}
+	step("📄", fmt.Sprintf("Read %d variable(s) from %s", len(values), source))
+
+	name := envSecretPrefix + target.component
+	secret := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"type":       "Opaque",
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"namespace":   target.namespace,
+			"labels":      map[string]string{secretsLabelKey: secretsLabelValue},
+			"annotations": map[string]string{"kindling.dev/sync-source": source},
+		},
+		"stringData": values,
+	}
+	manifest, _ := json.Marshal(secret)
+	step("☸️", fmt.Sprintf("Applying Secret %s in namespace %s", name, target.namespace))
+	// Replace rather than apply, so keys removed from the source go away too.
+	if out, err := runSilent("kubectl", "--context", kubeContextName(), "delete", "secret", name,
+		"-n", target.namespace, "--ignore-not-found"); err != nil {
+		return fmt.Errorf("cannot replace secret %s: %s", name, out)
+	}
+	if err := runStdin(string(manifest), "kubectl", "--context", kubeContextName(), "create", "-f", "-"); err != nil {
+		return fmt.Errorf("cannot create secret %s: %w", name, err)
+	}
+
+	if target.referencesSecret(name) {
+		// The pod spec is unchanged, so the operator won't roll the pods.
+		step("🔄", fmt.Sprintf("Restarting %s to load the new values", target.component))
+		workload, err := componentWorkload(target.namespace, target.component)
+		if err == nil {
+			if out, rerr := runSilent("kubectl", "--context", kubeContextName(), "rollout", "restart",
+				workload, "-n", target.namespace); rerr != nil {
+				err = fmt.Errorf("%s", out)
+			}
+		}
+		if err != nil {
+			warn(fmt.Sprintf("Could not restart %s: %v", target.component, err))
+		}
+	} else {
+		step("🔗", fmt.Sprintf("Adding %s to the envFrom of %s", name, target.component))
+		if err := target.addSecretRef(name); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	success(fmt.Sprintf("%s now loads %s", target.component, strings.Join(keys, ", ")))
+	fmt.Println()
+	return nil
+}
+
+// resolveSyncTarget finds the DevStagingEnvironment entry of a component.
+func resolveSyncTarget(component, env string) (syncTarget, error) {
+	out, err := kubectlJSON("get", "devstagingenvironments", "-A", "-o", "json")
+	if err != nil {
+		return syncTarget{}, fmt.Errorf("cannot list DevStagingEnvironments: %w", err)
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+			Spec struct {
+				Deployment struct {
+					EnvFrom []map[string]interface{} `json:"envFrom"`
+				} `json:"deployment"`
+				Dependencies []struct {
+					Type    string                   `json:"type"`
+					EnvFrom []map[string]interface{} `json:"envFrom"`
+				} `json:"dependencies"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return syncTarget{}, fmt.Errorf("cannot parse DevStagingEnvironments: %w", err)
+	}
+
+	var matches []syncTarget
+	found := env == ""
+	for _, dse := range list.Items {
+		name, ns := dse.Metadata.Name, dse.Metadata.Namespace
+		if env != "" && name != env {
+			continue
+		}
+		found = true
+		if component == name {
+			matches = append(matches, syncTarget{env: name, namespace: ns, component: name,
+				path: "/spec/deployment", envFrom: dse.Spec.Deployment.EnvFrom})
+		}
+		for i, dep := range dse.Spec.Dependencies {
+			full := name + "-" + dep.Type
+			if component == dep.Type || component == full {
+				matches = append(matches, syncTarget{env: name, namespace: ns, component: full,
+					path: fmt.Sprintf("/spec/dependencies/%d", i), envFrom: dep.EnvFrom})
+			}
+		}
+	}
+	switch {
+	case !found:
+		return syncTarget{}, fmt.Errorf("DevStagingEnvironment %q not found — see: kindling status", env)
+	case len(matches) == 0:
+		return syncTarget{}, fmt.Errorf("no component matches %s — see: kindling status", describeLogTarget(component, env))
+	case len(matches) > 1:
+		var names []string
+		for _, m := range matches {
+			names = append(names, m.component)
+		}
+		return syncTarget{}, fmt.Errorf("%q matches %s — pass the full name or --env", component, strings.Join(names, ", "))
+	}
+	return matches[0], nil
+}
+
+func (t syncTarget) referencesSecret(name string) bool {
+	for _, src := range t.envFrom {
+		if ref, ok := src["secretRef"].(map[string]interface{}); ok && ref["name"] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addSecretRef appends a secretRef to the target's envFrom with a JSON
+// patch, leaving any other sources in place.
+func (t syncTarget) addSecretRef(name string) error {
+	ref := map[string]interface{}{"secretRef": map[string]string{"name": name}}
+	op := map[string]interface{}{"op": "add", "path": t.path + "/envFrom/-", "value": ref}
+	if len(t.envFrom) == 0 {
+		op = map[string]interface{}{"op": "add", "path": t.path + "/envFrom", "value": []interface{}{ref}}
+	}
+	patch, _ := json.Marshal([]interface{}{op})
+	if out, err := runSilent("kubectl", "--context", kubeContextName(), "patch", "devstagingenvironment", t.env,
+		"-n", t.namespace, "--type", "json", "-p", string(patch)); err != nil {
+		return fmt.Errorf("cannot patch %s: %s", t.env, out)
+	}
+	return nil
+}
+
+// ── Sources ─────────────────────────────────────────────────────
+
+// readSyncSource reads the variables from whichever source flag was given
+// and returns a description of the source along with them.
+func readSyncSource() (string, map[string]string, error) {
+	switch {
+	case syncEnvFile != "":
+		data, err := os.ReadFile(syncEnvFile)
+		if err != nil {
+			return "", nil, fmt.Errorf("cannot read %s: %w", syncEnvFile, err)
+		}
+		values, err := parseDotenv(string(data))
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", syncEnvFile, err)
+		}
+		return syncEnvFile, values, nil
+
+	case syncSops != "":
+		if !commandExists("sops") {
+			return "", nil, fmt.Errorf("sops is not installed — see https://github.com/getsops/sops")
+		}
+		out, err := runCapture("sops", "--decrypt", "--output-type", "dotenv", syncSops)
+		if err != nil {
+			return "", nil, fmt.Errorf("sops could not decrypt %s: %w", syncSops, err)
+		}
+		values, err := parseDotenv(out)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", syncSops, err)
+		}
+		return "sops:" + syncSops, values, nil
+
+	case syncOnePassword != "":
+		if !commandExists("op") {
+			return "", nil, fmt.Errorf("the 1Password CLI (op) is not installed — see https://developer.1password.com/docs/cli")
+		}
+		args := []string{"item", "get", syncOnePassword, "--format", "json"}
+		if syncOpVault != "" {
+			args = append(args, "--vault", syncOpVault)
+		}
+		out, err := runCapture("op", args...)
+		if err != nil {
+			return "", nil, fmt.Errorf("op could not read %q — are you signed in (op signin)? %w", syncOnePassword, err)
+		}
+		values, err := onePasswordFields(out)
+		if err != nil {
+			return "", nil, err
+		}
+		return "1password:" + syncOnePassword, values, nil
+
+	default:
+		if !commandExists("vault") {
+			return "", nil, fmt.Errorf("the Vault CLI is not installed — see https://developer.hashicorp.com/vault/install")
+		}
+		out, err := runCapture("vault", "kv", "get", "-format=json", syncVault)
+		if err != nil {
+			return "", nil, fmt.Errorf("vault could not read %s — check VAULT_ADDR and vault login: %w", syncVault, err)
+		}
+		values, err := vaultKVData(out)
+		if err != nil {
+			return "", nil, err
+		}
+		return "vault:" + syncVault, values, nil
+	}
+}
+
+var envKeyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// parseDotenv parses KEY=value lines. It accepts an optional export
+// prefix, # comments, and single- or double-quoted values; double-quoted
+// values may use \n, \t, \" and \\ escapes.
+func parseDotenv(data string) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !envKeyRe.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=value", n)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			value = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// onePasswordFields turns the fields of `op item get --format json` into
+// variables named after their labels. Empty fields and labels that aren't
+// valid variable names are skipped.
+func onePasswordFields(out string) (map[string]string, error) {
+	var item struct {
+		Fields []struct {
+			Label string `json:"label"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(out), &item); err != nil {
+		return nil, fmt.Errorf("cannot parse op output: %w", err)
+	}
+	values := map[string]string{}
+	for _, f := range item.Fields {
+		if f.Value == "" {
+			continue
+		}
+		if !envKeyRe.MatchString(f.Label) {
+			warn(fmt.Sprintf("Skipping 1Password field %q — not a valid variable name", f.Label))
+			continue
+		}
+		values[f.Label] = f.Value
+	}
+	return values, nil
+}
+
+// vaultKVData returns the key/value pairs of `vault kv get -format=json`,
+// which nests them under data.data for KV v2 and data for KV v1.
+func vaultKVData(out string) (map[string]string, error) {
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		return nil, fmt.Errorf("cannot parse vault output: %w", err)
+	}
+	data := resp.Data
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, v2 := data["metadata"]; v2 {
+			data = inner
+		}
+	}
+	values := map[string]string{}
+	for k, v := range data {
+		if !envKeyRe.MatchString(k) {
+			warn(fmt.Sprintf("Skipping Vault key %q — not a valid variable name", k))
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			values[k] = v
+		default:
+			b, _ := json.Marshal(v)
+			values[k] = string(b)
+		}
+	}
+	return values, nil
+}
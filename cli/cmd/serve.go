@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/jeffvincent/kindling/cli/internal/daemon"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run kindlingd, the local API that IDE plugins and web UIs drive kindling through",
+	Long: `Runs kindlingd: a long-running server with a JSON REST API over the
+core operations — generate, validate, deploy, status, logs (streamed),
+and expose — so IDE plugins and web UIs can drive kindling without
+shelling out to the CLI. The same binary installed as kindlingd runs it
+when started without a command. The API is REST only; there is no gRPC
+endpoint.
+
+kindlingd listens on 127.0.0.1:7717 by default. Every request but
+GET /v1/health, which only answers that kindlingd is up, needs the bearer token from the token file, which is
+created on first start and only readable by you:
+
+  curl -H "Authorization: Bearer $(cat ~/.config/kindling/kindlingd.token)" \
+    http://127.0.0.1:7717/v1/status
+
+Each operation runs in the project of the request's projectDir (default:
+the directory kindlingd was started in). Status, logs, and deploy talk
+to the cluster from kindlingd itself; validate, generate, and expose run
+the command, with the user's kindling config, so they behave exactly as
+it would. Request values starting with "-" are refused rather than
+passed to a command. Operations that change the cluster run one at a
+time.
+
+With --detach, kindlingd runs in the background with the other flags
+given: kindling ps lists it, and kindling ps stop kindlingd stops it.
+
+Examples:
+  kindling serve
+  kindling serve --detach
+  kindling serve --addr 127.0.0.1:8080 --cors-origin http://localhost:5173
+  kindlingd`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runServe,
+}
+
+var (
+	serveAddr        string
+	serveTokenFile   string
+	serveDetach      bool
+	serveCORSOrigins []string
+)
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:7717", "Address to listen on")
+	serveCmd.Flags().StringVar(&serveTokenFile, "token-file", "", "File holding the API token, created if missing (default: $XDG_CONFIG_HOME/kindling/kindlingd.token, or KINDLINGD_TOKEN)")
+	serveCmd.Flags().BoolVarP(&serveDetach, "detach", "d", false, "Run in the background, registered with kindling ps")
+	serveCmd.Flags().StringSliceVar(&serveCORSOrigins, "cors-origin", nil, "Browser origin allowed to call the API, e.g. http://localhost:5173 (repeatable)")
+	rootCmd.AddCommand(serveCmd)
+}
+
+// serveDaemon is the name kindlingd is registered under with --detach.
+const serveDaemon = "kindlingd"
+
+func runServe(cmd *cobra.Command, args []string) error {
+	token, tokenFile, err := serveToken()
+	if err != nil {
+		return err
+	}
+	if serveDetach {
+		return detachServe(cmd, tokenFile)
+	}
+
+	listener, err := net.Listen("tcp", serveAddr)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", serveAddr, err)
+	}
+	if host, _, _ := net.SplitHostPort(serveAddr); !isLoopbackHost(host) {
+		warn(fmt.Sprintf("kindlingd listens on %s, beyond this machine — anyone with the token can deploy to your cluster", serveAddr))
+	}
+
+	dir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return err
+	}
+	api := &serveAPI{projectDir: dir}
+	server := &http.Server{
+		Handler:           serveAuth(token, serveCORS(api.routes())),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		fmt.Fprintln(os.Stderr, "\nShutting down kindlingd...")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	header("kindlingd")
+	step("🌐", fmt.Sprintf("http://%s", listener.Addr()))
+	step("🔑", fmt.Sprintf("Token in %s", tokenFile))
+	step("📁", fmt.Sprintf("Project %s", dir))
+	fmt.Fprintf(os.Stderr, "  %sPress Ctrl+C to stop%s\n\n", colorDim, colorReset)
+
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server error: %w", err)
+	}
+	return nil
+}
+
+// detachServe starts kindlingd again in the background, registered with
+// the project's daemons, with every flag cmd was given but --detach.
+func detachServe(cmd *cobra.Command, tokenFile string) error {
+	registry := daemons()
+	if d, err := registry.Get(serveDaemon); err == nil && d.Alive() {
+		return fmt.Errorf("kindlingd is already running (pid %d) — stop it with: kindling ps stop %s", d.PID, serveDaemon)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	// The cluster may come from KINDLING_CLUSTER or the project's config
+	// rather than a flag; pass the one resolved here.
+	args := []string{"serve", "--addr=" + serveAddr, "--cluster=" + clusterName}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		switch f.Name {
+		case "detach", "addr", "cluster":
+			return
+		}
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			for _, v := range slice.GetSlice() {
+				args = append(args, "--"+f.Name+"="+v)
+			}
+			return
+		}
+		args = append(args, "--"+f.Name+"="+f.Value.String())
+	})
+	d, err := startDaemon(registry, daemon.Daemon{
+		Name:   serveDaemon,
+		Kind:   daemon.KindServer,
+		Health: "http://" + serveAddr + "/v1/health",
+	}, exec.Command(exe, args...))
+	if err != nil {
+		return err
+	}
+	success(fmt.Sprintf("kindlingd running on http://%s (pid %d)", serveAddr, d.PID))
+	fmt.Fprintf(os.Stderr, "  %sToken in %s — stop it with: kindling ps stop %s%s\n\n", colorDim, tokenFile, serveDaemon, colorReset)
+	return nil
+}
+
+// serveToken returns the API token: KINDLINGD_TOKEN, or the contents of
+// the token file, which is created with a random token when missing.
+func serveToken() (token, file string, err error) {
+	if token := os.Getenv("KINDLINGD_TOKEN"); token != "" {
+		return token, "$KINDLINGD_TOKEN", nil
+	}
+	file = serveTokenFile
+	if file == "" {
+		config, err := userConfigPath()
+		if err != nil {
+			return "", "", err
+		}
+		file = filepath.Join(filepath.Dir(config), "kindlingd.token")
+	}
+	if data, err := os.ReadFile(file); err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, file, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", "", fmt.Errorf("cannot read the token: %w", err)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(buf)
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(file, []byte(token+"\n"), 0600); err != nil {
+		return "", "", fmt.Errorf("cannot write the token: %w", err)
+	}
+	return token, file, nil
+}
+
+// serveAuth rejects requests without the bearer token. GET /v1/health
+// and CORS preflights are let through, so clients can find the server
+// before they have the token.
+func serveAuth(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/health" || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kindlingd"`)
+			actionErr(w, "missing or wrong bearer token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveCORS lets the --cors-origin origins call the API from a browser.
+func serveCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && slices.Contains(serveCORSOrigins, origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE")
+			w.Header().Add("Vary", "Origin")
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopbackHost reports whether host only accepts connections from this
+// machine.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
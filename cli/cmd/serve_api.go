@@ -0,0 +1,507 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeffvincent/kindling/cli/pkg/deploy"
+	"github.com/jeffvincent/kindling/cli/pkg/kube"
+)
+
+// serveAPI serves kindlingd's REST API. Status, logs, and deploy go
+// through the cluster client in this process. Validate, generate, and
+// expose run this same executable as a child process in the request's
+// project, so they behave exactly like the command and can't leak flag
+// state into the next request.
+type serveAPI struct {
+	projectDir string
+
+	// mu serializes the operations that change the cluster or the repo.
+	mu sync.Mutex
+}
+
+func (a *serveAPI) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/health", a.handleHealth)
+	mux.HandleFunc("GET /v1/status", a.handleStatus)
+	mux.HandleFunc("POST /v1/validate", a.handleValidate)
+	mux.HandleFunc("POST /v1/deploy", a.handleDeploy)
+	mux.HandleFunc("POST /v1/generate", a.handleGenerate)
+	mux.HandleFunc("GET /v1/logs", a.handleLogs)
+	mux.HandleFunc("GET /v1/expose", a.handleExposeList)
+	mux.HandleFunc("POST /v1/expose", a.handleExpose)
+	mux.HandleFunc("DELETE /v1/expose", a.handleUnexpose)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		actionErr(w, fmt.Sprintf("no endpoint %s %s", r.Method, r.URL.Path), http.StatusNotFound)
+	})
+	return mux
+}
+
+// handleHealth answers without the token, so it says nothing but that
+// kindlingd is up.
+func (a *serveAPI) handleHealth(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, map[string]interface{}{"ok": true})
+}
+
+func (a *serveAPI) handleStatus(w http.ResponseWriter, r *http.Request) {
+	dir, err := a.requestDir(r.URL.Query().Get("projectDir"))
+	if err != nil {
+		actionErr(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	jsonResponse(w, collectStatus(projectEnvironment(dir)))
+}
+
+type serveValidateRequest struct {
+	ProjectDir string `json:"projectDir"`
+	File       string `json:"file"`
+	RepoPath   string `json:"repoPath"`
+}
+
+func (a *serveAPI) handleValidate(w http.ResponseWriter, r *http.Request) {
+	var req serveValidateRequest
+	dir, ok := a.decode(w, r, &req, &req.ProjectDir)
+	if !ok {
+		return
+	}
+	if req.File == "" {
+		actionErr(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	args, err := optionArgs([]string{"validate", "-o", outputJSON}, "--file", req.File, "--repo-path", req.RepoPath)
+	if err != nil {
+		actionErr(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// A report with errors exits non-zero but is still the answer.
+	res := a.run(r.Context(), dir, args...)
+	if res.err != nil && json.Valid(res.stdout) {
+		res.err = nil
+	}
+	a.respond(w, res)
+}
+
+type serveDeployRequest struct {
+	ProjectDir string `json:"projectDir"`
+	File       string `json:"file"`
+	Env        string `json:"env"`
+}
+
+func (a *serveAPI) handleDeploy(w http.ResponseWriter, r *http.Request) {
+	var req serveDeployRequest
+	dir, ok := a.decode(w, r, &req, &req.ProjectDir)
+	if !ok {
+		return
+	}
+	if req.File == "" {
+		actionErr(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	file := req.File
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	manifest, err := os.ReadFile(file)
+	if err != nil {
+		actionErr(w, fmt.Sprintf("cannot read %s: %v", req.File, err), http.StatusBadRequest)
+		return
+	}
+	env, err := resolveEnvName(req.Env, false, dir)
+	if err != nil {
+		actionErr(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if env == "" {
+		env = projectEnvironment(dir)
+	}
+	c, err := kubeClient()
+	if err != nil {
+		actionErr(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	capacity, _ := readClusterCapacity()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	result, err := deploy.Deploy(r.Context(), c, manifest, deploy.Options{Environment: env, Capacity: capacity})
+	if err != nil {
+		actionErr(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	jsonResponse(w, result)
+}
+
+type serveGenerateRequest struct {
+	ProjectDir  string `json:"projectDir"`
+	RepoPath    string `json:"repoPath"`
+	LLMProvider string `json:"llmProvider"`
+	Model       string `json:"model"`
+	Branch      string `json:"branch"`
+	Output      string `json:"output"`
+	NoAI        bool   `json:"noAI"`
+	DryRun      bool   `json:"dryRun"`
+	NoCache     bool   `json:"noCache"`
+}
+
+// handleGenerate runs kindling generate. The API key comes from
+// kindlingd's environment or the user's config, never from the request.
+func (a *serveAPI) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	var req serveGenerateRequest
+	dir, ok := a.decode(w, r, &req, &req.ProjectDir)
+	if !ok {
+		return
+	}
+	args, err := optionArgs([]string{"generate"}, "--repo-path", req.RepoPath, "--llm-provider", req.LLMProvider,
+		"--model", req.Model, "--branch", req.Branch, "--output", req.Output)
+	if err != nil {
+		actionErr(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.NoAI {
+		args = append(args, "--no-ai")
+	}
+	if req.DryRun {
+		args = append(args, "--dry-run")
+	}
+	if req.NoCache {
+		args = append(args, "--no-cache")
+	}
+
+	if !req.DryRun {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+	}
+	res := a.run(r.Context(), dir, args...)
+	if res.err != nil {
+		a.respond(w, res)
+		return
+	}
+	// generate has no JSON output: a dry run's stdout is the YAML, and
+	// the progress on stderr names the file it wrote.
+	jsonResponse(w, map[string]interface{}{
+		"ok":      true,
+		"content": string(res.stdout),
+		"log":     stripANSI(string(res.stderr)),
+	})
+}
+
+// handleLogs streams the logs of a component's pods as plain text, a line
+// per write behind the pod's name, until the client goes away. Without a
+// component or env it streams the controller's. With follow=false it
+// returns the current logs as JSON instead.
+func (a *serveAPI) handleLogs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	dir, err := a.requestDir(q.Get("projectDir"))
+	if err != nil {
+		actionErr(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts := kube.LogOptions{Container: q.Get("container"), Follow: true}
+	if v := q.Get("follow"); v != "" {
+		if opts.Follow, err = strconv.ParseBool(v); err != nil {
+			actionErr(w, "follow must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+	since := q.Get("since")
+	if since == "" {
+		since = "5m"
+	}
+	if opts.Since, err = time.ParseDuration(since); err != nil {
+		actionErr(w, fmt.Sprintf("invalid since %q: %v", since, err), http.StatusBadRequest)
+		return
+	}
+
+	component, env := q.Get("component"), q.Get("env")
+	var sources []logSource
+	if component == "" && env == "" {
+		sources = controllerLogSources()
+		if opts.Container == "" {
+			opts.Container = "manager"
+		}
+	} else {
+		if env == "" {
+			env = projectEnvironment(dir)
+		}
+		comps, err := matchComponents(collectEnvironments(), component, env)
+		if err != nil {
+			actionErr(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		sources = logSources(comps)
+	}
+	if len(sources) == 0 {
+		actionErr(w, fmt.Sprintf("no pods found for %s", describeLogTarget(component, env)), http.StatusNotFound)
+		return
+	}
+	c, err := kubeClient()
+	if err != nil {
+		actionErr(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !opts.Follow {
+		entries := []logEntry{}
+		for _, src := range sources {
+			err := c.PodLogs(r.Context(), src.namespace, src.pod, opts, func(line string) {
+				if line != "" {
+					entries = append(entries, logEntry{Component: src.component, Pod: src.pod, Line: line})
+				}
+			})
+			if err != nil {
+				actionErr(w, fmt.Sprintf("reading the logs of %s failed: %v", src.pod, err), http.StatusUnprocessableEntity)
+				return
+			}
+		}
+		jsonResponse(w, struct {
+			Lines []logEntry `json:"lines"`
+		}{entries})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	flusher, _ := w.(http.Flusher)
+	var mu sync.Mutex
+	write := func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintln(w, line)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	var wg sync.WaitGroup
+	for _, src := range sources {
+		prefix := stripANSI(src.prefix)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.PodLogs(r.Context(), src.namespace, src.pod, opts, func(line string) {
+				write(prefix + line)
+			})
+			if err != nil && r.Context().Err() == nil {
+				write(prefix + err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// controllerLogSources are the controller-manager pods, unprefixed like
+// kindling logs prints them.
+func controllerLogSources() []logSource {
+	var sources []logSource
+	for _, row := range statusRows("pods", "kindling-system", "control-plane=controller-manager", func(o map[string]interface{}) map[string]string {
+		return map[string]string{"name": statusField(o, "metadata", "name")}
+	}) {
+		sources = append(sources, logSource{component: "controller", namespace: "kindling-system", pod: row["name"]})
+	}
+	return sources
+}
+
+func (a *serveAPI) handleExposeList(w http.ResponseWriter, r *http.Request) {
+	dir, err := a.requestDir(r.URL.Query().Get("projectDir"))
+	if err != nil {
+		actionErr(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a.respond(w, a.run(r.Context(), dir, "expose", "--list", "-o", outputJSON))
+}
+
+type serveExposeRequest struct {
+	ProjectDir string `json:"projectDir"`
+	Service    string `json:"service"`
+	Provider   string `json:"provider"`
+	Port       int    `json:"port"`
+	TunnelName string `json:"tunnelName"`
+	Hostname   string `json:"hostname"`
+}
+
+func (a *serveAPI) handleExpose(w http.ResponseWriter, r *http.Request) {
+	var req serveExposeRequest
+	dir, ok := a.decode(w, r, &req, &req.ProjectDir)
+	if !ok {
+		return
+	}
+	port := ""
+	if req.Port != 0 {
+		port = strconv.Itoa(req.Port)
+	}
+	args, err := optionArgs([]string{"expose", "-o", outputJSON}, "--service", req.Service, "--provider", req.Provider,
+		"--port", port, "--tunnel-name", req.TunnelName, "--hostname", req.Hostname)
+	if err != nil {
+		actionErr(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.respond(w, a.run(r.Context(), dir, args...))
+}
+
+func (a *serveAPI) handleUnexpose(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	dir, err := a.requestDir(q.Get("projectDir"))
+	if err != nil {
+		actionErr(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	args, err := optionArgs([]string{"expose", "--stop", "-o", outputJSON}, "--service", q.Get("service"))
+	if err != nil {
+		actionErr(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.respond(w, a.run(r.Context(), dir, args...))
+}
+
+// decode reads a JSON request body into req and resolves its projectDir,
+// writing a 400 when either is wrong.
+func (a *serveAPI) decode(w http.ResponseWriter, r *http.Request, req interface{}, projectDir *string) (string, bool) {
+	if r.ContentLength != 0 {
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(req); err != nil {
+			actionErr(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return "", false
+		}
+	}
+	dir, err := a.requestDir(*projectDir)
+	if err != nil {
+		actionErr(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+	return dir, true
+}
+
+// requestDir resolves a request's projectDir against the directory
+// kindlingd was started in.
+func (a *serveAPI) requestDir(dir string) (string, error) {
+	if dir == "" {
+		return a.projectDir, nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(a.projectDir, dir)
+	}
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return "", fmt.Errorf("projectDir %s is not a directory", dir)
+	}
+	return dir, nil
+}
+
+// optionArgs appends each flag of flagValues, a list of flag and value
+// pairs, whose value isn't empty. A value starting with "-" is refused:
+// the child command would read it as a flag of its own.
+func optionArgs(args []string, flagValues ...string) ([]string, error) {
+	for i := 0; i+1 < len(flagValues); i += 2 {
+		flag, value := flagValues[i], flagValues[i+1]
+		if value == "" {
+			continue
+		}
+		if strings.HasPrefix(value, "-") {
+			return nil, fmt.Errorf("%s can't start with \"-\"", strings.TrimPrefix(flag, "--"))
+		}
+		args = append(args, flag, value)
+	}
+	return args, nil
+}
+
+// serveResult is the output of one child kindling command.
+type serveResult struct {
+	stdout []byte
+	stderr []byte
+	err    error
+}
+
+// command builds a child kindling command for dir, carrying over the
+// cluster and registry flags kindlingd was started with.
+func (a *serveAPI) command(ctx context.Context, dir string, args ...string) (*exec.Cmd, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, "--cluster="+clusterName)
+	if kubeconfigPath != "" {
+		args = append(args, "--kubeconfig="+kubeconfigPath)
+	}
+	if kubeContext != "" {
+		args = append(args, "--context="+kubeContext)
+	}
+	if imageRegistry != "" {
+		args = append(args, "--image-registry="+imageRegistry)
+	}
+	if useKubectl {
+		args = append(args, "--kubectl")
+	}
+	cmd := exec.CommandContext(ctx, exe, args...)
+	cmd.Dir = dir
+	return cmd, nil
+}
+
+func (a *serveAPI) run(ctx context.Context, dir string, args ...string) serveResult {
+	cmd, err := a.command(ctx, dir, args...)
+	if err != nil {
+		return serveResult{err: err}
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	return serveResult{stdout: stdout.Bytes(), stderr: stderr.Bytes(), err: err}
+}
+
+// respond writes a command's JSON output as the response, or its error
+// with the end of what it printed to stderr.
+func (a *serveAPI) respond(w http.ResponseWriter, res serveResult) {
+	if res.err != nil {
+		code := http.StatusUnprocessableEntity
+		var exitErr *exec.ExitError
+		if !errors.As(res.err, &exitErr) {
+			code = http.StatusInternalServerError
+		}
+		actionErr(w, commandError(res.stderr, res.err), code)
+		return
+	}
+	out := bytes.TrimSpace(res.stdout)
+	if !json.Valid(out) {
+		actionOK(w, stripANSI(string(out)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(out, '\n'))
+}
+
+// commandError is the message for a failed child command: the last lines
+// it printed to stderr, or the exit error when it printed nothing.
+func commandError(stderr []byte, err error) string {
+	msg := strings.TrimSpace(stripANSI(string(stderr)))
+	if msg == "" {
+		return err.Error()
+	}
+	lines := strings.Split(msg, "\n")
+	if len(lines) > 20 {
+		lines = lines[len(lines)-20:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// stripANSI removes the terminal colors from a command's output.
+func stripANSI(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
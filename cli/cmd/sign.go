@@ -0,0 +1,398 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+var signCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Sign a DevStagingEnvironment manifest, and its images, with Sigstore",
+	Long: `Signs every DevStagingEnvironment of a manifest with cosign and writes
+the signature into the manifest, so a shared environment definition
+carries who published it wherever it is copied.
+
+Each DevStagingEnvironment gets two annotations: the environment as it
+was signed (kindling.dev/signed-manifest) and the Sigstore bundle of the
+signature (kindling.dev/signature-bundle). They travel with the
+manifest into the cluster, where an operator started with
+--require-signed-environments only reconciles environments whose
+signature verifies and whose spec is still the signed one.
+
+Signing is keyless by default: cosign has you log in with your OIDC
+provider, or uses the workflow's identity in GitHub Actions, and the
+signature is recorded in the Rekor transparency log. --key signs with a
+cosign key pair instead. --images also signs the images the environment
+runs, which must be in a registry.
+
+--verify checks the signatures of a manifest instead of signing it, and
+that nothing has changed since.
+
+Examples:
+  kindling sign -f dev-environment.yaml
+  kindling sign -f dev-environment.yaml --images
+  kindling sign -f dev-environment.yaml --key cosign.key
+  kindling sign -f dev-environment.yaml --verify --identity platform@acme.com --oidc-issuer https://accounts.google.com
+  kindling sign -f dev-environment.yaml --verify --key cosign.pub`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runSign,
+}
+
+var (
+	signFile     string
+	signKey      string
+	signImages   bool
+	signVerify   bool
+	signIdentity string
+	signIssuer   string
+)
+
+func init() {
+	signCmd.Flags().StringVarP(&signFile, "file", "f", "", "DevStagingEnvironment manifest to sign (required)")
+	signCmd.Flags().StringVar(&signKey, "key", "", "cosign private key to sign with, or public key to verify with (default: keyless)")
+	signCmd.Flags().BoolVar(&signImages, "images", false, "Also sign the images the environments run")
+	signCmd.Flags().BoolVar(&signVerify, "verify", false, "Verify the manifest's signatures instead of signing it")
+	signCmd.Flags().StringVar(&signIdentity, "identity", "", "With --verify: regular expression the keyless signer's email or URI must match")
+	signCmd.Flags().StringVar(&signIssuer, "oidc-issuer", "", "With --verify: OIDC issuer the keyless signer logged in with")
+	_ = signCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(signCmd)
+}
+
+// The annotations of a signed DevStagingEnvironment, which the operator
+// verifies (internal/controller/devstagingenvironment_signature.go).
+const (
+	signedManifestAnnotation  = "kindling.dev/signed-manifest"
+	signatureBundleAnnotation = "kindling.dev/signature-bundle"
+)
+
+// signResult is the JSON form of sign.
+type signResult struct {
+	File      string         `json:"file"`
+	Resources []signedEntity `json:"resources"`
+	Images    []signedEntity `json:"images"`
+}
+
+type signedEntity struct {
+	Name     string `json:"name"`
+	Signed   bool   `json:"signed"`
+	Verified bool   `json:"verified,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+func runSign(cmd *cobra.Command, args []string) error {
+	if !commandExists("cosign") {
+		return fmt.Errorf("signing needs cosign — install it: https://docs.sigstore.dev/cosign/system_config/installation/")
+	}
+	if signVerify && signKey == "" && (signIdentity == "" || signIssuer == "") {
+		return fmt.Errorf("--verify needs --key, or --identity and --oidc-issuer for keyless signatures")
+	}
+	data, err := os.ReadFile(signFile)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", signFile, err)
+	}
+	docs, err := decodeDocuments(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", signFile, err)
+	}
+
+	tmp, err := os.MkdirTemp("", "kindling-sign-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	result := signResult{File: signFile, Resources: []signedEntity{}, Images: []signedEntity{}}
+	if signVerify {
+		header(fmt.Sprintf("Verifying %s", signFile))
+	} else {
+		header(fmt.Sprintf("Signing %s", signFile))
+	}
+	var images []string
+	failed := 0
+	for _, doc := range docs {
+		root := doc.Content[0]
+		if kind := devstaging.MappingValue(root, "kind"); root.Kind != yaml.MappingNode || kind == nil || kind.Value != "DevStagingEnvironment" {
+			continue
+		}
+		name := manifestName(root)
+		images = append(images, manifestImages(root)...)
+
+		payload, err := signingPayload(root)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		payloadPath, bundlePath := filepath.Join(tmp, name+".json"), filepath.Join(tmp, name+".sigstore.json")
+		if err := os.WriteFile(payloadPath, payload, 0600); err != nil {
+			return err
+		}
+
+		if signVerify {
+			entity := signedEntity{Name: name, Signed: true}
+			if err := verifySignedDocument(root, payload, payloadPath, bundlePath); err != nil {
+				entity.Detail = err.Error()
+				failed++
+				fail(fmt.Sprintf("%s: %v", name, err))
+			} else {
+				entity.Verified = true
+				success(fmt.Sprintf("%s: the signature verifies", name))
+			}
+			result.Resources = append(result.Resources, entity)
+			continue
+		}
+
+		step("✍️ ", fmt.Sprintf("Signing %s", name))
+		signArgs := []string{"sign-blob", "--yes", "--new-bundle-format", "--bundle", bundlePath}
+		if signKey != "" {
+			signArgs = append(signArgs, "--key", signKey)
+		}
+		if err := cosign(append(signArgs, payloadPath)...); err != nil {
+			return fmt.Errorf("cosign could not sign %s: %w", name, err)
+		}
+		bundle, err := os.ReadFile(bundlePath)
+		if err != nil {
+			return err
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, bundle); err != nil {
+			return fmt.Errorf("cosign wrote an invalid bundle: %w", err)
+		}
+		annotations := ensureMapping(ensureMapping(root, "metadata"), "annotations")
+		setMappingScalar(annotations, signedManifestAnnotation, string(payload))
+		setMappingScalar(annotations, signatureBundleAnnotation, compact.String())
+		result.Resources = append(result.Resources, signedEntity{Name: name, Signed: true})
+	}
+	if len(result.Resources) == 0 {
+		return fmt.Errorf("no DevStagingEnvironment in %s", signFile)
+	}
+
+	if !signVerify {
+		out, err := encodeDocuments(docs)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(signFile, out, 0644); err != nil {
+			return fmt.Errorf("cannot write %s: %w", signFile, err)
+		}
+		success(fmt.Sprintf("Signed %d DevStagingEnvironment(s) in %s", len(result.Resources), signFile))
+	}
+
+	if signImages {
+		seen := map[string]bool{}
+		for _, image := range images {
+			if seen[image] {
+				continue
+			}
+			seen[image] = true
+			entity := signedEntity{Name: image}
+			switch {
+			case !inRegistry(image):
+				entity.Detail = "not in a registry — push it first"
+				warn(fmt.Sprintf("%s: %s", image, entity.Detail))
+			case signVerify:
+				entity.Signed = true
+				verifyArgs := append([]string{"verify"}, cosignIdentityArgs()...)
+				if out, err := runSilent("cosign", append(verifyArgs, image)...); err != nil {
+					entity.Detail = lastLines(out, 1)
+					failed++
+					fail(fmt.Sprintf("%s: %s", image, entity.Detail))
+				} else {
+					entity.Verified = true
+					success(fmt.Sprintf("%s: the signature verifies", image))
+				}
+			default:
+				step("✍️ ", fmt.Sprintf("Signing %s", image))
+				signArgs := []string{"sign", "--yes"}
+				if signKey != "" {
+					signArgs = append(signArgs, "--key", signKey)
+				}
+				if err := cosign(append(signArgs, image)...); err != nil {
+					return fmt.Errorf("cosign could not sign %s: %w", image, err)
+				}
+				entity.Signed = true
+				success(fmt.Sprintf("Signed %s", image))
+			}
+			result.Images = append(result.Images, entity)
+		}
+	}
+
+	if err := render(result, func() { fmt.Println() }); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d signature(s) in %s don't verify", failed, signFile)
+	}
+	return nil
+}
+
+// verifySignedDocument checks that root's signature verifies and that root
+// hasn't changed since it was signed.
+func verifySignedDocument(root *yaml.Node, payload []byte, payloadPath, bundlePath string) error {
+	signed, bundle := annotation(root, signedManifestAnnotation), annotation(root, signatureBundleAnnotation)
+	if signed == "" || bundle == "" {
+		return fmt.Errorf("not signed")
+	}
+	if signed != string(payload) {
+		return fmt.Errorf("changed since it was signed — sign it again")
+	}
+	if err := os.WriteFile(bundlePath, []byte(bundle), 0600); err != nil {
+		return err
+	}
+	args := append([]string{"verify-blob", "--new-bundle-format", "--bundle", bundlePath}, cosignIdentityArgs()...)
+	if out, err := runSilent("cosign", append(args, payloadPath)...); err != nil {
+		return errors.New(lastLines(out, 1))
+	}
+	return nil
+}
+
+// cosignIdentityArgs returns the flags that tell cosign whose signature
+// to accept.
+func cosignIdentityArgs() []string {
+	if signKey != "" {
+		return []string{"--key", signKey}
+	}
+	return []string{"--certificate-identity-regexp", "^(?:" + signIdentity + ")$", "--certificate-oidc-issuer", signIssuer}
+}
+
+// cosign runs cosign with its output on stderr, which leaves stdout to
+// -o json and the terminal to a keyless login.
+func cosign(args ...string) error {
+	c := exec.Command("cosign", args...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stderr
+	c.Stderr = os.Stderr
+	return runCommand(c)
+}
+
+// signingPayload returns what is signed of a DevStagingEnvironment: the
+// document as JSON, without its status and signature annotations.
+// encoding/json sorts the keys, so the same manifest always gives the
+// same payload.
+func signingPayload(root *yaml.Node) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := root.Decode(&doc); err != nil {
+		return nil, err
+	}
+	delete(doc, "status")
+	if metadata, ok := doc["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, signedManifestAnnotation)
+			delete(annotations, signatureBundleAnnotation)
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// annotation returns the value of an annotation of a document, or "".
+func annotation(root *yaml.Node, key string) string {
+	if metadata := devstaging.MappingValue(root, "metadata"); metadata != nil {
+		if annotations := devstaging.MappingValue(metadata, "annotations"); annotations != nil {
+			if v := devstaging.MappingValue(annotations, key); v != nil {
+				return v.Value
+			}
+		}
+	}
+	return ""
+}
+
+// manifestName returns metadata.name of a document.
+func manifestName(root *yaml.Node) string {
+	if metadata := devstaging.MappingValue(root, "metadata"); metadata != nil {
+		if n := devstaging.MappingValue(metadata, "name"); n != nil {
+			return n.Value
+		}
+	}
+	return ""
+}
+
+// manifestImages returns the images a DevStagingEnvironment document
+// runs: the app's, its canary's, its init containers', sidecars', and
+// jobs'. Dependencies run public images kindling picks.
+func manifestImages(root *yaml.Node) []string {
+	spec := devstaging.MappingValue(root, "spec")
+	if spec == nil {
+		return nil
+	}
+	var images []string
+	image := func(node *yaml.Node) {
+		if node == nil {
+			return
+		}
+		if v := devstaging.MappingValue(node, "image"); v != nil && v.Value != "" {
+			images = append(images, v.Value)
+		}
+	}
+	each := func(list *yaml.Node) {
+		if list != nil && list.Kind == yaml.SequenceNode {
+			for _, item := range list.Content {
+				image(item)
+			}
+		}
+	}
+	deployment := devstaging.MappingValue(spec, "deployment")
+	image(deployment)
+	image(devstaging.MappingValue(spec, "canary"))
+	if deployment != nil {
+		each(devstaging.MappingValue(deployment, "initContainers"))
+		each(devstaging.MappingValue(deployment, "sidecars"))
+	}
+	each(devstaging.MappingValue(spec, "jobs"))
+	return images
+}
+
+// inRegistry reports whether image names a registry host, as opposed to
+// a local image like myapp:dev that Kind loads straight onto its nodes.
+func inRegistry(image string) bool {
+	host, _, found := strings.Cut(image, "/")
+	return found && (strings.ContainsAny(host, ".:") || host == "localhost")
+}
+
+// decodeDocuments returns the non-empty documents of a YAML stream.
+func decodeDocuments(data []byte) ([]*yaml.Node, error) {
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		if len(doc.Content) > 0 {
+			docs = append(docs, &doc)
+		}
+	}
+}
+
+// encodeDocuments writes docs back as one YAML stream.
+func encodeDocuments(docs []*yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ── CLI/controller version skew ─────────────────────────────────
+//
+// Before a command touches the cluster, the CLI checks what is installed
+// there: the CRDs must serve every API version the CLI writes, and the
+// controller must not be older than the CLI supports (see versionSkew).
+// Either mismatch stops the command — manifests would be rejected, or
+// their newer fields silently dropped — unless --force-version-skew is
+// given. A controller newer than the CLI only warns.
+
+// requiredCRDVersions are the API versions of each CRD that the CLI
+// reads and writes.
+var requiredCRDVersions = map[string][]string{
+	"devstagingenvironments.apps.example.com":  {"v1alpha1", "v1beta1"},
+	"githubactionrunnerpools.apps.example.com": {"v1alpha1"},
+}
+
+// skewExemptCommands don't touch the cluster's kindling resources, create
+// or delete the cluster, or check versions themselves. Subcommands are
+// listed by their path, e.g. "debug bundle".
+var skewExemptCommands = map[string]bool{
+	cobra.ShellCompRequestCmd:       true,
+	cobra.ShellCompNoDescRequestCmd: true,
+	"bundle":                        true,
+	"cache":                         true,
+	"ci":                            true,
+	"debug bundle":                  true,
+	"completion":                    true,
+	"config":                        true,
+	"destroy":                       true,
+	"doctor":                        true,
+	"generate":                      true,
+	"help":                          true,
+	"init":                          true,
+	"migrate":                       true,
+	"ps":                            true,
+	"registry":                      true,
+	"upgrade":                       true,
+	"validate":                      true,
+	"version":                       true,
+}
+
+// checkVersionSkew runs the handshake for cmd. It does nothing when the
+// cluster or the controller isn't there — the command reports that
+// itself.
+func checkVersionSkew(cmd *cobra.Command) error {
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	if forceVersionSkew || skewExemptCommands[topLevelCommand(cmd).Name()] || skewExemptCommands[path] {
+		return nil
+	}
+	if !clusterExists(clusterName) {
+		return nil
+	}
+	controller, installed := controllerVersion()
+	if !installed {
+		return nil
+	}
+
+	problems, newer := versionSkewProblems(controller)
+	if newer != "" {
+		warn(newer + " — update the CLI: kindling upgrade --cli")
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	cmd.SilenceUsage = true
+	return fmt.Errorf("the cluster's kindling install is older than this CLI expects: %s — run: kindling upgrade --controller (or pass --force-version-skew)",
+		strings.Join(problems, "; "))
+}
+
+// versionSkewProblems returns what makes the cluster's install too old
+// for this CLI, and the skew when the controller is instead too new.
+func versionSkewProblems(controller string) (problems []string, newer string) {
+	problems = missingCRDVersions()
+	if reason := versionSkew(Version, controller); reason != "" {
+		c, _ := parseSemver(Version)
+		s, _ := parseSemver(controller)
+		if s.less(c) {
+			problems = append(problems, reason)
+		} else {
+			newer = reason
+		}
+	}
+	return problems, newer
+}
+
+// missingCRDVersions lists the required API versions the cluster's CRDs
+// don't serve.
+func missingCRDVersions() []string {
+	var missing []string
+	for _, crd := range sortedKeys(requiredCRDVersions) {
+		obj, err := getObject("customresourcedefinitions", "", crd)
+		if err != nil {
+			missing = append(missing, "CRD "+crd+" is not installed")
+			continue
+		}
+		served := map[string]bool{}
+		versions, _, _ := unstructured.NestedSlice(obj, "spec", "versions")
+		for _, v := range versions {
+			v, _ := v.(map[string]interface{})
+			if name, _ := v["name"].(string); name != "" && v["served"] == true {
+				served[name] = true
+			}
+		}
+		for _, want := range requiredCRDVersions[crd] {
+			if !served[want] {
+				missing = append(missing, fmt.Sprintf("CRD %s doesn't serve %s", crd, want))
+			}
+		}
+	}
+	return missing
+}
+
+// topLevelCommand returns the child of the root command that cmd is, or
+// is nested under.
+func topLevelCommand(cmd *cobra.Command) *cobra.Command {
+	for cmd.HasParent() && cmd.Parent().HasParent() {
+		cmd = cmd.Parent()
+	}
+	return cmd
+}
@@ -0,0 +1,722 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+// ── Snapshots ───────────────────────────────────────────────────
+//
+// A snapshot is a gzipped tarball holding everything needed to bring an
+// environment back: the DSE itself, the ConfigMaps and Secrets its spec
+// references, and the contents of its dependencies' volumes. Volumes are
+// copied through a short-lived helper Job that mounts the claim while the
+// dependency's StatefulSet is scaled to zero, so the copy is consistent.
+// The operator only touches a StatefulSet's replicas when the DSE spec
+// changes, so it leaves the scaled-down set alone meanwhile.
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save an environment to a tarball and restore it later",
+	Long: `Captures a DevStagingEnvironment — its spec, the ConfigMaps and Secrets
+it references, and the data in its dependencies' volumes — into a local
+tarball, so a broken environment can be rolled back to a known-good state
+or handed to a teammate.
+
+Snapshots are stored as .kindling/snapshots/<name>.tar.gz. They contain
+Secret values in plain text; share them accordingly.
+
+Examples:
+  kindling snapshot create myuser-app
+  kindling snapshot create myuser-app --name seeded
+  kindling snapshot list
+  kindling snapshot restore seeded
+  kindling snapshot restore ~/Downloads/myuser-app-20260301-101500.tar.gz`,
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create <env-name>",
+	Short: "Snapshot a DevStagingEnvironment",
+	Long: `Writes the environment's DSE, referenced ConfigMaps and Secrets, and
+dependency volume contents to .kindling/snapshots/<name>.tar.gz.
+
+Each stateful dependency is stopped while its volume is copied and started
+again afterwards. --live copies volumes from the running dependencies
+instead, which is faster but may catch a database mid-write.`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeDSEs),
+	RunE:              runSnapshotCreate,
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <name|file>",
+	Short: "Restore an environment from a snapshot",
+	Long: `Re-applies a snapshot's ConfigMaps, Secrets, and DSE, then replaces the
+contents of each dependency volume with the saved copy. The environment
+is restored under its original name and namespace, and created if it no
+longer exists.
+
+The argument is a snapshot name from kindling snapshot list, or the path
+of a snapshot tarball.`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeSnapshots),
+	RunE:              runSnapshotRestore,
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the snapshots in .kindling/snapshots",
+	SilenceUsage: true,
+	RunE:         runSnapshotList,
+}
+
+var (
+	snapshotName    string
+	snapshotLive    bool
+	snapshotTimeout time.Duration
+)
+
+func init() {
+	snapshotCreateCmd.Flags().StringVar(&snapshotName, "name", "", "Snapshot name (default: <env-name>-<timestamp>)")
+	snapshotCreateCmd.Flags().BoolVar(&snapshotLive, "live", false, "Copy volumes without stopping the dependencies")
+	snapshotCmd.PersistentFlags().DurationVar(&snapshotTimeout, "timeout", 3*time.Minute, "How long to wait for each workload to stop, start, or become ready")
+	snapshotCmd.AddCommand(snapshotCreateCmd, snapshotRestoreCmd, snapshotListCmd)
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+const (
+	snapshotDir       = "snapshots"
+	snapshotIndexFile = "snapshot.json"
+	snapshotDSEFile   = "devstagingenvironment.yaml"
+	snapshotCMFile    = "configmaps.yaml"
+	snapshotSecFile   = "secrets.yaml"
+	snapshotHelperImg = "busybox:1.36"
+	snapshotMount     = "/data"
+)
+
+// snapshotIndex is snapshot.json, the table of contents of a snapshot.
+type snapshotIndex struct {
+	Version     int              `json:"version"`
+	Environment string           `json:"environment"`
+	Namespace   string           `json:"namespace"`
+	Created     time.Time        `json:"created"`
+	ConfigMaps  []string         `json:"configMaps"`
+	Secrets     []string         `json:"secrets"`
+	Volumes     []snapshotVolume `json:"volumes"`
+}
+
+// snapshotVolume is one dependency volume in a snapshot.
+type snapshotVolume struct {
+	Claim       string `json:"claim"`
+	StatefulSet string `json:"statefulSet"`
+	File        string `json:"file"` // path of the tar.gz inside the snapshot
+	Bytes       int64  `json:"bytes"`
+}
+
+// snapshotsPath returns .kindling/snapshots under the working directory.
+func snapshotsPath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine working directory: %w", err)
+	}
+	return filepath.Join(cwd, ".kindling", snapshotDir), nil
+}
+
+// snapshotFile resolves a snapshot name or path to a tarball path.
+func snapshotFile(arg string) (string, error) {
+	if strings.HasSuffix(arg, ".tar.gz") || strings.ContainsRune(arg, os.PathSeparator) {
+		return arg, nil
+	}
+	dir, err := snapshotsPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, arg+".tar.gz"), nil
+}
+
+// kubectlCtx runs kubectl against the kindling cluster with stdin and
+// stdout attached to the given streams, for the binary volume copies.
+func kubectlCtx(stdin io.Reader, stdout io.Writer, args ...string) error {
+	cmd := exec.Command("kubectl", append([]string{"--context", kubeContextName()}, args...)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := runCommand(cmd); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// ── create ──────────────────────────────────────────────────────
+
+func runSnapshotCreate(cmd *cobra.Command, args []string) error {
+	env := args[0]
+	dses, err := listDSEs()
+	if err != nil {
+		return err
+	}
+	var ns string
+	for _, d := range dses {
+		if d.Metadata.Name == env {
+			if ns != "" {
+				return fmt.Errorf("%q exists in several namespaces", env)
+			}
+			ns = d.Metadata.Namespace
+		}
+	}
+	if ns == "" {
+		return fmt.Errorf("DevStagingEnvironment %q not found — see: kindling status", env)
+	}
+
+	name := snapshotName
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", env, time.Now().Format("20060102-150405"))
+	}
+	path, err := snapshotFile(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("cannot create %s: %w", filepath.Dir(path), err)
+	}
+
+	header(fmt.Sprintf("Snapshotting %s", env))
+	work, err := os.MkdirTemp("", "kindling-snapshot-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(work)
+
+	index := snapshotIndex{Version: 1, Environment: env, Namespace: ns, Created: time.Now().UTC(),
+		ConfigMaps: []string{}, Secrets: []string{}, Volumes: []snapshotVolume{}}
+
+	// ── DSE and the objects it references ───────────────────────
+	dseOut, err := kubectlJSON("get", "devstagingenvironment", env, "-n", ns, "-o", "json")
+	if err != nil {
+		return fmt.Errorf("cannot read DevStagingEnvironment %s", env)
+	}
+	var dse map[string]interface{}
+	if err := json.Unmarshal([]byte(dseOut), &dse); err != nil {
+		return fmt.Errorf("cannot parse DevStagingEnvironment %s: %w", env, err)
+	}
+	if err := writeSnapshotObjects(filepath.Join(work, snapshotDSEFile), []map[string]interface{}{dse}); err != nil {
+		return err
+	}
+	step("📄", "DevStagingEnvironment spec")
+
+	configMaps, secrets := referencedObjects(dse["spec"])
+	for _, ref := range []struct {
+		kind  string
+		names []string
+		file  string
+		dest  *[]string
+	}{
+		{"configmap", configMaps, snapshotCMFile, &index.ConfigMaps},
+		{"secret", secrets, snapshotSecFile, &index.Secrets},
+	} {
+		var objs []map[string]interface{}
+		for _, n := range ref.names {
+			out, err := kubectlJSON("get", ref.kind, n, "-n", ns, "-o", "json")
+			if err != nil {
+				warn(fmt.Sprintf("%s %s is referenced but missing — skipped", ref.kind, n))
+				continue
+			}
+			var obj map[string]interface{}
+			if json.Unmarshal([]byte(out), &obj) == nil {
+				objs = append(objs, obj)
+				*ref.dest = append(*ref.dest, n)
+			}
+		}
+		if err := writeSnapshotObjects(filepath.Join(work, ref.file), objs); err != nil {
+			return err
+		}
+		if len(objs) > 0 {
+			step("🔑", fmt.Sprintf("%d %s(s): %s", len(objs), ref.kind, strings.Join(*ref.dest, ", ")))
+		}
+	}
+
+	// ── Volumes ─────────────────────────────────────────────────
+	claims, err := environmentClaims(ns, env)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(work, "volumes"), 0700); err != nil {
+		return err
+	}
+	for _, v := range claims {
+		v.File = filepath.Join("volumes", v.Claim+".tar.gz")
+		f, err := os.Create(filepath.Join(work, v.File))
+		if err != nil {
+			return err
+		}
+		err = withStoppedStatefulSet(ns, v.StatefulSet, snapshotLive, func() error {
+			return withVolumeHelper(ns, v.Claim, func(pod string) error {
+				return kubectlCtx(nil, f, "exec", "-n", ns, pod, "--", "tar", "czf", "-", "-C", snapshotMount, ".")
+			})
+		})
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("cannot copy volume %s: %w", v.Claim, err)
+		}
+		if fi, err := os.Stat(filepath.Join(work, v.File)); err == nil {
+			v.Bytes = fi.Size()
+		}
+		step("💾", fmt.Sprintf("volume %s (%s)", v.Claim, devstaging.FormatBytes(v.Bytes)))
+		index.Volumes = append(index.Volumes, v)
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(work, snapshotIndexFile), data, 0600); err != nil {
+		return err
+	}
+	if err := writeTarball(path, work); err != nil {
+		return err
+	}
+
+	return render(struct {
+		snapshotIndex
+		File string `json:"file"`
+	}{index, path}, func() {
+		success(fmt.Sprintf("Snapshot saved to %s", path))
+		fmt.Println()
+		fmt.Printf("  Restore with: %skindling snapshot restore %s%s\n", colorCyan, name, colorReset)
+		fmt.Println()
+	})
+}
+
+// referencedObjects returns the ConfigMaps and Secrets a DSE spec names
+// in env valueFrom, envFrom, and seed.configMap, sorted and de-duplicated.
+func referencedObjects(spec interface{}) (configMaps, secrets []string) {
+	cms, secs := map[string]bool{}, map[string]bool{}
+	var walk func(v interface{}, parent string)
+	walk = func(v interface{}, parent string) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				if name, ok := child.(string); ok && k == "name" {
+					switch parent {
+					case "configMapRef", "configMapKeyRef":
+						cms[name] = true
+					case "secretRef", "secretKeyRef":
+						secs[name] = true
+					}
+				}
+				if name, ok := child.(string); ok && parent == "seed" && k == "configMap" {
+					cms[name] = true
+				}
+				walk(child, k)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child, parent)
+			}
+		}
+	}
+	walk(spec, "")
+	return sortedKeys(cms), sortedKeys(secs)
+}
+
+// environmentClaims returns the dependency volumes of an environment.
+// Claims from a StatefulSet's volumeClaimTemplates carry its labels.
+func environmentClaims(ns, env string) ([]snapshotVolume, error) {
+	out, err := kubectlJSON("get", "pvc", "-n", ns, "-l", "app.kubernetes.io/part-of="+env, "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the volumes of %s", env)
+	}
+	var list struct {
+		Items []kubeObject `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return nil, err
+	}
+	var volumes []snapshotVolume
+	for _, pvc := range list.Items {
+		volumes = append(volumes, snapshotVolume{
+			Claim:       pvc.Metadata.Name,
+			StatefulSet: pvc.Metadata.Labels["app.kubernetes.io/name"],
+		})
+	}
+	sort.Slice(volumes, func(i, k int) bool { return volumes[i].Claim < volumes[k].Claim })
+	return volumes, nil
+}
+
+// cleanObject strips the server-populated fields of an object so it can
+// be applied to another cluster, or after the original was deleted.
+func cleanObject(obj map[string]interface{}) map[string]interface{} {
+	delete(obj, "status")
+	meta, _ := obj["metadata"].(map[string]interface{})
+	clean := map[string]interface{}{"name": meta["name"], "namespace": meta["namespace"]}
+	if labels, ok := meta["labels"]; ok {
+		clean["labels"] = labels
+	}
+	if annotations, ok := meta["annotations"].(map[string]interface{}); ok {
+		delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+		delete(annotations, "apps.example.com/spec-hash")
+		if len(annotations) > 0 {
+			clean["annotations"] = annotations
+		}
+	}
+	obj["metadata"] = clean
+	return obj
+}
+
+// writeSnapshotObjects writes objects as a multi-document YAML file.
+func writeSnapshotObjects(path string, objs []map[string]interface{}) error {
+	var sb strings.Builder
+	for i, obj := range objs {
+		data, err := yaml.Marshal(cleanObject(obj))
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			sb.WriteString("---\n")
+		}
+		sb.Write(data)
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0600)
+}
+
+// ── Workload helpers ────────────────────────────────────────────
+
+// withStoppedStatefulSet scales a StatefulSet to zero around fn and back
+// to its previous size afterwards. With live set, fn runs with it up.
+func withStoppedStatefulSet(ns, name string, live bool, fn func() error) error {
+	if live || name == "" {
+		return fn()
+	}
+	replicas, err := kubectlJSON("get", "statefulset", name, "-n", ns, "-o", "jsonpath={.spec.replicas}")
+	if err != nil {
+		return fmt.Errorf("cannot read StatefulSet %s", name)
+	}
+	if replicas == "" {
+		replicas = "1"
+	}
+
+	spin := startSpinner(fmt.Sprintf("Stopping %s", name))
+	if out, err := captureKubectl("scale", "statefulset", name, "-n", ns, "--replicas=0"); err != nil {
+		spin.stop()
+		return fmt.Errorf("cannot stop %s: %s", name, out)
+	}
+	_, _ = captureKubectl("wait", "--for=delete", "pod", "-n", ns, "-l", "app.kubernetes.io/name="+name,
+		fmt.Sprintf("--timeout=%s", snapshotTimeout))
+	spin.update(fmt.Sprintf("Copying the volume of %s", name))
+	fnErr := fn()
+	spin.update(fmt.Sprintf("Starting %s", name))
+	out, err := captureKubectl("scale", "statefulset", name, "-n", ns, "--replicas="+replicas)
+	spin.stop()
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("cannot restart %s: %s", name, out)
+	}
+	return nil
+}
+
+// withVolumeHelper runs a Job that mounts claim at /data and idles, and
+// calls fn with the name of its pod. The Job is deleted afterwards.
+func withVolumeHelper(ns, claim string, fn func(pod string) error) error {
+	job := claim + "-snapshot"
+	if len(job) > 63 {
+		job = job[:63]
+	}
+	manifest := fmt.Sprintf(`apiVersion: batch/v1
+kind: Job
+metadata:
+  name: %[1]s
+  namespace: %[2]s
+  labels:
+    app.kubernetes.io/managed-by: kindling
+    app.kubernetes.io/component: snapshot
+spec:
+  backoffLimit: 0
+  activeDeadlineSeconds: 3600
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/managed-by: kindling
+        app.kubernetes.io/component: snapshot
+    spec:
+      restartPolicy: Never
+      containers:
+        - name: helper
+          image: %[4]s
+          command: ["sleep", "3600"]
+          volumeMounts:
+            - name: data
+              mountPath: %[5]s
+      volumes:
+        - name: data
+          persistentVolumeClaim:
+            claimName: %[3]s
+`, job, ns, claim, snapshotHelperImg, snapshotMount)
+
+	if out, err := runSilentStdin(manifest, "kubectl", "--context", kubeContextName(), "apply", "-f", "-"); err != nil {
+		return fmt.Errorf("cannot start the helper Job: %s", out)
+	}
+	defer captureKubectl("delete", "job", job, "-n", ns, "--wait=false", "--cascade=background")
+
+	if out, err := captureKubectl("wait", "--for=condition=Ready", "pod", "-n", ns, "-l", "job-name="+job,
+		fmt.Sprintf("--timeout=%s", snapshotTimeout)); err != nil {
+		return fmt.Errorf("helper pod did not start: %s", out)
+	}
+	pod, err := kubectlJSON("get", "pods", "-n", ns, "-l", "job-name="+job, "-o", "jsonpath={.items[0].metadata.name}")
+	if err != nil || pod == "" {
+		return fmt.Errorf("cannot find the helper pod of Job %s", job)
+	}
+	return fn(pod)
+}
+
+// ── Tarballs ────────────────────────────────────────────────────
+
+// writeTarball packs every file under dir into a gzipped tarball at path.
+func writeTarball(path, dir string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("cannot create %s: %w", path, err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{Name: filepath.ToSlash(rel), Mode: 0600, Size: info.Size(), ModTime: info.ModTime()}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("cannot write %s: %w", path, err)
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// extractTarball unpacks a gzipped tarball, such as a snapshot, into dir.
+func extractTarball(path, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %w", path, err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s is not a gzipped tarball: %w", path, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", path, err)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("%s: entry %q escapes the archive", path, hdr.Name)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ── restore ─────────────────────────────────────────────────────
+
+func runSnapshotRestore(cmd *cobra.Command, args []string) error {
+	path, err := snapshotFile(args[0])
+	if err != nil {
+		return err
+	}
+	work, err := os.MkdirTemp("", "kindling-snapshot-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(work)
+	if err := extractTarball(path, work); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(work, snapshotIndexFile))
+	if err != nil {
+		return fmt.Errorf("%s has no %s — not a kindling snapshot", path, snapshotIndexFile)
+	}
+	var index snapshotIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", snapshotIndexFile, err)
+	}
+	env, ns := index.Environment, index.Namespace
+
+	header(fmt.Sprintf("Restoring %s from %s", env, filepath.Base(path)))
+
+	for _, file := range []string{snapshotCMFile, snapshotSecFile, snapshotDSEFile} {
+		manifest, err := os.ReadFile(filepath.Join(work, file))
+		if err != nil || len(strings.TrimSpace(string(manifest))) == 0 {
+			continue
+		}
+		if out, err := runSilentStdin(string(manifest), "kubectl", "--context", kubeContextName(), "apply", "-f", "-"); err != nil {
+			return fmt.Errorf("cannot apply %s: %s", file, out)
+		}
+		step("📄", fmt.Sprintf("applied %s", strings.TrimSuffix(file, ".yaml")))
+	}
+
+	if len(index.Volumes) > 0 {
+		// The dependencies must run the snapshot's spec before their
+		// volumes are replaced, or the operator's rollout would restart
+		// them mid-copy.
+		spin := startSpinner(fmt.Sprintf("Waiting for the dependencies of %s", env))
+		out, err := captureKubectl("wait", "--for=condition=DependenciesReady", "devstagingenvironment/"+env,
+			"-n", ns, fmt.Sprintf("--timeout=%s", snapshotTimeout))
+		spin.stop()
+		if err != nil {
+			return fmt.Errorf("dependencies of %s did not become ready: %s", env, out)
+		}
+	}
+	for _, v := range index.Volumes {
+		f, err := os.Open(filepath.Join(work, filepath.FromSlash(v.File)))
+		if err != nil {
+			return fmt.Errorf("snapshot is missing %s: %w", v.File, err)
+		}
+		err = withStoppedStatefulSet(ns, v.StatefulSet, false, func() error {
+			return withVolumeHelper(ns, v.Claim, func(pod string) error {
+				script := fmt.Sprintf("find %[1]s -mindepth 1 -delete && tar xzf - -C %[1]s", snapshotMount)
+				return kubectlCtx(f, io.Discard, "exec", "-i", "-n", ns, pod, "--", "sh", "-c", script)
+			})
+		})
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("cannot restore volume %s: %w", v.Claim, err)
+		}
+		step("💾", fmt.Sprintf("volume %s (%s)", v.Claim, devstaging.FormatBytes(v.Bytes)))
+	}
+
+	return render(index, func() {
+		success(fmt.Sprintf("%s restored to its state of %s", env, index.Created.Local().Format("2006-01-02 15:04")))
+		fmt.Println()
+		fmt.Printf("  Track progress with: %skindling status%s\n", colorCyan, colorReset)
+		fmt.Println()
+	})
+}
+
+// ── list ────────────────────────────────────────────────────────
+
+// snapshotEntry is one row of kindling snapshot list.
+type snapshotEntry struct {
+	Name        string    `json:"name"`
+	File        string    `json:"file"`
+	Environment string    `json:"environment"`
+	Created     time.Time `json:"created"`
+	Volumes     int       `json:"volumes"`
+	Bytes       int64     `json:"bytes"`
+}
+
+func runSnapshotList(cmd *cobra.Command, args []string) error {
+	dir, err := snapshotsPath()
+	if err != nil {
+		return err
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.tar.gz"))
+	entries := []snapshotEntry{}
+	for _, file := range files {
+		index, err := readSnapshotIndex(file)
+		if err != nil {
+			continue
+		}
+		e := snapshotEntry{
+			Name:        strings.TrimSuffix(filepath.Base(file), ".tar.gz"),
+			File:        file,
+			Environment: index.Environment,
+			Created:     index.Created,
+			Volumes:     len(index.Volumes),
+		}
+		if fi, err := os.Stat(file); err == nil {
+			e.Bytes = fi.Size()
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, k int) bool { return entries[i].Created.After(entries[k].Created) })
+
+	return render(entries, func() {
+		header("Snapshots")
+		if len(entries) == 0 {
+			fmt.Printf("    %sNone — create one with:%s kindling snapshot create <env-name>\n", colorDim, colorReset)
+			return
+		}
+		for _, e := range entries {
+			fmt.Printf("    📸 %-32s %-20s %s  %d volume(s)  %s\n", e.Name, e.Environment,
+				e.Created.Local().Format("2006-01-02 15:04"), e.Volumes, dimText(devstaging.FormatBytes(e.Bytes)))
+		}
+	})
+}
+
+// readSnapshotIndex reads only snapshot.json from a snapshot tarball.
+func readSnapshotIndex(path string) (snapshotIndex, error) {
+	var index snapshotIndex
+	f, err := os.Open(path)
+	if err != nil {
+		return index, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return index, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			return index, fmt.Errorf("no %s in %s", snapshotIndexFile, path)
+		}
+		if hdr.Name == snapshotIndexFile {
+			err := json.NewDecoder(tr).Decode(&index)
+			return index, err
+		}
+	}
+}
@@ -1,10 +1,16 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
+	"text/tabwriter"
 
+	"github.com/jeffvincent/kindling/cli/pkg/kube"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 var statusCmd = &cobra.Command{
@@ -14,208 +20,329 @@ var statusCmd = &cobra.Command{
   • Cluster info and node status
   • kindling operator health
   • GitHub Actions runner pools
-  • Dev staging environments and their dependencies`,
+  • Dev staging environments as a readiness tree: the app and each
+    dependency with ready/desired pods, restart counts, image tags,
+    services, ingress hosts, and the public tunnel URL (if exposed)
+
+With --graph, prints only the topology of the environments instead: which
+component depends on which (spec.dependsOn), the dependencies backing each,
+and which components the operator is holding back until their upstreams
+are available.`,
 	RunE: runStatus,
 }
 
+var statusGraph bool
+
 func init() {
+	statusCmd.Flags().BoolVar(&statusGraph, "graph", false, "Show the dependsOn topology of the environments only")
 	rootCmd.AddCommand(statusCmd)
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
+	report := collectStatus(currentEnvironment())
+	if isJSONOutput() {
+		return printJSON(report)
+	}
+
 	// ── Cluster ─────────────────────────────────────────────────
 	header("Cluster")
 
-	if !clusterExists(clusterName) {
-		fail(fmt.Sprintf("Kind cluster %q not found. Run: kindling init", clusterName))
+	if !report.ClusterExists {
+		fail(errNoCluster().Error())
 		return nil
 	}
-	success(fmt.Sprintf("Kind cluster %q exists", clusterName))
-
-	nodesOut, err := runCapture("kubectl", "get", "nodes",
-		"-o", "custom-columns=NAME:.metadata.name,STATUS:.status.conditions[-1].type,VERSION:.status.nodeInfo.kubeletVersion",
-		"--no-headers")
-	if err == nil && nodesOut != "" {
-		for _, line := range strings.Split(nodesOut, "\n") {
-			fmt.Printf("    %s\n", strings.TrimSpace(line))
+	if statusGraph {
+		header("Topology")
+		if len(report.EnvironmentTree) == 0 {
+			fmt.Printf("    %sNone — run:%s kindling deploy -f <file.yaml>\n", colorDim, colorReset)
+		} else {
+			printEnvironmentGraph(report.EnvironmentTree)
 		}
+		fmt.Println()
+		return nil
 	}
+	success(fmt.Sprintf("%s is up", clusterLabel()))
+	printStatusRows(report.Nodes, []string{"name", "status", "version"}, "")
 
 	// ── Operator ────────────────────────────────────────────────
 	header("Operator")
 
-	operatorOut, err := runCapture("kubectl", "get", "deployment",
-		"-n", "kindling-system",
-		"-o", "custom-columns=NAME:.metadata.name,READY:.status.readyReplicas,DESIRED:.spec.replicas,AGE:.metadata.creationTimestamp",
-		"--no-headers")
-	if err != nil || operatorOut == "" {
+	if len(report.Operator) == 0 {
 		warn("Controller not found in kindling-system namespace")
-	} else {
-		for _, line := range strings.Split(operatorOut, "\n") {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-			if strings.Contains(line, "<none>") {
-				fmt.Printf("    %s⚠%s  %s\n", colorYellow, colorReset, line)
-			} else {
-				fmt.Printf("    %s✓%s  %s\n", colorGreen, colorReset, line)
-			}
+	}
+	for _, row := range report.Operator {
+		icon := colorGreen + "✓" + colorReset
+		if row["ready"] == "" {
+			icon = colorYellow + "⚠" + colorReset
 		}
+		printStatusRows([]map[string]string{row}, []string{"name", "ready", "desired", "created"}, icon+"  ")
 	}
 
 	// ── Registry ────────────────────────────────────────────────
 	header("Registry")
 
-	regOut, err := runCapture("kubectl", "get", "deployment/registry",
-		"-o", "custom-columns=READY:.status.readyReplicas,DESIRED:.spec.replicas",
-		"--no-headers")
-	if err != nil {
+	if len(report.Registry) == 0 {
 		warn("In-cluster registry not found")
 	} else {
-		fmt.Printf("    registry:5000  %s\n", strings.TrimSpace(regOut))
+		printStatusRows(report.Registry, []string{"ready", "desired"}, "registry:5000  ")
 	}
 
 	// ── Ingress ─────────────────────────────────────────────────
 	header("Ingress Controller")
 
-	ingOut, err := runCapture("kubectl", "get", "pods",
-		"-n", "ingress-nginx",
-		"-l", "app.kubernetes.io/component=controller",
-		"-o", "custom-columns=NAME:.metadata.name,STATUS:.status.phase,RESTARTS:.status.containerStatuses[0].restartCount",
-		"--no-headers")
-	if err != nil || ingOut == "" {
-		warn("ingress-nginx controller not found")
+	if len(report.IngressController) == 0 {
+		warn("ingress controller not found")
 	} else {
-		for _, line := range strings.Split(ingOut, "\n") {
-			fmt.Printf("    %s\n", strings.TrimSpace(line))
-		}
+		ingName, _, _ := installedIngressController()
+		fmt.Printf("    %s%s%s\n", colorBold, ingName, colorReset)
+		printStatusRows(report.IngressController, []string{"name", "status", "restarts"}, "")
 	}
 
 	// ── Runner Pools ────────────────────────────────────────────
 	header("GitHub Actions Runner Pools")
 
-	rpOut, err := runCapture("kubectl", "get", "githubactionrunnerpools",
-		"-o", "custom-columns=NAME:.metadata.name,USERNAME:.spec.githubUsername,REPO:.spec.repository",
-		"--no-headers")
-	if err != nil || rpOut == "" || strings.Contains(rpOut, "No resources") {
+	if len(report.RunnerPools) == 0 {
 		fmt.Printf("    %sNone — run:%s kindling runners\n", colorDim, colorReset)
 	} else {
-		for _, line := range strings.Split(rpOut, "\n") {
-			line = strings.TrimSpace(line)
-			if line != "" {
-				fmt.Printf("    🏃 %s\n", line)
-			}
-		}
+		printStatusRows(report.RunnerPools, []string{"name", "username", "repository"}, "🏃 ")
 
 		// Show runner deployment status
 		fmt.Println()
-		runnerDeploys, _ := runCapture("kubectl", "get", "deployments",
-			"-l", "app.kubernetes.io/managed-by=kindling",
-			"-o", "custom-columns=NAME:.metadata.name,READY:.status.readyReplicas,DESIRED:.spec.replicas",
-			"--no-headers")
-		if runnerDeploys != "" {
-			for _, line := range strings.Split(runnerDeploys, "\n") {
-				line = strings.TrimSpace(line)
-				if line != "" {
-					fmt.Printf("      ↳ %s\n", line)
-				}
-			}
-		}
+		printStatusRows(statusRows("deployments", "", "app.kubernetes.io/managed-by=kindling", deploymentReadiness),
+			[]string{"name", "ready", "desired"}, "  ↳ ")
 	}
 
 	// ── Dev Staging Environments ────────────────────────────────
-	header("Dev Staging Environments")
+	envs := report.EnvironmentTree
+	if report.CurrentEnvironment != "" {
+		header(fmt.Sprintf("Dev Staging Environments — %s", report.CurrentEnvironment))
+		if others := report.otherEnvironments; others > 0 {
+			fmt.Printf("    %s%d more in other environments — see: kindling env list%s\n\n", colorDim, others, colorReset)
+		}
+	} else {
+		header("Dev Staging Environments")
+	}
 
-	dseOut, err := runCapture("kubectl", "get", "devstagingenvironments",
-		"-o", "custom-columns=NAME:.metadata.name,IMAGE:.spec.deployment.image,PORT:.spec.deployment.port,INGRESS:.spec.ingress.host",
-		"--no-headers")
-	if err != nil || dseOut == "" || strings.Contains(dseOut, "No resources") {
+	if len(envs) == 0 {
 		fmt.Printf("    %sNone — run:%s kindling deploy -f <file.yaml>\n", colorDim, colorReset)
 	} else {
-		for _, line := range strings.Split(dseOut, "\n") {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-			fmt.Printf("    📦 %s\n", line)
-		}
+		printEnvironmentTree(envs)
 	}
 
 	// ── Deployments ─────────────────────────────────────────────
 	header("All Deployments")
 
-	depOut, _ := runCapture("kubectl", "get", "deployments",
-		"-o", "custom-columns=NAME:.metadata.name,READY:.status.readyReplicas,UP-TO-DATE:.status.updatedReplicas,AVAILABLE:.status.availableReplicas",
-		"--no-headers")
-	if depOut != "" {
-		for _, line := range strings.Split(depOut, "\n") {
-			line = strings.TrimSpace(line)
-			if line != "" {
-				fmt.Printf("    %s\n", line)
-			}
-		}
-	}
+	printStatusRows(report.Deployments, []string{"name", "ready", "updated", "available"}, "")
 
 	// ── Unhealthy Pods ──────────────────────────────────────────
 	// Show CrashLoopBackOff / Error pods with their last log lines
 	// so the developer doesn't have to manually run kubectl logs.
-	crashPods, _ := runCapture("kubectl", "get", "pods",
-		"--field-selector=status.phase!=Running,status.phase!=Succeeded",
-		"-o", "custom-columns=NAME:.metadata.name,STATUS:.status.phase,REASON:.status.containerStatuses[0].state.waiting.reason",
-		"--no-headers")
-	if crashPods != "" {
-		hasCrash := false
-		for _, line := range strings.Split(crashPods, "\n") {
-			line = strings.TrimSpace(line)
-			if line == "" || strings.Contains(line, "<none>") {
-				continue
-			}
-			if !hasCrash {
-				header("Unhealthy Pods")
-				hasCrash = true
-			}
-			parts := strings.Fields(line)
-			podName := parts[0]
-			fmt.Printf("    %s❌ %s%s\n", colorRed, line, colorReset)
-
-			// Show last few log lines for this pod
-			logs, _ := runCapture("kubectl", "logs", podName, "--tail=10")
-			if logs != "" {
-				for _, logLine := range strings.Split(logs, "\n") {
-					logLine = strings.TrimSpace(logLine)
-					if logLine != "" {
-						fmt.Printf("       %s%s%s\n", colorDim, logLine, colorReset)
-					}
+	if len(report.UnhealthyPods) > 0 {
+		header("Unhealthy Pods")
+	}
+	for _, pod := range report.UnhealthyPods {
+		fmt.Printf("    %s❌ %s  %s  %s%s\n", colorRed, pod["name"], pod["status"], pod["reason"], colorReset)
+
+		// Show last few log lines for this pod
+		logs, _ := podLogs("", pod["name"], 10)
+		if logs != "" {
+			for _, logLine := range strings.Split(logs, "\n") {
+				logLine = strings.TrimSpace(logLine)
+				if logLine != "" {
+					fmt.Printf("       %s%s%s\n", colorDim, logLine, colorReset)
 				}
-				fmt.Println()
 			}
+			fmt.Println()
 		}
 	}
 
 	// ── Ingress Routes ──────────────────────────────────────────
 	header("Ingress Routes")
 
-	ingRoutes, err := runCapture("kubectl", "get", "ingress",
-		"-o", "custom-columns=NAME:.metadata.name,HOST:.spec.rules[*].host,SERVICE:.spec.rules[*].http.paths[*].backend.service.name",
-		"--no-headers")
-	if err != nil || ingRoutes == "" || strings.Contains(ingRoutes, "No resources") {
+	if len(report.IngressRoutes) == 0 {
 		fmt.Printf("    %sNo ingress routes configured%s\n", colorDim, colorReset)
-	} else {
-		for _, line := range strings.Split(ingRoutes, "\n") {
-			line = strings.TrimSpace(line)
-			if line != "" {
-				// Extract host for a clickable link
-				parts := strings.Fields(line)
-				if len(parts) >= 2 {
-					fmt.Printf("    🌐 http://%s  →  %s\n", parts[1], line)
-				} else {
-					fmt.Printf("    🌐 %s\n", line)
+	}
+	for _, route := range report.IngressRoutes {
+		fmt.Printf("    🌐 http://%s  →  %s  %s\n", route["host"], route["name"], route["service"])
+	}
+
+	fmt.Println()
+	return nil
+}
+
+// ── Report ──────────────────────────────────────────────────────
+
+// statusReport is the machine-readable form of kindling status.
+type statusReport struct {
+	Cluster            string              `json:"cluster"`
+	ClusterExists      bool                `json:"clusterExists"`
+	Nodes              []map[string]string `json:"nodes"`
+	Operator           []map[string]string `json:"operator"`
+	Registry           []map[string]string `json:"registry"`
+	IngressController  []map[string]string `json:"ingressController"`
+	RunnerPools        []map[string]string `json:"runnerPools"`
+	Environments       []map[string]string `json:"environments"`
+	CurrentEnvironment string              `json:"currentEnvironment,omitempty"` // environmentTree is limited to it
+	EnvironmentTree    []envStatus         `json:"environmentTree"`
+	Deployments        []map[string]string `json:"deployments"`
+	UnhealthyPods      []map[string]string `json:"unhealthyPods"`
+	IngressRoutes      []map[string]string `json:"ingressRoutes"`
+
+	otherEnvironments int // DSEs outside the current environment
+}
+
+// collectStatus gathers everything status shows, with the environment
+// tree limited to env unless it is "". Sections that cannot be queried
+// are left empty.
+func collectStatus(env string) statusReport {
+	report := statusReport{Cluster: clusterName}
+	if !clusterExists(clusterName) {
+		return report
+	}
+	report.ClusterExists = true
+
+	report.Nodes = statusRows("nodes", "", "", func(o map[string]interface{}) map[string]string {
+		conditions, _, _ := unstructured.NestedSlice(o, "status", "conditions")
+		status := ""
+		if len(conditions) > 0 {
+			status = statusField(conditions[len(conditions)-1], "type")
+		}
+		return map[string]string{"name": statusField(o, "metadata", "name"), "status": status,
+			"version": statusField(o, "status", "nodeInfo", "kubeletVersion")}
+	})
+	report.Operator = statusRows("deployments", "kindling-system", "", func(o map[string]interface{}) map[string]string {
+		row := deploymentReadiness(o)
+		row["created"] = statusField(o, "metadata", "creationTimestamp")
+		return row
+	})
+	for _, d := range statusRows("deployments", "", "", deploymentReadiness) {
+		if d["name"] == "registry" {
+			report.Registry = append(report.Registry, d)
+		}
+	}
+	_, ing, _ := installedIngressController()
+	report.IngressController = statusRows("pods", ing.namespace, ing.selector, func(o map[string]interface{}) map[string]string {
+		return map[string]string{"name": statusField(o, "metadata", "name"), "status": statusField(o, "status", "phase"),
+			"restarts": firstContainerField(o, "restartCount")}
+	})
+	report.RunnerPools = statusRows("githubactionrunnerpools", "", "", func(o map[string]interface{}) map[string]string {
+		return map[string]string{"name": statusField(o, "metadata", "name"),
+			"username": statusField(o, "spec", "githubUsername"), "repository": statusField(o, "spec", "repository")}
+	})
+	report.Environments = statusRows("devstagingenvironments", "", "", func(o map[string]interface{}) map[string]string {
+		return map[string]string{"name": statusField(o, "metadata", "name"), "image": statusField(o, "spec", "deployment", "image"),
+			"port": statusField(o, "spec", "deployment", "port"), "host": statusField(o, "spec", "ingress", "host")}
+	})
+	report.EnvironmentTree = collectEnvironments()
+	if report.CurrentEnvironment = env; env != "" {
+		all := len(report.EnvironmentTree)
+		report.EnvironmentTree = inEnvironment(report.EnvironmentTree, report.CurrentEnvironment)
+		report.otherEnvironments = all - len(report.EnvironmentTree)
+	}
+	report.Deployments = statusRows("deployments", "", "", func(o map[string]interface{}) map[string]string {
+		return map[string]string{"name": statusField(o, "metadata", "name"), "ready": statusField(o, "status", "readyReplicas"),
+			"updated": statusField(o, "status", "updatedReplicas"), "available": statusField(o, "status", "availableReplicas")}
+	})
+	for _, pod := range statusRows("pods", "", "", func(o map[string]interface{}) map[string]string {
+		return map[string]string{"name": statusField(o, "metadata", "name"), "status": statusField(o, "status", "phase"),
+			"reason": firstContainerField(o, "state", "waiting", "reason")}
+	}) {
+		if pod["status"] == "Running" || pod["status"] == "Succeeded" || pod["reason"] == "" {
+			continue
+		}
+		report.UnhealthyPods = append(report.UnhealthyPods, pod)
+	}
+	report.IngressRoutes = statusRows("ingresses", "", "", func(o map[string]interface{}) map[string]string {
+		rules, _, _ := unstructured.NestedSlice(o, "spec", "rules")
+		var hosts, services []string
+		for _, rule := range rules {
+			if host := statusField(rule, "host"); host != "" {
+				hosts = append(hosts, host)
+			}
+			paths, _, _ := unstructured.NestedSlice(asObject(rule), "http", "paths")
+			for _, p := range paths {
+				if svc := statusField(p, "backend", "service", "name"); svc != "" {
+					services = append(services, svc)
 				}
 			}
 		}
+		return map[string]string{"name": statusField(o, "metadata", "name"),
+			"host": strings.Join(hosts, ","), "service": strings.Join(services, ",")}
+	})
+	return report
+}
+
+// statusRows lists resource in namespace ("" is the context's) and maps
+// each object matching the label selector to a row with row.
+func statusRows(resource, namespace, selector string, row func(o map[string]interface{}) map[string]string) []map[string]string {
+	out, err := listObjects(resource, namespace, selector)
+	if err != nil {
+		return nil
+	}
+	var list struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	if json.Unmarshal(out, &list) != nil {
+		return nil
 	}
+	var rows []map[string]string
+	for _, item := range list.Items {
+		rows = append(rows, row(item))
+	}
+	return rows
+}
 
-	fmt.Println()
-	return nil
+// deploymentReadiness is the name, ready, and desired row of a Deployment.
+func deploymentReadiness(o map[string]interface{}) map[string]string {
+	return map[string]string{"name": statusField(o, "metadata", "name"),
+		"ready": statusField(o, "status", "readyReplicas"), "desired": statusField(o, "spec", "replicas")}
+}
+
+// statusField returns the field at path in a decoded object as a string,
+// or "" when it's not set.
+func statusField(o interface{}, path ...string) string {
+	v, found, err := unstructured.NestedFieldNoCopy(asObject(o), path...)
+	if !found || err != nil || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// firstContainerField returns a field of a pod's first container status.
+func firstContainerField(pod map[string]interface{}, path ...string) string {
+	statuses, _, _ := unstructured.NestedSlice(pod, "status", "containerStatuses")
+	if len(statuses) == 0 {
+		return ""
+	}
+	return statusField(statuses[0], path...)
+}
+
+func asObject(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// printStatusRows prints rows as aligned columns of keys, each line
+// starting with prefix. Unset cells show as <none>, as kubectl does.
+func printStatusRows(rows []map[string]string, keys []string, prefix string) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, row := range rows {
+		cells := make([]string, len(keys))
+		for i, key := range keys {
+			if cells[i] = row[key]; cells[i] == "" {
+				cells[i] = "<none>"
+			}
+		}
+		fmt.Fprintf(tw, "    %s%s\n", prefix, strings.Join(cells, "\t"))
+	}
+	tw.Flush()
+}
+
+// podLogs returns the last lines of a pod's log.
+func podLogs(namespace, pod string, lines int64) (string, error) {
+	var out []string
+	err := streamPodLogs(context.Background(), namespace, pod, kube.LogOptions{Tail: lines}, func(line string) {
+		out = append(out, line)
+	})
+	return strings.Join(out, "\n"), err
 }
@@ -0,0 +1,620 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jeffvincent/kindling/cli/pkg/build"
+)
+
+// ── Environment tree ────────────────────────────────────────────
+//
+// status renders each DevStagingEnvironment as a tree of the workloads the
+// operator created for it: the app Deployment plus one Deployment per
+// dependency, with their Services, Ingress, and any Jobs. Children are
+// matched by the operator's labels — app.kubernetes.io/instance for the
+// app, app.kubernetes.io/part-of for dependencies.
+
+const operatorManagedBy = "app.kubernetes.io/managed-by=devstagingenvironment-operator"
+
+// envStatus is one DevStagingEnvironment and its children.
+type envStatus struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Environment string            `json:"environment"` // see environmentOf
+	Ready       bool              `json:"ready"`
+	Paused      bool              `json:"paused,omitempty"` // spec.paused: its pods are stopped
+	Asleep      bool              `json:"asleep,omitempty"` // spec.autoSleep stopped its pods; a request wakes it
+	URL         string            `json:"url,omitempty"`
+	PublicURL   string            `json:"publicUrl,omitempty"`
+	DependsOn   []string          `json:"dependsOn,omitempty"` // DSEs that must be available first
+	Conditions  []envCondition    `json:"conditions,omitempty"`
+	Events      []envEvent        `json:"events,omitempty"`
+	Components  []componentStatus `json:"components"`
+}
+
+// envCondition is one of the operator's status conditions on a DSE
+// (ComponentsReady, IngressReady, ImagesBuilt, DependenciesReady, ...).
+type envCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"` // True, False, or Unknown
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// envEvent is a Kubernetes Event the operator emitted for a DSE.
+type envEvent struct {
+	Type     string `json:"type"` // Normal or Warning
+	Reason   string `json:"reason"`
+	Message  string `json:"message"`
+	Count    int    `json:"count,omitempty"`
+	LastSeen string `json:"lastSeen,omitempty"`
+}
+
+// maxEnvEvents is how many of the latest events status keeps per DSE.
+const maxEnvEvents = 5
+
+// componentStatus is one workload of an environment.
+type componentStatus struct {
+	Name     string   `json:"name"`
+	Role     string   `json:"role"` // "app", "canary", a dependency type, or "job"
+	Kind     string   `json:"kind"` // Deployment, StatefulSet, CronJob, or Job
+	Image    string   `json:"image,omitempty"`
+	Tag      string   `json:"tag,omitempty"`
+	Ready    int      `json:"ready"`
+	Desired  int      `json:"desired"`
+	Restarts int      `json:"restarts"`
+	Problem  string   `json:"problem,omitempty"` // e.g. CrashLoopBackOff
+	Service  string   `json:"service,omitempty"`
+	Hosts    []string `json:"hosts,omitempty"`
+	Schedule string   `json:"schedule,omitempty"` // CronJob only
+	LastRun  string   `json:"lastRun,omitempty"`  // CronJob only
+}
+
+// kubeObject holds the fields status reads from DSEs, Deployments,
+// StatefulSets, Pods, Services, Ingresses, and Jobs.
+type kubeObject struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas  *int     `json:"replicas"`
+		DependsOn []string `json:"dependsOn"`
+		Paused    bool     `json:"paused"`
+		Template  struct {
+			Spec struct {
+				Containers []struct {
+					Image string `json:"image"`
+				} `json:"containers"`
+			} `json:"spec"`
+		} `json:"template"`
+		Ports []struct {
+			Port int `json:"port"`
+		} `json:"ports"`
+		Rules []struct {
+			Host string `json:"host"`
+		} `json:"rules"`
+	} `json:"spec"`
+	Status struct {
+		ReadyReplicas     int            `json:"readyReplicas"`
+		DeploymentReady   bool           `json:"deploymentReady"`
+		DependenciesReady bool           `json:"dependenciesReady"`
+		URL               string         `json:"url"`
+		AsleepSince       string         `json:"asleepSince"`
+		Conditions        []envCondition `json:"conditions"`
+		Active            int            `json:"active"`
+		Succeeded         int            `json:"succeeded"`
+		Failed            int            `json:"failed"`
+		ContainerStatuses []struct {
+			RestartCount int `json:"restartCount"`
+			State        struct {
+				Waiting *struct {
+					Reason string `json:"reason"`
+				} `json:"waiting"`
+			} `json:"state"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// cronJobObject holds the fields status reads from a CronJob. Its
+// status.active is a list, unlike a Job's count, so it can't share
+// kubeObject.
+type cronJobObject struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		Schedule    string `json:"schedule"`
+		Suspend     bool   `json:"suspend"`
+		JobTemplate struct {
+			Spec struct {
+				Template struct {
+					Spec struct {
+						Containers []struct {
+							Image string `json:"image"`
+						} `json:"containers"`
+					} `json:"spec"`
+				} `json:"template"`
+			} `json:"spec"`
+		} `json:"jobTemplate"`
+	} `json:"spec"`
+	Status struct {
+		Active           []struct{} `json:"active"`
+		LastScheduleTime string     `json:"lastScheduleTime"`
+	} `json:"status"`
+}
+
+// listCronJobs returns the operator's CronJobs: the apps that run on a
+// schedule.
+func listCronJobs() []cronJobObject {
+	out, err := listObjects("cronjobs", allNamespaces, operatorManagedBy)
+	if err != nil {
+		return nil
+	}
+	var list struct {
+		Items []cronJobObject `json:"items"`
+	}
+	if json.Unmarshal(out, &list) != nil {
+		return nil
+	}
+	return list.Items
+}
+
+// kubeList returns the objects of resource in every namespace that match
+// the label selector.
+func kubeList(resource, selector string) []kubeObject {
+	out, err := listObjects(resource, allNamespaces, selector)
+	if err != nil {
+		return nil
+	}
+	var list struct {
+		Items []kubeObject `json:"items"`
+	}
+	if json.Unmarshal(out, &list) != nil {
+		return nil
+	}
+	return list.Items
+}
+
+// collectEnvironments builds the tree for every DevStagingEnvironment.
+func collectEnvironments() []envStatus {
+	dses := kubeList("devstagingenvironments", "")
+	if len(dses) == 0 {
+		return nil
+	}
+	// Stateful dependencies run as StatefulSets, everything else as Deployments.
+	workloads := append(kubeList("deployments", operatorManagedBy),
+		kubeList("statefulsets", operatorManagedBy)...)
+	pods := kubeList("pods", operatorManagedBy)
+	services := kubeList("services", operatorManagedBy)
+	ingresses := kubeList("ingresses", operatorManagedBy)
+	jobs := kubeList("jobs", operatorManagedBy)
+	cronJobs := listCronJobs()
+	tunnel := tunnelConfigMapData()
+	events := environmentEvents()
+
+	envs := make([]envStatus, 0, len(dses))
+	for _, dse := range dses {
+		name, ns := dse.Metadata.Name, dse.Metadata.Namespace
+		env := envStatus{
+			Name:        name,
+			Namespace:   ns,
+			Environment: environmentOf(ns),
+			Ready:       dse.Status.DeploymentReady && (dse.Status.DependenciesReady || !hasDependencyWorkloads(workloads, ns, name)),
+			URL:         dse.Status.URL,
+			Paused:      dse.Spec.Paused,
+			Asleep:      dse.Status.AsleepSince != "",
+			DependsOn:   dse.Spec.DependsOn,
+			Conditions:  dse.Status.Conditions,
+			Events:      events[ns+"/"+name],
+			Components:  []componentStatus{},
+		}
+		for _, c := range dse.Status.Conditions {
+			if c.Type == "Ready" {
+				env.Ready = c.Status == "True"
+			}
+		}
+
+		belongs := func(o kubeObject) bool {
+			l := o.Metadata.Labels
+			return o.Metadata.Namespace == ns && (l["app.kubernetes.io/instance"] == name || l["app.kubernetes.io/part-of"] == name)
+		}
+		for _, d := range workloads {
+			if !belongs(d) {
+				continue
+			}
+			c := componentStatus{Name: d.Metadata.Name, Role: "app", Kind: d.Kind, Ready: d.Status.ReadyReplicas, Desired: 1}
+			if role := d.Metadata.Labels["app.kubernetes.io/component"]; role != "" {
+				c.Role = role
+			}
+			if d.Spec.Replicas != nil {
+				c.Desired = *d.Spec.Replicas
+			}
+			if cs := d.Spec.Template.Spec.Containers; len(cs) > 0 {
+				c.Image, c.Tag = build.SplitTag(cs[0].Image)
+			}
+			c.Restarts, c.Problem = podHealth(pods, ns, d.Metadata.Name)
+			c.Service = serviceFor(services, ns, d.Metadata.Name)
+			c.Hosts = ingressHosts(ingresses, ns, d.Metadata.Name)
+			env.Components = append(env.Components, c)
+		}
+		for _, cj := range cronJobs {
+			if cj.Metadata.Namespace != ns || cj.Metadata.Labels["app.kubernetes.io/instance"] != name {
+				continue
+			}
+			c := componentStatus{Name: cj.Metadata.Name, Role: "app", Kind: "CronJob", Ready: 1, Desired: 1,
+				Schedule: cj.Spec.Schedule, LastRun: cj.Status.LastScheduleTime}
+			if cj.Spec.Suspend {
+				c.Ready, c.Problem = 0, "Suspended"
+			}
+			if cs := cj.Spec.JobTemplate.Spec.Template.Spec.Containers; len(cs) > 0 {
+				c.Image, c.Tag = build.SplitTag(cs[0].Image)
+			}
+			env.Components = append(env.Components, c)
+		}
+		for _, j := range jobs {
+			if !belongs(j) {
+				continue
+			}
+			c := componentStatus{Name: j.Metadata.Name, Role: "job", Kind: "Job", Ready: min(j.Status.Succeeded, 1), Desired: 1}
+			if j.Status.Failed > 0 && j.Status.Active == 0 && j.Status.Succeeded == 0 {
+				c.Problem = "Failed"
+			}
+			if cs := j.Spec.Template.Spec.Containers; len(cs) > 0 {
+				c.Image, c.Tag = build.SplitTag(cs[0].Image)
+			}
+			env.Components = append(env.Components, c)
+		}
+		// App first, then dependencies and jobs by name.
+		sort.SliceStable(env.Components, func(i, k int) bool {
+			ai, ak := env.Components[i].Role == "app", env.Components[k].Role == "app"
+			if ai != ak {
+				return ai
+			}
+			return env.Components[i].Name < env.Components[k].Name
+		})
+
+		env.PublicURL = publicURLFor(tunnel, name, ingressHosts(ingresses, ns, name))
+		envs = append(envs, env)
+	}
+	sort.Slice(envs, func(i, k int) bool {
+		if envs[i].Namespace != envs[k].Namespace {
+			return envs[i].Namespace < envs[k].Namespace
+		}
+		return envs[i].Name < envs[k].Name
+	})
+	return envs
+}
+
+// environmentEvents returns the latest events of every DSE, oldest first,
+// keyed by "<namespace>/<name>".
+func environmentEvents() map[string][]envEvent {
+	out, err := listObjects("events", allNamespaces, "")
+	if err != nil {
+		return nil
+	}
+	var list struct {
+		Items []struct {
+			InvolvedObject struct {
+				Kind      string `json:"kind"`
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"involvedObject"`
+			Type          string `json:"type"`
+			Reason        string `json:"reason"`
+			Message       string `json:"message"`
+			Count         int    `json:"count"`
+			LastTimestamp string `json:"lastTimestamp"`
+			EventTime     string `json:"eventTime"`
+		} `json:"items"`
+	}
+	if json.Unmarshal(out, &list) != nil {
+		return nil
+	}
+	sort.SliceStable(list.Items, func(i, k int) bool {
+		ti, tk := list.Items[i].LastTimestamp, list.Items[k].LastTimestamp
+		if ti == "" {
+			ti = list.Items[i].EventTime
+		}
+		if tk == "" {
+			tk = list.Items[k].EventTime
+		}
+		return ti < tk
+	})
+	events := map[string][]envEvent{}
+	for _, e := range list.Items {
+		if e.InvolvedObject.Kind != "DevStagingEnvironment" {
+			continue
+		}
+		key := e.InvolvedObject.Namespace + "/" + e.InvolvedObject.Name
+		seen := e.LastTimestamp
+		if seen == "" {
+			seen = e.EventTime
+		}
+		events[key] = append(events[key], envEvent{Type: e.Type, Reason: e.Reason, Message: e.Message, Count: e.Count, LastSeen: seen})
+		if len(events[key]) > maxEnvEvents {
+			events[key] = events[key][1:]
+		}
+	}
+	return events
+}
+
+// componentRef identifies one component's workload.
+type componentRef struct {
+	namespace string
+	name      string
+	service   string // "<name>:<port>", "" when it has no Service
+}
+
+// resolveComponents finds the components named by arg: a component's
+// full name, an environment name (its app), or a role such as "postgres".
+// env limits the search to one DevStagingEnvironment or to the ones of an
+// environment namespace, the current environment when empty; with no arg
+// it selects every component of env.
+func resolveComponents(envs []envStatus, arg, env string) ([]componentRef, error) {
+	if env == "" {
+		env = currentEnvironment()
+	}
+	return matchComponents(envs, arg, env)
+}
+
+// matchComponents is resolveComponents without the current environment:
+// an empty env searches every DevStagingEnvironment.
+func matchComponents(envs []envStatus, arg, env string) ([]componentRef, error) {
+	var matches []componentRef
+	matchedDSEs := map[string]bool{}
+	found := env == ""
+	for _, e := range envs {
+		if env != "" && e.Name != env && e.Environment != env {
+			continue
+		}
+		found = true
+		for _, c := range e.Components {
+			if c.Role == "job" {
+				continue
+			}
+			if arg == "" || c.Name == arg || c.Role == arg || (c.Role == "app" && e.Name == arg) {
+				matches = append(matches, componentRef{namespace: e.Namespace, name: c.Name, service: c.Service})
+				matchedDSEs[e.Namespace+"/"+e.Name] = true
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("DevStagingEnvironment or environment %q not found — see: kindling status, kindling env list", env)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no component matches %s — see: kindling status", describeLogTarget(arg, env))
+	}
+
+	// A role like "postgres" may match one component per
+	// DevStagingEnvironment.
+	if arg != "" && len(matchedDSEs) > 1 {
+		var exact []componentRef
+		for _, m := range matches {
+			if m.name == arg {
+				exact = append(exact, m)
+			}
+		}
+		if len(exact) == 0 {
+			var names []string
+			for _, m := range matches {
+				names = append(names, m.name)
+			}
+			return nil, fmt.Errorf("%q matches %s — pass --env or the full name", arg, strings.Join(names, ", "))
+		}
+		matches = exact
+	}
+	return matches, nil
+}
+
+func hasDependencyWorkloads(workloads []kubeObject, ns, name string) bool {
+	for _, d := range workloads {
+		if d.Metadata.Namespace == ns && d.Metadata.Labels["app.kubernetes.io/part-of"] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// podHealth sums restarts across a workload's pods and returns the first
+// waiting reason (CrashLoopBackOff, ImagePullBackOff, ...).
+func podHealth(pods []kubeObject, ns, name string) (restarts int, problem string) {
+	for _, p := range pods {
+		if p.Metadata.Namespace != ns || p.Metadata.Labels["app.kubernetes.io/name"] != name {
+			continue
+		}
+		for _, cs := range p.Status.ContainerStatuses {
+			restarts += cs.RestartCount
+			if w := cs.State.Waiting; w != nil && problem == "" && w.Reason != "ContainerCreating" {
+				problem = w.Reason
+			}
+		}
+	}
+	return restarts, problem
+}
+
+func serviceFor(services []kubeObject, ns, name string) string {
+	for _, s := range services {
+		if s.Metadata.Namespace == ns && s.Metadata.Name == name && len(s.Spec.Ports) > 0 {
+			return fmt.Sprintf("%s:%d", name, s.Spec.Ports[0].Port)
+		}
+	}
+	return ""
+}
+
+func ingressHosts(ingresses []kubeObject, ns, name string) []string {
+	var hosts []string
+	for _, ing := range ingresses {
+		if ing.Metadata.Namespace != ns || ing.Metadata.Name != name {
+			continue
+		}
+		for _, r := range ing.Spec.Rules {
+			if r.Host != "" {
+				hosts = append(hosts, r.Host)
+			}
+		}
+	}
+	return hosts
+}
+
+// tunnelConfigMapData reads the kindling-tunnel ConfigMap written by
+// kindling expose.
+func tunnelConfigMapData() map[string]string {
+	data, err := readConfigMap("default", "kindling-tunnel")
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// publicURLFor returns the tunnel URL that reaches an environment: its
+// per-service tunnel, or the default tunnel when its ingress was patched
+// to the tunnel hostname.
+func publicURLFor(tunnel map[string]string, name string, hosts []string) string {
+	if u := tunnel[name+".url"]; u != "" {
+		return u
+	}
+	if tunnel["hostname"] != "" && containsString(hosts, tunnel["hostname"]) {
+		return tunnel["url"]
+	}
+	return ""
+}
+
+func printEnvironmentTree(envs []envStatus) {
+	for i, env := range envs {
+		if i > 0 {
+			fmt.Println()
+		}
+		state := colorGreen + "✓ ready" + colorReset
+		switch {
+		case env.Paused:
+			state = colorDim + "⏸ paused" + colorReset
+		case env.Asleep:
+			state = colorDim + "💤 asleep" + colorReset
+		case !env.Ready:
+			state = colorYellow + "⚠ not ready" + colorReset
+		}
+		fmt.Printf("    📦 %s%s%s  %s  %s\n", colorBold, env.Name, colorReset, state, dimText(env.Namespace))
+		if env.URL != "" {
+			fmt.Printf("       🔗 %s\n", env.URL)
+		}
+		if env.PublicURL != "" {
+			fmt.Printf("       🌍 %s\n", env.PublicURL)
+		}
+		if !env.Ready && !env.Paused && !env.Asleep {
+			printEnvironmentProblems(env)
+		}
+		if len(env.Components) == 0 {
+			fmt.Printf("       %s(no workloads yet — check kindling logs)%s\n", colorDim, colorReset)
+		}
+		for j, c := range env.Components {
+			branch, indent := "├─", "│ "
+			if j == len(env.Components)-1 {
+				branch, indent = "└─", "  "
+			}
+			icon := colorGreen + "✓" + colorReset
+			if c.Problem != "" {
+				icon = colorRed + "✗" + colorReset
+			} else if c.Ready < c.Desired {
+				icon = colorYellow + "⚠" + colorReset
+			}
+			image := c.Image
+			if c.Tag != "" {
+				image += ":" + c.Tag
+			}
+			restarts := fmt.Sprintf("restarts %d", c.Restarts)
+			if c.Restarts > 0 {
+				restarts = colorYellow + restarts + colorReset
+			}
+			if c.Kind == "CronJob" {
+				restarts = fmt.Sprintf("runs %q", c.Schedule)
+			}
+			if c.Kind == "Job" {
+				// A job's outcome matters, not its restarts.
+				switch {
+				case c.Problem != "":
+					restarts = colorRed + "failed" + colorReset
+				case c.Ready >= c.Desired:
+					restarts = colorGreen + "succeeded" + colorReset
+				default:
+					restarts = colorYellow + "running" + colorReset
+				}
+			}
+			line := fmt.Sprintf("       %s %s %-14s %d/%d  %s", branch, icon, c.Role, c.Ready, c.Desired, restarts)
+			if image != "" {
+				line += "  " + dimText(image)
+			}
+			if c.Problem != "" {
+				line += "  " + colorRed + c.Problem + colorReset
+			}
+			fmt.Println(line)
+
+			var details []string
+			if c.Kind == "CronJob" {
+				if c.LastRun == "" {
+					details = append(details, "not run yet")
+				} else {
+					details = append(details, "last run "+c.LastRun)
+				}
+			}
+			if c.Service != "" {
+				details = append(details, "svc "+c.Service)
+			}
+			for _, h := range c.Hosts {
+				if env.PublicURL == "" || tunnelHostname(env.PublicURL) != h {
+					details = append(details, "http://"+h)
+				}
+			}
+			if len(details) > 0 {
+				fmt.Printf("       %s     %s\n", indent, dimText(strings.Join(details, " · ")))
+			}
+		}
+	}
+}
+
+// componentWorkload returns the "deployment/<name>" or "statefulset/<name>"
+// reference kubectl rollout needs for a component.
+func componentWorkload(ns, name string) (string, error) {
+	out, err := kubectlJSON("get", "deployments,statefulsets", "-n", ns,
+		"--field-selector=metadata.name="+name, "-o", "name")
+	if err != nil {
+		return "", fmt.Errorf("cannot find the workload of %s: %w", name, err)
+	}
+	ref := strings.Fields(out)
+	if len(ref) == 0 {
+		return "", fmt.Errorf("%s has no Deployment or StatefulSet — see: kindling status", name)
+	}
+	kind, _, _ := strings.Cut(ref[0], ".") // deployment.apps/<name>
+	return kind + "/" + name, nil
+}
+
+// printEnvironmentProblems lists the conditions of a not-ready
+// environment that aren't True, then its latest Warning events that the
+// conditions don't already explain.
+func printEnvironmentProblems(env envStatus) {
+	shown := map[string]bool{}
+	for _, c := range env.Conditions {
+		if c.Status == "True" || c.Type == "Ready" {
+			continue
+		}
+		icon := colorYellow + "⚠" + colorReset
+		if c.Status == "False" {
+			icon = colorRed + "✗" + colorReset
+		}
+		fmt.Printf("       %s %s  %s\n", icon, c.Type, dimText(c.Message))
+		shown[c.Reason] = true
+	}
+	for _, e := range env.Events {
+		if e.Type != "Warning" || shown[e.Reason] {
+			continue
+		}
+		fmt.Printf("       %s⚡ %s%s  %s\n", colorYellow, e.Reason, colorReset, dimText(e.Message))
+	}
+}
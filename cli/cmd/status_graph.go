@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ── Topology ────────────────────────────────────────────────────
+//
+// status --graph renders the DevStagingEnvironments of each namespace as
+// the graph their spec.dependsOn lists draw: the components nothing
+// depends on at the top, each followed by the components it calls. The
+// operator holds a component back until everything it depends on is
+// available, so a waiting component shows what it waits for.
+
+// upstreamsReadyCondition is the DSE condition the operator sets while a
+// component waits for the ones it depends on.
+const upstreamsReadyCondition = "UpstreamsReady"
+
+// printEnvironmentGraph prints the dependsOn topology of envs, one tree
+// per namespace.
+func printEnvironmentGraph(envs []envStatus) {
+	byNamespace := map[string][]envStatus{}
+	var namespaces []string
+	for _, e := range envs {
+		if _, ok := byNamespace[e.Namespace]; !ok {
+			namespaces = append(namespaces, e.Namespace)
+		}
+		byNamespace[e.Namespace] = append(byNamespace[e.Namespace], e)
+	}
+	sort.Strings(namespaces)
+
+	for i, ns := range namespaces {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("    %s%s%s\n", colorBold, ns, colorReset)
+		nodes := map[string]envStatus{}
+		called := map[string]bool{}
+		for _, e := range byNamespace[ns] {
+			nodes[e.Name] = e
+			for _, up := range e.DependsOn {
+				called[up] = true
+			}
+		}
+		var roots []string
+		for _, e := range byNamespace[ns] {
+			if !called[e.Name] {
+				roots = append(roots, e.Name)
+			}
+		}
+		if len(roots) == 0 {
+			// Every component is called by another: a cycle. Start anywhere.
+			roots = []string{byNamespace[ns][0].Name}
+		}
+		sort.Strings(roots)
+		printed := map[string]bool{}
+		for j, name := range roots {
+			printGraphNode(nodes, name, "    ", j == len(roots)-1, printed, map[string]bool{})
+		}
+	}
+}
+
+// printGraphNode prints name and, below it, the components it depends on.
+// A component reached a second time is printed without its upstreams, and
+// one reached again on its own path closes a cycle.
+func printGraphNode(nodes map[string]envStatus, name, prefix string, last bool, printed, path map[string]bool) {
+	branch, indent := "├─", "│  "
+	if last {
+		branch, indent = "└─", "   "
+	}
+	e, ok := nodes[name]
+	line := fmt.Sprintf("%s%s ", prefix, branch)
+	switch {
+	case !ok:
+		fmt.Printf("%s%s✗%s %s  %s\n", line, colorRed, colorReset, name, colorRed+"not deployed"+colorReset)
+		return
+	case path[name]:
+		fmt.Printf("%s%s↺%s %s  %s\n", line, colorRed, colorReset, name, colorRed+"dependsOn cycle"+colorReset)
+		return
+	}
+
+	icon := colorGreen + "✓" + colorReset
+	detail := ""
+	if !e.Ready {
+		icon = colorYellow + "⚠" + colorReset
+		for _, c := range e.Conditions {
+			if c.Type == upstreamsReadyCondition && c.Status == "False" && c.Message != "" {
+				icon = colorYellow + "⏳" + colorReset
+				detail = colorYellow + strings.ToLower(c.Message[:1]) + c.Message[1:] + colorReset
+			}
+		}
+	}
+	var backing []string
+	for _, c := range e.Components {
+		if c.Role != "app" && c.Role != "job" {
+			backing = append(backing, c.Role)
+		}
+	}
+	if len(backing) > 0 {
+		line += fmt.Sprintf("%s %s  %s", icon, e.Name, dimText("· "+strings.Join(backing, ", ")))
+	} else {
+		line += fmt.Sprintf("%s %s", icon, e.Name)
+	}
+	if detail != "" {
+		line += "  " + detail
+	}
+	if printed[name] && len(e.DependsOn) > 0 {
+		fmt.Println(line + "  " + dimText("(see above)"))
+		return
+	}
+	fmt.Println(line)
+	printed[name] = true
+
+	path[name] = true
+	ups := append([]string(nil), e.DependsOn...)
+	sort.Strings(ups)
+	for i, up := range ups {
+		printGraphNode(nodes, up, prefix+indent, i == len(ups)-1, printed, path)
+	}
+	delete(path, name)
+}
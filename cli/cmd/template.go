@@ -0,0 +1,865 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+// ── Template repositories ───────────────────────────────────────
+//
+// Besides the built-in catalog, kindling new scaffolds from template
+// repositories a platform team publishes: a git repository or an OCI
+// artifact with a kindling-templates.yaml at its root that lists the
+// templates and their versions, and the conventions every
+// DevStagingEnvironment generated from them must follow — labels, a
+// resource tier, required sidecars. Repositories are registered in the
+// user's config.yaml (or the project's) and fetched into the user cache
+// directory; kindling new --template <repo>/<template>[@version] renders
+// from there.
+
+// templateCatalogFile is the catalog at the root of a template repository.
+const templateCatalogFile = "kindling-templates.yaml"
+
+// templateRepo is one registered template repository.
+type templateRepo struct {
+	Name string `yaml:"name" json:"name"`
+	URL  string `yaml:"url" json:"url"`           // git URL or path, or oci://<registry>/<repository>
+	Ref  string `yaml:"ref,omitempty" json:"ref"` // git branch or tag, or OCI tag
+}
+
+// templateCatalog is the layout of kindling-templates.yaml.
+//
+//	conventions:
+//	  labels:
+//	    acme.com/cost-center: platform
+//	  tier: small
+//	  tiers:
+//	    small: {cpuRequest: 100m, memoryRequest: 128Mi, memoryLimit: 256Mi}
+//	    large: {cpuRequest: 500m, memoryRequest: 512Mi, memoryLimit: 1Gi}
+//	  sidecars:
+//	    - name: otel-agent
+//	      image: ghcr.io/acme/otel-agent:1.4
+//	templates:
+//	  - name: go-service
+//	    description: Go HTTP service with Acme's middleware
+//	    dependencies: [postgres]
+//	    tier: large
+//	    versions:            # newest first
+//	      - version: "2.0"
+//	        path: go-service/2.0
+//	      - version: "1.4"
+//	        path: go-service/1.4
+type templateCatalog struct {
+	Conventions templateConventions `yaml:"conventions,omitempty"`
+	Templates   []catalogTemplate   `yaml:"templates"`
+}
+
+// templateConventions are applied to every DevStagingEnvironment a
+// repository's templates generate.
+type templateConventions struct {
+	Labels   map[string]string       `yaml:"labels,omitempty"`
+	Tier     string                  `yaml:"tier,omitempty"` // default resource tier
+	Tiers    map[string]resourceTier `yaml:"tiers,omitempty"`
+	Sidecars []devstaging.Sidecar    `yaml:"sidecars,omitempty"`
+}
+
+// resourceTier is a named set of app container resources.
+type resourceTier struct {
+	CPURequest    string `yaml:"cpuRequest,omitempty"`
+	MemoryRequest string `yaml:"memoryRequest,omitempty"`
+	CPULimit      string `yaml:"cpuLimit,omitempty"`
+	MemoryLimit   string `yaml:"memoryLimit,omitempty"`
+}
+
+// catalogTemplate is one template of a repository. A template without
+// versions is the directory Path, or the one named after it.
+type catalogTemplate struct {
+	Name         string            `yaml:"name"`
+	Description  string            `yaml:"description,omitempty"`
+	Dependencies []string          `yaml:"dependencies,omitempty"`
+	Tier         string            `yaml:"tier,omitempty"`
+	Path         string            `yaml:"path,omitempty"`
+	Versions     []templateVersion `yaml:"versions,omitempty"` // newest first
+}
+
+// templateVersion is one version of a template and its directory.
+type templateVersion struct {
+	Version string `yaml:"version"`
+	Path    string `yaml:"path"`
+}
+
+var dnsLabelPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage the template repositories of kindling new",
+	Long: `Registers template repositories — your organization's own catalog of
+project templates — alongside the built-in one, so kindling new
+scaffolds projects that follow its conventions.
+
+A template repository is a git repository or an OCI artifact with a
+kindling-templates.yaml at its root. It lists the templates, each with
+its versions (newest first) and the directory holding each, and the
+conventions applied to every DevStagingEnvironment they generate:
+
+  conventions:
+    labels:                 # added to metadata.labels
+      acme.com/cost-center: platform
+    tier: small             # default resource tier of the app container
+    tiers:
+      small: {cpuRequest: 100m, memoryRequest: 128Mi, memoryLimit: 256Mi}
+      large: {cpuRequest: 500m, memoryRequest: 512Mi, memoryLimit: 1Gi}
+    sidecars:               # added to spec.deployment.sidecars
+      - name: otel-agent
+        image: ghcr.io/acme/otel-agent:1.4
+  templates:
+    - name: go-service
+      description: Go HTTP service with Acme's middleware
+      dependencies: [postgres]
+      tier: large           # overrides the default tier
+      versions:
+        - version: "2.0"
+          path: go-service/2.0
+        - version: "1.4"
+          path: go-service/1.4
+
+Template directories are rendered like the built-in templates: {{name}}
+and {{ingressClass}} are replaced, and a .tmpl suffix is dropped.
+
+Examples:
+  kindling template repo add acme https://github.com/acme/kindling-templates.git
+  kindling template repo add acme oci://ghcr.io/acme/kindling-templates --ref 2026.10
+  kindling new --list
+  kindling new --template acme/go-service@1.4 payments`,
+}
+
+var templateRepoCmd = &cobra.Command{
+	Use:   "repo",
+	Short: "Add, list, update, and remove template repositories",
+}
+
+var templateRepoAddCmd = &cobra.Command{
+	Use:   "add <name> <url>",
+	Short: "Register a template repository and fetch it",
+	Long: `Fetches the template repository at <url> and registers it as <name>,
+the prefix of its templates in kindling new --template <name>/<template>.
+
+<url> is anything git clone takes — an HTTPS or SSH URL, or a local
+path — or oci://<registry>/<repository> for an OCI artifact, which is
+pulled with oras. --ref picks a branch or tag of a git repository, or
+the tag of an artifact (default: latest).
+
+The repository is saved in your ~/.config/kindling/config.yaml, or in
+the project's .kindling/config.yaml with --project; a project's
+repository of the same name takes precedence.
+
+Examples:
+  kindling template repo add acme https://github.com/acme/kindling-templates.git
+  kindling template repo add acme git@github.com:acme/kindling-templates.git --ref v3
+  kindling template repo add acme oci://ghcr.io/acme/kindling-templates --ref 2026.10`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         runTemplateRepoAdd,
+}
+
+var templateRepoListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the template repositories and their templates",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runTemplateRepoList,
+}
+
+var templateRepoUpdateCmd = &cobra.Command{
+	Use:               "update [name...]",
+	Short:             "Fetch the latest templates of every repository, or the named ones",
+	SilenceUsage:      true,
+	ValidArgsFunction: completeTemplateRepos,
+	RunE:              runTemplateRepoUpdate,
+}
+
+var templateRepoRemoveCmd = &cobra.Command{
+	Use:               "remove <name>",
+	Short:             "Unregister a template repository",
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeTemplateRepos),
+	RunE:              runTemplateRepoRemove,
+}
+
+var (
+	templateRepoRef     string
+	templateRepoProject bool
+)
+
+func init() {
+	templateRepoAddCmd.Flags().StringVar(&templateRepoRef, "ref", "", "Branch or tag of a git repository, or tag of an OCI artifact")
+	templateRepoAddCmd.Flags().BoolVar(&templateRepoProject, "project", false, "Save in the project's .kindling/config.yaml instead of ~/.config/kindling/config.yaml")
+	templateRepoRemoveCmd.Flags().BoolVar(&templateRepoProject, "project", false, "Remove from the project's .kindling/config.yaml instead of ~/.config/kindling/config.yaml")
+	templateRepoCmd.AddCommand(templateRepoAddCmd, templateRepoListCmd, templateRepoUpdateCmd, templateRepoRemoveCmd)
+	templateCmd.AddCommand(templateRepoCmd)
+	rootCmd.AddCommand(templateCmd)
+}
+
+// templateRepos returns the registered repositories: the user's, then the
+// project's, which replace the user's of the same name.
+func templateRepos() ([]templateRepo, error) {
+	project, user, err := loadConfigLayers()
+	if err != nil {
+		return nil, err
+	}
+	repos := append([]templateRepo{}, user.Templates.Repos...)
+	for _, r := range project.Templates.Repos {
+		i := 0
+		for i < len(repos) && repos[i].Name != r.Name {
+			i++
+		}
+		if i < len(repos) {
+			repos[i] = r
+		} else {
+			repos = append(repos, r)
+		}
+	}
+	return repos, nil
+}
+
+// lookupTemplateRepo returns the repository registered as name.
+func lookupTemplateRepo(name string) (templateRepo, error) {
+	repos, err := templateRepos()
+	if err != nil {
+		return templateRepo{}, err
+	}
+	for _, r := range repos {
+		if r.Name == name {
+			return r, nil
+		}
+	}
+	return templateRepo{}, fmt.Errorf("no template repository named %q — add it with: kindling template repo add %s <url>", name, name)
+}
+
+// templateRepoDir returns where repo is fetched to:
+// <user cache dir>/kindling/templates/<name>.
+func templateRepoDir(repo templateRepo) (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "kindling", "templates", repo.Name), nil
+}
+
+// fetchTemplateRepo fetches repo into its cache directory, replacing what
+// was there only once the new copy has a readable catalog.
+func fetchTemplateRepo(repo templateRepo) (*templateCatalog, error) {
+	dir, err := templateRepoDir(repo)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), repo.Name+"-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	if ref, ok := strings.CutPrefix(repo.URL, "oci://"); ok {
+		if !commandExists("oras") {
+			return nil, fmt.Errorf("pulling %s needs oras — install it: https://oras.land/docs/installation", repo.URL)
+		}
+		tag := repo.Ref
+		if tag == "" {
+			tag = "latest"
+		}
+		if out, err := runSilent("oras", "pull", ref+":"+tag, "--output", tmp); err != nil {
+			return nil, fmt.Errorf("cannot pull %s:%s: %s", repo.URL, tag, out)
+		}
+	} else {
+		args := []string{"clone", "--quiet", "--depth=1"}
+		if repo.Ref != "" {
+			args = append(args, "--branch", repo.Ref)
+		}
+		if out, err := runSilent("git", append(args, repo.URL, tmp)...); err != nil {
+			return nil, fmt.Errorf("cannot clone %s: %s", repo.URL, out)
+		}
+		_ = os.RemoveAll(filepath.Join(tmp, ".git"))
+	}
+
+	catalog, err := loadTemplateCatalog(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", repo.URL, err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// repoCatalog returns the catalog of repo, fetching it first if it
+// hasn't been.
+func repoCatalog(repo templateRepo) (*templateCatalog, string, error) {
+	dir, err := templateRepoDir(repo)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := os.Stat(filepath.Join(dir, templateCatalogFile)); err != nil {
+		step("📥", fmt.Sprintf("Fetching template repository %s", repo.Name))
+		catalog, err := fetchTemplateRepo(repo)
+		return catalog, dir, err
+	}
+	catalog, err := loadTemplateCatalog(dir)
+	return catalog, dir, err
+}
+
+// loadTemplateCatalog reads and checks dir's kindling-templates.yaml.
+func loadTemplateCatalog(dir string) (*templateCatalog, error) {
+	data, err := os.ReadFile(filepath.Join(dir, templateCatalogFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no %s at the repository's root", templateCatalogFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	catalog := &templateCatalog{}
+	if err := yaml.Unmarshal(data, catalog); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", templateCatalogFile, err)
+	}
+
+	conv := catalog.Conventions
+	if conv.Tier != "" && conv.Tiers[conv.Tier] == (resourceTier{}) {
+		return nil, fmt.Errorf("%s: conventions.tier %q isn't one of conventions.tiers", templateCatalogFile, conv.Tier)
+	}
+	for i, sc := range conv.Sidecars {
+		if !dnsLabelPattern.MatchString(sc.Name) || sc.Image == "" {
+			return nil, fmt.Errorf("%s: conventions.sidecars[%d] needs a lowercase DNS label name and an image", templateCatalogFile, i)
+		}
+	}
+	seen := map[string]bool{}
+	for i, t := range catalog.Templates {
+		switch {
+		case source.DNSLabel(t.Name) != t.Name:
+			return nil, fmt.Errorf("%s: templates[%d]: %q isn't a lowercase name of letters, digits, and dashes", templateCatalogFile, i, t.Name)
+		case seen[t.Name]:
+			return nil, fmt.Errorf("%s: templates[%d]: %s is listed twice", templateCatalogFile, i, t.Name)
+		case t.Tier != "" && conv.Tiers[t.Tier] == (resourceTier{}):
+			return nil, fmt.Errorf("%s: %s: tier %q isn't one of conventions.tiers", templateCatalogFile, t.Name, t.Tier)
+		}
+		seen[t.Name] = true
+		for _, v := range t.Versions {
+			if v.Version == "" || v.Path == "" {
+				return nil, fmt.Errorf("%s: %s: every version needs a version and a path", templateCatalogFile, t.Name)
+			}
+		}
+	}
+	return catalog, nil
+}
+
+// repoTemplate is a template of a repository, resolved to one version.
+type repoTemplate struct {
+	Repo        templateRepo
+	Template    catalogTemplate
+	Version     string // "" for a template without versions
+	Dir         string // the version's directory
+	Conventions templateConventions
+}
+
+// findRepoTemplate resolves <repo>/<template>[@version].
+func findRepoTemplate(ref string) (*repoTemplate, error) {
+	repoName, name, _ := strings.Cut(ref, "/")
+	name, version, _ := strings.Cut(name, "@")
+	repo, err := lookupTemplateRepo(repoName)
+	if err != nil {
+		return nil, err
+	}
+	catalog, dir, err := repoCatalog(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, t := range catalog.Templates {
+		names = append(names, repoName+"/"+t.Name)
+		if t.Name != name {
+			continue
+		}
+		rt := &repoTemplate{Repo: repo, Template: t, Conventions: catalog.Conventions}
+		if len(t.Versions) == 0 {
+			if version != "" {
+				return nil, fmt.Errorf("%s/%s has no versions", repoName, name)
+			}
+			rt.Dir, err = templateDir(dir, templatePath(t))
+			return rt, err
+		}
+		v := t.Versions[0]
+		if version != "" {
+			var versions []string
+			found := false
+			for _, tv := range t.Versions {
+				versions = append(versions, tv.Version)
+				if tv.Version == version {
+					v, found = tv, true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("%s/%s has no version %q — pick one of %s", repoName, name, version, strings.Join(versions, ", "))
+			}
+		}
+		rt.Version = v.Version
+		rt.Dir, err = templateDir(dir, v.Path)
+		return rt, err
+	}
+	return nil, fmt.Errorf("%s has no template %q — pick one of %s", repoName, name, strings.Join(names, ", "))
+}
+
+// templateDir returns the directory p of the repository fetched to dir,
+// which must be inside it.
+func templateDir(dir, p string) (string, error) {
+	clean := path.Clean(p)
+	if path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%s: template path %q must be a directory inside the repository", templateCatalogFile, p)
+	}
+	full := filepath.Join(dir, filepath.FromSlash(clean))
+	if info, err := os.Stat(full); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s: template path %q isn't a directory of the repository", templateCatalogFile, p)
+	}
+	return full, nil
+}
+
+// templatePath is the directory of a template without versions.
+func templatePath(t catalogTemplate) string {
+	if t.Path != "" {
+		return t.Path
+	}
+	return t.Name
+}
+
+// applyConventions makes every DevStagingEnvironment in the YAML files of
+// dir follow the repository's conventions: its labels, the template's
+// resource tier where the app sets no resources, and the required
+// sidecars the app doesn't already run. It returns the files it changed.
+func applyConventions(dir string, files []string, rt *repoTemplate) ([]string, error) {
+	conv := rt.Conventions
+	tierName := rt.Template.Tier
+	if tierName == "" {
+		tierName = conv.Tier
+	}
+	tier := conv.Tiers[tierName]
+
+	var changed []string
+	for _, f := range files {
+		if ext := path.Ext(f); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		file := filepath.Join(dir, filepath.FromSlash(f))
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var docs []*yaml.Node
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		for {
+			var doc yaml.Node
+			err := dec.Decode(&doc)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid YAML: %w", f, err)
+			}
+			docs = append(docs, &doc)
+		}
+
+		dses := 0
+		for _, doc := range docs {
+			if len(doc.Content) == 0 {
+				continue
+			}
+			root := doc.Content[0]
+			if kind := devstaging.MappingValue(root, "kind"); kind == nil || kind.Value != "DevStagingEnvironment" {
+				continue
+			}
+			if err := applyDSEConventions(root, conv, tier); err != nil {
+				return nil, fmt.Errorf("%s: %w", f, err)
+			}
+			dses++
+		}
+		if dses == 0 {
+			continue
+		}
+
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		for _, doc := range docs {
+			if err := enc.Encode(doc); err != nil {
+				return nil, err
+			}
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+			return nil, fmt.Errorf("cannot write %s: %w", file, err)
+		}
+		changed = append(changed, f)
+	}
+	return changed, nil
+}
+
+// applyDSEConventions applies conv and tier to the DevStagingEnvironment
+// root. Resources are written in the form of its apiVersion.
+func applyDSEConventions(root *yaml.Node, conv templateConventions, tier resourceTier) error {
+	if len(conv.Labels) > 0 {
+		labels := ensureMapping(ensureMapping(root, "metadata"), "labels")
+		keys := make([]string, 0, len(conv.Labels))
+		for k := range conv.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			setMappingScalar(labels, k, conv.Labels[k])
+		}
+	}
+
+	deployment := ensureMapping(ensureMapping(root, "spec"), "deployment")
+	if tier != (resourceTier{}) && devstaging.MappingValue(deployment, "resources") == nil {
+		fields := [][2]string{
+			{"cpuRequest", tier.CPURequest},
+			{"memoryRequest", tier.MemoryRequest},
+			{"cpuLimit", tier.CPULimit},
+			{"memoryLimit", tier.MemoryLimit},
+		}
+		resources := ensureMapping(deployment, "resources")
+		v1beta1 := false
+		if v := devstaging.MappingValue(root, "apiVersion"); v != nil && v.Value == devstaging.APIVersionV1beta1 {
+			v1beta1 = true
+		}
+		for _, f := range fields {
+			if f[1] == "" {
+				continue
+			}
+			if v1beta1 {
+				list := devstaging.V1alpha1ResourceFields[f[0]]
+				setMappingScalar(ensureMapping(resources, list[0]), list[1], f[1])
+			} else {
+				setMappingScalar(resources, f[0], f[1])
+			}
+		}
+	}
+
+	if len(conv.Sidecars) > 0 {
+		sidecars := devstaging.MappingValue(deployment, "sidecars")
+		if sidecars == nil {
+			sidecars = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			deployment.Content = append(deployment.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "sidecars"}, sidecars)
+		}
+		if sidecars.Kind != yaml.SequenceNode {
+			return fmt.Errorf("spec.deployment.sidecars isn't a list")
+		}
+		running := map[string]bool{}
+		for _, sc := range sidecars.Content {
+			if n := devstaging.MappingValue(sc, "name"); n != nil {
+				running[n.Value] = true
+			}
+		}
+		for _, sc := range conv.Sidecars {
+			if running[sc.Name] {
+				continue
+			}
+			node := &yaml.Node{}
+			if err := node.Encode(sc); err != nil {
+				return err
+			}
+			sidecars.Content = append(sidecars.Content, node)
+		}
+	}
+	return nil
+}
+
+// ensureMapping returns the mapping under key in node, adding an empty
+// one when there is none.
+func ensureMapping(node *yaml.Node, key string) *yaml.Node {
+	if v := devstaging.MappingValue(node, key); v != nil {
+		if v.Kind != yaml.MappingNode {
+			*v = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		return v
+	}
+	v := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, v)
+	return v
+}
+
+// setMappingScalar sets key in node to the string value.
+func setMappingScalar(node *yaml.Node, key, value string) {
+	if v := devstaging.MappingValue(node, key); v != nil {
+		*v = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, LineComment: v.LineComment}
+		return
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+}
+
+// repoTemplates returns the catalog entries of every registered
+// repository, named <repo>/<template>, for new --list. A repository that
+// can't be fetched is reported and skipped.
+func repoTemplates() []projectTemplate {
+	repos, err := templateRepos()
+	if err != nil {
+		warn(err.Error())
+		return nil
+	}
+	var templates []projectTemplate
+	for _, repo := range repos {
+		catalog, _, err := repoCatalog(repo)
+		if err != nil {
+			warn(fmt.Sprintf("%s: %v", repo.Name, err))
+			continue
+		}
+		for _, t := range catalog.Templates {
+			pt := projectTemplate{
+				Name:         repo.Name + "/" + t.Name,
+				Description:  t.Description,
+				Dependencies: t.Dependencies,
+				Repo:         repo.Name,
+			}
+			if pt.Dependencies == nil {
+				pt.Dependencies = []string{}
+			}
+			for _, v := range t.Versions {
+				pt.Versions = append(pt.Versions, v.Version)
+			}
+			templates = append(templates, pt)
+		}
+	}
+	return templates
+}
+
+// templateRepoConfigPath is the config file repo add and remove write:
+// the user's, or the project's with --project.
+func templateRepoConfigPath() (string, error) {
+	if !templateRepoProject {
+		return userConfigPath()
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return configPath(cwd), nil
+}
+
+// templateRepoStatus is the JSON form of a repository in repo add,
+// list, and update.
+type templateRepoStatus struct {
+	templateRepo
+	Templates []string `json:"templates"`
+	Error     string   `json:"error,omitempty"`
+}
+
+func runTemplateRepoAdd(cmd *cobra.Command, args []string) error {
+	repo := templateRepo{Name: args[0], URL: args[1], Ref: templateRepoRef}
+	if source.DNSLabel(repo.Name) != repo.Name {
+		return fmt.Errorf("%q can't prefix template names — use lowercase letters, digits, and dashes, e.g. %s", repo.Name, source.DNSLabel(repo.Name))
+	}
+	if !strings.HasPrefix(repo.URL, "oci://") && !strings.Contains(repo.URL, ":") {
+		// A local path: clone it from wherever kindling is run later.
+		abs, err := filepath.Abs(repo.URL)
+		if err != nil {
+			return err
+		}
+		repo.URL = abs
+	}
+
+	sp := startSpinner(fmt.Sprintf("Fetching %s", repo.URL))
+	catalog, err := fetchTemplateRepo(repo)
+	sp.stop()
+	if err != nil {
+		return err
+	}
+
+	path, err := templateRepoConfigPath()
+	if err != nil {
+		return err
+	}
+	cfg := &kindlingConfig{}
+	if err := readConfigFile(path, cfg); err != nil {
+		return err
+	}
+	replaced := false
+	for i, r := range cfg.Templates.Repos {
+		if r.Name == repo.Name {
+			cfg.Templates.Repos[i], replaced = repo, true
+		}
+	}
+	if !replaced {
+		cfg.Templates.Repos = append(cfg.Templates.Repos, repo)
+	}
+	if err := writeConfigFile(path, cfg); err != nil {
+		return err
+	}
+
+	status := templateRepoStatus{templateRepo: repo}
+	for _, t := range catalog.Templates {
+		status.Templates = append(status.Templates, repo.Name+"/"+t.Name)
+	}
+	return render(status, func() {
+		verb := "Added"
+		if replaced {
+			verb = "Updated"
+		}
+		success(fmt.Sprintf("%s template repository %s in %s", verb, repo.Name, path))
+		for _, t := range status.Templates {
+			step("📦", t)
+		}
+		fmt.Printf("\n  Scaffold one with: %skindling new --template <template> <name>%s\n\n", colorCyan, colorReset)
+	})
+}
+
+func runTemplateRepoList(cmd *cobra.Command, args []string) error {
+	repos, err := templateRepos()
+	if err != nil {
+		return err
+	}
+	var rows []templateRepoStatus
+	for _, repo := range repos {
+		row := templateRepoStatus{templateRepo: repo, Templates: []string{}}
+		if catalog, _, err := repoCatalog(repo); err != nil {
+			row.Error = err.Error()
+		} else {
+			for _, t := range catalog.Templates {
+				row.Templates = append(row.Templates, t.Name)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return render(rows, func() {
+		header("Template repositories")
+		if len(rows) == 0 {
+			fmt.Printf("    %s\n\n", dimText("None — add one with: kindling template repo add <name> <url>"))
+			return
+		}
+		for _, r := range rows {
+			ref := ""
+			if r.Ref != "" {
+				ref = dimText(" @ " + r.Ref)
+			}
+			fmt.Printf("    📚 %-16s %s%s\n", r.Name, r.URL, ref)
+			if r.Error != "" {
+				fmt.Printf("       %s❌ %s%s\n", colorRed, r.Error, colorReset)
+				continue
+			}
+			fmt.Printf("       %s\n", dimText(strings.Join(r.Templates, ", ")))
+		}
+		fmt.Println()
+	})
+}
+
+func runTemplateRepoUpdate(cmd *cobra.Command, args []string) error {
+	repos, err := templateRepos()
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		var named []templateRepo
+		for _, name := range args {
+			repo, err := lookupTemplateRepo(name)
+			if err != nil {
+				return err
+			}
+			named = append(named, repo)
+		}
+		repos = named
+	}
+
+	header("Updating template repositories")
+	var rows []templateRepoStatus
+	failed := 0
+	for _, repo := range repos {
+		row := templateRepoStatus{templateRepo: repo, Templates: []string{}}
+		catalog, err := fetchTemplateRepo(repo)
+		if err != nil {
+			row.Error = err.Error()
+			fail(fmt.Sprintf("%s: %v", repo.Name, err))
+			failed++
+		} else {
+			for _, t := range catalog.Templates {
+				row.Templates = append(row.Templates, t.Name)
+			}
+			success(fmt.Sprintf("%s: %d template(s)", repo.Name, len(row.Templates)))
+		}
+		rows = append(rows, row)
+	}
+	if err := render(rows, func() { fmt.Println() }); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d template repositories could not be fetched", failed, len(repos))
+	}
+	return nil
+}
+
+func runTemplateRepoRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	path, err := templateRepoConfigPath()
+	if err != nil {
+		return err
+	}
+	cfg := &kindlingConfig{}
+	if err := readConfigFile(path, cfg); err != nil {
+		return err
+	}
+	var kept []templateRepo
+	var removed *templateRepo
+	for _, r := range cfg.Templates.Repos {
+		if r.Name == name {
+			removed = &r
+			continue
+		}
+		kept = append(kept, r)
+	}
+	if removed == nil {
+		return fmt.Errorf("%s has no template repository named %q", path, name)
+	}
+	cfg.Templates.Repos = kept
+	if err := writeConfigFile(path, cfg); err != nil {
+		return err
+	}
+	// Another config file may still register the name.
+	if _, err := lookupTemplateRepo(name); err != nil {
+		if dir, err := templateRepoDir(*removed); err == nil {
+			_ = os.RemoveAll(dir)
+		}
+	}
+	return render(templateRepoStatus{templateRepo: *removed, Templates: []string{}}, func() {
+		success(fmt.Sprintf("Removed template repository %s from %s", name, path))
+	})
+}
+
+// completeTemplateRepos completes the names of registered repositories.
+func completeTemplateRepos(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	repos, _ := templateRepos()
+	var names []string
+	for _, r := range repos {
+		names = append(names, r.Name+"\t"+r.URL)
+	}
+	return completions(names, args), cobra.ShellCompDirectiveNoFileComp
+}
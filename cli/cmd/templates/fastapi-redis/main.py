@@ -0,0 +1,43 @@
+"""{{name}} is a FastAPI service backed by Redis, scaffolded by kindling new.
+
+The operator provisions the redis dependency of dev-environment.yaml and
+injects REDIS_URL; the app only has to read it.
+"""
+
+import os
+
+import redis
+from fastapi import FastAPI, HTTPException
+from pydantic import BaseModel
+
+cache = redis.Redis.from_url(os.environ.get("REDIS_URL", "redis://localhost:6379/0"), decode_responses=True)
+app = FastAPI(title="{{name}}")
+
+
+class Item(BaseModel):
+    value: str
+
+
+@app.get("/healthz")
+def healthz():
+    cache.ping()
+    return {"status": "ok"}
+
+
+@app.get("/")
+def index():
+    return {"app": "{{name}}", "visits": cache.incr("visits")}
+
+
+@app.put("/items/{key}")
+def put_item(key: str, item: Item):
+    cache.set(f"item:{key}", item.value)
+    return {"key": key, "value": item.value}
+
+
+@app.get("/items/{key}")
+def get_item(key: str):
+    value = cache.get(f"item:{key}")
+    if value is None:
+        raise HTTPException(status_code=404, detail=f"no item {key}")
+    return {"key": key, "value": value}
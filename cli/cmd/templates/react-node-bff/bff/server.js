@@ -0,0 +1,18 @@
+// The backend for the {{name}} frontend, scaffolded by kindling new.
+//
+// The Ingress routes every request under /api here. This is the place to
+// call the backends the frontend needs and shape their answers for it.
+import os from 'node:os'
+import express from 'express'
+
+const app = express()
+app.use(express.json())
+
+app.get('/healthz', (req, res) => res.send('ok'))
+
+app.get('/api/greeting', (req, res) => {
+  res.json({ message: 'Hello from the {{name}} BFF', servedBy: os.hostname() })
+})
+
+const port = process.env.PORT || 3000
+app.listen(port, () => console.log(`{{name}}-bff listening on :${port}`))
@@ -0,0 +1,25 @@
+import { useEffect, useState } from 'react'
+
+export default function App() {
+  const [greeting, setGreeting] = useState(null)
+  const [error, setError] = useState(null)
+
+  useEffect(() => {
+    fetch('/api/greeting')
+      .then((res) => (res.ok ? res.json() : Promise.reject(new Error(res.statusText))))
+      .then(setGreeting)
+      .catch((err) => setError(err.message))
+  }, [])
+
+  return (
+    <main>
+      <h1>{{name}}</h1>
+      {error && <p>The BFF didn't answer: {error}</p>}
+      {greeting && (
+        <p>
+          {greeting.message} <small>(served by {greeting.servedBy})</small>
+        </p>
+      )}
+    </main>
+  )
+}
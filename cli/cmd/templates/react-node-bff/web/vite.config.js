@@ -0,0 +1,11 @@
+import { defineConfig } from 'vite'
+import react from '@vitejs/plugin-react'
+
+// In the cluster the Ingress sends /api to the BFF; under npm run dev
+// Vite does, to one started with npm start in ../bff.
+export default defineConfig({
+  plugins: [react()],
+  server: {
+    proxy: { '/api': 'http://localhost:3000' },
+  },
+})
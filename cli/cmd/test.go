@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Smoke-test running environments",
+	Long:  `Commands that check running DevStagingEnvironments from the outside.`,
+}
+
+var testNetworkingCmd = &cobra.Command{
+	Use:   "networking [environment...]",
+	Short: "Check every ingress route end to end: DNS, TLS, and HTTP",
+	Long: `Requests the health-check path of every environment with an ingress
+the way a browser would — through the ingress controller, and through the
+public tunnel when kindling expose is running — and prints a pass/fail
+table with one row per route.
+
+Each route is checked in order:
+
+  DNS   the host resolves (*.localhost always resolves to loopback)
+  TLS   for https routes, the certificate is trusted and matches the host
+  HTTP  the health-check path answers 2xx or 3xx; redirects aren't followed
+
+Environments with a tcp health check, or whose health path lies outside
+the ingress path, are checked at the ingress path instead, where any
+response below 500 passes. gRPC routes get the DNS and TLS checks only.
+
+With no arguments every environment is checked. The command exits
+non-zero when any route fails.
+
+Examples:
+  kindling test networking
+  kindling test networking orders-dev
+  kindling test networking --timeout 30s -o json`,
+	SilenceUsage:      true,
+	ValidArgsFunction: completeDSEs,
+	RunE:              runTestNetworking,
+}
+
+var testTimeout time.Duration
+
+func init() {
+	testNetworkingCmd.Flags().DurationVar(&testTimeout, "timeout", 10*time.Second, "Time limit for each route's checks")
+	testCmd.AddCommand(testNetworkingCmd)
+	rootCmd.AddCommand(testCmd)
+}
+
+// routeCheck is one row of the networking report.
+type routeCheck struct {
+	Environment string `json:"environment"`
+	Namespace   string `json:"namespace"`
+	Via         string `json:"via"` // ingress or tunnel
+	URL         string `json:"url"`
+	DNS         string `json:"dns,omitempty"`
+	TLS         string `json:"tls,omitempty"`
+	Status      int    `json:"status,omitempty"`
+	Millis      int64  `json:"millis,omitempty"`
+	Pass        bool   `json:"pass"`
+	Error       string `json:"error,omitempty"`
+}
+
+// networkedDSE holds the fields test networking reads from a DSE.
+type networkedDSE struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Deployment struct {
+			Schedule    string `json:"schedule"`
+			HealthCheck *struct {
+				Type string `json:"type"`
+				Path string `json:"path"`
+			} `json:"healthCheck"`
+		} `json:"deployment"`
+		Ingress *struct {
+			Enabled  bool      `json:"enabled"`
+			Host     string    `json:"host"`
+			Path     string    `json:"path"`
+			Protocol string    `json:"protocol"`
+			TLS      *struct{} `json:"tls"`
+		} `json:"ingress"`
+	} `json:"spec"`
+}
+
+func runTestNetworking(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	out, err := kubectlJSON("get", "devstagingenvironments", "-A", "-o", "json")
+	if err != nil {
+		return fmt.Errorf("cannot list DevStagingEnvironments: %w", err)
+	}
+	var list struct {
+		Items []networkedDSE `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return fmt.Errorf("cannot parse DevStagingEnvironments: %w", err)
+	}
+
+	wanted := map[string]bool{}
+	for _, a := range args {
+		wanted[a] = true
+	}
+	tunnel := tunnelConfigMapData()
+	checks := []routeCheck{}
+	for _, dse := range list.Items {
+		name := dse.Metadata.Name
+		if len(wanted) > 0 && !wanted[name] {
+			continue
+		}
+		delete(wanted, name)
+		ing := dse.Spec.Ingress
+		if ing == nil || !ing.Enabled || ing.Host == "" || dse.Spec.Deployment.Schedule != "" {
+			continue
+		}
+		path, lenient := networkCheckPath(dse)
+		scheme := "http"
+		if ing.TLS != nil {
+			scheme = "https"
+		}
+		grpc := ing.Protocol == "grpc"
+		header(fmt.Sprintf("Checking %s", name))
+		c := checkRoute(scheme+"://"+ing.Host+path, lenient, grpc)
+		c.Environment, c.Namespace, c.Via = name, dse.Metadata.Namespace, "ingress"
+		checks = append(checks, c)
+		if public := publicURLFor(tunnel, name, []string{ing.Host}); public != "" {
+			c := checkRoute(strings.TrimSuffix(public, "/")+path, lenient, grpc)
+			c.Environment, c.Namespace, c.Via = name, dse.Metadata.Namespace, "tunnel"
+			checks = append(checks, c)
+		}
+	}
+	if len(wanted) > 0 {
+		return fmt.Errorf("no DevStagingEnvironment named %s — see: kindling status", strings.Join(sortedKeys(wanted), ", "))
+	}
+
+	if err := render(checks, func() { printNetworkReport(checks) }); err != nil {
+		return err
+	}
+	failed := 0
+	for _, c := range checks {
+		if !c.Pass {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d route(s) failed", failed, len(checks))
+	}
+	return nil
+}
+
+// networkCheckPath returns the path to request through the ingress and
+// whether any response below 500 counts as a pass. That's the health
+// path, unless the probe is tcp or the ingress doesn't route the path.
+func networkCheckPath(dse networkedDSE) (string, bool) {
+	prefix := dse.Spec.Ingress.Path
+	if prefix == "" {
+		prefix = "/"
+	}
+	hc := dse.Spec.Deployment.HealthCheck
+	health := "/healthz"
+	if hc != nil && hc.Path != "" {
+		health = hc.Path
+	}
+	if hc != nil && hc.Type == "tcp" {
+		return prefix, true
+	}
+	if prefix != "/" && health != prefix && !strings.HasPrefix(health, strings.TrimSuffix(prefix, "/")+"/") {
+		return prefix, true
+	}
+	return health, false
+}
+
+// checkRoute resolves the route's host, then requests it, recording the
+// result of each step. It stops at the first step that fails.
+func checkRoute(target string, lenient, grpc bool) routeCheck {
+	c := routeCheck{URL: target}
+	u, err := url.Parse(target)
+	if err != nil {
+		c.Error = err.Error()
+		return c
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	host := u.Hostname()
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		c.DNS = "loopback"
+	} else {
+		addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil || len(addrs) == 0 {
+			c.DNS = "✗"
+			c.Error = fmt.Sprintf("%s does not resolve", host)
+			step("❌", fmt.Sprintf("%s: %s", target, c.Error))
+			return c
+		}
+		c.DNS = addrs[0]
+	}
+	if grpc {
+		// A gRPC ingress won't answer a plain GET, so only the TLS
+		// handshake is left to check.
+		c.Pass = true
+		if u.Scheme == "https" {
+			c.TLS, c.Error, c.Pass = checkTLS(ctx, u.Host)
+		}
+		step(routeIcon(c), fmt.Sprintf("%s (gRPC — HTTP check skipped)", target))
+		return c
+	}
+
+	client := &http.Client{
+		// A redirect is the route's answer; following it would test
+		// somewhere else.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	if strings.HasSuffix(host, ".localhost") {
+		// Resolve *.localhost to loopback the way browsers do, whatever
+		// the system resolver thinks.
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			_, port, _ := net.SplitHostPort(addr)
+			return (&net.Dialer{}).DialContext(ctx, network, net.JoinHostPort("127.0.0.1", port))
+		}
+		client.Transport = transport
+	}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	start := time.Now()
+	resp, err := client.Do(req)
+	c.Millis = time.Since(start).Milliseconds()
+	if err != nil {
+		if u.Scheme == "https" && isTLSError(err) {
+			c.TLS = "✗"
+		}
+		c.Error = err.Error()
+		step("❌", fmt.Sprintf("%s: %s", target, c.Error))
+		return c
+	}
+	resp.Body.Close()
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		c.TLS = "valid until " + resp.TLS.PeerCertificates[0].NotAfter.Format("2006-01-02")
+	}
+	c.Status = resp.StatusCode
+	c.Pass = resp.StatusCode >= 200 && resp.StatusCode < 400
+	if lenient {
+		c.Pass = resp.StatusCode < 500
+	}
+	if !c.Pass {
+		c.Error = fmt.Sprintf("answered %s", resp.Status)
+	}
+	step(routeIcon(c), fmt.Sprintf("%s → %d in %dms", target, c.Status, c.Millis))
+	return c
+}
+
+// checkTLS completes a TLS handshake with addr, returning the TLS column,
+// the error if any, and whether the certificate is valid.
+func checkTLS(ctx context.Context, addr string) (string, string, bool) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "443")
+	}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodHead, "https://"+addr, nil)
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		if isTLSError(err) {
+			return "✗", err.Error(), false
+		}
+		return "", err.Error(), false
+	}
+	resp.Body.Close()
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return "", "", true
+	}
+	return "valid until " + resp.TLS.PeerCertificates[0].NotAfter.Format("2006-01-02"), "", true
+}
+
+// isTLSError reports whether a request failed on the certificate or the
+// handshake rather than on the connection or the response.
+func isTLSError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "x509:") || strings.Contains(msg, "tls:") || strings.Contains(msg, "certificate")
+}
+
+func routeIcon(c routeCheck) string {
+	if c.Pass {
+		return "✅"
+	}
+	return "❌"
+}
+
+func printNetworkReport(checks []routeCheck) {
+	header("Networking report")
+	if len(checks) == 0 {
+		fmt.Printf("  %sNo environment has an ingress — nothing to check.%s\n\n", colorDim, colorReset)
+		return
+	}
+	fmt.Printf("  %s%-20s %-8s %-16s %-22s %-6s %-6s %s%s\n", colorBold,
+		"ENVIRONMENT", "VIA", "DNS", "TLS", "HTTP", "RESULT", "URL", colorReset)
+	passed := 0
+	for _, c := range checks {
+		tlsCol, status, result := c.TLS, "—", colorGreen+"pass  "+colorReset
+		if tlsCol == "" {
+			tlsCol = "—"
+		}
+		if c.Status != 0 {
+			status = fmt.Sprint(c.Status)
+		}
+		if c.Pass {
+			passed++
+		} else {
+			result = colorRed + "fail  " + colorReset
+		}
+		dns := c.DNS
+		if dns == "" {
+			dns = "—"
+		}
+		fmt.Printf("  %-20s %-8s %-16s %-22s %-6s %s %s\n", c.Environment, c.Via, dns, tlsCol, status, result, c.URL)
+		if c.Error != "" {
+			fmt.Printf("      %s\n", dimText(c.Error))
+		}
+	}
+	fmt.Printf("\n  %s%d of %d route(s) passed%s\n\n", colorDim, passed, len(checks), colorReset)
+}
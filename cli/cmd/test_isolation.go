@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var testIsolationCmd = &cobra.Command{
+	Use:   "isolation [environment...]",
+	Short: "Check that networkPolicies: strict blocks undeclared traffic",
+	Long: `Tries every connection between the components of the namespaces that
+hold a DevStagingEnvironment with networkPolicies: strict, and checks
+that only the declared edges get through:
+
+  app → app          allowed when the caller lists the target in dependsOn
+  app → dependency   allowed only from the dependency's own environment
+
+Each environment's app is stood in for by a short-lived probe pod that
+carries the app's name label, so the app needn't run and no Service routes
+to the probe. Only the Services of strict environments are targets; the
+ingress controller, which strict environments always admit, isn't tested.
+
+Every row passes when what happened matches what was declared: a blocked
+dependsOn edge fails as much as an undeclared one that gets through. When
+nothing at all is blocked, the cluster's CNI probably doesn't enforce
+NetworkPolicies — kindling init --profile full runs Calico, which does.
+
+With no arguments every strict environment is a target. The command exits
+non-zero when any check fails.
+
+Examples:
+  kindling test isolation
+  kindling test isolation orders-dev
+  kindling test isolation --timeout 5s -o json`,
+	SilenceUsage:      true,
+	ValidArgsFunction: completeDSEs,
+	RunE:              runTestIsolation,
+}
+
+var isolationTimeout time.Duration
+
+func init() {
+	testIsolationCmd.Flags().DurationVar(&isolationTimeout, "timeout", 3*time.Second, "How long each connection may take before it counts as blocked")
+	testCmd.AddCommand(testIsolationCmd)
+}
+
+// isolationProbeImage provides nc for the probe pods.
+const isolationProbeImage = "busybox:1.36"
+
+// isolationCheck is one row of the isolation report.
+type isolationCheck struct {
+	Namespace string `json:"namespace"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Port      int    `json:"port"`
+	Declared  bool   `json:"declared"`
+	Reachable bool   `json:"reachable"`
+	Pass      bool   `json:"pass"`
+}
+
+// isolatedDSE holds the fields test isolation reads from a DSE.
+type isolatedDSE struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Deployment struct {
+			Schedule string `json:"schedule"`
+		} `json:"deployment"`
+		Dependencies []struct {
+			Type string `json:"type"`
+		} `json:"dependencies"`
+		DependsOn       []string `json:"dependsOn"`
+		NetworkPolicies string   `json:"networkPolicies"`
+	} `json:"spec"`
+}
+
+// isolationTarget is a Service a probe connects to.
+type isolationTarget struct {
+	service string
+	port    int
+	owner   string // the DSE it belongs to
+	app     bool   // the owner's app rather than a dependency
+}
+
+func runTestIsolation(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	out, err := kubectlJSON("get", "devstagingenvironments", "-A", "-o", "json")
+	if err != nil {
+		return fmt.Errorf("cannot list DevStagingEnvironments: %w", err)
+	}
+	var list struct {
+		Items []isolatedDSE `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return fmt.Errorf("cannot parse DevStagingEnvironments: %w", err)
+	}
+
+	wanted := map[string]bool{}
+	for _, a := range args {
+		wanted[a] = true
+	}
+	byNamespace := map[string][]isolatedDSE{}
+	strict := map[string][]isolatedDSE{}
+	for _, dse := range list.Items {
+		ns, name := dse.Metadata.Namespace, dse.Metadata.Name
+		byNamespace[ns] = append(byNamespace[ns], dse)
+		if len(wanted) > 0 && !wanted[name] {
+			continue
+		}
+		if len(wanted) > 0 && dse.Spec.NetworkPolicies != "strict" {
+			return fmt.Errorf("%s doesn't set networkPolicies: strict — there is nothing to test", name)
+		}
+		delete(wanted, name)
+		if dse.Spec.NetworkPolicies == "strict" {
+			strict[ns] = append(strict[ns], dse)
+		}
+	}
+	if len(wanted) > 0 {
+		return fmt.Errorf("no DevStagingEnvironment named %s — see: kindling status", strings.Join(sortedKeys(wanted), ", "))
+	}
+	if len(strict) == 0 {
+		return fmt.Errorf("no DevStagingEnvironment sets networkPolicies: strict")
+	}
+
+	checks := []isolationCheck{}
+	for _, ns := range sortedKeys(strict) {
+		header(fmt.Sprintf("Probing namespace %s", ns))
+		found, err := probeNamespace(ns, byNamespace[ns], strict[ns])
+		if err != nil {
+			return err
+		}
+		checks = append(checks, found...)
+	}
+
+	if err := render(checks, func() { printIsolationReport(checks) }); err != nil {
+		return err
+	}
+	failed := 0
+	for _, c := range checks {
+		if !c.Pass {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d connection(s) didn't match the declared edges", failed, len(checks))
+	}
+	return nil
+}
+
+// probeNamespace connects from a stand-in of every app in ns to the
+// Services of its strict DSEs.
+func probeNamespace(ns string, all, strict []isolatedDSE) ([]isolationCheck, error) {
+	ports, err := servicePorts(ns)
+	if err != nil {
+		return nil, err
+	}
+	var targets []isolationTarget
+	for _, dse := range strict {
+		name := dse.Metadata.Name
+		if port, ok := ports[name]; ok {
+			targets = append(targets, isolationTarget{service: name, port: port, owner: name, app: true})
+		}
+		for _, dep := range dse.Spec.Dependencies {
+			svc := name + "-" + dep.Type
+			if port, ok := ports[svc]; ok {
+				targets = append(targets, isolationTarget{service: svc, port: port, owner: name})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		warn(fmt.Sprintf("No Services to probe in %s yet — are the environments deployed?", ns))
+		return nil, nil
+	}
+
+	var checks []isolationCheck
+	for _, from := range all {
+		if from.Spec.Deployment.Schedule != "" {
+			continue
+		}
+		source := from.Metadata.Name
+		var probed []isolationTarget
+		for _, t := range targets {
+			if !(t.app && t.owner == source) {
+				probed = append(probed, t)
+			}
+		}
+		if len(probed) == 0 {
+			continue
+		}
+		step("🔌", fmt.Sprintf("From %s to %d Service(s)", source, len(probed)))
+		reachable, err := runIsolationProbe(ns, source, probed)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range probed {
+			declared := t.owner == source
+			if t.app {
+				declared = containsString(from.Spec.DependsOn, t.owner)
+			}
+			checks = append(checks, isolationCheck{
+				Namespace: ns, From: source, To: t.service, Port: t.port,
+				Declared: declared, Reachable: reachable[t.service],
+				Pass: declared == reachable[t.service],
+			})
+		}
+	}
+	return checks, nil
+}
+
+// servicePorts maps the operator's Services in ns to their first port.
+func servicePorts(ns string) (map[string]int, error) {
+	out, err := kubectlJSON("get", "services", "-n", ns, "-l", "app.kubernetes.io/managed-by=devstagingenvironment-operator", "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("cannot list Services in %s: %w", ns, err)
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				Ports []struct {
+					Port int `json:"port"`
+				} `json:"ports"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return nil, fmt.Errorf("cannot parse Services: %w", err)
+	}
+	ports := map[string]int{}
+	for _, svc := range list.Items {
+		if len(svc.Spec.Ports) > 0 {
+			ports[svc.Metadata.Name] = svc.Spec.Ports[0].Port
+		}
+	}
+	return ports, nil
+}
+
+// runIsolationProbe runs a pod labelled as source's app that tries each
+// target once, and returns which it reached. The labels are the ones the
+// operator's NetworkPolicies match on; without app.kubernetes.io/instance
+// the app's Service doesn't select the probe.
+func runIsolationProbe(ns, source string, targets []isolationTarget) (map[string]bool, error) {
+	pod := fmt.Sprintf("kindling-isolation-%d", time.Now().UnixNano()%1_000_000)
+	wait := int(isolationTimeout.Seconds())
+	if wait < 1 {
+		wait = 1
+	}
+	var script strings.Builder
+	for _, t := range targets {
+		fmt.Fprintf(&script, "if nc -z -w %d %s %d; then echo 'open %s'; else echo 'blocked %s'; fi\n",
+			wait, t.service, t.port, t.service, t.service)
+	}
+	manifest := fmt.Sprintf(`apiVersion: v1
+kind: Pod
+metadata:
+  name: %s
+  namespace: %s
+  labels:
+    app.kubernetes.io/name: %s
+    app.kubernetes.io/managed-by: devstagingenvironment-operator
+    app.kubernetes.io/component: isolation-probe
+spec:
+  restartPolicy: Never
+  containers:
+    - name: probe
+      image: %s
+      command: ["sh", "-c", %q]
+`, pod, ns, source, isolationProbeImage, script.String())
+
+	if out, err := runSilentStdin(manifest, "kubectl", "--context", kubeContextName(), "apply", "-f", "-"); err != nil {
+		return nil, fmt.Errorf("cannot start the probe pod: %s", out)
+	}
+	defer captureKubectl("delete", "pod", pod, "-n", ns, "--wait=false")
+
+	limit := time.Minute + time.Duration(len(targets))*isolationTimeout
+	if out, err := captureKubectl("wait", "--for=jsonpath={.status.phase}=Succeeded", "pod/"+pod, "-n", ns,
+		fmt.Sprintf("--timeout=%s", limit)); err != nil {
+		return nil, fmt.Errorf("probe pod did not finish: %s", out)
+	}
+	out, err := captureKubectl("logs", pod, "-n", ns)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the probe's results: %s", out)
+	}
+	reachable := map[string]bool{}
+	for _, line := range strings.Split(out, "\n") {
+		if service, ok := strings.CutPrefix(strings.TrimSpace(line), "open "); ok {
+			reachable[service] = true
+		}
+	}
+	return reachable, nil
+}
+
+func printIsolationReport(checks []isolationCheck) {
+	header("Isolation report")
+	if len(checks) == 0 {
+		fmt.Printf("  %sNothing to probe.%s\n\n", colorDim, colorReset)
+		return
+	}
+	sort.SliceStable(checks, func(i, j int) bool {
+		if checks[i].Namespace != checks[j].Namespace {
+			return checks[i].Namespace < checks[j].Namespace
+		}
+		return checks[i].From < checks[j].From
+	})
+	fmt.Printf("  %s%-24s %-32s %-10s %-10s %s%s\n", colorBold, "FROM", "TO", "DECLARED", "GOT", "RESULT", colorReset)
+	passed, blocked := 0, 0
+	for _, c := range checks {
+		declared, got, result := "no", "blocked", colorGreen+"pass"+colorReset
+		if c.Declared {
+			declared = "yes"
+		}
+		if c.Reachable {
+			got = "open"
+		} else {
+			blocked++
+		}
+		if c.Pass {
+			passed++
+		} else {
+			result = colorRed + "fail" + colorReset
+		}
+		fmt.Printf("  %-24s %-32s %-10s %-10s %s\n", c.From, fmt.Sprintf("%s:%d", c.To, c.Port), declared, got, result)
+	}
+	fmt.Printf("\n  %s%d of %d connection(s) matched the declared edges%s\n\n", colorDim, passed, len(checks), colorReset)
+	if blocked == 0 {
+		warn("Nothing was blocked — the cluster's CNI may not enforce NetworkPolicies (kindling init --profile full runs Calico)")
+		fmt.Println()
+	}
+	for _, c := range checks {
+		if c.Declared && !c.Reachable {
+			fmt.Printf("  %sA declared edge that is blocked may just be a target that isn't running — see: kindling status%s\n\n", colorDim, colorReset)
+			break
+		}
+	}
+}
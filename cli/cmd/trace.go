@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var traceCmd = &cobra.Command{
+	Use:   "trace <component>",
+	Short: "List a component's recent traces from the tracing collector",
+	Long: `Lists the most recent traces of a component whose DevStagingEnvironment
+sets observability.tracing: true, newest first: when each started, how
+long it took, how many spans and services it touched, its root
+operation, and whether any span failed.
+
+Traces come from kindling-otel-collector, the OpenTelemetry collector the
+operator runs in the component's namespace, through the Kubernetes API
+server — no port-forward needed. --open forwards the collector's Jaeger
+UI to localhost instead (tracked like kindling port-forward) and opens the
+component's traces in the browser.
+
+Components are named the same way as in kindling logs; only apps export
+traces, under their DevStagingEnvironment's name.
+
+Examples:
+  kindling trace orders-dev
+  kindling trace orders-dev --since 10m --limit 50
+  kindling trace orders-dev --errors
+  kindling trace orders-dev --open`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeComponents),
+	RunE:              runTrace,
+}
+
+var (
+	traceEnv    string
+	traceLimit  int
+	traceSince  time.Duration
+	traceErrors bool
+	traceOpen   bool
+)
+
+func init() {
+	traceCmd.Flags().StringVar(&traceEnv, "env", "", "DevStagingEnvironment to resolve the component in")
+	_ = traceCmd.RegisterFlagCompletionFunc("env", completeEnvFlag)
+	traceCmd.Flags().IntVar(&traceLimit, "limit", 20, "Most traces to list")
+	traceCmd.Flags().DurationVar(&traceSince, "since", time.Hour, "Only traces that started this long ago or later")
+	traceCmd.Flags().BoolVar(&traceErrors, "errors", false, "Only traces with a failed span")
+	traceCmd.Flags().BoolVar(&traceOpen, "open", false, "Open the component's traces in the Jaeger UI instead")
+	rootCmd.AddCommand(traceCmd)
+}
+
+// tracingCollector is the Service the operator runs for
+// observability.tracing, and the port of its Jaeger UI and query API.
+const (
+	tracingCollector   = "kindling-otel-collector"
+	tracingCollectorUI = 16686
+)
+
+// traceSummary is one row of kindling trace.
+type traceSummary struct {
+	TraceID   string        `json:"traceId"`
+	Start     time.Time     `json:"start"`
+	Duration  time.Duration `json:"durationNs"`
+	Spans     int           `json:"spans"`
+	Services  []string      `json:"services"`
+	Operation string        `json:"operation"` // of the root span
+	Error     bool          `json:"error"`
+}
+
+// jaegerTraces is the part of the Jaeger query API's /api/traces reply
+// kindling trace reads.
+type jaegerTraces struct {
+	Data []struct {
+		TraceID string `json:"traceID"`
+		Spans   []struct {
+			SpanID        string `json:"spanID"`
+			OperationName string `json:"operationName"`
+			StartTime     int64  `json:"startTime"` // µs since the epoch
+			Duration      int64  `json:"duration"`  // µs
+			ProcessID     string `json:"processID"`
+			References    []struct {
+				RefType string `json:"refType"`
+				SpanID  string `json:"spanID"`
+			} `json:"references"`
+			Tags []struct {
+				Key   string      `json:"key"`
+				Value interface{} `json:"value"`
+			} `json:"tags"`
+		} `json:"spans"`
+		Processes map[string]struct {
+			ServiceName string `json:"serviceName"`
+		} `json:"processes"`
+	} `json:"data"`
+}
+
+func runTrace(cmd *cobra.Command, args []string) error {
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	comps, err := resolveComponents(collectEnvironments(), args[0], traceEnv)
+	if err != nil {
+		return err
+	}
+	if len(comps) > 1 {
+		var names []string
+		for _, c := range comps {
+			names = append(names, c.name)
+		}
+		return fmt.Errorf("%q matches %s — pass the full name", args[0], strings.Join(names, ", "))
+	}
+	target := comps[0]
+	if _, err := kubectlJSON("get", "service", tracingCollector, "-n", target.namespace, "-o", "name"); err != nil {
+		return fmt.Errorf("no tracing collector in namespace %s — set spec.observability.tracing: true on the DevStagingEnvironment", target.namespace)
+	}
+
+	if traceOpen {
+		return openTraceUI(target)
+	}
+
+	query := url.Values{}
+	query.Set("service", target.name)
+	query.Set("limit", fmt.Sprint(traceLimit))
+	query.Set("start", fmt.Sprint(time.Now().Add(-traceSince).UnixMicro()))
+	query.Set("end", fmt.Sprint(time.Now().UnixMicro()))
+	if traceErrors {
+		query.Set("tags", `{"error":"true"}`)
+	}
+	path := fmt.Sprintf("/api/v1/namespaces/%s/services/%s:%d/proxy/api/traces?%s",
+		target.namespace, tracingCollector, tracingCollectorUI, query.Encode())
+	out, err := kubectlJSON("get", "--raw", path)
+	if err != nil {
+		return fmt.Errorf("cannot query the tracing collector: %w", err)
+	}
+	var reply jaegerTraces
+	if err := json.Unmarshal([]byte(out), &reply); err != nil {
+		return fmt.Errorf("unexpected reply from the tracing collector: %w", err)
+	}
+
+	traces := summarizeTraces(reply)
+	if traceErrors {
+		kept := traces[:0]
+		for _, t := range traces {
+			if t.Error {
+				kept = append(kept, t)
+			}
+		}
+		traces = kept
+	}
+	if len(traces) > traceLimit {
+		traces = traces[:traceLimit]
+	}
+	return render(traces, func() { printTraces(target, traces) })
+}
+
+// summarizeTraces turns the query API's reply into rows, newest first.
+func summarizeTraces(reply jaegerTraces) []traceSummary {
+	traces := []traceSummary{}
+	for _, t := range reply.Data {
+		if len(t.Spans) == 0 {
+			continue
+		}
+		ids := map[string]bool{}
+		for _, s := range t.Spans {
+			ids[s.SpanID] = true
+		}
+		var start, end int64
+		services := map[string]bool{}
+		summary := traceSummary{TraceID: t.TraceID, Spans: len(t.Spans)}
+		for i, s := range t.Spans {
+			if i == 0 || s.StartTime < start {
+				start = s.StartTime
+			}
+			if s.StartTime+s.Duration > end {
+				end = s.StartTime + s.Duration
+			}
+			if p, ok := t.Processes[s.ProcessID]; ok {
+				services[p.ServiceName] = true
+			}
+			// The root span has no parent in the trace; with several
+			// candidates, as when the parent wasn't exported, take the first.
+			root := true
+			for _, ref := range s.References {
+				if ref.RefType == "CHILD_OF" && ids[ref.SpanID] {
+					root = false
+				}
+			}
+			if root && summary.Operation == "" {
+				summary.Operation = s.OperationName
+			}
+			for _, tag := range s.Tags {
+				if tag.Key == "error" && fmt.Sprint(tag.Value) == "true" {
+					summary.Error = true
+				}
+			}
+		}
+		summary.Start = time.UnixMicro(start).UTC()
+		summary.Duration = time.Duration(end-start) * time.Microsecond
+		for name := range services {
+			summary.Services = append(summary.Services, name)
+		}
+		sort.Strings(summary.Services)
+		traces = append(traces, summary)
+	}
+	sort.Slice(traces, func(i, j int) bool { return traces[i].Start.After(traces[j].Start) })
+	return traces
+}
+
+func printTraces(target componentRef, traces []traceSummary) {
+	header(fmt.Sprintf("Traces of %s/%s", target.namespace, target.name))
+	if len(traces) == 0 {
+		fmt.Printf("    %sNo traces in the last %s — is the app sending any? See: kindling logs %s%s\n\n",
+			colorDim, jaegerLookback(traceSince), target.name, colorReset)
+		return
+	}
+	fmt.Printf("    %s%-18s %-10s %-10s %-6s %-32s %s%s\n", colorBold, "TRACE", "STARTED", "DURATION", "SPANS", "SERVICES", "OPERATION", colorReset)
+	for _, t := range traces {
+		op := t.Operation
+		if t.Error {
+			op = colorRed + op + " ✗" + colorReset
+		}
+		fmt.Printf("    %-18s %-10s %-10s %-6d %-32s %s\n",
+			shortTraceID(t.TraceID), traceAge(time.Since(t.Start)), t.Duration.Round(time.Millisecond/10),
+			t.Spans, strings.Join(t.Services, ","), op)
+	}
+	fmt.Println()
+	fmt.Printf("  Details: %skindling trace %s --open%s\n\n", colorCyan, target.name, colorReset)
+}
+
+// shortTraceID keeps the first 16 hex digits, enough to find a trace.
+func shortTraceID(id string) string {
+	if len(id) > 16 {
+		return id[:16]
+	}
+	return id
+}
+
+// traceAge renders how long ago a trace started, like kubectl's AGE.
+func traceAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
+
+// jaegerLookback renders d the way the Jaeger UI's lookback parameter
+// takes it: whole hours, else whole minutes.
+func jaegerLookback(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
+
+// openTraceUI forwards the collector's Jaeger UI, reusing a running
+// forward, and opens the component's traces.
+func openTraceUI(target componentRef) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	forwards, err := livePortForwards(cwd)
+	if err != nil {
+		return err
+	}
+	var local int
+	if i := findPortForward(forwards, target.namespace, tracingCollector); i >= 0 {
+		local = forwards[i].LocalPort
+	} else {
+		claimed := map[int]bool{}
+		for _, f := range forwards {
+			claimed[f.LocalPort] = true
+		}
+		state := PortForwardState{Component: tracingCollector, Namespace: target.namespace,
+			LocalPort: freeLocalPort(tracingCollectorUI, claimed), RemotePort: tracingCollectorUI}
+		pid, err := startPortForward(cwd, state)
+		if err != nil {
+			return err
+		}
+		state.PID, state.Created = pid, time.Now().UTC().Truncate(time.Second)
+		if err := writePortForwards(cwd, append(forwards, state)); err != nil {
+			return err
+		}
+		ensureTunnelGitignored(cwd)
+		local = state.LocalPort
+	}
+
+	link := fmt.Sprintf("http://localhost:%d/search?service=%s&lookback=%s", local, url.QueryEscape(target.name), jaegerLookback(traceSince))
+	success(fmt.Sprintf("Jaeger UI at %s", link))
+	fmt.Printf("  Stop with: %skindling port-forward --stop %s%s\n\n", colorCyan, tracingCollector, colorReset)
+	if err := openBrowser(link); err != nil {
+		warn("Could not open a browser — open the link above")
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var tunnelCmd = &cobra.Command{
+	Use:   "tunnel",
+	Short: "Inspect the public HTTPS tunnel started by kindling expose",
+	Long: `Inspect the background tunnel managed by kindling expose.
+
+Tunnels are started and stopped with kindling expose; these subcommands
+only read .kindling/tunnels.yaml and probe the tunnel processes.
+
+Examples:
+  kindling tunnel status
+  kindling tunnel status -o json`,
+}
+
+var tunnelStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show provider, URL, uptime, and health of each running tunnel",
+	RunE:  runTunnelStatus,
+}
+
+func init() {
+	tunnelCmd.AddCommand(tunnelStatusCmd)
+	rootCmd.AddCommand(tunnelCmd)
+}
+
+// ── kindling tunnel status ──────────────────────────────────────
+
+// tunnelStatusReport is the rendered view of one tunnel for status output.
+type tunnelStatusReport struct {
+	Service  string `json:"service"`
+	Running  bool   `json:"running"`
+	Provider string `json:"provider"`
+	URL      string `json:"url"`
+	Tunnel   string `json:"tunnel,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	PID      int    `json:"pid"`
+	Uptime   string `json:"uptime"`
+	Process  string `json:"process"`
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+func runTunnelStatus(cmd *cobra.Command, args []string) error {
+	reports, err := collectTunnelReports(true)
+	if err != nil {
+		return err
+	}
+	return render(reports, func() { printTunnelStatus(reports) })
+}
+
+// collectTunnelReports builds a status report for every tracked tunnel.
+// When probe is set, the public URL of each live tunnel is requested too.
+func collectTunnelReports(probe bool) ([]tunnelStatusReport, error) {
+	tunnels, err := loadTunnels()
+	if err != nil {
+		return nil, err
+	}
+	reports := []tunnelStatusReport{}
+	for i := range tunnels {
+		state := &tunnels[i]
+		report := tunnelStatusReport{
+			Service:  state.Label(),
+			Provider: state.Provider,
+			URL:      state.URL,
+			Port:     state.Port,
+			PID:      state.PID,
+			Uptime:   state.Uptime().String(),
+			Process:  "exited",
+		}
+		if state.Named != nil {
+			report.Tunnel = state.Named.Name
+		}
+		if processAlive(state.PID) {
+			report.Running = true
+			report.Process = "healthy"
+			if probe {
+				report.Endpoint = probeTunnelEndpoint(state.URL)
+			}
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// printTunnelStatus renders each tunnel report as a key/value block.
+func printTunnelStatus(reports []tunnelStatusReport) {
+	header("Tunnels")
+	if len(reports) == 0 {
+		fmt.Printf("    %sNo tunnel — run:%s kindling expose\n\n", colorDim, colorReset)
+		return
+	}
+
+	stale := false
+	for _, report := range reports {
+		processColor := colorGreen
+		if !report.Running {
+			processColor = colorRed
+			stale = true
+		}
+		fmt.Printf("    %-10s %s%s%s\n", "SERVICE", colorBold, report.Service, colorReset)
+		fmt.Printf("    %-10s %s\n", "PROVIDER", report.Provider)
+		fmt.Printf("    %-10s %s\n", "URL", report.URL)
+		if report.Tunnel != "" {
+			fmt.Printf("    %-10s %s\n", "TUNNEL", report.Tunnel)
+		}
+		fmt.Printf("    %-10s %d\n", "PID", report.PID)
+		fmt.Printf("    %-10s %s\n", "UPTIME", report.Uptime)
+		fmt.Printf("    %-10s %s%s%s\n", "PROCESS", processColor, report.Process, colorReset)
+		if report.Endpoint != "" {
+			fmt.Printf("    %-10s %s\n", "ENDPOINT", report.Endpoint)
+		}
+		fmt.Println()
+	}
+	if stale {
+		fmt.Printf("  Clean up with: %skindling expose --stop%s\n", colorCyan, colorReset)
+		fmt.Println()
+	}
+}
+
+// probeTunnelEndpoint issues a HEAD request against the public URL and
+// returns a short description of the result. Any HTTP response (even a 404
+// from the ingress) means the tunnel is carrying traffic.
+func probeTunnelEndpoint(publicURL string) string {
+	if publicURL == "" {
+		return ""
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Head(publicURL)
+	if err != nil {
+		return "unreachable"
+	}
+	defer resp.Body.Close()
+	return fmt.Sprintf("reachable (HTTP %d)", resp.StatusCode)
+}
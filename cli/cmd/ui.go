@@ -0,0 +1,412 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Interactive terminal UI for environments, pods, and logs",
+	Long: `Opens a full-screen terminal UI with every DevStagingEnvironment and its
+components on the left and the selected component's logs streaming on the
+right. The tree refreshes every few seconds.
+
+Keys:
+  ↑/↓, j/k   select a component
+  r          restart the component (kubectl rollout restart)
+  p          start/stop a port-forward to its Service on localhost
+  o          open the environment's public or ingress URL in a browser
+  q, Ctrl+C  quit (stops port-forwards and log streams)`,
+	RunE: runUI,
+}
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+}
+
+func runUI(cmd *cobra.Command, args []string) error {
+	if isJSONOutput() {
+		return fmt.Errorf("kindling ui is interactive and does not support --output json")
+	}
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	m := newUIModel()
+	defer m.stopAll()
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+// uiRefreshInterval is how often the environment tree is re-queried.
+const uiRefreshInterval = 3 * time.Second
+
+// uiMaxLogLines caps the log buffer of the selected component.
+const uiMaxLogLines = 500
+
+// uiRow is one selectable line of the tree: a component of an environment.
+type uiRow struct {
+	env  envStatus
+	comp componentStatus
+}
+
+type uiModel struct {
+	envs     []envStatus
+	rows     []uiRow
+	cursor   int
+	selected string // component name whose logs are streaming
+
+	logs      []string
+	logCh     chan string
+	logCancel context.CancelFunc
+
+	forwards map[string]*exec.Cmd // component name → kubectl port-forward
+	status   string
+	width    int
+	height   int
+}
+
+// ── Messages ────────────────────────────────────────────────────
+
+type uiEnvsMsg []envStatus
+type uiTickMsg struct{}
+type uiLogMsg struct {
+	component string
+	line      string
+}
+type uiStatusMsg string
+
+func newUIModel() *uiModel {
+	return &uiModel{forwards: map[string]*exec.Cmd{}, status: "Loading environments…"}
+}
+
+func (m *uiModel) Init() tea.Cmd {
+	return uiFetchEnvs
+}
+
+func uiFetchEnvs() tea.Msg {
+	return uiEnvsMsg(collectEnvironments())
+}
+
+func uiTick() tea.Cmd {
+	return tea.Tick(uiRefreshInterval, func(time.Time) tea.Msg { return uiTickMsg{} })
+}
+
+func (m *uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case uiEnvsMsg:
+		m.envs = msg
+		m.rebuildRows()
+		if m.status == "Loading environments…" {
+			m.status = ""
+		}
+		return m, tea.Batch(m.followSelection(), uiTick())
+
+	case uiTickMsg:
+		return m, uiFetchEnvs
+
+	case uiLogMsg:
+		if msg.component != m.selected {
+			return m, nil
+		}
+		m.logs = append(m.logs, msg.line)
+		if len(m.logs) > uiMaxLogLines {
+			m.logs = m.logs[len(m.logs)-uiMaxLogLines:]
+		}
+		return m, m.waitForLog()
+
+	case uiStatusMsg:
+		m.status = string(msg)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, m.followSelection()
+		case "down", "j":
+			if m.cursor < len(m.rows)-1 {
+				m.cursor++
+			}
+			return m, m.followSelection()
+		case "r":
+			if row, ok := m.current(); ok {
+				m.status = "Restarting " + row.comp.Name + "…"
+				return m, uiRestart(row)
+			}
+		case "p":
+			if row, ok := m.current(); ok {
+				m.status = m.togglePortForward(row)
+			}
+		case "o":
+			if row, ok := m.current(); ok {
+				m.status = uiOpenURL(row.env)
+			}
+		}
+	}
+	return m, nil
+}
+
+// rebuildRows flattens the tree, keeping the cursor on the same component.
+func (m *uiModel) rebuildRows() {
+	m.rows = m.rows[:0]
+	for _, env := range m.envs {
+		for _, c := range env.Components {
+			if c.Role != "job" {
+				m.rows = append(m.rows, uiRow{env: env, comp: c})
+			}
+		}
+	}
+	for i, r := range m.rows {
+		if r.comp.Name == m.selected {
+			m.cursor = i
+			return
+		}
+	}
+	if m.cursor >= len(m.rows) {
+		m.cursor = max(len(m.rows)-1, 0)
+	}
+}
+
+func (m *uiModel) current() (uiRow, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return uiRow{}, false
+	}
+	return m.rows[m.cursor], true
+}
+
+// ── Log streaming ───────────────────────────────────────────────
+
+// followSelection (re)starts the log stream when the selection changes.
+func (m *uiModel) followSelection() tea.Cmd {
+	row, ok := m.current()
+	if !ok || row.comp.Name == m.selected {
+		return nil
+	}
+	if m.logCancel != nil {
+		m.logCancel()
+	}
+	m.selected = row.comp.Name
+	m.logs = nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.logCancel = cancel
+	ch := make(chan string, 256)
+	m.logCh = ch
+
+	c := exec.CommandContext(ctx, "kubectl", "--context", kubeContextName(),
+		"logs", "-f", "--tail=100", "--all-containers", "--max-log-requests=20",
+		"-n", row.env.Namespace, "-l", "app.kubernetes.io/name="+row.comp.Name)
+	out, err := c.StdoutPipe()
+	if err != nil {
+		close(ch)
+		return nil
+	}
+	c.Stderr = c.Stdout
+	if err := startCommand(c); err != nil {
+		close(ch)
+		return func() tea.Msg { return uiStatusMsg("could not stream logs: " + err.Error()) }
+	}
+	go func() {
+		defer close(ch)
+		scanner := bufio.NewScanner(out)
+		for scanner.Scan() {
+			ch <- scanner.Text()
+		}
+		_ = c.Wait()
+	}()
+	return m.waitForLog()
+}
+
+func (m *uiModel) waitForLog() tea.Cmd {
+	ch, component := m.logCh, m.selected
+	return func() tea.Msg {
+		line, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return uiLogMsg{component: component, line: line}
+	}
+}
+
+// ── Actions ─────────────────────────────────────────────────────
+
+func uiRestart(row uiRow) tea.Cmd {
+	return func() tea.Msg {
+		out, err := kubectlJSON("rollout", "restart", strings.ToLower(row.comp.Kind)+"/"+row.comp.Name, "-n", row.env.Namespace)
+		if err != nil {
+			return uiStatusMsg("restart failed: " + strings.TrimSpace(out))
+		}
+		return uiStatusMsg("Restarted " + row.comp.Name)
+	}
+}
+
+// togglePortForward forwards localhost:<service port> to the component's
+// Service, or stops the forward if one is running.
+func (m *uiModel) togglePortForward(row uiRow) string {
+	name := row.comp.Name
+	if c, ok := m.forwards[name]; ok {
+		_ = c.Process.Kill()
+		delete(m.forwards, name)
+		return "Stopped port-forward for " + name
+	}
+	i := strings.LastIndex(row.comp.Service, ":")
+	if i < 0 {
+		return name + " has no Service to forward"
+	}
+	port := row.comp.Service[i+1:]
+	c := exec.Command("kubectl", "--context", kubeContextName(),
+		"port-forward", "-n", row.env.Namespace, "svc/"+name, port+":"+port)
+	if err := startCommand(c); err != nil {
+		return "port-forward failed: " + err.Error()
+	}
+	m.forwards[name] = c
+	return fmt.Sprintf("Forwarding localhost:%s → svc/%s", port, name)
+}
+
+func uiOpenURL(env envStatus) string {
+	url := env.PublicURL
+	if url == "" {
+		url = env.URL
+	}
+	if url == "" {
+		return env.Name + " has no ingress URL"
+	}
+	if err := openBrowser(url); err != nil {
+		return "could not open browser: " + err.Error()
+	}
+	return "Opened " + url
+}
+
+// openBrowser opens url with the platform's default handler.
+func openBrowser(url string) error {
+	name := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		name = "open"
+	}
+	return startCommand(exec.Command(name, url))
+}
+
+// stopAll ends the log stream and every port-forward.
+func (m *uiModel) stopAll() {
+	if m.logCancel != nil {
+		m.logCancel()
+	}
+	for _, c := range m.forwards {
+		_ = c.Process.Kill()
+	}
+}
+
+// ── View ────────────────────────────────────────────────────────
+
+var (
+	uiPane     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("8")).Padding(0, 1)
+	uiTitle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
+	uiSelected = lipgloss.NewStyle().Reverse(true)
+	uiDim      = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	uiOK       = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	uiWarn     = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	uiBad      = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+func (m *uiModel) View() string {
+	if m.width == 0 {
+		return ""
+	}
+	bodyHeight := m.height - 4 // borders + help line
+	leftWidth := min(48, m.width/2)
+	rightWidth := m.width - leftWidth - 4
+
+	left := uiPane.Width(leftWidth - 2).Height(bodyHeight).Render(m.viewTree(bodyHeight))
+	right := uiPane.Width(rightWidth - 2).Height(bodyHeight).Render(m.viewLogs(rightWidth-4, bodyHeight))
+	help := uiDim.Render(" ↑/↓ select · r restart · p port-forward · o open URL · q quit")
+	if m.status != "" {
+		help += "   " + m.status
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lipgloss.JoinHorizontal(lipgloss.Top, left, right), help)
+}
+
+func (m *uiModel) viewTree(height int) string {
+	var b strings.Builder
+	b.WriteString(uiTitle.Render("Environments") + "\n")
+	if len(m.envs) == 0 {
+		b.WriteString(uiDim.Render("None — run: kindling deploy -f <file.yaml>"))
+		return b.String()
+	}
+	row := 0
+	for _, env := range m.envs {
+		state := uiOK.Render("✓")
+		if !env.Ready {
+			state = uiWarn.Render("⚠")
+		}
+		b.WriteString(fmt.Sprintf("\n%s %s %s\n", state, lipgloss.NewStyle().Bold(true).Render(env.Name), uiDim.Render(env.Namespace)))
+		for _, c := range env.Components {
+			if c.Role == "job" {
+				continue
+			}
+			icon := uiOK.Render("●")
+			switch {
+			case c.Problem != "":
+				icon = uiBad.Render("●")
+			case c.Ready < c.Desired:
+				icon = uiWarn.Render("●")
+			}
+			line := fmt.Sprintf("%-12s %d/%d  ↻%d", c.Role, c.Ready, c.Desired, c.Restarts)
+			if _, ok := m.forwards[c.Name]; ok {
+				line += " ⇄"
+			}
+			if row == m.cursor {
+				line = uiSelected.Render(line)
+			}
+			b.WriteString("  " + icon + " " + line + "\n")
+			if c.Problem != "" {
+				b.WriteString("      " + uiBad.Render(c.Problem) + "\n")
+			}
+			row++
+		}
+	}
+	return lipgloss.NewStyle().MaxHeight(height).Render(b.String())
+}
+
+func (m *uiModel) viewLogs(width, height int) string {
+	title := "Logs"
+	if row, ok := m.current(); ok {
+		title = fmt.Sprintf("Logs — %s", row.comp.Name)
+		if row.comp.Image != "" {
+			title += uiDim.Render(fmt.Sprintf("  %s:%s", row.comp.Image, row.comp.Tag))
+		}
+	}
+	lines := m.logs
+	if room := height - 2; len(lines) > room && room > 0 {
+		lines = lines[len(lines)-room:]
+	}
+	var b strings.Builder
+	b.WriteString(uiTitle.Render(title) + "\n\n")
+	for _, l := range lines {
+		if len(l) > width && width > 0 {
+			l = l[:width]
+		}
+		b.WriteString(l + "\n")
+	}
+	if len(lines) == 0 {
+		b.WriteString(uiDim.Render("waiting for log output…"))
+	}
+	return b.String()
+}
@@ -0,0 +1,590 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Update the CLI and the in-cluster controller to the latest release",
+	Long: `Checks GitHub for the latest kindling release, replaces the running
+kindling binary with it, and upgrades the controller in the cluster by
+applying the release's install.yaml: the CRDs with their conversion
+webhook, the RBAC, the webhook configurations, and the controller
+Deployment at the release's image — everything kindling init installs.
+
+The downloaded archive and install.yaml are verified against the
+release's checksums.txt before anything is replaced or applied. Before touching the cluster, the controller's
+version is checked against the target: downgrades and major-version jumps
+are refused unless --force is given, since the CRDs may not convert back.
+
+A CLI and controller work together when they share a major version and
+are at most one minor version apart. kindling upgrade --cli warns when it
+would leave them further apart than that.
+
+Binaries installed with Homebrew are left to brew upgrade kindling.
+
+Examples:
+  kindling upgrade --check               # compare versions, change nothing
+  kindling upgrade                       # CLI and controller to the latest release
+  kindling upgrade --version 0.9.2       # a specific release
+  kindling upgrade --cli                 # only the CLI binary
+  kindling upgrade --controller          # only the controller and CRDs`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runUpgrade,
+}
+
+var (
+	upgradeCheck      bool
+	upgradeVersion    string
+	upgradeCLI        bool
+	upgradeController bool
+	upgradeForce      bool
+)
+
+func init() {
+	upgradeCmd.Flags().BoolVar(&upgradeCheck, "check", false, "Only report the installed and available versions")
+	upgradeCmd.Flags().StringVar(&upgradeVersion, "version", "", "Release to upgrade to (default: the latest)")
+	upgradeCmd.Flags().BoolVar(&upgradeCLI, "cli", false, "Only upgrade the CLI binary")
+	upgradeCmd.Flags().BoolVar(&upgradeController, "controller", false, "Only upgrade the in-cluster controller and CRDs")
+	upgradeCmd.Flags().BoolVar(&upgradeForce, "force", false, "Skip the compatibility check (allows downgrades and major-version jumps)")
+	rootCmd.AddCommand(upgradeCmd)
+}
+
+// ── Releases ────────────────────────────────────────────────────
+
+const (
+	releaseRepo       = "kindling-sh/kindling"
+	operatorImageRepo = "ghcr.io/kindling-sh/kindling"
+)
+
+// releaseInstallManifest is the release asset a controller upgrade
+// applies: config/default rendered by make build-installer, with the
+// controller at the release's image.
+const releaseInstallManifest = "install.yaml"
+
+// release is the part of a GitHub release kindling upgrade reads.
+type release struct {
+	Tag    string `json:"tag_name"`
+	Assets []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// version is the tag without its leading v, as in archive names and
+// image tags.
+func (r *release) version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+func (r *release) assetURL(name string) (string, error) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.URL, nil
+		}
+	}
+	return "", fmt.Errorf("release %s has no %s", r.Tag, name)
+}
+
+var releaseClient = outboundClient(2 * time.Minute)
+
+// fetchRelease looks up the named release, or the latest when version is
+// "". GITHUB_TOKEN, when set, lifts the API's anonymous rate limit.
+func fetchRelease(version string) (*release, error) {
+	url := "https://api.github.com/repos/" + releaseRepo + "/releases/latest"
+	if version != "" {
+		url = "https://api.github.com/repos/" + releaseRepo + "/releases/tags/v" + strings.TrimPrefix(version, "v")
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := releaseClient.Do(req)
+	if err != nil {
+		return nil, outboundError(url, fmt.Errorf("cannot reach GitHub: %w", err))
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound && version != "":
+		return nil, fmt.Errorf("release %s not found", version)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("GitHub releases API returned %s", resp.Status)
+	}
+	var r release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("cannot parse release: %w", err)
+	}
+	return &r, nil
+}
+
+func download(url string) ([]byte, error) {
+	resp, err := releaseClient.Get(url)
+	if err != nil {
+		return nil, outboundError(url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// ── Versions ────────────────────────────────────────────────────
+
+// semver is a parsed release version. Dev builds don't parse.
+type semver struct{ major, minor, patch int }
+
+func parseSemver(v string) (semver, bool) {
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "-")
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return semver{}, false
+	}
+	var n [3]int
+	for i, p := range parts {
+		x, err := strconv.Atoi(p)
+		if err != nil {
+			return semver{}, false
+		}
+		n[i] = x
+	}
+	return semver{n[0], n[1], n[2]}, true
+}
+
+func (a semver) less(b semver) bool {
+	if a.major != b.major {
+		return a.major < b.major
+	}
+	if a.minor != b.minor {
+		return a.minor < b.minor
+	}
+	return a.patch < b.patch
+}
+
+// versionSkew explains why a CLI and a controller version can't work
+// together, or returns "" when they can. Versions that don't parse — dev
+// builds, a controller built by kindling init — are assumed to work.
+func versionSkew(cli, controller string) string {
+	c, ok1 := parseSemver(cli)
+	s, ok2 := parseSemver(controller)
+	switch {
+	case !ok1 || !ok2:
+		return ""
+	case c.major != s.major:
+		return fmt.Sprintf("CLI %s and controller %s are different major versions", cli, controller)
+	case c.minor-s.minor > 1 || s.minor-c.minor > 1:
+		return fmt.Sprintf("CLI %s and controller %s are more than one minor version apart", cli, controller)
+	}
+	return ""
+}
+
+// controllerVersionLabel on the controller Deployment records the
+// release it runs; kindling upgrade sets it.
+const controllerVersionLabel = "app.kubernetes.io/version"
+
+// controllerVersion returns the release the controller runs — its
+// version label, else the tag of a release image, else "dev" for an image
+// kindling init built from source — and whether it is deployed at all.
+func controllerVersion() (string, bool) {
+	obj, err := getObject("deployments", "kindling-system", "kindling-controller-manager")
+	if err != nil {
+		return "", false
+	}
+	if v, _, _ := unstructured.NestedString(obj, "metadata", "labels", controllerVersionLabel); v != "" {
+		return v, true
+	}
+	containers, _, _ := unstructured.NestedSlice(obj, "spec", "template", "spec", "containers")
+	for _, c := range containers {
+		c, _ := c.(map[string]interface{})
+		if c["name"] != "manager" {
+			continue
+		}
+		image, _ := c["image"].(string)
+		if repo, tag, ok := strings.Cut(image, ":"); ok && repo == operatorImageRepo {
+			return tag, true
+		}
+		return "dev", true
+	}
+	return "dev", true
+}
+
+// ── Command ─────────────────────────────────────────────────────
+
+// upgradeTarget is one row of the report: the CLI or the controller.
+type upgradeTarget struct {
+	Current string `json:"current"`
+	Target  string `json:"target"`
+	// Status is "up-to-date", "available", "upgraded", "skipped", or
+	// "not-installed".
+	Status string `json:"status"`
+}
+
+type upgradeReport struct {
+	Release    string         `json:"release"`
+	CLI        *upgradeTarget `json:"cli,omitempty"`
+	Controller *upgradeTarget `json:"controller,omitempty"`
+	Warnings   []string       `json:"warnings,omitempty"`
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	if upgradeCLI && upgradeController {
+		return fmt.Errorf("--cli and --controller can't be combined — omit both to upgrade both")
+	}
+	doCLI, doController := !upgradeController, !upgradeCLI
+
+	header("Checking for updates")
+	rel, err := fetchRelease(upgradeVersion)
+	if err != nil {
+		return err
+	}
+	target := rel.version()
+	step("📦", fmt.Sprintf("Release %s", rel.Tag))
+
+	report := upgradeReport{Release: rel.Tag}
+	var ctrlVersion string
+	var ctrlInstalled bool
+	if doCLI {
+		report.CLI = &upgradeTarget{Current: Version, Target: target, Status: plannedStatus(Version, target)}
+	}
+	// The controller is looked up even for --cli, to warn about skew.
+	if clusterExists(clusterName) {
+		ctrlVersion, ctrlInstalled = controllerVersion()
+	}
+	if doController {
+		report.Controller = &upgradeTarget{Current: ctrlVersion, Target: target, Status: plannedStatus(ctrlVersion, target)}
+		if !ctrlInstalled {
+			report.Controller.Status = "not-installed"
+		}
+	}
+
+	// ── Pre-flight ──────────────────────────────────────────────
+	if report.Controller != nil && report.Controller.Status == "available" {
+		if reason := controllerUpgradeBlocked(ctrlVersion, target); reason != "" {
+			if !upgradeForce {
+				return fmt.Errorf("%s — pass --force to upgrade anyway", reason)
+			}
+			report.Warnings = append(report.Warnings, reason+" (--force)")
+		}
+	}
+	cliAfter, ctrlAfter := Version, ctrlVersion
+	if doCLI {
+		cliAfter = target
+	}
+	if doController && ctrlInstalled {
+		ctrlAfter = target
+	}
+	if ctrlInstalled {
+		if skew := versionSkew(cliAfter, ctrlAfter); skew != "" {
+			if doController {
+				report.Warnings = append(report.Warnings, skew+" — run: kindling upgrade")
+			} else {
+				report.Warnings = append(report.Warnings, skew+" — run: kindling upgrade --controller")
+			}
+		}
+	}
+
+	if upgradeCheck {
+		return render(report, func() { printUpgradeReport(report) })
+	}
+
+	// ── CLI ─────────────────────────────────────────────────────
+	if report.CLI != nil && report.CLI.Status == "available" {
+		header("Upgrading the CLI")
+		if err := selfUpdate(rel); err != nil {
+			return err
+		}
+		report.CLI.Status = "upgraded"
+	}
+
+	// ── Controller ──────────────────────────────────────────────
+	if report.Controller != nil && report.Controller.Status == "available" {
+		header("Upgrading the controller")
+		if err := upgradeControllerTo(rel); err != nil {
+			return err
+		}
+		report.Controller.Status = "upgraded"
+	}
+
+	return render(report, func() { printUpgradeReport(report) })
+}
+
+// plannedStatus compares an installed version with the release. A dev
+// build is always upgradable.
+func plannedStatus(current, target string) string {
+	c, ok1 := parseSemver(current)
+	t, ok2 := parseSemver(target)
+	if ok1 && ok2 && !c.less(t) {
+		return "up-to-date"
+	}
+	return "available"
+}
+
+// controllerUpgradeBlocked explains why moving the controller from
+// current to target is unsafe: CRDs may not convert back down, or across
+// a major version. It returns "" when the move is safe.
+func controllerUpgradeBlocked(current, target string) string {
+	c, ok1 := parseSemver(current)
+	t, ok2 := parseSemver(target)
+	switch {
+	case !ok1 || !ok2:
+		return ""
+	case t.less(c):
+		return fmt.Sprintf("controller %s is newer than %s — downgrading may leave CRDs the old controller can't read", current, target)
+	case t.major != c.major:
+		return fmt.Sprintf("controller %s → %s is a major-version upgrade — read the release notes first", current, target)
+	}
+	return ""
+}
+
+func printUpgradeReport(r upgradeReport) {
+	header("Versions")
+	row := func(name string, t *upgradeTarget) {
+		if t == nil {
+			return
+		}
+		current := t.Current
+		if current == "" {
+			current = "—"
+		}
+		fmt.Printf("  %-12s %-10s → %-10s %s\n", name, current, t.Target, dimText(t.Status))
+	}
+	row("CLI", r.CLI)
+	row("controller", r.Controller)
+	for _, w := range r.Warnings {
+		warn(w)
+	}
+	fmt.Println()
+	if upgradeCheck {
+		if (r.CLI != nil && r.CLI.Status == "available") || (r.Controller != nil && r.Controller.Status == "available") {
+			fmt.Printf("  Upgrade with: %skindling upgrade%s\n\n", colorCyan, colorReset)
+		} else {
+			success("Everything is up to date")
+		}
+	}
+}
+
+// ── Self-update ─────────────────────────────────────────────────
+
+// selfUpdate downloads the release archive for this platform, checks it
+// against checksums.txt, and replaces the running binary with the one
+// inside.
+func selfUpdate(rel *release) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	if strings.Contains(filepath.ToSlash(exe), "/Cellar/") {
+		return fmt.Errorf("%s was installed with Homebrew — run: brew upgrade kindling", exe)
+	}
+
+	ext := "tar.gz"
+	if runtime.GOOS == "windows" {
+		ext = "zip"
+	}
+	name := fmt.Sprintf("kindling_%s_%s_%s.%s", rel.version(), runtime.GOOS, runtime.GOARCH, ext)
+	archiveURL, err := rel.assetURL(name)
+	if err != nil {
+		return err
+	}
+	sumsURL, err := rel.assetURL("checksums.txt")
+	if err != nil {
+		return err
+	}
+
+	step("📥", "Downloading "+name)
+	archive, err := download(archiveURL)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	sums, err := download(sumsURL)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	if err := verifyChecksum(name, archive, sums); err != nil {
+		return err
+	}
+	step("🔒", "Checksum verified")
+
+	bin, err := extractBinary(name, archive)
+	if err != nil {
+		return err
+	}
+	if err := replaceExecutable(exe, bin); err != nil {
+		return err
+	}
+	success(fmt.Sprintf("Installed kindling %s at %s", rel.version(), exe))
+	return nil
+}
+
+// verifyChecksum checks data against name's line in a checksums.txt of
+// "<sha256>  <file>" lines.
+func verifyChecksum(name string, data, sums []byte) error {
+	sum := sha256.Sum256(data)
+	got := hex.EncodeToString(sum[:])
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			if !strings.EqualFold(fields[0], got) {
+				return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, fields[0], got)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("checksums.txt has no entry for %s", name)
+}
+
+// extractBinary returns the kindling executable from a release archive.
+func extractBinary(name string, archive []byte) ([]byte, error) {
+	want := "kindling"
+	if runtime.GOOS == "windows" {
+		want += ".exe"
+	}
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("cannot open %s: %w", name, err)
+		}
+		for _, f := range zr.File {
+			if path.Base(f.Name) == want {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			}
+		}
+		return nil, fmt.Errorf("%s has no %s", name, want)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s: %w", name, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s has no %s", name, want)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", name, err)
+		}
+		if h.Typeflag == tar.TypeReg && path.Base(h.Name) == want {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// replaceExecutable swaps exe for bin. The new binary is written next to
+// exe and renamed over it, so a failure leaves the old one in place.
+// Windows can't overwrite a running executable, so there the old one is
+// moved aside to <exe>.old first.
+func replaceExecutable(exe string, bin []byte) error {
+	dir := filepath.Dir(exe)
+	tmp, err := os.CreateTemp(dir, ".kindling-upgrade-*")
+	if err != nil {
+		return fmt.Errorf("cannot write to %s (%w) — rerun with the permissions to replace %s", dir, err, exe)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bin); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("cannot move %s aside: %w", exe, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("cannot replace %s: %w", exe, err)
+	}
+	return nil
+}
+
+// ── Controller ──────────────────────────────────────────────────
+
+// upgradeControllerTo applies the release's install manifest, checked
+// against checksums.txt, labels the controller Deployment with the
+// release's version, and waits for the rollout. Applying the whole
+// manifest, as init does, keeps the CRDs' conversion webhook and CA
+// injection, and brings in the RBAC and webhooks the new controller needs.
+func upgradeControllerTo(rel *release) error {
+	manifestURL, err := rel.assetURL(releaseInstallManifest)
+	if err != nil {
+		return err
+	}
+	sumsURL, err := rel.assetURL("checksums.txt")
+	if err != nil {
+		return err
+	}
+	step("📥", "Downloading "+releaseInstallManifest)
+	manifest, err := download(manifestURL)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	sums, err := download(sumsURL)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	if err := verifyChecksum(releaseInstallManifest, manifest, sums); err != nil {
+		return err
+	}
+	step("🔒", "Checksum verified")
+
+	step("🚀", fmt.Sprintf("Applying the controller, CRDs, RBAC, and webhooks of %s (%s:%s)", rel.Tag, operatorImageRepo, rel.version()))
+	if err := applyWhenWebhookReady(string(manifest)); err != nil {
+		return fmt.Errorf("applying %s failed: %s", releaseInstallManifest, lastLines(err.Error(), 5))
+	}
+	success("Install manifest applied")
+	if out, err := runSilent("kubectl", "label", "deployment/kindling-controller-manager", "--overwrite",
+		controllerVersionLabel+"="+rel.version(), "-n", "kindling-system"); err != nil {
+		return fmt.Errorf("kubectl label failed: %s", out)
+	}
+	step("⏳", "Waiting for controller-manager rollout")
+	if out, err := runSilent("kubectl", "rollout", "status", "deployment/kindling-controller-manager",
+		"-n", "kindling-system", "--timeout=180s"); err != nil {
+		return fmt.Errorf("controller rollout did not finish: %s — check with: kindling logs", lastLines(out, 3))
+	}
+	success("Controller is running " + rel.version())
+	return nil
+}
@@ -0,0 +1,488 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage [component]",
+	Short: "Report the CPU and memory components use against their requests",
+	Long: `Samples metrics-server a few times and reports, for each app, canary,
+and dependency, the CPU and memory its pods use next to what they request
+and are limited to — and how much of the machine the cluster takes.
+
+Kind nodes share the host, so a component using a quarter or more of the
+host's CPU or memory is marked as one starving the laptop, and the report
+suggests what to change in its DevStagingEnvironment: fewer replicas, a
+limit to cap it, requests lowered to what it uses so more fits, or a
+memory limit raised before it is OOM-killed.
+
+Components are named the same way as in kindling logs; without one,
+every component of the --env (or current) environment is reported.
+metrics-server comes with kindling init --profile full, or metricsServer:
+true in .kindling/cluster.yaml.
+
+Examples:
+  kindling usage
+  kindling usage orders-dev-postgres
+  kindling usage --env checkout-dev --samples 6 --interval 15s
+  kindling usage -o json`,
+	Args:              cobra.MaximumNArgs(1),
+	SilenceUsage:      true,
+	ValidArgsFunction: firstArg(completeComponents),
+	RunE:              runUsage,
+}
+
+var (
+	usageEnv      string
+	usageSamples  int
+	usageInterval time.Duration
+)
+
+func init() {
+	usageCmd.Flags().StringVar(&usageEnv, "env", "", "DevStagingEnvironment or environment to report on")
+	_ = usageCmd.RegisterFlagCompletionFunc("env", completeEnvFlag)
+	usageCmd.Flags().IntVar(&usageSamples, "samples", 3, "How many times to sample metrics-server")
+	usageCmd.Flags().DurationVar(&usageInterval, "interval", 10*time.Second, "Time between samples")
+	rootCmd.AddCommand(usageCmd)
+}
+
+// usageHeavyShare is the share of the host's CPU or memory from which a
+// component is reported as starving the laptop.
+const usageHeavyShare = 0.25
+
+// usageFigures are one resource's numbers for a component, summed over
+// its pods: millicores for CPU, bytes for memory.
+type usageFigures struct {
+	Average int64 `json:"average"`
+	Peak    int64 `json:"peak"`
+	Request int64 `json:"request"`
+	Limit   int64 `json:"limit,omitempty"` // 0 when the pods have none
+}
+
+// componentUsage is one row of the usage report.
+type componentUsage struct {
+	Component   string       `json:"component"`
+	Namespace   string       `json:"namespace"`
+	Environment string       `json:"environment"` // the DevStagingEnvironment
+	Role        string       `json:"role"`        // "app", "canary", or a dependency type
+	Pods        int          `json:"pods"`
+	CPU         usageFigures `json:"cpu"`
+	Memory      usageFigures `json:"memory"`
+	Heavy       bool         `json:"heavy"` // uses a quarter or more of the host's CPU or memory
+	Suggestions []string     `json:"suggestions,omitempty"`
+}
+
+// usageReport is the JSON form of usage's output.
+type usageReport struct {
+	Samples    int              `json:"samples"`
+	HostCPU    int64            `json:"hostCpu"`    // millicores of the largest node, which Kind nodes share
+	HostMemory int64            `json:"hostMemory"` // bytes
+	UsedCPU    int64            `json:"usedCpu"`    // by everything on the cluster's nodes
+	UsedMemory int64            `json:"usedMemory"`
+	Components []componentUsage `json:"components"`
+	Warnings   []string         `json:"warnings,omitempty"`
+}
+
+// usageWorkload holds the fields usage reads from the operator's
+// Deployments and StatefulSets.
+type usageWorkload struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas *int `json:"replicas"`
+		Selector struct {
+			MatchLabels map[string]string `json:"matchLabels"`
+		} `json:"selector"`
+		Template struct {
+			Spec struct {
+				Containers []struct {
+					Resources struct {
+						Requests map[string]string `json:"requests"`
+						Limits   map[string]string `json:"limits"`
+					} `json:"resources"`
+				} `json:"containers"`
+			} `json:"spec"`
+		} `json:"template"`
+	} `json:"spec"`
+}
+
+// metricsItem is a PodMetrics or NodeMetrics object of metrics.k8s.io.
+type metricsItem struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Usage      map[string]string `json:"usage"` // nodes
+	Containers []struct {
+		Usage map[string]string `json:"usage"`
+	} `json:"containers"` // pods
+}
+
+// listMetrics lists metrics.k8s.io's PodMetrics or NodeMetrics.
+func listMetrics(resource, selector string) ([]metricsItem, error) {
+	out, err := listObjects(resource+".metrics.k8s.io", allNamespaces, selector)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []metricsItem `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// usageOf returns the CPU and memory a metrics usage map reports.
+func usageOf(usage map[string]string) devstaging.Request {
+	cpu, _ := devstaging.ParseQuantity(usage["cpu"])
+	mem, _ := devstaging.ParseQuantity(usage["memory"])
+	return devstaging.Request{CPUMilli: int64(math.Round(cpu * 1000)), MemBytes: int64(mem)}
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	if usageSamples < 1 {
+		return fmt.Errorf("--samples must be at least 1")
+	}
+	if !clusterExists(clusterName) {
+		return errNoCluster()
+	}
+	arg := ""
+	if len(args) == 1 {
+		arg = args[0]
+	}
+	envs := collectEnvironments()
+	refs, err := resolveComponents(envs, arg, usageEnv)
+	if err != nil {
+		return err
+	}
+	if _, err := listMetrics("nodes", ""); err != nil {
+		return fmt.Errorf("metrics-server isn't answering — it comes with kindling init --profile full, or metricsServer: true in .kindling/cluster.yaml")
+	}
+
+	var workloads []usageWorkload
+	for _, resource := range []string{"deployments", "statefulsets"} {
+		out, err := listObjects(resource, allNamespaces, operatorManagedBy)
+		if err != nil {
+			return fmt.Errorf("cannot list %s: %w", resource, err)
+		}
+		var list struct {
+			Items []usageWorkload `json:"items"`
+		}
+		if err := json.Unmarshal(out, &list); err != nil {
+			return fmt.Errorf("cannot parse %s: %w", resource, err)
+		}
+		workloads = append(workloads, list.Items...)
+	}
+	selected := map[string]bool{}
+	for _, r := range refs {
+		selected[r.namespace+"/"+r.name] = true
+	}
+	var rows []*componentUsage
+	var rowWorkloads []usageWorkload
+	for _, w := range workloads {
+		if !selected[w.Metadata.Namespace+"/"+w.Metadata.Name] {
+			continue
+		}
+		rows = append(rows, newComponentUsage(w))
+		rowWorkloads = append(rowWorkloads, w)
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("no running app or dependency matches %s — scheduled apps and jobs aren't sampled", describeLogTarget(arg, usageEnv))
+	}
+
+	header("Resource usage")
+	step("📈", fmt.Sprintf("Sampling metrics-server %d time(s), %s apart", usageSamples, usageInterval))
+	report := usageReport{Samples: usageSamples, Components: []componentUsage{}}
+	if capacity, ok := readClusterCapacity(); ok {
+		report.HostCPU, report.HostMemory = capacity.LargestCPU, capacity.LargestMem
+	}
+	var nodeCPU, nodeMem []int64
+	for i := 0; i < usageSamples; i++ {
+		if i > 0 {
+			time.Sleep(usageInterval)
+		}
+		pods, err := listMetrics("pods", operatorManagedBy)
+		if err != nil {
+			return fmt.Errorf("cannot read pod metrics: %w", err)
+		}
+		for k, row := range rows {
+			var sample devstaging.Request
+			for _, p := range pods {
+				if p.Metadata.Namespace != row.Namespace || !matchesLabels(p.Metadata.Labels, rowWorkloads[k].Spec.Selector.MatchLabels) {
+					continue
+				}
+				for _, c := range p.Containers {
+					sample.Add(usageOf(c.Usage))
+				}
+			}
+			row.CPU.Average += sample.CPUMilli
+			row.Memory.Average += sample.MemBytes
+			row.CPU.Peak = max(row.CPU.Peak, sample.CPUMilli)
+			row.Memory.Peak = max(row.Memory.Peak, sample.MemBytes)
+		}
+		if nodes, err := listMetrics("nodes", ""); err == nil {
+			var used devstaging.Request
+			for _, n := range nodes {
+				used.Add(usageOf(n.Usage))
+			}
+			nodeCPU = append(nodeCPU, used.CPUMilli)
+			nodeMem = append(nodeMem, used.MemBytes)
+		}
+	}
+	report.UsedCPU, report.UsedMemory = averageOf(nodeCPU), averageOf(nodeMem)
+
+	for _, row := range rows {
+		row.CPU.Average /= int64(usageSamples)
+		row.Memory.Average /= int64(usageSamples)
+		suggestUsage(row, report.HostCPU, report.HostMemory)
+		report.Components = append(report.Components, *row)
+	}
+	sort.SliceStable(report.Components, func(i, k int) bool {
+		a, b := report.Components[i], report.Components[k]
+		if a.Heavy != b.Heavy {
+			return a.Heavy
+		}
+		return a.CPU.Peak > b.CPU.Peak
+	})
+	report.Warnings = hostPressure(report)
+	return render(report, func() { printUsage(report) })
+}
+
+// newComponentUsage sums the requests and limits of a workload's pods.
+func newComponentUsage(w usageWorkload) *componentUsage {
+	l := w.Metadata.Labels
+	row := &componentUsage{
+		Component:   w.Metadata.Name,
+		Namespace:   w.Metadata.Namespace,
+		Environment: l["app.kubernetes.io/part-of"],
+		Role:        "app",
+		Pods:        1,
+	}
+	if row.Environment == "" {
+		row.Environment = l["app.kubernetes.io/instance"]
+	}
+	if role := l["app.kubernetes.io/component"]; role != "" {
+		row.Role = role
+	}
+	if w.Spec.Replicas != nil {
+		row.Pods = *w.Spec.Replicas
+	}
+	cpuCapped, memCapped := true, true
+	for _, c := range w.Spec.Template.Spec.Containers {
+		req := devstaging.ContainerRequest(c.Resources.Requests["cpu"], c.Resources.Limits["cpu"],
+			c.Resources.Requests["memory"], c.Resources.Limits["memory"])
+		lim := devstaging.ContainerRequest(c.Resources.Limits["cpu"], "", c.Resources.Limits["memory"], "")
+		row.CPU.Request += req.CPUMilli * int64(row.Pods)
+		row.Memory.Request += req.MemBytes * int64(row.Pods)
+		row.CPU.Limit += lim.CPUMilli * int64(row.Pods)
+		row.Memory.Limit += lim.MemBytes * int64(row.Pods)
+		// A container without a limit leaves the pod uncapped.
+		cpuCapped = cpuCapped && lim.CPUMilli > 0
+		memCapped = memCapped && lim.MemBytes > 0
+	}
+	if !cpuCapped {
+		row.CPU.Limit = 0
+	}
+	if !memCapped {
+		row.Memory.Limit = 0
+	}
+	return row
+}
+
+// matchesLabels reports whether labels has every key and value of
+// selector.
+func matchesLabels(labels, selector map[string]string) bool {
+	if len(selector) == 0 {
+		return false
+	}
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func averageOf(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / int64(len(values))
+}
+
+// usageFields returns where a component's replicas and resources are set
+// in its DevStagingEnvironment, or "" for replicas when it runs one pod.
+func usageFields(row *componentUsage) (replicas, resources string) {
+	switch row.Role {
+	case "app":
+		return "deployment.replicas", "deployment.resources"
+	case "canary":
+		// The canary runs with the app's resources.
+		return "canary.replicas", "deployment.resources"
+	}
+	return "", fmt.Sprintf("dependencies[type=%s].resources", row.Role)
+}
+
+// suggestUsage marks a component that starves the host and lists what to
+// change in its DevStagingEnvironment. Field names are v1alpha1's; a
+// v1beta1 manifest sets resources.requests and resources.limits instead.
+func suggestUsage(row *componentUsage, hostCPU, hostMem int64) {
+	replicasField, resources := usageFields(row)
+	heavyCPU := hostCPU > 0 && float64(row.CPU.Peak) >= usageHeavyShare*float64(hostCPU)
+	heavyMem := hostMem > 0 && float64(row.Memory.Peak) >= usageHeavyShare*float64(hostMem)
+	row.Heavy = heavyCPU || heavyMem
+	pods := int64(max(row.Pods, 1))
+	add := func(format string, a ...any) { row.Suggestions = append(row.Suggestions, fmt.Sprintf(format, a...)) }
+
+	if row.Heavy && row.Pods > 1 && replicasField != "" {
+		add("lower %s from %d to 1", replicasField, row.Pods)
+	}
+	if heavyCPU && row.CPU.Limit == 0 {
+		add("cap it with %s.cpuLimit: %s", resources, formatMilliQuantity(roundUp(hostCPU/4/pods, 50)))
+	}
+	if heavyMem && row.Memory.Limit == 0 {
+		add("cap it with %s.memoryLimit: %s", resources, formatMiQuantity(roundUp(row.Memory.Peak/pods*5/4, 16<<20)))
+	}
+
+	// Memory at its limit is the next OOM kill.
+	if row.Memory.Limit > 0 && float64(row.Memory.Peak) >= 0.9*float64(row.Memory.Limit) {
+		add("raise %s.memoryLimit from %s — it is at %d%% and will be OOM-killed", resources,
+			formatMiQuantity(row.Memory.Limit/pods), row.Memory.Peak*100/row.Memory.Limit)
+	}
+	if row.CPU.Limit > 0 && float64(row.CPU.Peak) >= 0.9*float64(row.CPU.Limit) && !heavyCPU {
+		add("raise %s.cpuLimit from %s if it responds slowly — it is throttled", resources,
+			formatMilliQuantity(row.CPU.Limit/pods))
+	}
+
+	// Requests well above use hold room the scheduler could give others:
+	// suggest half again the peak.
+	cpuRequest, memRequest := row.CPU.Request/pods, row.Memory.Request/pods
+	if want := max(roundUp(row.CPU.Peak/pods*3/2, 10), 50); cpuRequest >= 100 && want*4 <= cpuRequest*3 {
+		add("lower %s.cpuRequest from %s to %s", resources, formatMilliQuantity(cpuRequest), formatMilliQuantity(want))
+	}
+	if want := max(roundUp(row.Memory.Peak/pods*3/2, 16<<20), 32<<20); memRequest >= 64<<20 && want*4 <= memRequest*3 {
+		add("lower %s.memoryRequest from %s to %s", resources, formatMiQuantity(memRequest), formatMiQuantity(want))
+	}
+	if row.CPU.Request == 0 && row.CPU.Peak > 0 && row.Heavy {
+		add("set %s.cpuRequest: %s so the scheduler counts it", resources, formatMilliQuantity(max(roundUp(row.CPU.Average/pods, 10), 10)))
+	}
+	if row.Memory.Request == 0 && row.Memory.Peak > 0 && row.Heavy {
+		add("set %s.memoryRequest: %s so the scheduler counts it", resources, formatMiQuantity(max(roundUp(row.Memory.Average/pods, 16<<20), 32<<20)))
+	}
+}
+
+// hostPressure warns when the cluster takes most of the machine.
+func hostPressure(report usageReport) []string {
+	var warnings []string
+	var heavy []string
+	for _, c := range report.Components {
+		if c.Heavy {
+			heavy = append(heavy, c.Component)
+		}
+	}
+	busiest := ""
+	if len(heavy) > 0 {
+		busiest = " — most of it by " + strings.Join(heavy, ", ")
+	}
+	if report.HostCPU > 0 && report.UsedCPU*100 >= report.HostCPU*80 {
+		warnings = append(warnings, fmt.Sprintf("the cluster uses %d%% of the host's %s CPU%s", report.UsedCPU*100/report.HostCPU, devstaging.FormatMilliCPU(report.HostCPU), busiest))
+	}
+	if report.HostMemory > 0 && report.UsedMemory*100 >= report.HostMemory*80 {
+		warnings = append(warnings, fmt.Sprintf("the cluster uses %d%% of the host's %s memory%s", report.UsedMemory*100/report.HostMemory, formatMiQuantity(report.HostMemory), busiest))
+	}
+	return warnings
+}
+
+// roundUp rounds n up to a multiple of step.
+func roundUp(n, step int64) int64 {
+	return (n + step - 1) / step * step
+}
+
+// formatMilliQuantity prints millicores as a Kubernetes quantity, e.g.
+// 250 → "250m", 2000 → "2".
+func formatMilliQuantity(m int64) string {
+	if m%1000 == 0 {
+		return fmt.Sprintf("%d", m/1000)
+	}
+	return fmt.Sprintf("%dm", m)
+}
+
+// formatMiQuantity prints bytes as a Kubernetes quantity in Mi or Gi.
+func formatMiQuantity(b int64) string {
+	if mi := b >> 20; mi >= 1024 && mi%1024 == 0 {
+		return fmt.Sprintf("%dGi", mi/1024)
+	}
+	return fmt.Sprintf("%dMi", (b+(1<<20)-1)>>20)
+}
+
+// usageCell prints "use / request" for the table, with the limit when
+// there is one.
+func usageCell(f usageFigures, format func(int64) string) string {
+	s := format(f.Peak) + " / "
+	if f.Request == 0 {
+		s += "—"
+	} else {
+		s += format(f.Request)
+	}
+	if f.Limit > 0 {
+		s += " ≤ " + format(f.Limit)
+	}
+	return s
+}
+
+func printUsage(report usageReport) {
+	if report.HostCPU > 0 {
+		fmt.Printf("  Host: %s CPU, %s memory — the cluster uses %s CPU, %s memory\n\n",
+			devstaging.FormatMilliCPU(report.HostCPU), formatMiQuantity(report.HostMemory),
+			formatMilliQuantity(report.UsedCPU), formatMiQuantity(report.UsedMemory))
+	}
+	fmt.Printf("  %s%-28s %-10s %-5s %-26s %s%s\n", colorBold, "COMPONENT", "ROLE", "PODS", "CPU PEAK / REQ ≤ LIMIT", "MEMORY PEAK / REQ ≤ LIMIT", colorReset)
+	for _, c := range report.Components {
+		name := c.Component
+		if c.Heavy {
+			name = colorRed + fmt.Sprintf("%-28s", name) + colorReset
+		} else {
+			name = fmt.Sprintf("%-28s", name)
+		}
+		fmt.Printf("  %s %-10s %-5d %-26s %s\n", name, c.Role, c.Pods,
+			usageCell(c.CPU, formatMilliQuantity), usageCell(c.Memory, formatMiQuantity))
+	}
+	fmt.Println()
+	for _, w := range report.Warnings {
+		warn(w)
+	}
+	suggested := false
+	for _, c := range report.Components {
+		for _, s := range c.Suggestions {
+			if !suggested {
+				header("Suggestions")
+				suggested = true
+			}
+			fmt.Printf("  %s%s%s: %s\n", colorCyan, c.Component, colorReset, s)
+		}
+	}
+	if suggested {
+		fmt.Printf("\n  %sSet these in the manifest and run kindling deploy; kindling scale changes replicas on the cluster only.%s\n\n", colorDim, colorReset)
+	} else if len(report.Warnings) == 0 {
+		success("Nothing to change — no component is starving the host")
+	}
+}
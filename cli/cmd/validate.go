@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Statically check a DevStagingEnvironment manifest or kindling workflow",
+	Long: `Runs static analysis over a DevStagingEnvironment manifest (or a
+generated dev-deploy.yml workflow) without changing the cluster.
+
+Checks:
+  schema                    fields, types, and enums match the CRD
+  missing_dockerfile        a build context has no Dockerfile
+  expose_mismatch           Dockerfile EXPOSE differs from the declared port
+  port_mismatch             a URL in env points at the wrong service port
+  dangling_ref              a URL in env points at an undeclared service
+  missing_health_check      no probe, or the default /healthz is assumed
+  env                       an env value is unset, or the code reads one
+                            nothing sets
+  duplicate_hostname        two ingresses claim the same host and path
+  duplicate_node_port       two services fix the same node port
+  duplicate_name            two resources share a name
+  depends_on                a dependsOn cycle or an unknown upstream
+  unsatisfiable_dependency  a dependency can't be provisioned or wired up
+  insufficient_capacity     the CPU/memory requests don't fit in the Kind
+                            cluster (only checked when it is running)
+  policy                    a policy in .kindling/policies/ is broken
+                            (see: kindling policy)
+
+Findings are 🔴 error, 🟡 warning, or 🔵 info. The command exits non-zero
+when any error is found, so it can gate CI. Use -o json for a
+machine-readable report.
+
+Examples:
+  kindling validate -f dev-environment.yaml
+  kindling validate -f .github/workflows/dev-deploy.yml
+  kindling validate -f dev-environment.yaml -o json
+  kindling validate -f dev-environment.yaml --policies ../platform/policies`,
+	SilenceUsage: true,
+	RunE:         runValidate,
+}
+
+var (
+	validateFile     string
+	validateRepoPath string
+	validatePolicies string
+)
+
+func init() {
+	validateCmd.Flags().StringVarP(&validateFile, "file", "f", "", "DevStagingEnvironment YAML or dev-deploy workflow to check (required)")
+	validateCmd.Flags().StringVarP(&validateRepoPath, "repo-path", "r", "", "Repository root used to resolve Dockerfiles (default: the file's directory, or the repo root for .github/workflows files)")
+	validateCmd.Flags().StringVar(&validatePolicies, "policies", "", "Policies directory to enforce (default: .kindling/policies of the project)")
+	_ = validateCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(validateCmd)
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(validateFile)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", validateFile, err)
+	}
+
+	repoPath := validateRepoPath
+	if repoPath == "" {
+		repoPath = validateRepoRoot(validateFile)
+	}
+
+	capacity, _ := readClusterCapacity()
+	report := devstaging.Validate(data, devstaging.Options{
+		RepoPath:    repoPath,
+		Capacity:    capacity,
+		HostMounts:  hostMounts(),
+		PoliciesDir: validatePolicies,
+	})
+	report.File = validateFile
+
+	if err := render(report, func() { printValidationReport(report) }); err != nil {
+		return err
+	}
+	if report.Errors > 0 {
+		return fmt.Errorf("%d error(s) found in %s", report.Errors, validateFile)
+	}
+	return nil
+}
+
+// validateRepoRoot returns the repository a file describes: the file's
+// directory, or the repo root for a workflow under .github/workflows.
+func validateRepoRoot(file string) string {
+	dir := filepath.Dir(file)
+	if filepath.Base(dir) == "workflows" && filepath.Base(filepath.Dir(dir)) == ".github" {
+		return filepath.Dir(filepath.Dir(dir))
+	}
+	return dir
+}
+
+// hostMounts returns where kindling init --mount put host directories
+// into the nodes of the cluster profile.
+func hostMounts() []string {
+	p, _, err := resolveClusterProfile(".", "")
+	if err != nil {
+		return nil
+	}
+	var nodePaths []string
+	for _, m := range p.Mounts {
+		_, nodePath := splitMount(m)
+		nodePaths = append(nodePaths, nodePath)
+	}
+	return nodePaths
+}
+
+// severityIcon maps a severity to the marker used in text output.
+func severityIcon(severity string) string {
+	switch severity {
+	case devstaging.SeverityError:
+		return "🔴"
+	case devstaging.SeverityWarning:
+		return "🟡"
+	default:
+		return "🔵"
+	}
+}
+
+func printValidationReport(r devstaging.Report) {
+	header(fmt.Sprintf("Validating %s", r.File))
+	step("📄", fmt.Sprintf("%s with %d resource(s): %s", r.Kind, len(r.Resources), strings.Join(r.Resources, ", ")))
+	fmt.Fprintln(os.Stderr)
+
+	for _, f := range r.Findings {
+		target := ""
+		if f.Resource != "" {
+			target = f.Resource + ": "
+		}
+		fmt.Fprintf(os.Stderr, "  %s  %s%s %s\n", severityIcon(f.Severity), target, f.Detail, dimText("["+f.Check+"]"))
+	}
+	if len(r.Findings) > 0 {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	summary := fmt.Sprintf("%d error(s), %d warning(s), %d finding(s) total", r.Errors, r.Warnings, len(r.Findings))
+	if r.Valid {
+		success(summary)
+	} else {
+		fail(summary)
+	}
+}
@@ -13,8 +13,15 @@ var Version = "dev"
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the kindling CLI version",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("kindling %s (%s/%s)\n", Version, runtime.GOOS, runtime.GOARCH)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := struct {
+			Version string `json:"version"`
+			OS      string `json:"os"`
+			Arch    string `json:"arch"`
+		}{Version, runtime.GOOS, runtime.GOARCH}
+		return render(info, func() {
+			fmt.Printf("kindling %s (%s/%s)\n", info.Version, info.OS, info.Arch)
+		})
 	},
 }
 
@@ -0,0 +1,282 @@
+// Package daemon keeps track of the background processes the kindling CLI
+// leaves running — tunnels, port-forwards, dev sessions, kindlingd — so
+// they can be listed, health-checked, and stopped from any later command.
+//
+// Each process has an entry in <project>/.kindling/daemons/: <name>.yaml
+// records how it was started, and <name>.log holds its output.
+package daemon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeffvincent/kindling/cli/internal/procutil"
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of daemon the CLI starts.
+const (
+	KindTunnel      = "tunnel"
+	KindPortForward = "port-forward"
+	KindDev         = "dev"
+	KindServer      = "server"
+)
+
+// ErrTimeout is returned by WaitFor when nothing turned up in time.
+var ErrTimeout = errors.New("timed out")
+
+// Daemon is one registered background process.
+type Daemon struct {
+	Name    string            `yaml:"name" json:"name"`
+	Kind    string            `yaml:"kind" json:"kind"`
+	PID     int               `yaml:"pid" json:"pid"`
+	Command []string          `yaml:"command" json:"command"`
+	Log     string            `yaml:"log,omitempty" json:"log,omitempty"`
+	Health  string            `yaml:"health,omitempty" json:"health,omitempty"` // http(s):// URL or tcp://host:port
+	Labels  map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Started time.Time         `yaml:"started" json:"started"`
+}
+
+// Alive reports whether the process is still running.
+func (d *Daemon) Alive() bool {
+	return procutil.Alive(d.PID)
+}
+
+// Uptime returns how long the process has been running.
+func (d *Daemon) Uptime() time.Duration {
+	if d.Started.IsZero() {
+		return 0
+	}
+	return time.Since(d.Started).Truncate(time.Second)
+}
+
+// Check health-checks the process: it must be running and, when it has a
+// health target, answer there. Any HTTP response counts, since an error
+// page still means the process carries traffic.
+func (d *Daemon) Check(timeout time.Duration) error {
+	if !d.Alive() {
+		return fmt.Errorf("not running")
+	}
+	switch {
+	case strings.HasPrefix(d.Health, "http://"), strings.HasPrefix(d.Health, "https://"):
+		client := &http.Client{Timeout: timeout}
+		resp, err := client.Head(d.Health)
+		if err != nil {
+			return fmt.Errorf("%s unreachable", d.Health)
+		}
+		resp.Body.Close()
+	case strings.HasPrefix(d.Health, "tcp://"):
+		conn, err := net.DialTimeout("tcp", strings.TrimPrefix(d.Health, "tcp://"), timeout)
+		if err != nil {
+			return fmt.Errorf("%s refuses connections", strings.TrimPrefix(d.Health, "tcp://"))
+		}
+		conn.Close()
+	}
+	return nil
+}
+
+// Registry is the daemons directory of a project.
+type Registry struct {
+	dir string
+}
+
+// Open returns the registry under projectDir/.kindling/daemons.
+func Open(projectDir string) *Registry {
+	return &Registry{dir: filepath.Join(projectDir, ".kindling", "daemons")}
+}
+
+// Dir returns the directory holding the registry.
+func (r *Registry) Dir() string {
+	return r.dir
+}
+
+// LogPath returns the log file of the named daemon.
+func (r *Registry) LogPath(name string) string {
+	return filepath.Join(r.dir, name+".log")
+}
+
+func (r *Registry) recordPath(name string) string {
+	return filepath.Join(r.dir, name+".yaml")
+}
+
+// Start runs cmd in the background with its output in the daemon's log,
+// and registers it as d. A daemon of the same name is replaced.
+func (r *Registry) Start(d Daemon, cmd *exec.Cmd) (*Daemon, error) {
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return nil, err
+	}
+	d.Log = r.LogPath(d.Name)
+	logFile, err := os.Create(d.Log)
+	if err != nil {
+		return nil, err
+	}
+	defer logFile.Close()
+
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	procutil.Detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", filepath.Base(cmd.Path), err)
+	}
+	// Reap the process if it exits while the CLI is still running.
+	go func() { _ = cmd.Wait() }()
+
+	d.PID = cmd.Process.Pid
+	d.Command = cmd.Args
+	d.Started = time.Now().UTC().Truncate(time.Second)
+	if err := r.Register(d); err != nil {
+		_ = procutil.Kill(d.PID)
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Register records a process that was started some other way, such as
+// the CLI itself during a dev session.
+func (r *Registry) Register(d Daemon) error {
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return err
+	}
+	if d.Started.IsZero() {
+		d.Started = time.Now().UTC().Truncate(time.Second)
+	}
+	data, err := yaml.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.recordPath(d.Name), data, 0644)
+}
+
+// Get returns the named daemon.
+func (r *Registry) Get(name string) (*Daemon, error) {
+	data, err := os.ReadFile(r.recordPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no background process named %q", name)
+		}
+		return nil, err
+	}
+	var d Daemon
+	if err := yaml.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", r.recordPath(name), err)
+	}
+	return &d, nil
+}
+
+// List returns every registered daemon, sorted by kind and name. A missing
+// registry is empty.
+func (r *Registry) List() ([]Daemon, error) {
+	entries, err := os.ReadDir(r.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var daemons []Daemon
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".yaml")
+		if !ok {
+			continue
+		}
+		d, err := r.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		daemons = append(daemons, *d)
+	}
+	sort.Slice(daemons, func(i, j int) bool {
+		if daemons[i].Kind != daemons[j].Kind {
+			return daemons[i].Kind < daemons[j].Kind
+		}
+		return daemons[i].Name < daemons[j].Name
+	})
+	return daemons, nil
+}
+
+// Stop ends the named daemon — asking first, then killing it after grace —
+// and removes it from the registry.
+func (r *Registry) Stop(name string, grace time.Duration) error {
+	d, err := r.Get(name)
+	if err != nil {
+		return err
+	}
+	procutil.Stop(d.PID, grace)
+	return r.Remove(name)
+}
+
+// Remove drops the named daemon and its log from the registry without
+// touching the process.
+func (r *Registry) Remove(name string) error {
+	if err := os.Remove(r.recordPath(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	_ = os.Remove(r.LogPath(name))
+	return nil
+}
+
+// Prune removes the daemons whose process has exited and returns them.
+func (r *Registry) Prune() ([]Daemon, error) {
+	daemons, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	var pruned []Daemon
+	for _, d := range daemons {
+		if d.Alive() {
+			continue
+		}
+		if err := r.Remove(d.Name); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, d)
+	}
+	return pruned, nil
+}
+
+// Logs returns the last lines of the daemon's log, or all of it when lines
+// is 0.
+func (d *Daemon) Logs(lines int) (string, error) {
+	if d.Log == "" {
+		return "", fmt.Errorf("%s keeps no log", d.Name)
+	}
+	data, err := os.ReadFile(d.Log)
+	if err != nil {
+		return "", err
+	}
+	data = bytes.TrimRight(data, "\n")
+	if lines > 0 {
+		all := strings.Split(string(data), "\n")
+		if len(all) > lines {
+			return strings.Join(all[len(all)-lines:], "\n"), nil
+		}
+	}
+	return string(data), nil
+}
+
+// WaitFor polls the daemon's log until match finds what it is waiting for
+// in it, and returns that. It fails when the process exits first or
+// nothing turns up within timeout.
+func (d *Daemon) WaitFor(timeout time.Duration, match func(log string) string) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(500 * time.Millisecond)
+		out, _ := d.Logs(0)
+		if found := match(out); found != "" {
+			return found, nil
+		}
+		if !d.Alive() {
+			tail, _ := d.Logs(5)
+			return "", fmt.Errorf("%s exited: %s", d.Name, strings.TrimSpace(tail))
+		}
+	}
+	return "", fmt.Errorf("%w after %s", ErrTimeout, timeout)
+}
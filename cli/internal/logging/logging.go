@@ -0,0 +1,208 @@
+// Package logging is the structured log behind the CLI's pretty output.
+// Every run appends a debug log to <project>/.kindling/logs/kindling.log,
+// which is rotated when it grows too large; --verbose copies the same
+// records to stderr. Nothing is sent anywhere.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileName is the current log in the logs directory; rotated logs are
+// FileName.1 (the newest) to FileName.<Keep>.
+const FileName = "kindling.log"
+
+const (
+	// MaxSize is the size beyond which the log is rotated when opened.
+	MaxSize = 5 << 20
+	// Keep is how many rotated logs are kept.
+	Keep = 3
+)
+
+// Dir returns the logs directory of the project in dir.
+func Dir(dir string) string {
+	return filepath.Join(dir, ".kindling", "logs")
+}
+
+// Open opens the log in the logs directory for appending, first rotating
+// it if it is larger than MaxSize. The directory gets a .gitignore of its
+// own, so the logs stay out of the repository even where .kindling/
+// isn't ignored.
+func Open(dir string) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); errors.Is(err, os.ErrNotExist) {
+		_ = os.WriteFile(ignore, []byte("*\n"), 0o644)
+	}
+	path := filepath.Join(dir, FileName)
+	if info, err := os.Stat(path); err == nil && info.Size() > MaxSize {
+		if err := rotate(path); err != nil {
+			return nil, err
+		}
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+}
+
+// rotate shifts path.1 … path.<Keep-1> up by one, dropping the oldest, and
+// moves path to path.1.
+func rotate(path string) error {
+	for i := Keep - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(path, path+".1")
+}
+
+// Files returns the logs in dir, the current one first.
+func Files(dir string) []string {
+	var files []string
+	for i := 0; i <= Keep; i++ {
+		path := filepath.Join(dir, FileName)
+		if i > 0 {
+			path = fmt.Sprintf("%s.%d", path, i)
+		}
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// Fanout returns a handler that passes each record to every handler that
+// is enabled for its level.
+func Fanout(handlers ...slog.Handler) slog.Handler {
+	return fanout(handlers)
+}
+
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
+
+// secretFlags are flags whose value, the next argument or after "=", is
+// a credential.
+var secretFlags = map[string]bool{
+	"--password":         true,
+	"--token":            true,
+	"--authtoken":        true,
+	"--docker-password":  true,
+	"--client-secret":    true,
+	"--api-key":          true,
+	"--registration-key": true,
+}
+
+// Redact returns args with credentials replaced by "***": the values of
+// secretFlags and of --from-literal=KEY=value.
+func Redact(args []string) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		out[i] = arg
+		switch {
+		case i > 0 && secretFlags[args[i-1]]:
+			out[i] = "***"
+		case strings.HasPrefix(arg, "--from-literal="):
+			if key, _, ok := strings.Cut(strings.TrimPrefix(arg, "--from-literal="), "="); ok {
+				out[i] = "--from-literal=" + key + "=***"
+			}
+		default:
+			if flag, _, ok := strings.Cut(arg, "="); ok && secretFlags[flag] {
+				out[i] = flag + "=***"
+			}
+		}
+	}
+	return out
+}
+
+// secretText matches credentials in free text — logs, command output —
+// with the parts to keep around them in the first and second groups.
+var secretText = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)((?:bearer|basic)[ \t]+)[A-Za-z0-9._~+/=-]+`),
+	regexp.MustCompile(`(?i)((?:password|passwd|secret|token|api[_-]?key|access[_-]?key)["']?[ \t]*[:=][ \t]*["']?)[^\s"',}]+`),
+	regexp.MustCompile(`(://[^:/\s@]+:)[^@/\s]+(@)`),
+	regexp.MustCompile(`()\b(?:gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|sk-[A-Za-z0-9_-]{20,}|xox[abpr]-[A-Za-z0-9-]{10,}|(?:AKIA|ASIA)[0-9A-Z]{16})`),
+	regexp.MustCompile(`(-----BEGIN [A-Z ]*PRIVATE KEY-----)[\s\S]*?(-----END [A-Z ]*PRIVATE KEY-----)`),
+}
+
+// RedactText masks the credentials in s that secretText recognises:
+// key=value and key: value pairs named like secrets, bearer tokens,
+// passwords in URLs, GitHub, OpenAI, Anthropic, Slack, and AWS tokens,
+// and PEM private keys.
+func RedactText(s string) string {
+	for _, re := range secretText {
+		s = re.ReplaceAllString(s, "${1}***${2}")
+	}
+	return s
+}
+
+// secretName matches the names of env vars and keys that hold
+// credentials.
+var secretName = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|private_?key|credential|dsn)`)
+
+// SecretName reports whether name looks like the name of a credential,
+// e.g. DATABASE_PASSWORD or STRIPE_API_KEY.
+func SecretName(name string) bool {
+	return secretName.MatchString(name)
+}
+
+// RedactEnvValues replaces, anywhere in v, the value of each env entry
+// ({name, value}) whose name looks like a credential.
+func RedactEnvValues(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if name, ok := v["name"].(string); ok && SecretName(name) {
+			if _, ok := v["value"].(string); ok {
+				v["value"] = "***"
+			}
+		}
+		for _, child := range v {
+			RedactEnvValues(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			RedactEnvValues(child)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Package procutil starts, probes, and stops the background processes the
+// kindling CLI leaves running — tunnels, port-forwards, the dashboard's
+// cloudflared — the same way on Unix and Windows.
+//
+// On Unix a background process gets a process group of its own, is probed
+// with signal 0, and is stopped with SIGTERM, then SIGKILL. Windows has
+// neither process groups in that sense nor SIGTERM: the process is started
+// in a new process group without a console, probed through its exit code,
+// and stopped with taskkill, which takes its child processes with it.
+package procutil
+
+import (
+	"os/exec"
+	"time"
+)
+
+// Detach makes cmd start in the background, so it keeps running when the
+// CLI exits and doesn't receive the terminal's Ctrl+C.
+func Detach(cmd *exec.Cmd) {
+	detach(cmd)
+}
+
+// Alive reports whether a process with the given PID is running.
+func Alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	return alive(pid)
+}
+
+// Terminate asks the process to exit.
+func Terminate(pid int) error {
+	return terminate(pid)
+}
+
+// Kill ends the process without waiting for it to clean up.
+func Kill(pid int) error {
+	return kill(pid)
+}
+
+// Stop terminates the process, then kills it if it is still running after
+// grace.
+func Stop(pid int, grace time.Duration) {
+	if !Alive(pid) {
+		return
+	}
+	_ = Terminate(pid)
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		if !Alive(pid) {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	_ = Kill(pid)
+}
+
+// KillMatching kills every process whose command line contains pattern.
+// It is best effort: finding nothing to kill is not an error.
+func KillMatching(pattern string) {
+	killMatching(pattern)
+}
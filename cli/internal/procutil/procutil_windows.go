@@ -0,0 +1,60 @@
+//go:build windows
+
+package procutil
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	// createNoWindow runs a console program without a console, so closing
+	// the terminal that started it doesn't end it.
+	createNoWindow = 0x08000000
+
+	// processQueryLimitedInformation is enough access to read an exit code.
+	processQueryLimitedInformation = 0x1000
+
+	// stillActive is the exit code of a process that hasn't exited.
+	stillActive = 259
+)
+
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | createNoWindow,
+		HideWindow:    true,
+	}
+}
+
+func alive(pid int) bool {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// A process without a console can't be asked to exit the way SIGTERM
+// asks, so terminate ends it like kill does. /T takes its children too.
+func terminate(pid int) error {
+	return kill(pid)
+}
+
+func kill(pid int) error {
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).Run()
+}
+
+func killMatching(pattern string) {
+	// The PowerShell running the query matches too, so it skips itself.
+	filter := "CommandLine like '%" + strings.ReplaceAll(pattern, "'", "''") + "%'"
+	_ = exec.Command("powershell", "-NoProfile", "-Command",
+		`Get-CimInstance Win32_Process -Filter "`+filter+`" | Where-Object ProcessId -ne $PID | `+
+			`Invoke-CimMethod -MethodName Terminate | Out-Null`).Run()
+}
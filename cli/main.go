@@ -2,11 +2,19 @@ package main
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/jeffvincent/kindling/cli/cmd"
 )
 
 func main() {
+	// Installed as kindlingd, the binary runs the API server unless it's
+	// given a command.
+	if strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe") == "kindlingd" &&
+		(len(os.Args) == 1 || strings.HasPrefix(os.Args[1], "-")) {
+		os.Args = append([]string{os.Args[0], "serve"}, os.Args[1:]...)
+	}
 	if err := cmd.Execute(); err != nil {
 		os.Exit(1)
 	}
@@ -0,0 +1,144 @@
+// Package build builds container images the way kindling build and
+// kindling dev do — with docker build, or with a buildx builder that loads
+// the result into the local image store — and reads from the build output
+// how much of each build the layer cache served.
+package build
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Options describe one image build.
+type Options struct {
+	Context    string // build context directory
+	Dockerfile string // path of the Dockerfile; "" is <Context>/Dockerfile
+	Image      string // tag of the image to build
+
+	// Builder is the buildx builder to build with, such as kindling's
+	// "kindling" cache builder or a remote BuildKit; "" builds with plain
+	// docker build. A buildx build is loaded into the local image store.
+	Builder string
+	// Platform is the --platform to build for, e.g. linux/arm64; "" is
+	// the builder's own.
+	Platform string
+
+	// BuildArgs are names of build args passed through from the
+	// environment, such as HTTP_PROXY; their values stay out of the image.
+	BuildArgs []string
+	// Flags are further docker build flags, such as --progress=plain.
+	Flags []string
+}
+
+// Args returns the docker arguments that build opts.
+func Args(opts Options) []string {
+	args := []string{"build"}
+	if opts.Builder != "" {
+		args = []string{"buildx", "build", "--builder", opts.Builder, "--load"}
+		if opts.Platform != "" {
+			args = append(args, "--platform", opts.Platform)
+		}
+	}
+	args = append(append(args, opts.Flags...), "-t", opts.Image)
+	for _, name := range opts.BuildArgs {
+		args = append(args, "--build-arg", name)
+	}
+	if opts.Dockerfile != "" {
+		args = append(args, "-f", opts.Dockerfile)
+	}
+	return append(args, opts.Context)
+}
+
+// SplitTag splits an image reference into its repository and tag,
+// "latest" when it has none; a registry port isn't mistaken for a tag.
+func SplitTag(image string) (repo, tag string) {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+// Result is what a build produced.
+type Result struct {
+	Image       string
+	Output      string // docker's combined output
+	Duration    time.Duration
+	CachedSteps int // Dockerfile steps served from the layer cache
+	TotalSteps  int
+}
+
+// Image builds opts with docker. The error of a failed build carries the
+// last lines of its output; Result.Output has all of it.
+func Image(ctx context.Context, opts Options) (Result, error) {
+	opts.Flags = append([]string{"--progress=plain"}, opts.Flags...)
+	cmd := exec.CommandContext(ctx, "docker", Args(opts)...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	start := time.Now()
+	err := cmd.Run()
+	res := Result{Image: opts.Image, Output: out.String(), Duration: time.Since(start)}
+	res.CachedSteps, res.TotalSteps = CacheStats(res.Output)
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(res.Output), "\n")
+		return res, fmt.Errorf("docker build of %s failed: %w\n%s", opts.Image, err, strings.Join(lines[max(0, len(lines)-15):], "\n"))
+	}
+	return res, nil
+}
+
+var (
+	// BuildKit plain progress: "#7 [build 3/6] RUN go mod download" starts
+	// a Dockerfile step and "#7 CACHED" marks it as served from cache.
+	buildkitStepRe   = regexp.MustCompile(`^#(\d+) \[[^\]]*\d+/\d+\]`)
+	buildkitCachedRe = regexp.MustCompile(`^#(\d+) CACHED`)
+	// The legacy builder prints "Step 3/6 : RUN ..." and " ---> Using cache".
+	legacyStepRe   = regexp.MustCompile(`^Step \d+/\d+ :`)
+	legacyCachedRe = regexp.MustCompile(`^ ---> Using cache`)
+)
+
+// CacheStats counts the Dockerfile steps in docker build output and how
+// many of them were cache hits.
+func CacheStats(out string) (cached, total int) {
+	steps := map[string]bool{}
+	hits := map[string]bool{}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case buildkitStepRe.MatchString(line):
+			steps[buildkitStepRe.FindStringSubmatch(line)[1]] = true
+		case buildkitCachedRe.MatchString(line):
+			hits[buildkitCachedRe.FindStringSubmatch(line)[1]] = true
+		case legacyStepRe.MatchString(line):
+			total++
+		case legacyCachedRe.MatchString(line):
+			cached++
+		}
+	}
+	for id := range hits {
+		if steps[id] {
+			cached++
+		}
+	}
+	return cached, total + len(steps)
+}
+
+// GitTag returns the image tag kindling gives a build of repoPath: the
+// short HEAD SHA, suffixed with -dirty when the work tree has changes. It
+// fails outside git or before the first commit.
+func GitTag(repoPath string) (string, error) {
+	sha, err := exec.Command("git", "-C", repoPath, "rev-parse", "--short", "HEAD").Output()
+	if err != nil || len(bytes.TrimSpace(sha)) == 0 {
+		return "", fmt.Errorf("%s has no git commit to tag from", repoPath)
+	}
+	tag := string(bytes.TrimSpace(sha))
+	if status, _ := exec.Command("git", "-C", repoPath, "status", "--porcelain").Output(); len(bytes.TrimSpace(status)) > 0 {
+		tag += "-dirty"
+	}
+	return tag, nil
+}
@@ -0,0 +1,67 @@
+package build
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestArgs(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{
+			name: "docker build",
+			opts: Options{Context: "services/orders", Image: "orders:abc123"},
+			want: []string{"build", "-t", "orders:abc123", "services/orders"},
+		},
+		{
+			name: "buildx with a platform, build args, and a Dockerfile",
+			opts: Options{
+				Context: ".", Dockerfile: "build/Dockerfile", Image: "orders:dev",
+				Builder: "kindling", Platform: "linux/arm64",
+				BuildArgs: []string{"HTTP_PROXY"}, Flags: []string{"--progress=plain"},
+			},
+			want: []string{"buildx", "build", "--builder", "kindling", "--load", "--platform", "linux/arm64",
+				"--progress=plain", "-t", "orders:dev", "--build-arg", "HTTP_PROXY", "-f", "build/Dockerfile", "."},
+		},
+	} {
+		if got := Args(tc.opts); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: Args = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSplitTag(t *testing.T) {
+	for image, want := range map[string][2]string{
+		"orders:abc123":             {"orders", "abc123"},
+		"orders":                    {"orders", "latest"},
+		"localhost:5001/orders":     {"localhost:5001/orders", "latest"},
+		"localhost:5001/orders:dev": {"localhost:5001/orders", "dev"},
+	} {
+		if repo, tag := SplitTag(image); repo != want[0] || tag != want[1] {
+			t.Errorf("SplitTag(%q) = %q, %q; want %q, %q", image, repo, tag, want[0], want[1])
+		}
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	buildkit := `#5 [build 1/3] FROM docker.io/library/golang:1.25
+#5 CACHED
+#6 [build 2/3] COPY go.mod go.sum ./
+#6 CACHED
+#7 [build 3/3] RUN go build ./...
+#7 DONE 12.3s
+#8 exporting to image
+#8 CACHED
+`
+	if cached, total := CacheStats(buildkit); cached != 2 || total != 3 {
+		t.Errorf("CacheStats(buildkit) = %d/%d, want 2/3: steps without a [n/m] aren't Dockerfile steps", cached, total)
+	}
+
+	legacy := "Step 1/3 : FROM golang:1.25\n ---> Using cache\nStep 2/3 : COPY . .\nStep 3/3 : RUN go build\r\n"
+	if cached, total := CacheStats(legacy); cached != 1 || total != 3 {
+		t.Errorf("CacheStats(legacy) = %d/%d, want 1/3", cached, total)
+	}
+}
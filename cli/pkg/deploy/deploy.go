@@ -0,0 +1,166 @@
+// Package deploy applies DevStagingEnvironment manifests the way kindling
+// deploy does: into the namespace of an environment, created on first use
+// with the kindling secrets copied into it, with a warning for each
+// environment the cluster has no room for.
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+	"github.com/jeffvincent/kindling/cli/pkg/kube"
+)
+
+const (
+	// NamespacePrefix starts the name of every environment namespace.
+	NamespacePrefix = "env-"
+	// EnvironmentLabel marks a namespace as a kindling environment and
+	// names it.
+	EnvironmentLabel = "kindling.dev/environment"
+	// DefaultEnvironment is the environment of the default namespace.
+	DefaultEnvironment = "default"
+
+	// SecretsNamespace holds the secrets kindling secrets set stores, and
+	// SecretsSelector selects them. Every environment gets a copy.
+	SecretsNamespace = "default"
+	SecretsSelector  = "app.kubernetes.io/managed-by=kindling"
+)
+
+// Namespace returns the namespace of an environment.
+func Namespace(env string) string {
+	if env == "" || env == DefaultEnvironment {
+		return DefaultEnvironment
+	}
+	return NamespacePrefix + env
+}
+
+// SecretName returns the name of the Secret kindling secrets set stores
+// the secret name in, e.g. kindling-secret-stripe-api-key for
+// STRIPE_API_KEY.
+func SecretName(name string) string {
+	return "kindling-secret-" + strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
+
+// EnsureEnvironment creates the namespace of env, unless it is the default
+// one, and copies the kindling secrets into it, replacing older copies.
+// It returns the namespace.
+func EnsureEnvironment(ctx context.Context, c *kube.Client, env string) (string, error) {
+	ns := Namespace(env)
+	if ns == DefaultEnvironment {
+		return ns, nil
+	}
+	if _, err := c.Apply(ctx, namespaceManifest(env), kube.ApplyOptions{}); err != nil {
+		return "", fmt.Errorf("cannot create namespace %s: %w", ns, err)
+	}
+	if err := copySecrets(ctx, c, ns); err != nil {
+		return "", fmt.Errorf("cannot copy the kindling secrets into %s: %w", ns, err)
+	}
+	return ns, nil
+}
+
+// namespaceManifest is the Namespace of an environment.
+func namespaceManifest(env string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata": map[string]interface{}{
+			"name": Namespace(env),
+			"labels": map[string]interface{}{
+				EnvironmentLabel:               env,
+				"app.kubernetes.io/managed-by": "kindling",
+			},
+		},
+	})
+	return data
+}
+
+// copySecrets applies a copy of each kindling secret into ns.
+func copySecrets(ctx context.Context, c *kube.Client, ns string) error {
+	secrets, err := c.Clientset.CoreV1().Secrets(SecretsNamespace).List(ctx, metav1.ListOptions{LabelSelector: SecretsSelector})
+	if err != nil || len(secrets.Items) == 0 {
+		return nil // no secrets yet
+	}
+	items := make([]interface{}, 0, len(secrets.Items))
+	for _, s := range secrets.Items {
+		items = append(items, map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": s.Name, "namespace": ns, "labels": s.Labels},
+			"type":       s.Type,
+			"data":       s.Data,
+		})
+	}
+	data, err := json.Marshal(map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": items})
+	if err != nil {
+		return err
+	}
+	_, err = c.Apply(ctx, data, kube.ApplyOptions{Namespace: ns})
+	return err
+}
+
+// Options tunes Deploy.
+type Options struct {
+	// Environment receives the objects; "" leaves them in the namespace of
+	// the client's context, or the one each names.
+	Environment string
+	// Capacity is what the cluster can schedule; nil skips the capacity
+	// check.
+	Capacity *devstaging.Capacity
+}
+
+// Result is what Deploy applied, in kubectl's kind.group/name form.
+type Result struct {
+	Environment string   `json:"environment,omitempty"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Resources   []string `json:"resources"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// Deploy applies every object of manifest, a YAML or JSON file of
+// DevStagingEnvironments. The objects applied before a failure are in the
+// result along with the error.
+func Deploy(ctx context.Context, c *kube.Client, manifest []byte, opts Options) (*Result, error) {
+	result := &Result{Environment: opts.Environment, Resources: []string{}, Warnings: CapacityWarnings(manifest, opts.Capacity)}
+	if opts.Environment != "" {
+		ns, err := EnsureEnvironment(ctx, c, opts.Environment)
+		if err != nil {
+			return result, err
+		}
+		result.Namespace = ns
+	}
+	applied, err := c.Apply(ctx, manifest, kube.ApplyOptions{Namespace: result.Namespace})
+	for _, obj := range applied {
+		result.Resources = append(result.Resources, kube.ObjectName(obj))
+	}
+	if err != nil {
+		return result, fmt.Errorf("apply failed: %w", err)
+	}
+	return result, nil
+}
+
+// CapacityWarnings checks the resource requests of manifest against the
+// cluster's free capacity. Deploying goes ahead either way: the pods that
+// don't fit stay Pending until room is made.
+func CapacityWarnings(manifest []byte, capacity *devstaging.Capacity) []string {
+	if capacity == nil {
+		return nil
+	}
+	targets, isWorkflow, _ := devstaging.ParseTargets(manifest)
+	if isWorkflow {
+		return nil
+	}
+	var warnings []string
+	for _, f := range devstaging.CheckCapacity(targets, capacity) {
+		detail := f.Detail
+		if f.Resource != "" {
+			detail = f.Resource + ": " + detail
+		}
+		warnings = append(warnings, detail)
+	}
+	return warnings
+}
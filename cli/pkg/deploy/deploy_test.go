@@ -0,0 +1,146 @@
+package deploy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+	"github.com/jeffvincent/kindling/cli/pkg/kube"
+)
+
+const testManifest = `apiVersion: apps.example.com/v1alpha1
+kind: DevStagingEnvironment
+metadata:
+  name: orders
+spec:
+  deployment:
+    image: orders:dev
+    port: 8080
+    replicas: 2
+    resources:
+      cpuRequest: "1"
+      memoryRequest: 512Mi
+  service:
+    port: 80
+`
+
+// fakeCluster returns a Client over fake clients that answer each
+// server-side apply with the applied object, and the objects applied,
+// keyed by namespace/name.
+func fakeCluster(objects ...runtime.Object) (*kube.Client, map[string]*unstructured.Unstructured) {
+	clientset := fake.NewClientset(objects...)
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "namespaces", SingularName: "namespace", Kind: "Namespace", Verbs: []string{"get", "patch"}},
+			{Name: "secrets", SingularName: "secret", Namespaced: true, Kind: "Secret", Verbs: []string{"get", "patch"}},
+		}},
+		{GroupVersion: "apps.example.com/v1alpha1", APIResources: []metav1.APIResource{
+			{Name: "devstagingenvironments", SingularName: "devstagingenvironment", Namespaced: true, Kind: "DevStagingEnvironment", Verbs: []string{"get", "patch"}},
+		}},
+	}
+	dyn := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	applied := map[string]*unstructured.Unstructured{}
+	dyn.PrependReactor("patch", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patch := action.(clienttesting.PatchActionImpl)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(patch.GetPatch()); err != nil {
+			return true, nil, err
+		}
+		applied[patch.GetNamespace()+"/"+patch.GetName()] = obj
+		return true, obj, nil
+	})
+	return kube.NewFromClients(clientset, dyn, "default"), applied
+}
+
+func TestNamespace(t *testing.T) {
+	for env, want := range map[string]string{"": "default", "default": "default", "feature-x": "env-feature-x"} {
+		if got := Namespace(env); got != want {
+			t.Errorf("Namespace(%q) = %q, want %q", env, got, want)
+		}
+	}
+}
+
+func TestSecretName(t *testing.T) {
+	if got := SecretName("STRIPE_API_KEY"); got != "kindling-secret-stripe-api-key" {
+		t.Errorf("SecretName = %q, want kindling-secret-stripe-api-key", got)
+	}
+}
+
+func TestDeployIntoEnvironment(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "kindling-secret-stripe", Namespace: SecretsNamespace,
+			Labels: map[string]string{"app.kubernetes.io/managed-by": "kindling"}},
+		Data: map[string][]byte{"value": []byte("sk_test")},
+	}
+	c, applied := fakeCluster(secret)
+
+	result, err := Deploy(context.Background(), c, []byte(testManifest), Options{Environment: "feature-x"})
+	if err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+	if result.Namespace != "env-feature-x" || len(result.Resources) != 1 ||
+		result.Resources[0] != "devstagingenvironment.apps.example.com/orders" {
+		t.Errorf("Deploy = %+v, want the environment applied into env-feature-x", result)
+	}
+
+	ns, ok := applied["/env-feature-x"]
+	if !ok {
+		t.Fatal("the environment's namespace wasn't created")
+	}
+	if ns.GetLabels()[EnvironmentLabel] != "feature-x" {
+		t.Errorf("namespace labels = %v, want %s=feature-x", ns.GetLabels(), EnvironmentLabel)
+	}
+	copied, ok := applied["env-feature-x/kindling-secret-stripe"]
+	if !ok {
+		t.Fatal("the kindling secret wasn't copied into the environment")
+	}
+	if value, _, _ := unstructured.NestedString(copied.Object, "data", "value"); value != "c2tfdGVzdA==" {
+		t.Errorf("copied secret data.value = %q, want the base64 of the original", value)
+	}
+	if _, ok := applied["env-feature-x/orders"]; !ok {
+		t.Errorf("applied %v, want env-feature-x/orders", applied)
+	}
+}
+
+func TestDeployIntoContextNamespace(t *testing.T) {
+	c, applied := fakeCluster()
+	result, err := Deploy(context.Background(), c, []byte(testManifest), Options{})
+	if err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+	if result.Namespace != "" || len(applied) != 1 {
+		t.Errorf("Deploy = %+v, applied %v; want only the environment, in the context's namespace", result, applied)
+	}
+	if _, ok := applied["default/orders"]; !ok {
+		t.Errorf("applied %v, want default/orders", applied)
+	}
+}
+
+func TestCapacityWarnings(t *testing.T) {
+	if w := CapacityWarnings([]byte(testManifest), nil); w != nil {
+		t.Errorf("CapacityWarnings without a capacity = %v, want none", w)
+	}
+	roomy := &devstaging.Capacity{Cluster: "dev", Nodes: 1, CPUMilli: 8000, MemBytes: 16e9, LargestCPU: 8000, LargestMem: 16e9}
+	if w := CapacityWarnings([]byte(testManifest), roomy); len(w) != 0 {
+		t.Errorf("CapacityWarnings on a roomy cluster = %v, want none", w)
+	}
+	tight := &devstaging.Capacity{Cluster: "dev", Nodes: 1, CPUMilli: 1500, MemBytes: 16e9, LargestCPU: 1500, LargestMem: 16e9}
+	w := CapacityWarnings([]byte(testManifest), tight)
+	if len(w) != 1 || !strings.Contains(w[0], "requests 2 CPU") {
+		t.Errorf("CapacityWarnings for two 1-CPU replicas on 1.5 CPUs = %v, want one insufficient capacity warning", w)
+	}
+}
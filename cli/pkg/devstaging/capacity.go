@@ -0,0 +1,196 @@
+package devstaging
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ── Cluster capacity ────────────────────────────────────────────
+//
+// The capacity check adds up the CPU and memory a manifest requests and
+// compares it with what the Kind cluster can still schedule: the
+// allocatable capacity of its schedulable nodes, minus what the pods that
+// aren't environments (ingress, operator, cert-manager, runners, ...)
+// already request. Kind nodes share the host, so each one reports the
+// host's resources — more workers is how the scheduler gets more room.
+
+// Capacity is what a Kind cluster can schedule, in millicores and bytes.
+type Capacity struct {
+	Cluster                 string // the Kind cluster's name
+	Nodes                   int
+	CPUMilli                int64 // allocatable, summed over schedulable nodes
+	MemBytes                int64
+	LargestCPU              int64 // allocatable of the largest node
+	LargestMem              int64
+	ReservedCPU             int64 // requested by pods that aren't environments
+	ReservedMem             int64
+	ControlPlaneSchedulable bool // the control plane takes pods too
+}
+
+// Request is a CPU and memory request in millicores and bytes.
+type Request struct {
+	CPUMilli int64
+	MemBytes int64
+}
+
+// Add adds o to r.
+func (r *Request) Add(o Request) {
+	r.CPUMilli += o.CPUMilli
+	r.MemBytes += o.MemBytes
+}
+
+// ContainerRequest is what the scheduler reserves for a container: its
+// request, or its limit when only a limit is set.
+func ContainerRequest(cpuRequest, cpuLimit, memRequest, memLimit string) Request {
+	if cpuRequest == "" {
+		cpuRequest = cpuLimit
+	}
+	if memRequest == "" {
+		memRequest = memLimit
+	}
+	cpu, _ := ParseQuantity(cpuRequest)
+	mem, _ := ParseQuantity(memRequest)
+	return Request{CPUMilli: int64(math.Ceil(cpu * 1000)), MemBytes: int64(mem)}
+}
+
+// manifestRequest reads a manifest resources block in either version's
+// shape: flat cpuRequest/memoryRequest/... fields (v1alpha1) or requests
+// and limits maps (v1beta1).
+func manifestRequest(res map[string]interface{}) Request {
+	field := func(m map[string]interface{}, key string) string {
+		if v, ok := m[key]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+	requests, _ := res["requests"].(map[string]interface{})
+	limits, _ := res["limits"].(map[string]interface{})
+	if requests != nil || limits != nil {
+		return ContainerRequest(field(requests, "cpu"), field(limits, "cpu"), field(requests, "memory"), field(limits, "memory"))
+	}
+	return ContainerRequest(field(res, "cpuRequest"), field(res, "cpuLimit"), field(res, "memoryRequest"), field(res, "memoryLimit"))
+}
+
+// CheckCapacity returns the insufficient_capacity warnings for targets:
+// the manifest requests more than the cluster has free, or a single pod
+// is larger than any node.
+func CheckCapacity(targets []Target, capacity *Capacity) []Finding {
+	var findings []Finding
+	checkCapacity(targets, capacity, collect(&findings))
+	return findings
+}
+
+func checkCapacity(targets []Target, capacity *Capacity, add addFinding) {
+	if capacity == nil {
+		return
+	}
+	var total Request
+	for _, t := range targets {
+		app := manifestRequest(t.Manifest.Spec.Deployment.Resources)
+		replicas := 1
+		if r := t.Manifest.Spec.Deployment.Replicas; r != nil && *r > 0 {
+			replicas = *r
+		}
+		checkPodFits(t.Name, "the app", app, capacity, add)
+		for i := 0; i < replicas; i++ {
+			total.Add(app)
+		}
+		for _, dp := range t.Manifest.Spec.Dependencies {
+			dep := manifestRequest(dp.Resources)
+			checkPodFits(t.Name, dp.Type, dep, capacity, add)
+			total.Add(dep)
+		}
+	}
+	if total.CPUMilli == 0 && total.MemBytes == 0 {
+		return
+	}
+
+	freeCPU := capacity.CPUMilli - capacity.ReservedCPU
+	freeMem := capacity.MemBytes - capacity.ReservedMem
+	if total.CPUMilli <= freeCPU && total.MemBytes <= freeMem {
+		return
+	}
+	add(SeverityWarning, "insufficient_capacity", "", fmt.Sprintf(
+		"the manifest requests %s CPU and %s memory, but cluster %q has %s CPU and %s free across %d node(s) — pods will stay Pending; recreate it with more nodes: kindling destroy && kindling init --workers %d",
+		FormatMilliCPU(total.CPUMilli), FormatBytes(total.MemBytes), capacity.Cluster,
+		FormatMilliCPU(max(freeCPU, 0)), FormatBytes(max(freeMem, 0)), capacity.Nodes, capacity.workersFor(total)))
+}
+
+// checkPodFits reports a pod that no node is big enough to run.
+func checkPodFits(resource, what string, r Request, capacity *Capacity, add addFinding) {
+	switch {
+	case r.CPUMilli > capacity.LargestCPU:
+		add(SeverityWarning, "insufficient_capacity", resource, fmt.Sprintf("%s requests %s CPU, more than any node has (%s) — it can never be scheduled",
+			what, FormatMilliCPU(r.CPUMilli), FormatMilliCPU(capacity.LargestCPU)))
+	case r.MemBytes > capacity.LargestMem:
+		add(SeverityWarning, "insufficient_capacity", resource, fmt.Sprintf("%s requests %s memory, more than any node has (%s) — it can never be scheduled",
+			what, FormatBytes(r.MemBytes), FormatBytes(capacity.LargestMem)))
+	}
+}
+
+// workersFor estimates the workers needed to fit the request next to
+// what is already reserved, counting each node as an average one. With
+// workers, Kind keeps pods off the control plane.
+func (c *Capacity) workersFor(r Request) int {
+	perCPU := float64(c.CPUMilli) / float64(c.Nodes)
+	perMem := float64(c.MemBytes) / float64(c.Nodes)
+	need := math.Max(float64(r.CPUMilli+c.ReservedCPU)/perCPU, float64(r.MemBytes+c.ReservedMem)/perMem)
+	workers := int(math.Ceil(need))
+	current := c.Nodes
+	if c.ControlPlaneSchedulable {
+		current-- // the control plane stops taking pods once there are workers
+	}
+	return max(workers, current+1)
+}
+
+// ParseQuantity parses a Kubernetes quantity ("500m", "2", "128Mi",
+// "1G", "1.5e9") into base units: cores or bytes.
+func ParseQuantity(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	suffixes := []struct {
+		suffix string
+		factor float64
+	}{
+		{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40}, {"Pi", 1 << 50}, {"Ei", 1 << 60},
+		{"n", 1e-9}, {"u", 1e-6}, {"m", 1e-3},
+		{"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12}, {"P", 1e15}, {"E", 1e18},
+	}
+	for _, sf := range suffixes {
+		if num, ok := strings.CutSuffix(s, sf.suffix); ok {
+			v, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid quantity %q", s)
+			}
+			return v * sf.factor, nil
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	return v, nil
+}
+
+// FormatMilliCPU prints millicores as cores, e.g. 2500 → "2.5".
+func FormatMilliCPU(m int64) string {
+	return strconv.FormatFloat(float64(m)/1000, 'f', -1, 64)
+}
+
+// FormatBytes prints n in decimal units, as kindling's commands do.
+func FormatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
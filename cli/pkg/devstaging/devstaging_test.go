@@ -0,0 +1,158 @@
+package devstaging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testManifest = `apiVersion: apps.example.com/v1alpha1
+kind: DevStagingEnvironment
+metadata:
+  name: orders
+spec:
+  deployment:
+    image: orders:1.4.2
+    port: 8080
+    healthCheck:
+      path: /healthz
+  service:
+    port: 8080
+`
+
+// hasFinding reports whether report has a finding of check at severity.
+func hasFinding(report Report, severity, check string) bool {
+	for _, f := range report.Findings {
+		if f.Severity == severity && f.Check == check {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidate(t *testing.T) {
+	report := Validate([]byte(testManifest), Options{})
+	if !report.Valid || report.Kind != "manifest" || len(report.Resources) != 1 {
+		t.Errorf("Validate = %+v, want one valid manifest", report)
+	}
+
+	duplicate := Validate([]byte(testManifest+"---\n"+testManifest), Options{})
+	if duplicate.Valid || !hasFinding(duplicate, SeverityError, "duplicate_name") {
+		t.Errorf("Validate of the same environment twice = %+v, want a duplicate_name error", duplicate.Findings)
+	}
+
+	empty := Validate([]byte("# nothing here\n"), Options{})
+	if empty.Valid || !hasFinding(empty, SeverityError, "schema") {
+		t.Errorf("Validate of an empty file = %+v, want a schema error", empty.Findings)
+	}
+}
+
+func TestValidatePolicies(t *testing.T) {
+	dir := t.TempDir()
+	policies := `name: no-unpinned-images
+action: deny
+validations:
+  - expression: "object.spec.deployment.image.matches(':[0-9]+[.][0-9]+[.][0-9]+$')"
+    messageExpression: "'image ' + object.spec.deployment.image + ' is not a release'"
+    fieldPath: spec.deployment.image
+---
+name: single-replica
+action: warn
+validations:
+  - expression: "object.spec.deployment.replicas == 1"
+`
+	if err := os.WriteFile(filepath.Join(dir, "platform.yaml"), []byte(policies), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if report := Validate([]byte(testManifest), Options{PoliciesDir: dir}); !report.Valid || report.Warnings != 0 {
+		t.Errorf("Validate of a pinned, defaulted manifest = %+v, want it to pass both policies", report.Findings)
+	}
+
+	unpinned := strings.Replace(testManifest, "orders:1.4.2", "orders:latest", 1)
+	report := Validate([]byte(unpinned), Options{PoliciesDir: dir})
+	if report.Valid || len(report.Findings) != 1 ||
+		report.Findings[0].Detail != "spec.deployment.image: image orders:latest is not a release (policy no-unpinned-images)" {
+		t.Errorf("Validate of an unpinned image = %+v, want the deny policy's message", report.Findings)
+	}
+}
+
+func TestDecodeEnvironment(t *testing.T) {
+	beta := `apiVersion: apps.example.com/v1beta1
+kind: DevStagingEnvironment
+metadata:
+  name: orders
+spec:
+  deployment:
+    image: localhost:5001/orders:dev
+    port: 8080
+    resources:
+      limits:
+        memory: 64Mi
+  service:
+    port: 80
+`
+	cr, err := DecodeEnvironment([]byte(beta))
+	if err != nil {
+		t.Fatalf("DecodeEnvironment: %v", err)
+	}
+	if r := cr.Spec.Deployment.Resources; r == nil || r.MemoryLimit == nil || r.MemoryLimit.String() != "64Mi" {
+		t.Errorf("resources = %+v, want the v1beta1 limit as memoryLimit", r)
+	}
+
+	if _, err := DecodeEnvironment([]byte("apiVersion: apps.example.com/v2\nkind: DevStagingEnvironment\n")); err == nil {
+		t.Error("DecodeEnvironment of an unknown apiVersion succeeded")
+	}
+}
+
+func TestMappingValue(t *testing.T) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(testManifest), &doc); err != nil {
+		t.Fatal(err)
+	}
+	root := doc.Content[0]
+	if name := MappingValue(MappingValue(root, "metadata"), "name"); name == nil || name.Value != "orders" {
+		t.Errorf("metadata.name = %v, want orders", name)
+	}
+	if v := MappingValue(root, "status"); v != nil {
+		t.Errorf("MappingValue of a missing key = %v, want nil", v)
+	}
+	if v := MappingValue(MappingValue(root, "kind"), "name"); v != nil {
+		t.Errorf("MappingValue of a scalar = %v, want nil", v)
+	}
+}
+
+func TestQuantities(t *testing.T) {
+	for in, want := range map[string]float64{"500m": 0.5, "2": 2, "128Mi": 128 << 20, "1G": 1e9, "1.5e9": 1.5e9} {
+		if got, err := ParseQuantity(in); err != nil || got != want {
+			t.Errorf("ParseQuantity(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseQuantity("lots"); err == nil {
+		t.Error(`ParseQuantity("lots") succeeded`)
+	}
+
+	for n, want := range map[int64]string{999: "999 B", 1500: "1.5 KB", 2_500_000_000: "2.5 GB"} {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+	if got := FormatMilliCPU(1500); got != "1.5" {
+		t.Errorf("FormatMilliCPU(1500) = %q, want 1.5", got)
+	}
+}
+
+func TestCheckCapacity(t *testing.T) {
+	targets, _, _ := ParseTargets([]byte(strings.Replace(testManifest, "    port: 8080\n    healthCheck",
+		"    port: 8080\n    resources:\n      cpuRequest: \"4\"\n    healthCheck", 1)))
+	capacity := &Capacity{Cluster: "dev", Nodes: 1, CPUMilli: 2000, MemBytes: 8e9, LargestCPU: 2000, LargestMem: 8e9}
+	findings := CheckCapacity(targets, capacity)
+	if len(findings) != 2 {
+		t.Fatalf("CheckCapacity = %+v, want the pod too big for any node and the total too big for the cluster", findings)
+	}
+	if !strings.Contains(findings[0].Detail, "can never be scheduled") || !strings.Contains(findings[1].Detail, "--workers 2") {
+		t.Errorf("CheckCapacity = %+v", findings)
+	}
+}
@@ -0,0 +1,271 @@
+// Package devstaging models the DevStagingEnvironment resource the
+// kindling operator reconciles, and checks manifests and kindling
+// workflows against it without a cluster: the CRD's schema, the
+// operator's dependency conventions, the capacity of a Kind cluster, and
+// the CEL and Rego policies of a project.
+//
+// The types mirror the CRD, so a manifest can be built in Go and
+// marshalled with gopkg.in/yaml.v3, or read from YAML and inspected:
+//
+//	report := devstaging.Validate(data, devstaging.Options{RepoPath: "."})
+//	if !report.Valid {
+//		for _, f := range report.Findings {
+//			fmt.Println(f.Severity, f.Resource, f.Detail)
+//		}
+//	}
+package devstaging
+
+// The API versions of the DevStagingEnvironment CRD.
+const (
+	APIVersionV1alpha1 = "apps.example.com/v1alpha1"
+	APIVersionV1beta1  = "apps.example.com/v1beta1"
+)
+
+// V1alpha1ResourceFields maps each flat v1alpha1 resource field to its
+// v1beta1 list and resource name.
+var V1alpha1ResourceFields = map[string][2]string{
+	"cpuRequest":    {"requests", "cpu"},
+	"memoryRequest": {"requests", "memory"},
+	"cpuLimit":      {"limits", "cpu"},
+	"memoryLimit":   {"limits", "memory"},
+}
+
+// Manifest mirrors the DevStagingEnvironment schema. Validate decodes it
+// strictly, so unknown fields surface as schema errors.
+type Manifest struct {
+	APIVersion string                 `yaml:"apiVersion"`
+	Kind       string                 `yaml:"kind"`
+	Metadata   map[string]interface{} `yaml:"metadata"`
+	Spec       Spec                   `yaml:"spec"`
+	Status     map[string]interface{} `yaml:"status,omitempty"`
+}
+
+type Spec struct {
+	Deployment   Deployment   `yaml:"deployment"`
+	Service      Service      `yaml:"service"`
+	Ingress      *Ingress     `yaml:"ingress,omitempty"`
+	Canary       *Canary      `yaml:"canary,omitempty"`
+	Dependencies []Dependency `yaml:"dependencies,omitempty"`
+	Jobs         []Job        `yaml:"jobs,omitempty"`
+	DependsOn    []string     `yaml:"dependsOn,omitempty"`
+
+	NetworkPolicies string         `yaml:"networkPolicies,omitempty"`
+	Observability   *Observability `yaml:"observability,omitempty"`
+	Paused          bool           `yaml:"paused,omitempty"`
+	AutoSleep       *AutoSleep     `yaml:"autoSleep,omitempty"`
+}
+
+type AutoSleep struct {
+	IdleMinutes *int `yaml:"idleMinutes,omitempty"`
+}
+
+type Observability struct {
+	Tracing         bool   `yaml:"tracing,omitempty"`
+	Instrumentation string `yaml:"instrumentation,omitempty"`
+}
+
+type Deployment struct {
+	Replicas     *int                   `yaml:"replicas,omitempty"`
+	Image        string                 `yaml:"image"`
+	PullPolicy   string                 `yaml:"imagePullPolicy,omitempty"`
+	Port         int                    `yaml:"port"`
+	Command      []string               `yaml:"command,omitempty"`
+	Args         []string               `yaml:"args,omitempty"`
+	Env          []EnvVar               `yaml:"env,omitempty"`
+	EnvFrom      []EnvFrom              `yaml:"envFrom,omitempty"`
+	Resources    map[string]interface{} `yaml:"resources,omitempty"`
+	HealthCheck  *HealthCheck           `yaml:"healthCheck,omitempty"`
+	NodeSelector map[string]string      `yaml:"nodeSelector,omitempty"`
+	Affinity     map[string]interface{} `yaml:"affinity,omitempty"`
+	Schedule     string                 `yaml:"schedule,omitempty"`
+
+	InitContainers []InitContainer `yaml:"initContainers,omitempty"`
+	Sidecars       []Sidecar       `yaml:"sidecars,omitempty"`
+	Volumes        []Volume        `yaml:"volumes,omitempty"`
+}
+
+type Volume struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	Size      string `yaml:"size,omitempty"`
+	HostPath  string `yaml:"hostPath,omitempty"`
+}
+
+type InitContainer struct {
+	Name    string   `yaml:"name"`
+	Image   string   `yaml:"image,omitempty"`
+	Command []string `yaml:"command,omitempty"`
+	Args    []string `yaml:"args,omitempty"`
+	Env     []EnvVar `yaml:"env,omitempty"`
+}
+
+type Sidecar struct {
+	Name    string   `yaml:"name"`
+	Image   string   `yaml:"image"`
+	Command []string `yaml:"command,omitempty"`
+	Args    []string `yaml:"args,omitempty"`
+	Env     []EnvVar `yaml:"env,omitempty"`
+}
+
+type Job struct {
+	Name         string    `yaml:"name"`
+	Image        string    `yaml:"image,omitempty"`
+	Command      []string  `yaml:"command,omitempty"`
+	Args         []string  `yaml:"args,omitempty"`
+	Env          []EnvVar  `yaml:"env,omitempty"`
+	EnvFrom      []EnvFrom `yaml:"envFrom,omitempty"`
+	BackoffLimit *int      `yaml:"backoffLimit,omitempty"`
+}
+
+type EnvVar struct {
+	Name      string                 `yaml:"name"`
+	Value     string                 `yaml:"value,omitempty"`
+	ValueFrom map[string]interface{} `yaml:"valueFrom,omitempty"`
+}
+
+type EnvFrom struct {
+	Prefix       string                 `yaml:"prefix,omitempty"`
+	SecretRef    map[string]interface{} `yaml:"secretRef,omitempty"`
+	ConfigMapRef map[string]interface{} `yaml:"configMapRef,omitempty"`
+}
+
+type HealthCheck struct {
+	Type                string `yaml:"type,omitempty"`
+	Path                string `yaml:"path,omitempty"`
+	Port                *int   `yaml:"port,omitempty"`
+	InitialDelaySeconds *int   `yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       *int   `yaml:"periodSeconds,omitempty"`
+}
+
+type Service struct {
+	Port       int    `yaml:"port"`
+	TargetPort *int   `yaml:"targetPort,omitempty"`
+	NodePort   *int   `yaml:"nodePort,omitempty"`
+	Type       string `yaml:"type,omitempty"`
+}
+
+type Ingress struct {
+	Enabled          bool                   `yaml:"enabled,omitempty"`
+	Host             string                 `yaml:"host,omitempty"`
+	Path             string                 `yaml:"path,omitempty"`
+	PathType         string                 `yaml:"pathType,omitempty"`
+	Protocol         string                 `yaml:"protocol,omitempty"`
+	IngressClassName string                 `yaml:"ingressClassName,omitempty"`
+	TLS              map[string]interface{} `yaml:"tls,omitempty"`
+	Tunnel           bool                   `yaml:"tunnel,omitempty"`
+	Annotations      map[string]string      `yaml:"annotations,omitempty"`
+}
+
+type Canary struct {
+	Image       string   `yaml:"image"`
+	Replicas    *int     `yaml:"replicas,omitempty"`
+	Weight      int      `yaml:"weight,omitempty"`
+	Header      string   `yaml:"header,omitempty"`
+	HeaderValue string   `yaml:"headerValue,omitempty"`
+	Env         []EnvVar `yaml:"env,omitempty"`
+}
+
+type Dependency struct {
+	Type         string                 `yaml:"type"`
+	Version      string                 `yaml:"version,omitempty"`
+	Image        string                 `yaml:"image,omitempty"`
+	Port         *int                   `yaml:"port,omitempty"`
+	Env          []EnvVar               `yaml:"env,omitempty"`
+	EnvFrom      []EnvFrom              `yaml:"envFrom,omitempty"`
+	EnvVarName   string                 `yaml:"envVarName,omitempty"`
+	StorageSize  string                 `yaml:"storageSize,omitempty"`
+	Resources    map[string]interface{} `yaml:"resources,omitempty"`
+	NodeSelector map[string]string      `yaml:"nodeSelector,omitempty"`
+	Affinity     map[string]interface{} `yaml:"affinity,omitempty"`
+	Seed         *Seed                  `yaml:"seed,omitempty"`
+}
+
+type Seed struct {
+	ConfigMap    string   `yaml:"configMap,omitempty"`
+	Image        string   `yaml:"image,omitempty"`
+	Command      []string `yaml:"command,omitempty"`
+	Args         []string `yaml:"args,omitempty"`
+	BackoffLimit *int     `yaml:"backoffLimit,omitempty"`
+}
+
+// seedLoaderTypes are the dependency types whose seed files the operator
+// can apply without a command.
+var seedLoaderTypes = map[string]bool{"postgres": true, "mysql": true, "mongodb": true, "redis": true}
+
+// DependencyConvention is the operator's default port, injected
+// connection variable, and image (without tag) for a dependency type.
+type DependencyConvention struct {
+	Port   int
+	EnvVar string
+	Image  string
+}
+
+// DependencyConventions matches the operator's dependency registry.
+var DependencyConventions = map[string]DependencyConvention{
+	"postgres":      {5432, "DATABASE_URL", "postgres"},
+	"redis":         {6379, "REDIS_URL", "redis"},
+	"mysql":         {3306, "DATABASE_URL", "mysql"},
+	"mongodb":       {27017, "MONGO_URL", "mongo"},
+	"rabbitmq":      {5672, "AMQP_URL", "rabbitmq"},
+	"minio":         {9000, "S3_ENDPOINT", "minio/minio"},
+	"elasticsearch": {9200, "ELASTICSEARCH_URL", "docker.elastic.co/elasticsearch/elasticsearch"},
+	"kafka":         {9092, "KAFKA_BROKER_URL", "apache/kafka"},
+	"nats":          {4222, "NATS_URL", "nats"},
+	"memcached":     {11211, "MEMCACHED_URL", "memcached"},
+	"cassandra":     {9042, "CASSANDRA_URL", "cassandra"},
+	"consul":        {8500, "CONSUL_HTTP_ADDR", "hashicorp/consul"},
+	"vault":         {8200, "VAULT_ADDR", "hashicorp/vault"},
+	"influxdb":      {8086, "INFLUXDB_URL", "influxdb"},
+	"jaeger":        {16686, "JAEGER_ENDPOINT", "jaegertracing/all-in-one"},
+}
+
+// ManagedEnvVars are the env vars the operator's dependency system sets:
+// connection URLs and the dependencies' default credentials.
+var ManagedEnvVars = map[string]bool{
+	// Connection URLs (auto-injected when dependency is declared)
+	"DATABASE_URL":      true,
+	"REDIS_URL":         true,
+	"MONGO_URL":         true,
+	"MONGODB_URI":       true,
+	"MONGODB_URL":       true,
+	"AMQP_URL":          true,
+	"RABBITMQ_URL":      true,
+	"KAFKA_BROKER_URL":  true,
+	"KAFKA_BROKERS":     true,
+	"ELASTICSEARCH_URL": true,
+	"S3_ENDPOINT":       true,
+	"NATS_URL":          true,
+	"MEMCACHED_URL":     true,
+	"CASSANDRA_URL":     true,
+	"CONSUL_HTTP_ADDR":  true,
+	"VAULT_ADDR":        true,
+	"INFLUXDB_URL":      true,
+	"JAEGER_ENDPOINT":   true,
+	// Dependency credentials (managed by operator defaults)
+	"POSTGRES_PASSWORD":          true,
+	"POSTGRES_USER":              true,
+	"POSTGRES_DB":                true,
+	"DATABASE_PASSWORD":          true,
+	"MYSQL_PASSWORD":             true,
+	"MYSQL_ROOT_PASSWORD":        true,
+	"MYSQL_USER":                 true,
+	"MYSQL_DATABASE":             true,
+	"REDIS_PASSWORD":             true,
+	"MONGO_INITDB_ROOT_USERNAME": true,
+	"MONGO_INITDB_ROOT_PASSWORD": true,
+}
+
+// InjectedEnvVars returns the variables the operator sets on a component
+// with these dependency types, connection URLs and credentials alike.
+func InjectedEnvVars(depTypes []string) map[string]bool {
+	names := map[string]bool{}
+	for name := range ManagedEnvVars {
+		names[name] = true
+	}
+	for _, t := range depTypes {
+		if conv, ok := DependencyConventions[t]; ok {
+			names[conv.EnvVar] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,197 @@
+package devstaging
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/jeffvincent/kindling/api/v1alpha1"
+	"github.com/jeffvincent/kindling/api/v1beta1"
+	"github.com/jeffvincent/kindling/pkg/policy"
+)
+
+// ── Policies ────────────────────────────────────────────────────
+//
+// Platform admins keep rules every environment must follow in
+// .kindling/policies/*.yaml. Validate finds and reads them, and
+// evaluates them with the operator's evaluator (pkg/policy) over the
+// object its admission webhook evaluates them over, so a manifest that
+// passes here isn't denied at admission.
+
+// PoliciesDirName is the policies directory inside .kindling/.
+const PoliciesDirName = "policies"
+
+// FindPoliciesDir returns the .kindling/policies directory of the
+// project dir is in: dir's, or that of the nearest parent up to the
+// repository root. It returns "" when there is none.
+func FindPoliciesDir(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		candidate := filepath.Join(dir, ".kindling", PoliciesDirName)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// PolicyFiles returns the .yaml and .yml files of dir, by name.
+func PolicyFiles(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if ext := filepath.Ext(e.Name()); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[e.Name()] = string(data)
+	}
+	return files, nil
+}
+
+// DecodeEnvironment reads a DevStagingEnvironment manifest of either
+// API version into the v1alpha1 hub, converting a v1beta1 one with the
+// conversion the operator's conversion webhook runs.
+func DecodeEnvironment(data []byte) (*v1alpha1.DevStagingEnvironment, error) {
+	var meta metav1.TypeMeta
+	if err := sigsyaml.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	cr := &v1alpha1.DevStagingEnvironment{}
+	switch meta.APIVersion {
+	case APIVersionV1beta1:
+		beta := &v1beta1.DevStagingEnvironment{}
+		if err := sigsyaml.Unmarshal(data, beta); err != nil {
+			return nil, err
+		}
+		if err := beta.ConvertTo(cr); err != nil {
+			return nil, err
+		}
+	case APIVersionV1alpha1, "":
+		if err := sigsyaml.Unmarshal(data, cr); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown apiVersion %q", meta.APIVersion)
+	}
+	return cr, nil
+}
+
+// DockerfileBaseImages returns the images the Dockerfile at path builds
+// FROM, leaving out earlier stages, or nil when it can't be read.
+func DockerfileBaseImages(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	images := []string{}
+	stages := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+			continue
+		}
+		args := fields[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if !stages[strings.ToLower(args[0])] {
+			images = append(images, args[0])
+		}
+		if len(args) >= 3 && strings.EqualFold(args[1], "AS") {
+			stages[strings.ToLower(args[2])] = true
+		}
+	}
+	return images
+}
+
+// checkPolicies evaluates the policies in dir, or those of the project at
+// repoPath when dir is "", against each target: a deny policy's
+// violations are errors, a warn policy's warnings.
+func checkPolicies(targets []Target, repoPath, dir string, add addFinding) {
+	if dir == "" {
+		if dir = FindPoliciesDir(repoPath); dir == "" {
+			return
+		}
+	}
+	files, err := PolicyFiles(dir)
+	if err != nil {
+		add(SeverityError, "policy", "", fmt.Sprintf("cannot read the policies: %v", err))
+		return
+	}
+	policies, err := policy.Load(files)
+	if err != nil {
+		add(SeverityError, "policy", "", err.Error())
+		return
+	}
+	if len(policies) == 0 {
+		return
+	}
+
+	for _, t := range targets {
+		var data []byte
+		var err error
+		if t.Raw != nil {
+			data, err = yaml.Marshal(t.Raw)
+		} else {
+			data, err = yaml.Marshal(t.Manifest)
+		}
+		if err != nil {
+			continue
+		}
+		cr, err := DecodeEnvironment(data)
+		if err != nil {
+			add(SeverityError, "policy", t.Name, fmt.Sprintf("cannot evaluate the policies: %v", err))
+			continue
+		}
+		object, err := policy.Object(cr)
+		if err != nil {
+			add(SeverityError, "policy", t.Name, fmt.Sprintf("cannot evaluate the policies: %v", err))
+			continue
+		}
+		var baseImages []string
+		if path, _ := TargetDockerfile(t, repoPath); path != "" {
+			baseImages = DockerfileBaseImages(path)
+		}
+		for _, p := range policies {
+			for _, v := range p.Evaluate(object, baseImages) {
+				severity := SeverityError
+				if v.Action == policy.ActionWarn {
+					severity = SeverityWarning
+				}
+				add(severity, "policy", t.Name, fmt.Sprintf("%s: %s (policy %s)", v.FieldPath, v.Message, v.Policy))
+			}
+		}
+	}
+}
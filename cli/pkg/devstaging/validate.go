@@ -0,0 +1,1141 @@
+package devstaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+// ── Static analysis ─────────────────────────────────────────────
+//
+// Validate mirrors the DevStagingEnvironment CRD and the operator's
+// dependency conventions locally, so a manifest can be checked without a
+// cluster. Workflows are checked by turning each kindling-deploy step into
+// the DSE the action would apply.
+
+// Finding severities, in decreasing order of importance.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Finding is one problem Validate reports.
+type Finding struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Resource string `json:"resource,omitempty"`
+	Detail   string `json:"detail"`
+}
+
+// Report is the result of validating one file.
+type Report struct {
+	File      string    `json:"file"`
+	Kind      string    `json:"kind"` // "manifest" or "workflow"
+	Resources []string  `json:"resources"`
+	Findings  []Finding `json:"findings"`
+	Errors    int       `json:"errors"`
+	Warnings  int       `json:"warnings"`
+	Valid     bool      `json:"valid"`
+}
+
+// Options tunes the checks of Validate. The zero value checks the
+// document on its own.
+type Options struct {
+	// RepoPath is the repository the document describes, used to find
+	// Dockerfiles, the env vars the code reads, and the policies.
+	RepoPath string
+	// Capacity is what the cluster can schedule; nil skips the capacity
+	// check.
+	Capacity *Capacity
+	// HostMounts are the paths inside the Kind nodes that directories of
+	// the host are mounted at. A hostPath volume outside them is lost
+	// with the cluster.
+	HostMounts []string
+	// PoliciesDir holds the policies to enforce; "" looks for the
+	// .kindling/policies directory of RepoPath's project.
+	PoliciesDir string
+}
+
+// Target is one DSE to check, with the build context it comes from when
+// that is known (workflows only).
+type Target struct {
+	Name         string
+	Manifest     *Manifest
+	BuildContext string // relative to the repo root
+	Dockerfile   string // relative to BuildContext
+	FromWorkflow bool
+	Raw          *yaml.Node // the manifest document; nil for workflows
+}
+
+var (
+	dnsLabelPattern  = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	imageTagPattern  = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	envURLPattern    = regexp.MustCompile(`(?:https?|redis|rediss|mongodb|amqp|grpc|postgres|postgresql|mysql|nats|kafka)://(?:[^@/\s]+@)?([^:/\s]+):(\d+)`)
+	envHostPort      = regexp.MustCompile(`^([a-zA-Z][\w.-]*):(\d+)$`)
+	actorPrefix      = regexp.MustCompile(`\$\{\{\s*github\.actor\s*\}\}-?`)
+	workspacePrefix  = regexp.MustCompile(`\$\{\{\s*github\.workspace\s*\}\}/?`)
+	actionExpression = regexp.MustCompile(`\$\{\{[^}]*\}\}`)
+	yamlTypeSuffix   = regexp.MustCompile(` (?:in|into) (?:type )?devstaging\.\w+`)
+)
+
+// Validate parses data as DSE manifests or a kindling workflow and runs
+// every check.
+func Validate(data []byte, opts Options) Report {
+	report := Report{Kind: "manifest", Resources: []string{}, Findings: []Finding{}}
+	add := func(severity, check, resource, detail string) {
+		report.Findings = append(report.Findings, Finding{
+			Severity: severity, Check: check, Resource: resource, Detail: detail,
+		})
+	}
+
+	targets, isWorkflow := parseTargets(data, add)
+	if isWorkflow {
+		report.Kind = "workflow"
+	}
+	if len(targets) == 0 && len(report.Findings) == 0 {
+		add(SeverityError, "schema", "", "no DevStagingEnvironment found")
+	}
+
+	for _, t := range targets {
+		report.Resources = append(report.Resources, t.Name)
+		checkSchema(t, opts.HostMounts, add)
+		checkDockerfile(t, opts.RepoPath, add)
+		checkHealthCheck(t, add)
+		checkDependencies(t, add)
+	}
+	checkNetworking(targets, add)
+	checkUniqueness(targets, add)
+	checkDependsOn(targets, add)
+	checkEnv(targets, opts.RepoPath, add)
+	checkCapacity(targets, opts.Capacity, add)
+	checkPolicies(targets, opts.RepoPath, opts.PoliciesDir, add)
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return severityRank(report.Findings[i].Severity) < severityRank(report.Findings[j].Severity)
+	})
+	for _, f := range report.Findings {
+		switch f.Severity {
+		case SeverityError:
+			report.Errors++
+		case SeverityWarning:
+			report.Warnings++
+		}
+	}
+	report.Valid = report.Errors == 0
+	return report
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case SeverityError:
+		return 0
+	case SeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+type addFinding func(severity, check, resource, detail string)
+
+// collect returns an addFinding that appends to findings.
+func collect(findings *[]Finding) addFinding {
+	return func(severity, check, resource, detail string) {
+		*findings = append(*findings, Finding{Severity: severity, Check: check, Resource: resource, Detail: detail})
+	}
+}
+
+// ParseTargets decodes every YAML document of data into the DSEs to
+// check, reporting whether data is a workflow. The findings are the
+// documents that aren't valid YAML or don't match the schema.
+func ParseTargets(data []byte) (targets []Target, isWorkflow bool, findings []Finding) {
+	targets, isWorkflow = parseTargets(data, collect(&findings))
+	return targets, isWorkflow, findings
+}
+
+// parseTargets decodes every YAML document. A document with a top-level
+// "jobs" key makes the file a workflow.
+func parseTargets(data []byte, add addFinding) ([]Target, bool) {
+	// A second, strict decoder walks the same documents so fields the CRD
+	// doesn't define are reported with their line in the file.
+	var docs []*yaml.Node
+	var strictErrs []error
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	strict := yaml.NewDecoder(bytes.NewReader(data))
+	strict.KnownFields(true)
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			add(SeverityError, "schema", "", fmt.Sprintf("invalid YAML: %v", err))
+			return nil, false
+		}
+		strictErr := strict.Decode(&Manifest{})
+		if len(doc.Content) > 0 && doc.Content[0].Tag != "!!null" {
+			docs = append(docs, &doc)
+			strictErrs = append(strictErrs, strictErr)
+		}
+	}
+
+	for _, doc := range docs {
+		if MappingValue(doc.Content[0], "jobs") != nil {
+			return workflowTargets(doc, add), true
+		}
+	}
+
+	var targets []Target
+	for i, doc := range docs {
+		var dse Manifest
+		if err := doc.Decode(&dse); err != nil {
+			add(SeverityError, "schema", fmt.Sprintf("document %d", i+1), strings.TrimPrefix(err.Error(), "yaml: "))
+			continue
+		}
+		if dse.Kind != "DevStagingEnvironment" {
+			add(SeverityInfo, "schema", fmt.Sprintf("document %d", i+1), fmt.Sprintf("skipped %s — not a DevStagingEnvironment", dse.Kind))
+			continue
+		}
+		name, _ := dse.Metadata["name"].(string)
+
+		var typeErr *yaml.TypeError
+		if errors.As(strictErrs[i], &typeErr) {
+			for _, msg := range typeErr.Errors {
+				add(SeverityError, "schema", name, yamlTypeSuffix.ReplaceAllString(msg, ""))
+			}
+		}
+		targets = append(targets, Target{Name: name, Manifest: &dse, Raw: doc.Content[0]})
+	}
+	return targets, false
+}
+
+// MappingValue returns the value node for key in a mapping node.
+func MappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// workflowStep is the subset of a GitHub Actions step validate reads.
+type workflowStep struct {
+	Uses string            `yaml:"uses"`
+	With map[string]string `yaml:"with"`
+}
+
+// workflowTargets turns each kindling-deploy step into the DSE the action
+// would apply, and links it to the kindling-build step for its image.
+func workflowTargets(doc *yaml.Node, add addFinding) []Target {
+	var wf struct {
+		Jobs map[string]struct {
+			Steps []workflowStep `yaml:"steps"`
+		} `yaml:"jobs"`
+	}
+	if err := doc.Decode(&wf); err != nil {
+		add(SeverityError, "schema", "", fmt.Sprintf("cannot parse workflow: %v", err))
+		return nil
+	}
+
+	jobs := make([]string, 0, len(wf.Jobs))
+	for name := range wf.Jobs {
+		jobs = append(jobs, name)
+	}
+	sort.Strings(jobs)
+
+	type build struct{ context, dockerfile string }
+	builds := map[string]build{}
+	var deploys []workflowStep
+	for _, job := range jobs {
+		for _, s := range wf.Jobs[job].Steps {
+			switch {
+			case strings.Contains(s.Uses, "kindling-build"):
+				ctx := workspacePrefix.ReplaceAllString(s.With["context"], "")
+				if ctx == "" {
+					ctx = "."
+				}
+				builds[s.With["image"]] = build{context: filepath.Clean(ctx), dockerfile: s.With["dockerfile"]}
+			case strings.Contains(s.Uses, "kindling-deploy"):
+				deploys = append(deploys, s)
+			}
+		}
+	}
+
+	var targets []Target
+	for _, s := range deploys {
+		w := s.With
+		name := actorPrefix.ReplaceAllString(w["name"], "")
+		dse := &Manifest{
+			APIVersion: "apps.example.com/v1alpha1",
+			Kind:       "DevStagingEnvironment",
+			Metadata:   map[string]interface{}{"name": name},
+		}
+		dse.Spec.Deployment.Image = w["image"]
+		dse.Spec.Deployment.Port = parsePortInput(name, "port", w["port"], add)
+		dse.Spec.Service.Port = dse.Spec.Deployment.Port
+		dse.Spec.Service.Type = w["service-type"]
+		if np := w["node-port"]; np != "" {
+			port := parsePortInput(name, "node-port", np, add)
+			dse.Spec.Service.NodePort = &port
+		}
+		if w["tracing"] == "true" {
+			dse.Spec.Observability = &Observability{Tracing: true}
+		}
+		dse.Spec.NetworkPolicies = w["network-policies"]
+
+		// kindling-deploy always writes a healthCheck; the path defaults
+		// to /healthz. An empty path is recorded so the default can be
+		// reported.
+		dse.Spec.Deployment.HealthCheck = &HealthCheck{Type: w["health-check-type"], Path: w["health-check-path"]}
+
+		for input, field := range actionResourceInputs {
+			if v := w[input]; v != "" {
+				if dse.Spec.Deployment.Resources == nil {
+					dse.Spec.Deployment.Resources = map[string]interface{}{}
+				}
+				dse.Spec.Deployment.Resources[field] = v
+			}
+		}
+		if host := w["ingress-host"]; host != "" {
+			dse.Spec.Ingress = &Ingress{Enabled: true, Host: actionExpression.ReplaceAllString(host, "actor"), Protocol: w["ingress-protocol"]}
+		}
+		if env := w["env"]; env != "" {
+			if err := yaml.Unmarshal([]byte(env), &dse.Spec.Deployment.Env); err != nil {
+				add(SeverityError, "schema", name, fmt.Sprintf("env must be a list of {name, value} entries: %v", err))
+			}
+		}
+		if deps := w["dependencies"]; deps != "" {
+			if err := yaml.Unmarshal([]byte(deps), &dse.Spec.Dependencies); err != nil {
+				add(SeverityError, "schema", name, fmt.Sprintf("dependencies must be a list of {type, ...} entries: %v", err))
+			}
+		}
+		upstreams := actorPrefix.ReplaceAllString(w["depends-on"], "")
+		dse.Spec.DependsOn = strings.FieldsFunc(upstreams, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+
+		t := Target{Name: name, Manifest: dse, FromWorkflow: true}
+		if b, ok := builds[w["image"]]; ok {
+			t.BuildContext, t.Dockerfile = b.context, b.dockerfile
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+// checkCanary checks spec.canary, and warns when no Ingress can split
+// traffic to it.
+func checkCanary(t Target, c *Canary, add addFinding) {
+	if c.Image == "" {
+		add(SeverityError, "schema", t.Name, "spec.canary.image is required")
+	}
+	if c.Replicas != nil && *c.Replicas < 1 {
+		add(SeverityError, "schema", t.Name, "spec.canary.replicas must be at least 1")
+	}
+	if c.Weight < 0 || c.Weight > 100 {
+		add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.canary.weight must be 0–100, got %d", c.Weight))
+	}
+	if c.HeaderValue != "" && c.Header == "" {
+		add(SeverityError, "schema", t.Name, "spec.canary.headerValue needs a header to match")
+	}
+	for i, e := range c.Env {
+		if e.Name == "" {
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.canary.env[%d] has no name", i))
+		}
+	}
+	if t.Manifest.Spec.Deployment.Schedule != "" {
+		add(SeverityError, "schema", t.Name, "a scheduled app serves no requests to split — remove spec.deployment.schedule or spec.canary")
+		return
+	}
+	switch ing := t.Manifest.Spec.Ingress; {
+	case ing == nil || !ing.Enabled:
+		add(SeverityWarning, "schema", t.Name, "spec.canary gets no share of requests without spec.ingress — only its Service reaches it")
+	case ing.IngressClassName == "traefik" || ing.IngressClassName == "contour":
+		add(SeverityWarning, "schema", t.Name, fmt.Sprintf("splitting traffic to spec.canary takes ingress-nginx — under %s only its Service reaches it", ing.IngressClassName))
+	}
+}
+
+// checkAutoSleep checks spec.autoSleep, which only requests to an HTTP
+// Ingress without a canary can wake.
+func checkAutoSleep(t Target, s *AutoSleep, add addFinding) {
+	if s.IdleMinutes != nil && *s.IdleMinutes < 1 {
+		add(SeverityError, "schema", t.Name, "spec.autoSleep.idleMinutes must be at least 1")
+	}
+	switch ing := t.Manifest.Spec.Ingress; {
+	case t.Manifest.Spec.Deployment.Schedule != "":
+		add(SeverityError, "schema", t.Name, "a scheduled app serves no requests to wake it — remove spec.deployment.schedule or spec.autoSleep")
+	case ing == nil || !ing.Enabled:
+		add(SeverityError, "schema", t.Name, "only requests to the Ingress wake the environment — enable spec.ingress or remove spec.autoSleep")
+	case ing.Protocol == "grpc":
+		add(SeverityError, "schema", t.Name, "the activator proxies HTTP/1.1 and WebSockets, not gRPC — remove spec.autoSleep")
+	case t.Manifest.Spec.Canary != nil:
+		add(SeverityError, "schema", t.Name, "the canary's share of requests would skip the activator and find no pods — remove spec.canary or spec.autoSleep")
+	}
+}
+
+// actionResourceInputs maps kindling-deploy's resource inputs to the
+// v1alpha1 fields the action writes them to.
+var actionResourceInputs = map[string]string{
+	"cpu-request":    "cpuRequest",
+	"cpu-limit":      "cpuLimit",
+	"memory-request": "memoryRequest",
+	"memory-limit":   "memoryLimit",
+}
+
+func parsePortInput(resource, field, value string, add addFinding) int {
+	if value == "" {
+		return 0
+	}
+	port, err := strconv.Atoi(strings.Trim(value, `"'`))
+	if err != nil {
+		add(SeverityError, "schema", resource, fmt.Sprintf("%s %q is not a number", field, value))
+	}
+	return port
+}
+
+// ── Checks ──────────────────────────────────────────────────────
+
+// checkSchema enforces the CRD's required fields, ranges, and enums.
+func checkSchema(t Target, hostMounts []string, add addFinding) {
+	d := t.Manifest
+	if d.APIVersion != APIVersionV1alpha1 && d.APIVersion != APIVersionV1beta1 {
+		add(SeverityError, "schema", t.Name, fmt.Sprintf("apiVersion must be %s or %s, got %q", APIVersionV1alpha1, APIVersionV1beta1, d.APIVersion))
+	}
+	switch {
+	case t.Name == "":
+		add(SeverityError, "schema", "", "metadata.name is required")
+	case len(t.Name) > 63 || !dnsLabelPattern.MatchString(t.Name):
+		// Dependency Services are named <name>-<type>, so the name must
+		// be a valid DNS label.
+		add(SeverityError, "schema", t.Name, "metadata.name must be a lowercase DNS label (a-z, 0-9, '-', at most 63 characters)")
+	}
+
+	dep := d.Spec.Deployment
+	if dep.Image == "" {
+		add(SeverityError, "schema", t.Name, "spec.deployment.image is required")
+	}
+	if !validPort(dep.Port) {
+		add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.deployment.port must be 1–65535, got %d", dep.Port))
+	}
+	if dep.Replicas != nil && *dep.Replicas < 1 {
+		add(SeverityError, "schema", t.Name, "spec.deployment.replicas must be at least 1")
+	}
+	if dep.Schedule != "" {
+		if !validCronSchedule(dep.Schedule) {
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.deployment.schedule %q is not a cron expression — use five fields, e.g. \"*/5 * * * *\", or a macro such as @hourly", dep.Schedule))
+		}
+		if ing := d.Spec.Ingress; ing != nil && ing.Enabled {
+			add(SeverityWarning, "schema", t.Name, "a scheduled app gets no Service or Ingress — spec.ingress is ignored")
+		}
+		if dep.Replicas != nil && *dep.Replicas > 1 {
+			add(SeverityWarning, "schema", t.Name, "a scheduled app runs one pod at a time — spec.deployment.replicas is ignored")
+		}
+	}
+	for i, e := range dep.Env {
+		if e.Name == "" {
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.deployment.env[%d] has no name", i))
+		}
+	}
+	checkResources(t, "spec.deployment.resources", dep.Resources, add)
+	if hc := dep.HealthCheck; hc != nil {
+		if hc.Type != "" && hc.Type != "http" && hc.Type != "tcp" {
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("healthCheck.type must be http or tcp, got %q", hc.Type))
+		}
+		if hc.Port != nil && !validPort(*hc.Port) {
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("healthCheck.port must be 1–65535, got %d", *hc.Port))
+		}
+	}
+
+	switch dep.PullPolicy {
+	case "", "Always", "IfNotPresent", "Never":
+	default:
+		add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.deployment.imagePullPolicy must be Always, IfNotPresent, or Never, got %q", dep.PullPolicy))
+	}
+
+	if o := d.Spec.Observability; o != nil {
+		switch o.Instrumentation {
+		case "", "java", "nodejs", "python", "dotnet":
+		default:
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.observability.instrumentation must be java, nodejs, python, or dotnet, got %q", o.Instrumentation))
+		}
+		if o.Instrumentation != "" && !o.Tracing {
+			add(SeverityError, "schema", t.Name, "spec.observability.instrumentation needs spec.observability.tracing: true")
+		}
+	}
+	switch d.Spec.NetworkPolicies {
+	case "", "open", "strict":
+	default:
+		add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.networkPolicies must be open or strict, got %q", d.Spec.NetworkPolicies))
+	}
+	if t.Name == "kindling-otel-collector" {
+		add(SeverityError, "schema", t.Name, "metadata.name is the name of the namespace's tracing collector")
+	}
+
+	svc := d.Spec.Service
+	if !t.FromWorkflow && !validPort(svc.Port) {
+		add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.service.port must be 1–65535, got %d", svc.Port))
+	}
+	switch svc.Type {
+	case "", "ClusterIP", "NodePort", "LoadBalancer":
+	default:
+		add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.service.type must be ClusterIP, NodePort, or LoadBalancer, got %q", svc.Type))
+	}
+	if np := svc.NodePort; np != nil {
+		switch {
+		case *np < 30000 || *np > 32767:
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.service.nodePort must be 30000–32767, got %d", *np))
+		case svc.Type != "NodePort" && svc.Type != "LoadBalancer":
+			add(SeverityWarning, "schema", t.Name, "spec.service.nodePort is ignored unless spec.service.type is NodePort or LoadBalancer")
+		}
+	}
+	if svc.TargetPort != nil && *svc.TargetPort != dep.Port {
+		add(SeverityWarning, "port_mismatch", t.Name, fmt.Sprintf("service targetPort %d differs from the container port %d", *svc.TargetPort, dep.Port))
+	}
+
+	if ing := d.Spec.Ingress; ing != nil && ing.Enabled {
+		switch ing.PathType {
+		case "", "Prefix", "Exact", "ImplementationSpecific":
+		default:
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("ingress.pathType must be Prefix, Exact, or ImplementationSpecific, got %q", ing.PathType))
+		}
+		switch ing.Protocol {
+		case "", "http", "grpc", "websocket":
+		default:
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("ingress.protocol must be http, grpc, or websocket, got %q", ing.Protocol))
+		}
+		if ing.Host == "" {
+			add(SeverityWarning, "schema", t.Name, "ingress is enabled without a host — it will match every hostname")
+		}
+	}
+
+	if c := d.Spec.Canary; c != nil {
+		checkCanary(t, c, add)
+	}
+	if s := d.Spec.AutoSleep; s != nil {
+		checkAutoSleep(t, s, add)
+	}
+
+	for i, dp := range d.Spec.Dependencies {
+		if _, ok := DependencyConventions[dp.Type]; !ok {
+			add(SeverityError, "unsatisfiable_dependency", t.Name, fmt.Sprintf("dependencies[%d]: unknown type %q", i, dp.Type))
+		}
+		if dp.Port != nil && !validPort(*dp.Port) {
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("dependencies[%d].port must be 1–65535, got %d", i, *dp.Port))
+		}
+		checkResources(t, fmt.Sprintf("dependencies[%d].resources", i), dp.Resources, add)
+	}
+
+	// Init containers and sidecars share the pod with the dependency
+	// waits; jobs are named <name>-<job>, next to the dependencies'
+	// <name>-<type>.
+	names := map[string]bool{}
+	for _, dp := range d.Spec.Dependencies {
+		names["wait-for-"+dp.Type] = true
+	}
+	for i, ic := range dep.InitContainers {
+		switch {
+		case !dnsLabelPattern.MatchString(ic.Name):
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.deployment.initContainers[%d].name must be a lowercase DNS label", i))
+		case names[ic.Name]:
+			add(SeverityError, "duplicate_name", t.Name, fmt.Sprintf("spec.deployment.initContainers[%d]: %q is already used in the pod", i, ic.Name))
+		}
+		names[ic.Name] = true
+	}
+	for i, sc := range dep.Sidecars {
+		switch {
+		case !dnsLabelPattern.MatchString(sc.Name):
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.deployment.sidecars[%d].name must be a lowercase DNS label", i))
+		case names[sc.Name]:
+			add(SeverityError, "duplicate_name", t.Name, fmt.Sprintf("spec.deployment.sidecars[%d]: %q is already used in the pod", i, sc.Name))
+		}
+		names[sc.Name] = true
+		if sc.Image == "" {
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.deployment.sidecars[%d].image is required", i))
+		}
+	}
+	checkVolumes(t, hostMounts, add)
+	names = map[string]bool{}
+	for _, dp := range d.Spec.Dependencies {
+		names[dp.Type] = true
+	}
+	for i, job := range d.Spec.Jobs {
+		switch {
+		case len(job.Name) > 40 || !dnsLabelPattern.MatchString(job.Name):
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.jobs[%d].name must be a lowercase DNS label of at most 40 characters", i))
+		case names[job.Name]:
+			add(SeverityError, "duplicate_name", t.Name, fmt.Sprintf("spec.jobs[%d]: %s-%s is already the name of a dependency or another job", i, t.Name, job.Name))
+		}
+		names[job.Name] = true
+		if job.BackoffLimit != nil && *job.BackoffLimit < 0 {
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("spec.jobs[%d].backoffLimit must be at least 0", i))
+		}
+	}
+}
+
+// checkVolumes checks spec.deployment.volumes. A hostPath outside the
+// hostMounts, the directories kindling init --mount put into the nodes,
+// is only on the node, so it is lost with the cluster and can't be seen
+// from the host.
+func checkVolumes(t Target, hostMounts []string, add addFinding) {
+	dep := t.Manifest.Spec.Deployment
+	names, mountPaths := map[string]bool{}, map[string]bool{}
+	claims := false
+	for i, v := range dep.Volumes {
+		field := fmt.Sprintf("spec.deployment.volumes[%d]", i)
+		switch {
+		case len(v.Name) > 40 || !dnsLabelPattern.MatchString(v.Name):
+			add(SeverityError, "schema", t.Name, field+".name must be a lowercase DNS label of at most 40 characters")
+		case names[v.Name]:
+			add(SeverityError, "duplicate_name", t.Name, fmt.Sprintf("%s: volume %q is declared twice", field, v.Name))
+		}
+		names[v.Name] = true
+		switch {
+		case !strings.HasPrefix(v.MountPath, "/"):
+			add(SeverityError, "schema", t.Name, field+".mountPath must be an absolute path")
+		case mountPaths[v.MountPath]:
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("%s: %s is already mounted", field, v.MountPath))
+		}
+		mountPaths[v.MountPath] = true
+
+		if v.HostPath == "" {
+			claims = true
+			if _, err := ParseQuantity(v.Size); err != nil {
+				add(SeverityError, "schema", t.Name, fmt.Sprintf("%s.size: %v", field, err))
+			}
+			continue
+		}
+		switch {
+		case !strings.HasPrefix(v.HostPath, "/"):
+			add(SeverityError, "schema", t.Name, field+".hostPath must be an absolute path on the Kind node")
+		case v.Size != "":
+			add(SeverityError, "schema", t.Name, field+": size applies to claims only, not to a hostPath volume")
+		case !underAny(v.HostPath, hostMounts):
+			add(SeverityWarning, "schema", t.Name, fmt.Sprintf("%s: %s is not in a directory mounted from the host, so it lives inside the Kind node — mount one with: kindling destroy && kindling init --mount ./data:%s", field, v.HostPath, path.Dir(v.HostPath)))
+		}
+	}
+	if claims && dep.Replicas != nil && *dep.Replicas > 1 {
+		add(SeverityWarning, "schema", t.Name, "replicas share each volume's claim, which lives on one node — on a multi-node cluster they all run there")
+	}
+}
+
+// underAny reports whether p is one of dirs or inside one of them.
+func underAny(p string, dirs []string) bool {
+	p = path.Clean(p)
+	for _, d := range dirs {
+		d = path.Clean(d)
+		if p == d || strings.HasPrefix(p, strings.TrimSuffix(d, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// cronMacros are the schedule shorthands CronJobs accept.
+var cronMacros = map[string]bool{"@yearly": true, "@annually": true, "@monthly": true, "@weekly": true,
+	"@daily": true, "@midnight": true, "@hourly": true}
+
+// cronField matches one field of a five-field cron expression: numbers,
+// names (MON, JAN), *, ?, ranges, steps, and lists.
+var cronField = regexp.MustCompile(`^[0-9A-Za-z*?/,\-]+$`)
+
+// validCronSchedule reports whether schedule has the shape of a CronJob
+// schedule, optionally prefixed with a CRON_TZ= or TZ= time zone. It
+// doesn't check field ranges; the API server does.
+func validCronSchedule(schedule string) bool {
+	fields := strings.Fields(schedule)
+	if len(fields) > 0 && (strings.HasPrefix(fields[0], "CRON_TZ=") || strings.HasPrefix(fields[0], "TZ=")) {
+		fields = fields[1:]
+	}
+	if len(fields) == 1 {
+		return cronMacros[fields[0]]
+	}
+	if len(fields) == 2 && fields[0] == "@every" {
+		_, err := time.ParseDuration(fields[1])
+		return err == nil
+	}
+	if len(fields) != 5 {
+		return false
+	}
+	for _, f := range fields {
+		if !cronField.MatchString(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkResources enforces the resources shape of the manifest's version:
+// flat cpu/memory fields in v1alpha1, requests and limits in v1beta1.
+func checkResources(t Target, field string, res map[string]interface{}, add addFinding) {
+	keys := make([]string, 0, len(res))
+	for key := range res {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := res[key]
+		if t.Manifest.APIVersion == APIVersionV1beta1 {
+			if key != "requests" && key != "limits" {
+				add(SeverityError, "schema", t.Name, fmt.Sprintf("%s.%s is not a v1beta1 field (use requests and limits)", field, key))
+			} else if _, ok := value.(map[string]interface{}); !ok {
+				add(SeverityError, "schema", t.Name, fmt.Sprintf("%s.%s must be a map of resource names to quantities", field, key))
+			}
+			continue
+		}
+		if _, ok := V1alpha1ResourceFields[key]; !ok {
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("%s.%s is not a v1alpha1 field (v1alpha1 has cpuRequest, cpuLimit, memoryRequest, and memoryLimit)", field, key))
+		} else if _, ok := value.(map[string]interface{}); ok {
+			add(SeverityError, "schema", t.Name, fmt.Sprintf("%s.%s must be a quantity", field, key))
+		}
+	}
+}
+
+func validPort(p int) bool {
+	return p >= 1 && p <= 65535
+}
+
+// checkDockerfile makes sure the image can be built. Workflows name the
+// build context; for manifests it is inferred from the image name, and
+// only kindling-style local images (<name>:dev) are required to have one.
+func checkDockerfile(t Target, repoPath string, add addFinding) {
+	path, local := TargetDockerfile(t, repoPath)
+	if path == "" {
+		if local {
+			add(SeverityWarning, "missing_dockerfile", t.Name, fmt.Sprintf("can't find a build context for %s in %s", t.Manifest.Spec.Deployment.Image, repoPath))
+		}
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		rel, _ := filepath.Rel(repoPath, path)
+		add(SeverityError, "missing_dockerfile", t.Name, fmt.Sprintf("no Dockerfile at %s (try: kindling generate --synthesize-dockerfiles)", rel))
+		return
+	}
+
+	var exposed []string
+	for _, m := range source.ExposeDirective.FindAllSubmatch(data, -1) {
+		exposed = append(exposed, string(m[1]))
+	}
+	port := strconv.Itoa(t.Manifest.Spec.Deployment.Port)
+	if len(exposed) > 0 && t.Manifest.Spec.Deployment.Port > 0 && !slices.Contains(exposed, port) {
+		add(SeverityWarning, "expose_mismatch", t.Name, fmt.Sprintf("declares port %s but the Dockerfile EXPOSEs %s", port, strings.Join(exposed, ", ")))
+	}
+}
+
+// TargetDockerfile returns the path of the Dockerfile t is built from, or
+// "" when that isn't known. local reports whether a manifest's image looks
+// locally built even though no build context was found for it.
+func TargetDockerfile(t Target, repoPath string) (path string, local bool) {
+	var context, dockerfile string
+	if t.FromWorkflow {
+		if t.BuildContext == "" {
+			return "", false
+		}
+		context, dockerfile = t.BuildContext, t.Dockerfile
+	} else {
+		context, dockerfile, local = BuildContext(t.Manifest.Spec.Deployment.Image, repoPath)
+		if context == "" && dockerfile == "" {
+			return "", local
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(dockerfile, DockerfileOverlayDir):
+		return filepath.Join(repoPath, dockerfile), false
+	case dockerfile != "":
+		return filepath.Join(repoPath, context, dockerfile), false
+	}
+	return filepath.Join(repoPath, context, "Dockerfile"), false
+}
+
+// checkEnv flags variables a component's code reads with no default that
+// its spec leaves unset, and env entries still empty or holding the
+// placeholder. A component with envFrom is only checked for the latter:
+// what the referenced Secret or ConfigMap holds can't be seen here.
+func checkEnv(targets []Target, repoPath string, add addFinding) {
+	contexts := make([]string, len(targets))
+	dockerfiles := make([]string, len(targets))
+	for i, t := range targets {
+		if t.FromWorkflow {
+			contexts[i], dockerfiles[i] = t.BuildContext, t.Dockerfile
+			if dockerfiles[i] != "" && !strings.HasPrefix(dockerfiles[i], DockerfileOverlayDir) {
+				dockerfiles[i] = filepath.Join(t.BuildContext, dockerfiles[i])
+			}
+		} else if repoPath != "" {
+			contexts[i], dockerfiles[i], _ = BuildContext(t.Manifest.Spec.Deployment.Image, repoPath)
+		}
+		if contexts[i] != "" && dockerfiles[i] == "" {
+			dockerfiles[i] = filepath.Join(contexts[i], "Dockerfile")
+		}
+	}
+
+	for i, t := range targets {
+		set := map[string]bool{}
+		for _, e := range t.Manifest.Spec.Deployment.Env {
+			switch {
+			case e.ValueFrom != nil:
+			case e.Value == source.EnvPlaceholder:
+				add(SeverityError, "env", t.Name, fmt.Sprintf("%s is still the placeholder %s — set a value before deploying", e.Name, source.EnvPlaceholder))
+			case strings.TrimSpace(e.Value) == "":
+				add(SeverityError, "env", t.Name, fmt.Sprintf("%s is empty — set a value before deploying, or remove it", e.Name))
+			}
+			set[e.Name] = true
+		}
+		dir := contexts[i]
+		if dir == "" || len(t.Manifest.Spec.Deployment.EnvFrom) > 0 {
+			continue
+		}
+
+		var nested, deps []string
+		for j, other := range contexts {
+			if j != i && other != "" && other != dir && (dir == "." || strings.HasPrefix(other, dir+string(filepath.Separator))) {
+				nested = append(nested, other)
+			}
+		}
+		for _, d := range t.Manifest.Spec.Dependencies {
+			deps = append(deps, d.Type)
+		}
+		injected := InjectedEnvVars(deps)
+		for _, d := range t.Manifest.Spec.Dependencies {
+			if d.EnvVarName != "" {
+				injected[d.EnvVarName] = true
+			}
+		}
+		dockerfile, _ := os.ReadFile(filepath.Join(repoPath, dockerfiles[i]))
+
+		reads := source.EnvReads(repoPath, dir, nested)
+		for _, r := range source.RequiredEnv(reads, set, injected, source.DockerfileEnv(string(dockerfile))) {
+			add(SeverityWarning, "env", t.Name, fmt.Sprintf("the code reads %s (%s) with no default, but env doesn't set it", r.Name, r.Location))
+		}
+	}
+}
+
+// DockerfileOverlayDir holds the Dockerfiles kindling generate writes for
+// images built from a repo without one, as <image name>/Dockerfile,
+// relative to the repo root.
+var DockerfileOverlayDir = filepath.Join(".kindling", "dockerfiles")
+
+// BuildContext guesses where a manifest image is built from: a
+// .kindling/dockerfiles overlay, or the repo directory named after it.
+// local reports whether the image looks locally built (<name>:dev, or a
+// local registry) even when no context was found.
+func BuildContext(image, repoPath string) (context, dockerfile string, local bool) {
+	ref, tag := image, ""
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref, tag = ref[:i], ref[i+1:]
+	}
+	parts := strings.Split(ref, "/")
+	local = tag == "dev"
+	if len(parts) > 1 {
+		registry := parts[0]
+		if strings.HasPrefix(registry, "localhost") || strings.HasPrefix(registry, "registry:") || strings.HasPrefix(registry, "kind-registry") {
+			local = true
+		} else if strings.ContainsAny(registry, ".:") {
+			return "", "", false
+		}
+	}
+	name := parts[len(parts)-1]
+
+	dir := dirForImage(repoPath, name)
+	overlay := filepath.Join(DockerfileOverlayDir, name, "Dockerfile")
+	if _, err := os.Stat(filepath.Join(repoPath, overlay)); err == nil {
+		return dir, overlay, true
+	}
+	return dir, "", local
+}
+
+// dirForImage returns the repo directory an image named name is built
+// from: the root when it's named after the repo, else a directory of that
+// name (with or without a "<repo>-" prefix) within the top two levels.
+func dirForImage(repoPath, name string) string {
+	repo := source.DNSLabel(filepath.Base(repoPath))
+	if name == repo {
+		return "."
+	}
+	candidates := []string{name}
+	if trimmed := strings.TrimPrefix(name, repo+"-"); trimmed != name {
+		candidates = append(candidates, trimmed)
+	}
+	for _, c := range candidates {
+		for _, pattern := range []string{c, filepath.Join("*", c)} {
+			matches, _ := filepath.Glob(filepath.Join(repoPath, pattern))
+			for _, m := range matches {
+				if info, err := os.Stat(m); err == nil && info.IsDir() {
+					rel, _ := filepath.Rel(repoPath, m)
+					return rel
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// checkHealthCheck flags apps with no probe and probes that fall back to
+// the /healthz default.
+func checkHealthCheck(t Target, add addFinding) {
+	hc := t.Manifest.Spec.Deployment.HealthCheck
+	switch {
+	case t.Manifest.Spec.Deployment.Schedule != "":
+		// Each run exits; there is nothing to probe.
+	case hc == nil:
+		add(SeverityWarning, "missing_health_check", t.Name, "no healthCheck — Kubernetes can't tell when the app is ready")
+	case hc.Type == "tcp":
+	case hc.Path == "":
+		add(SeverityWarning, "missing_health_check", t.Name, "no health-check path — the probe defaults to /healthz; set the app's route or use type tcp")
+	}
+}
+
+// checkDependencies flags dependencies the operator can't provision side
+// by side.
+func checkDependencies(t Target, add addFinding) {
+	seen := map[string]bool{}
+	injected := map[string]string{}
+	for i, dp := range t.Manifest.Spec.Dependencies {
+		conv, ok := DependencyConventions[dp.Type]
+		if !ok {
+			continue
+		}
+		if seen[dp.Type] {
+			add(SeverityError, "unsatisfiable_dependency", t.Name, fmt.Sprintf("%s is declared twice — both would be named %s-%s", dp.Type, t.Name, dp.Type))
+		}
+		seen[dp.Type] = true
+
+		if dp.Version != "" && !imageTagPattern.MatchString(dp.Version) {
+			add(SeverityError, "unsatisfiable_dependency", t.Name, fmt.Sprintf("dependencies[%d]: version %q is not a valid image tag", i, dp.Version))
+		}
+
+		envVar := conv.EnvVar
+		if dp.EnvVarName != "" {
+			envVar = dp.EnvVarName
+		}
+		if other, ok := injected[envVar]; ok {
+			add(SeverityError, "unsatisfiable_dependency", t.Name, fmt.Sprintf("%s and %s both inject %s — set envVarName on one of them", other, dp.Type, envVar))
+		}
+		injected[envVar] = dp.Type
+
+		if seed := dp.Seed; seed != nil {
+			switch {
+			case seed.ConfigMap == "" && len(seed.Command) == 0:
+				add(SeverityError, "unsatisfiable_dependency", t.Name, fmt.Sprintf("dependencies[%d]: seed needs a configMap or a command", i))
+			case len(seed.Command) == 0 && !seedLoaderTypes[dp.Type]:
+				add(SeverityError, "unsatisfiable_dependency", t.Name, fmt.Sprintf("dependencies[%d]: %s has no built-in seed loader — set seed.command", i, dp.Type))
+			}
+		}
+	}
+
+	for _, e := range t.Manifest.Spec.Deployment.Env {
+		if depType, ok := injected[e.Name]; ok {
+			add(SeverityInfo, "unsatisfiable_dependency", t.Name, fmt.Sprintf("env %s overrides the connection string injected for %s", e.Name, depType))
+		}
+	}
+}
+
+// checkNetworking follows every URL in the app env to a declared service
+// or dependency and compares ports.
+func checkNetworking(targets []Target, add addFinding) {
+	type endpoint struct {
+		port  int
+		label string
+	}
+	endpoints := map[string]endpoint{}
+	for _, t := range targets {
+		if t.Manifest.Spec.Deployment.Schedule == "" {
+			endpoints[t.Name] = endpoint{t.Manifest.Spec.Service.Port, fmt.Sprintf("service %q", t.Name)}
+		}
+		for _, dp := range t.Manifest.Spec.Dependencies {
+			conv, ok := DependencyConventions[dp.Type]
+			if !ok {
+				continue
+			}
+			port := conv.Port
+			if dp.Port != nil {
+				port = *dp.Port
+			}
+			endpoints[t.Name+"-"+dp.Type] = endpoint{port, fmt.Sprintf("%s dependency of %q", dp.Type, t.Name)}
+		}
+	}
+
+	for _, t := range targets {
+		for _, e := range t.Manifest.Spec.Deployment.Env {
+			value := actorPrefix.ReplaceAllString(e.Value, "")
+			m := envURLPattern.FindStringSubmatch(value)
+			if m == nil {
+				m = envHostPort.FindStringSubmatch(strings.TrimSpace(value))
+			}
+			if m == nil {
+				continue
+			}
+			host := m[1]
+			if strings.HasSuffix(host, ".svc.cluster.local") || strings.HasSuffix(host, ".svc") {
+				host = strings.SplitN(host, ".", 2)[0]
+			}
+			if strings.Contains(host, ".") || host == "localhost" {
+				continue // external or loopback
+			}
+			port, _ := strconv.Atoi(m[2])
+
+			if ep, ok := endpoints[host]; ok {
+				if ep.port > 0 && port != ep.port {
+					add(SeverityError, "port_mismatch", t.Name, fmt.Sprintf("env %s uses %s:%d but the %s listens on %d", e.Name, host, port, ep.label, ep.port))
+				}
+				continue
+			}
+
+			if owner, depType := splitDependencyHost(host); depType != "" {
+				if _, ok := endpoints[owner]; ok {
+					add(SeverityError, "unsatisfiable_dependency", t.Name, fmt.Sprintf("env %s points at %s, but %q does not declare a %s dependency", e.Name, host, owner, depType))
+					continue
+				}
+			}
+			add(SeverityWarning, "dangling_ref", t.Name, fmt.Sprintf("env %s references %q, which is not a declared service or dependency", e.Name, host))
+		}
+	}
+}
+
+// checkDependsOn flags dependsOn entries the operator would wait on
+// forever — the component itself, or a cycle — and ones naming a
+// component the file doesn't deploy. A URL in env to another component
+// that isn't in dependsOn is reported as info, or as a warning when the
+// component called is strict: its NetworkPolicies would block the call.
+func checkDependsOn(targets []Target, add addFinding) {
+	graph := map[string][]string{}
+	strict := map[string]bool{}
+	for _, t := range targets {
+		graph[t.Name] = t.Manifest.Spec.DependsOn
+		strict[t.Name] = t.Manifest.Spec.NetworkPolicies == "strict"
+	}
+	for _, t := range targets {
+		seen := map[string]bool{}
+		for i, upstream := range t.Manifest.Spec.DependsOn {
+			switch {
+			case upstream == t.Name:
+				add(SeverityError, "depends_on", t.Name, "dependsOn names the component itself — it would never start")
+			case seen[upstream]:
+				add(SeverityError, "schema", t.Name, fmt.Sprintf("dependsOn[%d]: %q is listed twice", i, upstream))
+			case !dnsLabelPattern.MatchString(upstream):
+				add(SeverityError, "schema", t.Name, fmt.Sprintf("dependsOn[%d]: %q is not a valid DNS label", i, upstream))
+			default:
+				if _, ok := graph[upstream]; !ok {
+					add(SeverityWarning, "depends_on", t.Name, fmt.Sprintf("dependsOn %q, which this file doesn't deploy — it waits until that component exists and is available", upstream))
+				}
+			}
+			seen[upstream] = true
+		}
+
+		for _, e := range t.Manifest.Spec.Deployment.Env {
+			m := envURLPattern.FindStringSubmatch(actorPrefix.ReplaceAllString(e.Value, ""))
+			if m == nil || m[1] == t.Name || slices.Contains(t.Manifest.Spec.DependsOn, m[1]) {
+				continue
+			}
+			if _, ok := graph[m[1]]; !ok {
+				continue
+			}
+			if strict[m[1]] {
+				add(SeverityWarning, "depends_on", t.Name, fmt.Sprintf("env %s calls %q, whose networkPolicies: strict blocks undeclared callers — add it to dependsOn", e.Name, m[1]))
+			} else {
+				add(SeverityInfo, "depends_on", t.Name, fmt.Sprintf("env %s calls %q — add it to dependsOn to start after it", e.Name, m[1]))
+			}
+		}
+	}
+
+	// A cycle is reported once, from its alphabetically first component.
+	reported := map[string]bool{}
+	for _, name := range slices.Sorted(maps.Keys(graph)) {
+		if cycle := dependsOnCycle(graph, name); cycle != nil && !reported[name] {
+			for _, n := range cycle {
+				reported[n] = true
+			}
+			add(SeverityError, "depends_on", name, fmt.Sprintf("dependsOn cycle %s — none of them would start", strings.Join(cycle, " → ")))
+		}
+	}
+}
+
+// dependsOnCycle returns a path from start back to itself, or nil.
+func dependsOnCycle(graph map[string][]string, start string) []string {
+	visited := map[string]bool{}
+	var walk func(name string, path []string) []string
+	walk = func(name string, path []string) []string {
+		for _, next := range graph[name] {
+			if next == start && next != name {
+				return append(path, next)
+			}
+			if !visited[next] {
+				visited[next] = true
+				if cycle := walk(next, append(path, next)); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		return nil
+	}
+	return walk(start, []string{start})
+}
+
+// splitDependencyHost splits "<dse>-<type>" when type is a dependency type.
+func splitDependencyHost(host string) (string, string) {
+	for depType := range DependencyConventions {
+		if strings.HasSuffix(host, "-"+depType) {
+			return strings.TrimSuffix(host, "-"+depType), depType
+		}
+	}
+	return "", ""
+}
+
+// checkUniqueness flags resource names and ingress hosts used twice.
+func checkUniqueness(targets []Target, add addFinding) {
+	names := map[string]int{}
+	routes := map[string]string{}
+	nodePorts := map[int]string{}
+	for _, t := range targets {
+		if t.Name != "" {
+			names[t.Name]++
+			if names[t.Name] == 2 {
+				add(SeverityError, "duplicate_name", t.Name, "declared more than once — later definitions overwrite earlier ones")
+			}
+		}
+		svc := t.Manifest.Spec.Service
+		if np := svc.NodePort; np != nil && (svc.Type == "NodePort" || svc.Type == "LoadBalancer") {
+			if other, ok := nodePorts[*np]; ok && other != t.Name {
+				add(SeverityError, "duplicate_node_port", t.Name, fmt.Sprintf("node port %d is also used by %q", *np, other))
+			} else {
+				nodePorts[*np] = t.Name
+			}
+		}
+		ing := t.Manifest.Spec.Ingress
+		if ing == nil || !ing.Enabled || ing.Host == "" {
+			continue
+		}
+		// The operator routes a host and path to one component; the same
+		// host on different paths is fine.
+		path := strings.TrimRight(ing.Path, "/")
+		if path == "" {
+			path = "/"
+		}
+		route := strings.ToLower(ing.Host) + path
+		if other, ok := routes[route]; ok && other != t.Name {
+			add(SeverityError, "duplicate_hostname", t.Name, fmt.Sprintf("ingress route %s is also used by %q", route, other))
+			continue
+		}
+		routes[route] = t.Name
+	}
+}
@@ -0,0 +1,131 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jeffvincent/kindling/cli/internal/logging"
+)
+
+// ── AI audit log ────────────────────────────────────────────────
+//
+// Every request generate sends to an AI provider, and what came back, is
+// appended to .kindling/ai-audit/<run>.jsonl: when, to which provider and
+// endpoint, the model asked for and the model version that answered, the
+// status, and both bodies with credentials masked by logging.RedactText.
+// Retried attempts are recorded too. The API key travels in a header and
+// is never written. Like the history, the log is a record, not a result:
+// failing to write it is logged and otherwise ignored.
+
+// aiAuditDir holds one file per AI run, under .kindling.
+const aiAuditDir = "ai-audit"
+
+// AuditLog is the audit file of one run.
+type AuditLog struct {
+	path string
+}
+
+// NewAuditLog returns the audit log of a run started at started.
+func NewAuditLog(repoPath string, started time.Time) *AuditLog {
+	return &AuditLog{path: filepath.Join(repoPath, ".kindling", aiAuditDir, started.Format("20060102-150405")+".jsonl")}
+}
+
+// aiAuditRecord is one line of the audit log.
+type aiAuditRecord struct {
+	Time          time.Time   `json:"time"`
+	Provider      string      `json:"provider"`
+	Endpoint      string      `json:"endpoint"`
+	Model         string      `json:"model,omitempty"`         // asked for
+	ResponseModel string      `json:"responseModel,omitempty"` // the version that answered
+	Status        int         `json:"status,omitempty"`
+	Error         string      `json:"error,omitempty"`
+	DurationMS    int64       `json:"durationMs"`
+	Request       interface{} `json:"request"`
+	Response      interface{} `json:"response,omitempty"`
+}
+
+// record appends one attempt of a call: the request body sent to
+// endpoint, and the response's status and body or the error.
+func (l *AuditLog) record(provider, endpoint string, req []byte, status int, resp []byte, callErr error, took time.Duration) error {
+	if l == nil {
+		return nil
+	}
+	rec := aiAuditRecord{
+		Time:       time.Now().UTC(),
+		Provider:   provider,
+		Endpoint:   endpoint,
+		Status:     status,
+		DurationMS: took.Milliseconds(),
+		Request:    redactAuditBody(req),
+	}
+	rec.Model = auditModel(rec.Request)
+	if resp != nil {
+		rec.Response = redactAuditBody(resp)
+		rec.ResponseModel = auditModel(rec.Response)
+	}
+	if callErr != nil {
+		rec.Error = logging.RedactText(callErr.Error())
+	}
+	line, err := json.Marshal(rec)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(l.path), 0o755)
+	}
+	if err == nil {
+		ignore := filepath.Join(filepath.Dir(l.path), ".gitignore")
+		if _, statErr := os.Stat(ignore); os.IsNotExist(statErr) {
+			_ = os.WriteFile(ignore, []byte("*\n"), 0o644)
+		}
+		var f *os.File
+		if f, err = os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600); err == nil {
+			_, err = f.Write(append(line, '\n'))
+			f.Close()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("write %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// redactAuditBody decodes a JSON body and masks the credentials in its
+// strings and in env entries named like credentials. A body that isn't
+// JSON, such as a proxy's error page, is kept as redacted text.
+func redactAuditBody(data []byte) interface{} {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return logging.RedactText(string(data))
+	}
+	logging.RedactEnvValues(v)
+	return redactStrings(v)
+}
+
+// redactStrings applies logging.RedactText to every string in v.
+func redactStrings(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return logging.RedactText(v)
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = redactStrings(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactStrings(child)
+		}
+	}
+	return v
+}
+
+// auditModel returns the model field of a decoded body, which every
+// provider sets in its responses and all but Azure in requests.
+func auditModel(body interface{}) string {
+	if m, ok := body.(map[string]interface{}); ok {
+		if model, ok := m["model"].(string); ok {
+			return model
+		}
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package generate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ── Generate cache ──────────────────────────────────────────────
+//
+// The workflow the AI settles on is cached under the user cache directory,
+// keyed by everything that decides it: the content of every repo file the
+// scan found, the prompt built from them, the provider and model, the
+// reply format, the correction rounds, and the token budget. Running
+// generate again on an unchanged repo reuses it without calling the AI.
+// generatePromptVersion is part of the key, so a kindling with different
+// prompts doesn't reuse another's answers. --no-cache skips the cache;
+// kindling cache clear generate empties it.
+
+const (
+	// generatePromptVersion is bumped whenever the prompts, the deploy
+	// plan schema, or how a plan is rendered change.
+	generatePromptVersion = 1
+
+	// generateCacheKeep is how many cached workflows are kept.
+	generateCacheKeep = 50
+)
+
+// CacheEntry is one cached workflow.
+type CacheEntry struct {
+	Key      string    `json:"key"`
+	Created  time.Time `json:"created"`
+	Repo     string    `json:"repo"`
+	Provider string    `json:"provider"`
+	Model    string    `json:"model"`
+	Usage    Usage     `json:"usage"` // what the cached run cost, not spent again
+	Workflow string    `json:"workflow"`
+}
+
+// generateCacheDir returns <user cache dir>/kindling/generate.
+func generateCacheDir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "kindling", "generate"), nil
+}
+
+// CacheKey hashes the inputs of an AI run.
+func CacheKey(ctx *Repo, generator Generator, format ReplyFormat, systemPrompt, userPrompt string, rounds int, budget *Budget) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "v%d\x00%s\x00%s\x00%s\x00%T\x00%d\x00%d\x00%g\x00%s\x00", generatePromptVersion,
+		generator.Name(), generator.Model(), format.lang(), format, rounds, budget.maxTokens, budget.maxCost, ctx.Branch)
+	for _, part := range []string{systemPrompt, userPrompt} {
+		fmt.Fprintf(h, "%d\x00%s", len(part), part)
+	}
+	writeRepoFingerprint(h, ctx)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeRepoFingerprint writes the path and content of every file the scan
+// found to h, so that a change the prompt didn't have room for still
+// changes the key.
+func writeRepoFingerprint(h io.Writer, ctx *Repo) {
+	paths := map[string]bool{}
+	for _, f := range ctx.contextFiles {
+		paths[f.path] = true
+	}
+	for _, m := range []map[string]string{ctx.dockerfiles, ctx.depFiles, ctx.overlayDockerfiles} {
+		for rel := range m {
+			paths[filepath.ToSlash(rel)] = true
+		}
+	}
+	for _, rel := range slices.Sorted(maps.Keys(paths)) {
+		data, err := os.ReadFile(filepath.Join(ctx.Root, filepath.FromSlash(rel)))
+		if err != nil {
+			data = []byte("\x00missing")
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", rel, len(data))
+		h.Write(data)
+	}
+	fmt.Fprintf(h, "%d\x00%s", len(ctx.composeFile), ctx.composeFile)
+}
+
+// LoadCache returns the workflow cached under key, or nil when
+// there is none. An error means the entry is damaged; it is best ignored.
+func LoadCache(key string) (*CacheEntry, error) {
+	dir, err := generateCacheDir()
+	if err != nil {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, key+".json"))
+	if err != nil {
+		return nil, nil
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("damaged generate cache entry %s: %w", key, err)
+	}
+	if entry.Key != key || entry.Workflow == "" {
+		return nil, fmt.Errorf("damaged generate cache entry %s", key)
+	}
+	return &entry, nil
+}
+
+// StoreCache caches entry, keeping the newest generateCacheKeep
+// entries.
+func StoreCache(entry CacheEntry) error {
+	dir, err := generateCacheDir()
+	if err == nil {
+		err = os.MkdirAll(dir, 0o755)
+	}
+	var data []byte
+	if err == nil {
+		data, err = json.MarshalIndent(entry, "", "  ")
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, entry.Key+".json"), append(data, '\n'), 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	pruneGenerateCache(dir, generateCacheKeep)
+	return nil
+}
+
+// pruneGenerateCache removes all but the keep most recently written
+// entries in dir.
+func pruneGenerateCache(dir string, keep int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type cached struct {
+		name    string
+		modTime time.Time
+	}
+	var files []cached
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && strings.HasSuffix(e.Name(), ".json") {
+			files = append(files, cached{e.Name(), info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	for _, f := range files[min(keep, len(files)):] {
+		_ = os.Remove(filepath.Join(dir, f.name))
+	}
+}
+
+// ClearCache deletes every cached workflow and returns how many
+// there were.
+func ClearCache() (int, error) {
+	dir, err := generateCacheDir()
+	if err != nil {
+		return 0, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".json") {
+			n++
+		}
+	}
+	return n, os.RemoveAll(dir)
+}
@@ -0,0 +1,384 @@
+package generate
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// docker-compose conversion (--from-compose)
+// ────────────────────────────────────────────────────────────────────────────
+//
+// A compose file already names the services, their ports, env, and what
+// they depend on, so it converts to DevStagingEnvironments without any
+// guessing. Services whose image is a known backing service become
+// dependencies of the services that depend_on them; every other service
+// becomes a component with its own DSE, whose dependsOn lists the
+// application services it depends_on or addresses in its env.
+
+// composeSpecService is the part of a docker-compose service that
+// --from-compose converts. The fields with both a short and a long form
+// are kept as nodes and read by the compose* helpers below.
+type composeSpecService struct {
+	Image       string                  `yaml:"image"`
+	Build       yaml.Node               `yaml:"build"`
+	Ports       []yaml.Node             `yaml:"ports"`
+	Expose      []yaml.Node             `yaml:"expose"`
+	Environment yaml.Node               `yaml:"environment"`
+	EnvFile     yaml.Node               `yaml:"env_file"`
+	DependsOn   yaml.Node               `yaml:"depends_on"`
+	Volumes     []yaml.Node             `yaml:"volumes"`
+	Healthcheck *composeSpecHealthcheck `yaml:"healthcheck"`
+	Deploy      struct {
+		Replicas int `yaml:"replicas"`
+	} `yaml:"deploy"`
+}
+
+type composeSpecHealthcheck struct {
+	Test    yaml.Node `yaml:"test"`
+	Disable bool      `yaml:"disable"`
+}
+
+// composeVolume is one entry of a service's volumes.
+type composeVolume struct {
+	source string
+	target string
+	bind   bool // host path rather than a named or anonymous volume
+}
+
+// composeInitDirs are where the backing-service images run init scripts
+// from on first start; mounting files there becomes a seed.
+var composeInitDirs = map[string]string{
+	"postgres": "/docker-entrypoint-initdb.d",
+	"mysql":    "/docker-entrypoint-initdb.d",
+	"mongodb":  "/docker-entrypoint-initdb.d",
+}
+
+var composeHealthURL = regexp.MustCompile(`https?://[^/\s"']+(/[^\s"'|;&]*)?`)
+
+// ConvertCompose reads a compose file and returns one component per
+// application service, in name order, with notes on what didn't map.
+func ConvertCompose(repoPath, composePath string) ([]*Component, []string, error) {
+	data, err := os.ReadFile(composePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read compose file: %w", err)
+	}
+	var file struct {
+		Services map[string]composeSpecService `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("invalid compose file %s: %w", composePath, err)
+	}
+	if len(file.Services) == 0 {
+		return nil, nil, fmt.Errorf("%s defines no services", composePath)
+	}
+	composeDir := filepath.Dir(composePath)
+
+	// Split backing services from the application.
+	backing := map[string]string{} // service → dependency type
+	var apps []string
+	for _, name := range slices.Sorted(maps.Keys(file.Services)) {
+		svc := file.Services[name]
+		if svc.Build.Kind == 0 {
+			if depType, _, ok := composeImageDependency(svc.Image); ok {
+				backing[name] = depType
+				continue
+			}
+		}
+		apps = append(apps, name)
+	}
+	if len(apps) == 0 {
+		return nil, nil, fmt.Errorf("%s has only backing services (%s) — nothing to deploy",
+			composePath, strings.Join(slices.Sorted(maps.Keys(backing)), ", "))
+	}
+
+	// Compose services reach each other by service name; in the cluster
+	// each component's Service is named after its DSE.
+	hosts := map[string]string{}
+	for _, name := range apps {
+		hosts[name] = source.DNSLabel(name) + "-dev"
+	}
+
+	var notes []string
+	var components []*Component
+	for _, name := range apps {
+		svc := file.Services[name]
+		c := &Component{
+			Name:               source.DNSLabel(name),
+			Dir:                ".",
+			Replicas:           svc.Deploy.Replicas,
+			Dependencies:       map[string]bool{},
+			DependencySettings: map[string]Dependency{},
+		}
+
+		if svc.Build.Kind != 0 {
+			context, dockerfile := composeBuild(svc.Build)
+			dir, err := filepath.Rel(repoPath, filepath.Join(composeDir, context))
+			if err != nil || strings.HasPrefix(dir, "..") {
+				dir = filepath.Join(composeDir, context)
+			}
+			c.Dir = dir
+			if dockerfile != "" && dockerfile != "Dockerfile" {
+				c.Dockerfile = filepath.Join(dir, dockerfile)
+			}
+		} else {
+			c.Image = svc.Image
+		}
+
+		c.Port = composePort(svc.Ports)
+		if c.Port == 0 {
+			c.Port = composePort(svc.Expose)
+		}
+		if c.Port == 0 {
+			c.Port = 8080
+			notes = append(notes, fmt.Sprintf("%s: no ports or expose — assuming 8080", name))
+		}
+
+		if svc.Healthcheck != nil && !svc.Healthcheck.Disable {
+			c.HealthPath = composeHealthPath(svc.Healthcheck.Test)
+		}
+
+		calls := map[string]bool{}
+		for _, dep := range composeNames(svc.DependsOn) {
+			depType, ok := backing[dep]
+			if !ok {
+				if _, app := hosts[dep]; app && dep != name {
+					calls[source.DNSLabel(dep)] = true
+				}
+				continue
+			}
+			c.Dependencies[depType] = true
+			bsvc := file.Services[dep]
+			_, details, _ := composeImageDependency(bsvc.Image)
+			for _, v := range composeVolumes(bsvc.Volumes) {
+				initDir, ok := composeInitDirs[depType]
+				if v.bind && ok && (v.target == initDir || strings.HasPrefix(v.target, initDir+"/")) {
+					src, err := filepath.Rel(repoPath, filepath.Join(composeDir, v.source))
+					if err != nil {
+						src = filepath.Join(composeDir, v.source)
+					}
+					details.SeedFrom = src
+				}
+			}
+			c.DependencySettings[depType] = details
+		}
+
+		injected := map[string]bool{}
+		for depType := range c.Dependencies {
+			injected[devstaging.DependencyConventions[depType].EnvVar] = true
+		}
+		for _, e := range composeEnv(svc.Environment) {
+			if e.Value == "" {
+				notes = append(notes, fmt.Sprintf("%s: %s takes its value from the host — set it in the manifest or with kindling secrets", name, e.Name))
+				continue
+			}
+			if injected[e.Name] {
+				continue // the operator sets it to the dependency's address
+			}
+			for other := range hosts {
+				if other != name && composeHostPattern(other).MatchString(e.Value) {
+					calls[source.DNSLabel(other)] = true
+				}
+			}
+			value, refersToBacking := rewriteComposeHosts(e.Value, hosts, backing)
+			if refersToBacking {
+				notes = append(notes, fmt.Sprintf("%s: %s points at a backing service — use the connection vars kindling injects instead", name, e.Name))
+			}
+			c.Env = append(c.Env, EnvVar{Name: e.Name, Value: value})
+		}
+		if svc.EnvFile.Kind != 0 {
+			notes = append(notes, fmt.Sprintf("%s: env_file is not converted — load it with kindling secrets sync --from-env-file", name))
+		}
+		c.DependsOn = slices.Sorted(maps.Keys(calls))
+		for _, v := range composeVolumes(svc.Volumes) {
+			if !v.bind {
+				notes = append(notes, fmt.Sprintf("%s: volume %s is not converted — components run without persistent storage", name, v.target))
+			}
+		}
+		components = append(components, c)
+	}
+
+	// Backing services nothing depends on would otherwise be dropped.
+	used := map[string]bool{}
+	for _, name := range apps {
+		for _, dep := range composeNames(file.Services[name].DependsOn) {
+			used[dep] = true
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(backing)) {
+		if !used[name] {
+			notes = append(notes, fmt.Sprintf("%s (%s) is not in any depends_on — add it to a component's dependencies if one uses it", name, backing[name]))
+		}
+	}
+	return components, notes, nil
+}
+
+// composeBuild returns the context and dockerfile of a build entry, which
+// is either the context path or a mapping.
+func composeBuild(n yaml.Node) (context, dockerfile string) {
+	if n.Kind == yaml.ScalarNode {
+		return n.Value, ""
+	}
+	var build struct {
+		Context    string `yaml:"context"`
+		Dockerfile string `yaml:"dockerfile"`
+	}
+	_ = n.Decode(&build)
+	if build.Context == "" {
+		build.Context = "."
+	}
+	return build.Context, build.Dockerfile
+}
+
+// composePort returns the first container port of a ports or expose list:
+// "8080", "3000:8080", "127.0.0.1:3000:8080/tcp", "8080-8081", or a
+// mapping with target. It returns 0 when there is none.
+func composePort(entries []yaml.Node) int {
+	for _, n := range entries {
+		if n.Kind == yaml.MappingNode {
+			var long struct {
+				Target int `yaml:"target"`
+			}
+			if n.Decode(&long) == nil && long.Target > 0 {
+				return long.Target
+			}
+			continue
+		}
+		parts := strings.Split(strings.Split(n.Value, "/")[0], ":")
+		container := strings.Split(parts[len(parts)-1], "-")[0]
+		if port, err := strconv.Atoi(container); err == nil && port > 0 {
+			return port
+		}
+	}
+	return 0
+}
+
+// composeEnv returns the environment entries, from a mapping or a list of
+// NAME=value strings. Entries without a value pass the host's through and
+// are returned with an empty value.
+func composeEnv(n yaml.Node) []EnvVar {
+	var env []EnvVar
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			value := n.Content[i+1]
+			v := value.Value
+			if value.Tag == "!!null" {
+				v = ""
+			}
+			env = append(env, EnvVar{Name: n.Content[i].Value, Value: v})
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			name, value, _ := strings.Cut(item.Value, "=")
+			env = append(env, EnvVar{Name: name, Value: value})
+		}
+	}
+	return env
+}
+
+// composeNames returns the service names of a depends_on entry, which is
+// either a list or a mapping of name to condition.
+func composeNames(n yaml.Node) []string {
+	var names []string
+	switch n.Kind {
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			names = append(names, item.Value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(n.Content); i += 2 {
+			names = append(names, n.Content[i].Value)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// composeVolumes parses the short ("src:target[:mode]" or "target") and
+// long forms of a service's volumes.
+func composeVolumes(entries []yaml.Node) []composeVolume {
+	var volumes []composeVolume
+	for _, n := range entries {
+		var v composeVolume
+		if n.Kind == yaml.MappingNode {
+			var long struct {
+				Type   string `yaml:"type"`
+				Source string `yaml:"source"`
+				Target string `yaml:"target"`
+			}
+			if n.Decode(&long) != nil {
+				continue
+			}
+			v = composeVolume{source: long.Source, target: long.Target, bind: long.Type == "bind"}
+		} else {
+			parts := strings.Split(n.Value, ":")
+			if len(parts) == 1 {
+				v.target = parts[0]
+			} else {
+				v.source, v.target = parts[0], parts[1]
+				v.bind = strings.HasPrefix(v.source, ".") || strings.HasPrefix(v.source, "/") || strings.HasPrefix(v.source, "~")
+			}
+		}
+		volumes = append(volumes, v)
+	}
+	return volumes
+}
+
+// composeHealthPath returns the path of the URL a healthcheck test probes,
+// or "" for tests that aren't HTTP requests (which become TCP probes).
+func composeHealthPath(test yaml.Node) string {
+	var cmd string
+	switch test.Kind {
+	case yaml.ScalarNode:
+		cmd = test.Value
+	case yaml.SequenceNode:
+		var parts []string
+		for _, item := range test.Content {
+			parts = append(parts, item.Value)
+		}
+		cmd = strings.Join(parts, " ")
+	}
+	m := composeHealthURL.FindStringSubmatch(cmd)
+	if m == nil {
+		return ""
+	}
+	if m[1] == "" {
+		return "/"
+	}
+	return m[1]
+}
+
+// rewriteComposeHosts replaces compose service names used as hosts in
+// value (http://api:8080, api:8080) with the in-cluster Service names, and
+// reports whether value refers to a backing service.
+func rewriteComposeHosts(value string, hosts, backing map[string]string) (string, bool) {
+	refersToBacking := false
+	for name := range backing {
+		if composeHostPattern(name).MatchString(value) {
+			refersToBacking = true
+		}
+	}
+	for name, host := range hosts {
+		value = composeHostPattern(name).ReplaceAllString(value, "${1}"+host+"${2}")
+	}
+	return value, refersToBacking
+}
+
+// composeHostPattern matches name as a host: after a scheme or userinfo,
+// or alone, and followed by a port, path, or the end of the value.
+func composeHostPattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|://|@)` + regexp.QuoteMeta(name) + `(:\d|/|$)`)
+}
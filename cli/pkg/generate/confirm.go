@@ -0,0 +1,64 @@
+package generate
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Confirm records components as confirmed by the user — in kindling
+// generate, by its --interactive wizard — so the AI prompt states them
+// instead of the detected guesses.
+func (r *Repo) Confirm(components []*Component) {
+	r.confirmedComponents = components
+	r.healthChecks = map[string]string{}
+	r.ingressProtocols = map[string]string{}
+	for _, c := range components {
+		r.healthChecks[c.Dir] = c.HealthPath
+		if c.Protocol != "" {
+			r.ingressProtocols[c.Dir] = c.Protocol
+		}
+	}
+}
+
+// writeConfirmedComponents adds the wizard's answers to the AI prompt.
+func writeConfirmedComponents(b *strings.Builder, components []*Component) {
+	b.WriteString("## Components confirmed by the user\n\n")
+	b.WriteString("The user reviewed the repository and confirmed exactly these components.\n")
+	b.WriteString("Build and deploy only these, with these names, ports, health checks,\n")
+	b.WriteString("environment variables, and dependencies. They override anything you\n")
+	b.WriteString("would infer from the files above.\n\n")
+	for _, c := range components {
+		fmt.Fprintf(b, "### %s\n", c.Name)
+		fmt.Fprintf(b, "- build context: %s\n", filepath.ToSlash(c.Dir))
+		fmt.Fprintf(b, "- container port: %d\n", c.Port)
+		if c.Replicas > 1 {
+			fmt.Fprintf(b, "- replicas: %d\n", c.Replicas)
+		}
+		if c.HealthPath != "" {
+			fmt.Fprintf(b, "- health check: HTTP GET %s\n", c.HealthPath)
+		} else {
+			b.WriteString("- health check: none (use health-check-type: \"tcp\")\n")
+		}
+		if c.Protocol != "" {
+			fmt.Fprintf(b, "- ingress protocol: %s\n", c.Protocol)
+		}
+		if len(c.Dependencies) > 0 {
+			fmt.Fprintf(b, "- dependencies: %s\n", strings.Join(slices.Sorted(maps.Keys(c.Dependencies)), ", "))
+		} else {
+			b.WriteString("- dependencies: none\n")
+		}
+		if len(c.Env) > 0 {
+			env := make([]string, 0, len(c.Env))
+			for _, e := range c.Env {
+				env = append(env, e.String())
+			}
+			sort.Strings(env)
+			fmt.Fprintf(b, "- environment variables: %s\n", strings.Join(env, ", "))
+		}
+		b.WriteString("\n")
+	}
+}
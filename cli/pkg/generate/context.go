@@ -0,0 +1,497 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+// ── Prompt context ──────────────────────────────────────────────
+//
+// A monorepo's Dockerfiles, manifests, and sources don't fit in a prompt,
+// so the scan only collects candidate files and the prompt is packed
+// within a token budget (--context-tokens). Files are ranked by what they
+// tell the model — Dockerfiles, compose files, and dependency manifests
+// first, then entry points, routers, and files that read env vars — and
+// cut into chunks; the best chunks go in until the budget is spent. What
+// doesn't fit is listed by name. --exclude drops paths from the scan and
+// --include pins files in, ahead of everything else.
+
+const (
+	// DefaultContextTokens is the prompt budget when neither
+	// --context-tokens nor llm.contextTokens is set. It leaves room for
+	// the reply in every supported model's context window.
+	DefaultContextTokens = 32000
+
+	// contextChunkLines is the size of the pieces files are cut into.
+	contextChunkLines = 60
+	// contextMaxFileSize skips generated and data files.
+	contextMaxFileSize = 256 << 10
+	// contextMinTokens is the least the repo's content can be given.
+	contextMinTokens = 1000
+	// contextOmittedListed caps the list of files left out.
+	contextOmittedListed = 30
+)
+
+// Kinds of prompt context files, in the order their sections appear.
+const (
+	contextDockerfile = "dockerfile"
+	contextCompose    = "compose"
+	contextManifest   = "manifest"
+	contextSource     = "source"
+	contextIncluded   = "included"
+)
+
+// contextFile is a file the scan found that may go in the prompt.
+type contextFile struct {
+	path   string // relative to the repo, slash-separated
+	kind   string
+	pinned bool // matched --include
+}
+
+// ContextStats describes the repo content of a prompt.
+type ContextStats struct {
+	Tokens    int      // estimated tokens of the repo content
+	Budget    int      // what it was allowed
+	Files     int      // candidate files
+	Shown     int      // files with at least one chunk in the prompt
+	Truncated int      // files shown in part
+	Omitted   []string // files left out
+}
+
+// sourcePriority ranks well-known entry points and app files: 1 for
+// primary entry points, 2 for server and app setup, 3 for secondary
+// config and routing.
+var sourcePriority = map[string]int{
+	// Tier 1: primary entry points
+	"main.go": 1, "main.py": 1, "app.py": 1, "app.js": 1, "app.ts": 1,
+	"main.rs": 1, "main.java": 1, "main.kt": 1,
+	"main.cs": 1, "Program.cs": 1, "Startup.cs": 1,
+	"main.zig": 1, "main.c": 1, "main.cpp": 1,
+	"lib.rs":         1,                 // Rust lib entry
+	"application.ex": 1, "router.ex": 1, // Elixir/Phoenix
+	"Main.jl": 1, // Julia
+	// Tier 2: common server/app files
+	"index.js": 2, "index.ts": 2, "index.tsx": 2,
+	"server.go": 2, "server.js": 2, "server.ts": 2,
+	"app.rb": 2, "config.ru": 2, // Ruby/Rails
+	"manage.py": 2, "wsgi.py": 2, "asgi.py": 2, // Django
+	"artisan": 2, "index.php": 2, // PHP/Laravel
+	"Application.java": 2, "Application.kt": 2, // Spring Boot
+	"mix.exs": 2, "endpoint.ex": 2, // Elixir/Phoenix
+	"App.vue": 2, "App.svelte": 2, // Frontend SPA
+	"nuxt.config.ts": 2, "next.config.js": 2, "next.config.ts": 2,
+	"vite.config.ts": 2, "vite.config.js": 2,
+	// Tier 3: secondary config/setup files
+	"settings.py": 3, "urls.py": 3, // Django
+	"routes.rb":  3,                  // Rails
+	"startup.cs": 3, "program.cs": 3, // .NET (lowercase)
+	"build.zig": 3, // Zig build
+}
+
+var (
+	// routerFileName matches source files that usually register routes.
+	routerFileName = regexp.MustCompile(`(?i)(route|router|urls|handler|controller|endpoint|api)`)
+	// serveHint matches lines that bind a port or read one.
+	serveHint = regexp.MustCompile(`(?i)\b(listen|port|serve|addr|bind)\b`)
+)
+
+// ── Path filters ────────────────────────────────────────────────
+
+// PathFilter holds the --include and --exclude globs. A pattern matches
+// a path or any of its parent directories; "*" and "?" stay within a path
+// segment, "**" spans them, and a pattern without "/" matches at any
+// depth, like in .gitignore.
+type PathFilter struct {
+	include, exclude []*regexp.Regexp
+	// reach holds the directories named by include patterns, which are
+	// walked even where the scan normally doesn't go.
+	reach []string
+}
+
+// NewPathFilter compiles the globs.
+func NewPathFilter(include, exclude []string) (PathFilter, error) {
+	var f PathFilter
+	for _, p := range include {
+		re, err := compileGlob(p)
+		if err != nil {
+			return f, fmt.Errorf("invalid --include pattern %q: %w", p, err)
+		}
+		f.include = append(f.include, re)
+		if dir := globLiteralDir(p); dir != "" {
+			f.reach = append(f.reach, dir)
+		}
+	}
+	for _, p := range exclude {
+		re, err := compileGlob(p)
+		if err != nil {
+			return f, fmt.Errorf("invalid --exclude pattern %q: %w", p, err)
+		}
+		f.exclude = append(f.exclude, re)
+	}
+	return f, nil
+}
+
+// compileGlob turns a glob into a regexp matching whole slash-separated
+// paths.
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	p := strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(p, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; c {
+		case '*':
+			if strings.HasPrefix(p[i:], "**/") {
+				b.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(p[i:], "**") {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(p[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [")
+			}
+			b.WriteString(p[i : i+end+1])
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// globLiteralDir returns the directories of a pattern before its first
+// wildcard, "" when there are none.
+func globLiteralDir(pattern string) string {
+	p := strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	if i := strings.IndexAny(p, "*?["); i >= 0 {
+		p = p[:max(strings.LastIndexByte(p[:i], '/'), 0)]
+	}
+	return strings.Trim(p, "/")
+}
+
+// inSkippedDir reports whether rel lies under a directory source.SkipDir skips.
+func inSkippedDir(rel string) bool {
+	dirs := strings.Split(filepath.ToSlash(filepath.Dir(rel)), "/")
+	for _, d := range dirs {
+		if source.SkipDir(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether rel or one of its parent directories matches
+// one of the patterns.
+func matchesAny(patterns []*regexp.Regexp, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, re := range patterns {
+		for p := rel; p != "."; p = filepath.ToSlash(filepath.Dir(p)) {
+			if re.MatchString(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f PathFilter) excluded(rel string) bool { return matchesAny(f.exclude, rel) }
+func (f PathFilter) included(rel string) bool { return matchesAny(f.include, rel) }
+
+// reaches reports whether an include pattern names dir or something in
+// it, so a directory the scan skips is walked anyway.
+func (f PathFilter) reaches(dir string) bool {
+	dir = filepath.ToSlash(dir)
+	for _, r := range f.reach {
+		if r == dir || strings.HasPrefix(r, dir+"/") || strings.HasPrefix(dir, r+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ── Packing ─────────────────────────────────────────────────────
+
+// contextChunk is a run of lines of a candidate file.
+type contextChunk struct {
+	file       int // index into the candidates
+	index      int // position in the file
+	start, end int // 1-based lines, inclusive
+	text       string
+	score      int
+	tokens     int
+}
+
+// buildRepoContext renders the repo's tree and the best-ranked chunks of
+// its files within budget tokens.
+func buildRepoContext(ctx *Repo, budget int) (string, ContextStats) {
+	stats := ContextStats{Budget: budget, Files: len(ctx.contextFiles)}
+	var out strings.Builder
+
+	out.WriteString("## Repository structure\n```\n")
+	out.WriteString(summarizeTree(ctx.tree, budget/5))
+	out.WriteString("```\n\n")
+	left := budget - EstimateTokens(out.String()) - contextOmittedListed*10
+
+	files := ctx.contextFiles
+	var chunks []contextChunk
+	lines := make([]int, len(files))
+	for i, f := range files {
+		data, err := os.ReadFile(filepath.Join(ctx.Root, filepath.FromSlash(f.path)))
+		if err != nil || len(data) > contextMaxFileSize || bytes.IndexByte(data, 0) >= 0 {
+			continue
+		}
+		text := strings.TrimRight(string(data), "\n")
+		score := contextScore(f, text)
+		fileLines := strings.Split(text, "\n")
+		lines[i] = len(fileLines)
+		for c, start := 0, 0; start < len(fileLines); c, start = c+1, start+contextChunkLines {
+			end := min(start+contextChunkLines, len(fileLines))
+			chunk := strings.Join(fileLines[start:end], "\n")
+			chunks = append(chunks, contextChunk{
+				file: i, index: c, start: start + 1, end: end, text: chunk,
+				score: chunkScore(f, score, c, chunk), tokens: EstimateTokens(chunk) + 1,
+			})
+		}
+	}
+
+	// Greedy by score; a file's header is paid for with its first chunk.
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].score > chunks[j].score })
+	picked := make([][]contextChunk, len(files))
+	for _, c := range chunks {
+		cost := c.tokens
+		if picked[c.file] == nil {
+			cost += EstimateTokens(files[c.file].path) + 12
+		} else {
+			cost += 8 // an omission marker
+		}
+		if cost > left {
+			continue
+		}
+		left -= cost
+		picked[c.file] = append(picked[c.file], c)
+	}
+
+	sections := []struct{ kind, title string }{
+		{contextIncluded, "Files you asked to include"},
+		{contextDockerfile, "Dockerfiles"},
+		{contextCompose, "Compose files"},
+		{contextManifest, "Dependency manifests"},
+		{contextSource, "Key source files (ranked by relevance)"},
+	}
+	for _, sec := range sections {
+		var paths []int
+		for i, f := range files {
+			if f.kind == sec.kind && picked[i] != nil {
+				paths = append(paths, i)
+			}
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		sort.Slice(paths, func(a, b int) bool { return files[paths[a]].path < files[paths[b]].path })
+		fmt.Fprintf(&out, "## %s\n\n", sec.title)
+		for _, i := range paths {
+			writeContextFile(&out, files[i], picked[i], lines[i])
+			stats.Shown++
+			if len(picked[i]) < (lines[i]+contextChunkLines-1)/contextChunkLines {
+				stats.Truncated++
+			}
+		}
+	}
+
+	for i, f := range files {
+		if picked[i] == nil {
+			stats.Omitted = append(stats.Omitted, f.path)
+		}
+	}
+	if len(stats.Omitted) > 0 {
+		out.WriteString("## Not shown (over the context budget)\n\n")
+		for i, p := range stats.Omitted {
+			if i == contextOmittedListed {
+				fmt.Fprintf(&out, "- … and %d more\n", len(stats.Omitted)-i)
+				break
+			}
+			fmt.Fprintf(&out, "- %s\n", p)
+		}
+		out.WriteString("\n")
+	}
+	stats.Tokens = EstimateTokens(out.String())
+	return out.String(), stats
+}
+
+// writeContextFile writes the picked chunks of a file in order, marking
+// the lines left out.
+func writeContextFile(out *strings.Builder, f contextFile, chunks []contextChunk, total int) {
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].index < chunks[j].index })
+	lang := ""
+	switch f.kind {
+	case contextDockerfile:
+		lang = "dockerfile"
+	case contextCompose:
+		lang = "yaml"
+	case contextSource, contextIncluded:
+		lang = strings.TrimPrefix(filepath.Ext(f.path), ".")
+	}
+	fmt.Fprintf(out, "### %s\n```%s\n", f.path, lang)
+	next := 1
+	for _, c := range chunks {
+		if c.start > next {
+			fmt.Fprintf(out, "... (lines %d–%d omitted)\n", next, c.start-1)
+		}
+		out.WriteString(c.text)
+		out.WriteString("\n")
+		next = c.end + 1
+	}
+	if next <= total {
+		fmt.Fprintf(out, "... (%d more lines omitted)\n", total-next+1)
+	}
+	out.WriteString("```\n\n")
+}
+
+// contextScore ranks a file by what it tells the model about building and
+// running the app. Shallower files win ties.
+func contextScore(f contextFile, text string) int {
+	depth := strings.Count(f.path, "/")
+	score := 0
+	switch {
+	case f.pinned:
+		score = 1000
+	case f.kind == contextDockerfile:
+		score = 900
+	case f.kind == contextCompose:
+		score = 850
+	case f.kind == contextManifest:
+		score = 800
+	default:
+		switch sourcePriority[filepath.Base(f.path)] {
+		case 1:
+			score = 700
+		case 2:
+			score = 600
+		case 3:
+			score = 400
+		default:
+			switch {
+			case routerFileName.MatchString(filepath.Base(f.path)) || definesRoutes(text):
+				score = 500
+			case readsEnv(text):
+				score = 450
+			default:
+				score = 100
+			}
+		}
+	}
+	return score - 5*depth
+}
+
+// chunkScore ranks a chunk of a file scored score: the head of a file
+// ranks as the file, later chunks of source code rank far lower unless
+// they register routes, bind ports, or read env vars.
+func chunkScore(f contextFile, score, index int, text string) int {
+	switch {
+	case index == 0:
+		return score
+	case f.kind != contextSource:
+		return score - 10*index
+	case definesRoutes(text) || readsEnv(text) || serveHint.MatchString(text):
+		return score - 50 - index
+	default:
+		return score - 300 - index
+	}
+}
+
+// definesRoutes reports whether text registers an HTTP route.
+func definesRoutes(text string) bool {
+	for _, re := range healthRouteDefs {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// readsEnv reports whether text reads an env var.
+func readsEnv(text string) bool {
+	for _, p := range envVarAccessPatterns {
+		if strings.Contains(text, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeTree returns the file list, or, when it is over maxTokens,
+// directories collapsed to a file count at the deepest level that fits.
+func summarizeTree(tree string, maxTokens int) string {
+	if EstimateTokens(tree) <= maxTokens {
+		return tree
+	}
+	paths := strings.Split(strings.TrimRight(tree, "\n"), "\n")
+	for depth := 3; depth >= 1; depth-- {
+		counts := map[string]int{}
+		var entries []string
+		for _, p := range paths {
+			parts := strings.Split(filepath.ToSlash(p), "/")
+			if len(parts) <= depth {
+				entries = append(entries, p)
+				continue
+			}
+			dir := strings.Join(parts[:depth], "/") + "/"
+			if counts[dir] == 0 {
+				entries = append(entries, dir)
+			}
+			counts[dir]++
+		}
+		var b strings.Builder
+		for _, e := range entries {
+			if n := counts[e]; n > 0 {
+				fmt.Fprintf(&b, "%s (%d files)\n", e, n)
+			} else {
+				b.WriteString(e + "\n")
+			}
+		}
+		if EstimateTokens(b.String()) <= maxTokens || depth == 1 {
+			return truncateTree(b.String(), maxTokens)
+		}
+	}
+	return ""
+}
+
+// truncateTree cuts a tree listing to maxTokens, noting how many lines
+// are left out.
+func truncateTree(tree string, maxTokens int) string {
+	if EstimateTokens(tree) <= maxTokens {
+		return tree
+	}
+	lines := strings.SplitAfter(tree, "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		if EstimateTokens(b.String()+line) > maxTokens-10 {
+			fmt.Fprintf(&b, "... (%d more)\n", len(lines)-i)
+			break
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
@@ -0,0 +1,245 @@
+package generate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+// ── Self-correction ─────────────────────────────────────────────
+//
+// The model's workflow goes through the validate checks before anything is
+// written. When they find problems the model can fix, the findings are
+// sent back with the original request for another attempt, up to
+// --max-corrections rounds; the attempt with the fewest problems wins.
+// Every attempt is kept in .kindling/generate-history/<run>/, so prompts
+// and models can be compared on the same repo. A reply that can't be
+// turned into a workflow at all — a deploy plan that doesn't decode — is
+// a problem to correct like any other.
+
+const (
+	// DefaultCorrections is how many times the model is asked to fix
+	// its workflow when neither --max-corrections nor llm.corrections is
+	// set.
+	DefaultCorrections = 2
+
+	// generateHistoryDir holds one directory per AI run, under .kindling.
+	generateHistoryDir = "generate-history"
+	// generateHistoryKeep is how many runs are kept.
+	generateHistoryKeep = 20
+)
+
+// uncorrectableChecks find problems the workflow can't fix: a missing
+// Dockerfile has to be added to the repo, and capacity isn't checked here.
+var uncorrectableChecks = map[string]bool{
+	"missing_dockerfile":    true,
+	"insufficient_capacity": true,
+}
+
+// generateAttempt is one answer of the model, as recorded in the history.
+type generateAttempt struct {
+	Attempt  int                  `json:"attempt"`
+	File     string               `json:"file"`
+	Errors   int                  `json:"errors"`
+	Warnings int                  `json:"warnings"`
+	Findings []devstaging.Finding `json:"findings"`
+	Usage    Usage                `json:"usage"`
+}
+
+// generateRun is the attempts.json of a history directory.
+type generateRun struct {
+	Provider string            `json:"provider"`
+	Model    string            `json:"model"`
+	Started  time.Time         `json:"started"`
+	Chosen   int               `json:"chosen"`
+	Attempts []generateAttempt `json:"attempts"`
+}
+
+// Workflow asks the model for a workflow, then for up to
+// rounds corrections of it within budget, and returns the attempt with the
+// fewest problems.
+func Workflow(generator Generator, format ReplyFormat, systemPrompt, userPrompt, repoPath string, rounds int, budget *Budget, opts Options) (string, error) {
+	run := generateRun{Provider: generator.Name(), Model: generator.Model(), Started: time.Now()}
+	dir := generateHistoryRunDir(repoPath, run.Started)
+
+	var best string
+	var lastErr error
+	bestScore := -1
+	prompt := userPrompt
+	for attempt := 1; ; attempt++ {
+		maxTokens, err := budget.ReplyLimit(systemPrompt + prompt)
+		if err != nil {
+			if best == "" {
+				return "", err
+			}
+			opts.progress(Warning, fmt.Sprintf("Stopping corrections: %v — keeping attempt %d", err, run.Chosen))
+			break
+		}
+		reply, usage, err := format.generate(generator, systemPrompt, prompt, maxTokens)
+		budget.Spend(usage)
+		if err != nil && attempt == 1 && !errors.Is(err, ErrUnavailable) {
+			if _, ok := format.(PlanReply); ok {
+				opts.progress(Warning, fmt.Sprintf("%s didn't take the deploy plan schema (%v) — asking for YAML instead; pass --structured=false to skip this", generator.Model(), err))
+				format = YAMLReply{}
+				attempt--
+				continue
+			}
+		}
+		if err != nil {
+			if best == "" && lastErr == nil {
+				return "", fmt.Errorf("AI generation failed: %w", err)
+			}
+			opts.progress(Warning, fmt.Sprintf("Correction round %d failed: %v — keeping the best attempt so far", attempt-1, err))
+			break
+		}
+
+		workflow, answer, err := format.workflow(reply)
+		var report devstaging.Report
+		if err != nil {
+			lastErr = err
+			report = devstaging.Report{Errors: 1, Findings: []devstaging.Finding{{Severity: devstaging.SeverityError, Check: "plan", Detail: err.Error()}}}
+		} else {
+			report = devstaging.Validate([]byte(workflow+"\n"), devstaging.Options{RepoPath: repoPath, HostMounts: opts.HostMounts})
+		}
+		problems := correctableFindings(report)
+		file := fmt.Sprintf("attempt-%d.%s", attempt, format.lang())
+		run.Attempts = append(run.Attempts, generateAttempt{
+			Attempt: attempt, File: file, Errors: report.Errors, Warnings: report.Warnings, Findings: report.Findings, Usage: usage,
+		})
+		writeGenerateHistory(dir, file, []byte(answer+"\n"), opts.log())
+		if workflow != answer && workflow != "" {
+			writeGenerateHistory(dir, fmt.Sprintf("attempt-%d.yaml", attempt), []byte(workflow+"\n"), opts.log())
+		}
+		opts.log().Info("generate attempt", "attempt", attempt, "errors", report.Errors, "warnings", report.Warnings, "correctable", len(problems),
+			"prompt_tokens", usage.Prompt, "completion_tokens", usage.Completion)
+
+		if score := problemScore(problems); workflow != "" && (bestScore < 0 || score < bestScore) {
+			best, bestScore, run.Chosen = workflow, score, attempt
+		}
+		if len(problems) == 0 {
+			if attempt > 1 {
+				opts.progress(Done, fmt.Sprintf("Attempt %d passes the checks", attempt))
+			}
+			break
+		}
+		if attempt > rounds {
+			if best == "" {
+				break
+			}
+			if rounds > 0 {
+				opts.progress(Warning, fmt.Sprintf("%d problem(s) left after %d correction round(s) — keeping attempt %d", len(problems), rounds, run.Chosen))
+			}
+			break
+		}
+		opts.progress(Started, fmt.Sprintf("Attempt %d has %d problem(s) — asking the model to fix them (round %d of %d)", attempt, len(problems), attempt, rounds))
+		prompt = correctionPrompt(userPrompt, answer, format.lang(), problems)
+	}
+
+	if data, err := json.MarshalIndent(run, "", "  "); err == nil {
+		writeGenerateHistory(dir, "attempts.json", append(data, '\n'), opts.log())
+	}
+	pruneGenerateHistory(filepath.Dir(dir))
+	if best == "" {
+		return "", fmt.Errorf("no answer of the model could be turned into a workflow: %w", lastErr)
+	}
+	return best, nil
+}
+
+// correctableFindings returns the errors and warnings the model can fix.
+func correctableFindings(r devstaging.Report) []devstaging.Finding {
+	var out []devstaging.Finding
+	for _, f := range r.Findings {
+		if f.Severity != devstaging.SeverityInfo && !uncorrectableChecks[f.Check] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// problemScore ranks attempts: fewer errors first, then fewer warnings.
+func problemScore(findings []devstaging.Finding) int {
+	score := 0
+	for _, f := range findings {
+		if f.Severity == devstaging.SeverityError {
+			score += 1000
+		} else {
+			score++
+		}
+	}
+	return score
+}
+
+// correctionPrompt repeats the request with the previous answer and what
+// is wrong with it.
+func correctionPrompt(userPrompt, answer, lang string, problems []devstaging.Finding) string {
+	var b strings.Builder
+	b.WriteString(userPrompt)
+	fmt.Fprintf(&b, "\n\n---\n\nYour previous answer was:\n\n```%s\n", lang)
+	b.WriteString(answer)
+	b.WriteString("\n```\n\nkindling validate found these problems in it:\n")
+	for _, f := range problems {
+		target := ""
+		if f.Resource != "" {
+			target = f.Resource + ": "
+		}
+		fmt.Fprintf(&b, "- %s [%s] %s%s\n", f.Severity, f.Check, target, f.Detail)
+	}
+	if lang == "json" {
+		b.WriteString("\nFix every problem and return the complete corrected deploy plan. Keep everything " +
+			"that was correct unchanged.\n")
+	} else {
+		b.WriteString("\nFix every problem and return the complete corrected workflow. Keep everything " +
+			"that was correct unchanged. Output ONLY the YAML, with no explanation.\n")
+	}
+	return b.String()
+}
+
+// generateHistoryRunDir returns the history directory of a run started at
+// started.
+func generateHistoryRunDir(repoPath string, started time.Time) string {
+	return filepath.Join(repoPath, ".kindling", generateHistoryDir, started.Format("20060102-150405"))
+}
+
+// writeGenerateHistory writes one file of a run. The history is a record,
+// not a result: failing to write it is logged and otherwise ignored. The
+// history directory gets a .gitignore of its own.
+func writeGenerateHistory(dir, name string, data []byte, log *slog.Logger) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Warn("cannot write the generate history", "error", err)
+		return
+	}
+	ignore := filepath.Join(filepath.Dir(dir), ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		_ = os.WriteFile(ignore, []byte("*\n"), 0o644)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		log.Warn("cannot write the generate history", "file", name, "error", err)
+	}
+}
+
+// pruneGenerateHistory removes all but the newest generateHistoryKeep runs.
+func pruneGenerateHistory(historyDir string) {
+	entries, err := os.ReadDir(historyDir)
+	if err != nil {
+		return
+	}
+	var runs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			runs = append(runs, e.Name())
+		}
+	}
+	sort.Strings(runs)
+	for len(runs) > generateHistoryKeep {
+		_ = os.RemoveAll(filepath.Join(historyDir, runs[0]))
+		runs = runs[1:]
+	}
+}
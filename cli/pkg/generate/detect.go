@@ -0,0 +1,400 @@
+package generate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+)
+
+// ── Framework detectors ─────────────────────────────────────────
+//
+// A detector recognizes one framework in a component's directory and
+// reports what the scan can't work out generically: the port the framework
+// listens on, its health route, how to build and start it, and the backing
+// services it needs. Built-in detectors cover Phoenix, Rails, and Spring
+// Boot. Any executable in ~/.kindling/detectors/ is a detector too: it
+// gets a detectorInput as JSON on stdin and prints a Framework as
+// JSON on stdout, or nothing when it doesn't recognize the directory.
+// External detectors run first, so they can override the built-in ones;
+// the first detection for a directory wins.
+
+const (
+	// detectorsDirName holds external detectors, under ~/.kindling.
+	detectorsDirName = "detectors"
+	// detectorTimeout bounds one run of an external detector.
+	detectorTimeout = 10 * time.Second
+)
+
+// frameworkDetector recognizes a framework.
+type frameworkDetector interface {
+	Name() string
+	// Detect returns what it knows about the component in in.Dir, or nil
+	// when it doesn't recognize the framework.
+	Detect(in detectorInput) (*Framework, error)
+}
+
+// detectorInput is what a detector is given about one directory.
+type detectorInput struct {
+	Repo  string            `json:"repo"`  // absolute path of the repository
+	Dir   string            `json:"dir"`   // the directory, relative to the repo, slash-separated
+	Files map[string]string `json:"files"` // its dependency manifests and Dockerfiles, by path relative to the repo
+}
+
+// Framework is what a detector found. Zero fields are unknown.
+type Framework struct {
+	Name       string     `json:"framework"`
+	Port       int        `json:"port,omitempty"`
+	HealthPath string     `json:"healthPath,omitempty"`
+	Build      BuildHints `json:"build,omitempty"`
+	Services   []string   `json:"services,omitempty"` // dependency types, e.g. postgres or redis
+
+	detector string // name of the detector that found it
+}
+
+// BuildHints describe how to build the component's image when kindling has
+// no Dockerfile template for it.
+type BuildHints struct {
+	Image string `json:"image,omitempty"` // base image
+	Build string `json:"build,omitempty"` // shell command run after the sources are copied
+	Start string `json:"start,omitempty"` // shell command that starts the app
+}
+
+// DetectFrameworks runs the detectors on every directory holding a
+// dependency manifest or a Dockerfile and returns the detections by
+// directory. A detector that fails is reported and skipped.
+func DetectFrameworks(repoPath string, ctx *Repo, opts Options) map[string]*Framework {
+	files := map[string]map[string]string{}
+	for _, m := range []map[string]string{ctx.depFiles, ctx.dockerfiles} {
+		for rel, content := range m {
+			dir := filepath.Dir(rel)
+			if files[dir] == nil {
+				files[dir] = map[string]string{}
+			}
+			files[dir][filepath.ToSlash(rel)] = content
+		}
+	}
+	dirs := make([]string, 0, len(files))
+	for d := range files {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+
+	detectors := append(loadExternalDetectors(opts.log()), builtinDetectors...)
+	failed := map[string]bool{}
+	found := map[string]*Framework{}
+	for _, dir := range dirs {
+		in := detectorInput{Repo: repoPath, Dir: filepath.ToSlash(dir), Files: files[dir]}
+		for _, d := range detectors {
+			if failed[d.Name()] {
+				continue
+			}
+			det, err := d.Detect(in)
+			if err != nil {
+				opts.progress(Warning, fmt.Sprintf("Detector %s failed, skipping it: %v", d.Name(), err))
+				failed[d.Name()] = true
+				continue
+			}
+			if det == nil || det.Name == "" {
+				continue
+			}
+			det.detector = d.Name()
+			det.Services = knownServices(det, opts)
+			opts.log().Debug("framework detected", "dir", dir, "detector", d.Name(), "framework", det.Name)
+			found[dir] = det
+			break
+		}
+	}
+	return found
+}
+
+// knownServices drops the services of det the operator has no dependency
+// type for.
+func knownServices(det *Framework, opts Options) []string {
+	var out []string
+	for _, s := range det.Services {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if _, ok := devstaging.DependencyConventions[s]; !ok {
+			opts.progress(Warning, fmt.Sprintf("Detector %s: unknown service %q ignored", det.detector, s))
+			continue
+		}
+		if !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// String sums up a detection for the scan's output and the prompt.
+func (d *Framework) String() string {
+	var parts []string
+	if d.Port > 0 {
+		parts = append(parts, fmt.Sprintf("port %d", d.Port))
+	}
+	if d.HealthPath != "" {
+		parts = append(parts, "health "+d.HealthPath)
+	}
+	if len(d.Services) > 0 {
+		parts = append(parts, "needs "+strings.Join(d.Services, ", "))
+	}
+	if len(parts) == 0 {
+		return d.Name
+	}
+	return fmt.Sprintf("%s (%s)", d.Name, strings.Join(parts, ", "))
+}
+
+// applyFrameworkHealth fills in the health path of directories the route
+// scan found none for.
+func applyFrameworkHealth(health map[string]string, frameworks map[string]*Framework) {
+	for dir, path := range health {
+		if det := frameworks[dir]; path == "" && det != nil && det.HealthPath != "" {
+			health[dir] = det.HealthPath
+		}
+	}
+}
+
+// hintedDockerfile renders a Dockerfile from a detection's build hints, or
+// "" when they don't name an image and a start command.
+func hintedDockerfile(det *Framework) string {
+	h := det.Build
+	if h.Image == "" || h.Start == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(dockerfileHeader)
+	fmt.Fprintf(&b, "FROM %s\nWORKDIR /app\nCOPY . .\n", h.Image)
+	if h.Build != "" {
+		fmt.Fprintf(&b, "RUN %s\n", h.Build)
+	}
+	if det.Port > 0 {
+		fmt.Fprintf(&b, "EXPOSE %d\n", det.Port)
+	}
+	fmt.Fprintf(&b, "CMD [\"sh\", \"-c\", %q]\n", h.Start)
+	return b.String()
+}
+
+// writeDetectedFrameworks adds the detections to the AI prompt.
+func writeDetectedFrameworks(b *strings.Builder, frameworks map[string]*Framework) {
+	b.WriteString("## Detected frameworks\n\n")
+	b.WriteString("Use these ports, health paths, and dependencies unless the Dockerfile says otherwise:\n\n")
+	for _, d := range slices.Sorted(maps.Keys(frameworks)) {
+		fmt.Fprintf(b, "- %s: %s\n", d, frameworks[d].String())
+	}
+	b.WriteString("\n")
+}
+
+// ── External detectors ──────────────────────────────────────────
+
+// execDetector is an executable in ~/.kindling/detectors/.
+type execDetector struct {
+	path string
+}
+
+func (d execDetector) Name() string { return filepath.Base(d.path) }
+
+func (d execDetector) Detect(in detectorInput) (*Framework, error) {
+	input, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), detectorTimeout)
+	defer cancel()
+	c := exec.CommandContext(ctx, d.path)
+	c.Dir = in.Repo
+	c.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	out = bytes.TrimSpace(out)
+	if len(out) == 0 || string(out) == "null" {
+		return nil, nil
+	}
+	var det Framework
+	if err := json.Unmarshal(out, &det); err != nil {
+		return nil, fmt.Errorf("invalid output: %w", err)
+	}
+	return &det, nil
+}
+
+// loadExternalDetectors returns the executables in ~/.kindling/detectors/,
+// by name.
+func loadExternalDetectors(log *slog.Logger) []frameworkDetector {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	dir := filepath.Join(home, ".kindling", detectorsDirName)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var detectors []frameworkDetector
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if info.Mode()&0o111 == 0 && filepath.Ext(e.Name()) != ".exe" {
+			log.Debug("detector is not executable, skipping", "file", e.Name())
+			continue
+		}
+		detectors = append(detectors, execDetector{path: filepath.Join(dir, e.Name())})
+	}
+	return detectors
+}
+
+// ── Built-in detectors ──────────────────────────────────────────
+
+// builtinDetector is a detector compiled into kindling.
+type builtinDetector struct {
+	name   string
+	detect func(in detectorInput) *Framework
+}
+
+func (d builtinDetector) Name() string { return d.name }
+
+func (d builtinDetector) Detect(in detectorInput) (*Framework, error) {
+	return d.detect(in), nil
+}
+
+var builtinDetectors = []frameworkDetector{
+	builtinDetector{"phoenix", detectPhoenix},
+	builtinDetector{"rails", detectRails},
+	builtinDetector{"spring-boot", detectSpringBoot},
+}
+
+// serviceHints maps the client libraries of each framework's ecosystem to
+// dependency types.
+type serviceHints []struct{ hint, service string }
+
+func (h serviceHints) match(content string) []string {
+	var out []string
+	for _, s := range h {
+		if strings.Contains(content, s.hint) && !slices.Contains(out, s.service) {
+			out = append(out, s.service)
+		}
+	}
+	return out
+}
+
+// inputFile returns the content of the file called name in the directory,
+// and whether there is one.
+func (in detectorInput) inputFile(name string) (string, bool) {
+	content, ok := in.Files[strings.TrimPrefix(in.Dir+"/"+name, "./")]
+	return content, ok
+}
+
+var phoenixServices = serviceHints{
+	{":postgrex", "postgres"},
+	{":myxql", "mysql"},
+	{":redix", "redis"},
+	{":amqp", "rabbitmq"},
+}
+
+// detectPhoenix recognizes a Phoenix app by its mix.exs. kindling has no
+// Elixir Dockerfile template, so it also gives build hints.
+func detectPhoenix(in detectorInput) *Framework {
+	mix, ok := in.inputFile("mix.exs")
+	if !ok || !strings.Contains(mix, ":phoenix") {
+		return nil
+	}
+	return &Framework{
+		Name:     "phoenix",
+		Port:     4000,
+		Services: phoenixServices.match(mix),
+		Build: BuildHints{
+			Image: "elixir:1.17",
+			Build: "mix local.hex --force && mix local.rebar --force && mix deps.get && mix compile",
+			Start: "mix phx.server",
+		},
+	}
+}
+
+var (
+	railsServices = serviceHints{
+		{`"pg"`, "postgres"}, {`'pg'`, "postgres"},
+		{"mysql2", "mysql"},
+		{"redis", "redis"}, {"sidekiq", "redis"},
+		{"bunny", "rabbitmq"},
+	}
+	railsGem         = regexp.MustCompile(`(?m)^\s*gem\s+["']rails["']`)
+	railsHealthRoute = regexp.MustCompile(`rails/health#show`)
+)
+
+// detectRails recognizes a Rails app by its Gemfile. Rails 7.1 and later
+// serve a health check at /up.
+func detectRails(in detectorInput) *Framework {
+	gemfile, ok := in.inputFile("Gemfile")
+	if !ok || !railsGem.MatchString(gemfile) {
+		return nil
+	}
+	det := &Framework{Name: "rails", Port: 3000, Services: railsServices.match(gemfile)}
+	if routes, err := os.ReadFile(filepath.Join(in.Repo, in.Dir, "config", "routes.rb")); err == nil && railsHealthRoute.Match(routes) {
+		det.HealthPath = "/up"
+	}
+	return det
+}
+
+var (
+	springServices = serviceHints{
+		{"postgresql", "postgres"},
+		{"mysql-connector", "mysql"},
+		{"spring-boot-starter-data-redis", "redis"},
+		{"spring-boot-starter-data-mongodb", "mongodb"},
+		{"spring-boot-starter-amqp", "rabbitmq"},
+		{"spring-kafka", "kafka"},
+		{"spring-boot-starter-data-elasticsearch", "elasticsearch"},
+	}
+	springServerPort = regexp.MustCompile(`(?m)^\s*(?:server\.port\s*[=:]|port:)\s*(\d+)\s*$`)
+)
+
+// detectSpringBoot recognizes a Spring Boot app by its Maven or Gradle
+// build, and reads server.port from its application config.
+func detectSpringBoot(in detectorInput) *Framework {
+	var build string
+	for _, name := range []string{"pom.xml", "build.gradle", "build.gradle.kts"} {
+		if content, ok := in.inputFile(name); ok {
+			build = content
+			break
+		}
+	}
+	if !strings.Contains(build, "spring-boot") {
+		return nil
+	}
+	det := &Framework{Name: "spring-boot", Port: 8080, Services: springServices.match(build)}
+	if strings.Contains(build, "spring-boot-starter-actuator") {
+		det.HealthPath = "/actuator/health"
+	}
+	for _, name := range []string{"application.properties", "application.yml", "application.yaml"} {
+		data, err := os.ReadFile(filepath.Join(in.Repo, in.Dir, "src", "main", "resources", name))
+		if err != nil {
+			continue
+		}
+		if m := springServerPort.FindSubmatch(data); m != nil {
+			if port, err := strconv.Atoi(string(m[1])); err == nil {
+				det.Port = port
+			}
+			break
+		}
+	}
+	return det
+}
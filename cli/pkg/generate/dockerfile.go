@@ -0,0 +1,492 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Dockerfile synthesis
+// ────────────────────────────────────────────────────────────────────────────
+//
+// Every DSE needs an image, and every kindling-build step needs a
+// Dockerfile. With --synthesize-dockerfiles, generate writes a templated
+// Dockerfile for each component that has a dependency manifest but no
+// Dockerfile, so the rest of the pipeline has something to build.
+//
+// Dockerfiles go either next to the component ("repo") or into
+// .kindling/dockerfiles/<component>/ ("overlay"), which leaves the repo
+// untouched. Overlay files are only usable for local builds — in-cluster
+// Kaniko builds only see the build context, so CI needs the repo target.
+
+// Where SynthesizeDockerfiles writes.
+const (
+	DockerfileTargetRepo    = "repo"
+	DockerfileTargetOverlay = "overlay"
+)
+
+// Dockerfile is one generated Dockerfile.
+type Dockerfile struct {
+	Component string // component name (overlay sub-directory)
+	Dir       string // build context, relative to the repo root
+	Path      string // Dockerfile path, relative to the repo root
+	Language  string // e.g. "go", "node (next)", "python (fastapi)"
+	Content   string
+}
+
+// dockerfileManifests are the dependency manifests that mark a buildable
+// component, in the order languages are tried.
+var dockerfileManifests = []string{
+	"go.mod",
+	"package.json",
+	"pyproject.toml", "requirements.txt", "Pipfile",
+	"Cargo.toml",
+	"pom.xml", "build.gradle", "build.gradle.kts",
+	"Gemfile",
+}
+
+// SynthesizeDockerfiles writes a Dockerfile for every component that lacks
+// one and registers it in ctx so the generators see it. With dryRun the
+// files are only reported.
+func SynthesizeDockerfiles(repoPath string, ctx *Repo, target string, dryRun bool) ([]Dockerfile, error) {
+	var written []Dockerfile
+	for _, dir := range dirsMissingDockerfile(ctx) {
+		lang, content := renderDockerfile(repoPath, dir, ctx)
+		if content == "" {
+			continue
+		}
+
+		name := ctx.Name
+		if dir != "." {
+			name = strings.ReplaceAll(dir, string(filepath.Separator), "-")
+		}
+		name = source.DNSLabel(name)
+
+		rel := filepath.Join(dir, "Dockerfile")
+		if target == DockerfileTargetOverlay {
+			rel = filepath.Join(devstaging.DockerfileOverlayDir, name, "Dockerfile")
+		}
+
+		if !dryRun {
+			path := filepath.Join(repoPath, rel)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return written, fmt.Errorf("cannot create %s: %w", filepath.Dir(rel), err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				return written, fmt.Errorf("cannot write %s: %w", rel, err)
+			}
+		}
+
+		written = append(written, Dockerfile{
+			Component: name,
+			Dir:       dir,
+			Path:      rel,
+			Language:  lang,
+			Content:   content,
+		})
+
+		// Register the Dockerfile under its build context so port and
+		// component detection treat it like a checked-in one.
+		ctx.dockerfiles[filepath.Join(dir, "Dockerfile")] = content
+		ctx.DockerfileCount++
+		if target == DockerfileTargetOverlay {
+			if ctx.overlayDockerfiles == nil {
+				ctx.overlayDockerfiles = map[string]string{}
+			}
+			ctx.overlayDockerfiles[dir] = rel
+		}
+	}
+	return written, nil
+}
+
+// dirsMissingDockerfile returns the directories holding a dependency
+// manifest, or a framework with build hints, that are not covered by a
+// Dockerfile in the same directory or any parent directory.
+func dirsMissingDockerfile(ctx *Repo) []string {
+	covered := map[string]bool{}
+	for rel := range ctx.dockerfiles {
+		covered[filepath.Dir(rel)] = true
+	}
+
+	dirs := map[string]bool{}
+	for rel := range ctx.depFiles {
+		base := filepath.Base(rel)
+		dir := filepath.Dir(rel)
+		if det := ctx.Frameworks[dir]; !isDockerfileManifest(base) && (det == nil || det.Build.Image == "") {
+			continue
+		}
+		if isCoveredDir(covered, dir) {
+			continue
+		}
+		dirs[dir] = true
+	}
+
+	sorted := make([]string, 0, len(dirs))
+	for d := range dirs {
+		sorted = append(sorted, d)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func isDockerfileManifest(name string) bool {
+	for _, m := range dockerfileManifests {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isCoveredDir reports whether dir or one of its parents is in covered.
+func isCoveredDir(covered map[string]bool, dir string) bool {
+	for {
+		if covered[dir] {
+			return true
+		}
+		if dir == "." || dir == string(filepath.Separator) {
+			return false
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// renderDockerfile picks a template from the first manifest found in dir,
+// or else follows the build hints of the framework detected there. It
+// returns the detected language and the Dockerfile, or "" when neither
+// applies.
+func renderDockerfile(repoPath, dir string, ctx *Repo) (string, string) {
+	has := func(name string) bool {
+		_, ok := ctx.depFiles[filepath.Join(dir, name)]
+		return ok
+	}
+	abs := filepath.Join(repoPath, dir)
+
+	switch {
+	case has("go.mod"):
+		return "go", goDockerfile(abs)
+	case has("package.json"):
+		return nodeDockerfile(abs)
+	case has("pyproject.toml"), has("requirements.txt"), has("Pipfile"):
+		return pythonDockerfile(abs, ctx.depFiles, dir)
+	case has("Cargo.toml"):
+		return "rust", rustDockerfile(abs)
+	case has("pom.xml"):
+		return "java (maven)", mavenDockerfile
+	case has("build.gradle"), has("build.gradle.kts"):
+		return "java (gradle)", gradleDockerfile
+	case has("Gemfile"):
+		return rubyDockerfile(abs)
+	}
+	if det := ctx.Frameworks[dir]; det != nil {
+		if content := hintedDockerfile(det); content != "" {
+			return det.Name, content
+		}
+	}
+	return "", ""
+}
+
+// dockerfileHeader marks generated Dockerfiles so they are easy to spot.
+const dockerfileHeader = "# Generated by kindling generate --synthesize-dockerfiles — review before committing\n"
+
+// ── Go ──────────────────────────────────────────────────────────
+
+var goVersionDirective = regexp.MustCompile(`(?m)^go\s+(\d+\.\d+)`)
+
+// goDockerfile builds the main package (the root, or the only cmd/<name>)
+// in a golang builder stage and copies the static binary into distroless.
+func goDockerfile(abs string) string {
+	version := "1.22"
+	if data, err := os.ReadFile(filepath.Join(abs, "go.mod")); err == nil {
+		if m := goVersionDirective.FindSubmatch(data); m != nil {
+			version = string(m[1])
+		}
+	}
+
+	pkg := "."
+	if !fileExists(filepath.Join(abs, "main.go")) {
+		if entries, err := os.ReadDir(filepath.Join(abs, "cmd")); err == nil {
+			for _, e := range entries {
+				if e.IsDir() && fileExists(filepath.Join(abs, "cmd", e.Name(), "main.go")) {
+					pkg = "./cmd/" + e.Name()
+					break
+				}
+			}
+		}
+	}
+
+	// Without a go.sum the module graph has to be resolved in the build.
+	deps := "COPY . .\nRUN go mod tidy\n"
+	if fileExists(filepath.Join(abs, "go.sum")) {
+		deps = "COPY go.mod go.sum ./\nRUN go mod download\nCOPY . .\n"
+	}
+
+	return dockerfileHeader + fmt.Sprintf(`FROM golang:%s-alpine AS build
+WORKDIR /src
+%sRUN CGO_ENABLED=0 go build -buildvcs=false -trimpath -ldflags="-s -w" -o /out/app %s
+
+FROM gcr.io/distroless/static-debian12
+COPY --from=build /out/app /app
+EXPOSE 8080
+USER nonroot:nonroot
+ENTRYPOINT ["/app"]
+`, version, deps, pkg)
+}
+
+// ── Node.js ─────────────────────────────────────────────────────
+
+// packageJSON is the subset of package.json the Node template reads.
+type packageJSON struct {
+	Main            string            `json:"main"`
+	Scripts         map[string]string `json:"scripts"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+}
+
+// nodeFrameworks are the frameworks named in the detected language, in
+// order of precedence.
+var nodeFrameworks = []struct{ dep, label string }{
+	{"next", "next"},
+	{"nuxt", "nuxt"},
+	{"@nestjs/core", "nest"},
+	{"express", "express"},
+	{"fastify", "fastify"},
+}
+
+func nodeDockerfile(abs string) (string, string) {
+	var pkg packageJSON
+	if data, err := os.ReadFile(filepath.Join(abs, "package.json")); err == nil {
+		_ = json.Unmarshal(data, &pkg)
+	}
+
+	install := "npm install"
+	lockfile := ""
+	switch {
+	case fileExists(filepath.Join(abs, "package-lock.json")):
+		install, lockfile = "npm ci", " package-lock.json"
+	case fileExists(filepath.Join(abs, "yarn.lock")):
+		install, lockfile = "corepack enable && yarn install --frozen-lockfile", " yarn.lock"
+	case fileExists(filepath.Join(abs, "pnpm-lock.yaml")):
+		install, lockfile = "corepack enable && pnpm install --frozen-lockfile", " pnpm-lock.yaml"
+	}
+
+	lang := "node"
+	for _, fw := range nodeFrameworks {
+		if _, ok := pkg.Dependencies[fw.dep]; ok {
+			lang = "node (" + fw.label + ")"
+			break
+		}
+	}
+
+	build := ""
+	if _, ok := pkg.Scripts["build"]; ok {
+		build = "RUN npm run build\n"
+	}
+
+	cmd := `["npm", "start"]`
+	if _, ok := pkg.Scripts["start"]; !ok {
+		entry := pkg.Main
+		if entry == "" {
+			entry = "index.js"
+			for _, candidate := range []string{"server.js", "app.js", "index.js"} {
+				if fileExists(filepath.Join(abs, candidate)) {
+					entry = candidate
+					break
+				}
+			}
+		}
+		cmd = fmt.Sprintf(`["node", %q]`, entry)
+	}
+
+	return lang, dockerfileHeader + fmt.Sprintf(`FROM node:20-alpine
+ENV npm_config_cache=/tmp/.npm
+WORKDIR /app
+COPY package.json%s ./
+RUN %s
+COPY . .
+%sENV PORT=3000
+EXPOSE 3000
+CMD %s
+`, lockfile, install, build, cmd)
+}
+
+// ── Python ──────────────────────────────────────────────────────
+
+var (
+	fastAPIApp = regexp.MustCompile(`(?m)^(\w+)\s*=\s*FastAPI\(`)
+	flaskApp   = regexp.MustCompile(`(?m)^(\w+)\s*=\s*Flask\(`)
+)
+
+// pythonEntryCandidates are the files checked, in order, for an ASGI/WSGI
+// application object.
+var pythonEntryCandidates = []string{
+	"main.py", "app.py", "server.py",
+	filepath.Join("app", "main.py"), filepath.Join("src", "main.py"),
+}
+
+func pythonDockerfile(abs string, depFiles map[string]string, dir string) (string, string) {
+	manifests := strings.ToLower(depFiles[filepath.Join(dir, "requirements.txt")] +
+		depFiles[filepath.Join(dir, "pyproject.toml")] +
+		depFiles[filepath.Join(dir, "Pipfile")])
+
+	var install string
+	switch {
+	case fileExists(filepath.Join(abs, "requirements.txt")):
+		install = "COPY requirements.txt ./\nRUN pip install --no-cache-dir -r requirements.txt\nCOPY . .\n"
+	case fileExists(filepath.Join(abs, "Pipfile")):
+		install = "COPY Pipfile* ./\nRUN pip install --no-cache-dir pipenv && pipenv install --system --deploy\nCOPY . .\n"
+	default:
+		install = "COPY . .\nRUN pip install --no-cache-dir .\n"
+	}
+
+	lang := "python"
+	cmd := `["python", "main.py"]`
+
+	switch {
+	case fileExists(filepath.Join(abs, "manage.py")):
+		lang = "python (django)"
+		cmd = `["python", "manage.py", "runserver", "0.0.0.0:8000"]`
+		if strings.Contains(manifests, "gunicorn") {
+			if project := djangoProject(abs); project != "" {
+				cmd = fmt.Sprintf(`["gunicorn", "--bind", "0.0.0.0:8000", "%s.wsgi"]`, project)
+			}
+		}
+	default:
+		for _, candidate := range pythonEntryCandidates {
+			data, err := os.ReadFile(filepath.Join(abs, candidate))
+			if err != nil {
+				continue
+			}
+			module := strings.ReplaceAll(strings.TrimSuffix(candidate, ".py"), string(filepath.Separator), ".")
+			if m := fastAPIApp.FindSubmatch(data); m != nil {
+				lang = "python (fastapi)"
+				cmd = fmt.Sprintf(`["uvicorn", "%s:%s", "--host", "0.0.0.0", "--port", "8000"]`, module, m[1])
+				break
+			}
+			if m := flaskApp.FindSubmatch(data); m != nil {
+				lang = "python (flask)"
+				if strings.Contains(manifests, "gunicorn") {
+					cmd = fmt.Sprintf(`["gunicorn", "--bind", "0.0.0.0:8000", "%s:%s"]`, module, m[1])
+				} else {
+					cmd = fmt.Sprintf(`["flask", "--app", "%s:%s", "run", "--host", "0.0.0.0", "--port", "8000"]`, module, m[1])
+				}
+				break
+			}
+			cmd = fmt.Sprintf(`["python", %q]`, filepath.ToSlash(candidate))
+			break
+		}
+	}
+
+	return lang, dockerfileHeader + fmt.Sprintf(`FROM python:3.12-slim
+ENV PYTHONDONTWRITEBYTECODE=1 \
+    PYTHONUNBUFFERED=1
+WORKDIR /app
+%sEXPOSE 8000
+CMD %s
+`, install, cmd)
+}
+
+// djangoProject returns the directory next to manage.py that holds
+// wsgi.py, i.e. the Django project package.
+func djangoProject(abs string) string {
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		return ""
+	}
+	for _, e := range entries {
+		if e.IsDir() && fileExists(filepath.Join(abs, e.Name(), "wsgi.py")) {
+			return e.Name()
+		}
+	}
+	return ""
+}
+
+// ── Rust ────────────────────────────────────────────────────────
+
+var cargoPackageName = regexp.MustCompile(`(?m)^name\s*=\s*"([^"]+)"`)
+
+func rustDockerfile(abs string) string {
+	bin := "app"
+	if data, err := os.ReadFile(filepath.Join(abs, "Cargo.toml")); err == nil {
+		if m := cargoPackageName.FindSubmatch(data); m != nil {
+			bin = string(m[1])
+		}
+	}
+	return dockerfileHeader + fmt.Sprintf(`FROM rust:1-slim AS build
+WORKDIR /src
+COPY . .
+RUN cargo build --release
+
+FROM debian:bookworm-slim
+COPY --from=build /src/target/release/%[1]s /usr/local/bin/%[1]s
+EXPOSE 8080
+CMD ["%[1]s"]
+`, bin)
+}
+
+// ── Java ────────────────────────────────────────────────────────
+
+const mavenDockerfile = dockerfileHeader + `FROM maven:3.9-eclipse-temurin-21 AS build
+WORKDIR /src
+COPY pom.xml ./
+RUN mvn -B -q dependency:go-offline
+COPY . .
+RUN mvn -B -q package -DskipTests && cp target/*.jar /app.jar
+
+FROM eclipse-temurin:21-jre
+COPY --from=build /app.jar /app.jar
+EXPOSE 8080
+ENTRYPOINT ["java", "-jar", "/app.jar"]
+`
+
+const gradleDockerfile = dockerfileHeader + `FROM gradle:8-jdk21 AS build
+WORKDIR /src
+COPY . .
+RUN gradle --no-daemon -q bootJar || gradle --no-daemon -q build -x test
+RUN cp $(ls build/libs/*.jar | grep -v -- '-plain' | head -n 1) /app.jar
+
+FROM eclipse-temurin:21-jre
+COPY --from=build /app.jar /app.jar
+EXPOSE 8080
+ENTRYPOINT ["java", "-jar", "/app.jar"]
+`
+
+// ── Ruby ────────────────────────────────────────────────────────
+
+func rubyDockerfile(abs string) (string, string) {
+	lang := "ruby"
+	cmd := `["ruby", "app.rb", "-o", "0.0.0.0", "-p", "8080"]`
+	port := 8080
+	switch {
+	case fileExists(filepath.Join(abs, "config", "application.rb")):
+		lang = "ruby (rails)"
+		cmd = `["bin/rails", "server", "-b", "0.0.0.0", "-p", "3000"]`
+		port = 3000
+	case fileExists(filepath.Join(abs, "config.ru")):
+		lang = "ruby (rack)"
+		cmd = `["bundle", "exec", "rackup", "--host", "0.0.0.0", "--port", "8080"]`
+	}
+	return lang, dockerfileHeader + fmt.Sprintf(`FROM ruby:3.3-slim
+RUN apt-get update && apt-get install -y --no-install-recommends build-essential && rm -rf /var/lib/apt/lists/*
+WORKDIR /app
+COPY Gemfile* ./
+RUN bundle install
+COPY . .
+EXPOSE %d
+CMD %s
+`, port, cmd)
+}
+
+// fileExists reports whether path exists and is a regular file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
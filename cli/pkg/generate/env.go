@@ -0,0 +1,86 @@
+package generate
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jeffvincent/kindling/cli/pkg/deploy"
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+// ── Environment variables in the generated spec ─────────────────
+//
+// source.EnvReads finds the variables each component's code reads; the
+// ones nothing provides go in the offline spec and the AI's prompt.
+
+// applyEnvReads adds the variables a component's code reads to its
+// offline spec: the required ones as env entries — a Secret reference for
+// credentials, the placeholder otherwise — and the rest as a comment.
+func applyEnvReads(c *Component, reads []source.EnvRead, dockerfile string) {
+	have := map[string]bool{}
+	for _, e := range c.Env {
+		have[e.Name] = true
+	}
+	deps := make([]string, 0, len(c.Dependencies))
+	for d := range c.Dependencies {
+		deps = append(deps, d)
+	}
+	injected := devstaging.InjectedEnvVars(deps)
+	fromDockerfile := source.DockerfileEnv(dockerfile)
+
+	for _, r := range source.RequiredEnv(reads, have, injected, fromDockerfile) {
+		e := EnvVar{Name: r.Name, Value: source.EnvPlaceholder, Note: fmt.Sprintf("read in %s with no default — set a real value", r.Location)}
+		if isExternalCredential(r.Name) {
+			e.Value, e.Secret = "", deploy.SecretName(r.Name)
+			e.Note = fmt.Sprintf("read in %s — kindling secrets set %s <value>", r.Location, r.Name)
+		}
+		c.Env = append(c.Env, e)
+		have[r.Name] = true
+	}
+	for _, r := range reads {
+		if r.Default && !have[r.Name] && !injected[r.Name] && !fromDockerfile[r.Name] {
+			c.EnvDefaults = append(c.EnvDefaults, r.Name)
+		}
+	}
+}
+
+// writeEnvReads tells the AI which variables each component reads.
+func writeEnvReads(b *strings.Builder, ctx *Repo) {
+	dirs := slices.Sorted(maps.Keys(ctx.envReads))
+	found := false
+	for _, dir := range dirs {
+		found = found || len(ctx.envReads[dir]) > 0
+	}
+	if !found {
+		return
+	}
+	b.WriteString("## Environment variables read by the code\n\n")
+	b.WriteString("Static analysis found these reads in each component's source. Every variable\n")
+	b.WriteString("marked \"required\" has no default in the code: set it in the component's env —\n")
+	b.WriteString("a working dev value, a reference to another service, or a secretKeyRef for a\n")
+	b.WriteString("truly external credential — unless a declared dependency injects it. Variables\n")
+	b.WriteString("with a default may be left out.\n\n")
+	for _, dir := range dirs {
+		reads := ctx.envReads[dir]
+		if len(reads) == 0 {
+			continue
+		}
+		fromDockerfile := source.DockerfileEnv(ctx.dockerfiles[filepath.Join(dir, "Dockerfile")])
+		fmt.Fprintf(b, "### %s\n\n", dir)
+		for _, r := range reads {
+			kind := "required"
+			switch {
+			case fromDockerfile[r.Name]:
+				kind = "set by the Dockerfile"
+			case r.Default:
+				kind = "has a default"
+			}
+			fmt.Fprintf(b, "- %s (%s, %s)\n", r.Name, kind, r.Location)
+		}
+		b.WriteString("\n")
+	}
+}
@@ -0,0 +1,562 @@
+package generate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Generator is an LLM backend that turns a system + user prompt into the
+// model's text response.
+type Generator interface {
+	// Name is the provider name shown to the user.
+	Name() string
+	// Model is the model (or deployment) the generator calls.
+	Model() string
+	// Generate sends the prompts and returns the model's reply, at most
+	// maxTokens long, and the tokens the call used.
+	Generate(systemPrompt, userPrompt string, maxTokens int) (string, Usage, error)
+}
+
+// StructuredGenerator is a Generator that can hold the model's reply to a
+// JSON Schema — response_format for OpenAI and Azure, a forced tool call
+// for Anthropic, and format for Ollama.
+type StructuredGenerator interface {
+	Generator
+	// GenerateJSON is Generate with the reply a JSON document matching
+	// schema.
+	GenerateJSON(systemPrompt, userPrompt string, maxTokens int, schema ReplySchema) (string, Usage, error)
+}
+
+// ReplySchema is the JSON Schema a structured reply must match.
+type ReplySchema struct {
+	Name        string
+	Description string
+	Schema      map[string]interface{}
+}
+
+// ProviderConfig holds the settings for one LLM backend. Fields that
+// don't apply to a backend are ignored.
+type ProviderConfig struct {
+	APIKey     string `yaml:"apiKey,omitempty"`
+	Model      string `yaml:"model,omitempty"`
+	BaseURL    string `yaml:"baseURL,omitempty"`    // openai, anthropic, ollama
+	Endpoint   string `yaml:"endpoint,omitempty"`   // azure
+	Deployment string `yaml:"deployment,omitempty"` // azure
+	APIVersion string `yaml:"apiVersion,omitempty"` // azure
+
+	// Prices in USD per million tokens, for models kindling doesn't
+	// know the price of (see llmPrices).
+	InputPrice  float64 `yaml:"inputPrice,omitempty"`
+	OutputPrice float64 `yaml:"outputPrice,omitempty"`
+}
+
+// llmMaxReplyTokens caps every reply; --max-tokens and --max-cost lower it.
+const llmMaxReplyTokens = 8192
+
+// Providers lists the supported --llm-provider values.
+var Providers = []string{"openai", "azure", "anthropic", "ollama"}
+
+// llmAPIKeyEnv is the environment variable each provider's API key is read
+// from when neither --api-key nor the config file sets one.
+var llmAPIKeyEnv = map[string]string{
+	"openai":    "OPENAI_API_KEY",
+	"azure":     "AZURE_OPENAI_API_KEY",
+	"anthropic": "ANTHROPIC_API_KEY",
+}
+
+// IsProvider reports whether name is one of Providers.
+func IsProvider(name string) bool {
+	for _, p := range Providers {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsAPIKey reports whether the provider requires an API key. Local
+// Ollama does not.
+func NeedsAPIKey(provider string) bool {
+	return provider != "ollama"
+}
+
+// ResolveAPIKey picks the API key for provider: an explicit key, then the
+// config file, then the provider's environment variable.
+func ResolveAPIKey(provider, explicit string, cfg ProviderConfig) string {
+	if explicit != "" {
+		return explicit
+	}
+	if cfg.APIKey != "" {
+		return cfg.APIKey
+	}
+	if env, ok := llmAPIKeyEnv[provider]; ok {
+		return os.Getenv(env)
+	}
+	return ""
+}
+
+// NewGenerator builds the Generator for provider. apiKey and model override
+// the values from cfg when non-empty.
+func NewGenerator(provider, apiKey, model string, cfg ProviderConfig, opts Options) (Generator, error) {
+	calls := &llmRetryPolicy{opts: opts}
+	if model == "" {
+		model = cfg.Model
+	}
+	switch provider {
+	case "openai":
+		if model == "" {
+			model = "gpt-4o"
+		}
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+		}
+		return &openAIGenerator{llmRetryPolicy: calls, apiKey: apiKey, model: model, baseURL: baseURL}, nil
+	case "azure":
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = os.Getenv("AZURE_OPENAI_ENDPOINT")
+		}
+		if endpoint == "" {
+			return nil, fmt.Errorf("azure requires an endpoint — set llm.providers.azure.endpoint in .kindling/config.yaml or AZURE_OPENAI_ENDPOINT")
+		}
+		deployment := cfg.Deployment
+		if deployment == "" {
+			deployment = model
+		}
+		if deployment == "" {
+			return nil, fmt.Errorf("azure requires a deployment — set llm.providers.azure.deployment in .kindling/config.yaml or pass --model")
+		}
+		apiVersion := cfg.APIVersion
+		if apiVersion == "" {
+			apiVersion = "2024-10-21" // the first GA version with structured outputs
+		}
+		return &azureOpenAIGenerator{llmRetryPolicy: calls, apiKey: apiKey, endpoint: endpoint, deployment: deployment, apiVersion: apiVersion}, nil
+	case "anthropic":
+		if model == "" {
+			model = "claude-sonnet-4-20250514"
+		}
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = "https://api.anthropic.com"
+		}
+		return &anthropicGenerator{llmRetryPolicy: calls, apiKey: apiKey, model: model, baseURL: baseURL}, nil
+	case "ollama":
+		if model == "" {
+			model = "llama3.1"
+		}
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = os.Getenv("OLLAMA_HOST")
+		}
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		if !strings.Contains(baseURL, "://") {
+			baseURL = "http://" + baseURL
+		}
+		return &ollamaGenerator{llmRetryPolicy: calls, model: model, baseURL: baseURL}, nil
+	default:
+		return nil, fmt.Errorf("unsupported LLM provider %q (use %s)", provider, strings.Join(Providers, ", "))
+	}
+}
+
+// postJSON sends reqBody to endpoint and decodes a 200 response into out,
+// retrying as the policy allows and recording each attempt in the audit
+// log. Other responses are reported with the provider label and raw body.
+func (p *llmRetryPolicy) postJSON(label, endpoint string, headers map[string]string, reqBody, out interface{}) error {
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	client := p.opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	respBody, err := p.call(label, func() (*http.Response, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout())
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			p.audit(label, endpoint, body, 0, nil, err, time.Since(start))
+			return nil, fmt.Errorf("API request failed: %w", err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		p.audit(label, endpoint, body, resp.StatusCode, data, err, time.Since(start))
+		if err != nil {
+			return nil, fmt.Errorf("read response: %w", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		return resp, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
+}
+
+// audit records one attempt of a call in the audit log, and logs a
+// failure to write it.
+func (p *llmRetryPolicy) audit(label, endpoint string, req []byte, status int, resp []byte, callErr error, took time.Duration) {
+	if err := p.opts.Audit.record(label, endpoint, req, status, resp, callErr, took); err != nil {
+		p.opts.log().Warn("cannot write the AI audit log", "error", err)
+	}
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// OpenAI (and OpenAI-compatible endpoints)
+// ────────────────────────────────────────────────────────────────────────────
+
+type openAIRequest struct {
+	Model          string                `json:"model,omitempty"`
+	Messages       []openAIMessage       `json:"messages"`
+	Temperature    float64               `json:"temperature"`
+	MaxTokens      int                   `json:"max_tokens,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// openAIResponseFormat asks for a reply matching a JSON Schema. Strict
+// schemas are enforced while the reply is generated.
+type openAIResponseFormat struct {
+	Type       string `json:"type"`
+	JSONSchema struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description,omitempty"`
+		Schema      map[string]interface{} `json:"schema"`
+		Strict      bool                   `json:"strict"`
+	} `json:"json_schema"`
+}
+
+// withSchema holds the request's reply to schema.
+func (r openAIRequest) withSchema(schema ReplySchema) openAIRequest {
+	f := &openAIResponseFormat{Type: "json_schema"}
+	f.JSONSchema.Name = schema.Name
+	f.JSONSchema.Description = schema.Description
+	f.JSONSchema.Schema = schema.Schema
+	f.JSONSchema.Strict = true
+	r.ResponseFormat = f
+	return r
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage,omitempty"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// newOpenAIRequest builds the chat-completions body shared by OpenAI and
+// Azure OpenAI.
+func newOpenAIRequest(model, systemPrompt, userPrompt string, maxTokens int) openAIRequest {
+	return openAIRequest{
+		Model: model,
+		Messages: []openAIMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Temperature: 0.2,
+		MaxTokens:   maxTokens,
+	}
+}
+
+// usage returns the tokens the call used, estimated from the prompts and
+// the reply when the endpoint doesn't report them.
+func (r *openAIResponse) usage(systemPrompt, userPrompt, reply string) Usage {
+	if r.Usage == nil {
+		return estimateUsage(systemPrompt+userPrompt, reply)
+	}
+	return Usage{Prompt: r.Usage.PromptTokens, Completion: r.Usage.CompletionTokens}
+}
+
+// firstChoice extracts the reply text from a chat-completions response.
+func (r *openAIResponse) firstChoice(label string) (string, error) {
+	if r.Error != nil {
+		return "", fmt.Errorf("%s API error: %s", label, r.Error.Message)
+	}
+	if len(r.Choices) == 0 {
+		return "", fmt.Errorf("%s API returned no choices", label)
+	}
+	return r.Choices[0].Message.Content, nil
+}
+
+type openAIGenerator struct {
+	*llmRetryPolicy
+	apiKey  string
+	model   string
+	baseURL string
+}
+
+func (g *openAIGenerator) Name() string  { return "openai" }
+func (g *openAIGenerator) Model() string { return g.model }
+
+func (g *openAIGenerator) Generate(systemPrompt, userPrompt string, maxTokens int) (string, Usage, error) {
+	return g.send(systemPrompt, userPrompt, newOpenAIRequest(g.model, systemPrompt, userPrompt, maxTokens))
+}
+
+func (g *openAIGenerator) GenerateJSON(systemPrompt, userPrompt string, maxTokens int, schema ReplySchema) (string, Usage, error) {
+	return g.send(systemPrompt, userPrompt, newOpenAIRequest(g.model, systemPrompt, userPrompt, maxTokens).withSchema(schema))
+}
+
+func (g *openAIGenerator) send(systemPrompt, userPrompt string, req openAIRequest) (string, Usage, error) {
+	var result openAIResponse
+	err := g.postJSON("OpenAI", strings.TrimRight(g.baseURL, "/")+"/chat/completions",
+		map[string]string{"Authorization": "Bearer " + g.apiKey}, req, &result)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	reply, err := result.firstChoice("OpenAI")
+	return reply, result.usage(systemPrompt, userPrompt, reply), err
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// Azure OpenAI
+// ────────────────────────────────────────────────────────────────────────────
+
+type azureOpenAIGenerator struct {
+	*llmRetryPolicy
+	apiKey     string
+	endpoint   string
+	deployment string
+	apiVersion string
+}
+
+func (g *azureOpenAIGenerator) Name() string  { return "azure" }
+func (g *azureOpenAIGenerator) Model() string { return g.deployment }
+
+func (g *azureOpenAIGenerator) Generate(systemPrompt, userPrompt string, maxTokens int) (string, Usage, error) {
+	return g.send(systemPrompt, userPrompt, newOpenAIRequest("", systemPrompt, userPrompt, maxTokens))
+}
+
+func (g *azureOpenAIGenerator) GenerateJSON(systemPrompt, userPrompt string, maxTokens int, schema ReplySchema) (string, Usage, error) {
+	return g.send(systemPrompt, userPrompt, newOpenAIRequest("", systemPrompt, userPrompt, maxTokens).withSchema(schema))
+}
+
+func (g *azureOpenAIGenerator) send(systemPrompt, userPrompt string, req openAIRequest) (string, Usage, error) {
+	endpoint := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		strings.TrimRight(g.endpoint, "/"), url.PathEscape(g.deployment), url.QueryEscape(g.apiVersion))
+
+	// Azure routes by deployment, so the model field is left out.
+	var result openAIResponse
+	err := g.postJSON("Azure OpenAI", endpoint, map[string]string{"api-key": g.apiKey}, req, &result)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	reply, err := result.firstChoice("Azure OpenAI")
+	return reply, result.usage(systemPrompt, userPrompt, reply), err
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// Anthropic
+// ────────────────────────────────────────────────────────────────────────────
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Temperature float64            `json:"temperature"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice  map[string]string  `json:"tool_choice,omitempty"`
+}
+
+// anthropicTool is a tool the model can call; forcing the call makes its
+// input the structured reply.
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Input json.RawMessage `json:"input,omitempty"` // of tool_use blocks
+	} `json:"content"`
+	Usage *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage,omitempty"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+type anthropicGenerator struct {
+	*llmRetryPolicy
+	apiKey  string
+	model   string
+	baseURL string
+}
+
+func (g *anthropicGenerator) Name() string  { return "anthropic" }
+func (g *anthropicGenerator) Model() string { return g.model }
+
+func (g *anthropicGenerator) Generate(systemPrompt, userPrompt string, maxTokens int) (string, Usage, error) {
+	return g.send(systemPrompt, userPrompt, g.request(systemPrompt, userPrompt, maxTokens))
+}
+
+func (g *anthropicGenerator) GenerateJSON(systemPrompt, userPrompt string, maxTokens int, schema ReplySchema) (string, Usage, error) {
+	req := g.request(systemPrompt, userPrompt, maxTokens)
+	req.Tools = []anthropicTool{{Name: schema.Name, Description: schema.Description, InputSchema: schema.Schema}}
+	req.ToolChoice = map[string]string{"type": "tool", "name": schema.Name}
+	return g.send(systemPrompt, userPrompt, req)
+}
+
+func (g *anthropicGenerator) request(systemPrompt, userPrompt string, maxTokens int) anthropicRequest {
+	return anthropicRequest{
+		Model:     g.model,
+		MaxTokens: maxTokens,
+		System:    systemPrompt,
+		Messages: []anthropicMessage{
+			{Role: "user", Content: userPrompt},
+		},
+		Temperature: 0.2,
+	}
+}
+
+func (g *anthropicGenerator) send(systemPrompt, userPrompt string, reqBody anthropicRequest) (string, Usage, error) {
+	var result anthropicResponse
+	err := g.postJSON("Anthropic", strings.TrimRight(g.baseURL, "/")+"/v1/messages",
+		map[string]string{"x-api-key": g.apiKey, "anthropic-version": "2023-06-01"},
+		reqBody, &result)
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	if result.Error != nil {
+		return "", Usage{}, fmt.Errorf("Anthropic API error: %s: %s", result.Error.Type, result.Error.Message)
+	}
+
+	if len(result.Content) == 0 {
+		return "", Usage{}, fmt.Errorf("Anthropic API returned no content blocks")
+	}
+
+	// Concatenate all text blocks; a forced tool call's input is the reply
+	var sb strings.Builder
+	for _, block := range result.Content {
+		switch block.Type {
+		case "text":
+			sb.WriteString(block.Text)
+		case "tool_use":
+			sb.Reset()
+			sb.Write(block.Input)
+		}
+	}
+
+	if result.Usage == nil {
+		return sb.String(), estimateUsage(systemPrompt+userPrompt, sb.String()), nil
+	}
+	return sb.String(), Usage{Prompt: result.Usage.InputTokens, Completion: result.Usage.OutputTokens}, nil
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// Ollama (local)
+// ────────────────────────────────────────────────────────────────────────────
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   interface{}     `json:"format,omitempty"` // a JSON Schema the reply must match
+	Options  struct {
+		Temperature float64 `json:"temperature"`
+		NumPredict  int     `json:"num_predict,omitempty"`
+	} `json:"options"`
+}
+
+type ollamaResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	Error           string `json:"error,omitempty"`
+}
+
+type ollamaGenerator struct {
+	*llmRetryPolicy
+	model   string
+	baseURL string
+}
+
+func (g *ollamaGenerator) Name() string  { return "ollama" }
+func (g *ollamaGenerator) Model() string { return g.model }
+
+func (g *ollamaGenerator) Generate(systemPrompt, userPrompt string, maxTokens int) (string, Usage, error) {
+	return g.send(systemPrompt, userPrompt, maxTokens, nil)
+}
+
+func (g *ollamaGenerator) GenerateJSON(systemPrompt, userPrompt string, maxTokens int, schema ReplySchema) (string, Usage, error) {
+	return g.send(systemPrompt, userPrompt, maxTokens, schema.Schema)
+}
+
+func (g *ollamaGenerator) send(systemPrompt, userPrompt string, maxTokens int, format interface{}) (string, Usage, error) {
+	reqBody := ollamaRequest{
+		Model: g.model,
+		Messages: []openAIMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+	}
+	if format != nil {
+		reqBody.Format = format
+	}
+	reqBody.Options.Temperature = 0.2
+	reqBody.Options.NumPredict = maxTokens
+
+	var result ollamaResponse
+	err := g.postJSON("Ollama", strings.TrimRight(g.baseURL, "/")+"/api/chat", nil, reqBody, &result)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	if result.Error != "" {
+		return "", Usage{}, fmt.Errorf("Ollama error: %s", result.Error)
+	}
+	if result.PromptEvalCount == 0 && result.EvalCount == 0 {
+		return result.Message.Content, estimateUsage(systemPrompt+userPrompt, result.Message.Content), nil
+	}
+	return result.Message.Content, Usage{Prompt: result.PromptEvalCount, Completion: result.EvalCount}, nil
+}
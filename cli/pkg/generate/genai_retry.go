@@ -0,0 +1,181 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ── Retries and the circuit breaker ─────────────────────────────
+//
+// Hosted models rate-limit and have bad minutes. A call that fails with
+// 429, a 5xx, or a network error is tried again, up to --max-retries
+// times, after an exponential backoff with jitter — or after the wait the
+// provider asks for in Retry-After. Failures the provider won't get over
+// soon (a Retry-After of minutes, an exhausted quota) aren't retried, and
+// after llmBreakerThreshold failed attempts in a row the provider isn't
+// called again for the rest of the run. Either way the error wraps
+// ErrUnavailable, and generate falls back to the offline heuristics.
+
+// ErrUnavailable is wrapped by the errors of calls that failed because
+// the provider is unavailable, rather than because of the request.
+var ErrUnavailable = errors.New("AI provider unavailable")
+
+const (
+	// DefaultRetries is how many times a call is retried when neither
+	// --max-retries nor llm.retries is set.
+	DefaultRetries = 4
+	// DefaultRequestTimeout bounds each attempt of a call.
+	DefaultRequestTimeout = 2 * time.Minute
+
+	// llmBackoffBase and llmBackoffMax bound the wait before a retry,
+	// which doubles with each attempt.
+	llmBackoffBase = time.Second
+	llmBackoffMax  = 30 * time.Second
+	// llmMaxRetryAfter is the longest Retry-After that is waited out.
+	llmMaxRetryAfter = time.Minute
+	// llmBreakerThreshold is how many failed attempts in a row, across
+	// calls, open the circuit.
+	llmBreakerThreshold = 8
+)
+
+// llmRetryPolicy is how a generator's calls are retried, as its Options
+// say, and the state of its circuit breaker.
+type llmRetryPolicy struct {
+	opts Options
+
+	failures int  // failed attempts in a row
+	open     bool // the provider isn't called again
+}
+
+// timeout bounds each attempt of a call.
+func (p *llmRetryPolicy) timeout() time.Duration {
+	if p.opts.Timeout <= 0 {
+		return DefaultRequestTimeout
+	}
+	return p.opts.Timeout
+}
+
+// call sends a request with send until it gets a 200 and returns its
+// body, retrying as the policy allows.
+func (p *llmRetryPolicy) call(label string, send func() (*http.Response, error)) ([]byte, error) {
+	if p.open {
+		return nil, fmt.Errorf("%w: %s failed %d times in a row, so it isn't called again in this run", ErrUnavailable, label, p.failures)
+	}
+	for attempt := 1; ; attempt++ {
+		var wait time.Duration
+		var reason string
+		resp, err := send()
+		if err == nil {
+			var body []byte
+			body, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			switch {
+			case err != nil:
+				err = fmt.Errorf("read response: %w", err)
+				reason = "the response was cut off"
+			case resp.StatusCode == http.StatusOK:
+				p.failures = 0
+				return body, nil
+			default:
+				err = fmt.Errorf("%s API returned HTTP %d: %s", label, resp.StatusCode, string(body))
+				if !retryableStatus(resp.StatusCode) {
+					p.failures = 0 // the provider is up; the request is wrong
+					return nil, err
+				}
+				if quotaExhausted(body) {
+					return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+				}
+				wait = retryAfter(resp.Header)
+				reason = describeStatus(resp.StatusCode)
+			}
+		} else {
+			reason = "the request failed"
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				reason = fmt.Sprintf("no answer within %s", p.timeout())
+			}
+		}
+
+		p.failures++
+		p.opts.log().Info("llm call failed", "provider", label, "attempt", attempt, "failures", p.failures, "error", err)
+		if wait > llmMaxRetryAfter {
+			return nil, fmt.Errorf("%w: %v (the provider asks to wait %s)", ErrUnavailable, err, wait.Round(time.Second))
+		}
+		if p.failures >= llmBreakerThreshold {
+			p.open = true
+			p.opts.progress(Warning, fmt.Sprintf("%s failed %d times in a row — not calling it again in this run", label, p.failures))
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		if attempt > p.opts.Retries {
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		if wait == 0 {
+			wait = llmBackoff(attempt)
+		}
+		p.opts.progress(Started, fmt.Sprintf("%s: %s — retrying in %s (retry %d of %d)", label, reason, wait.Round(10*time.Millisecond), attempt, p.opts.Retries))
+		time.Sleep(wait)
+	}
+}
+
+// retryableStatus reports whether a call that got code may succeed when
+// repeated: rate limits, server errors, and Anthropic's 529 overloaded.
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusRequestTimeout ||
+		(code >= 500 && code != http.StatusNotImplemented)
+}
+
+// quotaExhausted reports whether a 429's body says the account is out of
+// credit, which waiting doesn't fix.
+func quotaExhausted(body []byte) bool {
+	return strings.Contains(string(body), "insufficient_quota")
+}
+
+// describeStatus is the progress line's reason for a retryable status.
+func describeStatus(code int) string {
+	switch code {
+	case http.StatusTooManyRequests:
+		return "rate limited (HTTP 429)"
+	case http.StatusServiceUnavailable, 529:
+		return fmt.Sprintf("overloaded (HTTP %d)", code)
+	}
+	return fmt.Sprintf("HTTP %d", code)
+}
+
+// retryAfter is the wait a response asks for: retry-after-ms (OpenAI and
+// Azure), then Retry-After in seconds or as a date; 0 when there is none.
+func retryAfter(h http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(h.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.ParseFloat(v, 64); err == nil && s > 0 {
+		return time.Duration(s * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// llmBackoff is the wait before retry n: llmBackoffBase doubled n-1
+// times, capped at llmBackoffMax, with its upper half random so that
+// clients rate-limited together don't retry together.
+func llmBackoff(n int) time.Duration {
+	d := llmBackoffMax
+	if n < 16 {
+		d = min(llmBackoffBase<<(n-1), llmBackoffMax)
+	}
+	return d/2 + rand.N(d/2+1)
+}
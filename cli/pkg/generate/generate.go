@@ -0,0 +1,70 @@
+// Package generate derives what kindling deploys an application with from
+// its repository, the way kindling generate does: it scans the repo for
+// components, Dockerfiles, frameworks, and the credentials and services
+// the code needs, then renders a DevStagingEnvironment manifest from
+// heuristics or a docker-compose file, or has an LLM — OpenAI, Azure
+// OpenAI, Anthropic, or Ollama — write a GitHub Actions workflow, checked
+// like kindling validate does and sent back with its problems for
+// correction.
+package generate
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Progress states.
+const (
+	Started = "started"
+	Done    = "done"
+	Warning = "warning"
+)
+
+// Progress is one update from a step of a run: a retry or a correction
+// round starting, an attempt passing the checks, or a warning.
+type Progress struct {
+	State   string `json:"state"`
+	Message string `json:"message"`
+}
+
+// Options are what the steps of a run share: where they report to, how
+// they call the model, and what the generated YAML is checked against.
+// The zero Options reports nothing and calls the model once, without an
+// audit log.
+type Options struct {
+	// Progress receives the run's progress and warnings; it may be nil.
+	Progress func(Progress)
+	// Log receives what isn't worth a progress line; nil discards it.
+	Log *slog.Logger
+
+	// Retries is how many times a call that failed for lack of the
+	// provider is retried, and Timeout bounds each attempt (0 is
+	// DefaultRequestTimeout).
+	Retries int
+	Timeout time.Duration
+	// Client sends the calls, e.g. through a proxy; nil is
+	// http.DefaultClient.
+	Client *http.Client
+	// Audit records each call; nil records nothing.
+	Audit *AuditLog
+
+	// HostMounts are the node paths the cluster mounts from the host, for
+	// checking the hostPath volumes of a generated workflow.
+	HostMounts []string
+}
+
+// progress sends an update, if anyone listens.
+func (o Options) progress(state, message string) {
+	if o.Progress != nil {
+		o.Progress(Progress{State: state, Message: message})
+	}
+}
+
+// log returns o.Log, or a logger that discards everything.
+func (o Options) log() *slog.Logger {
+	if o.Log == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return o.Log
+}
@@ -0,0 +1,224 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeRepo creates files, path → content, under a temporary repo root.
+func writeRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	repo := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(repo, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+var apiRepo = map[string]string{
+	"api/Dockerfile": "FROM golang:1.22\nWORKDIR /src\nCOPY . .\nRUN go build -o /api .\nEXPOSE 8080\nCMD [\"/api\"]\n",
+	"api/go.mod":     "module example.com/api\n\ngo 1.22\n\nrequire github.com/jackc/pgx/v5 v5.5.0\n",
+	"api/main.go": `package main
+
+import (
+	"net/http"
+	"os"
+)
+
+func main() {
+	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+	_ = os.Getenv("STRIPE_API_KEY")
+	http.ListenAndServe(":8080", nil)
+}
+`,
+}
+
+func TestScanAndDetectComponents(t *testing.T) {
+	repo := writeRepo(t, apiRepo)
+	ctx, err := Scan(repo, PathFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.DockerfileCount != 1 || ctx.DepFileCount != 1 {
+		t.Errorf("Scan found %d Dockerfile(s) and %d manifest(s), want 1 and 1", ctx.DockerfileCount, ctx.DepFileCount)
+	}
+	if strings.Join(ctx.ExternalSecrets, ",") != "STRIPE_API_KEY" {
+		t.Errorf("ExternalSecrets = %v, want [STRIPE_API_KEY]", ctx.ExternalSecrets)
+	}
+
+	components := DetectComponents(repo, ctx)
+	if len(components) != 1 {
+		t.Fatalf("DetectComponents = %d component(s), want 1", len(components))
+	}
+	c := components[0]
+	if c.Name != "api" || c.Dir != "api" || c.Port != 8080 || c.HealthPath != "/healthz" || !c.Dependencies["postgres"] {
+		t.Errorf("component = %+v, want api in api/ on 8080 with /healthz and postgres", c)
+	}
+
+	manifest := RenderManifest(components, Cluster{Namespace: "env-dev"})
+	for _, want := range []string{"kind: DevStagingEnvironment", "namespace: env-dev", "port: 8080", "path: /healthz", "type: postgres"} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("manifest is missing %q:\n%s", want, manifest)
+		}
+	}
+}
+
+func TestConvertCompose(t *testing.T) {
+	repo := writeRepo(t, map[string]string{
+		"web/Dockerfile": "FROM node:22\n",
+		"docker-compose.yml": `services:
+  web:
+    build: ./web
+    ports: ["3000:3000"]
+    environment:
+      DATABASE_URL: postgres://app:app@db:5432/app
+      LOG_LEVEL: debug
+    depends_on: [db]
+  db:
+    image: postgres:16
+`,
+	})
+	components, _, err := ConvertCompose(repo, filepath.Join(repo, "docker-compose.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(components) != 1 {
+		t.Fatalf("ConvertCompose = %d component(s), want 1 (db is a dependency)", len(components))
+	}
+	c := components[0]
+	if c.Name != "web" || c.Port != 3000 || !c.Dependencies["postgres"] {
+		t.Errorf("component = %+v, want web on 3000 with postgres", c)
+	}
+	if c.DependencySettings["postgres"].Version != "16" {
+		t.Errorf("postgres settings = %+v, want version 16", c.DependencySettings["postgres"])
+	}
+	var env []string
+	for _, e := range c.Env {
+		env = append(env, e.String())
+	}
+	if got := strings.Join(env, " "); !strings.Contains(got, "LOG_LEVEL=debug") || strings.Contains(got, "DATABASE_URL") {
+		t.Errorf("env = %s, want LOG_LEVEL kept and DATABASE_URL left to the operator", got)
+	}
+}
+
+func TestPathFilter(t *testing.T) {
+	f, err := NewPathFilter([]string{"vendor/acme/**/*.go"}, []string{"**/*.min.js", "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rel, want := range map[string]bool{
+		"web/app.min.js":    true,
+		"docs/index.md":     true,
+		"docs":              true,
+		"web/app.js":        false,
+		"vendor/acme/x.go":  false,
+		"documentation/x.m": false,
+	} {
+		if got := f.excluded(rel); got != want {
+			t.Errorf("excluded(%q) = %v, want %v", rel, got, want)
+		}
+	}
+	if !f.included("vendor/acme/sub/x.go") || f.included("vendor/other/x.go") {
+		t.Error("the include glob should match only under vendor/acme")
+	}
+	if !f.reaches("vendor") || f.reaches("node_modules") {
+		t.Error("the include glob should reach into vendor and nowhere else")
+	}
+	if _, err := NewPathFilter(nil, []string{"[a-"}); err == nil {
+		t.Error("NewPathFilter accepted a malformed glob")
+	}
+}
+
+// fakeGenerator answers with replies in turn and records the prompts.
+type fakeGenerator struct {
+	replies []string
+	prompts []string
+}
+
+func (g *fakeGenerator) Name() string  { return "fake" }
+func (g *fakeGenerator) Model() string { return "fake-1" }
+
+func (g *fakeGenerator) Generate(systemPrompt, userPrompt string, maxTokens int) (string, Usage, error) {
+	g.prompts = append(g.prompts, userPrompt)
+	if len(g.replies) == 0 {
+		return "", Usage{}, fmt.Errorf("no more replies")
+	}
+	reply := g.replies[0]
+	g.replies = g.replies[1:]
+	return reply, estimateUsage(systemPrompt+userPrompt, reply), nil
+}
+
+func (g *fakeGenerator) GenerateJSON(systemPrompt, userPrompt string, maxTokens int, schema ReplySchema) (string, Usage, error) {
+	return g.Generate(systemPrompt, userPrompt, maxTokens)
+}
+
+const apiPlan = `{"services": [{"name": "api", "context": "api", "dockerfile": "Dockerfile", "port": 8080,
+  "healthCheckType": "http", "healthCheckPath": "/healthz", "ingress": true, "ingressProtocol": "",
+  "env": [{"name": "STRIPE_API_KEY", "value": "", "secretName": "kindling-secret-stripe-api-key", "secretKey": ""}],
+  "dependencies": [{"type": "postgres", "version": ""}], "dependsOn": []}], "notes": []}`
+
+func TestWorkflowCorrectsABrokenPlan(t *testing.T) {
+	repo := writeRepo(t, apiRepo)
+	g := &fakeGenerator{replies: []string{"not a plan", apiPlan}}
+	budget, err := NewBudget(g, ProviderConfig{}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var progress []Progress
+	opts := Options{Progress: func(p Progress) { progress = append(progress, p) }}
+
+	workflow, err := Workflow(g, PlanReply{Branch: "trunk", Project: "shop"}, "system", "user", repo, 2, budget, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.prompts) != 2 || !strings.Contains(g.prompts[1], "not a plan") {
+		t.Fatalf("prompts = %q, want the broken answer sent back once", g.prompts)
+	}
+	for _, want := range []string{"branches: [trunk]", "kindling-build", "kindling-deploy", "type: postgres", "name: kindling-secret-stripe-api-key"} {
+		if !strings.Contains(workflow, want) {
+			t.Errorf("workflow is missing %q:\n%s", want, workflow)
+		}
+	}
+	if budget.Calls != 2 || budget.Used.Total() == 0 {
+		t.Errorf("budget = %d call(s), %d tokens; want 2 calls with their tokens", budget.Calls, budget.Used.Total())
+	}
+	if len(progress) == 0 || progress[0].State != Started {
+		t.Errorf("progress = %+v, want the correction round reported", progress)
+	}
+	if _, err := os.Stat(filepath.Join(repo, ".kindling", "generate-history")); err != nil {
+		t.Errorf("no history was written: %v", err)
+	}
+}
+
+func TestBudgetReplyLimit(t *testing.T) {
+	b, err := NewBudget(&fakeGenerator{}, ProviderConfig{}, 3000, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit, err := b.ReplyLimit(strings.Repeat("x", 4000)) // ~1000 tokens
+	if err != nil || limit != 2000 {
+		t.Errorf("ReplyLimit = %d, %v; want 2000", limit, err)
+	}
+	b.Spend(Usage{Prompt: 1000, Completion: 1500})
+	if _, err := b.ReplyLimit(strings.Repeat("x", 4000)); err == nil {
+		t.Error("ReplyLimit allowed a call past --max-tokens")
+	}
+	if _, err := NewBudget(&fakeGenerator{}, ProviderConfig{}, 0, 1); err == nil {
+		t.Error("NewBudget took --max-cost for a model without a price")
+	}
+}
+
+func TestCleanYAML(t *testing.T) {
+	if got := CleanYAML("```yaml\nname: Dev Deploy\n```\n"); got != "name: Dev Deploy" {
+		t.Errorf("CleanYAML = %q", got)
+	}
+}
@@ -0,0 +1,217 @@
+package generate
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Component call graph
+// ────────────────────────────────────────────────────────────────────────────
+//
+// generate works out which components call which, so each DSE can list
+// the ones it needs in spec.dependsOn and the operator starts them in
+// order. A call is a URL or host:port naming another component in the
+// source or config files of a component, or a docker-compose depends_on
+// between two application services.
+
+// componentCall is a call from one component to another.
+type componentCall struct {
+	Dir      string // directory of the called component
+	Evidence string // where it was found, e.g. "web/src/api.js:12"
+}
+
+var (
+	// callURLHost is the host of a URL: http://api:8080, grpc://users-dev.
+	callURLHost = regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://(?:[^@/\s"'` + "`" + `]*@)?([a-z0-9][a-z0-9.-]*)`)
+	// callHostPort is a bare host:port, as gRPC and TCP clients take it.
+	callHostPort = regexp.MustCompile(`(?:^|[\s"'` + "`" + `=,(])([a-z0-9][a-z0-9-]*):\d{2,5}\b`)
+)
+
+// callConfigExts are config files that hold service addresses, scanned
+// besides the source files.
+var callConfigExts = map[string]bool{
+	".yaml": true, ".yml": true, ".json": true, ".toml": true,
+	".properties": true, ".ini": true, ".conf": true, ".cfg": true,
+}
+
+// inferComponentCalls returns, for each component directory in names
+// (directory → component name), the other components it calls, sorted
+// by directory.
+func inferComponentCalls(repoPath string, names map[string]string, compose map[string]composeService) map[string][]componentCall {
+	// A component is reached by its name, its DSE's Service name, or its
+	// docker-compose service name.
+	hosts := map[string]string{}
+	for dir, name := range names {
+		hosts[name] = dir
+		hosts[name+"-dev"] = dir
+	}
+	composeDirs := map[string]string{}
+	for svcName, svc := range compose {
+		if svc.Build.Kind == 0 {
+			continue
+		}
+		context, _ := composeBuild(svc.Build)
+		dir := filepath.Clean(context)
+		if _, ok := names[dir]; ok {
+			composeDirs[svcName] = dir
+			hosts[svcName] = dir
+		}
+	}
+
+	dirs := slices.Sorted(maps.Keys(names))
+	result := make(map[string][]componentCall, len(names))
+	for _, dir := range dirs {
+		found := map[string]string{} // called dir → evidence
+		for svcName, svcDir := range composeDirs {
+			if svcDir != dir {
+				continue
+			}
+			for _, dep := range composeNames(compose[svcName].DependsOn) {
+				if to, ok := composeDirs[dep]; ok && to != dir {
+					found[to] = "docker-compose depends_on"
+				}
+			}
+		}
+
+		var nested []string
+		for _, other := range dirs {
+			if other != dir && (dir == "." || strings.HasPrefix(other, dir+string(filepath.Separator))) {
+				nested = append(nested, other)
+			}
+		}
+		for to, evidence := range scanComponentCalls(repoPath, dir, nested, hosts) {
+			if _, ok := found[to]; !ok && to != dir {
+				found[to] = evidence
+			}
+		}
+
+		for _, to := range slices.Sorted(maps.Keys(found)) {
+			result[dir] = append(result[dir], componentCall{Dir: to, Evidence: found[to]})
+		}
+	}
+	return result
+}
+
+// scanComponentCalls scans the source and config files under dir, except
+// the nested component directories and tests, for addresses of hosts. It
+// returns the first place each called directory appears.
+func scanComponentCalls(repoPath, dir string, nested []string, hosts map[string]string) map[string]string {
+	skip := map[string]bool{}
+	for _, n := range nested {
+		skip[filepath.Join(repoPath, n)] = true
+	}
+
+	found := map[string]string{}
+	files := 0
+	root := filepath.Join(repoPath, dir)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (source.SkipDir(d.Name()) || skip[path]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(path)
+		isEnvFile := strings.HasPrefix(d.Name(), ".env")
+		if !source.IsCode(ext) && !callConfigExts[ext] && !isEnvFile || source.IsTest(d.Name()) {
+			return nil
+		}
+		if files >= source.MaxFiles {
+			return filepath.SkipAll
+		}
+		files++
+
+		info, err := d.Info()
+		if err != nil || info.Size() > 512*1024 {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(repoPath, path)
+		for i, line := range strings.Split(string(data), "\n") {
+			for _, host := range callHosts(line) {
+				to, ok := hosts[host]
+				if _, seen := found[to]; ok && !seen {
+					found[to] = fmt.Sprintf("%s:%d", filepath.ToSlash(rel), i+1)
+				}
+			}
+		}
+		return nil
+	})
+	return found
+}
+
+// callHosts returns the in-cluster host names addressed on line: URL
+// hosts and host:port pairs, with a Kubernetes Service suffix such as
+// .default.svc.cluster.local dropped. Other dotted names are external.
+func callHosts(line string) []string {
+	var hosts []string
+	for _, m := range callURLHost.FindAllStringSubmatch(line, -1) {
+		host, rest, dotted := strings.Cut(m[1], ".")
+		if !dotted || rest == "svc" || strings.HasPrefix(rest, "svc.") || strings.Contains(rest, ".svc") {
+			hosts = append(hosts, host)
+		}
+	}
+	for _, m := range callHostPort.FindAllStringSubmatch(line, -1) {
+		hosts = append(hosts, m[1])
+	}
+	return hosts
+}
+
+// componentNames maps each component's directory to its name.
+func componentNames(components []*Component) map[string]string {
+	names := make(map[string]string, len(components))
+	for _, c := range components {
+		names[c.Dir] = c.Name
+	}
+	return names
+}
+
+// applyComponentCalls sets each component's dependsOn to the components
+// it calls.
+func applyComponentCalls(components []*Component, calls map[string][]componentCall) {
+	names := componentNames(components)
+	for _, c := range components {
+		for _, call := range calls[c.Dir] {
+			c.DependsOn = append(c.DependsOn, names[call.Dir])
+		}
+		sort.Strings(c.DependsOn)
+	}
+}
+
+// writeComponentCalls tells the AI which components call which.
+func writeComponentCalls(b *strings.Builder, ctx *Repo) {
+	found := false
+	for _, calls := range ctx.componentCalls {
+		found = found || len(calls) > 0
+	}
+	if !found {
+		return
+	}
+	b.WriteString("## Calls between components\n\n")
+	b.WriteString("These components address another one of the repo (by URL, host:port, or a\n")
+	b.WriteString("docker-compose depends_on). Set depends-on on the caller's kindling-deploy\n")
+	b.WriteString("step to the DSE names of the components it calls, and point its env at them\n")
+	b.WriteString("as http://${{ github.actor }}-<name>:<port>.\n\n")
+	for _, dir := range slices.Sorted(maps.Keys(ctx.componentCalls)) {
+		for _, call := range ctx.componentCalls[dir] {
+			fmt.Fprintf(b, "- %s calls %s (%s)\n", dir, call.Dir, call.Evidence)
+		}
+	}
+	b.WriteString("\n")
+}
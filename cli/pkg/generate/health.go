@@ -0,0 +1,148 @@
+package generate
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Health-check inference
+// ────────────────────────────────────────────────────────────────────────────
+//
+// The DSE probes default to GET /healthz, which crash-loops any app that
+// doesn't serve it. generate reads each component's route registrations
+// and uses the health endpoint it finds; components with none get a TCP
+// probe instead.
+
+// healthRouteNames are the last path segments treated as health endpoints,
+// most preferred first.
+var healthRouteNames = []string{"healthz", "health", "livez", "readyz", "ready", "ping"}
+
+// healthRouteDefs match route registrations and capture the path.
+var healthRouteDefs = []*regexp.Regexp{
+	// Go: gin/echo/fiber (r.GET), chi (r.Get), gorilla/mux and net/http (HandleFunc/Handle)
+	regexp.MustCompile(`\.(?:GET|Get|HEAD|Head|Any|HandleFunc|Handle)\(\s*"(/[^"]*)"`),
+	// Python: FastAPI/Flask decorators (@app.get, @router.get, @app.route)
+	regexp.MustCompile(`@\w+\.(?:get|head|route|api_route)\(\s*["'](/[^"']*)["']`),
+	// Node: Express/Fastify/Koa routers
+	regexp.MustCompile("\\b\\w+\\.(?:get|head|all|use)\\(\\s*[\"'`](/[^\"'`]*)[\"'`]"),
+	// Java/Kotlin: Spring MVC
+	regexp.MustCompile(`@(?:GetMapping|RequestMapping)\(\s*(?:(?:value|path)\s*=\s*)?"(/[^"]*)"`),
+}
+
+// inferHealthChecks returns the detected health path for each build
+// directory. Directories with no health route map to "". Nested
+// directories in dirs are left out of their parents' scans.
+func inferHealthChecks(repoPath string, dirs []string, depFiles map[string]string) map[string]string {
+	result := make(map[string]string, len(dirs))
+	for _, dir := range dirs {
+		var nested []string
+		for _, other := range dirs {
+			if other != dir && (dir == "." || strings.HasPrefix(other, dir+string(filepath.Separator))) {
+				nested = append(nested, other)
+			}
+		}
+		result[dir] = inferHealthCheckPath(repoPath, dir, nested, depFiles)
+	}
+	return result
+}
+
+// inferHealthCheckPath scans the route definitions under dir (skipping the
+// nested component directories) and returns the preferred health path, or
+// "" when there is none.
+func inferHealthCheckPath(repoPath, dir string, nested []string, depFiles map[string]string) string {
+	// Spring Boot Actuator serves its own endpoint.
+	for rel, content := range depFiles {
+		if filepath.Dir(rel) == dir && strings.Contains(content, "spring-boot-starter-actuator") {
+			return "/actuator/health"
+		}
+	}
+
+	skip := map[string]bool{}
+	for _, n := range nested {
+		skip[filepath.Join(repoPath, n)] = true
+	}
+
+	var found []string
+	files := 0
+	root := filepath.Join(repoPath, dir)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (source.SkipDir(d.Name()) || skip[path]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !source.IsCode(filepath.Ext(path)) || source.IsTest(d.Name()) {
+			return nil
+		}
+		if files >= source.MaxFiles {
+			return filepath.SkipAll
+		}
+		files++
+
+		info, err := d.Info()
+		if err != nil || info.Size() > 512*1024 {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, re := range healthRouteDefs {
+			for _, m := range re.FindAllSubmatch(data, -1) {
+				if isHealthRoute(string(m[1])) {
+					found = append(found, string(m[1]))
+				}
+			}
+		}
+		return nil
+	})
+
+	return preferredHealthRoute(found)
+}
+
+// isHealthRoute reports whether the last segment of route is one of
+// healthRouteNames.
+func isHealthRoute(route string) bool {
+	return healthRouteRank(route) < len(healthRouteNames)
+}
+
+func healthRouteRank(route string) int {
+	last := strings.ToLower(route[strings.LastIndex(strings.TrimRight(route, "/"), "/")+1:])
+	last = strings.TrimRight(last, "/")
+	for i, name := range healthRouteNames {
+		if last == name {
+			return i
+		}
+	}
+	return len(healthRouteNames)
+}
+
+// preferredHealthRoute picks the best-ranked route, then the shortest, so
+// "/healthz" wins over "/api/v1/health" and "/ping".
+func preferredHealthRoute(routes []string) string {
+	if len(routes) == 0 {
+		return ""
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		ri, rj := healthRouteRank(routes[i]), healthRouteRank(routes[j])
+		if ri != rj {
+			return ri < rj
+		}
+		if len(routes[i]) != len(routes[j]) {
+			return len(routes[i]) < len(routes[j])
+		}
+		return routes[i] < routes[j]
+	})
+	return strings.TrimRight(routes[0], "/")
+}
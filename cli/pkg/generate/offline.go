@@ -0,0 +1,514 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffvincent/kindling/cli/pkg/build"
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Offline (heuristic) generation
+// ────────────────────────────────────────────────────────────────────────────
+//
+// When no API key is available — or --no-ai is passed — generate falls back
+// to a rule-based scan that needs no network access. It only knows the
+// common layouts (one Dockerfile per service, dependency clients named in
+// the manifest, docker-compose backing services), so the result is a
+// best-effort starting point rather than a finished manifest.
+
+// Component is one deployable service detected in the repo.
+type Component struct {
+	Name               string
+	Dir                string // relative to the repo root; "." for the root
+	Dockerfile         string // overlay Dockerfile path, when synthesized outside the repo
+	Image              string // prebuilt image to run instead of building dir
+	Port               int
+	Replicas           int // 0 means 1
+	HealthPath         string
+	Protocol           string // ingress protocol: grpc, websocket, or "" for HTTP
+	Env                []EnvVar
+	EnvDefaults        []string // read by the code with a default, listed as a comment
+	Dependencies       map[string]bool
+	DependencySettings map[string]Dependency // optional settings, by type
+	DependsOn          []string              // names of the components it calls
+}
+
+// Dependency holds the settings of a dependency beyond its type.
+type Dependency struct {
+	Version  string // image tag of the operator's default image
+	Image    string // full image, when it isn't the default one
+	SeedFrom string // host path of init scripts for the seed ConfigMap
+}
+
+// EnvVar is an environment variable set on a component: a literal
+// value, or the key of a kindling secret when secret is set.
+type EnvVar struct {
+	Name   string
+	Value  string
+	Secret string // kindling-secret-<name> Secret holding the value
+	Note   string // comment written above the entry
+}
+
+// String renders e as NAME=value for the wizard and the prompt.
+func (e EnvVar) String() string {
+	if e.Secret != "" {
+		return fmt.Sprintf("%s from secret %s", e.Name, e.Secret)
+	}
+	return e.Name + "=" + e.Value
+}
+
+// offlineDependencyHints maps a dependency type to substrings that reveal a
+// client library for it in a dependency manifest.
+var offlineDependencyHints = []struct {
+	depType string
+	hints   []string
+}{
+	{"postgres", []string{"postgres", "psycopg", "asyncpg", "github.com/lib/pq", "github.com/jackc/pgx", `"pg":`}},
+	{"mysql", []string{"mysql", "pymysql", "mysqlclient"}},
+	{"mongodb", []string{"mongo"}},
+	{"redis", []string{"redis", "ioredis"}},
+	{"rabbitmq", []string{"amqp", "pika", "rabbitmq"}},
+	{"kafka", []string{"kafka"}},
+	{"nats", []string{"nats.go", "nats-py", `"nats":`, "nats.io"}},
+	{"elasticsearch", []string{"elasticsearch"}},
+	{"memcached", []string{"memcache"}},
+	{"minio", []string{"minio"}},
+	{"cassandra", []string{"cassandra", "gocql"}},
+	{"consul", []string{"hashicorp/consul", "python-consul"}},
+	{"vault", []string{"hashicorp/vault", "hvac", "node-vault"}},
+	{"influxdb", []string{"influxdb"}},
+	{"jaeger", []string{"jaeger"}},
+}
+
+// offlineComposeImages maps docker-compose image name prefixes to
+// dependency types.
+var offlineComposeImages = map[string]string{
+	"postgres":                 "postgres",
+	"postgis/postgis":          "postgres",
+	"bitnami/postgresql":       "postgres",
+	"mysql":                    "mysql",
+	"mariadb":                  "mysql",
+	"bitnami/mysql":            "mysql",
+	"mongo":                    "mongodb",
+	"bitnami/mongodb":          "mongodb",
+	"redis":                    "redis",
+	"bitnami/redis":            "redis",
+	"rabbitmq":                 "rabbitmq",
+	"bitnami/rabbitmq":         "rabbitmq",
+	"confluentinc/cp-kafka":    "kafka",
+	"bitnami/kafka":            "kafka",
+	"apache/kafka":             "kafka",
+	"nats":                     "nats",
+	"elasticsearch":            "elasticsearch",
+	"memcached":                "memcached",
+	"minio/minio":              "minio",
+	"cassandra":                "cassandra",
+	"consul":                   "consul",
+	"hashicorp/consul":         "consul",
+	"vault":                    "vault",
+	"hashicorp/vault":          "vault",
+	"influxdb":                 "influxdb",
+	"jaegertracing/all-in-one": "jaeger",
+}
+
+// DetectComponents works out the components of the repo and their ports,
+// health checks, protocols, and dependencies from the scan results alone.
+func DetectComponents(repoPath string, ctx *Repo) []*Component {
+	components := detectOfflineComponents(ctx)
+	compose := readComposeServices(repoPath)
+
+	dirs := make([]string, 0, len(components))
+	for _, c := range components {
+		dirs = append(dirs, c.Dir)
+	}
+	health := inferHealthChecks(repoPath, dirs, ctx.depFiles)
+	applyFrameworkHealth(health, ctx.Frameworks)
+	protocols := inferIngressProtocols(dirs, ctx.depFiles)
+	envReads := source.EnvVars(repoPath, dirs)
+
+	for _, c := range components {
+		c.Dockerfile = ctx.overlayDockerfiles[c.Dir]
+		c.Port = detectOfflinePort(c, ctx, compose)
+		c.HealthPath = health[c.Dir]
+		c.Protocol = protocols[c.Dir]
+		for rel, content := range ctx.depFiles {
+			if ownerComponent(components, rel) == c {
+				for dep := range detectManifestDependencies(content) {
+					c.Dependencies[dep] = true
+				}
+			}
+		}
+		if det := ctx.Frameworks[c.Dir]; det != nil {
+			for _, dep := range det.Services {
+				c.Dependencies[dep] = true
+			}
+		}
+	}
+
+	// Backing services in docker-compose are shared by every component
+	// that doesn't already name its own client libraries.
+	composeDeps := composeDependencies(compose)
+	for _, c := range components {
+		if len(c.Dependencies) == 0 {
+			for dep := range composeDeps {
+				c.Dependencies[dep] = true
+			}
+		}
+		applyEnvReads(c, envReads[c.Dir], ctx.dockerfiles[filepath.Join(c.Dir, "Dockerfile")])
+	}
+	applyComponentCalls(components, inferComponentCalls(repoPath, componentNames(components), compose))
+	return components
+}
+
+// RenderManifest builds the DevStagingEnvironment manifests for the
+// components, one document each, for cluster.
+func RenderManifest(components []*Component, cluster Cluster) string {
+	var sb strings.Builder
+	for i, c := range components {
+		if i > 0 {
+			sb.WriteString("---\n")
+		}
+		writeOfflineDSE(&sb, c, cluster)
+	}
+	return sb.String()
+}
+
+// detectOfflineComponents returns one component per directory holding a
+// Dockerfile, or per directory holding a dependency manifest when the repo
+// has no Dockerfiles at all.
+func detectOfflineComponents(ctx *Repo) []*Component {
+	dirs := map[string]bool{}
+	for rel := range ctx.dockerfiles {
+		dirs[filepath.Dir(rel)] = true
+	}
+	if len(dirs) == 0 {
+		for rel := range ctx.depFiles {
+			switch filepath.Base(rel) {
+			case "go.mod", "package.json", "requirements.txt", "pyproject.toml", "Pipfile":
+				dirs[filepath.Dir(rel)] = true
+			}
+		}
+	}
+	if len(dirs) == 0 {
+		dirs["."] = true
+	}
+
+	sorted := make([]string, 0, len(dirs))
+	for d := range dirs {
+		sorted = append(sorted, d)
+	}
+	sort.Strings(sorted)
+
+	var components []*Component
+	seen := map[string]bool{}
+	for _, dir := range sorted {
+		name := ctx.Name
+		if dir != "." {
+			name = filepath.Base(dir)
+		}
+		name = source.DNSLabel(name)
+		if seen[name] {
+			name = source.DNSLabel(strings.ReplaceAll(dir, string(filepath.Separator), "-"))
+		}
+		seen[name] = true
+		components = append(components, &Component{
+			Name:         name,
+			Dir:          dir,
+			Dependencies: map[string]bool{},
+		})
+	}
+	return components
+}
+
+// ownerComponent returns the component whose directory most closely
+// contains rel, or nil.
+func ownerComponent(components []*Component, rel string) *Component {
+	var owner *Component
+	best := -1
+	dir := filepath.Dir(rel)
+	for _, c := range components {
+		if c.Dir == "." || dir == c.Dir || strings.HasPrefix(dir, c.Dir+string(filepath.Separator)) {
+			if len(c.Dir) > best {
+				owner, best = c, len(c.Dir)
+			}
+		}
+	}
+	return owner
+}
+
+// detectOfflinePort picks the container port from EXPOSE, then the
+// docker-compose service of the same name, then the detected framework's,
+// then a per-language default.
+func detectOfflinePort(c *Component, ctx *Repo, compose map[string]composeService) int {
+	for rel, content := range ctx.dockerfiles {
+		if filepath.Dir(rel) != c.Dir {
+			continue
+		}
+		if m := source.ExposeDirective.FindStringSubmatch(content); m != nil {
+			if port, err := strconv.Atoi(m[1]); err == nil {
+				return port
+			}
+		}
+	}
+
+	if svc, ok := compose[c.Name]; ok {
+		for _, mapping := range svc.Ports {
+			// "8080", "3000:8080", "127.0.0.1:3000:8080/tcp" → container side
+			parts := strings.Split(strings.Split(mapping, "/")[0], ":")
+			if port, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
+				return port
+			}
+		}
+	}
+
+	if det := ctx.Frameworks[c.Dir]; det != nil && det.Port > 0 {
+		return det.Port
+	}
+
+	for rel := range ctx.depFiles {
+		if filepath.Dir(rel) != c.Dir {
+			continue
+		}
+		switch filepath.Base(rel) {
+		case "package.json":
+			return 3000
+		case "requirements.txt", "pyproject.toml", "Pipfile":
+			return 8000
+		}
+	}
+	return 8080
+}
+
+// detectManifestDependencies returns the dependency types whose client
+// libraries appear in a dependency manifest.
+func detectManifestDependencies(content string) map[string]bool {
+	lower := strings.ToLower(content)
+	deps := map[string]bool{}
+	for _, h := range offlineDependencyHints {
+		for _, hint := range h.hints {
+			if strings.Contains(lower, hint) {
+				deps[h.depType] = true
+				break
+			}
+		}
+	}
+	return deps
+}
+
+// composeService is the subset of a docker-compose service kindling reads.
+type composeService struct {
+	Image     string    `yaml:"image"`
+	Ports     []string  `yaml:"ports"`
+	Build     yaml.Node `yaml:"build"`
+	DependsOn yaml.Node `yaml:"depends_on"`
+}
+
+// readComposeServices parses the docker-compose file at the repo root, if
+// any. Parse errors are ignored — compose data only refines the guesses.
+func readComposeServices(repoPath string) map[string]composeService {
+	for _, name := range []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"} {
+		data, err := os.ReadFile(filepath.Join(repoPath, name))
+		if err != nil {
+			continue
+		}
+		var file struct {
+			Services map[string]composeService `yaml:"services"`
+		}
+		if yaml.Unmarshal(data, &file) == nil {
+			return file.Services
+		}
+	}
+	return nil
+}
+
+// composeDependencies maps docker-compose backing-service images to
+// dependency types.
+func composeDependencies(services map[string]composeService) map[string]bool {
+	deps := map[string]bool{}
+	for _, svc := range services {
+		if depType, _, ok := composeImageDependency(svc.Image); ok {
+			deps[depType] = true
+		}
+	}
+	return deps
+}
+
+// composeImageDependency maps a docker-compose image to a dependency type.
+// When the image is the operator's default for that type, details carries
+// its tag as the version; otherwise it carries the whole image.
+func composeImageDependency(image string) (string, Dependency, bool) {
+	repo, tag := build.SplitTag(image)
+	repo = strings.TrimPrefix(repo, "docker.io/")
+	repo = strings.TrimPrefix(repo, "library/")
+	depType, ok := offlineComposeImages[repo]
+	if !ok {
+		return "", Dependency{}, false
+	}
+	var details Dependency
+	switch {
+	case repo != devstaging.DependencyConventions[depType].Image:
+		details.Image = image
+	case tag != "latest":
+		details.Version = tag
+	}
+	return depType, details, true
+}
+
+// Cluster is what the running cluster contributes to the offline
+// manifests. The zero value describes a cluster without a local registry
+// or TLS.
+type Cluster struct {
+	Registry     string // local registry address, "" when not running
+	TLSIssuer    string // cert-manager ClusterIssuer of the ingresses, "" for none
+	TLSDomain    string // domain the ingresses are served on over HTTPS, "" for localhost
+	IngressClass string // "" is nginx
+	Namespace    string // environment namespace, "" to leave it to kubectl
+}
+
+// writeOfflineDSE renders one component as a DevStagingEnvironment in the
+// same layout as the examples/ manifests. With a local registry address the
+// image lives there and is pushed rather than loaded with kind load. With a
+// local TLS setup the ingress gets a certificate from its issuer.
+func writeOfflineDSE(sb *strings.Builder, c *Component, cluster Cluster) {
+	registry := cluster.Registry
+	ingressClass := cluster.IngressClass
+	if ingressClass == "" {
+		ingressClass = "nginx"
+	}
+	buildArgs := c.Dir
+	if c.Dockerfile != "" {
+		buildArgs = fmt.Sprintf("-f %s %s", c.Dockerfile, c.Dir)
+	}
+	image := c.Name + ":dev"
+	ship := fmt.Sprintf("kind load docker-image %s --name dev", image)
+	verb := "load"
+	if registry != "" {
+		image = registry + "/" + image
+		ship = "docker push " + image
+		verb = "push"
+	}
+	build := fmt.Sprintf(`  # Build and %[4]s the image first:
+  #   docker build -t %[2]s %[1]s
+  #   %[3]s
+`, buildArgs, image, ship, verb)
+	if c.Image != "" {
+		image, build = c.Image, "  # Runs a prebuilt image — nothing to build\n"
+	}
+	namespace := ""
+	if cluster.Namespace != "" {
+		namespace = "  namespace: " + cluster.Namespace + "\n"
+	}
+	// One replica is the default; the operator's defaulting webhook
+	// writes it, with the pull policy and resource requests, into the
+	// object in the cluster.
+	replicas := ""
+	if c.Replicas > 1 {
+		replicas = fmt.Sprintf("    replicas: %d\n", c.Replicas)
+	}
+	fmt.Fprintf(sb, `apiVersion: apps.example.com/v1alpha1
+kind: DevStagingEnvironment
+metadata:
+  name: %[1]s-dev
+%[6]s  labels:
+    app.kubernetes.io/part-of: %[1]s
+    app.kubernetes.io/managed-by: kindling
+spec:
+  # ── Application ─────────────────────────────────────────────────
+%[2]s  deployment:
+    image: %[4]s
+%[5]s    port: %[3]d
+`, c.Name, build, c.Port, image, replicas, namespace)
+	if c.HealthPath != "" {
+		fmt.Fprintf(sb, "    healthCheck:\n      path: %s\n", c.HealthPath)
+	} else {
+		sb.WriteString("    # No health route found in the source — probe the port instead\n")
+		sb.WriteString("    healthCheck:\n      type: tcp\n")
+	}
+	if len(c.Env) > 0 {
+		sb.WriteString("    env:\n")
+		for _, e := range c.Env {
+			if e.Note != "" {
+				fmt.Fprintf(sb, "      # %s\n", e.Note)
+			}
+			if e.Secret != "" {
+				fmt.Fprintf(sb, "      - name: %s\n        valueFrom:\n          secretKeyRef:\n            name: %s\n            key: %s\n", e.Name, e.Secret, e.Name)
+				continue
+			}
+			fmt.Fprintf(sb, "      - name: %s\n        value: %s\n", e.Name, strconv.Quote(e.Value))
+		}
+	}
+	if len(c.EnvDefaults) > 0 {
+		fmt.Fprintf(sb, "    # Also read by the code, with a default: %s\n", strings.Join(c.EnvDefaults, ", "))
+	}
+	scheme, domain := "http", "localhost"
+	if cluster.TLSDomain != "" {
+		scheme, domain = "https", cluster.TLSDomain
+	}
+	fmt.Fprintf(sb, `
+  # ── Networking ──────────────────────────────────────────────────
+  service:
+    port: %[2]d
+    type: ClusterIP
+
+  # ── Ingress ────────────────────────────────────────────────────
+  # Access via: %[3]s://%[1]s.%[4]s
+  ingress:
+    enabled: true
+    host: %[1]s.%[4]s
+    ingressClassName: %[5]s
+`, c.Name, c.Port, scheme, domain, ingressClass)
+	if c.Protocol != "" {
+		fmt.Fprintf(sb, "    protocol: %s\n", c.Protocol)
+	}
+	if cluster.TLSIssuer != "" {
+		fmt.Fprintf(sb, `    annotations:
+      cert-manager.io/cluster-issuer: %[2]s
+    tls:
+      secretName: %[1]s-dev-tls
+`, c.Name, cluster.TLSIssuer)
+	}
+
+	if len(c.DependsOn) > 0 {
+		sb.WriteString("\n  # ── Startup order ──────────────────────────────────────────────\n")
+		sb.WriteString("  # Calls these components; the operator starts it once they're available\n")
+		sb.WriteString("  dependsOn:\n")
+		for _, d := range c.DependsOn {
+			fmt.Fprintf(sb, "    - %s-dev\n", d)
+		}
+	}
+
+	if len(c.Dependencies) > 0 {
+		deps := make([]string, 0, len(c.Dependencies))
+		for d := range c.Dependencies {
+			deps = append(deps, d)
+		}
+		sort.Strings(deps)
+		sb.WriteString("\n  # ── Dependencies ────────────────────────────────────────────────\n")
+		sb.WriteString("  dependencies:\n")
+		for _, d := range deps {
+			fmt.Fprintf(sb, "    - type: %s\n", d)
+			details := c.DependencySettings[d]
+			if details.Version != "" {
+				fmt.Fprintf(sb, "      version: %s\n", strconv.Quote(details.Version))
+			}
+			if details.Image != "" {
+				fmt.Fprintf(sb, "      image: %s\n", details.Image)
+			}
+			if details.SeedFrom != "" {
+				seed := fmt.Sprintf("%s-%s-seed", c.Name, d)
+				fmt.Fprintf(sb, "      # Create the seed ConfigMap from the compose init scripts first:\n")
+				fmt.Fprintf(sb, "      #   kubectl create configmap %s --from-file=%s\n", seed, details.SeedFrom)
+				fmt.Fprintf(sb, "      seed:\n        configMap: %s\n", seed)
+			}
+		}
+	}
+}
@@ -0,0 +1,419 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+// ── Structured generation ───────────────────────────────────────
+//
+// Rather than trusting the model to write valid YAML, generate asks it for
+// a deploy plan: a JSON object held to PlanSchema by the provider's
+// structured-output support. Each service of the plan is a build context
+// plus the DevStagingEnvironment fields kindling-deploy takes, and the
+// workflow is rendered from it here, so it always parses and always has
+// the shape kindling-build and kindling-deploy expect. --structured=false
+// goes back to asking for the YAML itself; kindling generate
+// --print-schema prints the schema.
+
+// deployPlan is the model's structured answer.
+type deployPlan struct {
+	Services []planService `json:"services"`
+	Notes    []string      `json:"notes"`
+}
+
+// planService is one component: how to build it and the DSE to deploy.
+type planService struct {
+	Name            string           `json:"name"`
+	Context         string           `json:"context"`
+	Dockerfile      string           `json:"dockerfile"`
+	Port            int              `json:"port"`
+	HealthCheckType string           `json:"healthCheckType"`
+	HealthCheckPath string           `json:"healthCheckPath"`
+	Ingress         bool             `json:"ingress"`
+	IngressProtocol string           `json:"ingressProtocol"`
+	Env             []planEnvVar     `json:"env"`
+	Dependencies    []planDependency `json:"dependencies"`
+	DependsOn       []string         `json:"dependsOn"`
+}
+
+// planEnvVar is a literal value, or a key of a Secret when SecretName is
+// set.
+type planEnvVar struct {
+	Name       string `json:"name"`
+	Value      string `json:"value"`
+	SecretName string `json:"secretName"`
+	SecretKey  string `json:"secretKey"`
+}
+
+// planDependency is a backing service the operator provisions.
+type planDependency struct {
+	Type    string `json:"type"`
+	Version string `json:"version"`
+}
+
+// PlanSchema returns the JSON Schema of deployPlan. Every property
+// is required and none may be added, as strict structured outputs demand;
+// empty strings and lists stand for "not set".
+func PlanSchema() ReplySchema {
+	str := func(desc string) map[string]interface{} {
+		return map[string]interface{}{"type": "string", "description": desc}
+	}
+	enum := func(desc string, values ...string) map[string]interface{} {
+		return map[string]interface{}{"type": "string", "description": desc, "enum": values}
+	}
+	list := func(desc string, items map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"type": "array", "description": desc, "items": items}
+	}
+
+	depTypes := slices.Sorted(maps.Keys(devstaging.DependencyConventions))
+	envVar := SchemaObject("An environment variable of the app container.", map[string]interface{}{
+		"name":       str("Variable name."),
+		"value":      str(`Literal value; "" when secretName is set. Reference another service as http://${{ github.actor }}-<name>:<port>.`),
+		"secretName": str(`Kubernetes Secret the value comes from (kindling-secret-<name>), or "".`),
+		"secretKey":  str(`Key in secretName, or "".`),
+	})
+	dependency := SchemaObject("A backing service the kindling operator provisions and injects the connection URL of.", map[string]interface{}{
+		"type":    enum("Dependency type.", depTypes...),
+		"version": str(`Image tag, e.g. "16" for postgres, or "" for the default.`),
+	})
+	service := SchemaObject("One deployable component: how its image is built and the DevStagingEnvironment it runs as.", map[string]interface{}{
+		"name":            str("Short DNS label of the component, e.g. api or web."),
+		"context":         str(`Build context directory relative to the repository root, "." for the root.`),
+		"dockerfile":      str(`Dockerfile path relative to the context, "" for Dockerfile.`),
+		"port":            map[string]interface{}{"type": "integer", "description": "Port the container listens on (spec.deployment.port), 1-65535."},
+		"healthCheckType": enum("http to GET healthCheckPath, tcp when the app serves no health route.", "http", "tcp"),
+		"healthCheckPath": str(`HTTP health route, e.g. /healthz; "" with tcp.`),
+		"ingress":         map[string]interface{}{"type": "boolean", "description": "Expose the component at <actor>-<name>.localhost (frontends and public APIs)."},
+		"ingressProtocol": enum(`Protocol behind the ingress: "" for HTTP, grpc, or websocket.`, "", "grpc", "websocket"),
+		"env":             list("Environment variables (spec.deployment.env). Leave out the connection URLs dependencies inject.", envVar),
+		"dependencies":    list("Backing services (spec.dependencies).", dependency),
+		"dependsOn":       list("Names of the other services this one calls, which must be available before it starts (spec.dependsOn).", str("Service name, as in name.")),
+	})
+	return ReplySchema{
+		Name:        "deploy_plan",
+		Description: "The services of the repository and how kindling builds and deploys each of them.",
+		Schema: SchemaObject("A kindling deploy plan.", map[string]interface{}{
+			"services": list("Every deployable component, in build order.", service),
+			"notes":    list("Things the developer must do before deploying, such as secrets to set with kindling secrets set or running kindling expose for OAuth; each becomes a comment.", str("One note.")),
+		}),
+	}
+}
+
+// SchemaObject is a closed object schema whose properties are all
+// required.
+func SchemaObject(desc string, props map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"description":          desc,
+		"properties":           props,
+		"required":             slices.Sorted(maps.Keys(props)),
+		"additionalProperties": false,
+	}
+}
+
+// ── Prompts ─────────────────────────────────────────────────────
+
+const (
+	yamlReplyInstruction = "Return ONLY the raw YAML content of the workflow file."
+	yamlFinalInstruction = "Now generate the dev-deploy.yml workflow YAML for this repository. Return ONLY the YAML."
+
+	planReplyInstruction = `Answer with a deploy plan instead of the workflow YAML: a JSON object
+matching the deploy_plan schema, with one service per kindling-build +
+kindling-deploy pair. kindling renders the workflow from it — the build
+and deploy steps, the <actor>- name prefixes, image names, labels, and
+ingress hosts — so give only the facts: contexts, Dockerfiles, ports,
+health checks, env vars, dependencies, and notes. Everything the system
+prompt says about choosing them still applies.`
+	planFinalInstruction = "Now produce the deploy plan for this repository."
+)
+
+// planPrompts turns the YAML prompts into the deploy plan's.
+func planPrompts(systemPrompt, userPrompt string) (string, string) {
+	if i := strings.Index(systemPrompt, yamlReplyInstruction); i >= 0 {
+		systemPrompt = systemPrompt[:i] + planReplyInstruction
+	}
+	return systemPrompt, strings.Replace(userPrompt, yamlFinalInstruction, planFinalInstruction, 1)
+}
+
+// ── Reply formats ───────────────────────────────────────────────
+
+// ReplyFormat is what the model is asked to answer with and how the answer
+// becomes a workflow.
+type ReplyFormat interface {
+	generate(g Generator, systemPrompt, userPrompt string, maxTokens int) (string, Usage, error)
+	// workflow returns the workflow of a reply, and the reply cleaned up
+	// for the history and the correction prompt.
+	workflow(reply string) (workflow, answer string, err error)
+	// lang is the code fence language and file extension of answers.
+	lang() string
+}
+
+// YAMLReply asks for the workflow itself.
+type YAMLReply struct{}
+
+func (YAMLReply) generate(g Generator, systemPrompt, userPrompt string, maxTokens int) (string, Usage, error) {
+	return g.Generate(systemPrompt, userPrompt, maxTokens)
+}
+
+func (YAMLReply) workflow(reply string) (string, string, error) {
+	w := CleanYAML(reply)
+	return w, w, nil
+}
+
+func (YAMLReply) lang() string { return "yaml" }
+
+// PlanReply asks for a deploy plan and renders it.
+type PlanReply struct {
+	Branch  string // the workflow deploys on pushes to it
+	Project string // labels the deployments as parts of it, e.g. the repo's name
+}
+
+func (p PlanReply) generate(g Generator, systemPrompt, userPrompt string, maxTokens int) (string, Usage, error) {
+	sg, ok := g.(StructuredGenerator)
+	if !ok {
+		return "", Usage{}, fmt.Errorf("%s can't hold replies to a schema", g.Name())
+	}
+	systemPrompt, userPrompt = planPrompts(systemPrompt, userPrompt)
+	return sg.GenerateJSON(systemPrompt, userPrompt, maxTokens, PlanSchema())
+}
+
+func (p PlanReply) workflow(reply string) (string, string, error) {
+	answer := strings.TrimSpace(CleanYAML(reply))
+	plan, err := decodeDeployPlan(answer)
+	if err != nil {
+		return "", answer, err
+	}
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, []byte(answer), "", "  ") == nil {
+		answer = pretty.String()
+	}
+	return renderPlanWorkflow(plan, p.Branch, p.Project), answer, nil
+}
+
+func (PlanReply) lang() string { return "json" }
+
+// decodeDeployPlan parses a plan and checks what the schema can't.
+func decodeDeployPlan(data string) (*deployPlan, error) {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.DisallowUnknownFields()
+	var plan deployPlan
+	if err := dec.Decode(&plan); err != nil {
+		return nil, fmt.Errorf("the deploy plan is not valid JSON for the schema: %w", err)
+	}
+	if len(plan.Services) == 0 {
+		return nil, fmt.Errorf("the deploy plan has no services")
+	}
+	seen := map[string]bool{}
+	for i := range plan.Services {
+		s := &plan.Services[i]
+		s.Name = source.DNSLabel(s.Name)
+		if s.Name == "" {
+			return nil, fmt.Errorf("service %d of the deploy plan has no name", i+1)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("the deploy plan names two services %q", s.Name)
+		}
+		seen[s.Name] = true
+		s.Context = path.Clean(strings.TrimPrefix(strings.ReplaceAll(s.Context, "\\", "/"), "/"))
+		if s.Context == ".." || strings.HasPrefix(s.Context, "../") {
+			return nil, fmt.Errorf("service %q builds from %q, outside the repository", s.Name, s.Context)
+		}
+	}
+	return &plan, nil
+}
+
+// ── Rendering ───────────────────────────────────────────────────
+
+// renderPlanWorkflow writes the dev-deploy.yml of a plan, in the layout of
+// the prompt's reference examples.
+func renderPlanWorkflow(plan *deployPlan, branch, project string) string {
+	var b strings.Builder
+	for _, n := range plan.Notes {
+		if n = strings.TrimSpace(n); n != "" {
+			fmt.Fprintf(&b, "# NOTE: %s\n", strings.ReplaceAll(n, "\n", "\n#       "))
+		}
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, `name: Dev Deploy
+
+on:
+  push:
+    branches: [%s]
+  workflow_dispatch:
+
+env:
+  REGISTRY: "registry:5000"
+  TAG: "${{ github.actor }}-${{ github.sha }}"
+
+jobs:
+  build-and-deploy:
+    runs-on: [self-hosted, "${{ github.actor }}"]
+
+    steps:
+      - name: Checkout code
+        uses: actions/checkout@v4
+
+      - name: Clean builds directory
+        shell: bash
+        run: |
+          rm -f /builds/*.done /builds/*.request /builds/*.processing \
+                /builds/*.apply /builds/*.apply-done /builds/*.apply-log \
+                /builds/*.apply-exitcode /builds/*.exitcode \
+                /builds/*.log /builds/*.dest /builds/*.tar.gz \
+                /builds/*.yaml /builds/*.sh
+`, branch)
+
+	for _, s := range plan.Services {
+		writePlanBuild(&b, s, plan.Services)
+	}
+	for _, s := range planDeployOrder(plan.Services) {
+		writePlanDeploy(&b, s, project)
+	}
+
+	b.WriteString("\n      - name: Summary\n        run: |\n          echo \"🎉 Deploy complete!\"\n")
+	for _, s := range plan.Services {
+		if s.Ingress {
+			fmt.Fprintf(&b, "          echo \"🌐 %s: http://${{ github.actor }}-%s.localhost\"\n", s.Name, s.Name)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// planDeployOrder orders services so each comes after the ones it
+// depends on, keeping the plan's order otherwise. Services on a dependsOn
+// cycle keep their place; validate reports the cycle.
+func planDeployOrder(services []planService) []planService {
+	byName := map[string]int{}
+	for i, s := range services {
+		byName[s.Name] = i
+	}
+	ordered := make([]planService, 0, len(services))
+	state := make([]int, len(services)) // 0 unvisited, 1 visiting, 2 done
+	var visit func(i int)
+	visit = func(i int) {
+		if state[i] != 0 {
+			return
+		}
+		state[i] = 1
+		for _, up := range services[i].DependsOn {
+			if j, ok := byName[up]; ok {
+				visit(j)
+			}
+		}
+		state[i] = 2
+		ordered = append(ordered, services[i])
+	}
+	for i := range services {
+		visit(i)
+	}
+	return ordered
+}
+
+// writePlanBuild writes the kindling-build step of s. Contexts of other
+// services nested in its own are left out of the tarball.
+func writePlanBuild(b *strings.Builder, s planService, all []planService) {
+	ctx := "${{ github.workspace }}"
+	if s.Context != "." {
+		ctx += "/" + s.Context
+	}
+	fmt.Fprintf(b, "\n      - name: Build %s image\n", s.Name)
+	b.WriteString("        uses: kindling-sh/kindling/.github/actions/kindling-build@main\n        with:\n")
+	fmt.Fprintf(b, "          name: %s\n", s.Name)
+	fmt.Fprintf(b, "          context: %s\n", yamlQuote(ctx))
+	fmt.Fprintf(b, "          image: \"${{ env.REGISTRY }}/%s:${{ env.TAG }}\"\n", s.Name)
+	if df := strings.TrimPrefix(s.Dockerfile, "./"); df != "" && df != "Dockerfile" {
+		fmt.Fprintf(b, "          dockerfile: %s\n", yamlQuote(df))
+	}
+	var exclude []string
+	for _, o := range all {
+		if o.Context == s.Context {
+			continue
+		}
+		if s.Context == "." {
+			exclude = append(exclude, "./"+o.Context)
+		} else if strings.HasPrefix(o.Context, s.Context+"/") {
+			exclude = append(exclude, "./"+strings.TrimPrefix(o.Context, s.Context+"/"))
+		}
+	}
+	if len(exclude) > 0 {
+		sort.Strings(exclude)
+		fmt.Fprintf(b, "          exclude: %s\n", yamlQuote(strings.Join(exclude, " ")))
+	}
+}
+
+// writePlanDeploy writes the kindling-deploy step of s.
+func writePlanDeploy(b *strings.Builder, s planService, project string) {
+	fmt.Fprintf(b, "\n      - name: Deploy %s\n", s.Name)
+	b.WriteString("        uses: kindling-sh/kindling/.github/actions/kindling-deploy@main\n        with:\n")
+	fmt.Fprintf(b, "          name: \"${{ github.actor }}-%s\"\n", s.Name)
+	fmt.Fprintf(b, "          image: \"${{ env.REGISTRY }}/%s:${{ env.TAG }}\"\n", s.Name)
+	fmt.Fprintf(b, "          port: \"%d\"\n", s.Port)
+	if s.HealthCheckType == "tcp" {
+		b.WriteString("          health-check-type: \"tcp\"\n")
+	} else if s.HealthCheckPath != "" {
+		fmt.Fprintf(b, "          health-check-path: %s\n", yamlQuote(s.HealthCheckPath))
+	}
+	b.WriteString("          labels: |\n")
+	fmt.Fprintf(b, "            app.kubernetes.io/part-of: %s\n", source.DNSLabel(project))
+	fmt.Fprintf(b, "            app.kubernetes.io/component: %s\n", s.Name)
+	b.WriteString("            apps.example.com/github-username: ${{ github.actor }}\n")
+	if len(s.Env) > 0 {
+		b.WriteString("          env: |\n")
+		for _, e := range s.Env {
+			fmt.Fprintf(b, "            - name: %s\n", e.Name)
+			if e.SecretName != "" {
+				key := e.SecretKey
+				if key == "" {
+					key = "value"
+				}
+				b.WriteString("              valueFrom:\n                secretKeyRef:\n")
+				fmt.Fprintf(b, "                  name: %s\n                  key: %s\n", e.SecretName, key)
+			} else {
+				fmt.Fprintf(b, "              value: %s\n", yamlQuote(e.Value))
+			}
+		}
+	}
+	if s.Ingress {
+		fmt.Fprintf(b, "          ingress-host: \"${{ github.actor }}-%s.localhost\"\n", s.Name)
+		if s.IngressProtocol != "" {
+			fmt.Fprintf(b, "          ingress-protocol: %s\n", yamlQuote(s.IngressProtocol))
+		}
+	}
+	if len(s.Dependencies) > 0 {
+		b.WriteString("          dependencies: |\n")
+		for _, d := range s.Dependencies {
+			fmt.Fprintf(b, "            - type: %s\n", d.Type)
+			if d.Version != "" {
+				fmt.Fprintf(b, "              version: %s\n", yamlQuote(d.Version))
+			}
+		}
+	}
+	if len(s.DependsOn) > 0 {
+		upstreams := make([]string, len(s.DependsOn))
+		for i, name := range s.DependsOn {
+			upstreams[i] = "${{ github.actor }}-" + name
+		}
+		fmt.Fprintf(b, "          depends-on: %s\n", yamlQuote(strings.Join(upstreams, ",")))
+	}
+}
+
+// yamlQuote double-quotes s for YAML; JSON string escapes are valid there.
+func yamlQuote(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,669 @@
+package generate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Prompt Builder
+// ────────────────────────────────────────────────────────────────────────────
+
+// BuildPrompt returns the prompts for ctx. The repository's tree
+// and files are packed so the whole prompt stays within tokens; it fails
+// when the fixed parts alone don't leave room for them.
+func BuildPrompt(ctx *Repo, tokens int) (system, user string, stats ContextStats, err error) {
+	system = `You are an expert at generating GitHub Actions workflow files for kindling, a Kubernetes operator that provides local dev/staging environments on Kind clusters.
+
+You generate dev-deploy.yml workflow files that use two reusable composite actions:
+
+1. kindling-build — builds a container image via Kaniko sidecar
+   Uses: kindling-sh/kindling/.github/actions/kindling-build@main
+   Inputs: name (required), context (required), image (required), exclude (optional), timeout (optional)
+   IMPORTANT: kindling-build runs the Dockerfile found at <context>/Dockerfile as-is
+   using Kaniko inside the cluster. It does NOT modify or generate Dockerfiles.
+   Every service in the workflow MUST have a working Dockerfile already in the repo.
+   If the Dockerfile doesn't build locally (e.g. docker build), it won't build
+   in kindling either. The "context" input must point to the directory containing
+   the service's Dockerfile.
+
+2. kindling-deploy — deploys a DevStagingEnvironment CR via sidecar
+   Uses: kindling-sh/kindling/.github/actions/kindling-deploy@main
+   Inputs: name (required), image (required), port (required),
+           labels, env, dependencies, depends-on, ingress-host, ingress-class,
+           ingress-protocol, health-check-path, health-check-type, replicas,
+           service-type, wait
+
+Key conventions you MUST follow:
+- Registry: registry:5000 (in-cluster)
+- Image tag: ${{ github.actor }}-${{ github.sha }}
+- Runner: runs-on: [self-hosted, "${{ github.actor }}"]
+- Ingress host pattern: ${{ github.actor }}-<service>.localhost
+- Omit ingress-class: kindling-deploy uses the cluster's default ingress class
+  (nginx, contour, or traefik, whichever kindling init installed)
+- DSE name pattern: ${{ github.actor }}-<service>
+- Always trigger on push to the default branch (specified below) + workflow_dispatch
+- Always include a "Checkout code" step with actions/checkout@v4
+- Always include a "Clean builds directory" step immediately after checkout
+- For multi-service repos, build all images first, then deploy in dependency order
+- Use the "Detected health endpoints" section for each service: set
+  health-check-path to the detected route, or set health-check-type: "tcp" (and
+  omit health-check-path) when none was found — the default /healthz probe
+  crash-loops apps that don't serve it
+- For Java/Spring Boot services, use health-check-path: "/actuator/health"
+- Use the "Detected ingress protocols" section: set ingress-protocol: "grpc" or
+  "websocket" for the listed services so their ingress routes HTTP/2 or
+  long-lived upgraded connections; omit it for plain HTTP services. gRPC
+  services rarely serve an HTTP health route, so give them health-check-type: "tcp"
+- If a service (like an API gateway) depends on other services via env vars,
+  deploy it LAST so its upstreams are already running, and set depends-on to
+  their DSE names ("${{ github.actor }}-<service>", comma-separated) so the
+  operator starts it only once they are available. Use the "Calls between
+  components" section when present
+- Add comment separators between build and deploy sections for readability:
+  "# -- Build all images --" before the first build step
+  "# -- Deploy in dependency order --" before the first deploy step
+
+kindling-deploy field ordering (follow this order exactly):
+  name, image, port, ingress-host, ingress-protocol, health-check-path, health-check-type, labels, env,
+  dependencies, depends-on, replicas, service-type, ingress-class, wait
+
+Supported dependency types for the "dependencies" input (YAML list under the input):
+  postgres, redis, mysql, mongodb, rabbitmq, minio, elasticsearch,
+  kafka, nats, memcached, cassandra, consul, vault, influxdb, jaeger
+
+Detect which dependencies to include by analyzing imports, packages, and env var
+references across ALL common languages:
+
+- Go:       "github.com/lib/pq"/"database/sql" → postgres, "github.com/go-redis" → redis,
+            "go.mongodb.org/mongo-driver" → mongodb, "github.com/streadway/amqp" → rabbitmq,
+            "github.com/segmentio/kafka-go" → kafka, "github.com/nats-io/nats.go" → nats,
+            "github.com/minio/minio-go" → minio, "github.com/elastic/go-elasticsearch" → elasticsearch,
+            "github.com/hashicorp/vault" → vault, "github.com/hashicorp/consul" → consul
+- Node/TS:  "pg"/"pg-promise" → postgres, "ioredis"/"redis" → redis, "mysql2" → mysql,
+            "mongoose"/"mongodb" → mongodb, "amqplib" → rabbitmq, "kafkajs" → kafka,
+            "nats" → nats, "memcached"/"memjs" → memcached, "@elastic/elasticsearch" → elasticsearch,
+            "minio" → minio, "cassandra-driver" → cassandra
+- Python:   "psycopg2"/"asyncpg"/"sqlalchemy" → postgres, "redis"/"aioredis" → redis,
+            "pymysql"/"mysqlclient" → mysql, "pymongo"/"motor" → mongodb,
+            "pika"/"aio-pika" → rabbitmq, "kafka-python"/"confluent-kafka" → kafka,
+            "nats-py" → nats, "pymemcache" → memcached, "elasticsearch" → elasticsearch,
+            "boto3"/"minio" → minio, "cassandra-driver" → cassandra, "hvac" → vault
+- Java/Kotlin: "org.postgresql" → postgres, "jedis"/"lettuce" → redis, "mysql-connector" → mysql,
+            "mongo-java-driver" → mongodb, "spring-boot-starter-amqp" → rabbitmq,
+            "spring-kafka" → kafka, "spring-data-elasticsearch" → elasticsearch,
+            "spring-cloud-vault" → vault, "spring-cloud-consul" → consul
+- Rust:     "tokio-postgres"/"diesel" → postgres, "redis" → redis, "sqlx" + mysql feature → mysql,
+            "mongodb" → mongodb, "lapin" → rabbitmq, "rdkafka" → kafka
+- Ruby:     "pg" gem → postgres, "redis" gem → redis, "mysql2" gem → mysql,
+            "mongo"/"mongoid" → mongodb, "bunny" → rabbitmq, "sidekiq" → redis
+- PHP:      "predis"/"phpredis" → redis, "doctrine/dbal" → postgres or mysql,
+            "php-amqplib" → rabbitmq, "mongodb/mongodb" → mongodb
+- C#/.NET:  "Npgsql" → postgres, "StackExchange.Redis" → redis,
+            "MySqlConnector" → mysql, "MongoDB.Driver" → mongodb,
+            "RabbitMQ.Client" → rabbitmq, "Confluent.Kafka" → kafka,
+            "NATS.Client" → nats, "Elasticsearch.Net" → elasticsearch
+- Elixir:   "postgrex"/"ecto" → postgres, "redix" → redis, "amqp" → rabbitmq,
+            "kafka_ex" → kafka, "mongodb_driver" → mongodb
+- docker-compose.yml service names (postgres, redis, mysql, mongo, rabbitmq, etc.)
+- Environment variable references in code (DATABASE_URL, REDIS_URL, MONGO_URL, etc.)
+
+CRITICAL — Dependency connection URLs are auto-injected:
+When you declare a dependency in the "dependencies" input, the kindling operator
+AUTOMATICALLY injects the corresponding connection URL environment variable into the
+application container. You MUST NOT include these env vars in the "env" input — they
+will be duplicated, and any secretKeyRef will fail because no such secret exists.
+
+Auto-injected env vars by dependency type:
+  postgres       → DATABASE_URL  (e.g. postgres://devuser:devpass@<name>-postgres:5432/devdb?sslmode=disable)
+  redis          → REDIS_URL     (e.g. redis://<name>-redis:6379/0)
+  mysql          → DATABASE_URL  (e.g. mysql://devuser:devpass@<name>-mysql:3306/devdb)
+  mongodb        → MONGO_URL     (e.g. mongodb://devuser:devpass@<name>-mongodb:27017)
+  rabbitmq       → AMQP_URL      (e.g. amqp://devuser:devpass@<name>-rabbitmq:5672)
+  minio          → S3_ENDPOINT   (e.g. http://<name>-minio:9000)
+  elasticsearch  → ELASTICSEARCH_URL (e.g. http://<name>-elasticsearch:9200)
+  kafka          → KAFKA_BROKER_URL  (e.g. <name>-kafka:9092)
+  nats           → NATS_URL      (e.g. nats://<name>-nats:4222)
+  memcached      → MEMCACHED_URL (e.g. <name>-memcached:11211)
+  cassandra      → CASSANDRA_URL (e.g. <name>-cassandra:9042)
+  consul         → CONSUL_HTTP_ADDR (e.g. http://<name>-consul:8500)
+  vault          → VAULT_ADDR    (e.g. http://<name>-vault:8200)
+  influxdb       → INFLUXDB_URL  (e.g. http://<name>-influxdb:8086)
+  jaeger         → JAEGER_ENDPOINT (e.g. http://<name>-jaeger:16686)
+
+So if you write "dependencies: postgres, redis", do NOT also write:
+  env: |
+    - name: DATABASE_URL
+      valueFrom:
+        secretKeyRef: ...     ← WRONG, will fail
+    - name: REDIS_URL
+      value: "redis://..."    ← WRONG, duplicates auto-injected value
+
+The ONLY env vars that belong in the "env" input are:
+  1. Truly external credentials (API keys, tokens, third-party DSNs) as secretKeyRef
+  2. App configuration that is NOT a dependency connection URL (e.g. NODE_ENV, LOG_LEVEL)
+  3. Env vars that reference an auto-injected URL via variable expansion, e.g.:
+       - name: ADDITIONAL_DB
+         value: "$(DATABASE_URL)&options=extra"
+  4. When the app uses a DIFFERENT env var name than the auto-injected one, map it
+     using variable expansion. For example, if the app expects CELERY_BROKER_URL but
+     the dependency is rabbitmq (which auto-injects AMQP_URL):
+       - name: CELERY_BROKER_URL
+         value: "$(AMQP_URL)"
+     Similarly for CELERY_RESULT_BACKEND when redis auto-injects REDIS_URL:
+       - name: CELERY_RESULT_BACKEND
+         value: "$(REDIS_URL)"
+     Check the app's source code, docker-compose environment, and .env files to
+     discover what env var names the app actually uses for each dependency connection.
+     If the app's name differs from the auto-injected name, add the mapping.
+
+Build timeout guidance:
+The default kindling-build timeout is 300 seconds (5 minutes). This is sufficient for
+interpreted languages and lightweight compiled languages (Go, TypeScript, Python, Ruby,
+PHP). However, languages with heavy compilation toolchains need a longer timeout.
+Set timeout: "900" (15 minutes) on the kindling-build step for services written in:
+  - Rust (cargo builds from scratch without a cache layer)
+  - Java/Kotlin (Maven/Gradle dependency download + compilation)
+  - C#/.NET (dotnet restore + publish)
+  - Elixir (Mix deps.get + compilation of dependencies)
+Only add the timeout input when it differs from the default 300.
+
+CRITICAL — Kaniko vs Docker BuildKit compatibility:
+kindling-build uses Kaniko, NOT Docker BuildKit. Kaniko does NOT support:
+  - Automatic BuildKit platform ARGs: BUILDPLATFORM, TARGETPLATFORM, TARGETARCH, TARGETOS, TARGETVARIANT
+  - FROM --platform=${BUILDPLATFORM} syntax
+If a Dockerfile uses any of these (common in .NET, cross-compiled Go, and multi-arch images),
+the build WILL fail because the ARG values will be empty.
+
+IMPORTANT: Only patch what is specifically broken. Do NOT touch ARG lines that are NOT
+BuildKit platform ARGs. Application-level ARGs like ARG APP_PATH, ARG BASE_IMAGE,
+ARG ENVIRONMENT, ARG CDN_URL, etc. are perfectly valid in Kaniko and MUST be left alone.
+Kaniko fully supports ARG, ENV, FROM ${VAR}, and multi-stage builds.
+If a Dockerfile has NO known Kaniko issues (no BuildKit platform ARGs, no poetry,
+no npm, no go build without -buildvcs=false), do NOT generate a patch step at all.
+
+When you detect a Dockerfile that uses BuildKit platform ARGs, you MUST generate a
+"Patch Dockerfile for Kaniko" step BEFORE the kindling-build step for that service.
+The patch step should:
+  1. Remove "--platform=${BUILDPLATFORM}" from any FROM line
+  2. Remove the ARG TARGETPLATFORM, ARG TARGETARCH, ARG BUILDPLATFORM, ARG TARGETOS declarations
+  3. Replace any usage of $TARGETARCH or ${TARGETARCH} with the concrete architecture (amd64)
+  4. Replace any usage of $TARGETPLATFORM or ${TARGETPLATFORM} with linux/amd64
+  5. Replace any usage of $BUILDPLATFORM or ${BUILDPLATFORM} with linux/amd64
+
+Example patch step for a .NET worker with BuildKit ARGs:
+  - name: Patch worker Dockerfile for Kaniko
+    shell: bash
+    run: |
+      cd ${{ github.workspace }}/worker
+      # Remove --platform=${BUILDPLATFORM} from FROM lines
+      sed -i 's/FROM --platform=\${BUILDPLATFORM} /FROM /g' Dockerfile
+      # Remove BuildKit ARG declarations
+      sed -i '/^ARG TARGETPLATFORM$/d' Dockerfile
+      sed -i '/^ARG TARGETARCH$/d' Dockerfile
+      sed -i '/^ARG BUILDPLATFORM$/d' Dockerfile
+      sed -i '/^ARG TARGETOS$/d' Dockerfile
+      sed -i '/^ARG TARGETVARIANT$/d' Dockerfile
+      # Replace architecture variables with concrete amd64 values
+      sed -i 's/\$TARGETARCH/amd64/g; s/\${TARGETARCH}/amd64/g' Dockerfile
+      sed -i 's/\$TARGETPLATFORM/linux\/amd64/g; s/\${TARGETPLATFORM}/linux\/amd64/g' Dockerfile
+      sed -i 's/\$BUILDPLATFORM/linux\/amd64/g; s/\${BUILDPLATFORM}/linux\/amd64/g' Dockerfile
+      sed -i 's/\$TARGETOS/linux/g; s/\${TARGETOS}/linux/g' Dockerfile
+
+Additional Kaniko compatibility issues that require Dockerfile patching:
+
+Go VCS stamping:
+Kaniko does NOT have a .git directory. Go 1.18+ embeds VCS info by default, which
+causes "error obtaining VCS status: exit status 128" and fails the build.
+When a Go Dockerfile contains "go build" WITHOUT "-buildvcs=false", you MUST add a
+patch step to inject it. Use sed to replace "go build" with "go build -buildvcs=false"
+in the Dockerfile:
+  sed -i 's/go build /go build -buildvcs=false /g' Dockerfile
+
+Poetry install without --no-root:
+When ANY Dockerfile in the repo uses "poetry install" WITHOUT "--no-root", Poetry tries to
+install the current project as a package — this fails if README.md or other metadata
+files are missing from the build context. You MUST ALWAYS patch "poetry install" to
+"poetry install --no-root" in EVERY Dockerfile that uses poetry. This is NOT optional.
+ALWAYS add a patch step for this before the build step:
+  sed -i 's/poetry install/poetry install --no-root/g' Dockerfile
+If the Dockerfile is specified via the "dockerfile" input (not at context root), adjust the path:
+  sed -i 's/poetry install/poetry install --no-root/g' path/to/Dockerfile
+
+RUN --mount=type=cache:
+Kaniko ignores --mount=type=cache flags (they're BuildKit-only cache mounts).
+The build will still work but without caching. No patching is needed for this —
+it's safe to leave as-is.
+
+npm cache permissions:
+Kaniko's filesystem snapshotting changes ownership of /root/.npm between layers,
+causing "EACCES: permission denied" errors on npm install, npm run build, etc.
+When ANY Dockerfile uses npm (npm install, npm run build, npm ci, etc.), you MUST
+patch it to redirect the npm cache to /tmp/.npm by inserting an ENV line after the
+FROM line. Use sed to insert it:
+  sed -i '/^FROM /a ENV npm_config_cache=/tmp/.npm' Dockerfile
+This MUST be included in the patch step for every Node.js/npm-based service.
+
+ONLY generate a Kaniko patch step when the Dockerfile has one or more of these specific issues:
+  1. BuildKit platform ARGs (TARGETARCH, BUILDPLATFORM, etc.)
+  2. "poetry install" without "--no-root"
+  3. npm usage (needs npm_config_cache redirect)
+  4. "go build" without "-buildvcs=false"
+If NONE of these apply, do NOT generate a patch step — the Dockerfile works as-is.
+
+Combine all Kaniko patches for a service into a SINGLE "Patch <service> Dockerfile for Kaniko"
+step BEFORE the corresponding build step. Examples:
+
+Python service with poetry:
+  - name: Patch backend Dockerfile for Kaniko
+    shell: bash
+    run: |
+      cd ${{ github.workspace }}/backend
+      sed -i 's/poetry install/poetry install --no-root/g' Dockerfile
+
+Python service where Dockerfile is NOT at context root (uses "dockerfile" input):
+  - name: Patch daily-job Dockerfile for Kaniko
+    shell: bash
+    run: |
+      cd ${{ github.workspace }}/backend
+      sed -i 's/poetry install/poetry install --no-root/g' jobs/daily/Dockerfile
+
+Go service with VCS issues:
+  - name: Patch api Dockerfile for Kaniko
+    shell: bash
+    run: |
+      cd ${{ github.workspace }}/api
+      sed -i 's/go build /go build -buildvcs=false /g' Dockerfile
+
+Node.js/npm service:
+  - name: Patch frontend Dockerfile for Kaniko
+    shell: bash
+    run: |
+      cd ${{ github.workspace }}/frontend
+      sed -i '/^FROM /a ENV npm_config_cache=/tmp/.npm' Dockerfile
+
+Common Dockerfile pitfalls to be aware of when reviewing repo structure:
+  - Go: if go.sum is missing, the Dockerfile must run "go mod tidy" before "go build"
+  - Node/TS: if package-lock.json is missing, "npm ci" will fail — use "npm install" instead
+  - Rust: use "rust:1-alpine" (latest stable) to avoid MSRV (minimum supported Rust version) breakage
+  - PHP: composer.json "name" field must use vendor/package format (e.g. "myapp/service") or composer install fails
+  - Elixir: use "elixir:1.16-alpine" or newer; runtime image needs libstdc++, openssl, ncurses-libs
+These are Dockerfile concerns, not workflow concerns — but if you detect these languages,
+be aware that build failures are often caused by these issues.
+
+For multi-service repos (multiple Dockerfiles in subdirectories), generate one
+build step per service and one deploy step per service, with inter-service env
+vars wired up (e.g. API_URL pointing to the other service's cluster-internal DNS name).
+
+Docker build context vs Dockerfile path:
+When a docker-compose.yml exists, ALWAYS check it to determine the correct build
+"context" and "dockerfile" for each service. Many repos use a DIFFERENT context
+directory than where the Dockerfile lives. For example:
+  docker-compose.yml:
+    my_job:
+      build:
+        context: ./backend/           # <-- build context is the parent
+        dockerfile: jobs/daily/Dockerfile  # <-- Dockerfile path relative to context
+In this case the kindling-build step MUST use:
+  context: ${{ github.workspace }}/backend
+  dockerfile: jobs/daily/Dockerfile
+NOT context: ${{ github.workspace }}/backend/jobs/daily (which would be wrong
+because the Dockerfile expects files from the parent backend/ directory like
+pyproject.toml, poetry.lock, etc.).
+The kindling-build action supports a "dockerfile" input for this — use it whenever
+the Dockerfile is not at the root of the build context:
+  - name: Build daily job image
+    uses: kindling-sh/kindling/.github/actions/kindling-build@main
+    with:
+      name: daily-job
+      context: ${{ github.workspace }}/backend
+      dockerfile: jobs/daily/Dockerfile
+      image: "${{ env.REGISTRY }}/daily-job:${{ env.TAG }}"
+
+CRITICAL — Dev staging environment philosophy:
+This generates a LOCAL DEV environment, NOT production. The goal is for the app
+to start and be usable with ZERO manual secret setup. Follow these rules:
+
+1. Dependency connection URLs and passwords are ALREADY handled by the operator.
+   When you declare a dependency (postgres, redis, etc.), the operator auto-injects
+   the connection URL (DATABASE_URL, REDIS_URL, etc.) AND manages the dependency
+   container's credentials internally. Do NOT add any of these to the env block:
+   - DATABASE_URL, REDIS_URL, MONGO_URL, AMQP_URL, S3_ENDPOINT, etc.
+   - POSTGRES_PASSWORD, DATABASE_PASSWORD, REDIS_PASSWORD, MYSQL_PASSWORD, etc.
+   - POSTGRES_USER, POSTGRES_DB, MYSQL_USER, MYSQL_DATABASE, etc.
+
+2. App-level secrets (SECRET_KEY, SESSION_SECRET, JWT_SECRET, UTILS_SECRET,
+   ENCRYPTION_KEY, etc.) should be set as plain env vars with a generated
+   dev-safe random hex value. Example:
+     - name: SECRET_KEY
+       value: "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2"
+     - name: UTILS_SECRET
+       value: "f6e5d4c3b2a1f6e5d4c3b2a1f6e5d4c3b2a1f6e5d4c3b2a1f6e5d4c3b2a1f6e5"
+   Generate a DIFFERENT 64-char hex string for each secret.
+
+3. Optional external integrations should be OMITTED entirely from the env block.
+   These are not needed for local dev and including them as secretKeyRef will
+   cause the pod to fail with CreateContainerConfigError. Skip:
+   - Cloud storage (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, GCS_*, AZURE_STORAGE_*)
+   - Monitoring/APM (DD_API_KEY, SENTRY_DSN, NEW_RELIC_*, DATADOG_*)
+   - Email/SMS (SMTP_*, SENDGRID_*, TWILIO_*, MAILGUN_*)
+   - OAuth providers (SLACK_CLIENT_*, GOOGLE_CLIENT_*, GITHUB_CLIENT_*, AUTH0_*)
+   - Analytics (SEGMENT_*, MIXPANEL_*, AMPLITUDE_*)
+   Instead, if the app has a config option to disable these features, set it.
+   For example: FILE_STORAGE=local instead of FILE_STORAGE=s3.
+
+4. Only use valueFrom.secretKeyRef for credentials that are BOTH:
+   (a) absolutely required for the app to start (it will crash without them), AND
+   (b) truly external (not provided by an in-cluster dependency)
+   This should be RARE in a dev environment.
+
+5. ALWAYS check .env.sample, .env.example, .env.development, and similar files
+   for REQUIRED configuration. These files list every env var the app expects.
+   For each variable found:
+   - Skip it if it's an auto-injected dependency URL (DATABASE_URL, REDIS_URL, etc.)
+   - Skip it if it's a dependency credential (POSTGRES_PASSWORD, etc.)
+   - Skip it if it's an optional external integration (AWS_*, DD_*, SENTRY_*, etc.)
+   - For app secrets (SECRET_KEY, SESSION_SECRET, etc.) → set a random 64-char hex value
+   - For URL vars that reference the app itself (URL, BASE_URL, APP_URL, COLLABORATION_URL,
+     etc.) → set to "http://${{ github.actor }}-<name>.localhost"
+   - For feature flags / storage config → set the local/dev option (e.g. FILE_STORAGE=local)
+   - For remaining config → set a sensible dev default
+   Missing a required env var is the #1 cause of pods crashing on startup. When in doubt,
+   include it with a dev-safe default rather than omitting it.
+
+The "env" input is a YAML block that maps directly to a Kubernetes []EnvVar list.
+You MUST use standard Kubernetes EnvVar list format (NOT a map/dict).
+
+Correct format for env vars:
+  env: |
+    - name: SECRET_KEY
+      value: "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2"
+    - name: NODE_ENV
+      value: "production"
+    - name: FILE_STORAGE
+      value: "local"
+
+WRONG format (this will cause a CRD validation error):
+  env: |
+    SECRET_KEY:
+      value: "some-value"
+
+If any secretKeyRef IS used, those secrets are managed by
+"kindling secrets set <NAME> <VALUE>" and stored as Kubernetes Secrets.
+Include a YAML comment noting which secrets need to be set.
+
+OAuth / public exposure:
+If the repository uses OAuth or OIDC (Auth0, Okta, Firebase Auth, NextAuth, etc.),
+services that handle OAuth callbacks need a publicly accessible URL instead of
+*.localhost. When the user indicates a public URL is available, use it for the
+ingress-host of the auth-handling service. If no public URL is specified but OAuth
+patterns are detected, add a YAML comment noting:
+  # NOTE: OAuth detected — run 'kindling expose' for a public HTTPS URL
+
+Return ONLY the raw YAML content of the workflow file. No markdown code fences,
+no explanation text, no commentary. Just the YAML.`
+
+	// ── Build user prompt ───────────────────────────────────────
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Generate a kindling dev-deploy.yml GitHub Actions workflow for this repository named %q.\n\n", ctx.Name))
+	b.WriteString(fmt.Sprintf("Default branch: %s (use this in the 'on: push: branches:' trigger)\n\n", ctx.Branch))
+
+	head := b.String()
+	b.Reset()
+
+	// Detected health endpoints
+	if len(ctx.healthChecks) > 0 {
+		b.WriteString("## Detected health endpoints\n\n")
+		dirs := make([]string, 0, len(ctx.healthChecks))
+		for d := range ctx.healthChecks {
+			dirs = append(dirs, d)
+		}
+		sort.Strings(dirs)
+		for _, d := range dirs {
+			if path := ctx.healthChecks[d]; path != "" {
+				b.WriteString(fmt.Sprintf("- %s: %s\n", d, path))
+			} else {
+				b.WriteString(fmt.Sprintf("- %s: none found (use health-check-type: \"tcp\")\n", d))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	// Detected ingress protocols
+	if len(ctx.ingressProtocols) > 0 {
+		b.WriteString("## Detected ingress protocols\n\n")
+		dirs := make([]string, 0, len(ctx.ingressProtocols))
+		for d := range ctx.ingressProtocols {
+			dirs = append(dirs, d)
+		}
+		sort.Strings(dirs)
+		for _, d := range dirs {
+			b.WriteString(fmt.Sprintf("- %s: %s\n", d, ctx.ingressProtocols[d]))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(ctx.Frameworks) > 0 {
+		writeDetectedFrameworks(&b, ctx.Frameworks)
+	}
+
+	if len(ctx.confirmedComponents) > 0 {
+		writeConfirmedComponents(&b, ctx.confirmedComponents)
+	}
+
+	writeEnvReads(&b, ctx)
+	writeComponentCalls(&b, ctx)
+
+	// Detected external credentials
+	if len(ctx.ExternalSecrets) > 0 {
+		b.WriteString("## Detected credential-like environment variables\n\n")
+		b.WriteString("The following environment variables were detected in source code.\n")
+		b.WriteString("Apply the dev staging philosophy from the system prompt to decide how to handle each:\n")
+		b.WriteString("- If it is an app-level secret (SECRET_KEY, JWT_SECRET, etc.), set a random hex dev value\n")
+		b.WriteString("- If it is an optional integration (AWS, Datadog, SMTP, OAuth), OMIT it entirely\n")
+		b.WriteString("- If it is truly required AND external, use secretKeyRef with name kindling-secret-<name>\n\n")
+		for _, name := range ctx.ExternalSecrets {
+			b.WriteString(fmt.Sprintf("- %s\n", name))
+		}
+		b.WriteString("\n")
+	}
+
+	// OAuth / OIDC indicators
+	if ctx.NeedsPublicExpose && len(ctx.OAuthHints) > 0 {
+		b.WriteString("## Detected OAuth / OIDC indicators\n\n")
+		b.WriteString("This repository appears to use external authentication. Detected:\n\n")
+		for _, hint := range ctx.OAuthHints {
+			b.WriteString(fmt.Sprintf("- %s\n", hint))
+		}
+		b.WriteString("\nThe user may not have a public URL yet. Add a YAML comment noting that\n")
+		b.WriteString("`kindling expose` should be run if OAuth callbacks need to reach the cluster.\n\n")
+	}
+
+	// Reference examples
+	b.WriteString("## Reference: single-service workflow example\n```yaml\n")
+	b.WriteString(singleServiceExample)
+	b.WriteString("\n```\n\n")
+
+	b.WriteString("## Reference: multi-service workflow example\n```yaml\n")
+	b.WriteString(multiServiceExample)
+	b.WriteString("\n```\n\n")
+
+	b.WriteString("Now generate the dev-deploy.yml workflow YAML for this repository. Return ONLY the YAML.\n")
+
+	tail := b.String()
+	room := tokens - EstimateTokens(system+head+tail)
+	if room < contextMinTokens {
+		return "", "", stats, fmt.Errorf("a context budget of %d tokens leaves no room for the repository — the instructions alone take about %d; raise --context-tokens to at least %d",
+			tokens, tokens-room, tokens-room+contextMinTokens)
+	}
+	repo, stats := buildRepoContext(ctx, room)
+	return system, head + repo + tail, stats, nil
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// Reference examples embedded in the prompt
+// ────────────────────────────────────────────────────────────────────────────
+
+const singleServiceExample = `name: Dev Deploy
+
+on:
+  push:
+    branches: [main]
+  workflow_dispatch:
+
+env:
+  REGISTRY: "registry:5000"
+  TAG: "${{ github.actor }}-${{ github.sha }}"
+
+jobs:
+  build-and-deploy:
+    runs-on: [self-hosted, "${{ github.actor }}"]
+
+    steps:
+      - name: Checkout code
+        uses: actions/checkout@v4
+
+      - name: Clean builds directory
+        shell: bash
+        run: |
+          rm -f /builds/*.done /builds/*.request /builds/*.processing \
+                /builds/*.apply /builds/*.apply-done /builds/*.apply-log \
+                /builds/*.apply-exitcode /builds/*.exitcode \
+                /builds/*.log /builds/*.dest /builds/*.tar.gz \
+                /builds/*.yaml /builds/*.sh
+
+      - name: Build image
+        uses: kindling-sh/kindling/.github/actions/kindling-build@main
+        with:
+          name: sample-app
+          context: ${{ github.workspace }}
+          image: "${{ env.REGISTRY }}/sample-app:${{ env.TAG }}"
+
+      - name: Deploy
+        uses: kindling-sh/kindling/.github/actions/kindling-deploy@main
+        with:
+          name: "${{ github.actor }}-sample-app"
+          image: "${{ env.REGISTRY }}/sample-app:${{ env.TAG }}"
+          port: "8080"
+          labels: |
+            app.kubernetes.io/part-of: sample-app
+            apps.example.com/github-username: ${{ github.actor }}
+          ingress-host: "${{ github.actor }}-sample-app.localhost"
+          dependencies: |
+            - type: postgres
+              version: "16"
+            - type: redis
+
+      - name: Summary
+        run: |
+          echo "🎉 Deploy complete!"
+          echo "🌐 http://${{ github.actor }}-sample-app.localhost"`
+
+const multiServiceExample = `name: Dev Deploy
+
+on:
+  push:
+    branches: [main]
+  workflow_dispatch:
+
+env:
+  REGISTRY: "registry:5000"
+  TAG: "${{ github.actor }}-${{ github.sha }}"
+
+jobs:
+  build-and-deploy:
+    runs-on: [self-hosted, "${{ github.actor }}"]
+
+    steps:
+      - name: Checkout code
+        uses: actions/checkout@v4
+
+      - name: Clean builds directory
+        shell: bash
+        run: |
+          rm -f /builds/*.done /builds/*.request /builds/*.processing \
+                /builds/*.apply /builds/*.apply-done /builds/*.apply-log \
+                /builds/*.apply-exitcode /builds/*.exitcode \
+                /builds/*.log /builds/*.dest /builds/*.tar.gz \
+                /builds/*.yaml /builds/*.sh
+
+      - name: Build API image
+        uses: kindling-sh/kindling/.github/actions/kindling-build@main
+        with:
+          name: api
+          context: ${{ github.workspace }}
+          image: "${{ env.REGISTRY }}/api:${{ env.TAG }}"
+          exclude: "./ui"
+
+      - name: Build UI image
+        uses: kindling-sh/kindling/.github/actions/kindling-build@main
+        with:
+          name: ui
+          context: "${{ github.workspace }}/ui"
+          image: "${{ env.REGISTRY }}/ui:${{ env.TAG }}"
+
+      - name: Deploy API
+        uses: kindling-sh/kindling/.github/actions/kindling-deploy@main
+        with:
+          name: "${{ github.actor }}-api"
+          image: "${{ env.REGISTRY }}/api:${{ env.TAG }}"
+          port: "8080"
+          labels: |
+            app.kubernetes.io/part-of: my-app
+            app.kubernetes.io/component: api
+            apps.example.com/github-username: ${{ github.actor }}
+          ingress-host: "${{ github.actor }}-api.localhost"
+          dependencies: |
+            - type: postgres
+              version: "16"
+            - type: redis
+
+      - name: Deploy UI
+        uses: kindling-sh/kindling/.github/actions/kindling-deploy@main
+        with:
+          name: "${{ github.actor }}-ui"
+          image: "${{ env.REGISTRY }}/ui:${{ env.TAG }}"
+          port: "80"
+          health-check-path: "/"
+          labels: |
+            app.kubernetes.io/part-of: my-app
+            app.kubernetes.io/component: ui
+            apps.example.com/github-username: ${{ github.actor }}
+          env: |
+            - name: API_URL
+              value: "http://${{ github.actor }}-api:8080"
+          ingress-host: "${{ github.actor }}-ui.localhost"
+
+      - name: Summary
+        run: |
+          echo "🎉 Deploy complete!"
+          echo "🌐 UI:  http://${{ github.actor }}-ui.localhost"
+          echo "🌐 API: http://${{ github.actor }}-api.localhost"`
+
+// ────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ────────────────────────────────────────────────────────────────────────────
+
+// CleanYAML strips markdown code fences from the AI response in case
+// the model wraps the output despite instructions.
+func CleanYAML(s string) string {
+	s = strings.TrimSpace(s)
+
+	// Remove ```yaml ... ``` or ``` ... ``` wrapping
+	if strings.HasPrefix(s, "```") {
+		lines := strings.Split(s, "\n")
+		// Remove first line (```yaml or ```)
+		if len(lines) > 1 {
+			lines = lines[1:]
+		}
+		// Remove last line if it's ```
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "```" {
+			lines = lines[:len(lines)-1]
+		}
+		s = strings.Join(lines, "\n")
+	}
+
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,77 @@
+package generate
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Ingress protocol inference
+// ────────────────────────────────────────────────────────────────────────────
+//
+// A plain HTTP ingress rule breaks gRPC (the upstream must be HTTP/2) and
+// drops idle WebSockets after the controller's default 60s timeout.
+// generate reads each component's dependency manifests for a gRPC or
+// WebSocket server library and sets ingress.protocol, from which the
+// operator derives the controller-specific annotations.
+
+// ingressProtocolHints maps a protocol to substrings that reveal a server
+// library for it in a dependency manifest, most specific protocol first:
+// gRPC services often pull in a WebSocket library for tooling, not the
+// other way round.
+var ingressProtocolHints = []struct {
+	protocol string
+	hints    []string
+}{
+	{"grpc", []string{"google.golang.org/grpc", "grpcio", "@grpc/grpc-js", "grpc-spring", "io.grpc", "tonic"}},
+	{"websocket", []string{"websocket", "socket.io", "socketio", `"ws":`, "tungstenite"}},
+}
+
+// inferIngressProtocols returns the protocol of each build directory whose
+// manifests name a gRPC or WebSocket library. Plain HTTP directories are
+// left out.
+func inferIngressProtocols(dirs []string, depFiles map[string]string) map[string]string {
+	result := map[string]string{}
+	for _, dir := range dirs {
+		var manifests []string
+		for rel, content := range depFiles {
+			if filepath.Dir(rel) == dir {
+				manifests = append(manifests, directDependencies(rel, content))
+			}
+		}
+		if p := detectIngressProtocol(strings.Join(manifests, "\n")); p != "" {
+			result[dir] = p
+		}
+	}
+	return result
+}
+
+// detectIngressProtocol returns the protocol whose library appears in a
+// dependency manifest, or "" for plain HTTP.
+func detectIngressProtocol(content string) string {
+	lower := strings.ToLower(content)
+	for _, h := range ingressProtocolHints {
+		for _, hint := range h.hints {
+			if strings.Contains(lower, hint) {
+				return h.protocol
+			}
+		}
+	}
+	return ""
+}
+
+// directDependencies drops the indirect requirements of a go.mod, where
+// gRPC routinely appears as a transitive dependency of cloud SDKs in
+// services that never serve it.
+func directDependencies(rel, content string) string {
+	if filepath.Base(rel) != "go.mod" {
+		return content
+	}
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.Contains(line, "// indirect") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
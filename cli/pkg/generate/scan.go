@@ -0,0 +1,594 @@
+package generate
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jeffvincent/kindling/cli/pkg/devstaging"
+	"github.com/jeffvincent/kindling/cli/pkg/source"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Repo Scanner
+// ────────────────────────────────────────────────────────────────────────────
+
+// Repo holds all the information gathered from scanning a repository
+// that will be sent to the AI as context.
+type Repo struct {
+	Name                string
+	Root                string // absolute path of the repository
+	Branch              string // the workflow deploys on pushes to it; Scan leaves it empty
+	tree                string
+	dockerfiles         map[string]string           // relative path → content
+	overlayDockerfiles  map[string]string           // build context → .kindling/dockerfiles path
+	healthChecks        map[string]string           // Dockerfile dir → health route ("" if none)
+	Frameworks          map[string]*Framework       // directory → framework, see detect.go
+	confirmedComponents []*Component                // from Confirm; nil otherwise
+	ingressProtocols    map[string]string           // Dockerfile dir → grpc or websocket (HTTP dirs omitted)
+	envReads            map[string][]source.EnvRead // Dockerfile dir → env vars its code reads
+	componentCalls      map[string][]componentCall  // Dockerfile dir → components it calls
+	depFiles            map[string]string           // relative path → content
+	composeFile         string                      // docker-compose.yml content (if found)
+	SourceSnippets      map[string]string           // relative path → truncated content
+	contextFiles        []contextFile               // candidates for the prompt, see context.go
+	DockerfileCount     int
+	DepFileCount        int
+	ExternalSecrets     []string // detected external credential env var names
+	NeedsPublicExpose   bool     // true if OAuth/OIDC patterns detected
+	OAuthHints          []string // descriptions of detected OAuth indicators
+}
+
+// Dependency manifests worth reading.
+var scanDepFiles = map[string]bool{
+	// Environment configuration templates (required env vars)
+	".env.sample":      true,
+	".env.example":     true,
+	".env.development": true,
+	".env.template":    true,
+	// Go
+	"go.mod": true,
+	// JavaScript / TypeScript
+	"package.json": true,
+	// Python
+	"requirements.txt": true,
+	"Pipfile":          true,
+	"pyproject.toml":   true,
+	"setup.py":         true,
+	"setup.cfg":        true,
+	// Rust
+	"Cargo.toml": true,
+	// Java / Kotlin
+	"pom.xml":          true,
+	"build.gradle":     true,
+	"build.gradle.kts": true,
+	// Ruby
+	"Gemfile": true,
+	// PHP
+	"composer.json": true,
+	// Elixir
+	"mix.exs": true,
+	// .NET / C# / F#
+	"Directory.Build.props": true,
+	"global.json":           true,
+
+	// Zig
+	"build.zig.zon": true,
+	// C / C++
+	"CMakeLists.txt": true,
+	"conanfile.txt":  true,
+	"conanfile.py":   true,
+	"vcpkg.json":     true,
+	"meson.build":    true,
+	// Perl
+	"cpanfile":    true,
+	"Makefile.PL": true,
+	// R
+	"DESCRIPTION": true,
+	"renv.lock":   true,
+	// Julia
+	"Project.toml": true,
+	// Lua
+	"rockspec": true,
+	// Deno
+	"deno.json":  true,
+	"deno.jsonc": true,
+	// Bun
+	"bun.lockb": true,
+}
+
+// scanDepExts matches dependency manifests by extension (e.g. .csproj, .fsproj).
+var scanDepExts = map[string]bool{
+	".csproj": true, // C#
+	".fsproj": true, // F#
+	".vbproj": true, // VB.NET
+	".sln":    true, // .NET solution
+}
+
+// envVarAccessPatterns are code-level patterns that indicate a file reads
+// configuration from environment variables. Files containing these patterns
+// are boosted in source-file prioritization so the LLM sees how the app is
+// configured (ports, env var names, health paths, etc.).
+var envVarAccessPatterns = []string{
+	// Go
+	"os.Getenv", "os.LookupEnv",
+	// Node.js / Deno / Bun
+	"process.env.", "process.env[", "Deno.env.get", "Bun.env.",
+	// Python
+	"os.environ", "os.getenv",
+	// Java / Kotlin
+	"System.getenv",
+	// C# / .NET
+	"Environment.GetEnvironmentVariable",
+	// Rust
+	"env::var", "std::env",
+	// Ruby
+	"ENV[", "ENV.fetch",
+	// PHP
+	"getenv(", "$_ENV[", "$_SERVER[",
+	// Elixir
+	"System.get_env", "Application.get_env",
+	// C / C++
+	"std::getenv",
+}
+
+// hasEnvVarPatterns does a quick scan of a source file and returns true if it
+// contains any env-var-access pattern. This is used to boost config-bearing
+// files into the sample window sent to the LLM.
+func hasEnvVarPatterns(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	content := string(data)
+	for _, p := range envVarAccessPatterns {
+		if strings.Contains(content, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan reads the repository at repoPath, an absolute path: its tree,
+// Dockerfiles, dependency manifests, and the source files most likely to
+// show how it runs, leaving out what filter excludes. It also notes the
+// external credentials and OAuth callbacks the code refers to.
+func Scan(repoPath string, filter PathFilter) (*Repo, error) {
+	ctx := &Repo{
+		Name:           filepath.Base(repoPath),
+		Root:           repoPath,
+		dockerfiles:    make(map[string]string),
+		depFiles:       make(map[string]string),
+		SourceSnippets: make(map[string]string),
+	}
+
+	var treeLines []string
+	var sourceFiles []string
+
+	err := filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // skip inaccessible entries
+		}
+
+		rel, _ := filepath.Rel(repoPath, path)
+		if rel == "." {
+			return nil
+		}
+
+		if filter.excluded(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Skip ignored directories, unless --include reaches into them
+		depth := strings.Count(rel, string(filepath.Separator))
+		if d.IsDir() {
+			if (source.SkipDir(d.Name()) || depth >= 4) && !filter.reaches(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Files past the usual scan are only taken when --include names them
+		pinned := filter.included(rel)
+		outside := depth > 3 || inSkippedDir(rel)
+		if outside && !pinned {
+			return nil
+		}
+		if !outside {
+			treeLines = append(treeLines, rel)
+		}
+
+		name := d.Name()
+		kind := ""
+		if pinned {
+			kind = contextIncluded
+		}
+		nameLower := strings.ToLower(name)
+
+		// Collect Dockerfiles
+		if nameLower == "dockerfile" || strings.HasPrefix(nameLower, "dockerfile.") {
+			content, err := readFileCapped(path, 80)
+			if err == nil {
+				ctx.dockerfiles[rel] = content
+				ctx.DockerfileCount++
+			}
+			kind = contextDockerfile
+		}
+
+		// Collect dependency manifests (by name or by extension)
+		ext := strings.ToLower(filepath.Ext(name))
+		if scanDepFiles[name] || scanDepExts[ext] {
+			content, err := readFileCapped(path, 120)
+			if err == nil {
+				ctx.depFiles[rel] = content
+				ctx.DepFileCount++
+			}
+			kind = contextManifest
+		}
+
+		// Collect docker-compose
+		if nameLower == "docker-compose.yml" || nameLower == "docker-compose.yaml" ||
+			nameLower == "compose.yml" || nameLower == "compose.yaml" {
+			content, err := readFileCapped(path, 150)
+			if err == nil {
+				ctx.composeFile = content
+			}
+			kind = contextCompose
+		}
+
+		// Collect source files for analysis (top 2 levels only); the
+		// prompt may take them one level deeper
+		if source.IsCode(ext) {
+			if depth <= 2 {
+				sourceFiles = append(sourceFiles, path)
+			}
+			if kind == "" {
+				kind = contextSource
+			}
+		}
+
+		if kind != "" {
+			ctx.contextFiles = append(ctx.contextFiles, contextFile{path: filepath.ToSlash(rel), kind: kind, pinned: pinned})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Build tree string
+	sort.Strings(treeLines)
+	var sb strings.Builder
+	for _, line := range treeLines {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	ctx.tree = sb.String()
+
+	// Pre-scan source files for env var access patterns so config-bearing
+	// files get boosted into the sample window.
+	envVarFiles := make(map[string]bool)
+	for _, path := range sourceFiles {
+		if hasEnvVarPatterns(path) {
+			envVarFiles[path] = true
+		}
+	}
+
+	// Sample entry-point source files (up to 6)
+	mainFiles := prioritizeSourceFiles(sourceFiles, envVarFiles)
+	for i, path := range mainFiles {
+		if i >= 6 {
+			break
+		}
+		content, err := readFileCapped(path, 80)
+		if err == nil {
+			rel, _ := filepath.Rel(repoPath, path)
+			ctx.SourceSnippets[rel] = content
+		}
+	}
+
+	// Detect external credential references
+	ctx.ExternalSecrets = detectExternalSecrets(repoPath, ctx)
+
+	// Detect OAuth/OIDC patterns that need public exposure
+	ctx.OAuthHints, ctx.NeedsPublicExpose = detectOAuthRequirements(ctx)
+
+	return ctx, nil
+}
+
+// InferFacts works out what the AI prompt states as facts rather than
+// leaving the model to guess: each Dockerfile directory's health route
+// and ingress protocol, the env vars its code reads, and the components
+// it calls. It uses the health routes of r.Frameworks, so it runs after
+// DetectFrameworks. Offline generation works these out on its own.
+func (r *Repo) InferFacts() {
+	dirs := make([]string, 0, len(r.dockerfiles))
+	for rel := range r.dockerfiles {
+		dirs = append(dirs, filepath.Dir(rel))
+	}
+	r.healthChecks = inferHealthChecks(r.Root, dirs, r.depFiles)
+	applyFrameworkHealth(r.healthChecks, r.Frameworks)
+	r.ingressProtocols = inferIngressProtocols(dirs, r.depFiles)
+	r.envReads = source.EnvVars(r.Root, dirs)
+	r.componentCalls = inferComponentCalls(r.Root, componentNames(detectOfflineComponents(r)),
+		readComposeServices(r.Root))
+}
+
+// readFileCapped reads up to maxLines lines from a file and truncates with a
+// note if the file is longer.
+func readFileCapped(path string, maxLines int) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(string(data), "\n")
+	if len(lines) > maxLines {
+		total := len(lines)
+		lines = lines[:maxLines]
+		lines = append(lines, fmt.Sprintf("... (%d more lines truncated)", total-maxLines))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// prioritizeSourceFiles sorts files so entry-point files (main.go, app.py, etc.)
+// appear first, since they usually reveal ports, routes, and dependencies.
+// Files in envVarFiles are boosted to tier 1 even if their filename isn't a
+// known entry point, because they contain env-var-access patterns that reveal
+// how the app is configured.
+func prioritizeSourceFiles(files []string, envVarFiles map[string]bool) []string {
+	priority := sourcePriority
+	sort.Slice(files, func(i, j int) bool {
+		pi := priority[filepath.Base(files[i])]
+		pj := priority[filepath.Base(files[j])]
+
+		// Boost files containing env var patterns to tier 1 (entry-point
+		// level) so config files make the cut even with generic names.
+		if pi == 0 && envVarFiles[files[i]] {
+			pi = 1
+		}
+		if pj == 0 && envVarFiles[files[j]] {
+			pj = 1
+		}
+
+		if pi != pj {
+			if pi == 0 {
+				return false
+			}
+			if pj == 0 {
+				return true
+			}
+			return pi < pj
+		}
+		return files[i] < files[j]
+	})
+	return files
+}
+
+// ── External credential detection ───────────────────────────────
+
+// credentialPatterns are suffixes that indicate an env var is an external credential.
+var credentialSuffixes = []string{
+	"_API_KEY", "_APIKEY", "_SECRET", "_SECRET_KEY",
+	"_TOKEN", "_ACCESS_TOKEN", "_AUTH_TOKEN", "_REFRESH_TOKEN",
+	"_DSN", "_CONNECTION_STRING", "_CONN_STR",
+	"_PASSWORD", "_PASSWD",
+	"_CLIENT_ID", "_CLIENT_SECRET",
+	"_PRIVATE_KEY", "_SIGNING_KEY",
+	"_WEBHOOK_SECRET",
+}
+
+// credentialExactNames are full env var names that indicate external credentials.
+var credentialExactNames = map[string]bool{
+	"STRIPE_KEY":            true,
+	"SENDGRID_API_KEY":      true,
+	"TWILIO_AUTH_TOKEN":     true,
+	"AWS_SECRET_ACCESS_KEY": true,
+	"AWS_ACCESS_KEY_ID":     true,
+	"GITHUB_TOKEN":          true,
+	"SENTRY_DSN":            true,
+	"AUTH0_DOMAIN":          true,
+	"AUTH0_CLIENT_ID":       true,
+	"AUTH0_CLIENT_SECRET":   true,
+	"OKTA_DOMAIN":           true,
+	"FIREBASE_API_KEY":      true,
+	"OPENAI_API_KEY":        true,
+	"ANTHROPIC_API_KEY":     true,
+}
+
+// detectExternalSecrets scans source files, Dockerfiles, compose files, and .env
+// files for references to external credentials.
+func detectExternalSecrets(repoPath string, ctx *Repo) []string {
+	seen := make(map[string]bool)
+
+	// Scan all collected content for env var patterns
+	allContent := make(map[string]string)
+	for k, v := range ctx.dockerfiles {
+		allContent[k] = v
+	}
+	for k, v := range ctx.depFiles {
+		allContent[k] = v
+	}
+	for k, v := range ctx.SourceSnippets {
+		allContent[k] = v
+	}
+	if ctx.composeFile != "" {
+		allContent["docker-compose.yml"] = ctx.composeFile
+	}
+
+	// Also scan .env files
+	envFiles := []string{".env", ".env.example", ".env.sample", ".env.development", ".env.local"}
+	for _, envFile := range envFiles {
+		path := filepath.Join(repoPath, envFile)
+		if content, err := readFileCapped(path, 100); err == nil {
+			allContent[envFile] = content
+		}
+	}
+
+	for _, content := range allContent {
+		for _, line := range strings.Split(content, "\n") {
+			// Look for ENV VAR_NAME, os.Getenv("VAR"), process.env.VAR, etc.
+			words := extractEnvVarNames(line)
+			for _, w := range words {
+				if isExternalCredential(w) && !seen[w] {
+					seen[w] = true
+				}
+			}
+		}
+	}
+
+	// Sort for deterministic output
+	var result []string
+	for name := range seen {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// extractEnvVarNames pulls env-var-like names from a line of code.
+func extractEnvVarNames(line string) []string {
+	var names []string
+
+	// Match patterns like:
+	//   os.Getenv("FOO_BAR")
+	//   process.env.FOO_BAR
+	//   ENV FOO_BAR
+	//   FOO_BAR=
+	//   ${FOO_BAR}
+	//   getenv("FOO_BAR")
+	//   env("FOO_BAR")
+	//   Environment.GetEnvironmentVariable("FOO_BAR")
+	//   System.getenv("FOO_BAR")
+	for i := 0; i < len(line); i++ {
+		// Find sequences of UPPER_CASE characters
+		if isUpperOrUnderscore(line[i]) {
+			start := i
+			for i < len(line) && (isUpperOrUnderscore(line[i]) || isDigit(line[i])) {
+				i++
+			}
+			candidate := line[start:i]
+			// Must contain at least one underscore and be 4+ chars
+			if len(candidate) >= 4 && strings.Contains(candidate, "_") {
+				names = append(names, candidate)
+			}
+		}
+	}
+	return names
+}
+
+func isUpperOrUnderscore(b byte) bool {
+	return (b >= 'A' && b <= 'Z') || b == '_'
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// isExternalCredential checks if an env var name matches credential patterns.
+func isExternalCredential(name string) bool {
+	// Never flag dependency-managed env vars
+	if devstaging.ManagedEnvVars[name] {
+		return false
+	}
+	if credentialExactNames[name] {
+		return true
+	}
+	upper := strings.ToUpper(name)
+	for _, suffix := range credentialSuffixes {
+		if strings.HasSuffix(upper, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ── OAuth / OIDC detection ──────────────────────────────────────
+
+// oauthPatterns maps search strings to human-readable descriptions.
+var oauthPatterns = []struct {
+	pattern string
+	desc    string
+}{
+	// Provider SDKs / packages
+	{"auth0", "Auth0 SDK or configuration"},
+	{"okta", "Okta SDK or configuration"},
+	{"firebase/auth", "Firebase Authentication"},
+	{"firebase-admin", "Firebase Admin SDK"},
+	{"@auth0", "Auth0 npm package"},
+	{"passport-oauth", "Passport.js OAuth strategy"},
+	{"passport-google", "Passport.js Google strategy"},
+	{"passport-github", "Passport.js GitHub strategy"},
+	{"next-auth", "NextAuth.js"},
+	{"@nextauth", "NextAuth.js"},
+	{"clerk", "Clerk authentication"},
+	{"supabase/auth", "Supabase Auth"},
+	{"keycloak", "Keycloak integration"},
+
+	// OIDC / OAuth protocols
+	{"openid-connect", "OpenID Connect"},
+	{"oidc", "OIDC discovery/configuration"},
+	{"oauth2", "OAuth 2.0 flow"},
+	{"authorization_code", "OAuth authorization code flow"},
+	{"/callback", "OAuth callback endpoint"},
+	{"/auth/callback", "Auth callback route"},
+	{"redirect_uri", "OAuth redirect URI"},
+	{"REDIRECT_URI", "OAuth redirect URI env var"},
+	{"CALLBACK_URL", "OAuth callback URL env var"},
+
+	// Environment variables
+	{"AUTH0_DOMAIN", "Auth0 domain config"},
+	{"AUTH0_CLIENT_ID", "Auth0 client ID"},
+	{"OKTA_DOMAIN", "Okta domain config"},
+	{"OKTA_CLIENT_ID", "Okta client ID"},
+	{"GOOGLE_CLIENT_ID", "Google OAuth client ID"},
+	{"GITHUB_CLIENT_ID", "GitHub OAuth client ID"},
+	{"NEXTAUTH_URL", "NextAuth.js public URL"},
+	{"NEXTAUTH_SECRET", "NextAuth.js secret"},
+
+	// Well-known endpoints
+	{".well-known/openid-configuration", "OIDC discovery endpoint"},
+	{"/authorize", "OAuth authorize endpoint"},
+	{"/oauth/token", "OAuth token endpoint"},
+}
+
+// detectOAuthRequirements scans all collected content for OAuth/OIDC patterns.
+func detectOAuthRequirements(ctx *Repo) (hints []string, needsExpose bool) {
+	// Combine all scanned content
+	allContent := make(map[string]string)
+	for k, v := range ctx.dockerfiles {
+		allContent[k] = v
+	}
+	for k, v := range ctx.depFiles {
+		allContent[k] = v
+	}
+	for k, v := range ctx.SourceSnippets {
+		allContent[k] = v
+	}
+	if ctx.composeFile != "" {
+		allContent["docker-compose.yml"] = ctx.composeFile
+	}
+
+	seen := make(map[string]bool)
+	for _, content := range allContent {
+		lower := strings.ToLower(content)
+		for _, p := range oauthPatterns {
+			if seen[p.desc] {
+				continue
+			}
+			if strings.Contains(lower, strings.ToLower(p.pattern)) {
+				seen[p.desc] = true
+				hints = append(hints, p.desc)
+			}
+		}
+	}
+
+	sort.Strings(hints)
+	needsExpose = len(hints) > 0
+	return hints, needsExpose
+}
@@ -0,0 +1,177 @@
+package generate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ── Token usage and cost ────────────────────────────────────────
+//
+// Every AI call reports the tokens it used; generate adds them up, prices
+// them with llmPrices, and kindling generate appends the run to a ledger in
+// the user config directory. --max-tokens and --max-cost bound a run: each call's reply is
+// capped to what is left of the budget, and no call is made when too
+// little is left for a useful reply.
+
+// Usage is the tokens of one or more calls. Estimated is set when a
+// provider didn't report them and they were counted from the text.
+type Usage struct {
+	Prompt     int  `json:"promptTokens"`
+	Completion int  `json:"completionTokens"`
+	Estimated  bool `json:"estimated,omitempty"`
+}
+
+// Total is the prompt and completion tokens together.
+func (u Usage) Total() int { return u.Prompt + u.Completion }
+
+func (u *Usage) add(o Usage) {
+	u.Prompt += o.Prompt
+	u.Completion += o.Completion
+	u.Estimated = u.Estimated || o.Estimated
+}
+
+// EstimateTokens approximates the tokens of text at four characters a
+// token, which is close for English and YAML with every tokenizer used.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// estimateUsage is the usage of a call whose provider didn't report it.
+func estimateUsage(prompt, reply string) Usage {
+	return Usage{Prompt: EstimateTokens(prompt), Completion: EstimateTokens(reply), Estimated: true}
+}
+
+// llmPrice is what a model costs, in USD per million tokens.
+type llmPrice struct {
+	Input, Output float64
+}
+
+func (p llmPrice) cost(u Usage) float64 {
+	return (float64(u.Prompt)*p.Input + float64(u.Completion)*p.Output) / 1e6
+}
+
+// llmPrices are the list prices of the hosted models generate is used
+// with, by model name prefix; the longest matching prefix wins, so
+// gpt-4o-mini-2024-07-18 is priced as gpt-4o-mini. They are estimates —
+// llm.providers.<provider>.inputPrice and outputPrice override them.
+var llmPrices = map[string]llmPrice{
+	"gpt-4o":            {2.50, 10.00},
+	"gpt-4o-mini":       {0.15, 0.60},
+	"gpt-4.1":           {2.00, 8.00},
+	"gpt-4.1-mini":      {0.40, 1.60},
+	"gpt-4.1-nano":      {0.10, 0.40},
+	"gpt-4-turbo":       {10.00, 30.00},
+	"o3":                {2.00, 8.00},
+	"o3-mini":           {1.10, 4.40},
+	"o4-mini":           {1.10, 4.40},
+	"claude-opus-4":     {15.00, 75.00},
+	"claude-sonnet-4":   {3.00, 15.00},
+	"claude-3-7-sonnet": {3.00, 15.00},
+	"claude-3-5-sonnet": {3.00, 15.00},
+	"claude-3-5-haiku":  {0.80, 4.00},
+	"claude-3-haiku":    {0.25, 1.25},
+}
+
+// lookupLLMPrice returns the price of the generator's model, and false
+// when it isn't known. Ollama runs locally and costs nothing.
+func lookupLLMPrice(generator Generator, cfg ProviderConfig) (llmPrice, bool) {
+	if cfg.InputPrice > 0 || cfg.OutputPrice > 0 {
+		return llmPrice{cfg.InputPrice, cfg.OutputPrice}, true
+	}
+	if generator.Name() == "ollama" {
+		return llmPrice{}, true
+	}
+	model := strings.ToLower(generator.Model())
+	best := ""
+	for prefix := range llmPrices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return llmPrice{}, false
+	}
+	return llmPrices[best], true
+}
+
+// llmMinReplyTokens is the smallest reply worth asking for: less can't
+// hold a workflow.
+const llmMinReplyTokens = 1024
+
+// Budget tracks a run's usage against --max-tokens and --max-cost.
+type Budget struct {
+	Provider  string // the generator's name and model
+	Model     string
+	price     llmPrice
+	priced    bool
+	maxTokens int     // 0 for no limit
+	maxCost   float64 // 0 for no limit
+	Used      Usage   // spent so far
+	Calls     int
+}
+
+// NewBudget sets up the budget of a run. --max-cost needs the
+// model's price.
+func NewBudget(generator Generator, cfg ProviderConfig, maxTokens int, maxCost float64) (*Budget, error) {
+	if maxTokens < 0 || maxCost < 0 {
+		return nil, fmt.Errorf("--max-tokens and --max-cost must be positive")
+	}
+	price, priced := lookupLLMPrice(generator, cfg)
+	if maxCost > 0 && !priced {
+		return nil, fmt.Errorf("no price is known for %s model %q, so --max-cost can't be enforced — set llm.providers.%s.inputPrice and outputPrice (USD per million tokens) in the config",
+			generator.Name(), generator.Model(), generator.Name())
+	}
+	return &Budget{
+		Provider: generator.Name(), Model: generator.Model(),
+		price: price, priced: priced, maxTokens: maxTokens, maxCost: maxCost,
+	}, nil
+}
+
+// ReplyLimit returns the longest reply the next call, with prompt, can be
+// allowed within the budget, or an error when it can't be made.
+func (b *Budget) ReplyLimit(prompt string) (int, error) {
+	promptTokens := EstimateTokens(prompt)
+	limit := llmMaxReplyTokens
+	if b.maxTokens > 0 {
+		left := b.maxTokens - b.Used.Total() - promptTokens
+		if left < llmMinReplyTokens {
+			return 0, fmt.Errorf("the next prompt is about %d tokens, which with the %d used leaves less than %d for the reply within --max-tokens %d",
+				promptTokens, b.Used.Total(), llmMinReplyTokens, b.maxTokens)
+		}
+		limit = min(limit, left)
+	}
+	if b.maxCost > 0 && b.price.Output > 0 {
+		left := b.maxCost - b.price.cost(b.Used) - b.price.cost(Usage{Prompt: promptTokens})
+		replyTokens := int(left * 1e6 / b.price.Output)
+		if replyTokens < llmMinReplyTokens {
+			return 0, fmt.Errorf("the next call would cost at least $%.4f, which with the $%.4f spent is over --max-cost $%.2f",
+				b.price.cost(Usage{Prompt: promptTokens, Completion: llmMinReplyTokens}), b.price.cost(b.Used), b.maxCost)
+		}
+		limit = min(limit, replyTokens)
+	}
+	return limit, nil
+}
+
+// Spend records a call.
+func (b *Budget) Spend(u Usage) {
+	b.Used.add(u)
+	b.Calls++
+}
+
+// Cost returns what the run Cost so far, and false when the model's price
+// isn't known.
+func (b *Budget) Cost() (float64, bool) {
+	return b.price.cost(b.Used), b.priced
+}
+
+// CostText describes the run's cost so far.
+func (b *Budget) CostText() string {
+	switch {
+	case !b.priced:
+		return fmt.Sprintf("cost unknown for %s (set llm.providers.%s.inputPrice and outputPrice)", b.Model, b.Provider)
+	case b.Provider == "ollama" && b.price == llmPrice{}:
+		return "no cost (local)"
+	default:
+		return fmt.Sprintf("~$%.4f", b.price.cost(b.Used))
+	}
+}
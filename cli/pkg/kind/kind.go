@@ -0,0 +1,298 @@
+// Package kind creates, inspects, and deletes Kind clusters through the
+// sigs.k8s.io/kind library, so the CLI doesn't need the kind binary and
+// can build a cluster's node configuration in Go.
+package kind
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kind/pkg/apis/config/defaults"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cluster/nodeutils"
+	kindexec "sigs.k8s.io/kind/pkg/exec"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+// Config is a Kind cluster configuration, as in a kind-config.yaml.
+type Config = v1alpha4.Cluster
+
+// Parts of a Config.
+type (
+	Node        = v1alpha4.Node
+	Mount       = v1alpha4.Mount
+	PortMapping = v1alpha4.PortMapping
+)
+
+// DefaultNodeImage is the node image clusters are created from when no
+// other is given, pinned by digest.
+const DefaultNodeImage = defaults.Image
+
+// Node roles.
+const (
+	ControlPlaneRole = v1alpha4.ControlPlaneRole
+	WorkerRole       = v1alpha4.WorkerRole
+)
+
+// Progress states.
+const (
+	Started = "started"
+	Done    = "done"
+	Failed  = "failed"
+	Warning = "warning"
+)
+
+// Progress is one update from a long-running operation: a phase of
+// cluster creation ("Preparing nodes 📦") starting, finishing, or failing,
+// or a warning.
+type Progress struct {
+	State   string `json:"state"`
+	Message string `json:"message"`
+}
+
+// Provider manages the Kind clusters of one container runtime.
+type Provider struct {
+	p *cluster.Provider
+}
+
+// New returns a provider on the container runtime kind itself would use:
+// $KIND_EXPERIMENTAL_PROVIDER when set, otherwise docker, nerdctl, or
+// podman, whichever is available first. progress receives the phases of
+// each operation; it may be nil.
+func New(progress func(Progress)) *Provider {
+	logger := progressLogger{emit: progress}
+	if progress == nil {
+		logger.emit = func(Progress) {}
+	}
+	opts := []cluster.ProviderOption{cluster.ProviderWithLogger(logger)}
+	switch runtime := os.Getenv("KIND_EXPERIMENTAL_PROVIDER"); runtime {
+	case "docker":
+		opts = append(opts, cluster.ProviderWithDocker())
+	case "podman":
+		opts = append(opts, cluster.ProviderWithPodman())
+	case "nerdctl", "finch", "nerdctl.lima":
+		opts = append(opts, cluster.ProviderWithNerdctl(runtime))
+	default:
+		if detected, err := cluster.DetectNodeProvider(); err == nil {
+			opts = append(opts, detected)
+		}
+	}
+	return &Provider{p: cluster.NewProvider(opts...)}
+}
+
+// Clusters returns the names of the Kind clusters that have nodes.
+func (p *Provider) Clusters() ([]string, error) {
+	return p.p.List()
+}
+
+// Exists reports whether the named cluster exists. A runtime that can't
+// be reached has no clusters.
+func (p *Provider) Exists(name string) bool {
+	clusters, err := p.Clusters()
+	if err != nil {
+		return false
+	}
+	for _, c := range clusters {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Nodes returns the container names of the cluster's nodes.
+func (p *Provider) Nodes(name string) ([]string, error) {
+	nodes, err := p.p.ListNodes(name)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		names = append(names, n.String())
+	}
+	return names, nil
+}
+
+// CreateOptions tunes Create.
+type CreateOptions struct {
+	NodeImage  string        // "" is the library's default kindest/node image
+	Kubeconfig string        // "" is $KUBECONFIG or ~/.kube/config
+	Wait       time.Duration // wait this long for the control plane; 0 doesn't wait
+	Retain     bool          // keep the nodes when creation fails
+}
+
+// Create creates the named cluster from cfg and adds its context to the
+// kubeconfig.
+func (p *Provider) Create(name string, cfg *Config, opts CreateOptions) error {
+	err := p.p.Create(name,
+		cluster.CreateWithV1Alpha4Config(cfg),
+		cluster.CreateWithNodeImage(opts.NodeImage),
+		cluster.CreateWithKubeconfigPath(opts.Kubeconfig),
+		cluster.CreateWithWaitForReady(opts.Wait),
+		cluster.CreateWithRetain(opts.Retain),
+		cluster.CreateWithDisplayUsage(false),
+		cluster.CreateWithDisplaySalutation(false),
+	)
+	return explain(err)
+}
+
+// Delete deletes the named cluster and removes its kubeconfig context.
+// Deleting a cluster that doesn't exist is not an error.
+func (p *Provider) Delete(name, kubeconfig string) error {
+	return explain(p.p.Delete(name, kubeconfig))
+}
+
+// LoadImage copies an image from the local Docker daemon into every node
+// of the cluster, skipping nodes that already have it — what kind load
+// docker-image does.
+func (p *Provider) LoadImage(name, image string) error {
+	nodes, err := p.p.ListInternalNodes(name)
+	if err != nil {
+		return explain(err)
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("cluster %q has no nodes", name)
+	}
+	id, err := exec.Command("docker", "image", "inspect", "-f", "{{.Id}}", image).Output()
+	if err != nil {
+		return fmt.Errorf("image %q not present locally", image)
+	}
+	imageID := strings.TrimSpace(string(id))
+
+	var missing []string
+	for _, n := range nodes {
+		if nodeID, err := nodeutils.ImageID(n, image); err != nil || nodeID != imageID {
+			missing = append(missing, n.String())
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	archive, err := os.CreateTemp("", "kindling-image-*.tar")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+	var stderr bytes.Buffer
+	save := exec.Command("docker", "save", image)
+	save.Stdout, save.Stderr = archive, &stderr
+	if err := save.Run(); err != nil {
+		return fmt.Errorf("docker save %s failed: %s", image, strings.TrimSpace(stderr.String()))
+	}
+
+	for _, n := range nodes {
+		if !slices.Contains(missing, n.String()) {
+			continue
+		}
+		if _, err := archive.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if err := nodeutils.LoadImageArchive(n, archive); err != nil {
+			return fmt.Errorf("loading %s into %s: %w", image, n, explain(err))
+		}
+	}
+	return nil
+}
+
+// ReadConfig parses a kind-config.yaml. Unknown fields are an error, as
+// they are for kind create cluster --config.
+func ReadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	if cfg.Kind != "Cluster" || cfg.APIVersion != "kind.x-k8s.io/v1alpha4" {
+		return nil, fmt.Errorf("%s is not a kind.x-k8s.io/v1alpha4 Cluster", path)
+	}
+	return cfg, nil
+}
+
+// WriteConfig saves cfg as YAML, below a comment header.
+func WriteConfig(path, comment string, cfg *Config) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(comment), data...), 0644)
+}
+
+// explain adds the output of a failed node command to err, which kind
+// otherwise only keeps inside the error chain.
+func explain(err error) error {
+	if err == nil {
+		return nil
+	}
+	if runErr := kindexec.RunErrorForError(err); runErr != nil && len(runErr.Output) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(runErr.Output)))
+	}
+	return err
+}
+
+// progressLogger turns the status lines kind writes while it works into
+// Progress updates and drops its debug output.
+type progressLogger struct {
+	emit func(Progress)
+}
+
+func (l progressLogger) Warn(message string) {
+	l.emit(Progress{State: Warning, Message: strings.TrimSpace(message)})
+}
+
+func (l progressLogger) Warnf(format string, args ...interface{}) {
+	l.Warn(fmt.Sprintf(format, args...))
+}
+
+func (l progressLogger) Error(message string) {
+	l.emit(Progress{State: Failed, Message: strings.TrimSpace(message)})
+}
+
+func (l progressLogger) Errorf(format string, args ...interface{}) {
+	l.Error(fmt.Sprintf(format, args...))
+}
+
+func (l progressLogger) V(level log.Level) log.InfoLogger {
+	if level > 0 {
+		return log.NoopInfoLogger{}
+	}
+	return progressInfoLogger(l)
+}
+
+type progressInfoLogger progressLogger
+
+func (l progressInfoLogger) Enabled() bool { return true }
+
+func (l progressInfoLogger) Infof(format string, args ...interface{}) {
+	l.Info(fmt.Sprintf(format, args...))
+}
+
+// Info parses kind's " • phase  ...", " ✓ phase", and " ✗ phase" lines.
+func (l progressInfoLogger) Info(message string) {
+	message = strings.TrimSpace(message)
+	switch {
+	case message == "":
+		return
+	case strings.HasPrefix(message, "• "):
+		l.emit(Progress{State: Started, Message: strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(message, "• "), "..."))})
+	case strings.HasPrefix(message, "✓ "):
+		l.emit(Progress{State: Done, Message: strings.TrimPrefix(message, "✓ ")})
+	case strings.HasPrefix(message, "✗ "):
+		l.emit(Progress{State: Failed, Message: strings.TrimPrefix(message, "✗ ")})
+	}
+}
@@ -0,0 +1,50 @@
+package kind
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kind-config.yaml")
+	cfg := &Config{
+		Nodes: []Node{
+			{Role: ControlPlaneRole, ExtraPortMappings: []PortMapping{{ContainerPort: 80, HostPort: 80}}},
+			{Role: WorkerRole, ExtraMounts: []Mount{{HostPath: "/data", ContainerPath: "/data"}}},
+		},
+	}
+	cfg.Kind, cfg.APIVersion = "Cluster", "kind.x-k8s.io/v1alpha4"
+	if err := WriteConfig(path, "# Generated by kindling\n", cfg); err != nil {
+		t.Fatalf("WriteConfig: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "# Generated by kindling\n") {
+		t.Errorf("the config doesn't start with its comment:\n%s", data)
+	}
+
+	got, err := ReadConfig(path)
+	if err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	if len(got.Nodes) != 2 || got.Nodes[0].ExtraPortMappings[0].HostPort != 80 || got.Nodes[1].ExtraMounts[0].HostPath != "/data" {
+		t.Errorf("ReadConfig = %+v, want the config written", got)
+	}
+}
+
+func TestReadConfigRejects(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a config with an unknown field": "kind: Cluster\napiVersion: kind.x-k8s.io/v1alpha4\nnodez: []\n",
+		"a Pod":                          "kind: Pod\napiVersion: v1\n",
+	} {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "-")+".yaml")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadConfig(path); err == nil {
+			t.Errorf("ReadConfig of %s succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package kube
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConfigMap returns the data of a ConfigMap. Binary keys are left out.
+func (c *Client) ConfigMap(ctx context.Context, namespace, name string) (map[string]string, error) {
+	cm, err := c.Clientset.CoreV1().ConfigMaps(c.namespaceOr(namespace)).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return cm.Data, nil
+}
+
+// ApplyConfigMap creates a ConfigMap, or replaces the data of an existing
+// one, so it holds exactly data and binaryData. labels are added to the
+// ones it already has.
+func (c *Client) ApplyConfigMap(ctx context.Context, namespace, name string, labels, data map[string]string, binaryData map[string][]byte) error {
+	configMaps := c.Clientset.CoreV1().ConfigMaps(c.namespaceOr(namespace))
+	cm, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	if IsNotFound(err) {
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: c.namespaceOr(namespace), Labels: labels},
+			Data: data, BinaryData: binaryData}
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{FieldManager: FieldManager})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	for k, v := range labels {
+		if cm.Labels == nil {
+			cm.Labels = map[string]string{}
+		}
+		cm.Labels[k] = v
+	}
+	cm.Data, cm.BinaryData = data, binaryData
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{FieldManager: FieldManager})
+	return err
+}
+
+// DeleteConfigMap deletes a ConfigMap. A missing one is not an error.
+func (c *Client) DeleteConfigMap(ctx context.Context, namespace, name string) error {
+	err := c.Clientset.CoreV1().ConfigMaps(c.namespaceOr(namespace)).Delete(ctx, name, metav1.DeleteOptions{})
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
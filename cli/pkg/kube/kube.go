@@ -0,0 +1,299 @@
+// Package kube talks to the cluster through client-go, so the CLI works
+// without a kubectl binary and always targets the kubeconfig and context
+// it was given rather than whatever kubectl's current context is.
+package kube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// FieldManager is the server-side apply field manager of everything the
+// CLI applies.
+const FieldManager = "kindling"
+
+// Client is a connection to one kubeconfig context.
+type Client struct {
+	Clientset kubernetes.Interface
+	Dynamic   dynamic.Interface
+
+	mapper    *restmapper.DeferredDiscoveryRESTMapper
+	namespace string
+}
+
+// New connects to context in kubeconfig. An empty kubeconfig follows the
+// usual $KUBECONFIG / ~/.kube/config lookup; an empty context uses the
+// kubeconfig's current context. namespace, unless "", replaces the
+// context's default namespace. Every API request is logged to log at
+// debug level.
+func New(kubeconfig, context, namespace string, log *slog.Logger) (*Client, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{
+		CurrentContext: context,
+		Context:        clientcmdapi.Context{Namespace: namespace},
+	})
+	cfg, err := loader.ClientConfig()
+	if err != nil {
+		if context != "" {
+			return nil, fmt.Errorf("cannot load kubeconfig context %q: %w", context, err)
+		}
+		return nil, fmt.Errorf("cannot load kubeconfig: %w", err)
+	}
+	namespace, _, err = loader.Namespace()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &loggingTransport{next: rt, log: log}
+	})
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromClients(clientset, dyn, namespace), nil
+}
+
+// NewFromClients wraps clients a tool already has — or fake ones in
+// tests. Resources are mapped through clientset's discovery.
+func NewFromClients(clientset kubernetes.Interface, dyn dynamic.Interface, namespace string) *Client {
+	return &Client{
+		Clientset: clientset,
+		Dynamic:   dyn,
+		mapper:    restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery())),
+		namespace: namespace,
+	}
+}
+
+// loggingTransport logs each API request: method, path, status, and how
+// long it took.
+type loggingTransport struct {
+	next http.RoundTripper
+	log  *slog.Logger
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	attrs := []any{"method", req.Method, "url", req.URL.RequestURI(), "duration", time.Since(start).Round(time.Millisecond)}
+	if err != nil {
+		t.log.Debug("api request failed", append(attrs, "error", err)...)
+		return resp, err
+	}
+	t.log.Debug("api request", append(attrs, "status", resp.StatusCode)...)
+	return resp, nil
+}
+
+// Contexts returns the names of the contexts in kubeconfig, following the
+// same lookup as New.
+func Contexts(kubeconfig string) ([]string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+	cfg, err := rules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load kubeconfig: %w", err)
+	}
+	names := make([]string, 0, len(cfg.Contexts))
+	for name := range cfg.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Namespace returns the namespace of the context — "default" unless the
+// kubeconfig sets one.
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
+// IsNotFound reports whether err means the object does not exist.
+func IsNotFound(err error) bool {
+	return apierrors.IsNotFound(err)
+}
+
+// Resource returns the client of a resource — "pods", "ingress",
+// "devstagingenvironments.apps.example.com" — in namespace, or across
+// every namespace when it is "". The namespace is ignored for
+// cluster-scoped resources.
+func (c *Client) Resource(resource, namespace string) (dynamic.ResourceInterface, error) {
+	gvr, err := c.mapper.ResourceFor(schema.ParseGroupResource(resource).WithVersion(""))
+	if err != nil {
+		return nil, err
+	}
+	gvk, err := c.mapper.KindFor(gvr)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return c.Dynamic.Resource(gvr), nil
+	}
+	return c.Dynamic.Resource(gvr).Namespace(namespace), nil
+}
+
+// List returns the objects of resource in namespace ("" is every
+// namespace) that match the label selector.
+func (c *Client) List(ctx context.Context, resource, namespace, selector string) (*unstructured.UnstructuredList, error) {
+	ri, err := c.Resource(resource, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return ri.List(ctx, metav1.ListOptions{LabelSelector: selector})
+}
+
+// Get returns one object of resource; namespace "" is the context's.
+func (c *Client) Get(ctx context.Context, resource, namespace, name string) (*unstructured.Unstructured, error) {
+	ri, err := c.Resource(resource, c.namespaceOr(namespace))
+	if err != nil {
+		return nil, err
+	}
+	return ri.Get(ctx, name, metav1.GetOptions{})
+}
+
+func (c *Client) namespaceOr(namespace string) string {
+	if namespace != "" {
+		return namespace
+	}
+	return c.namespace
+}
+
+// ── Apply ───────────────────────────────────────────────────────
+
+// ApplyOptions tunes Apply.
+type ApplyOptions struct {
+	// Namespace receives the namespaced objects that don't name one; ""
+	// is the context's namespace. Objects naming another namespace are
+	// refused when it is set, as kubectl apply -n does.
+	Namespace string
+	// DryRun has the server validate and default the objects without
+	// persisting them.
+	DryRun bool
+}
+
+// Apply server-side applies every object of a YAML or JSON manifest, in
+// order, and returns the objects as the server stored them.
+func (c *Client) Apply(ctx context.Context, manifest []byte, opts ApplyOptions) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	dec := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := dec.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("cannot parse manifest: %w", err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if obj.IsList() {
+			err := obj.EachListItem(func(item runtime.Object) error {
+				objects = append(objects, item.(*unstructured.Unstructured))
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		objects = append(objects, obj)
+	}
+
+	applied := []*unstructured.Unstructured{}
+	for _, obj := range objects {
+		out, err := c.applyObject(ctx, obj, opts)
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, out)
+	}
+	return applied, nil
+}
+
+func (c *Client) applyObject(ctx context.Context, obj *unstructured.Unstructured, opts ApplyOptions) (*unstructured.Unstructured, error) {
+	gvk := obj.GroupVersionKind()
+	if gvk.Kind == "" || obj.GetName() == "" {
+		return nil, fmt.Errorf("every object needs a kind and metadata.name")
+	}
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// The CRD may have been applied moments ago.
+		c.mapper.Reset()
+		mapping, err = c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+
+	ri := dynamic.ResourceInterface(c.Dynamic.Resource(mapping.Resource))
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		switch {
+		case obj.GetNamespace() == "":
+			obj.SetNamespace(c.namespaceOr(opts.Namespace))
+		case opts.Namespace != "" && obj.GetNamespace() != opts.Namespace:
+			return nil, fmt.Errorf("%s %s sets namespace %q, which does not match %q", gvk.Kind, obj.GetName(), obj.GetNamespace(), opts.Namespace)
+		}
+		ri = c.Dynamic.Resource(mapping.Resource).Namespace(obj.GetNamespace())
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	force := true
+	patchOpts := metav1.PatchOptions{FieldManager: FieldManager, Force: &force}
+	if opts.DryRun {
+		patchOpts.DryRun = []string{metav1.DryRunAll}
+	}
+	out, err := ri.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, patchOpts)
+	if err != nil {
+		return nil, fmt.Errorf("applying %s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+	return out, nil
+}
+
+// ObjectName returns the name of obj in kubectl's kind.group/name form,
+// e.g. devstagingenvironment.apps.example.com/orders.
+func ObjectName(obj *unstructured.Unstructured) string {
+	gvk := obj.GroupVersionKind()
+	name := strings.ToLower(gvk.Kind)
+	if gvk.Group != "" {
+		name += "." + gvk.Group
+	}
+	return name + "/" + obj.GetName()
+}
@@ -0,0 +1,171 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// newFakeClient returns a Client over fake clients that know ConfigMaps
+// and Namespaces.
+func newFakeClient() *Client {
+	clientset := fake.NewClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "configmaps", SingularName: "configmap", Namespaced: true, Kind: "ConfigMap", Verbs: []string{"get", "list", "patch"}},
+			{Name: "namespaces", SingularName: "namespace", Kind: "Namespace", Verbs: []string{"get", "list", "patch"}},
+		},
+	}}
+	return NewFromClients(clientset, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), "default")
+}
+
+// recordApplies makes c's dynamic client answer each server-side apply
+// with the applied object, and returns the applies it got.
+func recordApplies(c *Client) *[]clienttesting.PatchActionImpl {
+	var applies []clienttesting.PatchActionImpl
+	c.Dynamic.(*dynamicfake.FakeDynamicClient).PrependReactor("patch", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patch := action.(clienttesting.PatchActionImpl)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		applies = append(applies, patch)
+		obj := &unstructured.Unstructured{}
+		return true, obj, obj.UnmarshalJSON(patch.GetPatch())
+	})
+	return &applies
+}
+
+func TestObjectName(t *testing.T) {
+	for _, tc := range []struct {
+		apiVersion, kind, want string
+	}{
+		{"apps.example.com/v1alpha1", "DevStagingEnvironment", "devstagingenvironment.apps.example.com/orders"},
+		{"v1", "ConfigMap", "configmap/orders"},
+	} {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(tc.apiVersion)
+		obj.SetKind(tc.kind)
+		obj.SetName("orders")
+		if got := ObjectName(obj); got != tc.want {
+			t.Errorf("ObjectName(%s %s) = %q, want %q", tc.apiVersion, tc.kind, got, tc.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	c := newFakeClient()
+	applies := recordApplies(c)
+	manifest := []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  level: debug
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: team
+`)
+	applied, err := c.Apply(context.Background(), manifest, ApplyOptions{Namespace: "team", DryRun: true})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(applied) != 2 || len(*applies) != 2 {
+		t.Fatalf("Apply applied %d object(s) in %d request(s), want 2", len(applied), len(*applies))
+	}
+	if got := (*applies)[0].GetNamespace(); got != "team" {
+		t.Errorf("the ConfigMap went to namespace %q, want team", got)
+	}
+	if got := (*applies)[1].GetNamespace(); got != "" {
+		t.Errorf("the Namespace went to namespace %q, want none: it is cluster-scoped", got)
+	}
+	if level, _, _ := unstructured.NestedString(applied[0].Object, "data", "level"); level != "debug" {
+		t.Errorf("data.level = %q, want debug", level)
+	}
+	opts := (*applies)[0].GetPatchOptions()
+	if opts.FieldManager != FieldManager || opts.Force == nil || !*opts.Force || len(opts.DryRun) != 1 {
+		t.Errorf("patch options = %+v, want a forced, dry-run apply by %s", opts, FieldManager)
+	}
+}
+
+func TestApplyRefusesAnotherNamespace(t *testing.T) {
+	c := newFakeClient()
+	manifest := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"settings","namespace":"prod"}}`)
+	_, err := c.Apply(context.Background(), manifest, ApplyOptions{Namespace: "team"})
+	if err == nil || !strings.Contains(err.Error(), `sets namespace "prod"`) {
+		t.Errorf("Apply into team of an object in prod: err = %v, want a namespace mismatch", err)
+	}
+	if _, err := c.Apply(context.Background(), []byte(`{"apiVersion":"v1","kind":"ConfigMap"}`), ApplyOptions{}); err == nil {
+		t.Error("Apply of an object without a name succeeded")
+	}
+}
+
+func TestConfigMaps(t *testing.T) {
+	c := newFakeClient()
+	ctx := context.Background()
+	labels := map[string]string{"app.kubernetes.io/managed-by": "kindling"}
+	if err := c.ApplyConfigMap(ctx, "", "policies", labels, map[string]string{"a.yaml": "1"}, nil); err != nil {
+		t.Fatalf("ApplyConfigMap (create): %v", err)
+	}
+	if err := c.ApplyConfigMap(ctx, "", "policies", nil, map[string]string{"b.yaml": "2"}, nil); err != nil {
+		t.Fatalf("ApplyConfigMap (update): %v", err)
+	}
+	data, err := c.ConfigMap(ctx, "default", "policies")
+	if err != nil {
+		t.Fatalf("ConfigMap: %v", err)
+	}
+	if len(data) != 1 || data["b.yaml"] != "2" {
+		t.Errorf("ConfigMap data = %v, want exactly the last data applied", data)
+	}
+	cm, _ := c.Clientset.CoreV1().ConfigMaps("default").Get(ctx, "policies", metav1.GetOptions{})
+	if cm.Labels["app.kubernetes.io/managed-by"] != "kindling" {
+		t.Errorf("labels = %v, want the labels of the first apply kept", cm.Labels)
+	}
+
+	if err := c.DeleteConfigMap(ctx, "", "policies"); err != nil {
+		t.Fatalf("DeleteConfigMap: %v", err)
+	}
+	if err := c.DeleteConfigMap(ctx, "", "policies"); err != nil {
+		t.Errorf("DeleteConfigMap of a missing ConfigMap: %v", err)
+	}
+	if _, err := c.ConfigMap(ctx, "", "policies"); !IsNotFound(err) {
+		t.Errorf("ConfigMap after delete: err = %v, want not found", err)
+	}
+}
+
+func TestLogs(t *testing.T) {
+	c := newFakeClient()
+	ctx := context.Background()
+	for _, name := range []string{"orders-1", "orders-2"} {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "orders"}}}
+		if _, err := c.Clientset.CoreV1().Pods("default").Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := c.Logs(ctx, "", "app=orders", LogOptions{Tail: 10}, &out); err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+	// The fake API server answers every log request with "fake logs".
+	if got := strings.Count(out.String(), "fake logs\n"); got != 2 {
+		t.Errorf("Logs wrote %q, want one line per pod", out.String())
+	}
+
+	if err := c.Logs(ctx, "", "app=payments", LogOptions{}, &out); err == nil {
+		t.Error("Logs of a selector matching no pods succeeded")
+	}
+}
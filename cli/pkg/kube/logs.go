@@ -0,0 +1,109 @@
+package kube
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LogOptions selects the log lines of a pod, as kubectl logs' flags do.
+type LogOptions struct {
+	Container     string        // "" is the pod's first container
+	AllContainers bool          // every container of the pod
+	Since         time.Duration // 0 is the whole log
+	Tail          int64         // last lines only; 0 is every line
+	Follow        bool
+	Previous      bool // the previous, crashed instance of the container
+}
+
+// Logs writes the logs of every pod matching selector in namespace to w.
+// With Follow it streams until ctx is done or every stream ends.
+func (c *Client) Logs(ctx context.Context, namespace, selector string, opts LogOptions, w io.Writer) error {
+	namespace = c.namespaceOr(namespace)
+	pods, err := c.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no pods in %s match %s", namespace, selector)
+	}
+	var mu sync.Mutex
+	return parallel(len(pods.Items), func(i int) error {
+		return c.PodLogs(ctx, namespace, pods.Items[i].Name, opts, func(line string) {
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Fprintln(w, line)
+		})
+	})
+}
+
+// PodLogs calls emit with each log line of one pod. Lines of several
+// containers may arrive concurrently.
+func (c *Client) PodLogs(ctx context.Context, namespace, pod string, opts LogOptions, emit func(line string)) error {
+	namespace = c.namespaceOr(namespace)
+	containers := []string{opts.Container}
+	if opts.AllContainers {
+		p, err := c.Clientset.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		containers = containers[:0]
+		for _, container := range p.Spec.Containers {
+			containers = append(containers, container.Name)
+		}
+	}
+	return parallel(len(containers), func(i int) error {
+		return c.streamLogs(ctx, namespace, pod, containers[i], opts, emit)
+	})
+}
+
+func (c *Client) streamLogs(ctx context.Context, namespace, pod, container string, opts LogOptions, emit func(string)) error {
+	podOpts := &corev1.PodLogOptions{Container: container, Follow: opts.Follow, Previous: opts.Previous}
+	if opts.Since > 0 {
+		seconds := int64(opts.Since.Seconds())
+		podOpts.SinceSeconds = &seconds
+	}
+	if opts.Tail > 0 {
+		podOpts.TailLines = &opts.Tail
+	}
+	rc, err := c.Clientset.CoreV1().Pods(namespace).GetLogs(pod, podOpts).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	scanner := bufio.NewScanner(rc)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		emit(scanner.Text())
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
+
+// parallel runs fn for 0..n-1 concurrently and returns the first error.
+func parallel(n int, fn func(i int) error) error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = fn(i)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package source
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ── Environment variable inference ──────────────────────────────
+//
+// A component's source is read for the environment variables it reads —
+// os.Getenv, process.env, os.environ, ENV[], System.getenv, and their
+// kin — and whether the code falls back to a default when one is unset.
+// kindling generate puts the variables read with no default in the spec,
+// with a placeholder for the developer to fill in, and kindling validate
+// flags them when a manifest or workflow leaves them unset.
+
+// EnvPlaceholder is the value generate gives a required variable it can't
+// guess. kindling validate reports it as unset.
+const EnvPlaceholder = "CHANGE_ME"
+
+// EnvRead is an environment variable a component's code reads.
+type EnvRead struct {
+	Name     string
+	Location string // file:line of the first read, relative to the component
+	Default  bool   // some read falls back to a default when it's unset
+}
+
+// envReadPatterns find env var reads. The first group is the name; a
+// second group, when it matched, means the call itself passes a default.
+var envReadPatterns = []*regexp.Regexp{
+	// Go
+	regexp.MustCompile(`\bos\.Getenv\(\s*"([A-Z][A-Z0-9_]*)"\s*\)`),
+	regexp.MustCompile(`\bos\.LookupEnv\(\s*"([A-Z][A-Z0-9_]*)"\s*(\))`),
+	// Helpers taking a fallback: getEnv("PORT", "8080"), env("X", d), ...
+	regexp.MustCompile(`\b(?:get[Ee]nv|getEnvOr\w*|envOr\w*|env)\(\s*["']([A-Z][A-Z0-9_]*)["']\s*(,)`),
+	// Node.js / Deno / Bun
+	regexp.MustCompile(`\bprocess\.env\.([A-Z][A-Z0-9_]*)\b`),
+	regexp.MustCompile(`\bprocess\.env\[\s*["']([A-Z][A-Z0-9_]*)["']\s*\]`),
+	regexp.MustCompile(`\bDeno\.env\.get\(\s*["']([A-Z][A-Z0-9_]*)["']\s*\)`),
+	regexp.MustCompile(`\bBun\.env\.([A-Z][A-Z0-9_]*)\b`),
+	// Python
+	regexp.MustCompile(`\bos\.environ\[\s*["']([A-Z][A-Z0-9_]*)["']\s*\]`),
+	regexp.MustCompile(`\bos\.(?:environ\.get|getenv)\(\s*["']([A-Z][A-Z0-9_]*)["']\s*(,)?`),
+	// Java / Kotlin
+	regexp.MustCompile(`\bSystem\.getenv\(\s*"([A-Z][A-Z0-9_]*)"\s*\)`),
+	// C# / .NET
+	regexp.MustCompile(`\bEnvironment\.GetEnvironmentVariable\(\s*"([A-Z][A-Z0-9_]*)"\s*\)`),
+	// Rust
+	regexp.MustCompile(`\benv::var(?:_os)?\(\s*"([A-Z][A-Z0-9_]*)"\s*\)`),
+	// Ruby
+	regexp.MustCompile(`\bENV\[\s*["']([A-Z][A-Z0-9_]*)["']\s*\]`),
+	regexp.MustCompile(`\bENV\.fetch\(\s*["']([A-Z][A-Z0-9_]*)["']\s*(,|\)\s*(?:\{|do\b))?`),
+	// PHP
+	regexp.MustCompile(`\bgetenv\(\s*["']([A-Z][A-Z0-9_]*)["']\s*\)`),
+	regexp.MustCompile(`\$_(?:ENV|SERVER)\[\s*["']([A-Z][A-Z0-9_]*)["']\s*\]`),
+	// Elixir
+	regexp.MustCompile(`\bSystem\.get_env\(\s*"([A-Z][A-Z0-9_]*)"\s*(,)?`),
+	regexp.MustCompile(`\bSystem\.fetch_env!\(\s*"([A-Z][A-Z0-9_]*)"\s*\)`),
+}
+
+var (
+	// envFallbackAfter follows a read that falls back to a default or only
+	// tests whether the variable is set.
+	envFallbackAfter = regexp.MustCompile(`^\)?\s*(?:\|\||\?\?|\?:|[=!]==?|&&|\?[^.?]|\.unwrap_or|\.ok\(\)|\.is_ok\(\)|\.is_err\(\)|\.or_else|\.orElse|\.getOrElse|\.unwrap_or_default|\.presence)`)
+	// envAssigned is the variable a read is assigned to: x := , const x = .
+	envAssigned = regexp.MustCompile(`(\w+)\s*:?=\s*$`)
+	// envFallbackNext, on one of the lines after a read, checks whether the
+	// variable it was assigned to came back empty.
+	envFallbackNext = regexp.MustCompile(`==\s*""|!=\s*""|===?\s*undefined|\bis (?:not )?None\b|== nil\b|\.nil\?|\.empty\?|\.isEmpty\(\)|IsNullOrEmpty|^\s*if\s+not\s|^\s*if\s*\(\s*!`)
+	// dockerfileEnv finds the variables a Dockerfile sets with ENV.
+	dockerfileEnv = regexp.MustCompile(`(?im)^\s*ENV\s+(.+)$`)
+)
+
+// ambientEnvVars are set by the container runtime, the base image, or
+// Kubernetes, or are commonly read only to switch behaviour; they are
+// never required.
+var ambientEnvVars = map[string]bool{
+	"HOME": true, "PATH": true, "HOSTNAME": true, "USER": true, "PWD": true, "SHELL": true,
+	"TMPDIR": true, "TEMP": true, "TMP": true, "TZ": true, "LANG": true, "LC_ALL": true, "TERM": true,
+	"CI": true, "DEBUG": true, "NODE_ENV": true, "RAILS_ENV": true, "RACK_ENV": true, "MIX_ENV": true,
+	"APP_ENV": true, "GO_ENV": true, "ENV": true, "ENVIRONMENT": true, "FLASK_ENV": true, "FLASK_DEBUG": true,
+	"GOMAXPROCS": true, "GOMEMLIMIT": true, "PYTHONPATH": true, "PYTHONUNBUFFERED": true, "JAVA_OPTS": true,
+	"KUBERNETES_SERVICE_HOST": true, "KUBERNETES_SERVICE_PORT": true, "POD_NAME": true, "POD_NAMESPACE": true,
+}
+
+// EnvVars returns, for each component directory, the environment
+// variables its code reads, sorted by name. Nested component directories
+// are left to their own component.
+func EnvVars(repoPath string, dirs []string) map[string][]EnvRead {
+	result := make(map[string][]EnvRead, len(dirs))
+	for _, dir := range dirs {
+		var nested []string
+		for _, other := range dirs {
+			if other != dir && (dir == "." || strings.HasPrefix(other, dir+string(filepath.Separator))) {
+				nested = append(nested, other)
+			}
+		}
+		result[dir] = EnvReads(repoPath, dir, nested)
+	}
+	return result
+}
+
+// EnvReads scans the source files under dir, skipping the nested
+// component directories and tests. A variable counts as having a default
+// when any of its reads has one.
+func EnvReads(repoPath, dir string, nested []string) []EnvRead {
+	skip := map[string]bool{}
+	for _, n := range nested {
+		skip[filepath.Join(repoPath, n)] = true
+	}
+
+	reads := map[string]*EnvRead{}
+	files := 0
+	root := filepath.Join(repoPath, dir)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (SkipDir(d.Name()) || skip[path]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !IsCode(filepath.Ext(path)) || IsTest(d.Name()) {
+			return nil
+		}
+		if files >= MaxFiles {
+			return filepath.SkipAll
+		}
+		files++
+
+		info, err := d.Info()
+		if err != nil || info.Size() > 512*1024 {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		lines := strings.Split(string(data), "\n")
+		for i, line := range lines {
+			for _, re := range envReadPatterns {
+				for _, m := range re.FindAllStringSubmatchIndex(line, -1) {
+					name := line[m[2]:m[3]]
+					if ambientEnvVars[name] {
+						continue
+					}
+					def := len(m) > 4 && m[4] >= 0 || envReadHasFallback(line, m[0], m[1], lines[i+1:])
+					r := reads[name]
+					if r == nil {
+						r = &EnvRead{Name: name, Location: fmt.Sprintf("%s:%d", filepath.ToSlash(rel), i+1)}
+						reads[name] = r
+					}
+					r.Default = r.Default || def
+				}
+			}
+		}
+		return nil
+	})
+
+	result := make([]EnvRead, 0, len(reads))
+	for _, name := range slices.Sorted(maps.Keys(reads)) {
+		result = append(result, *reads[name])
+	}
+	return result
+}
+
+// envReadHasFallback reports whether the read at line[start:end] falls
+// back to a default: right after it on the line, with a negation in front
+// of it, or with an emptiness check of the variable it was assigned to on
+// one of the next two lines.
+func envReadHasFallback(line string, start, end int, next []string) bool {
+	if envFallbackAfter.MatchString(line[end:]) {
+		return true
+	}
+	before := strings.TrimRight(line[:start], " \t")
+	if strings.HasSuffix(before, "!") || strings.HasSuffix(before, "cmp.Or(") || strings.HasSuffix(before, "if") ||
+		strings.HasSuffix(before, "if (") || strings.HasSuffix(before, "unless") {
+		return true
+	}
+	m := envAssigned.FindStringSubmatch(before)
+	if m == nil {
+		return false
+	}
+	ident := regexp.MustCompile(`\b` + m[1] + `\b`)
+	for i := 0; i < len(next) && i < 2; i++ {
+		if ident.MatchString(next[i]) && envFallbackNext.MatchString(next[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// DockerfileEnv returns the variables a Dockerfile sets with ENV,
+// in both the "ENV A=1 B=2" and the legacy "ENV A 1" forms.
+func DockerfileEnv(content string) map[string]bool {
+	names := map[string]bool{}
+	for _, m := range dockerfileEnv.FindAllStringSubmatch(content, -1) {
+		fields := strings.Fields(m[1])
+		if len(fields) == 0 {
+			continue
+		}
+		if !strings.Contains(fields[0], "=") {
+			names[fields[0]] = true
+			continue
+		}
+		for _, f := range fields {
+			if name, _, ok := strings.Cut(f, "="); ok && name != "" {
+				names[name] = true
+			}
+		}
+	}
+	return names
+}
+
+// RequiredEnv filters reads down to the variables nothing provides:
+// read with no default, not set by the Dockerfile, and not injected.
+func RequiredEnv(reads []EnvRead, provided ...map[string]bool) []EnvRead {
+	var required []EnvRead
+	for _, r := range reads {
+		if r.Default {
+			continue
+		}
+		set := false
+		for _, p := range provided {
+			set = set || p[r.Name]
+		}
+		if !set {
+			required = append(required, r)
+		}
+	}
+	return required
+}
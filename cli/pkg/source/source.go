@@ -0,0 +1,128 @@
+// Package source reads an application's source tree the way kindling
+// generate and kindling validate do: which directories hold code, which
+// environment variables the code reads, and the names and ports kindling
+// derives from it.
+package source
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxFiles caps how many source files a scan reads per component.
+const MaxFiles = 500
+
+// skipDirs are the directories a scan skips.
+var skipDirs = map[string]bool{
+	".git":         true,
+	".kindling":    true, // local state and synthesized Dockerfiles
+	"node_modules": true,
+	"vendor":       true,
+	"__pycache__":  true,
+	".venv":        true,
+	"venv":         true,
+	"env":          true,
+	".tox":         true,
+	".mypy_cache":  true,
+	".ruff_cache":  true,
+	"dist":         true,
+	"build":        true,
+	".next":        true,
+	".nuxt":        true,
+	".svelte-kit":  true,
+	"target":       true, // Rust, Java/Maven
+	".terraform":   true,
+	".idea":        true,
+	".vscode":      true,
+	".github":      true, // don't confuse the AI with existing workflows
+	"bin":          true,
+	"obj":          true, // .NET build output
+	"_output":      true,
+	".cache":       true,
+	"_build":       true, // Elixir/Mix
+	"deps":         true, // Elixir/Mix
+	"zig-cache":    true,
+	"zig-out":      true,
+	".gradle":      true,
+	".m2":          true,
+	".elixir_ls":   true,
+	"coverage":     true,
+	".nyc_output":  true,
+	"htmlcov":      true,
+}
+
+// codeExts are the extensions of source files.
+var codeExts = map[string]bool{
+	".go":     true, // Go
+	".py":     true, // Python
+	".js":     true, // JavaScript
+	".ts":     true, // TypeScript
+	".jsx":    true, // React JSX
+	".tsx":    true, // React TSX
+	".java":   true, // Java
+	".kt":     true, // Kotlin
+	".kts":    true, // Kotlin Script
+	".rs":     true, // Rust
+	".rb":     true, // Ruby
+	".php":    true, // PHP
+	".cs":     true, // C#
+	".fs":     true, // F#
+	".ex":     true, // Elixir
+	".exs":    true, // Elixir Script
+	".zig":    true, // Zig
+	".c":      true, // C
+	".cpp":    true, // C++
+	".cc":     true, // C++
+	".h":      true, // C/C++ header
+	".pl":     true, // Perl
+	".r":      true, // R
+	".R":      true, // R
+	".jl":     true, // Julia
+	".lua":    true, // Lua
+	".cr":     true, // Crystal
+	".nim":    true, // Nim
+	".vue":    true, // Vue.js SFC
+	".svelte": true, // Svelte
+}
+
+// SkipDir reports whether a scan skips directories called name:
+// dependencies, build output, caches, and tool state.
+func SkipDir(name string) bool {
+	return skipDirs[name]
+}
+
+// IsCode reports whether ext, such as ".go", is a source file extension.
+func IsCode(ext string) bool {
+	return codeExts[ext]
+}
+
+// IsTest reports whether name looks like a test file, whose routes and
+// env reads are usually fakes.
+func IsTest(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, "_test.go") ||
+		strings.HasPrefix(lower, "test_") ||
+		strings.Contains(lower, ".test.") ||
+		strings.Contains(lower, ".spec.")
+}
+
+var (
+	// ExposeDirective matches the EXPOSE lines of a Dockerfile; the
+	// submatch is the first port.
+	ExposeDirective = regexp.MustCompile(`(?im)^\s*EXPOSE\s+(\d+)`)
+	dnsLabelInvalid = regexp.MustCompile(`[^a-z0-9-]+`)
+)
+
+// DNSLabel lower-cases s and replaces anything that isn't valid in a
+// Kubernetes resource name.
+func DNSLabel(s string) string {
+	s = dnsLabelInvalid.ReplaceAllString(strings.ToLower(s), "-")
+	s = strings.Trim(s, "-")
+	if len(s) > 50 {
+		s = strings.TrimRight(s[:50], "-")
+	}
+	if s == "" {
+		return "app"
+	}
+	return s
+}
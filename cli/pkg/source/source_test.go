@@ -0,0 +1,114 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDNSLabel(t *testing.T) {
+	for in, want := range map[string]string{
+		"Orders_API":                   "orders-api",
+		"--web--":                      "web",
+		"@scope/ui":                    "scope-ui",
+		"!!!":                          "app",
+		strings.Repeat("x", 60):        strings.Repeat("x", 50),
+		strings.Repeat("x", 49) + "-y": strings.Repeat("x", 49),
+	} {
+		if got := DNSLabel(in); got != want {
+			t.Errorf("DNSLabel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestIsTest(t *testing.T) {
+	for name, want := range map[string]bool{
+		"server_test.go":  true,
+		"test_views.py":   true,
+		"app.test.ts":     true,
+		"Button.spec.tsx": true,
+		"server.go":       false,
+		"contest.py":      false,
+	} {
+		if got := IsTest(name); got != want {
+			t.Errorf("IsTest(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestDockerfileEnv(t *testing.T) {
+	got := DockerfileEnv("FROM node:22\nENV PORT=3000 NODE_ENV=production\nenv LEGACY value with spaces\n")
+	for _, name := range []string{"PORT", "NODE_ENV", "LEGACY"} {
+		if !got[name] {
+			t.Errorf("DockerfileEnv is missing %s: %v", name, got)
+		}
+	}
+	if len(got) != 3 {
+		t.Errorf("DockerfileEnv = %v, want 3 variables", got)
+	}
+}
+
+func TestEnvReads(t *testing.T) {
+	repo := t.TempDir()
+	write := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(repo, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("api/main.go", `package main
+
+import "os"
+
+func main() {
+	dsn := os.Getenv("DATABASE_URL")
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	_ = os.Getenv("HOME")
+	_, _ = dsn, port
+}
+`)
+	write("api/main_test.go", `package main
+
+import "os"
+
+var _ = os.Getenv("TEST_ONLY")
+`)
+	write("api/node_modules/lib/index.js", `process.env.VENDORED`)
+	write("web/index.js", `const key = process.env.STRIPE_KEY;`)
+
+	reads := EnvVars(repo, []string{"api", "web"})
+	byName := map[string]EnvRead{}
+	for _, r := range reads["api"] {
+		byName[r.Name] = r
+	}
+	if r, ok := byName["DATABASE_URL"]; !ok || r.Default || r.Location != "main.go:6" {
+		t.Errorf("DATABASE_URL read = %+v, want a read without a default at main.go:6", r)
+	}
+	if r := byName["PORT"]; !r.Default {
+		t.Errorf("PORT read = %+v, want a read with a default", r)
+	}
+	for _, name := range []string{"HOME", "TEST_ONLY", "VENDORED", "STRIPE_KEY"} {
+		if _, ok := byName[name]; ok {
+			t.Errorf("api reads %s, want it left out", name)
+		}
+	}
+	if len(reads["web"]) != 1 || reads["web"][0].Name != "STRIPE_KEY" {
+		t.Errorf("web reads %v, want STRIPE_KEY", reads["web"])
+	}
+
+	required := RequiredEnv(reads["api"], map[string]bool{"DATABASE_URL": false})
+	if len(required) != 1 || required[0].Name != "DATABASE_URL" {
+		t.Errorf("RequiredEnv = %v, want DATABASE_URL", required)
+	}
+	if required := RequiredEnv(reads["api"], map[string]bool{"DATABASE_URL": true}); len(required) != 0 {
+		t.Errorf("RequiredEnv with DATABASE_URL provided = %v, want none", required)
+	}
+}
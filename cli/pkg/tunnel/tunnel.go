@@ -0,0 +1,139 @@
+// Package tunnel reads and writes the state of the public tunnels kindling
+// expose runs: .kindling/tunnels.yaml in the project, one entry per tunnel
+// with its provider, public URL, and process.
+package tunnel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StateFile is the file name of the tunnel list inside .kindling/.
+const StateFile = "tunnels.yaml"
+
+// legacyStateFile held a single tunnel before multiple concurrent tunnels
+// were supported. It is still read so that a tunnel started by an older
+// kindling can be listed and stopped.
+const legacyStateFile = "tunnel.yaml"
+
+// State is the persisted state of one running tunnel, stored as an entry
+// in .kindling/tunnels.yaml by kindling expose. Service is the ingress the
+// tunnel routes to; it is empty for the default tunnel, which routes to
+// every ingress not claimed by another tunnel.
+type State struct {
+	Provider string    `yaml:"provider" json:"provider"`
+	URL      string    `yaml:"url" json:"url"`
+	PID      int       `yaml:"pid" json:"pid"`
+	Port     int       `yaml:"port,omitempty" json:"port,omitempty"`
+	Service  string    `yaml:"service,omitempty" json:"service,omitempty"`
+	Created  time.Time `yaml:"created" json:"created"`
+
+	// Named is set when the tunnel is an authenticated Cloudflare named
+	// tunnel routed to a custom hostname rather than a quick tunnel.
+	Named *Named `yaml:"named,omitempty" json:"named,omitempty"`
+}
+
+// Named records the Cloudflare named tunnel ↔ hostname mapping.
+type Named struct {
+	Name            string `yaml:"name" json:"name"`
+	ID              string `yaml:"id" json:"id"`
+	Hostname        string `yaml:"hostname" json:"hostname"`
+	CredentialsFile string `yaml:"credentialsFile" json:"credentialsFile"`
+}
+
+// Uptime returns how long the tunnel has been running. It is zero if the
+// creation time was never recorded.
+func (s *State) Uptime() time.Duration {
+	if s.Created.IsZero() {
+		return 0
+	}
+	return time.Since(s.Created).Truncate(time.Second)
+}
+
+// Label returns the service the tunnel routes to, or "(default)".
+func (s *State) Label() string {
+	if s.Service == "" {
+		return "(default)"
+	}
+	return s.Service
+}
+
+// list is the on-disk layout of .kindling/tunnels.yaml.
+type list struct {
+	Tunnels []State `yaml:"tunnels"`
+}
+
+// StatePath returns the path of .kindling/tunnels.yaml under dir.
+func StatePath(dir string) string {
+	return filepath.Join(dir, ".kindling", StateFile)
+}
+
+// Read loads every tracked tunnel from <dir>/.kindling/tunnels.yaml,
+// falling back to the legacy single-tunnel tunnel.yaml. A missing file is
+// not an error.
+func Read(dir string) ([]State, error) {
+	data, err := os.ReadFile(StatePath(dir))
+	if err == nil {
+		var l list
+		if err := yaml.Unmarshal(data, &l); err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %w", StatePath(dir), err)
+		}
+		return l.Tunnels, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	legacyPath := filepath.Join(dir, ".kindling", legacyStateFile)
+	data, err = os.ReadFile(legacyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", legacyPath, err)
+	}
+	if state.PID == 0 {
+		return nil, nil
+	}
+	return []State{state}, nil
+}
+
+// Write persists the tunnel list to <dir>/.kindling/tunnels.yaml,
+// removing the file once the list is empty. The legacy tunnel.yaml is
+// always removed since its tunnel is carried over into the list.
+func Write(dir string, tunnels []State) error {
+	_ = os.Remove(filepath.Join(dir, ".kindling", legacyStateFile))
+	if len(tunnels) == 0 {
+		if err := os.Remove(StatePath(dir)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".kindling"), 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(list{Tunnels: tunnels})
+	if err != nil {
+		return fmt.Errorf("cannot marshal tunnel state: %w", err)
+	}
+	content := append([]byte("# Generated by kindling expose — do not edit\n"), data...)
+	return os.WriteFile(StatePath(dir), content, 0644)
+}
+
+// Find returns the index of the tunnel routing to service, or -1.
+func Find(tunnels []State, service string) int {
+	for i := range tunnels {
+		if tunnels[i].Service == service {
+			return i
+		}
+	}
+	return -1
+}
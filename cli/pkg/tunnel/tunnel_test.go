@@ -0,0 +1,70 @@
+package tunnel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteRead(t *testing.T) {
+	dir := t.TempDir()
+	if tunnels, err := Read(dir); err != nil || tunnels != nil {
+		t.Fatalf("Read of a project without tunnels = %v, %v; want none", tunnels, err)
+	}
+
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	tunnels := []State{
+		{Provider: "cloudflare", URL: "https://a.trycloudflare.com", PID: 41, Port: 80, Created: created},
+		{Provider: "tailscale", URL: "https://orders.tail.ts.net", PID: 42, Service: "orders", Created: created},
+	}
+	if err := Write(dir, tunnels); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	got, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(got) != 2 || got[1] != tunnels[1] || !got[0].Created.Equal(created) {
+		t.Errorf("Read = %+v, want %+v", got, tunnels)
+	}
+	if i := Find(got, "orders"); i != 1 {
+		t.Errorf("Find(orders) = %d, want 1", i)
+	}
+	if i := Find(got, "payments"); i != -1 {
+		t.Errorf("Find(payments) = %d, want -1", i)
+	}
+	if got[0].Label() != "(default)" || got[1].Label() != "orders" {
+		t.Errorf("labels = %q, %q; want (default) and orders", got[0].Label(), got[1].Label())
+	}
+
+	if err := Write(dir, nil); err != nil {
+		t.Fatalf("Write of no tunnels: %v", err)
+	}
+	if _, err := os.Stat(StatePath(dir)); !os.IsNotExist(err) {
+		t.Errorf("%s is still there with no tunnels left", StatePath(dir))
+	}
+}
+
+func TestReadLegacy(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".kindling"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := "provider: cloudflare\nurl: https://b.trycloudflare.com\npid: 7\n"
+	if err := os.WriteFile(filepath.Join(dir, ".kindling", legacyStateFile), []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(dir)
+	if err != nil || len(got) != 1 || got[0].PID != 7 {
+		t.Fatalf("Read of a legacy tunnel.yaml = %+v, %v; want its tunnel", got, err)
+	}
+
+	// Writing carries the tunnel over into tunnels.yaml.
+	if err := Write(dir, got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".kindling", legacyStateFile)); !os.IsNotExist(err) {
+		t.Error("the legacy tunnel.yaml is still there after Write")
+	}
+}
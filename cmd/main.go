@@ -33,7 +33,9 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+	appsv1beta1 "github.com/jeffvincent/kindling/api/v1beta1"
 	"github.com/jeffvincent/kindling/internal/controller"
+	webhookv1alpha1 "github.com/jeffvincent/kindling/internal/webhook/v1alpha1"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -46,6 +48,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(appsv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(appsv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -53,8 +56,30 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var activatorAddr string
+	var activatorService string
+	var activatorPort int
+	var requireSigned bool
+	var signaturePublicKey, signatureTrustedRoot, signatureIdentity, signatureIssuer string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&activatorAddr, "activator-bind-address", ":8082",
+		"The address the activator, which proxies and wakes environments with spec.autoSleep, binds to. "+
+			"Set this to \"0\" to disable auto-sleep.")
+	flag.StringVar(&activatorService, "activator-service", "kindling-activator.kindling-system.svc.cluster.local",
+		"The DNS name of the Service in front of the activator.")
+	flag.IntVar(&activatorPort, "activator-service-port", 8082, "The port of the Service in front of the activator.")
+	flag.BoolVar(&requireSigned, "require-signed-environments", false,
+		"Only reconcile DevStagingEnvironments whose spec matches a manifest signed with kindling sign. "+
+			"Needs --signature-public-key, or --signature-trusted-root with --signature-identity and --signature-oidc-issuer.")
+	flag.StringVar(&signaturePublicKey, "signature-public-key", "",
+		"The PEM public key (cosign.pub) of key-based signatures.")
+	flag.StringVar(&signatureTrustedRoot, "signature-trusted-root", "",
+		"The Sigstore trusted_root.json with the Fulcio CAs and Rekor keys of keyless signatures.")
+	flag.StringVar(&signatureIdentity, "signature-identity", "",
+		"A regular expression the email or URI of a keyless signer's certificate must match in full.")
+	flag.StringVar(&signatureIssuer, "signature-oidc-issuer", "",
+		"The OIDC issuer keyless signers log in with, e.g. https://token.actions.githubusercontent.com.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -89,9 +114,32 @@ func main() {
 		os.Exit(1)
 	}
 
+	var activator *controller.Activator
+	if activatorAddr != "0" {
+		activator = &controller.Activator{
+			Client:      mgr.GetClient(),
+			Addr:        activatorAddr,
+			ServiceHost: activatorService,
+			Port:        int32(activatorPort),
+		}
+		if err := mgr.Add(activator); err != nil {
+			setupLog.Error(err, "unable to set up activator")
+			os.Exit(1)
+		}
+	}
+	var signatures *controller.SignatureVerifier
+	if requireSigned {
+		signatures, err = controller.LoadSignatureVerifier(signaturePublicKey, signatureTrustedRoot, signatureIdentity, signatureIssuer)
+		if err != nil {
+			setupLog.Error(err, "unable to set up signature verification")
+			os.Exit(1)
+		}
+	}
 	if err = (&controller.DevStagingEnvironmentReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		Activator:  activator,
+		Signatures: signatures,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "DevStagingEnvironment")
 		os.Exit(1)
@@ -103,6 +151,15 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "GithubActionRunnerPool")
 		os.Exit(1)
 	}
+	// The conversion webhook needs serving certificates (issued by
+	// cert-manager in-cluster); ENABLE_WEBHOOKS=false skips it for
+	// "make run", where only v1alpha1 objects can be served.
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookv1alpha1.SetupDevStagingEnvironmentWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "DevStagingEnvironment")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
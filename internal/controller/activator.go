@@ -0,0 +1,249 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+// activatorWakeTimeout is how long a request to a sleeping environment
+// waits for the app to come up before it gets a 503.
+const activatorWakeTimeout = 2 * time.Minute
+
+// Activator is the reverse proxy in front of the environments with
+// spec.autoSleep. It runs in the manager, behind the kindling-activator
+// Service, and knows the routes of the environments the reconciler has
+// tracked: requests are matched to one by host and path prefix, as the
+// Ingress matched them, and proxied to the app's Service once it runs.
+type Activator struct {
+	// Client reads environments and Deployments, and clears
+	// status.asleepSince to wake an environment.
+	Client client.Client
+	// Addr is the address the proxy listens on.
+	Addr string
+	// ServiceHost is the DNS name of the Service in front of Addr, which
+	// each environment's <name>-activator Service resolves to.
+	ServiceHost string
+	// Port is that Service's port.
+	Port int32
+
+	mu     sync.Mutex
+	routes map[types.NamespacedName]*activatorRoute
+}
+
+// activatorRoute is what the activator knows of one environment.
+type activatorRoute struct {
+	host, path string
+	upstream   string
+	proxy      *httputil.ReverseProxy
+
+	// lastActive is when a request last started or finished, or when the
+	// environment last started running; inflight counts the requests —
+	// WebSockets included — still open.
+	lastActive time.Time
+	inflight   int
+	running    bool
+}
+
+// track records cr's route, and restarts its idle clock when it starts
+// running. The first track starts it too, so an operator restart gives
+// every environment a full idleMinutes.
+func (a *Activator) track(cr *appsv1alpha1.DevStagingEnvironment) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.routes == nil {
+		a.routes = map[types.NamespacedName]*activatorRoute{}
+	}
+	key := types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}
+	route, ok := a.routes[key]
+	if !ok {
+		route = &activatorRoute{}
+		a.routes[key] = route
+	}
+	running := !paused(cr)
+	if !ok || (running && !route.running) {
+		route.lastActive = time.Now()
+	}
+	route.running = running
+
+	route.host = strings.ToLower(cr.Spec.Ingress.Host)
+	route.path = cr.Spec.Ingress.Path
+	if route.path == "" {
+		route.path = "/"
+	}
+	upstream := fmt.Sprintf("http://%s.%s.svc:%d", cr.Name, cr.Namespace, cr.Spec.Service.Port)
+	if upstream != route.upstream {
+		route.upstream = upstream
+		target, _ := url.Parse(upstream)
+		route.proxy = httputil.NewSingleHostReverseProxy(target)
+	}
+}
+
+// forget drops key's route, once it no longer sleeps.
+func (a *Activator) forget(key types.NamespacedName) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.routes, key)
+}
+
+// idleFor returns how long key has gone without a request; zero while one
+// is open, or before the first track.
+func (a *Activator) idleFor(key types.NamespacedName) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	route, ok := a.routes[key]
+	if !ok || route.inflight > 0 {
+		return 0
+	}
+	return time.Since(route.lastActive)
+}
+
+// begin matches a request to a route, preferring its exact host to a
+// route without one and then the longest path, and counts it as open.
+func (a *Activator) begin(host, path string) (types.NamespacedName, *httputil.ReverseProxy) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var (
+		best     types.NamespacedName
+		bestSpec *activatorRoute
+	)
+	for key, route := range a.routes {
+		if (route.host != "" && route.host != host) || !strings.HasPrefix(path, route.path) {
+			continue
+		}
+		if bestSpec != nil && (bestSpec.host != "" && route.host == "" ||
+			bestSpec.host == route.host && len(bestSpec.path) >= len(route.path)) {
+			continue
+		}
+		best, bestSpec = key, route
+	}
+	if bestSpec == nil {
+		return best, nil
+	}
+	bestSpec.inflight++
+	bestSpec.lastActive = time.Now()
+	return best, bestSpec.proxy
+}
+
+// end marks a request begun on key as finished.
+func (a *Activator) end(key types.NamespacedName) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if route, ok := a.routes[key]; ok {
+		route.inflight--
+		route.lastActive = time.Now()
+	}
+}
+
+// ServeHTTP wakes the environment a request is for when it sleeps, waits
+// for its app, and proxies the request to it.
+func (a *Activator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	host := req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	key, proxy := a.begin(strings.ToLower(host), req.URL.Path)
+	if proxy == nil {
+		http.Error(w, fmt.Sprintf("no environment with autoSleep serves %s%s", host, req.URL.Path), http.StatusNotFound)
+		return
+	}
+	defer a.end(key)
+
+	if err := a.wake(req.Context(), key); err != nil {
+		w.Header().Set("Retry-After", "10")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	proxy.ServeHTTP(w, req)
+}
+
+// wake clears key's asleepSince if it is set, and waits until its app has
+// an available pod.
+func (a *Activator) wake(ctx context.Context, key types.NamespacedName) error {
+	cr := &appsv1alpha1.DevStagingEnvironment{}
+	if err := a.Client.Get(ctx, key, cr); err != nil {
+		return fmt.Errorf("cannot read DevStagingEnvironment %s: %w", key.Name, err)
+	}
+	if cr.Spec.Paused {
+		return fmt.Errorf("%s is paused; kindling resume %s starts it", key.Name, key.Name)
+	}
+	if cr.Status.AsleepSince != nil {
+		logf.FromContext(ctx).Info("Waking environment", "devstagingenvironment", key)
+		patch := client.RawPatch(types.MergePatchType, []byte(`{"status":{"asleepSince":null}}`))
+		if err := a.Client.Status().Patch(ctx, cr, patch); err != nil {
+			return fmt.Errorf("cannot wake %s: %w", key.Name, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, activatorWakeTimeout)
+	defer cancel()
+	for {
+		deploy := &appsv1.Deployment{}
+		if err := a.Client.Get(ctx, key, deploy); err == nil && deploy.Status.AvailableReplicas > 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s is still waking up; try again in a moment", key.Name)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// Start serves the proxy until ctx is cancelled.
+func (a *Activator) Start(ctx context.Context) error {
+	server := &http.Server{Addr: a.Addr, Handler: a, ReadHeaderTimeout: 30 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+	logf.FromContext(ctx).Info("Starting activator", "addr", a.Addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// NeedLeaderElection is false: every replica proxies the requests it
+// gets, although only the leader's reconciler reads their idle clocks.
+func (a *Activator) NeedLeaderElection() bool {
+	return false
+}
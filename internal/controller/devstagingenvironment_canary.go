@@ -0,0 +1,271 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Canary — spec.canary, a second build of the app behind the same Ingress
+// ────────────────────────────────────────────────────────────────────────────
+//
+// The canary is the app built from a copy of the spec with the canary's
+// image, replicas, and env: a Deployment and a ClusterIP Service named
+// <name>-canary, and under ingress-nginx a canary Ingress on the app's
+// host and path that takes spec.canary.weight percent of its requests and
+// those spec.canary.header picks.
+//
+// Canary pods carry the app's app.kubernetes.io/name, so NetworkPolicies
+// and dependencies treat them as the app, but their own
+// app.kubernetes.io/instance, so the app's Deployment and Service leave
+// them alone.
+
+const canaryComponent = "canary"
+
+// nginx canary annotations; a canary Ingress adds its routing to the
+// Ingress of the same host and path without the canary annotation.
+const (
+	nginxCanaryAnnotation            = "nginx.ingress.kubernetes.io/canary"
+	nginxCanaryWeightAnnotation      = "nginx.ingress.kubernetes.io/canary-weight"
+	nginxCanaryHeaderAnnotation      = "nginx.ingress.kubernetes.io/canary-by-header"
+	nginxCanaryHeaderValueAnnotation = "nginx.ingress.kubernetes.io/canary-by-header-value"
+)
+
+func canaryName(crName string) string { return crName + "-" + canaryComponent }
+
+// canaryEnabled reports whether cr runs a canary. A scheduled app serves
+// no traffic to split.
+func canaryEnabled(cr *appsv1alpha1.DevStagingEnvironment) bool {
+	return cr.Spec.Canary != nil && !scheduled(cr)
+}
+
+// canaryRouted reports whether the Ingress splits traffic to cr's canary,
+// which only ingress-nginx does from Ingress annotations.
+func canaryRouted(cr *appsv1alpha1.DevStagingEnvironment) bool {
+	return canaryEnabled(cr) && cr.Spec.Ingress != nil && cr.Spec.Ingress.Enabled &&
+		ingressProvider(cr.Spec.Ingress.IngressClassName) == ingressProviderNginx
+}
+
+// labelsForCanary returns the labels of the canary's pods, which its
+// Deployment and Service select on.
+func labelsForCanary(cr *appsv1alpha1.DevStagingEnvironment) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       cr.Name,
+		"app.kubernetes.io/managed-by": "devstagingenvironment-operator",
+		"app.kubernetes.io/instance":   canaryName(cr.Name),
+		"app.kubernetes.io/component":  canaryComponent,
+	}
+}
+
+// canaryObjectLabels adds part-of to the canary's labels, so that it is
+// listed with its environment. Its pods go without: the dependencies'
+// NetworkPolicy guards part-of pods, and would shut the canary off from
+// the ingress controller.
+func canaryObjectLabels(cr *appsv1alpha1.DevStagingEnvironment) map[string]string {
+	labels := labelsForCanary(cr)
+	labels["app.kubernetes.io/part-of"] = cr.Name
+	return labels
+}
+
+// canaryCR returns the copy of cr the canary is built from: the app with
+// the canary's image, replicas, and env.
+func canaryCR(cr *appsv1alpha1.DevStagingEnvironment) *appsv1alpha1.DevStagingEnvironment {
+	c := cr.DeepCopy()
+	d := &c.Spec.Deployment
+	d.Image = cr.Spec.Canary.Image
+	d.Replicas = cr.Spec.Canary.Replicas
+	d.Env = mergeEnvVars(d.Env, cr.Spec.Canary.Env)
+	return c
+}
+
+func (r *DevStagingEnvironmentReconciler) buildCanaryDeployment(cr *appsv1alpha1.DevStagingEnvironment) *appsv1.Deployment {
+	deploy := r.buildDeployment(canaryCR(cr))
+	deploy.Name = canaryName(cr.Name)
+	deploy.Labels = canaryObjectLabels(cr)
+	deploy.Spec.Selector = &metav1.LabelSelector{MatchLabels: labelsForCanary(cr)}
+	deploy.Spec.Template.Labels = labelsForCanary(cr)
+	return deploy
+}
+
+func (r *DevStagingEnvironmentReconciler) buildCanaryService(cr *appsv1alpha1.DevStagingEnvironment) *corev1.Service {
+	svc := r.buildService(cr)
+	svc.Name = canaryName(cr.Name)
+	svc.Labels = canaryObjectLabels(cr)
+	svc.Spec.Selector = labelsForCanary(cr)
+	// Only the Ingress reaches the canary from outside; a node port of its
+	// own would take one the app's Service may want.
+	svc.Spec.Type = corev1.ServiceTypeClusterIP
+	svc.Spec.Ports[0].NodePort = 0
+	svc.Annotations[specHashAnnotation] = computeSpecHash(struct {
+		Hash   string
+		Canary bool
+	}{svc.Annotations[specHashAnnotation], true})
+	return svc
+}
+
+// buildCanaryIngress returns the app's Ingress pointed at the canary's
+// Service and marked as ingress-nginx's canary for its route.
+func (r *DevStagingEnvironmentReconciler) buildCanaryIngress(cr *appsv1alpha1.DevStagingEnvironment) *networkingv1.Ingress {
+	ing := r.buildIngress(cr)
+	ing.Name = canaryName(cr.Name)
+	ing.Labels = canaryObjectLabels(cr)
+	ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service = &networkingv1.IngressServiceBackend{
+		Name: canaryName(cr.Name),
+		Port: networkingv1.ServiceBackendPort{Number: cr.Spec.Service.Port},
+	}
+
+	canary := cr.Spec.Canary
+	ing.Annotations[nginxCanaryAnnotation] = "true"
+	ing.Annotations[nginxCanaryWeightAnnotation] = strconv.Itoa(int(canary.Weight))
+	if canary.Header != "" {
+		ing.Annotations[nginxCanaryHeaderAnnotation] = canary.Header
+		if canary.HeaderValue != "" {
+			ing.Annotations[nginxCanaryHeaderValueAnnotation] = canary.HeaderValue
+		}
+	}
+	ing.Annotations[specHashAnnotation] = computeSpecHash(struct {
+		Ingress *appsv1alpha1.IngressSpec
+		Canary  *appsv1alpha1.CanarySpec
+	}{cr.Spec.Ingress, canary})
+	return ing
+}
+
+// reconcileCanary runs cr's canary and routes its share of the Ingress's
+// requests to it, or removes them once spec.canary is gone. The canary
+// Ingress steps aside with the app's when another component holds the
+// route.
+func (r *DevStagingEnvironmentReconciler) reconcileCanary(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment, network networkProblems) error {
+	wanted := canaryEnabled(cr)
+	routed := canaryRouted(cr) && !network.has(ReasonIngressPathConflict)
+	if wanted && cr.Spec.Ingress != nil && cr.Spec.Ingress.Enabled && !canaryRouted(cr) {
+		r.recordEvent(cr, "Warning", "CanaryNotRouted",
+			"The %s ingress controller can't split traffic from Ingress annotations; the canary is reachable through Service %s only",
+			ingressProvider(cr.Spec.Ingress.IngressClassName), canaryName(cr.Name))
+	}
+
+	var (
+		deploy = &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: canaryName(cr.Name), Namespace: cr.Namespace}}
+		svc    = &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: canaryName(cr.Name), Namespace: cr.Namespace}}
+		ing    = &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: canaryName(cr.Name), Namespace: cr.Namespace}}
+	)
+	if wanted {
+		deploy = r.buildCanaryDeployment(cr)
+		svc = r.buildCanaryService(cr)
+	}
+	if routed {
+		ing = r.buildCanaryIngress(cr)
+	}
+
+	if err := r.reconcileCanaryObject(ctx, cr, deploy, &appsv1.Deployment{}, wanted, func(existing client.Object) {
+		existing.(*appsv1.Deployment).Spec = deploy.Spec
+	}); err != nil {
+		return err
+	}
+	if err := r.reconcileCanaryObject(ctx, cr, svc, &corev1.Service{}, wanted, func(existing client.Object) {
+		e := existing.(*corev1.Service)
+		clusterIP := e.Spec.ClusterIP // immutable
+		e.Spec = svc.Spec
+		e.Spec.ClusterIP = clusterIP
+	}); err != nil {
+		return err
+	}
+	return r.reconcileCanaryObject(ctx, cr, ing, &networkingv1.Ingress{}, routed, func(existing client.Object) {
+		e := existing.(*networkingv1.Ingress)
+		e.Spec = ing.Spec
+		e.Annotations = ing.Annotations
+	})
+}
+
+// reconcileCanaryObject creates desired, brings existing up to date with
+// update when desired's spec hash changed, or deletes it when it isn't
+// wanted.
+func (r *DevStagingEnvironmentReconciler) reconcileCanaryObject(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment,
+	desired, existing client.Object, wanted bool, update func(existing client.Object)) error {
+	logger := log.FromContext(ctx)
+	kind := kindOf(existing)
+
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+	if errors.IsNotFound(err) {
+		if !wanted {
+			return nil
+		}
+		if err := controllerutil.SetControllerReference(cr, desired, r.Scheme); err != nil {
+			return err
+		}
+		logger.Info("Creating canary "+kind, "name", desired.GetName())
+		if err := r.Create(ctx, desired); err != nil {
+			return err
+		}
+		r.recordEvent(cr, "Normal", "Canary"+kind+"Created", "Created canary %s %s", kind, desired.GetName())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(existing, cr) {
+		return nil
+	}
+	if !wanted {
+		logger.Info("Deleting canary "+kind, "name", existing.GetName())
+		if err := client.IgnoreNotFound(r.Delete(ctx, existing)); err != nil {
+			return err
+		}
+		r.recordEvent(cr, "Normal", "Canary"+kind+"Deleted", "Deleted canary %s %s", kind, existing.GetName())
+		return nil
+	}
+	hash := desired.GetAnnotations()[specHashAnnotation]
+	if existing.GetAnnotations()[specHashAnnotation] == hash {
+		return nil
+	}
+	update(existing)
+	annotations := existing.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[specHashAnnotation] = hash
+	existing.SetAnnotations(annotations)
+	existing.SetLabels(desired.GetLabels())
+	logger.Info("Updating canary "+kind, "name", existing.GetName())
+	return r.Update(ctx, existing)
+}
+
+// kindOf names the kind of the objects reconcileCanaryObject handles.
+func kindOf(obj client.Object) string {
+	switch obj.(type) {
+	case *appsv1.Deployment:
+		return "Deployment"
+	case *corev1.Service:
+		return "Service"
+	case *networkingv1.Ingress:
+		return "Ingress"
+	}
+	return "object"
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+var _ = Describe("canary", func() {
+	var r *DevStagingEnvironmentReconciler
+
+	BeforeEach(func() {
+		r = &DevStagingEnvironmentReconciler{}
+	})
+
+	newCanaryDSE := func() *appsv1alpha1.DevStagingEnvironment {
+		cr := newTestDSE("test-app")
+		cr.Spec.Deployment.Env = []corev1.EnvVar{{Name: "FEATURE_X", Value: "off"}, {Name: "LOG_LEVEL", Value: "info"}}
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: "app.localhost", Path: "/"}
+		cr.Spec.Canary = &appsv1alpha1.CanarySpec{
+			Image:  "my-image:canary",
+			Weight: 20,
+			Env:    []corev1.EnvVar{{Name: "FEATURE_X", Value: "on"}},
+		}
+		return cr
+	}
+
+	It("runs the canary image with its own env beside the app", func() {
+		cr := newCanaryDSE()
+		deploy := r.buildCanaryDeployment(cr)
+
+		Expect(deploy.Name).To(Equal("test-app-canary"))
+		container := deploy.Spec.Template.Spec.Containers[0]
+		Expect(container.Image).To(Equal("my-image:canary"))
+		Expect(findEnvVar(container.Env, "FEATURE_X")).To(Equal("on"))
+		Expect(findEnvVar(container.Env, "LOG_LEVEL")).To(Equal("info"))
+		Expect(findEnvVar(r.buildDeployment(cr).Spec.Template.Spec.Containers[0].Env, "FEATURE_X")).To(Equal("off"))
+	})
+
+	It("keeps the app's Deployment and Service off the canary's pods", func() {
+		cr := newCanaryDSE()
+		canaryPods := labels.Set(r.buildCanaryDeployment(cr).Spec.Template.Labels)
+
+		Expect(labels.SelectorFromSet(r.buildDeployment(cr).Spec.Selector.MatchLabels).Matches(canaryPods)).To(BeFalse())
+		Expect(labels.SelectorFromSet(r.buildService(cr).Spec.Selector).Matches(canaryPods)).To(BeFalse())
+		Expect(labels.SelectorFromSet(r.buildCanaryService(cr).Spec.Selector).Matches(canaryPods)).To(BeTrue())
+	})
+
+	It("splits the app's route by weight and header under ingress-nginx", func() {
+		cr := newCanaryDSE()
+		cr.Spec.Canary.Header = "X-Canary"
+		ing := r.buildCanaryIngress(cr)
+		app := r.buildIngress(cr)
+
+		Expect(ing.Spec.Rules[0].Host).To(Equal(app.Spec.Rules[0].Host))
+		Expect(ing.Spec.Rules[0].HTTP.Paths[0].Path).To(Equal(app.Spec.Rules[0].HTTP.Paths[0].Path))
+		Expect(ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name).To(Equal("test-app-canary"))
+		Expect(ing.Annotations).To(HaveKeyWithValue(nginxCanaryAnnotation, "true"))
+		Expect(ing.Annotations).To(HaveKeyWithValue(nginxCanaryWeightAnnotation, "20"))
+		Expect(ing.Annotations).To(HaveKeyWithValue(nginxCanaryHeaderAnnotation, "X-Canary"))
+		Expect(ing.Annotations).NotTo(HaveKey(nginxCanaryHeaderValueAnnotation))
+		Expect(app.Annotations).NotTo(HaveKey(nginxCanaryAnnotation))
+	})
+
+	It("changes the canary Ingress's hash when the weight changes", func() {
+		cr := newCanaryDSE()
+		before := r.buildCanaryIngress(cr).Annotations[specHashAnnotation]
+		cr.Spec.Canary.Weight = 50
+
+		Expect(r.buildCanaryIngress(cr).Annotations[specHashAnnotation]).NotTo(Equal(before))
+	})
+
+	It("routes only under ingress-nginx", func() {
+		cr := newCanaryDSE()
+		Expect(canaryRouted(cr)).To(BeTrue())
+
+		traefik := "traefik"
+		cr.Spec.Ingress.IngressClassName = &traefik
+		Expect(canaryRouted(cr)).To(BeFalse())
+		Expect(canaryEnabled(cr)).To(BeTrue())
+	})
+
+	It("guards the canary's pods with the app's NetworkPolicy", func() {
+		cr := newCanaryDSE()
+		cr.Spec.NetworkPolicies = appsv1alpha1.NetworkPoliciesStrict
+		guarded := selectorOf(&buildAppNetworkPolicy(cr, nil, "").Spec.PodSelector)
+
+		Expect(guarded.Matches(labels.Set(labelsForCR(cr)))).To(BeTrue())
+		Expect(guarded.Matches(labels.Set(labelsForCanary(cr)))).To(BeTrue())
+		Expect(selectorOf(&buildDependenciesNetworkPolicy(cr).Spec.PodSelector).Matches(labels.Set(labelsForCanary(cr)))).To(BeFalse())
+	})
+})
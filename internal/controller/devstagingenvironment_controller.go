@@ -22,23 +22,31 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
 )
@@ -48,6 +56,15 @@ type DevStagingEnvironmentReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+
+	// Activator proxies the Ingress of environments with spec.autoSleep
+	// and tells reconcile how long they have been idle. Without one,
+	// autoSleep is ignored.
+	Activator *Activator
+
+	// Signatures, when set, keeps environments that don't match a signed
+	// manifest from being reconciled (devstagingenvironment_signature.go).
+	Signatures *SignatureVerifier
 }
 
 const specHashAnnotation = "apps.example.com/spec-hash"
@@ -57,10 +74,16 @@ const specHashAnnotation = "apps.example.com/spec-hash"
 //+kubebuilder:rbac:groups=apps.example.com,resources=devstagingenvironments/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile reads the state of the cluster for a DevStagingEnvironment object and makes changes
 // to bring the cluster state closer to the desired state defined in the CR spec.
@@ -73,78 +96,143 @@ func (r *DevStagingEnvironmentReconciler) Reconcile(ctx context.Context, req ctr
 		if errors.IsNotFound(err) {
 			// CR was deleted — child objects are garbage-collected via OwnerReferences
 			logger.Info("DevStagingEnvironment resource not found, likely deleted")
+			r.Activator.forget(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
 	}
 
+	// With --require-signed-environments, leave an environment that
+	// doesn't match a signed manifest alone. This checks the spec as
+	// stored, before anything below resolves it.
+	if r.Signatures != nil {
+		if ok, err := r.checkSignature(ctx, cr); !ok {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Resolve ${KINDLING_TUNNEL_URL} and friends in env values, and the
+	// host of a tunnel: true Ingress. Only this in-memory copy changes:
+	// status updates don't write the spec back.
+	if err := r.resolveTunnel(ctx, cr); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Put an idle environment with spec.autoSleep to sleep, before the
+	// workloads are built from whether it is paused.
+	idleLeft := r.reconcileSleep(ctx, cr)
+
+	// Find the ingress route and node port someone else already holds, so
+	// the Service and Ingress steps leave them alone.
+	network, err := r.checkNetwork(ctx, cr)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// ── Step 2: Reconcile the Deployment ───────────────────────────────
-	if err := r.reconcileDeployment(ctx, cr); err != nil {
-		r.recordEvent(cr, "Warning", "ReconcileFailed", "Deployment reconciliation failed: %v", err)
-		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
-			Type:    "DeploymentReady",
+	if err := timeStep("deployment", func() error { return r.reconcileDeployment(ctx, cr) }); err != nil {
+		r.setCondition(cr, metav1.Condition{
+			Type:    componentsReadyCondition,
 			Status:  metav1.ConditionFalse,
-			Reason:  "ReconcileFailed",
-			Message: err.Error(),
+			Reason:  "DeploymentFailed",
+			Message: fmt.Sprintf("Deployment reconciliation failed: %v", err),
 		})
 		_ = r.Status().Update(ctx, cr)
 		return ctrl.Result{}, err
 	}
 
 	// ── Step 3: Reconcile the Service ──────────────────────────────────
-	if err := r.reconcileService(ctx, cr); err != nil {
-		r.recordEvent(cr, "Warning", "ReconcileFailed", "Service reconciliation failed: %v", err)
-		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
-			Type:    "ServiceReady",
+	if err := timeStep("service", func() error { return r.reconcileService(ctx, cr, network) }); err != nil {
+		r.setCondition(cr, metav1.Condition{
+			Type:    componentsReadyCondition,
 			Status:  metav1.ConditionFalse,
-			Reason:  "ReconcileFailed",
-			Message: err.Error(),
+			Reason:  "ServiceFailed",
+			Message: fmt.Sprintf("Service reconciliation failed: %v", err),
 		})
 		_ = r.Status().Update(ctx, cr)
 		return ctrl.Result{}, err
 	}
 
 	// ── Step 4: Reconcile the Ingress (if enabled) ─────────────────────
-	if err := r.reconcileIngress(ctx, cr); err != nil {
-		r.recordEvent(cr, "Warning", "ReconcileFailed", "Ingress reconciliation failed: %v", err)
-		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
-			Type:    "IngressReady",
+	if err := timeStep("ingress", func() error {
+		if err := r.reconcileActivatorService(ctx, cr); err != nil {
+			return err
+		}
+		return r.reconcileIngress(ctx, cr, network)
+	}); err != nil {
+		r.setCondition(cr, metav1.Condition{
+			Type:    ingressReadyCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "ReconcileFailed",
+			Message: fmt.Sprintf("Ingress reconciliation failed: %v", err),
+		})
+		_ = r.Status().Update(ctx, cr)
+		return ctrl.Result{}, err
+	}
+
+	// ── Step 5: Reconcile the canary (spec.canary) ────────────────────
+	if err := timeStep("canary", func() error { return r.reconcileCanary(ctx, cr, network) }); err != nil {
+		r.recordEvent(cr, "Warning", "CanaryFailed", "Canary reconciliation failed: %v", err)
+		return ctrl.Result{}, err
+	}
+
+	// ── Step 6: Reconcile Dependencies (databases, caches, etc.) ──────
+	if err := timeStep("dependencies", func() error { return r.reconcileDependencies(ctx, cr) }); err != nil {
+		r.setCondition(cr, metav1.Condition{
+			Type:    dependenciesReadyCondition,
 			Status:  metav1.ConditionFalse,
 			Reason:  "ReconcileFailed",
-			Message: err.Error(),
+			Message: fmt.Sprintf("Dependencies reconciliation failed: %v", err),
 		})
 		_ = r.Status().Update(ctx, cr)
 		return ctrl.Result{}, err
 	}
 
-	// ── Step 5: Reconcile Dependencies (databases, caches, etc.) ──────
-	if err := r.reconcileDependencies(ctx, cr); err != nil {
-		r.recordEvent(cr, "Warning", "ReconcileFailed", "Dependencies reconciliation failed: %v", err)
-		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
-			Type:    "DependenciesReady",
+	// ── Step 7: Reconcile the tracing collector ───────────────────────
+	if err := timeStep("tracing", func() error { return r.reconcileTracing(ctx, cr) }); err != nil {
+		r.recordEvent(cr, "Warning", "TracingFailed", "Tracing collector reconciliation failed: %v", err)
+		return ctrl.Result{}, err
+	}
+
+	// ── Step 8: Reconcile NetworkPolicies (networkPolicies: strict) ───
+	if err := timeStep("networkpolicies", func() error { return r.reconcileNetworkPolicies(ctx, cr) }); err != nil {
+		r.recordEvent(cr, "Warning", "NetworkPolicyFailed", "NetworkPolicy reconciliation failed: %v", err)
+		return ctrl.Result{}, err
+	}
+
+	// ── Step 9: Run Jobs (migrations, one-off tasks) ──────────────────
+	if err := timeStep("jobs", func() error { return r.reconcileJobs(ctx, cr) }); err != nil {
+		r.setCondition(cr, metav1.Condition{
+			Type:    jobsCompleteCondition,
 			Status:  metav1.ConditionFalse,
 			Reason:  "ReconcileFailed",
-			Message: err.Error(),
+			Message: fmt.Sprintf("Jobs reconciliation failed: %v", err),
 		})
 		_ = r.Status().Update(ctx, cr)
 		return ctrl.Result{}, err
 	}
 
-	// ── Step 6: Update status ──────────────────────────────────────────
-	if err := r.updateStatus(ctx, cr); err != nil {
+	// ── Step 10: Update status ─────────────────────────────────────────
+	if err := timeStep("status", func() error { return r.updateStatus(ctx, cr, network) }); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if paused(cr) {
+		logger.Info("Environment is paused")
+		return ctrl.Result{}, nil
+	}
+
 	// If status is not fully ready yet, requeue to pick up child resource
 	// status changes (e.g. Deployment replicas becoming available).
-	if !cr.Status.DeploymentReady || !cr.Status.ServiceReady || !cr.Status.DependenciesReady {
+	if !cr.Status.DeploymentReady || !serviceReady(cr) || !cr.Status.DependenciesReady {
 		logger.Info("Not all child resources are ready yet, requeueing")
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	}
 
 	logger.Info("Reconciliation complete")
 	r.recordEvent(cr, "Normal", "ReconcileComplete", "All resources reconciled successfully")
-	return ctrl.Result{}, nil
+	// Come back when an environment with autoSleep would have gone idle.
+	return ctrl.Result{RequeueAfter: idleLeft}, nil
 }
 
 // ────────────────────────────────────────────────────────────────────────────
@@ -153,6 +241,18 @@ func (r *DevStagingEnvironmentReconciler) Reconcile(ctx context.Context, req ctr
 
 func (r *DevStagingEnvironmentReconciler) reconcileDeployment(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
 	logger := log.FromContext(ctx)
+	if err := r.reconcileVolumes(ctx, cr); err != nil {
+		return err
+	}
+	if scheduled(cr) {
+		if err := r.deleteIfExists(ctx, cr, &appsv1.Deployment{}); err != nil {
+			return err
+		}
+		return r.reconcileCronJob(ctx, cr)
+	}
+	if err := r.deleteIfExists(ctx, cr, &batchv1.CronJob{}); err != nil {
+		return err
+	}
 	desired := r.buildDeployment(cr)
 
 	// Set the CR as the owner so garbage collection cleans up if the CR is deleted
@@ -164,8 +264,16 @@ func (r *DevStagingEnvironmentReconciler) reconcileDeployment(ctx context.Contex
 	err := r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			if pending := r.pendingUpstreams(ctx, cr); len(pending) > 0 {
+				logger.Info("Waiting for upstream components before creating the Deployment", "upstreams", pending)
+				return nil
+			}
 			logger.Info("Creating Deployment", "name", desired.Name)
-			return r.Create(ctx, desired)
+			if err := r.Create(ctx, desired); err != nil {
+				return err
+			}
+			r.recordEvent(cr, "Normal", "DeploymentCreated", "Created Deployment %s with image %s", desired.Name, cr.Spec.Deployment.Image)
+			return nil
 		}
 		return err
 	}
@@ -184,30 +292,27 @@ func (r *DevStagingEnvironmentReconciler) reconcileDeployment(ctx context.Contex
 	}
 	existing.Annotations[specHashAnnotation] = desiredHash
 	logger.Info("Updating Deployment", "name", desired.Name)
-	return r.Update(ctx, existing)
+	if err := r.Update(ctx, existing); err != nil {
+		return err
+	}
+	r.recordEvent(cr, "Normal", "DeploymentUpdated", "Updated Deployment %s with image %s", desired.Name, cr.Spec.Deployment.Image)
+	return nil
 }
 
 func (r *DevStagingEnvironmentReconciler) buildDeployment(cr *appsv1alpha1.DevStagingEnvironment) *appsv1.Deployment {
 	labels := labelsForCR(cr)
 	spec := cr.Spec.Deployment
 
-	// Merge dependency connection strings with user-provided env vars.
-	// Dependency vars (DATABASE_URL, REDIS_URL, etc.) must come first so that
-	// user env vars can reference them via Kubernetes $(VAR) expansion —
-	// e.g. PG_DSN: "$(DATABASE_URL)" only resolves if DATABASE_URL is
-	// defined earlier in the env list.
-	var allEnv []corev1.EnvVar
-	for _, dep := range cr.Spec.Dependencies {
-		allEnv = append(allEnv, buildDependencyConnectionEnvVars(cr.Name, dep)...)
-	}
-	allEnv = append(allEnv, spec.Env...)
+	allEnv := buildAppEnvVars(cr)
 
 	container := corev1.Container{
-		Name:    cr.Name,
-		Image:   spec.Image,
-		Command: spec.Command,
-		Args:    spec.Args,
-		Env:     allEnv,
+		Name:            cr.Name,
+		Image:           spec.Image,
+		ImagePullPolicy: spec.ImagePullPolicy,
+		Command:         spec.Command,
+		Args:            spec.Args,
+		Env:             allEnv,
+		EnvFrom:         spec.EnvFrom,
 		Ports: []corev1.ContainerPort{{
 			Name:          "http",
 			ContainerPort: spec.Port,
@@ -222,13 +327,46 @@ func (r *DevStagingEnvironmentReconciler) buildDeployment(cr *appsv1alpha1.DevSt
 
 	// Wire up health checks if specified
 	if spec.HealthCheck != nil {
-		probe := buildHTTPProbe(spec.HealthCheck, spec.Port)
+		probe := buildProbe(spec.HealthCheck, spec.Port)
 		container.LivenessProbe = probe.DeepCopy()
 		container.ReadinessProbe = probe.DeepCopy()
 	}
 
-	// Build init containers that wait for each dependency to accept TCP connections
+	// Build init containers that wait for each dependency to accept TCP
+	// connections, then the user's own, which can count on them being up
 	initContainers := buildDependencyWaitInitContainers(cr)
+	for _, ic := range spec.InitContainers {
+		image, pullPolicy := ic.Image, corev1.PullPolicy("")
+		if image == "" {
+			image, pullPolicy = spec.Image, spec.ImagePullPolicy
+		}
+		initContainers = append(initContainers, corev1.Container{
+			Name:            ic.Name,
+			Image:           image,
+			ImagePullPolicy: pullPolicy,
+			Command:         ic.Command,
+			Args:            ic.Args,
+			Env:             mergeEnvVars(allEnv, ic.Env),
+			EnvFrom:         spec.EnvFrom,
+		})
+	}
+
+	// Sidecars are restartable init containers, so they start before the
+	// app container and don't keep a scheduled app's run from finishing
+	always := corev1.ContainerRestartPolicyAlways
+	for _, sc := range spec.Sidecars {
+		initContainers = append(initContainers, corev1.Container{
+			Name:          sc.Name,
+			Image:         sc.Image,
+			Command:       sc.Command,
+			Args:          sc.Args,
+			Env:           sc.Env,
+			RestartPolicy: &always,
+		})
+	}
+
+	volumes, mounts := buildAppVolumes(cr)
+	container.VolumeMounts = mounts
 
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -236,34 +374,448 @@ func (r *DevStagingEnvironmentReconciler) buildDeployment(cr *appsv1alpha1.DevSt
 			Namespace: cr.Namespace,
 			Labels:    labels,
 			Annotations: map[string]string{
-				specHashAnnotation: computeSpecHash(cr.Spec),
+				// cr.Spec doesn't change when autoSleep puts cr to
+				// sleep; pausedHash does.
+				specHashAnnotation: pausedHash(cr, computeSpecHash(cr.Spec)),
 			},
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: spec.Replicas,
+			Replicas: runningReplicas(cr, spec.Replicas),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      labels,
+					Annotations: tracingPodAnnotations(cr),
 				},
 				Spec: corev1.PodSpec{
 					InitContainers: initContainers,
 					Containers:     []corev1.Container{container},
+					Volumes:        volumes,
+					NodeSelector:   spec.NodeSelector,
+					Affinity:       spec.Affinity,
+				},
+			},
+		},
+	}
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// CronJob — an app with spec.deployment.schedule runs as a periodic worker
+// ────────────────────────────────────────────────────────────────────────────
+
+// scheduled reports whether the app runs as a CronJob rather than a
+// Deployment.
+func scheduled(cr *appsv1alpha1.DevStagingEnvironment) bool {
+	return cr.Spec.Deployment.Schedule != ""
+}
+
+// serviceReady reports whether the app's Service is in place, or isn't
+// needed because the app is scheduled.
+func serviceReady(cr *appsv1alpha1.DevStagingEnvironment) bool {
+	return cr.Status.ServiceReady || scheduled(cr)
+}
+
+func (r *DevStagingEnvironmentReconciler) reconcileCronJob(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
+	logger := log.FromContext(ctx)
+	desired := r.buildCronJob(cr)
+	if err := controllerutil.SetControllerReference(cr, desired, r.Scheme); err != nil {
+		return err
+	}
+
+	existing := &batchv1.CronJob{}
+	if err := r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if pending := r.pendingUpstreams(ctx, cr); len(pending) > 0 {
+			logger.Info("Waiting for upstream components before creating the CronJob", "upstreams", pending)
+			return nil
+		}
+		logger.Info("Creating CronJob", "name", desired.Name)
+		if err := r.Create(ctx, desired); err != nil {
+			return err
+		}
+		r.recordEvent(cr, "Normal", "CronJobCreated", "Created CronJob %s running %s on %q", desired.Name, cr.Spec.Deployment.Image, cr.Spec.Deployment.Schedule)
+		return nil
+	}
+
+	if existing.Annotations[specHashAnnotation] == desired.Annotations[specHashAnnotation] {
+		logger.V(1).Info("CronJob already up to date, skipping", "name", desired.Name)
+		return nil
+	}
+	// Unlike a Job's, a CronJob's template can change; runs already
+	// started finish with the old one.
+	existing.Spec = desired.Spec
+	if existing.Annotations == nil {
+		existing.Annotations = make(map[string]string)
+	}
+	existing.Annotations[specHashAnnotation] = desired.Annotations[specHashAnnotation]
+	logger.Info("Updating CronJob", "name", desired.Name)
+	if err := r.Update(ctx, existing); err != nil {
+		return err
+	}
+	r.recordEvent(cr, "Normal", "CronJobUpdated", "Updated CronJob %s with image %s", desired.Name, cr.Spec.Deployment.Image)
+	return nil
+}
+
+// buildCronJob runs the app's pod on spec.deployment.schedule. The pod is
+// the Deployment's — same container, env, and dependency waits — without
+// probes, since each run is expected to exit. A run that is still going
+// when the next one is due makes the next one wait.
+func (r *DevStagingEnvironmentReconciler) buildCronJob(cr *appsv1alpha1.DevStagingEnvironment) *batchv1.CronJob {
+	template := r.buildDeployment(cr).Spec.Template
+	template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	for i := range template.Spec.Containers {
+		template.Spec.Containers[i].LivenessProbe = nil
+		template.Spec.Containers[i].ReadinessProbe = nil
+	}
+	backoffLimit := int32(defaultJobBackoffLimit)
+	suspend := paused(cr)
+
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cr.Name,
+			Namespace: cr.Namespace,
+			Labels:    labelsForCR(cr),
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:          cr.Spec.Deployment.Schedule,
+			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			Suspend:           &suspend,
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template:     template,
 				},
 			},
 		},
 	}
+	cronJob.Annotations = map[string]string{specHashAnnotation: computeSpecHash(cronJob.Spec)}
+	return cronJob
+}
+
+// deleteIfExists deletes the app's object of obj's kind, named after the
+// CR, when the CR no longer calls for it — the Deployment of an app that
+// became scheduled, or the other way round.
+func (r *DevStagingEnvironmentReconciler) deleteIfExists(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment, obj client.Object) error {
+	if err := r.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(obj, cr) {
+		return nil
+	}
+	log.FromContext(ctx).Info("Deleting unused app object", "kind", fmt.Sprintf("%T", obj), "name", cr.Name)
+	return client.IgnoreNotFound(r.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+}
+
+// buildAppEnvVars merges dependency connection strings with user-provided
+// env vars. Dependency vars (DATABASE_URL, REDIS_URL, etc.) must come first
+// so that user env vars can reference them via Kubernetes $(VAR) expansion —
+// e.g. PG_DSN: "$(DATABASE_URL)" only resolves if DATABASE_URL is defined
+// earlier in the env list.
+func buildAppEnvVars(cr *appsv1alpha1.DevStagingEnvironment) []corev1.EnvVar {
+	var allEnv []corev1.EnvVar
+	for _, dep := range cr.Spec.Dependencies {
+		allEnv = append(allEnv, buildDependencyConnectionEnvVars(cr.Name, dep)...)
+	}
+	if tracingEnabled(cr) {
+		// The shared collector replaces a jaeger dependency's endpoint.
+		allEnv = mergeEnvVars(allEnv, buildTracingEnvVars(cr))
+	}
+	return append(allEnv, cr.Spec.Deployment.Env...)
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// Tunnels — env values and Ingresses that follow the kindling expose tunnel
+// ────────────────────────────────────────────────────────────────────────────
+
+// The kindling CLI records its running tunnels in this ConfigMap: "url" and
+// "hostname" for the default tunnel, "<name>.url" and "<name>.hostname" for
+// a tunnel to one DevStagingEnvironment.
+const (
+	tunnelConfigMapName      = "kindling-tunnel"
+	tunnelConfigMapNamespace = "default"
+)
+
+// tunnelHostAnnotation marks an Ingress whose host the operator took from
+// the tunnel, recording that host.
+const tunnelHostAnnotation = "kindling.dev/tunnel-host"
+
+// tunnelTemplatePattern matches the placeholders env values may contain.
+var tunnelTemplatePattern = regexp.MustCompile(`\$\{(KINDLING_TUNNEL_URL|KINDLING_TUNNEL_HOST)\}`)
+
+// usesTunnelTemplates reports whether any env value of the app, its init
+// containers, or its jobs contains a tunnel placeholder.
+func usesTunnelTemplates(cr *appsv1alpha1.DevStagingEnvironment) bool {
+	envs := [][]corev1.EnvVar{cr.Spec.Deployment.Env}
+	for _, ic := range cr.Spec.Deployment.InitContainers {
+		envs = append(envs, ic.Env)
+	}
+	for _, job := range cr.Spec.Jobs {
+		envs = append(envs, job.Env)
+	}
+	for _, env := range envs {
+		for _, e := range env {
+			if tunnelTemplatePattern.MatchString(e.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// followsTunnel reports whether cr depends on the kindling-tunnel
+// ConfigMap: through env placeholders, or an Ingress with tunnel: true.
+func followsTunnel(cr *appsv1alpha1.DevStagingEnvironment) bool {
+	ing := cr.Spec.Ingress
+	return usesTunnelTemplates(cr) || (ing != nil && ing.Enabled && ing.Tunnel)
+}
+
+// tunnelHost returns the hostname of cr's own tunnel, else of the default
+// tunnel, or "" when no tunnel is running.
+func tunnelHost(cr *appsv1alpha1.DevStagingEnvironment, data map[string]string) string {
+	if h := data[cr.Name+".hostname"]; h != "" {
+		return h
+	}
+	return data["hostname"]
+}
+
+// tunnelValues returns what the placeholders resolve to for cr: its own
+// tunnel, else the default tunnel, else its ingress URL, so callbacks
+// still work locally before anything is exposed.
+func tunnelValues(cr *appsv1alpha1.DevStagingEnvironment, data map[string]string) map[string]string {
+	switch {
+	case data[cr.Name+".url"] != "":
+		return map[string]string{"KINDLING_TUNNEL_URL": data[cr.Name+".url"], "KINDLING_TUNNEL_HOST": data[cr.Name+".hostname"]}
+	case data["url"] != "":
+		return map[string]string{"KINDLING_TUNNEL_URL": data["url"], "KINDLING_TUNNEL_HOST": data["hostname"]}
+	case cr.Spec.Ingress != nil && cr.Spec.Ingress.Enabled && cr.Spec.Ingress.Host != "":
+		scheme := "http"
+		if cr.Spec.Ingress.TLS != nil {
+			scheme = "https"
+		}
+		return map[string]string{"KINDLING_TUNNEL_URL": scheme + "://" + cr.Spec.Ingress.Host, "KINDLING_TUNNEL_HOST": cr.Spec.Ingress.Host}
+	}
+	return map[string]string{"KINDLING_TUNNEL_URL": "", "KINDLING_TUNNEL_HOST": ""}
+}
+
+// expandTunnelTemplates returns envs with the placeholders in their values
+// replaced. A trailing slash of the URL is dropped, so
+// "${KINDLING_TUNNEL_URL}/auth/callback" never has a double slash.
+func expandTunnelTemplates(envs []corev1.EnvVar, values map[string]string) []corev1.EnvVar {
+	if len(envs) == 0 {
+		return envs
+	}
+	out := make([]corev1.EnvVar, len(envs))
+	for i, e := range envs {
+		out[i] = *e.DeepCopy()
+		out[i].Value = tunnelTemplatePattern.ReplaceAllStringFunc(e.Value, func(m string) string {
+			return strings.TrimSuffix(values[tunnelTemplatePattern.FindStringSubmatch(m)[1]], "/")
+		})
+	}
+	return out
+}
+
+// resolveTunnel applies the kindling-tunnel ConfigMap to cr: it expands
+// the tunnel placeholders in env values, and with tunnel: true moves the
+// Ingress onto the tunnel's hostname. Both are part of the spec hashes, so
+// a new tunnel URL rolls the app and updates its Ingress.
+func (r *DevStagingEnvironmentReconciler) resolveTunnel(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
+	if !followsTunnel(cr) {
+		return nil
+	}
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: tunnelConfigMapName, Namespace: tunnelConfigMapNamespace}, cm)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	values := tunnelValues(cr, cm.Data)
+	routeIngressThroughTunnel(cr, tunnelHost(cr, cm.Data))
+
+	cr.Spec.Deployment.Env = expandTunnelTemplates(cr.Spec.Deployment.Env, values)
+	for i := range cr.Spec.Deployment.InitContainers {
+		ic := &cr.Spec.Deployment.InitContainers[i]
+		ic.Env = expandTunnelTemplates(ic.Env, values)
+	}
+	for i := range cr.Spec.Jobs {
+		cr.Spec.Jobs[i].Env = expandTunnelTemplates(cr.Spec.Jobs[i].Env, values)
+	}
+	return nil
+}
+
+// routeIngressThroughTunnel points cr's Ingress at host when it has
+// tunnel: true. The tunnel terminates HTTPS, so TLS is dropped. Without a
+// tunnel (host "") the Ingress keeps its own host.
+func routeIngressThroughTunnel(cr *appsv1alpha1.DevStagingEnvironment, host string) {
+	ing := cr.Spec.Ingress
+	if ing == nil || !ing.Enabled || !ing.Tunnel || host == "" {
+		return
+	}
+	annotations := map[string]string{tunnelHostAnnotation: host}
+	for k, v := range ing.Annotations {
+		annotations[k] = v
+	}
+	ing.Annotations = annotations
+	ing.Host = host
+	ing.TLS = nil
+}
+
+// requestsForTunnel maps a change to the kindling-tunnel ConfigMap to the
+// DevStagingEnvironments that follow it.
+func (r *DevStagingEnvironmentReconciler) requestsForTunnel(ctx context.Context, _ client.Object) []reconcile.Request {
+	list := &appsv1alpha1.DevStagingEnvironmentList{}
+	if err := r.List(ctx, list); err != nil {
+		log.FromContext(ctx).Error(err, "Listing DevStagingEnvironments for a tunnel change")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range list.Items {
+		if followsTunnel(&list.Items[i]) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])})
+		}
+	}
+	return requests
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// Volumes — app directories that outlive the app's pods
+// ────────────────────────────────────────────────────────────────────────────
+
+// appVolumeClaimName returns the name of the PVC behind one of
+// spec.deployment.volumes.
+func appVolumeClaimName(crName, volumeName string) string {
+	return crName + "-" + volumeName
+}
+
+// labelsForVolume returns the labels of a volume's PVC. They differ from
+// the app's so that listing the app's objects doesn't pick up its data.
+func labelsForVolume(cr *appsv1alpha1.DevStagingEnvironment, volumeName string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       appVolumeClaimName(cr.Name, volumeName),
+		"app.kubernetes.io/component":  "volume",
+		"app.kubernetes.io/part-of":    cr.Name,
+		"app.kubernetes.io/managed-by": "devstagingenvironment-operator",
+	}
+}
+
+// buildAppVolumes returns the pod volumes and container mounts for
+// spec.deployment.volumes: a claim for each, or a directory on the node
+// for those with a hostPath.
+func buildAppVolumes(cr *appsv1alpha1.DevStagingEnvironment) ([]corev1.Volume, []corev1.VolumeMount) {
+	var volumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+	for _, v := range cr.Spec.Deployment.Volumes {
+		volume := corev1.Volume{Name: v.Name}
+		if v.HostPath != "" {
+			hostPathType := corev1.HostPathDirectoryOrCreate
+			volume.HostPath = &corev1.HostPathVolumeSource{Path: v.HostPath, Type: &hostPathType}
+		} else {
+			volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: appVolumeClaimName(cr.Name, v.Name),
+			}
+		}
+		volumes = append(volumes, volume)
+		mounts = append(mounts, corev1.VolumeMount{Name: v.Name, MountPath: v.MountPath})
+	}
+	return volumes, mounts
+}
+
+// buildAppVolumeClaim returns the PVC for a volume without a hostPath. It
+// leaves the StorageClass to the cluster's default, which on Kind is the
+// local-path provisioner: a directory on the node the pod first runs on.
+func buildAppVolumeClaim(cr *appsv1alpha1.DevStagingEnvironment, v appsv1alpha1.VolumeSpec) *corev1.PersistentVolumeClaim {
+	size := resource.MustParse(defaultStorageSize)
+	if v.Size != nil {
+		size = *v.Size
+	}
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appVolumeClaimName(cr.Name, v.Name),
+			Namespace: cr.Namespace,
+			Labels:    labelsForVolume(cr, v.Name),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+			},
+		},
+	}
+}
+
+// reconcileVolumes creates a PVC for each claim-backed volume before the
+// pods that mount it. A claim is never updated — its size is fixed once
+// bound — and it is deleted, with its data, when its volume is removed
+// from the spec, becomes a hostPath volume, or the CR is deleted.
+func (r *DevStagingEnvironmentReconciler) reconcileVolumes(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
+	logger := log.FromContext(ctx)
+
+	wanted := make(map[string]bool, len(cr.Spec.Deployment.Volumes))
+	for _, v := range cr.Spec.Deployment.Volumes {
+		if v.HostPath != "" {
+			continue
+		}
+		desired := buildAppVolumeClaim(cr, v)
+		wanted[desired.Name] = true
+		if err := controllerutil.SetControllerReference(cr, desired, r.Scheme); err != nil {
+			return err
+		}
+		existing := &corev1.PersistentVolumeClaim{}
+		if err := r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing); err == nil {
+			continue
+		} else if !errors.IsNotFound(err) {
+			return err
+		}
+		logger.Info("Creating PersistentVolumeClaim", "name", desired.Name)
+		if err := r.Create(ctx, desired); err != nil {
+			return fmt.Errorf("volume %s: %w", v.Name, err)
+		}
+		r.recordEvent(cr, "Normal", "VolumeCreated", "Created PersistentVolumeClaim %s for %s", desired.Name, v.MountPath)
+	}
+
+	claims := &corev1.PersistentVolumeClaimList{}
+	if err := r.List(ctx, claims, client.InNamespace(cr.Namespace), client.MatchingLabels{
+		"app.kubernetes.io/part-of":    cr.Name,
+		"app.kubernetes.io/managed-by": "devstagingenvironment-operator",
+		"app.kubernetes.io/component":  "volume",
+	}); err != nil {
+		return err
+	}
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		if wanted[claim.Name] || !metav1.IsControlledBy(claim, cr) {
+			continue
+		}
+		logger.Info("Pruning orphaned PersistentVolumeClaim", "name", claim.Name)
+		if err := r.Delete(ctx, claim); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
 }
 
 // ────────────────────────────────────────────────────────────────────────────
 // Service
 // ────────────────────────────────────────────────────────────────────────────
 
-func (r *DevStagingEnvironmentReconciler) reconcileService(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
+func (r *DevStagingEnvironmentReconciler) reconcileService(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment, network networkProblems) error {
 	logger := log.FromContext(ctx)
+	if scheduled(cr) {
+		// A periodic worker serves nothing.
+		return r.deleteIfExists(ctx, cr, &corev1.Service{})
+	}
 	desired := r.buildService(cr)
+	if network.has(ReasonNodePortConflict) {
+		// The API server would refuse the port; let it pick a free one
+		// until the holder lets go, and hash that in so we switch back.
+		desired.Spec.Ports[0].NodePort = 0
+		desired.Annotations[specHashAnnotation] = computeSpecHash(struct {
+			Hash         string
+			NodePortHeld bool
+		}{desired.Annotations[specHashAnnotation], true})
+	}
 
 	if err := controllerutil.SetControllerReference(cr, desired, r.Scheme); err != nil {
 		return err
@@ -274,7 +826,11 @@ func (r *DevStagingEnvironmentReconciler) reconcileService(ctx context.Context,
 	if err != nil {
 		if errors.IsNotFound(err) {
 			logger.Info("Creating Service", "name", desired.Name)
-			return r.Create(ctx, desired)
+			if err := r.Create(ctx, desired); err != nil {
+				return err
+			}
+			r.recordEvent(cr, "Normal", "ServiceCreated", "Created Service %s", desired.Name)
+			return nil
 		}
 		return err
 	}
@@ -294,7 +850,14 @@ func (r *DevStagingEnvironmentReconciler) reconcileService(ctx context.Context,
 	if existing.Annotations == nil {
 		existing.Annotations = make(map[string]string)
 	}
-	existing.Annotations[specHashAnnotation] = desiredHash
+	for k, v := range desired.Annotations {
+		existing.Annotations[k] = v
+	}
+	for _, k := range protocolAnnotationKeys {
+		if _, ok := desired.Annotations[k]; !ok {
+			delete(existing.Annotations, k)
+		}
+	}
 	logger.Info("Updating Service", "name", desired.Name)
 	return r.Update(ctx, existing)
 }
@@ -317,23 +880,49 @@ func (r *DevStagingEnvironmentReconciler) buildService(cr *appsv1alpha1.DevStagi
 		svcType = corev1.ServiceTypeLoadBalancer
 	}
 
+	// gRPC backends speak cleartext HTTP/2; the appProtocol tells ingress
+	// controllers that read it (Contour, Gateway API implementations) to
+	// proxy with h2c rather than HTTP/1.1. Traefik reads an annotation.
+	var appProtocol *string
+	annotations := map[string]string{}
+	hash := computeSpecHash(cr.Spec.Service)
+	if ingressProtocol(cr) == ingressProtocolGRPC {
+		h2c := h2cAppProtocol
+		appProtocol = &h2c
+		if ingressProvider(cr.Spec.Ingress.IngressClassName) == ingressProviderTraefik {
+			annotations["traefik.ingress.kubernetes.io/service.serversscheme"] = "h2c"
+		}
+		hash = computeSpecHash(struct {
+			Service     appsv1alpha1.ServiceSpec
+			AppProtocol string
+			Annotations map[string]string
+		}{cr.Spec.Service, h2cAppProtocol, annotations})
+	}
+	annotations[specHashAnnotation] = hash
+
+	// A fixed node port only means something on a Service that has one.
+	var nodePort int32
+	if spec.NodePort != nil && svcType != corev1.ServiceTypeClusterIP {
+		nodePort = *spec.NodePort
+	}
+
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      cr.Name,
-			Namespace: cr.Namespace,
-			Labels:    labels,
-			Annotations: map[string]string{
-				specHashAnnotation: computeSpecHash(cr.Spec.Service),
-			},
+			Name:        cr.Name,
+			Namespace:   cr.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
 		},
 		Spec: corev1.ServiceSpec{
 			Type:     svcType,
 			Selector: labels,
 			Ports: []corev1.ServicePort{{
-				Name:       "http",
-				Port:       spec.Port,
-				TargetPort: intstr.FromInt(int(targetPort)),
-				Protocol:   corev1.ProtocolTCP,
+				Name:        "http",
+				Port:        spec.Port,
+				TargetPort:  intstr.FromInt(int(targetPort)),
+				NodePort:    nodePort,
+				Protocol:    corev1.ProtocolTCP,
+				AppProtocol: appProtocol,
 			}},
 		},
 	}
@@ -343,16 +932,37 @@ func (r *DevStagingEnvironmentReconciler) buildService(cr *appsv1alpha1.DevStagi
 // Ingress
 // ────────────────────────────────────────────────────────────────────────────
 
-func (r *DevStagingEnvironmentReconciler) reconcileIngress(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
+func (r *DevStagingEnvironmentReconciler) reconcileIngress(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment, network networkProblems) error {
 	logger := log.FromContext(ctx)
 	ingressName := types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}
 
-	// If Ingress is not enabled, clean up any existing one
-	if cr.Spec.Ingress == nil || !cr.Spec.Ingress.Enabled {
+	// Someone else holds the route: two Ingresses for one host and path
+	// leave the ingress controller to pick, so step aside until they go.
+	// An Ingress of the same name the CR doesn't control may be the very
+	// one holding the route, so only the CR's own is deleted.
+	if network.has(ReasonIngressPathConflict) {
+		existing := &networkingv1.Ingress{}
+		if err := r.Get(ctx, ingressName, existing); err == nil && metav1.IsControlledBy(existing, cr) {
+			logger.Info("Deleting Ingress (route conflict)", "name", cr.Name)
+			if err := r.Delete(ctx, existing); err != nil {
+				return err
+			}
+			r.recordEvent(cr, "Warning", "IngressDeleted", "Deleted Ingress %s: its route is held by another component", cr.Name)
+		}
+		return nil
+	}
+
+	// If Ingress is not enabled, or there is no Service to route to, clean
+	// up any existing one
+	if cr.Spec.Ingress == nil || !cr.Spec.Ingress.Enabled || scheduled(cr) {
 		existing := &networkingv1.Ingress{}
-		if err := r.Get(ctx, ingressName, existing); err == nil {
+		if err := r.Get(ctx, ingressName, existing); err == nil && metav1.IsControlledBy(existing, cr) {
 			logger.Info("Deleting Ingress (disabled)", "name", cr.Name)
-			return r.Delete(ctx, existing)
+			if err := r.Delete(ctx, existing); err != nil {
+				return err
+			}
+			r.recordEvent(cr, "Normal", "IngressDeleted", "Deleted Ingress %s (ingress disabled)", cr.Name)
+			return nil
 		}
 		return nil
 	}
@@ -367,7 +977,11 @@ func (r *DevStagingEnvironmentReconciler) reconcileIngress(ctx context.Context,
 	if err != nil {
 		if errors.IsNotFound(err) {
 			logger.Info("Creating Ingress", "name", desired.Name)
-			return r.Create(ctx, desired)
+			if err := r.Create(ctx, desired); err != nil {
+				return err
+			}
+			r.recordEvent(cr, "Normal", "IngressCreated", "Created Ingress %s", desired.Name)
+			return nil
 		}
 		return err
 	}
@@ -387,6 +1001,12 @@ func (r *DevStagingEnvironmentReconciler) reconcileIngress(ctx context.Context,
 	for k, v := range desired.Annotations {
 		existing.Annotations[k] = v
 	}
+	// Drop protocol annotations left over from a previous protocol.
+	for _, k := range protocolAnnotationKeys {
+		if _, ok := desired.Annotations[k]; !ok {
+			delete(existing.Annotations, k)
+		}
+	}
 	logger.Info("Updating Ingress", "name", desired.Name)
 	return r.Update(ctx, existing)
 }
@@ -408,14 +1028,32 @@ func (r *DevStagingEnvironmentReconciler) buildIngress(cr *appsv1alpha1.DevStagi
 		path = spec.Path
 	}
 
-	// Merge our spec hash into user-provided annotations
-	annotations := make(map[string]string)
+	// Protocol annotations first, so user-provided ones can override them,
+	// then our spec hash
+	annotations := ingressProtocolAnnotations(spec.Protocol, spec.IngressClassName, path)
 	for k, v := range spec.Annotations {
 		annotations[k] = v
 	}
 	annotations[specHashAnnotation] = computeSpecHash(cr.Spec.Ingress)
 
-	ingress := &networkingv1.Ingress{
+	// With autoSleep, requests go through the activator, which wakes the
+	// app before proxying to its Service.
+	backend := networkingv1.IngressServiceBackend{
+		Name: cr.Name,
+		Port: networkingv1.ServiceBackendPort{Number: cr.Spec.Service.Port},
+	}
+	if r.autoSleeps(cr) {
+		backend = networkingv1.IngressServiceBackend{
+			Name: activatorServiceName(cr.Name),
+			Port: networkingv1.ServiceBackendPort{Number: r.Activator.Port},
+		}
+		annotations[specHashAnnotation] = computeSpecHash(struct {
+			Ingress   *appsv1alpha1.IngressSpec
+			Activator networkingv1.IngressServiceBackend
+		}{cr.Spec.Ingress, backend})
+	}
+
+	ingress := &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        cr.Name,
 			Namespace:   cr.Namespace,
@@ -432,12 +1070,7 @@ func (r *DevStagingEnvironmentReconciler) buildIngress(cr *appsv1alpha1.DevStagi
 							Path:     path,
 							PathType: &pathType,
 							Backend: networkingv1.IngressBackend{
-								Service: &networkingv1.IngressServiceBackend{
-									Name: cr.Name,
-									Port: networkingv1.ServiceBackendPort{
-										Number: cr.Spec.Service.Port,
-									},
-								},
+								Service: &backend,
 							},
 						}},
 					},
@@ -461,17 +1094,111 @@ func (r *DevStagingEnvironmentReconciler) buildIngress(cr *appsv1alpha1.DevStagi
 	return ingress
 }
 
+const (
+	ingressProtocolGRPC      = "grpc"
+	ingressProtocolWebSocket = "websocket"
+
+	// h2cAppProtocol marks a Service port as cleartext HTTP/2 (KEP-3726).
+	h2cAppProtocol = "kubernetes.io/h2c"
+
+	// streamTimeout keeps idle WebSocket connections open under ingress-nginx,
+	// whose proxy timeouts default to 60s.
+	streamTimeout = "3600"
+)
+
+// Ingress controllers whose annotations the operator renders. The
+// provider is picked from the Ingress class name.
+const (
+	ingressProviderNginx   = "nginx"
+	ingressProviderContour = "contour"
+	ingressProviderTraefik = "traefik"
+)
+
+// protocolAnnotationKeys are every annotation buildIngress and
+// buildService derive from the protocol, so that switching protocol or
+// controller removes the previous ones.
+var protocolAnnotationKeys = []string{
+	"nginx.ingress.kubernetes.io/backend-protocol",
+	"nginx.ingress.kubernetes.io/proxy-read-timeout",
+	"nginx.ingress.kubernetes.io/proxy-send-timeout",
+	"nginx.ingress.kubernetes.io/proxy-http-version",
+	"projectcontour.io/websocket-routes",
+	"projectcontour.io/response-timeout",
+	"traefik.ingress.kubernetes.io/service.serversscheme",
+}
+
+// ingressProvider returns the controller serving className. Classes other
+// than contour and traefik — including none, which leaves the choice to
+// the cluster's default class — are treated as ingress-nginx, kindling's
+// default controller.
+func ingressProvider(className *string) string {
+	if className != nil {
+		switch *className {
+		case ingressProviderContour, ingressProviderTraefik:
+			return *className
+		}
+	}
+	return ingressProviderNginx
+}
+
+// ingressProtocol returns the protocol of the CR's Ingress, "" when it
+// has none.
+func ingressProtocol(cr *appsv1alpha1.DevStagingEnvironment) string {
+	if cr.Spec.Ingress == nil || !cr.Spec.Ingress.Enabled {
+		return ""
+	}
+	return cr.Spec.Ingress.Protocol
+}
+
+// ingressProtocolAnnotations returns the Ingress annotations the
+// controller serving className needs to route protocol.
+//
+// ingress-nginx forwards the Upgrade and Connection headers of WebSocket
+// handshakes on its own, so WebSockets only need longer timeouts there.
+// Contour needs the route opted in to upgrades. Traefik proxies both
+// protocols without Ingress annotations. Contour and Traefik take gRPC's
+// h2c upstream from the Service instead (see buildService).
+func ingressProtocolAnnotations(protocol string, className *string, path string) map[string]string {
+	annotations := map[string]string{}
+	switch ingressProvider(className) {
+	case ingressProviderNginx:
+		switch protocol {
+		case ingressProtocolGRPC:
+			annotations["nginx.ingress.kubernetes.io/backend-protocol"] = "GRPC"
+		case ingressProtocolWebSocket:
+			annotations["nginx.ingress.kubernetes.io/proxy-http-version"] = "1.1"
+			annotations["nginx.ingress.kubernetes.io/proxy-read-timeout"] = streamTimeout
+			annotations["nginx.ingress.kubernetes.io/proxy-send-timeout"] = streamTimeout
+		}
+	case ingressProviderContour:
+		if protocol == ingressProtocolWebSocket {
+			annotations["projectcontour.io/websocket-routes"] = path
+			annotations["projectcontour.io/response-timeout"] = "infinity"
+		}
+	}
+	return annotations
+}
+
 // ────────────────────────────────────────────────────────────────────────────
 // Status
 // ────────────────────────────────────────────────────────────────────────────
 
-func (r *DevStagingEnvironmentReconciler) updateStatus(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
-	// Fetch current Deployment state
+func (r *DevStagingEnvironmentReconciler) updateStatus(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment, network networkProblems) error {
+	// Fetch current Deployment state, or the CronJob's for a scheduled app
 	deploy := &appsv1.Deployment{}
-	if err := r.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, deploy); err == nil {
-		cr.Status.AvailableReplicas = deploy.Status.AvailableReplicas
-		cr.Status.DeploymentReady = deploy.Status.AvailableReplicas == deploy.Status.Replicas &&
-			deploy.Status.Replicas > 0
+	cronJob := &batchv1.CronJob{}
+	var deployErr error
+	if scheduled(cr) {
+		deployErr = r.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, cronJob)
+		cr.Status.AvailableReplicas = int32(len(cronJob.Status.Active))
+		cr.Status.DeploymentReady = deployErr == nil
+	} else {
+		deployErr = r.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, deploy)
+		if deployErr == nil {
+			cr.Status.AvailableReplicas = deploy.Status.AvailableReplicas
+			cr.Status.DeploymentReady = deploy.Status.AvailableReplicas == deploy.Status.Replicas &&
+				deploy.Status.Replicas > 0
+		}
 	}
 
 	// Fetch current Service state
@@ -483,13 +1210,13 @@ func (r *DevStagingEnvironmentReconciler) updateStatus(ctx context.Context, cr *
 	}
 
 	// Fetch current Ingress state
-	if cr.Spec.Ingress != nil && cr.Spec.Ingress.Enabled {
+	if cr.Spec.Ingress != nil && cr.Spec.Ingress.Enabled && !scheduled(cr) {
 		ing := &networkingv1.Ingress{}
 		if err := r.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, ing); err == nil {
 			cr.Status.IngressReady = true
 			if cr.Spec.Ingress.Host != "" {
 				scheme := "http"
-				if cr.Spec.Ingress.TLS != nil {
+				if cr.Spec.Ingress.TLS != nil || cr.Spec.Ingress.Annotations[tunnelHostAnnotation] != "" {
 					scheme = "https"
 				}
 				cr.Status.URL = fmt.Sprintf("%s://%s%s", scheme, cr.Spec.Ingress.Host, cr.Spec.Ingress.Path)
@@ -503,36 +1230,61 @@ func (r *DevStagingEnvironmentReconciler) updateStatus(ctx context.Context, cr *
 	}
 
 	// Check dependency readiness
-	depsReady := true
+	var depsPending []string
 	for _, dep := range cr.Spec.Dependencies {
-		depDeploy := &appsv1.Deployment{}
-		depName := dependencyName(cr.Name, dep.Type)
-		if err := r.Get(ctx, types.NamespacedName{Name: depName, Namespace: cr.Namespace}, depDeploy); err != nil {
-			depsReady = false
-			break
-		}
-		if depDeploy.Status.AvailableReplicas < 1 {
-			depsReady = false
-			break
+		if !r.dependencyAvailable(ctx, cr, dep) {
+			depsPending = append(depsPending, dependencyName(cr.Name, dep.Type))
 		}
 	}
-	if len(cr.Spec.Dependencies) == 0 {
-		depsReady = true
-	}
+	depsReady := len(depsPending) == 0
 	cr.Status.DependenciesReady = depsReady
 
+	switch {
+	case paused(cr):
+		r.setCondition(cr, pausedCondition(cr, componentsReadyCondition))
+	case scheduled(cr):
+		r.setCondition(cr, cronJobCondition(cr, cronJob, deployErr == nil))
+	default:
+		r.setCondition(cr, componentsCondition(cr, deploy, deployErr == nil))
+	}
+	if network.has(ReasonIngressPathConflict) {
+		r.setCondition(cr, metav1.Condition{Type: ingressReadyCondition, Status: metav1.ConditionFalse,
+			Reason: ReasonIngressPathConflict, Message: "The Ingress is held back; see the NetworkValid condition"})
+	} else {
+		r.setCondition(cr, ingressCondition(cr))
+	}
+	r.setCondition(cr, networkCondition(network))
+	r.setCondition(cr, r.imagesCondition(ctx, cr))
+	if paused(cr) && len(depsPending) > 0 {
+		r.setCondition(cr, pausedCondition(cr, dependenciesReadyCondition))
+	} else {
+		r.setCondition(cr, dependenciesCondition(cr, depsPending))
+	}
+	upstreamsPending := r.pendingUpstreams(ctx, cr)
+	var cycle []string
+	if len(upstreamsPending) > 0 {
+		// A failed read only leaves the plain "Waiting for" message.
+		cycle, _ = FindDependsOnCycle(ctx, r.Client, cr)
+	}
+	r.setUpstreamsCondition(cr, upstreamsPending, cycle)
+	r.updateSeedCondition(ctx, cr)
+	jobsDone := r.updateJobStatus(ctx, cr)
+
 	// Set an overall "Ready" condition
-	allReady := cr.Status.DeploymentReady && cr.Status.ServiceReady && depsReady
-	if allReady {
-		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
-			Type:    "Ready",
+	allReady := cr.Status.DeploymentReady && serviceReady(cr) && depsReady && len(upstreamsPending) == 0 && jobsDone
+	switch {
+	case paused(cr):
+		r.setCondition(cr, pausedCondition(cr, readyCondition))
+	case allReady:
+		r.setCondition(cr, metav1.Condition{
+			Type:    readyCondition,
 			Status:  metav1.ConditionTrue,
 			Reason:  "AllResourcesReady",
-			Message: "Deployment, Service, Ingress (if enabled), and Dependencies are ready",
+			Message: "Deployment, Service, Ingress (if enabled), Dependencies, and Jobs are ready",
 		})
-	} else {
-		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
-			Type:    "Ready",
+	default:
+		r.setCondition(cr, metav1.Condition{
+			Type:    readyCondition,
 			Status:  metav1.ConditionFalse,
 			Reason:  "ResourcesNotReady",
 			Message: "One or more child resources are not yet ready",
@@ -542,6 +1294,282 @@ func (r *DevStagingEnvironmentReconciler) updateStatus(ctx context.Context, cr *
 	return r.Status().Update(ctx, cr)
 }
 
+// Condition types set on every DevStagingEnvironment. Ready summarises
+// the others, except NetworkValid, which reports route and port conflicts
+// rather than readiness; Seeded (seedConditionType) is only set when a dependency
+// declares a seed, JobsComplete only when the spec declares jobs,
+// UpstreamsReady only when it declares dependsOn, and Signed only when the
+// manager requires signed environments.
+const (
+	readyCondition             = "Ready"
+	componentsReadyCondition   = "ComponentsReady"
+	ingressReadyCondition      = "IngressReady"
+	imagesBuiltCondition       = "ImagesBuilt"
+	dependenciesReadyCondition = "DependenciesReady"
+	jobsCompleteCondition      = "JobsComplete"
+	upstreamsReadyCondition    = "UpstreamsReady"
+	networkValidCondition      = "NetworkValid"
+	signedCondition            = "Signed"
+)
+
+// imagePullFailures are the container waiting reasons that mean the app
+// image was never built or pushed, or cannot be reached.
+var imagePullFailures = map[string]bool{
+	"ErrImagePull":        true,
+	"ImagePullBackOff":    true,
+	"InvalidImageName":    true,
+	"ErrImageNeverPull":   true,
+	"ImageInspectError":   true,
+	"RegistryUnavailable": true,
+}
+
+// setCondition records condition on the CR and emits an Event when its
+// status or reason changes — Normal when it becomes True or the
+// environment is paused or asleep, Warning when it becomes False — so kubectl
+// describe shows each milestone once. The same changes feed the readiness
+// and build failure metrics.
+func (r *DevStagingEnvironmentReconciler) setCondition(cr *appsv1alpha1.DevStagingEnvironment, condition metav1.Condition) {
+	changed := true
+	prev := meta.FindStatusCondition(cr.Status.Conditions, condition.Type)
+	if prev != nil {
+		changed = prev.Status != condition.Status || prev.Reason != condition.Reason
+		prev = prev.DeepCopy()
+	}
+	condition.ObservedGeneration = cr.Generation
+	meta.SetStatusCondition(&cr.Status.Conditions, condition)
+	if !changed {
+		return
+	}
+	observeCondition(cr, prev, condition)
+	switch {
+	case condition.Status == metav1.ConditionTrue, condition.Reason == ReasonPaused, condition.Reason == ReasonAsleep:
+		r.recordEvent(cr, "Normal", condition.Reason, "%s: %s", condition.Type, condition.Message)
+	case condition.Status == metav1.ConditionFalse:
+		r.recordEvent(cr, "Warning", condition.Reason, "%s: %s", condition.Type, condition.Message)
+	}
+}
+
+// componentsCondition reports whether the app's Deployment has all its
+// replicas available behind its Service.
+func componentsCondition(cr *appsv1alpha1.DevStagingEnvironment, deploy *appsv1.Deployment, deployFound bool) metav1.Condition {
+	condition := metav1.Condition{Type: componentsReadyCondition, Status: metav1.ConditionFalse}
+	switch {
+	case !deployFound:
+		condition.Reason = "DeploymentNotFound"
+		condition.Message = fmt.Sprintf("Deployment %s does not exist yet", cr.Name)
+	case !cr.Status.ServiceReady:
+		condition.Reason = "ServiceNotFound"
+		condition.Message = fmt.Sprintf("Service %s does not exist yet", cr.Name)
+	case !cr.Status.DeploymentReady:
+		condition.Reason = "ReplicasUnavailable"
+		condition.Message = fmt.Sprintf("Deployment %s has %d/%d replicas available",
+			cr.Name, deploy.Status.AvailableReplicas, deploy.Status.Replicas)
+	default:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ComponentsAvailable"
+		condition.Message = fmt.Sprintf("Deployment %s has %d/%d replicas available behind Service %s",
+			cr.Name, deploy.Status.AvailableReplicas, deploy.Status.Replicas, cr.Name)
+	}
+	return condition
+}
+
+// cronJobCondition reports whether a scheduled app's CronJob exists, and
+// when it last ran.
+func cronJobCondition(cr *appsv1alpha1.DevStagingEnvironment, cronJob *batchv1.CronJob, found bool) metav1.Condition {
+	if !found {
+		return metav1.Condition{Type: componentsReadyCondition, Status: metav1.ConditionFalse,
+			Reason: "CronJobNotFound", Message: fmt.Sprintf("CronJob %s does not exist yet", cr.Name)}
+	}
+	message := fmt.Sprintf("CronJob %s runs on %q", cr.Name, cr.Spec.Deployment.Schedule)
+	switch {
+	case len(cronJob.Status.Active) > 0:
+		message += "; a run is in progress"
+	case cronJob.Status.LastScheduleTime == nil:
+		message += "; it has not run yet"
+	default:
+		message += "; last run at " + cronJob.Status.LastScheduleTime.UTC().Format(time.RFC3339)
+	}
+	return metav1.Condition{Type: componentsReadyCondition, Status: metav1.ConditionTrue,
+		Reason: "Scheduled", Message: message}
+}
+
+// ingressCondition reports whether the Ingress exists. Without an enabled
+// ingress there is nothing to wait for, so the condition is True.
+func ingressCondition(cr *appsv1alpha1.DevStagingEnvironment) metav1.Condition {
+	switch {
+	case cr.Spec.Ingress == nil || !cr.Spec.Ingress.Enabled:
+		return metav1.Condition{Type: ingressReadyCondition, Status: metav1.ConditionTrue,
+			Reason: "IngressDisabled", Message: "spec.ingress is not enabled"}
+	case scheduled(cr):
+		return metav1.Condition{Type: ingressReadyCondition, Status: metav1.ConditionTrue,
+			Reason: "IngressDisabled", Message: "a scheduled app serves no traffic, so spec.ingress is ignored"}
+	case !cr.Status.IngressReady:
+		return metav1.Condition{Type: ingressReadyCondition, Status: metav1.ConditionFalse,
+			Reason: "IngressNotFound", Message: fmt.Sprintf("Ingress %s does not exist yet", cr.Name)}
+	case cr.Status.URL != "":
+		return metav1.Condition{Type: ingressReadyCondition, Status: metav1.ConditionTrue,
+			Reason: "IngressCreated", Message: "Routing " + cr.Status.URL}
+	default:
+		return metav1.Condition{Type: ingressReadyCondition, Status: metav1.ConditionTrue,
+			Reason: "IngressCreated", Message: fmt.Sprintf("Ingress %s routes every host", cr.Name)}
+	}
+}
+
+// imagesCondition reports whether the app's pods could pull their images.
+// The operator never builds images itself — CI or kindling push does — so
+// a pull failure is how a missing or unpushed build shows up.
+func (r *DevStagingEnvironmentReconciler) imagesCondition(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    imagesBuiltCondition,
+		Status:  metav1.ConditionUnknown,
+		Reason:  "PodsPending",
+		Message: fmt.Sprintf("Waiting for pods to pull %s", cr.Spec.Deployment.Image),
+	}
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(cr.Namespace), client.MatchingLabels(labelsForCR(cr))); err != nil {
+		return condition
+	}
+	for _, pod := range pods.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if w := cs.State.Waiting; w != nil && imagePullFailures[w.Reason] {
+				condition.Status = metav1.ConditionFalse
+				condition.Reason = "ImagePullFailed"
+				condition.Message = fmt.Sprintf("%s: %s — was it built and pushed?", cs.Image, w.Reason)
+				return condition
+			}
+			if cs.ImageID != "" {
+				condition.Status = metav1.ConditionTrue
+				condition.Reason = "ImagesAvailable"
+				condition.Message = fmt.Sprintf("Pulled %s", cs.Image)
+			}
+		}
+	}
+	return condition
+}
+
+// dependenciesCondition reports which dependencies have no available pod.
+func dependenciesCondition(cr *appsv1alpha1.DevStagingEnvironment, pending []string) metav1.Condition {
+	switch {
+	case len(cr.Spec.Dependencies) == 0:
+		return metav1.Condition{Type: dependenciesReadyCondition, Status: metav1.ConditionTrue,
+			Reason: "NoDependencies", Message: "No dependencies declared"}
+	case len(pending) > 0:
+		return metav1.Condition{Type: dependenciesReadyCondition, Status: metav1.ConditionFalse,
+			Reason: "DependenciesUnavailable", Message: "Waiting for " + strings.Join(pending, ", ")}
+	default:
+		return metav1.Condition{Type: dependenciesReadyCondition, Status: metav1.ConditionTrue,
+			Reason: "DependenciesAvailable", Message: fmt.Sprintf("All %d dependencies are available", len(cr.Spec.Dependencies))}
+	}
+}
+
+// dependencyAvailable reports whether a dependency's workload has at least
+// one available pod.
+func (r *DevStagingEnvironmentReconciler) dependencyAvailable(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment, dep appsv1alpha1.DependencySpec) bool {
+	key := types.NamespacedName{Name: dependencyName(cr.Name, dep.Type), Namespace: cr.Namespace}
+	if dependencyRegistry[dep.Type].Stateful {
+		sts := &appsv1.StatefulSet{}
+		return r.Get(ctx, key, sts) == nil && sts.Status.AvailableReplicas >= 1
+	}
+	deploy := &appsv1.Deployment{}
+	return r.Get(ctx, key, deploy) == nil && deploy.Status.AvailableReplicas >= 1
+}
+
+// pendingUpstreams returns the spec.dependsOn components that don't have
+// all their replicas available yet — or don't exist. A scheduled upstream
+// counts as available once its CronJob exists.
+func (r *DevStagingEnvironmentReconciler) pendingUpstreams(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) []string {
+	var pending []string
+	for _, name := range cr.Spec.DependsOn {
+		upstream := &appsv1alpha1.DevStagingEnvironment{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: cr.Namespace}, upstream); err != nil || !upstream.Status.DeploymentReady {
+			pending = append(pending, name)
+		}
+	}
+	return pending
+}
+
+// ReasonDependsOnCycle is the UpstreamsReady reason of a DSE whose
+// spec.dependsOn leads back to itself: every component on the cycle waits
+// for the next, so none of them ever starts.
+const ReasonDependsOnCycle = "DependsOnCycle"
+
+// FindDependsOnCycle follows cr's spec.dependsOn through the
+// DevStagingEnvironments of its namespace and returns a path that leads
+// back to cr, e.g. [a b a], or nil. cr's own dependsOn is taken from cr
+// rather than the cluster, so the webhook can check an apply before it
+// lands.
+func FindDependsOnCycle(ctx context.Context, c client.Reader, cr *appsv1alpha1.DevStagingEnvironment) ([]string, error) {
+	if len(cr.Spec.DependsOn) == 0 {
+		return nil, nil
+	}
+	list := &appsv1alpha1.DevStagingEnvironmentList{}
+	if err := c.List(ctx, list, client.InNamespace(cr.Namespace)); err != nil {
+		return nil, err
+	}
+	graph := map[string][]string{}
+	for _, other := range list.Items {
+		graph[other.Name] = other.Spec.DependsOn
+	}
+	graph[cr.Name] = cr.Spec.DependsOn
+
+	visited := map[string]bool{}
+	var walk func(path []string) []string
+	walk = func(path []string) []string {
+		for _, next := range graph[path[len(path)-1]] {
+			if next == cr.Name {
+				return append(path, next)
+			}
+			if !visited[next] {
+				visited[next] = true
+				if cycle := walk(append(slices.Clip(path), next)); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		return nil
+	}
+	return walk([]string{cr.Name}), nil
+}
+
+// setUpstreamsCondition reports which of spec.dependsOn the app is waiting
+// for, or the cycle that keeps it waiting. CRs without dependsOn carry no
+// condition.
+func (r *DevStagingEnvironmentReconciler) setUpstreamsCondition(cr *appsv1alpha1.DevStagingEnvironment, pending, cycle []string) {
+	switch {
+	case len(cr.Spec.DependsOn) == 0:
+		meta.RemoveStatusCondition(&cr.Status.Conditions, upstreamsReadyCondition)
+	case len(cycle) > 0:
+		r.setCondition(cr, metav1.Condition{Type: upstreamsReadyCondition, Status: metav1.ConditionFalse,
+			Reason: ReasonDependsOnCycle, Message: fmt.Sprintf("dependsOn cycle %s — none of them would start", strings.Join(cycle, " → "))})
+	case len(pending) > 0:
+		r.setCondition(cr, metav1.Condition{Type: upstreamsReadyCondition, Status: metav1.ConditionFalse,
+			Reason: "UpstreamsUnavailable", Message: "Waiting for " + strings.Join(pending, ", ")})
+	default:
+		r.setCondition(cr, metav1.Condition{Type: upstreamsReadyCondition, Status: metav1.ConditionTrue,
+			Reason: "UpstreamsAvailable", Message: fmt.Sprintf("All %d upstream components are available", len(cr.Spec.DependsOn))})
+	}
+}
+
+// requestsForDependents maps a DevStagingEnvironment to the ones in its
+// namespace that depend on it, so they roll out as soon as it's ready
+// rather than on their next requeue.
+func (r *DevStagingEnvironmentReconciler) requestsForDependents(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := &appsv1alpha1.DevStagingEnvironmentList{}
+	if err := r.List(ctx, list, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, cr := range list.Items {
+		for _, name := range cr.Spec.DependsOn {
+			if name == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}})
+				break
+			}
+		}
+	}
+	return requests
+}
+
 // ────────────────────────────────────────────────────────────────────────────
 // Helpers
 // ────────────────────────────────────────────────────────────────────────────
@@ -577,20 +1605,24 @@ func buildResourceRequirements(res *appsv1alpha1.ResourceRequirements) corev1.Re
 	return reqs
 }
 
-// buildHTTPProbe constructs a liveness/readiness probe from the health check spec.
-func buildHTTPProbe(hc *appsv1alpha1.HealthCheckSpec, defaultPort int32) *corev1.Probe {
+// buildProbe constructs a liveness/readiness probe from the health check spec:
+// an HTTP GET by default, or a TCP socket check when Type is "tcp".
+func buildProbe(hc *appsv1alpha1.HealthCheckSpec, defaultPort int32) *corev1.Probe {
 	port := defaultPort
 	if hc.Port != nil {
 		port = *hc.Port
 	}
 
-	probe := &corev1.Probe{
-		ProbeHandler: corev1.ProbeHandler{
-			HTTPGet: &corev1.HTTPGetAction{
-				Path: hc.Path,
-				Port: intstr.FromInt(int(port)),
-			},
-		},
+	probe := &corev1.Probe{}
+	if hc.Type == "tcp" {
+		probe.TCPSocket = &corev1.TCPSocketAction{
+			Port: intstr.FromInt(int(port)),
+		}
+	} else {
+		probe.HTTPGet = &corev1.HTTPGetAction{
+			Path: hc.Path,
+			Port: intstr.FromInt(int(port)),
+		}
 	}
 
 	if hc.InitialDelaySeconds != nil {
@@ -613,17 +1645,37 @@ func computeSpecHash(obj interface{}) string {
 }
 
 // SetupWithManager sets up the controller with the Manager.
-// It watches DevStagingEnvironment (primary) and also watches Deployments, Services,
-// and Ingresses that the operator owns, so changes to child resources
-// trigger a reconciliation of the parent CR.
+// It watches DevStagingEnvironment (primary) and also watches Deployments,
+// StatefulSets, Jobs, CronJobs, Services, Ingresses, and NetworkPolicies that the operator owns, so changes to child resources
+// trigger a reconciliation of the parent CR. Changes to the kindling-tunnel
+// ConfigMap reconcile the CRs that follow the tunnel.
 func (r *DevStagingEnvironmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Recorder = mgr.GetEventRecorderFor("devstagingenvironment-controller")
+	if err := registerEnvironmentCollector(mgr.GetClient()); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&appsv1alpha1.DevStagingEnvironment{}).
 		Owns(&appsv1.Deployment{}).
+		Owns(&appsv1.StatefulSet{}).
+		Owns(&batchv1.Job{}).
+		Owns(&batchv1.CronJob{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.Secret{}).
+		Owns(&corev1.PersistentVolumeClaim{}).
 		Owns(&networkingv1.Ingress{}).
+		Owns(&networkingv1.NetworkPolicy{}).
+		Watches(&appsv1alpha1.DevStagingEnvironment{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForDependents)).
+		Watches(&appsv1alpha1.DevStagingEnvironment{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForNetworkPeers)).
+		Watches(&appsv1alpha1.DevStagingEnvironment{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForIsolatedPeers)).
+		Watches(&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForTunnel),
+			builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				return obj.GetName() == tunnelConfigMapName && obj.GetNamespace() == tunnelConfigMapNamespace
+			}))).
 		Complete(r)
 }
 
@@ -647,7 +1699,22 @@ type dependencyDefaults struct {
 	Port       int32           // e.g. 5432
 	EnvVarName string          // injected into the app container
 	Env        []corev1.EnvVar // container env vars to configure the dep itself
-	Stateful   bool            // true = needs a PVC
+	Stateful   bool            // true = runs as a StatefulSet with a PVC
+	DataPath   string          // where a Stateful dependency keeps its data
+}
+
+// defaultStorageSize is the PVC size of a Stateful dependency when the spec
+// doesn't set storageSize.
+const defaultStorageSize = "1Gi"
+
+// DependencyStorageSize returns the size of a dependency's data volume,
+// and false for a dependency that keeps no data.
+func DependencyStorageSize(dep appsv1alpha1.DependencySpec) (resource.Quantity, bool) {
+	size := resource.MustParse(defaultStorageSize)
+	if dep.StorageSize != nil {
+		size = *dep.StorageSize
+	}
+	return size, dependencyRegistry[dep.Type].Stateful
 }
 
 // dependencyRegistry maps each supported DependencyType to its defaults.
@@ -662,13 +1729,15 @@ var dependencyRegistry = map[appsv1alpha1.DependencyType]dependencyDefaults{
 			{Name: "POSTGRES_DB", Value: "devdb"},
 		},
 		Stateful: true,
+		DataPath: "/var/lib/postgresql",
 	},
 	appsv1alpha1.DependencyRedis: {
 		Image:      "redis",
 		Port:       6379,
 		EnvVarName: "REDIS_URL",
 		Env:        nil,
-		Stateful:   false,
+		Stateful:   true,
+		DataPath:   "/data",
 	},
 	appsv1alpha1.DependencyMySQL: {
 		Image:      "mysql",
@@ -681,6 +1750,7 @@ var dependencyRegistry = map[appsv1alpha1.DependencyType]dependencyDefaults{
 			{Name: "MYSQL_PASSWORD", Value: "devpass"},
 		},
 		Stateful: true,
+		DataPath: "/var/lib/mysql",
 	},
 	appsv1alpha1.DependencyMongoDB: {
 		Image:      "mongo",
@@ -691,6 +1761,7 @@ var dependencyRegistry = map[appsv1alpha1.DependencyType]dependencyDefaults{
 			{Name: "MONGO_INITDB_ROOT_PASSWORD", Value: "devpass"},
 		},
 		Stateful: true,
+		DataPath: "/data/db",
 	},
 	appsv1alpha1.DependencyRabbitMQ: {
 		Image:      "rabbitmq",
@@ -700,7 +1771,8 @@ var dependencyRegistry = map[appsv1alpha1.DependencyType]dependencyDefaults{
 			{Name: "RABBITMQ_DEFAULT_USER", Value: "devuser"},
 			{Name: "RABBITMQ_DEFAULT_PASS", Value: "devpass"},
 		},
-		Stateful: false,
+		Stateful: true,
+		DataPath: "/var/lib/rabbitmq",
 	},
 	appsv1alpha1.DependencyMinIO: {
 		Image:      "minio/minio",
@@ -711,6 +1783,7 @@ var dependencyRegistry = map[appsv1alpha1.DependencyType]dependencyDefaults{
 			{Name: "MINIO_ROOT_PASSWORD", Value: "minioadmin"},
 		},
 		Stateful: true,
+		DataPath: "/data",
 	},
 	appsv1alpha1.DependencyElasticsearch: {
 		Image:      "docker.elastic.co/elasticsearch/elasticsearch",
@@ -722,6 +1795,7 @@ var dependencyRegistry = map[appsv1alpha1.DependencyType]dependencyDefaults{
 			{Name: "ES_JAVA_OPTS", Value: "-Xms256m -Xmx256m"},
 		},
 		Stateful: true,
+		DataPath: "/usr/share/elasticsearch/data",
 	},
 	appsv1alpha1.DependencyKafka: {
 		Image:      "apache/kafka",
@@ -735,8 +1809,10 @@ var dependencyRegistry = map[appsv1alpha1.DependencyType]dependencyDefaults{
 			{Name: "KAFKA_LISTENER_SECURITY_PROTOCOL_MAP", Value: "PLAINTEXT:PLAINTEXT,CONTROLLER:PLAINTEXT"},
 			{Name: "KAFKA_CONTROLLER_LISTENER_NAMES", Value: "CONTROLLER"},
 			{Name: "CLUSTER_ID", Value: "kindling-dev-kafka-cluster"},
+			{Name: "KAFKA_LOG_DIRS", Value: "/var/lib/kafka/data"},
 		},
 		Stateful: true,
+		DataPath: "/var/lib/kafka/data",
 	},
 	appsv1alpha1.DependencyNATS: {
 		Image:      "nats",
@@ -763,6 +1839,7 @@ var dependencyRegistry = map[appsv1alpha1.DependencyType]dependencyDefaults{
 			{Name: "HEAP_NEWSIZE", Value: "64M"},
 		},
 		Stateful: true,
+		DataPath: "/var/lib/cassandra",
 	},
 	appsv1alpha1.DependencyConsul: {
 		Image:      "hashicorp/consul",
@@ -793,6 +1870,7 @@ var dependencyRegistry = map[appsv1alpha1.DependencyType]dependencyDefaults{
 			{Name: "DOCKER_INFLUXDB_INIT_BUCKET", Value: "devbucket"},
 		},
 		Stateful: true,
+		DataPath: "/var/lib/influxdb2",
 	},
 	appsv1alpha1.DependencyJaeger: {
 		Image:      "jaegertracing/all-in-one",
@@ -825,39 +1903,45 @@ func buildDependencyWaitInitContainers(cr *appsv1alpha1.DevStagingEnvironment) [
 		if !ok {
 			continue
 		}
+		initContainers = append(initContainers, buildDependencyWaitContainer(cr.Name, dep, defaults))
+	}
 
-		svcName := dependencyName(cr.Name, dep.Type)
-		port := defaults.Port
-		if dep.Port != nil {
-			port = *dep.Port
-		}
+	return initContainers
+}
+
+// buildDependencyWaitContainer returns an init container that waits until
+// the dependency's Service accepts TCP connections.
+func buildDependencyWaitContainer(crName string, dep appsv1alpha1.DependencySpec, defaults dependencyDefaults) corev1.Container {
+	svcName := dependencyName(crName, dep.Type)
+	port := defaults.Port
+	if dep.Port != nil {
+		port = *dep.Port
+	}
 
-		// Use busybox to do a TCP probe in a loop until the service is reachable
-		script := fmt.Sprintf(
-			`echo "Waiting for %s at %s:%d..."
+	// Use busybox to do a TCP probe in a loop until the service is reachable
+	script := fmt.Sprintf(
+		`echo "Waiting for %s at %s:%d..."
 until nc -z -w2 %s %d; do
   echo "  %s not ready, retrying in 2s..."
   sleep 2
 done
 echo "%s is ready!"`,
-			dep.Type, svcName, port,
-			svcName, port,
-			dep.Type,
-			dep.Type,
-		)
+		dep.Type, svcName, port,
+		svcName, port,
+		dep.Type,
+		dep.Type,
+	)
 
-		initContainers = append(initContainers, corev1.Container{
-			Name:    fmt.Sprintf("wait-for-%s", dep.Type),
-			Image:   "busybox:1.36",
-			Command: []string{"/bin/sh", "-c", script},
-		})
+	return corev1.Container{
+		Name:    fmt.Sprintf("wait-for-%s", dep.Type),
+		Image:   "busybox:1.36",
+		Command: []string{"/bin/sh", "-c", script},
 	}
-
-	return initContainers
 }
 
 // reconcileDependencies processes each declared dependency: creates a Secret
-// (with credentials), a Deployment, and a Service.
+// (with credentials), a Deployment or StatefulSet, a Service, and a seed Job
+// when the dependency declares one.
 func (r *DevStagingEnvironmentReconciler) reconcileDependencies(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
 	logger := log.FromContext(ctx)
 
@@ -872,9 +1956,9 @@ func (r *DevStagingEnvironmentReconciler) reconcileDependencies(ctx context.Cont
 			return fmt.Errorf("dependency %s secret: %w", dep.Type, err)
 		}
 
-		// 2. Reconcile the Deployment for this dependency
+		// 2. Reconcile the Deployment (or StatefulSet) for this dependency
 		if err := r.reconcileDependencyDeployment(ctx, cr, dep, defaults); err != nil {
-			return fmt.Errorf("dependency %s deployment: %w", dep.Type, err)
+			return fmt.Errorf("dependency %s workload: %w", dep.Type, err)
 		}
 
 		// 3. Reconcile the Service for this dependency
@@ -882,22 +1966,34 @@ func (r *DevStagingEnvironmentReconciler) reconcileDependencies(ctx context.Cont
 			return fmt.Errorf("dependency %s service: %w", dep.Type, err)
 		}
 
+		// 4. Seed it once it is up
+		if err := r.reconcileDependencySeed(ctx, cr, dep, defaults); err != nil {
+			return fmt.Errorf("dependency %s seed: %w", dep.Type, err)
+		}
+
 		logger.Info("Dependency reconciled", "type", dep.Type, "name", dependencyName(cr.Name, dep.Type))
 	}
 
-	// 4. Prune stale dependencies — if a dep was removed from the spec,
-	//    delete its Deployment, Service, and Secret.
+	// 5. Prune stale dependencies — if a dep was removed from the spec,
+	//    delete its workload, Service, and Secret, and any seed Job that
+	//    is no longer declared.
 	if err := r.pruneOrphanedDependencies(ctx, cr); err != nil {
 		return fmt.Errorf("prune orphaned dependencies: %w", err)
 	}
+	if err := r.pruneOrphanedSeeds(ctx, cr); err != nil {
+		return fmt.Errorf("prune orphaned seeds: %w", err)
+	}
 
 	return nil
 }
 
-// pruneOrphanedDependencies deletes Deployments, Services, and Secrets for
+// pruneOrphanedDependencies deletes workloads, Services, and Secrets for
 // dependencies that were removed from the CR spec. It finds all child
-// Deployments labelled as managed by this CR and deletes any whose dependency
-// type is no longer in cr.Spec.Dependencies.
+// Deployments and StatefulSets labelled as managed by this CR and deletes any
+// whose dependency type is no longer in cr.Spec.Dependencies. Children
+// whose component is not a dependency type, like the canary, are left
+// alone. A StatefulSet's data claim goes with it through its retention
+// policy.
 func (r *DevStagingEnvironmentReconciler) pruneOrphanedDependencies(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
 	logger := log.FromContext(ctx)
 
@@ -907,36 +2003,47 @@ func (r *DevStagingEnvironmentReconciler) pruneOrphanedDependencies(ctx context.
 		wantedTypes[string(dep.Type)] = true
 	}
 
-	// List all Deployments that belong to this CR's dependencies
-	depDeployments := &appsv1.DeploymentList{}
-	if err := r.List(ctx, depDeployments,
+	// List all workloads that belong to this CR's dependencies
+	selector := []client.ListOption{
 		client.InNamespace(cr.Namespace),
 		client.MatchingLabels{
 			"app.kubernetes.io/part-of":    cr.Name,
 			"app.kubernetes.io/managed-by": "devstagingenvironment-operator",
 		},
-	); err != nil {
+	}
+	depDeployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, depDeployments, selector...); err != nil {
 		return err
 	}
-
+	depStatefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, depStatefulSets, selector...); err != nil {
+		return err
+	}
+	var workloads []client.Object
 	for i := range depDeployments.Items {
-		dep := &depDeployments.Items[i]
-		component := dep.Labels["app.kubernetes.io/component"]
-		if component == "" {
-			continue // not a dependency resource
+		workloads = append(workloads, &depDeployments.Items[i])
+	}
+	for i := range depStatefulSets.Items {
+		workloads = append(workloads, &depStatefulSets.Items[i])
+	}
+
+	for _, dep := range workloads {
+		component := dep.GetLabels()["app.kubernetes.io/component"]
+		if _, ok := dependencyRegistry[appsv1alpha1.DependencyType(component)]; !ok {
+			continue // not a dependency resource, e.g. the canary
 		}
 		if wantedTypes[component] {
 			continue // still declared in the spec
 		}
 
-		logger.Info("Pruning orphaned dependency Deployment", "name", dep.Name, "type", component)
+		logger.Info("Pruning orphaned dependency workload", "name", dep.GetName(), "type", component)
 		if err := r.Delete(ctx, dep); err != nil && !errors.IsNotFound(err) {
 			return err
 		}
 
 		// Also delete the corresponding Service
 		svc := &corev1.Service{}
-		svcKey := types.NamespacedName{Name: dep.Name, Namespace: cr.Namespace}
+		svcKey := types.NamespacedName{Name: dep.GetName(), Namespace: cr.Namespace}
 		if err := r.Get(ctx, svcKey, svc); err == nil {
 			logger.Info("Pruning orphaned dependency Service", "name", svc.Name)
 			if err := r.Delete(ctx, svc); err != nil && !errors.IsNotFound(err) {
@@ -946,7 +2053,7 @@ func (r *DevStagingEnvironmentReconciler) pruneOrphanedDependencies(ctx context.
 
 		// Also delete the corresponding credentials Secret
 		secret := &corev1.Secret{}
-		secretKey := types.NamespacedName{Name: dep.Name + "-credentials", Namespace: cr.Namespace}
+		secretKey := types.NamespacedName{Name: dep.GetName() + "-credentials", Namespace: cr.Namespace}
 		if err := r.Get(ctx, secretKey, secret); err == nil {
 			logger.Info("Pruning orphaned dependency Secret", "name", secret.Name)
 			if err := r.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
@@ -1013,18 +2120,13 @@ func (r *DevStagingEnvironmentReconciler) reconcileDependencySecret(ctx context.
 	return r.Update(ctx, existing)
 }
 
-// reconcileDependencyDeployment creates a Deployment for the dependency service.
+// reconcileDependencyDeployment creates the workload for the dependency
+// service: a StatefulSet for Stateful types, a Deployment for the rest.
 func (r *DevStagingEnvironmentReconciler) reconcileDependencyDeployment(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment, dep appsv1alpha1.DependencySpec, defaults dependencyDefaults) error {
 	name := dependencyName(cr.Name, dep.Type)
 	labels := labelsForDependency(cr, dep.Type)
 
-	// Resolve image
-	image := defaults.Image
-	if dep.Image != "" {
-		image = dep.Image
-	} else if dep.Version != "" {
-		image = fmt.Sprintf("%s:%s", defaults.Image, dep.Version)
-	}
+	image := dependencyImage(dep, defaults)
 
 	// Resolve port
 	port := defaults.Port
@@ -1040,39 +2142,19 @@ func (r *DevStagingEnvironmentReconciler) reconcileDependencyDeployment(ctx cont
 	if dep.Type == appsv1alpha1.DependencyMinIO {
 		args = []string{"server", "/data"}
 	}
-	if dep.Type == appsv1alpha1.DependencyRabbitMQ {
-		// Use the management tag by default for the UI
-		if dep.Image == "" && dep.Version == "" {
-			image = defaults.Image + ":3-management"
-		}
-	}
 	if dep.Type == appsv1alpha1.DependencyConsul {
 		args = []string{"agent", "-dev", "-client=0.0.0.0"}
 	}
 	if dep.Type == appsv1alpha1.DependencyVault {
 		args = []string{"server", "-dev"}
 	}
-	if dep.Type == appsv1alpha1.DependencyElasticsearch {
-		if dep.Image == "" && dep.Version == "" {
-			image = defaults.Image + ":8.12.0"
-		}
-	}
-	if dep.Type == appsv1alpha1.DependencyKafka {
-		if dep.Image == "" && dep.Version == "" {
-			image = defaults.Image + ":latest"
-		}
-	}
-	if dep.Type == appsv1alpha1.DependencyJaeger {
-		if dep.Image == "" && dep.Version == "" {
-			image = defaults.Image + ":latest"
-		}
-	}
 
 	container := corev1.Container{
-		Name:  string(dep.Type),
-		Image: image,
-		Env:   env,
-		Args:  args,
+		Name:    string(dep.Type),
+		Image:   image,
+		Env:     env,
+		EnvFrom: dep.EnvFrom,
+		Args:    args,
 		Ports: []corev1.ContainerPort{{
 			Name:          string(dep.Type),
 			ContainerPort: port,
@@ -1106,6 +2188,15 @@ func (r *DevStagingEnvironmentReconciler) reconcileDependencyDeployment(ctx cont
 		container.Resources = buildResourceRequirements(dep.Resources)
 	}
 
+	podSpec := corev1.PodSpec{
+		Containers:   []corev1.Container{container},
+		NodeSelector: dep.NodeSelector,
+		Affinity:     dep.Affinity,
+	}
+	if defaults.Stateful {
+		return r.reconcileDependencyStatefulSet(ctx, cr, buildDependencyStatefulSet(cr, dep, defaults, podSpec))
+	}
+
 	replicas := int32(1)
 	desired := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1113,17 +2204,15 @@ func (r *DevStagingEnvironmentReconciler) reconcileDependencyDeployment(ctx cont
 			Namespace: cr.Namespace,
 			Labels:    labels,
 			Annotations: map[string]string{
-				specHashAnnotation: computeSpecHash(dep),
+				specHashAnnotation: pausedHash(cr, dependencySpecHash(dep)),
 			},
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: &replicas,
+			Replicas: runningReplicas(cr, &replicas),
 			Selector: &metav1.LabelSelector{MatchLabels: labels},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{Labels: labels},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{container},
-				},
+				Spec:       podSpec,
 			},
 		},
 	}
@@ -1154,6 +2243,123 @@ func (r *DevStagingEnvironmentReconciler) reconcileDependencyDeployment(ctx cont
 	return r.Update(ctx, existing)
 }
 
+// buildDependencyStatefulSet wraps a Stateful dependency's pod in a
+// single-replica StatefulSet whose volumeClaimTemplate keeps the data
+// directory across restarts. The claim is deleted along with the
+// StatefulSet, so removing the dependency (or the CR) discards its data.
+func buildDependencyStatefulSet(cr *appsv1alpha1.DevStagingEnvironment, dep appsv1alpha1.DependencySpec, defaults dependencyDefaults, podSpec corev1.PodSpec) *appsv1.StatefulSet {
+	name := dependencyName(cr.Name, dep.Type)
+	labels := labelsForDependency(cr, dep.Type)
+
+	size, _ := DependencyStorageSize(dep)
+
+	// The claim is mounted at the root of the data path; a subPath would be
+	// created root-owned, which images that run as non-root can't write to.
+	spec := podSpec.DeepCopy()
+	spec.Containers[0].VolumeMounts = append(spec.Containers[0].VolumeMounts,
+		corev1.VolumeMount{Name: "data", MountPath: defaults.DataPath})
+
+	replicas := int32(1)
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cr.Namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				specHashAnnotation: pausedHash(cr, dependencySpecHash(dep)),
+			},
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    runningReplicas(cr, &replicas),
+			ServiceName: name,
+			Selector:    &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       *spec,
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
+				ObjectMeta: metav1.ObjectMeta{Name: "data", Labels: labels},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+					},
+				},
+			}},
+			PersistentVolumeClaimRetentionPolicy: &appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+				WhenDeleted: appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+				WhenScaled:  appsv1.RetainPersistentVolumeClaimRetentionPolicyType,
+			},
+		},
+	}
+}
+
+// reconcileDependencyStatefulSet creates or updates a Stateful dependency's
+// StatefulSet. Only the mutable fields are updated: volumeClaimTemplates
+// can't change, and Kind's local-path volumes can't grow, so the webhook
+// denies a changed storageSize. One that got past it is reported as an
+// event instead of being dropped silently.
+func (r *DevStagingEnvironmentReconciler) reconcileDependencyStatefulSet(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment, desired *appsv1.StatefulSet) error {
+	logger := log.FromContext(ctx)
+	if err := controllerutil.SetControllerReference(cr, desired, r.Scheme); err != nil {
+		return err
+	}
+
+	key := types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}
+	existing := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, key, existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		// Older operator versions ran every dependency as a Deployment;
+		// replace it rather than run both against the same Service.
+		legacy := &appsv1.Deployment{}
+		if err := r.Get(ctx, key, legacy); err == nil {
+			logger.Info("Replacing dependency Deployment with a StatefulSet", "name", legacy.Name)
+			if err := r.Delete(ctx, legacy); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return r.Create(ctx, desired)
+	}
+
+	desiredHash := desired.Annotations[specHashAnnotation]
+	if existing.Annotations[specHashAnnotation] == desiredHash {
+		return nil
+	}
+
+	if had, want := claimTemplateSize(existing), claimTemplateSize(desired); had != nil && want != nil && had.Cmp(*want) != 0 {
+		r.recordEvent(cr, corev1.EventTypeWarning, ReasonStorageSizeUnchanged,
+			"%s keeps its %s volume: storageSize can't change on an existing dependency; remove the dependency and add it back to recreate the volume with %s (its data is lost)",
+			desired.Name, had.String(), want.String())
+	}
+
+	existing.Spec.Replicas = desired.Spec.Replicas
+	existing.Spec.Template = desired.Spec.Template
+	existing.Spec.PersistentVolumeClaimRetentionPolicy = desired.Spec.PersistentVolumeClaimRetentionPolicy
+	if existing.Annotations == nil {
+		existing.Annotations = make(map[string]string)
+	}
+	existing.Annotations[specHashAnnotation] = desiredHash
+	return r.Update(ctx, existing)
+}
+
+// ReasonStorageSizeUnchanged is the event reason for a storageSize change
+// that can't be applied to the existing volume.
+const ReasonStorageSizeUnchanged = "StorageSizeUnchanged"
+
+// claimTemplateSize returns the storage request of a StatefulSet's data
+// claim, or nil when it has none.
+func claimTemplateSize(sts *appsv1.StatefulSet) *resource.Quantity {
+	for _, claim := range sts.Spec.VolumeClaimTemplates {
+		if claim.Name == "data" {
+			size := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+			return &size
+		}
+	}
+	return nil
+}
+
 // reconcileDependencyService creates a ClusterIP Service for the dependency.
 func (r *DevStagingEnvironmentReconciler) reconcileDependencyService(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment, dep appsv1alpha1.DependencySpec, defaults dependencyDefaults) error {
 	name := dependencyName(cr.Name, dep.Type)
@@ -1170,7 +2376,7 @@ func (r *DevStagingEnvironmentReconciler) reconcileDependencyService(ctx context
 			Namespace: cr.Namespace,
 			Labels:    labels,
 			Annotations: map[string]string{
-				specHashAnnotation: computeSpecHash(dep),
+				specHashAnnotation: dependencySpecHash(dep),
 			},
 		},
 		Spec: corev1.ServiceSpec{
@@ -1212,10 +2418,527 @@ func (r *DevStagingEnvironmentReconciler) reconcileDependencyService(ctx context
 	return r.Update(ctx, existing)
 }
 
+// ────────────────────────────────────────────────────────────────────────────
+// Dependency seeds — one-off Jobs that load data once a dependency is up
+// ────────────────────────────────────────────────────────────────────────────
+
+const (
+	// seedDir is where a seed's ConfigMap is mounted.
+	seedDir = "/seed"
+	// defaultSeedBackoffLimit is how often a failed seed is retried when
+	// the spec doesn't set backoffLimit.
+	defaultSeedBackoffLimit = 3
+	// seedConditionType reports the outcome of every seed Job of a CR.
+	seedConditionType = "Seeded"
+)
+
+// seedJobName returns the name of a dependency's seed Job.
+func seedJobName(crName string, depType appsv1alpha1.DependencyType) string {
+	return dependencyName(crName, depType) + "-seed"
+}
+
+// seedLoaderScript returns the shell script that applies the files in
+// seedDir with the dependency's own client, or "" when the type has no
+// built-in loader. Files are applied in name order; SEED_URL holds the
+// dependency's connection URL.
+func seedLoaderScript(crName string, dep appsv1alpha1.DependencySpec, defaults dependencyDefaults) string {
+	host := dependencyName(crName, dep.Type)
+	port := defaults.Port
+	if dep.Port != nil {
+		port = *dep.Port
+	}
+
+	var cases []string
+	switch dep.Type {
+	case appsv1alpha1.DependencyPostgres:
+		cases = []string{`*.sql) echo "Applying $f"; psql "$SEED_URL" -v ON_ERROR_STOP=1 -q -f "$f" ;;`}
+	case appsv1alpha1.DependencyMySQL:
+		cases = []string{fmt.Sprintf(`*.sql) echo "Applying $f"; mysql -h %s -P %d -uroot -p"$MYSQL_ROOT_PASSWORD" "$MYSQL_DATABASE" < "$f" ;;`, host, port)}
+	case appsv1alpha1.DependencyMongoDB:
+		db := `"$SEED_URL/${MONGO_INITDB_DATABASE:-devdb}?authSource=admin"`
+		cases = []string{
+			`*.js) echo "Running $f"; mongosh ` + db + ` --quiet "$f" ;;`,
+			`*.json) echo "Importing $f"; mongoimport --uri ` + db + ` --collection "$(basename "$f" .json)" --jsonArray --file "$f" ;;`,
+		}
+	case appsv1alpha1.DependencyRedis:
+		cases = []string{`*.redis | *.txt) echo "Applying $f"; redis-cli -u "$SEED_URL" < "$f" ;;`}
+	default:
+		return ""
+	}
+
+	return fmt.Sprintf(`set -e
+for f in %s/*; do
+  case "$f" in
+    %s
+    *) echo "Skipping $f" ;;
+  esac
+done
+echo "Seed complete"`, seedDir, strings.Join(cases, "\n    "))
+}
+
+// buildDependencySeedJob returns the Job that seeds a dependency, or nil
+// when the dependency has no seed or its seed can't run: a ConfigMap
+// without a command for a type that has no built-in loader.
+func buildDependencySeedJob(cr *appsv1alpha1.DevStagingEnvironment, dep appsv1alpha1.DependencySpec, defaults dependencyDefaults) *batchv1.Job {
+	seed := dep.Seed
+	if seed == nil {
+		return nil
+	}
+
+	container := corev1.Container{
+		Name:  "seed",
+		Image: dependencyImage(dep, defaults),
+		// The dependency's own settings (credentials, database name) plus
+		// the same connection env vars the app receives.
+		Env: append(append(mergeEnvVars(defaults.Env, dep.Env),
+			corev1.EnvVar{Name: "SEED_URL", Value: buildConnectionURL(cr.Name, dep, defaults)}),
+			buildDependencyConnectionEnvVars(cr.Name, dep)...),
+		EnvFrom: dep.EnvFrom,
+	}
+	if seed.Image != "" {
+		container.Image = seed.Image
+	}
+	if len(seed.Command) > 0 {
+		container.Command = seed.Command
+		container.Args = seed.Args
+	} else {
+		script := seedLoaderScript(cr.Name, dep, defaults)
+		if script == "" {
+			return nil
+		}
+		container.Command = []string{"/bin/sh", "-c", script}
+	}
+
+	podSpec := corev1.PodSpec{
+		RestartPolicy:  corev1.RestartPolicyNever,
+		InitContainers: []corev1.Container{buildDependencyWaitContainer(cr.Name, dep, defaults)},
+		Containers:     []corev1.Container{container},
+	}
+	if seed.ConfigMap != "" {
+		podSpec.Volumes = []corev1.Volume{{
+			Name: "seed",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: seed.ConfigMap},
+				},
+			},
+		}}
+		podSpec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "seed", MountPath: seedDir, ReadOnly: true}}
+	}
+
+	backoffLimit := int32(defaultSeedBackoffLimit)
+	if seed.BackoffLimit != nil {
+		backoffLimit = *seed.BackoffLimit
+	}
+
+	// Distinct from the dependency's labels so the seed pod is not selected
+	// by the dependency's Service or workload.
+	labels := labelsForDependency(cr, dep.Type)
+	labels["app.kubernetes.io/name"] = seedJobName(cr.Name, dep.Type)
+	labels["app.kubernetes.io/component"] = "seed"
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      seedJobName(cr.Name, dep.Type),
+			Namespace: cr.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       podSpec,
+			},
+		},
+	}
+	job.Annotations = map[string]string{specHashAnnotation: computeSpecHash(job.Spec)}
+	return job
+}
+
+// reconcileDependencySeed runs a dependency's seed Job once the dependency
+// is available. A finished Job is left in place as the record that the
+// seed ran; it is replaced when the seed spec changes, and recreated when
+// it is deleted (kindling reseed).
+func (r *DevStagingEnvironmentReconciler) reconcileDependencySeed(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment, dep appsv1alpha1.DependencySpec, defaults dependencyDefaults) error {
+	logger := log.FromContext(ctx)
+	desired := buildDependencySeedJob(cr, dep, defaults)
+	if desired == nil {
+		return nil
+	}
+	if err := controllerutil.SetControllerReference(cr, desired, r.Scheme); err != nil {
+		return err
+	}
+
+	existing := &batchv1.Job{}
+	if err := r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if !r.dependencyAvailable(ctx, cr, dep) {
+			return nil // seeded on a later reconcile, once the dependency is up
+		}
+		logger.Info("Creating seed Job", "name", desired.Name)
+		r.recordEvent(cr, "Normal", "SeedStarted", "Seeding %s", dependencyName(cr.Name, dep.Type))
+		return r.Create(ctx, desired)
+	}
+
+	if !existing.DeletionTimestamp.IsZero() ||
+		existing.Annotations[specHashAnnotation] == desired.Annotations[specHashAnnotation] {
+		return nil
+	}
+	// A Job's pod template is immutable: delete it and let the delete
+	// event bring us back here to create the new one.
+	logger.Info("Seed spec changed, replacing seed Job", "name", existing.Name)
+	return client.IgnoreNotFound(r.Delete(ctx, existing, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+}
+
+// pruneOrphanedSeeds deletes seed Jobs of dependencies that were removed
+// from the spec or no longer declare a seed.
+func (r *DevStagingEnvironmentReconciler) pruneOrphanedSeeds(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
+	logger := log.FromContext(ctx)
+
+	wanted := make(map[string]bool, len(cr.Spec.Dependencies))
+	for _, dep := range cr.Spec.Dependencies {
+		if dep.Seed != nil {
+			wanted[seedJobName(cr.Name, dep.Type)] = true
+		}
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(cr.Namespace), client.MatchingLabels{
+		"app.kubernetes.io/part-of":    cr.Name,
+		"app.kubernetes.io/managed-by": "devstagingenvironment-operator",
+		"app.kubernetes.io/component":  "seed",
+	}); err != nil {
+		return err
+	}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if wanted[job.Name] {
+			continue
+		}
+		logger.Info("Pruning orphaned seed Job", "name", job.Name)
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateSeedCondition sets the Seeded condition from the CR's seed Jobs:
+// True once every seed has succeeded, False while one is pending or
+// running or when one has failed. CRs without seeds carry no condition.
+func (r *DevStagingEnvironmentReconciler) updateSeedCondition(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) {
+	var failed, pending []string
+	seeds := 0
+	for _, dep := range cr.Spec.Dependencies {
+		if dep.Seed == nil {
+			continue
+		}
+		seeds++
+		name := seedJobName(cr.Name, dep.Type)
+		if buildDependencySeedJob(cr, dep, dependencyRegistry[dep.Type]) == nil {
+			failed = append(failed, fmt.Sprintf("%s: %s has no built-in seed loader, set seed.command", name, dep.Type))
+			continue
+		}
+		job := &batchv1.Job{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: cr.Namespace}, job); err != nil {
+			pending = append(pending, name)
+			continue
+		}
+		switch {
+		case job.Status.Succeeded > 0:
+		case jobFailed(job):
+			failed = append(failed, fmt.Sprintf("%s: %s", name, jobFailureMessage(job)))
+		default:
+			pending = append(pending, name)
+		}
+	}
+
+	if seeds == 0 {
+		meta.RemoveStatusCondition(&cr.Status.Conditions, seedConditionType)
+		return
+	}
+	condition := metav1.Condition{
+		Type:    seedConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  "SeedSucceeded",
+		Message: "All dependency seeds completed",
+	}
+	switch {
+	case len(failed) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "SeedFailed"
+		condition.Message = strings.Join(failed, "; ")
+		if prev := meta.FindStatusCondition(cr.Status.Conditions, seedConditionType); prev == nil || prev.Reason != "SeedFailed" {
+			r.recordEvent(cr, "Warning", "SeedFailed", "%s", condition.Message)
+		}
+	case len(pending) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Seeding"
+		condition.Message = "Waiting for " + strings.Join(pending, ", ")
+	}
+	meta.SetStatusCondition(&cr.Status.Conditions, condition)
+}
+
+// jobFailed reports whether a Job has given up.
+func jobFailed(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// jobFailureMessage returns why a failed Job gave up.
+func jobFailureMessage(job *batchv1.Job) string {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Message != "" {
+			return c.Message
+		}
+	}
+	return "failed"
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// Jobs — run-to-completion components such as migrations
+// ────────────────────────────────────────────────────────────────────────────
+
+// defaultJobBackoffLimit is how often a failed job is retried when the spec
+// doesn't set backoffLimit.
+const defaultJobBackoffLimit = 3
+
+// appJobName returns the name of the Job that runs one of spec.jobs.
+func appJobName(crName, jobName string) string {
+	return crName + "-" + jobName
+}
+
+// buildAppJob returns the Job for one of spec.jobs. It runs with the app's
+// image and environment unless the job overrides them, is scheduled like
+// the app, and waits for every dependency the same way the app does.
+func buildAppJob(cr *appsv1alpha1.DevStagingEnvironment, spec appsv1alpha1.JobSpec) *batchv1.Job {
+	container := corev1.Container{
+		Name:            spec.Name,
+		Image:           cr.Spec.Deployment.Image,
+		ImagePullPolicy: cr.Spec.Deployment.ImagePullPolicy,
+		Command:         spec.Command,
+		Args:            spec.Args,
+		Env:             mergeEnvVars(buildAppEnvVars(cr), spec.Env),
+		EnvFrom:         cr.Spec.Deployment.EnvFrom,
+	}
+	if spec.Image != "" {
+		container.Image, container.ImagePullPolicy = spec.Image, ""
+	}
+	if spec.EnvFrom != nil {
+		container.EnvFrom = spec.EnvFrom
+	}
+
+	backoffLimit := int32(defaultJobBackoffLimit)
+	if spec.BackoffLimit != nil {
+		backoffLimit = *spec.BackoffLimit
+	}
+
+	// Distinct from the app's labels so the job pod is not selected by the
+	// app's Service or Deployment.
+	labels := map[string]string{
+		"app.kubernetes.io/name":       appJobName(cr.Name, spec.Name),
+		"app.kubernetes.io/component":  "job",
+		"app.kubernetes.io/part-of":    cr.Name,
+		"app.kubernetes.io/managed-by": "devstagingenvironment-operator",
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appJobName(cr.Name, spec.Name),
+			Namespace: cr.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy:  corev1.RestartPolicyNever,
+					InitContainers: buildDependencyWaitInitContainers(cr),
+					Containers:     []corev1.Container{container},
+					NodeSelector:   cr.Spec.Deployment.NodeSelector,
+					Affinity:       cr.Spec.Deployment.Affinity,
+				},
+			},
+		},
+	}
+	job.Annotations = map[string]string{specHashAnnotation: computeSpecHash(job.Spec)}
+	return job
+}
+
+// reconcileJobs starts each of spec.jobs once every dependency is
+// available. Like a seed, a finished Job is left in place as the record
+// that it ran; it is replaced when its spec changes (a new app image
+// included), and recreated when it is deleted. Jobs removed from the spec
+// are deleted.
+func (r *DevStagingEnvironmentReconciler) reconcileJobs(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
+	logger := log.FromContext(ctx)
+
+	wanted := make(map[string]bool, len(cr.Spec.Jobs))
+	for _, spec := range cr.Spec.Jobs {
+		desired := buildAppJob(cr, spec)
+		wanted[desired.Name] = true
+		if err := controllerutil.SetControllerReference(cr, desired, r.Scheme); err != nil {
+			return err
+		}
+
+		existing := &batchv1.Job{}
+		if err := r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing); err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+			if paused(cr) || !r.dependenciesAvailable(ctx, cr) {
+				continue // started on a later reconcile, once the dependencies are up
+			}
+			logger.Info("Creating Job", "name", desired.Name)
+			r.recordEvent(cr, "Normal", "JobStarted", "Running job %s", spec.Name)
+			if err := r.Create(ctx, desired); err != nil {
+				return fmt.Errorf("job %s: %w", spec.Name, err)
+			}
+			continue
+		}
+
+		if !existing.DeletionTimestamp.IsZero() ||
+			existing.Annotations[specHashAnnotation] == desired.Annotations[specHashAnnotation] {
+			continue
+		}
+		// A Job's pod template is immutable: delete it and let the delete
+		// event bring us back here to create the new one.
+		logger.Info("Job spec changed, replacing Job", "name", existing.Name)
+		if err := r.Delete(ctx, existing, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("job %s: %w", spec.Name, err)
+		}
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(cr.Namespace), client.MatchingLabels{
+		"app.kubernetes.io/part-of":    cr.Name,
+		"app.kubernetes.io/managed-by": "devstagingenvironment-operator",
+		"app.kubernetes.io/component":  "job",
+	}); err != nil {
+		return err
+	}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if wanted[job.Name] {
+			continue
+		}
+		logger.Info("Pruning orphaned Job", "name", job.Name)
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// dependenciesAvailable reports whether every dependency has an available pod.
+func (r *DevStagingEnvironmentReconciler) dependenciesAvailable(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) bool {
+	for _, dep := range cr.Spec.Dependencies {
+		if !r.dependencyAvailable(ctx, cr, dep) {
+			return false
+		}
+	}
+	return true
+}
+
+// updateJobStatus records the phase of each of spec.jobs in status.jobs
+// and sets the JobsComplete condition from them. A Job left over from an
+// earlier spec counts as pending. It returns whether every job has
+// succeeded; CRs without jobs carry no condition and are always done.
+func (r *DevStagingEnvironmentReconciler) updateJobStatus(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) bool {
+	cr.Status.Jobs = nil
+	if len(cr.Spec.Jobs) == 0 {
+		meta.RemoveStatusCondition(&cr.Status.Conditions, jobsCompleteCondition)
+		return true
+	}
+
+	var failed, pending []string
+	for _, spec := range cr.Spec.Jobs {
+		status := appsv1alpha1.JobStatus{Name: spec.Name, Phase: appsv1alpha1.JobPending}
+		desired := buildAppJob(cr, spec)
+		job := &batchv1.Job{}
+		err := r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: cr.Namespace}, job)
+		if err == nil && job.Annotations[specHashAnnotation] == desired.Annotations[specHashAnnotation] {
+			switch {
+			case job.Status.Succeeded > 0:
+				status.Phase = appsv1alpha1.JobSucceeded
+				status.CompletionTime = job.Status.CompletionTime
+			case jobFailed(job):
+				status.Phase = appsv1alpha1.JobFailed
+				status.Message = jobFailureMessage(job)
+			default:
+				status.Phase = appsv1alpha1.JobRunning
+			}
+		}
+		switch status.Phase {
+		case appsv1alpha1.JobFailed:
+			failed = append(failed, fmt.Sprintf("%s: %s", spec.Name, status.Message))
+		case appsv1alpha1.JobPending, appsv1alpha1.JobRunning:
+			pending = append(pending, spec.Name)
+		}
+		cr.Status.Jobs = append(cr.Status.Jobs, status)
+	}
+
+	condition := metav1.Condition{
+		Type:    jobsCompleteCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "JobsSucceeded",
+		Message: "All jobs completed",
+	}
+	switch {
+	case len(failed) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "JobFailed"
+		condition.Message = strings.Join(failed, "; ")
+	case len(pending) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "JobsRunning"
+		condition.Message = "Waiting for " + strings.Join(pending, ", ")
+	}
+	r.setCondition(cr, condition)
+	return condition.Status == metav1.ConditionTrue
+}
+
 // ────────────────────────────────────────────────────────────────────────────
 // Dependency Helpers
 // ────────────────────────────────────────────────────────────────────────────
 
+// dependencyImage resolves the container image of a dependency: the spec's
+// image override, the default image at the spec's version, or a per-type
+// default tag.
+func dependencyImage(dep appsv1alpha1.DependencySpec, defaults dependencyDefaults) string {
+	if dep.Image != "" {
+		return dep.Image
+	}
+	if dep.Version != "" {
+		return fmt.Sprintf("%s:%s", defaults.Image, dep.Version)
+	}
+	switch dep.Type {
+	case appsv1alpha1.DependencyRabbitMQ:
+		// Use the management tag by default for the UI
+		return defaults.Image + ":3-management"
+	case appsv1alpha1.DependencyElasticsearch:
+		return defaults.Image + ":8.12.0"
+	case appsv1alpha1.DependencyKafka, appsv1alpha1.DependencyJaeger:
+		return defaults.Image + ":latest"
+	}
+	return defaults.Image
+}
+
+// dependencySpecHash is the spec hash of a dependency's workload and
+// Service. The seed is left out so that editing it reruns the seed Job
+// without restarting the dependency.
+func dependencySpecHash(dep appsv1alpha1.DependencySpec) string {
+	dep.Seed = nil
+	return computeSpecHash(dep)
+}
+
 // labelsForDependency returns labels for a dependency's child resources.
 func labelsForDependency(cr *appsv1alpha1.DevStagingEnvironment, depType appsv1alpha1.DependencyType) map[string]string {
 	return map[string]string{
@@ -24,12 +24,15 @@ import (
 	. "github.com/onsi/gomega"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 
 	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
 )
@@ -184,6 +187,75 @@ var _ = Describe("computeSpecHash", func() {
 	})
 })
 
+var _ = Describe("tunnel templates", func() {
+	newCR := func() *appsv1alpha1.DevStagingEnvironment {
+		return &appsv1alpha1.DevStagingEnvironment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web"},
+			Spec: appsv1alpha1.DevStagingEnvironmentSpec{
+				Deployment: appsv1alpha1.DeploymentSpec{
+					Env: []corev1.EnvVar{{Name: "CALLBACK_URL", Value: "${KINDLING_TUNNEL_URL}/auth/callback"}},
+				},
+			},
+		}
+	}
+
+	It("expands the URL and host placeholders", func() {
+		values := map[string]string{"KINDLING_TUNNEL_URL": "https://abc.trycloudflare.com/", "KINDLING_TUNNEL_HOST": "abc.trycloudflare.com"}
+		envs := []corev1.EnvVar{
+			{Name: "CALLBACK_URL", Value: "${KINDLING_TUNNEL_URL}/auth/callback"},
+			{Name: "ALLOWED_HOSTS", Value: "localhost,${KINDLING_TUNNEL_HOST}"},
+			{Name: "PLAIN", Value: "${OTHER}"},
+		}
+		result := expandTunnelTemplates(envs, values)
+		Expect(findEnvVar(result, "CALLBACK_URL")).To(Equal("https://abc.trycloudflare.com/auth/callback"))
+		Expect(findEnvVar(result, "ALLOWED_HOSTS")).To(Equal("localhost,abc.trycloudflare.com"))
+		Expect(findEnvVar(result, "PLAIN")).To(Equal("${OTHER}"))
+		Expect(envs[0].Value).To(Equal("${KINDLING_TUNNEL_URL}/auth/callback"))
+	})
+
+	It("prefers the CR's own tunnel over the default one", func() {
+		values := tunnelValues(newCR(), map[string]string{
+			"url": "https://default.example.com", "hostname": "default.example.com",
+			"web.url": "https://web.example.com", "web.hostname": "web.example.com",
+		})
+		Expect(values["KINDLING_TUNNEL_URL"]).To(Equal("https://web.example.com"))
+		Expect(values["KINDLING_TUNNEL_HOST"]).To(Equal("web.example.com"))
+	})
+
+	It("falls back to the ingress URL without a tunnel", func() {
+		cr := newCR()
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: "web.localhost"}
+		values := tunnelValues(cr, nil)
+		Expect(values["KINDLING_TUNNEL_URL"]).To(Equal("http://web.localhost"))
+		Expect(values["KINDLING_TUNNEL_HOST"]).To(Equal("web.localhost"))
+	})
+
+	It("only reports CRs that use a placeholder", func() {
+		Expect(usesTunnelTemplates(newCR())).To(BeTrue())
+		Expect(usesTunnelTemplates(&appsv1alpha1.DevStagingEnvironment{})).To(BeFalse())
+	})
+
+	It("moves a tunnel: true Ingress onto the tunnel's host without TLS", func() {
+		cr := newCR()
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: "web.localhost", Tunnel: true,
+			TLS: &appsv1alpha1.IngressTLSSpec{SecretName: "web-tls"}}
+		Expect(followsTunnel(cr)).To(BeTrue())
+
+		routeIngressThroughTunnel(cr, tunnelHost(cr, map[string]string{"hostname": "abc.trycloudflare.com"}))
+		Expect(cr.Spec.Ingress.Host).To(Equal("abc.trycloudflare.com"))
+		Expect(cr.Spec.Ingress.TLS).To(BeNil())
+		Expect(cr.Spec.Ingress.Annotations).To(HaveKeyWithValue(tunnelHostAnnotation, "abc.trycloudflare.com"))
+	})
+
+	It("keeps the Ingress host while no tunnel is running", func() {
+		cr := newCR()
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: "web.localhost", Tunnel: true}
+		routeIngressThroughTunnel(cr, tunnelHost(cr, nil))
+		Expect(cr.Spec.Ingress.Host).To(Equal("web.localhost"))
+		Expect(cr.Spec.Ingress.Annotations).NotTo(HaveKey(tunnelHostAnnotation))
+	})
+})
+
 var _ = Describe("dependencyName", func() {
 	It("returns crName-depType", func() {
 		Expect(dependencyName("myapp", appsv1alpha1.DependencyPostgres)).To(Equal("myapp-postgres"))
@@ -202,6 +274,25 @@ var _ = Describe("buildDeployment", func() {
 		r = &DevStagingEnvironmentReconciler{}
 	})
 
+	It("applies the pull policy to the containers that run the app image", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Deployment.ImagePullPolicy = corev1.PullAlways
+		cr.Spec.Deployment.InitContainers = []appsv1alpha1.InitContainerSpec{
+			{Name: "render"},
+			{Name: "fetch", Image: "curlimages/curl:8.8.0"},
+		}
+		pod := r.buildDeployment(cr).Spec.Template.Spec
+
+		Expect(pod.Containers[0].ImagePullPolicy).To(Equal(corev1.PullAlways))
+		Expect(pod.InitContainers[0].ImagePullPolicy).To(Equal(corev1.PullAlways))
+		Expect(pod.InitContainers[1].ImagePullPolicy).To(BeEmpty())
+
+		job := buildAppJob(cr, appsv1alpha1.JobSpec{Name: "migrate"})
+		Expect(job.Spec.Template.Spec.Containers[0].ImagePullPolicy).To(Equal(corev1.PullAlways))
+		job = buildAppJob(cr, appsv1alpha1.JobSpec{Name: "seed", Image: "seeder:1"})
+		Expect(job.Spec.Template.Spec.Containers[0].ImagePullPolicy).To(BeEmpty())
+	})
+
 	It("builds a Deployment with correct labels and container spec", func() {
 		cr := newTestDSE("test-app")
 		deploy := r.buildDeployment(cr)
@@ -229,6 +320,44 @@ var _ = Describe("buildDeployment", func() {
 		Expect(names).To(ContainElements("DATABASE_URL", "REDIS_URL"))
 	})
 
+	It("runs user init containers after the dependency waits, with the app's env", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Dependencies = []appsv1alpha1.DependencySpec{{Type: appsv1alpha1.DependencyPostgres}}
+		cr.Spec.Deployment.Env = []corev1.EnvVar{{Name: "MODE", Value: "dev"}}
+		cr.Spec.Deployment.InitContainers = []appsv1alpha1.InitContainerSpec{
+			{Name: "render-config", Command: []string{"./render"}},
+			{Name: "fetch", Image: "curlimages/curl:8.8.0", Env: []corev1.EnvVar{{Name: "MODE", Value: "init"}}},
+		}
+		deploy := r.buildDeployment(cr)
+
+		inits := deploy.Spec.Template.Spec.InitContainers
+		Expect(inits).To(HaveLen(3))
+		Expect(inits[0].Name).To(Equal("wait-for-postgres"))
+		Expect(inits[1].Name).To(Equal("render-config"))
+		Expect(inits[1].Image).To(Equal("my-image:latest"))
+		Expect(inits[1].Command).To(Equal([]string{"./render"}))
+		Expect(envVarNames(inits[1].Env)).To(ContainElements("DATABASE_URL", "MODE"))
+		Expect(inits[2].Image).To(Equal("curlimages/curl:8.8.0"))
+		Expect(envVarsToMap(inits[2].Env)).To(HaveKeyWithValue("MODE", "init"))
+	})
+
+	It("runs sidecars as restartable init containers after the user's", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Deployment.InitContainers = []appsv1alpha1.InitContainerSpec{{Name: "render-config"}}
+		cr.Spec.Deployment.Sidecars = []appsv1alpha1.SidecarSpec{{
+			Name:  "log-shipper",
+			Image: "fluent/fluent-bit:3.1",
+			Env:   []corev1.EnvVar{{Name: "OUTPUT", Value: "stdout"}},
+		}}
+		inits := r.buildDeployment(cr).Spec.Template.Spec.InitContainers
+		Expect(inits).To(HaveLen(2))
+		Expect(inits[0].RestartPolicy).To(BeNil())
+		Expect(inits[1].Name).To(Equal("log-shipper"))
+		Expect(inits[1].Image).To(Equal("fluent/fluent-bit:3.1"))
+		Expect(*inits[1].RestartPolicy).To(Equal(corev1.ContainerRestartPolicyAlways))
+		Expect(envVarsToMap(inits[1].Env)).To(Equal(map[string]string{"OUTPUT": "stdout"}))
+	})
+
 	It("sets a spec-hash annotation", func() {
 		cr := newTestDSE("test-app")
 		deploy := r.buildDeployment(cr)
@@ -261,6 +390,311 @@ var _ = Describe("buildDeployment", func() {
 		Expect(container.ReadinessProbe).NotTo(BeNil())
 		Expect(container.LivenessProbe.HTTPGet.Path).To(Equal("/healthz"))
 	})
+
+	It("uses a TCP socket probe when the health check type is tcp", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Deployment.HealthCheck = &appsv1alpha1.HealthCheckSpec{
+			Type: "tcp",
+		}
+		deploy := r.buildDeployment(cr)
+		container := deploy.Spec.Template.Spec.Containers[0]
+		Expect(container.LivenessProbe.HTTPGet).To(BeNil())
+		Expect(container.LivenessProbe.TCPSocket).NotTo(BeNil())
+		Expect(container.LivenessProbe.TCPSocket.Port.IntValue()).To(Equal(int(cr.Spec.Deployment.Port)))
+	})
+
+	It("applies nodeSelector and affinity when specified", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Deployment.NodeSelector = map[string]string{"kindling.dev/worker": "2"}
+		cr.Spec.Deployment.Affinity = &corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+					Weight: 100,
+					PodAffinityTerm: corev1.PodAffinityTerm{
+						TopologyKey:   "kubernetes.io/hostname",
+						LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": "test-app"}},
+					},
+				}},
+			},
+		}
+		deploy := r.buildDeployment(cr)
+		podSpec := deploy.Spec.Template.Spec
+		Expect(podSpec.NodeSelector).To(HaveKeyWithValue("kindling.dev/worker", "2"))
+		Expect(podSpec.Affinity).NotTo(BeNil())
+		Expect(podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+	})
+
+	It("passes envFrom sources through to the container", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Deployment.EnvFrom = []corev1.EnvFromSource{{
+			SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "kindling-env-test-app"}},
+		}}
+		deploy := r.buildDeployment(cr)
+		container := deploy.Spec.Template.Spec.Containers[0]
+		Expect(container.EnvFrom).To(HaveLen(1))
+		Expect(container.EnvFrom[0].SecretRef.Name).To(Equal("kindling-env-test-app"))
+	})
+
+	It("mounts a claim per volume, or a node directory for a hostPath volume", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Deployment.Volumes = []appsv1alpha1.VolumeSpec{
+			{Name: "uploads", MountPath: "/app/uploads"},
+			{Name: "cache", MountPath: "/var/cache/app", HostPath: "/kindling/cache"},
+		}
+		podSpec := r.buildDeployment(cr).Spec.Template.Spec
+		Expect(podSpec.Volumes).To(HaveLen(2))
+		Expect(podSpec.Volumes[0].PersistentVolumeClaim.ClaimName).To(Equal("test-app-uploads"))
+		Expect(podSpec.Volumes[1].HostPath.Path).To(Equal("/kindling/cache"))
+		Expect(*podSpec.Volumes[1].HostPath.Type).To(Equal(corev1.HostPathDirectoryOrCreate))
+		Expect(podSpec.Containers[0].VolumeMounts).To(ConsistOf(
+			corev1.VolumeMount{Name: "uploads", MountPath: "/app/uploads"},
+			corev1.VolumeMount{Name: "cache", MountPath: "/var/cache/app"},
+		))
+
+		claim := buildAppVolumeClaim(cr, cr.Spec.Deployment.Volumes[0])
+		Expect(claim.Spec.Resources.Requests.Storage().String()).To(Equal(defaultStorageSize))
+		Expect(claim.Spec.StorageClassName).To(BeNil())
+	})
+})
+
+var _ = Describe("buildDependencyStatefulSet", func() {
+	It("gives stateful dependencies a data claim mounted at their data path", func() {
+		cr := newTestDSE("test-app")
+		dep := appsv1alpha1.DependencySpec{Type: appsv1alpha1.DependencyPostgres}
+		defaults := dependencyRegistry[appsv1alpha1.DependencyPostgres]
+		podSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "postgres"}}}
+
+		sts := buildDependencyStatefulSet(cr, dep, defaults, podSpec)
+		Expect(sts.Name).To(Equal("test-app-postgres"))
+		Expect(sts.Spec.ServiceName).To(Equal("test-app-postgres"))
+		Expect(sts.Spec.VolumeClaimTemplates).To(HaveLen(1))
+		claim := sts.Spec.VolumeClaimTemplates[0]
+		Expect(claim.Name).To(Equal("data"))
+		Expect(claim.Spec.Resources.Requests.Storage().String()).To(Equal(defaultStorageSize))
+		Expect(sts.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(
+			corev1.VolumeMount{Name: "data", MountPath: defaults.DataPath}))
+		Expect(sts.Spec.PersistentVolumeClaimRetentionPolicy.WhenDeleted).To(Equal(appsv1.DeletePersistentVolumeClaimRetentionPolicyType))
+		Expect(podSpec.Containers[0].VolumeMounts).To(BeEmpty(), "the caller's pod spec must not be modified")
+	})
+
+	It("keeps redis's data at /data", func() {
+		dep := appsv1alpha1.DependencySpec{Type: appsv1alpha1.DependencyRedis}
+		defaults := dependencyRegistry[appsv1alpha1.DependencyRedis]
+		Expect(defaults.Stateful).To(BeTrue())
+		podSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "redis"}}}
+
+		sts := buildDependencyStatefulSet(newTestDSE("test-app"), dep, defaults, podSpec)
+		Expect(sts.Name).To(Equal("test-app-redis"))
+		Expect(sts.Spec.VolumeClaimTemplates).To(HaveLen(1))
+		Expect(sts.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(
+			corev1.VolumeMount{Name: "data", MountPath: "/data"}))
+	})
+
+	It("sizes the claim from storageSize", func() {
+		size := resource.MustParse("5Gi")
+		dep := appsv1alpha1.DependencySpec{Type: appsv1alpha1.DependencyMySQL, StorageSize: &size}
+		podSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "mysql"}}}
+		sts := buildDependencyStatefulSet(newTestDSE("test-app"), dep, dependencyRegistry[dep.Type], podSpec)
+		Expect(sts.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests.Storage().String()).To(Equal("5Gi"))
+	})
+
+	It("has a data path for every stateful dependency type", func() {
+		for depType, defaults := range dependencyRegistry {
+			if defaults.Stateful {
+				Expect(defaults.DataPath).NotTo(BeEmpty(), "dependency %s", depType)
+			}
+		}
+	})
+})
+
+var _ = Describe("buildDependencySeedJob", func() {
+	It("returns nil when the dependency has no seed", func() {
+		dep := appsv1alpha1.DependencySpec{Type: appsv1alpha1.DependencyPostgres}
+		Expect(buildDependencySeedJob(newTestDSE("test-app"), dep, dependencyRegistry[dep.Type])).To(BeNil())
+	})
+
+	It("applies a ConfigMap of SQL files with the built-in loader", func() {
+		dep := appsv1alpha1.DependencySpec{
+			Type: appsv1alpha1.DependencyPostgres,
+			Seed: &appsv1alpha1.SeedSpec{ConfigMap: "test-app-seed-files"},
+		}
+		job := buildDependencySeedJob(newTestDSE("test-app"), dep, dependencyRegistry[dep.Type])
+		Expect(job).NotTo(BeNil())
+		Expect(job.Name).To(Equal("test-app-postgres-seed"))
+		Expect(*job.Spec.BackoffLimit).To(Equal(int32(defaultSeedBackoffLimit)))
+
+		pod := job.Spec.Template.Spec
+		Expect(pod.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+		Expect(pod.InitContainers).To(HaveLen(1))
+		Expect(pod.InitContainers[0].Name).To(Equal("wait-for-postgres"))
+		Expect(pod.Volumes[0].ConfigMap.Name).To(Equal("test-app-seed-files"))
+
+		container := pod.Containers[0]
+		Expect(container.Image).To(Equal("postgres"))
+		Expect(container.Command[2]).To(ContainSubstring(`psql "$SEED_URL"`))
+		Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "seed", MountPath: seedDir, ReadOnly: true}))
+		Expect(envVarNames(container.Env)).To(ContainElements("SEED_URL", "DATABASE_URL", "POSTGRES_PASSWORD"))
+	})
+
+	It("runs a custom command in a custom image", func() {
+		backoff := int32(0)
+		dep := appsv1alpha1.DependencySpec{
+			Type: appsv1alpha1.DependencyKafka,
+			Seed: &appsv1alpha1.SeedSpec{
+				Image:        "my-fixtures:latest",
+				Command:      []string{"./load-topics"},
+				Args:         []string{"--all"},
+				BackoffLimit: &backoff,
+			},
+		}
+		job := buildDependencySeedJob(newTestDSE("test-app"), dep, dependencyRegistry[dep.Type])
+		Expect(job).NotTo(BeNil())
+		container := job.Spec.Template.Spec.Containers[0]
+		Expect(container.Image).To(Equal("my-fixtures:latest"))
+		Expect(container.Command).To(Equal([]string{"./load-topics"}))
+		Expect(container.Args).To(Equal([]string{"--all"}))
+		Expect(job.Spec.Template.Spec.Volumes).To(BeEmpty())
+		Expect(*job.Spec.BackoffLimit).To(Equal(int32(0)))
+	})
+
+	It("returns nil for a ConfigMap seed of a type without a built-in loader", func() {
+		dep := appsv1alpha1.DependencySpec{
+			Type: appsv1alpha1.DependencyKafka,
+			Seed: &appsv1alpha1.SeedSpec{ConfigMap: "topics"},
+		}
+		Expect(buildDependencySeedJob(newTestDSE("test-app"), dep, dependencyRegistry[dep.Type])).To(BeNil())
+	})
+
+	It("does not label the seed pod like the dependency it seeds", func() {
+		cr := newTestDSE("test-app")
+		dep := appsv1alpha1.DependencySpec{
+			Type: appsv1alpha1.DependencyRedis,
+			Seed: &appsv1alpha1.SeedSpec{ConfigMap: "keys"},
+		}
+		job := buildDependencySeedJob(cr, dep, dependencyRegistry[dep.Type])
+		Expect(job.Spec.Template.Labels["app.kubernetes.io/name"]).NotTo(Equal(dependencyName(cr.Name, dep.Type)))
+	})
+
+	It("does not change the dependency's spec hash when the seed changes", func() {
+		dep := appsv1alpha1.DependencySpec{Type: appsv1alpha1.DependencyPostgres}
+		seeded := dep
+		seeded.Seed = &appsv1alpha1.SeedSpec{ConfigMap: "fixtures"}
+		Expect(dependencySpecHash(seeded)).To(Equal(dependencySpecHash(dep)))
+	})
+})
+
+var _ = Describe("buildCronJob", func() {
+	It("runs the app's pod on the schedule, without probes", func() {
+		r := &DevStagingEnvironmentReconciler{}
+		cr := newTestDSE("test-app")
+		cr.Spec.Deployment.Schedule = "*/5 * * * *"
+		cr.Spec.Deployment.HealthCheck = &appsv1alpha1.HealthCheckSpec{Path: "/healthz"}
+		cr.Spec.Dependencies = []appsv1alpha1.DependencySpec{{Type: appsv1alpha1.DependencyRedis}}
+		cronJob := r.buildCronJob(cr)
+
+		Expect(cronJob.Name).To(Equal("test-app"))
+		Expect(cronJob.Spec.Schedule).To(Equal("*/5 * * * *"))
+		Expect(cronJob.Spec.ConcurrencyPolicy).To(Equal(batchv1.ForbidConcurrent))
+		Expect(cronJob.Annotations).To(HaveKey(specHashAnnotation))
+
+		pod := cronJob.Spec.JobTemplate.Spec.Template
+		Expect(pod.Labels).To(Equal(labelsForCR(cr)))
+		Expect(pod.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+		Expect(pod.Spec.InitContainers[0].Name).To(Equal("wait-for-redis"))
+		container := pod.Spec.Containers[0]
+		Expect(container.Image).To(Equal("my-image:latest"))
+		Expect(container.LivenessProbe).To(BeNil())
+		Expect(container.ReadinessProbe).To(BeNil())
+		Expect(envVarNames(container.Env)).To(ContainElement("REDIS_URL"))
+	})
+
+	It("reports when the CronJob last ran", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Deployment.Schedule = "@hourly"
+		Expect(cronJobCondition(cr, &batchv1.CronJob{}, false).Reason).To(Equal("CronJobNotFound"))
+
+		cronJob := &batchv1.CronJob{}
+		c := cronJobCondition(cr, cronJob, true)
+		Expect(c.Status).To(Equal(metav1.ConditionTrue))
+		Expect(c.Message).To(ContainSubstring("has not run yet"))
+
+		last := metav1.NewTime(time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC))
+		cronJob.Status.LastScheduleTime = &last
+		Expect(cronJobCondition(cr, cronJob, true).Message).To(ContainSubstring("last run at 2026-01-02T03:04:00Z"))
+	})
+})
+
+var _ = Describe("buildAppJob", func() {
+	It("runs in the app's image with the app's env and dependency waits", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Dependencies = []appsv1alpha1.DependencySpec{{Type: appsv1alpha1.DependencyPostgres}}
+		cr.Spec.Deployment.Env = []corev1.EnvVar{{Name: "MODE", Value: "dev"}}
+		job := buildAppJob(cr, appsv1alpha1.JobSpec{Name: "migrate", Command: []string{"npm", "run", "migrate"}})
+
+		Expect(job.Name).To(Equal("test-app-migrate"))
+		Expect(*job.Spec.BackoffLimit).To(Equal(int32(defaultJobBackoffLimit)))
+		Expect(job.Annotations).To(HaveKey(specHashAnnotation))
+
+		pod := job.Spec.Template.Spec
+		Expect(pod.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+		Expect(pod.InitContainers).To(HaveLen(1))
+		Expect(pod.InitContainers[0].Name).To(Equal("wait-for-postgres"))
+
+		container := pod.Containers[0]
+		Expect(container.Image).To(Equal("my-image:latest"))
+		Expect(container.Command).To(Equal([]string{"npm", "run", "migrate"}))
+		Expect(envVarNames(container.Env)).To(ContainElements("DATABASE_URL", "MODE"))
+	})
+
+	It("uses the job's image, env, and backoffLimit when set", func() {
+		backoff := int32(0)
+		job := buildAppJob(newTestDSE("test-app"), appsv1alpha1.JobSpec{
+			Name:         "load-fixtures",
+			Image:        "my-fixtures:latest",
+			Env:          []corev1.EnvVar{{Name: "FIXTURES", Value: "small"}},
+			BackoffLimit: &backoff,
+		})
+		container := job.Spec.Template.Spec.Containers[0]
+		Expect(container.Image).To(Equal("my-fixtures:latest"))
+		Expect(envVarsToMap(container.Env)).To(HaveKeyWithValue("FIXTURES", "small"))
+		Expect(*job.Spec.BackoffLimit).To(Equal(int32(0)))
+	})
+
+	It("schedules the job pod like the app", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Deployment.NodeSelector = map[string]string{"kindling.dev/worker": "2"}
+		cr.Spec.Deployment.Affinity = &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key:      "kubernetes.io/arch",
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{"arm64"},
+						}},
+					}},
+				},
+			},
+		}
+		pod := buildAppJob(cr, appsv1alpha1.JobSpec{Name: "migrate"}).Spec.Template.Spec
+		Expect(pod.NodeSelector).To(HaveKeyWithValue("kindling.dev/worker", "2"))
+		Expect(pod.Affinity).To(Equal(cr.Spec.Deployment.Affinity))
+	})
+
+	It("reruns when the app image changes", func() {
+		cr := newTestDSE("test-app")
+		spec := appsv1alpha1.JobSpec{Name: "migrate"}
+		before := buildAppJob(cr, spec).Annotations[specHashAnnotation]
+		cr.Spec.Deployment.Image = "my-image:v2"
+		Expect(buildAppJob(cr, spec).Annotations[specHashAnnotation]).NotTo(Equal(before))
+	})
+
+	It("does not label the job pod like the app", func() {
+		cr := newTestDSE("test-app")
+		job := buildAppJob(cr, appsv1alpha1.JobSpec{Name: "migrate"})
+		Expect(job.Spec.Template.Labels).NotTo(HaveKeyWithValue("app.kubernetes.io/instance", cr.Name))
+		Expect(job.Spec.Template.Labels).To(HaveKeyWithValue("app.kubernetes.io/component", "job"))
+	})
 })
 
 var _ = Describe("buildService", func() {
@@ -300,6 +734,26 @@ var _ = Describe("buildService", func() {
 		svc := r.buildService(cr)
 		Expect(svc.Spec.Ports[0].TargetPort.IntValue()).To(Equal(9090))
 	})
+
+	It("marks the port as h2c for a gRPC ingress", func() {
+		cr := newTestDSE("test-app")
+		plain := r.buildService(cr)
+		Expect(plain.Spec.Ports[0].AppProtocol).To(BeNil())
+
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Protocol: "grpc"}
+		svc := r.buildService(cr)
+		Expect(svc.Spec.Ports[0].AppProtocol).NotTo(BeNil())
+		Expect(*svc.Spec.Ports[0].AppProtocol).To(Equal("kubernetes.io/h2c"))
+		Expect(svc.Annotations[specHashAnnotation]).NotTo(Equal(plain.Annotations[specHashAnnotation]))
+	})
+
+	It("sets Traefik's h2c server scheme for a gRPC ingress on the traefik class", func() {
+		cr := newTestDSE("test-app")
+		traefik := "traefik"
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Protocol: "grpc", IngressClassName: &traefik}
+		svc := r.buildService(cr)
+		Expect(svc.Annotations).To(HaveKeyWithValue("traefik.ingress.kubernetes.io/service.serversscheme", "h2c"))
+	})
 })
 
 var _ = Describe("buildIngress", func() {
@@ -363,6 +817,155 @@ var _ = Describe("buildIngress", func() {
 		Expect(ing.Annotations).To(HaveKey("custom-annotation"))
 		Expect(ing.Annotations).To(HaveKey(specHashAnnotation))
 	})
+
+	It("sets the gRPC backend protocol for ingress-nginx", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{
+			Enabled:  true,
+			Host:     "test-app.localhost",
+			Protocol: "grpc",
+		}
+		ing := r.buildIngress(cr)
+		Expect(ing.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/backend-protocol", "GRPC"))
+	})
+
+	It("raises the ingress-nginx timeouts for WebSockets", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{
+			Enabled:  true,
+			Host:     "test-app.localhost",
+			Protocol: "websocket",
+		}
+		ing := r.buildIngress(cr)
+		Expect(ing.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/proxy-read-timeout", "3600"))
+		Expect(ing.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/proxy-send-timeout", "3600"))
+	})
+
+	It("opts the route in to WebSocket upgrades under Contour", func() {
+		cr := newTestDSE("test-app")
+		contour := "contour"
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{
+			Enabled:          true,
+			Host:             "test-app.localhost",
+			Path:             "/ws",
+			IngressClassName: &contour,
+			Protocol:         "websocket",
+		}
+		ing := r.buildIngress(cr)
+		Expect(ing.Annotations).To(HaveKeyWithValue("projectcontour.io/websocket-routes", "/ws"))
+		Expect(ing.Annotations).NotTo(HaveKey("nginx.ingress.kubernetes.io/proxy-read-timeout"))
+	})
+
+	It("adds no protocol annotations to a Traefik ingress", func() {
+		cr := newTestDSE("test-app")
+		traefik := "traefik"
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{
+			Enabled:          true,
+			Host:             "test-app.localhost",
+			IngressClassName: &traefik,
+			Protocol:         "websocket",
+		}
+		ing := r.buildIngress(cr)
+		Expect(ing.Annotations).To(HaveLen(1))
+		Expect(ing.Annotations).To(HaveKey(specHashAnnotation))
+	})
+
+	It("lets user annotations override the protocol annotations", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{
+			Enabled:  true,
+			Host:     "test-app.localhost",
+			Protocol: "grpc",
+			Annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/backend-protocol": "GRPCS",
+			},
+		}
+		ing := r.buildIngress(cr)
+		Expect(ing.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/backend-protocol", "GRPCS"))
+	})
+})
+
+var _ = Describe("status conditions", func() {
+	It("reports ComponentsReady from the Deployment and Service", func() {
+		cr := newTestDSE("test-app")
+		deploy := &appsv1.Deployment{}
+		deploy.Status.Replicas, deploy.Status.AvailableReplicas = 2, 1
+
+		Expect(componentsCondition(cr, deploy, false).Reason).To(Equal("DeploymentNotFound"))
+
+		cr.Status.ServiceReady = true
+		c := componentsCondition(cr, deploy, true)
+		Expect(c.Status).To(Equal(metav1.ConditionFalse))
+		Expect(c.Reason).To(Equal("ReplicasUnavailable"))
+		Expect(c.Message).To(ContainSubstring("1/2"))
+
+		cr.Status.DeploymentReady = true
+		Expect(componentsCondition(cr, deploy, true).Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("treats a disabled ingress as ready", func() {
+		cr := newTestDSE("test-app")
+		c := ingressCondition(cr)
+		Expect(c.Status).To(Equal(metav1.ConditionTrue))
+		Expect(c.Reason).To(Equal("IngressDisabled"))
+
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: "test-app.localhost"}
+		Expect(ingressCondition(cr).Reason).To(Equal("IngressNotFound"))
+
+		cr.Status.IngressReady, cr.Status.URL = true, "http://test-app.localhost"
+		c = ingressCondition(cr)
+		Expect(c.Status).To(Equal(metav1.ConditionTrue))
+		Expect(c.Message).To(ContainSubstring("http://test-app.localhost"))
+	})
+
+	It("names the dependencies that are not available", func() {
+		cr := newTestDSE("test-app")
+		Expect(dependenciesCondition(cr, nil).Reason).To(Equal("NoDependencies"))
+
+		cr.Spec.Dependencies = []appsv1alpha1.DependencySpec{{Type: appsv1alpha1.DependencyPostgres}}
+		c := dependenciesCondition(cr, []string{"test-app-postgres"})
+		Expect(c.Status).To(Equal(metav1.ConditionFalse))
+		Expect(c.Message).To(Equal("Waiting for test-app-postgres"))
+		Expect(dependenciesCondition(cr, nil).Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("names the upstream components that are not available", func() {
+		r := &DevStagingEnvironmentReconciler{}
+		cr := newTestDSE("test-app")
+		r.setUpstreamsCondition(cr, nil, nil)
+		Expect(meta.FindStatusCondition(cr.Status.Conditions, upstreamsReadyCondition)).To(BeNil())
+
+		cr.Spec.DependsOn = []string{"users", "orders"}
+		r.setUpstreamsCondition(cr, []string{"orders"}, nil)
+		c := meta.FindStatusCondition(cr.Status.Conditions, upstreamsReadyCondition)
+		Expect(c.Status).To(Equal(metav1.ConditionFalse))
+		Expect(c.Message).To(Equal("Waiting for orders"))
+
+		r.setUpstreamsCondition(cr, []string{"orders"}, []string{"test-app", "orders", "test-app"})
+		c = meta.FindStatusCondition(cr.Status.Conditions, upstreamsReadyCondition)
+		Expect(c.Status).To(Equal(metav1.ConditionFalse))
+		Expect(c.Reason).To(Equal(ReasonDependsOnCycle))
+		Expect(c.Message).To(Equal("dependsOn cycle test-app → orders → test-app — none of them would start"))
+
+		r.setUpstreamsCondition(cr, nil, nil)
+		Expect(meta.IsStatusConditionTrue(cr.Status.Conditions, upstreamsReadyCondition)).To(BeTrue())
+	})
+
+	It("emits an Event only when a condition changes", func() {
+		recorder := record.NewFakeRecorder(10)
+		r := &DevStagingEnvironmentReconciler{Recorder: recorder}
+		cr := newTestDSE("test-app")
+		failing := metav1.Condition{Type: imagesBuiltCondition, Status: metav1.ConditionFalse, Reason: "ImagePullFailed", Message: "my-image:latest: ErrImagePull"}
+
+		r.setCondition(cr, failing)
+		r.setCondition(cr, failing)
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(HavePrefix("Warning ImagePullFailed ImagesBuilt:"))
+
+		r.setCondition(cr, metav1.Condition{Type: imagesBuiltCondition, Status: metav1.ConditionTrue, Reason: "ImagesAvailable", Message: "Pulled my-image:latest"})
+		Expect(<-recorder.Events).To(HavePrefix("Normal ImagesAvailable"))
+		Expect(meta.IsStatusConditionTrue(cr.Status.Conditions, imagesBuiltCondition)).To(BeTrue())
+	})
 })
 
 // ────────────────────────────────────────────────────────────────────────────
@@ -403,6 +1006,19 @@ var _ = Describe("DevStagingEnvironment Reconciler", func() {
 			Expect(svc.Spec.Ports[0].Port).To(Equal(int32(80)))
 		})
 
+		It("should report status conditions", func() {
+			// envtest runs no kubelet, so the Deployment never becomes available.
+			Eventually(func(g Gomega) {
+				updated := &appsv1alpha1.DevStagingEnvironment{}
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: "default"}, updated)).To(Succeed())
+				g.Expect(meta.IsStatusConditionTrue(updated.Status.Conditions, ingressReadyCondition)).To(BeTrue())
+				g.Expect(meta.IsStatusConditionTrue(updated.Status.Conditions, dependenciesReadyCondition)).To(BeTrue())
+				components := meta.FindStatusCondition(updated.Status.Conditions, componentsReadyCondition)
+				g.Expect(components).NotTo(BeNil())
+				g.Expect(components.Status).To(Equal(metav1.ConditionFalse))
+			}, timeout, interval).Should(Succeed())
+		})
+
 		It("should NOT create an Ingress when not enabled", func() {
 			ing := &networkingv1.Ingress{}
 			Consistently(func() bool {
@@ -439,14 +1055,194 @@ var _ = Describe("DevStagingEnvironment Reconciler", func() {
 		})
 	})
 
+	Context("when two CRs claim the same ingress route", func() {
+		var first, second *appsv1alpha1.DevStagingEnvironment
+
+		BeforeEach(func() {
+			first = newTestDSE("reconcile-route-first")
+			first.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: "shared.localhost"}
+			Expect(k8sClient.Create(ctx, first)).To(Succeed())
+			// Creation timestamps have one-second resolution.
+			time.Sleep(1100 * time.Millisecond)
+			second = newTestDSE("reconcile-route-second")
+			second.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: "shared.localhost", Path: "/"}
+			Expect(k8sClient.Create(ctx, second)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, first)
+			_ = k8sClient.Delete(ctx, second)
+		})
+
+		It("should hold back the newer CR's Ingress until the route is free", func() {
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: first.Name, Namespace: "default"}, &networkingv1.Ingress{})
+			}, timeout, interval).Should(Succeed())
+
+			secondKey := types.NamespacedName{Name: second.Name, Namespace: "default"}
+			Eventually(func(g Gomega) {
+				updated := &appsv1alpha1.DevStagingEnvironment{}
+				g.Expect(k8sClient.Get(ctx, secondKey, updated)).To(Succeed())
+				network := meta.FindStatusCondition(updated.Status.Conditions, networkValidCondition)
+				g.Expect(network).NotTo(BeNil())
+				g.Expect(network.Reason).To(Equal(ReasonIngressPathConflict))
+				g.Expect(network.Message).To(ContainSubstring("default/reconcile-route-first"))
+			}, timeout, interval).Should(Succeed())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, secondKey, &networkingv1.Ingress{}))).To(BeTrue())
+
+			Expect(k8sClient.Delete(ctx, first)).To(Succeed())
+			Eventually(func() error {
+				return k8sClient.Get(ctx, secondKey, &networkingv1.Ingress{})
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	Context("when a user's Ingress of the same name holds the route", func() {
+		var cr *appsv1alpha1.DevStagingEnvironment
+		var userIngress *networkingv1.Ingress
+
+		BeforeEach(func() {
+			pathType := networkingv1.PathTypePrefix
+			userIngress = &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "reconcile-route-user", Namespace: "default"},
+				Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{
+					Host: "user.localhost",
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+								Name: "user-app", Port: networkingv1.ServiceBackendPort{Number: 80},
+							}},
+						}},
+					}},
+				}}},
+			}
+			Expect(k8sClient.Create(ctx, userIngress)).To(Succeed())
+			cr = newTestDSE("reconcile-route-user")
+			cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: "user.localhost", Path: "/"}
+			Expect(k8sClient.Create(ctx, cr)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, cr)
+			_ = k8sClient.Delete(ctx, userIngress)
+		})
+
+		It("should leave the user's Ingress alone", func() {
+			key := types.NamespacedName{Name: cr.Name, Namespace: "default"}
+			Eventually(func(g Gomega) {
+				updated := &appsv1alpha1.DevStagingEnvironment{}
+				g.Expect(k8sClient.Get(ctx, key, updated)).To(Succeed())
+				network := meta.FindStatusCondition(updated.Status.Conditions, networkValidCondition)
+				g.Expect(network).NotTo(BeNil())
+				g.Expect(network.Reason).To(Equal(ReasonIngressPathConflict))
+			}, timeout, interval).Should(Succeed())
+			Consistently(func(g Gomega) {
+				ing := &networkingv1.Ingress{}
+				g.Expect(k8sClient.Get(ctx, key, ing)).To(Succeed())
+				g.Expect(metav1.GetControllerOf(ing)).To(BeNil())
+				g.Expect(ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name).To(Equal("user-app"))
+			}, time.Second*3, interval).Should(Succeed())
+		})
+	})
+
+	Context("when two CRs enable tracing", func() {
+		var first, second *appsv1alpha1.DevStagingEnvironment
+
+		BeforeEach(func() {
+			first = newTestDSE("reconcile-tracing-first")
+			first.Spec.Observability = &appsv1alpha1.ObservabilitySpec{Tracing: true}
+			Expect(k8sClient.Create(ctx, first)).To(Succeed())
+			second = newTestDSE("reconcile-tracing-second")
+			second.Spec.Observability = &appsv1alpha1.ObservabilitySpec{Tracing: true}
+			Expect(k8sClient.Create(ctx, second)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, first)
+			_ = k8sClient.Delete(ctx, second)
+			_ = k8sClient.Delete(ctx, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: TracingCollectorName, Namespace: "default"}})
+			_ = k8sClient.Delete(ctx, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: TracingCollectorName, Namespace: "default"}})
+		})
+
+		It("should share one collector until the last of them turns tracing off", func() {
+			key := types.NamespacedName{Name: TracingCollectorName, Namespace: "default"}
+			Eventually(func(g Gomega) {
+				collector := &appsv1.Deployment{}
+				g.Expect(k8sClient.Get(ctx, key, collector)).To(Succeed())
+				g.Expect(collector.OwnerReferences).To(HaveLen(2))
+			}, timeout, interval).Should(Succeed())
+
+			for _, cr := range []*appsv1alpha1.DevStagingEnvironment{first, second} {
+				Eventually(func() error {
+					latest := &appsv1alpha1.DevStagingEnvironment{}
+					if err := k8sClient.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: "default"}, latest); err != nil {
+						return err
+					}
+					latest.Spec.Observability = nil
+					return k8sClient.Update(ctx, latest)
+				}, timeout, interval).Should(Succeed())
+			}
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, key, &appsv1.Deployment{}))
+			}, timeout, interval).Should(BeTrue())
+		})
+	})
+
+	Context("when a strict CR is called by another", func() {
+		var strict, caller *appsv1alpha1.DevStagingEnvironment
+
+		BeforeEach(func() {
+			strict = newTestDSE("reconcile-strict")
+			strict.Spec.NetworkPolicies = appsv1alpha1.NetworkPoliciesStrict
+			strict.Spec.Dependencies = []appsv1alpha1.DependencySpec{{Type: appsv1alpha1.DependencyRedis}}
+			Expect(k8sClient.Create(ctx, strict)).To(Succeed())
+			caller = newTestDSE("reconcile-strict-caller")
+			caller.Spec.DependsOn = []string{"reconcile-strict"}
+			Expect(k8sClient.Create(ctx, caller)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, strict)
+			_ = k8sClient.Delete(ctx, caller)
+		})
+
+		It("should admit the caller until the CR turns strict off", func() {
+			appKey := types.NamespacedName{Name: "reconcile-strict-app", Namespace: "default"}
+			depsKey := types.NamespacedName{Name: "reconcile-strict-dependencies", Namespace: "default"}
+			Eventually(func(g Gomega) {
+				policy := &networkingv1.NetworkPolicy{}
+				g.Expect(k8sClient.Get(ctx, appKey, policy)).To(Succeed())
+				g.Expect(policy.Spec.Ingress).To(HaveLen(2))
+				g.Expect(policy.Spec.Ingress[1].From[0].PodSelector.MatchExpressions[0].Values).To(ConsistOf("reconcile-strict-caller"))
+				g.Expect(k8sClient.Get(ctx, depsKey, &networkingv1.NetworkPolicy{})).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() error {
+				latest := &appsv1alpha1.DevStagingEnvironment{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: strict.Name, Namespace: "default"}, latest); err != nil {
+					return err
+				}
+				latest.Spec.NetworkPolicies = ""
+				return k8sClient.Update(ctx, latest)
+			}, timeout, interval).Should(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, appKey, &networkingv1.NetworkPolicy{})) &&
+					errors.IsNotFound(k8sClient.Get(ctx, depsKey, &networkingv1.NetworkPolicy{}))
+			}, timeout, interval).Should(BeTrue())
+		})
+	})
+
 	Context("when a CR with dependencies is created", func() {
 		var cr *appsv1alpha1.DevStagingEnvironment
 
 		BeforeEach(func() {
 			cr = newTestDSE("reconcile-deps")
 			cr.Spec.Dependencies = []appsv1alpha1.DependencySpec{
-				{Type: appsv1alpha1.DependencyPostgres},
+				{Type: appsv1alpha1.DependencyPostgres, NodeSelector: map[string]string{"kindling.dev/worker": "1"}},
 				{Type: appsv1alpha1.DependencyRedis},
+				{Type: appsv1alpha1.DependencyMemcached},
 			}
 			Expect(k8sClient.Create(ctx, cr)).To(Succeed())
 		})
@@ -455,17 +1251,31 @@ var _ = Describe("DevStagingEnvironment Reconciler", func() {
 			_ = k8sClient.Delete(ctx, cr)
 		})
 
-		It("should create dependency Deployments", func() {
-			for _, name := range []string{"reconcile-deps-postgres", "reconcile-deps-redis"} {
-				deploy := &appsv1.Deployment{}
-				Eventually(func() error {
-					return k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, deploy)
-				}, timeout, interval).Should(Succeed(), "expected Deployment %s", name)
-			}
+		It("should create a StatefulSet for a stateful dependency", func() {
+			sts := &appsv1.StatefulSet{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: "reconcile-deps-postgres", Namespace: "default"}, sts)
+			}, timeout, interval).Should(Succeed())
+			Expect(sts.Spec.VolumeClaimTemplates).To(HaveLen(1))
+		})
+
+		It("should keep redis's data in a StatefulSet", func() {
+			sts := &appsv1.StatefulSet{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: "reconcile-deps-redis", Namespace: "default"}, sts)
+			}, timeout, interval).Should(Succeed())
+			Expect(sts.Spec.VolumeClaimTemplates).To(HaveLen(1))
+		})
+
+		It("should create a Deployment for a stateless dependency", func() {
+			deploy := &appsv1.Deployment{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: "reconcile-deps-memcached", Namespace: "default"}, deploy)
+			}, timeout, interval).Should(Succeed())
 		})
 
 		It("should create dependency Services", func() {
-			for _, name := range []string{"reconcile-deps-postgres", "reconcile-deps-redis"} {
+			for _, name := range []string{"reconcile-deps-postgres", "reconcile-deps-redis", "reconcile-deps-memcached"} {
 				svc := &corev1.Service{}
 				Eventually(func() error {
 					return k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, svc)
@@ -473,6 +1283,14 @@ var _ = Describe("DevStagingEnvironment Reconciler", func() {
 			}
 		})
 
+		It("should pin a dependency to its nodeSelector", func() {
+			sts := &appsv1.StatefulSet{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: "reconcile-deps-postgres", Namespace: "default"}, sts)
+			}, timeout, interval).Should(Succeed())
+			Expect(sts.Spec.Template.Spec.NodeSelector).To(HaveKeyWithValue("kindling.dev/worker", "1"))
+		})
+
 		It("should inject connection env vars into the app container", func() {
 			deploy := &appsv1.Deployment{}
 			Eventually(func() error {
@@ -485,30 +1303,343 @@ var _ = Describe("DevStagingEnvironment Reconciler", func() {
 		})
 	})
 
+	Context("when a CR with a canary and a dependency is created", func() {
+		var cr *appsv1alpha1.DevStagingEnvironment
+
+		BeforeEach(func() {
+			cr = newTestDSE("reconcile-canary")
+			cr.Spec.Canary = &appsv1alpha1.CanarySpec{Image: "my-image:canary", Weight: 10}
+			cr.Spec.Dependencies = []appsv1alpha1.DependencySpec{{Type: appsv1alpha1.DependencyRedis}}
+			Expect(k8sClient.Create(ctx, cr)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, cr)
+		})
+
+		It("should keep the canary across reconciles", func() {
+			canaryKey := types.NamespacedName{Name: "reconcile-canary-canary", Namespace: "default"}
+			Eventually(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "reconcile-canary-redis", Namespace: "default"}, &appsv1.StatefulSet{})).To(Succeed())
+				g.Expect(k8sClient.Get(ctx, canaryKey, &appsv1.Deployment{})).To(Succeed())
+				g.Expect(k8sClient.Get(ctx, canaryKey, &corev1.Service{})).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			// Change the spec so that the CR is reconciled again.
+			Eventually(func() error {
+				latest := &appsv1alpha1.DevStagingEnvironment{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: "default"}, latest); err != nil {
+					return err
+				}
+				latest.Spec.Canary.Weight = 50
+				return k8sClient.Update(ctx, latest)
+			}, timeout, interval).Should(Succeed())
+			Consistently(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, canaryKey, &appsv1.Deployment{})).To(Succeed())
+				g.Expect(k8sClient.Get(ctx, canaryKey, &corev1.Service{})).To(Succeed())
+			}, time.Second*3, interval).Should(Succeed())
+		})
+	})
+
+	Context("when a CR depends on another component", func() {
+		var upstream, downstream *appsv1alpha1.DevStagingEnvironment
+
+		BeforeEach(func() {
+			upstream = newTestDSE("reconcile-upstream")
+			downstream = newTestDSE("reconcile-downstream")
+			downstream.Spec.DependsOn = []string{upstream.Name}
+			Expect(k8sClient.Create(ctx, downstream)).To(Succeed())
+			Expect(k8sClient.Create(ctx, upstream)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, downstream)
+			_ = k8sClient.Delete(ctx, upstream)
+		})
+
+		It("should create its Deployment only once the upstream is available", func() {
+			upstreamDeploy := &appsv1.Deployment{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: upstream.Name, Namespace: "default"}, upstreamDeploy)
+			}, timeout, interval).Should(Succeed())
+
+			key := types.NamespacedName{Name: downstream.Name, Namespace: "default"}
+			Consistently(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, key, &appsv1.Deployment{}))
+			}, time.Second*3, interval).Should(BeTrue())
+			Eventually(func(g Gomega) {
+				updated := &appsv1alpha1.DevStagingEnvironment{}
+				g.Expect(k8sClient.Get(ctx, key, updated)).To(Succeed())
+				c := meta.FindStatusCondition(updated.Status.Conditions, upstreamsReadyCondition)
+				g.Expect(c).NotTo(BeNil())
+				g.Expect(c.Message).To(Equal("Waiting for reconcile-upstream"))
+			}, timeout, interval).Should(Succeed())
+
+			// envtest runs no Deployment controller, so mark the pod available.
+			upstreamDeploy.Status.Replicas = 1
+			upstreamDeploy.Status.AvailableReplicas = 1
+			Expect(k8sClient.Status().Update(ctx, upstreamDeploy)).To(Succeed())
+
+			Eventually(func() error {
+				return k8sClient.Get(ctx, key, &appsv1.Deployment{})
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	Context("when two CRs depend on each other", func() {
+		var a, b *appsv1alpha1.DevStagingEnvironment
+
+		BeforeEach(func() {
+			a = newTestDSE("reconcile-cycle-a")
+			b = newTestDSE("reconcile-cycle-b")
+			a.Spec.DependsOn = []string{b.Name}
+			b.Spec.DependsOn = []string{a.Name}
+			Expect(k8sClient.Create(ctx, a)).To(Succeed())
+			Expect(k8sClient.Create(ctx, b)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, a)
+			_ = k8sClient.Delete(ctx, b)
+		})
+
+		It("should report the cycle rather than wait for it", func() {
+			for _, cr := range []*appsv1alpha1.DevStagingEnvironment{a, b} {
+				Eventually(func(g Gomega) {
+					updated := &appsv1alpha1.DevStagingEnvironment{}
+					g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: "default"}, updated)).To(Succeed())
+					c := meta.FindStatusCondition(updated.Status.Conditions, upstreamsReadyCondition)
+					g.Expect(c).NotTo(BeNil())
+					g.Expect(c.Status).To(Equal(metav1.ConditionFalse))
+					g.Expect(c.Reason).To(Equal(ReasonDependsOnCycle))
+					g.Expect(c.Message).To(ContainSubstring(cr.Name + " → "))
+				}, timeout, interval).Should(Succeed())
+			}
+		})
+	})
+
+	Context("when a dependency declares a seed", func() {
+		var cr *appsv1alpha1.DevStagingEnvironment
+
+		BeforeEach(func() {
+			cr = newTestDSE("reconcile-seed")
+			cr.Spec.Dependencies = []appsv1alpha1.DependencySpec{{
+				Type: appsv1alpha1.DependencyPostgres,
+				Seed: &appsv1alpha1.SeedSpec{ConfigMap: "reconcile-seed-files"},
+			}}
+			Expect(k8sClient.Create(ctx, cr)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, cr)
+		})
+
+		It("should run the seed Job only once the dependency is available", func() {
+			key := types.NamespacedName{Name: "reconcile-seed-postgres", Namespace: "default"}
+			sts := &appsv1.StatefulSet{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, key, sts)
+			}, timeout, interval).Should(Succeed())
+
+			jobKey := types.NamespacedName{Name: "reconcile-seed-postgres-seed", Namespace: "default"}
+			Consistently(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, jobKey, &batchv1.Job{}))
+			}, time.Second*3, interval).Should(BeTrue())
+
+			// envtest runs no StatefulSet controller, so mark the pod available.
+			sts.Status.Replicas = 1
+			sts.Status.AvailableReplicas = 1
+			Expect(k8sClient.Status().Update(ctx, sts)).To(Succeed())
+
+			job := &batchv1.Job{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, jobKey, job)
+			}, timeout, interval).Should(Succeed())
+			Expect(job.OwnerReferences[0].Name).To(Equal("reconcile-seed"))
+
+			Eventually(func(g Gomega) string {
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: "default"}, cr)).To(Succeed())
+				c := meta.FindStatusCondition(cr.Status.Conditions, seedConditionType)
+				g.Expect(c).NotTo(BeNil())
+				return c.Reason
+			}, timeout, interval).Should(Equal("Seeding"))
+		})
+	})
+
+	Context("when a CR declares jobs", func() {
+		var cr *appsv1alpha1.DevStagingEnvironment
+
+		BeforeEach(func() {
+			cr = newTestDSE("reconcile-jobs")
+			cr.Spec.Jobs = []appsv1alpha1.JobSpec{{Name: "migrate", Command: []string{"./migrate"}}}
+			Expect(k8sClient.Create(ctx, cr)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, cr)
+		})
+
+		It("should run the Job and report its outcome in the status", func() {
+			jobKey := types.NamespacedName{Name: "reconcile-jobs-migrate", Namespace: "default"}
+			job := &batchv1.Job{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, jobKey, job)
+			}, timeout, interval).Should(Succeed())
+			Expect(job.OwnerReferences[0].Name).To(Equal("reconcile-jobs"))
+
+			key := types.NamespacedName{Name: cr.Name, Namespace: "default"}
+			Eventually(func(g Gomega) string {
+				g.Expect(k8sClient.Get(ctx, key, cr)).To(Succeed())
+				g.Expect(cr.Status.Jobs).To(HaveLen(1))
+				return cr.Status.Jobs[0].Phase
+			}, timeout, interval).Should(Equal(appsv1alpha1.JobRunning))
+			Expect(meta.FindStatusCondition(cr.Status.Conditions, jobsCompleteCondition).Reason).To(Equal("JobsRunning"))
+
+			// envtest runs no Job controller, so mark the Job as failed.
+			now := metav1.Now()
+			job.Status.StartTime = &now
+			job.Status.Failed = 4
+			job.Status.Conditions = []batchv1.JobCondition{
+				{Type: batchv1.JobFailureTarget, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"},
+				{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"},
+			}
+			Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+
+			Eventually(func(g Gomega) string {
+				g.Expect(k8sClient.Get(ctx, key, cr)).To(Succeed())
+				g.Expect(cr.Status.Jobs).To(HaveLen(1))
+				return cr.Status.Jobs[0].Phase
+			}, timeout, interval).Should(Equal(appsv1alpha1.JobFailed))
+			Expect(cr.Status.Jobs[0].Message).To(ContainSubstring("backoff limit"))
+			Expect(meta.IsStatusConditionTrue(cr.Status.Conditions, readyCondition)).To(BeFalse())
+		})
+
+		It("should delete the Job when it is removed from the spec", func() {
+			jobKey := types.NamespacedName{Name: "reconcile-jobs-migrate", Namespace: "default"}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, jobKey, &batchv1.Job{})
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: "default"}, cr); err != nil {
+					return err
+				}
+				cr.Spec.Jobs = nil
+				return k8sClient.Update(ctx, cr)
+			}, timeout, interval).Should(Succeed())
+
+			// envtest has no garbage collector, so a background delete only
+			// marks the Job for deletion.
+			Eventually(func() bool {
+				job := &batchv1.Job{}
+				err := k8sClient.Get(ctx, jobKey, job)
+				return errors.IsNotFound(err) || !job.DeletionTimestamp.IsZero()
+			}, timeout, interval).Should(BeTrue())
+		})
+	})
+
+	Context("when a CR has a schedule", func() {
+		var cr *appsv1alpha1.DevStagingEnvironment
+
+		BeforeEach(func() {
+			cr = newTestDSE("reconcile-cron")
+			cr.Spec.Deployment.Schedule = "*/5 * * * *"
+			cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: "reconcile-cron.localhost"}
+			Expect(k8sClient.Create(ctx, cr)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, cr)
+		})
+
+		It("should create a CronJob instead of a Deployment, Service, and Ingress", func() {
+			key := types.NamespacedName{Name: cr.Name, Namespace: "default"}
+			cronJob := &batchv1.CronJob{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, key, cronJob)
+			}, timeout, interval).Should(Succeed())
+			Expect(cronJob.Spec.Schedule).To(Equal("*/5 * * * *"))
+
+			Eventually(func(g Gomega) bool {
+				g.Expect(k8sClient.Get(ctx, key, cr)).To(Succeed())
+				return meta.IsStatusConditionTrue(cr.Status.Conditions, readyCondition)
+			}, timeout, interval).Should(BeTrue())
+			Expect(meta.FindStatusCondition(cr.Status.Conditions, componentsReadyCondition).Reason).To(Equal("Scheduled"))
+
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, key, &appsv1.Deployment{}))).To(BeTrue())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, key, &corev1.Service{}))).To(BeTrue())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, key, &networkingv1.Ingress{}))).To(BeTrue())
+		})
+	})
+
+	Context("when a CR has volumes", func() {
+		var cr *appsv1alpha1.DevStagingEnvironment
+
+		BeforeEach(func() {
+			cr = newTestDSE("reconcile-volumes")
+			size := resource.MustParse("5Gi")
+			cr.Spec.Deployment.Volumes = []appsv1alpha1.VolumeSpec{
+				{Name: "uploads", MountPath: "/app/uploads", Size: &size},
+				{Name: "cache", MountPath: "/var/cache/app", HostPath: "/kindling/cache"},
+			}
+			Expect(k8sClient.Create(ctx, cr)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, cr)
+		})
+
+		It("should create a claim only for volumes without a hostPath, and delete it when the volume is removed", func() {
+			claimKey := types.NamespacedName{Name: "reconcile-volumes-uploads", Namespace: "default"}
+			claim := &corev1.PersistentVolumeClaim{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, claimKey, claim)
+			}, timeout, interval).Should(Succeed())
+			Expect(claim.Spec.Resources.Requests.Storage().String()).To(Equal("5Gi"))
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, types.NamespacedName{Name: "reconcile-volumes-cache", Namespace: "default"},
+				&corev1.PersistentVolumeClaim{}))).To(BeTrue())
+
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: "default"}, cr); err != nil {
+					return err
+				}
+				cr.Spec.Deployment.Volumes = cr.Spec.Deployment.Volumes[1:]
+				return k8sClient.Update(ctx, cr)
+			}, timeout, interval).Should(Succeed())
+
+			// The pvc-protection finalizer keeps a deleted claim around while
+			// a pod could still use it.
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, claimKey, claim)
+				return errors.IsNotFound(err) || !claim.DeletionTimestamp.IsZero()
+			}, timeout, interval).Should(BeTrue())
+		})
+	})
+
 	Context("when a CR is deleted", func() {
-		It("should garbage-collect child Deployments via OwnerReferences", func() {
+		It("should garbage-collect child workloads via OwnerReferences", func() {
 			cr := newTestDSE("reconcile-delete")
 			cr.Spec.Dependencies = []appsv1alpha1.DependencySpec{
 				{Type: appsv1alpha1.DependencyPostgres},
 			}
 			Expect(k8sClient.Create(ctx, cr)).To(Succeed())
 
-			// Wait for child Deployment to exist
-			deploy := &appsv1.Deployment{}
+			// Wait for child StatefulSet to exist
+			sts := &appsv1.StatefulSet{}
 			Eventually(func() error {
-				return k8sClient.Get(ctx, types.NamespacedName{Name: "reconcile-delete-postgres", Namespace: "default"}, deploy)
+				return k8sClient.Get(ctx, types.NamespacedName{Name: "reconcile-delete-postgres", Namespace: "default"}, sts)
 			}, timeout, interval).Should(Succeed())
 
 			// Delete the CR
 			Expect(k8sClient.Delete(ctx, cr)).To(Succeed())
 
-			// Child Deployment should be garbage-collected (envtest may not run the GC,
+			// Child StatefulSet should be garbage-collected (envtest may not run the GC,
 			// but at minimum the owner reference should be set correctly)
 			Eventually(func() error {
-				return k8sClient.Get(ctx, types.NamespacedName{Name: "reconcile-delete-postgres", Namespace: "default"}, deploy)
+				return k8sClient.Get(ctx, types.NamespacedName{Name: "reconcile-delete-postgres", Namespace: "default"}, sts)
 			}, timeout, interval).Should(Succeed())
-			Expect(deploy.OwnerReferences).To(HaveLen(1))
-			Expect(deploy.OwnerReferences[0].Name).To(Equal("reconcile-delete"))
+			Expect(sts.OwnerReferences).To(HaveLen(1))
+			Expect(sts.OwnerReferences[0].Name).To(Equal("reconcile-delete"))
 		})
 	})
 
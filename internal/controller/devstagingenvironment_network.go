@@ -0,0 +1,273 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Network conflicts
+// ────────────────────────────────────────────────────────────────────────────
+//
+// Two components that claim the same ingress host and path, or the same
+// node port, don't fail loudly: the ingress controller quietly routes to
+// one of them, and the second Service is refused by the API server. The
+// validating webhook turns new conflicts away; reconcile catches the rest
+// (objects applied before the webhook, Ingresses made by hand) and
+// reports them in the NetworkValid condition.
+
+// Reasons of the NetworkValid condition when it is False.
+const (
+	ReasonIngressPathConflict = "IngressPathConflict"
+	ReasonNodePortConflict    = "NodePortConflict"
+	ReasonPortMismatch        = "PortMismatch"
+)
+
+// NetworkProblem is one conflict or mismatch in a DevStagingEnvironment's
+// networking. Reason is one of the NetworkValid reasons above.
+type NetworkProblem struct {
+	Reason  string
+	Message string
+}
+
+// networkProblems is everything wrong with one DSE's networking.
+type networkProblems []NetworkProblem
+
+// has reports whether any problem has reason.
+func (p networkProblems) has(reason string) bool {
+	for _, problem := range p {
+		if problem.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// ingressRoute is the host and path an ingress rule claims. Hosts compare
+// case-insensitively and paths without a trailing slash.
+type ingressRoute struct{ host, path string }
+
+func (r ingressRoute) String() string { return r.host + r.path }
+
+func newIngressRoute(host, path string) ingressRoute {
+	if path == "" {
+		path = "/"
+	}
+	if len(path) > 1 {
+		path = strings.TrimRight(path, "/")
+	}
+	return ingressRoute{host: strings.ToLower(host), path: path}
+}
+
+// dseRoute returns the route cr's Ingress claims. ok is false without an
+// enabled ingress, without a host (such an Ingress matches every host and
+// only ever loses to a more specific one), or for a tunnel: true ingress,
+// whose host the tunnel makes unique.
+func dseRoute(cr *appsv1alpha1.DevStagingEnvironment) (route ingressRoute, ok bool) {
+	ing := cr.Spec.Ingress
+	if ing == nil || !ing.Enabled || ing.Tunnel || ing.Host == "" || scheduled(cr) {
+		return ingressRoute{}, false
+	}
+	return newIngressRoute(ing.Host, ing.Path), true
+}
+
+// dseNodePort returns the node port cr's Service fixes, or 0 if it leaves
+// the choice to Kubernetes.
+func dseNodePort(cr *appsv1alpha1.DevStagingEnvironment) int32 {
+	svc := cr.Spec.Service
+	if svc.NodePort == nil || (svc.Type != "NodePort" && svc.Type != "LoadBalancer") || scheduled(cr) {
+		return 0
+	}
+	return *svc.NodePort
+}
+
+// claimsFirst reports whether a holds a route or port ahead of b: the
+// older object wins, then the lower namespace/name, so two DSEs never
+// both lose. An object not created yet, as in the webhook, is the newest.
+func claimsFirst(a, b metav1.Object) bool {
+	ta, tb := a.GetCreationTimestamp(), b.GetCreationTimestamp()
+	switch {
+	case ta.IsZero() != tb.IsZero():
+		return tb.IsZero()
+	case !ta.Equal(&tb):
+		return ta.Before(&tb)
+	}
+	return a.GetNamespace()+"/"+a.GetName() < b.GetNamespace()+"/"+b.GetName()
+}
+
+// ownedByDSE reports whether a DevStagingEnvironment controls obj. Those
+// objects are judged by their DSE's spec instead.
+func ownedByDSE(obj metav1.Object) bool {
+	owner := metav1.GetControllerOf(obj)
+	return owner != nil && owner.Kind == "DevStagingEnvironment"
+}
+
+// FindNetworkConflicts returns the ingress route and node port of cr that
+// something else already holds: an older DevStagingEnvironment, or an
+// Ingress or Service no DevStagingEnvironment controls. Hosts and node
+// ports are cluster-wide, so every namespace is searched.
+func FindNetworkConflicts(ctx context.Context, c client.Reader, cr *appsv1alpha1.DevStagingEnvironment) ([]NetworkProblem, error) {
+	route, hasRoute := dseRoute(cr)
+	nodePort := dseNodePort(cr)
+	if !hasRoute && nodePort == 0 {
+		return nil, nil
+	}
+
+	var problems []NetworkProblem
+	dses := &appsv1alpha1.DevStagingEnvironmentList{}
+	if err := c.List(ctx, dses); err != nil {
+		return nil, err
+	}
+	for i := range dses.Items {
+		other := &dses.Items[i]
+		if (other.Namespace == cr.Namespace && other.Name == cr.Name) || !claimsFirst(other, cr) {
+			continue
+		}
+		if r, ok := dseRoute(other); hasRoute && ok && r == route {
+			problems = append(problems, NetworkProblem{ReasonIngressPathConflict,
+				fmt.Sprintf("%s is already routed to DevStagingEnvironment %s/%s", route, other.Namespace, other.Name)})
+		}
+		if p := dseNodePort(other); nodePort != 0 && p == nodePort {
+			problems = append(problems, NetworkProblem{ReasonNodePortConflict,
+				fmt.Sprintf("node port %d is already used by DevStagingEnvironment %s/%s", nodePort, other.Namespace, other.Name)})
+		}
+	}
+
+	if hasRoute {
+		ingresses := &networkingv1.IngressList{}
+		if err := c.List(ctx, ingresses); err != nil {
+			return nil, err
+		}
+		for i := range ingresses.Items {
+			ing := &ingresses.Items[i]
+			if ownedByDSE(ing) || !ingressClaims(ing, route) {
+				continue
+			}
+			problems = append(problems, NetworkProblem{ReasonIngressPathConflict,
+				fmt.Sprintf("%s is already routed by Ingress %s/%s", route, ing.Namespace, ing.Name)})
+		}
+	}
+
+	if nodePort != 0 {
+		services := &corev1.ServiceList{}
+		if err := c.List(ctx, services); err != nil {
+			return nil, err
+		}
+		for i := range services.Items {
+			svc := &services.Items[i]
+			if ownedByDSE(svc) {
+				continue
+			}
+			for _, port := range svc.Spec.Ports {
+				if port.NodePort == nodePort {
+					problems = append(problems, NetworkProblem{ReasonNodePortConflict,
+						fmt.Sprintf("node port %d is already used by Service %s/%s", nodePort, svc.Namespace, svc.Name)})
+					break
+				}
+			}
+		}
+	}
+	return problems, nil
+}
+
+// ingressClaims reports whether any rule of ing routes route.
+func ingressClaims(ing *networkingv1.Ingress, route ingressRoute) bool {
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			if newIngressRoute(rule.Host, p.Path) == route {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PortMismatches returns the ports in cr's spec that don't line up: a
+// Service targetPort other than the port the container listens on sends
+// every request to a closed port.
+func PortMismatches(cr *appsv1alpha1.DevStagingEnvironment) []NetworkProblem {
+	svc := cr.Spec.Service
+	if scheduled(cr) || svc.TargetPort == nil || *svc.TargetPort == cr.Spec.Deployment.Port {
+		return nil
+	}
+	return []NetworkProblem{{ReasonPortMismatch, fmt.Sprintf(
+		"spec.service.targetPort %d is not the container port %d (spec.deployment.port)",
+		*svc.TargetPort, cr.Spec.Deployment.Port)}}
+}
+
+// checkNetwork returns every conflict and mismatch in cr's networking.
+func (r *DevStagingEnvironmentReconciler) checkNetwork(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) (networkProblems, error) {
+	conflicts, err := FindNetworkConflicts(ctx, r.Client, cr)
+	if err != nil {
+		return nil, err
+	}
+	return append(networkProblems(conflicts), PortMismatches(cr)...), nil
+}
+
+// networkCondition reports the problems checkNetwork found. The reason is
+// the first problem's; the message lists them all.
+func networkCondition(problems networkProblems) metav1.Condition {
+	if len(problems) == 0 {
+		return metav1.Condition{Type: networkValidCondition, Status: metav1.ConditionTrue,
+			Reason: "NoConflicts", Message: "No other component claims this ingress route or node port"}
+	}
+	messages := make([]string, len(problems))
+	for i, p := range problems {
+		messages[i] = p.Message
+	}
+	return metav1.Condition{Type: networkValidCondition, Status: metav1.ConditionFalse,
+		Reason: problems[0].Reason, Message: strings.Join(messages, "; ")}
+}
+
+// requestsForNetworkPeers re-queues the DSEs held back by a conflict when
+// any DSE changes or goes away: the route or port they wait for may be
+// free now. The changed object's old spec isn't known here, so every
+// held-back DSE in the cluster is checked again.
+func (r *DevStagingEnvironmentReconciler) requestsForNetworkPeers(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := &appsv1alpha1.DevStagingEnvironmentList{}
+	if err := r.List(ctx, list); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, cr := range list.Items {
+		if cr.Namespace == obj.GetNamespace() && cr.Name == obj.GetName() {
+			continue
+		}
+		condition := meta.FindStatusCondition(cr.Status.Conditions, networkValidCondition)
+		if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason == ReasonPortMismatch {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}})
+	}
+	return requests
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+var _ = Describe("network conflicts", func() {
+	It("compares routes by lowercase host and path without a trailing slash", func() {
+		Expect(newIngressRoute("Web.Localhost", "")).To(Equal(newIngressRoute("web.localhost", "/")))
+		Expect(newIngressRoute("web.localhost", "/api/")).To(Equal(newIngressRoute("web.localhost", "/api")))
+		Expect(newIngressRoute("web.localhost", "/api")).NotTo(Equal(newIngressRoute("web.localhost", "/")))
+	})
+
+	It("claims no route for a hostless, tunnel, or scheduled ingress", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true}
+		_, ok := dseRoute(cr)
+		Expect(ok).To(BeFalse())
+
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: "web.localhost", Tunnel: true}
+		_, ok = dseRoute(cr)
+		Expect(ok).To(BeFalse())
+
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: "web.localhost"}
+		cr.Spec.Deployment.Schedule = "*/5 * * * *"
+		_, ok = dseRoute(cr)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("only counts a node port on a NodePort or LoadBalancer Service", func() {
+		cr := newTestDSE("test-app")
+		port := int32(30080)
+		cr.Spec.Service.NodePort = &port
+		Expect(dseNodePort(cr)).To(BeZero())
+		cr.Spec.Service.Type = "LoadBalancer"
+		Expect(dseNodePort(cr)).To(Equal(int32(30080)))
+	})
+
+	It("gives a route to the older object, and to the existing one over a new one", func() {
+		older, newer := newTestDSE("b-app"), newTestDSE("a-app")
+		older.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+		newer.CreationTimestamp = metav1.Now()
+		Expect(claimsFirst(older, newer)).To(BeTrue())
+		Expect(claimsFirst(newer, older)).To(BeFalse())
+
+		pending := newTestDSE("0-app")
+		Expect(claimsFirst(newer, pending)).To(BeTrue())
+		Expect(claimsFirst(pending, newer)).To(BeFalse())
+
+		twin := newTestDSE("c-app")
+		twin.CreationTimestamp = newer.CreationTimestamp
+		Expect(claimsFirst(newer, twin)).To(BeTrue())
+		Expect(claimsFirst(twin, newer)).To(BeFalse())
+	})
+
+	It("finds the route in any rule of an Ingress", func() {
+		ing := &networkingv1.Ingress{Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{
+			{Host: "other.localhost"},
+			{Host: "web.localhost", IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+				Paths: []networkingv1.HTTPIngressPath{{Path: "/api"}},
+			}}},
+		}}}
+		Expect(ingressClaims(ing, newIngressRoute("web.localhost", "/api/"))).To(BeTrue())
+		Expect(ingressClaims(ing, newIngressRoute("web.localhost", "/"))).To(BeFalse())
+	})
+
+	It("reports a targetPort that isn't the container port", func() {
+		cr := newTestDSE("test-app")
+		Expect(PortMismatches(cr)).To(BeEmpty())
+
+		tp := int32(8080)
+		cr.Spec.Service.TargetPort = &tp
+		Expect(PortMismatches(cr)).To(BeEmpty())
+
+		tp = 9090
+		problems := PortMismatches(cr)
+		Expect(problems).To(HaveLen(1))
+		Expect(problems[0].Reason).To(Equal(ReasonPortMismatch))
+		Expect(problems[0].Message).To(ContainSubstring("9090"))
+	})
+
+	It("reports every problem in the NetworkValid condition", func() {
+		c := networkCondition(nil)
+		Expect(c.Status).To(Equal(metav1.ConditionTrue))
+		Expect(c.Reason).To(Equal("NoConflicts"))
+
+		c = networkCondition(networkProblems{
+			{Reason: ReasonNodePortConflict, Message: "node port taken"},
+			{Reason: ReasonPortMismatch, Message: "ports differ"},
+		})
+		Expect(c.Status).To(Equal(metav1.ConditionFalse))
+		Expect(c.Reason).To(Equal(ReasonNodePortConflict))
+		Expect(c.Message).To(Equal("node port taken; ports differ"))
+	})
+})
+
+var _ = Describe("buildService node port", func() {
+	It("fixes the node port only on a Service that has one", func() {
+		r := &DevStagingEnvironmentReconciler{}
+		cr := newTestDSE("test-app")
+		port := int32(30080)
+		cr.Spec.Service.NodePort = &port
+		Expect(r.buildService(cr).Spec.Ports[0].NodePort).To(BeZero())
+
+		cr.Spec.Service.Type = "NodePort"
+		svc := r.buildService(cr)
+		Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+		Expect(svc.Spec.Ports[0].NodePort).To(Equal(int32(30080)))
+	})
+})
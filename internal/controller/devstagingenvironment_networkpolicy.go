@@ -0,0 +1,267 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// NetworkPolicies — networkPolicies: strict
+// ────────────────────────────────────────────────────────────────────────────
+//
+// A strict DSE gets two ingress-only NetworkPolicies. <name>-app lets into
+// the app's pods the ingress controller and the apps of the DSEs in its
+// namespace whose dependsOn names it — plus, with autoSleep, the
+// activator, which proxies the Ingress's requests from the operator's
+// namespace. Nothing else from another namespace gets in.
+// <name>-dependencies lets into the dependencies' pods only the DSE's own
+// app, jobs, seeds, and dependencies. Egress stays open, so DNS and the
+// tracing collector keep working.
+//
+// Peers are picked by app.kubernetes.io/name and managed-by alone, never
+// app.kubernetes.io/instance, so kindling test isolation can stand in for
+// an app with a probe pod that no Service selects.
+
+// namespaceNameLabel is set by the API server on every namespace.
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// ingressControllerNamespaces maps each ingress provider to the namespace
+// kindling init installs its controller in.
+var ingressControllerNamespaces = map[string]string{
+	ingressProviderNginx:   "ingress-nginx",
+	ingressProviderContour: "projectcontour",
+	ingressProviderTraefik: "traefik",
+}
+
+// ingressControllerNamespace returns the namespace of the controller that
+// serves cr's Ingress class.
+func ingressControllerNamespace(cr *appsv1alpha1.DevStagingEnvironment) string {
+	var className *string
+	if cr.Spec.Ingress != nil {
+		className = cr.Spec.Ingress.IngressClassName
+	}
+	return ingressControllerNamespaces[ingressProvider(className)]
+}
+
+// networkPoliciesStrict reports whether cr asks for NetworkPolicies.
+func networkPoliciesStrict(cr *appsv1alpha1.DevStagingEnvironment) bool {
+	return cr.Spec.NetworkPolicies == appsv1alpha1.NetworkPoliciesStrict
+}
+
+func appNetworkPolicyName(crName string) string          { return crName + "-app" }
+func dependenciesNetworkPolicyName(crName string) string { return crName + "-dependencies" }
+
+// buildAppNetworkPolicy admits into cr's app and canary pods the ingress
+// controller of cr's Ingress class, the apps named in callers, and, when activatorNamespace
+// isn't empty, the activator's namespace.
+func buildAppNetworkPolicy(cr *appsv1alpha1.DevStagingEnvironment, callers []string, activatorNamespace string) *networkingv1.NetworkPolicy {
+	from := []networkingv1.NetworkPolicyPeer{namespacePeer(ingressControllerNamespace(cr))}
+	if len(callers) > 0 {
+		// A pod selector alone only matches pods in the policy's namespace.
+		from = append(from, networkingv1.NetworkPolicyPeer{
+			PodSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app.kubernetes.io/managed-by": "devstagingenvironment-operator"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key: "app.kubernetes.io/name", Operator: metav1.LabelSelectorOpIn, Values: callers,
+				}},
+			},
+		})
+	}
+	if activatorNamespace != "" {
+		from = append(from, namespacePeer(activatorNamespace))
+	}
+	ingress := []networkingv1.NetworkPolicyIngressRule{{From: from}}
+	// The canary's pods differ from the app's only in instance.
+	guarded := labelsForCR(cr)
+	if canaryEnabled(cr) {
+		delete(guarded, "app.kubernetes.io/instance")
+	}
+	return newNetworkPolicy(cr, appNetworkPolicyName(cr.Name), guarded, ingress)
+}
+
+// namespacePeer admits every pod of the namespace ns.
+func namespacePeer(ns string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{namespaceNameLabel: ns}},
+	}
+}
+
+// activatorNamespace returns the namespace the activator's requests to
+// cr's app come from, or "" when cr doesn't sleep.
+func (r *DevStagingEnvironmentReconciler) activatorNamespace(cr *appsv1alpha1.DevStagingEnvironment) string {
+	if !r.autoSleeps(cr) {
+		return ""
+	}
+	// The Service's DNS name is <service>.<namespace>.svc[.<domain>].
+	parts := strings.Split(r.Activator.ServiceHost, ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// buildDependenciesNetworkPolicy admits into cr's dependency pods only
+// cr's app and the pods that are part of cr: its jobs, seeds, and the
+// dependencies themselves.
+func buildDependenciesNetworkPolicy(cr *appsv1alpha1.DevStagingEnvironment) *networkingv1.NetworkPolicy {
+	partOf := map[string]string{
+		"app.kubernetes.io/part-of":    cr.Name,
+		"app.kubernetes.io/managed-by": "devstagingenvironment-operator",
+	}
+	ingress := []networkingv1.NetworkPolicyIngressRule{{
+		From: []networkingv1.NetworkPolicyPeer{
+			{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{
+				"app.kubernetes.io/name":       cr.Name,
+				"app.kubernetes.io/managed-by": "devstagingenvironment-operator",
+			}}},
+			{PodSelector: &metav1.LabelSelector{MatchLabels: partOf}},
+		},
+	}}
+	return newNetworkPolicy(cr, dependenciesNetworkPolicyName(cr.Name), partOf, ingress)
+}
+
+func newNetworkPolicy(cr *appsv1alpha1.DevStagingEnvironment, name string, podLabels map[string]string, ingress []networkingv1.NetworkPolicyIngressRule) *networkingv1.NetworkPolicy {
+	spec := networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{MatchLabels: podLabels},
+		Ingress:     ingress,
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+	}
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/part-of":    cr.Name,
+				"app.kubernetes.io/managed-by": "devstagingenvironment-operator",
+			},
+			Annotations: map[string]string{specHashAnnotation: computeSpecHash(spec)},
+		},
+		Spec: spec,
+	}
+}
+
+// callersOf returns, sorted, the DSEs in cr's namespace whose dependsOn
+// names cr.
+func (r *DevStagingEnvironmentReconciler) callersOf(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) ([]string, error) {
+	list := &appsv1alpha1.DevStagingEnvironmentList{}
+	if err := r.List(ctx, list, client.InNamespace(cr.Namespace)); err != nil {
+		return nil, err
+	}
+	var callers []string
+	for _, other := range list.Items {
+		for _, name := range other.Spec.DependsOn {
+			if name == cr.Name && other.Name != cr.Name {
+				callers = append(callers, other.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(callers)
+	return callers, nil
+}
+
+// reconcileNetworkPolicies writes cr's NetworkPolicies when it is strict
+// and removes them when it isn't, or has no dependencies to guard.
+func (r *DevStagingEnvironmentReconciler) reconcileNetworkPolicies(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
+	strict := networkPoliciesStrict(cr)
+	var callers []string
+	if strict {
+		var err error
+		if callers, err = r.callersOf(ctx, cr); err != nil {
+			return err
+		}
+	}
+	if err := r.reconcileNetworkPolicy(ctx, cr, buildAppNetworkPolicy(cr, callers, r.activatorNamespace(cr)), strict); err != nil {
+		return err
+	}
+	return r.reconcileNetworkPolicy(ctx, cr, buildDependenciesNetworkPolicy(cr), strict && len(cr.Spec.Dependencies) > 0)
+}
+
+func (r *DevStagingEnvironmentReconciler) reconcileNetworkPolicy(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment, desired *networkingv1.NetworkPolicy, wanted bool) error {
+	logger := log.FromContext(ctx)
+
+	existing := &networkingv1.NetworkPolicy{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+	if errors.IsNotFound(err) {
+		if !wanted {
+			return nil
+		}
+		if err := controllerutil.SetControllerReference(cr, desired, r.Scheme); err != nil {
+			return err
+		}
+		logger.Info("Creating NetworkPolicy", "name", desired.Name)
+		if err := r.Create(ctx, desired); err != nil {
+			return err
+		}
+		r.recordEvent(cr, "Normal", "NetworkPolicyCreated", "Created NetworkPolicy %s", desired.Name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// A policy someone else wrote under the same name is left alone.
+	if !metav1.IsControlledBy(existing, cr) {
+		return nil
+	}
+	if !wanted {
+		logger.Info("Deleting NetworkPolicy", "name", existing.Name)
+		return client.IgnoreNotFound(r.Delete(ctx, existing))
+	}
+	if existing.Annotations[specHashAnnotation] == desired.Annotations[specHashAnnotation] {
+		return nil
+	}
+	existing.Spec = desired.Spec
+	if existing.Annotations == nil {
+		existing.Annotations = map[string]string{}
+	}
+	existing.Annotations[specHashAnnotation] = desired.Annotations[specHashAnnotation]
+	logger.Info("Updating NetworkPolicy", "name", existing.Name)
+	return r.Update(ctx, existing)
+}
+
+// requestsForIsolatedPeers re-queues the strict DSEs in a changed DSE's
+// namespace: its dependsOn may have gained or lost one of them, and the
+// old spec isn't known here.
+func (r *DevStagingEnvironmentReconciler) requestsForIsolatedPeers(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := &appsv1alpha1.DevStagingEnvironmentList{}
+	if err := r.List(ctx, list, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, cr := range list.Items {
+		if cr.Name == obj.GetName() || !networkPoliciesStrict(&cr) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}})
+	}
+	return requests
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+var _ = Describe("network policies", func() {
+	var r *DevStagingEnvironmentReconciler
+
+	BeforeEach(func() {
+		r = &DevStagingEnvironmentReconciler{}
+	})
+
+	It("guards the app's pods but not its Service", func() {
+		cr := newTestDSE("test-app")
+		policy := buildAppNetworkPolicy(cr, nil, "")
+		deploy := r.buildDeployment(cr)
+
+		Expect(policy.Name).To(Equal("test-app-app"))
+		Expect(policy.Spec.PodSelector.MatchLabels).To(Equal(deploy.Spec.Selector.MatchLabels))
+	})
+
+	It("admits only the ingress controller and the declared callers", func() {
+		cr := newTestDSE("test-app")
+		policy := buildAppNetworkPolicy(cr, []string{"caller"}, "")
+
+		Expect(policy.Spec.Ingress).To(HaveLen(1))
+		from := policy.Spec.Ingress[0].From
+		Expect(from).To(HaveLen(2))
+
+		Expect(from[0].PodSelector).To(BeNil())
+		Expect(from[0].NamespaceSelector).To(Equal(&metav1.LabelSelector{
+			MatchLabels: map[string]string{namespaceNameLabel: "ingress-nginx"},
+		}))
+
+		// Without a namespace selector, the callers are pods of the
+		// environment's own namespace.
+		Expect(from[1].NamespaceSelector).To(BeNil())
+		callers := from[1].PodSelector
+		Expect(callers.MatchExpressions[0].Values).To(ConsistOf("caller"))
+		selector := selectorOf(callers)
+		Expect(selector.Matches(labels.Set(labelsForCR(newTestDSE("caller"))))).To(BeTrue())
+		Expect(selector.Matches(labels.Set(labelsForCR(newTestDSE("stranger"))))).To(BeFalse())
+
+		Expect(buildAppNetworkPolicy(cr, nil, "").Spec.Ingress[0].From).To(HaveLen(1))
+	})
+
+	It("admits the controller of the Ingress's class", func() {
+		for class, ns := range map[string]string{"nginx": "ingress-nginx", "traefik": "traefik", "contour": "projectcontour"} {
+			cr := newTestDSE("test-app")
+			cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: "test-app.localhost", IngressClassName: &class}
+			from := buildAppNetworkPolicy(cr, nil, "").Spec.Ingress[0].From
+			Expect(from[0].NamespaceSelector.MatchLabels).To(Equal(map[string]string{namespaceNameLabel: ns}), class)
+		}
+	})
+
+	It("admits the activator's namespace when the environment sleeps", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: "test-app.localhost"}
+		cr.Spec.AutoSleep = &appsv1alpha1.AutoSleepSpec{IdleMinutes: 30}
+		r.Activator = &Activator{ServiceHost: "kindling-activator.kindling-system.svc.cluster.local", Port: 8082}
+		Expect(r.activatorNamespace(cr)).To(Equal("kindling-system"))
+
+		from := buildAppNetworkPolicy(cr, nil, r.activatorNamespace(cr)).Spec.Ingress[0].From
+		Expect(from).To(HaveLen(2))
+		Expect(from[1].NamespaceSelector.MatchLabels).To(Equal(map[string]string{namespaceNameLabel: "kindling-system"}))
+
+		cr.Spec.AutoSleep = nil
+		Expect(r.activatorNamespace(cr)).To(BeEmpty())
+	})
+
+	It("admits only the environment's own pods to its dependencies", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Dependencies = []appsv1alpha1.DependencySpec{{Type: appsv1alpha1.DependencyPostgres}}
+		policy := buildDependenciesNetworkPolicy(cr)
+
+		guarded := selectorOf(&policy.Spec.PodSelector)
+		Expect(guarded.Matches(labels.Set(labelsForDependency(cr, appsv1alpha1.DependencyPostgres)))).To(BeTrue())
+		Expect(guarded.Matches(labels.Set(labelsForCR(cr)))).To(BeFalse())
+
+		admitted := func(podLabels map[string]string) bool {
+			for _, peer := range policy.Spec.Ingress[0].From {
+				if selectorOf(peer.PodSelector).Matches(labels.Set(podLabels)) {
+					return true
+				}
+			}
+			return false
+		}
+		Expect(admitted(labelsForCR(cr))).To(BeTrue())
+		Expect(admitted(buildAppJob(cr, appsv1alpha1.JobSpec{Name: "migrate"}).Spec.Template.Labels)).To(BeTrue())
+		Expect(admitted(labelsForCR(newTestDSE("other-app")))).To(BeFalse())
+		Expect(admitted(labelsForDependency(newTestDSE("other-app"), appsv1alpha1.DependencyPostgres))).To(BeFalse())
+	})
+})
+
+func selectorOf(s *metav1.LabelSelector) labels.Selector {
+	selector, err := metav1.LabelSelectorAsSelector(s)
+	Expect(err).NotTo(HaveOccurred())
+	return selector
+}
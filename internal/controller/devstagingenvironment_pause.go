@@ -0,0 +1,79 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Pause — spec.paused keeps the environment's definition and data, not pods
+// ────────────────────────────────────────────────────────────────────────────
+//
+// A paused environment's workloads are scaled to zero — the app, its
+// canary, and its dependencies — and a scheduled app's CronJob is
+// suspended. Claims, Secrets, Services, and the Ingress stay, so
+// unpausing scales everything back up onto the same data. No Job or seed
+// starts while paused. An environment that spec.autoSleep has put to
+// sleep is paused the same way (see devstagingenvironment_sleep.go).
+
+// ReasonPaused is the reason of the conditions a paused environment
+// can't meet.
+const ReasonPaused = "Paused"
+
+// paused reports whether cr's pods are to be stopped: it is paused, or
+// asleep.
+func paused(cr *appsv1alpha1.DevStagingEnvironment) bool {
+	return cr.Spec.Paused || asleep(cr)
+}
+
+// runningReplicas returns replicas, or zero while cr is paused.
+func runningReplicas(cr *appsv1alpha1.DevStagingEnvironment, replicas *int32) *int32 {
+	if !paused(cr) {
+		return replicas
+	}
+	zero := int32(0)
+	return &zero
+}
+
+// pausedHash folds the pause into a workload's spec hash, so pausing and
+// unpausing update it, and leaves a running environment's hash as it was.
+func pausedHash(cr *appsv1alpha1.DevStagingEnvironment, hash string) string {
+	if !paused(cr) {
+		return hash
+	}
+	return computeSpecHash(struct {
+		Hash   string
+		Paused bool
+	}{hash, true})
+}
+
+// pausedCondition is the condition of type conditionType while cr is
+// paused or asleep.
+func pausedCondition(cr *appsv1alpha1.DevStagingEnvironment, conditionType string) metav1.Condition {
+	if !cr.Spec.Paused {
+		return metav1.Condition{Type: conditionType, Status: metav1.ConditionFalse, Reason: ReasonAsleep,
+			Message: fmt.Sprintf("No requests for %d minutes, so %s runs no pods; the next request to its Ingress wakes it",
+				cr.Spec.AutoSleep.IdleMinutes, cr.Name)}
+	}
+	return metav1.Condition{Type: conditionType, Status: metav1.ConditionFalse, Reason: ReasonPaused,
+		Message: fmt.Sprintf("spec.paused is set, so %s runs no pods; clear it (kindling resume %s) to start them", cr.Name, cr.Name)}
+}
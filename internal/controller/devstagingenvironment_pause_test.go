@@ -0,0 +1,82 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+var _ = Describe("pause", func() {
+	var r *DevStagingEnvironmentReconciler
+
+	BeforeEach(func() {
+		r = &DevStagingEnvironmentReconciler{}
+	})
+
+	It("scales the app and its canary to zero and back", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Canary = &appsv1alpha1.CanarySpec{Image: "my-image:canary"}
+		running := r.buildDeployment(cr)
+
+		cr.Spec.Paused = true
+		stopped := r.buildDeployment(cr)
+		Expect(*stopped.Spec.Replicas).To(BeZero())
+		Expect(*r.buildCanaryDeployment(cr).Spec.Replicas).To(BeZero())
+		Expect(stopped.Annotations[specHashAnnotation]).NotTo(Equal(running.Annotations[specHashAnnotation]))
+		Expect(*cr.Spec.Deployment.Replicas).To(Equal(int32(1)), "the spec's replicas must be kept for resume")
+
+		cr.Spec.Paused = false
+		Expect(r.buildDeployment(cr).Annotations[specHashAnnotation]).To(Equal(running.Annotations[specHashAnnotation]))
+	})
+
+	It("scales stateful dependencies to zero and keeps their claims", func() {
+		cr := newTestDSE("test-app")
+		dep := appsv1alpha1.DependencySpec{Type: appsv1alpha1.DependencyPostgres}
+		podSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "postgres"}}}
+		running := buildDependencyStatefulSet(cr, dep, dependencyRegistry[dep.Type], podSpec)
+		Expect(running.Annotations[specHashAnnotation]).To(Equal(dependencySpecHash(dep)),
+			"a running dependency's hash must not change, or upgrading the operator restarts it")
+
+		cr.Spec.Paused = true
+		stopped := buildDependencyStatefulSet(cr, dep, dependencyRegistry[dep.Type], podSpec)
+		Expect(*stopped.Spec.Replicas).To(BeZero())
+		Expect(stopped.Annotations[specHashAnnotation]).NotTo(Equal(running.Annotations[specHashAnnotation]))
+		Expect(stopped.Spec.PersistentVolumeClaimRetentionPolicy.WhenScaled).To(Equal(appsv1.RetainPersistentVolumeClaimRetentionPolicyType))
+	})
+
+	It("suspends a scheduled app", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Deployment.Schedule = "*/5 * * * *"
+		Expect(*r.buildCronJob(cr).Spec.Suspend).To(BeFalse())
+
+		cr.Spec.Paused = true
+		Expect(*r.buildCronJob(cr).Spec.Suspend).To(BeTrue())
+	})
+
+	It("reports a paused environment as Paused, not Pending", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Paused = true
+		Expect(Phase(cr)).To(Equal(PhasePaused))
+		Expect(pausedCondition(cr, readyCondition).Reason).To(Equal(ReasonPaused))
+	})
+})
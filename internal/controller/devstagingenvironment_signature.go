@@ -0,0 +1,342 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+	appsv1beta1 "github.com/jeffvincent/kindling/api/v1beta1"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Signatures — environments that come from a signed manifest
+// ────────────────────────────────────────────────────────────────────────────
+//
+// kindling sign signs each DevStagingEnvironment of a manifest with
+// cosign and stores, as annotations, the manifest as it signed it and the
+// Sigstore bundle of the signature. With --require-signed-environments
+// the operator only reconciles an environment whose bundle verifies and
+// whose spec is the signed one as the defaulting webhook completes it;
+// adding, changing, or removing any field needs a new signature.
+//
+// Keyless bundles are checked by sigstore-go against a Sigstore trusted
+// root: the certificate must chain to one of its Fulcio CAs and name the
+// expected identity and OIDC issuer, and the bundle's Rekor entry must be
+// from one of its logs, prove its inclusion (a signed entry timestamp, or
+// an inclusion proof with its checkpoint), record this signature by this
+// certificate, and fall within the certificate's lifetime. Key-based
+// bundles only need their signature to verify with the public key.
+
+const (
+	// SignedManifestAnnotation holds the DevStagingEnvironment, as JSON,
+	// that SignatureBundleAnnotation's signature covers.
+	SignedManifestAnnotation = "kindling.dev/signed-manifest"
+	// SignatureBundleAnnotation holds the Sigstore bundle, as JSON, of
+	// the signature.
+	SignatureBundleAnnotation = "kindling.dev/signature-bundle"
+
+	// ReasonUnsigned and ReasonSignatureInvalid are the reasons an
+	// environment isn't reconciled under --require-signed-environments.
+	ReasonUnsigned         = "Unsigned"
+	ReasonSignatureInvalid = "SignatureInvalid"
+)
+
+// SignatureVerifier checks the signatures kindling sign adds. Set
+// PublicKey for key-based signatures, or TrustedRoot, Identity, and
+// Issuer for keyless ones.
+type SignatureVerifier struct {
+	PublicKey crypto.PublicKey
+
+	// TrustedRoot holds the Fulcio CAs and Rekor logs of a Sigstore
+	// trusted_root.json.
+	TrustedRoot root.TrustedMaterial
+	// Identity must match the whole of a subject alternative name of the
+	// signing certificate: an email or a URI.
+	Identity *regexp.Regexp
+	// Issuer is the OIDC issuer the signer logged in with.
+	Issuer string
+}
+
+// LoadSignatureVerifier builds a SignatureVerifier from the manager's
+// flags: a PEM public key file, or a trusted_root.json file with the
+// identity and issuer keyless signers must have.
+func LoadSignatureVerifier(publicKeyPath, trustedRootPath, identity, issuer string) (*SignatureVerifier, error) {
+	if publicKeyPath != "" {
+		data, err := os.ReadFile(publicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s is not a PEM public key", publicKeyPath)
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", publicKeyPath, err)
+		}
+		return &SignatureVerifier{PublicKey: key}, nil
+	}
+
+	if trustedRootPath == "" || identity == "" || issuer == "" {
+		return nil, fmt.Errorf("keyless verification needs a trusted root, an identity, and an OIDC issuer")
+	}
+	data, err := os.ReadFile(trustedRootPath)
+	if err != nil {
+		return nil, err
+	}
+	trusted, err := root.NewTrustedRootFromJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", trustedRootPath, err)
+	}
+	pattern, err := regexp.Compile("^(?:" + identity + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("identity: %w", err)
+	}
+	return &SignatureVerifier{TrustedRoot: trusted, Identity: pattern, Issuer: issuer}, nil
+}
+
+// keyBundle is the part of a Sigstore bundle over a blob, as cosign
+// sign-blob --new-bundle-format writes it, that a key-based signature
+// needs.
+type keyBundle struct {
+	MessageSignature *struct {
+		MessageDigest struct {
+			Algorithm string `json:"algorithm"`
+			Digest    []byte `json:"digest"`
+		} `json:"messageDigest"`
+		Signature []byte `json:"signature"`
+	} `json:"messageSignature"`
+}
+
+// Verify checks cr's signature and that its spec is the signed one, and
+// returns who signed it.
+func (v *SignatureVerifier) Verify(cr *appsv1alpha1.DevStagingEnvironment) (string, error) {
+	manifest, bundleJSON := cr.Annotations[SignedManifestAnnotation], cr.Annotations[SignatureBundleAnnotation]
+	if manifest == "" || bundleJSON == "" {
+		return "", errUnsigned
+	}
+	signer := "the signing key"
+	if v.PublicKey != nil {
+		if err := verifyKeyBundle(v.PublicKey, []byte(manifest), []byte(bundleJSON)); err != nil {
+			return "", err
+		}
+	} else {
+		var err error
+		if signer, err = v.verifyKeyless([]byte(bundleJSON), verify.WithArtifact(bytes.NewReader([]byte(manifest)))); err != nil {
+			return "", err
+		}
+	}
+	if err := matchesSignedManifest(cr, []byte(manifest)); err != nil {
+		return "", err
+	}
+	return signer, nil
+}
+
+var errUnsigned = fmt.Errorf("not signed — sign its manifest with kindling sign")
+
+// verifyKeyBundle checks that the message signature of bundleJSON
+// signs manifest with key.
+func verifyKeyBundle(key crypto.PublicKey, manifest, bundleJSON []byte) error {
+	var b keyBundle
+	if err := json.Unmarshal(bundleJSON, &b); err != nil {
+		return fmt.Errorf("invalid signature bundle: %w", err)
+	}
+	if b.MessageSignature == nil {
+		return fmt.Errorf("the signature bundle has no message signature")
+	}
+	digest := sha256.Sum256(manifest)
+	if d := b.MessageSignature.MessageDigest.Digest; len(d) > 0 && !bytes.Equal(d, digest[:]) {
+		return fmt.Errorf("the signature is for another manifest")
+	}
+	return verifySignature(key, manifest, digest[:], b.MessageSignature.Signature)
+}
+
+// verifyKeyless checks a keyless bundle over artifact with sigstore-go
+// and returns the identity of its certificate.
+func (v *SignatureVerifier) verifyKeyless(bundleJSON []byte, artifact verify.ArtifactPolicyOption) (string, error) {
+	var b bundle.Bundle
+	if err := b.UnmarshalJSON(bundleJSON); err != nil {
+		return "", fmt.Errorf("invalid signature bundle: %w", err)
+	}
+	verifier, err := verify.NewVerifier(v.TrustedRoot, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	if err != nil {
+		return "", err
+	}
+	identity, err := verify.NewShortCertificateIdentity(v.Issuer, "", "", v.Identity.String())
+	if err != nil {
+		return "", err
+	}
+	result, err := verifier.Verify(&b, verify.NewPolicy(artifact, verify.WithCertificateIdentity(identity)))
+	if err != nil {
+		return "", fmt.Errorf("the signature doesn't verify: %w", err)
+	}
+	return result.Signature.Certificate.SubjectAlternativeName, nil
+}
+
+// verifySignature checks signature over message, whose SHA-256 is digest.
+func verifySignature(key crypto.PublicKey, message, digest, signature []byte) error {
+	ok := false
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(k, digest, signature)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, signature) == nil
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(k, message, signature)
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+	if !ok {
+		return fmt.Errorf("the signature doesn't verify")
+	}
+	return nil
+}
+
+// matchesSignedManifest checks that cr is the environment the signed
+// manifest describes: the same name, and the spec the defaulting webhook
+// makes of the signed one, exactly. Anything else in cr's spec — a
+// sidecar, a volume, an env var — isn't signed.
+func matchesSignedManifest(cr *appsv1alpha1.DevStagingEnvironment, manifest []byte) error {
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(manifest, &typeMeta); err != nil {
+		return fmt.Errorf("invalid signed manifest: %w", err)
+	}
+	signed := &appsv1alpha1.DevStagingEnvironment{}
+	switch typeMeta.APIVersion {
+	case appsv1alpha1.GroupVersion.String():
+		if err := json.Unmarshal(manifest, signed); err != nil {
+			return fmt.Errorf("invalid signed manifest: %w", err)
+		}
+	case appsv1beta1.GroupVersion.String():
+		beta := &appsv1beta1.DevStagingEnvironment{}
+		if err := json.Unmarshal(manifest, beta); err != nil {
+			return fmt.Errorf("invalid signed manifest: %w", err)
+		}
+		if err := beta.ConvertTo(signed); err != nil {
+			return fmt.Errorf("invalid signed manifest: %w", err)
+		}
+	default:
+		return fmt.Errorf("the signed manifest is a %s, not a DevStagingEnvironment", typeMeta.APIVersion)
+	}
+	if signed.Name != cr.Name {
+		return fmt.Errorf("the signed manifest is for %s", signed.Name)
+	}
+	signed.ApplyDefaults()
+
+	want, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&signed.Spec)
+	if err != nil {
+		return err
+	}
+	got, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&cr.Spec)
+	if err != nil {
+		return err
+	}
+	if path := firstDifference("spec", want, got); path != "" {
+		return fmt.Errorf("%s differs from the signed manifest", path)
+	}
+	return nil
+}
+
+// firstDifference returns the path of the first value want and got
+// disagree on, or "" when they are equal.
+func firstDifference(path string, want, got interface{}) string {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return path
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p := firstDifference(path+"."+k, w[k], g[k]); p != "" {
+				return p
+			}
+		}
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			return path
+		}
+		for i := range w {
+			if p := firstDifference(fmt.Sprintf("%s[%d]", path, i), w[i], g[i]); p != "" {
+				return p
+			}
+		}
+	default:
+		if !reflect.DeepEqual(want, got) {
+			return path
+		}
+	}
+	return ""
+}
+
+// checkSignature reports, when the manager requires signed environments,
+// whether cr may be reconciled. One that may not gets
+// Ready False with the reason, and nothing else is done until it changes.
+func (r *DevStagingEnvironmentReconciler) checkSignature(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) (bool, error) {
+	signer, err := r.Signatures.Verify(cr)
+	if err == nil {
+		r.setCondition(cr, metav1.Condition{
+			Type:    signedCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Verified",
+			Message: fmt.Sprintf("Signed by %s", signer),
+		})
+		return true, nil
+	}
+
+	reason := ReasonSignatureInvalid
+	if err == errUnsigned {
+		reason = ReasonUnsigned
+	}
+	log.FromContext(ctx).Info("Not reconciling an environment without a valid signature", "reason", err.Error())
+	message := fmt.Sprintf("The operator requires signed environments: %v", err)
+	r.setCondition(cr, metav1.Condition{Type: signedCondition, Status: metav1.ConditionFalse, Reason: reason, Message: message})
+	r.setCondition(cr, metav1.Condition{Type: readyCondition, Status: metav1.ConditionFalse, Reason: reason, Message: message})
+	return false, r.Status().Update(ctx, cr)
+}
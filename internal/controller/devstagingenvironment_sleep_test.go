@@ -0,0 +1,125 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+var _ = Describe("autoSleep", func() {
+	var r *DevStagingEnvironmentReconciler
+
+	BeforeEach(func() {
+		r = &DevStagingEnvironmentReconciler{Activator: &Activator{
+			ServiceHost: "kindling-activator.kindling-system.svc.cluster.local",
+			Port:        8082,
+		}}
+	})
+
+	sleepyDSE := func(name, host string) *appsv1alpha1.DevStagingEnvironment {
+		cr := newTestDSE(name)
+		cr.Spec.Ingress = &appsv1alpha1.IngressSpec{Enabled: true, Host: host}
+		cr.Spec.AutoSleep = &appsv1alpha1.AutoSleepSpec{IdleMinutes: 30}
+		return cr
+	}
+
+	It("routes the Ingress through the activator", func() {
+		cr := sleepyDSE("test-app", "app.localhost")
+		backend := r.buildIngress(cr).Spec.Rules[0].HTTP.Paths[0].Backend.Service
+		Expect(backend.Name).To(Equal("test-app-activator"))
+		Expect(backend.Port.Number).To(Equal(int32(8082)))
+
+		svc := r.buildActivatorService(cr)
+		Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeExternalName))
+		Expect(svc.Spec.ExternalName).To(Equal("kindling-activator.kindling-system.svc.cluster.local"))
+
+		r.Activator = nil
+		Expect(r.buildIngress(cr).Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name).To(Equal("test-app"),
+			"without an activator, autoSleep is ignored")
+	})
+
+	It("sleeps once idle and scales the app to zero", func() {
+		cr := sleepyDSE("test-app", "app.localhost")
+		Expect(r.reconcileSleep(context.Background(), cr)).To(BeNumerically(">", 29*time.Minute))
+		Expect(asleep(cr)).To(BeFalse())
+		cr.Spec.Canary = &appsv1alpha1.CanarySpec{Image: "test-app:next", Weight: 10}
+		awakeHash := r.buildDeployment(cr).Annotations[specHashAnnotation]
+		awakeCanaryHash := r.buildCanaryDeployment(cr).Annotations[specHashAnnotation]
+
+		key := types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}
+		r.Activator.routes[key].lastActive = time.Now().Add(-31 * time.Minute)
+		Expect(r.reconcileSleep(context.Background(), cr)).To(BeZero())
+		Expect(asleep(cr)).To(BeTrue())
+		Expect(*r.buildDeployment(cr).Spec.Replicas).To(BeZero())
+		// Only the status changed, so the hash must too, or the
+		// Deployments are never updated to zero replicas.
+		Expect(r.buildDeployment(cr).Annotations[specHashAnnotation]).NotTo(Equal(awakeHash))
+		Expect(*r.buildCanaryDeployment(cr).Spec.Replicas).To(BeZero())
+		Expect(r.buildCanaryDeployment(cr).Annotations[specHashAnnotation]).NotTo(Equal(awakeCanaryHash))
+		Expect(Phase(cr)).To(Equal(PhaseAsleep))
+		Expect(pausedCondition(cr, readyCondition).Reason).To(Equal(ReasonAsleep))
+
+		// Woken: the idle clock starts over.
+		cr.Status.AsleepSince = nil
+		Expect(r.reconcileSleep(context.Background(), cr)).To(BeNumerically(">", 29*time.Minute))
+	})
+
+	It("stays awake while a request is open", func() {
+		cr := sleepyDSE("test-app", "app.localhost")
+		r.reconcileSleep(context.Background(), cr)
+		key, proxy := r.Activator.begin("app.localhost", "/")
+		Expect(proxy).NotTo(BeNil())
+		r.Activator.routes[key].lastActive = time.Now().Add(-time.Hour)
+		Expect(r.Activator.idleFor(key)).To(BeZero())
+
+		r.Activator.end(key)
+		Expect(r.Activator.idleFor(key)).To(BeNumerically("<", time.Minute))
+	})
+
+	It("matches requests to environments as the Ingress does", func() {
+		api := sleepyDSE("api", "shop.localhost")
+		api.Spec.Ingress.Path = "/api"
+		r.reconcileSleep(context.Background(), sleepyDSE("web", "shop.localhost"))
+		r.reconcileSleep(context.Background(), api)
+		r.reconcileSleep(context.Background(), sleepyDSE("any", ""))
+
+		for _, tc := range []struct{ host, path, want string }{
+			{"shop.localhost", "/api/orders", "api"},
+			{"shop.localhost", "/cart", "web"},
+			{"other.localhost", "/", "any"},
+		} {
+			key, _ := r.Activator.begin(tc.host, tc.path)
+			Expect(key.Name).To(Equal(tc.want), tc.host+tc.path)
+		}
+
+		r.Activator.forget(types.NamespacedName{Name: "any", Namespace: "default"})
+		rec := httptest.NewRecorder()
+		r.Activator.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://other.localhost/", nil))
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,227 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Tracing — a shared OpenTelemetry collector per namespace
+// ────────────────────────────────────────────────────────────────────────────
+//
+// Every DSE with observability.tracing sends its spans to the same
+// collector, so a request that crosses components shows up as one trace.
+// The collector is owned (without being controlled) by each of those DSEs:
+// the garbage collector removes it once the last one is deleted, and
+// reconcile removes it once the last one turns tracing off.
+
+const (
+	// TracingCollectorName is the Deployment and Service of the collector.
+	TracingCollectorName = "kindling-otel-collector"
+
+	// Jaeger v2 is an OpenTelemetry Collector distribution: it receives
+	// OTLP, keeps traces in memory, and serves the Jaeger UI and query API.
+	tracingCollectorImage = "jaegertracing/jaeger:2.5.0"
+
+	tracingOTLPGRPCPort = 4317
+	tracingOTLPHTTPPort = 4318
+	// TracingUIPort serves the Jaeger UI and its /api/traces query API.
+	TracingUIPort = 16686
+
+	// instrumentationAnnotationPrefix, followed by the language, asks the
+	// OpenTelemetry Operator to inject its auto-instrumentation agent.
+	instrumentationAnnotationPrefix = "instrumentation.opentelemetry.io/inject-"
+)
+
+// tracingEnabled reports whether cr sends traces to the collector.
+func tracingEnabled(cr *appsv1alpha1.DevStagingEnvironment) bool {
+	return cr.Spec.Observability != nil && cr.Spec.Observability.Tracing
+}
+
+// buildTracingEnvVars returns the OpenTelemetry SDK settings that send
+// cr's spans to the collector over OTLP/gRPC, or nil without tracing.
+// Values in spec.deployment.env override them.
+func buildTracingEnvVars(cr *appsv1alpha1.DevStagingEnvironment) []corev1.EnvVar {
+	if !tracingEnabled(cr) {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{Name: "OTEL_SERVICE_NAME", Value: cr.Name},
+		{Name: "OTEL_TRACES_EXPORTER", Value: "otlp"},
+		{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: fmt.Sprintf("http://%s:%d", TracingCollectorName, tracingOTLPGRPCPort)},
+		{Name: "OTEL_EXPORTER_OTLP_PROTOCOL", Value: "grpc"},
+		{Name: "OTEL_RESOURCE_ATTRIBUTES", Value: "k8s.namespace.name=" + cr.Namespace},
+	}
+}
+
+// tracingPodAnnotations returns the OpenTelemetry Operator's injection
+// annotation for cr's instrumentation language, or nil.
+func tracingPodAnnotations(cr *appsv1alpha1.DevStagingEnvironment) map[string]string {
+	if !tracingEnabled(cr) || cr.Spec.Observability.Instrumentation == "" {
+		return nil
+	}
+	return map[string]string{instrumentationAnnotationPrefix + cr.Spec.Observability.Instrumentation: "true"}
+}
+
+func tracingCollectorLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       TracingCollectorName,
+		"app.kubernetes.io/component":  "tracing",
+		"app.kubernetes.io/managed-by": "devstagingenvironment-operator",
+	}
+}
+
+// buildTracingCollectorDeployment returns the collector of namespace.
+func buildTracingCollectorDeployment(namespace string) *appsv1.Deployment {
+	labels := tracingCollectorLabels()
+	replicas := int32(1)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: TracingCollectorName, Namespace: namespace, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "collector",
+						Image: tracingCollectorImage,
+						Ports: []corev1.ContainerPort{
+							{Name: "otlp-grpc", ContainerPort: tracingOTLPGRPCPort, Protocol: corev1.ProtocolTCP},
+							{Name: "otlp-http", ContainerPort: tracingOTLPHTTPPort, Protocol: corev1.ProtocolTCP},
+							{Name: "ui", ContainerPort: TracingUIPort, Protocol: corev1.ProtocolTCP},
+						},
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(tracingOTLPGRPCPort)},
+							},
+							PeriodSeconds: 5,
+						},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("50m"),
+								corev1.ResourceMemory: resource.MustParse("128Mi"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("512Mi"),
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// buildTracingCollectorService returns the Service apps export to.
+func buildTracingCollectorService(namespace string) *corev1.Service {
+	labels := tracingCollectorLabels()
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: TracingCollectorName, Namespace: namespace, Labels: labels},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{Name: "otlp-grpc", Port: tracingOTLPGRPCPort, TargetPort: intstr.FromInt32(tracingOTLPGRPCPort), Protocol: corev1.ProtocolTCP},
+				{Name: "otlp-http", Port: tracingOTLPHTTPPort, TargetPort: intstr.FromInt32(tracingOTLPHTTPPort), Protocol: corev1.ProtocolTCP},
+				{Name: "ui", Port: TracingUIPort, TargetPort: intstr.FromInt32(TracingUIPort), Protocol: corev1.ProtocolTCP},
+			},
+		},
+	}
+}
+
+// reconcileTracing adds cr to the owners of its namespace's collector when
+// it enables tracing, creating the collector if needed, and takes it off
+// them when it doesn't, deleting the collector along with the last owner.
+func (r *DevStagingEnvironmentReconciler) reconcileTracing(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
+	for _, desired := range []client.Object{
+		buildTracingCollectorDeployment(cr.Namespace),
+		buildTracingCollectorService(cr.Namespace),
+	} {
+		if err := r.reconcileTracingObject(ctx, cr, desired); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *DevStagingEnvironmentReconciler) reconcileTracingObject(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment, desired client.Object) error {
+	logger := log.FromContext(ctx)
+	enabled := tracingEnabled(cr)
+
+	existing := desired.DeepCopyObject().(client.Object)
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+	if errors.IsNotFound(err) {
+		if !enabled {
+			return nil
+		}
+		if err := controllerutil.SetOwnerReference(cr, desired, r.Scheme); err != nil {
+			return err
+		}
+		logger.Info("Creating tracing collector", "kind", fmt.Sprintf("%T", desired), "name", desired.GetName())
+		return r.Create(ctx, desired)
+	}
+	if err != nil {
+		return err
+	}
+
+	// A collector someone else put there is used as it is.
+	if existing.GetLabels()["app.kubernetes.io/managed-by"] != "devstagingenvironment-operator" {
+		return nil
+	}
+	owned := ownedBy(existing, cr)
+	switch {
+	case enabled && !owned:
+		if err := controllerutil.SetOwnerReference(cr, existing, r.Scheme); err != nil {
+			return err
+		}
+		return r.Update(ctx, existing)
+	case !enabled && owned:
+		if err := controllerutil.RemoveOwnerReference(cr, existing, r.Scheme); err != nil {
+			return err
+		}
+		if len(existing.GetOwnerReferences()) == 0 {
+			logger.Info("Deleting tracing collector, no environment traces any more", "name", existing.GetName())
+			return client.IgnoreNotFound(r.Delete(ctx, existing))
+		}
+		return r.Update(ctx, existing)
+	}
+	return nil
+}
+
+// ownedBy reports whether cr is among obj's owners.
+func ownedBy(obj metav1.Object, cr *appsv1alpha1.DevStagingEnvironment) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == cr.UID {
+			return true
+		}
+	}
+	return false
+}
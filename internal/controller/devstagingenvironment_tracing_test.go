@@ -0,0 +1,89 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+var _ = Describe("tracing", func() {
+	var r *DevStagingEnvironmentReconciler
+
+	BeforeEach(func() {
+		r = &DevStagingEnvironmentReconciler{}
+	})
+
+	It("injects nothing without tracing", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Observability = &appsv1alpha1.ObservabilitySpec{Instrumentation: "java"}
+		deploy := r.buildDeployment(cr)
+
+		Expect(envVarNames(deploy.Spec.Template.Spec.Containers[0].Env)).NotTo(ContainElement("OTEL_SERVICE_NAME"))
+		Expect(deploy.Spec.Template.Annotations).To(BeEmpty())
+	})
+
+	It("points the app at the namespace's collector", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Observability = &appsv1alpha1.ObservabilitySpec{Tracing: true}
+		env := r.buildDeployment(cr).Spec.Template.Spec.Containers[0].Env
+
+		Expect(env).To(ContainElements(
+			corev1.EnvVar{Name: "OTEL_SERVICE_NAME", Value: "test-app"},
+			corev1.EnvVar{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: "http://kindling-otel-collector:4317"},
+			corev1.EnvVar{Name: "OTEL_EXPORTER_OTLP_PROTOCOL", Value: "grpc"},
+		))
+	})
+
+	It("replaces a jaeger dependency's endpoint, but not the user's", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Observability = &appsv1alpha1.ObservabilitySpec{Tracing: true}
+		cr.Spec.Dependencies = []appsv1alpha1.DependencySpec{{Type: appsv1alpha1.DependencyJaeger}}
+		env := buildAppEnvVars(cr)
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: "http://kindling-otel-collector:4317"}))
+		Expect(env).NotTo(ContainElement(corev1.EnvVar{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: "http://test-app-jaeger:4317"}))
+
+		cr.Spec.Deployment.Env = []corev1.EnvVar{{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: "http://kindling-otel-collector:4318"}}
+		env = buildAppEnvVars(cr)
+		Expect(env[len(env)-1]).To(Equal(cr.Spec.Deployment.Env[0]))
+	})
+
+	It("asks the OpenTelemetry Operator for the instrumentation agent", func() {
+		cr := newTestDSE("test-app")
+		cr.Spec.Observability = &appsv1alpha1.ObservabilitySpec{Tracing: true, Instrumentation: "python"}
+		deploy := r.buildDeployment(cr)
+
+		Expect(deploy.Spec.Template.Annotations).To(HaveKeyWithValue("instrumentation.opentelemetry.io/inject-python", "true"))
+	})
+
+	It("serves OTLP and the UI from one collector", func() {
+		svc := buildTracingCollectorService("default")
+		deploy := buildTracingCollectorDeployment("default")
+
+		Expect(svc.Name).To(Equal(TracingCollectorName))
+		Expect(svc.Spec.Selector).To(Equal(deploy.Spec.Template.Labels))
+		var ports []int32
+		for _, p := range svc.Spec.Ports {
+			ports = append(ports, p.Port)
+		}
+		Expect(ports).To(ConsistOf(int32(4317), int32(4318), int32(TracingUIPort)))
+	})
+})
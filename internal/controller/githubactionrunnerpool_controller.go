@@ -30,6 +30,7 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -552,6 +553,15 @@ exec ./run.sh
 set -uo pipefail
 
 BUILDS_DIR=/builds
+
+# Kaniko pods pull base images through the same proxy as the runner.
+PROXY_FLAGS=()
+for var in HTTP_PROXY HTTPS_PROXY NO_PROXY http_proxy https_proxy no_proxy; do
+  if [ -n "${!var:-}" ]; then
+    PROXY_FLAGS+=("--env=${var}=${!var}")
+  fi
+done
+
 echo "🔧 Build-agent sidecar ready, watching ${BUILDS_DIR}..."
 
 while true; do
@@ -581,6 +591,7 @@ while true; do
     cat "${BUILDS_DIR}/${SERVICE}.tar.gz" | kubectl run "kaniko-${SERVICE}" \
       --rm -i --restart=Never \
       --image=gcr.io/kaniko-project/executor:latest \
+      "${PROXY_FLAGS[@]}" \
       -- --context=tar://stdin \
          --destination="${DEST}" \
          --insecure \
@@ -662,6 +673,7 @@ done
 		Name:    "build-agent",
 		Image:   "bitnami/kubectl:latest",
 		Command: []string{"/bin/bash", "-c", buildAgentScript},
+		Env:     runnerProxyEnv(spec.Env),
 		VolumeMounts: []corev1.VolumeMount{
 			{
 				Name:      "builds",
@@ -803,6 +815,49 @@ func int64Ptr(v int64) *int64 {
 	return &v
 }
 
+// proxyEnvNames are the proxy variables honoured by kubectl, Kaniko, and
+// most other tools, in both spellings.
+var proxyEnvNames = []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"}
+
+// clusterNoProxy are the in-cluster destinations that must bypass a
+// proxy: the API server, Services, and the registry Kaniko pushes to.
+const clusterNoProxy = "localhost,127.0.0.1,registry,.svc,.svc.cluster.local,.cluster.local,10.96.0.0/12,10.244.0.0/16"
+
+// runnerProxyEnv returns the proxy settings among env, for the build-agent
+// sidecar and the Kaniko pods it starts. When a proxy is set, NO_PROXY and
+// no_proxy both get the user's exclusions, from either spelling, followed
+// by clusterNoProxy, so that pushes to registry:5000 and kubectl calls stay
+// in the cluster whichever spelling a tool reads.
+func runnerProxyEnv(env []corev1.EnvVar) []corev1.EnvVar {
+	values := map[string]string{}
+	for _, e := range env {
+		if e.ValueFrom == nil && slices.Contains(proxyEnvNames, e.Name) {
+			values[e.Name] = e.Value
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	var noProxy []string
+	for _, name := range []string{"NO_PROXY", "no_proxy"} {
+		for _, host := range strings.Split(values[name], ",") {
+			if host = strings.TrimSpace(host); host != "" && !slices.Contains(noProxy, host) {
+				noProxy = append(noProxy, host)
+			}
+		}
+	}
+	noProxy = append(noProxy, clusterNoProxy)
+	values["NO_PROXY"] = strings.Join(noProxy, ",")
+	values["no_proxy"] = values["NO_PROXY"]
+	var proxy []corev1.EnvVar
+	for _, name := range proxyEnvNames {
+		if v, ok := values[name]; ok {
+			proxy = append(proxy, corev1.EnvVar{Name: name, Value: v})
+		}
+	}
+	return proxy
+}
+
 // githubAPIURL returns the REST API base URL for a given GitHub instance.
 // For github.com it returns "https://api.github.com".
 // For GitHub Enterprise Server (e.g. "https://git.corp.com") it returns
@@ -171,6 +171,37 @@ var _ = Describe("buildRunnerDeployment", func() {
 		deploy := r.buildRunnerDeployment(cr)
 		Expect(deploy.Annotations).To(HaveKey(runnerPoolHashAnnotation))
 	})
+
+	It("passes the proxy settings to the build-agent with the cluster bypassed", func() {
+		cr := newTestRunnerPool("test-pool", "jeff", "jeff/repo")
+		deploy := r.buildRunnerDeployment(cr)
+		Expect(deploy.Spec.Template.Spec.Containers[1].Env).To(BeEmpty())
+
+		cr.Spec.Env = []corev1.EnvVar{
+			{Name: "HTTPS_PROXY", Value: "http://proxy.corp:3128"},
+			{Name: "NO_PROXY", Value: ".corp"},
+			{Name: "LOG_LEVEL", Value: "debug"},
+		}
+		deploy = r.buildRunnerDeployment(cr)
+		env := map[string]string{}
+		for _, e := range deploy.Spec.Template.Spec.Containers[1].Env {
+			env[e.Name] = e.Value
+		}
+		Expect(env).To(HaveKeyWithValue("HTTPS_PROXY", "http://proxy.corp:3128"))
+		Expect(env).To(HaveKeyWithValue("NO_PROXY", ".corp,"+clusterNoProxy))
+		Expect(env).To(HaveKeyWithValue("no_proxy", ".corp,"+clusterNoProxy), "the user's exclusions reach both spellings")
+		Expect(env).NotTo(HaveKey("LOG_LEVEL"))
+		Expect(deploy.Spec.Template.Spec.Containers[1].Command[2]).To(ContainSubstring(`"${PROXY_FLAGS[@]}"`))
+
+		cr.Spec.Env = append(cr.Spec.Env, corev1.EnvVar{Name: "no_proxy", Value: "git.corp.example.com,.corp"})
+		deploy = r.buildRunnerDeployment(cr)
+		env = map[string]string{}
+		for _, e := range deploy.Spec.Template.Spec.Containers[1].Env {
+			env[e.Name] = e.Value
+		}
+		Expect(env).To(HaveKeyWithValue("NO_PROXY", ".corp,git.corp.example.com,"+clusterNoProxy))
+		Expect(env).To(HaveKeyWithValue("no_proxy", env["NO_PROXY"]))
+	})
 })
 
 // ────────────────────────────────────────────────────────────────────────────
@@ -0,0 +1,173 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Metrics
+// ────────────────────────────────────────────────────────────────────────────
+//
+// The manager serves these next to controller-runtime's own (reconcile
+// counts and totals, work queue depth) on its metrics endpoint. The
+// kindling Grafana dashboard (config/observability) is built on them.
+
+// DSE phases reported by kindling_environments.
+const (
+	PhaseReady   = "Ready"
+	PhasePending = "Pending"
+	PhaseFailed  = "Failed"
+	PhasePaused  = "Paused"
+	PhaseAsleep  = "Asleep"
+)
+
+var (
+	reconcileStepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kindling_reconcile_step_duration_seconds",
+		Help:    "Time taken by each step of a DevStagingEnvironment reconcile.",
+		Buckets: prometheus.ExponentialBuckets(0.005, 2, 12),
+	}, []string{"step", "result"})
+
+	componentReadySeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kindling_component_ready_seconds",
+		Help:    "Time from a DevStagingEnvironment being created or falling out of Ready until it is Ready again.",
+		Buckets: []float64{1, 2, 5, 10, 20, 30, 60, 120, 300, 600, 1200},
+	})
+
+	buildFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kindling_build_failures_total",
+		Help: "Times a DevStagingEnvironment's image could not be pulled because it was never built or pushed.",
+	}, []string{"namespace", "name"})
+
+	environmentsDesc = prometheus.NewDesc("kindling_environments",
+		"DevStagingEnvironments in the cluster by phase.", []string{"phase"}, nil)
+)
+
+func init() {
+	metrics.Registry.MustRegister(reconcileStepDuration, componentReadySeconds, buildFailures)
+}
+
+// timeStep runs one reconcile step and records how long it took.
+func timeStep(step string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	reconcileStepDuration.WithLabelValues(step, result).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// observeCondition updates the metrics a condition change feeds. prev is
+// the condition condition replaces, or nil.
+func observeCondition(cr *appsv1alpha1.DevStagingEnvironment, prev *metav1.Condition, condition metav1.Condition) {
+	switch {
+	case condition.Type == readyCondition && condition.Status == metav1.ConditionTrue:
+		since := cr.CreationTimestamp.Time
+		if prev != nil {
+			since = prev.LastTransitionTime.Time
+		}
+		if !since.IsZero() {
+			componentReadySeconds.Observe(time.Since(since).Seconds())
+		}
+	case condition.Type == imagesBuiltCondition && condition.Reason == "ImagePullFailed":
+		buildFailures.WithLabelValues(cr.Namespace, cr.Name).Inc()
+	}
+}
+
+// failureReasons are the condition reasons that put a DSE in PhaseFailed:
+// it won't become Ready until someone changes something.
+var failureReasons = map[string]bool{
+	"DeploymentFailed":        true,
+	"ServiceFailed":           true,
+	"ReconcileFailed":         true,
+	"ImagePullFailed":         true,
+	"JobFailed":               true,
+	"SeedFailed":              true,
+	ReasonIngressPathConflict: true,
+	ReasonNodePortConflict:    true,
+}
+
+// Phase sums up cr's conditions as Ready, Failed or Pending, or is Paused
+// while spec.paused is set and Asleep while spec.autoSleep has it asleep.
+func Phase(cr *appsv1alpha1.DevStagingEnvironment) string {
+	switch {
+	case cr.Spec.Paused:
+		return PhasePaused
+	case asleep(cr):
+		return PhaseAsleep
+	}
+	if meta.IsStatusConditionTrue(cr.Status.Conditions, readyCondition) {
+		return PhaseReady
+	}
+	for _, c := range cr.Status.Conditions {
+		if c.Status == metav1.ConditionFalse && failureReasons[c.Reason] {
+			return PhaseFailed
+		}
+	}
+	return PhasePending
+}
+
+// environmentCollector counts DSEs by phase at scrape time, from the
+// manager's cache, so deleted DSEs never linger in the gauge.
+type environmentCollector struct {
+	reader client.Reader
+}
+
+func (c environmentCollector) Describe(ch chan<- *prometheus.Desc) { ch <- environmentsDesc }
+
+func (c environmentCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	list := &appsv1alpha1.DevStagingEnvironmentList{}
+	if err := c.reader.List(ctx, list); err != nil {
+		ch <- prometheus.NewInvalidMetric(environmentsDesc, err)
+		return
+	}
+	counts := map[string]int{PhaseReady: 0, PhasePending: 0, PhaseFailed: 0, PhasePaused: 0, PhaseAsleep: 0}
+	for i := range list.Items {
+		counts[Phase(&list.Items[i])]++
+	}
+	for phase, n := range counts {
+		ch <- prometheus.MustNewConstMetric(environmentsDesc, prometheus.GaugeValue, float64(n), phase)
+	}
+}
+
+// registerEnvironmentCollector adds the kindling_environments gauge, read
+// through reader. A second manager in the same process (as in tests)
+// keeps the first one's collector.
+func registerEnvironmentCollector(reader client.Reader) error {
+	err := metrics.Registry.Register(environmentCollector{reader: reader})
+	var already prometheus.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		return nil
+	}
+	return err
+}
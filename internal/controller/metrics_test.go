@@ -0,0 +1,63 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("metrics", func() {
+	It("sums up conditions as a phase", func() {
+		cr := newTestDSE("test-app")
+		Expect(Phase(cr)).To(Equal(PhasePending))
+
+		cr.Status.Conditions = []metav1.Condition{
+			{Type: readyCondition, Status: metav1.ConditionFalse, Reason: "ResourcesNotReady"},
+			{Type: componentsReadyCondition, Status: metav1.ConditionFalse, Reason: "ReplicasUnavailable"},
+		}
+		Expect(Phase(cr)).To(Equal(PhasePending))
+
+		cr.Status.Conditions = append(cr.Status.Conditions,
+			metav1.Condition{Type: imagesBuiltCondition, Status: metav1.ConditionFalse, Reason: "ImagePullFailed"})
+		Expect(Phase(cr)).To(Equal(PhaseFailed))
+
+		cr.Status.Conditions = []metav1.Condition{
+			{Type: readyCondition, Status: metav1.ConditionTrue, Reason: "AllResourcesReady"},
+		}
+		Expect(Phase(cr)).To(Equal(PhaseReady))
+	})
+
+	It("counts a build failure each time the image stops pulling", func() {
+		r := &DevStagingEnvironmentReconciler{}
+		cr := newTestDSE("metrics-app")
+		failures := buildFailures.WithLabelValues(cr.Namespace, cr.Name)
+		before := testutil.ToFloat64(failures)
+
+		failed := metav1.Condition{Type: imagesBuiltCondition, Status: metav1.ConditionFalse, Reason: "ImagePullFailed"}
+		r.setCondition(cr, failed)
+		r.setCondition(cr, failed)
+		Expect(testutil.ToFloat64(failures)).To(Equal(before + 1))
+
+		r.setCondition(cr, metav1.Condition{Type: imagesBuiltCondition, Status: metav1.ConditionTrue, Reason: "ImagesAvailable"})
+		r.setCondition(cr, failed)
+		Expect(testutil.ToFloat64(failures)).To(Equal(before + 2))
+	})
+})
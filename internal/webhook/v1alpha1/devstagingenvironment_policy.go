@@ -0,0 +1,143 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+	"github.com/jeffvincent/kindling/pkg/policy"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Policies — platform rules written in CEL
+// ────────────────────────────────────────────────────────────────────────────
+//
+// kindling policy sync copies a repo's .kindling/policies/*.yaml into the
+// kindling-policies ConfigMap, one key per file. The policies are parsed,
+// compiled, and evaluated by pkg/policy, the same code kindling validate
+// runs, over the same object (policy.Object), so a manifest that passes
+// kindling validate isn't denied here.
+//
+// kindling validate can also give the policies the Dockerfile's base
+// images as baseImages. The webhook has no Dockerfile, so it skips
+// validations that read baseImages.
+
+const (
+	policyConfigMapName      = "kindling-policies"
+	policyConfigMapNamespace = "default"
+)
+
+// policyCache holds the policies compiled from the kindling-policies
+// ConfigMap, recompiled when it changes.
+type policyCache struct {
+	mu              sync.Mutex
+	resourceVersion string
+	policies        []*policy.Compiled
+	problems        []string
+}
+
+// load returns the ConfigMap's policies, and what kept any of them from
+// compiling. No ConfigMap means no policies.
+func (c *policyCache) load(ctx context.Context, reader client.Reader) ([]*policy.Compiled, []string, error) {
+	cm := &corev1.ConfigMap{}
+	err := reader.Get(ctx, types.NamespacedName{Name: policyConfigMapName, Namespace: policyConfigMapNamespace}, cm)
+	if apierrors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cm.ResourceVersion == c.resourceVersion {
+		return c.policies, c.problems, nil
+	}
+	policies, problems := compilePolicies(cm.Data)
+	c.resourceVersion, c.policies, c.problems = cm.ResourceVersion, policies, problems
+	return policies, problems, nil
+}
+
+// compilePolicies compiles the policies of files, skipping the ones that
+// don't compile rather than all of them.
+func compilePolicies(files map[string]string) ([]*policy.Compiled, []string) {
+	names := make([]string, 0, len(files))
+	for f := range files {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+	var policies []*policy.Compiled
+	var problems []string
+	for _, f := range names {
+		parsed, err := policy.Parse(f, files[f])
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		for _, p := range parsed {
+			compiled, err := policy.Compile(p)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", f, err))
+				continue
+			}
+			policies = append(policies, compiled)
+		}
+	}
+	return policies, problems
+}
+
+// policyErrors returns cr's violations of a deny policy as field errors,
+// and of a warn policy as warnings.
+func policyErrors(policies []*policy.Compiled, cr *appsv1alpha1.DevStagingEnvironment) (field.ErrorList, []string, error) {
+	if len(policies) == 0 {
+		return nil, nil, nil
+	}
+	object, err := policy.Object(cr)
+	if err != nil {
+		return nil, nil, err
+	}
+	var errs field.ErrorList
+	var warnings []string
+	for _, p := range policies {
+		for _, v := range p.Evaluate(object, nil) {
+			msg := fmt.Sprintf("policy %s: %s", v.Policy, v.Message)
+			if v.Action == policy.ActionWarn {
+				warnings = append(warnings, msg)
+				continue
+			}
+			errs = append(errs, field.Forbidden(policyPath(v.FieldPath), msg))
+		}
+	}
+	return errs, warnings, nil
+}
+
+// policyPath turns a dotted fieldPath into a field.Path.
+func policyPath(fieldPath string) *field.Path {
+	parts := strings.Split(fieldPath, ".")
+	return field.NewPath(parts[0], parts[1:]...)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/yaml"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+	appsv1beta1 "github.com/jeffvincent/kindling/api/v1beta1"
+	"github.com/jeffvincent/kindling/pkg/policy"
+)
+
+// A v1beta1 manifest that leaves replicas, the pull policy, and the
+// requests to the defaulting webhook.
+const policyTestManifest = `apiVersion: apps.example.com/v1beta1
+kind: DevStagingEnvironment
+metadata:
+  name: orders
+  namespace: default
+spec:
+  deployment:
+    image: localhost:5001/orders:dev
+    port: 8080
+    resources:
+      limits:
+        cpu: 500m
+        memory: 256Mi
+  service:
+    port: 80
+`
+
+// Policies over fields that only exist after conversion or defaulting,
+// and two the manifest breaks, one of them in Rego.
+const policyTestPolicies = `name: limits-set
+action: deny
+validations:
+  - expression: "has(object.spec.deployment.resources.memoryLimit)"
+    message: set a memory limit
+    fieldPath: spec.deployment.resources.memoryLimit
+---
+name: requests-set
+action: deny
+validations:
+  - expression: "has(object.spec.deployment.resources.memoryRequest)"
+    message: set a memory request
+    fieldPath: spec.deployment.resources.memoryRequest
+---
+name: single-replica
+action: deny
+validations:
+  - expression: "object.spec.deployment.replicas == 1"
+    message: run one replica
+    fieldPath: spec.deployment.replicas
+---
+name: pull-local-images
+action: warn
+validations:
+  - expression: "object.spec.deployment.imagePullPolicy == 'Always'"
+    message: local images must be pulled on every start
+    fieldPath: spec.deployment.imagePullPolicy
+---
+name: no-dev-tags
+action: deny
+validations:
+  - expression: "!object.spec.deployment.image.endsWith(':dev')"
+    message: pin the image to a release
+    fieldPath: spec.deployment.image
+---
+name: rego-release-images
+action: deny
+language: rego
+rego: |
+  package kindling.images
+  violation contains "run one replica" if {
+    input.object.spec.deployment.replicas != 1
+  }
+  violation contains {"message": "pin the image to a release", "fieldPath": "spec.deployment.image"} if {
+    endswith(input.object.spec.deployment.image, ":dev")
+  }
+`
+
+var _ = Describe("Policies", func() {
+	// kindling validate's path: it decodes the manifest and converts it
+	// to the hub, and policy.Object applies the defaults.
+	decoded := func() *appsv1alpha1.DevStagingEnvironment {
+		beta := &appsv1beta1.DevStagingEnvironment{}
+		Expect(yaml.Unmarshal([]byte(policyTestManifest), beta)).To(Succeed())
+		cr := &appsv1alpha1.DevStagingEnvironment{}
+		Expect(beta.ConvertTo(cr)).To(Succeed())
+		return cr
+	}
+	// The webhook path: the API server converts the v1beta1 manifest to
+	// the stored version, and the defaulting webhook runs before the
+	// validating one.
+	admitted := func() *appsv1alpha1.DevStagingEnvironment {
+		cr := decoded()
+		Expect((&DevStagingEnvironmentDefaulter{}).Default(context.Background(), cr)).To(Succeed())
+		return cr
+	}
+
+	It("evaluates a manifest to the same object kindling validate does", func() {
+		cliObject, err := policy.Object(decoded())
+		Expect(err).NotTo(HaveOccurred())
+
+		webhookObject, err := policy.Object(admitted())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cliObject["spec"]).To(Equal(webhookObject["spec"]))
+	})
+
+	It("reaches the same verdicts as kindling validate", func() {
+		loaded, err := policy.Load(map[string]string{"platform.yaml": policyTestPolicies})
+		Expect(err).NotTo(HaveOccurred())
+		object, err := policy.Object(decoded())
+		Expect(err).NotTo(HaveOccurred())
+		var cliDenied, cliWarned []string
+		for _, p := range loaded {
+			for _, v := range p.Evaluate(object, nil) {
+				if v.Action == policy.ActionWarn {
+					cliWarned = append(cliWarned, v.Policy)
+				} else {
+					cliDenied = append(cliDenied, v.Policy)
+				}
+			}
+		}
+
+		policies, problems := compilePolicies(map[string]string{"platform.yaml": policyTestPolicies})
+		Expect(problems).To(BeEmpty())
+		errs, warnings, err := policyErrors(policies, admitted())
+		Expect(err).NotTo(HaveOccurred())
+		var webhookDenied, webhookWarned []string
+		for _, e := range errs {
+			webhookDenied = append(webhookDenied, strings.TrimSuffix(strings.Fields(e.Detail)[1], ":"))
+		}
+		for _, w := range warnings {
+			webhookWarned = append(webhookWarned, strings.TrimSuffix(strings.Fields(w)[1], ":"))
+		}
+
+		sort.Strings(cliDenied)
+		sort.Strings(webhookDenied)
+		Expect(cliDenied).To(Equal([]string{"no-dev-tags", "rego-release-images"}))
+		Expect(webhookDenied).To(Equal(cliDenied))
+		Expect(cliWarned).To(BeEmpty())
+		Expect(webhookWarned).To(Equal(cliWarned))
+	})
+})
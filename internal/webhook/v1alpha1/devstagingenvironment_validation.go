@@ -0,0 +1,221 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+	"github.com/jeffvincent/kindling/internal/controller"
+)
+
+// validateSpec returns what the CRD schema can't express but would break
+// reconcile: the API server rejects the Deployment, CronJob, or pod the
+// operator builds, or two of the environment's objects get one name.
+func validateSpec(cr *appsv1alpha1.DevStagingEnvironment) field.ErrorList {
+	var errs field.ErrorList
+	spec := field.NewPath("spec")
+	deployment := spec.Child("deployment")
+	d := cr.Spec.Deployment
+
+	if strings.TrimSpace(d.Image) == "" {
+		errs = append(errs, field.Required(deployment.Child("image"),
+			"nothing to run: build and push the app's image (kindling push, or the kindling-build action) and set it here"))
+	} else {
+		errs = append(errs, validateImage(deployment.Child("image"), d.Image)...)
+	}
+	errs = append(errs, validateSchedule(deployment.Child("schedule"), d.Schedule)...)
+	if hc := d.HealthCheck; hc != nil {
+		errs = append(errs, validatePort(deployment.Child("healthCheck", "port"), hc.Port)...)
+	}
+	errs = append(errs, validatePort(spec.Child("service", "targetPort"), cr.Spec.Service.TargetPort)...)
+
+	// Each init container and sidecar shares the pod with the app
+	// container, named after the environment, and a wait-for-<type>
+	// container per dependency.
+	initNames := map[string]bool{cr.Name: true}
+	for _, dep := range cr.Spec.Dependencies {
+		initNames["wait-for-"+string(dep.Type)] = true
+	}
+	for i, c := range d.InitContainers {
+		path := deployment.Child("initContainers").Index(i)
+		if initNames[c.Name] {
+			errs = append(errs, field.Duplicate(path.Child("name"), c.Name))
+		}
+		initNames[c.Name] = true
+		errs = append(errs, validateImage(path.Child("image"), c.Image)...)
+	}
+	for i, c := range d.Sidecars {
+		path := deployment.Child("sidecars").Index(i)
+		if initNames[c.Name] {
+			errs = append(errs, field.Duplicate(path.Child("name"), c.Name))
+		}
+		initNames[c.Name] = true
+		errs = append(errs, validateImage(path.Child("image"), c.Image)...)
+	}
+
+	// A dependency's objects are named <environment>-<type>, and its seed
+	// Job <environment>-<type>-seed, which a job of that name would reuse.
+	types := map[appsv1alpha1.DependencyType]bool{}
+	seedJobs := map[string]bool{}
+	for i, dep := range cr.Spec.Dependencies {
+		path := spec.Child("dependencies").Index(i)
+		if types[dep.Type] {
+			errs = append(errs, field.Duplicate(path.Child("type"), dep.Type))
+		}
+		types[dep.Type] = true
+		errs = append(errs, validatePort(path.Child("port"), dep.Port)...)
+		errs = append(errs, validateImage(path.Child("image"), dep.Image)...)
+		if dep.Seed != nil {
+			seedJobs[string(dep.Type)+"-seed"] = true
+			errs = append(errs, validateImage(path.Child("seed", "image"), dep.Seed.Image)...)
+		}
+	}
+	for i, job := range cr.Spec.Jobs {
+		path := spec.Child("jobs").Index(i)
+		if seedJobs[job.Name] {
+			errs = append(errs, field.Invalid(path.Child("name"), job.Name,
+				fmt.Sprintf("the Job would be %s-%s, the name of the %s seed Job", cr.Name, job.Name, strings.TrimSuffix(job.Name, "-seed"))))
+		}
+		errs = append(errs, validateImage(path.Child("image"), job.Image)...)
+	}
+
+	if c := cr.Spec.Canary; c != nil {
+		errs = append(errs, validateImage(spec.Child("canary", "image"), c.Image)...)
+		if d.Schedule != "" {
+			errs = append(errs, field.Invalid(spec.Child("canary"), c.Image,
+				"a scheduled app serves no requests to split: remove deployment.schedule or the canary"))
+		}
+	}
+
+	if cr.Spec.AutoSleep != nil {
+		errs = append(errs, validateAutoSleep(spec.Child("autoSleep"), cr)...)
+	}
+
+	for i, name := range cr.Spec.DependsOn {
+		if name == cr.Name {
+			errs = append(errs, field.Invalid(spec.Child("dependsOn").Index(i), name, "a component can't wait for itself"))
+		}
+	}
+
+	if o := cr.Spec.Observability; o != nil && o.Instrumentation != "" && !o.Tracing {
+		errs = append(errs, field.Invalid(spec.Child("observability", "instrumentation"), o.Instrumentation,
+			"the agent would have nowhere to send traces: set observability.tracing: true"))
+	}
+	if cr.Name == controller.TracingCollectorName {
+		errs = append(errs, field.Invalid(field.NewPath("metadata", "name"), cr.Name,
+			"the name of the namespace's tracing collector"))
+	}
+	return errs
+}
+
+// validateStorageSizes denies changing the storageSize of a dependency
+// that already has a volume: a StatefulSet's volumeClaimTemplates can't
+// change, and Kind's local-path volumes can't grow.
+func validateStorageSizes(oldCR, cr *appsv1alpha1.DevStagingEnvironment) field.ErrorList {
+	had := map[appsv1alpha1.DependencyType]resource.Quantity{}
+	for _, dep := range oldCR.Spec.Dependencies {
+		if size, stateful := controller.DependencyStorageSize(dep); stateful {
+			had[dep.Type] = size
+		}
+	}
+	var errs field.ErrorList
+	for i, dep := range cr.Spec.Dependencies {
+		old, ok := had[dep.Type]
+		if !ok {
+			continue
+		}
+		if size, _ := controller.DependencyStorageSize(dep); size.Cmp(old) != 0 {
+			errs = append(errs, field.Forbidden(field.NewPath("spec", "dependencies").Index(i).Child("storageSize"),
+				fmt.Sprintf("the %s volume is %s and can't be resized: remove the dependency and add it back to recreate it with %s (its data is lost)",
+					dep.Type, old.String(), size.String())))
+		}
+	}
+	return errs
+}
+
+// validateAutoSleep checks that requests can wake the environment: they
+// arrive through an Ingress, which the activator can proxy, and all of
+// them reach the app.
+func validateAutoSleep(path *field.Path, cr *appsv1alpha1.DevStagingEnvironment) field.ErrorList {
+	idle := cr.Spec.AutoSleep.IdleMinutes
+	switch ing := cr.Spec.Ingress; {
+	case cr.Spec.Deployment.Schedule != "":
+		return field.ErrorList{field.Invalid(path, idle, "a scheduled app serves no requests to wake it: remove deployment.schedule or autoSleep")}
+	case ing == nil || !ing.Enabled:
+		return field.ErrorList{field.Invalid(path, idle, "only requests to the Ingress wake the environment: enable spec.ingress")}
+	case ing.Protocol == "grpc":
+		return field.ErrorList{field.Invalid(path, idle, "the activator proxies HTTP/1.1 and WebSockets, not gRPC: remove autoSleep")}
+	case cr.Spec.Canary != nil:
+		return field.ErrorList{field.Invalid(path, idle, "the canary's share of requests would skip the activator and find no pods: remove the canary or autoSleep")}
+	}
+	return nil
+}
+
+// validateImage rejects an image reference with whitespace in it, which
+// no registry serves. An empty optional image is fine.
+func validateImage(path *field.Path, image string) field.ErrorList {
+	if image == "" || !strings.ContainsAny(image, " \t\n") {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(path, image, "an image reference can't contain whitespace")}
+}
+
+// validatePort checks an optional port that the schema leaves unbounded.
+func validatePort(path *field.Path, port *int32) field.ErrorList {
+	if port == nil || (*port >= 1 && *port <= 65535) {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(path, *port, "must be between 1 and 65535")}
+}
+
+// validateSchedule parses a schedule the way the CronJob controller does:
+// five fields or a descriptor such as @hourly, without a time zone.
+func validateSchedule(path *field.Path, schedule string) field.ErrorList {
+	if schedule == "" {
+		return nil
+	}
+	if strings.Contains(schedule, "TZ=") {
+		return field.ErrorList{field.Invalid(path, schedule, "time zones aren't supported; the schedule runs in the cluster's time zone")}
+	}
+	if _, err := cron.ParseStandard(schedule); err != nil {
+		return field.ErrorList{field.Invalid(path, schedule, fmt.Sprintf("not a cron schedule: %v", err))}
+	}
+	return nil
+}
+
+// dependencyName is the name of a dependency's objects, as the operator
+// names them.
+func dependencyName(crName string, depType appsv1alpha1.DependencyType) string {
+	return crName + "-" + string(depType)
+}
+
+// activatorName is the name of the Service an environment with autoSleep
+// routes its Ingress through.
+func activatorName(crName string) string {
+	return crName + "-activator"
+}
+
+// canaryName is the name of a canary's objects.
+func canaryName(crName string) string {
+	return crName + "-canary"
+}
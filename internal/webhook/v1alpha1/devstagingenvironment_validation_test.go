@@ -0,0 +1,115 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+var _ = Describe("storageSize", func() {
+	withDependency := func(depType appsv1alpha1.DependencyType, size string) *appsv1alpha1.DevStagingEnvironment {
+		cr := newTestDSE("test-app")
+		dep := appsv1alpha1.DependencySpec{Type: depType}
+		if size != "" {
+			q := resource.MustParse(size)
+			dep.StorageSize = &q
+		}
+		cr.Spec.Dependencies = []appsv1alpha1.DependencySpec{dep}
+		return cr
+	}
+
+	It("denies resizing an existing dependency's volume", func() {
+		errs := validateStorageSizes(withDependency(appsv1alpha1.DependencyPostgres, ""), withDependency(appsv1alpha1.DependencyPostgres, "5Gi"))
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Field).To(Equal("spec.dependencies[0].storageSize"))
+		Expect(errs[0].Detail).To(ContainSubstring("is 1Gi and can't be resized"))
+	})
+
+	It("allows the same size however it is written", func() {
+		Expect(validateStorageSizes(withDependency(appsv1alpha1.DependencyPostgres, ""), withDependency(appsv1alpha1.DependencyPostgres, "1Gi"))).To(BeEmpty())
+		Expect(validateStorageSizes(withDependency(appsv1alpha1.DependencyPostgres, "1024Mi"), withDependency(appsv1alpha1.DependencyPostgres, "1Gi"))).To(BeEmpty())
+	})
+
+	It("allows any size for a new dependency or one without a volume", func() {
+		Expect(validateStorageSizes(newTestDSE("test-app"), withDependency(appsv1alpha1.DependencyPostgres, "5Gi"))).To(BeEmpty())
+		Expect(validateStorageSizes(withDependency(appsv1alpha1.DependencyMemcached, ""), withDependency(appsv1alpha1.DependencyMemcached, "5Gi"))).To(BeEmpty())
+	})
+})
+
+var _ = Describe("dependsOn", func() {
+	// validatorWith returns a validator reading a namespace that holds
+	// objs.
+	validatorWith := func(objs ...client.Object) *DevStagingEnvironmentValidator {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1alpha1.AddToScheme(scheme)).To(Succeed())
+		return &DevStagingEnvironmentValidator{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()}
+	}
+	dependsOn := func(name string, upstreams ...string) *appsv1alpha1.DevStagingEnvironment {
+		cr := newTestDSE(name)
+		cr.Spec.DependsOn = upstreams
+		return cr
+	}
+
+	It("denies an edge that closes a cycle", func() {
+		v := validatorWith(dependsOn("orders", "users"), dependsOn("users", "gateway"))
+		_, err := v.ValidateCreate(context.Background(), dependsOn("gateway", "billing", "orders"))
+		Expect(apierrors.IsInvalid(err)).To(BeTrue(), "err = %v", err)
+		Expect(err.Error()).To(ContainSubstring("spec.dependsOn[1]"))
+		Expect(err.Error()).To(ContainSubstring("dependsOn cycle gateway → orders → users → gateway"))
+	})
+
+	It("denies the second of two components that wait for each other", func() {
+		v := validatorWith(dependsOn("orders", "users"))
+		_, err := v.ValidateCreate(context.Background(), dependsOn("users", "orders"))
+		Expect(err).To(MatchError(ContainSubstring("dependsOn cycle users → orders → users")))
+	})
+
+	It("allows a chain and a diamond", func() {
+		v := validatorWith(dependsOn("orders", "users"), dependsOn("billing", "users"), dependsOn("users"))
+		_, err := v.ValidateCreate(context.Background(), dependsOn("gateway", "orders", "billing"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("leaves a component waiting for itself to validateSpec", func() {
+		errs, err := validatorWith().dependsOnCycle(context.Background(), dependsOn("orders", "orders"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errs).To(BeEmpty())
+	})
+
+	It("only warns about a cycle an update doesn't introduce", func() {
+		v := validatorWith(dependsOn("orders", "users"), dependsOn("users", "orders"))
+		oldCR := dependsOn("users", "orders")
+		cr := oldCR.DeepCopy()
+		cr.Spec.Deployment.Image = "my-image:v2"
+		warnings, err := v.ValidateUpdate(context.Background(), oldCR, cr)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(ContainElement(ContainSubstring("dependsOn cycle users → orders → users")))
+	})
+})
@@ -0,0 +1,296 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+	"github.com/jeffvincent/kindling/internal/controller"
+)
+
+// SetupDevStagingEnvironmentWebhookWithManager registers the conversion,
+// defaulting, and validating webhooks for DevStagingEnvironment. v1alpha1
+// is the hub; the other versions in the manager's scheme convert through
+// it at /convert, and are defaulted and validated as v1alpha1.
+func SetupDevStagingEnvironmentWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &appsv1alpha1.DevStagingEnvironment{}).
+		WithDefaulter(&DevStagingEnvironmentDefaulter{}).
+		WithValidator(&DevStagingEnvironmentValidator{Client: mgr.GetAPIReader()}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-apps-example-com-v1alpha1-devstagingenvironment,mutating=true,failurePolicy=ignore,sideEffects=None,groups=apps.example.com,resources=devstagingenvironments,verbs=create;update,versions=v1alpha1,name=mdevstagingenvironment-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// DevStagingEnvironmentDefaulter fills in the defaults a generated
+// manifest leaves out — replicas, the image pull policy, the probe, the
+// resource requests, and the standard labels — so the YAML stays short
+// while the object in the cluster is complete.
+type DevStagingEnvironmentDefaulter struct{}
+
+// Default applies the defaults on create and update.
+func (d *DevStagingEnvironmentDefaulter) Default(_ context.Context, cr *appsv1alpha1.DevStagingEnvironment) error {
+	cr.ApplyDefaults()
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-apps-example-com-v1alpha1-devstagingenvironment,mutating=false,failurePolicy=ignore,sideEffects=None,groups=apps.example.com,resources=devstagingenvironments,verbs=create;update,versions=v1alpha1,name=vdevstagingenvironment-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// DevStagingEnvironmentValidator turns away DevStagingEnvironments that
+// would only fail mid-reconcile: an image that can't be pulled by name, a
+// port out of range, a schedule that isn't cron, two parts of the
+// environment that get the same object name, a new storageSize for a
+// dependency's existing volume, a dependsOn that closes a cycle, or an
+// ingress host and path or node port something else already holds, and
+// whatever the platform's policies deny (devstagingenvironment_policy.go). Port mismatches are
+// only warned about. It fails open: reconcile reports the conflicts in the
+// NetworkValid condition, and the rest as events.
+type DevStagingEnvironmentValidator struct {
+	// Client reads straight from the API server; the webhook runs before
+	// the manager's cache has anything to say about the object.
+	Client client.Reader
+
+	policies policyCache
+}
+
+// ValidateCreate denies a DSE with any problem.
+func (v *DevStagingEnvironmentValidator) ValidateCreate(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) (admission.Warnings, error) {
+	return v.validate(ctx, nil, cr)
+}
+
+// ValidateUpdate denies an update that introduces a problem. A problem the
+// DSE already had is only a warning, so an object admitted before the
+// webhook, or stuck in a conflict, can still be edited out of it.
+func (v *DevStagingEnvironmentValidator) ValidateUpdate(ctx context.Context, oldCR, cr *appsv1alpha1.DevStagingEnvironment) (admission.Warnings, error) {
+	return v.validate(ctx, oldCR, cr)
+}
+
+// ValidateDelete allows every delete.
+func (v *DevStagingEnvironmentValidator) ValidateDelete(context.Context, *appsv1alpha1.DevStagingEnvironment) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks cr, and on update oldCR too, so that only new problems
+// are denied.
+func (v *DevStagingEnvironmentValidator) validate(ctx context.Context, oldCR, cr *appsv1alpha1.DevStagingEnvironment) (admission.Warnings, error) {
+	var warnings admission.Warnings
+	for _, p := range controller.PortMismatches(cr) {
+		warnings = append(warnings, p.Message)
+	}
+
+	errs := validateSpec(cr)
+	if oldCR != nil {
+		errs, warnings = ratchet(errs, validateSpec(oldCR), warnings)
+		errs = append(errs, validateStorageSizes(oldCR, cr)...)
+	}
+
+	// The checks against other objects read the cluster. Reconcile checks
+	// again, so a failed read doesn't block the apply.
+	collisions, err := v.nameCollisions(ctx, cr)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("could not check for name collisions: %v", err))
+	} else if oldCR != nil {
+		old, err := v.nameCollisions(ctx, oldCR)
+		if err == nil {
+			collisions, warnings = ratchet(collisions, old, warnings)
+		}
+	}
+	errs = append(errs, collisions...)
+
+	cycle, err := v.dependsOnCycle(ctx, cr)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("could not check spec.dependsOn for cycles: %v", err))
+	} else if oldCR != nil {
+		old, err := v.dependsOnCycle(ctx, oldCR)
+		if err == nil {
+			cycle, warnings = ratchet(cycle, old, warnings)
+		}
+	}
+	errs = append(errs, cycle...)
+
+	policyErrs, policyWarnings, err := v.checkPolicies(ctx, oldCR, cr)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("could not check the platform's policies: %v", err))
+	}
+	errs = append(errs, policyErrs...)
+	warnings = append(warnings, policyWarnings...)
+
+	conflicts, err := controller.FindNetworkConflicts(ctx, v.Client, cr)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("could not check for route and port conflicts: %v", err))
+	}
+	for _, p := range conflicts {
+		if oldCR != nil && !networkChanged(oldCR, cr) {
+			warnings = append(warnings, p.Message)
+			continue
+		}
+		errs = append(errs, conflictError(cr, p))
+	}
+
+	if len(errs) == 0 {
+		return warnings, nil
+	}
+	return warnings, apierrors.NewInvalid(appsv1alpha1.GroupVersion.WithKind("DevStagingEnvironment").GroupKind(), cr.Name, errs)
+}
+
+// checkPolicies evaluates the kindling-policies ConfigMap's policies. As
+// with the other checks, an update is only denied for a violation it
+// introduces. A policy that doesn't compile is skipped with a warning.
+func (v *DevStagingEnvironmentValidator) checkPolicies(ctx context.Context, oldCR, cr *appsv1alpha1.DevStagingEnvironment) (field.ErrorList, admission.Warnings, error) {
+	policies, problems, err := v.policies.load(ctx, v.Client)
+	if err != nil {
+		return nil, nil, err
+	}
+	var warnings admission.Warnings
+	for _, p := range problems {
+		warnings = append(warnings, fmt.Sprintf("skipped a policy of ConfigMap %s/%s: %s", policyConfigMapNamespace, policyConfigMapName, p))
+	}
+	errs, warned, err := policyErrors(policies, cr)
+	if err != nil {
+		return nil, warnings, err
+	}
+	warnings = append(warnings, warned...)
+	if oldCR != nil {
+		if old, _, err := policyErrors(policies, oldCR); err == nil {
+			errs, warnings = ratchet(errs, old, warnings)
+		}
+	}
+	return errs, warnings, nil
+}
+
+// ratchet splits errs into the ones that aren't in old, which are denied,
+// and the ones that are, which become warnings.
+func ratchet(errs, old field.ErrorList, warnings admission.Warnings) (field.ErrorList, admission.Warnings) {
+	had := map[string]bool{}
+	for _, e := range old {
+		had[e.Error()] = true
+	}
+	var denied field.ErrorList
+	for _, e := range errs {
+		if had[e.Error()] {
+			warnings = append(warnings, e.Error())
+			continue
+		}
+		denied = append(denied, e)
+	}
+	return denied, warnings
+}
+
+// conflictError points a route or node port conflict at the field that
+// claims it.
+func conflictError(cr *appsv1alpha1.DevStagingEnvironment, p controller.NetworkProblem) *field.Error {
+	if p.Reason == controller.ReasonNodePortConflict {
+		return field.Invalid(field.NewPath("spec", "service", "nodePort"), *cr.Spec.Service.NodePort, p.Message)
+	}
+	return field.Invalid(field.NewPath("spec", "ingress", "host"), cr.Spec.Ingress.Host, p.Message)
+}
+
+// nameCollisions finds other DSEs in cr's namespace that would create an
+// object with the same name as one of cr's: a DSE named like another's
+// dependency (<name>-<type>) or canary (<name>-canary) shares its Service
+// and workload names, and one named like another's activator Service
+// (<name>-activator) its Service.
+func (v *DevStagingEnvironmentValidator) nameCollisions(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) (field.ErrorList, error) {
+	list := &appsv1alpha1.DevStagingEnvironmentList{}
+	if err := v.Client.List(ctx, list, client.InNamespace(cr.Namespace)); err != nil {
+		return nil, err
+	}
+	var errs field.ErrorList
+	deps := field.NewPath("spec", "dependencies")
+	for _, other := range list.Items {
+		if other.Name == cr.Name {
+			continue
+		}
+		for i, dep := range cr.Spec.Dependencies {
+			if dependencyName(cr.Name, dep.Type) == other.Name {
+				errs = append(errs, field.Invalid(deps.Index(i).Child("type"), dep.Type,
+					fmt.Sprintf("the %s dependency would be named %s, which is DevStagingEnvironment %s", dep.Type, other.Name, other.Name)))
+			}
+		}
+		for _, dep := range other.Spec.Dependencies {
+			if dependencyName(other.Name, dep.Type) == cr.Name {
+				errs = append(errs, field.Invalid(field.NewPath("metadata", "name"), cr.Name,
+					fmt.Sprintf("DevStagingEnvironment %s already names its %s dependency %s", other.Name, dep.Type, cr.Name)))
+			}
+		}
+		if cr.Spec.Canary != nil && canaryName(cr.Name) == other.Name {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "canary"), cr.Spec.Canary.Image,
+				fmt.Sprintf("the canary would be named %s, which is DevStagingEnvironment %s", other.Name, other.Name)))
+		}
+		if other.Spec.Canary != nil && canaryName(other.Name) == cr.Name {
+			errs = append(errs, field.Invalid(field.NewPath("metadata", "name"), cr.Name,
+				fmt.Sprintf("DevStagingEnvironment %s already names its canary %s", other.Name, cr.Name)))
+		}
+		if cr.Spec.AutoSleep != nil && activatorName(cr.Name) == other.Name {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "autoSleep"), cr.Spec.AutoSleep.IdleMinutes,
+				fmt.Sprintf("the activator Service would be named %s, which is DevStagingEnvironment %s's Service", other.Name, other.Name)))
+		}
+		if other.Spec.AutoSleep != nil && activatorName(other.Name) == cr.Name {
+			errs = append(errs, field.Invalid(field.NewPath("metadata", "name"), cr.Name,
+				fmt.Sprintf("DevStagingEnvironment %s already names its activator Service %s", other.Name, cr.Name)))
+		}
+	}
+	return errs, nil
+}
+
+// dependsOnCycle finds a spec.dependsOn entry of cr that, through the
+// dependsOn of the other DSEs in its namespace, leads back to cr: the
+// components on the cycle would each wait for the next forever. A
+// component that names itself is validateSpec's to report.
+func (v *DevStagingEnvironmentValidator) dependsOnCycle(ctx context.Context, cr *appsv1alpha1.DevStagingEnvironment) (field.ErrorList, error) {
+	cycle, err := controller.FindDependsOnCycle(ctx, v.Client, cr)
+	if err != nil || len(cycle) <= 2 {
+		return nil, err
+	}
+	i := slices.Index(cr.Spec.DependsOn, cycle[1])
+	return field.ErrorList{field.Invalid(field.NewPath("spec", "dependsOn").Index(i), cycle[1],
+		fmt.Sprintf("dependsOn cycle %s — none of them would start", strings.Join(cycle, " → ")))}, nil
+}
+
+// networkClaim is the part of a DSE's spec that claims a route or port.
+type networkClaim struct {
+	host, path  string
+	tunnel      bool
+	serviceType string
+	nodePort    int32
+}
+
+func claimOf(cr *appsv1alpha1.DevStagingEnvironment) networkClaim {
+	var claim networkClaim
+	if ing := cr.Spec.Ingress; ing != nil && ing.Enabled {
+		claim.host, claim.path, claim.tunnel = strings.ToLower(ing.Host), ing.Path, ing.Tunnel
+	}
+	if cr.Spec.Service.NodePort != nil {
+		claim.serviceType, claim.nodePort = cr.Spec.Service.Type, *cr.Spec.Service.NodePort
+	}
+	return claim
+}
+
+// networkChanged reports whether an update touches the ingress route or
+// the node port of a DSE.
+func networkChanged(oldCR, cr *appsv1alpha1.DevStagingEnvironment) bool {
+	return claimOf(oldCR) != claimOf(cr)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+// The webhook's checks are plain functions of the object, so these specs
+// need no API server.
+func TestWebhook(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Webhook Suite")
+}
+
+// newTestDSE returns a minimal valid DevStagingEnvironment.
+func newTestDSE(name string) *appsv1alpha1.DevStagingEnvironment {
+	return &appsv1alpha1.DevStagingEnvironment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: appsv1alpha1.DevStagingEnvironmentSpec{
+			Deployment: appsv1alpha1.DeploymentSpec{Image: "my-image:latest", Port: 8080},
+			Service:    appsv1alpha1.ServiceSpec{Port: 80},
+		},
+	}
+}
@@ -0,0 +1,305 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy evaluates the platform's policies over a
+// DevStagingEnvironment, which platform admins keep in
+// .kindling/policies/*.yaml. A policy is either a list of CEL
+// validations, shaped like a ValidatingAdmissionPolicy's, or an OPA Rego
+// module whose violation rule is the set of its violations.
+// kindling validate evaluates them against manifests; the operator's
+// admission webhook evaluates them on every apply once kindling policy
+// sync has published them. Both parse, compile, and evaluate them here,
+// over the same object (Object), so a manifest that passes kindling
+// validate isn't denied at admission.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+const (
+	// The actions of a policy: a deny policy's violations are errors, a
+	// warn policy's warnings.
+	ActionDeny = "deny"
+	ActionWarn = "warn"
+
+	// The languages of a policy. CEL is the default.
+	LanguageCEL  = "cel"
+	LanguageRego = "rego"
+
+	// costLimit bounds the work one expression may do per object, as it
+	// does in the webhook.
+	costLimit = 1_000_000
+)
+
+// Policy is one document of a policy file.
+//
+//	name: no-latest-tags
+//	description: Pin every image to a version
+//	action: deny            # or warn
+//	validations:
+//	  - expression: "!object.spec.deployment.image.endsWith(':latest')"
+//	    message: "pin the image to a version, not :latest"
+//	    fieldPath: spec.deployment.image
+//
+// A Rego policy has a module instead of validations:
+//
+//	name: no-latest-tags
+//	language: rego
+//	rego: |
+//	  package kindling.tags
+//	  violation contains msg if {
+//	    endswith(input.object.spec.deployment.image, ":latest")
+//	    msg := "pin the image to a version, not :latest"
+//	  }
+type Policy struct {
+	Name        string       `yaml:"name" json:"name"`
+	Description string       `yaml:"description,omitempty" json:"description,omitempty"`
+	Action      string       `yaml:"action,omitempty" json:"action"`
+	Language    string       `yaml:"language,omitempty" json:"language,omitempty"`
+	Validations []Validation `yaml:"validations,omitempty" json:"validations,omitempty"`
+	Rego        string       `yaml:"rego,omitempty" json:"rego,omitempty"`
+	File        string       `yaml:"-" json:"file"` // relative to the policies directory
+}
+
+// Validation is a CEL expression every environment must satisfy.
+type Validation struct {
+	Expression        string `yaml:"expression" json:"expression"`
+	Message           string `yaml:"message,omitempty" json:"message,omitempty"`
+	MessageExpression string `yaml:"messageExpression,omitempty" json:"messageExpression,omitempty"`
+	FieldPath         string `yaml:"fieldPath,omitempty" json:"fieldPath,omitempty"`
+}
+
+// Compiled is a policy with its expressions, or its module, ready to run.
+type Compiled struct {
+	Policy
+	checks []compiledValidation
+	query  *rego.PreparedEvalQuery // nil for a CEL policy
+}
+
+type compiledValidation struct {
+	Validation
+	program    cel.Program
+	message    cel.Program // nil without a messageExpression
+	baseImages bool        // reads baseImages
+}
+
+// Violation is a validation an environment fails.
+type Violation struct {
+	Policy    string
+	Action    string
+	FieldPath string
+	Message   string
+}
+
+var env = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		cel.Variable("baseImages", cel.ListType(cel.StringType)),
+		ext.Strings(ext.StringsVersion(2)),
+	)
+})
+
+// Load parses and compiles every policy in files, by file name. A policy
+// name may only be defined once.
+func Load(files map[string]string) ([]*Compiled, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var policies []*Compiled
+	seen := map[string]string{}
+	for _, name := range names {
+		parsed, err := Parse(name, files[name])
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range parsed {
+			if other, ok := seen[p.Name]; ok {
+				return nil, fmt.Errorf("%s: policy %s is already defined in %s", name, p.Name, other)
+			}
+			seen[p.Name] = name
+			compiled, err := Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			policies = append(policies, compiled)
+		}
+	}
+	return policies, nil
+}
+
+// Parse reads the policies of the file name, which may hold several YAML
+// documents.
+func Parse(name, data string) ([]Policy, error) {
+	var policies []Policy
+	dec := yaml.NewDecoder(strings.NewReader(data))
+	dec.KnownFields(true)
+	for {
+		var p Policy
+		err := dec.Decode(&p)
+		if errors.Is(err, io.EOF) {
+			return policies, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if p.Name == "" && len(p.Validations) == 0 && p.Rego == "" {
+			continue
+		}
+		p.File = name
+		policies = append(policies, p)
+	}
+}
+
+// Compile checks p and compiles its expressions or its Rego module.
+func Compile(p Policy) (*Compiled, error) {
+	if p.Name == "" {
+		return nil, fmt.Errorf("a policy needs a name")
+	}
+	if p.Action == "" {
+		p.Action = ActionDeny
+	}
+	if p.Action != ActionDeny && p.Action != ActionWarn {
+		return nil, fmt.Errorf("policy %s: action must be deny or warn, got %q", p.Name, p.Action)
+	}
+	switch p.Language {
+	case "", LanguageCEL:
+	case LanguageRego:
+		return compileRego(p)
+	default:
+		return nil, fmt.Errorf("policy %s: language must be cel or rego, got %q", p.Name, p.Language)
+	}
+	switch {
+	case len(p.Validations) == 0:
+		return nil, fmt.Errorf("policy %s has no validations", p.Name)
+	case p.Rego != "":
+		return nil, fmt.Errorf("policy %s: rego needs language: rego", p.Name)
+	}
+	env, err := env()
+	if err != nil {
+		return nil, err
+	}
+
+	compiled := &Compiled{Policy: p}
+	for i, v := range p.Validations {
+		ast, iss := env.Compile(v.Expression)
+		if iss.Err() != nil {
+			return nil, fmt.Errorf("policy %s: validations[%d].expression: %w", p.Name, i, iss.Err())
+		}
+		if t := ast.OutputType(); t != cel.BoolType && t != cel.DynType {
+			return nil, fmt.Errorf("policy %s: validations[%d].expression must be a bool, not %s", p.Name, i, t)
+		}
+		check := compiledValidation{Validation: v}
+		if check.program, err = env.Program(ast, cel.CostLimit(costLimit)); err != nil {
+			return nil, fmt.Errorf("policy %s: validations[%d].expression: %w", p.Name, i, err)
+		}
+		for _, ref := range ast.NativeRep().ReferenceMap() {
+			if ref.Name == "baseImages" {
+				check.baseImages = true
+			}
+		}
+		if v.MessageExpression != "" {
+			msg, iss := env.Compile(v.MessageExpression)
+			if iss.Err() != nil {
+				return nil, fmt.Errorf("policy %s: validations[%d].messageExpression: %w", p.Name, i, iss.Err())
+			}
+			if check.message, err = env.Program(msg, cel.CostLimit(costLimit)); err != nil {
+				return nil, fmt.Errorf("policy %s: validations[%d].messageExpression: %w", p.Name, i, err)
+			}
+		}
+		compiled.checks = append(compiled.checks, check)
+	}
+	return compiled, nil
+}
+
+// Evaluate returns the validations object fails. baseImages is nil when
+// the Dockerfile is unknown, which skips the validations that read it.
+// One that can't be evaluated, say because it reads a field the object
+// doesn't have without has(), fails too.
+func (p *Compiled) Evaluate(object map[string]interface{}, baseImages []string) []Violation {
+	if p.query != nil {
+		return p.evaluateRego(object, baseImages)
+	}
+	images := baseImages
+	if images == nil {
+		images = []string{}
+	}
+	vars := map[string]interface{}{"object": object, "baseImages": images}
+	var violations []Violation
+	for _, c := range p.checks {
+		if c.baseImages && baseImages == nil {
+			continue
+		}
+		out, _, err := c.program.Eval(vars)
+		if err == nil {
+			if ok, isBool := out.Value().(bool); isBool && ok {
+				continue
+			}
+		}
+		message := c.Message
+		if c.message != nil {
+			if out, _, merr := c.message.Eval(vars); merr == nil {
+				if s, ok := out.Value().(string); ok && s != "" {
+					message = s
+				}
+			}
+		}
+		if message == "" {
+			message = "failed expression: " + c.Expression
+		}
+		if err != nil {
+			message += fmt.Sprintf(" (could not evaluate: %v)", err)
+		}
+		fieldPath := c.FieldPath
+		if fieldPath == "" {
+			fieldPath = "spec"
+		}
+		violations = append(violations, Violation{Policy: p.Name, Action: p.Action, FieldPath: fieldPath, Message: message})
+	}
+	return violations
+}
+
+// Object returns cr as every policy sees it, in kindling validate and in
+// the admission webhook alike: the v1alpha1 object with the defaulting
+// webhook's defaults applied, as JSON, without its status.
+func Object(cr *v1alpha1.DevStagingEnvironment) (map[string]interface{}, error) {
+	cr = cr.DeepCopy()
+	cr.ApplyDefaults()
+	cr.APIVersion, cr.Kind = v1alpha1.GroupVersion.String(), "DevStagingEnvironment"
+	cr.Status = v1alpha1.DevStagingEnvironmentStatus{}
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cr)
+	if err != nil {
+		return nil, err
+	}
+	delete(object, "status")
+	return object, nil
+}
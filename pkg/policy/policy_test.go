@@ -0,0 +1,170 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jeffvincent/kindling/api/v1alpha1"
+)
+
+var _ = Describe("Compile", func() {
+	DescribeTable("rejects a policy with",
+		func(p Policy) {
+			_, err := Compile(p)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("no name", Policy{Validations: []Validation{{Expression: "true"}}}),
+		Entry("no validations", Policy{Name: "empty"}),
+		Entry("an unknown action", Policy{Name: "a", Action: "block", Validations: []Validation{{Expression: "true"}}}),
+		Entry("a syntax error", Policy{Name: "b", Validations: []Validation{{Expression: "object.spec.("}}}),
+		Entry("a string result", Policy{Name: "c", Validations: []Validation{{Expression: "'yes'"}}}),
+		Entry("an unknown language", Policy{Name: "d", Language: "cue", Validations: []Validation{{Expression: "true"}}}),
+		Entry("a rego module but no language", Policy{Name: "e", Validations: []Validation{{Expression: "true"}}, Rego: "package e"}),
+		Entry("no rego module", Policy{Name: "f", Language: LanguageRego}),
+		Entry("rego and validations", Policy{Name: "g", Language: LanguageRego, Rego: regoModule, Validations: []Validation{{Expression: "true"}}}),
+		Entry("a rego syntax error", Policy{Name: "h", Language: LanguageRego, Rego: "package h\nviolation contains msg if {"}),
+		Entry("no violation rule", Policy{Name: "i", Language: LanguageRego, Rego: "package i\ndeny contains \"no\" if { true }"}),
+		Entry("a network call", Policy{Name: "j", Language: LanguageRego, Rego: `package j
+violation contains "down" if { http.send({"method": "GET", "url": "http://example.com"}).status_code != 200 }`}),
+	)
+
+	It("skips the validations that read baseImages when they're unknown", func() {
+		parsed, err := Parse("base.yaml", `name: approved-bases
+validations:
+  - expression: "baseImages.all(i, i.startsWith('cgr.dev/'))"
+    message: build FROM an approved base image
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(HaveLen(1))
+		Expect(parsed[0].File).To(Equal("base.yaml"))
+
+		p, err := Compile(parsed[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Action).To(Equal(ActionDeny), "deny is the default action")
+
+		object := map[string]interface{}{}
+		Expect(p.Evaluate(object, nil)).To(BeEmpty())
+		v := p.Evaluate(object, []string{"cgr.dev/chainguard/go", "node:22"})
+		Expect(v).To(HaveLen(1))
+		Expect(v[0].Message).To(Equal("build FROM an approved base image"))
+		Expect(v[0].FieldPath).To(Equal("spec"))
+	})
+})
+
+// regoModule denies :latest images and, when the Dockerfile is known,
+// unapproved base images.
+const regoModule = `package kindling.platform
+
+violation contains {"message": msg, "fieldPath": "spec.deployment.image"} if {
+	endswith(input.object.spec.deployment.image, ":latest")
+	msg := sprintf("image %s is not pinned to a version", [input.object.spec.deployment.image])
+}
+
+violation contains msg if {
+	some image in input.baseImages
+	not startswith(image, "cgr.dev/")
+	msg := sprintf("unapproved base image %s", [image])
+}
+`
+
+var _ = Describe("a Rego policy", func() {
+	var p *Compiled
+
+	BeforeEach(func() {
+		policies, err := Load(map[string]string{"platform.yaml": "name: platform\nlanguage: rego\naction: warn\nrego: |\n" +
+			"  " + strings.ReplaceAll(strings.TrimSpace(regoModule), "\n", "\n  ") + "\n"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policies).To(HaveLen(1))
+		p = policies[0]
+		Expect(p.Action).To(Equal(ActionWarn))
+	})
+
+	object := func(image string) map[string]interface{} {
+		return map[string]interface{}{"spec": map[string]interface{}{
+			"deployment": map[string]interface{}{"image": image, "replicas": int64(1)},
+		}}
+	}
+
+	It("reports each element of its violation set", func() {
+		Expect(p.Evaluate(object("orders:1.2.0"), []string{"cgr.dev/chainguard/go"})).To(BeEmpty())
+
+		v := p.Evaluate(object("orders:latest"), []string{"node:22"})
+		Expect(v).To(ConsistOf(
+			Violation{Policy: "platform", Action: ActionWarn, FieldPath: "spec.deployment.image", Message: "image orders:latest is not pinned to a version"},
+			Violation{Policy: "platform", Action: ActionWarn, FieldPath: "spec", Message: "unapproved base image node:22"},
+		))
+	})
+
+	It("skips the rules that read baseImages when they're unknown", func() {
+		Expect(p.Evaluate(object("orders:1.2.0"), nil)).To(BeEmpty())
+	})
+
+	It("fails when its module can't be evaluated", func() {
+		broken, err := Compile(Policy{Name: "broken", Language: LanguageRego, Rego: "package broken\nviolation contains x if { x := 1 / 0 }"})
+		Expect(err).NotTo(HaveOccurred())
+		v := broken.Evaluate(object("orders:1.2.0"), nil)
+		Expect(v).To(HaveLen(1))
+		Expect(v[0].Message).To(ContainSubstring("could not evaluate"))
+	})
+})
+
+var _ = Describe("Load", func() {
+	It("rejects a policy defined twice", func() {
+		_, err := Load(map[string]string{
+			"a.yaml": "name: once\nvalidations:\n  - expression: \"true\"\n",
+			"b.yaml": "name: once\nvalidations:\n  - expression: \"true\"\n",
+		})
+		Expect(err).To(MatchError(ContainSubstring("policy once is already defined in a.yaml")))
+	})
+})
+
+var _ = Describe("Object", func() {
+	It("is the defaulted v1alpha1 object without its status", func() {
+		limit := resource.MustParse("64Mi")
+		cr := &v1alpha1.DevStagingEnvironment{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders"},
+			Spec: v1alpha1.DevStagingEnvironmentSpec{
+				Deployment: v1alpha1.DeploymentSpec{
+					Image:     "localhost:5001/orders:dev",
+					Port:      8080,
+					Resources: &v1alpha1.ResourceRequirements{MemoryLimit: &limit},
+				},
+				Service: v1alpha1.ServiceSpec{Port: 80},
+			},
+			Status: v1alpha1.DevStagingEnvironmentStatus{DeploymentReady: true},
+		}
+
+		object, err := Object(cr)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(object).NotTo(HaveKey("status"))
+		Expect(object["apiVersion"]).To(Equal("apps.example.com/v1alpha1"))
+		deployment := object["spec"].(map[string]interface{})["deployment"].(map[string]interface{})
+		Expect(deployment["replicas"]).To(Equal(int64(1)))
+		Expect(deployment["imagePullPolicy"]).To(Equal("Always"))
+		// The default request is capped at the limit, as the webhook does.
+		Expect(deployment["resources"].(map[string]interface{})["memoryRequest"]).To(Equal("64Mi"))
+
+		Expect(cr.Spec.Deployment.Replicas).To(BeNil(), "Object mustn't default the environment it was given")
+	})
+})
@@ -0,0 +1,121 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// regoTimeout bounds one evaluation of a Rego policy, which has no cost
+// limit of its own.
+const regoTimeout = time.Second
+
+// regoRule is the rule a Rego policy's module defines: the set of its
+// violations.
+const regoRule = "violation"
+
+// regoUnsafeBuiltins are the built-ins a Rego policy may not call, the
+// ones that reach the network or describe the host it runs on.
+var regoUnsafeBuiltins = map[string]struct{}{
+	ast.HTTPSend.Name:        {},
+	ast.NetLookupIPAddr.Name: {},
+	ast.OPARuntime.Name:      {},
+}
+
+// compileRego compiles the module of a Rego policy, which must define
+// violation.
+func compileRego(p Policy) (*Compiled, error) {
+	switch {
+	case p.Rego == "":
+		return nil, fmt.Errorf("policy %s has no rego module", p.Name)
+	case len(p.Validations) > 0:
+		return nil, fmt.Errorf("policy %s: a rego policy has no validations", p.Name)
+	}
+	module, err := ast.ParseModule(p.Name+".rego", p.Rego)
+	if err != nil {
+		return nil, fmt.Errorf("policy %s: rego: %w", p.Name, err)
+	}
+	if module == nil {
+		return nil, fmt.Errorf("policy %s: rego: the module is empty", p.Name)
+	}
+	defined := false
+	for _, r := range module.Rules {
+		if r.Head.Ref()[0].Equal(ast.VarTerm(regoRule)) {
+			defined = true
+		}
+	}
+	if !defined {
+		return nil, fmt.Errorf("policy %s: rego: the module defines no %s rule", p.Name, regoRule)
+	}
+	query, err := rego.New(
+		rego.Query(module.Package.Path.String()+"."+regoRule),
+		rego.ParsedModule(module),
+		rego.UnsafeBuiltins(regoUnsafeBuiltins),
+		rego.StrictBuiltinErrors(true),
+	).PrepareForEval(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("policy %s: rego: %w", p.Name, err)
+	}
+	return &Compiled{Policy: p, query: &query}, nil
+}
+
+// evaluateRego returns the violations of a Rego policy: an element of its
+// violation set is either the message or an object with a message and a
+// fieldPath. input.baseImages is left out when the images are unknown, so
+// the rules that read it don't fire. A module that can't be evaluated, say
+// because a built-in fails, fails the policy, as a CEL expression does.
+func (p *Compiled) evaluateRego(object map[string]interface{}, baseImages []string) []Violation {
+	input := map[string]interface{}{"object": object}
+	if baseImages != nil {
+		input["baseImages"] = baseImages
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), regoTimeout)
+	defer cancel()
+	rs, err := p.query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return []Violation{{Policy: p.Name, Action: p.Action, FieldPath: "spec", Message: fmt.Sprintf("could not evaluate: %v", err)}}
+	}
+
+	var violations []Violation
+	for _, r := range rs {
+		for _, e := range r.Expressions {
+			set, _ := e.Value.([]interface{})
+			for _, elem := range set {
+				v := Violation{Policy: p.Name, Action: p.Action, FieldPath: "spec"}
+				switch elem := elem.(type) {
+				case string:
+					v.Message = elem
+				case map[string]interface{}:
+					v.Message, _ = elem["message"].(string)
+					if fieldPath, _ := elem["fieldPath"].(string); fieldPath != "" {
+						v.FieldPath = fieldPath
+					}
+				}
+				if v.Message == "" {
+					v.Message = fmt.Sprintf("violation %v", elem)
+				}
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations
+}
@@ -1,11 +1,12 @@
 #!/usr/bin/env bash
 # ─────────────────────────────────────────────────────────────────
-# setup-ingress.sh — Install ingress-nginx and the in-cluster
-# image registry on a Kind cluster.
+# setup-ingress.sh — Install the ingress controller and the
+# in-cluster image registry on a Kind cluster.
 #
 # This script:
-#   1. Deploys the ingress-nginx controller with Kind-specific
-#      patches so it binds to host ports 80/443.
+#   1. Deploys the profile's ingress controller (ingress-nginx by
+#      default, or Contour or Traefik) bound to host ports 80/443,
+#      and makes its IngressClass the cluster default.
 #   2. Deploys a registry:2 pod with hostNetwork so containerd
 #      (via the mirror in kind-config.yaml) and Kaniko pods can
 #      both reach it.
@@ -13,46 +14,129 @@
 # Usage:
 #   ./setup-ingress.sh
 #
+# Environment (set by "kindling init" from the cluster profile):
+#   KIND_CLUSTER_NAME   Kind cluster to configure (default: dev)
+#   KINDLING_NODES      Node containers (default: kind get nodes)
+#   KINDLING_CERTS_DIR  containerd hosts directory on the nodes
+#                       (default: /etc/containerd/certs.d)
+#   KINDLING_INGRESS    nginx (default), contour, traefik, or none
+#   KINDLING_REGISTRY   true (default) or false to skip the registry
+#   KINDLING_INGRESS_MANIFEST
+#                       local copy of the nginx or contour manifest to
+#                       apply instead of downloading it (kindling init
+#                       --airgap)
+#
 # Prerequisites:
-#   - Kind cluster created with kind-config.yaml
+#   - Kind cluster created with kind-config.yaml (or a k3d or minikube
+#     cluster created by kindling init --backend)
 #   - kubectl configured to talk to the Kind cluster
 # ─────────────────────────────────────────────────────────────────
 set -euo pipefail
 
+INGRESS="${KINDLING_INGRESS:-nginx}"
+REGISTRY="${KINDLING_REGISTRY:-true}"
+
 # ── In-cluster image registry ──────────────────────────────────────
-echo "📦 Deploying in-cluster image registry..."
-kubectl apply -f config/registry/registry.yaml
-
-# Configure containerd registry mirror on Kind nodes (config_path mode
-# for containerd 2.x).  This makes containerd resolve "registry:5000"
-# to localhost:5000 where the hostNetwork registry pod is listening.
-REGISTRY_DIR="/etc/containerd/certs.d/registry:5000"
-for node in $(kind get nodes --name "${KIND_CLUSTER_NAME:-dev}" 2>/dev/null); do
-  docker exec "$node" mkdir -p "$REGISTRY_DIR"
-  docker exec -i "$node" sh -c "cat > ${REGISTRY_DIR}/hosts.toml" <<EOF
-[host."http://localhost:5000"]
+if [[ "$REGISTRY" == "true" ]]; then
+  echo "📦 Deploying in-cluster image registry..."
+  kubectl apply -f config/registry/registry.yaml
+
+  # Configure containerd registry mirror on Kind nodes (config_path mode
+  # for containerd 2.x).  This makes containerd resolve "registry:5000"
+  # to localhost:5000 where the hostNetwork registry pod is listening.
+  # With worker nodes the pod runs on only one of them, so the mirror
+  # points at the Service's ClusterIP instead, which every node can reach.
+  REGISTRY_DIR="${KINDLING_CERTS_DIR:-/etc/containerd/certs.d}/registry:5000"
+  NODES="${KINDLING_NODES:-$(kind get nodes --name "${KIND_CLUSTER_NAME:-dev}" 2>/dev/null)}"
+  MIRROR="localhost:5000"
+  if [[ $(echo "$NODES" | wc -w) -gt 1 ]]; then
+    MIRROR="$(kubectl get service registry -o jsonpath='{.spec.clusterIP}'):5000"
+  fi
+  for node in $NODES; do
+    docker exec "$node" mkdir -p "$REGISTRY_DIR"
+    docker exec -i "$node" sh -c "cat > ${REGISTRY_DIR}/hosts.toml" <<EOF
+[host."http://${MIRROR}"]
   capabilities = ["pull", "resolve", "push"]
 EOF
-done
+  done
 
-echo "⏳ Waiting for registry to be ready..."
-kubectl wait --for=condition=available deployment/registry --timeout=60s
-echo "✅ Registry is ready at registry:5000 (in-cluster)"
+  echo "⏳ Waiting for registry to be ready..."
+  kubectl wait --for=condition=available deployment/registry --timeout=60s
+  echo "✅ Registry is ready at registry:5000 (in-cluster)"
+else
+  echo "⏭️  Skipping in-cluster image registry (KINDLING_REGISTRY=$REGISTRY)"
+fi
 
 # ── Ingress controller ────────────────────────────────────────────
 echo ""
-echo "📦 Installing ingress-nginx for Kind..."
+case "$INGRESS" in
+nginx)
+  echo "📦 Installing ingress-nginx for Kind..."
+
+  kubectl apply -f "${KINDLING_INGRESS_MANIFEST:-https://raw.githubusercontent.com/kubernetes/ingress-nginx/main/deploy/static/provider/kind/deploy.yaml}"
+
+  echo "⏳ Waiting for ingress-nginx controller to be ready..."
+
+  kubectl wait --namespace ingress-nginx \
+    --for=condition=ready pod \
+    --selector=app.kubernetes.io/component=controller \
+    --timeout=120s
+
+  echo "✅ ingress-nginx is ready!"
+  ;;
+contour)
+  echo "📦 Installing Contour for Kind..."
+
+  kubectl apply -f "${KINDLING_INGRESS_MANIFEST:-https://projectcontour.io/quickstart/contour.yaml}"
 
-kubectl apply -f https://raw.githubusercontent.com/kubernetes/ingress-nginx/main/deploy/static/provider/kind/deploy.yaml
+  # Envoy binds host ports 80/443; pin it to the node that kind-config.yaml
+  # maps them on, which is tainted once the cluster has workers.
+  kubectl patch daemonset envoy -n projectcontour --type merge -p \
+    '{"spec":{"template":{"spec":{"nodeSelector":{"ingress-ready":"true"},"tolerations":[{"key":"node-role.kubernetes.io/control-plane","operator":"Exists","effect":"NoSchedule"}]}}}}'
 
-echo "⏳ Waiting for ingress-nginx controller to be ready..."
+  echo "⏳ Waiting for Envoy to be ready..."
 
-kubectl wait --namespace ingress-nginx \
-  --for=condition=ready pod \
-  --selector=app.kubernetes.io/component=controller \
-  --timeout=120s
+  kubectl rollout status daemonset/envoy -n projectcontour --timeout=120s
 
-echo "✅ ingress-nginx is ready!"
+  # The quickstart serves the contour class without declaring it.
+  kubectl apply -f - <<EOF
+apiVersion: networking.k8s.io/v1
+kind: IngressClass
+metadata:
+  name: contour
+spec:
+  controller: projectcontour.io/ingress-controller
+EOF
+
+  echo "✅ Contour is ready! Use ingressClassName: contour"
+  ;;
+traefik)
+  echo "📦 Installing Traefik for Kind..."
+
+  kubectl apply -f config/ingress/traefik.yaml
+
+  echo "⏳ Waiting for Traefik to be ready..."
+
+  kubectl rollout status deployment/traefik -n traefik --timeout=120s
+
+  echo "✅ Traefik is ready! Use ingressClassName: traefik"
+  ;;
+none)
+  echo "⏭️  Skipping ingress controller (KINDLING_INGRESS=none)"
+  exit 0
+  ;;
+*)
+  echo "❌ Unknown KINDLING_INGRESS: $INGRESS (want nginx, contour, traefik, or none)" >&2
+  exit 1
+  ;;
+esac
+
+# Ingresses without an ingressClassName — and kindling's generators, which
+# read the default — use the controller just installed.
+for class in $(kubectl get ingressclass -o name); do
+  kubectl annotate "$class" ingressclass.kubernetes.io/is-default-class- >/dev/null 2>&1 || true
+done
+kubectl annotate ingressclass "$INGRESS" ingressclass.kubernetes.io/is-default-class=true --overwrite
 echo ""
 echo "Your Kind cluster now routes:"
 echo "  http://<host>.localhost  →  Ingress → Service → Pod"
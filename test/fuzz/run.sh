@@ -29,6 +29,9 @@
 #   FUZZ_PROVIDER   LLM provider for generate (default: openai)
 #   FUZZ_API_KEY    API key (falls back to OPENAI_API_KEY)
 #   FUZZ_MODEL      Model override (optional)
+#   FUZZ_MAX_CORRECTIONS  Self-correction rounds of generate (default: 2)
+#   FUZZ_STRUCTURED Set to false to have generate ask for raw YAML
+#                   instead of a schema-checked deploy plan
 #   FUZZ_CLUSTER    Kind cluster name (default: fuzz)
 #   FUZZ_NAMESPACE  Namespace for DSE deployments (default: default)
 #   SKIP_E2E        Set to 1 to skip cluster deploy (static only)
@@ -119,6 +122,8 @@ test_repo() {
       --provider "${FUZZ_PROVIDER:-openai}" \
       --api-key "${FUZZ_API_KEY:-$OPENAI_API_KEY}" \
       ${FUZZ_MODEL:+--model "$FUZZ_MODEL"} \
+      ${FUZZ_MAX_CORRECTIONS:+--max-corrections "$FUZZ_MAX_CORRECTIONS"} \
+      ${FUZZ_STRUCTURED:+--structured="$FUZZ_STRUCTURED"} \
       > "$workflow_file" 2>"$gen_stderr"; then
     local dur=$(( $(now_ms) - t0 ))
     GENERATE_OK=$((GENERATE_OK + 1))